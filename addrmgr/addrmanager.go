@@ -49,6 +49,8 @@ type AddrManager struct {
 	version        int
 }
 
+// serializedKnownAddress and serializedAddrManager are the format of the
+// versions 1 and 2 of peers.json, superseded by PeersFile.
 type serializedKnownAddress struct {
 	Addr        string
 	Src         string
@@ -158,7 +160,8 @@ const (
 	getAddrPercent = 23
 
 	// serialisationVersion is the current version of the on-disk format.
-	serialisationVersion = 2
+	// Version 3 introduced PeersFile.
+	serialisationVersion = 3
 )
 
 // updateAddress is a helper function to either update an address already known
@@ -364,6 +367,39 @@ func (a *AddrManager) savePeers() {
 	a.mtx.Lock()
 	defer a.mtx.Unlock()
 
+	// The file is replaced once fully written, so that it doesn't end up
+	// truncated if lbcd is killed while saving it.
+	var peers interface{} = a.exportPeers(true)
+	if a.version < 3 {
+		peers = a.serializeLegacyPeers()
+	}
+	tmpFile := a.peersFile + ".tmp"
+	w, err := os.Create(tmpFile)
+	if err != nil {
+		log.Errorf("Error opening file %s: %v", tmpFile, err)
+		return
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	err = enc.Encode(peers)
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpFile, a.peersFile)
+	}
+	if err != nil {
+		log.Errorf("Failed to encode file %s: %v", a.peersFile, err)
+		os.Remove(tmpFile)
+	}
+}
+
+// serializeLegacyPeers returns the contents of the address manager in the
+// format of the versions 1 and 2 of peers.json.
+//
+// This function MUST be called with the address manager lock held (for
+// reads).
+func (a *AddrManager) serializeLegacyPeers() *serializedAddrManager {
 	// First we make a serialisable datastructure so we can encode it to
 	// json.
 	sam := new(serializedAddrManager)
@@ -407,17 +443,7 @@ func (a *AddrManager) savePeers() {
 		}
 	}
 
-	w, err := os.Create(a.peersFile)
-	if err != nil {
-		log.Errorf("Error opening file %s: %v", a.peersFile, err)
-		return
-	}
-	enc := json.NewEncoder(w)
-	defer w.Close()
-	if err := enc.Encode(&sam); err != nil {
-		log.Errorf("Failed to encode file %s: %v", a.peersFile, err)
-		return
-	}
+	return sam
 }
 
 // loadPeers loads the known address from the saved file.  If empty, missing, or
@@ -447,25 +473,37 @@ func (a *AddrManager) deserializePeers(filePath string) error {
 	if os.IsNotExist(err) {
 		return nil
 	}
-	r, err := os.Open(filePath)
+	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return fmt.Errorf("%s error opening file: %v", filePath, err)
 	}
-	defer r.Close()
 
-	var sam serializedAddrManager
-	dec := json.NewDecoder(r)
-	err = dec.Decode(&sam)
-	if err != nil {
+	// The version field of all the formats decodes into the Version
+	// field, as the field names are matched case-insensitively.
+	var version struct{ Version int }
+	if err := json.Unmarshal(data, &version); err != nil {
 		return fmt.Errorf("error reading %s: %v", filePath, err)
 	}
 
 	// Since decoding JSON is backwards compatible (i.e., only decodes
 	// fields it understands), we'll only return an error upon seeing a
 	// version past our latest supported version.
-	if sam.Version > serialisationVersion {
+	if version.Version > serialisationVersion {
 		return fmt.Errorf("unknown version %v in serialized "+
-			"addrmanager", sam.Version)
+			"addrmanager", version.Version)
+	}
+
+	if version.Version >= 3 {
+		var pf PeersFile
+		if err := json.Unmarshal(data, &pf); err != nil {
+			return fmt.Errorf("error reading %s: %v", filePath, err)
+		}
+		return a.restorePeers(&pf)
+	}
+
+	var sam serializedAddrManager
+	if err := json.Unmarshal(data, &sam); err != nil {
+		return fmt.Errorf("error reading %s: %v", filePath, err)
 	}
 
 	copy(a.key[:], sam.Key[:])
@@ -842,6 +880,50 @@ func (a *AddrManager) GetAddress() *KnownAddress {
 	}
 }
 
+// GetNetworkAddress returns a single address of the network that should be
+// routable, as GetAddress does among all the addresses:  ipv4, ipv6 or onion,
+// as named by NetworkCounts.  It returns nil if there is no address of the
+// network.
+func (a *AddrManager) GetNetworkAddress(network string) *KnownAddress {
+	// Protect concurrent access.
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	var triedAddrs, newAddrs []*KnownAddress
+	for _, ka := range a.addrIndex {
+		if networkName(ka.na) != network {
+			continue
+		}
+		if ka.tried {
+			triedAddrs = append(triedAddrs, ka)
+		} else {
+			newAddrs = append(newAddrs, ka)
+		}
+	}
+	if len(triedAddrs) == 0 && len(newAddrs) == 0 {
+		return nil
+	}
+
+	// Use a 50% chance for choosing between tried and new entries, then
+	// pick random entries until one is accepted by its chance.
+	kas := newAddrs
+	if len(triedAddrs) > 0 && (len(newAddrs) == 0 || a.rand.Intn(2) == 0) {
+		kas = triedAddrs
+	}
+	large := 1 << 30
+	factor := 1.0
+	for {
+		ka := kas[a.rand.Intn(len(kas))]
+		randval := a.rand.Intn(large)
+		if float64(randval) < (factor * ka.chance() * float64(large)) {
+			log.Tracef("Selected %v of network %s",
+				NetAddressKey(ka.na), network)
+			return ka
+		}
+		factor *= 1.2
+	}
+}
+
 func (a *AddrManager) find(addr *wire.NetAddress) *KnownAddress {
 	return a.addrIndex[NetAddressKey(addr)]
 }
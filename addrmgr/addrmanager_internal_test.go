@@ -5,6 +5,8 @@ import (
 	"math/rand"
 	"net"
 	"os"
+	"reflect"
+	"sort"
 	"testing"
 
 	"github.com/lbryio/lbcd/wire"
@@ -192,3 +194,77 @@ func TestAddrManagerV1ToV2(t *testing.T) {
 	addrMgr.loadPeers()
 	assertAddrs(t, addrMgr, expectedAddrs)
 }
+
+// TestAddrManagerPeersFile ensures that the tables and buckets of the
+// addresses are restored from the current format of peers.json, and that the
+// exported addresses are imported to the new table of another manager.
+func TestAddrManagerPeersFile(t *testing.T) {
+	t.Parallel()
+
+	tempDir, err := ioutil.TempDir("", "addrmgr")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	addrMgr := New(tempDir, nil)
+	srcAddr := wire.NewNetAddressIPPort(net.IPv4(173, 144, 173, 111), 9246,
+		wire.SFNodeNetwork)
+	for i := 0; i < 10; i++ {
+		addr := wire.NewNetAddressIPPort(net.IPv4(80, 12, 34, byte(i+10)),
+			9246, wire.SFNodeNetwork)
+		addrMgr.AddAddress(addr, srcAddr)
+		if i < 3 {
+			addrMgr.Attempt(addr)
+			addrMgr.Good(addr)
+		}
+	}
+	counts := addrMgr.NetworkCounts()
+	if counts["ipv4"] != (AddressCounts{New: 7, Tried: 3}) {
+		t.Fatalf("unexpected counts %v", counts)
+	}
+
+	sortedPeers := func(addrMgr *AddrManager) *PeersFile {
+		pf := addrMgr.exportPeers(true)
+		sort.Slice(pf.Addresses, func(i, j int) bool {
+			return pf.Addresses[i].Addr < pf.Addresses[j].Addr
+		})
+		return pf
+	}
+	want := sortedPeers(addrMgr)
+	if want.Version != 3 || !want.Addresses[0].Tried ||
+		want.Addresses[0].LastSuccess == 0 {
+
+		t.Fatalf("unexpected exported address %+v", want.Addresses[0])
+	}
+
+	addrMgr.savePeers()
+	addrMgr = New(tempDir, nil)
+	addrMgr.loadPeers()
+	if got := sortedPeers(addrMgr); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected restored peers: got %+v, want %+v", got,
+			want)
+	}
+
+	importMgr := New(tempDir, nil)
+	exported := addrMgr.Export()
+	if exported.Key != "" {
+		t.Fatalf("exported key %s", exported.Key)
+	}
+	n, err := importMgr.Import(exported)
+	if err != nil || n != 10 {
+		t.Fatalf("unexpected import: %d, %v", n, err)
+	}
+	counts = importMgr.NetworkCounts()
+	if counts["ipv4"] != (AddressCounts{New: 10}) {
+		t.Fatalf("unexpected imported counts %v", counts)
+	}
+	if n, err := importMgr.Import(exported); err != nil || n != 0 {
+		t.Fatalf("unexpected reimport: %d, %v", n, err)
+	}
+
+	exported.Addresses[0].Addr = "invalid"
+	if _, err := importMgr.Import(exported); err == nil {
+		t.Fatal("expected invalid address error")
+	}
+}
@@ -351,6 +351,40 @@ func TestGetAddress(t *testing.T) {
 	}
 }
 
+func TestGetNetworkAddress(t *testing.T) {
+	n := addrmgr.New("testgetnetworkaddress", lookupFunc)
+
+	err := n.AddAddressByIP(someIP + ":9244")
+	if err != nil {
+		t.Fatalf("Adding address failed: %v", err)
+	}
+	if rv := n.GetNetworkAddress("onion"); rv != nil {
+		t.Errorf("GetNetworkAddress failed: got: %v want: %v\n", rv, nil)
+	}
+
+	onion, err := n.DeserializeNetAddress("aaaaaaaaaaaaaaaa.onion:9246",
+		wire.SFNodeNetwork)
+	if err != nil {
+		t.Fatalf("DeserializeNetAddress failed: %v", err)
+	}
+	n.AddAddress(onion, onion)
+	for i := 0; i < 10; i++ {
+		ka := n.GetNetworkAddress("onion")
+		if ka == nil {
+			t.Fatalf("Did not get an onion address where there is one " +
+				"in the pool")
+		}
+		if !ka.NetAddress().IP.Equal(onion.IP) {
+			t.Errorf("Wrong IP: got %v, want %v", ka.NetAddress().IP,
+				onion.IP)
+		}
+	}
+	ka := n.GetNetworkAddress("ipv4")
+	if ka == nil || ka.NetAddress().IP.String() != someIP {
+		t.Errorf("Wrong ipv4 address: got %v, want %v", ka, someIP)
+	}
+}
+
 func TestGetBestLocalAddress(t *testing.T) {
 	localAddrs := []wire.NetAddress{
 		{IP: net.ParseIP("192.168.0.100")},
@@ -104,6 +104,18 @@ func IsIPv4(na *wire.NetAddress) bool {
 	return na.IP.To4() != nil
 }
 
+// networkName returns the name of the network of the given address: ipv4,
+// onion or ipv6.
+func networkName(na *wire.NetAddress) string {
+	switch {
+	case IsIPv4(na):
+		return "ipv4"
+	case IsOnionCatTor(na):
+		return "onion"
+	}
+	return "ipv6"
+}
+
 // IsLocal returns whether or not the given address is a local address.
 func IsLocal(na *wire.NetAddress) bool {
 	return na.IP.IsLoopback() || zero4Net.Contains(na.IP)
@@ -0,0 +1,274 @@
+package addrmgr
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/lbryio/lbcd/wire"
+)
+
+// PeersFile is the versioned format of the contents of the address manager,
+// in which it saves its addresses to peers.json, and exports and imports them.
+// Unlike the format of the previous versions, each address records its own
+// table and buckets, so the file can be inspected and edited by hand.
+type PeersFile struct {
+	// Version is the version of the format, serialisationVersion since
+	// this format was introduced.
+	Version int `json:"version"`
+
+	// Key is the hex-encoded secret key placing the addresses in their
+	// buckets.  It is only set in peers.json: the buckets of exported
+	// addresses are informative.
+	Key string `json:"key,omitempty"`
+
+	Addresses []*PeerAddress `json:"addresses"`
+}
+
+// PeerAddress is a known address of a PeersFile.  The times are in seconds
+// since the Unix epoch, zero when they are unknown.
+type PeerAddress struct {
+	// Addr is the address in the form of ip:port, [ip]:port or
+	// onion:port.
+	Addr     string           `json:"addr"`
+	Services wire.ServiceFlag `json:"services"`
+
+	// Src is the address of the peer the address was learned from.
+	Src         string           `json:"src"`
+	SrcServices wire.ServiceFlag `json:"srcservices"`
+
+	TimeStamp   int64 `json:"timestamp"`
+	Attempts    int   `json:"attempts"`
+	LastAttempt int64 `json:"lastattempt"`
+	LastSuccess int64 `json:"lastsuccess"`
+
+	// Tried reports whether the address is in the tried table, in the
+	// single bucket of Buckets, instead of in the new buckets of Buckets.
+	Tried   bool  `json:"tried"`
+	Buckets []int `json:"buckets"`
+}
+
+// AddressCounts is the number of addresses in the new and tried tables of the
+// address manager.
+type AddressCounts struct {
+	New   int
+	Tried int
+}
+
+// unixTime returns the seconds since the Unix epoch of a time, or zero for the
+// zero time.
+func unixTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// timeUnix is the inverse of unixTime.
+func timeUnix(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+// exportPeers returns the contents of the address manager, along with its key
+// when requested.
+//
+// This function MUST be called with the address manager lock held (for
+// reads).
+func (a *AddrManager) exportPeers(withKey bool) *PeersFile {
+	pf := &PeersFile{
+		Version:   serialisationVersion,
+		Addresses: make([]*PeerAddress, 0, len(a.addrIndex)),
+	}
+	if withKey {
+		pf.Key = hex.EncodeToString(a.key[:])
+	}
+
+	addresses := make(map[string]*PeerAddress, len(a.addrIndex))
+	for k, v := range a.addrIndex {
+		pa := &PeerAddress{
+			Addr:        k,
+			Services:    v.na.Services,
+			Src:         NetAddressKey(v.srcAddr),
+			SrcServices: v.srcAddr.Services,
+			TimeStamp:   unixTime(v.na.Timestamp),
+			Attempts:    v.attempts,
+			LastAttempt: unixTime(v.lastattempt),
+			LastSuccess: unixTime(v.lastsuccess),
+			Tried:       v.tried,
+		}
+		addresses[k] = pa
+		pf.Addresses = append(pf.Addresses, pa)
+	}
+	for i := range a.addrNew {
+		for k := range a.addrNew[i] {
+			addresses[k].Buckets = append(addresses[k].Buckets, i)
+		}
+	}
+	for i := range a.addrTried {
+		for e := a.addrTried[i].Front(); e != nil; e = e.Next() {
+			k := NetAddressKey(e.Value.(*KnownAddress).na)
+			addresses[k].Buckets = append(addresses[k].Buckets, i)
+		}
+	}
+	return pf
+}
+
+// restorePeers restores the contents of the address manager from a
+// PeersFile, which has to include the key.
+//
+// This function MUST be called with the address manager lock held (for
+// writes) on an empty address manager.
+func (a *AddrManager) restorePeers(pf *PeersFile) error {
+	key, err := hex.DecodeString(pf.Key)
+	if err != nil || len(key) != len(a.key) {
+		return fmt.Errorf("invalid key %q", pf.Key)
+	}
+	copy(a.key[:], key)
+
+	for _, pa := range pf.Addresses {
+		ka, err := a.knownAddress(pa)
+		if err != nil {
+			return err
+		}
+		k := NetAddressKey(ka.na)
+		if _, ok := a.addrIndex[k]; ok {
+			return fmt.Errorf("duplicate address %s", k)
+		}
+		a.addrIndex[k] = ka
+
+		if pa.Tried {
+			if len(pa.Buckets) != 1 || pa.Buckets[0] < 0 ||
+				pa.Buckets[0] >= triedBucketCount {
+
+				return fmt.Errorf("tried address %s with "+
+					"invalid buckets %v", k, pa.Buckets)
+			}
+			ka.tried = true
+			a.nTried++
+			a.addrTried[pa.Buckets[0]].PushBack(ka)
+			continue
+		}
+
+		if len(pa.Buckets) == 0 || len(pa.Buckets) > newBucketsPerAddress {
+			return fmt.Errorf("new address %s with invalid "+
+				"buckets %v", k, pa.Buckets)
+		}
+		for _, bucket := range pa.Buckets {
+			if bucket < 0 || bucket >= newBucketCount {
+				return fmt.Errorf("new address %s with "+
+					"invalid bucket %d", k, bucket)
+			}
+			if _, ok := a.addrNew[bucket][k]; ok {
+				return fmt.Errorf("new address %s with "+
+					"duplicate bucket %d", k, bucket)
+			}
+			ka.refs++
+			a.addrNew[bucket][k] = ka
+		}
+		a.nNew++
+	}
+
+	return nil
+}
+
+// knownAddress returns the known address of a PeerAddress, outside of any
+// bucket.
+func (a *AddrManager) knownAddress(pa *PeerAddress) (*KnownAddress, error) {
+	na, err := a.DeserializeNetAddress(pa.Addr, pa.Services)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deserialize netaddress "+
+			"%s: %v", pa.Addr, err)
+	}
+	if pa.TimeStamp != 0 {
+		na.Timestamp = time.Unix(pa.TimeStamp, 0)
+	}
+	srcAddr, err := a.DeserializeNetAddress(pa.Src, pa.SrcServices)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deserialize netaddress "+
+			"%s: %v", pa.Src, err)
+	}
+
+	return &KnownAddress{
+		na:          na,
+		srcAddr:     srcAddr,
+		attempts:    pa.Attempts,
+		lastattempt: timeUnix(pa.LastAttempt),
+		lastsuccess: timeUnix(pa.LastSuccess),
+	}, nil
+}
+
+// Export returns the known addresses of the address manager, without its key.
+// The buckets of the addresses are only meaningful to this address manager.
+func (a *AddrManager) Export() *PeersFile {
+	a.mtx.RLock()
+	defer a.mtx.RUnlock()
+
+	return a.exportPeers(false)
+}
+
+// Import adds the unknown routable addresses of a PeersFile, such as one
+// exported by another node, to the new table of the address manager, and
+// returns how many were added.  The tables and buckets of the addresses in the
+// PeersFile are ignored: an address is only tried once this address manager
+// connects to it.  An error is returned if an address is invalid, in which
+// case none is added.
+func (a *AddrManager) Import(pf *PeersFile) (int, error) {
+	if pf.Version > serialisationVersion {
+		return 0, fmt.Errorf("unknown version %v of peers file",
+			pf.Version)
+	}
+
+	kas := make([]*KnownAddress, 0, len(pf.Addresses))
+	for _, pa := range pf.Addresses {
+		ka, err := a.knownAddress(pa)
+		if err != nil {
+			return 0, err
+		}
+		kas = append(kas, ka)
+	}
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	var added int
+	for _, ka := range kas {
+		if !IsRoutable(ka.na) || a.find(ka.na) != nil {
+			continue
+		}
+		a.updateAddress(ka.na, ka.srcAddr)
+
+		// The statistics of the connection attempts are imported too.
+		if imported := a.find(ka.na); imported != nil {
+			imported.attempts = ka.attempts
+			imported.lastattempt = ka.lastattempt
+			imported.lastsuccess = ka.lastsuccess
+			added++
+		}
+	}
+
+	log.Infof("Imported %d of %d addresses", added, len(kas))
+	return added, nil
+}
+
+// NetworkCounts returns the number of addresses in the new and tried tables
+// of the address manager by network: ipv4, ipv6 and onion.
+func (a *AddrManager) NetworkCounts() map[string]AddressCounts {
+	a.mtx.RLock()
+	defer a.mtx.RUnlock()
+
+	counts := make(map[string]AddressCounts)
+	for _, ka := range a.addrIndex {
+		network := networkName(ka.na)
+		c := counts[network]
+		if ka.tried {
+			c.Tried++
+		} else {
+			c.New++
+		}
+		counts[network] = c
+	}
+	return counts
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/lbryio/lbcd/blockchain"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+// blockArchiveLogInterval is the interval between the logs of the progress of
+// the import of a block archive.
+const blockArchiveLogInterval = 10 * time.Second
+
+// errServerShutdown is returned by loadBlockArchive when the server shuts down
+// during the import.
+var errServerShutdown = errors.New("server shutting down")
+
+// writeArchiveBlock writes a serialized block to a block archive, in the format
+// of bootstrap.dat:
+//
+//	<network> <block length> <serialized block>
+func writeArchiveBlock(w io.Writer, net wire.BitcoinNet, block []byte) error {
+	var header [8]byte
+	binary.LittleEndian.PutUint32(header[0:4], uint32(net))
+	binary.LittleEndian.PutUint32(header[4:8], uint32(len(block)))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(block)
+	return err
+}
+
+// readArchiveBlock reads the next serialized block of a block archive, or
+// returns nil once there are no more blocks.  The zeros following the last
+// block of the block files preallocated by lbrycrd also end the archive.
+func readArchiveBlock(r io.Reader, net wire.BitcoinNet) ([]byte, error) {
+	var header [8]byte
+	_, err := io.ReadFull(r, header[:4])
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	magic := binary.LittleEndian.Uint32(header[0:4])
+	if magic == 0 {
+		return nil, nil
+	}
+	if magic != uint32(net) {
+		return nil, fmt.Errorf("network mismatch -- got %x, want %x",
+			magic, uint32(net))
+	}
+
+	if _, err := io.ReadFull(r, header[4:8]); err != nil {
+		return nil, err
+	}
+	blockLen := binary.LittleEndian.Uint32(header[4:8])
+	if blockLen > wire.MaxBlockPayload {
+		return nil, fmt.Errorf("block payload of %d bytes is larger "+
+			"than the max allowed %d bytes", blockLen,
+			wire.MaxBlockPayload)
+	}
+
+	block := make([]byte, blockLen)
+	if _, err := io.ReadFull(r, block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// loadBlockArchives imports the blocks of the block archives passed with the
+// loadblock option to the block chain, stopping at the first error.  It must
+// be run as a goroutine.
+func (s *server) loadBlockArchives(paths []string) {
+	defer s.wg.Done()
+
+	for _, path := range paths {
+		err := s.loadBlockArchive(path)
+		if errors.Is(err, errServerShutdown) {
+			return
+		}
+		if err != nil {
+			srvrLog.Errorf("Failed to import block archive %s: %v",
+				path, err)
+			return
+		}
+	}
+}
+
+// loadBlockArchive imports the blocks of a block archive to the block chain,
+// skipping those already known.
+func (s *server) loadBlockArchive(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	srvrLog.Infof("Importing blocks from %s", path)
+	r := bufio.NewReader(f)
+	var imported, known int
+	lastLog := time.Now()
+	for {
+		select {
+		case <-s.quit:
+			srvrLog.Infof("Stopped importing blocks from %s after "+
+				"%d blocks", path, imported)
+			return errServerShutdown
+		default:
+		}
+
+		serializedBlock, err := readArchiveBlock(r, s.chainParams.Net)
+		if err != nil {
+			return err
+		}
+		if serializedBlock == nil {
+			break
+		}
+		block, err := btcutil.NewBlockFromBytes(serializedBlock)
+		if err != nil {
+			return err
+		}
+
+		exists, err := s.chain.HaveBlock(block.Hash())
+		if err != nil {
+			return err
+		}
+		if exists {
+			known++
+			continue
+		}
+		prevHash := &block.MsgBlock().Header.PrevBlock
+		exists, err = s.chain.HaveBlock(prevHash)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("block %v does not link to the "+
+				"available block chain", block.Hash())
+		}
+
+		_, _, err = s.chain.ProcessBlock(block, blockchain.BFNone)
+		if err != nil {
+			// The block may have been downloaded from a peer in
+			// the meantime.
+			var ruleErr blockchain.RuleError
+			if errors.As(err, &ruleErr) &&
+				ruleErr.ErrorCode == blockchain.ErrDuplicateBlock {
+
+				known++
+				continue
+			}
+			return err
+		}
+		imported++
+
+		if time.Since(lastLog) >= blockArchiveLogInterval {
+			srvrLog.Infof("Imported %d blocks from %s (height %d)",
+				imported, path, s.chain.BestSnapshot().Height)
+			lastLog = time.Now()
+		}
+	}
+
+	srvrLog.Infof("Finished importing blocks from %s: %d imported, %d "+
+		"already known", path, imported, known)
+	return nil
+}
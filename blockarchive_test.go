@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lbryio/lbcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockArchive(t *testing.T) {
+
+	r := require.New(t)
+
+	var buf bytes.Buffer
+	blocks := [][]byte{{1, 2, 3}, {}, bytes.Repeat([]byte{4}, 1000)}
+	for _, block := range blocks {
+		r.NoError(writeArchiveBlock(&buf, wire.TestNet, block))
+	}
+	archive := buf.Bytes()
+
+	// The blocks are read back in order, and the zeros preallocated by
+	// lbrycrd end the archive.
+	for _, data := range [][]byte{archive, append(archive, 0, 0, 0, 0, 0)} {
+		rd := bytes.NewReader(data)
+		for _, block := range blocks {
+			read, err := readArchiveBlock(rd, wire.TestNet)
+			r.NoError(err)
+			r.Equal(block, read)
+		}
+		read, err := readArchiveBlock(rd, wire.TestNet)
+		r.NoError(err)
+		r.Nil(read)
+	}
+
+	// The archive must be of the active network.
+	_, err := readArchiveBlock(bytes.NewReader(archive), wire.MainNet)
+	r.ErrorContains(err, "network mismatch")
+
+	// A truncated archive is an error.
+	_, err = readArchiveBlock(bytes.NewReader(archive[:10]), wire.TestNet)
+	r.Error(err)
+}
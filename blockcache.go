@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+
+	"github.com/decred/dcrd/lru"
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/database"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+// servedBlock is a block recently served to an RPC client or a peer, along with
+// the verbose getblock results generated for it.
+type servedBlock struct {
+	bytes []byte
+	block *btcutil.Block
+
+	// cached is set when the block is kept by a blockCache, and therefore
+	// worth keeping the verbose results of.
+	cached bool
+
+	// The verbose results depend on the best chain, such as through the
+	// confirmations or the next block hash, so they are only valid while
+	// bestHash is the tip of the best chain.
+	mtx      sync.Mutex
+	bestHash chainhash.Hash
+	verbose  map[int]json.RawMessage
+}
+
+// verboseResult returns the getblock result of the verbosity generated while
+// bestHash was the tip of the best chain, if any.
+func (b *servedBlock) verboseResult(verbosity int, bestHash *chainhash.Hash) (json.RawMessage, bool) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if b.bestHash != *bestHash {
+		return nil, false
+	}
+	result, ok := b.verbose[verbosity]
+	return result, ok
+}
+
+// cacheVerboseResult keeps the getblock result of the verbosity generated while
+// bestHash was the tip of the best chain.  It returns the result to serve,
+// which is the marshalled form kept, if the block is cached.
+func (b *servedBlock) cacheVerboseResult(verbosity int, bestHash *chainhash.Hash,
+	result interface{}) (interface{}, error) {
+
+	if !b.cached {
+		return result, nil
+	}
+	var buf bytes.Buffer
+	if err := btcjson.EncodeStream(&buf, result); err != nil {
+		return nil, err
+	}
+	marshalled := buf.Bytes()
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if b.bestHash != *bestHash || b.verbose == nil {
+		b.bestHash = *bestHash
+		b.verbose = make(map[int]json.RawMessage)
+	}
+	b.verbose[verbosity] = marshalled
+	return json.RawMessage(marshalled), nil
+}
+
+// blockCache keeps the most recently served blocks in memory, so bursts of
+// requests for the same blocks, such as those following a new tip, don't each
+// load the block from the database and deserialize it.  A nil blockCache is
+// valid and caches nothing.
+type blockCache struct {
+	blocks lru.KVCache
+}
+
+// newBlockCache returns a blockCache keeping up to size blocks, or nil when
+// size is zero.
+func newBlockCache(size uint) *blockCache {
+	if size == 0 {
+		return nil
+	}
+	return &blockCache{blocks: lru.NewKVCache(size)}
+}
+
+// fetch returns the block with the given hash from the cache, loading it from
+// the database when it isn't cached.
+func (c *blockCache) fetch(db database.DB, hash *chainhash.Hash) (*servedBlock, error) {
+	if c != nil {
+		if b, ok := c.blocks.Lookup(*hash); ok {
+			return b.(*servedBlock), nil
+		}
+	}
+
+	var blockBytes []byte
+	err := db.View(func(dbTx database.Tx) error {
+		var err error
+		blockBytes, err = dbTx.FetchBlock(hash)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	block, err := btcutil.NewBlockFromBytes(blockBytes)
+	if err != nil {
+		return nil, err
+	}
+	b := &servedBlock{bytes: blockBytes, block: block}
+	if c == nil {
+		return b, nil
+	}
+
+	// The block is shared by the goroutines serving it from now on, so the
+	// hashes it computes lazily are computed before publishing it.
+	block.Hash()
+	for _, tx := range block.Transactions() {
+		tx.Hash()
+		tx.WitnessHash()
+		tx.HasWitness()
+	}
+	b.cached = true
+	c.blocks.Add(*hash, b)
+	return b, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/database"
+	btcutil "github.com/lbryio/lbcutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockCache(t *testing.T) {
+
+	r := require.New(t)
+	params := &chaincfg.RegressionNetParams
+	db, err := database.Create("ffldb", t.TempDir(), params.Net)
+	r.NoError(err)
+	defer db.Close()
+	genesis := btcutil.NewBlock(params.GenesisBlock)
+	r.NoError(db.Update(func(dbTx database.Tx) error {
+		return dbTx.StoreBlock(genesis)
+	}))
+
+	cache := newBlockCache(2)
+	served, err := cache.fetch(db, params.GenesisHash)
+	r.NoError(err)
+	r.Equal(params.GenesisHash, served.block.Hash())
+	again, err := cache.fetch(db, params.GenesisHash)
+	r.NoError(err)
+	r.Same(served, again)
+	_, err = cache.fetch(db, &chainhash.Hash{1})
+	r.Error(err)
+
+	// The verbose results are only served while the tip is unchanged.
+	tip, next := chainhash.Hash{2}, chainhash.Hash{3}
+	result, err := served.cacheVerboseResult(1, &tip, map[string]int{"height": 0})
+	r.NoError(err)
+	r.Equal(json.RawMessage(`{"height":0}`), result)
+	cached, ok := served.verboseResult(1, &tip)
+	r.True(ok)
+	r.Equal(result, cached)
+	_, ok = served.verboseResult(2, &tip)
+	r.False(ok)
+	_, ok = served.verboseResult(1, &next)
+	r.False(ok)
+
+	// Without a cache the blocks are loaded every time, and the results are
+	// served as they are.
+	var none *blockCache
+	r.Nil(newBlockCache(0))
+	served, err = none.fetch(db, params.GenesisHash)
+	r.NoError(err)
+	r.NotSame(served, again)
+	result, err = served.cacheVerboseResult(1, &tip, 7)
+	r.NoError(err)
+	r.Equal(7, result)
+	_, ok = served.verboseResult(1, &tip)
+	r.False(ok)
+}
@@ -6,6 +6,7 @@
 package blockchain
 
 import (
+	"bytes"
 	"container/list"
 	"fmt"
 	"math/big"
@@ -15,6 +16,7 @@ import (
 	"github.com/lbryio/lbcd/chaincfg"
 	"github.com/lbryio/lbcd/chaincfg/chainhash"
 	"github.com/lbryio/lbcd/database"
+	"github.com/lbryio/lbcd/tracing"
 	"github.com/lbryio/lbcd/txscript"
 	"github.com/lbryio/lbcd/wire"
 	btcutil "github.com/lbryio/lbcutil"
@@ -105,6 +107,8 @@ type BlockChain struct {
 	sigCache            *txscript.SigCache
 	indexManager        IndexManager
 	hashCache           *txscript.HashCache
+	batchSigVerify      bool
+	slowBlockThreshold  time.Duration
 
 	// The following fields are calculated based upon the provided chain
 	// parameters.  They are also set when the instance is created and
@@ -186,12 +190,41 @@ type BlockChain struct {
 	// activated.
 	unknownRulesWarned bool
 
+	// haltReason is the reason the acceptance of blocks is halted for, or
+	// empty when blocks are accepted.  It is protected by the chain lock.
+	haltReason string
+
 	// The notifications field stores a slice of callbacks to be executed on
 	// certain blockchain events.
 	notificationsLock sync.RWMutex
 	notifications     []NotificationCallback
 
 	claimTrie *claimtrie.ClaimTrie
+
+	// claimTrieDeferred is set while the claims of the blocks aren't
+	// processed, until the empty claimtrie is loaded from a snapshot.  It is
+	// protected by the chain lock.
+	claimTrieDeferred bool
+
+	// claimTrieLoading is set while the deferred claimtrie is loaded from a
+	// snapshot and caught up with the main chain.  It is protected by the
+	// chain lock.
+	claimTrieLoading bool
+
+	// blockTimings times the connection of the block extending the main
+	// chain.  It is protected by the chain lock.
+	blockTimings *BlockTimings
+
+	// recentTimings is a ring of the timings of the most recently
+	// connected blocks, overwritten from nextTiming once full.
+	timingsLock   sync.Mutex
+	recentTimings []BlockTimings
+	nextTiming    int
+
+	// missingBlocks are the blocks of the main chain whose data was dropped
+	// from the database, by ascending height.  It is protected by the chain
+	// lock.
+	missingBlocks []*blockNode
 }
 
 // HaveBlock returns whether or not the chain instance has the block represented
@@ -602,9 +635,17 @@ func (b *BlockChain) connectBlock(node *blockNode, block *btcutil.Block,
 	}
 
 	// Handle LBRY Claim Scripts
-	if b.claimTrie != nil {
+	if b.claimTrie != nil && !b.claimTrieDeferred {
 		shouldFlush := current && b.chainParams.Net != wire.TestNet
-		if err := b.ParseClaimScripts(block, node, view, shouldFlush); err != nil {
+		span := tracing.StartBlockChild(block.Hash(), "claimtrie")
+		start := time.Now()
+		err := b.ParseClaimScripts(block, node, view, shouldFlush)
+		if b.blockTimings != nil {
+			b.blockTimings.ClaimTrie = time.Since(start)
+		}
+		span.SetError(err)
+		span.End()
+		if err != nil {
 			return ruleError(ErrBadClaimTrie, err.Error())
 		}
 	}
@@ -627,6 +668,7 @@ func (b *BlockChain) connectBlock(node *blockNode, block *btcutil.Block,
 		curTotalTxns+numTxns, node.CalcPastMedianTime())
 
 	// Atomically insert info into the database.
+	dbStart := time.Now()
 	err = b.db.Update(func(dbTx database.Tx) error {
 		// Update best block state.
 		err := dbPutBestState(dbTx, state, node.workSum)
@@ -660,7 +702,10 @@ func (b *BlockChain) connectBlock(node *blockNode, block *btcutil.Block,
 		// optional indexes with the block being connected so they can
 		// update themselves accordingly.
 		if b.indexManager != nil {
+			span := tracing.StartBlockChild(block.Hash(), "index")
 			err := b.indexManager.ConnectBlock(dbTx, block, stxos)
+			span.SetError(err)
+			span.End()
 			if err != nil {
 				return err
 			}
@@ -671,6 +716,9 @@ func (b *BlockChain) connectBlock(node *blockNode, block *btcutil.Block,
 	if err != nil {
 		return err
 	}
+	if b.blockTimings != nil {
+		b.blockTimings.DB = time.Since(dbStart)
+	}
 
 	// Prune fully spent entries and mark all entries in the view unmodified
 	// now that the modifications have been committed to the database.
@@ -678,6 +726,7 @@ func (b *BlockChain) connectBlock(node *blockNode, block *btcutil.Block,
 
 	// This node is now the end of the best chain.
 	b.bestChain.SetTip(node)
+	b.recordBlockTimings()
 
 	// Update the state for the best block.  Notice how this replaces the
 	// entire struct instead of updating the existing one.  This effectively
@@ -793,7 +842,7 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block *btcutil.Block, view
 		return err
 	}
 
-	if b.claimTrie != nil {
+	if b.claimTrie != nil && !b.claimTrieDeferred {
 		if err = b.claimTrie.ResetHeight(node.parent.height); err != nil {
 			return err
 		}
@@ -1003,7 +1052,10 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List) error
 		// In the case the block is determined to be invalid due to a
 		// rule violation, mark it as invalid and mark all of its
 		// descendants as having an invalid ancestor.
+		span := tracing.StartBlockChild(block.Hash(), "validate")
 		err = b.checkConnectBlock(n, block, view, nil)
+		span.SetError(err)
+		span.End()
 		if err != nil {
 			if _, ok := err.(RuleError); ok {
 				b.index.UnsetStatusFlags(n, statusValid)
@@ -1095,6 +1147,34 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List) error
 	log.Infof("REORGANIZE: New best chain head is %v (height %v)",
 		newBest.hash, newBest.height)
 
+	// Notify the caller of the whole reorganization, so that it can be
+	// rolled back precisely without tracking the individual blocks.
+	var fork *blockNode
+	if detachNodes.Len() != 0 {
+		fork = detachNodes.Back().Value.(*blockNode).parent
+	} else {
+		fork = attachNodes.Front().Value.(*blockNode).parent
+	}
+	reorg := &Reorganization{
+		ForkHash:     fork.hash,
+		ForkHeight:   fork.height,
+		Disconnected: make([]chainhash.Hash, 0, detachNodes.Len()),
+		Connected:    make([]chainhash.Hash, 0, attachNodes.Len()),
+	}
+	for e := detachNodes.Front(); e != nil; e = e.Next() {
+		reorg.Disconnected = append(reorg.Disconnected,
+			e.Value.(*blockNode).hash)
+	}
+	for e := attachNodes.Front(); e != nil; e = e.Next() {
+		reorg.Connected = append(reorg.Connected,
+			e.Value.(*blockNode).hash)
+	}
+	b.notificationSendLock.Lock()
+	defer b.notificationSendLock.Unlock()
+	b.chainLock.Unlock()
+	defer b.chainLock.Lock()
+	b.sendNotification(NTChainReorganized, reorg)
+
 	return nil
 }
 
@@ -1133,6 +1213,8 @@ func (b *BlockChain) connectBestChain(node *blockNode, block *btcutil.Block, fla
 		// Skip checks if node has already been fully validated.
 		fastAdd = fastAdd || b.index.NodeStatus(node).KnownValid()
 
+		defer b.startBlockTimings(node)()
+
 		// Perform several checks to verify the block can be connected
 		// to the main chain without violating any rules and without
 		// actually connecting the block.
@@ -1140,7 +1222,12 @@ func (b *BlockChain) connectBestChain(node *blockNode, block *btcutil.Block, fla
 		view.SetBestHash(parentHash)
 		stxos := make([]SpentTxOut, 0, countSpentOutputs(block))
 		if !fastAdd {
+			span := tracing.StartBlockChild(block.Hash(), "validate")
+			start := time.Now()
 			err := b.checkConnectBlock(node, block, view, &stxos)
+			b.blockTimings.Validate = time.Since(start)
+			span.SetError(err)
+			span.End()
 			if err == nil {
 				b.index.SetStatusFlags(node, statusValid)
 			} else if _, ok := err.(RuleError); ok {
@@ -1426,6 +1513,35 @@ func (b *BlockChain) BlockAttributesByHash(hash *chainhash.Hash, prevHash *chain
 	return attrs, best, nil
 }
 
+// BlockTxCountByHash returns the number of transactions in the block with the
+// given hash.  Only the transaction count following the header of the stored
+// block is read, rather than the whole block.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) BlockTxCountByHash(hash *chainhash.Hash) (uint64, error) {
+	if b.index.LookupNode(hash) == nil {
+		str := fmt.Sprintf("block %s not found", hash)
+		return 0, errNotInMainChain(str)
+	}
+
+	var count uint64
+	err := b.db.View(func(dbTx database.Tx) error {
+		// The serialized block is always longer than the header and a
+		// varint of the maximum size, since it contains a coinbase.
+		region, err := dbTx.FetchBlockRegion(&database.BlockRegion{
+			Hash:   hash,
+			Offset: wire.MaxBlockHeaderPayload,
+			Len:    wire.MaxVarIntPayload,
+		})
+		if err != nil {
+			return err
+		}
+		count, err = wire.ReadVarInt(bytes.NewReader(region), 0)
+		return err
+	})
+	return count, err
+}
+
 // HeightRange returns a range of block hashes for the given start and end
 // heights.  It is inclusive of the start height and exclusive of the end
 // height.  The end height will be limited to the current main chain height.
@@ -1910,7 +2026,23 @@ type Config struct {
 	// signature cache.
 	HashCache *txscript.HashCache
 
+	// BatchSigVerify defines whether signature checks performed while
+	// validating the scripts of a block are deferred and verified together
+	// by a pool of workers once all of the scripts have been executed.
+	BatchSigVerify bool
+
+	// SlowBlockThreshold is the time past which connecting a block to the
+	// main chain is logged with a breakdown of the time spent.  Zero
+	// disables the logging.
+	SlowBlockThreshold time.Duration
+
 	ClaimTrie *claimtrie.ClaimTrie
+
+	// DeferClaimTrie defers the processing of the claims of the blocks
+	// while the claimtrie is empty, until it is loaded from a snapshot with
+	// LoadClaimTrieSnapshot.  The claimtrie hashes of the headers of the
+	// blocks below the snapshot are then checked by the snapshot only.
+	DeferClaimTrie bool
 }
 
 // New returns a BlockChain instance using the provided configuration details.
@@ -1960,6 +2092,8 @@ func New(config *Config) (*BlockChain, error) {
 		blocksPerRetarget:   int32(targetTimespan / targetTimePerBlock),
 		index:               newBlockIndex(config.DB, params),
 		hashCache:           config.HashCache,
+		batchSigVerify:      config.BatchSigVerify,
+		slowBlockThreshold:  config.SlowBlockThreshold,
 		bestChain:           newChainView(nil),
 		orphans:             make(map[chainhash.Hash]*orphanBlock),
 		prevOrphans:         make(map[chainhash.Hash][]*orphanBlock),
@@ -2008,7 +2142,18 @@ func New(config *Config) (*BlockChain, error) {
 		return nil, err
 	}
 
-	if b.claimTrie != nil {
+	if b.claimTrie != nil && config.DeferClaimTrie && b.claimTrie.Height() == 0 {
+		log.Infof("Deferring the claimtrie until it is loaded from a snapshot")
+		b.claimTrieDeferred = true
+	} else if b.claimTrie != nil {
+		// Drop the chunks of any snapshot appended while the claimtrie
+		// was deferred before rebuilding it from the blocks.
+		if b.claimTrie.Height() == 0 {
+			if err := b.claimTrie.DiscardSnapshot(); err != nil {
+				b.claimTrie.Close()
+				return nil, err
+			}
+		}
 		err := rebuildMissingClaimTrieData(&b, config.Interrupt)
 		if err != nil {
 			b.claimTrie.Close()
@@ -868,3 +868,28 @@ func TestIntervalBlockHashes(t *testing.T) {
 		}
 	}
 }
+
+// TestBlockTxCountByHash ensures the number of transactions of the stored
+// blocks is read from the database.
+func TestBlockTxCountByHash(t *testing.T) {
+	chain, teardownFunc, err := chainSetup("txcounttest",
+		&chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("Failed to setup chain instance: %v", err)
+	}
+	defer teardownFunc()
+
+	genesisHash := chaincfg.RegressionNetParams.GenesisHash
+	count, err := chain.BlockTxCountByHash(genesisHash)
+	if err != nil {
+		t.Fatalf("BlockTxCountByHash: unexpected error: %v", err)
+	}
+	want := uint64(len(chaincfg.RegressionNetParams.GenesisBlock.Transactions))
+	if count != want {
+		t.Fatalf("BlockTxCountByHash: got %d, want %d", count, want)
+	}
+
+	if _, err := chain.BlockTxCountByHash(&chainhash.Hash{1}); err == nil {
+		t.Fatalf("BlockTxCountByHash: expected error for unknown block")
+	}
+}
@@ -1200,16 +1200,23 @@ func (b *BlockChain) initChainState() error {
 				"chain tip %s in block index", state.hash))
 		}
 		b.bestChain.SetTip(tip)
-
-		// Load the raw block bytes for the best block.
-		blockBytes, err := dbTx.FetchBlock(&state.hash)
-		if err != nil {
+		if err := b.findMissingBlocks(dbTx, tip); err != nil {
 			return err
 		}
+
+		// Load the raw block bytes for the best block, unless its data
+		// was dropped from the database.
+		var blockBytes []byte
 		var block wire.MsgBlock
-		err = block.Deserialize(bytes.NewReader(blockBytes))
-		if err != nil {
-			return err
+		if tip.status.HaveData() {
+			blockBytes, err = dbTx.FetchBlock(&state.hash)
+			if err != nil {
+				return err
+			}
+			err = block.Deserialize(bytes.NewReader(blockBytes))
+			if err != nil {
+				return err
+			}
 		}
 
 		// As a final consistency check, we'll run through all the
@@ -6,12 +6,15 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
 	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/txscript/claimscript"
 	"github.com/lbryio/lbcd/wire"
 	btcutil "github.com/lbryio/lbcutil"
 
 	"github.com/lbryio/lbcd/claimtrie"
 	"github.com/lbryio/lbcd/claimtrie/change"
+	"github.com/lbryio/lbcd/claimtrie/merkletrie"
 	"github.com/lbryio/lbcd/claimtrie/node"
 	"github.com/lbryio/lbcd/claimtrie/normalization"
 )
@@ -20,6 +23,10 @@ func (b *BlockChain) SetClaimtrieHeader(block *btcutil.Block, view *UtxoViewpoin
 	b.chainLock.Lock()
 	defer b.chainLock.Unlock()
 
+	if b.claimTrieDeferred {
+		return errors.New("the claimtrie awaits a snapshot")
+	}
+
 	err := b.ParseClaimScripts(block, nil, view, false)
 	if err != nil {
 		return errors.Wrapf(err, "in parse claim scripts")
@@ -80,7 +87,7 @@ func (h *handler) handleTxIns(ct *claimtrie.ClaimTrie) error {
 		if e == nil {
 			return errors.Errorf("missing input in view for %s", op.String())
 		}
-		cs, err := txscript.ExtractClaimScript(e.pkScript)
+		cs, err := claimscript.Parse(e.pkScript)
 		if txscript.IsErrorCode(err, txscript.ErrNotClaimScript) {
 			continue
 		}
@@ -88,20 +95,14 @@ func (h *handler) handleTxIns(ct *claimtrie.ClaimTrie) error {
 			return err
 		}
 
-		var id change.ClaimID
-		name := cs.Name // name of the previous one (that we're now spending)
+		id := cs.ClaimIDFor(op) // claimID of the previous item now being spent
+		name := cs.Name         // name of the previous one (that we're now spending)
 
-		switch cs.Opcode {
-		case txscript.OP_CLAIMNAME: // OP code from previous transaction
-			id = change.NewClaimID(op) // claimID of the previous item now being spent
-			h.spent[id.Key()] = normalization.NormalizeIfNecessary(name, ct.Height())
-			err = ct.SpendClaim(name, op, id)
-		case txscript.OP_UPDATECLAIM:
-			copy(id[:], cs.ClaimID)
+		switch cs.Type {
+		case claimscript.TypeClaimName, claimscript.TypeUpdateClaim:
 			h.spent[id.Key()] = normalization.NormalizeIfNecessary(name, ct.Height())
 			err = ct.SpendClaim(name, op, id)
-		case txscript.OP_SUPPORTCLAIM:
-			copy(id[:], cs.ClaimID)
+		case claimscript.TypeSupportClaim:
 			err = ct.SpendSupport(name, op, id)
 		}
 		if err != nil {
@@ -114,7 +115,7 @@ func (h *handler) handleTxIns(ct *claimtrie.ClaimTrie) error {
 func (h *handler) handleTxOuts(ct *claimtrie.ClaimTrie) error {
 	for i, txOut := range h.tx.MsgTx().TxOut {
 		op := *wire.NewOutPoint(h.tx.Hash(), uint32(i))
-		cs, err := txscript.ExtractClaimScript(txOut.PkScript)
+		cs, err := claimscript.Parse(txOut.PkScript)
 		if txscript.IsErrorCode(err, txscript.ErrNotClaimScript) {
 			continue
 		}
@@ -122,22 +123,19 @@ func (h *handler) handleTxOuts(ct *claimtrie.ClaimTrie) error {
 			return err
 		}
 
-		var id change.ClaimID
+		id := cs.ClaimIDFor(op)
 		name := cs.Name
 		amt := txOut.Value
 
-		switch cs.Opcode {
-		case txscript.OP_CLAIMNAME:
-			id = change.NewClaimID(op)
+		switch cs.Type {
+		case claimscript.TypeClaimName:
 			err = ct.AddClaim(name, op, id, amt)
-		case txscript.OP_SUPPORTCLAIM:
-			copy(id[:], cs.ClaimID)
+		case claimscript.TypeSupportClaim:
 			err = ct.AddSupport(name, op, amt, id)
-		case txscript.OP_UPDATECLAIM:
+		case claimscript.TypeUpdateClaim:
 			// old code wouldn't run the update if name or claimID didn't match existing data
 			// that was a safety feature, but it should have rejected the transaction instead
 			// TODO: reject transactions with invalid update commands
-			copy(id[:], cs.ClaimID)
 			normName := normalization.NormalizeIfNecessary(name, ct.Height())
 			if !bytes.Equal(h.spent[id.Key()], normName) {
 				node.LogOnce(fmt.Sprintf("Invalid update operation: name or ID mismatch at %d for: %s, %s",
@@ -181,3 +179,138 @@ func (b *BlockChain) GetClaimsForName(height int32, name string) (string, *node.
 	n.SortClaimsByBid()
 	return string(normalizedName), n, nil
 }
+
+// SimulateClaimSupport simulates a support of amount for the claim of name with
+// the passed ID included in the block after the tip of the main chain.  It
+// returns the normalized name along with the outcome of the simulation.
+func (b *BlockChain) SimulateClaimSupport(name string, claimID change.ClaimID, amount int64) (string, *node.SupportSimulation, error) {
+
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	if b.claimTrieDeferred {
+		return "", nil, errors.New("the claimtrie awaits a snapshot")
+	}
+
+	tip := b.bestChain.Tip()
+	normalizedName := normalization.NormalizeIfNecessary([]byte(name), tip.height)
+
+	sim, err := b.claimTrie.SimulateSupport(normalizedName, claimID, amount)
+	return string(normalizedName), sim, err
+}
+
+// ClaimTrieProof is the merkle proof of the claims hash of a name against the
+// claimtrie hash of a block, and of the best claim of the name against its
+// claims hash.
+type ClaimTrieProof struct {
+	Name           string
+	BlockHash      chainhash.Hash
+	Height         int32
+	ClaimTrieHash  chainhash.Hash
+	ClaimsHash     *chainhash.Hash
+	Pairs          []merkletrie.ProofPair
+	BestClaim      wire.OutPoint
+	TakeoverHeight int32
+	ClaimPairs     []merkletrie.ProofPair
+}
+
+// GetClaimTrieProof returns the proof of a name at the tip of the main chain.
+func (b *BlockChain) GetClaimTrieProof(name string) (*ClaimTrieProof, error) {
+
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	if b.claimTrieDeferred {
+		return nil, errors.New("the claimtrie awaits a snapshot")
+	}
+
+	tip := b.bestChain.Tip()
+	normalizedName := normalization.NormalizeIfNecessary([]byte(name), tip.height)
+
+	hash, pairs, err := b.claimTrie.ClaimHashProof(normalizedName)
+	if err != nil {
+		return nil, err
+	}
+	if hash == nil {
+		return nil, fmt.Errorf("name does not exist at height %d: %s", tip.height, name)
+	}
+
+	bestClaim, takeover, claimPairs, err := b.claimTrie.BestClaimProof(normalizedName)
+	if err != nil {
+		return nil, err
+	}
+	if bestClaim == nil || !merkletrie.VerifyProof(node.ClaimHash(*bestClaim, takeover), claimPairs).IsEqual(hash) {
+		return nil, fmt.Errorf("best claim proof of %s does not match its claims hash", name)
+	}
+
+	return &ClaimTrieProof{
+		Name:           string(normalizedName),
+		BlockHash:      tip.hash,
+		Height:         tip.height,
+		ClaimTrieHash:  tip.claimTrie,
+		ClaimsHash:     hash,
+		Pairs:          pairs,
+		BestClaim:      *bestClaim,
+		TakeoverHeight: takeover,
+		ClaimPairs:     claimPairs,
+	}, nil
+}
+
+// GetClaimTrieNodeCount returns the number of nodes of the claimtrie merkle
+// trie held in memory.
+func (b *BlockChain) GetClaimTrieNodeCount() int {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	if b.claimTrie == nil {
+		return 0
+	}
+	return b.claimTrie.TrieNodeCount()
+}
+
+// CheckClaimTrie returns an error unless the claimtrie is at the best block and
+// its hash matches the claimtrie hash of the best block.
+func (b *BlockChain) CheckClaimTrie() error {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	if b.claimTrie == nil {
+		return nil
+	}
+	if b.claimTrieDeferred {
+		return errors.New("the claimtrie awaits a snapshot")
+	}
+	tip := b.bestChain.Tip()
+	if height := b.claimTrie.Height(); height != tip.height {
+		return errors.Errorf("claimtrie height %d != best height %d",
+			height, tip.height)
+	}
+	if hash := b.claimTrie.MerkleHash(); *hash != tip.claimTrie {
+		return errors.Errorf("claimtrie hash %s != best block's ClaimTrie %s",
+			hash, tip.claimTrie)
+	}
+	return nil
+}
+
+// GetClaimTrieRepoMetrics returns the metrics of the Pebble repositories of the
+// claimtrie.
+func (b *BlockChain) GetClaimTrieRepoMetrics() []claimtrie.RepoMetrics {
+	if b.claimTrie == nil {
+		return nil
+	}
+	return b.claimTrie.RepoMetrics()
+}
+
+// BackupClaimTrie writes a consistent copy of the Pebble repositories of the
+// claimtrie to dir, laid out as the claim_dbs directory of the data directory,
+// and returns the height of the claimtrie.  Blocks aren't connected during the
+// backup.
+func (b *BlockChain) BackupClaimTrie(dir string) (int32, error) {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	if b.claimTrie == nil {
+		return 0, errors.New("no claimtrie")
+	}
+	return b.claimTrie.Height(), b.claimTrie.Backup(dir)
+}
@@ -0,0 +1,289 @@
+package blockchain
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/claimtrie"
+	"github.com/lbryio/lbcd/database"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+// ClaimTrieDeferred returns whether the claims of the blocks aren't processed
+// until the claimtrie is loaded from a snapshot.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ClaimTrieDeferred() bool {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	return b.claimTrieDeferred
+}
+
+// WriteClaimTrieSnapshot writes the snapshot of the claimtrie at the main chain
+// block of the height, emitting its chunks in order, and returns the hash of
+// the block.  Blocks are connected during the writing, which fails when they
+// reorganize the chain below the height.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) WriteClaimTrieSnapshot(height int32, emit func(chunk []byte) error) (*chainhash.Hash, error) {
+	b.chainLock.RLock()
+	var n *blockNode
+	if b.claimTrie != nil && !b.claimTrieDeferred && height <= b.claimTrie.Height() {
+		n = b.bestChain.NodeByHeight(height)
+	}
+	b.chainLock.RUnlock()
+	if n == nil {
+		return nil, errors.Errorf("no claimtrie snapshot at height %d", height)
+	}
+
+	err := b.claimTrie.WriteSnapshot(height, claimtrie.SnapshotChunkSize, emit)
+	if err != nil {
+		return nil, err
+	}
+
+	if !b.MainChainHasBlock(&n.hash) {
+		return nil, errors.Errorf("block %s of the claimtrie snapshot was "+
+			"reorganized", n.hash)
+	}
+	return &n.hash, nil
+}
+
+// BeginClaimTrieSnapshot discards the chunks of any snapshot appended to the
+// deferred claimtrie, before the chunks of a new one are appended with
+// AppendClaimTrieSnapshotChunk.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) BeginClaimTrieSnapshot() error {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	if err := b.checkClaimTrieSnapshotTarget(); err != nil {
+		return err
+	}
+	return b.claimTrie.DiscardSnapshot()
+}
+
+// AppendClaimTrieSnapshotChunk appends the next chunk of the snapshot of the
+// claimtrie at height to the deferred claimtrie.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) AppendClaimTrieSnapshotChunk(height int32, chunk []byte) error {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	if err := b.checkClaimTrieSnapshotTarget(); err != nil {
+		return err
+	}
+	return b.claimTrie.AppendSnapshotChunk(height, chunk)
+}
+
+// checkClaimTrieSnapshotTarget returns an error unless the claimtrie is
+// deferred and no snapshot is being loaded into it.
+//
+// This function MUST be called with the chain state lock held.
+func (b *BlockChain) checkClaimTrieSnapshotTarget() error {
+	if !b.claimTrieDeferred {
+		return errors.New("the claimtrie isn't deferred")
+	}
+	if b.claimTrieLoading {
+		return errors.New("a claimtrie snapshot is being loaded")
+	}
+	return nil
+}
+
+// LoadClaimTrieSnapshot loads the deferred claimtrie from the snapshot whose
+// chunks were appended, which is checked against the claimtrie hash of the
+// main chain block hash.  The claims of the blocks after it are then processed
+// and the claimtrie is no longer deferred.
+//
+// The claimtrie stays deferred until it is caught up with the end of the main
+// chain, so the blocks connected in the meantime don't process their claims.
+// It is emptied again when the catch up fails.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) LoadClaimTrieSnapshot(hash *chainhash.Hash) error {
+	b.chainLock.Lock()
+	if err := b.checkClaimTrieSnapshotTarget(); err != nil {
+		b.chainLock.Unlock()
+		return err
+	}
+	n := b.index.LookupNode(hash)
+	if n == nil || !b.bestChain.Contains(n) {
+		b.chainLock.Unlock()
+		return errors.Errorf("block %s of the claimtrie snapshot is not in "+
+			"the main chain", hash)
+	}
+	err := b.claimTrie.FinishSnapshot(n.height, &n.claimTrie)
+	if err != nil {
+		b.chainLock.Unlock()
+		return err
+	}
+	b.claimTrieLoading = true
+	b.chainLock.Unlock()
+	log.Infof("Loaded the claimtrie snapshot at height %d", n.height)
+
+	err = b.catchUpClaimTrie(n, n)
+	if err != nil {
+		if dropErr := b.claimTrie.DropSnapshot(); dropErr != nil {
+			log.Errorf("Unable to drop the claimtrie snapshot: %v",
+				dropErr)
+		}
+	}
+
+	b.chainLock.Lock()
+	b.claimTrieLoading = false
+	b.chainLock.Unlock()
+	return err
+}
+
+// catchUpClaimTrie processes the claims of the main chain blocks after the
+// block n of the claimtrie loaded from the snapshot of the block base, with the
+// outputs their transactions spend from the spend journal, and then marks the
+// claimtrie as no longer deferred.
+//
+// The chain state lock is only held while each block is read, until the
+// blocks connected in the meantime are processed with it held at the end.
+func (b *BlockChain) catchUpClaimTrie(n, base *blockNode) error {
+	start := time.Now()
+	lastReport := time.Now()
+	for {
+		b.chainLock.RLock()
+		next, block, view, err := b.nextClaimTrieBlock(n, base)
+		target := b.bestChain.Height()
+		b.chainLock.RUnlock()
+		if err != nil {
+			return err
+		}
+		if next == nil {
+			break
+		}
+		if block == nil {
+			// The claimtrie was reset to the fork point of a
+			// reorganization.
+			n = next
+			continue
+		}
+
+		err = b.ParseClaimScripts(block, next, view, false)
+		if err != nil {
+			return err
+		}
+		n = next
+
+		if time.Since(lastReport) > time.Second*5 {
+			lastReport = time.Now()
+			log.Infof("Catching up the claimtrie to %d. At: %d", target,
+				n.height)
+		}
+	}
+
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	for {
+		next, block, view, err := b.nextClaimTrieBlock(n, base)
+		if err != nil {
+			return err
+		}
+		if next == nil {
+			break
+		}
+		if block == nil {
+			n = next
+			continue
+		}
+		err = b.ParseClaimScripts(block, next, view, false)
+		if err != nil {
+			return err
+		}
+		n = next
+	}
+	b.claimTrie.FlushToDisk()
+	b.claimTrieDeferred = false
+	log.Infof("Caught up the claimtrie to %d. Took %s", n.height,
+		time.Since(start))
+	return nil
+}
+
+// nextClaimTrieBlock returns the main chain block after the node n of the
+// claimtrie being caught up, along with the view of the outputs it spends, or
+// a nil node at the end of the main chain.
+//
+// When n was reorganized out of the main chain, the claimtrie is reset to the
+// fork point, which is returned with no block.  It fails when the fork point is
+// below the block base of the snapshot.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) nextClaimTrieBlock(n, base *blockNode) (*blockNode, *btcutil.Block, *UtxoViewpoint, error) {
+	if !b.bestChain.Contains(n) {
+		fork := b.bestChain.FindFork(n)
+		if fork == nil || fork.height < base.height {
+			return nil, nil, nil, errors.Errorf("block %s of the "+
+				"claimtrie snapshot was reorganized out of the "+
+				"main chain", base.hash)
+		}
+		err := b.claimTrie.ResetHeight(fork.height)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return fork, nil, nil, nil
+	}
+	next := b.bestChain.Next(n)
+	if next == nil {
+		return nil, nil, nil, nil
+	}
+
+	var block *btcutil.Block
+	var stxos []SpentTxOut
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		block, err = dbFetchBlockByNode(dbTx, next)
+		if err != nil {
+			return err
+		}
+		stxos, err = dbFetchSpendJournalEntry(dbTx, block)
+		return err
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	view, err := spentOutputsView(block, stxos)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return next, block, view, nil
+}
+
+// spentOutputsView returns a view holding the outputs spent by the
+// transactions of the block, which are the stxos of its spend journal entry.
+func spentOutputsView(block *btcutil.Block, stxos []SpentTxOut) (*UtxoViewpoint, error) {
+	if len(stxos) != countSpentOutputs(block) {
+		return nil, AssertError("spentOutputsView called with " +
+			"inconsistent spent transaction out information")
+	}
+
+	view := NewUtxoViewpoint()
+	stxoIdx := 0
+	for _, tx := range block.Transactions()[1:] {
+		for _, txIn := range tx.MsgTx().TxIn {
+			stxo := &stxos[stxoIdx]
+			stxoIdx++
+
+			var packedFlags txoFlags
+			if stxo.IsCoinBase {
+				packedFlags |= tfCoinBase
+			}
+			view.entries[txIn.PreviousOutPoint] = &UtxoEntry{
+				amount:      stxo.Amount,
+				pkScript:    stxo.PkScript,
+				blockHeight: stxo.Height,
+				packedFlags: packedFlags,
+			}
+		}
+	}
+	return view, nil
+}
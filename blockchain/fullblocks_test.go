@@ -307,4 +307,20 @@ func TestFullBlocks(t *testing.T) {
 			}
 		}
 	}
+
+	// The undo data of the resulting chain must replay back to the
+	// genesis block.
+	var progress blockchain.VerifyProgress
+	err = chain.VerifyChain(blockchain.MaxVerifyLevel, 0, nil,
+		func(p *blockchain.VerifyProgress) { progress = *p })
+	if err != nil {
+		t.Fatalf("failed to verify the chain: %v", err)
+	}
+	best := chain.BestSnapshot()
+	if progress.Blocks != int(best.Height) ||
+		progress.ReplayedBlocks != int(best.Height) {
+
+		t.Fatalf("verified %d blocks and replayed %d, want %d",
+			progress.Blocks, progress.ReplayedBlocks, best.Height)
+	}
 }
@@ -0,0 +1,54 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrAcceptanceHalted is returned by ProcessBlock, wrapped along with the
+// reason, while the acceptance of blocks is halted.
+var ErrAcceptanceHalted = errors.New("block acceptance halted")
+
+// HaltAcceptance makes ProcessBlock refuse new blocks for the passed reason
+// until ResumeAcceptance is called.  It waits for the block being processed,
+// if any, so no block is written to the databases once it returns.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) HaltAcceptance(reason string) {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	b.haltReason = reason
+}
+
+// ResumeAcceptance makes ProcessBlock accept blocks again after HaltAcceptance.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ResumeAcceptance() {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	b.haltReason = ""
+}
+
+// AcceptanceHalted returns the reason the acceptance of blocks is halted for,
+// or an empty string when blocks are accepted.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) AcceptanceHalted() string {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	return b.haltReason
+}
+
+// checkAcceptanceHalted returns an error wrapping ErrAcceptanceHalted when the
+// acceptance of blocks is halted.
+//
+// This function MUST be called with the chain state lock held.
+func (b *BlockChain) checkAcceptanceHalted() error {
+	if b.haltReason == "" {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrAcceptanceHalted, b.haltReason)
+}
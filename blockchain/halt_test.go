@@ -0,0 +1,37 @@
+package blockchain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lbryio/lbcd/chaincfg"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+// TestHaltAcceptance ensures blocks are refused while their acceptance is
+// halted.
+func TestHaltAcceptance(t *testing.T) {
+	var b BlockChain
+	if reason := b.AcceptanceHalted(); reason != "" {
+		t.Fatalf("acceptance halted before any halt: %q", reason)
+	}
+
+	b.HaltAcceptance("low disk space")
+	if reason := b.AcceptanceHalted(); reason != "low disk space" {
+		t.Fatalf("got halt reason %q", reason)
+	}
+	block := btcutil.NewBlock(chaincfg.RegressionNetParams.GenesisBlock)
+	_, _, err := b.ProcessBlock(block, BFNone)
+	if !errors.Is(err, ErrAcceptanceHalted) {
+		t.Fatalf("got error %v, want %v", err, ErrAcceptanceHalted)
+	}
+	if !strings.Contains(err.Error(), "low disk space") {
+		t.Fatalf("error %q doesn't tell the halt reason", err)
+	}
+
+	b.ResumeAcceptance()
+	if reason := b.AcceptanceHalted(); reason != "" {
+		t.Fatalf("acceptance still halted: %q", reason)
+	}
+}
@@ -253,6 +253,79 @@ func dbPutAddrIndexEntry(bucket internalBucket, addrKey [addrKeySize]byte,
 	return bucket.Put(level0Key[:], newData)
 }
 
+// addrIndexWriteBuffer is an internalBucket collecting the updates made to the
+// address index while connecting or disconnecting a block.  The entries of an
+// address are added one at a time, each rewriting the levels it touches, so
+// the updates are kept in memory and only the final value of each level is
+// written to the underlying bucket once all of them are made.
+type addrIndexWriteBuffer struct {
+	bucket  internalBucket
+	pending map[[levelKeySize]byte][]byte
+	deleted map[[levelKeySize]byte]struct{}
+}
+
+// newAddrIndexWriteBuffer returns an empty addrIndexWriteBuffer over the
+// provided bucket.
+func newAddrIndexWriteBuffer(bucket internalBucket) *addrIndexWriteBuffer {
+	return &addrIndexWriteBuffer{
+		bucket:  bucket,
+		pending: make(map[[levelKeySize]byte][]byte),
+		deleted: make(map[[levelKeySize]byte]struct{}),
+	}
+}
+
+// Get returns the value of the key, taking the pending updates into account.
+//
+// This is part of the internalBucket interface.
+func (b *addrIndexWriteBuffer) Get(key []byte) []byte {
+	var levelKey [levelKeySize]byte
+	copy(levelKey[:], key)
+	if data, ok := b.pending[levelKey]; ok {
+		return data
+	}
+	if _, ok := b.deleted[levelKey]; ok {
+		return nil
+	}
+	return b.bucket.Get(key)
+}
+
+// Put records the key/value pair to write to the underlying bucket.
+//
+// This is part of the internalBucket interface.
+func (b *addrIndexWriteBuffer) Put(key []byte, value []byte) error {
+	var levelKey [levelKeySize]byte
+	copy(levelKey[:], key)
+	b.pending[levelKey] = value
+	delete(b.deleted, levelKey)
+	return nil
+}
+
+// Delete records the key to remove from the underlying bucket.
+//
+// This is part of the internalBucket interface.
+func (b *addrIndexWriteBuffer) Delete(key []byte) error {
+	var levelKey [levelKeySize]byte
+	copy(levelKey[:], key)
+	b.deleted[levelKey] = struct{}{}
+	delete(b.pending, levelKey)
+	return nil
+}
+
+// flush applies the pending updates to the underlying bucket.
+func (b *addrIndexWriteBuffer) flush() error {
+	for levelKey := range b.deleted {
+		if err := b.bucket.Delete(levelKey[:]); err != nil {
+			return err
+		}
+	}
+	for levelKey, data := range b.pending {
+		if err := b.bucket.Put(levelKey[:], data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // dbFetchAddrIndexEntries returns block regions for transactions referenced by
 // the given address key and the number of entries skipped since it could have
 // been less in the case where there are less total entries than the requested
@@ -755,8 +828,9 @@ func (idx *AddrIndex) ConnectBlock(dbTx database.Tx, block *btcutil.Block,
 	addrsToTxns := make(writeIndexData)
 	idx.indexBlock(addrsToTxns, block, stxos)
 
-	// Add all of the index entries for each address.
-	addrIdxBucket := dbTx.Metadata().Bucket(addrIndexKey)
+	// Add all of the index entries for each address, and write the
+	// resulting levels to the database together.
+	addrIdxBucket := newAddrIndexWriteBuffer(dbTx.Metadata().Bucket(addrIndexKey))
 	for addrKey, txIdxs := range addrsToTxns {
 		for _, txIdx := range txIdxs {
 			err := dbPutAddrIndexEntry(addrIdxBucket, addrKey,
@@ -767,7 +841,7 @@ func (idx *AddrIndex) ConnectBlock(dbTx database.Tx, block *btcutil.Block,
 		}
 	}
 
-	return nil
+	return addrIdxBucket.flush()
 }
 
 // DisconnectBlock is invoked by the index manager when a block has been
@@ -782,8 +856,9 @@ func (idx *AddrIndex) DisconnectBlock(dbTx database.Tx, block *btcutil.Block,
 	addrsToTxns := make(writeIndexData)
 	idx.indexBlock(addrsToTxns, block, stxos)
 
-	// Remove all of the index entries for each address.
-	bucket := dbTx.Metadata().Bucket(addrIndexKey)
+	// Remove all of the index entries for each address, and write the
+	// resulting levels to the database together.
+	bucket := newAddrIndexWriteBuffer(dbTx.Metadata().Bucket(addrIndexKey))
 	for addrKey, txIdxs := range addrsToTxns {
 		err := dbRemoveAddrIndexEntries(bucket, addrKey, len(txIdxs))
 		if err != nil {
@@ -791,7 +866,7 @@ func (idx *AddrIndex) DisconnectBlock(dbTx database.Tx, block *btcutil.Block,
 		}
 	}
 
-	return nil
+	return bucket.flush()
 }
 
 // TxRegionsForAddress returns a slice of block regions which identify each
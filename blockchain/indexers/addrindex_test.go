@@ -274,3 +274,59 @@ nextTest:
 		}
 	}
 }
+
+// TestAddrIndexWriteBuffer ensures that buffering the updates of the address
+// index results in the same levels as making them to the bucket directly.
+func TestAddrIndexWriteBuffer(t *testing.T) {
+	t.Parallel()
+
+	var key [addrKeySize]byte
+	direct := &addrIndexBucket{levels: make(map[[levelKeySize]byte][]byte)}
+	for i := 0; i < level0MaxEntries*3; i++ {
+		txLoc := wire.TxLoc{TxStart: i * 2}
+		if err := dbPutAddrIndexEntry(direct, key, uint32(i), txLoc); err != nil {
+			t.Fatalf("dbPutAddrIndexEntry: unexpected error: %v", err)
+		}
+	}
+
+	// Add a block's worth of entries, spilling over several levels, and
+	// then remove some of them.
+	for _, numRemove := range []int{0, 1, level0MaxEntries + 3} {
+		want := direct.Clone()
+		got := direct.Clone()
+		buffer := newAddrIndexWriteBuffer(got)
+		for _, bucket := range []internalBucket{want, buffer} {
+			for i := 0; i < level0MaxEntries*5; i++ {
+				txLoc := wire.TxLoc{TxStart: i * 3}
+				err := dbPutAddrIndexEntry(bucket, key, 1000, txLoc)
+				if err != nil {
+					t.Fatalf("dbPutAddrIndexEntry: unexpected "+
+						"error: %v", err)
+				}
+			}
+			err := dbRemoveAddrIndexEntries(bucket, key, numRemove)
+			if err != nil {
+				t.Fatalf("dbRemoveAddrIndexEntries: unexpected "+
+					"error: %v", err)
+			}
+		}
+		if len(got.levels) != len(direct.levels) {
+			t.Fatalf("bucket updated before the buffer is flushed")
+		}
+		if err := buffer.flush(); err != nil {
+			t.Fatalf("flush: unexpected error: %v", err)
+		}
+
+		if len(got.levels) != len(want.levels) {
+			t.Fatalf("remove %d: got %d levels, want %d", numRemove,
+				len(got.levels), len(want.levels))
+		}
+		for levelKey, data := range want.levels {
+			if !bytes.Equal(got.levels[levelKey], data) {
+				t.Fatalf("remove %d: mismatched level %d:\n%s\nwant:\n%s",
+					numRemove, levelKey[levelOffset],
+					got.printLevels(key), want.printLevels(key))
+			}
+		}
+	}
+}
@@ -64,6 +64,32 @@ func dbFetchIndexerTip(dbTx database.Tx, idxKey []byte) (*chainhash.Hash, int32,
 	return &hash, height, nil
 }
 
+// IndexTip returns the hash and height of the block the passed index is caught
+// up with.
+func IndexTip(db database.DB, indexer Indexer) (*chainhash.Hash, int32, error) {
+	var hash *chainhash.Hash
+	var height int32
+	err := db.View(func(dbTx database.Tx) error {
+		var err error
+		hash, height, err = dbFetchIndexerTip(dbTx, indexer.Key())
+		return err
+	})
+	return hash, height, err
+}
+
+// BucketNames returns the names of the top-level buckets of the database which
+// hold the optional indexes and their tips.
+func BucketNames() [][]byte {
+	return [][]byte{
+		indexTipsBucketName,
+		txIndexKey,
+		idByHashIndexBucketName,
+		hashByIDIndexBucketName,
+		addrIndexKey,
+		cfIndexParentBucketKey,
+	}
+}
+
 // dbIndexConnectBlock adds all of the index entries associated with the
 // given block using the provided indexer and updates the tip of the indexer
 // accordingly.  An error will be returned if the current tip for the indexer is
@@ -0,0 +1,177 @@
+package indexers
+
+import (
+	"crypto/sha256"
+
+	"github.com/lbryio/lbcd/blockchain"
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/database"
+	"github.com/lbryio/lbcd/txscript"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+const (
+	// scriptHashIndexName is the human-readable name for the index.
+	scriptHashIndexName = "script hash index"
+)
+
+var (
+	// scriptHashIndexKey is the key of the script hash index and the db
+	// bucket used to house it.
+	scriptHashIndexKey = []byte("scripthashidx")
+)
+
+// -----------------------------------------------------------------------------
+// The script hash index maps the hashes of the output scripts paying to a
+// single standard address to the scripts, which lets the servers of the
+// Electrum protocol look up the address of the script hashes the clients refer
+// to in the address index.
+//
+// The script hash is the sha256 of the script with its claim prefix, if any,
+// stripped, so the claims, their updates and their supports of an address are
+// found under the hash of its plain script, as the LBRY wallets expect.
+//
+// The serialized format for keys and values in the bucket is:
+//   <script hash> = <script>
+//
+//   Field           Type              Size
+//   script hash     chainhash.Hash    32 bytes
+//   script          []byte            variable
+//
+// The entries are never removed, as the scripts stay valid for their hashes
+// when the blocks paying to them are disconnected.
+// -----------------------------------------------------------------------------
+
+// ScriptHash returns the hash of the script as referred to by the Electrum
+// protocol, whose string form is the one of the script hashes of the protocol.
+func ScriptHash(pkScript []byte) chainhash.Hash {
+	return chainhash.Hash(sha256.Sum256(txscript.StripClaimScriptPrefix(pkScript)))
+}
+
+// dbPutScriptHashEntry adds the entry of the script to the bucket when it pays
+// to a single address supported by the address index.
+func dbPutScriptHashEntry(bucket internalBucket, pkScript []byte,
+	chainParams *chaincfg.Params) error {
+
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript, chainParams)
+	if err != nil || len(addrs) != 1 {
+		return nil
+	}
+	if _, err := addrToKey(addrs[0]); err != nil {
+		return nil
+	}
+
+	hash := ScriptHash(pkScript)
+	if bucket.Get(hash[:]) != nil {
+		return nil
+	}
+	return bucket.Put(hash[:], txscript.StripClaimScriptPrefix(pkScript))
+}
+
+// ScriptHashIndex implements an index of the output scripts by their hashes as
+// referred to by the Electrum protocol.
+type ScriptHashIndex struct {
+	db          database.DB
+	chainParams *chaincfg.Params
+}
+
+// Ensure the ScriptHashIndex type implements the Indexer interface.
+var _ Indexer = (*ScriptHashIndex)(nil)
+
+// Init is only provided to satisfy the Indexer interface as there is nothing to
+// initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *ScriptHashIndex) Init() error {
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *ScriptHashIndex) Key() []byte {
+	return scriptHashIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *ScriptHashIndex) Name() string {
+	return scriptHashIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the index.
+//
+// This is part of the Indexer interface.
+func (idx *ScriptHashIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(scriptHashIndexKey)
+	return err
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer adds an entry for each script the
+// outputs of the block pay to which isn't indexed yet.
+//
+// This is part of the Indexer interface.
+func (idx *ScriptHashIndex) ConnectBlock(dbTx database.Tx, block *btcutil.Block,
+	_ []blockchain.SpentTxOut) error {
+
+	bucket := dbTx.Metadata().Bucket(scriptHashIndexKey)
+	for _, tx := range block.Transactions() {
+		for _, txOut := range tx.MsgTx().TxOut {
+			err := dbPutScriptHashEntry(bucket, txOut.PkScript,
+				idx.chainParams)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  The entries are kept, as they remain
+// valid.
+//
+// This is part of the Indexer interface.
+func (idx *ScriptHashIndex) DisconnectBlock(database.Tx, *btcutil.Block,
+	[]blockchain.SpentTxOut) error {
+
+	return nil
+}
+
+// ScriptForHash returns the script with the hash, or nil when no output of the
+// main chain has paid to it.
+//
+// This function is safe for concurrent access.
+func (idx *ScriptHashIndex) ScriptForHash(hash *chainhash.Hash) ([]byte, error) {
+	var pkScript []byte
+	err := idx.db.View(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(scriptHashIndexKey)
+		if script := bucket.Get(hash[:]); script != nil {
+			pkScript = make([]byte, len(script))
+			copy(pkScript, script)
+		}
+		return nil
+	})
+	return pkScript, err
+}
+
+// NewScriptHashIndex returns a new instance of an indexer that is used to
+// look up the output scripts by their hashes as referred to by the Electrum
+// protocol.
+//
+// It implements the Indexer interface which plugs into the IndexManager that
+// in turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewScriptHashIndex(db database.DB, chainParams *chaincfg.Params) *ScriptHashIndex {
+	return &ScriptHashIndex{db: db, chainParams: chainParams}
+}
+
+// DropScriptHashIndex drops the script hash index from the provided database
+// if it exists.
+func DropScriptHashIndex(db database.DB, interrupt <-chan struct{}) error {
+	return dropIndex(db, scriptHashIndexKey, scriptHashIndexName, interrupt)
+}
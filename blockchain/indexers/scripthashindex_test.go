@@ -0,0 +1,68 @@
+package indexers
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/txscript"
+)
+
+// scriptHashIndexBucket provides a mock script hash index database bucket by
+// implementing the internalBucket interface.
+type scriptHashIndexBucket map[string][]byte
+
+func (b scriptHashIndexBucket) Get(key []byte) []byte {
+	return b[string(key)]
+}
+
+func (b scriptHashIndexBucket) Put(key []byte, value []byte) error {
+	b[string(key)] = value
+	return nil
+}
+
+func (b scriptHashIndexBucket) Delete(key []byte) error {
+	delete(b, string(key))
+	return nil
+}
+
+// TestScriptHashIndexEntries ensures the scripts paying to a single address
+// are indexed by the hash of their script without the claim prefix.
+func TestScriptHashIndexEntries(t *testing.T) {
+	t.Parallel()
+
+	p2pkh, _ := hex.DecodeString("76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac")
+
+	// The example script hash of the Electrum protocol documentation.
+	hash := ScriptHash(p2pkh)
+	want := "8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a0cfbf90b5c39161"
+	if hash.String() != want {
+		t.Fatalf("ScriptHash: got %v, want %v", hash, want)
+	}
+
+	claim, err := txscript.ClaimNameScript("name", "value")
+	if err != nil {
+		t.Fatalf("ClaimNameScript: unexpected error: %v", err)
+	}
+	claim = append(claim[:len(claim)-1], p2pkh...)
+	if claimHash := ScriptHash(claim); claimHash != hash {
+		t.Fatalf("ScriptHash: got %v for the claim, want %v", claimHash,
+			hash)
+	}
+
+	bucket := make(scriptHashIndexBucket)
+	params := &chaincfg.MainNetParams
+	for _, script := range [][]byte{claim, p2pkh, {txscript.OP_RETURN}} {
+		if err := dbPutScriptHashEntry(bucket, script, params); err != nil {
+			t.Fatalf("dbPutScriptHashEntry: unexpected error: %v", err)
+		}
+	}
+	if len(bucket) != 1 {
+		t.Fatalf("dbPutScriptHashEntry: got %d entries, want 1", len(bucket))
+	}
+	if script := bucket.Get(hash[:]); !bytes.Equal(script, p2pkh) {
+		t.Fatalf("dbPutScriptHashEntry: got script %x, want %x", script,
+			p2pkh)
+	}
+}
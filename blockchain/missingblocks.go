@@ -0,0 +1,138 @@
+package blockchain
+
+import (
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/database"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+// findMissingBlocks marks the blocks of the block index whose data is missing
+// from the database as such, and records those of the main chain as missing
+// blocks to be restored.  The data of blocks is dropped by a repair of the
+// block files of the database, which truncates them to their last consistent
+// point after an unclean shutdown.  Since the blocks are stored after their
+// parents, those of the main chain are the last ones of it.
+//
+// This function MUST be called while initializing the chain state.
+func (b *BlockChain) findMissingBlocks(dbTx database.Tx, tip *blockNode) error {
+	// The blocks are marked on the first start after the repair, so those
+	// of the main chain are found from the database on the next ones.
+	var missing []*blockNode
+	for node := tip; node != nil; node = node.parent {
+		hasBlock, err := dbTx.HasBlock(&node.hash)
+		if err != nil {
+			return err
+		}
+		if hasBlock {
+			break
+		}
+		if node.status.HaveData() {
+			b.index.UnsetStatusFlags(node, statusDataStored)
+		}
+		missing = append(missing, node)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	// The side chain blocks stored along with the missing ones can't
+	// usually be fetched again, so they are left without their data.
+	for _, node := range b.index.index {
+		if !node.status.HaveData() || b.bestChain.Contains(node) {
+			continue
+		}
+		hasBlock, err := dbTx.HasBlock(&node.hash)
+		if err != nil {
+			return err
+		}
+		if !hasBlock {
+			b.index.UnsetStatusFlags(node, statusDataStored)
+		}
+	}
+
+	b.missingBlocks = make([]*blockNode, len(missing))
+	for i, node := range missing {
+		b.missingBlocks[len(missing)-1-i] = node
+	}
+	log.Warnf("The data of %d blocks of the main chain, from height %d, is "+
+		"missing from the database and will be downloaded again",
+		len(missing), b.missingBlocks[0].height)
+	return nil
+}
+
+// MissingBlocks returns the hashes of up to the passed number of blocks of the
+// main chain whose data is missing from the database, such as after a repair
+// of its block files, by ascending height.  Their data is restored by
+// RestoreBlock.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) MissingBlocks(max int) []chainhash.Hash {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	if max > len(b.missingBlocks) {
+		max = len(b.missingBlocks)
+	}
+	hashes := make([]chainhash.Hash, max)
+	for i, node := range b.missingBlocks[:max] {
+		hashes[i] = node.hash
+	}
+	return hashes
+}
+
+// RestoreBlock stores the data of the block when it is one of the missing
+// blocks of the main chain returned by MissingBlocks, after checking its
+// sanity and, once segwit is active, its witness commitment so that blocks
+// stripped of their witness data are rejected, and returns whether it is.
+// Other blocks are left to ProcessBlock.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) RestoreBlock(block *btcutil.Block) (bool, error) {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	i := 0
+	for i < len(b.missingBlocks) && b.missingBlocks[i].hash != *block.Hash() {
+		i++
+	}
+	if i == len(b.missingBlocks) {
+		return false, nil
+	}
+	node := b.missingBlocks[i]
+
+	err := checkBlockSanity(block, b.chainParams.PowLimit, b.timeSource,
+		BFNone)
+	if err != nil {
+		return true, err
+	}
+	segwitState, err := b.deploymentState(node.parent,
+		chaincfg.DeploymentSegwit)
+	if err != nil {
+		return true, err
+	}
+	if segwitState == ThresholdActive {
+		if err := ValidateWitnessCommitment(block); err != nil {
+			return true, err
+		}
+	}
+	err = b.db.Update(func(dbTx database.Tx) error {
+		return dbStoreBlock(dbTx, block)
+	})
+	if err != nil {
+		return true, err
+	}
+	b.index.SetStatusFlags(node, statusDataStored)
+	if err := b.index.flushToDB(); err != nil {
+		return true, err
+	}
+
+	b.missingBlocks = append(b.missingBlocks[:i], b.missingBlocks[i+1:]...)
+	log.Debugf("Restored the data of block %v (height %d)", block.Hash(),
+		node.height)
+	if len(b.missingBlocks) == 0 {
+		log.Infof("Restored the data of the blocks missing from the " +
+			"database")
+	}
+	return true, nil
+}
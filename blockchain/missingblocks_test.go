@@ -0,0 +1,78 @@
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/database"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+// TestFindMissingBlocks ensures the blocks whose data is missing from the
+// database are found at the tip of the main chain and in the side chains.
+func TestFindMissingBlocks(t *testing.T) {
+	params := &chaincfg.RegressionNetParams
+	db, err := database.Create(testDbType, t.TempDir(), params.Net)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	defer db.Close()
+	genesis := btcutil.NewBlock(params.GenesisBlock)
+	err = db.Update(func(dbTx database.Tx) error {
+		return dbTx.StoreBlock(genesis)
+	})
+	if err != nil {
+		t.Fatalf("failed to store genesis block: %v", err)
+	}
+
+	// Only the genesis block is stored, so the data of the others is
+	// missing.
+	chain := newFakeChain(params)
+	chain.db = db
+	genesisNode := chain.bestChain.Tip()
+	nodes := chainedNodes(genesisNode, 5)
+	sideNode := chainedNodes(nodes[1], 1)[0]
+	for _, node := range append([]*blockNode{genesisNode, sideNode}, nodes...) {
+		node.status = statusDataStored | statusValid
+		chain.index.AddNode(node)
+	}
+	tip := nodes[len(nodes)-1]
+	chain.bestChain.SetTip(tip)
+
+	err = db.View(func(dbTx database.Tx) error {
+		return chain.findMissingBlocks(dbTx, tip)
+	})
+	if err != nil {
+		t.Fatalf("findMissingBlocks: unexpected error: %v", err)
+	}
+	if !genesisNode.status.HaveData() {
+		t.Fatalf("genesis block marked as missing its data")
+	}
+	for _, node := range append([]*blockNode{sideNode}, nodes...) {
+		if node.status.HaveData() {
+			t.Fatalf("block %v not marked as missing its data", node)
+		}
+	}
+
+	hashes := chain.MissingBlocks(3)
+	want := []chainhash.Hash{nodes[0].hash, nodes[1].hash, nodes[2].hash}
+	if len(hashes) != len(want) {
+		t.Fatalf("MissingBlocks: got %v, want %v", hashes, want)
+	}
+	for i := range want {
+		if hashes[i] != want[i] {
+			t.Fatalf("MissingBlocks: got %v, want %v", hashes, want)
+		}
+	}
+	if hashes := chain.MissingBlocks(10); len(hashes) != len(nodes) {
+		t.Fatalf("MissingBlocks: got %d blocks, want %d", len(hashes),
+			len(nodes))
+	}
+
+	// The blocks which aren't missing aren't restored.
+	restored, err := chain.RestoreBlock(genesis)
+	if restored || err != nil {
+		t.Fatalf("RestoreBlock: got %v, %v", restored, err)
+	}
+}
@@ -6,6 +6,8 @@ package blockchain
 
 import (
 	"fmt"
+
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
 )
 
 // NotificationType represents the type of a notification message.
@@ -29,6 +31,11 @@ const (
 	// NTBlockDisconnected indicates the associated block was disconnected
 	// from the main chain.
 	NTBlockDisconnected
+
+	// NTChainReorganized indicates the main chain was reorganized.  It is
+	// sent once all of the blocks involved were disconnected and
+	// connected.
+	NTChainReorganized
 )
 
 // notificationTypeStrings is a map of notification types back to their constant
@@ -37,6 +44,7 @@ var notificationTypeStrings = map[NotificationType]string{
 	NTBlockAccepted:     "NTBlockAccepted",
 	NTBlockConnected:    "NTBlockConnected",
 	NTBlockDisconnected: "NTBlockDisconnected",
+	NTChainReorganized:  "NTChainReorganized",
 }
 
 // String returns the NotificationType in human-readable form.
@@ -53,11 +61,22 @@ func (n NotificationType) String() string {
 //   - NTBlockAccepted:     *btcutil.Block
 //   - NTBlockConnected:    *btcutil.Block
 //   - NTBlockDisconnected: *btcutil.Block
+//   - NTChainReorganized:  *Reorganization
 type Notification struct {
 	Type NotificationType
 	Data interface{}
 }
 
+// Reorganization describes a reorganization of the main chain.  Disconnected
+// lists the hashes of the blocks disconnected from the old best chain head
+// down, and Connected those of the blocks connected from the fork point up.
+type Reorganization struct {
+	ForkHash     chainhash.Hash
+	ForkHeight   int32
+	Disconnected []chainhash.Hash
+	Connected    []chainhash.Hash
+}
+
 // Subscribe to block chain notifications. Registers a callback to be executed
 // when various events take place. See the documentation on Notification and
 // NotificationType for details on the types and contents of notifications.
@@ -152,6 +152,10 @@ func (b *BlockChain) ProcessBlock(block *btcutil.Block, flags BehaviorFlags) (bo
 	blockHash := block.Hash()
 	log.Tracef("Processing block %v", blockHash)
 
+	if err := b.checkAcceptanceHalted(); err != nil {
+		return false, false, err
+	}
+
 	if flags&BFNoDupBlockCheck != BFNoDupBlockCheck {
 		// The block must not already exist in the main chain or side chains.
 		exists, err := b.blockExists(blockHash)
@@ -34,6 +34,7 @@ type txValidator struct {
 	flags        txscript.ScriptFlags
 	sigCache     *txscript.SigCache
 	hashCache    *txscript.HashCache
+	sigBatch     *txscript.SigBatch
 }
 
 // sendResult sends the result of a script pair validation on the internal
@@ -89,6 +90,9 @@ out:
 				v.sendResult(err)
 				break out
 			}
+			if v.sigBatch != nil {
+				vm.SetSigBatch(v.sigBatch)
+			}
 
 			// Execute the script pair.
 			if err := vm.Execute(); err != nil {
@@ -239,10 +243,12 @@ func ValidateTransactionScripts(tx *btcutil.Tx, utxoView *UtxoViewpoint,
 }
 
 // checkBlockScripts executes and validates the scripts for all transactions in
-// the passed block using multiple goroutines.
+// the passed block using multiple goroutines.  When batchSigVerify is true,
+// the signature checks which may be deferred are verified together once all
+// of the scripts have been executed.
 func checkBlockScripts(block *btcutil.Block, utxoView *UtxoViewpoint,
 	scriptFlags txscript.ScriptFlags, sigCache *txscript.SigCache,
-	hashCache *txscript.HashCache) error {
+	hashCache *txscript.HashCache, batchSigVerify bool) error {
 
 	// First determine if segwit is active according to the scriptFlags. If
 	// it isn't then we don't need to interact with the HashCache.
@@ -296,10 +302,23 @@ func checkBlockScripts(block *btcutil.Block, utxoView *UtxoViewpoint,
 
 	// Validate all of the inputs.
 	validator := newTxValidator(utxoView, scriptFlags, sigCache, hashCache)
+	if batchSigVerify {
+		validator.sigBatch = txscript.NewSigBatch(sigCache)
+	}
 	start := time.Now()
 	if err := validator.Validate(txValItems); err != nil {
 		return err
 	}
+	if validator.sigBatch != nil {
+		numSigs := validator.sigBatch.Len()
+		if err := validator.sigBatch.Verify(runtime.NumCPU()); err != nil {
+			str := fmt.Sprintf("failed to validate block %v - %v",
+				block.Hash(), err)
+			return ruleError(ErrScriptValidation, str)
+		}
+		log.Tracef("block %v batch verified %d signatures", block.Hash(),
+			numSigs)
+	}
 	elapsed := time.Since(start)
 
 	log.Tracef("block %v took %v to verify", block.Hash(), elapsed)
@@ -38,9 +38,13 @@ func TestCheckBlockScripts(t *testing.T) {
 	}
 
 	scriptFlags := txscript.ScriptBip16
-	err = checkBlockScripts(blocks[0], view, scriptFlags, nil, nil)
-	if err != nil {
-		t.Errorf("Transaction script validation failed: %v\n", err)
-		return
+	for _, batchSigVerify := range []bool{false, true} {
+		err = checkBlockScripts(blocks[0], view, scriptFlags, nil, nil,
+			batchSigVerify)
+		if err != nil {
+			t.Errorf("Transaction script validation failed "+
+				"(batch %v): %v\n", batchSigVerify, err)
+			return
+		}
 	}
 }
@@ -0,0 +1,259 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+// signetScriptFlags are the flags the signet solutions are verified with, as
+// defined by BIP 325.
+const signetScriptFlags = txscript.ScriptBip16 | txscript.ScriptVerifyWitness |
+	txscript.ScriptVerifyDERSignatures | txscript.ScriptStrictMultiSig
+
+// SignetHeader is the prefix of the push of the witness commitment output of
+// the coinbase of a signet block which holds the signet solution of the block.
+var SignetHeader = []byte{0xec, 0xc7, 0xda, 0xa2}
+
+// witnessCommitmentIndex returns the index of the output of the coinbase which
+// holds the witness commitment of the block, which is the last one matching its
+// pattern, or -1 when there is none.
+func witnessCommitmentIndex(coinbase *wire.MsgTx) int {
+	for i := len(coinbase.TxOut) - 1; i >= 0; i-- {
+		pkScript := coinbase.TxOut[i].PkScript
+		if len(pkScript) >= CoinbaseWitnessPkScriptLength &&
+			bytes.HasPrefix(pkScript, WitnessMagicBytes) {
+
+			return i
+		}
+	}
+	return -1
+}
+
+// appendPush appends the push of data to script the way the signet commitment
+// is rebuilt by the reference implementation.
+func appendPush(script, data []byte) []byte {
+	switch n := len(data); {
+	case n < txscript.OP_PUSHDATA1:
+		script = append(script, byte(n))
+	case n <= 0xff:
+		script = append(script, txscript.OP_PUSHDATA1, byte(n))
+	case n <= 0xffff:
+		script = append(script, txscript.OP_PUSHDATA2, 0, 0)
+		binary.LittleEndian.PutUint16(script[len(script)-2:], uint16(n))
+	default:
+		script = append(script, txscript.OP_PUSHDATA4, 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(script[len(script)-4:], uint32(n))
+	}
+	return append(script, data...)
+}
+
+// replaceSignetSolution returns the witness commitment script with the data
+// of its first push starting with the SignetHeader, which holds the signet
+// solution, replaced by replacement, along with the solution.  It returns false
+// when the script has no solution.
+func replaceSignetSolution(pkScript, replacement []byte) ([]byte, []byte, bool, error) {
+	var rebuilt, solution []byte
+	var found bool
+	tokenizer := txscript.MakeScriptTokenizer(0, pkScript)
+	for tokenizer.Next() {
+		data := tokenizer.Data()
+		if len(data) == 0 {
+			rebuilt = append(rebuilt, tokenizer.Opcode())
+			continue
+		}
+		if !found && len(data) > len(SignetHeader) &&
+			bytes.HasPrefix(data, SignetHeader) {
+
+			solution = data[len(SignetHeader):]
+			data = replacement
+			found = true
+		}
+		rebuilt = appendPush(rebuilt, data)
+	}
+	if err := tokenizer.Err(); err != nil {
+		return nil, nil, false, err
+	}
+	return rebuilt, solution, found, nil
+}
+
+// SignetSigningTxs returns the virtual transactions of BIP 325 whose input
+// spending the output of the challenge script of the first one is the signet
+// solution of the block.  The second one holds the solution found in the
+// block, if any, and is the transaction to sign for a new solution of a block
+// already holding a placeholder one, as set by SetSignetSolution.
+//
+// The block data committed to by the first transaction of the LBRY signet is
+// the header of the block without its bits and nonce, which is the version,
+// previous block, merkle root, claimtrie root and timestamp of the block.  Its
+// merkle root is the one of the block with the solution removed.
+func SignetSigningTxs(block *wire.MsgBlock, challenge []byte) (*wire.MsgTx, *wire.MsgTx, error) {
+	if len(block.Transactions) == 0 {
+		return nil, nil, fmt.Errorf("the block has no coinbase")
+	}
+	coinbase := block.Transactions[0]
+	cidx := witnessCommitmentIndex(coinbase)
+	if cidx < 0 {
+		return nil, nil, fmt.Errorf("the block has no witness commitment")
+	}
+
+	toSign := wire.NewMsgTx(0)
+	toSign.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_RETURN}))
+	txIn := wire.NewTxIn(&wire.OutPoint{}, nil, nil)
+	txIn.Sequence = 0
+	toSign.AddTxIn(txIn)
+
+	// Parse the solution, which is the serialized signature script and
+	// witness of the input of the transaction to sign.
+	pkScript := coinbase.TxOut[cidx].PkScript
+	cleared, solution, found, err := replaceSignetSolution(pkScript,
+		SignetHeader)
+	if err != nil {
+		return nil, nil, err
+	}
+	if found {
+		r := bytes.NewReader(solution)
+		txIn.SignatureScript, err = wire.ReadVarBytes(r, 0,
+			wire.MaxBlockPayload, "signet signature script")
+		if err != nil {
+			return nil, nil, err
+		}
+		count, err := wire.ReadVarInt(r, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		if count > uint64(len(solution)) {
+			return nil, nil, fmt.Errorf("the signet witness has too "+
+				"many items: %d", count)
+		}
+		for i := uint64(0); i < count; i++ {
+			item, err := wire.ReadVarBytes(r, 0, wire.MaxBlockPayload,
+				"signet witness item")
+			if err != nil {
+				return nil, nil, err
+			}
+			txIn.Witness = append(txIn.Witness, item)
+		}
+		if r.Len() != 0 {
+			return nil, nil, fmt.Errorf("the signet solution has %d "+
+				"extraneous bytes", r.Len())
+		}
+	}
+
+	// The merkle root is computed with the coinbase cleared of the
+	// solution.
+	modified := coinbase.Copy()
+	if found {
+		modified.TxOut[cidx].PkScript = cleared
+	}
+	hashes := make([]*btcutil.Tx, len(block.Transactions))
+	hashes[0] = btcutil.NewTx(modified)
+	for i, tx := range block.Transactions[1:] {
+		hashes[i+1] = btcutil.NewTx(tx)
+	}
+	merkles := BuildMerkleTreeStore(hashes, false)
+	merkleRoot := merkles[len(merkles)-1]
+
+	var blockData bytes.Buffer
+	header := &block.Header
+	binary.Write(&blockData, binary.LittleEndian, header.Version)
+	blockData.Write(header.PrevBlock[:])
+	blockData.Write(merkleRoot[:])
+	blockData.Write(header.ClaimTrie[:])
+	binary.Write(&blockData, binary.LittleEndian, uint32(header.Timestamp.Unix()))
+
+	toSpend := wire.NewMsgTx(0)
+	sigScript, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).
+		AddData(blockData.Bytes()).Script()
+	if err != nil {
+		return nil, nil, err
+	}
+	spendIn := wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{},
+		wire.MaxPrevOutIndex), sigScript, nil)
+	spendIn.Sequence = 0
+	toSpend.AddTxIn(spendIn)
+	toSpend.AddTxOut(wire.NewTxOut(0, challenge))
+
+	txIn.PreviousOutPoint = wire.OutPoint{Hash: toSpend.TxHash()}
+	return toSpend, toSign, nil
+}
+
+// SetSignetSolution sets the signet solution of the block to the signature
+// script and witness, and updates the merkle root of the block accordingly.
+// The block must have a witness commitment.
+//
+// A placeholder solution is set before computing the transaction to sign with
+// SignetSigningTxs, so that the pushes of the commitment are the same once the
+// actual solution is set.
+func SetSignetSolution(block *wire.MsgBlock, sigScript []byte, witness wire.TxWitness) error {
+	if len(block.Transactions) == 0 {
+		return fmt.Errorf("the block has no coinbase")
+	}
+	coinbase := block.Transactions[0]
+	cidx := witnessCommitmentIndex(coinbase)
+	if cidx < 0 {
+		return fmt.Errorf("the block has no witness commitment")
+	}
+
+	var solution bytes.Buffer
+	solution.Write(SignetHeader)
+	if err := wire.WriteVarBytes(&solution, 0, sigScript); err != nil {
+		return err
+	}
+	if err := wire.WriteVarInt(&solution, 0, uint64(len(witness))); err != nil {
+		return err
+	}
+	for _, item := range witness {
+		if err := wire.WriteVarBytes(&solution, 0, item); err != nil {
+			return err
+		}
+	}
+
+	// Replace the existing solution, or else append it.
+	pkScript := coinbase.TxOut[cidx].PkScript
+	updated, _, found, err := replaceSignetSolution(pkScript, solution.Bytes())
+	if err != nil {
+		return err
+	}
+	if !found {
+		updated = appendPush(append([]byte(nil), pkScript...), solution.Bytes())
+	}
+	coinbase.TxOut[cidx].PkScript = updated
+
+	txs := make([]*btcutil.Tx, len(block.Transactions))
+	for i, tx := range block.Transactions {
+		txs[i] = btcutil.NewTx(tx)
+	}
+	merkles := BuildMerkleTreeStore(txs, false)
+	block.Header.MerkleRoot = *merkles[len(merkles)-1]
+	return nil
+}
+
+// CheckSignetSolution returns an error when the signet solution of the block
+// doesn't satisfy the challenge script of the signet.
+func CheckSignetSolution(block *wire.MsgBlock, challenge []byte) error {
+	toSpend, toSign, err := SignetSigningTxs(block, challenge)
+	if err != nil {
+		str := fmt.Sprintf("invalid signet solution: %v", err)
+		return ruleError(ErrBadSignetSolution, str)
+	}
+
+	prevOut := toSpend.TxOut[0]
+	vm, err := txscript.NewEngine(prevOut.PkScript, toSign, 0,
+		signetScriptFlags, nil, txscript.NewTxSigHashes(toSign),
+		prevOut.Value)
+	if err == nil {
+		err = vm.Execute()
+	}
+	if err != nil {
+		str := fmt.Sprintf("the signet solution doesn't satisfy the "+
+			"challenge: %v", err)
+		return ruleError(ErrBadSignetSolution, str)
+	}
+	return nil
+}
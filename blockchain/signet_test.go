@@ -0,0 +1,153 @@
+package blockchain
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbcd/btcec"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/wire"
+)
+
+// signetTestBlock returns a block whose coinbase holds a witness commitment,
+// along with a transaction spending an output of it.
+func signetTestBlock() *wire.MsgBlock {
+	coinbase := wire.NewMsgTx(1)
+	coinbase.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{},
+		wire.MaxPrevOutIndex), []byte{txscript.OP_1, txscript.OP_1}, nil))
+	coinbase.AddTxOut(wire.NewTxOut(1e8, []byte{txscript.OP_TRUE}))
+	commitment := append(append([]byte(nil), WitnessMagicBytes...),
+		make([]byte, 32)...)
+	coinbase.AddTxOut(wire.NewTxOut(0, commitment))
+
+	spend := wire.NewMsgTx(1)
+	spend.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0),
+		nil, nil))
+	spend.AddTxOut(wire.NewTxOut(1e7, []byte{txscript.OP_TRUE}))
+
+	block := wire.NewMsgBlock(&wire.BlockHeader{
+		Version:   4,
+		PrevBlock: chainhash.Hash{2},
+		ClaimTrie: chainhash.Hash{3},
+		Timestamp: time.Unix(1700000000, 0),
+		Bits:      0x1e0377ae,
+	})
+	block.AddTransaction(coinbase)
+	block.AddTransaction(spend)
+	return block
+}
+
+// TestSignetSolution ensures the signet solutions set in blocks by signing the
+// signet signing transactions satisfy the challenge, and only for the blocks
+// signed.
+func TestSignetSolution(t *testing.T) {
+	t.Parallel()
+
+	key, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("NewPrivateKey: unexpected error: %v", err)
+	}
+	challenge, err := txscript.NewScriptBuilder().
+		AddData(key.PubKey().SerializeCompressed()).
+		AddOp(txscript.OP_CHECKSIG).Script()
+	if err != nil {
+		t.Fatalf("Script: unexpected error: %v", err)
+	}
+
+	// Sign the block after setting a placeholder solution.
+	block := signetTestBlock()
+	if err := SetSignetSolution(block, nil, nil); err != nil {
+		t.Fatalf("SetSignetSolution: unexpected error: %v", err)
+	}
+	if err := CheckSignetSolution(block, challenge); err == nil {
+		t.Fatalf("CheckSignetSolution: expected error for unsigned block")
+	}
+	_, toSign, err := SignetSigningTxs(block, challenge)
+	if err != nil {
+		t.Fatalf("SignetSigningTxs: unexpected error: %v", err)
+	}
+	sig, err := txscript.RawTxInSignature(toSign, 0, challenge,
+		txscript.SigHashAll, key)
+	if err != nil {
+		t.Fatalf("RawTxInSignature: unexpected error: %v", err)
+	}
+	sigScript, err := txscript.NewScriptBuilder().AddData(sig).Script()
+	if err != nil {
+		t.Fatalf("Script: unexpected error: %v", err)
+	}
+	if err := SetSignetSolution(block, sigScript, nil); err != nil {
+		t.Fatalf("SetSignetSolution: unexpected error: %v", err)
+	}
+	if err := CheckSignetSolution(block, challenge); err != nil {
+		t.Fatalf("CheckSignetSolution: unexpected error: %v", err)
+	}
+
+	// The merkle root matches the coinbase holding the solution.
+	pkScript := block.Transactions[0].TxOut[1].PkScript
+	if !bytes.Contains(pkScript, SignetHeader) {
+		t.Fatalf("SetSignetSolution: no signet header in %x", pkScript)
+	}
+	coinbaseHash := block.Transactions[0].TxHash()
+	spendHash := block.Transactions[1].TxHash()
+	if got := HashMerkleBranches(&coinbaseHash, &spendHash); *got != block.Header.MerkleRoot {
+		t.Fatalf("SetSignetSolution: merkle root %v, want %v",
+			block.Header.MerkleRoot, got)
+	}
+
+	// The nonce isn't signed, unlike the rest of the header.
+	block.Header.Nonce++
+	if err := CheckSignetSolution(block, challenge); err != nil {
+		t.Fatalf("CheckSignetSolution: unexpected error: %v", err)
+	}
+	tampered := copySignetBlock(t, block)
+	tampered.Header.ClaimTrie = chainhash.Hash{4}
+	err = CheckSignetSolution(tampered, challenge)
+	if !isRuleError(err, ErrBadSignetSolution) {
+		t.Fatalf("CheckSignetSolution: got %v, want %v", err,
+			ErrBadSignetSolution)
+	}
+	tampered = copySignetBlock(t, block)
+	tampered.Transactions[1].TxOut[0].Value++
+	if err := CheckSignetSolution(tampered, challenge); err == nil {
+		t.Fatalf("CheckSignetSolution: expected error for changed " +
+			"transaction")
+	}
+
+	// Trivial challenges are satisfied without a solution, but a witness
+	// commitment is always required.
+	trivial := []byte{txscript.OP_TRUE}
+	if err := CheckSignetSolution(signetTestBlock(), trivial); err != nil {
+		t.Fatalf("CheckSignetSolution: unexpected error: %v", err)
+	}
+	noCommitment := signetTestBlock()
+	noCommitment.Transactions[0].TxOut = noCommitment.Transactions[0].TxOut[:1]
+	if err := CheckSignetSolution(noCommitment, trivial); err == nil {
+		t.Fatalf("CheckSignetSolution: expected error without witness " +
+			"commitment")
+	}
+	if err := SetSignetSolution(noCommitment, nil, nil); err == nil {
+		t.Fatalf("SetSignetSolution: expected error without witness " +
+			"commitment")
+	}
+}
+
+// copySignetBlock returns a deep copy of the block.
+func copySignetBlock(t *testing.T, block *wire.MsgBlock) *wire.MsgBlock {
+	var buf bytes.Buffer
+	if err := block.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize: unexpected error: %v", err)
+	}
+	var copied wire.MsgBlock
+	if err := copied.Deserialize(&buf); err != nil {
+		t.Fatalf("Deserialize: unexpected error: %v", err)
+	}
+	return &copied
+}
+
+// isRuleError returns whether err is a RuleError with the error code.
+func isRuleError(err error, code ErrorCode) bool {
+	rerr, ok := err.(RuleError)
+	return ok && rerr.ErrorCode == code
+}
@@ -0,0 +1,92 @@
+package blockchain
+
+import (
+	"time"
+
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+)
+
+// maxRecentBlockTimings is the number of most recently connected blocks whose
+// timings are kept.
+const maxRecentBlockTimings = 1000
+
+// BlockTimings is the breakdown of the time spent connecting a block to the
+// main chain.  Validate includes Scripts, and DB includes the updates of the
+// optional indexes.
+type BlockTimings struct {
+	Hash      chainhash.Hash
+	Height    int32
+	Connected time.Time
+	Total     time.Duration
+	Validate  time.Duration
+	Scripts   time.Duration
+	ClaimTrie time.Duration
+	DB        time.Duration
+
+	start time.Time
+}
+
+// startBlockTimings starts timing the connection of a block extending the main
+// chain.  The returned function stops timing it, unless the timings were
+// recorded already.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) startBlockTimings(node *blockNode) func() {
+	t := &BlockTimings{
+		Hash:   node.hash,
+		Height: node.height,
+		start:  time.Now(),
+	}
+	b.blockTimings = t
+	return func() {
+		if b.blockTimings == t {
+			b.blockTimings = nil
+		}
+	}
+}
+
+// recordBlockTimings records the timings of the block which was just connected,
+// and logs them when connecting the block was slow.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) recordBlockTimings() {
+	t := b.blockTimings
+	if t == nil {
+		return
+	}
+	b.blockTimings = nil
+
+	t.Connected = time.Now()
+	t.Total = t.Connected.Sub(t.start)
+
+	b.timingsLock.Lock()
+	if len(b.recentTimings) < maxRecentBlockTimings {
+		b.recentTimings = append(b.recentTimings, *t)
+	} else {
+		b.recentTimings[b.nextTiming] = *t
+	}
+	b.nextTiming = (b.nextTiming + 1) % maxRecentBlockTimings
+	b.timingsLock.Unlock()
+
+	if b.slowBlockThreshold > 0 && t.Total > b.slowBlockThreshold {
+		log.Warnf("Slow block %v at height=%d connected in total=%v "+
+			"validate=%v scripts=%v claimtrie=%v db=%v", t.Hash,
+			t.Height, t.Total, t.Validate, t.Scripts, t.ClaimTrie, t.DB)
+	}
+}
+
+// RecentBlockTimings returns the timings of the most recently connected blocks,
+// oldest first.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) RecentBlockTimings() []BlockTimings {
+	b.timingsLock.Lock()
+	defer b.timingsLock.Unlock()
+
+	timings := make([]BlockTimings, 0, len(b.recentTimings))
+	if len(b.recentTimings) == maxRecentBlockTimings {
+		timings = append(timings, b.recentTimings[b.nextTiming:]...)
+		return append(timings, b.recentTimings[:b.nextTiming]...)
+	}
+	return append(timings, b.recentTimings...)
+}
@@ -0,0 +1,54 @@
+package blockchain
+
+import (
+	"testing"
+)
+
+// TestRecentBlockTimings ensures the timings of the most recently connected
+// blocks are kept, oldest first.
+func TestRecentBlockTimings(t *testing.T) {
+	var b BlockChain
+	if timings := b.RecentBlockTimings(); len(timings) != 0 {
+		t.Fatalf("unexpected timings before any block: %v", timings)
+	}
+
+	// Connect more blocks than are kept so the oldest are overwritten.
+	connected := maxRecentBlockTimings + 10
+	for height := int32(1); height <= int32(connected); height++ {
+		done := b.startBlockTimings(&blockNode{height: height})
+		b.recordBlockTimings()
+		done()
+		if b.blockTimings != nil {
+			t.Fatalf("timings of height %d still pending", height)
+		}
+	}
+
+	timings := b.RecentBlockTimings()
+	if len(timings) != maxRecentBlockTimings {
+		t.Fatalf("got %d timings, want %d", len(timings),
+			maxRecentBlockTimings)
+	}
+	for i, timing := range timings {
+		want := int32(connected - maxRecentBlockTimings + i + 1)
+		if timing.Height != want {
+			t.Fatalf("timings %d: got height %d, want %d", i,
+				timing.Height, want)
+		}
+		if timing.Total < 0 || timing.Connected.IsZero() {
+			t.Fatalf("timings %d: unexpected total %v at %v", i,
+				timing.Total, timing.Connected)
+		}
+	}
+
+	// Stopping the timings of a block which failed to connect discards
+	// them.
+	done := b.startBlockTimings(&blockNode{height: int32(connected + 1)})
+	done()
+	b.recordBlockTimings()
+	if n := len(b.RecentBlockTimings()); n != maxRecentBlockTimings {
+		t.Fatalf("got %d timings after a failed block", n)
+	}
+	if last := b.RecentBlockTimings()[maxRecentBlockTimings-1]; last.Height != int32(connected) {
+		t.Fatalf("unexpected last height %d", last.Height)
+	}
+}
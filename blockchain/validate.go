@@ -763,6 +763,17 @@ func (b *BlockChain) checkBlockContext(block *btcutil.Block, prevNode *blockNode
 		return err
 	}
 
+	// The blocks of signet networks must be signed as required by the
+	// challenge of the network, except for the block templates checked
+	// before they are signed and solved.
+	challenge := b.chainParams.SignetChallenge
+	if challenge != nil && flags&BFNoPoWCheck != BFNoPoWCheck {
+		err := CheckSignetSolution(block.MsgBlock(), challenge)
+		if err != nil {
+			return err
+		}
+	}
+
 	fastAdd := flags&BFFastAdd == BFFastAdd
 	if !fastAdd {
 		// Obtain the latest state of the deployed CSV soft-fork in
@@ -1255,8 +1266,12 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *btcutil.Block, vi
 	// expensive ECDSA signature check scripts.  Doing this last helps
 	// prevent CPU exhaustion attacks.
 	if runScripts {
+		start := time.Now()
 		err := checkBlockScripts(block, view, scriptFlags, b.sigCache,
-			b.hashCache)
+			b.hashCache, b.batchSigVerify)
+		if b.blockTimings != nil {
+			b.blockTimings.Scripts = time.Since(start)
+		}
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,312 @@
+package blockchain
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/lbryio/lbcd/claimtrie"
+	"github.com/lbryio/lbcd/database"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+// The check levels of VerifyChain.  Each level also performs the checks of the
+// levels below it.
+const (
+	// VerifyLoad loads each block from the database.
+	VerifyLoad int32 = iota
+
+	// VerifySanity performs the context-free sanity checks of each block.
+	VerifySanity
+
+	// VerifyUndo checks the spend journal of each block, which is the undo
+	// data restoring the outputs spent by the block when it is
+	// disconnected, matches the inputs of the block.
+	VerifyUndo
+
+	// VerifyReplay disconnects the blocks from the UTXO set in memory with
+	// their undo data, checking the outputs created by each block are
+	// unspent until disconnected and the outputs it spent are restored
+	// only once.
+	VerifyReplay
+
+	// VerifyClaimTrie checks the claimtrie hash recorded at the height of
+	// each block matches its header, and recomputes the hash of the whole
+	// claimtrie at the best block from its claims.
+	VerifyClaimTrie
+
+	// MaxVerifyLevel is the highest check level.
+	MaxVerifyLevel = VerifyClaimTrie
+)
+
+// maxVerifyReplayEntries is the maximum number of outputs held in memory by
+// the replay of a verification, beyond which the older blocks are only checked
+// at VerifyUndo.
+const maxVerifyReplayEntries = 2000000
+
+// VerifyProgress is the progress of a verification of the chain.
+type VerifyProgress struct {
+	// Height is the height of the block being checked.
+	Height int32
+
+	// Blocks is the number of blocks checked, out of TotalBlocks.
+	Blocks      int
+	TotalBlocks int
+
+	// ReplayedBlocks is the number of blocks checked at VerifyReplay,
+	// which stops once too many outputs are held in memory.
+	ReplayedBlocks int
+
+	// ClaimNames is the number of names of the claimtrie rehashed.
+	ClaimNames int
+}
+
+// verifyBlockUndo checks the spend journal of a block against its inputs.  The
+// spent outputs must be created at or below the height of the block.
+func verifyBlockUndo(block *btcutil.Block, stxos []SpentTxOut) error {
+	if len(stxos) != countSpentOutputs(block) {
+		return fmt.Errorf("the spend journal has %d outputs instead "+
+			"of %d", len(stxos), countSpentOutputs(block))
+	}
+	for _, stxo := range stxos {
+		if stxo.Height > block.Height() {
+			return fmt.Errorf("the spend journal has an output "+
+				"created at height %d", stxo.Height)
+		}
+	}
+	return nil
+}
+
+// verifyReplayEntry returns the entry of the view for the outpoint, loading it
+// from the UTXO set of the database transaction when the view doesn't hold it.
+// The entries disconnected by the replay are kept spent in the view, so that
+// the database isn't read for them again.
+func (view *UtxoViewpoint) verifyReplayEntry(dbTx database.Tx, outpoint wire.OutPoint) (*UtxoEntry, error) {
+	if entry, ok := view.entries[outpoint]; ok {
+		return entry, nil
+	}
+	entry, err := dbFetchUtxoEntry(dbTx, outpoint)
+	if err != nil {
+		return nil, err
+	}
+	view.entries[outpoint] = entry
+	return entry, nil
+}
+
+// verifyBlockReplay disconnects a block from the view with its spend journal.
+// The outputs created by the block which aren't spent by the block itself must
+// be unspent, and the outputs it spent must be spent.
+func (b *BlockChain) verifyBlockReplay(dbTx database.Tx, view *UtxoViewpoint, block *btcutil.Block, stxos []SpentTxOut) error {
+	spentInBlock := make(map[wire.OutPoint]struct{})
+	createdInBlock := make(map[wire.OutPoint]struct{})
+	for txIdx, tx := range block.Transactions() {
+		if txIdx > 0 {
+			for _, txIn := range tx.MsgTx().TxIn {
+				spentInBlock[txIn.PreviousOutPoint] = struct{}{}
+			}
+		}
+		for txOutIdx := range tx.MsgTx().TxOut {
+			outpoint := wire.OutPoint{Hash: *tx.Hash(), Index: uint32(txOutIdx)}
+			createdInBlock[outpoint] = struct{}{}
+		}
+	}
+
+	for _, tx := range block.Transactions() {
+		for txOutIdx, txOut := range tx.MsgTx().TxOut {
+			outpoint := wire.OutPoint{Hash: *tx.Hash(), Index: uint32(txOutIdx)}
+			if _, ok := spentInBlock[outpoint]; ok ||
+				txscript.IsUnspendable(txOut.PkScript) {
+
+				continue
+			}
+			entry, err := view.verifyReplayEntry(dbTx, outpoint)
+			if err != nil {
+				return err
+			}
+			switch {
+			case entry == nil || entry.IsSpent():
+				return fmt.Errorf("output %v is missing from the "+
+					"UTXO set", outpoint)
+			case entry.Amount() != txOut.Value ||
+				!bytes.Equal(entry.PkScript(), txOut.PkScript) ||
+				entry.BlockHeight() != block.Height():
+
+				return fmt.Errorf("output %v differs in the UTXO "+
+					"set", outpoint)
+			}
+		}
+	}
+	for outpoint := range spentInBlock {
+		if _, ok := createdInBlock[outpoint]; ok {
+			continue
+		}
+		entry, err := view.verifyReplayEntry(dbTx, outpoint)
+		if err != nil {
+			return err
+		}
+		if entry != nil && !entry.IsSpent() {
+			return fmt.Errorf("spent output %v is in the UTXO set",
+				outpoint)
+		}
+	}
+
+	return view.disconnectTransactions(b.db, block, stxos)
+}
+
+// VerifyChain verifies the depth blocks at the end of the main chain, or all of
+// them when depth is zero, at the passed check level, from the best block
+// backwards.  The interrupt channel stops the verification, and the progress
+// function is called after each block and while the claimtrie is rehashed.
+//
+// The blocks aren't connected during the verifications at VerifyReplay and
+// above, since the UTXO set and the claimtrie must stay at the best block.
+// Instead, the blocks and the UTXO set are read from a snapshot of the database
+// taken at the best block, and the claimtrie is rehashed from a snapshot of its
+// names, so that the chain isn't locked while they are checked.  The chain is
+// only locked to check the claimtrie hash recorded at the height of each block,
+// and the verification fails when the block was reorganized out of the main
+// chain meanwhile.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) VerifyChain(level, depth int32, interrupt <-chan struct{},
+	progress func(*VerifyProgress)) error {
+
+	b.chainLock.RLock()
+	tip := b.bestChain.Tip()
+	dbTx, err := b.db.Begin(false)
+	if err != nil {
+		b.chainLock.RUnlock()
+		return err
+	}
+	defer dbTx.Rollback()
+	claimTrie := b.claimTrie
+	if b.claimTrieDeferred || level < VerifyClaimTrie {
+		claimTrie = nil
+	}
+	var rehasher *claimtrie.Rehasher
+	if claimTrie != nil && claimTrie.Height() == tip.height {
+		rehasher, err = claimTrie.NewRehasher()
+		if err != nil {
+			b.chainLock.RUnlock()
+			return err
+		}
+		defer rehasher.Close()
+	}
+	b.chainLock.RUnlock()
+
+	finishHeight := int32(0)
+	if depth > 0 && tip.height-depth > 0 {
+		finishHeight = tip.height - depth
+	}
+	p := &VerifyProgress{
+		Height:      tip.height,
+		TotalBlocks: int(tip.height - finishHeight),
+	}
+	log.Infof("Verifying %d blocks at level %d", p.TotalBlocks, level)
+
+	var view *UtxoViewpoint
+	if level >= VerifyReplay {
+		view = NewUtxoViewpoint()
+		view.SetBestHash(&tip.hash)
+	}
+
+	for node := tip; node != nil && node.height > finishHeight; node = node.parent {
+		if interruptRequested(interrupt) {
+			return errInterruptRequested
+		}
+		p.Height = node.height
+
+		var stxos []SpentTxOut
+		block, err := dbFetchBlockByNode(dbTx, node)
+		if err == nil && level >= VerifyUndo {
+			stxos, err = dbFetchSpendJournalEntry(dbTx, block)
+		}
+		if err == nil && level >= VerifySanity {
+			err = CheckBlockSanity(block, b.chainParams.PowLimit,
+				b.timeSource)
+		}
+		if err == nil && level >= VerifyUndo {
+			err = verifyBlockUndo(block, stxos)
+		}
+		if err == nil && view != nil {
+			err = b.verifyBlockReplay(dbTx, view, block, stxos)
+			p.ReplayedBlocks++
+			if len(view.entries) > maxVerifyReplayEntries {
+				log.Infof("Stopping the replay of the blocks "+
+					"at height %d with %d outputs in memory",
+					node.height, len(view.entries))
+				view = nil
+			}
+		}
+		if err == nil && claimTrie != nil {
+			err = b.verifyClaimTrieHash(claimTrie, node)
+		}
+		if err != nil {
+			return fmt.Errorf("block %v at height %d: %v", node.hash,
+				node.height, err)
+		}
+
+		p.Blocks++
+		if progress != nil {
+			progress(p)
+		}
+	}
+
+	if rehasher != nil {
+		log.Infof("Rehashing the claimtrie at height %d", tip.height)
+		hash, err := rehasher.Rehash(interrupt, func(names int) {
+			p.ClaimNames = names
+			if names%10000 == 0 && progress != nil {
+				progress(p)
+			}
+		})
+		if progress != nil {
+			progress(p)
+		}
+		if interruptRequested(interrupt) {
+			return errInterruptRequested
+		}
+		if err != nil {
+			return fmt.Errorf("failed to rehash the claimtrie: %v", err)
+		}
+		if *hash != tip.claimTrie {
+			return fmt.Errorf("the rehashed claimtrie hash is %v "+
+				"instead of %v at height %d", hash, tip.claimTrie,
+				tip.height)
+		}
+	}
+
+	log.Infof("Verified %d blocks at level %d", p.Blocks, level)
+	return nil
+}
+
+// verifyClaimTrieHash checks the claimtrie hash recorded at the height of the
+// block of the main chain matches its header.  The hashes recorded at the
+// heights above the claimtrie, such as those of the blocks connected while the
+// claimtrie is deferred, aren't checked.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) verifyClaimTrieHash(claimTrie *claimtrie.ClaimTrie,
+	node *blockNode) error {
+
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	if !b.bestChain.Contains(node) {
+		return fmt.Errorf("the block was reorganized out of the main " +
+			"chain during the verification")
+	}
+	if b.claimTrieDeferred || node.height > claimTrie.Height() {
+		return nil
+	}
+	hash, err := claimTrie.MerkleHashAt(node.height)
+	if err != nil {
+		return err
+	}
+	if *hash != node.claimTrie {
+		return fmt.Errorf("the claimtrie hash is %v instead of %v",
+			hash, node.claimTrie)
+	}
+	return nil
+}
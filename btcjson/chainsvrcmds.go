@@ -48,6 +48,81 @@ func NewAddNodeCmd(addr string, subCmd AddNodeSubCmd) *AddNodeCmd {
 	}
 }
 
+// AnalyzePsbtCmd defines the analyzepsbt JSON-RPC command.
+type AnalyzePsbtCmd struct {
+	Psbt string
+}
+
+// NewAnalyzePsbtCmd returns a new instance which can be used to issue an
+// analyzepsbt JSON-RPC command.
+func NewAnalyzePsbtCmd(psbt string) *AnalyzePsbtCmd {
+	return &AnalyzePsbtCmd{
+		Psbt: psbt,
+	}
+}
+
+// BackupChainStateCmd defines the backupchainstate JSON-RPC command.
+type BackupChainStateCmd struct {
+	Destination string
+}
+
+// NewBackupChainStateCmd returns a new instance which can be used to issue a
+// backupchainstate JSON-RPC command.
+func NewBackupChainStateCmd(destination string) *BackupChainStateCmd {
+	return &BackupChainStateCmd{
+		Destination: destination,
+	}
+}
+
+// BackupClaimDBsCmd defines the backupclaimdbs JSON-RPC command.
+type BackupClaimDBsCmd struct {
+	Destination string
+}
+
+// NewBackupClaimDBsCmd returns a new instance which can be used to issue a
+// backupclaimdbs JSON-RPC command.
+func NewBackupClaimDBsCmd(destination string) *BackupClaimDBsCmd {
+	return &BackupClaimDBsCmd{
+		Destination: destination,
+	}
+}
+
+// CaptureProfileCmd defines the captureprofile JSON-RPC command.
+type CaptureProfileCmd struct {
+	Profiles []string
+	Seconds  *int  `jsonrpcdefault:"30"`
+	Base64   *bool `jsonrpcdefault:"false"`
+}
+
+// NewCaptureProfileCmd returns a new instance which can be used to issue a
+// captureprofile JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewCaptureProfileCmd(profiles []string, seconds *int, base64 *bool) *CaptureProfileCmd {
+	return &CaptureProfileCmd{
+		Profiles: profiles,
+		Seconds:  seconds,
+		Base64:   base64,
+	}
+}
+
+// CheckDBCmd defines the checkdb JSON-RPC command.
+type CheckDBCmd struct {
+	Level *string `jsonrpcdefault:"\"quick\""`
+}
+
+// NewCheckDBCmd returns a new instance which can be used to issue a checkdb
+// JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewCheckDBCmd(level *string) *CheckDBCmd {
+	return &CheckDBCmd{
+		Level: level,
+	}
+}
+
 // ClearBannedCmd defines the clearbanned JSON-RPC command.
 type ClearBannedCmd struct{}
 
@@ -57,6 +132,75 @@ func NewClearBannedCmd() *ClearBannedCmd {
 	return &ClearBannedCmd{}
 }
 
+// ConvertToPsbtCmd defines the converttopsbt JSON-RPC command.
+type ConvertToPsbtCmd struct {
+	HexTx         string
+	PermitSigData *bool `jsonrpcdefault:"false"`
+	IsWitness     *bool
+}
+
+// NewConvertToPsbtCmd returns a new instance which can be used to issue a
+// converttopsbt JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewConvertToPsbtCmd(hexTx string, permitSigData, isWitness *bool) *ConvertToPsbtCmd {
+	return &ConvertToPsbtCmd{
+		HexTx:         hexTx,
+		PermitSigData: permitSigData,
+		IsWitness:     isWitness,
+	}
+}
+
+// CreateMultisigClaim is the claim prefix of a multisig script of the
+// createmultisig command.  The type is "claim", the default, to create a claim
+// of the name with the value, "update" to update the claim of the claim ID to
+// the value, or "support" to support the claim of the claim ID, with the value
+// when it isn't empty.  The value is hex-encoded.
+type CreateMultisigClaim struct {
+	Type    string `json:"type,omitempty"`
+	Name    string `json:"name"`
+	ClaimID string `json:"claimid,omitempty"`
+	Value   string `json:"value,omitempty"`
+}
+
+// CreateChainSnapshotCmd defines the createchainsnapshot JSON-RPC command.
+type CreateChainSnapshotCmd struct {
+	Name string
+}
+
+// NewCreateChainSnapshotCmd returns a new instance which can be used to issue
+// a createchainsnapshot JSON-RPC command.
+func NewCreateChainSnapshotCmd(name string) *CreateChainSnapshotCmd {
+	return &CreateChainSnapshotCmd{
+		Name: name,
+	}
+}
+
+// CreateMultisigCmd defines the createmultisig JSON-RPC command.
+type CreateMultisigCmd struct {
+	NRequired   int
+	Keys        []string
+	AddressType *string `jsonrpcdefault:"\"legacy\""`
+	Claim       *CreateMultisigClaim
+}
+
+// NewCreateMultisigCmd returns a new instance which can be used to issue a
+// createmultisig JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewCreateMultisigCmd(nRequired int, keys []string, addressType *string,
+	claim *CreateMultisigClaim) *CreateMultisigCmd {
+
+	return &CreateMultisigCmd{
+		NRequired:   nRequired,
+		Keys:        keys,
+		AddressType: addressType,
+		Claim:       claim,
+	}
+}
+
 // TransactionInput represents the inputs to a transaction.  Specifically a
 // transaction hash and output number pair.
 type TransactionInput struct {
@@ -64,10 +208,22 @@ type TransactionInput struct {
 	Vout uint32 `json:"vout"`
 }
 
+// CreateRawTransactionClaim is a claim, an update or a support output of the
+// createrawtransaction command, which is a value of its "claim", "update" or
+// "support" output key, along with the others of the same key in an array.
+// The value is hex-encoded.
+type CreateRawTransactionClaim struct {
+	Address string  `json:"address"`
+	Amount  float64 `json:"amount"`
+	ClaimID string  `json:"claimid,omitempty"`
+	Name    string  `json:"name"`
+	Value   string  `json:"value,omitempty"`
+}
+
 // CreateRawTransactionCmd defines the createrawtransaction JSON-RPC command.
 type CreateRawTransactionCmd struct {
 	Inputs   []TransactionInput
-	Outputs  map[string]interface{} `jsonrpcusage:"{\"address\":amount, \"data\":\"hex\", ...}"`
+	Outputs  map[string]interface{} `jsonrpcusage:"{\"address\":amount, \"data\":\"hex\", \"claim\":{\"name\":\"name\",\"value\":\"hex\",\"address\":\"address\",\"amount\":amount}, ...}"`
 	LockTime *int64
 }
 
@@ -75,7 +231,8 @@ type CreateRawTransactionCmd struct {
 // a createrawtransaction JSON-RPC command.
 //
 // Amounts are in BTC. Passing in nil and the empty slice as inputs is equivalent,
-// both gets interpreted as the empty slice.
+// both gets interpreted as the empty slice.  The claim, update and support
+// outputs are values of type CreateRawTransactionClaim, or slices of them.
 func NewCreateRawTransactionCmd(inputs []TransactionInput, outputs map[string]interface{},
 	lockTime *int64) *CreateRawTransactionCmd {
 	// to make sure we're serializing this to the empty list and not null, we
@@ -90,6 +247,30 @@ func NewCreateRawTransactionCmd(inputs []TransactionInput, outputs map[string]in
 	}
 }
 
+// CreatePsbtCmd defines the createpsbt JSON-RPC command.  Its inputs and
+// outputs are those of the createrawtransaction command.
+type CreatePsbtCmd struct {
+	Inputs   []TransactionInput
+	Outputs  map[string]interface{} `jsonrpcusage:"{\"address\":amount, \"data\":\"hex\", \"claim\":{\"name\":\"name\",\"value\":\"hex\",\"address\":\"address\",\"amount\":amount}, ...}"`
+	LockTime *int64
+}
+
+// NewCreatePsbtCmd returns a new instance which can be used to issue a
+// createpsbt JSON-RPC command.
+//
+// The inputs and the outputs are those of NewCreateRawTransactionCmd.
+func NewCreatePsbtCmd(inputs []TransactionInput, outputs map[string]interface{},
+	lockTime *int64) *CreatePsbtCmd {
+	if inputs == nil {
+		inputs = []TransactionInput{}
+	}
+	return &CreatePsbtCmd{
+		Inputs:   inputs,
+		Outputs:  outputs,
+		LockTime: lockTime,
+	}
+}
+
 // DecodeRawTransactionCmd defines the decoderawtransaction JSON-RPC command.
 type DecodeRawTransactionCmd struct {
 	HexTx string
@@ -103,6 +284,19 @@ func NewDecodeRawTransactionCmd(hexTx string) *DecodeRawTransactionCmd {
 	}
 }
 
+// DecodePsbtCmd defines the decodepsbt JSON-RPC command.
+type DecodePsbtCmd struct {
+	Psbt string
+}
+
+// NewDecodePsbtCmd returns a new instance which can be used to issue a
+// decodepsbt JSON-RPC command.
+func NewDecodePsbtCmd(psbt string) *DecodePsbtCmd {
+	return &DecodePsbtCmd{
+		Psbt: psbt,
+	}
+}
+
 // DecodeScriptCmd defines the decodescript JSON-RPC command.
 type DecodeScriptCmd struct {
 	HexScript string
@@ -131,6 +325,56 @@ func NewDeriveAddressesCmd(descriptor string, descriptorRange *DescriptorRange)
 	}
 }
 
+// DownloadChainSnapshotCmd defines the downloadchainsnapshot JSON-RPC command.
+// Hash is the hash of the manifest of the snapshot, as listed by the server it
+// is downloaded from.
+type DownloadChainSnapshotCmd struct {
+	Host     string
+	User     string
+	Pass     string
+	Name     string
+	Hash     string
+	CertFile *string
+	NoTLS    *bool `jsonrpcdefault:"false"`
+}
+
+// NewDownloadChainSnapshotCmd returns a new instance which can be used to
+// issue a downloadchainsnapshot JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewDownloadChainSnapshotCmd(host, user, pass, name, hash string,
+	certFile *string, noTLS *bool) *DownloadChainSnapshotCmd {
+
+	return &DownloadChainSnapshotCmd{
+		Host:     host,
+		User:     user,
+		Pass:     pass,
+		Name:     name,
+		Hash:     hash,
+		CertFile: certFile,
+		NoTLS:    noTLS,
+	}
+}
+
+// ExportBlocksCmd defines the exportblocks JSON-RPC command.
+type ExportBlocksCmd struct {
+	StartHeight *int32 `jsonrpcdefault:"0"`
+	EndHeight   *int32
+}
+
+// NewExportBlocksCmd returns a new instance which can be used to issue an
+// exportblocks JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewExportBlocksCmd(startHeight, endHeight *int32) *ExportBlocksCmd {
+	return &ExportBlocksCmd{
+		StartHeight: startHeight,
+		EndHeight:   endHeight,
+	}
+}
+
 // ChangeType defines the different output types to use for the change address
 // of a transaction built by the node.
 type ChangeType string
@@ -144,6 +388,24 @@ var (
 	ChangeTypeBech32 ChangeType = "bech32"
 )
 
+// FinalizePsbtCmd defines the finalizepsbt JSON-RPC command.
+type FinalizePsbtCmd struct {
+	Psbt    string
+	Extract *bool `jsonrpcdefault:"true"`
+}
+
+// NewFinalizePsbtCmd returns a new instance which can be used to issue a
+// finalizepsbt JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewFinalizePsbtCmd(psbt string, extract *bool) *FinalizePsbtCmd {
+	return &FinalizePsbtCmd{
+		Psbt:    psbt,
+		Extract: extract,
+	}
+}
+
 // FundRawTransactionOpts are the different options that can be passed to rawtransaction
 type FundRawTransactionOpts struct {
 	ChangeAddress          *string               `json:"changeAddress,omitempty"`
@@ -460,6 +722,34 @@ func NewGetCFilterHeaderCmd(hash string,
 	}
 }
 
+// GetChainSnapshotCmd defines the getchainsnapshot JSON-RPC command.
+type GetChainSnapshotCmd struct {
+	Name string
+}
+
+// NewGetChainSnapshotCmd returns a new instance which can be used to issue a
+// getchainsnapshot JSON-RPC command.
+func NewGetChainSnapshotCmd(name string) *GetChainSnapshotCmd {
+	return &GetChainSnapshotCmd{
+		Name: name,
+	}
+}
+
+// GetChainSnapshotChunkCmd defines the getchainsnapshotchunk JSON-RPC command.
+type GetChainSnapshotChunkCmd struct {
+	Name  string
+	Index int
+}
+
+// NewGetChainSnapshotChunkCmd returns a new instance which can be used to
+// issue a getchainsnapshotchunk JSON-RPC command.
+func NewGetChainSnapshotChunkCmd(name string, index int) *GetChainSnapshotChunkCmd {
+	return &GetChainSnapshotChunkCmd{
+		Name:  name,
+		Index: index,
+	}
+}
+
 // GetChainTipsCmd defines the getchaintips JSON-RPC command.
 type GetChainTipsCmd struct{}
 
@@ -509,13 +799,29 @@ func NewGetDescriptorInfoCmd(descriptor string) *GetDescriptorInfoCmd {
 	}
 }
 
+// GetDBInfoCmd defines the getdbinfo JSON-RPC command.
+type GetDBInfoCmd struct{}
+
+// NewGetDBInfoCmd returns a new instance which can be used to issue a
+// getdbinfo JSON-RPC command.
+func NewGetDBInfoCmd() *GetDBInfoCmd {
+	return &GetDBInfoCmd{}
+}
+
 // GetDifficultyCmd defines the getdifficulty JSON-RPC command.
-type GetDifficultyCmd struct{}
+type GetDifficultyCmd struct {
+	HashOrHeight *HashOrHeight
+}
 
 // NewGetDifficultyCmd returns a new instance which can be used to issue a
 // getdifficulty JSON-RPC command.
-func NewGetDifficultyCmd() *GetDifficultyCmd {
-	return &GetDifficultyCmd{}
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value, which is the best block.
+func NewGetDifficultyCmd(hashOrHeight *HashOrHeight) *GetDifficultyCmd {
+	return &GetDifficultyCmd{
+		HashOrHeight: hashOrHeight,
+	}
 }
 
 // GetGenerateCmd defines the getgenerate JSON-RPC command.
@@ -545,6 +851,15 @@ func NewGetInfoCmd() *GetInfoCmd {
 	return &GetInfoCmd{}
 }
 
+// GetLatencyStatsCmd defines the getlatencystats JSON-RPC command.
+type GetLatencyStatsCmd struct{}
+
+// NewGetLatencyStatsCmd returns a new instance which can be used to issue a
+// getlatencystats JSON-RPC command.
+func NewGetLatencyStatsCmd() *GetLatencyStatsCmd {
+	return &GetLatencyStatsCmd{}
+}
+
 // GetMempoolEntryCmd defines the getmempoolentry JSON-RPC command.
 type GetMempoolEntryCmd struct {
 	TxID string
@@ -567,6 +882,15 @@ func NewGetMempoolInfoCmd() *GetMempoolInfoCmd {
 	return &GetMempoolInfoCmd{}
 }
 
+// GetMemoryInfoCmd defines the getmemoryinfo JSON-RPC command.
+type GetMemoryInfoCmd struct{}
+
+// NewGetMemoryInfoCmd returns a new instance which can be used to issue a
+// getmemoryinfo JSON-RPC command.
+func NewGetMemoryInfoCmd() *GetMemoryInfoCmd {
+	return &GetMemoryInfoCmd{}
+}
+
 // GetMiningInfoCmd defines the getmininginfo JSON-RPC command.
 type GetMiningInfoCmd struct{}
 
@@ -598,10 +922,12 @@ func NewGetNetTotalsCmd() *GetNetTotalsCmd {
 type GetNetworkHashPSCmd struct {
 	Blocks *int `jsonrpcdefault:"120"`
 	Height *int `jsonrpcdefault:"-1"`
+	Hash   *string
 }
 
 // NewGetNetworkHashPSCmd returns a new instance which can be used to issue a
-// getnetworkhashps JSON-RPC command.
+// getnetworkhashps JSON-RPC command.  The Hash field of the command may be set
+// to end the estimate with the block of the hash instead of the height.
 //
 // The parameters which are pointers indicate they are optional.  Passing nil
 // for optional parameters will use the default value.
@@ -612,6 +938,37 @@ func NewGetNetworkHashPSCmd(numBlocks, height *int) *GetNetworkHashPSCmd {
 	}
 }
 
+// ExportAddrManCmd defines the exportaddrman JSON-RPC command.
+type ExportAddrManCmd struct{}
+
+// NewExportAddrManCmd returns a new instance which can be used to issue an
+// exportaddrman JSON-RPC command.
+func NewExportAddrManCmd() *ExportAddrManCmd {
+	return &ExportAddrManCmd{}
+}
+
+// GetAddrManInfoCmd defines the getaddrmaninfo JSON-RPC command.
+type GetAddrManInfoCmd struct{}
+
+// NewGetAddrManInfoCmd returns a new instance which can be used to issue a
+// getaddrmaninfo JSON-RPC command.
+func NewGetAddrManInfoCmd() *GetAddrManInfoCmd {
+	return &GetAddrManInfoCmd{}
+}
+
+// ImportAddrManCmd defines the importaddrman JSON-RPC command.
+type ImportAddrManCmd struct {
+	Peers AddrManPeers
+}
+
+// NewImportAddrManCmd returns a new instance which can be used to issue an
+// importaddrman JSON-RPC command.
+func NewImportAddrManCmd(peers AddrManPeers) *ImportAddrManCmd {
+	return &ImportAddrManCmd{
+		Peers: peers,
+	}
+}
+
 // GetNodeAddressesCmd defines the getnodeaddresses JSON-RPC command.
 type GetNodeAddressesCmd struct {
 	Count *int32 `jsonrpcdefault:"1"`
@@ -674,6 +1031,42 @@ func NewGetRawTransactionCmd(txHash string, verbose *bool) *GetRawTransactionCmd
 	}
 }
 
+// GetRawTransactionsCmd defines the getrawtransactions JSON-RPC command.
+type GetRawTransactionsCmd struct {
+	Txids   []string
+	Verbose *bool `jsonrpcdefault:"false"`
+}
+
+// NewGetRawTransactionsCmd returns a new instance which can be used to issue a
+// getrawtransactions JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetRawTransactionsCmd(txHashes []string, verbose *bool) *GetRawTransactionsCmd {
+	return &GetRawTransactionsCmd{
+		Txids:   txHashes,
+		Verbose: verbose,
+	}
+}
+
+// GetRPCInfoCmd defines the getrpcinfo JSON-RPC command.
+type GetRPCInfoCmd struct{}
+
+// NewGetRPCInfoCmd returns a new instance which can be used to issue a
+// getrpcinfo JSON-RPC command.
+func NewGetRPCInfoCmd() *GetRPCInfoCmd {
+	return &GetRPCInfoCmd{}
+}
+
+// GetSystemInfoCmd defines the getsysteminfo JSON-RPC command.
+type GetSystemInfoCmd struct{}
+
+// NewGetSystemInfoCmd returns a new instance which can be used to issue a
+// getsysteminfo JSON-RPC command.
+func NewGetSystemInfoCmd() *GetSystemInfoCmd {
+	return &GetSystemInfoCmd{}
+}
+
 // GetTxOutCmd defines the gettxout JSON-RPC command.
 type GetTxOutCmd struct {
 	Txid           string
@@ -721,6 +1114,24 @@ func NewGetTxOutSetInfoCmd() *GetTxOutSetInfoCmd {
 	return &GetTxOutSetInfoCmd{}
 }
 
+// GetVerifyChainInfoCmd defines the getverifychaininfo JSON-RPC command.
+type GetVerifyChainInfoCmd struct{}
+
+// NewGetVerifyChainInfoCmd returns a new instance which can be used to issue a
+// getverifychaininfo JSON-RPC command.
+func NewGetVerifyChainInfoCmd() *GetVerifyChainInfoCmd {
+	return &GetVerifyChainInfoCmd{}
+}
+
+// GetVersionBitsCmd defines the getversionbits JSON-RPC command.
+type GetVersionBitsCmd struct{}
+
+// NewGetVersionBitsCmd returns a new instance which can be used to issue a
+// getversionbits JSON-RPC command.
+func NewGetVersionBitsCmd() *GetVersionBitsCmd {
+	return &GetVersionBitsCmd{}
+}
+
 // GetWorkCmd defines the getwork JSON-RPC command.
 type GetWorkCmd struct {
 	Data *string
@@ -766,6 +1177,19 @@ func NewInvalidateBlockCmd(blockHash string) *InvalidateBlockCmd {
 	}
 }
 
+// JoinPsbtsCmd defines the joinpsbts JSON-RPC command.
+type JoinPsbtsCmd struct {
+	Psbts []string
+}
+
+// NewJoinPsbtsCmd returns a new instance which can be used to issue a
+// joinpsbts JSON-RPC command.
+func NewJoinPsbtsCmd(psbts []string) *JoinPsbtsCmd {
+	return &JoinPsbtsCmd{
+		Psbts: psbts,
+	}
+}
+
 // ListBannedCmd defines the listbanned JSON-RPC command.
 type ListBannedCmd struct{}
 
@@ -775,6 +1199,15 @@ func NewListBannedCmd() *ListBannedCmd {
 	return &ListBannedCmd{}
 }
 
+// ListChainSnapshotsCmd defines the listchainsnapshots JSON-RPC command.
+type ListChainSnapshotsCmd struct{}
+
+// NewListChainSnapshotsCmd returns a new instance which can be used to issue a
+// listchainsnapshots JSON-RPC command.
+func NewListChainSnapshotsCmd() *ListChainSnapshotsCmd {
+	return &ListChainSnapshotsCmd{}
+}
+
 // PingCmd defines the ping JSON-RPC command.
 type PingCmd struct{}
 
@@ -972,6 +1405,34 @@ func NewSetGenerateCmd(generate bool, genProcLimit *int) *SetGenerateCmd {
 	}
 }
 
+// SetSigCacheSizeCmd defines the setsigcachesize JSON-RPC command.
+type SetSigCacheSizeCmd struct {
+	MaxEntries uint
+}
+
+// NewSetSigCacheSizeCmd returns a new instance which can be used to issue a
+// setsigcachesize JSON-RPC command.
+func NewSetSigCacheSizeCmd(maxEntries uint) *SetSigCacheSizeCmd {
+	return &SetSigCacheSizeCmd{
+		MaxEntries: maxEntries,
+	}
+}
+
+// SetVersionBitsCmd defines the setversionbits JSON-RPC command.
+type SetVersionBitsCmd struct {
+	Deployment string
+	Signal     string
+}
+
+// NewSetVersionBitsCmd returns a new instance which can be used to issue a
+// setversionbits JSON-RPC command.
+func NewSetVersionBitsCmd(deployment, signal string) *SetVersionBitsCmd {
+	return &SetVersionBitsCmd{
+		Deployment: deployment,
+		Signal:     signal,
+	}
+}
+
 // SignMessageWithPrivKeyCmd defines the signmessagewithprivkey JSON-RPC command.
 type SignMessageWithPrivKeyCmd struct {
 	PrivKey string // base 58 Wallet Import format private key
@@ -990,6 +1451,31 @@ func NewSignMessageWithPrivKey(privKey, message string) *SignMessageWithPrivKeyC
 	}
 }
 
+// SignRawTransactionWithKeyCmd defines the signrawtransactionwithkey JSON-RPC
+// command.
+type SignRawTransactionWithKeyCmd struct {
+	RawTx       string
+	PrivKeys    []string
+	PrevTxs     *[]RawTxWitnessInput
+	SigHashType *string `jsonrpcdefault:"\"ALL\""`
+}
+
+// NewSignRawTransactionWithKeyCmd returns a new instance which can be used to
+// issue a signrawtransactionwithkey JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewSignRawTransactionWithKeyCmd(hexEncodedTx string, privKeys []string,
+	prevTxs *[]RawTxWitnessInput, sigHashType *string) *SignRawTransactionWithKeyCmd {
+
+	return &SignRawTransactionWithKeyCmd{
+		RawTx:       hexEncodedTx,
+		PrivKeys:    privKeys,
+		PrevTxs:     prevTxs,
+		SigHashType: sigHashType,
+	}
+}
+
 // StopCmd defines the stop JSON-RPC command.
 type StopCmd struct{}
 
@@ -1032,16 +1518,34 @@ func NewUptimeCmd() *UptimeCmd {
 	return &UptimeCmd{}
 }
 
+// UtxoUpdatePsbtCmd defines the utxoupdatepsbt JSON-RPC command.
+type UtxoUpdatePsbtCmd struct {
+	Psbt string
+}
+
+// NewUtxoUpdatePsbtCmd returns a new instance which can be used to issue a
+// utxoupdatepsbt JSON-RPC command.
+func NewUtxoUpdatePsbtCmd(psbt string) *UtxoUpdatePsbtCmd {
+	return &UtxoUpdatePsbtCmd{
+		Psbt: psbt,
+	}
+}
+
 // ValidateAddressCmd defines the validateaddress JSON-RPC command.
 type ValidateAddressCmd struct {
-	Address string
+	Address       string
+	IncludeClaims *bool `jsonrpcdefault:"false"`
 }
 
 // NewValidateAddressCmd returns a new instance which can be used to issue a
 // validateaddress JSON-RPC command.
-func NewValidateAddressCmd(address string) *ValidateAddressCmd {
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewValidateAddressCmd(address string, includeClaims *bool) *ValidateAddressCmd {
 	return &ValidateAddressCmd{
-		Address: address,
+		Address:       address,
+		IncludeClaims: includeClaims,
 	}
 }
 
@@ -1063,6 +1567,19 @@ func NewVerifyChainCmd(checkLevel, checkDepth *int32) *VerifyChainCmd {
 	}
 }
 
+// VerifyChainSnapshotCmd defines the verifychainsnapshot JSON-RPC command.
+type VerifyChainSnapshotCmd struct {
+	Name string
+}
+
+// NewVerifyChainSnapshotCmd returns a new instance which can be used to issue
+// a verifychainsnapshot JSON-RPC command.
+func NewVerifyChainSnapshotCmd(name string) *VerifyChainSnapshotCmd {
+	return &VerifyChainSnapshotCmd{
+		Name: name,
+	}
+}
+
 // VerifyMessageCmd defines the verifymessage JSON-RPC command.
 type VerifyMessageCmd struct {
 	Address   string
@@ -1098,10 +1615,23 @@ func init() {
 	flags := UsageFlag(0)
 
 	MustRegisterCmd("addnode", (*AddNodeCmd)(nil), flags)
+	MustRegisterCmd("analyzepsbt", (*AnalyzePsbtCmd)(nil), flags)
+	MustRegisterCmd("backupchainstate", (*BackupChainStateCmd)(nil), flags)
+	MustRegisterCmd("backupclaimdbs", (*BackupClaimDBsCmd)(nil), flags)
+	MustRegisterCmd("captureprofile", (*CaptureProfileCmd)(nil), flags)
+	MustRegisterCmd("checkdb", (*CheckDBCmd)(nil), flags)
+	MustRegisterCmd("converttopsbt", (*ConvertToPsbtCmd)(nil), flags)
+	MustRegisterCmd("createchainsnapshot", (*CreateChainSnapshotCmd)(nil), flags)
+	MustRegisterCmd("createmultisig", (*CreateMultisigCmd)(nil), flags)
+	MustRegisterCmd("createpsbt", (*CreatePsbtCmd)(nil), flags)
 	MustRegisterCmd("createrawtransaction", (*CreateRawTransactionCmd)(nil), flags)
+	MustRegisterCmd("decodepsbt", (*DecodePsbtCmd)(nil), flags)
 	MustRegisterCmd("decoderawtransaction", (*DecodeRawTransactionCmd)(nil), flags)
 	MustRegisterCmd("decodescript", (*DecodeScriptCmd)(nil), flags)
 	MustRegisterCmd("deriveaddresses", (*DeriveAddressesCmd)(nil), flags)
+	MustRegisterCmd("downloadchainsnapshot", (*DownloadChainSnapshotCmd)(nil), flags)
+	MustRegisterCmd("exportblocks", (*ExportBlocksCmd)(nil), flags)
+	MustRegisterCmd("finalizepsbt", (*FinalizePsbtCmd)(nil), flags)
 	MustRegisterCmd("fundrawtransaction", (*FundRawTransactionCmd)(nil), flags)
 	MustRegisterCmd("getaddednodeinfo", (*GetAddedNodeInfoCmd)(nil), flags)
 	MustRegisterCmd("getbestblockhash", (*GetBestBlockHashCmd)(nil), flags)
@@ -1115,45 +1645,65 @@ func init() {
 	MustRegisterCmd("getblocktemplate", (*GetBlockTemplateCmd)(nil), flags)
 	MustRegisterCmd("getcfilter", (*GetCFilterCmd)(nil), flags)
 	MustRegisterCmd("getcfilterheader", (*GetCFilterHeaderCmd)(nil), flags)
+	MustRegisterCmd("getchainsnapshot", (*GetChainSnapshotCmd)(nil), flags)
+	MustRegisterCmd("getchainsnapshotchunk", (*GetChainSnapshotChunkCmd)(nil), flags)
 	MustRegisterCmd("getchaintips", (*GetChainTipsCmd)(nil), flags)
 	MustRegisterCmd("getchaintxstats", (*GetChainTxStatsCmd)(nil), flags)
 	MustRegisterCmd("getconnectioncount", (*GetConnectionCountCmd)(nil), flags)
+	MustRegisterCmd("getdbinfo", (*GetDBInfoCmd)(nil), flags)
 	MustRegisterCmd("getdescriptorinfo", (*GetDescriptorInfoCmd)(nil), flags)
 	MustRegisterCmd("getdifficulty", (*GetDifficultyCmd)(nil), flags)
 	MustRegisterCmd("getgenerate", (*GetGenerateCmd)(nil), flags)
 	MustRegisterCmd("gethashespersec", (*GetHashesPerSecCmd)(nil), flags)
 	MustRegisterCmd("getinfo", (*GetInfoCmd)(nil), flags)
+	MustRegisterCmd("getlatencystats", (*GetLatencyStatsCmd)(nil), flags)
 	MustRegisterCmd("getmempoolentry", (*GetMempoolEntryCmd)(nil), flags)
 	MustRegisterCmd("getmempoolinfo", (*GetMempoolInfoCmd)(nil), flags)
+	MustRegisterCmd("getmemoryinfo", (*GetMemoryInfoCmd)(nil), flags)
 	MustRegisterCmd("getmininginfo", (*GetMiningInfoCmd)(nil), flags)
 	MustRegisterCmd("getnetworkinfo", (*GetNetworkInfoCmd)(nil), flags)
 	MustRegisterCmd("getnettotals", (*GetNetTotalsCmd)(nil), flags)
 	MustRegisterCmd("getnetworkhashps", (*GetNetworkHashPSCmd)(nil), flags)
 	MustRegisterCmd("getnodeaddresses", (*GetNodeAddressesCmd)(nil), flags)
+	MustRegisterCmd("exportaddrman", (*ExportAddrManCmd)(nil), flags)
+	MustRegisterCmd("getaddrmaninfo", (*GetAddrManInfoCmd)(nil), flags)
+	MustRegisterCmd("importaddrman", (*ImportAddrManCmd)(nil), flags)
 	MustRegisterCmd("getpeerinfo", (*GetPeerInfoCmd)(nil), flags)
 	MustRegisterCmd("listbanned", (*ListBannedCmd)(nil), flags)
+	MustRegisterCmd("listchainsnapshots", (*ListChainSnapshotsCmd)(nil), flags)
 	MustRegisterCmd("setban", (*SetBanCmd)(nil), flags)
 	MustRegisterCmd("clearbanned", (*ClearBannedCmd)(nil), flags)
 	MustRegisterCmd("getrawmempool", (*GetRawMempoolCmd)(nil), flags)
 	MustRegisterCmd("getrawtransaction", (*GetRawTransactionCmd)(nil), flags)
+	MustRegisterCmd("getrawtransactions", (*GetRawTransactionsCmd)(nil), flags)
+	MustRegisterCmd("getrpcinfo", (*GetRPCInfoCmd)(nil), flags)
+	MustRegisterCmd("getsysteminfo", (*GetSystemInfoCmd)(nil), flags)
 	MustRegisterCmd("gettxout", (*GetTxOutCmd)(nil), flags)
 	MustRegisterCmd("gettxoutproof", (*GetTxOutProofCmd)(nil), flags)
 	MustRegisterCmd("gettxoutsetinfo", (*GetTxOutSetInfoCmd)(nil), flags)
+	MustRegisterCmd("getverifychaininfo", (*GetVerifyChainInfoCmd)(nil), flags)
+	MustRegisterCmd("getversionbits", (*GetVersionBitsCmd)(nil), flags)
 	MustRegisterCmd("getwork", (*GetWorkCmd)(nil), flags)
 	MustRegisterCmd("help", (*HelpCmd)(nil), flags)
 	MustRegisterCmd("invalidateblock", (*InvalidateBlockCmd)(nil), flags)
+	MustRegisterCmd("joinpsbts", (*JoinPsbtsCmd)(nil), flags)
 	MustRegisterCmd("ping", (*PingCmd)(nil), flags)
 	MustRegisterCmd("preciousblock", (*PreciousBlockCmd)(nil), flags)
 	MustRegisterCmd("reconsiderblock", (*ReconsiderBlockCmd)(nil), flags)
 	MustRegisterCmd("searchrawtransactions", (*SearchRawTransactionsCmd)(nil), flags)
 	MustRegisterCmd("sendrawtransaction", (*SendRawTransactionCmd)(nil), flags)
 	MustRegisterCmd("setgenerate", (*SetGenerateCmd)(nil), flags)
+	MustRegisterCmd("setsigcachesize", (*SetSigCacheSizeCmd)(nil), flags)
+	MustRegisterCmd("setversionbits", (*SetVersionBitsCmd)(nil), flags)
 	MustRegisterCmd("signmessagewithprivkey", (*SignMessageWithPrivKeyCmd)(nil), flags)
+	MustRegisterCmd("signrawtransactionwithkey", (*SignRawTransactionWithKeyCmd)(nil), flags)
 	MustRegisterCmd("stop", (*StopCmd)(nil), flags)
 	MustRegisterCmd("submitblock", (*SubmitBlockCmd)(nil), flags)
 	MustRegisterCmd("uptime", (*UptimeCmd)(nil), flags)
+	MustRegisterCmd("utxoupdatepsbt", (*UtxoUpdatePsbtCmd)(nil), flags)
 	MustRegisterCmd("validateaddress", (*ValidateAddressCmd)(nil), flags)
 	MustRegisterCmd("verifychain", (*VerifyChainCmd)(nil), flags)
+	MustRegisterCmd("verifychainsnapshot", (*VerifyChainSnapshotCmd)(nil), flags)
 	MustRegisterCmd("verifymessage", (*VerifyMessageCmd)(nil), flags)
 	MustRegisterCmd("verifytxoutproof", (*VerifyTxOutProofCmd)(nil), flags)
 }
@@ -42,6 +42,175 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"addnode","params":["127.0.0.1","remove"],"id":1}`,
 			unmarshalled: &btcjson.AddNodeCmd{Addr: "127.0.0.1", SubCmd: btcjson.ANRemove},
 		},
+		{
+			name: "backupchainstate",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("backupchainstate", "nightly")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewBackupChainStateCmd("nightly")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"backupchainstate","params":["nightly"],"id":1}`,
+			unmarshalled: &btcjson.BackupChainStateCmd{
+				Destination: "nightly",
+			},
+		},
+		{
+			name: "backupclaimdbs",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("backupclaimdbs", "/backups/lbcd")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewBackupClaimDBsCmd("/backups/lbcd")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"backupclaimdbs","params":["/backups/lbcd"],"id":1}`,
+			unmarshalled: &btcjson.BackupClaimDBsCmd{
+				Destination: "/backups/lbcd",
+			},
+		},
+		{
+			name: "checkdb",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("checkdb")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewCheckDBCmd(nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"checkdb","params":[],"id":1}`,
+			unmarshalled: &btcjson.CheckDBCmd{
+				Level: btcjson.String("quick"),
+			},
+		},
+		{
+			name: "checkdb full",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("checkdb", "full")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewCheckDBCmd(btcjson.String("full"))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"checkdb","params":["full"],"id":1}`,
+			unmarshalled: &btcjson.CheckDBCmd{
+				Level: btcjson.String("full"),
+			},
+		},
+		{
+			name: "captureprofile",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("captureprofile", []string{"cpu", "heap"})
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewCaptureProfileCmd([]string{"cpu", "heap"}, nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"captureprofile","params":[["cpu","heap"]],"id":1}`,
+			unmarshalled: &btcjson.CaptureProfileCmd{
+				Profiles: []string{"cpu", "heap"},
+				Seconds:  btcjson.Int(30),
+				Base64:   btcjson.Bool(false),
+			},
+		},
+		{
+			name: "captureprofile optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("captureprofile", []string{"goroutine"}, 5, true)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewCaptureProfileCmd([]string{"goroutine"},
+					btcjson.Int(5), btcjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"captureprofile","params":[["goroutine"],5,true],"id":1}`,
+			unmarshalled: &btcjson.CaptureProfileCmd{
+				Profiles: []string{"goroutine"},
+				Seconds:  btcjson.Int(5),
+				Base64:   btcjson.Bool(true),
+			},
+		},
+		{
+			name: "converttopsbt",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("converttopsbt", "0100")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewConvertToPsbtCmd("0100", nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"converttopsbt","params":["0100"],"id":1}`,
+			unmarshalled: &btcjson.ConvertToPsbtCmd{
+				HexTx:         "0100",
+				PermitSigData: btcjson.Bool(false),
+			},
+		},
+		{
+			name: "converttopsbt optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("converttopsbt", "0100", true, false)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewConvertToPsbtCmd("0100", btcjson.Bool(true), btcjson.Bool(false))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"converttopsbt","params":["0100",true,false],"id":1}`,
+			unmarshalled: &btcjson.ConvertToPsbtCmd{
+				HexTx:         "0100",
+				PermitSigData: btcjson.Bool(true),
+				IsWitness:     btcjson.Bool(false),
+			},
+		},
+		{
+			name: "createchainsnapshot",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("createchainsnapshot", "clone")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewCreateChainSnapshotCmd("clone")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"createchainsnapshot","params":["clone"],"id":1}`,
+			unmarshalled: &btcjson.CreateChainSnapshotCmd{
+				Name: "clone",
+			},
+		},
+		{
+			name: "createmultisig",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("createmultisig", 2, []string{"031234", "035678"})
+			},
+			staticCmd: func() interface{} {
+				keys := []string{"031234", "035678"}
+				return btcjson.NewCreateMultisigCmd(2, keys, nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"createmultisig","params":[2,["031234","035678"]],"id":1}`,
+			unmarshalled: &btcjson.CreateMultisigCmd{
+				NRequired:   2,
+				Keys:        []string{"031234", "035678"},
+				AddressType: btcjson.String("legacy"),
+			},
+		},
+		{
+			name: "createmultisig optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("createmultisig", 2, []string{"031234", "035678"},
+					"bech32", `{"type":"support","name":"@chan","claimid":"1234"}`)
+			},
+			staticCmd: func() interface{} {
+				keys := []string{"031234", "035678"}
+				claim := btcjson.CreateMultisigClaim{
+					Type:    "support",
+					Name:    "@chan",
+					ClaimID: "1234",
+				}
+				return btcjson.NewCreateMultisigCmd(2, keys,
+					btcjson.String("bech32"), &claim)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"createmultisig","params":[2,["031234","035678"],"bech32",{"type":"support","name":"@chan","claimid":"1234"}],"id":1}`,
+			unmarshalled: &btcjson.CreateMultisigCmd{
+				NRequired:   2,
+				Keys:        []string{"031234", "035678"},
+				AddressType: btcjson.String("bech32"),
+				Claim: &btcjson.CreateMultisigClaim{
+					Type:    "support",
+					Name:    "@chan",
+					ClaimID: "1234",
+				},
+			},
+		},
 		{
 			name: "createrawtransaction",
 			newCmd: func() (interface{}, error) {
@@ -115,6 +284,61 @@ func TestChainSvrCmds(t *testing.T) {
 				Outputs: map[string]interface{}{"data": "6a134920616d204672616374616c456e6372797074"},
 			},
 		},
+		{
+			name: "createrawtransaction with claims",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("createrawtransaction", `[{"txid":"123","vout":1}]`,
+					`{"claim":{"address":"456","amount":0.01,"name":"@chan","value":"cafe"},"support":[{"address":"456","amount":0.02,"claimid":"789","name":"@chan"}]}`)
+			},
+			staticCmd: func() interface{} {
+				txInputs := []btcjson.TransactionInput{
+					{Txid: "123", Vout: 1},
+				}
+				txOutputs := map[string]interface{}{
+					"claim": btcjson.CreateRawTransactionClaim{
+						Address: "456", Amount: .01, Name: "@chan", Value: "cafe",
+					},
+					"support": []btcjson.CreateRawTransactionClaim{
+						{Address: "456", Amount: .02, ClaimID: "789", Name: "@chan"},
+					},
+				}
+				return btcjson.NewCreateRawTransactionCmd(txInputs, txOutputs, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"createrawtransaction","params":[[{"txid":"123","vout":1}],{"claim":{"address":"456","amount":0.01,"name":"@chan","value":"cafe"},"support":[{"address":"456","amount":0.02,"claimid":"789","name":"@chan"}]}],"id":1}`,
+			unmarshalled: &btcjson.CreateRawTransactionCmd{
+				Inputs: []btcjson.TransactionInput{{Txid: "123", Vout: 1}},
+				Outputs: map[string]interface{}{
+					"claim": map[string]interface{}{
+						"address": "456", "amount": .01, "name": "@chan", "value": "cafe",
+					},
+					"support": []interface{}{
+						map[string]interface{}{
+							"address": "456", "amount": .02, "claimid": "789", "name": "@chan",
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "createpsbt",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("createpsbt", `[{"txid":"123","vout":1}]`,
+					`{"456":0.0123}`, int64(12))
+			},
+			staticCmd: func() interface{} {
+				txInputs := []btcjson.TransactionInput{
+					{Txid: "123", Vout: 1},
+				}
+				txOutputs := map[string]interface{}{"456": .0123}
+				return btcjson.NewCreatePsbtCmd(txInputs, txOutputs, btcjson.Int64(12))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"createpsbt","params":[[{"txid":"123","vout":1}],{"456":0.0123},12],"id":1}`,
+			unmarshalled: &btcjson.CreatePsbtCmd{
+				Inputs:   []btcjson.TransactionInput{{Txid: "123", Vout: 1}},
+				Outputs:  map[string]interface{}{"456": .0123},
+				LockTime: btcjson.Int64(12),
+			},
+		},
 		{
 			name: "fundrawtransaction - empty opts",
 			newCmd: func() (i interface{}, e error) {
@@ -228,6 +452,56 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"decoderawtransaction","params":["123"],"id":1}`,
 			unmarshalled: &btcjson.DecodeRawTransactionCmd{HexTx: "123"},
 		},
+		{
+			name: "decodepsbt",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("decodepsbt", "cHNidP8B")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewDecodePsbtCmd("cHNidP8B")
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"decodepsbt","params":["cHNidP8B"],"id":1}`,
+			unmarshalled: &btcjson.DecodePsbtCmd{Psbt: "cHNidP8B"},
+		},
+		{
+			name: "analyzepsbt",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("analyzepsbt", "cHNidP8B")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewAnalyzePsbtCmd("cHNidP8B")
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"analyzepsbt","params":["cHNidP8B"],"id":1}`,
+			unmarshalled: &btcjson.AnalyzePsbtCmd{Psbt: "cHNidP8B"},
+		},
+		{
+			name: "finalizepsbt",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("finalizepsbt", "cHNidP8B")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewFinalizePsbtCmd("cHNidP8B", nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"finalizepsbt","params":["cHNidP8B"],"id":1}`,
+			unmarshalled: &btcjson.FinalizePsbtCmd{
+				Psbt:    "cHNidP8B",
+				Extract: btcjson.Bool(true),
+			},
+		},
+		{
+			name: "finalizepsbt optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("finalizepsbt", "cHNidP8B", false)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewFinalizePsbtCmd("cHNidP8B", btcjson.Bool(false))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"finalizepsbt","params":["cHNidP8B",false],"id":1}`,
+			unmarshalled: &btcjson.FinalizePsbtCmd{
+				Psbt:    "cHNidP8B",
+				Extract: btcjson.Bool(false),
+			},
+		},
 		{
 			name: "decodescript",
 			newCmd: func() (interface{}, error) {
@@ -284,6 +558,72 @@ func TestChainSvrCmds(t *testing.T) {
 				Range:      &btcjson.DescriptorRange{Value: []int{0, 2}},
 			},
 		},
+		{
+			name: "downloadchainsnapshot",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("downloadchainsnapshot", "10.0.0.1:9245", "user", "pass", "clone", "00ff")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewDownloadChainSnapshotCmd("10.0.0.1:9245", "user", "pass", "clone", "00ff", nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"downloadchainsnapshot","params":["10.0.0.1:9245","user","pass","clone","00ff"],"id":1}`,
+			unmarshalled: &btcjson.DownloadChainSnapshotCmd{
+				Host:  "10.0.0.1:9245",
+				User:  "user",
+				Pass:  "pass",
+				Name:  "clone",
+				Hash:  "00ff",
+				NoTLS: btcjson.Bool(false),
+			},
+		},
+		{
+			name: "downloadchainsnapshot optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("downloadchainsnapshot", "10.0.0.1:9245", "user", "pass", "clone", "00ff", "", true)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewDownloadChainSnapshotCmd("10.0.0.1:9245", "user", "pass", "clone", "00ff",
+					btcjson.String(""), btcjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"downloadchainsnapshot","params":["10.0.0.1:9245","user","pass","clone","00ff","",true],"id":1}`,
+			unmarshalled: &btcjson.DownloadChainSnapshotCmd{
+				Host:     "10.0.0.1:9245",
+				User:     "user",
+				Pass:     "pass",
+				Name:     "clone",
+				Hash:     "00ff",
+				CertFile: btcjson.String(""),
+				NoTLS:    btcjson.Bool(true),
+			},
+		},
+		{
+			name: "exportblocks",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("exportblocks")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewExportBlocksCmd(nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"exportblocks","params":[],"id":1}`,
+			unmarshalled: &btcjson.ExportBlocksCmd{
+				StartHeight: btcjson.Int32(0),
+			},
+		},
+		{
+			name: "exportblocks range",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("exportblocks", 100, 200)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewExportBlocksCmd(btcjson.Int32(100),
+					btcjson.Int32(200))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"exportblocks","params":[100,200],"id":1}`,
+			unmarshalled: &btcjson.ExportBlocksCmd{
+				StartHeight: btcjson.Int32(100),
+				EndHeight:   btcjson.Int32(200),
+			},
+		},
 		{
 			name: "getaddednodeinfo",
 			newCmd: func() (interface{}, error) {
@@ -614,6 +954,33 @@ func TestChainSvrCmds(t *testing.T) {
 				FilterType: wire.GCSFilterRegular,
 			},
 		},
+		{
+			name: "getchainsnapshot",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getchainsnapshot", "clone")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetChainSnapshotCmd("clone")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getchainsnapshot","params":["clone"],"id":1}`,
+			unmarshalled: &btcjson.GetChainSnapshotCmd{
+				Name: "clone",
+			},
+		},
+		{
+			name: "getchainsnapshotchunk",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getchainsnapshotchunk", "clone", 12)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetChainSnapshotChunkCmd("clone", 12)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getchainsnapshotchunk","params":["clone",12],"id":1}`,
+			unmarshalled: &btcjson.GetChainSnapshotChunkCmd{
+				Name:  "clone",
+				Index: 12,
+			},
+		},
 		{
 			name: "getchaintips",
 			newCmd: func() (interface{}, error) {
@@ -674,17 +1041,41 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"getconnectioncount","params":[],"id":1}`,
 			unmarshalled: &btcjson.GetConnectionCountCmd{},
 		},
+		{
+			name: "getdbinfo",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getdbinfo")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetDBInfoCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getdbinfo","params":[],"id":1}`,
+			unmarshalled: &btcjson.GetDBInfoCmd{},
+		},
 		{
 			name: "getdifficulty",
 			newCmd: func() (interface{}, error) {
 				return btcjson.NewCmd("getdifficulty")
 			},
 			staticCmd: func() interface{} {
-				return btcjson.NewGetDifficultyCmd()
+				return btcjson.NewGetDifficultyCmd(nil)
 			},
 			marshalled:   `{"jsonrpc":"1.0","method":"getdifficulty","params":[],"id":1}`,
 			unmarshalled: &btcjson.GetDifficultyCmd{},
 		},
+		{
+			name: "getdifficulty optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getdifficulty", &btcjson.HashOrHeight{Value: 123})
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetDifficultyCmd(&btcjson.HashOrHeight{Value: 123})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getdifficulty","params":[123],"id":1}`,
+			unmarshalled: &btcjson.GetDifficultyCmd{
+				HashOrHeight: &btcjson.HashOrHeight{Value: 123},
+			},
+		},
 		{
 			name: "getgenerate",
 			newCmd: func() (interface{}, error) {
@@ -718,6 +1109,17 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"getinfo","params":[],"id":1}`,
 			unmarshalled: &btcjson.GetInfoCmd{},
 		},
+		{
+			name: "getlatencystats",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getlatencystats")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetLatencyStatsCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getlatencystats","params":[],"id":1}`,
+			unmarshalled: &btcjson.GetLatencyStatsCmd{},
+		},
 		{
 			name: "getmempoolentry",
 			newCmd: func() (interface{}, error) {
@@ -742,6 +1144,17 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"getmempoolinfo","params":[],"id":1}`,
 			unmarshalled: &btcjson.GetMempoolInfoCmd{},
 		},
+		{
+			name: "getmemoryinfo",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getmemoryinfo")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetMemoryInfoCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getmemoryinfo","params":[],"id":1}`,
+			unmarshalled: &btcjson.GetMemoryInfoCmd{},
+		},
 		{
 			name: "getmininginfo",
 			newCmd: func() (interface{}, error) {
@@ -817,6 +1230,78 @@ func TestChainSvrCmds(t *testing.T) {
 				Height: btcjson.Int(123),
 			},
 		},
+		{
+			name: "getnetworkhashps optional3",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getnetworkhashps", 200, -1, "123")
+			},
+			staticCmd: func() interface{} {
+				cmd := btcjson.NewGetNetworkHashPSCmd(btcjson.Int(200), btcjson.Int(-1))
+				cmd.Hash = btcjson.String("123")
+				return cmd
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getnetworkhashps","params":[200,-1,"123"],"id":1}`,
+			unmarshalled: &btcjson.GetNetworkHashPSCmd{
+				Blocks: btcjson.Int(200),
+				Height: btcjson.Int(-1),
+				Hash:   btcjson.String("123"),
+			},
+		},
+		{
+			name: "exportaddrman",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("exportaddrman")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewExportAddrManCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"exportaddrman","params":[],"id":1}`,
+			unmarshalled: &btcjson.ExportAddrManCmd{},
+		},
+		{
+			name: "getaddrmaninfo",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getaddrmaninfo")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetAddrManInfoCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getaddrmaninfo","params":[],"id":1}`,
+			unmarshalled: &btcjson.GetAddrManInfoCmd{},
+		},
+		{
+			name: "importaddrman",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("importaddrman", `{"version":3,"addresses":[{"addr":"1.2.3.4:9246","services":1,"src":"5.6.7.8:9246","srcservices":1,"timestamp":1,"attempts":0,"lastattempt":0,"lastsuccess":0,"tried":false,"buckets":[5]}]}`)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewImportAddrManCmd(btcjson.AddrManPeers{
+					Version: 3,
+					Addresses: []btcjson.AddrManAddress{{
+						Addr:        "1.2.3.4:9246",
+						Services:    1,
+						Src:         "5.6.7.8:9246",
+						SrcServices: 1,
+						TimeStamp:   1,
+						Buckets:     []int{5},
+					}},
+				})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"importaddrman","params":[{"version":3,"addresses":[{"addr":"1.2.3.4:9246","services":1,"src":"5.6.7.8:9246","srcservices":1,"timestamp":1,"attempts":0,"lastattempt":0,"lastsuccess":0,"tried":false,"buckets":[5]}]}],"id":1}`,
+			unmarshalled: &btcjson.ImportAddrManCmd{
+				Peers: btcjson.AddrManPeers{
+					Version: 3,
+					Addresses: []btcjson.AddrManAddress{{
+						Addr:        "1.2.3.4:9246",
+						Services:    1,
+						Src:         "5.6.7.8:9246",
+						SrcServices: 1,
+						TimeStamp:   1,
+						Buckets:     []int{5},
+					}},
+				},
+			},
+		},
 		{
 			name: "getnodeaddresses",
 			newCmd: func() (interface{}, error) {
@@ -908,6 +1393,56 @@ func TestChainSvrCmds(t *testing.T) {
 				Verbose: btcjson.Bool(true),
 			},
 		},
+		{
+			name: "getrawtransactions",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getrawtransactions", []string{"123", "456"})
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetRawTransactionsCmd([]string{"123", "456"}, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getrawtransactions","params":[["123","456"]],"id":1}`,
+			unmarshalled: &btcjson.GetRawTransactionsCmd{
+				Txids:   []string{"123", "456"},
+				Verbose: btcjson.Bool(false),
+			},
+		},
+		{
+			name: "getrawtransactions optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getrawtransactions", []string{"123"}, true)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetRawTransactionsCmd([]string{"123"}, btcjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getrawtransactions","params":[["123"],true],"id":1}`,
+			unmarshalled: &btcjson.GetRawTransactionsCmd{
+				Txids:   []string{"123"},
+				Verbose: btcjson.Bool(true),
+			},
+		},
+		{
+			name: "getrpcinfo",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getrpcinfo")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetRPCInfoCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getrpcinfo","params":[],"id":1}`,
+			unmarshalled: &btcjson.GetRPCInfoCmd{},
+		},
+		{
+			name: "getsysteminfo",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getsysteminfo")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetSystemInfoCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getsysteminfo","params":[],"id":1}`,
+			unmarshalled: &btcjson.GetSystemInfoCmd{},
+		},
 		{
 			name: "gettxout",
 			newCmd: func() (interface{}, error) {
@@ -979,6 +1514,28 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"gettxoutsetinfo","params":[],"id":1}`,
 			unmarshalled: &btcjson.GetTxOutSetInfoCmd{},
 		},
+		{
+			name: "getverifychaininfo",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getverifychaininfo")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetVerifyChainInfoCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getverifychaininfo","params":[],"id":1}`,
+			unmarshalled: &btcjson.GetVerifyChainInfoCmd{},
+		},
+		{
+			name: "getversionbits",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getversionbits")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetVersionBitsCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getversionbits","params":[],"id":1}`,
+			unmarshalled: &btcjson.GetVersionBitsCmd{},
+		},
 		{
 			name: "getwork",
 			newCmd: func() (interface{}, error) {
@@ -1031,6 +1588,19 @@ func TestChainSvrCmds(t *testing.T) {
 				Command: btcjson.String("getblock"),
 			},
 		},
+		{
+			name: "joinpsbts",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("joinpsbts", []string{"cHNidP8B", "cHNidP8C"})
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewJoinPsbtsCmd([]string{"cHNidP8B", "cHNidP8C"})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"joinpsbts","params":[["cHNidP8B","cHNidP8C"]],"id":1}`,
+			unmarshalled: &btcjson.JoinPsbtsCmd{
+				Psbts: []string{"cHNidP8B", "cHNidP8C"},
+			},
+		},
 		{
 			name: "invalidateblock",
 			newCmd: func() (interface{}, error) {
@@ -1044,6 +1614,17 @@ func TestChainSvrCmds(t *testing.T) {
 				BlockHash: "123",
 			},
 		},
+		{
+			name: "listchainsnapshots",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("listchainsnapshots")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewListChainSnapshotsCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"listchainsnapshots","params":[],"id":1}`,
+			unmarshalled: &btcjson.ListChainSnapshotsCmd{},
+		},
 		{
 			name: "ping",
 			newCmd: func() (interface{}, error) {
@@ -1316,6 +1897,33 @@ func TestChainSvrCmds(t *testing.T) {
 				GenProcLimit: btcjson.Int(6),
 			},
 		},
+		{
+			name: "setsigcachesize",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("setsigcachesize", 5000)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewSetSigCacheSizeCmd(5000)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"setsigcachesize","params":[5000],"id":1}`,
+			unmarshalled: &btcjson.SetSigCacheSizeCmd{
+				MaxEntries: 5000,
+			},
+		},
+		{
+			name: "setversionbits",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("setversionbits", "dummy", "signal")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewSetVersionBitsCmd("dummy", "signal")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"setversionbits","params":["dummy","signal"],"id":1}`,
+			unmarshalled: &btcjson.SetVersionBitsCmd{
+				Deployment: "dummy",
+				Signal:     "signal",
+			},
+		},
 		{
 			name: "signmessagewithprivkey",
 			newCmd: func() (interface{}, error) {
@@ -1330,6 +1938,57 @@ func TestChainSvrCmds(t *testing.T) {
 				Message: "Hey",
 			},
 		},
+		{
+			name: "signrawtransactionwithkey",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("signrawtransactionwithkey", "001122", `["cVhe"]`)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewSignRawTransactionWithKeyCmd("001122", []string{"cVhe"}, nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"signrawtransactionwithkey","params":["001122",["cVhe"]],"id":1}`,
+			unmarshalled: &btcjson.SignRawTransactionWithKeyCmd{
+				RawTx:       "001122",
+				PrivKeys:    []string{"cVhe"},
+				SigHashType: btcjson.String("ALL"),
+			},
+		},
+		{
+			name: "signrawtransactionwithkey optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("signrawtransactionwithkey", "001122", `["cVhe"]`,
+					`[{"txid":"123","vout":1,"scriptPubKey":"00","witnessScript":"01","amount":1.5}]`,
+					"SINGLE|ANYONECANPAY")
+			},
+			staticCmd: func() interface{} {
+				prevTxs := []btcjson.RawTxWitnessInput{
+					{
+						Txid:          "123",
+						Vout:          1,
+						ScriptPubKey:  "00",
+						WitnessScript: btcjson.String("01"),
+						Amount:        btcjson.Float64(1.5),
+					},
+				}
+				return btcjson.NewSignRawTransactionWithKeyCmd("001122", []string{"cVhe"},
+					&prevTxs, btcjson.String("SINGLE|ANYONECANPAY"))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"signrawtransactionwithkey","params":["001122",["cVhe"],[{"txid":"123","vout":1,"scriptPubKey":"00","witnessScript":"01","amount":1.5}],"SINGLE|ANYONECANPAY"],"id":1}`,
+			unmarshalled: &btcjson.SignRawTransactionWithKeyCmd{
+				RawTx:    "001122",
+				PrivKeys: []string{"cVhe"},
+				PrevTxs: &[]btcjson.RawTxWitnessInput{
+					{
+						Txid:          "123",
+						Vout:          1,
+						ScriptPubKey:  "00",
+						WitnessScript: btcjson.String("01"),
+						Amount:        btcjson.Float64(1.5),
+					},
+				},
+				SigHashType: btcjson.String("SINGLE|ANYONECANPAY"),
+			},
+		},
 		{
 			name: "stop",
 			newCmd: func() (interface{}, error) {
@@ -1385,17 +2044,56 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"uptime","params":[],"id":1}`,
 			unmarshalled: &btcjson.UptimeCmd{},
 		},
+		{
+			name: "utxoupdatepsbt",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("utxoupdatepsbt", "cHNidP8B")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewUtxoUpdatePsbtCmd("cHNidP8B")
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"utxoupdatepsbt","params":["cHNidP8B"],"id":1}`,
+			unmarshalled: &btcjson.UtxoUpdatePsbtCmd{Psbt: "cHNidP8B"},
+		},
 		{
 			name: "validateaddress",
 			newCmd: func() (interface{}, error) {
 				return btcjson.NewCmd("validateaddress", "1Address")
 			},
 			staticCmd: func() interface{} {
-				return btcjson.NewValidateAddressCmd("1Address")
+				return btcjson.NewValidateAddressCmd("1Address", nil)
 			},
 			marshalled: `{"jsonrpc":"1.0","method":"validateaddress","params":["1Address"],"id":1}`,
 			unmarshalled: &btcjson.ValidateAddressCmd{
-				Address: "1Address",
+				Address:       "1Address",
+				IncludeClaims: btcjson.Bool(false),
+			},
+		},
+		{
+			name: "validateaddress optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("validateaddress", "1Address", true)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewValidateAddressCmd("1Address", btcjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"validateaddress","params":["1Address",true],"id":1}`,
+			unmarshalled: &btcjson.ValidateAddressCmd{
+				Address:       "1Address",
+				IncludeClaims: btcjson.Bool(true),
+			},
+		},
+		{
+			name: "verifychainsnapshot",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("verifychainsnapshot", "clone")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewVerifyChainSnapshotCmd("clone")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"verifychainsnapshot","params":["clone"],"id":1}`,
+			unmarshalled: &btcjson.VerifyChainSnapshotCmd{
+				Name: "clone",
 			},
 		},
 		{
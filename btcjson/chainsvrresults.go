@@ -27,9 +27,12 @@ type GetBlockHeaderVerboseResult struct {
 	MerkleRoot    string  `json:"merkleroot"`
 	ClaimTrie     string  `json:"nameclaimroot,omitempty"`
 	Time          int64   `json:"time"`
+	MedianTime    int64   `json:"mediantime"`
 	Nonce         uint64  `json:"nonce"`
 	Bits          string  `json:"bits"`
 	Difficulty    float64 `json:"difficulty"`
+	ChainWork     string  `json:"chainwork"`
+	TxCount       int     `json:"nTx"`
 	PreviousHash  string  `json:"previousblockhash,omitempty"`
 	NextHash      string  `json:"nextblockhash,omitempty"`
 }
@@ -130,15 +133,145 @@ type GetChainTxStatsResult struct {
 type CreateMultiSigResult struct {
 	Address      string `json:"address"`
 	RedeemScript string `json:"redeemScript"`
+	ScriptPubKey string `json:"scriptPubKey,omitempty"`
+	Descriptor   string `json:"descriptor,omitempty"`
 }
 
 // DecodeScriptResult models the data returned from the decodescript command.
 type DecodeScriptResult struct {
-	Asm       string   `json:"asm"`
-	ReqSigs   int32    `json:"reqSigs,omitempty"`
-	Type      string   `json:"type"`
-	Addresses []string `json:"addresses,omitempty"`
-	P2sh      string   `json:"p2sh,omitempty"`
+	Asm       string              `json:"asm"`
+	ReqSigs   int32               `json:"reqSigs,omitempty"`
+	Type      string              `json:"type"`
+	Addresses []string            `json:"addresses,omitempty"`
+	P2sh      string              `json:"p2sh,omitempty"`
+	Claim     *DecodeScriptClaim  `json:"claim,omitempty"`
+	Segwit    *DecodeScriptSegwit `json:"segwit,omitempty"`
+}
+
+// DecodeScriptClaim models the claim prefix of a script decoded by the
+// decodescript command, along with the script which follows it.
+type DecodeScriptClaim struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	ClaimID string `json:"claimid,omitempty"`
+	Value   string `json:"value,omitempty"`
+	Asm     string `json:"asm"`
+	Hex     string `json:"hex"`
+}
+
+// DecodeScriptSegwit models the witness program paying to a script decoded by
+// the decodescript command.
+type DecodeScriptSegwit struct {
+	Asm        string   `json:"asm"`
+	Hex        string   `json:"hex"`
+	ReqSigs    int32    `json:"reqSigs,omitempty"`
+	Type       string   `json:"type"`
+	Addresses  []string `json:"addresses,omitempty"`
+	P2shSegwit string   `json:"p2sh-segwit"`
+}
+
+// PsbtScript models a redeem or witness script of a PSBT input or output.
+type PsbtScript struct {
+	Asm  string `json:"asm"`
+	Hex  string `json:"hex"`
+	Type string `json:"type"`
+}
+
+// PsbtUtxo models the witness UTXO of a PSBT input.
+type PsbtUtxo struct {
+	Amount       float64            `json:"amount"`
+	ScriptPubKey ScriptPubKeyResult `json:"scriptPubKey"`
+}
+
+// PsbtBip32Deriv models a BIP 32 derivation path of a PSBT input or output.
+type PsbtBip32Deriv struct {
+	PubKey            string `json:"pubkey"`
+	MasterFingerprint string `json:"master_fingerprint"`
+	Path              string `json:"path"`
+}
+
+// PsbtClaim models the claim information of a PSBT input or output.  The
+// claim ID is omitted for outputs creating a new claim.
+type PsbtClaim struct {
+	Name    string `json:"name"`
+	ClaimID string `json:"claimid,omitempty"`
+}
+
+// DecodePsbtInput models an input of the data returned from the decodepsbt
+// command.
+type DecodePsbtInput struct {
+	NonWitnessUtxo     *TxRawDecodeResult `json:"non_witness_utxo,omitempty"`
+	WitnessUtxo        *PsbtUtxo          `json:"witness_utxo,omitempty"`
+	PartialSignatures  map[string]string  `json:"partial_signatures,omitempty"`
+	Sighash            string             `json:"sighash,omitempty"`
+	RedeemScript       *PsbtScript        `json:"redeem_script,omitempty"`
+	WitnessScript      *PsbtScript        `json:"witness_script,omitempty"`
+	Bip32Derivs        []PsbtBip32Deriv   `json:"bip32_derivs,omitempty"`
+	FinalScriptSig     *ScriptSig         `json:"final_scriptSig,omitempty"`
+	FinalScriptWitness []string           `json:"final_scriptwitness,omitempty"`
+	Claim              *PsbtClaim         `json:"claim,omitempty"`
+	Unknown            map[string]string  `json:"unknown,omitempty"`
+}
+
+// DecodePsbtOutput models an output of the data returned from the decodepsbt
+// command.
+type DecodePsbtOutput struct {
+	RedeemScript  *PsbtScript      `json:"redeem_script,omitempty"`
+	WitnessScript *PsbtScript      `json:"witness_script,omitempty"`
+	Bip32Derivs   []PsbtBip32Deriv `json:"bip32_derivs,omitempty"`
+	Claim         *PsbtClaim       `json:"claim,omitempty"`
+}
+
+// DecodePsbtResult models the data returned from the decodepsbt command.
+type DecodePsbtResult struct {
+	Tx      TxRawDecodeResult  `json:"tx"`
+	Unknown map[string]string  `json:"unknown"`
+	Inputs  []DecodePsbtInput  `json:"inputs"`
+	Outputs []DecodePsbtOutput `json:"outputs"`
+	Fee     *float64           `json:"fee,omitempty"`
+}
+
+// AnalyzePsbtMissing models the data missing to sign a PSBT input.  Keys are
+// identified by the hex encoded hash160 of the public key.
+type AnalyzePsbtMissing struct {
+	Pubkeys       []string `json:"pubkeys,omitempty"`
+	Signatures    []string `json:"signatures,omitempty"`
+	RedeemScript  string   `json:"redeemscript,omitempty"`
+	WitnessScript string   `json:"witnessscript,omitempty"`
+}
+
+// AnalyzePsbtInput models an input of the data returned from the analyzepsbt
+// command.
+type AnalyzePsbtInput struct {
+	HasUtxo bool                `json:"has_utxo"`
+	IsFinal bool                `json:"is_final"`
+	Missing *AnalyzePsbtMissing `json:"missing,omitempty"`
+	Next    string              `json:"next,omitempty"`
+}
+
+// AnalyzePsbtResult models the data returned from the analyzepsbt command.
+type AnalyzePsbtResult struct {
+	Inputs           []AnalyzePsbtInput `json:"inputs,omitempty"`
+	EstimatedVSize   *int64             `json:"estimated_vsize,omitempty"`
+	EstimatedFeeRate *float64           `json:"estimated_feerate,omitempty"`
+	Fee              *float64           `json:"fee,omitempty"`
+	Next             string             `json:"next"`
+	Error            string             `json:"error,omitempty"`
+}
+
+// FinalizePsbtResult models the data returned from the finalizepsbt command.
+type FinalizePsbtResult struct {
+	Psbt     string `json:"psbt,omitempty"`
+	Hex      string `json:"hex,omitempty"`
+	Complete bool   `json:"complete"`
+}
+
+// SignRawTransactionWithKeyResult models the data returned from the
+// signrawtransactionwithkey command.
+type SignRawTransactionWithKeyResult struct {
+	Hex      string                    `json:"hex"`
+	Complete bool                      `json:"complete"`
+	Errors   []SignRawTransactionError `json:"errors,omitempty"`
 }
 
 // GetAddedNodeInfoResultAddr models the data of the addresses portion of the
@@ -350,6 +483,327 @@ type GetMempoolInfoResult struct {
 	UnbroadcastCount int64   `json:"unbroadcastcount"` // Current number of transactions that haven't passed initial broadcast yet
 }
 
+// RPCActiveCommand models an in-flight command of the getrpcinfo command.
+type RPCActiveCommand struct {
+	Method   string `json:"method"`
+	Duration int64  `json:"duration"` // Running time in microseconds
+	Client   string `json:"client"`
+}
+
+// RPCWebsocketClient models the notification queue of a websocket client of
+// the getrpcinfo command.
+type RPCWebsocketClient struct {
+	Client      string `json:"client"`
+	Queued      int    `json:"queued"`
+	QueuedBytes int    `json:"queuedbytes"`
+	PeakQueued  int    `json:"peakqueued"`
+	Sent        uint64 `json:"sent"`
+	Dropped     uint64 `json:"dropped"`
+	Slow        bool   `json:"slow"`
+}
+
+// BackupResult models the data returned from the backupchainstate and
+// backupclaimdbs commands.  Height is the height of the claimtrie backed up by
+// backupclaimdbs.
+type BackupResult struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Height int32  `json:"height,omitempty"`
+}
+
+// ChainSnapshotFile is a file of a chain snapshot.  Path is relative to the
+// snapshot directory, with forward slashes, and Chunks are the hex-encoded
+// SHA-256 hashes of the consecutive chunks of the file.  A chunk doesn't span
+// files, so the last chunk of a file may be shorter than the chunk size.
+type ChainSnapshotFile struct {
+	Path   string   `json:"path"`
+	Size   int64    `json:"size"`
+	Chunks []string `json:"chunks"`
+}
+
+// ChainSnapshotManifest describes the files of a chain snapshot, which is a
+// backup of the block database and the claimtrie databases of a node.  The
+// chunks of the snapshot are numbered across its files, in order.  The hash of
+// a snapshot is the hex-encoded SHA-256 hash of its manifest marshalled to
+// JSON, which pins the hashes of all its chunks.
+type ChainSnapshotManifest struct {
+	Version         int                 `json:"version"`
+	Network         string              `json:"network"`
+	DbType          string              `json:"dbtype"`
+	Height          int32               `json:"height"`
+	BlockHash       string              `json:"blockhash"`
+	ClaimTrieHeight int32               `json:"claimtrieheight"`
+	Created         int64               `json:"created"`
+	ChunkSize       int                 `json:"chunksize"`
+	Files           []ChainSnapshotFile `json:"files"`
+}
+
+// ChainSnapshotInfo models a chain snapshot returned by the listchainsnapshots
+// command.  The snapshots being downloaded have a status of downloading, or
+// failed along with the error of the download.
+type ChainSnapshotInfo struct {
+	Name             string `json:"name"`
+	Hash             string `json:"hash"`
+	Status           string `json:"status"`
+	Network          string `json:"network,omitempty"`
+	DbType           string `json:"dbtype,omitempty"`
+	Height           int32  `json:"height,omitempty"`
+	BlockHash        string `json:"blockhash,omitempty"`
+	Created          int64  `json:"created,omitempty"`
+	Size             int64  `json:"size"`
+	Chunks           int    `json:"chunks"`
+	DownloadedChunks int    `json:"downloadedchunks,omitempty"`
+	Source           string `json:"source,omitempty"`
+	Error            string `json:"error,omitempty"`
+}
+
+// GetChainSnapshotResult models the data returned from the getchainsnapshot
+// and createchainsnapshot commands.
+type GetChainSnapshotResult struct {
+	Name     string                `json:"name"`
+	Hash     string                `json:"hash"`
+	Manifest ChainSnapshotManifest `json:"manifest"`
+}
+
+// GetChainSnapshotChunkResult models the data returned from the
+// getchainsnapshotchunk command.  Data is the base64-encoded chunk.
+type GetChainSnapshotChunkResult struct {
+	Index int    `json:"index"`
+	Hash  string `json:"hash"`
+	Data  string `json:"data"`
+}
+
+// VerifyChainSnapshotResult models the data returned from the
+// verifychainsnapshot command.  BadChunks lists the indexes of the first bad
+// chunks, and BadFiles the files which are missing or of the wrong size.
+type VerifyChainSnapshotResult struct {
+	Name         string   `json:"name"`
+	Valid        bool     `json:"valid"`
+	Chunks       int      `json:"chunks"`
+	NumBadChunks int      `json:"numbadchunks"`
+	BadChunks    []int    `json:"badchunks,omitempty"`
+	BadFiles     []string `json:"badfiles,omitempty"`
+}
+
+// CapturedProfile models a profile returned by the captureprofile command,
+// either written to Path or encoded in Data.
+type CapturedProfile struct {
+	Name string `json:"name"`
+	Size int    `json:"size"`
+	Path string `json:"path,omitempty"`
+	Data string `json:"data,omitempty"`
+}
+
+// CaptureProfileResult models the data returned from the captureprofile
+// command.
+type CaptureProfileResult struct {
+	Seconds  int               `json:"seconds"`
+	Profiles []CapturedProfile `json:"profiles"`
+}
+
+// CheckDBBadBlock models a block found inconsistent by the checkdb command.
+type CheckDBBadBlock struct {
+	Hash   string `json:"hash"`
+	File   uint32 `json:"file"`
+	Offset uint32 `json:"offset"`
+	Reason string `json:"reason"`
+}
+
+// CheckDBRepair models the repair of the block database at startup returned by
+// the checkdb command.
+type CheckDBRepair struct {
+	ConsistentFile   uint32 `json:"consistentfile"`
+	ConsistentOffset uint32 `json:"consistentoffset"`
+	DroppedBlocks    int    `json:"droppedblocks"`
+}
+
+// CheckDBResult models the data returned from the checkdb command.  BadBlocks
+// is the number of inconsistent blocks, of which at most the first 100 are
+// listed in Bad.
+type CheckDBResult struct {
+	Level            string            `json:"level"`
+	Consistent       bool              `json:"consistent"`
+	Blocks           int               `json:"blocks"`
+	WriteFile        uint32            `json:"writefile"`
+	WriteOffset      uint32            `json:"writeoffset"`
+	ConsistentFile   uint32            `json:"consistentfile"`
+	ConsistentOffset uint32            `json:"consistentoffset"`
+	BadBlocks        int               `json:"badblocks"`
+	Bad              []CheckDBBadBlock `json:"bad,omitempty"`
+	DroppedBlocks    int               `json:"droppedblocks"`
+	LastRepair       *CheckDBRepair    `json:"lastrepair,omitempty"`
+	MissingBlocks    int               `json:"missingblocks"`
+}
+
+// ExportBlocksResult models the data returned from the exportblocks command.
+type ExportBlocksResult struct {
+	Path        string `json:"path"`
+	StartHeight int32  `json:"startheight"`
+	EndHeight   int32  `json:"endheight"`
+	Blocks      int    `json:"blocks"`
+	Size        int64  `json:"size"`
+}
+
+// DBInfo models the I/O statistics of a database since it was opened, returned
+// by the getdbinfo command.
+type DBInfo struct {
+	Name         string  `json:"name"`
+	Engine       string  `json:"engine"`
+	Size         int64   `json:"size"`
+	ReadBytes    uint64  `json:"read_bytes"`
+	WriteBytes   uint64  `json:"write_bytes"`
+	Compactions  uint64  `json:"compactions"`
+	Flushes      uint64  `json:"flushes"`
+	OpenFiles    int64   `json:"open_files"`
+	CacheHits    uint64  `json:"cache_hits"`
+	CacheMisses  uint64  `json:"cache_misses"`
+	CacheHitRate float64 `json:"cache_hit_rate"`
+}
+
+// GetDBInfoResult models the data returned from the getdbinfo command.
+type GetDBInfoResult struct {
+	Databases []DBInfo `json:"databases"`
+}
+
+// DataDirSizes models the sizes on disk in bytes of the data of the node
+// returned by the getsysteminfo command.
+type DataDirSizes struct {
+	Blocks     int64 `json:"blocks"`
+	ChainState int64 `json:"chainstate"`
+	ClaimDBs   int64 `json:"claim_dbs"`
+	Indexes    int64 `json:"indexes"`
+	Total      int64 `json:"total"`
+}
+
+// DiskSpaceResult models the free space of the volume of a database returned
+// by the getsysteminfo command.  The status is ok, low or full, in which case
+// blocks are not accepted.
+type DiskSpaceResult struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Free   uint64 `json:"free"`
+	Total  uint64 `json:"total"`
+	Status string `json:"status"`
+}
+
+// GetSystemInfoResult models the data returned from the getsysteminfo
+// command.
+type GetSystemInfoResult struct {
+	Version     string            `json:"version"`
+	GoVersion   string            `json:"goversion"`
+	OS          string            `json:"os"`
+	Arch        string            `json:"arch"`
+	BuildTags   []string          `json:"buildtags"`
+	Network     string            `json:"network"`
+	DataDir     string            `json:"datadir"`
+	BlocksDir   string            `json:"blocksdir,omitempty"`
+	DataDirSize DataDirSizes      `json:"datadirsize"`
+	DiskSpace   []DiskSpaceResult `json:"diskspace"`
+}
+
+// LatencyStats models the percentiles of the latencies of an operation
+// returned by the getlatencystats command.  The latencies are in microseconds.
+type LatencyStats struct {
+	Samples int   `json:"samples"`
+	P50     int64 `json:"p50"`
+	P90     int64 `json:"p90"`
+	P99     int64 `json:"p99"`
+	Max     int64 `json:"max"`
+}
+
+// RPCMethodLatency models the latencies of an RPC method returned by the
+// getlatencystats command.
+type RPCMethodLatency struct {
+	Method  string       `json:"method"`
+	Calls   uint64       `json:"calls"`
+	Errors  uint64       `json:"errors"`
+	Latency LatencyStats `json:"latency"`
+}
+
+// BlockTimings models the breakdown of the time spent connecting a block
+// returned by the getlatencystats command.  The times are in microseconds.
+type BlockTimings struct {
+	Hash      string `json:"hash"`
+	Height    int32  `json:"height"`
+	Time      int64  `json:"time"`
+	Total     int64  `json:"total"`
+	Validate  int64  `json:"validate"`
+	Scripts   int64  `json:"scripts"`
+	ClaimTrie int64  `json:"claimtrie"`
+	DB        int64  `json:"db"`
+}
+
+// BlockLatency models the latencies of the connection of the recent blocks
+// returned by the getlatencystats command.
+type BlockLatency struct {
+	Total     LatencyStats   `json:"total"`
+	Validate  LatencyStats   `json:"validate"`
+	Scripts   LatencyStats   `json:"scripts"`
+	ClaimTrie LatencyStats   `json:"claimtrie"`
+	DB        LatencyStats   `json:"db"`
+	Slowest   []BlockTimings `json:"slowest"`
+}
+
+// GetLatencyStatsResult models the data returned from the getlatencystats
+// command.
+type GetLatencyStatsResult struct {
+	RPC    []RPCMethodLatency `json:"rpc"`
+	Blocks BlockLatency       `json:"blocks"`
+}
+
+// MemoryInfoRuntime models the memory statistics of the Go runtime returned by
+// the getmemoryinfo command.
+type MemoryInfoRuntime struct {
+	Alloc        uint64 `json:"alloc"`
+	TotalAlloc   uint64 `json:"total_alloc"`
+	Sys          uint64 `json:"sys"`
+	HeapInuse    uint64 `json:"heap_inuse"`
+	HeapIdle     uint64 `json:"heap_idle"`
+	HeapReleased uint64 `json:"heap_released"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	StackInuse   uint64 `json:"stack_inuse"`
+	NumGC        uint32 `json:"num_gc"`
+	LastGC       int64  `json:"last_gc"`
+	PauseTotal   int64  `json:"pause_total_ns"`
+	Goroutines   int    `json:"goroutines"`
+}
+
+// MemoryInfoProcess models the resource usage of the process returned by the
+// getmemoryinfo command.
+type MemoryInfoProcess struct {
+	RSS       uint64 `json:"rss"`
+	VMS       uint64 `json:"vms"`
+	OpenFiles int32  `json:"open_files"`
+}
+
+// MemoryInfoCaches models the sizes of the caches returned by the
+// getmemoryinfo command.
+type MemoryInfoCaches struct {
+	SigCacheEntries    int    `json:"sigcache_entries"`
+	SigCacheMaxEntries uint   `json:"sigcache_max_entries"`
+	SigCacheHits       uint64 `json:"sigcache_hits"`
+	SigCacheMisses     uint64 `json:"sigcache_misses"`
+	HashCacheEntries   int    `json:"hashcache_entries"`
+	DBCacheBytes       uint64 `json:"dbcache_bytes"`
+	DBCacheMaxBytes    uint64 `json:"dbcache_max_bytes"`
+	ClaimTrieNodes     int    `json:"claimtrie_nodes"`
+}
+
+// GetMemoryInfoResult models the data returned from the getmemoryinfo
+// command.
+type GetMemoryInfoResult struct {
+	Runtime MemoryInfoRuntime `json:"runtime"`
+	Process MemoryInfoProcess `json:"process"`
+	Caches  MemoryInfoCaches  `json:"caches"`
+}
+
+// GetRPCInfoResult models the data returned from the getrpcinfo command.
+type GetRPCInfoResult struct {
+	ActiveCommands   []RPCActiveCommand   `json:"active_commands"`
+	WebsocketClients []RPCWebsocketClient `json:"websocket_clients"`
+	LogPath          string               `json:"logpath"`
+}
+
 // NetworksResult models the networks data from the getnetworkinfo command.
 type NetworksResult struct {
 	Name                      string `json:"name"`
@@ -385,6 +839,45 @@ type GetNetworkInfoResult struct {
 	Warnings        string                 `json:"warnings"`
 }
 
+// AddrManPeers models the contents of the address manager returned by the
+// exportaddrman command and accepted by the importaddrman command, in the
+// format of peers.json.
+type AddrManPeers struct {
+	Version   int              `json:"version"`
+	Addresses []AddrManAddress `json:"addresses"`
+}
+
+// AddrManAddress models a known address of the address manager.
+type AddrManAddress struct {
+	Addr        string `json:"addr"`
+	Services    uint64 `json:"services"`
+	Src         string `json:"src"`
+	SrcServices uint64 `json:"srcservices"`
+	TimeStamp   int64  `json:"timestamp"`
+	Attempts    int    `json:"attempts"`
+	LastAttempt int64  `json:"lastattempt"`
+	LastSuccess int64  `json:"lastsuccess"`
+	Tried       bool   `json:"tried"`
+	Buckets     []int  `json:"buckets"`
+}
+
+// AddrManCounts models the number of addresses of a network in the tables
+// of the address manager.
+type AddrManCounts struct {
+	New   int `json:"new"`
+	Tried int `json:"tried"`
+	Total int `json:"total"`
+}
+
+// GetAddrManInfoResult models the data returned from the getaddrmaninfo
+// command.
+type GetAddrManInfoResult struct {
+	IPv4        AddrManCounts `json:"ipv4"`
+	IPv6        AddrManCounts `json:"ipv6"`
+	Onion       AddrManCounts `json:"onion"`
+	AllNetworks AddrManCounts `json:"all_networks"`
+}
+
 // GetNodeAddressesResult models the data returned from the getnodeaddresses
 // command.
 type GetNodeAddressesResult struct {
@@ -413,6 +906,7 @@ type GetPeerInfoResult struct {
 	Version        uint32  `json:"version"`
 	SubVer         string  `json:"subver"`
 	Inbound        bool    `json:"inbound"`
+	BlockRelayOnly bool    `json:"blockrelayonly,omitempty"`
 	StartingHeight int32   `json:"startingheight"`
 	CurrentHeight  int32   `json:"currentheight,omitempty"`
 	BanScore       int32   `json:"banscore"`
@@ -457,6 +951,42 @@ type GetTxOutResult struct {
 	Coinbase      bool               `json:"coinbase"`
 }
 
+// VersionBitsDeployment models a rule change deployment returned by the
+// getversionbits and setversionbits commands.
+type VersionBitsDeployment struct {
+	Name      string `json:"name"`
+	Bit       uint8  `json:"bit"`
+	Status    string `json:"status"`
+	Signal    string `json:"signal"`
+	Signaling bool   `json:"signaling"`
+}
+
+// GetVerifyChainInfoResult models the data returned from the
+// getverifychaininfo command.  It describes the running verification of the
+// chain, or the last one when none is running.
+type GetVerifyChainInfoResult struct {
+	Status         string  `json:"status"`
+	CheckLevel     int32   `json:"checklevel"`
+	NumBlocks      int32   `json:"numblocks"`
+	BestHeight     int32   `json:"bestheight"`
+	Height         int32   `json:"height"`
+	Blocks         int     `json:"blocks"`
+	TotalBlocks    int     `json:"totalblocks"`
+	ReplayedBlocks int     `json:"replayedblocks"`
+	ClaimNames     int     `json:"claimnames"`
+	Progress       float64 `json:"progress"`
+	StartTime      int64   `json:"starttime"`
+	Elapsed        float64 `json:"elapsed"`
+	Error          string  `json:"error,omitempty"`
+}
+
+// GetVersionBitsResult models the data from the getversionbits and
+// setversionbits commands.
+type GetVersionBitsResult struct {
+	Version     int32                   `json:"version"`
+	Deployments []VersionBitsDeployment `json:"deployments"`
+}
+
 // GetTxOutSetInfoResult models the data from the gettxoutsetinfo command.
 type GetTxOutSetInfoResult struct {
 	Height         int64          `json:"height"`
@@ -781,16 +1311,32 @@ type TxRawDecodeResult struct {
 // ValidateAddressChainResult models the data returned by the chain server
 // validateaddress command.
 //
-// Compared to the Bitcoin Core version, this struct lacks the scriptPubKey
-// field since it requires wallet access, which is outside the scope of btcd.
+// The scriptPubKey is the output script paying to the address, which the claim
+// scripts held by the address embed after their claim prefix.  The claims are
+// only returned when they are requested.
 // Ref: https://bitcoincore.org/en/doc/0.20.0/rpc/util/validateaddress/
 type ValidateAddressChainResult struct {
-	IsValid        bool    `json:"isvalid"`
-	Address        string  `json:"address,omitempty"`
-	IsScript       *bool   `json:"isscript,omitempty"`
-	IsWitness      *bool   `json:"iswitness,omitempty"`
-	WitnessVersion *int32  `json:"witness_version,omitempty"`
-	WitnessProgram *string `json:"witness_program,omitempty"`
+	IsValid         bool                 `json:"isvalid"`
+	Address         string               `json:"address,omitempty"`
+	ScriptPubKey    string               `json:"scriptPubKey,omitempty"`
+	IsScript        *bool                `json:"isscript,omitempty"`
+	IsWitness       *bool                `json:"iswitness,omitempty"`
+	WitnessVersion  *int32               `json:"witness_version,omitempty"`
+	WitnessProgram  *string              `json:"witness_program,omitempty"`
+	HasActiveClaims *bool                `json:"hasactiveclaims,omitempty"`
+	Claims          []AddressClaimResult `json:"claims,omitempty"`
+}
+
+// AddressClaimResult models a claim or a support held by an address, as
+// returned by the validateaddress command.
+type AddressClaimResult struct {
+	Name      string `json:"name"`
+	ClaimID   string `json:"claimid"`
+	TXID      string `json:"txid"`
+	N         uint32 `json:"n"`
+	Amount    int64  `json:"amount"`
+	IsSupport bool   `json:"issupport"`
+	IsActive  bool   `json:"isactive"`
 }
 
 // EstimateSmartFeeResult models the data returned buy the chain server
@@ -23,13 +23,22 @@ func NewAuthenticateCmd(username, passphrase string) *AuthenticateCmd {
 	}
 }
 
-// NotifyBlocksCmd defines the notifyblocks JSON-RPC command.
-type NotifyBlocksCmd struct{}
+// NotifyBlocksCmd defines the notifyblocks JSON-RPC command.  RescanFrom is
+// the height or hash of the last block seen by the client, after which the
+// notifications are replayed before live delivery resumes.
+type NotifyBlocksCmd struct {
+	RescanFrom *string
+}
 
 // NewNotifyBlocksCmd returns a new instance which can be used to issue a
 // notifyblocks JSON-RPC command.
-func NewNotifyBlocksCmd() *NotifyBlocksCmd {
-	return &NotifyBlocksCmd{}
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewNotifyBlocksCmd(rescanFrom *string) *NotifyBlocksCmd {
+	return &NotifyBlocksCmd{
+		RescanFrom: rescanFrom,
+	}
 }
 
 // StopNotifyBlocksCmd defines the stopnotifyblocks JSON-RPC command.
@@ -57,6 +66,24 @@ func NewNotifyNewTransactionsCmd(verbose *bool) *NotifyNewTransactionsCmd {
 	}
 }
 
+// NotifyWorkCmd defines the notifywork JSON-RPC command.
+type NotifyWorkCmd struct {
+	Deltas *bool `jsonrpcdefault:"false"`
+}
+
+// NewNotifyWorkCmd returns a new instance which can be used to issue a
+// notifywork JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+//
+// NOTE: This is an lbcd extension and requires a websocket connection.
+func NewNotifyWorkCmd(deltas *bool) *NotifyWorkCmd {
+	return &NotifyWorkCmd{
+		Deltas: deltas,
+	}
+}
+
 // SessionCmd defines the session JSON-RPC command.
 type SessionCmd struct{}
 
@@ -78,6 +105,46 @@ func NewStopNotifyNewTransactionsCmd() *StopNotifyNewTransactionsCmd {
 	return &StopNotifyNewTransactionsCmd{}
 }
 
+// NotifyClaimsCmd defines the notifyclaims JSON-RPC command.
+type NotifyClaimsCmd struct {
+	Names *[]string
+}
+
+// NewNotifyClaimsCmd returns a new instance which can be used to issue a
+// notifyclaims JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+//
+// NOTE: This is an lbcd extension and requires a websocket connection.
+func NewNotifyClaimsCmd(names *[]string) *NotifyClaimsCmd {
+	return &NotifyClaimsCmd{
+		Names: names,
+	}
+}
+
+// StopNotifyClaimsCmd defines the stopnotifyclaims JSON-RPC command.
+type StopNotifyClaimsCmd struct{}
+
+// NewStopNotifyClaimsCmd returns a new instance which can be used to issue a
+// stopnotifyclaims JSON-RPC command.
+//
+// NOTE: This is an lbcd extension and requires a websocket connection.
+func NewStopNotifyClaimsCmd() *StopNotifyClaimsCmd {
+	return &StopNotifyClaimsCmd{}
+}
+
+// StopNotifyWorkCmd defines the stopnotifywork JSON-RPC command.
+type StopNotifyWorkCmd struct{}
+
+// NewStopNotifyWorkCmd returns a new instance which can be used to issue a
+// stopnotifywork JSON-RPC command.
+//
+// NOTE: This is an lbcd extension and requires a websocket connection.
+func NewStopNotifyWorkCmd() *StopNotifyWorkCmd {
+	return &StopNotifyWorkCmd{}
+}
+
 // NotifyReceivedCmd defines the notifyreceived JSON-RPC command.
 //
 // Deprecated: Use LoadTxFilterCmd instead.
@@ -107,22 +174,34 @@ type OutPoint struct {
 //
 // NOTE: This is a btcd extension ported from github.com/decred/dcrd/dcrjson
 // and requires a websocket connection.
+//
+// The claim names and claim IDs are an lbcd extension matching the claim,
+// update and support outputs of the claims.
 type LoadTxFilterCmd struct {
-	Reload    bool
-	Addresses []string
-	OutPoints []OutPoint
+	Reload     bool
+	Addresses  []string
+	OutPoints  []OutPoint
+	ClaimNames *[]string
+	ClaimIDs   *[]string
 }
 
 // NewLoadTxFilterCmd returns a new instance which can be used to issue a
 // loadtxfilter JSON-RPC command.
 //
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+//
 // NOTE: This is a btcd extension ported from github.com/decred/dcrd/dcrjson
 // and requires a websocket connection.
-func NewLoadTxFilterCmd(reload bool, addresses []string, outPoints []OutPoint) *LoadTxFilterCmd {
+func NewLoadTxFilterCmd(reload bool, addresses []string, outPoints []OutPoint,
+	claimNames, claimIDs *[]string) *LoadTxFilterCmd {
+
 	return &LoadTxFilterCmd{
-		Reload:    reload,
-		Addresses: addresses,
-		OutPoints: outPoints,
+		Reload:     reload,
+		Addresses:  addresses,
+		OutPoints:  outPoints,
+		ClaimNames: claimNames,
+		ClaimIDs:   claimIDs,
 	}
 }
 
@@ -228,14 +307,18 @@ func init() {
 	MustRegisterCmd("authenticate", (*AuthenticateCmd)(nil), flags)
 	MustRegisterCmd("loadtxfilter", (*LoadTxFilterCmd)(nil), flags)
 	MustRegisterCmd("notifyblocks", (*NotifyBlocksCmd)(nil), flags)
+	MustRegisterCmd("notifyclaims", (*NotifyClaimsCmd)(nil), flags)
 	MustRegisterCmd("notifynewtransactions", (*NotifyNewTransactionsCmd)(nil), flags)
 	MustRegisterCmd("notifyreceived", (*NotifyReceivedCmd)(nil), flags)
 	MustRegisterCmd("notifyspent", (*NotifySpentCmd)(nil), flags)
+	MustRegisterCmd("notifywork", (*NotifyWorkCmd)(nil), flags)
 	MustRegisterCmd("session", (*SessionCmd)(nil), flags)
 	MustRegisterCmd("stopnotifyblocks", (*StopNotifyBlocksCmd)(nil), flags)
+	MustRegisterCmd("stopnotifyclaims", (*StopNotifyClaimsCmd)(nil), flags)
 	MustRegisterCmd("stopnotifynewtransactions", (*StopNotifyNewTransactionsCmd)(nil), flags)
 	MustRegisterCmd("stopnotifyspent", (*StopNotifySpentCmd)(nil), flags)
 	MustRegisterCmd("stopnotifyreceived", (*StopNotifyReceivedCmd)(nil), flags)
+	MustRegisterCmd("stopnotifywork", (*StopNotifyWorkCmd)(nil), flags)
 	MustRegisterCmd("rescan", (*RescanCmd)(nil), flags)
 	MustRegisterCmd("rescanblocks", (*RescanBlocksCmd)(nil), flags)
 }
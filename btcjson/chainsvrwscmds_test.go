@@ -47,11 +47,24 @@ func TestChainSvrWsCmds(t *testing.T) {
 				return btcjson.NewCmd("notifyblocks")
 			},
 			staticCmd: func() interface{} {
-				return btcjson.NewNotifyBlocksCmd()
+				return btcjson.NewNotifyBlocksCmd(nil)
 			},
 			marshalled:   `{"jsonrpc":"1.0","method":"notifyblocks","params":[],"id":1}`,
 			unmarshalled: &btcjson.NotifyBlocksCmd{},
 		},
+		{
+			name: "notifyblocks rescanfrom",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("notifyblocks", "123")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewNotifyBlocksCmd(btcjson.String("123"))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"notifyblocks","params":["123"],"id":1}`,
+			unmarshalled: &btcjson.NotifyBlocksCmd{
+				RescanFrom: btcjson.String("123"),
+			},
+		},
 		{
 			name: "stopnotifyblocks",
 			newCmd: func() (interface{}, error) {
@@ -154,6 +167,78 @@ func TestChainSvrWsCmds(t *testing.T) {
 				OutPoints: []btcjson.OutPoint{{Hash: "123", Index: 0}},
 			},
 		},
+		{
+			name: "notifywork",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("notifywork")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewNotifyWorkCmd(nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"notifywork","params":[],"id":1}`,
+			unmarshalled: &btcjson.NotifyWorkCmd{
+				Deltas: btcjson.Bool(false),
+			},
+		},
+		{
+			name: "notifywork deltas",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("notifywork", true)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewNotifyWorkCmd(btcjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"notifywork","params":[true],"id":1}`,
+			unmarshalled: &btcjson.NotifyWorkCmd{
+				Deltas: btcjson.Bool(true),
+			},
+		},
+		{
+			name: "notifyclaims",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("notifyclaims")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewNotifyClaimsCmd(nil)
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"notifyclaims","params":[],"id":1}`,
+			unmarshalled: &btcjson.NotifyClaimsCmd{},
+		},
+		{
+			name: "notifyclaims names",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("notifyclaims", `["one","two"]`)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewNotifyClaimsCmd(&[]string{"one", "two"})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"notifyclaims","params":[["one","two"]],"id":1}`,
+			unmarshalled: &btcjson.NotifyClaimsCmd{
+				Names: &[]string{"one", "two"},
+			},
+		},
+		{
+			name: "stopnotifyclaims",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("stopnotifyclaims")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewStopNotifyClaimsCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"stopnotifyclaims","params":[],"id":1}`,
+			unmarshalled: &btcjson.StopNotifyClaimsCmd{},
+		},
+		{
+			name: "stopnotifywork",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("stopnotifywork")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewStopNotifyWorkCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"stopnotifywork","params":[],"id":1}`,
+			unmarshalled: &btcjson.StopNotifyWorkCmd{},
+		},
 		{
 			name: "rescan",
 			newCmd: func() (interface{}, error) {
@@ -204,7 +289,7 @@ func TestChainSvrWsCmds(t *testing.T) {
 					Hash:  "0000000000000000000000000000000000000000000000000000000000000123",
 					Index: 0,
 				}}
-				return btcjson.NewLoadTxFilterCmd(false, addrs, ops)
+				return btcjson.NewLoadTxFilterCmd(false, addrs, ops, nil, nil)
 			},
 			marshalled: `{"jsonrpc":"1.0","method":"loadtxfilter","params":[false,["1Address"],[{"hash":"0000000000000000000000000000000000000000000000000000000000000123","index":0}]],"id":1}`,
 			unmarshalled: &btcjson.LoadTxFilterCmd{
@@ -213,6 +298,25 @@ func TestChainSvrWsCmds(t *testing.T) {
 				OutPoints: []btcjson.OutPoint{{Hash: "0000000000000000000000000000000000000000000000000000000000000123", Index: 0}},
 			},
 		},
+		{
+			name: "loadtxfilter claims",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("loadtxfilter", true, `[]`, `[]`, `["hello"]`, `["0123456789abcdef0123456789abcdef01234567"]`)
+			},
+			staticCmd: func() interface{} {
+				names := []string{"hello"}
+				ids := []string{"0123456789abcdef0123456789abcdef01234567"}
+				return btcjson.NewLoadTxFilterCmd(true, []string{}, []btcjson.OutPoint{}, &names, &ids)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"loadtxfilter","params":[true,[],[],["hello"],["0123456789abcdef0123456789abcdef01234567"]],"id":1}`,
+			unmarshalled: &btcjson.LoadTxFilterCmd{
+				Reload:     true,
+				Addresses:  []string{},
+				OutPoints:  []btcjson.OutPoint{},
+				ClaimNames: &[]string{"hello"},
+				ClaimIDs:   &[]string{"0123456789abcdef0123456789abcdef01234567"},
+			},
+		},
 		{
 			name: "rescanblocks",
 			newCmd: func() (interface{}, error) {
@@ -31,6 +31,10 @@ const (
 	// disconnected.
 	FilteredBlockDisconnectedNtfnMethod = "filteredblockdisconnected"
 
+	// ChainReorganizedNtfnMethod is the method used for notifications from
+	// the chain server that the main chain has been reorganized.
+	ChainReorganizedNtfnMethod = "chainreorganized"
+
 	// RecvTxNtfnMethod is the legacy, deprecated method used for
 	// notifications from the chain server that a transaction which pays to
 	// a registered address has been processed.
@@ -75,6 +79,31 @@ const (
 	// from the chain server that inform a client that a transaction that
 	// matches the loaded filter was accepted by the mempool.
 	RelevantTxAcceptedNtfnMethod = "relevanttxaccepted"
+
+	// WorkNtfnMethod is the method used for notifications from the chain
+	// server that a new block template is available.
+	WorkNtfnMethod = "work"
+
+	// WorkDeltaNtfnMethod is the method used for notifications from the
+	// chain server that a new block template is available, which only
+	// carry its changes since the previous template of the same previous
+	// block.
+	WorkDeltaNtfnMethod = "workdelta"
+
+	// ClaimAddedNtfnMethod is the method used for notifications from the
+	// chain server that a claim was created by a block connected to the
+	// main chain.
+	ClaimAddedNtfnMethod = "claimadded"
+
+	// ClaimUpdatedNtfnMethod is the method used for notifications from the
+	// chain server that a claim was updated by a block connected to the
+	// main chain.
+	ClaimUpdatedNtfnMethod = "claimupdated"
+
+	// NameTakeoverNtfnMethod is the method used for notifications from the
+	// chain server that another claim took over a name in a block
+	// connected to the main chain.
+	NameTakeoverNtfnMethod = "nametakeover"
 )
 
 // BlockConnectedNtfn defines the blockconnected JSON-RPC notification.
@@ -161,6 +190,30 @@ type BlockDetails struct {
 	Time   int64  `json:"time"`
 }
 
+// ChainReorganization models a reorganization of the main chain.  Depth is
+// the number of blocks disconnected, listed from the old best chain head down,
+// while the blocks connected are listed from the fork point up.
+type ChainReorganization struct {
+	ForkHash     string   `json:"forkhash"`
+	ForkHeight   int32    `json:"forkheight"`
+	Depth        int32    `json:"depth"`
+	Disconnected []string `json:"disconnected"`
+	Connected    []string `json:"connected"`
+}
+
+// ChainReorganizedNtfn defines the chainreorganized JSON-RPC notification.
+type ChainReorganizedNtfn struct {
+	Reorganization ChainReorganization
+}
+
+// NewChainReorganizedNtfn returns a new instance which can be used to issue a
+// chainreorganized JSON-RPC notification.
+func NewChainReorganizedNtfn(reorg ChainReorganization) *ChainReorganizedNtfn {
+	return &ChainReorganizedNtfn{
+		Reorganization: reorg,
+	}
+}
+
 // RecvTxNtfn defines the recvtx JSON-RPC notification.
 //
 // Deprecated: Use RelevantTxAcceptedNtfn and FilteredBlockConnectedNtfn
@@ -285,6 +338,115 @@ func NewRelevantTxAcceptedNtfn(txHex string) *RelevantTxAcceptedNtfn {
 	return &RelevantTxAcceptedNtfn{Transaction: txHex}
 }
 
+// WorkNtfn defines the work JSON-RPC notification.
+type WorkNtfn struct {
+	Template GetBlockTemplateResult
+}
+
+// NewWorkNtfn returns a new instance which can be used to issue a work
+// JSON-RPC notification.
+func NewWorkNtfn(template GetBlockTemplateResult) *WorkNtfn {
+	return &WorkNtfn{
+		Template: template,
+	}
+}
+
+// WorkDelta models the changes of a block template since the previous
+// template of the same previous block.  The transactions of the template are
+// listed in order by TxIDs, with only those missing from the previous template
+// fully described in Added.
+type WorkDelta struct {
+	PreviousHash             string                     `json:"previousblockhash"`
+	LongPollID               string                     `json:"longpollid"`
+	CurTime                  int64                      `json:"curtime"`
+	MaxTime                  int64                      `json:"maxtime"`
+	CoinbaseValue            *int64                     `json:"coinbasevalue,omitempty"`
+	ClaimTrieHash            string                     `json:"claimtrie"`
+	DefaultWitnessCommitment string                     `json:"default_witness_commitment,omitempty"`
+	TxIDs                    []string                   `json:"txids"`
+	Added                    []GetBlockTemplateResultTx `json:"added"`
+	Removed                  []string                   `json:"removed"`
+}
+
+// WorkDeltaNtfn defines the workdelta JSON-RPC notification.
+type WorkDeltaNtfn struct {
+	Delta WorkDelta
+}
+
+// NewWorkDeltaNtfn returns a new instance which can be used to issue a
+// workdelta JSON-RPC notification.
+func NewWorkDeltaNtfn(delta WorkDelta) *WorkDeltaNtfn {
+	return &WorkDeltaNtfn{
+		Delta: delta,
+	}
+}
+
+// ClaimNtfnData models a claim created or updated by a block, as notified by
+// claimadded and claimupdated notifications.  Value is the hex-encoded value of
+// the claim, and Address the address of its output when it has one.
+type ClaimNtfnData struct {
+	Name           string `json:"name"`
+	NormalizedName string `json:"normalizedname"`
+	ClaimID        string `json:"claimid"`
+	TXID           string `json:"txid"`
+	N              uint32 `json:"n"`
+	Amount         int64  `json:"amount"`
+	Value          string `json:"value"`
+	Address        string `json:"address,omitempty"`
+	Hash           string `json:"hash"`
+	Height         int32  `json:"height"`
+}
+
+// ClaimAddedNtfn defines the claimadded JSON-RPC notification.
+type ClaimAddedNtfn struct {
+	Claim ClaimNtfnData
+}
+
+// NewClaimAddedNtfn returns a new instance which can be used to issue a
+// claimadded JSON-RPC notification.
+func NewClaimAddedNtfn(claim ClaimNtfnData) *ClaimAddedNtfn {
+	return &ClaimAddedNtfn{
+		Claim: claim,
+	}
+}
+
+// ClaimUpdatedNtfn defines the claimupdated JSON-RPC notification.
+type ClaimUpdatedNtfn struct {
+	Claim ClaimNtfnData
+}
+
+// NewClaimUpdatedNtfn returns a new instance which can be used to issue a
+// claimupdated JSON-RPC notification.
+func NewClaimUpdatedNtfn(claim ClaimNtfnData) *ClaimUpdatedNtfn {
+	return &ClaimUpdatedNtfn{
+		Claim: claim,
+	}
+}
+
+// NameTakeover models a takeover of a name by a block, as notified by
+// nametakeover notifications.  Claim is the claim now owning the name, and
+// PreviousClaimID the ID of the claim which owned it before, if any.
+type NameTakeover struct {
+	NormalizedName  string      `json:"normalizedname"`
+	PreviousClaimID string      `json:"previousclaimid,omitempty"`
+	Hash            string      `json:"hash"`
+	Height          int32       `json:"height"`
+	Claim           ClaimResult `json:"claim"`
+}
+
+// NameTakeoverNtfn defines the nametakeover JSON-RPC notification.
+type NameTakeoverNtfn struct {
+	Takeover NameTakeover
+}
+
+// NewNameTakeoverNtfn returns a new instance which can be used to issue a
+// nametakeover JSON-RPC notification.
+func NewNameTakeoverNtfn(takeover NameTakeover) *NameTakeoverNtfn {
+	return &NameTakeoverNtfn{
+		Takeover: takeover,
+	}
+}
+
 func init() {
 	// The commands in this file are only usable by websockets and are
 	// notifications.
@@ -294,6 +456,7 @@ func init() {
 	MustRegisterCmd(BlockDisconnectedNtfnMethod, (*BlockDisconnectedNtfn)(nil), flags)
 	MustRegisterCmd(FilteredBlockConnectedNtfnMethod, (*FilteredBlockConnectedNtfn)(nil), flags)
 	MustRegisterCmd(FilteredBlockDisconnectedNtfnMethod, (*FilteredBlockDisconnectedNtfn)(nil), flags)
+	MustRegisterCmd(ChainReorganizedNtfnMethod, (*ChainReorganizedNtfn)(nil), flags)
 	MustRegisterCmd(RecvTxNtfnMethod, (*RecvTxNtfn)(nil), flags)
 	MustRegisterCmd(RedeemingTxNtfnMethod, (*RedeemingTxNtfn)(nil), flags)
 	MustRegisterCmd(RescanFinishedNtfnMethod, (*RescanFinishedNtfn)(nil), flags)
@@ -301,4 +464,9 @@ func init() {
 	MustRegisterCmd(TxAcceptedNtfnMethod, (*TxAcceptedNtfn)(nil), flags)
 	MustRegisterCmd(TxAcceptedVerboseNtfnMethod, (*TxAcceptedVerboseNtfn)(nil), flags)
 	MustRegisterCmd(RelevantTxAcceptedNtfnMethod, (*RelevantTxAcceptedNtfn)(nil), flags)
+	MustRegisterCmd(WorkNtfnMethod, (*WorkNtfn)(nil), flags)
+	MustRegisterCmd(WorkDeltaNtfnMethod, (*WorkDeltaNtfn)(nil), flags)
+	MustRegisterCmd(ClaimAddedNtfnMethod, (*ClaimAddedNtfn)(nil), flags)
+	MustRegisterCmd(ClaimUpdatedNtfnMethod, (*ClaimUpdatedNtfn)(nil), flags)
+	MustRegisterCmd(NameTakeoverNtfnMethod, (*NameTakeoverNtfn)(nil), flags)
 }
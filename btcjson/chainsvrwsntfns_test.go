@@ -88,6 +88,32 @@ func TestChainSvrWsNtfns(t *testing.T) {
 				Header: "header",
 			},
 		},
+		{
+			name: "chainreorganized",
+			newNtfn: func() (interface{}, error) {
+				return btcjson.NewCmd("chainreorganized", `{"forkhash":"123","forkheight":100000,"depth":1,"disconnected":["456"],"connected":["789","abc"]}`)
+			},
+			staticNtfn: func() interface{} {
+				reorg := btcjson.ChainReorganization{
+					ForkHash:     "123",
+					ForkHeight:   100000,
+					Depth:        1,
+					Disconnected: []string{"456"},
+					Connected:    []string{"789", "abc"},
+				}
+				return btcjson.NewChainReorganizedNtfn(reorg)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"chainreorganized","params":[{"forkhash":"123","forkheight":100000,"depth":1,"disconnected":["456"],"connected":["789","abc"]}],"id":null}`,
+			unmarshalled: &btcjson.ChainReorganizedNtfn{
+				Reorganization: btcjson.ChainReorganization{
+					ForkHash:     "123",
+					ForkHeight:   100000,
+					Depth:        1,
+					Disconnected: []string{"456"},
+					Connected:    []string{"789", "abc"},
+				},
+			},
+		},
 		{
 			name: "recvtx",
 			newNtfn: func() (interface{}, error) {
@@ -225,6 +251,177 @@ func TestChainSvrWsNtfns(t *testing.T) {
 				Transaction: "001122",
 			},
 		},
+		{
+			name: "work",
+			newNtfn: func() (interface{}, error) {
+				return btcjson.NewCmd("work", `{"bits":"1d00ffff","curtime":1,"height":2,"previousblockhash":"123","transactions":[],"version":1,"claimtrie":"456"}`)
+			},
+			staticNtfn: func() interface{} {
+				return btcjson.NewWorkNtfn(btcjson.GetBlockTemplateResult{
+					Bits:          "1d00ffff",
+					CurTime:       1,
+					Height:        2,
+					PreviousHash:  "123",
+					Transactions:  []btcjson.GetBlockTemplateResultTx{},
+					Version:       1,
+					ClaimTrieHash: "456",
+				})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"work","params":[{"bits":"1d00ffff","curtime":1,"height":2,"previousblockhash":"123","transactions":[],"version":1,"claimtrie":"456"}],"id":null}`,
+			unmarshalled: &btcjson.WorkNtfn{
+				Template: btcjson.GetBlockTemplateResult{
+					Bits:          "1d00ffff",
+					CurTime:       1,
+					Height:        2,
+					PreviousHash:  "123",
+					Transactions:  []btcjson.GetBlockTemplateResultTx{},
+					Version:       1,
+					ClaimTrieHash: "456",
+				},
+			},
+		},
+		{
+			name: "workdelta",
+			newNtfn: func() (interface{}, error) {
+				return btcjson.NewCmd("workdelta", `{"previousblockhash":"123","longpollid":"id","curtime":1,"maxtime":2,"claimtrie":"456","txids":["a"],"added":[],"removed":["b"]}`)
+			},
+			staticNtfn: func() interface{} {
+				return btcjson.NewWorkDeltaNtfn(btcjson.WorkDelta{
+					PreviousHash:  "123",
+					LongPollID:    "id",
+					CurTime:       1,
+					MaxTime:       2,
+					ClaimTrieHash: "456",
+					TxIDs:         []string{"a"},
+					Added:         []btcjson.GetBlockTemplateResultTx{},
+					Removed:       []string{"b"},
+				})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"workdelta","params":[{"previousblockhash":"123","longpollid":"id","curtime":1,"maxtime":2,"claimtrie":"456","txids":["a"],"added":[],"removed":["b"]}],"id":null}`,
+			unmarshalled: &btcjson.WorkDeltaNtfn{
+				Delta: btcjson.WorkDelta{
+					PreviousHash:  "123",
+					LongPollID:    "id",
+					CurTime:       1,
+					MaxTime:       2,
+					ClaimTrieHash: "456",
+					TxIDs:         []string{"a"},
+					Added:         []btcjson.GetBlockTemplateResultTx{},
+					Removed:       []string{"b"},
+				},
+			},
+		},
+		{
+			name: "claimadded",
+			newNtfn: func() (interface{}, error) {
+				return btcjson.NewCmd("claimadded", `{"name":"One","normalizedname":"one","claimid":"abc","txid":"123","n":1,"amount":100,"value":"00","address":"bAddress","hash":"456","height":7}`)
+			},
+			staticNtfn: func() interface{} {
+				return btcjson.NewClaimAddedNtfn(btcjson.ClaimNtfnData{
+					Name:           "One",
+					NormalizedName: "one",
+					ClaimID:        "abc",
+					TXID:           "123",
+					N:              1,
+					Amount:         100,
+					Value:          "00",
+					Address:        "bAddress",
+					Hash:           "456",
+					Height:         7,
+				})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"claimadded","params":[{"name":"One","normalizedname":"one","claimid":"abc","txid":"123","n":1,"amount":100,"value":"00","address":"bAddress","hash":"456","height":7}],"id":null}`,
+			unmarshalled: &btcjson.ClaimAddedNtfn{
+				Claim: btcjson.ClaimNtfnData{
+					Name:           "One",
+					NormalizedName: "one",
+					ClaimID:        "abc",
+					TXID:           "123",
+					N:              1,
+					Amount:         100,
+					Value:          "00",
+					Address:        "bAddress",
+					Hash:           "456",
+					Height:         7,
+				},
+			},
+		},
+		{
+			name: "claimupdated",
+			newNtfn: func() (interface{}, error) {
+				return btcjson.NewCmd("claimupdated", `{"name":"One","normalizedname":"one","claimid":"abc","txid":"123","n":1,"amount":100,"value":"00","address":"bAddress","hash":"456","height":7}`)
+			},
+			staticNtfn: func() interface{} {
+				return btcjson.NewClaimUpdatedNtfn(btcjson.ClaimNtfnData{
+					Name:           "One",
+					NormalizedName: "one",
+					ClaimID:        "abc",
+					TXID:           "123",
+					N:              1,
+					Amount:         100,
+					Value:          "00",
+					Address:        "bAddress",
+					Hash:           "456",
+					Height:         7,
+				})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"claimupdated","params":[{"name":"One","normalizedname":"one","claimid":"abc","txid":"123","n":1,"amount":100,"value":"00","address":"bAddress","hash":"456","height":7}],"id":null}`,
+			unmarshalled: &btcjson.ClaimUpdatedNtfn{
+				Claim: btcjson.ClaimNtfnData{
+					Name:           "One",
+					NormalizedName: "one",
+					ClaimID:        "abc",
+					TXID:           "123",
+					N:              1,
+					Amount:         100,
+					Value:          "00",
+					Address:        "bAddress",
+					Hash:           "456",
+					Height:         7,
+				},
+			},
+		},
+		{
+			name: "nametakeover",
+			newNtfn: func() (interface{}, error) {
+				return btcjson.NewCmd("nametakeover", `{"normalizedname":"one","previousclaimid":"def","hash":"456","height":7,"claim":{"claimid":"abc","txid":"123","n":1,"bid":0,"sequence":0,"height":7,"validatheight":7,"amount":100,"effectiveamount":150}}`)
+			},
+			staticNtfn: func() interface{} {
+				return btcjson.NewNameTakeoverNtfn(btcjson.NameTakeover{
+					NormalizedName:  "one",
+					PreviousClaimID: "def",
+					Hash:            "456",
+					Height:          7,
+					Claim: btcjson.ClaimResult{
+						ClaimID:         "abc",
+						TXID:            "123",
+						N:               1,
+						Height:          7,
+						ValidAtHeight:   7,
+						Amount:          100,
+						EffectiveAmount: 150,
+					},
+				})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"nametakeover","params":[{"normalizedname":"one","previousclaimid":"def","hash":"456","height":7,"claim":{"claimid":"abc","txid":"123","n":1,"bid":0,"sequence":0,"height":7,"validatheight":7,"amount":100,"effectiveamount":150}}],"id":null}`,
+			unmarshalled: &btcjson.NameTakeoverNtfn{
+				Takeover: btcjson.NameTakeover{
+					NormalizedName:  "one",
+					PreviousClaimID: "def",
+					Hash:            "456",
+					Height:          7,
+					Claim: btcjson.ClaimResult{
+						ClaimID:         "abc",
+						TXID:            "123",
+						N:               1,
+						Height:          7,
+						ValidAtHeight:   7,
+						Amount:          100,
+						EffectiveAmount: 150,
+					},
+				},
+			},
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
@@ -9,7 +9,10 @@ func init() {
 	MustRegisterCmd("getclaimsfornamebyid", (*GetClaimsForNameByIDCmd)(nil), flags)
 	MustRegisterCmd("getclaimsfornamebybid", (*GetClaimsForNameByBidCmd)(nil), flags)
 	MustRegisterCmd("getclaimsfornamebyseq", (*GetClaimsForNameBySeqCmd)(nil), flags)
+	MustRegisterCmd("getnameproof", (*GetNameProofCmd)(nil), flags)
+	MustRegisterCmd("getvalueforname", (*GetValueForNameCmd)(nil), flags)
 	MustRegisterCmd("normalize", (*GetNormalizedCmd)(nil), flags)
+	MustRegisterCmd("simulateclaimsupport", (*SimulateClaimSupportCmd)(nil), flags)
 }
 
 // optional inputs are required to be pointers, but they support things like `jsonrpcdefault:"false"`
@@ -21,6 +24,14 @@ type GetChangesInBlockCmd struct {
 	HashOrHeight *string `json:"hashorheight" jsonrpcdefault:""`
 }
 
+// NewGetChangesInBlockCmd returns a new instance which can be used to issue a
+// getchangesinblock JSON-RPC command.  A nil hashOrHeight is the tip.
+func NewGetChangesInBlockCmd(hashOrHeight *string) *GetChangesInBlockCmd {
+	return &GetChangesInBlockCmd{
+		HashOrHeight: hashOrHeight,
+	}
+}
+
 type GetChangesInBlockResult struct {
 	Hash   string   `json:"hash"`
 	Height int32    `json:"height"`
@@ -33,6 +44,16 @@ type GetClaimsForNameCmd struct {
 	IncludeValues *bool   `json:"includevalues" jsonrpcdefault:"false"`
 }
 
+// NewGetClaimsForNameCmd returns a new instance which can be used to issue a
+// getclaimsforname JSON-RPC command.
+func NewGetClaimsForNameCmd(name string, hashOrHeight *string, includeValues *bool) *GetClaimsForNameCmd {
+	return &GetClaimsForNameCmd{
+		Name:          name,
+		HashOrHeight:  hashOrHeight,
+		IncludeValues: includeValues,
+	}
+}
+
 type GetClaimsForNameByIDCmd struct {
 	Name            string   `json:"name"`
 	PartialClaimIDs []string `json:"partialclaimids"`
@@ -40,6 +61,17 @@ type GetClaimsForNameByIDCmd struct {
 	IncludeValues   *bool    `json:"includevalues" jsonrpcdefault:"false"`
 }
 
+// NewGetClaimsForNameByIDCmd returns a new instance which can be used to issue
+// a getclaimsfornamebyid JSON-RPC command.
+func NewGetClaimsForNameByIDCmd(name string, partialClaimIDs []string, hashOrHeight *string, includeValues *bool) *GetClaimsForNameByIDCmd {
+	return &GetClaimsForNameByIDCmd{
+		Name:            name,
+		PartialClaimIDs: partialClaimIDs,
+		HashOrHeight:    hashOrHeight,
+		IncludeValues:   includeValues,
+	}
+}
+
 type GetClaimsForNameByBidCmd struct {
 	Name          string  `json:"name"`
 	Bids          []int32 `json:"bids"`
@@ -47,6 +79,17 @@ type GetClaimsForNameByBidCmd struct {
 	IncludeValues *bool   `json:"includevalues" jsonrpcdefault:"false"`
 }
 
+// NewGetClaimsForNameByBidCmd returns a new instance which can be used to
+// issue a getclaimsfornamebybid JSON-RPC command.
+func NewGetClaimsForNameByBidCmd(name string, bids []int32, hashOrHeight *string, includeValues *bool) *GetClaimsForNameByBidCmd {
+	return &GetClaimsForNameByBidCmd{
+		Name:          name,
+		Bids:          bids,
+		HashOrHeight:  hashOrHeight,
+		IncludeValues: includeValues,
+	}
+}
+
 type GetClaimsForNameBySeqCmd struct {
 	Name          string  `json:"name"`
 	Sequences     []int32 `json:"sequences" jsonrpcusage:"[sequence,...]"`
@@ -54,6 +97,17 @@ type GetClaimsForNameBySeqCmd struct {
 	IncludeValues *bool   `json:"includevalues" jsonrpcdefault:"false"`
 }
 
+// NewGetClaimsForNameBySeqCmd returns a new instance which can be used to
+// issue a getclaimsfornamebyseq JSON-RPC command.
+func NewGetClaimsForNameBySeqCmd(name string, sequences []int32, hashOrHeight *string, includeValues *bool) *GetClaimsForNameBySeqCmd {
+	return &GetClaimsForNameBySeqCmd{
+		Name:          name,
+		Sequences:     sequences,
+		HashOrHeight:  hashOrHeight,
+		IncludeValues: includeValues,
+	}
+}
+
 type GetClaimsForNameResult struct {
 	Hash               string        `json:"hash"`
 	Height             int32         `json:"height"`
@@ -92,6 +146,108 @@ type GetNormalizedCmd struct {
 	Name string `json:"name"`
 }
 
+// NewGetNormalizedCmd returns a new instance which can be used to issue a
+// normalize JSON-RPC command.
+func NewGetNormalizedCmd(name string) *GetNormalizedCmd {
+	return &GetNormalizedCmd{
+		Name: name,
+	}
+}
+
 type GetNormalizedResult struct {
 	NormalizedName string `json:"normalizedname"`
 }
+
+// GetValueForNameCmd looks up the claim owning a name, along with its value
+// unless includevalues is false.
+type GetValueForNameCmd struct {
+	Name          string  `json:"name"`
+	HashOrHeight  *string `json:"hashorheight" jsonrpcdefault:""`
+	IncludeValues *bool   `json:"includevalues" jsonrpcdefault:"true"`
+}
+
+// NewGetValueForNameCmd returns a new instance which can be used to issue a
+// getvalueforname JSON-RPC command.
+func NewGetValueForNameCmd(name string, hashOrHeight *string, includeValues *bool) *GetValueForNameCmd {
+	return &GetValueForNameCmd{
+		Name:          name,
+		HashOrHeight:  hashOrHeight,
+		IncludeValues: includeValues,
+	}
+}
+
+type GetValueForNameResult struct {
+	Hash               string      `json:"hash"`
+	Height             int32       `json:"height"`
+	LastTakeoverHeight int32       `json:"lasttakeoverheight"`
+	NormalizedName     string      `json:"normalizedname"`
+	Claim              ClaimResult `json:"claim"`
+}
+
+// GetNameProofCmd looks up the merkle proof of the claims of a name against
+// the claimtrie hash of the tip.
+type GetNameProofCmd struct {
+	Name string `json:"name"`
+}
+
+// NewGetNameProofCmd returns a new instance which can be used to issue a
+// getnameproof JSON-RPC command.
+func NewGetNameProofCmd(name string) *GetNameProofCmd {
+	return &GetNameProofCmd{
+		Name: name,
+	}
+}
+
+// GetNameProofResult is the merkle proof of the claims hash of a name.
+// Applying the pairs in order to the claims hash yields the claimtrie hash: an
+// odd pair is the left branch, and the others the right.
+type GetNameProofResult struct {
+	NormalizedName string            `json:"normalizedname"`
+	Hash           string            `json:"blockhash"`
+	Height         int32             `json:"height"`
+	ClaimTrie      string            `json:"claimtrie"`
+	ClaimsHash     string            `json:"claimshash"`
+	Pairs          []ProofPairResult `json:"pairs"`
+}
+
+type ProofPairResult struct {
+	Odd  bool   `json:"odd"`
+	Hash string `json:"hash"`
+}
+
+// SimulateClaimSupportCmd simulates a support of amount dewies for a claim of a
+// name, to find out whether and when it would make the claim the best claim.
+type SimulateClaimSupportCmd struct {
+	Name    string `json:"name"`
+	ClaimID string `json:"claimid"`
+	Amount  int64  `json:"amount"`
+}
+
+// NewSimulateClaimSupportCmd returns a new instance which can be used to issue
+// a simulateclaimsupport JSON-RPC command.
+func NewSimulateClaimSupportCmd(name, claimID string, amount int64) *SimulateClaimSupportCmd {
+	return &SimulateClaimSupportCmd{
+		Name:    name,
+		ClaimID: claimID,
+		Amount:  amount,
+	}
+}
+
+// SimulateClaimSupportResult is the outcome of a support simulated in the block
+// after the tip, at the height the support and the claim are both active.
+type SimulateClaimSupportResult struct {
+	Hash            string `json:"hash"`
+	Height          int32  `json:"height"`
+	NormalizedName  string `json:"normalizedname"`
+	ClaimID         string `json:"claimid"`
+	Amount          int64  `json:"amount"`
+	IsBest          bool   `json:"isbest"`
+	WouldBeBest     bool   `json:"wouldbebest"`
+	SupportHeight   int32  `json:"supportheight"`
+	ValidAtHeight   int32  `json:"validatheight"`
+	TakeoverHeight  int32  `json:"takeoverheight,omitempty"`
+	EffectiveAmount int64  `json:"effectiveamount"`
+	BestClaimID     string `json:"bestclaimid,omitempty"`
+	BestAmount      int64  `json:"bestamount"`
+	MinAmount       int64  `json:"minamount"`
+}
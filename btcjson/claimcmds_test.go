@@ -0,0 +1,174 @@
+package btcjson_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimCmds(t *testing.T) {
+
+	r := require.New(t)
+
+	tests := []struct {
+		name         string
+		newCmd       func() (interface{}, error)
+		staticCmd    func() interface{}
+		marshalled   string
+		unmarshalled interface{}
+	}{
+		{
+			name: "getchangesinblock",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getchangesinblock", "100")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetChangesInBlockCmd(btcjson.String("100"))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getchangesinblock","params":["100"],"id":1}`,
+			unmarshalled: &btcjson.GetChangesInBlockCmd{
+				HashOrHeight: btcjson.String("100"),
+			},
+		},
+		{
+			name: "getclaimsforname",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getclaimsforname", "@lbry")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetClaimsForNameCmd("@lbry", nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getclaimsforname","params":["@lbry"],"id":1}`,
+			unmarshalled: &btcjson.GetClaimsForNameCmd{
+				Name:          "@lbry",
+				IncludeValues: btcjson.Bool(false),
+			},
+		},
+		{
+			name: "getclaimsfornamebyid",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getclaimsfornamebyid", "@lbry",
+					[]string{"3fda"}, "100", true)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetClaimsForNameByIDCmd("@lbry",
+					[]string{"3fda"}, btcjson.String("100"), btcjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getclaimsfornamebyid","params":["@lbry",["3fda"],"100",true],"id":1}`,
+			unmarshalled: &btcjson.GetClaimsForNameByIDCmd{
+				Name:            "@lbry",
+				PartialClaimIDs: []string{"3fda"},
+				HashOrHeight:    btcjson.String("100"),
+				IncludeValues:   btcjson.Bool(true),
+			},
+		},
+		{
+			name: "getclaimsfornamebybid",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getclaimsfornamebybid", "@lbry",
+					[]int32{0, 1})
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetClaimsForNameByBidCmd("@lbry",
+					[]int32{0, 1}, nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getclaimsfornamebybid","params":["@lbry",[0,1]],"id":1}`,
+			unmarshalled: &btcjson.GetClaimsForNameByBidCmd{
+				Name:          "@lbry",
+				Bids:          []int32{0, 1},
+				IncludeValues: btcjson.Bool(false),
+			},
+		},
+		{
+			name: "getclaimsfornamebyseq",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getclaimsfornamebyseq", "@lbry",
+					[]int32{2}, "")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetClaimsForNameBySeqCmd("@lbry",
+					[]int32{2}, btcjson.String(""), nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getclaimsfornamebyseq","params":["@lbry",[2],""],"id":1}`,
+			unmarshalled: &btcjson.GetClaimsForNameBySeqCmd{
+				Name:          "@lbry",
+				Sequences:     []int32{2},
+				HashOrHeight:  btcjson.String(""),
+				IncludeValues: btcjson.Bool(false),
+			},
+		},
+		{
+			name: "getvalueforname",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getvalueforname", "@lbry")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetValueForNameCmd("@lbry", nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getvalueforname","params":["@lbry"],"id":1}`,
+			unmarshalled: &btcjson.GetValueForNameCmd{
+				Name:          "@lbry",
+				IncludeValues: btcjson.Bool(true),
+			},
+		},
+		{
+			name: "getnameproof",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getnameproof", "@lbry")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetNameProofCmd("@lbry")
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getnameproof","params":["@lbry"],"id":1}`,
+			unmarshalled: &btcjson.GetNameProofCmd{Name: "@lbry"},
+		},
+		{
+			name: "normalize",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("normalize", "LBRY")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetNormalizedCmd("LBRY")
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"normalize","params":["LBRY"],"id":1}`,
+			unmarshalled: &btcjson.GetNormalizedCmd{Name: "LBRY"},
+		},
+		{
+			name: "simulateclaimsupport",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("simulateclaimsupport", "@lbry",
+					"3fda836a92faaceedfe398225fb9b2ee2ed1f01a", 100000000)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewSimulateClaimSupportCmd("@lbry",
+					"3fda836a92faaceedfe398225fb9b2ee2ed1f01a", 100000000)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"simulateclaimsupport","params":["@lbry","3fda836a92faaceedfe398225fb9b2ee2ed1f01a",100000000],"id":1}`,
+			unmarshalled: &btcjson.SimulateClaimSupportCmd{
+				Name:    "@lbry",
+				ClaimID: "3fda836a92faaceedfe398225fb9b2ee2ed1f01a",
+				Amount:  100000000,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		marshalled, err := btcjson.MarshalCmd(btcjson.RpcVersion1, 1, test.staticCmd())
+		r.NoError(err, test.name)
+		r.Equal(test.marshalled, string(marshalled), test.name)
+
+		cmd, err := test.newCmd()
+		r.NoError(err, test.name)
+		marshalled, err = btcjson.MarshalCmd(btcjson.RpcVersion1, 1, cmd)
+		r.NoError(err, test.name)
+		r.Equal(test.marshalled, string(marshalled), test.name)
+
+		var request btcjson.Request
+		r.NoError(json.Unmarshal(marshalled, &request), test.name)
+		cmd, err = btcjson.UnmarshalCmd(&request)
+		r.NoError(err, test.name)
+		r.Equal(test.unmarshalled, cmd, test.name)
+	}
+}
@@ -44,6 +44,30 @@ func MethodUsageFlags(method string) (UsageFlag, error) {
 	return info.flags, nil
 }
 
+// MethodParamNames returns the names of the parameters of the passed command
+// method, in order, by which NewCmdNamed and the named parameters of a request
+// refer to them.  The provided method must be associated with a registered
+// type.  All commands provided by this package are registered by default.
+func MethodParamNames(method string) ([]string, error) {
+	// Look up details about the provided method and error out if not
+	// registered.
+	registerLock.RLock()
+	rtp, ok := methodToConcreteType[method]
+	info := methodToInfo[method]
+	registerLock.RUnlock()
+	if !ok {
+		str := fmt.Sprintf("%q is not registered", method)
+		return nil, makeError(ErrUnregisteredMethod, str)
+	}
+
+	rt := rtp.Elem()
+	names := make([]string, 0, info.maxParams)
+	for i := 0; i < info.maxParams; i++ {
+		names = append(names, paramName(rt.Field(i)))
+	}
+	return names, nil
+}
+
 // subStructUsage returns a string for use in the one-line usage for the given
 // sub struct.  Note that this is specifically for fields which consist of
 // structs (or an array/slice of structs) as opposed to the top-level command
@@ -127,6 +127,60 @@ func TestMethodUsageFlags(t *testing.T) {
 	}
 }
 
+// TestMethodParamNames tests the MethodParamNames function ensure it returns
+// the expected names and errors.
+func TestMethodParamNames(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		method string
+		err    error
+		names  []string
+	}{
+		{
+			name:   "unregistered type",
+			method: "bogusmethod",
+			err:    btcjson.Error{ErrorCode: btcjson.ErrUnregisteredMethod},
+		},
+		{
+			name:   "getblockcount",
+			method: "getblockcount",
+			names:  []string{},
+		},
+		{
+			name:   "getblock",
+			method: "getblock",
+			names:  []string{"hash", "verbosity"},
+		},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		names, err := btcjson.MethodParamNames(test.method)
+		if reflect.TypeOf(err) != reflect.TypeOf(test.err) {
+			t.Errorf("Test #%d (%s) wrong error - got %T (%[3]v), "+
+				"want %T", i, test.name, err, test.err)
+			continue
+		}
+		if err != nil {
+			gotErrorCode := err.(btcjson.Error).ErrorCode
+			if gotErrorCode != test.err.(btcjson.Error).ErrorCode {
+				t.Errorf("Test #%d (%s) mismatched error code "+
+					"- got %v (%v), want %v", i, test.name,
+					gotErrorCode, err,
+					test.err.(btcjson.Error).ErrorCode)
+			}
+			continue
+		}
+
+		if !reflect.DeepEqual(names, test.names) {
+			t.Errorf("Test #%d (%s) mismatched names - got %v, "+
+				"want %v", i, test.name, names, test.names)
+		}
+	}
+}
+
 // TestMethodUsageText tests the MethodUsageText function ensure it returns the
 // expected text.
 func TestMethodUsageText(t *testing.T) {
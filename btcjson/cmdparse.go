@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -121,6 +122,14 @@ func UnmarshalCmd(r *Request) (interface{}, error) {
 	rvp := reflect.New(rt)
 	rv := rvp.Elem()
 
+	// Named parameters are matched to the struct fields by name.
+	if r.namedParams != nil {
+		if err := unmarshalNamedParams(r.namedParams, &info, rv); err != nil {
+			return nil, err
+		}
+		return rvp.Interface(), nil
+	}
+
 	// Ensure the number of parameters are correct.
 	numParams := len(r.Params)
 	if err := checkNumParams(numParams, &info); err != nil {
@@ -130,34 +139,8 @@ func UnmarshalCmd(r *Request) (interface{}, error) {
 	// Loop through each of the struct fields and unmarshal the associated
 	// parameter into them.
 	for i := 0; i < numParams; i++ {
-		rvf := rv.Field(i)
-		// Unmarshal the parameter into the struct field.
-		concreteVal := rvf.Addr().Interface()
-		if err := json.Unmarshal(r.Params[i], &concreteVal); err != nil {
-			// Parse Integer into Bool for compatibility with lbrycrd.
-			if rvf.Kind() == reflect.Ptr &&
-				rvf.Elem().Type().Kind() == reflect.Bool {
-				boolInt, errBoolInt := strconv.Atoi(string(r.Params[i]))
-				if errBoolInt == nil {
-					rvf.Elem().SetBool(boolInt != 0)
-					continue
-				}
-			}
-
-			// The most common error is the wrong type, so
-			// explicitly detect that error and make it nicer.
-			fieldName := strings.ToLower(rt.Field(i).Name)
-			if jerr, ok := err.(*json.UnmarshalTypeError); ok {
-				str := fmt.Sprintf("parameter #%d '%s' must "+
-					"be type %v (got %v)", i+1, fieldName,
-					jerr.Type, jerr.Value)
-				return nil, makeError(ErrInvalidType, str)
-			}
-
-			// Fallback to showing the underlying error.
-			str := fmt.Sprintf("parameter #%d '%s' failed to "+
-				"unmarshal: %v", i+1, fieldName, err)
-			return nil, makeError(ErrInvalidType, str)
+		if err := unmarshalParam(rv, i, r.Params[i]); err != nil {
+			return nil, err
 		}
 	}
 
@@ -171,6 +154,106 @@ func UnmarshalCmd(r *Request) (interface{}, error) {
 	return rvp.Interface(), nil
 }
 
+// unmarshalParam unmarshals the passed parameter into field i of the command
+// struct rv.
+func unmarshalParam(rv reflect.Value, i int, param json.RawMessage) error {
+	rvf := rv.Field(i)
+	// Unmarshal the parameter into the struct field.
+	concreteVal := rvf.Addr().Interface()
+	if err := json.Unmarshal(param, &concreteVal); err != nil {
+		// Parse Integer into Bool for compatibility with lbrycrd.
+		if rvf.Kind() == reflect.Ptr &&
+			rvf.Elem().Type().Kind() == reflect.Bool {
+			boolInt, errBoolInt := strconv.Atoi(string(param))
+			if errBoolInt == nil {
+				rvf.Elem().SetBool(boolInt != 0)
+				return nil
+			}
+		}
+
+		// The most common error is the wrong type, so
+		// explicitly detect that error and make it nicer.
+		fieldName := strings.ToLower(rv.Type().Field(i).Name)
+		if jerr, ok := err.(*json.UnmarshalTypeError); ok {
+			str := fmt.Sprintf("parameter #%d '%s' must "+
+				"be type %v (got %v)", i+1, fieldName,
+				jerr.Type, jerr.Value)
+			return makeError(ErrInvalidType, str)
+		}
+
+		// Fallback to showing the underlying error.
+		str := fmt.Sprintf("parameter #%d '%s' failed to "+
+			"unmarshal: %v", i+1, fieldName, err)
+		return makeError(ErrInvalidType, str)
+	}
+	return nil
+}
+
+// paramName returns the name of a command parameter, which is the name in its
+// json tag if it has one, or else the lowercase field name.
+func paramName(sf reflect.StructField) string {
+	name := sf.Tag.Get("json")
+	if i := strings.IndexByte(name, ','); i >= 0 {
+		name = name[:i]
+	}
+	if name == "" || name == "-" {
+		name = strings.ToLower(sf.Name)
+	}
+	return name
+}
+
+// unmarshalNamedParams unmarshals named parameters, keyed by the parameter
+// names of the struct fields, into the command struct rv.  Optional parameters
+// which are not supplied are populated with their default value, so any of
+// them may be omitted.
+func unmarshalNamedParams(params map[string]json.RawMessage, info *methodInfo, rv reflect.Value) error {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rt := rv.Type()
+	supplied := make(map[int]json.RawMessage, len(params))
+	for _, name := range names {
+		i := 0
+		for ; i < info.maxParams; i++ {
+			if strings.EqualFold(paramName(rt.Field(i)), name) {
+				break
+			}
+		}
+		if i == info.maxParams {
+			str := fmt.Sprintf("unknown named parameter '%s'", name)
+			return makeError(ErrInvalidType, str)
+		}
+		if _, ok := supplied[i]; ok {
+			str := fmt.Sprintf("parameter #%d '%s' is supplied more "+
+				"than once", i+1, paramName(rt.Field(i)))
+			return makeError(ErrInvalidType, str)
+		}
+		supplied[i] = params[name]
+	}
+
+	for i := 0; i < info.maxParams; i++ {
+		param, ok := supplied[i]
+		if ok {
+			if err := unmarshalParam(rv, i, param); err != nil {
+				return err
+			}
+			continue
+		}
+		if i < info.numReqParams {
+			str := fmt.Sprintf("missing required parameter #%d '%s'",
+				i+1, paramName(rt.Field(i)))
+			return makeError(ErrNumParams, str)
+		}
+		if defaultVal, ok := info.defaults[i]; ok {
+			rv.Field(i).Set(defaultVal)
+		}
+	}
+	return nil
+}
+
 // isNumeric returns whether the passed reflect kind is a signed or unsigned
 // integer of any magnitude or a float of any magnitude.
 func isNumeric(kind reflect.Kind) bool {
@@ -564,3 +647,86 @@ func NewCmd(method string, args ...interface{}) (interface{}, error) {
 
 	return rvp.Interface(), nil
 }
+
+// NewCmdNamed provides the same functionality as NewCmd, except that the
+// parameters following the positional args may be supplied by name, as the
+// keys of namedArgs.  The names are those returned by MethodParamNames, and are
+// matched case-insensitively.  The optional parameters which are not supplied
+// but precede a supplied one are populated with their default value, so that
+// any of them may be omitted.
+func NewCmdNamed(method string, args []interface{}, namedArgs map[string]interface{}) (interface{}, error) {
+	// Look up details about the provided method.  Any methods that aren't
+	// registered are an error.
+	registerLock.RLock()
+	rtp, ok := methodToConcreteType[method]
+	info := methodToInfo[method]
+	registerLock.RUnlock()
+	if !ok {
+		str := fmt.Sprintf("%q is not registered", method)
+		return nil, makeError(ErrUnregisteredMethod, str)
+	}
+	if len(args) > info.maxParams {
+		return nil, checkNumParams(len(args), &info)
+	}
+
+	rvp := reflect.New(rtp.Elem())
+	rv := rvp.Elem()
+	rt := rtp.Elem()
+
+	// Match the named args to the parameters, sorted by name so that the
+	// errors are deterministic.
+	names := make([]string, 0, len(namedArgs))
+	for name := range namedArgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	supplied := make(map[int]interface{}, len(args)+len(namedArgs))
+	for i, arg := range args {
+		supplied[i] = arg
+	}
+	lastParam := len(args) - 1
+	for _, name := range names {
+		i := 0
+		for ; i < info.maxParams; i++ {
+			if strings.EqualFold(paramName(rt.Field(i)), name) {
+				break
+			}
+		}
+		if i == info.maxParams {
+			str := fmt.Sprintf("unknown named parameter '%s'", name)
+			return nil, makeError(ErrInvalidType, str)
+		}
+		if _, ok := supplied[i]; ok {
+			str := fmt.Sprintf("parameter #%d '%s' is supplied more "+
+				"than once", i+1, paramName(rt.Field(i)))
+			return nil, makeError(ErrInvalidType, str)
+		}
+		supplied[i] = namedArgs[name]
+		if i > lastParam {
+			lastParam = i
+		}
+	}
+
+	for i := 0; i < info.maxParams; i++ {
+		rvf := rv.Field(i)
+		if arg, ok := supplied[i]; ok {
+			fieldName := strings.ToLower(rt.Field(i).Name)
+			err := assignField(i+1, fieldName, rvf, reflect.ValueOf(arg))
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if i < info.numReqParams {
+			str := fmt.Sprintf("missing required parameter #%d '%s'",
+				i+1, paramName(rt.Field(i)))
+			return nil, makeError(ErrNumParams, str)
+		}
+		if defaultVal, ok := info.defaults[i]; ok && i < lastParam {
+			rvf.Set(defaultVal)
+		}
+	}
+
+	return rvp.Interface(), nil
+}
@@ -422,6 +422,120 @@ func TestNewCmdErrors(t *testing.T) {
 	}
 }
 
+// TestNewCmdNamed ensures NewCmdNamed creates the expected commands from
+// positional and named args, and fails as expected.
+func TestNewCmdNamed(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		method    string
+		args      []interface{}
+		namedArgs map[string]interface{}
+		expect    interface{}
+		err       btcjson.ErrorCode
+	}{
+		{
+			name:      "named params",
+			method:    "getblock",
+			namedArgs: map[string]interface{}{"hash": "123", "verbosity": "2"},
+			expect: &btcjson.GetBlockCmd{
+				Hash:      "123",
+				Verbosity: btcjson.Int(2),
+			},
+		},
+		{
+			name:      "positional and mixed case named params",
+			method:    "getblock",
+			args:      []interface{}{"123"},
+			namedArgs: map[string]interface{}{"Verbosity": 0},
+			expect: &btcjson.GetBlockCmd{
+				Hash:      "123",
+				Verbosity: btcjson.Int(0),
+			},
+		},
+		{
+			name:   "omitted trailing optional",
+			method: "getblock",
+			args:   []interface{}{"123"},
+			expect: &btcjson.GetBlockCmd{Hash: "123"},
+		},
+		{
+			name:      "omitted optional before supplied one",
+			method:    "searchrawtransactions",
+			args:      []interface{}{"1Address"},
+			namedArgs: map[string]interface{}{"count": 5, "reverse": "true"},
+			expect: &btcjson.SearchRawTransactionsCmd{
+				Address:  "1Address",
+				Verbose:  btcjson.Int(1),
+				Skip:     btcjson.Int(0),
+				Count:    btcjson.Int(5),
+				VinExtra: btcjson.Int(0),
+				Reverse:  btcjson.Bool(true),
+			},
+		},
+		{
+			name:   "unregistered command",
+			method: "boguscommand",
+			err:    btcjson.ErrUnregisteredMethod,
+		},
+		{
+			name:   "too many positional params",
+			method: "getblockcount",
+			args:   []interface{}{"123"},
+			err:    btcjson.ErrNumParams,
+		},
+		{
+			name:      "missing required",
+			method:    "getblock",
+			namedArgs: map[string]interface{}{"verbosity": 2},
+			err:       btcjson.ErrNumParams,
+		},
+		{
+			name:      "unknown param",
+			method:    "getblock",
+			namedArgs: map[string]interface{}{"hash": "123", "verbose": true},
+			err:       btcjson.ErrInvalidType,
+		},
+		{
+			name:      "param supplied twice",
+			method:    "getblock",
+			args:      []interface{}{"123"},
+			namedArgs: map[string]interface{}{"hash": "456"},
+			err:       btcjson.ErrInvalidType,
+		},
+		{
+			name:      "invalid type",
+			method:    "getblock",
+			namedArgs: map[string]interface{}{"hash": 1},
+			err:       btcjson.ErrInvalidType,
+		},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		cmd, err := btcjson.NewCmdNamed(test.method, test.args,
+			test.namedArgs)
+		if test.expect == nil {
+			jerr, ok := err.(btcjson.Error)
+			if !ok || jerr.ErrorCode != test.err {
+				t.Errorf("Test #%d (%s) wrong error - got %v, "+
+					"want %v", i, test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test #%d (%s) unexpected error: %v", i,
+				test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(cmd, test.expect) {
+			t.Errorf("Test #%d (%s) unexpected command - got %#v, "+
+				"want %#v", i, test.name, cmd, test.expect)
+		}
+	}
+}
+
 // TestMarshalCmd tests the MarshalCmd function.
 func TestMarshalCmd(t *testing.T) {
 	t.Parallel()
@@ -672,3 +786,94 @@ func TestUnmarshalCmdBoolParams(t *testing.T) {
 
 	}
 }
+
+// TestUnmarshalCmdNamedParams tests that UnmarshalCmd matches parameters
+// supplied as an object to the command fields by name.
+func TestUnmarshalCmdNamedParams(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		request string
+		expect  interface{}
+		err     btcjson.ErrorCode
+	}{
+		{
+			name:    "all params",
+			request: `{"method":"getblock","params":{"hash":"123","verbosity":2}}`,
+			expect: &btcjson.GetBlockCmd{
+				Hash:      "123",
+				Verbosity: btcjson.Int(2),
+			},
+		},
+		{
+			name:    "default and mixed case",
+			request: `{"method":"getblock","params":{"Hash":"123"}}`,
+			expect: &btcjson.GetBlockCmd{
+				Hash:      "123",
+				Verbosity: btcjson.Int(1),
+			},
+		},
+		{
+			name:    "omitted optional before supplied one",
+			request: `{"method":"getclaimsforname","params":{"includevalues":true,"name":"a"}}`,
+			expect: &btcjson.GetClaimsForNameCmd{
+				Name:          "a",
+				IncludeValues: btcjson.Bool(true),
+			},
+		},
+		{
+			name:    "no params",
+			request: `{"method":"getblockcount","params":{}}`,
+			expect:  &btcjson.GetBlockCountCmd{},
+		},
+		{
+			name:    "missing required",
+			request: `{"method":"getblock","params":{"verbosity":2}}`,
+			err:     btcjson.ErrNumParams,
+		},
+		{
+			name:    "unknown param",
+			request: `{"method":"getblock","params":{"hash":"123","verbose":true}}`,
+			err:     btcjson.ErrInvalidType,
+		},
+		{
+			name:    "duplicate param",
+			request: `{"method":"getblock","params":{"hash":"123","HASH":"456"}}`,
+			err:     btcjson.ErrInvalidType,
+		},
+		{
+			name:    "invalid type",
+			request: `{"method":"getblock","params":{"hash":1}}`,
+			err:     btcjson.ErrInvalidType,
+		},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		var request btcjson.Request
+		if err := json.Unmarshal([]byte(test.request), &request); err != nil {
+			t.Errorf("Test #%d (%s) unexpected unmarshal error: %v",
+				i, test.name, err)
+			continue
+		}
+		cmd, err := btcjson.UnmarshalCmd(&request)
+		if test.expect == nil {
+			jerr, ok := err.(btcjson.Error)
+			if !ok || jerr.ErrorCode != test.err {
+				t.Errorf("Test #%d (%s) wrong error - got %v, "+
+					"want %v", i, test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test #%d (%s) unexpected error: %v", i,
+				test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(cmd, test.expect) {
+			t.Errorf("Test #%d (%s) unexpected command - got %#v, "+
+				"want %#v", i, test.name, cmd, test.expect)
+		}
+	}
+}
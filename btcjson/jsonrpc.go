@@ -5,6 +5,7 @@
 package btcjson
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 )
@@ -98,11 +99,16 @@ type Request struct {
 	Method  string            `json:"method"`
 	Params  []json.RawMessage `json:"params"`
 	ID      interface{}       `json:"id"`
+
+	// namedParams holds the parameters of a request which supplied them as
+	// an object rather than an array.
+	namedParams map[string]json.RawMessage
 }
 
 // UnmarshalJSON is a custom unmarshal func for the Request struct. The param
 // field defaults to an empty json.RawMessage array it is omitted by the request
-// or nil if the supplied value is invalid.
+// or nil if the supplied value is invalid.  Params may also be an object of
+// named parameters, which UnmarshalCmd matches to the command's fields by name.
 func (request *Request) UnmarshalJSON(b []byte) error {
 	// Step 1: Create a type alias of the original struct.
 	type Alias Request
@@ -110,8 +116,8 @@ func (request *Request) UnmarshalJSON(b []byte) error {
 	// Step 2: Create an anonymous struct with raw replacements for the special
 	// fields.
 	aux := &struct {
-		Jsonrpc string        `json:"jsonrpc"`
-		Params  []interface{} `json:"params"`
+		Jsonrpc string          `json:"jsonrpc"`
+		Params  json.RawMessage `json:"params"`
 		*Alias
 	}{
 		Alias: (*Alias)(request),
@@ -132,7 +138,24 @@ func (request *Request) UnmarshalJSON(b []byte) error {
 
 	rawParams := make([]json.RawMessage, 0)
 
-	for _, param := range aux.Params {
+	if trimmed := bytes.TrimSpace(aux.Params); len(trimmed) > 0 && trimmed[0] == '{' {
+		err := json.Unmarshal(trimmed, &request.namedParams)
+		if err != nil {
+			return err
+		}
+		request.Params = rawParams
+		return nil
+	}
+
+	var params []interface{}
+	if len(aux.Params) > 0 {
+		err := json.Unmarshal(aux.Params, &params)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, param := range params {
 		marshalledParam, err := json.Marshal(param)
 		if err != nil {
 			return err
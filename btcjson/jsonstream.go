@@ -0,0 +1,500 @@
+package btcjson
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// parallelMinElements is the number of elements from which the elements
+	// of an array or map holding composite values are encoded concurrently.
+	parallelMinElements = 64
+
+	// parallelElementsPerWorker is the number of elements each worker
+	// encodes before they are written out in order.  It bounds the encoded
+	// data held in memory at once.
+	parallelElementsPerWorker = 16
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// WriteResponse writes the JSON-RPC response for the passed rpc version, id,
+// result, and RPCError to w.  The output is the same as MarshalResponse, but
+// the result is encoded piece by piece so that large results, such as verbose
+// blocks or mempools, are never held in memory in their marshalled form.
+func WriteResponse(w io.Writer, rpcVersion RPCVersion, id interface{}, result interface{}, rpcErr *RPCError) error {
+	if !rpcVersion.IsValid() {
+		if rpcVersion == "" {
+			rpcVersion = RpcVersion1
+		} else {
+			str := fmt.Sprintf("rpcversion '%s' is unsupported", rpcVersion)
+			return makeError(ErrInvalidType, str)
+		}
+	}
+	if !IsValidIDType(id) {
+		str := fmt.Sprintf("the id of type '%T' is invalid", id)
+		return makeError(ErrInvalidType, str)
+	}
+
+	bw := bufio.NewWriter(w)
+	e := &streamEncoder{w: bw, parallel: true}
+	e.writeString(`{"jsonrpc":`)
+	e.encodeLeaf(reflect.ValueOf(rpcVersion))
+	e.writeString(`,"result":`)
+	e.encode(reflect.ValueOf(result))
+	e.writeString(`,"error":`)
+	e.encodeLeaf(reflect.ValueOf(rpcErr))
+	e.writeString(`,"id":`)
+	e.encodeLeaf(reflect.ValueOf(&id))
+	e.writeString(`}`)
+	if e.err != nil {
+		return e.err
+	}
+	return bw.Flush()
+}
+
+// EncodeStream writes the JSON encoding of v to w, producing the same output
+// as json.Marshal.  Slices, maps and structs are encoded one element at a
+// time, with only their leaf values marshalled as a whole.  The elements of
+// large arrays and maps of composite values are encoded concurrently.
+func EncodeStream(w io.Writer, v interface{}) error {
+	bw := bufio.NewWriter(w)
+	e := &streamEncoder{w: bw, parallel: true}
+	e.encode(reflect.ValueOf(v))
+	if e.err != nil {
+		return e.err
+	}
+	return bw.Flush()
+}
+
+// streamWriter is the destination of a streamEncoder.
+type streamWriter interface {
+	io.Writer
+	io.StringWriter
+}
+
+// streamEncoder holds the first error encountered, after which all writes are
+// skipped.  When parallel is set, the elements of large arrays and maps are
+// encoded by other encoders, which don't themselves encode concurrently.
+type streamEncoder struct {
+	w        streamWriter
+	err      error
+	parallel bool
+	scratch  [64]byte
+}
+
+func (e *streamEncoder) writeString(s string) {
+	if e.err == nil {
+		_, e.err = e.w.WriteString(s)
+	}
+}
+
+func (e *streamEncoder) write(b []byte) {
+	if e.err == nil {
+		_, e.err = e.w.Write(b)
+	}
+}
+
+// encodeLeaf writes the marshalled form of v.
+func (e *streamEncoder) encodeLeaf(v reflect.Value) {
+	if e.err != nil {
+		return
+	}
+	var i interface{}
+	if v.IsValid() {
+		i = v.Interface()
+	}
+	b, err := json.Marshal(i)
+	if err != nil {
+		e.err = err
+		return
+	}
+	_, e.err = e.w.Write(b)
+}
+
+func (e *streamEncoder) encode(v reflect.Value) {
+	if e.err != nil {
+		return
+	}
+	if !v.IsValid() {
+		e.encodeLeaf(v)
+		return
+	}
+	if hasCustomMarshaler(v.Type()) {
+		// Like the json package, use pointer receivers when addressable.
+		if v.CanAddr() && v.Kind() != reflect.Ptr {
+			v = v.Addr()
+		}
+		e.encodeLeaf(v)
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			e.writeString("null")
+			return
+		}
+		e.encode(v.Elem())
+
+	case reflect.Slice:
+		if v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8 {
+			e.encodeLeaf(v)
+			return
+		}
+		e.encodeArray(v)
+
+	case reflect.Array:
+		e.encodeArray(v)
+
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String ||
+			hasCustomMarshaler(v.Type().Key()) {
+
+			e.encodeLeaf(v)
+			return
+		}
+		e.encodeMap(v)
+
+	case reflect.Struct:
+		fields, ok := structFields(v.Type())
+		if !ok {
+			e.encodeLeaf(v)
+			return
+		}
+		e.encodeStruct(v, fields)
+
+	case reflect.String:
+		e.encodeString(v.String())
+
+	case reflect.Bool:
+		e.writeString(strconv.FormatBool(v.Bool()))
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.write(strconv.AppendInt(e.scratch[:0], v.Int(), 10))
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.write(strconv.AppendUint(e.scratch[:0], v.Uint(), 10))
+
+	case reflect.Float64:
+		e.encodeFloat(v)
+
+	default:
+		e.encodeLeaf(v)
+	}
+}
+
+// encodeString writes the JSON string s, which is only marshalled by the json
+// package when it needs escaping.
+func (e *streamEncoder) encodeString(s string) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 || c >= 0x80 || c == '"' || c == '\\' ||
+			c == '<' || c == '>' || c == '&' {
+
+			e.encodeLeaf(reflect.ValueOf(s))
+			return
+		}
+	}
+	e.writeString(`"`)
+	e.writeString(s)
+	e.writeString(`"`)
+}
+
+// encodeFloat writes the float64 v formatted like the json package does,
+// which marshals the values it rejects so that they fail the same way.
+func (e *streamEncoder) encodeFloat(v reflect.Value) {
+	f := v.Float()
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		e.encodeLeaf(v)
+		return
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	b := strconv.AppendFloat(e.scratch[:0], f, format, -1, 64)
+	if format == 'e' {
+		// Clean up e-09 to e-9.
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	e.write(b)
+}
+
+func (e *streamEncoder) encodeArray(v reflect.Value) {
+	e.writeString("[")
+	e.encodeElements(v.Len(), v.Type().Elem(), func(e *streamEncoder, i int) {
+		e.encode(v.Index(i))
+	})
+	e.writeString("]")
+}
+
+func (e *streamEncoder) encodeMap(v reflect.Value) {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+
+	e.writeString("{")
+	e.encodeElements(len(keys), v.Type().Elem(), func(e *streamEncoder, i int) {
+		e.encodeString(keys[i].String())
+		e.writeString(":")
+		e.encode(v.MapIndex(keys[i]))
+	})
+	e.writeString("}")
+}
+
+// encodeElements writes the n comma separated elements encoded by encodeElem,
+// which are of type t.  Large numbers of composite elements, such as the
+// transactions of a verbose block or the entries of a verbose mempool, are
+// encoded concurrently, a window at a time, and written in order.
+func (e *streamEncoder) encodeElements(n int, t reflect.Type,
+	encodeElem func(e *streamEncoder, i int)) {
+
+	workers := runtime.GOMAXPROCS(0)
+	if !e.parallel || workers < 2 || n < parallelMinElements ||
+		!isComposite(t) {
+
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				e.writeString(",")
+			}
+			encodeElem(e, i)
+		}
+		return
+	}
+
+	window := make([]bytes.Buffer, workers*parallelElementsPerWorker)
+	errs := make([]error, workers)
+	for start := 0; start < n && e.err == nil; start += len(window) {
+		end := start + len(window)
+		if end > n {
+			end = n
+		}
+
+		// Each worker encodes every workers-th element of the window.
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				elemEncoder := new(streamEncoder)
+				for i := start + w; i < end; i += workers {
+					buf := &window[i-start]
+					buf.Reset()
+					elemEncoder.w = buf
+					encodeElem(elemEncoder, i)
+					if elemEncoder.err != nil {
+						errs[w] = elemEncoder.err
+						return
+					}
+				}
+			}(w)
+		}
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil {
+				e.err = err
+				return
+			}
+		}
+
+		for i := start; i < end; i++ {
+			if i > 0 {
+				e.writeString(",")
+			}
+			if e.err == nil {
+				_, e.err = e.w.Write(window[i-start].Bytes())
+			}
+		}
+	}
+}
+
+// isComposite returns whether values of type t, through pointers, are
+// encoded piece by piece, and are therefore worth encoding concurrently.
+func isComposite(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if hasCustomMarshaler(t) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Array, reflect.Interface:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Uint8
+	}
+	return false
+}
+
+func (e *streamEncoder) encodeStruct(v reflect.Value, fields []streamField) {
+	e.writeString("{")
+	first := true
+	for _, f := range fields {
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+			continue
+		}
+		if !first {
+			e.writeString(",")
+		}
+		first = false
+		e.writeString(f.key)
+		e.encode(fv)
+	}
+	e.writeString("}")
+}
+
+// streamField is a field of a struct as seen by the json package.  Its key is
+// the marshalled name followed by the colon preceding the value.
+type streamField struct {
+	name      string
+	key       string
+	index     []int
+	omitEmpty bool
+}
+
+// cachedStructFields is the result of structFields for a struct type.
+type cachedStructFields struct {
+	fields []streamField
+	ok     bool
+}
+
+// structFieldsCache maps the struct types encoded so far to their
+// cachedStructFields.
+var structFieldsCache sync.Map
+
+// structFields returns the encoded fields of t in the order of the json
+// package.  It returns false for structs using features it doesn't mirror, such
+// as the string option or conflicting field names, which are then marshalled
+// as a whole.
+func structFields(t reflect.Type) ([]streamField, bool) {
+	if cached, ok := structFieldsCache.Load(t); ok {
+		c := cached.(*cachedStructFields)
+		return c.fields, c.ok
+	}
+
+	c := new(cachedStructFields)
+	c.ok = appendStructFields(&c.fields, t, nil)
+	names := make(map[string]struct{}, len(c.fields))
+	for _, f := range c.fields {
+		if _, ok := names[f.name]; ok {
+			c.ok = false
+			break
+		}
+		names[f.name] = struct{}{}
+	}
+	if !c.ok {
+		c.fields = nil
+	}
+	structFieldsCache.Store(t, c)
+	return c.fields, c.ok
+}
+
+func appendStructFields(fields *[]streamField, t reflect.Type, index []int) bool {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if j := strings.IndexByte(tag, ','); j >= 0 {
+			name, opts = tag[:j], tag[j+1:]
+		}
+		if opts != "" && opts != "omitempty" {
+			return false
+		}
+
+		fieldIndex := append(append([]int(nil), index...), i)
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			if !appendStructFields(fields, ft, fieldIndex) {
+				return false
+			}
+			continue
+		}
+		if sf.Anonymous && (name == "" || !sf.IsExported()) {
+			return false
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		key, _ := json.Marshal(name)
+		*fields = append(*fields, streamField{
+			name:      name,
+			key:       string(key) + ":",
+			index:     fieldIndex,
+			omitEmpty: opts == "omitempty",
+		})
+	}
+	return true
+}
+
+// fieldByIndex returns the field of v at index, or false if it is reached
+// through a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func hasCustomMarshaler(t reflect.Type) bool {
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+		return true
+	}
+	pt := reflect.PtrTo(t)
+	return pt.Implements(jsonMarshalerType) || pt.Implements(textMarshalerType)
+}
+
+// isEmptyValue mirrors the omitempty rules of the json package.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package btcjson_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbcd/btcjson"
+)
+
+// TestEncodeStream ensures EncodeStream produces the same output as
+// json.Marshal.
+func TestEncodeStream(t *testing.T) {
+	t.Parallel()
+
+	type embedded struct {
+		A int `json:"a"`
+	}
+	type quoted struct {
+		N int `json:"n,string"`
+	}
+	type withPtr struct {
+		*embedded
+		B string `json:"b,omitempty"`
+	}
+
+	// Large enough arrays and maps are encoded concurrently.
+	largeBlock := btcjson.GetBlockVerboseTxResult{}
+	largeMempool := make(map[string]*btcjson.GetRawMempoolVerboseResult)
+	for i := 0; i < 1000; i++ {
+		txid := fmt.Sprintf("%064x", i)
+		largeBlock.Tx = append(largeBlock.Tx, btcjson.TxRawResult{
+			Txid: txid,
+			Vout: []btcjson.Vout{{N: uint32(i)}},
+		})
+		largeMempool[txid] = &btcjson.GetRawMempoolVerboseResult{
+			Size:    int32(i),
+			Depends: []string{},
+		}
+	}
+
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{"nil", nil},
+		{"string", "<escaped> & \"quoted\""},
+		{"nil slice", []string(nil)},
+		{"bytes", []byte{1, 2, 3}},
+		{"map", map[string]int{"b": 2, "a": 1, "c": 3}},
+		{"int map", map[int]string{2: "b", 1: "a"}},
+		{"string option", quoted{N: 5}},
+		{"nil embedded pointer", withPtr{B: "b"}},
+		{"embedded pointer", &withPtr{embedded: &embedded{A: 1}}},
+		{"marshaler", []*big.Int{big.NewInt(1), nil}},
+		{"text marshaler", time.Unix(1, 0).UTC()},
+		{"interface", []interface{}{1, "a", nil, map[string]interface{}{"x": 1.5}}},
+		{"block", btcjson.GetBlockVerboseTxResult{
+			GetBlockVerboseResultBase: btcjson.GetBlockVerboseResultBase{
+				Hash:   "hash",
+				Height: 100,
+			},
+			Tx: []btcjson.TxRawResult{{
+				Txid: "txid",
+				Vin: []btcjson.Vin{{
+					Coinbase: "coinbase",
+				}},
+				Vout: []btcjson.Vout{{
+					Value: 1.5,
+					ScriptPubKey: btcjson.ScriptPubKeyResult{
+						Asm:       "OP_TRUE",
+						Addresses: []string{"addr"},
+					},
+				}},
+			}},
+		}},
+		{"mempool", map[string]*btcjson.GetRawMempoolVerboseResult{
+			"b": {Size: 2, Depends: []string{"a"}},
+			"a": {Size: 1, Depends: []string{}},
+		}},
+		{"claims", &btcjson.GetClaimsForNameResult{
+			Hash:   "hash",
+			Claims: []btcjson.ClaimResult{{ClaimID: "id"}},
+		}},
+		{"large block", largeBlock},
+		{"large mempool", largeMempool},
+		{"large interface", make([]interface{}, 100)},
+	}
+
+	for _, test := range tests {
+		want, err := json.Marshal(test.value)
+		if err != nil {
+			t.Fatalf("%s: unexpected marshal error: %v", test.name, err)
+		}
+		var buf bytes.Buffer
+		if err := btcjson.EncodeStream(&buf, test.value); err != nil {
+			t.Errorf("%s: unexpected encode error: %v", test.name, err)
+			continue
+		}
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("%s: mismatched encoding - got %s, want %s",
+				test.name, buf.Bytes(), want)
+		}
+	}
+}
+
+// TestEncodeStreamError ensures the errors marshalling the elements encoded
+// concurrently are returned.
+func TestEncodeStreamError(t *testing.T) {
+	t.Parallel()
+
+	values := make([]map[string]float64, 200)
+	values[150] = map[string]float64{"nan": math.NaN()}
+	var buf bytes.Buffer
+	if err := btcjson.EncodeStream(&buf, values); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+// TestWriteResponse ensures WriteResponse produces the same output as
+// MarshalResponse.
+func TestWriteResponse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		version btcjson.RPCVersion
+		id      interface{}
+		result  interface{}
+		err     *btcjson.RPCError
+	}{
+		{"result", btcjson.RpcVersion1, 1, []string{"a", "b"}, nil},
+		{"error", btcjson.RpcVersion2, "id", nil, btcjson.ErrRPCInvalidRequest},
+		{"no version", "", nil, 5, nil},
+	}
+
+	for _, test := range tests {
+		want, err := btcjson.MarshalResponse(test.version, test.id,
+			test.result, test.err)
+		if err != nil {
+			t.Fatalf("%s: unexpected marshal error: %v", test.name, err)
+		}
+		var buf bytes.Buffer
+		err = btcjson.WriteResponse(&buf, test.version, test.id,
+			test.result, test.err)
+		if err != nil {
+			t.Errorf("%s: unexpected write error: %v", test.name, err)
+			continue
+		}
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("%s: mismatched response - got %s, want %s",
+				test.name, buf.Bytes(), want)
+		}
+	}
+
+	var buf bytes.Buffer
+	err := btcjson.WriteResponse(&buf, "3.0", 1, nil, nil)
+	if err == nil {
+		t.Errorf("unsupported version: expected error")
+	}
+}
@@ -48,21 +48,6 @@ func NewAddWitnessAddressCmd(address string) *AddWitnessAddressCmd {
 	}
 }
 
-// CreateMultisigCmd defines the createmultisig JSON-RPC command.
-type CreateMultisigCmd struct {
-	NRequired int
-	Keys      []string
-}
-
-// NewCreateMultisigCmd returns a new instance which can be used to issue a
-// createmultisig JSON-RPC command.
-func NewCreateMultisigCmd(nRequired int, keys []string) *CreateMultisigCmd {
-	return &CreateMultisigCmd{
-		NRequired: nRequired,
-		Keys:      keys,
-	}
-}
-
 // CreateWalletCmd defines the createwallet JSON-RPC command.
 type CreateWalletCmd struct {
 	WalletName         string
@@ -876,10 +861,12 @@ func (r *DescriptorRange) UnmarshalJSON(data []byte) error {
 		if len(v) != 2 {
 			return fmt.Errorf("expected [begin,end] integer range, got: %v", unmarshalled)
 		}
-		r.Value = []int{
-			int(v[0].(float64)),
-			int(v[1].(float64)),
+		begin, beginOk := v[0].(float64)
+		end, endOk := v[1].(float64)
+		if !beginOk || !endOk {
+			return fmt.Errorf("expected [begin,end] integer range, got: %v", unmarshalled)
 		}
+		r.Value = []int{int(begin), int(end)}
 	default:
 		return fmt.Errorf("invalid descriptor range value: %v", unmarshalled)
 	}
@@ -1082,7 +1069,6 @@ func init() {
 	MustRegisterCmd("addmultisigaddress", (*AddMultisigAddressCmd)(nil), flags)
 	MustRegisterCmd("addwitnessaddress", (*AddWitnessAddressCmd)(nil), flags)
 	MustRegisterCmd("backupwallet", (*BackupWalletCmd)(nil), flags)
-	MustRegisterCmd("createmultisig", (*CreateMultisigCmd)(nil), flags)
 	MustRegisterCmd("createwallet", (*CreateWalletCmd)(nil), flags)
 	MustRegisterCmd("dumpprivkey", (*DumpPrivKeyCmd)(nil), flags)
 	MustRegisterCmd("encryptwallet", (*EncryptWalletCmd)(nil), flags)
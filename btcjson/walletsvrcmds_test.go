@@ -173,21 +173,6 @@ func TestWalletSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"unloadwallet","params":[],"id":1}`,
 			unmarshalled: &btcjson.UnloadWalletCmd{WalletName: nil},
 		},
-		{
-			name: "createmultisig",
-			newCmd: func() (interface{}, error) {
-				return btcjson.NewCmd("createmultisig", 2, []string{"031234", "035678"})
-			},
-			staticCmd: func() interface{} {
-				keys := []string{"031234", "035678"}
-				return btcjson.NewCreateMultisigCmd(2, keys)
-			},
-			marshalled: `{"jsonrpc":"1.0","method":"createmultisig","params":[2,["031234","035678"]],"id":1}`,
-			unmarshalled: &btcjson.CreateMultisigCmd{
-				NRequired: 2,
-				Keys:      []string{"031234", "035678"},
-			},
-		},
 		{
 			name: "dumpprivkey",
 			newCmd: func() (interface{}, error) {
@@ -326,11 +326,12 @@ type RescanBlockchainResult struct {
 // SignRawTransactionError models the data that contains script verification
 // errors from the signrawtransaction request.
 type SignRawTransactionError struct {
-	TxID      string `json:"txid"`
-	Vout      uint32 `json:"vout"`
-	ScriptSig string `json:"scriptSig"`
-	Sequence  uint32 `json:"sequence"`
-	Error     string `json:"error"`
+	TxID      string   `json:"txid"`
+	Vout      uint32   `json:"vout"`
+	Witness   []string `json:"witness,omitempty"`
+	ScriptSig string   `json:"scriptSig"`
+	Sequence  uint32   `json:"sequence"`
+	Error     string   `json:"error"`
 }
 
 // SignRawTransactionResult models the data from the signrawtransaction
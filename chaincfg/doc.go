@@ -8,10 +8,9 @@
 // instance running on a different network.
 //
 // For library packages, chaincfg provides the ability to lookup chain
-// parameters and encoding magics when passed a *Params.  Older APIs not updated
-// to the new convention of passing a *Params may lookup the parameters for a
-// wire.BitcoinNet using ParamsForNet, but be aware that this usage is
-// deprecated and will be removed from chaincfg in the future.
+// parameters and encoding magics when passed a *Params.  APIs which are only
+// given a wire.BitcoinNet may lookup the parameters of the standard or
+// registered network using LookupNet.
 //
 // For main packages, a (typically global) var may be assigned the address of
 // one of the standard Param vars for use as the application's "active" network.
@@ -58,4 +57,26 @@
 // non-standard network.  As a general rule of thumb, all network parameters
 // should be unique to the network, but parameter collisions can still occur
 // (unfortunately, this is the case with regtest and testnet3 sharing magics).
+//
+// Such a network must be registered with Register before its addresses and
+// keys can be decoded.  Setting the ClaimTrie field registers the claimtrie
+// parameters of the network along with it, so that a fork or an application
+// embedding the node can define a complete network at runtime by filling in a
+// copy of the parameters of the standard network it is based on:
+//
+//	claims := chaincfg.RegressionNetParams.ClaimTrieParams()
+//	claims.NormalizedNameForkHeight = 100
+//
+//	myNet := chaincfg.RegressionNetParams
+//	myNet.Name = "mynet"
+//	myNet.Net = 0x4d594e54
+//	myNet.Bech32HRPSegwit = "mlbc"
+//	myNet.ClaimTrie = &claims
+//	if err := chaincfg.Register(&myNet); err != nil {
+//	        log.Fatal(err)
+//	}
+//
+// Register rejects the networks whose magic is already registered, as well as
+// the ones whose address or hd key magics would make the addresses or keys of
+// another registered network undeterminable, without registering anything.
 package chaincfg
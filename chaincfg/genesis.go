@@ -5,6 +5,7 @@
 package chaincfg
 
 import (
+	"encoding/binary"
 	"time"
 
 	"github.com/lbryio/lbcd/chaincfg/chainhash"
@@ -179,3 +180,36 @@ var sigNetGenesisBlock = wire.MsgBlock{
 // sigNetGenesisHash is the hash of the first block in the block chain for the
 // signet test network.
 var sigNetGenesisHash = sigNetGenesisBlock.BlockHash()
+
+// MaxGenesisMessageLen is the maximum length of the message of the coinbase of
+// a custom genesis block, for its signature script to be at most 100 bytes.
+const MaxGenesisMessageLen = 91
+
+// CustomGenesisBlock returns the genesis block of a private network with the
+// passed timestamp, target difficulty bits and coinbase message, which must be
+// at most MaxGenesisMessageLen bytes long.  Its coinbase is the one of the
+// genesis blocks of the default networks with the bits and message in its
+// signature script, and its claimtrie root is the one of the empty claimtrie
+// the block chain starts with.  The nonce of the block is left to be solved.
+func CustomGenesisBlock(timestamp time.Time, bits uint32, message string) *wire.MsgBlock {
+	sigScript := []byte{0x04, 0, 0, 0, 0, 0x01, 0x04}
+	binary.LittleEndian.PutUint32(sigScript[1:5], bits)
+	if len(message) >= 0x4c {
+		sigScript = append(sigScript, 0x4c) // OP_PUSHDATA1
+	}
+	sigScript = append(sigScript, byte(len(message)))
+	sigScript = append(sigScript, message...)
+
+	coinbase := genesisCoinbaseTx.Copy()
+	coinbase.TxIn[0].SignatureScript = sigScript
+	return &wire.MsgBlock{
+		Header: wire.BlockHeader{
+			Version:    1,
+			MerkleRoot: coinbase.TxHash(),
+			ClaimTrie:  genesisClaimTrie,
+			Timestamp:  timestamp,
+			Bits:       bits,
+		},
+		Transactions: []*wire.MsgTx{coinbase},
+	}
+}
@@ -6,9 +6,12 @@ package chaincfg
 
 import (
 	"bytes"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/lbryio/lbcd/wire"
 )
 
 // TestGenesisBlock tests the genesis block of the main network for validity by
@@ -105,3 +108,46 @@ func TestSigNetGenesisBlock(t *testing.T) {
 			spew.Sdump(SigNetParams.GenesisHash))
 	}
 }
+
+// TestCustomGenesisBlock tests that the custom genesis blocks have the coinbase
+// of the default ones, and that a long message still fits in the signature
+// script of the coinbase.
+func TestCustomGenesisBlock(t *testing.T) {
+	// The genesis block of the main network has the message and bits of
+	// the first genesis block of bitcoin in its coinbase.
+	block := CustomGenesisBlock(time.Unix(1446058291, 0), 0x1d00ffff,
+		"insert timestamp string")
+	if block.Header.MerkleRoot != genesisMerkleRoot {
+		t.Fatalf("CustomGenesisBlock: merkle root %v, want %v",
+			block.Header.MerkleRoot, genesisMerkleRoot)
+	}
+	block.Header.Bits = genesisBlock.Header.Bits
+	block.Header.Nonce = genesisBlock.Header.Nonce
+	if hash := block.BlockHash(); hash != genesisHash {
+		t.Fatalf("CustomGenesisBlock: hash %v, want %v", hash,
+			genesisHash)
+	}
+
+	block = CustomGenesisBlock(time.Unix(1700000000, 0), 0x207fffff,
+		strings.Repeat("x", MaxGenesisMessageLen))
+	coinbase := block.Transactions[0]
+	if n := len(coinbase.TxIn[0].SignatureScript); n != 100 {
+		t.Fatalf("CustomGenesisBlock: signature script of %d bytes, "+
+			"want 100", n)
+	}
+	if block.Header.MerkleRoot != coinbase.TxHash() {
+		t.Fatalf("CustomGenesisBlock: merkle root %v, want %v",
+			block.Header.MerkleRoot, coinbase.TxHash())
+	}
+	if block.Header.ClaimTrie != genesisClaimTrie {
+		t.Fatalf("CustomGenesisBlock: claimtrie root %v, want %v",
+			block.Header.ClaimTrie, genesisClaimTrie)
+	}
+
+	// The networks of different genesis blocks are told apart.
+	hash := block.BlockHash()
+	net := CustomGenesisNet(&hash)
+	if net == CustomGenesisNet(&genesisHash) || net == wire.TestNet {
+		t.Fatalf("CustomGenesisNet: network magic %v isn't unique", net)
+	}
+}
@@ -5,15 +5,20 @@
 package chaincfg
 
 import (
+	"bytes"
 	"encoding/binary"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"math"
 	"math/big"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/claimtrie/param"
 	"github.com/lbryio/lbcd/wire"
 )
 
@@ -212,6 +217,11 @@ type Params struct {
 	// GenerateSupported specifies whether or not CPU mining is allowed.
 	GenerateSupported bool
 
+	// SignetChallenge is the challenge script the signet solutions of the
+	// blocks must satisfy, as defined by BIP 325, on signet networks.  It
+	// is nil on the other networks.
+	SignetChallenge []byte
+
 	// Checkpoints ordered from oldest to newest.
 	Checkpoints []Checkpoint
 
@@ -235,6 +245,11 @@ type Params struct {
 	// Mempool parameters
 	RelayNonStdTxs bool
 
+	// ClaimTrie defines the claimtrie parameters of the network.  It may be
+	// nil for the networks whose claimtrie parameters are registered with
+	// the param package, such as the standard networks.
+	ClaimTrie *param.ClaimTrieParams
+
 	// Human-readable part for Bech32 encoded segwit addresses, as defined
 	// in BIP 173.
 	Bech32HRPSegwit string
@@ -634,6 +649,7 @@ func CustomSignetParams(challenge []byte, dnsSeeds []DNSSeed) Params {
 		ReduceMinDifficulty:      false,
 		MinDiffReductionTime:     time.Minute * 20, // TargetTimePerBlock * 2
 		GenerateSupported:        false,
+		SignetChallenge:          challenge,
 
 		// Checkpoints ordered from oldest to newest.
 		Checkpoints: nil,
@@ -651,14 +667,16 @@ func CustomSignetParams(challenge []byte, dnsSeeds []DNSSeed) Params {
 				ExpireTime: 1230767999, // December 31, 2008 UTC
 			},
 			DeploymentCSV: {
-				BitNumber:  29,
-				StartTime:  0,             // Always available for vote
-				ExpireTime: math.MaxInt64, // Never expires
+				BitNumber:     29,
+				StartTime:     0,             // Always available for vote
+				ExpireTime:    math.MaxInt64, // Never expires
+				ForceActiveAt: 1,
 			},
 			DeploymentSegwit: {
-				BitNumber:  29,
-				StartTime:  0,             // Always available for vote
-				ExpireTime: math.MaxInt64, // Never expires.
+				BitNumber:     29,
+				StartTime:     0,             // Always available for vote
+				ExpireTime:    math.MaxInt64, // Never expires.
+				ForceActiveAt: 1,
 			},
 			DeploymentTaproot: {
 				BitNumber:  29,
@@ -689,12 +707,38 @@ func CustomSignetParams(challenge []byte, dnsSeeds []DNSSeed) Params {
 	}
 }
 
+// CustomGenesisNet returns the network magic of a private network from the hash
+// of its genesis block.  Like the one of a custom signet network, it is defined
+// as the first four bytes of the sha256d of the hash, so that the nodes of
+// private networks with different genesis blocks don't connect to each other.
+func CustomGenesisNet(genesisHash *chainhash.Hash) wire.BitcoinNet {
+	hashDouble := chainhash.DoubleHashB(genesisHash[:])
+	return wire.BitcoinNet(binary.LittleEndian.Uint32(hashDouble[0:4]))
+}
+
 var (
 	// ErrDuplicateNet describes an error where the parameters for a Bitcoin
 	// network could not be set due to the network already being a standard
 	// network or previously-registered into this package.
 	ErrDuplicateNet = errors.New("duplicate Bitcoin network")
 
+	// ErrUnknownNet describes an error where the parameters of a Bitcoin
+	// network are looked up while the network is neither a standard nor a
+	// registered network.
+	ErrUnknownNet = errors.New("unknown Bitcoin network")
+
+	// ErrInvalidParams describes an error where the parameters for a
+	// Bitcoin network could not be set due to them lacking, or being
+	// inconsistent in, what identifies the network and its addresses.
+	ErrInvalidParams = errors.New("invalid network parameters")
+
+	// ErrConflictingMagics describes an error where the parameters for a
+	// Bitcoin network could not be set due to their address encoding or
+	// hd key magics meaning something else on a standard or registered
+	// network, which would make the addresses or keys of the networks
+	// undeterminable.
+	ErrConflictingMagics = errors.New("conflicting address encoding magics")
+
 	// ErrUnknownHDKeyID describes an error where the provided id which
 	// is intended to identify the network for a hierarchical deterministic
 	// private extended key is not registered.
@@ -705,8 +749,12 @@ var (
 	ErrInvalidHDKeyID = errors.New("invalid hd extended key version bytes")
 )
 
+// registryMtx protects the registered networks and their magics, which can be
+// registered at any time.
+var registryMtx sync.RWMutex
+
 var (
-	registeredNets       = make(map[wire.BitcoinNet]struct{})
+	registeredNets       = make(map[wire.BitcoinNet]*Params)
 	pubKeyHashAddrIDs    = make(map[byte]struct{})
 	scriptHashAddrIDs    = make(map[byte]struct{})
 	bech32SegwitPrefixes = make(map[string]struct{})
@@ -718,27 +766,56 @@ func (d DNSSeed) String() string {
 	return d.Host
 }
 
+// ClaimTrieParams returns the claimtrie parameters of the network, which are
+// the ones of the ClaimTrie field when set, or else the ones registered for
+// the network with the param package, or else the ones of the main network.
+func (p *Params) ClaimTrieParams() param.ClaimTrieParams {
+	if p.ClaimTrie != nil {
+		return *p.ClaimTrie
+	}
+	if claims, ok := param.ForNetwork(p.Net); ok {
+		return claims
+	}
+	return param.MainNet
+}
+
 // Register registers the network parameters for a Bitcoin network.  This may
 // error with ErrDuplicateNet if the network is already registered (either
 // due to a previous Register call, or the network being one of the default
-// networks).
+// networks).  It errors with ErrInvalidParams if the parameters lack a name or
+// a bech32 prefix, or their genesis hash isn't the one of their genesis block,
+// and with ErrConflictingMagics if their address or hd key magics conflict
+// with the ones of the registered networks.  Nothing is registered on error.
+//
+// The claimtrie parameters of the ClaimTrie field, when set, are registered
+// for the network with the param package as well, so that a complete custom
+// network only needs to be registered here.  The registered parameters
+// shouldn't be modified afterwards.
 //
 // Network parameters should be registered into this package by a main package
 // as early as possible.  Then, library packages may lookup networks or network
 // parameters based on inputs and work regardless of the network being standard
 // or not.
+//
+// This function is safe for concurrent access.
 func Register(params *Params) error {
-	if _, ok := registeredNets[params.Net]; ok {
-		return ErrDuplicateNet
-	}
-	registeredNets[params.Net] = struct{}{}
-	pubKeyHashAddrIDs[params.PubKeyHashAddrID] = struct{}{}
-	scriptHashAddrIDs[params.ScriptHashAddrID] = struct{}{}
+	registryMtx.Lock()
+	defer registryMtx.Unlock()
 
-	err := RegisterHDKeyID(params.HDPublicKeyID[:], params.HDPrivateKeyID[:])
-	if err != nil {
+	if err := checkRegistration(params); err != nil {
 		return err
 	}
+	if params.ClaimTrie != nil {
+		err := param.Register(params.Net, *params.ClaimTrie)
+		if err != nil {
+			return ErrDuplicateNet
+		}
+	}
+
+	registeredNets[params.Net] = params
+	pubKeyHashAddrIDs[params.PubKeyHashAddrID] = struct{}{}
+	scriptHashAddrIDs[params.ScriptHashAddrID] = struct{}{}
+	hdPrivToPubKeyIDs[params.HDPrivateKeyID] = params.HDPublicKeyID[:]
 
 	// A valid Bech32 encoded segwit address always has as prefix the
 	// human-readable part for the given net followed by '1'.
@@ -746,6 +823,84 @@ func Register(params *Params) error {
 	return nil
 }
 
+// checkRegistration returns an error when the network parameters can't be
+// registered.  It must be called with the registry lock held.
+func checkRegistration(params *Params) error {
+	if _, ok := registeredNets[params.Net]; ok {
+		return ErrDuplicateNet
+	}
+
+	switch {
+	case params.Name == "":
+		return fmt.Errorf("%w: the network has no name", ErrInvalidParams)
+	case params.Bech32HRPSegwit == "" ||
+		strings.ToLower(params.Bech32HRPSegwit) != params.Bech32HRPSegwit:
+		return fmt.Errorf("%w: the bech32 prefix %q of network %s "+
+			"isn't lowercase or is empty", ErrInvalidParams,
+			params.Bech32HRPSegwit, params.Name)
+	case params.GenesisBlock != nil && params.GenesisHash != nil &&
+		params.GenesisBlock.BlockHash() != *params.GenesisHash:
+		return fmt.Errorf("%w: the genesis hash of network %s isn't "+
+			"the one of its genesis block", ErrInvalidParams,
+			params.Name)
+	case params.PubKeyHashAddrID == params.ScriptHashAddrID:
+		return fmt.Errorf("%w: network %s prefixes its P2PKH and P2SH "+
+			"addresses alike", ErrConflictingMagics, params.Name)
+	}
+
+	if _, ok := scriptHashAddrIDs[params.PubKeyHashAddrID]; ok {
+		return fmt.Errorf("%w: the P2PKH prefix %#x of network %s "+
+			"prefixes P2SH addresses on a registered network",
+			ErrConflictingMagics, params.PubKeyHashAddrID, params.Name)
+	}
+	if _, ok := pubKeyHashAddrIDs[params.ScriptHashAddrID]; ok {
+		return fmt.Errorf("%w: the P2SH prefix %#x of network %s "+
+			"prefixes P2PKH addresses on a registered network",
+			ErrConflictingMagics, params.ScriptHashAddrID, params.Name)
+	}
+	pubKeyID, ok := hdPrivToPubKeyIDs[params.HDPrivateKeyID]
+	if ok && !bytes.Equal(pubKeyID, params.HDPublicKeyID[:]) {
+		return fmt.Errorf("%w: the hd private key magic %x of network "+
+			"%s is paired with the public key magic %x on a "+
+			"registered network", ErrConflictingMagics,
+			params.HDPrivateKeyID[:], params.Name, pubKeyID)
+	}
+	return nil
+}
+
+// LookupNet returns the parameters of the standard or registered network,
+// or ErrUnknownNet when there are none.
+//
+// This function is safe for concurrent access.
+func LookupNet(net wire.BitcoinNet) (*Params, error) {
+	registryMtx.RLock()
+	defer registryMtx.RUnlock()
+
+	params, ok := registeredNets[net]
+	if !ok {
+		return nil, ErrUnknownNet
+	}
+	return params, nil
+}
+
+// RegisteredNets returns the parameters of the standard and registered
+// networks, ordered by network magic.
+//
+// This function is safe for concurrent access.
+func RegisteredNets() []*Params {
+	registryMtx.RLock()
+	defer registryMtx.RUnlock()
+
+	nets := make([]*Params, 0, len(registeredNets))
+	for _, params := range registeredNets {
+		nets = append(nets, params)
+	}
+	sort.Slice(nets, func(i, j int) bool {
+		return nets[i].Net < nets[j].Net
+	})
+	return nets
+}
+
 // mustRegister performs the same function as Register except it panics if there
 // is an error.  This should only be called from package init functions.
 func mustRegister(params *Params) {
@@ -761,6 +916,9 @@ func mustRegister(params *Params) {
 // address is a pubkey hash address, script hash address, neither, or
 // undeterminable (if both return true).
 func IsPubKeyHashAddrID(id byte) bool {
+	registryMtx.RLock()
+	defer registryMtx.RUnlock()
+
 	_, ok := pubKeyHashAddrIDs[id]
 	return ok
 }
@@ -772,6 +930,9 @@ func IsPubKeyHashAddrID(id byte) bool {
 // address is a pubkey hash address, script hash address, neither, or
 // undeterminable (if both return true).
 func IsScriptHashAddrID(id byte) bool {
+	registryMtx.RLock()
+	defer registryMtx.RUnlock()
+
 	_, ok := scriptHashAddrIDs[id]
 	return ok
 }
@@ -781,6 +942,9 @@ func IsScriptHashAddrID(id byte) bool {
 // an address string into a specific address type.
 func IsBech32SegwitPrefix(prefix string) bool {
 	prefix = strings.ToLower(prefix)
+	registryMtx.RLock()
+	defer registryMtx.RUnlock()
+
 	_, ok := bech32SegwitPrefixes[prefix]
 	return ok
 }
@@ -804,7 +968,10 @@ func RegisterHDKeyID(hdPublicKeyID []byte, hdPrivateKeyID []byte) error {
 
 	var keyID [4]byte
 	copy(keyID[:], hdPrivateKeyID)
+
+	registryMtx.Lock()
 	hdPrivToPubKeyIDs[keyID] = hdPublicKeyID
+	registryMtx.Unlock()
 
 	return nil
 }
@@ -819,7 +986,9 @@ func HDPrivateKeyToPublicKeyID(id []byte) ([]byte, error) {
 
 	var key [4]byte
 	copy(key[:], id)
+	registryMtx.RLock()
 	pubBytes, ok := hdPrivToPubKeyIDs[key]
+	registryMtx.RUnlock()
 	if !ok {
 		return nil, ErrUnknownHDKeyID
 	}
@@ -2,11 +2,13 @@ package chaincfg_test
 
 import (
 	"bytes"
+	"errors"
 	"reflect"
 	"strings"
 	"testing"
 
 	. "github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/claimtrie/param"
 )
 
 // Define some of the required parameters for a user-registered
@@ -506,3 +508,97 @@ func TestRegister(t *testing.T) {
 		}
 	}
 }
+
+// TestRegisterConflicts ensures the network parameters which are invalid or
+// whose magics conflict with the ones of the registered networks are rejected
+// without registering anything, and that complete custom networks are
+// registered along with their claimtrie parameters.
+func TestRegisterConflicts(t *testing.T) {
+	claims := param.Regtest
+	claims.NormalizedNameForkHeight = 7
+	customNet := Params{
+		Name:             "customnet",
+		Net:              0x0badcafe,
+		GenesisBlock:     RegressionNetParams.GenesisBlock,
+		GenesisHash:      RegressionNetParams.GenesisHash,
+		PubKeyHashAddrID: 0x21,
+		ScriptHashAddrID: 0x22,
+		Bech32HRPSegwit:  "clbc",
+		HDPrivateKeyID:   [4]byte{0x0a, 0x0b, 0x0c, 0x0d},
+		HDPublicKeyID:    [4]byte{0x0e, 0x0f, 0x10, 0x11},
+		ClaimTrie:        &claims,
+	}
+
+	tests := []struct {
+		name   string
+		modify func(p *Params)
+		err    error
+	}{
+		{"no name", func(p *Params) { p.Name = "" }, ErrInvalidParams},
+		{"no bech32 prefix", func(p *Params) { p.Bech32HRPSegwit = "" }, ErrInvalidParams},
+		{"uppercase bech32 prefix", func(p *Params) { p.Bech32HRPSegwit = "CLBC" }, ErrInvalidParams},
+		{"wrong genesis hash", func(p *Params) { p.GenesisHash = MainNetParams.GenesisHash }, ErrInvalidParams},
+		{"same address magics", func(p *Params) { p.ScriptHashAddrID = p.PubKeyHashAddrID }, ErrConflictingMagics},
+		{"P2PKH magic of P2SH", func(p *Params) { p.PubKeyHashAddrID = MainNetParams.ScriptHashAddrID }, ErrConflictingMagics},
+		{"P2SH magic of P2PKH", func(p *Params) { p.ScriptHashAddrID = MainNetParams.PubKeyHashAddrID }, ErrConflictingMagics},
+		{"hd key magics", func(p *Params) { p.HDPrivateKeyID = MainNetParams.HDPrivateKeyID }, ErrConflictingMagics},
+		{"duplicate net", func(p *Params) { p.Net = RegressionNetParams.Net }, ErrDuplicateNet},
+	}
+	for _, test := range tests {
+		params := customNet
+		test.modify(&params)
+		if err := Register(&params); !errors.Is(err, test.err) {
+			t.Fatalf("%s: Register: got %v, want %v", test.name, err, test.err)
+		}
+	}
+	if IsBech32SegwitPrefix("clbc1") || IsPubKeyHashAddrID(0x21) {
+		t.Fatalf("Register: rejected parameters were registered")
+	}
+
+	// The claimtrie parameters of the network can't be registered twice
+	// either.
+	claimsNet := customNet
+	claimsNet.Net++
+	if err := param.Register(claimsNet.Net, claims); err != nil {
+		t.Fatalf("param.Register: unexpected error: %v", err)
+	}
+	if err := Register(&claimsNet); err != ErrDuplicateNet {
+		t.Fatalf("Register: got %v, want %v", err, ErrDuplicateNet)
+	}
+	if _, err := LookupNet(claimsNet.Net); err != ErrUnknownNet {
+		t.Fatalf("LookupNet: got %v, want %v", err, ErrUnknownNet)
+	}
+
+	if err := Register(&customNet); err != nil {
+		t.Fatalf("Register: unexpected error: %v", err)
+	}
+	params, err := LookupNet(customNet.Net)
+	if err != nil || params != &customNet {
+		t.Fatalf("LookupNet: got %v, %v", params, err)
+	}
+	registered, ok := param.ForNetwork(customNet.Net)
+	if !ok || registered != claims {
+		t.Fatalf("param.ForNetwork: got %v, %v", registered, ok)
+	}
+	if got := customNet.ClaimTrieParams(); got != claims {
+		t.Fatalf("ClaimTrieParams: got %v, want %v", got, claims)
+	}
+	if got := RegressionNetParams.ClaimTrieParams(); got != param.Regtest {
+		t.Fatalf("ClaimTrieParams: got %v, want %v", got, param.Regtest)
+	}
+	if !IsBech32SegwitPrefix("clbc1") || !IsScriptHashAddrID(0x22) {
+		t.Fatalf("Register: magics of the network weren't registered")
+	}
+
+	nets := RegisteredNets()
+	found := false
+	for i, params := range nets {
+		if i > 0 && nets[i-1].Net >= params.Net {
+			t.Fatalf("RegisteredNets: networks out of order")
+		}
+		found = found || params == &customNet
+	}
+	if !found {
+		t.Fatalf("RegisteredNets: custom network is missing")
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lbryio/lbcd/chaincfg"
+	claimtrieconfig "github.com/lbryio/lbcd/claimtrie/config"
+	"github.com/lbryio/lbcd/claimtrie/param"
+	"github.com/lbryio/lbcd/wire"
+)
+
+// chainParamSetter sets a chain parameter of the block chain from its value
+// given to the chainparam option.
+type chainParamSetter func(chain *chaincfg.Params, value string) error
+
+// chainParamSetters maps the names of the chain parameters of the block chain
+// which can be overridden on the test networks to their setters.  The other
+// chain parameters are the ones of the claimtrie, as known by the claimtrie
+// config package.
+var chainParamSetters = map[string]chainParamSetter{
+	"targettimeperblock": durationParam(func(c *chaincfg.Params) *time.Duration {
+		return &c.TargetTimePerBlock
+	}),
+	"targettimespan": durationParam(func(c *chaincfg.Params) *time.Duration {
+		return &c.TargetTimespan
+	}),
+	"coinbasematurity": func(c *chaincfg.Params, value string) error {
+		maturity, err := strconv.ParseUint(value, 10, 16)
+		if err != nil {
+			return err
+		}
+		c.CoinbaseMaturity = uint16(maturity)
+		return nil
+	},
+	"subsidyreductioninterval": int32Param(1, func(c *chaincfg.Params) *int32 {
+		return &c.SubsidyReductionInterval
+	}),
+	"bip34height": int32Param(0, func(c *chaincfg.Params) *int32 {
+		return &c.BIP0034Height
+	}),
+	"bip65height": int32Param(0, func(c *chaincfg.Params) *int32 {
+		return &c.BIP0065Height
+	}),
+	"bip66height": int32Param(0, func(c *chaincfg.Params) *int32 {
+		return &c.BIP0066Height
+	}),
+	"csvheight": int32Param(0, func(c *chaincfg.Params) *int32 {
+		return &c.Deployments[chaincfg.DeploymentCSV].ForceActiveAt
+	}),
+	"segwitheight": int32Param(0, func(c *chaincfg.Params) *int32 {
+		return &c.Deployments[chaincfg.DeploymentSegwit].ForceActiveAt
+	}),
+	"netmagic": func(c *chaincfg.Params, value string) error {
+		magic, err := strconv.ParseUint(value, 0, 32)
+		if err != nil {
+			return err
+		}
+		c.Net = wire.BitcoinNet(magic)
+		return nil
+	},
+	"pubkeyhashaddrid": byteParam(func(c *chaincfg.Params) *byte {
+		return &c.PubKeyHashAddrID
+	}),
+	"scripthashaddrid": byteParam(func(c *chaincfg.Params) *byte {
+		return &c.ScriptHashAddrID
+	}),
+	"privatekeyid": byteParam(func(c *chaincfg.Params) *byte {
+		return &c.PrivateKeyID
+	}),
+	"bech32hrp": func(c *chaincfg.Params, value string) error {
+		if value == "" || strings.ToLower(value) != value {
+			return fmt.Errorf("the prefix must be lowercase and not empty")
+		}
+		c.Bech32HRPSegwit = value
+		return nil
+	},
+}
+
+// int32Param returns the setter of the int32 chain parameter returned by field,
+// which can't be less than min.
+func int32Param(min int32, field func(*chaincfg.Params) *int32) chainParamSetter {
+	return func(c *chaincfg.Params, value string) error {
+		n, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return err
+		}
+		if n < int64(min) {
+			return fmt.Errorf("the value can't be less than %d", min)
+		}
+		*field(c) = int32(n)
+		return nil
+	}
+}
+
+// byteParam returns the setter of the byte chain parameter returned by field,
+// which is given in decimal or in hexadecimal with a 0x prefix.
+func byteParam(field func(*chaincfg.Params) *byte) chainParamSetter {
+	return func(c *chaincfg.Params, value string) error {
+		n, err := strconv.ParseUint(value, 0, 8)
+		if err != nil {
+			return err
+		}
+		*field(c) = byte(n)
+		return nil
+	}
+}
+
+// durationParam returns the setter of the positive duration chain parameter
+// returned by field.
+func durationParam(field func(*chaincfg.Params) *time.Duration) chainParamSetter {
+	return func(c *chaincfg.Params, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		if d <= 0 {
+			return fmt.Errorf("the duration must be positive")
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+// chainParamNames returns the sorted names of the chain parameters which can
+// be overridden.
+func chainParamNames() []string {
+	names := claimtrieconfig.ParamNames()
+	for name := range chainParamSetters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyChainParams overrides the chain parameters of the block chain and the
+// claimtrie with the passed <name>=<value> overrides, in order.
+func applyChainParams(chain *chaincfg.Params, claims *param.ClaimTrieParams,
+	overrides []string) error {
+
+	for _, override := range overrides {
+		name, value, ok := strings.Cut(override, "=")
+		if !ok {
+			return fmt.Errorf("chain parameter override %q is not "+
+				"of the form <name>=<value>", override)
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+		if claimtrieconfig.IsParam(name) {
+			err := claimtrieconfig.OverrideParam(claims, name, value)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		set, ok := chainParamSetters[name]
+		if !ok {
+			return fmt.Errorf("unknown chain parameter %q -- "+
+				"valid parameters are %s", name,
+				strings.Join(chainParamNames(), ", "))
+		}
+		if err := set(chain, value); err != nil {
+			return fmt.Errorf("invalid value %q for chain parameter "+
+				"%s: %v", value, name, err)
+		}
+	}
+	return nil
+}
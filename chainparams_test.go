@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/claimtrie/param"
+	"github.com/lbryio/lbcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyChainParams(t *testing.T) {
+
+	r := require.New(t)
+	chain := chaincfg.RegressionNetParams
+	claims := param.Regtest
+
+	err := applyChainParams(&chain, &claims, []string{
+		"targettimeperblock=2s",
+		"SegwitHeight = 500",
+		"subsidyreductioninterval=64",
+		"normalizationheight=300",
+		"extendedexpirationheight=400",
+		"netmagic=0xdab5bffb",
+		"pubkeyhashaddrid=0x30",
+		"bech32hrp=plbc",
+		"maxnamelength=16",
+	})
+	r.NoError(err)
+	r.Equal(2*time.Second, chain.TargetTimePerBlock)
+	r.Equal(int32(500), chain.Deployments[chaincfg.DeploymentSegwit].ForceActiveAt)
+	r.Equal(int32(64), chain.SubsidyReductionInterval)
+	r.Equal(int32(300), claims.NormalizedNameForkHeight)
+	r.Equal(int32(400), claims.ExtendedClaimExpirationForkHeight)
+	r.Equal(wire.BitcoinNet(0xdab5bffb), chain.Net)
+	r.Equal(byte(0x30), chain.PubKeyHashAddrID)
+	r.Equal("plbc", chain.Bech32HRPSegwit)
+	r.Equal(16, claims.MaxNameLength)
+
+	// The shared parameters are left alone.
+	r.Equal(int32(150), chaincfg.RegressionNetParams.Deployments[chaincfg.DeploymentSegwit].ForceActiveAt)
+	r.Equal(int32(250), param.Regtest.NormalizedNameForkHeight)
+
+	for _, override := range []string{
+		"segwitheight",
+		"unknown=1",
+		"segwitheight=high",
+		"activedelayfactor=0",
+		"targettimeperblock=-1s",
+		"coinbasematurity=70000",
+		"pubkeyhashaddrid=256",
+		"bech32hrp=PLBC",
+		"maxnamelength=1000",
+	} {
+		r.Error(applyChainParams(&chain, &claims, []string{override}), override)
+	}
+}
@@ -0,0 +1,694 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lbryio/lbcd/blockchain"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/claimtrie"
+	"github.com/lbryio/lbcd/wire"
+)
+
+const (
+	// claimSnapshotInterval is the number of blocks between the heights of
+	// the claimtrie snapshots written.
+	claimSnapshotInterval = 10000
+
+	// claimSnapshotDepth is the number of blocks on top of the height of a
+	// snapshot before it's written, so it isn't reorganized away.
+	claimSnapshotDepth = 100
+
+	// claimSnapshotTick is the interval between the checks for the
+	// snapshots to write, to download, and the chunk requests timed out.
+	claimSnapshotTick = 10 * time.Second
+
+	// claimChunkTimeout is how long a peer has to deliver a requested chunk
+	// before it's requested from another peer.
+	claimChunkTimeout = 2 * time.Minute
+
+	// maxClaimChunkRequests is the number of chunks requested at once while
+	// a snapshot is downloaded.
+	maxClaimChunkRequests = 4
+
+	// maxClaimSnapshotScore is the score of the getctsnap and getctchunk
+	// requests of a peer above which they are ignored.  Each request adds
+	// one to the score, which decays to half each minute, so a peer may
+	// download bursts of this many chunks and about one per second in the
+	// long run.
+	maxClaimSnapshotScore = 100
+
+	// claimSnapshotsDirname is the directory of the data directory the
+	// claimtrie snapshots served are written to.
+	claimSnapshotsDirname = "claimsnapshots"
+
+	// claimSnapshotManifest is the name of the file holding the ctsnap
+	// message of a snapshot written, next to its chunks.
+	claimSnapshotManifest = "manifest"
+)
+
+// claimSnapshotConfig is a descriptor containing the claimtrie snapshot
+// manager configuration.
+type claimSnapshotConfig struct {
+	// Chain is the chain the snapshots are written from, and loaded to
+	// while its claimtrie is deferred.
+	Chain *blockchain.BlockChain
+
+	// Dir is the directory the snapshots served are written to.
+	Dir string
+
+	// Serve writes a snapshot every claimSnapshotInterval blocks and
+	// serves it to the peers.
+	Serve bool
+}
+
+// servedClaimSnapshot is the snapshot written to disk which is served.
+type servedClaimSnapshot struct {
+	msg *wire.MsgClaimSnapshot
+	dir string
+}
+
+// claimSnapshotOffer is a snapshot advertised by peers.
+type claimSnapshotOffer struct {
+	msg   *wire.MsgClaimSnapshot
+	peers map[*serverPeer]struct{}
+}
+
+// claimChunkRequest is a chunk requested from a peer.
+type claimChunkRequest struct {
+	sp   *serverPeer
+	sent time.Time
+}
+
+// claimSnapshotDownload is the snapshot being downloaded.  The chunks are
+// requested from the peers advertising the snapshot, and appended to the
+// claimtrie in order.
+type claimSnapshotDownload struct {
+	hash        chainhash.Hash
+	msg         *wire.MsgClaimSnapshot
+	nextRequest uint32
+	nextAppend  uint32
+	retries     []uint32
+	requests    map[uint32]*claimChunkRequest
+	chunks      map[uint32][]byte
+}
+
+// claimSnapshotManager writes claimtrie snapshots and serves them to the peers,
+// and bootstraps the deferred claimtrie from the snapshots of the peers.
+//
+// The snapshots of the peers are checked against the claimtrie hashes of the
+// headers of their blocks, so only those of the main chain blocks are
+// downloaded.  The highest one is chosen, and its chunks, checked against the
+// hashes of the manifest, are appended in order to the claimtrie, which is
+// then checked against the header.  The snapshots that don't match are never
+// downloaded again.
+type claimSnapshotManager struct {
+	started  int32
+	shutdown int32
+	cfg      claimSnapshotConfig
+
+	mtx         sync.Mutex
+	served      *servedClaimSnapshot
+	writing     bool
+	writeFailed int32
+	offers      map[chainhash.Hash]*claimSnapshotOffer
+	rejected    map[chainhash.Hash]struct{}
+	download    *claimSnapshotDownload
+	loading     bool
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+// newClaimSnapshotManager returns a new instance of the claimSnapshotManager
+// struct.
+func newClaimSnapshotManager(config *claimSnapshotConfig) *claimSnapshotManager {
+	return &claimSnapshotManager{
+		cfg:      *config,
+		offers:   make(map[chainhash.Hash]*claimSnapshotOffer),
+		rejected: make(map[chainhash.Hash]struct{}),
+		quit:     make(chan struct{}),
+	}
+}
+
+// claimSnapshotHash returns the hash of the manifest of a snapshot, which
+// identifies it.
+func claimSnapshotHash(msg *wire.MsgClaimSnapshot) (chainhash.Hash, error) {
+	var buf bytes.Buffer
+	err := msg.BtcEncode(&buf, wire.ProtocolVersion, wire.BaseEncoding)
+	if err != nil {
+		return chainhash.Hash{}, err
+	}
+	return chainhash.DoubleHashH(buf.Bytes()), nil
+}
+
+// claimChunkFile returns the name of the file of a chunk of a snapshot.
+func claimChunkFile(index uint32) string {
+	return fmt.Sprintf("chunk%06d", index)
+}
+
+// Start serves the latest snapshot written, and then checks periodically for
+// the snapshots to write and to download.
+func (m *claimSnapshotManager) Start() {
+	if atomic.AddInt32(&m.started, 1) != 1 {
+		return
+	}
+
+	if m.cfg.Serve {
+		m.loadServed()
+	}
+	m.wg.Add(1)
+	go m.tickHandler()
+}
+
+// Stop stops the checks and waits for the snapshot being written to be
+// abandoned.
+func (m *claimSnapshotManager) Stop() {
+	if atomic.AddInt32(&m.shutdown, 1) != 1 {
+		return
+	}
+
+	close(m.quit)
+	m.wg.Wait()
+}
+
+// loadServed serves the latest snapshot written to disk, and removes the
+// others.
+func (m *claimSnapshotManager) loadServed() {
+	entries, err := os.ReadDir(m.cfg.Dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			srvrLog.Warnf("Unable to read the claimtrie snapshots: %v", err)
+		}
+		return
+	}
+
+	var served *servedClaimSnapshot
+	for _, entry := range entries {
+		dir := filepath.Join(m.cfg.Dir, entry.Name())
+		height, err := strconv.ParseInt(entry.Name(), 10, 32)
+		if err != nil || (served != nil && int32(height) <= served.msg.Height) {
+			os.RemoveAll(dir)
+			continue
+		}
+
+		msg := &wire.MsgClaimSnapshot{}
+		manifest, err := os.ReadFile(filepath.Join(dir, claimSnapshotManifest))
+		if err == nil {
+			err = msg.BtcDecode(bytes.NewReader(manifest),
+				wire.ProtocolVersion, wire.BaseEncoding)
+		}
+		if err != nil || !m.cfg.Chain.MainChainHasBlock(&msg.BlockHash) {
+			os.RemoveAll(dir)
+			continue
+		}
+
+		if served != nil {
+			os.RemoveAll(served.dir)
+		}
+		served = &servedClaimSnapshot{msg: msg, dir: dir}
+	}
+
+	if served != nil {
+		srvrLog.Infof("Serving the claimtrie snapshot at height %d",
+			served.msg.Height)
+	}
+	m.mtx.Lock()
+	m.served = served
+	m.mtx.Unlock()
+}
+
+// tickHandler writes the snapshots, starts the downloads and retries the
+// chunk requests timed out periodically.  It must be run as a goroutine.
+func (m *claimSnapshotManager) tickHandler() {
+	ticker := time.NewTicker(claimSnapshotTick)
+	defer ticker.Stop()
+
+out:
+	for {
+		select {
+		case <-ticker.C:
+			if m.cfg.Serve {
+				m.maybeWrite()
+			}
+			m.mtx.Lock()
+			m.retryRequests()
+			m.maybeDownload()
+			m.mtx.Unlock()
+
+		case <-m.quit:
+			break out
+		}
+	}
+	m.wg.Done()
+}
+
+// maybeWrite starts writing the snapshot at the latest height deep enough in
+// the main chain, unless it's already served.
+func (m *claimSnapshotManager) maybeWrite() {
+	chain := m.cfg.Chain
+	if chain.ClaimTrieDeferred() {
+		return
+	}
+	best := chain.BestSnapshot()
+	height := (best.Height - claimSnapshotDepth) / claimSnapshotInterval *
+		claimSnapshotInterval
+	if height <= 0 {
+		return
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.writing || height == m.writeFailed ||
+		(m.served != nil && m.served.msg.Height >= height) {
+		return
+	}
+	m.writing = true
+	m.wg.Add(1)
+	go m.write(height)
+}
+
+// write writes the snapshot at height to disk and serves it in place of the
+// previous one.  It must be run as a goroutine.
+func (m *claimSnapshotManager) write(height int32) {
+	defer m.wg.Done()
+
+	start := time.Now()
+	dir := filepath.Join(m.cfg.Dir, strconv.Itoa(int(height)))
+	msg, err := m.writeFiles(dir, height)
+
+	m.mtx.Lock()
+	m.writing = false
+	if err != nil {
+		m.writeFailed = height
+		m.mtx.Unlock()
+		os.RemoveAll(dir)
+		srvrLog.Warnf("Unable to write the claimtrie snapshot at height "+
+			"%d: %v", height, err)
+		return
+	}
+	old := m.served
+	m.served = &servedClaimSnapshot{msg: msg, dir: dir}
+	m.mtx.Unlock()
+
+	if old != nil {
+		os.RemoveAll(old.dir)
+	}
+	srvrLog.Infof("Wrote the claimtrie snapshot at height %d in %d %s "+
+		"(%v)", height, len(msg.ChunkHashes),
+		pickNoun(uint64(len(msg.ChunkHashes)), "chunk", "chunks"),
+		time.Since(start).Round(time.Millisecond))
+}
+
+// writeFiles writes the chunks and the manifest of the snapshot at height to
+// dir, and returns the manifest.
+func (m *claimSnapshotManager) writeFiles(dir string, height int32) (*wire.MsgClaimSnapshot, error) {
+	tmpDir := dir + ".tmp"
+	os.RemoveAll(tmpDir)
+	if err := os.MkdirAll(tmpDir, 0700); err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var hashes []chainhash.Hash
+	chain := m.cfg.Chain
+	blockHash, err := chain.WriteClaimTrieSnapshot(height, func(chunk []byte) error {
+		select {
+		case <-m.quit:
+			return errors.New("server shutting down")
+		default:
+		}
+
+		name := claimChunkFile(uint32(len(hashes)))
+		err := os.WriteFile(filepath.Join(tmpDir, name), chunk, 0600)
+		if err != nil {
+			return err
+		}
+		hashes = append(hashes, claimtrie.SnapshotChunkHash(chunk))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	header, err := chain.HeaderByHash(blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := wire.NewMsgClaimSnapshot(height, blockHash, &header.ClaimTrie)
+	for i := range hashes {
+		if err := msg.AddChunkHash(&hashes[i]); err != nil {
+			return nil, err
+		}
+	}
+	var buf bytes.Buffer
+	err = msg.BtcEncode(&buf, wire.ProtocolVersion, wire.BaseEncoding)
+	if err != nil {
+		return nil, err
+	}
+	err = os.WriteFile(filepath.Join(tmpDir, claimSnapshotManifest),
+		buf.Bytes(), 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	os.RemoveAll(dir)
+	return msg, os.Rename(tmpDir, dir)
+}
+
+// Served returns the manifest of the snapshot served, or a manifest without
+// chunks when there is none.
+//
+// This function is safe for concurrent access.
+func (m *claimSnapshotManager) Served() *wire.MsgClaimSnapshot {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.served == nil {
+		var zeroHash chainhash.Hash
+		return wire.NewMsgClaimSnapshot(0, &zeroHash, &zeroHash)
+	}
+	return m.served.msg
+}
+
+// Chunk returns the chunk index of the snapshot served at the block hash, or
+// nil when it's unavailable.
+//
+// This function is safe for concurrent access.
+func (m *claimSnapshotManager) Chunk(hash *chainhash.Hash, index uint32) []byte {
+	m.mtx.Lock()
+	served := m.served
+	m.mtx.Unlock()
+
+	if served == nil || served.msg.BlockHash != *hash ||
+		index >= uint32(len(served.msg.ChunkHashes)) {
+		return nil
+	}
+	chunk, err := os.ReadFile(filepath.Join(served.dir, claimChunkFile(index)))
+	if err != nil {
+		srvrLog.Debugf("Unable to read claimtrie snapshot chunk %d: %v",
+			index, err)
+		return nil
+	}
+	return chunk
+}
+
+// PeerConnected requests the snapshot of the peer when it serves them and the
+// claimtrie is deferred.
+//
+// This function is safe for concurrent access.
+func (m *claimSnapshotManager) PeerConnected(sp *serverPeer) {
+	if !hasServices(sp.Services(), wire.SFNodeClaimSnapshots) ||
+		!m.cfg.Chain.ClaimTrieDeferred() {
+		return
+	}
+	sp.QueueMessage(wire.NewMsgGetClaimSnapshot(), nil)
+}
+
+// Offered records the snapshot advertised by the peer, and starts downloading
+// it when it's usable.
+//
+// This function is safe for concurrent access.
+func (m *claimSnapshotManager) Offered(sp *serverPeer, msg *wire.MsgClaimSnapshot) {
+	if len(msg.ChunkHashes) == 0 {
+		return
+	}
+	hash, err := claimSnapshotHash(msg)
+	if err != nil {
+		return
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if _, ok := m.rejected[hash]; ok {
+		return
+	}
+	m.removeOffers(sp)
+	offer := m.offers[hash]
+	if offer == nil {
+		offer = &claimSnapshotOffer{
+			msg:   msg,
+			peers: make(map[*serverPeer]struct{}),
+		}
+		m.offers[hash] = offer
+	}
+	offer.peers[sp] = struct{}{}
+	srvrLog.Debugf("Peer %v offers the claimtrie snapshot at height %d",
+		sp, msg.Height)
+
+	if m.download == nil {
+		m.maybeDownload()
+	} else if m.download.hash == hash {
+		m.requestChunks()
+	}
+}
+
+// removeOffers removes the peer from the peers advertising the snapshots.
+//
+// This function MUST be called with the manager lock held.
+func (m *claimSnapshotManager) removeOffers(sp *serverPeer) {
+	for hash, offer := range m.offers {
+		delete(offer.peers, sp)
+		if len(offer.peers) == 0 {
+			delete(m.offers, hash)
+		}
+	}
+}
+
+// maybeDownload starts downloading the highest snapshot of a main chain block
+// advertised by the peers, when the claimtrie is deferred.
+//
+// This function MUST be called with the manager lock held.
+func (m *claimSnapshotManager) maybeDownload() {
+	chain := m.cfg.Chain
+	if m.download != nil || m.loading || !chain.ClaimTrieDeferred() {
+		return
+	}
+
+	var best *claimSnapshotOffer
+	var bestHash chainhash.Hash
+	for hash, offer := range m.offers {
+		msg := offer.msg
+		if best != nil && msg.Height <= best.msg.Height {
+			continue
+		}
+		height, err := chain.BlockHeightByHash(&msg.BlockHash)
+		if err != nil || height != msg.Height {
+			continue
+		}
+		header, err := chain.HeaderByHash(&msg.BlockHash)
+		if err != nil || header.ClaimTrie != msg.ClaimTrie {
+			continue
+		}
+		best, bestHash = offer, hash
+	}
+	if best == nil {
+		return
+	}
+
+	if err := chain.BeginClaimTrieSnapshot(); err != nil {
+		srvrLog.Errorf("Unable to begin the claimtrie snapshot: %v", err)
+		return
+	}
+	srvrLog.Infof("Downloading the claimtrie snapshot at height %d in %d "+
+		"%s", best.msg.Height, len(best.msg.ChunkHashes),
+		pickNoun(uint64(len(best.msg.ChunkHashes)), "chunk", "chunks"))
+	m.download = &claimSnapshotDownload{
+		hash:     bestHash,
+		msg:      best.msg,
+		requests: make(map[uint32]*claimChunkRequest),
+		chunks:   make(map[uint32][]byte),
+	}
+	m.requestChunks()
+}
+
+// requestChunks requests the next chunks of the snapshot being downloaded from
+// the peers advertising it, up to maxClaimChunkRequests at once.  The download
+// is abandoned when no peer advertises it anymore.
+//
+// This function MUST be called with the manager lock held.
+func (m *claimSnapshotManager) requestChunks() {
+	d := m.download
+	if d == nil || m.loading {
+		return
+	}
+	offer := m.offers[d.hash]
+	if offer == nil {
+		srvrLog.Infof("No peer serves the claimtrie snapshot at height "+
+			"%d anymore", d.msg.Height)
+		m.download = nil
+		m.maybeDownload()
+		return
+	}
+
+	peers := make([]*serverPeer, 0, len(offer.peers))
+	for sp := range offer.peers {
+		peers = append(peers, sp)
+	}
+	for len(d.requests) < maxClaimChunkRequests {
+		var index uint32
+		switch {
+		case len(d.retries) > 0:
+			index = d.retries[0]
+			d.retries = d.retries[1:]
+		case d.nextRequest < uint32(len(d.msg.ChunkHashes)):
+			index = d.nextRequest
+			d.nextRequest++
+		default:
+			return
+		}
+
+		sp := peers[randomUint16Number(uint16(len(peers)))]
+		d.requests[index] = &claimChunkRequest{sp: sp, sent: time.Now()}
+		sp.QueueMessage(wire.NewMsgGetClaimSnapshotChunk(&d.msg.BlockHash,
+			index), nil)
+	}
+}
+
+// retryRequests requests again the chunks the peers didn't deliver in time,
+// from other peers.
+//
+// This function MUST be called with the manager lock held.
+func (m *claimSnapshotManager) retryRequests() {
+	d := m.download
+	if d == nil {
+		return
+	}
+	for index, req := range d.requests {
+		if time.Since(req.sent) < claimChunkTimeout {
+			continue
+		}
+		srvrLog.Debugf("Peer %v didn't deliver claimtrie snapshot chunk "+
+			"%d in time", req.sp, index)
+		delete(d.requests, index)
+		d.retries = append(d.retries, index)
+		m.removeOffers(req.sp)
+	}
+	m.requestChunks()
+}
+
+// Received appends the chunk delivered by the peer to the claimtrie, with the
+// chunks before it, and loads the snapshot once all are appended.
+//
+// This function is safe for concurrent access.
+func (m *claimSnapshotManager) Received(sp *serverPeer, msg *wire.MsgClaimSnapshotChunk) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	d := m.download
+	if d == nil || msg.BlockHash != d.msg.BlockHash {
+		return
+	}
+	req := d.requests[msg.Index]
+	if req == nil || req.sp != sp {
+		return
+	}
+	delete(d.requests, msg.Index)
+
+	// The peer no longer serves the snapshot when the chunk has no data.
+	if len(msg.Data) == 0 {
+		d.retries = append(d.retries, msg.Index)
+		m.removeOffers(sp)
+		m.requestChunks()
+		return
+	}
+	if claimtrie.SnapshotChunkHash(msg.Data) != d.msg.ChunkHashes[msg.Index] {
+		d.retries = append(d.retries, msg.Index)
+		m.removeOffers(sp)
+		sp.addBanScore(100, 0, "invalid claimtrie snapshot chunk")
+		m.requestChunks()
+		return
+	}
+
+	d.chunks[msg.Index] = msg.Data
+	for {
+		chunk, ok := d.chunks[d.nextAppend]
+		if !ok {
+			break
+		}
+		err := m.cfg.Chain.AppendClaimTrieSnapshotChunk(d.msg.Height, chunk)
+		if err != nil {
+			m.reject(err)
+			return
+		}
+		delete(d.chunks, d.nextAppend)
+		d.nextAppend++
+	}
+
+	if d.nextAppend < uint32(len(d.msg.ChunkHashes)) {
+		m.requestChunks()
+		return
+	}
+	m.loading = true
+	m.wg.Add(1)
+	go m.load(d)
+}
+
+// reject abandons the download of the snapshot, which is never downloaded
+// again.
+//
+// This function MUST be called with the manager lock held.
+func (m *claimSnapshotManager) reject(err error) {
+	d := m.download
+	srvrLog.Warnf("Rejecting the claimtrie snapshot at height %d: %v",
+		d.msg.Height, err)
+	m.rejected[d.hash] = struct{}{}
+	delete(m.offers, d.hash)
+	m.download = nil
+	m.maybeDownload()
+}
+
+// load loads the claimtrie from the snapshot downloaded.  It must be run as a
+// goroutine.
+func (m *claimSnapshotManager) load(d *claimSnapshotDownload) {
+	defer m.wg.Done()
+
+	start := time.Now()
+	err := m.cfg.Chain.LoadClaimTrieSnapshot(&d.msg.BlockHash)
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	// The claimtrie is still deferred and empty when the snapshot failed
+	// to load or to catch up with the main chain.
+	m.loading = false
+	if err != nil {
+		m.reject(err)
+		return
+	}
+	m.download = nil
+	m.offers = make(map[chainhash.Hash]*claimSnapshotOffer)
+	srvrLog.Infof("Bootstrapped the claimtrie from the snapshot at height "+
+		"%d (%v)", d.msg.Height, time.Since(start).Round(time.Millisecond))
+}
+
+// PeerDone forgets the snapshot advertised by the peer, and requests the
+// chunks it didn't deliver from other peers.
+//
+// This function is safe for concurrent access.
+func (m *claimSnapshotManager) PeerDone(sp *serverPeer) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.removeOffers(sp)
+	d := m.download
+	if d == nil {
+		return
+	}
+	for index, req := range d.requests {
+		if req.sp == sp {
+			delete(d.requests, index)
+			d.retries = append(d.retries, index)
+		}
+	}
+	m.requestChunks()
+}
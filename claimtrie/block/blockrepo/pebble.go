@@ -8,6 +8,7 @@ import (
 	"github.com/lbryio/lbcd/chaincfg/chainhash"
 
 	"github.com/cockroachdb/pebble"
+	"github.com/lbryio/lbcd/claimtrie/pebbleopts"
 )
 
 type Pebble struct {
@@ -56,7 +57,7 @@ func (repo *Pebble) Set(height int32, hash *chainhash.Hash) error {
 	key := make([]byte, 4)
 	binary.BigEndian.PutUint32(key, uint32(height))
 
-	return errors.WithStack(repo.db.Set(key, hash[:], pebble.NoSync))
+	return errors.WithStack(repo.db.Set(key, hash[:], pebbleopts.WriteOptions))
 }
 
 func (repo *Pebble) Delete(heightMin, heightMax int32) error {
@@ -66,7 +67,7 @@ func (repo *Pebble) Delete(heightMin, heightMax int32) error {
 	upper := make([]byte, 4)
 	binary.BigEndian.PutUint32(upper, uint32(heightMax)+1)
 
-	return errors.Wrap(repo.db.DeleteRange(lower, upper, pebble.NoSync), "on range delete")
+	return errors.Wrap(repo.db.DeleteRange(lower, upper, pebbleopts.WriteOptions), "on range delete")
 }
 
 func (repo *Pebble) Close() error {
@@ -85,3 +86,13 @@ func (repo *Pebble) Flush() error {
 	_, err := repo.db.AsyncFlush()
 	return err
 }
+
+func (repo *Pebble) Metrics() *pebble.Metrics {
+	return repo.db.Metrics()
+}
+
+// Checkpoint writes a consistent copy of the repo to dir, hard linking the
+// files which don't change.
+func (repo *Pebble) Checkpoint(dir string) error {
+	return errors.Wrap(repo.db.Checkpoint(dir), "on checkpoint")
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/vmihailenco/msgpack/v5"
 
 	"github.com/cockroachdb/pebble"
+	"github.com/lbryio/lbcd/claimtrie/pebbleopts"
 )
 
 type Pebble struct {
@@ -37,7 +38,7 @@ func (repo *Pebble) Save(height int32, changes []change.Change) error {
 		return errors.Wrap(err, "in marshaller")
 	}
 
-	err = repo.db.Set(key[:], value, pebble.NoSync)
+	err = repo.db.Set(key[:], value, pebbleopts.WriteOptions)
 	return errors.Wrap(err, "in set")
 }
 
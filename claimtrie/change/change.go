@@ -3,6 +3,7 @@ package change
 import (
 	"bytes"
 	"encoding/binary"
+	"sort"
 
 	"github.com/lbryio/lbcd/chaincfg/chainhash"
 	"github.com/lbryio/lbcd/wire"
@@ -77,7 +78,13 @@ func (c *Change) Marshal(enc *bytes.Buffer) error {
 	if c.SpentChildren != nil {
 		binary.BigEndian.PutUint32(temp[:4], uint32(len(c.SpentChildren)))
 		enc.Write(temp[:4])
+		// sorted so that the same changes always marshal the same, as the claimtrie snapshots rely on
+		keys := make([]string, 0, len(c.SpentChildren))
 		for key := range c.SpentChildren {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
 			keySize := uint16(len(key))
 			binary.BigEndian.PutUint16(temp[:2], keySize) // technically limited to 255; not sure we trust it
 			enc.Write(temp[:2])
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"sort"
 
+	"github.com/cockroachdb/pebble"
 	"github.com/pkg/errors"
 
 	"github.com/lbryio/lbcd/claimtrie/block"
@@ -36,6 +37,10 @@ type ClaimTrie struct {
 	// due to stake expiration or delayed activation.
 	temporalRepo temporal.Repo
 
+	// Repository for the changes of nodes, which the snapshots are read from
+	// and loaded to.
+	nodeRepo node.Repo
+
 	// Cache layer of Nodes.
 	nodeManager node.Manager
 
@@ -48,6 +53,9 @@ type ClaimTrie struct {
 	// Registrered cleanup functions which are invoked in the Close() in reverse order.
 	cleanups []func() error
 
+	// Pebble repositories, which report their metrics.
+	repos []namedRepo
+
 	// claimLogger communicates progress of claimtrie rebuild.
 	claimLogger *claimProgressLogger
 }
@@ -55,6 +63,7 @@ type ClaimTrie struct {
 func New(cfg config.Config) (*ClaimTrie, error) {
 
 	var cleanups []func() error
+	var repos []namedRepo
 
 	// The passed in cfg.DataDir has been prepended with netname.
 	dataDir := filepath.Join(cfg.DataDir, "claim_dbs")
@@ -65,6 +74,7 @@ func New(cfg config.Config) (*ClaimTrie, error) {
 		return nil, errors.Wrap(err, "creating block repo")
 	}
 	cleanups = append(cleanups, blockRepo.Close)
+	repos = append(repos, namedRepo{"block", cfg.BlockRepoPebble.Path, blockRepo})
 	err = blockRepo.Set(0, merkletrie.EmptyTrieHash)
 	if err != nil {
 		return nil, errors.Wrap(err, "setting block repo genesis")
@@ -76,6 +86,7 @@ func New(cfg config.Config) (*ClaimTrie, error) {
 		return nil, errors.Wrap(err, "creating temporal repo")
 	}
 	cleanups = append(cleanups, temporalRepo.Close)
+	repos = append(repos, namedRepo{"temporal", cfg.TemporalRepoPebble.Path, temporalRepo})
 
 	// Initialize repository for changes to nodes.
 	// The cleanup is delegated to the Node Manager.
@@ -84,6 +95,7 @@ func New(cfg config.Config) (*ClaimTrie, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "creating node repo")
 	}
+	repos = append(repos, namedRepo{"node", cfg.NodeRepoPebble.Path, nodeRepo})
 
 	baseManager, err := node.NewBaseManager(nodeRepo)
 	if err != nil {
@@ -104,6 +116,7 @@ func New(cfg config.Config) (*ClaimTrie, error) {
 		if err != nil {
 			return nil, errors.Wrap(err, "creating trie repo")
 		}
+		repos = append(repos, namedRepo{"merkletrie", cfg.MerkleTrieRepoPebble.Path, trieRepo})
 
 		persistentTrie := merkletrie.NewPersistentTrie(trieRepo)
 		cleanups = append(cleanups, persistentTrie.Close)
@@ -120,6 +133,7 @@ func New(cfg config.Config) (*ClaimTrie, error) {
 		blockRepo:    blockRepo,
 		temporalRepo: temporalRepo,
 
+		nodeRepo:    nodeRepo,
 		nodeManager: nodeManager,
 		merkleTrie:  trie,
 
@@ -127,6 +141,7 @@ func New(cfg config.Config) (*ClaimTrie, error) {
 	}
 
 	ct.cleanups = cleanups
+	ct.repos = repos
 
 	if previousHeight > 0 {
 		hash, err := blockRepo.Get(previousHeight)
@@ -387,6 +402,183 @@ func (ct *ClaimTrie) MerkleHash() *chainhash.Hash {
 	return ct.merkleTrie.MerkleHash()
 }
 
+// ClaimHashProof returns the claims hash of name and its merkle proof against
+// the current MerkleHash. Proofs require the RamTrie and the all-claims hash fork.
+func (ct *ClaimTrie) ClaimHashProof(name []byte) (*chainhash.Hash, []merkletrie.ProofPair, error) {
+	if ct.height < param.ActiveParams.AllClaimsInMerkleForkHeight {
+		return nil, nil, errors.New("proofs are not supported before the all-claims hash fork")
+	}
+	rt, ok := ct.merkleTrie.(*merkletrie.RamTrie)
+	if !ok {
+		return nil, nil, errors.New("proofs are only supported by the RAM trie")
+	}
+	return rt.ProofAllClaims(name)
+}
+
+// BestClaimProof returns the claim controlling name, the height it took over
+// name at, and the merkle proof of its hash against the claims hash of name,
+// in which it comes first. It returns a nil outpoint if name has no active claims.
+func (ct *ClaimTrie) BestClaimProof(name []byte) (*wire.OutPoint, int32, []merkletrie.ProofPair, error) {
+	if ct.height < param.ActiveParams.AllClaimsInMerkleForkHeight {
+		return nil, 0, nil, errors.New("proofs are not supported before the all-claims hash fork")
+	}
+	n, err := ct.nodeManager.NodeAt(ct.height, name)
+	if err != nil || n == nil {
+		return nil, 0, nil, err
+	}
+
+	// The active claims are hashed in the order of their bids, as by the
+	// HashV2Manager.
+	n.SortClaimsByBid()
+	var best *node.Claim
+	hashes := make([]*chainhash.Hash, 0, len(n.Claims))
+	for _, c := range n.Claims {
+		if c.Status != node.Activated {
+			continue
+		}
+		if best == nil {
+			best = c
+		}
+		hashes = append(hashes, node.ClaimHash(c.OutPoint, n.TakenOverAt))
+	}
+	if best == nil {
+		return nil, 0, nil, nil
+	}
+	if n.BestClaim == nil || n.BestClaim.OutPoint != best.OutPoint {
+		return nil, 0, nil, errors.New("the best claim is not the first by bid")
+	}
+
+	op := best.OutPoint
+	return &op, n.TakenOverAt, merkletrie.MerkleBranch(hashes, 0), nil
+}
+
+// TrieNodeCount returns the number of nodes of the merkle trie held in memory,
+// or zero when the merkle trie is stored on disk.
+func (ct *ClaimTrie) TrieNodeCount() int {
+	rt, ok := ct.merkleTrie.(*merkletrie.RamTrie)
+	if !ok {
+		return 0
+	}
+	return rt.NodeCount()
+}
+
+// RepoMetrics are the metrics of a Pebble repository since it was opened.
+type RepoMetrics struct {
+	Name    string
+	Metrics *pebble.Metrics
+}
+
+type namedRepo struct {
+	name string
+	path string
+	repo interface {
+		Metrics() *pebble.Metrics
+		Checkpoint(dir string) error
+	}
+}
+
+// RepoMetrics returns the metrics of the Pebble repositories.
+func (ct *ClaimTrie) RepoMetrics() []RepoMetrics {
+	metrics := make([]RepoMetrics, 0, len(ct.repos))
+	for _, r := range ct.repos {
+		metrics = append(metrics, RepoMetrics{r.name, r.repo.Metrics()})
+	}
+	return metrics
+}
+
+// Backup writes a consistent copy of the Pebble repositories to dir, laid out
+// as the claim_dbs directory of the data directory.  The ClaimTrie must not be
+// updated during the backup.
+func (ct *ClaimTrie) Backup(dir string) error {
+	for _, r := range ct.repos {
+		err := r.repo.Checkpoint(filepath.Join(dir, r.path))
+		if err != nil {
+			return errors.Wrapf(err, "backing up %s repo", r.name)
+		}
+	}
+	return nil
+}
+
+// MerkleHashAt returns the Merkle hash of the ClaimTrie recorded at a height at
+// or below the current one.
+func (ct *ClaimTrie) MerkleHashAt(height int32) (*chainhash.Hash, error) {
+	return ct.blockRepo.Get(height)
+}
+
+// Rehasher recomputes the Merkle hash of the ClaimTrie at a height from a
+// snapshot of its names, so that the ClaimTrie can be updated meanwhile.
+type Rehasher struct {
+	repo        *noderepo.PebbleSnapshot
+	nodeManager node.Manager
+	height      int32
+}
+
+// NewRehasher returns a Rehasher of the ClaimTrie at the current height, which
+// must be closed once done with.  The ClaimTrie must not be updated while the
+// Rehasher is created.
+func (ct *ClaimTrie) NewRehasher() (*Rehasher, error) {
+	repo, ok := ct.nodeRepo.(*noderepo.Pebble)
+	if !ok {
+		return nil, errors.New("the node repo can't be snapshotted")
+	}
+	snapshot := repo.Snapshot()
+	return &Rehasher{
+		repo:        snapshot,
+		nodeManager: node.NewReadOnlyManager(snapshot, ct.height),
+		height:      ct.height,
+	}, nil
+}
+
+// Rehash recomputes the Merkle hash of the ClaimTrie at the height of the
+// Rehasher from the claims of all the names, in a separate RAM trie, so that
+// none of the hashes stored in the trie are relied on.  The progress function
+// is called with the number of names hashed so far.
+func (r *Rehasher) Rehash(interrupt <-chan struct{}, progress func(names int)) (*chainhash.Hash, error) {
+	rt := merkletrie.NewRamTrie()
+	names := 0
+	var err error
+	r.nodeManager.IterateNames(func(name []byte) bool {
+		if interruptRequested(interrupt) {
+			return false
+		}
+		clone := make([]byte, len(name))
+		copy(clone, name)
+		var hash *chainhash.Hash
+		hash, err = r.nodeManager.NodeHash(clone)
+		if err != nil {
+			err = errors.Wrapf(err, "in hash of %s", clone)
+			return false
+		}
+		rt.Update(clone, hash, false)
+		names++
+		if progress != nil {
+			progress(names)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if interruptRequested(interrupt) {
+		return nil, errors.New("rehash interrupted")
+	}
+
+	if r.height >= param.ActiveParams.AllClaimsInMerkleForkHeight {
+		return rt.MerkleHashAllClaims(), nil
+	}
+	return rt.MerkleHash(), nil
+}
+
+// Height returns the height the Rehasher recomputes the hash at.
+func (r *Rehasher) Height() int32 {
+	return r.height
+}
+
+// Close releases the snapshot of the names of the Rehasher.
+func (r *Rehasher) Close() error {
+	return r.repo.Close()
+}
+
 // Height returns the current block height.
 func (ct *ClaimTrie) Height() int32 {
 	return ct.height
@@ -417,6 +609,12 @@ func (ct *ClaimTrie) NodeAt(height int32, name []byte) (*node.Node, error) {
 	return ct.nodeManager.NodeAt(height, name)
 }
 
+// SimulateSupport simulates a support of amount for the claim of name with the
+// passed ID added in the next block.
+func (ct *ClaimTrie) SimulateSupport(name []byte, claimID change.ClaimID, amount int64) (*node.SupportSimulation, error) {
+	return ct.nodeManager.SimulateSupport(name, claimID, amount)
+}
+
 func (ct *ClaimTrie) NamesChangedInBlock(height int32) ([]string, error) {
 	hits, err := ct.temporalRepo.NodesAt(height)
 	r := make([]string, len(hits))
@@ -8,6 +8,7 @@ import (
 	"github.com/lbryio/lbcd/claimtrie/change"
 	"github.com/lbryio/lbcd/claimtrie/config"
 	"github.com/lbryio/lbcd/claimtrie/merkletrie"
+	"github.com/lbryio/lbcd/claimtrie/node"
 	"github.com/lbryio/lbcd/claimtrie/param"
 
 	"github.com/lbryio/lbcd/chaincfg/chainhash"
@@ -263,6 +264,55 @@ func TestRebuild(t *testing.T) {
 	r.Equal(*m, *m2)
 }
 
+func TestRehash(t *testing.T) {
+	r := require.New(t)
+	setup(t)
+	c := cfg
+	c.RamTrie = false
+	ct, err := New(c)
+	r.NoError(err)
+	defer ct.Close()
+
+	hash := chainhash.HashH([]byte{1, 2, 3})
+	for i, name := range []string{"test", "tester", "other"} {
+		o := wire.OutPoint{Hash: hash, Index: uint32(i)}
+		err = ct.AddClaim([]byte(name), o, change.NewClaimID(o), int64(i+1))
+		r.NoError(err)
+	}
+	incrementBlock(r, ct, 1)
+	o := wire.OutPoint{Hash: hash, Index: 2}
+	err = ct.SpendClaim([]byte("other"), o, change.NewClaimID(o))
+	r.NoError(err)
+	incrementBlock(r, ct, 1)
+
+	rehasher, err := ct.NewRehasher()
+	r.NoError(err)
+	defer func() {
+		r.NoError(rehasher.Close())
+	}()
+	root := *ct.MerkleHash()
+
+	// The names updated after the Rehasher is created aren't rehashed.
+	err = ct.AddClaim([]byte("another"), o, change.NewClaimID(o), 4)
+	r.NoError(err)
+	incrementBlock(r, ct, 1)
+
+	var names int
+	m, err := rehasher.Rehash(nil, func(n int) { names = n })
+	r.NoError(err)
+	r.Equal(root, *m)
+	r.Equal(int32(2), rehasher.Height())
+	r.Equal(3, names)
+	m, err = ct.MerkleHashAt(2)
+	r.NoError(err)
+	r.Equal(root, *m)
+
+	interrupt := make(chan struct{})
+	close(interrupt)
+	_, err = rehasher.Rehash(interrupt, nil)
+	r.Error(err)
+}
+
 func BenchmarkClaimTrie_AppendBlock256(b *testing.B) {
 
 	addUpdateRemoveRandoms(b, 256)
@@ -945,6 +995,45 @@ func TestStableTrieHash(t *testing.T) {
 	}
 }
 
+func TestPersistentTrieRestore(t *testing.T) {
+	r := require.New(t)
+	setup(t)
+	c := cfg
+	c.RamTrie = false
+
+	ct, err := New(c)
+	r.NoError(err)
+
+	hash := chainhash.HashH([]byte{1, 2, 3})
+	for i, name := range []string{"test", "tester", "other"} {
+		o := wire.OutPoint{Hash: hash, Index: uint32(i)}
+		err = ct.AddClaim([]byte(name), o, change.NewClaimID(o), int64(i+1))
+		r.NoError(err)
+	}
+	incrementBlock(r, ct, 1)
+	h := ct.MerkleHash()
+
+	o := wire.OutPoint{Hash: hash, Index: 3}
+	err = ct.AddClaim([]byte("test"), o, change.NewClaimID(o), 10)
+	r.NoError(err)
+	o2 := wire.OutPoint{Hash: hash, Index: 2}
+	err = ct.SpendClaim([]byte("other"), o2, change.NewClaimID(o2))
+	r.NoError(err)
+	incrementBlock(r, ct, 1)
+	r.NotEqual(h.String(), ct.MerkleHash().String())
+
+	incrementBlock(r, ct, -1)
+	r.Equal(h.String(), ct.MerkleHash().String())
+	ct.Close()
+
+	// New fails unless the trie restores to the hash of the last block.
+	ct, err = New(c)
+	r.NoError(err)
+	defer ct.Close()
+	r.Equal(int32(1), ct.Height())
+	r.Equal(h.String(), ct.MerkleHash().String())
+}
+
 func TestBlock884431(t *testing.T) {
 	r := require.New(t)
 	setup(t)
@@ -1025,3 +1114,129 @@ func TestBlock884431(t *testing.T) {
 	r.NoError(err)
 	r.Equal(o11.String(), n.BestClaim.OutPoint.String())
 }
+
+func TestBestClaimProof(t *testing.T) {
+	r := require.New(t)
+	setup(t)
+	param.ActiveParams.AllClaimsInMerkleForkHeight = 0
+
+	ct, err := New(cfg)
+	r.NoError(err)
+	defer ct.Close()
+
+	incrementBlock(r, ct, 1)
+
+	var best wire.OutPoint
+	for i, amt := range []int64{10, 30, 20} {
+		o := wire.OutPoint{Hash: chainhash.HashH([]byte{byte(i)}), Index: uint32(i)}
+		err = ct.AddClaim(b("test"), o, change.NewClaimID(o), amt)
+		r.NoError(err)
+		if amt == 30 {
+			best = o
+		}
+	}
+	o := wire.OutPoint{Hash: chainhash.HashH([]byte{9}), Index: 0}
+	err = ct.AddClaim(b("tester"), o, change.NewClaimID(o), 5)
+	r.NoError(err)
+	incrementBlock(r, ct, 1)
+
+	claimsHash, pairs, err := ct.ClaimHashProof(b("test"))
+	r.NoError(err)
+	r.Equal(ct.MerkleHash(), merkletrie.VerifyProof(claimsHash, pairs))
+
+	op, takeover, claimPairs, err := ct.BestClaimProof(b("test"))
+	r.NoError(err)
+	r.Equal(best, *op)
+	r.Equal(int32(2), takeover)
+	r.Len(claimPairs, 2)
+	for _, pair := range claimPairs {
+		r.False(pair.Odd)
+	}
+	r.Equal(claimsHash, merkletrie.VerifyProof(node.ClaimHash(*op, takeover), claimPairs))
+
+	op, _, _, err = ct.BestClaimProof(b("tes"))
+	r.NoError(err)
+	r.Nil(op)
+}
+
+func TestSnapshot(t *testing.T) {
+	r := require.New(t)
+	setup(t)
+	param.ActiveParams.ActiveDelayFactor = 1
+	param.ActiveParams.OriginalClaimExpirationTime = 12
+	param.ActiveParams.ExtendedClaimExpirationTime = 12
+	param.ActiveParams.AllClaimsInMerkleForkHeight = 5
+
+	ct, err := New(cfg)
+	r.NoError(err)
+	defer ct.Close()
+
+	addClaims := func(ct *ClaimTrie, i int) {
+		for j, name := range []string{"a", "ab", "test"} {
+			o := wire.OutPoint{Hash: chainhash.HashH([]byte{byte(i), byte(j)}), Index: uint32(j)}
+			err := ct.AddClaim(b(name), o, change.NewClaimID(o), int64(i*j+1))
+			r.NoError(err)
+			err = ct.AddSupport(b(name), o, int64(10-i), change.NewClaimID(o))
+			r.NoError(err)
+		}
+	}
+	for i := 1; i <= 8; i++ {
+		addClaims(ct, i)
+		incrementBlock(r, ct, 1)
+	}
+
+	// The small chunks split the changes of the names.
+	var chunks [][]byte
+	err = ct.WriteSnapshot(8, 500, func(chunk []byte) error {
+		chunks = append(chunks, append([]byte(nil), chunk...))
+		return nil
+	})
+	r.NoError(err)
+	r.Greater(len(chunks), 3)
+
+	loadCfg := cfg
+	loadCfg.DataDir = t.TempDir()
+	loaded, err := New(loadCfg)
+	r.NoError(err)
+	defer loaded.Close()
+
+	// The snapshot is discarded when its hash isn't the one expected.
+	for _, chunk := range chunks {
+		r.NoError(loaded.AppendSnapshotChunk(8, chunk))
+	}
+	r.Error(loaded.FinishSnapshot(8, &chainhash.Hash{1}))
+	r.Equal(int32(0), loaded.Height())
+	r.Equal(merkletrie.EmptyTrieHash[:], loaded.MerkleHash()[:])
+	r.Error(loaded.AppendSnapshotChunk(1, chunks[0]))
+
+	r.NoError(loaded.DiscardSnapshot())
+	for _, chunk := range chunks {
+		r.NoError(loaded.AppendSnapshotChunk(8, chunk))
+	}
+	root := ct.MerkleHash()
+	r.NoError(loaded.FinishSnapshot(8, root))
+	r.Equal(int32(8), loaded.Height())
+	r.Error(loaded.AppendSnapshotChunk(8, chunks[0]))
+
+	// The activations and expirations after the snapshot are the same.
+	for i := 9; i <= 30; i++ {
+		if i%5 == 0 {
+			addClaims(ct, i)
+			addClaims(loaded, i)
+		}
+		incrementBlock(r, ct, 1)
+		incrementBlock(r, loaded, 1)
+		r.Equal(ct.MerkleHash(), loaded.MerkleHash(), "height %d", i)
+	}
+
+	// The loaded snapshot is dropped along with the blocks appended since,
+	// and can be loaded again.
+	r.NoError(loaded.DropSnapshot())
+	r.Equal(int32(0), loaded.Height())
+	r.Equal(merkletrie.EmptyTrieHash[:], loaded.MerkleHash()[:])
+	for _, chunk := range chunks {
+		r.NoError(loaded.AppendSnapshotChunk(8, chunk))
+	}
+	r.NoError(loaded.FinishSnapshot(8, root))
+	r.Equal(root, loaded.MerkleHash())
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/lbryio/lbcd/database"
 	_ "github.com/lbryio/lbcd/database/ffldb"
 	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/txscript/claimscript"
 	"github.com/lbryio/lbcd/wire"
 	btcutil "github.com/lbryio/lbcutil"
 
@@ -349,7 +350,7 @@ func (cb *chainConverter) processBlock() {
 			for _, txIn := range tx.MsgTx().TxIn {
 				prevOutpoint := txIn.PreviousOutPoint
 				pkScript := utxoPubScripts[prevOutpoint]
-				cs, err := txscript.ExtractClaimScript(pkScript)
+				cs, err := claimscript.Parse(pkScript)
 				if txscript.IsErrorCode(err, txscript.ErrNotClaimScript) {
 					continue
 				}
@@ -361,19 +362,15 @@ func (cb *chainConverter) processBlock() {
 					Height:   block.Height(),
 					Name:     cs.Name,
 					OutPoint: txIn.PreviousOutPoint,
+					ClaimID:  cs.ClaimIDFor(txIn.PreviousOutPoint),
 				}
 				delete(utxoPubScripts, prevOutpoint)
 
-				switch cs.Opcode {
-				case txscript.OP_CLAIMNAME:
+				switch cs.Type {
+				case claimscript.TypeClaimName, claimscript.TypeUpdateClaim:
 					chg.Type = change.SpendClaim
-					chg.ClaimID = change.NewClaimID(chg.OutPoint)
-				case txscript.OP_UPDATECLAIM:
-					chg.Type = change.SpendClaim
-					copy(chg.ClaimID[:], cs.ClaimID)
-				case txscript.OP_SUPPORTCLAIM:
+				case claimscript.TypeSupportClaim:
 					chg.Type = change.SpendSupport
-					copy(chg.ClaimID[:], cs.ClaimID)
 				}
 
 				changes = append(changes, chg)
@@ -381,7 +378,7 @@ func (cb *chainConverter) processBlock() {
 
 			op := *wire.NewOutPoint(tx.Hash(), 0)
 			for i, txOut := range tx.MsgTx().TxOut {
-				cs, err := txscript.ExtractClaimScript(txOut.PkScript)
+				cs, err := claimscript.Parse(txOut.PkScript)
 				if txscript.IsErrorCode(err, txscript.ErrNotClaimScript) {
 					continue
 				}
@@ -392,19 +389,17 @@ func (cb *chainConverter) processBlock() {
 					Name:     cs.Name,
 					OutPoint: op,
 					Amount:   txOut.Value,
+					ClaimID:  cs.ClaimIDFor(op),
 				}
 				utxoPubScripts[op] = txOut.PkScript
 
-				switch cs.Opcode {
-				case txscript.OP_CLAIMNAME:
+				switch cs.Type {
+				case claimscript.TypeClaimName:
 					chg.Type = change.AddClaim
-					chg.ClaimID = change.NewClaimID(op)
-				case txscript.OP_SUPPORTCLAIM:
+				case claimscript.TypeSupportClaim:
 					chg.Type = change.AddSupport
-					copy(chg.ClaimID[:], cs.ClaimID)
-				case txscript.OP_UPDATECLAIM:
+				case claimscript.TypeUpdateClaim:
 					chg.Type = change.UpdateClaim
-					copy(chg.ClaimID[:], cs.ClaimID)
 				}
 				changes = append(changes, chg)
 			}
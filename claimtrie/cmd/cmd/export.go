@@ -0,0 +1,459 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lbryio/lbcd/blockchain"
+	"github.com/lbryio/lbcd/claimtrie/change"
+	"github.com/lbryio/lbcd/claimtrie/node/noderepo"
+	"github.com/lbryio/lbcd/claimtrie/temporal/temporalrepo"
+	btcutil "github.com/lbryio/lbcutil"
+
+	"github.com/btcsuite/btclog"
+	"github.com/cockroachdb/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// sqlInsertRows is the max number of rows of an INSERT statement.
+	sqlInsertRows = 500
+
+	// sqlStateHashes is the number of hashes of the last exported blocks
+	// kept in the state file to find where the chain forked on resume.
+	sqlStateHashes = 100
+)
+
+func init() {
+	rootCmd.AddCommand(NewExportCommands())
+}
+
+func NewExportCommands() *cobra.Command {
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export chain and claimtrie data",
+	}
+
+	cmd.AddCommand(NewExportSQLCommand())
+
+	return cmd
+}
+
+func NewExportSQLCommand() *cobra.Command {
+
+	var dialect string
+	var outPath string
+	var statePath string
+	var fromHeight int32
+	var toHeight int32
+	var batchSize int32
+
+	cmd := &cobra.Command{
+		Use:   "sql",
+		Short: "Export blocks, transactions and claim changes as SQL for PostgreSQL or SQLite",
+		Long: `Export blocks, transactions and claim changes as SQL statements creating
+and filling the blocks, transactions, claim_changes and export_state tables,
+to be piped to psql or sqlite3.
+
+The export resumes after the last block recorded in the state file, from where
+the chain forked if the block was since reorganized. Each batch of blocks is a
+transaction replacing the rows from its first height, so a batch exported again
+after an interruption or a reorganization doesn't duplicate rows.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if dialect != "postgres" && dialect != "sqlite" {
+				return errors.Errorf("invalid dialect %q: postgres or sqlite", dialect)
+			}
+			if batchSize <= 0 {
+				return errors.Errorf("invalid batch size %d", batchSize)
+			}
+			if outPath == "-" {
+				// Keep the log out of the statements.
+				level, _ := btclog.LevelFromString(debugLevel)
+				log = btclog.NewBackend(os.Stderr).Logger("CMDL")
+				log.SetLevel(level)
+			}
+			if statePath == "" {
+				statePath = filepath.Join(dataDir, netName, "sql_export.json")
+			}
+
+			state, err := loadSQLExportState(statePath)
+			if err != nil {
+				return errors.Wrapf(err, "load export state")
+			}
+
+			db, err := loadBlocksDB()
+			if err != nil {
+				return errors.Wrapf(err, "load blocks database")
+			}
+			defer db.Close()
+
+			chain, err := loadChain(db)
+			if err != nil {
+				return errors.Wrapf(err, "load chain")
+			}
+
+			dbPath := filepath.Join(dataDir, netName, "claim_dbs", cfg.NodeRepoPebble.Path)
+			log.Debugf("Open node repo: %q", dbPath)
+			nodeRepo, err := noderepo.NewPebble(dbPath)
+			if err != nil {
+				return errors.Wrapf(err, "open node repo")
+			}
+			defer nodeRepo.Close()
+
+			dbPath = filepath.Join(dataDir, netName, "claim_dbs", cfg.TemporalRepoPebble.Path)
+			log.Debugf("Open temporal repo: %q", dbPath)
+			temporalRepo, err := temporalrepo.NewPebble(dbPath)
+			if err != nil {
+				return errors.Wrapf(err, "open temporal repo")
+			}
+			defer temporalRepo.Close()
+
+			if !cmd.Flags().Changed("from") {
+				fromHeight, err = state.resumeHeight(chain)
+				if err != nil {
+					return err
+				}
+			}
+			best := chain.BestSnapshot().Height
+			if toHeight < 0 || toHeight > best {
+				toHeight = best
+			}
+
+			out := io.Writer(os.Stdout)
+			if outPath != "-" {
+				f, err := os.OpenFile(outPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+				if err != nil {
+					return errors.Wrapf(err, "open output")
+				}
+				defer f.Close()
+				out = f
+			}
+
+			exp := &sqlExporter{
+				dialect:      dialect,
+				chain:        chain,
+				nodeRepo:     nodeRepo,
+				temporalRepo: temporalRepo,
+				w:            bufio.NewWriter(out),
+			}
+			exp.writeSchema()
+
+			log.Infof("Export blocks %d to %d", fromHeight, toHeight)
+			startTime := time.Now()
+			for from := fromHeight; from <= toHeight; from += batchSize {
+				to := from + batchSize - 1
+				if to > toHeight {
+					to = toHeight
+				}
+
+				hashes, err := exp.writeBatch(from, to)
+				if err != nil {
+					return errors.Wrapf(err, "export blocks %d to %d", from, to)
+				}
+				if err = exp.w.Flush(); err != nil {
+					return errors.Wrapf(err, "write output")
+				}
+				if f, ok := out.(*os.File); ok && f != os.Stdout {
+					if err = f.Sync(); err != nil {
+						return errors.Wrapf(err, "sync output")
+					}
+				}
+
+				state.update(from, hashes)
+				if err = state.save(statePath); err != nil {
+					return errors.Wrapf(err, "save export state")
+				}
+
+				if time.Since(startTime) > 5*time.Second {
+					log.Infof("Block: %d", to)
+					startTime = time.Now()
+				}
+			}
+
+			return exp.w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&dialect, "dialect", "postgres", "SQL dialect: postgres or sqlite")
+	cmd.Flags().StringVar(&outPath, "out", "-", "Output file the statements are appended to, - for stdout")
+	cmd.Flags().StringVar(&statePath, "state", "", "Export state file (default <datadir>/<netname>/sql_export.json)")
+	cmd.Flags().Int32Var(&fromHeight, "from", 0, "From height (inclusive), instead of resuming from the export state")
+	cmd.Flags().Int32Var(&toHeight, "to", -1, "To height (inclusive), -1 for the best block")
+	cmd.Flags().Int32Var(&batchSize, "batch", 1000, "Number of blocks exported per SQL transaction")
+	cmd.Flags().SortFlags = false
+
+	return cmd
+}
+
+// sqlExportState is the export state file, recording the hashes of the last
+// blocks exported.
+type sqlExportState struct {
+	Height int32    `json:"height"`
+	Hashes []string `json:"hashes"`
+}
+
+func loadSQLExportState(path string) (*sqlExportState, error) {
+
+	state := &sqlExportState{Height: -1}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, state)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse %s", path)
+	}
+
+	return state, nil
+}
+
+func (s *sqlExportState) save(path string) error {
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// resumeHeight returns the height following the last exported block still in
+// the main chain.
+func (s *sqlExportState) resumeHeight(chain *blockchain.BlockChain) (int32, error) {
+
+	if len(s.Hashes) == 0 {
+		return 0, nil
+	}
+	for i := len(s.Hashes) - 1; i >= 0; i-- {
+		height := s.Height - int32(len(s.Hashes)-1-i)
+		hash, err := chain.BlockHashByHeight(height)
+		if err == nil && hash.String() == s.Hashes[i] {
+			if i != len(s.Hashes)-1 {
+				log.Infof("Chain forked after exported block %d", height)
+			}
+			return height + 1, nil
+		}
+	}
+
+	return 0, errors.Errorf("none of the blocks exported up to %d are in the main chain, "+
+		"resume with --from", s.Height)
+}
+
+// update records the hashes of the blocks exported from height, which replace
+// those exported from there before.
+func (s *sqlExportState) update(height int32, hashes []string) {
+
+	keep := len(s.Hashes) - int(s.Height-height+1)
+	if keep < 0 {
+		keep = 0
+	}
+	s.Hashes = append(s.Hashes[:keep], hashes...)
+	if len(s.Hashes) > sqlStateHashes {
+		s.Hashes = s.Hashes[len(s.Hashes)-sqlStateHashes:]
+	}
+	s.Height = height + int32(len(hashes)) - 1
+}
+
+type sqlExporter struct {
+	dialect      string
+	chain        *blockchain.BlockChain
+	nodeRepo     *noderepo.Pebble
+	temporalRepo *temporalrepo.Pebble
+	w            *bufio.Writer
+}
+
+func (e *sqlExporter) writeSchema() {
+
+	blob := "BYTEA"
+	if e.dialect == "sqlite" {
+		blob = "BLOB"
+	}
+
+	fmt.Fprintf(e.w, `CREATE TABLE IF NOT EXISTS blocks (
+	height INTEGER PRIMARY KEY,
+	hash TEXT NOT NULL,
+	prev_hash TEXT NOT NULL,
+	merkle_root TEXT NOT NULL,
+	claim_root TEXT NOT NULL,
+	version INTEGER NOT NULL,
+	time BIGINT NOT NULL,
+	bits BIGINT NOT NULL,
+	nonce BIGINT NOT NULL,
+	size INTEGER NOT NULL,
+	tx_count INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS transactions (
+	height INTEGER NOT NULL,
+	tx_index INTEGER NOT NULL,
+	txid TEXT NOT NULL,
+	version INTEGER NOT NULL,
+	lock_time BIGINT NOT NULL,
+	size INTEGER NOT NULL,
+	vsize INTEGER NOT NULL,
+	input_count INTEGER NOT NULL,
+	output_count INTEGER NOT NULL,
+	output_value BIGINT NOT NULL,
+	PRIMARY KEY (height, tx_index)
+);
+CREATE INDEX IF NOT EXISTS transactions_txid ON transactions (txid);
+CREATE TABLE IF NOT EXISTS claim_changes (
+	height INTEGER NOT NULL,
+	seq INTEGER NOT NULL,
+	type TEXT NOT NULL,
+	name %s NOT NULL,
+	claim_id TEXT NOT NULL,
+	txid TEXT NOT NULL,
+	vout INTEGER NOT NULL,
+	amount BIGINT NOT NULL,
+	active_height INTEGER NOT NULL,
+	visible_height INTEGER NOT NULL,
+	PRIMARY KEY (height, seq)
+);
+CREATE INDEX IF NOT EXISTS claim_changes_claim_id ON claim_changes (claim_id);
+CREATE INDEX IF NOT EXISTS claim_changes_name ON claim_changes (name);
+CREATE TABLE IF NOT EXISTS export_state (
+	id INTEGER PRIMARY KEY,
+	height INTEGER NOT NULL,
+	hash TEXT NOT NULL
+);
+`, blob)
+}
+
+// writeBatch writes the transaction replacing the rows of the blocks from
+// height from on, and returns the hashes of the blocks exported.
+func (e *sqlExporter) writeBatch(from, to int32) ([]string, error) {
+
+	var blocks, txs, changes []string
+	var hashes []string
+	for height := from; height <= to; height++ {
+		block, err := e.chain.BlockByHeight(height)
+		if err != nil {
+			return nil, errors.Wrapf(err, "load block %d", height)
+		}
+		hashes = append(hashes, block.Hash().String())
+		blocks = append(blocks, e.blockRow(block))
+		for i, tx := range block.Transactions() {
+			txs = append(txs, e.txRow(height, i, tx))
+		}
+
+		chgs, err := e.claimChanges(height)
+		if err != nil {
+			return nil, errors.Wrapf(err, "load claim changes at %d", height)
+		}
+		for i, chg := range chgs {
+			changes = append(changes, e.changeRow(i, chg))
+		}
+	}
+
+	fmt.Fprintf(e.w, "BEGIN;\n")
+	for _, table := range []string{"blocks", "transactions", "claim_changes"} {
+		fmt.Fprintf(e.w, "DELETE FROM %s WHERE height >= %d;\n", table, from)
+	}
+	e.writeInsert("blocks", blocks)
+	e.writeInsert("transactions", txs)
+	e.writeInsert("claim_changes", changes)
+	fmt.Fprintf(e.w, "INSERT INTO export_state (id, height, hash) VALUES (1, %d, '%s') "+
+		"ON CONFLICT (id) DO UPDATE SET height = excluded.height, hash = excluded.hash;\n",
+		to, hashes[len(hashes)-1])
+	fmt.Fprintf(e.w, "COMMIT;\n")
+
+	return hashes, nil
+}
+
+func (e *sqlExporter) writeInsert(table string, rows []string) {
+
+	for len(rows) > 0 {
+		n := len(rows)
+		if n > sqlInsertRows {
+			n = sqlInsertRows
+		}
+		fmt.Fprintf(e.w, "INSERT INTO %s VALUES\n%s;\n", table,
+			strings.Join(rows[:n], ",\n"))
+		rows = rows[n:]
+	}
+}
+
+func (e *sqlExporter) blockRow(block *btcutil.Block) string {
+
+	header := &block.MsgBlock().Header
+	return fmt.Sprintf("(%d, '%s', '%s', '%s', '%s', %d, %d, %d, %d, %d, %d)",
+		block.Height(), block.Hash(), header.PrevBlock, header.MerkleRoot,
+		header.ClaimTrie, header.Version, header.Timestamp.Unix(), header.Bits,
+		header.Nonce, block.MsgBlock().SerializeSize(), len(block.Transactions()))
+}
+
+func (e *sqlExporter) txRow(height int32, index int, tx *btcutil.Tx) string {
+
+	msgTx := tx.MsgTx()
+	var value int64
+	for _, txOut := range msgTx.TxOut {
+		value += txOut.Value
+	}
+	vsize := (blockchain.GetTransactionWeight(tx) + blockchain.WitnessScaleFactor - 1) /
+		blockchain.WitnessScaleFactor
+
+	return fmt.Sprintf("(%d, %d, '%s', %d, %d, %d, %d, %d, %d, %d)",
+		height, index, tx.Hash(), msgTx.Version, msgTx.LockTime,
+		msgTx.SerializeSize(), vsize, len(msgTx.TxIn), len(msgTx.TxOut), value)
+}
+
+func (e *sqlExporter) changeRow(seq int, chg change.Change) string {
+
+	return fmt.Sprintf("(%d, %d, '%s', %s, '%s', '%s', %d, %d, %d, %d)",
+		chg.Height, seq, changeType(chg.Type), e.bytesLiteral(chg.Name), chg.ClaimID,
+		chg.OutPoint.Hash, chg.OutPoint.Index, chg.Amount, chg.ActiveHeight,
+		chg.VisibleHeight)
+}
+
+// bytesLiteral returns the SQL literal of the bytes, as names aren't
+// necessarily valid UTF-8.
+func (e *sqlExporter) bytesLiteral(b []byte) string {
+
+	if e.dialect == "sqlite" {
+		return "X'" + hex.EncodeToString(b) + "'"
+	}
+	return "decode('" + hex.EncodeToString(b) + "', 'hex')"
+}
+
+// claimChanges returns the changes to the claimtrie made by the block at the
+// height, from the node repo entries of the names the temporal repo lists for
+// it.
+func (e *sqlExporter) claimChanges(height int32) ([]change.Change, error) {
+
+	names, err := e.temporalRepo.NodesAt(height)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get node names from temporal")
+	}
+
+	var changes []change.Change
+	for _, name := range names {
+		chgs, err := e.nodeRepo.LoadChanges(name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "load changes of %q", name)
+		}
+		for _, chg := range chgs {
+			if chg.Height == height {
+				changes = append(changes, chg)
+			}
+		}
+	}
+
+	return changes, nil
+}
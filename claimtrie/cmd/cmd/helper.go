@@ -7,6 +7,7 @@ import (
 	"github.com/lbryio/lbcd/blockchain"
 	"github.com/lbryio/lbcd/chaincfg"
 	"github.com/lbryio/lbcd/database"
+	"github.com/lbryio/lbcd/database/ffldb"
 	"github.com/lbryio/lbcd/txscript"
 
 	"github.com/cockroachdb/errors"
@@ -16,7 +17,10 @@ func loadBlocksDB() (database.DB, error) {
 
 	dbPath := filepath.Join(dataDir, netName, "blocks_ffldb")
 	log.Debugf("Loading blocks database: %s", dbPath)
-	db, err := database.Open("ffldb", dbPath, chainPramas().Net)
+	// The chain commands scan the blocks in order, so read them from the
+	// mapped block files.
+	db, err := database.Open("ffldb", dbPath, chainPramas().Net, dbPath,
+		ffldb.Options{MmapBlockFiles: true})
 	if err != nil {
 		return nil, errors.Wrapf(err, "open blocks database")
 	}
@@ -25,7 +29,7 @@ func loadBlocksDB() (database.DB, error) {
 }
 
 func loadChain(db database.DB) (*blockchain.BlockChain, error) {
-	paramsCopy := chaincfg.MainNetParams
+	paramsCopy := chainPramas()
 
 	log.Debugf("Loading chain from database")
 
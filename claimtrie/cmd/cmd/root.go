@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"os"
+	"strings"
 
 	"github.com/lbryio/lbcd/claimtrie/config"
 	"github.com/lbryio/lbcd/claimtrie/param"
@@ -18,6 +19,8 @@ var (
 	netName    string
 	dataDir    string
 	debugLevel string
+
+	paramOverrides []string
 )
 
 var rootCmd = NewRootCommand()
@@ -28,18 +31,28 @@ func NewRootCommand() *cobra.Command {
 		Use:          "claimtrie",
 		Short:        "ClaimTrie Command Line Interface",
 		SilenceUsage: true,
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			level, _ := btclog.LevelFromString(debugLevel)
 			log.SetLevel(level)
 
+			net := wire.MainNet
 			switch netName {
-			case "mainnet":
-				param.SetNetwork(wire.MainNet)
 			case "testnet":
-				param.SetNetwork(wire.TestNet3)
+				net = wire.TestNet3
 			case "regtest":
-				param.SetNetwork(wire.TestNet)
+				net = wire.TestNet
+			}
+			cfg = config.ForNetwork(net)
+			for _, override := range paramOverrides {
+				name, value, _ := strings.Cut(override, "=")
+				err := config.OverrideParam(&cfg.Params,
+					strings.TrimSpace(name), strings.TrimSpace(value))
+				if err != nil {
+					return err
+				}
 			}
+			param.ActiveParams = cfg.Params
+			return nil
 		},
 		PersistentPostRun: func(cmd *cobra.Command, args []string) {
 			os.Stdout.Sync()
@@ -49,6 +62,7 @@ func NewRootCommand() *cobra.Command {
 	cmd.PersistentFlags().StringVar(&netName, "netname", "mainnet", "Net name")
 	cmd.PersistentFlags().StringVarP(&dataDir, "datadir", "b", cfg.DataDir, "Data dir")
 	cmd.PersistentFlags().StringVarP(&debugLevel, "debuglevel", "d", cfg.DebugLevel, "Debug level")
+	cmd.PersistentFlags().StringArrayVar(&paramOverrides, "param", nil, "Override a claimtrie parameter, given as <name>=<value>")
 
 	return cmd
 }
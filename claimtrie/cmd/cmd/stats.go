@@ -0,0 +1,293 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+
+	"github.com/lbryio/lbcd/claimtrie/block/blockrepo"
+	"github.com/lbryio/lbcd/claimtrie/change"
+	"github.com/lbryio/lbcd/claimtrie/node"
+	"github.com/lbryio/lbcd/claimtrie/node/noderepo"
+	btcutil "github.com/lbryio/lbcutil"
+
+	"github.com/cockroachdb/errors"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(NewStatsCommand())
+}
+
+// claimsPerNameBuckets are the upper bounds of the buckets of the claims per
+// name distribution.
+var claimsPerNameBuckets = []int{1, 2, 5, 10, 100, math.MaxInt32}
+
+func NewStatsCommand() *cobra.Command {
+
+	var height int32
+	var top int
+	var epoch int32
+
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Report claims per name, support concentration, top staked names and takeovers per epoch",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if top <= 0 || epoch <= 0 {
+				return errors.Errorf("invalid top %d or epoch %d", top, epoch)
+			}
+
+			if height < 0 {
+				dbPath := filepath.Join(dataDir, netName, "claim_dbs", cfg.BlockRepoPebble.Path)
+				log.Debugf("Open block repo: %q", dbPath)
+				blockRepo, err := blockrepo.NewPebble(dbPath)
+				if err != nil {
+					return errors.Wrapf(err, "open block repo")
+				}
+				height, err = blockRepo.Load()
+				blockRepo.Close()
+				if err != nil {
+					return errors.Wrapf(err, "load claimtrie height")
+				}
+			}
+
+			dbPath := filepath.Join(dataDir, netName, "claim_dbs", cfg.NodeRepoPebble.Path)
+			log.Debugf("Open node repo: %q", dbPath)
+			repo, err := noderepo.NewPebble(dbPath)
+			if err != nil {
+				return errors.Wrapf(err, "open node repo")
+			}
+
+			bm, err := node.NewBaseManager(repo)
+			if err != nil {
+				return errors.Wrapf(err, "create node manager")
+			}
+			defer bm.Close()
+
+			st := newClaimtrieStats(height, top, epoch)
+			err = repo.IterateChildren([]byte{}, func(changes []change.Change) bool {
+				n, err := bm.Replay(changes, height, func(h int32) {
+					st.addTakeover(changes[0].Name, h)
+				})
+				if err != nil {
+					st.err = errors.Wrapf(err, "replay node: %s", changes[0].Name)
+					return false
+				}
+				st.addNode(changes[0].Name, n)
+				return true
+			})
+			if err == nil {
+				err = st.err
+			}
+			if err != nil {
+				return errors.Wrapf(err, "iterate node repo")
+			}
+
+			st.show()
+			return nil
+		},
+	}
+
+	cmd.Flags().Int32Var(&height, "height", -1, "Height of the report, -1 for the claimtrie height")
+	cmd.Flags().IntVar(&top, "top", 10, "Number of top staked names")
+	cmd.Flags().Int32Var(&epoch, "epoch", 10000, "Number of blocks per takeover epoch")
+	cmd.Flags().SortFlags = false
+
+	return cmd
+}
+
+type stakedName struct {
+	name      []byte
+	staked    int64
+	claims    int
+	supports  int
+	bestClaim *node.Claim
+}
+
+type takeoverEpoch struct {
+	takeovers int
+	names     int
+}
+
+type claimtrieStats struct {
+	height int32
+	top    int
+	epoch  int32
+
+	names    int
+	claims   int
+	supports int
+
+	claimsPerName []int
+	supportSums   []int64
+	topStaked     []stakedName
+
+	epochs    map[int32]*takeoverEpoch
+	lastEpoch int32
+	lastName  string
+
+	err error
+}
+
+func newClaimtrieStats(height int32, top int, epoch int32) *claimtrieStats {
+
+	return &claimtrieStats{
+		height:        height,
+		top:           top,
+		epoch:         epoch,
+		claimsPerName: make([]int, len(claimsPerNameBuckets)),
+		epochs:        map[int32]*takeoverEpoch{},
+		lastEpoch:     -1,
+	}
+}
+
+func (st *claimtrieStats) addTakeover(name []byte, height int32) {
+
+	e := height / st.epoch
+	te := st.epochs[e]
+	if te == nil {
+		te = &takeoverEpoch{}
+		st.epochs[e] = te
+	}
+	te.takeovers++
+
+	// The takeovers of a name are reported in order.
+	if st.lastName != string(name) || st.lastEpoch != e {
+		te.names++
+		st.lastName = string(name)
+		st.lastEpoch = e
+	}
+}
+
+func (st *claimtrieStats) addNode(name []byte, n *node.Node) {
+
+	if n == nil {
+		return
+	}
+
+	// The name is the ephemeral key of the iterator of the node repo.
+	sn := stakedName{name: append([]byte(nil), name...), bestClaim: n.BestClaim}
+	for _, c := range n.Claims {
+		if c.Status == node.Deactivated {
+			continue
+		}
+		sn.claims++
+		if c.Status == node.Activated {
+			sn.staked += c.Amount
+		}
+	}
+	if sn.claims == 0 {
+		return
+	}
+
+	var supportSum int64
+	for _, s := range n.Supports {
+		if s.Status == node.Deactivated {
+			continue
+		}
+		sn.supports++
+		if s.Status == node.Activated {
+			supportSum += s.Amount
+		}
+	}
+	sn.staked += supportSum
+
+	st.names++
+	st.claims += sn.claims
+	st.supports += sn.supports
+	for i, bound := range claimsPerNameBuckets {
+		if sn.claims <= bound {
+			st.claimsPerName[i]++
+			break
+		}
+	}
+	st.supportSums = append(st.supportSums, supportSum)
+
+	if len(st.topStaked) == st.top && sn.staked <= st.topStaked[st.top-1].staked {
+		return
+	}
+	i := sort.Search(len(st.topStaked), func(i int) bool {
+		return st.topStaked[i].staked < sn.staked
+	})
+	st.topStaked = append(st.topStaked, stakedName{})
+	copy(st.topStaked[i+1:], st.topStaked[i:])
+	st.topStaked[i] = sn
+	if len(st.topStaked) > st.top {
+		st.topStaked = st.topStaked[:st.top]
+	}
+}
+
+func (st *claimtrieStats) show() {
+
+	fmt.Printf("Height: %d, Names: %d, Claims: %d, Supports: %d\n",
+		st.height, st.names, st.claims, st.supports)
+	if st.names == 0 {
+		return
+	}
+
+	fmt.Printf("\nClaims per name:\n")
+	lo := 1
+	for i, hi := range claimsPerNameBuckets {
+		label := fmt.Sprintf("%d-%d", lo, hi)
+		switch {
+		case lo == hi:
+			label = fmt.Sprintf("%d", hi)
+		case hi == math.MaxInt32:
+			label = fmt.Sprintf(">%d", lo-1)
+		}
+		fmt.Printf("  %8s: %10d (%6.2f%%)\n", label, st.claimsPerName[i],
+			100*float64(st.claimsPerName[i])/float64(st.names))
+		lo = hi + 1
+	}
+
+	sort.Slice(st.supportSums, func(i, j int) bool {
+		return st.supportSums[i] > st.supportSums[j]
+	})
+	var total, top1, top10 int64
+	var weighted float64
+	for i, sum := range st.supportSums {
+		total += sum
+		if i < (st.names+99)/100 {
+			top1 += sum
+		}
+		if i < (st.names+9)/10 {
+			top10 += sum
+		}
+		weighted += float64(i+1) * float64(sum)
+	}
+	fmt.Printf("\nSupport concentration (%.8f LBC of active supports):\n",
+		btcutil.Amount(total).ToBTC())
+	if total > 0 {
+		// The Gini coefficient of the sums, from the descending order.
+		n := float64(st.names)
+		gini := (n+1)/n - 2*weighted/(n*float64(total))
+		fmt.Printf("  Top 1%% of names:  %6.2f%%\n", 100*float64(top1)/float64(total))
+		fmt.Printf("  Top 10%% of names: %6.2f%%\n", 100*float64(top10)/float64(total))
+		fmt.Printf("  Gini coefficient: %6.4f\n", gini)
+	}
+
+	fmt.Printf("\nTop staked names:\n")
+	for i, sn := range st.topStaked {
+		best := "-"
+		if sn.bestClaim != nil {
+			best = sn.bestClaim.ClaimID.String()
+		}
+		fmt.Printf("  %3d. %24.8f LBC, Claims: %5d, Supports: %7d, Best: %s, Name: %s\n",
+			i+1, btcutil.Amount(sn.staked).ToBTC(), sn.claims, sn.supports, best, sn.name)
+	}
+
+	fmt.Printf("\nTakeovers per epoch of %d blocks:\n", st.epoch)
+	epochs := make([]int32, 0, len(st.epochs))
+	for e := range st.epochs {
+		epochs = append(epochs, e)
+	}
+	sort.Slice(epochs, func(i, j int) bool { return epochs[i] < epochs[j] })
+	for _, e := range epochs {
+		te := st.epochs[e]
+		fmt.Printf("  %9d-%-9d: Takeovers: %8d, Names: %8d\n",
+			e*st.epoch, (e+1)*st.epoch-1, te.takeovers, te.names)
+	}
+}
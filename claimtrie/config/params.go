@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lbryio/lbcd/claimtrie/param"
+	"github.com/lbryio/lbcd/wire"
+)
+
+// maxNameLengthLimit is the highest claim name length the parameters may allow,
+// which is the size limit of the data pushed by a script.
+const maxNameLengthLimit = 520
+
+// paramSetter sets a claimtrie parameter from its string value.
+type paramSetter func(p *param.ClaimTrieParams, value string) error
+
+// paramSetters maps the names of the claimtrie parameters which can be
+// overridden, such as on the test networks to exercise the takeovers and the
+// expirations in minutes, to their setters.
+var paramSetters = map[string]paramSetter{
+	"normalizationheight": int32Param(0, func(p *param.ClaimTrieParams) *int32 {
+		return &p.NormalizedNameForkHeight
+	}),
+	"allclaimsinmerkleheight": int32Param(0, func(p *param.ClaimTrieParams) *int32 {
+		return &p.AllClaimsInMerkleForkHeight
+	}),
+	"extendedexpirationheight": int32Param(0, func(p *param.ClaimTrieParams) *int32 {
+		return &p.ExtendedClaimExpirationForkHeight
+	}),
+	"originalexpiration": int32Param(1, func(p *param.ClaimTrieParams) *int32 {
+		return &p.OriginalClaimExpirationTime
+	}),
+	"extendedexpiration": int32Param(1, func(p *param.ClaimTrieParams) *int32 {
+		return &p.ExtendedClaimExpirationTime
+	}),
+	"maxactivedelay": int32Param(0, func(p *param.ClaimTrieParams) *int32 {
+		return &p.MaxActiveDelay
+	}),
+	"activedelayfactor": int32Param(1, func(p *param.ClaimTrieParams) *int32 {
+		return &p.ActiveDelayFactor
+	}),
+	"maxnamelength": func(p *param.ClaimTrieParams, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		if n < 1 || n > maxNameLengthLimit {
+			return fmt.Errorf("the length must be between 1 and %d",
+				maxNameLengthLimit)
+		}
+		p.MaxNameLength = n
+		return nil
+	},
+}
+
+// int32Param returns the setter of the int32 claimtrie parameter returned by
+// field, which can't be less than min.
+func int32Param(min int32, field func(*param.ClaimTrieParams) *int32) paramSetter {
+	return func(p *param.ClaimTrieParams, value string) error {
+		n, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return err
+		}
+		if n < int64(min) {
+			return fmt.Errorf("the value can't be less than %d", min)
+		}
+		*field(p) = int32(n)
+		return nil
+	}
+}
+
+// ForNetwork returns the default configuration of the claimtrie of the network,
+// whose parameters are the ones registered for it with the param package, or
+// the ones of the main network for unknown networks.
+func ForNetwork(net wire.BitcoinNet) Config {
+	cfg := DefaultConfig
+	if params, ok := param.ForNetwork(net); ok {
+		cfg.Params = params
+	}
+	return cfg
+}
+
+// ParamNames returns the sorted names of the claimtrie parameters which can be
+// overridden.
+func ParamNames() []string {
+	names := make([]string, 0, len(paramSetters))
+	for name := range paramSetters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsParam returns whether the name, which is case insensitive, is the one of a
+// claimtrie parameter which can be overridden.
+func IsParam(name string) bool {
+	_, ok := paramSetters[strings.ToLower(name)]
+	return ok
+}
+
+// OverrideParam overrides the claimtrie parameter with the name, which is case
+// insensitive, with the value.
+func OverrideParam(p *param.ClaimTrieParams, name, value string) error {
+	set, ok := paramSetters[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("unknown claimtrie parameter %q -- valid "+
+			"parameters are %s", name, strings.Join(ParamNames(), ", "))
+	}
+	if err := set(p, value); err != nil {
+		return fmt.Errorf("invalid value %q for claimtrie parameter %s: %v",
+			value, name, err)
+	}
+	return nil
+}
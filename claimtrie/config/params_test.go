@@ -0,0 +1,45 @@
+package config
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/lbryio/lbcd/claimtrie/param"
+	"github.com/lbryio/lbcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverrideParam(t *testing.T) {
+
+	r := require.New(t)
+
+	r.Equal(param.Regtest, ForNetwork(wire.TestNet).Params)
+	r.Equal(param.TestNet, ForNetwork(wire.TestNet3).Params)
+	r.Equal(param.MainNet, ForNetwork(wire.BitcoinNet(0xfeedf00d)).Params)
+
+	cfg := ForNetwork(wire.TestNet)
+	r.NoError(OverrideParam(&cfg.Params, "OriginalExpiration", "20"))
+	r.NoError(OverrideParam(&cfg.Params, "maxactivedelay", "3"))
+	r.NoError(OverrideParam(&cfg.Params, "maxnamelength", "16"))
+	r.Equal(int32(20), cfg.Params.OriginalClaimExpirationTime)
+	r.Equal(int32(3), cfg.Params.MaxActiveDelay)
+	r.Equal(16, cfg.Params.MaxNameLength)
+	r.Equal(int32(500), param.Regtest.OriginalClaimExpirationTime)
+
+	for _, override := range [][2]string{
+		{"unknown", "1"},
+		{"originalexpiration", "0"},
+		{"activedelayfactor", "fast"},
+		{"maxnamelength", "0"},
+		{"maxnamelength", "521"},
+	} {
+		r.Error(OverrideParam(&cfg.Params, override[0], override[1]), override[0])
+	}
+
+	names := ParamNames()
+	r.True(sort.StringsAreSorted(names))
+	for _, name := range names {
+		r.True(IsParam(name), name)
+	}
+	r.False(IsParam("segwitheight"))
+}
@@ -108,7 +108,9 @@ func (t *PersistentTrie) resolveChildLinks(n *vertex, key []byte) {
 // All nodes must have been resolved before calling this function.
 func (t *PersistentTrie) MerkleHash() *chainhash.Hash {
 	buf := make([]byte, 0, 256)
-	if h := t.merkle(buf, t.root); h == nil {
+	h := t.merkle(buf, t.root)
+	t.commit()
+	if h == nil {
 		return EmptyTrieHash
 	}
 	return t.root.merkleHash
@@ -167,12 +169,22 @@ func keysInOrder(v *vertex) []byte {
 
 func (t *PersistentTrie) MerkleHashAllClaims() *chainhash.Hash {
 	buf := make([]byte, 0, 256)
-	if h := t.merkleAllClaims(buf, t.root); h == nil {
+	h := t.merkleAllClaims(buf, t.root)
+	t.commit()
+	if h == nil {
 		return EmptyTrieHash
 	}
 	return t.root.merkleHash
 }
 
+// commit writes the vertices computed by the last hashing to the repo in a
+// single batch.
+func (t *PersistentTrie) commit() {
+	if err := t.repo.Commit(); err != nil {
+		panic(err)
+	}
+}
+
 func (t *PersistentTrie) merkleAllClaims(prefix []byte, v *vertex) *chainhash.Hash {
 	if v.merkleHash != nil {
 		return v.merkleHash
@@ -23,3 +23,34 @@ func TestName(t *testing.T) {
 	root = node.ComputeMerkleRoot(data)
 	r.True(target.IsEqual(root))
 }
+
+func TestProofAllClaims(t *testing.T) {
+
+	r := require.New(t)
+
+	rt := NewRamTrie()
+	names := []string{"a", "ab", "abc", "abd", "b", "bcd", "c", "cat", "cab", "dog"}
+	for i, name := range names {
+		h := chainhash.DoubleHashH([]byte{byte(i)})
+		rt.Update([]byte(name), &h, false)
+	}
+	root := rt.MerkleHashAllClaims()
+
+	for i, name := range names {
+		claimHash, pairs, err := rt.ProofAllClaims([]byte(name))
+		r.NoError(err)
+		h := chainhash.DoubleHashH([]byte{byte(i)})
+		r.True(h.IsEqual(claimHash), name)
+		r.True(root.IsEqual(VerifyProof(claimHash, pairs)), name)
+	}
+
+	claimHash, pairs, err := rt.ProofAllClaims([]byte("ca"))
+	r.NoError(err)
+	r.Nil(claimHash)
+	r.Nil(pairs)
+
+	h := chainhash.DoubleHashH([]byte("new"))
+	rt.Update([]byte("cow"), &h, false)
+	_, _, err = rt.ProofAllClaims([]byte("cat"))
+	r.ErrorIs(err, ErrProofUnavailable)
+}
@@ -4,11 +4,16 @@ import (
 	"io"
 
 	"github.com/cockroachdb/pebble"
+	"github.com/lbryio/lbcd/claimtrie/pebbleopts"
 	"github.com/pkg/errors"
 )
 
 type Pebble struct {
 	db *pebble.DB
+
+	// batch accumulates the writes until the next Commit. It is indexed so
+	// the writes not yet committed can be read back.
+	batch *pebble.Batch
 }
 
 func NewPebble(path string) (*Pebble, error) {
@@ -32,13 +37,15 @@ func NewPebble(path string) (*Pebble, error) {
 	//}()
 
 	db, err := pebble.Open(path, &pebble.Options{Cache: cache, BytesPerSync: 32 << 20, MaxOpenFiles: 2000})
-	repo := &Pebble{db: db}
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to open %s", path)
+	}
 
-	return repo, errors.Wrapf(err, "unable to open %s", path)
+	return &Pebble{db: db, batch: db.NewIndexedBatch()}, nil
 }
 
 func (repo *Pebble) Get(key []byte) ([]byte, io.Closer, error) {
-	d, c, e := repo.db.Get(key)
+	d, c, e := repo.batch.Get(key)
 	if e == pebble.ErrNotFound {
 		return nil, c, nil
 	}
@@ -46,12 +53,38 @@ func (repo *Pebble) Get(key []byte) ([]byte, io.Closer, error) {
 }
 
 func (repo *Pebble) Set(key, value []byte) error {
-	return repo.db.Set(key, value, pebble.NoSync)
+	return repo.batch.Set(key, value, pebble.NoSync)
+}
+
+// Commit writes the accumulated writes to the db as a single batch.
+func (repo *Pebble) Commit() error {
+	if repo.batch.Empty() {
+		return nil
+	}
+
+	err := repo.batch.Commit(pebbleopts.WriteOptions)
+	if err != nil {
+		return errors.Wrap(err, "in commit")
+	}
+
+	err = repo.batch.Close()
+	repo.batch = repo.db.NewIndexedBatch()
+	return errors.Wrap(err, "on batch close")
 }
 
 func (repo *Pebble) Close() error {
 
-	err := repo.db.Flush()
+	err := repo.Commit()
+	if err != nil {
+		return err
+	}
+
+	err = repo.batch.Close()
+	if err != nil {
+		return errors.Wrap(err, "on batch close")
+	}
+
+	err = repo.db.Flush()
 	if err != nil {
 		// if we fail to close are we going to try again later?
 		return errors.Wrap(err, "on flush")
@@ -62,6 +95,25 @@ func (repo *Pebble) Close() error {
 }
 
 func (repo *Pebble) Flush() error {
-	_, err := repo.db.AsyncFlush()
+	err := repo.Commit()
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.db.AsyncFlush()
 	return err
 }
+
+func (repo *Pebble) Metrics() *pebble.Metrics {
+	return repo.db.Metrics()
+}
+
+// Checkpoint writes a consistent copy of the repo to dir, hard linking the
+// files which don't change.
+func (repo *Pebble) Checkpoint(dir string) error {
+	err := repo.Commit()
+	if err != nil {
+		return err
+	}
+	return errors.Wrap(repo.db.Checkpoint(dir), "on checkpoint")
+}
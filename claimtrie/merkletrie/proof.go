@@ -0,0 +1,106 @@
+package merkletrie
+
+import (
+	"errors"
+
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/claimtrie/node"
+)
+
+var ErrProofUnavailable = errors.New("the trie hashes are not available for a proof")
+
+// ProofPair is one step of a merkle proof. Hash is the sibling of the hash
+// being proven, and Odd is set if the proven hash is the right branch.
+type ProofPair struct {
+	Odd  bool
+	Hash *chainhash.Hash
+}
+
+// ProofAllClaims returns the merkle proof of the claims hash of name against
+// the root computed by MerkleHashAllClaims, ordered from the name up to the root.
+// MerkleHashAllClaims must have been called since the last Update.
+// It returns nil if the name has no claims hash.
+func (rt *RamTrie) ProofAllClaims(name []byte) (*chainhash.Hash, []ProofPair, error) {
+
+	indexes, path := rt.FindPath(name)
+	if len(path) == 0 || path[len(path)-1].claimHash == nil {
+		return nil, nil, nil
+	}
+	leaf := path[len(path)-1]
+
+	var pairs []ProofPair
+	childHash, err := childMerkleRoot(leaf)
+	if err != nil {
+		return nil, nil, err
+	}
+	pairs = append(pairs, ProofPair{Odd: true, Hash: childHash})
+
+	for i := len(path) - 1; i > 0; i-- {
+		parent := path[i-1]
+		hashes := make([]*chainhash.Hash, 0, len(parent.children))
+		for _, ch := range parent.children {
+			if ch.merkleHash == nil {
+				return nil, nil, ErrProofUnavailable
+			}
+			hashes = append(hashes, ch.merkleHash)
+		}
+		pairs = append(pairs, MerkleBranch(hashes, indexes[i])...)
+
+		claimHash := NoClaimsHash
+		if parent.claimHash != nil {
+			claimHash = parent.claimHash
+		}
+		pairs = append(pairs, ProofPair{Odd: false, Hash: claimHash})
+	}
+
+	if !VerifyProof(leaf.claimHash, pairs).IsEqual(rt.Root.merkleHash) {
+		return nil, nil, ErrProofUnavailable
+	}
+
+	return leaf.claimHash, pairs, nil
+}
+
+func childMerkleRoot(v *collapsedVertex) (*chainhash.Hash, error) {
+	if len(v.children) == 0 {
+		return NoChildrenHash, nil
+	}
+	hashes := make([]*chainhash.Hash, 0, len(v.children))
+	for _, ch := range v.children {
+		if ch.merkleHash == nil {
+			return nil, ErrProofUnavailable
+		}
+		hashes = append(hashes, ch.merkleHash)
+	}
+	return node.ComputeMerkleRoot(hashes), nil
+}
+
+// MerkleBranch returns the siblings of hashes[index] on the way up to their
+// merkle root, following the same rules as node.ComputeMerkleRoot.
+func MerkleBranch(hashes []*chainhash.Hash, index int) []ProofPair {
+	var pairs []ProofPair
+	for len(hashes) > 1 {
+		if (len(hashes) & 1) > 0 { // odd count
+			hashes = append(hashes, hashes[len(hashes)-1])
+		}
+		pairs = append(pairs, ProofPair{Odd: index&1 > 0, Hash: hashes[index^1]})
+		next := make([]*chainhash.Hash, 0, len(hashes)>>1)
+		for i := 0; i < len(hashes); i += 2 {
+			next = append(next, node.HashMerkleBranches(hashes[i], hashes[i+1]))
+		}
+		hashes = next
+		index >>= 1
+	}
+	return pairs
+}
+
+// VerifyProof returns the root hash resulting from applying pairs to hash.
+func VerifyProof(hash *chainhash.Hash, pairs []ProofPair) *chainhash.Hash {
+	for _, p := range pairs {
+		if p.Odd {
+			hash = node.HashMerkleBranches(p.Hash, hash)
+		} else {
+			hash = node.HashMerkleBranches(hash, p.Hash)
+		}
+	}
+	return hash
+}
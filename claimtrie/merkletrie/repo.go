@@ -8,6 +8,8 @@ import (
 type Repo interface {
 	Get(key []byte) ([]byte, io.Closer, error)
 	Set(key, value []byte) error
+	// Commit writes the values set since the last Commit as a single batch.
+	Commit() error
 	Close() error
 	Flush() error
 }
@@ -15,6 +15,10 @@ func (nm *HashV2Manager) computeClaimHashes(name []byte) (*chainhash.Hash, int32
 	if err != nil || n == nil {
 		return nil, 0
 	}
+	return claimsHash(n), n.NextUpdate()
+}
+
+func claimsHash(n *Node) *chainhash.Hash {
 
 	n.SortClaimsByBid()
 	claimHashes := make([]*chainhash.Hash, 0, len(n.Claims))
@@ -24,9 +28,9 @@ func (nm *HashV2Manager) computeClaimHashes(name []byte) (*chainhash.Hash, int32
 		}
 	}
 	if len(claimHashes) > 0 {
-		return ComputeMerkleRoot(claimHashes), n.NextUpdate()
+		return ComputeMerkleRoot(claimHashes)
 	}
-	return nil, n.NextUpdate()
+	return nil
 }
 
 func (nm *HashV2Manager) Hash(name []byte) (*chainhash.Hash, int32) {
@@ -37,3 +41,18 @@ func (nm *HashV2Manager) Hash(name []byte) (*chainhash.Hash, int32) {
 
 	return nm.Manager.Hash(name)
 }
+
+// NodeHash returns the hash of the node of the name at the current height like
+// Hash, or the error loading the node.
+func (nm *HashV2Manager) NodeHash(name []byte) (*chainhash.Hash, error) {
+
+	if nm.Height() >= param.ActiveParams.AllClaimsInMerkleForkHeight {
+		n, err := nm.NodeAt(nm.Height(), name)
+		if err != nil || n == nil {
+			return nil, err
+		}
+		return claimsHash(n), nil
+	}
+
+	return nm.Manager.NodeHash(name)
+}
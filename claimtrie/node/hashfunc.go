@@ -35,6 +35,12 @@ func ComputeMerkleRoot(hashes []*chainhash.Hash) *chainhash.Hash {
 	return hashes[0]
 }
 
+// ClaimHash returns the hash of the claim at op in the claims hash of a name
+// taken over at takeover.
+func ClaimHash(op wire.OutPoint, takeover int32) *chainhash.Hash {
+	return calculateNodeHash(op, takeover)
+}
+
 func calculateNodeHash(op wire.OutPoint, takeover int32) *chainhash.Hash {
 
 	txHash := chainhash.DoubleHashH(op.Hash[:])
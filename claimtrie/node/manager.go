@@ -18,8 +18,10 @@ type Manager interface {
 	Height() int32
 	Close() error
 	NodeAt(height int32, name []byte) (*Node, error)
+	SimulateSupport(name []byte, claimID change.ClaimID, amount int64) (*SupportSimulation, error)
 	IterateNames(predicate func(name []byte) bool)
 	Hash(name []byte) (*chainhash.Hash, int32)
+	NodeHash(name []byte) (*chainhash.Hash, error)
 	Flush() error
 	ClearCache()
 }
@@ -45,6 +47,16 @@ func NewBaseManager(repo Repo) (*BaseManager, error) {
 	return nm, nil
 }
 
+// NewReadOnlyManager returns a manager of the nodes of the repo at the height,
+// such as those of a snapshot of a repo, which are only read.
+func NewReadOnlyManager(repo Repo, height int32) Manager {
+	return &HashV2Manager{Manager: &BaseManager{
+		repo:   repo,
+		height: height,
+		cache:  NewCache(10000),
+	}}
+}
+
 func (nm *BaseManager) ClearCache() {
 	nm.cache.clear()
 }
@@ -156,6 +168,50 @@ func (nm *BaseManager) newNodeFromChanges(changes []change.Change, height int32)
 	return nil, nil
 }
 
+// Replay returns the Node constructed from the changes at the height, as
+// newNodeFromChanges does, calling takeover with the height of each takeover
+// of the node on the way.
+func (nm *BaseManager) Replay(changes []change.Change, height int32, takeover func(height int32)) (*Node, error) {
+
+	if len(changes) == 0 || changes[0].Height > height {
+		return nil, nil
+	}
+
+	n := New()
+	last := int32(-1)
+	adjust := func(from, to int32, name []byte) {
+		for h := from; h <= to; h = n.NextUpdate() {
+			n.AdjustTo(h, h, name)
+			if n.BestClaim != nil && n.TakenOverAt != last {
+				last = n.TakenOverAt
+				takeover(last)
+			}
+		}
+	}
+
+	previous := changes[0].Height
+	name := changes[0].Name
+	for _, chg := range changes {
+		if chg.Height > height {
+			break
+		}
+		if previous < chg.Height {
+			adjust(previous, chg.Height-1, chg.Name)
+			previous = chg.Height
+		}
+		name = chg.Name
+
+		delay := nm.getDelayForName(n, chg)
+		err := n.ApplyChange(chg, delay)
+		if err != nil {
+			return nil, errors.Wrap(err, "in apply change")
+		}
+	}
+	adjust(previous, height, name)
+
+	return n, nil
+}
+
 func (nm *BaseManager) AppendChange(chg change.Change) {
 
 	nm.changes = append(nm.changes, chg)
@@ -301,10 +357,8 @@ func (nm *BaseManager) DecrementHeightTo(affectedNames [][]byte, height int32) (
 		}
 		nm.tempChanges = nil
 	} else {
-		for _, name := range affectedNames {
-			if err := nm.repo.DropChanges(name, height); err != nil {
-				return affectedNames, errors.Wrap(err, "in drop changes")
-			}
+		if err := nm.repo.DropChanges(affectedNames, height); err != nil {
+			return affectedNames, errors.Wrap(err, "in drop changes")
 		}
 
 		nm.cache.drop(affectedNames)
@@ -440,13 +494,26 @@ func (nm *BaseManager) Hash(name []byte) (*chainhash.Hash, int32) {
 	if err != nil || n == nil {
 		return nil, 0
 	}
+	return bestClaimHash(n), n.NextUpdate()
+}
+
+// NodeHash returns the hash of the node of the name at the current height like
+// Hash, or the error loading the node.
+func (nm *BaseManager) NodeHash(name []byte) (*chainhash.Hash, error) {
+	n, err := nm.node(name)
+	if err != nil || n == nil {
+		return nil, err
+	}
+	return bestClaimHash(n), nil
+}
+
+func bestClaimHash(n *Node) *chainhash.Hash {
 	if len(n.Claims) > 0 {
 		if n.BestClaim != nil && n.BestClaim.Status == Activated {
-			h := calculateNodeHash(n.BestClaim.OutPoint, n.TakenOverAt)
-			return h, n.NextUpdate()
+			return calculateNodeHash(n.BestClaim.OutPoint, n.TakenOverAt)
 		}
 	}
-	return nil, n.NextUpdate()
+	return nil
 }
 
 func (nm *BaseManager) Flush() error {
@@ -137,6 +137,77 @@ func TestSupportAmounts(t *testing.T) {
 	r.Equal(int64(5), n1.BestClaim.Amount+n1.SupportSums[n1.BestClaim.ClaimID.Key()])
 }
 
+func TestSimulateSupport(t *testing.T) {
+
+	r := require.New(t)
+
+	param.SetNetwork(wire.TestNet)
+	repo, err := noderepo.NewPebble(t.TempDir())
+	r.NoError(err)
+
+	m, err := NewBaseManager(repo)
+	r.NoError(err)
+	defer m.Close()
+
+	_, err = m.IncrementHeightTo(10, false)
+	r.NoError(err)
+
+	id1 := change.NewClaimID(*out1)
+	chg := change.NewChange(change.AddClaim).SetName(name1).SetOutPoint(out1).SetHeight(11).SetAmount(10)
+	chg.ClaimID = id1
+	m.AppendChange(chg)
+	_, err = m.IncrementHeightTo(199, false)
+	r.NoError(err)
+
+	// The second claim is delayed by (200 - 11) / 32 blocks.
+	id2 := change.NewClaimID(*out2)
+	chg = change.NewChange(change.AddClaim).SetName(name1).SetOutPoint(out2).SetHeight(200).SetAmount(3)
+	chg.ClaimID = id2
+	m.AppendChange(chg)
+	_, err = m.IncrementHeightTo(210, false)
+	r.NoError(err)
+
+	// A support for the second claim in block 211 is delayed by 6 blocks,
+	// and must exceed 7 since the first claim wins the ties.
+	sim, err := m.SimulateSupport(name1, id2, 5)
+	r.NoError(err)
+	r.Equal(int32(211), sim.Height)
+	r.Equal(int32(217), sim.ActiveAt)
+	r.False(sim.WasBest)
+	r.Equal(id1, sim.Best.ClaimID)
+	r.Equal(int32(11), sim.TakenOverAt)
+	r.Equal(int64(8), sim.EffectiveAmount)
+	r.Equal(int64(10), sim.BestAmount)
+	r.Equal(int64(8), sim.MinAmount)
+
+	sim, err = m.SimulateSupport(name1, id2, 8)
+	r.NoError(err)
+	r.Equal(id2, sim.Best.ClaimID)
+	r.Equal(int32(217), sim.TakenOverAt)
+	r.Equal(int64(11), sim.EffectiveAmount)
+	r.Equal(int64(8), sim.MinAmount)
+
+	// The supports for the best claim aren't delayed.
+	sim, err = m.SimulateSupport(name1, id1, 1)
+	r.NoError(err)
+	r.True(sim.WasBest)
+	r.Equal(int32(211), sim.ActiveAt)
+	r.Equal(int64(11), sim.EffectiveAmount)
+	r.Equal(int64(1), sim.MinAmount)
+
+	_, err = m.SimulateSupport(name1, change.NewClaimID(*out3), 1)
+	r.Error(err)
+	_, err = m.SimulateSupport(name2, id1, 1)
+	r.Error(err)
+
+	// The simulations leave the node alone.
+	n, err := m.node(name1)
+	r.NoError(err)
+	r.Equal(id1, n.BestClaim.ClaimID)
+	r.Empty(n.Supports)
+	r.Zero(n.SupportSums[id2.Key()])
+}
+
 func TestNodeSort(t *testing.T) {
 
 	r := require.New(t)
@@ -297,3 +368,50 @@ func TestTemporaryAddClaim(t *testing.T) {
 	r.NoError(err)
 	r.Nil(n2)
 }
+
+func TestReplayTakeovers(t *testing.T) {
+
+	r := require.New(t)
+
+	param.SetNetwork(wire.TestNet)
+	repo, err := noderepo.NewPebble(t.TempDir())
+	r.NoError(err)
+
+	m, err := NewBaseManager(repo)
+	r.NoError(err)
+	defer m.Close()
+
+	chg := change.NewChange(change.AddClaim).SetName(name1).SetOutPoint(out1).SetHeight(11).SetAmount(3)
+	chg.ClaimID = change.NewClaimID(*out1)
+	changes := []change.Change{chg}
+
+	chg = change.NewChange(change.AddClaim).SetName(name1).SetOutPoint(out2).SetHeight(12).SetAmount(5)
+	chg.ClaimID = change.NewClaimID(*out2)
+	changes = append(changes, chg)
+
+	chg = change.NewChange(change.AddSupport).SetName(name1).SetOutPoint(out3).SetHeight(13).SetAmount(10)
+	chg.ClaimID = change.NewClaimID(*out1)
+	changes = append(changes, chg)
+
+	r.NoError(repo.AppendChanges(changes))
+
+	var takeovers []int32
+	n, err := m.Replay(changes, 20, func(height int32) {
+		takeovers = append(takeovers, height)
+	})
+	r.NoError(err)
+	r.Equal([]int32{11, 12, 13}, takeovers)
+
+	expected, err := m.NodeAt(20, name1)
+	r.NoError(err)
+	r.Equal(expected.BestClaim.ClaimID, n.BestClaim.ClaimID)
+	r.Equal(expected.TakenOverAt, n.TakenOverAt)
+
+	takeovers = nil
+	n, err = m.Replay(changes, 12, func(height int32) {
+		takeovers = append(takeovers, height)
+	})
+	r.NoError(err)
+	r.Equal([]int32{11, 12}, takeovers)
+	r.Equal(change.NewClaimID(*out2), n.BestClaim.ClaimID)
+}
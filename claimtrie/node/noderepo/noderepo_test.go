@@ -186,3 +186,42 @@ func TestIterator(t *testing.T) {
 		return true
 	})
 }
+
+func TestPebbleSnapshot(t *testing.T) {
+
+	r := require.New(t)
+
+	repo, err := NewPebble(t.TempDir())
+	r.NoError(err)
+	defer func() {
+		err := repo.Close()
+		r.NoError(err)
+	}()
+
+	chg := change.NewChange(change.AddClaim).SetName(testNodeName1).SetOutPoint(out1)
+	err = repo.AppendChanges([]change.Change{chg.SetHeight(1)})
+	r.NoError(err)
+
+	var snapshot node.Repo = repo.Snapshot()
+	defer func() {
+		err := snapshot.Close()
+		r.NoError(err)
+	}()
+	err = repo.AppendChanges([]change.Change{chg.SetHeight(2),
+		chg.SetName([]byte("name2")).SetHeight(2)})
+	r.NoError(err)
+
+	// The snapshot isn't affected by the changes made to the repo since.
+	changes, err := snapshot.LoadChanges(testNodeName1)
+	r.NoError(err)
+	r.Equal([]change.Change{chg.SetHeight(1)}, changes)
+	names := 0
+	snapshot.IterateAll(func(name []byte) bool {
+		names++
+		return true
+	})
+	r.Equal(1, names)
+
+	r.Error(snapshot.AppendChanges([]change.Change{chg.SetHeight(3)}))
+	r.Error(snapshot.DropChanges([][]byte{testNodeName1}, 0))
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/cockroachdb/pebble"
 	"github.com/lbryio/lbcd/claimtrie/change"
+	"github.com/lbryio/lbcd/claimtrie/pebbleopts"
 	"github.com/pkg/errors"
 )
 
@@ -15,6 +16,20 @@ type Pebble struct {
 	db *pebble.DB
 }
 
+// PebbleSnapshot is a read-only view of a Pebble repo at the time it was
+// taken, which isn't affected by the changes made to the repo since.
+type PebbleSnapshot struct {
+	snapshot *pebble.Snapshot
+}
+
+// reader is the read API shared by the database and its snapshots.
+type reader interface {
+	Get(key []byte) ([]byte, io.Closer, error)
+	NewIter(o *pebble.IterOptions) *pebble.Iterator
+}
+
+var errReadOnly = errors.New("the snapshot of the node repo is read-only")
+
 type pooledMerger struct {
 	values [][]byte
 	index  []int
@@ -109,12 +124,16 @@ func (repo *Pebble) AppendChanges(changes []change.Change) error {
 			return errors.Wrap(err, "in merge")
 		}
 	}
-	return errors.Wrap(batch.Commit(pebble.NoSync), "in commit")
+	return errors.Wrap(batch.Commit(pebbleopts.WriteOptions), "in commit")
 }
 
 func (repo *Pebble) LoadChanges(name []byte) ([]change.Change, error) {
+	return loadChanges(repo.db, name)
+}
 
-	data, closer, err := repo.db.Get(name)
+func loadChanges(db reader, name []byte) ([]change.Change, error) {
+
+	data, closer, err := db.Get(name)
 	if err != nil && err != pebble.ErrNotFound {
 		return nil, errors.Wrapf(err, "in get %s", name) // does returning a name in an error expose too much?
 	}
@@ -153,32 +172,46 @@ func unmarshalChanges(name, data []byte) ([]change.Change, error) {
 	return changes, nil
 }
 
-func (repo *Pebble) DropChanges(name []byte, finalHeight int32) error {
-	changes, err := repo.LoadChanges(name)
-	if err != nil {
-		return errors.Wrapf(err, "in load changes for %s", name)
-	}
+func (repo *Pebble) DropChanges(names [][]byte, finalHeight int32) error {
+
+	batch := repo.db.NewBatch()
+	defer batch.Close()
+
 	buffer := bytes.NewBuffer(nil)
-	for i := 0; i < len(changes); i++ { // assuming changes are ordered by height
-		if changes[i].Height > finalHeight {
-			break
+
+	for _, name := range names {
+		changes, err := repo.LoadChanges(name)
+		if err != nil {
+			return errors.Wrapf(err, "in load changes for %s", name)
 		}
-		if changes[i].VisibleHeight > finalHeight { // created after this height has to be skipped
-			continue
+		buffer.Reset()
+		for i := 0; i < len(changes); i++ { // assuming changes are ordered by height
+			if changes[i].Height > finalHeight {
+				break
+			}
+			if changes[i].VisibleHeight > finalHeight { // created after this height has to be skipped
+				continue
+			}
+			// having to sort the changes really messes up performance here. It would be better to not remarshal
+			err := changes[i].Marshal(buffer)
+			if err != nil {
+				return errors.Wrap(err, "in marshaller")
+			}
 		}
-		// having to sort the changes really messes up performance here. It would be better to not remarshal
-		err := changes[i].Marshal(buffer)
+
+		err = batch.Set(name, buffer.Bytes(), pebble.NoSync)
 		if err != nil {
-			return errors.Wrap(err, "in marshaller")
+			return errors.Wrapf(err, "in set at %s", name)
 		}
 	}
-
-	// making a performance assumption that DropChanges won't happen often:
-	err = repo.db.Set(name, buffer.Bytes(), pebble.NoSync)
-	return errors.Wrapf(err, "in set at %s", name)
+	return errors.Wrap(batch.Commit(pebbleopts.WriteOptions), "in commit")
 }
 
 func (repo *Pebble) IterateChildren(name []byte, f func(changes []change.Change) bool) error {
+	return iterateChildren(repo.db, name, f)
+}
+
+func iterateChildren(db reader, name []byte, f func(changes []change.Change) bool) error {
 	start := make([]byte, len(name)+1) // zeros that last byte; need a constant len for stack alloc?
 	copy(start, name)
 
@@ -201,7 +234,7 @@ func (repo *Pebble) IterateChildren(name []byte, f func(changes []change.Change)
 		UpperBound: end,
 	}
 
-	iter := repo.db.NewIter(prefixIterOptions)
+	iter := db.NewIter(prefixIterOptions)
 	defer iter.Close()
 
 	for iter.First(); iter.Valid(); iter.Next() {
@@ -218,7 +251,11 @@ func (repo *Pebble) IterateChildren(name []byte, f func(changes []change.Change)
 }
 
 func (repo *Pebble) IterateAll(predicate func(name []byte) bool) {
-	iter := repo.db.NewIter(nil)
+	iterateAll(repo.db, predicate)
+}
+
+func iterateAll(db reader, predicate func(name []byte) bool) {
+	iter := db.NewIter(nil)
 	defer iter.Close()
 
 	for iter.First(); iter.Valid(); iter.Next() {
@@ -244,3 +281,47 @@ func (repo *Pebble) Flush() error {
 	_, err := repo.db.AsyncFlush()
 	return err
 }
+
+func (repo *Pebble) Metrics() *pebble.Metrics {
+	return repo.db.Metrics()
+}
+
+// Checkpoint writes a consistent copy of the repo to dir, hard linking the
+// files which don't change.
+func (repo *Pebble) Checkpoint(dir string) error {
+	return errors.Wrap(repo.db.Checkpoint(dir), "on checkpoint")
+}
+
+// Snapshot returns a read-only view of the repo at its current state, which
+// must be closed once done with.
+func (repo *Pebble) Snapshot() *PebbleSnapshot {
+	return &PebbleSnapshot{snapshot: repo.db.NewSnapshot()}
+}
+
+func (repo *PebbleSnapshot) AppendChanges(changes []change.Change) error {
+	return errReadOnly
+}
+
+func (repo *PebbleSnapshot) LoadChanges(name []byte) ([]change.Change, error) {
+	return loadChanges(repo.snapshot, name)
+}
+
+func (repo *PebbleSnapshot) DropChanges(names [][]byte, finalHeight int32) error {
+	return errReadOnly
+}
+
+func (repo *PebbleSnapshot) IterateChildren(name []byte, f func(changes []change.Change) bool) error {
+	return iterateChildren(repo.snapshot, name, f)
+}
+
+func (repo *PebbleSnapshot) IterateAll(predicate func(name []byte) bool) {
+	iterateAll(repo.snapshot, predicate)
+}
+
+func (repo *PebbleSnapshot) Close() error {
+	return errors.Wrap(repo.snapshot.Close(), "on close")
+}
+
+func (repo *PebbleSnapshot) Flush() error {
+	return nil
+}
@@ -15,7 +15,8 @@ type Repo interface {
 	// If no changes found, both returned slice and error will be nil.
 	LoadChanges(name []byte) ([]change.Change, error)
 
-	DropChanges(name []byte, finalHeight int32) error
+	// DropChanges removes the changes of the nodes above the specified height.
+	DropChanges(names [][]byte, finalHeight int32) error
 
 	// Close closes the repo.
 	Close() error
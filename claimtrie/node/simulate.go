@@ -0,0 +1,133 @@
+package node
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/lbryio/lbcd/claimtrie/change"
+)
+
+// SupportSimulation is the outcome of a support simulated by SimulateSupport,
+// at the height the support and the supported claim are both active.
+type SupportSimulation struct {
+	Height          int32  // The height the support is added at.
+	ActiveAt        int32  // The height the support activates at.
+	WasBest         bool   // Whether the claim was the best claim before the support.
+	Best            *Claim // The best claim once the support and the claim are active.
+	TakenOverAt     int32  // The height the best claim took over at.
+	EffectiveAmount int64  // The effective amount of the supported claim.
+	BestAmount      int64  // The effective amount of the best claim.
+	MinAmount       int64  // The smallest support making the claim the best claim, or 0 if none does.
+}
+
+// effectiveAmount returns the amount of the claim with its active supports,
+// counting the amount of the claim only once it's active.
+func (n *Node) effectiveAmount(c *Claim) int64 {
+	amount := n.SupportSums[c.ClaimID.Key()]
+	if c.Status == Activated {
+		amount += c.Amount
+	}
+	return amount
+}
+
+// SimulateSupport simulates a support of amount for the claim with the passed
+// ID added to the name in the next block, with no other change to the name.
+// The simulation runs the activations and takeovers of the name up to the
+// height the support and the claim are both active, at which the outcome is
+// final unless the name changes.
+func (nm *BaseManager) SimulateSupport(name []byte, claimID change.ClaimID, amount int64) (*SupportSimulation, error) {
+
+	n, err := nm.NodeAt(nm.height, name)
+	if err != nil {
+		return nil, errors.Wrap(err, "in node at")
+	}
+	if n == nil || n.Claims.find(byID(claimID)) == nil {
+		return nil, errors.Errorf("claim %s of name %s not found", claimID, name)
+	}
+
+	height := nm.height + 1
+	chg := change.Change{
+		Type:    change.AddSupport,
+		Name:    name,
+		ClaimID: claimID,
+		Height:  height,
+	}
+	delay := nm.getDelayForName(n, chg)
+	sim := &SupportSimulation{
+		Height:   height,
+		ActiveAt: height + delay,
+		WasBest:  n.BestClaim != nil && n.BestClaim.ClaimID == claimID,
+	}
+
+	simulate := func(amount int64) (*Node, error) {
+		s := n.Clone()
+		chg.Amount = amount
+		if err := s.ApplyChange(chg, delay); err != nil {
+			return nil, err
+		}
+		until := sim.ActiveAt
+		if c := s.Claims.find(byID(claimID)); c.Status == Accepted {
+			if c.ActiveAt > until {
+				until = c.ActiveAt
+			}
+			if c.VisibleAt > until {
+				until = c.VisibleAt
+			}
+		}
+		s.AdjustTo(height, until, name)
+		return s, nil
+	}
+	wins := func(s *Node) bool {
+		return s.BestClaim != nil && s.BestClaim.ClaimID == claimID
+	}
+
+	s, err := simulate(amount)
+	if err != nil {
+		return nil, errors.Wrap(err, "in simulate")
+	}
+	if c := s.Claims.find(byID(claimID)); c != nil {
+		sim.EffectiveAmount = s.effectiveAmount(c)
+	}
+	if s.BestClaim != nil {
+		best := *s.BestClaim
+		sim.Best = &best
+		sim.TakenOverAt = s.TakenOverAt
+		sim.BestAmount = s.effectiveAmount(s.BestClaim)
+	}
+
+	// A support larger than all the claims and supports of the name beats
+	// any other claim, and more support never loses a takeover, so the
+	// smallest winning support is found by bisection.
+	hi := amount
+	if !wins(s) {
+		hi = 1
+		for _, c := range n.Claims {
+			hi += c.Amount
+		}
+		for _, c := range n.Supports {
+			hi += c.Amount
+		}
+		s, err = simulate(hi)
+		if err != nil {
+			return nil, errors.Wrap(err, "in simulate")
+		}
+		if !wins(s) {
+			return sim, nil
+		}
+	}
+	lo := int64(1)
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		s, err = simulate(mid)
+		if err != nil {
+			return nil, errors.Wrap(err, "in simulate")
+		}
+		if wins(s) {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	sim.MinAmount = hi
+
+	return sim, nil
+}
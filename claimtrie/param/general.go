@@ -1,6 +1,11 @@
 package param
 
-import "github.com/lbryio/lbcd/wire"
+import (
+	"errors"
+	"sync"
+
+	"github.com/lbryio/lbcd/wire"
+)
 
 type ClaimTrieParams struct {
 	MaxActiveDelay    int32
@@ -16,6 +21,10 @@ type ClaimTrieParams struct {
 
 	NormalizedNameForkHeight    int32
 	AllClaimsInMerkleForkHeight int32
+
+	// MaxNameLength is the max size in bytes of the claim names, or zero
+	// for the default of txscript.MaxClaimNameSize.
+	MaxNameLength int
 }
 
 var (
@@ -61,14 +70,48 @@ var (
 	}
 )
 
-func SetNetwork(net wire.BitcoinNet) {
+// ErrDuplicateNet describes an error where the claimtrie parameters of a network
+// could not be registered due to the network being registered already.
+var ErrDuplicateNet = errors.New("duplicate claimtrie network")
+
+var (
+	networksMtx sync.RWMutex
+	networks    = map[wire.BitcoinNet]ClaimTrieParams{
+		wire.MainNet:  MainNet,
+		wire.TestNet3: TestNet,
+		wire.TestNet:  Regtest, // "regtest"
+		wire.SimNet:   Regtest,
+	}
+)
 
-	switch net {
-	case wire.MainNet:
-		ActiveParams = MainNet
-	case wire.TestNet3:
-		ActiveParams = TestNet
-	case wire.TestNet, wire.SimNet: // "regtest"
-		ActiveParams = Regtest
+// Register registers the claimtrie parameters of a network, so that SetNetwork
+// and ForNetwork find them.  It errors with ErrDuplicateNet if the network is
+// already registered.
+func Register(net wire.BitcoinNet, params ClaimTrieParams) error {
+	networksMtx.Lock()
+	defer networksMtx.Unlock()
+
+	if _, ok := networks[net]; ok {
+		return ErrDuplicateNet
+	}
+	networks[net] = params
+	return nil
+}
+
+// ForNetwork returns the claimtrie parameters registered for the network, and
+// whether there are any.
+func ForNetwork(net wire.BitcoinNet) (ClaimTrieParams, bool) {
+	networksMtx.RLock()
+	defer networksMtx.RUnlock()
+
+	params, ok := networks[net]
+	return params, ok
+}
+
+// SetNetwork makes the claimtrie parameters registered for the network the
+// active ones.  The active parameters are left alone for unknown networks.
+func SetNetwork(net wire.BitcoinNet) {
+	if params, ok := ForNetwork(net); ok {
+		ActiveParams = params
 	}
 }
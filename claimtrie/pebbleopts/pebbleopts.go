@@ -0,0 +1,9 @@
+// Package pebbleopts holds the pebble settings shared by the claimtrie repos.
+package pebbleopts
+
+import "github.com/cockroachdb/pebble"
+
+// WriteOptions is the sync policy of the writes committed by the claimtrie
+// repos. Each repo commits the writes of a block as a single batch; the commits
+// don't wait on the WAL reaching the disk, which is left to FlushToDisk.
+var WriteOptions = pebble.NoSync
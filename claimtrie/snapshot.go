@@ -0,0 +1,336 @@
+package claimtrie
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/claimtrie/change"
+	"github.com/lbryio/lbcd/claimtrie/merkletrie"
+	"github.com/lbryio/lbcd/claimtrie/normalization"
+	"github.com/lbryio/lbcd/wire"
+)
+
+// SnapshotChunkSize is the size at which the chunks of a snapshot are cut.
+const SnapshotChunkSize = 1 << 20
+
+const (
+	// maxSnapshotNameSize is the max size of a name in a snapshot.
+	maxSnapshotNameSize = 1024
+
+	// changeFixedSize is the size of a marshalled change without its spent
+	// children, up to and including their count.
+	changeFixedSize = change.ClaimIDSize + chainhash.HashSize + 5*4 + 8 + 4
+
+	// discardBatchSize is the number of names dropped at once when a
+	// snapshot is discarded.
+	discardBatchSize = 10000
+)
+
+// A snapshot of the ClaimTrie at a height is the list of the changes of all
+// the names up to that height, sorted by name, and cut in chunks of about
+// SnapshotChunkSize.  Each chunk is a list of records, made of a name (var
+// bytes), the number of changes (var int), and the marshalled changes.  The
+// changes of a name are split across the records of consecutive chunks when
+// they don't fit in one.  The snapshot holds all the state of the ClaimTrie,
+// which is rebuilt from the changes when it's loaded and checked against the
+// claimtrie hash of the block at that height.
+
+// snapshotWriter cuts the changes of a snapshot in chunks.
+type snapshotWriter struct {
+	chunkSize int
+	emit      func(chunk []byte) error
+
+	chunk   bytes.Buffer
+	name    []byte
+	count   uint64
+	changes bytes.Buffer // marshalled changes of the current record
+}
+
+// add adds the change to the record of its name, and emits the chunk when it's
+// full.
+func (w *snapshotWriter) add(chg *change.Change) error {
+	err := chg.Marshal(&w.changes)
+	if err != nil {
+		return errors.Wrap(err, "in marshaller")
+	}
+	w.count++
+	if w.chunk.Len()+w.changes.Len() < w.chunkSize {
+		return nil
+	}
+	return w.cut()
+}
+
+// endRecord writes the record of the current name to the chunk.
+func (w *snapshotWriter) endRecord() error {
+	if w.count == 0 {
+		return nil
+	}
+	err := wire.WriteVarBytes(&w.chunk, 0, w.name)
+	if err != nil {
+		return err
+	}
+	err = wire.WriteVarInt(&w.chunk, 0, w.count)
+	if err != nil {
+		return err
+	}
+	w.chunk.Write(w.changes.Bytes())
+	w.changes.Reset()
+	w.count = 0
+	return nil
+}
+
+// cut ends the current record and emits the chunk.
+func (w *snapshotWriter) cut() error {
+	err := w.endRecord()
+	if err != nil {
+		return err
+	}
+	if w.chunk.Len() == 0 {
+		return nil
+	}
+	err = w.emit(w.chunk.Bytes())
+	w.chunk.Reset()
+	return err
+}
+
+// WriteSnapshot writes the snapshot of the ClaimTrie at height, emitting its
+// chunks in order.  The chunks are only valid during the call to emit.
+//
+// Only the changes of the repo are read, so the snapshot may be written while
+// blocks are appended, as long as they don't reorganize the chain below height.
+func (ct *ClaimTrie) WriteSnapshot(height int32, chunkSize int, emit func(chunk []byte) error) error {
+	if height <= 0 {
+		return errors.Errorf("no snapshot at height %d", height)
+	}
+
+	w := &snapshotWriter{chunkSize: chunkSize, emit: emit}
+	var err error
+	ct.nodeRepo.IterateAll(func(name []byte) bool {
+		var changes []change.Change
+		changes, err = ct.nodeRepo.LoadChanges(name)
+		if err != nil {
+			return false
+		}
+
+		w.name = append(w.name[:0], name...)
+		for i := range changes { // the same as DropChanges keeps
+			if changes[i].Height > height {
+				break
+			}
+			if changes[i].VisibleHeight > height {
+				continue
+			}
+			if err = w.add(&changes[i]); err != nil {
+				return false
+			}
+		}
+		err = w.endRecord()
+		return err == nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "writing snapshot at %d", height)
+	}
+	return w.cut()
+}
+
+// snapshotChangeSize returns the size of the marshalled change b starts with.
+func snapshotChangeSize(b []byte) (int, error) {
+	if len(b) < changeFixedSize {
+		return 0, errors.New("truncated change")
+	}
+	size := changeFixedSize
+	for keys := binary.BigEndian.Uint32(b[size-4:]); keys > 0; keys-- {
+		if len(b) < size+2 {
+			return 0, errors.New("truncated spent children")
+		}
+		size += 2 + int(binary.BigEndian.Uint16(b[size:]))
+		if len(b) < size {
+			return 0, errors.New("truncated spent children")
+		}
+	}
+	return size, nil
+}
+
+// readSnapshotChunk returns the changes of a chunk of a snapshot at height.
+func readSnapshotChunk(chunk []byte, height int32) ([]change.Change, error) {
+	buf := bytes.NewBuffer(chunk)
+	changes := make([]change.Change, 0, len(chunk)/changeFixedSize)
+	for buf.Len() > 0 {
+		name, err := wire.ReadVarBytes(buf, 0, maxSnapshotNameSize, "name")
+		if err != nil {
+			return nil, errors.Wrap(err, "in name")
+		}
+		count, err := wire.ReadVarInt(buf, 0)
+		if err != nil {
+			return nil, errors.Wrap(err, "in count")
+		}
+		if count == 0 || count > uint64(buf.Len()/changeFixedSize) {
+			return nil, errors.Errorf("invalid count %d of changes for %s", count, name)
+		}
+
+		for ; count > 0; count-- {
+			size, err := snapshotChangeSize(buf.Bytes())
+			if err != nil {
+				return nil, errors.Wrapf(err, "in change of %s", name)
+			}
+			var chg change.Change
+			err = chg.Unmarshal(bytes.NewBuffer(buf.Next(size)))
+			if err != nil {
+				return nil, errors.Wrapf(err, "in change of %s", name)
+			}
+			if chg.Height <= 0 || chg.Height > height || chg.VisibleHeight > height {
+				return nil, errors.Errorf("change of %s at %d out of the snapshot at %d",
+					name, chg.Height, height)
+			}
+			chg.Name = name
+			changes = append(changes, chg)
+		}
+	}
+	return changes, nil
+}
+
+// SnapshotChunkHash returns the hash of a chunk of a snapshot.
+func SnapshotChunkHash(chunk []byte) chainhash.Hash {
+	return chainhash.DoubleHashH(chunk)
+}
+
+// AppendSnapshotChunk saves the changes of a chunk of the snapshot at height.
+// The chunks are appended in order to an empty ClaimTrie, which is then set to
+// the height of the snapshot by FinishSnapshot.
+func (ct *ClaimTrie) AppendSnapshotChunk(height int32, chunk []byte) error {
+	if ct.height != 0 {
+		return errors.Errorf("the claimtrie at height %d isn't empty", ct.height)
+	}
+	changes, err := readSnapshotChunk(chunk, height)
+	if err != nil {
+		return errors.Wrap(err, "in snapshot chunk")
+	}
+	return errors.Wrap(ct.nodeRepo.AppendChanges(changes), "in append changes")
+}
+
+// FinishSnapshot rebuilds the ClaimTrie at height from the chunks of the
+// snapshot appended, and checks that its hash is root.  The ClaimTrie is
+// emptied when it isn't.
+func (ct *ClaimTrie) FinishSnapshot(height int32, root *chainhash.Hash) error {
+	if ct.height != 0 {
+		return errors.Errorf("the claimtrie at height %d isn't empty", ct.height)
+	}
+
+	ct.nodeManager.ClearCache() // of the nodes read while appending
+	_, err := ct.nodeManager.IncrementHeightTo(height, false)
+	if err != nil {
+		return errors.Wrap(err, "node manager increment")
+	}
+	ct.height = height // keep this before the rebuild
+
+	var names, updateNames [][]byte
+	var updateHeights []int32
+	ct.nodeManager.IterateNames(func(name []byte) bool {
+		clone := make([]byte, len(name))
+		copy(clone, name)
+		names = append(names, clone)
+		hash, next := ct.nodeManager.Hash(clone)
+		ct.merkleTrie.Update(clone, hash, false)
+		if next > height {
+			updateNames = append(updateNames, normalization.NormalizeIfNecessary(clone, next))
+			updateHeights = append(updateHeights, next)
+		}
+		return true
+	})
+
+	hash := ct.MerkleHash()
+	if !hash.IsEqual(root) {
+		for _, name := range names {
+			ct.merkleTrie.Update(name, nil, false)
+		}
+		ct.height = 0
+		_, err = ct.nodeManager.DecrementHeightTo(names, 0)
+		if err != nil {
+			return errors.Wrap(err, "node manager decrement")
+		}
+		return errors.Errorf("snapshot hash %s at height %d is not %s", hash, height, root)
+	}
+
+	if len(updateNames) > 0 {
+		err = ct.temporalRepo.SetNodesAt(updateNames, updateHeights)
+		if err != nil {
+			return errors.Wrap(err, "temporal repo set")
+		}
+	}
+	err = ct.merkleTrie.SetRoot(hash) // for clearing the memory entirely
+	if err != nil && err != merkletrie.ErrFullRebuildRequired {
+		return errors.Wrap(err, "merkle trie set root")
+	}
+	err = ct.blockRepo.Set(height, hash)
+	if err != nil {
+		return errors.Wrap(err, "block repo set")
+	}
+	ct.FlushToDisk()
+	return nil
+}
+
+// DiscardSnapshot drops the changes of the chunks of a snapshot appended to
+// the empty ClaimTrie.
+func (ct *ClaimTrie) DiscardSnapshot() error {
+	if ct.height != 0 {
+		return errors.Errorf("the claimtrie at height %d isn't empty", ct.height)
+	}
+
+	var err error
+	names := make([][]byte, 0, discardBatchSize)
+	ct.nodeRepo.IterateAll(func(name []byte) bool {
+		clone := make([]byte, len(name))
+		copy(clone, name)
+		names = append(names, clone)
+		if len(names) < discardBatchSize {
+			return true
+		}
+		err = ct.nodeRepo.DropChanges(names, 0)
+		names = names[:0]
+		return err == nil
+	})
+	if err == nil && len(names) > 0 {
+		err = ct.nodeRepo.DropChanges(names, 0)
+	}
+	ct.nodeManager.ClearCache()
+	return errors.Wrap(err, "in drop changes")
+}
+
+// DropSnapshot empties the ClaimTrie loaded from a snapshot by FinishSnapshot,
+// along with the blocks appended since, so another snapshot can be appended.
+func (ct *ClaimTrie) DropSnapshot() error {
+	if ct.height == 0 {
+		return ct.DiscardSnapshot()
+	}
+
+	var names [][]byte
+	ct.nodeManager.IterateNames(func(name []byte) bool {
+		clone := make([]byte, len(name))
+		copy(clone, name)
+		names = append(names, clone)
+		return true
+	})
+	for _, name := range names {
+		ct.merkleTrie.Update(name, nil, false)
+	}
+
+	oldHeight := ct.height
+	ct.height = 0
+	_, err := ct.nodeManager.DecrementHeightTo(names, 0)
+	if err != nil {
+		return errors.Wrap(err, "node manager decrement")
+	}
+	err = ct.blockRepo.Delete(1, oldHeight)
+	if err != nil {
+		return errors.Wrap(err, "block repo delete")
+	}
+	err = ct.DiscardSnapshot()
+	if err != nil {
+		return err
+	}
+	ct.FlushToDisk()
+	return nil
+}
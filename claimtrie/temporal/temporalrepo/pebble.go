@@ -7,6 +7,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/cockroachdb/pebble"
+	"github.com/lbryio/lbcd/claimtrie/pebbleopts"
 )
 
 type Pebble struct {
@@ -38,7 +39,7 @@ func (repo *Pebble) SetNodesAt(name [][]byte, heights []int32) error {
 			return errors.Wrap(err, "in set")
 		}
 	}
-	return errors.Wrap(batch.Commit(pebble.NoSync), "in commit")
+	return errors.Wrap(batch.Commit(pebbleopts.WriteOptions), "in commit")
 }
 
 func (repo *Pebble) NodesAt(height int32) ([][]byte, error) {
@@ -85,3 +86,13 @@ func (repo *Pebble) Flush() error {
 	_, err := repo.db.AsyncFlush()
 	return err
 }
+
+func (repo *Pebble) Metrics() *pebble.Metrics {
+	return repo.db.Metrics()
+}
+
+// Checkpoint writes a consistent copy of the repo to dir, hard linking the
+// files which don't change.
+func (repo *Pebble) Checkpoint(dir string) error {
+	return errors.Wrap(repo.db.Checkpoint(dir), "on checkpoint")
+}
@@ -11,6 +11,9 @@ import (
 	"github.com/btcsuite/btclog"
 	"github.com/lbryio/lbcd/blockchain"
 	"github.com/lbryio/lbcd/blockchain/indexers"
+	"github.com/lbryio/lbcd/claimtrie"
+	claimtrieconfig "github.com/lbryio/lbcd/claimtrie/config"
+	"github.com/lbryio/lbcd/claimtrie/param"
 	"github.com/lbryio/lbcd/database"
 	"github.com/lbryio/lbcd/limits"
 )
@@ -83,17 +86,22 @@ func realMain() error {
 	}
 	defer db.Close()
 
-	fi, err := os.Open(cfg.InFile)
+	// Load the claimtrie, which is updated along with the block chain.
+	param.ActiveParams = activeNetParams.ClaimTrieParams()
+	claimTrieCfg := claimtrieconfig.DefaultConfig
+	claimTrieCfg.DataDir = cfg.DataDir
+	ct, err := claimtrie.New(claimTrieCfg)
 	if err != nil {
-		log.Errorf("Failed to open file %v: %v", cfg.InFile, err)
+		log.Errorf("Failed to load claimtrie: %v", err)
 		return err
 	}
-	defer fi.Close()
+	defer ct.Close()
 
-	// Create a block importer for the database and input file and start it.
-	// The done channel returned from start will contain an error if
+	// Create a block importer for the database and input files and start
+	// it.  The done channel returned from start will contain an error if
 	// anything went wrong.
-	importer, err := newBlockImporter(db, fi)
+	importer, err := newBlockImporter(db, ct, cfg.blockFiles,
+		cfg.LbrycrdDir != "")
 	if err != nil {
 		log.Errorf("Failed create block importer: %v", err)
 		return err
@@ -113,7 +121,12 @@ func realMain() error {
 
 	log.Infof("Processed a total of %d blocks (%d imported, %d already "+
 		"known)", results.blocksProcessed, results.blocksImported,
-		results.blocksProcessed-results.blocksImported)
+		results.blocksProcessed-results.blocksImported-
+			int64(results.blocksPending))
+	if results.blocksPending > 0 {
+		log.Warnf("Skipped %d blocks which do not link to the imported "+
+			"block chain", results.blocksPending)
+	}
 	return nil
 }
 
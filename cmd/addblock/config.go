@@ -38,11 +38,15 @@ type config struct {
 	DataDir        string `short:"b" long:"datadir" description:"Location of the lbcd data directory"`
 	DbType         string `long:"dbtype" description:"Database backend to use for the Block Chain"`
 	InFile         string `short:"i" long:"infile" description:"File containing the block(s)"`
+	LbrycrdDir     string `long:"lbrycrddir" description:"Import the blocks from the blk*.dat files of this lbrycrd data directory instead of infile"`
 	Progress       int    `short:"p" long:"progress" description:"Show a progress message each time this number of seconds have passed -- Use 0 to disable progress announcements"`
 	RegressionTest bool   `long:"regtest" description:"Use the regression test network"`
 	SimNet         bool   `long:"simnet" description:"Use the simulation test network"`
 	TestNet3       bool   `long:"testnet" description:"Use the test network"`
 	TxIndex        bool   `long:"txindex" description:"Build a full hash-based transaction index which makes all transactions available via the getrawtransaction RPC"`
+
+	// blockFiles are the files the blocks are imported from.
+	blockFiles []string
 }
 
 // filesExists reports whether the named file or directory exists.
@@ -148,6 +152,20 @@ func loadConfig() (*config, []string, error) {
 	// worry about changing names per network and such.
 	cfg.DataDir = filepath.Join(cfg.DataDir, netName(activeNetParams))
 
+	// Find the block files of the lbrycrd data directory when importing
+	// from it.
+	if cfg.LbrycrdDir != "" {
+		files, err := lbrycrdBlockFiles(cfg.LbrycrdDir)
+		if err != nil {
+			err := fmt.Errorf("%s: %v", funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			parser.WriteHelp(os.Stderr)
+			return nil, nil, err
+		}
+		cfg.blockFiles = files
+		return &cfg, remainingArgs, nil
+	}
+
 	// Ensure the specified block file exists.
 	if !fileExists(cfg.InFile) {
 		str := "%s: The specified block file [%v] does not exist"
@@ -156,6 +174,7 @@ func loadConfig() (*config, []string, error) {
 		parser.WriteHelp(os.Stderr)
 		return nil, nil, err
 	}
+	cfg.blockFiles = []string{cfg.InFile}
 
 	return &cfg, remainingArgs, nil
 }
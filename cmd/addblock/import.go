@@ -5,26 +5,39 @@
 package main
 
 import (
+	"bufio"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
+	"os"
 	"sync"
 	"time"
 
 	"github.com/lbryio/lbcd/blockchain"
 	"github.com/lbryio/lbcd/blockchain/indexers"
 	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/claimtrie"
 	"github.com/lbryio/lbcd/database"
 	"github.com/lbryio/lbcd/wire"
 	btcutil "github.com/lbryio/lbcutil"
 )
 
+// maxPendingBlocks is the max number of blocks read out of order which are
+// kept until the block they extend is imported.
+const maxPendingBlocks = 100000
+
 var zeroHash = chainhash.Hash{}
 
+// errPendingBlock is returned by processBlock when the parent of a block read
+// out of order isn't imported yet.
+var errPendingBlock = errors.New("block parent not imported yet")
+
 // importResults houses the stats and result as an import operation.
 type importResults struct {
 	blocksProcessed int64
 	blocksImported  int64
+	blocksPending   int
 	err             error
 }
 
@@ -33,7 +46,10 @@ type importResults struct {
 type blockImporter struct {
 	db                database.DB
 	chain             *blockchain.BlockChain
-	r                 io.ReadSeeker
+	files             []string
+	r                 io.Reader
+	pending           map[chainhash.Hash][][]byte
+	numPending        int
 	processQueue      chan []byte
 	doneChan          chan bool
 	errChan           chan error
@@ -62,6 +78,12 @@ func (bi *blockImporter) readBlock() ([]byte, error) {
 		// No block and no error means there are no more blocks to read.
 		return nil, nil
 	}
+
+	// The block files of lbrycrd are preallocated with zeros, which follow
+	// the last block of the file.
+	if net == 0 && bi.pending != nil {
+		return nil, nil
+	}
 	if net != uint32(activeNetParams.Net) {
 		return nil, fmt.Errorf("network mismatch -- got %x, want %x",
 			net, uint32(activeNetParams.Net))
@@ -86,23 +108,13 @@ func (bi *blockImporter) readBlock() ([]byte, error) {
 	return serializedBlock, nil
 }
 
-// processBlock potentially imports the block into the database.  It first
-// deserializes the raw block while checking for errors.  Already known blocks
-// are skipped and orphan blocks are considered errors.  Finally, it runs the
-// block through the chain rules to ensure it follows all rules and matches
-// up to the known checkpoint.  Returns whether the block was imported along
-// with any potential errors.
-func (bi *blockImporter) processBlock(serializedBlock []byte) (bool, error) {
-	// Deserialize the block which includes checks for malformed blocks.
-	block, err := btcutil.NewBlockFromBytes(serializedBlock)
-	if err != nil {
-		return false, err
-	}
-
-	// update progress statistics
-	bi.lastBlockTime = block.MsgBlock().Header.Timestamp
-	bi.receivedLogTx += int64(len(block.MsgBlock().Transactions))
-
+// processBlock potentially imports the block into the database.  Already known
+// blocks are skipped and orphan blocks are considered errors, unless the
+// blocks may be read out of order, in which case errPendingBlock is returned.
+// Finally, it runs the block through the chain rules to ensure it follows all
+// rules and matches up to the known checkpoint.  Returns whether the block was
+// imported along with any potential errors.
+func (bi *blockImporter) processBlock(block *btcutil.Block) (bool, error) {
 	// Skip blocks that already exist.
 	blockHash := block.Hash()
 	exists, err := bi.chain.HaveBlock(blockHash)
@@ -120,6 +132,9 @@ func (bi *blockImporter) processBlock(serializedBlock []byte) (bool, error) {
 		if err != nil {
 			return false, err
 		}
+		if !exists && bi.pending != nil {
+			return false, errPendingBlock
+		}
 		if !exists {
 			return false, fmt.Errorf("import file contains block "+
 				"%v which does not link to the available "+
@@ -134,7 +149,10 @@ func (bi *blockImporter) processBlock(serializedBlock []byte) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	if !isMainChain {
+
+	// The block files of lbrycrd also contain the blocks of the forks it
+	// saw, which are imported to side chains.
+	if !isMainChain && bi.pending == nil {
 		return false, fmt.Errorf("import file contains an block that "+
 			"does not extend the main chain: %v", blockHash)
 	}
@@ -146,24 +164,84 @@ func (bi *blockImporter) processBlock(serializedBlock []byte) (bool, error) {
 	return true, nil
 }
 
-// readHandler is the main handler for reading blocks from the import file.
-// This allows block processing to take place in parallel with block reads.
-// It must be run as a goroutine.
-func (bi *blockImporter) readHandler() {
-out:
+// importBlock processes a block, followed by the blocks read before it which
+// were pending on it.  Blocks whose parent isn't imported yet are kept pending
+// when the blocks may be read out of order.
+func (bi *blockImporter) importBlock(serializedBlock []byte) error {
+	queue := [][]byte{serializedBlock}
+	for len(queue) > 0 {
+		serializedBlock, queue = queue[0], queue[1:]
+
+		// Deserialize the block which includes checks for malformed
+		// blocks.
+		block, err := btcutil.NewBlockFromBytes(serializedBlock)
+		if err != nil {
+			return err
+		}
+
+		imported, err := bi.processBlock(block)
+		if err == errPendingBlock {
+			if bi.numPending == maxPendingBlocks {
+				return fmt.Errorf("more than %d blocks do not "+
+					"link to the available block chain",
+					maxPendingBlocks)
+			}
+			prevHash := block.MsgBlock().Header.PrevBlock
+			bi.pending[prevHash] = append(bi.pending[prevHash],
+				serializedBlock)
+			bi.numPending++
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if imported {
+			bi.blocksImported++
+		}
+
+		// update progress statistics
+		bi.lastHeight = int64(bi.chain.BestSnapshot().Height)
+		bi.lastBlockTime = block.MsgBlock().Header.Timestamp
+		bi.receivedLogTx += int64(len(block.MsgBlock().Transactions))
+		bi.logProgress()
+
+		// Process the blocks which were pending on this one.
+		if children, ok := bi.pending[*block.Hash()]; ok {
+			delete(bi.pending, *block.Hash())
+			bi.numPending -= len(children)
+			queue = append(queue, children...)
+		}
+	}
+	return nil
+}
+
+// readFile reads the blocks of an import file and sends them to the process
+// handler.  It returns false when the import must stop.
+func (bi *blockImporter) readFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		bi.errChan <- fmt.Errorf("Error opening input file: %v", err)
+		return false
+	}
+	defer f.Close()
+	bi.r = bufio.NewReader(f)
+
+	if len(bi.files) > 1 {
+		log.Infof("Reading blocks from %s", path)
+	}
 	for {
 		// Read the next block from the file and if anything goes wrong
 		// notify the status handler with the error and bail.
 		serializedBlock, err := bi.readBlock()
 		if err != nil {
 			bi.errChan <- fmt.Errorf("Error reading from input "+
-				"file: %v", err.Error())
-			break out
+				"file %s: %v", path, err.Error())
+			return false
 		}
 
-		// A nil block with no error means we're done.
+		// A nil block with no error means we're done with the file.
 		if serializedBlock == nil {
-			break out
+			return true
 		}
 
 		// Send the block or quit if we've been signalled to exit by
@@ -171,7 +249,18 @@ out:
 		select {
 		case bi.processQueue <- serializedBlock:
 		case <-bi.quit:
-			break out
+			return false
+		}
+	}
+}
+
+// readHandler is the main handler for reading blocks from the import files.
+// This allows block processing to take place in parallel with block reads.
+// It must be run as a goroutine.
+func (bi *blockImporter) readHandler() {
+	for _, path := range bi.files {
+		if !bi.readFile(path) {
+			break
 		}
 	}
 
@@ -228,19 +317,12 @@ out:
 			}
 
 			bi.blocksProcessed++
-			bi.lastHeight++
-			imported, err := bi.processBlock(serializedBlock)
+			err := bi.importBlock(serializedBlock)
 			if err != nil {
 				bi.errChan <- err
 				break out
 			}
 
-			if imported {
-				bi.blocksImported++
-			}
-
-			bi.logProgress()
-
 		case <-bi.quit:
 			break out
 		}
@@ -259,6 +341,7 @@ func (bi *blockImporter) statusHandler(resultsChan chan *importResults) {
 		resultsChan <- &importResults{
 			blocksProcessed: bi.blocksProcessed,
 			blocksImported:  bi.blocksImported,
+			blocksPending:   bi.numPending,
 			err:             err,
 		}
 		close(bi.quit)
@@ -268,6 +351,7 @@ func (bi *blockImporter) statusHandler(resultsChan chan *importResults) {
 		resultsChan <- &importResults{
 			blocksProcessed: bi.blocksProcessed,
 			blocksImported:  bi.blocksImported,
+			blocksPending:   bi.numPending,
 			err:             nil,
 		}
 	}
@@ -297,9 +381,12 @@ func (bi *blockImporter) Import() chan *importResults {
 	return resultChan
 }
 
-// newBlockImporter returns a new importer for the provided file reader seeker
-// and database.
-func newBlockImporter(db database.DB, r io.ReadSeeker) (*blockImporter, error) {
+// newBlockImporter returns a new importer for the provided files, in which the
+// blocks may be out of order when outOfOrder is set, the database and the
+// claimtrie.
+func newBlockImporter(db database.DB, ct *claimtrie.ClaimTrie, files []string,
+	outOfOrder bool) (*blockImporter, error) {
+
 	// Create the transaction and address indexes if needed.
 	//
 	// CAUTION: the txindex needs to be first in the indexes array because
@@ -335,14 +422,21 @@ func newBlockImporter(db database.DB, r io.ReadSeeker) (*blockImporter, error) {
 		ChainParams:  activeNetParams,
 		TimeSource:   blockchain.NewMedianTime(),
 		IndexManager: indexManager,
+		ClaimTrie:    ct,
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	var pending map[chainhash.Hash][][]byte
+	if outOfOrder {
+		pending = make(map[chainhash.Hash][][]byte)
+	}
+
 	return &blockImporter{
 		db:           db,
-		r:            r,
+		files:        files,
+		pending:      pending,
 		processQueue: make(chan []byte, 2),
 		doneChan:     make(chan bool),
 		errChan:      make(chan error),
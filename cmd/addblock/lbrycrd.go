@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/lbryio/lbcd/wire"
+)
+
+// lbrycrdNetDir returns the subdirectory of the lbrycrd data directory of the
+// active network.
+func lbrycrdNetDir() string {
+	switch activeNetParams.Net {
+	case wire.TestNet3:
+		return "testnet3"
+	case wire.TestNet:
+		return "regtest"
+	default:
+		return ""
+	}
+}
+
+// lbrycrdBlockFiles returns the blk*.dat files of the active network in the
+// passed lbrycrd data directory, in the order lbrycrd wrote them.  The passed
+// directory may also be the directory of the network, or the blocks directory
+// itself.
+func lbrycrdBlockFiles(dataDir string) ([]string, error) {
+	dirs := []string{
+		filepath.Join(dataDir, lbrycrdNetDir(), "blocks"),
+		filepath.Join(dataDir, "blocks"),
+		dataDir,
+	}
+	for _, dir := range dirs {
+		files, err := filepath.Glob(filepath.Join(dir, "blk[0-9]*.dat"))
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			continue
+		}
+
+		// The files are numbered with 5 digits, so they sort by name
+		// until there are more than 100000 of them.
+		sort.Slice(files, func(i, j int) bool {
+			if len(files[i]) != len(files[j]) {
+				return len(files[i]) < len(files[j])
+			}
+			return files[i] < files[j]
+		})
+		return files, nil
+	}
+
+	if _, err := os.Stat(dataDir); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("no blk*.dat files found in lbrycrd data "+
+		"directory %s", dataDir)
+}
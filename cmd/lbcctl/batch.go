@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lbryio/lbcd/btcjson"
+)
+
+// batchCommand is the command running the commands of a file or stdin.
+const batchCommand = "batch"
+
+// batchEntry is a command of a batch, identified by its line number, along
+// with its marshalled request and the connection to the server of its method,
+// or the error of an invalid command.
+type batchEntry struct {
+	id      int
+	request []byte
+	conn    *connection
+	reply   batchReply
+}
+
+// batchReply is the output of a command of a batch, which is the JSON-RPC
+// response of the server identified by the line number of the command.
+type batchReply struct {
+	Result json.RawMessage   `json:"result"`
+	Error  *btcjson.RPCError `json:"error"`
+	ID     int               `json:"id"`
+}
+
+// batch runs commands over a single connection to each RPC server, and writes
+// their replies in their order, one JSON object per line.
+type batch struct {
+	cfg    *config
+	router *router
+	output *bufio.Writer
+
+	// entries are the entries read since their requests were last sent.
+	entries []*batchEntry
+
+	// failed is set once a command failed.
+	failed bool
+}
+
+// runBatch runs the commands of the passed file, or of stdin when it is empty
+// or -, one per line.  A command is either typed as in the shell, or is a
+// JSON-RPC request object, and the empty lines and those starting with # are
+// skipped.  It returns false when a command failed.
+func runBatch(cfg *config, path string) (bool, error) {
+	input := os.Stdin
+	if path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return false, err
+		}
+		defer f.Close()
+		input = f
+	}
+
+	r, err := newRouter(cfg)
+	if err != nil {
+		return false, err
+	}
+	var output io.Writer = os.Stdout
+	if cfg.Quiet {
+		output = io.Discard
+	}
+	b := &batch{
+		cfg:    cfg,
+		router: r,
+		output: bufio.NewWriter(output),
+	}
+
+	started := time.Now()
+	bio := bufio.NewReader(input)
+	for id := 1; ; id++ {
+		line, err := bio.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return false, err
+		}
+		if trimmed := strings.TrimSpace(line); trimmed != "" &&
+			!strings.HasPrefix(trimmed, "#") {
+
+			b.entries = append(b.entries, newBatchEntry(r, id, trimmed))
+			if len(b.entries) >= cfg.BatchSize {
+				if err := b.flush(); err != nil {
+					return false, err
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	if err := b.flush(); err != nil {
+		return false, err
+	}
+
+	if cfg.Timed {
+		fmt.Fprintf(os.Stderr, "%s\n", time.Since(started))
+	}
+	return !b.failed, nil
+}
+
+// newBatchEntry returns the entry of the command of the passed line, whose
+// request is sent to the server of its method by the passed router.
+func newBatchEntry(r *router, id int, line string) *batchEntry {
+	entry := &batchEntry{id: id, reply: batchReply{ID: id}}
+
+	// The JSON-RPC requests are sent as is, with the line number as id.
+	if strings.HasPrefix(line, "{") {
+		var request map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(line), &request); err != nil {
+			entry.reply.Error = &btcjson.RPCError{
+				Code: btcjson.ErrRPCParse.Code,
+				Message: fmt.Sprintf("Failed to parse request: %v",
+					err),
+			}
+			return entry
+		}
+		var method string
+		if err := json.Unmarshal(request["method"], &method); err != nil {
+			entry.reply.Error = &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidRequest.Code,
+				Message: "Invalid request: missing method",
+			}
+			return entry
+		}
+		request["id"] = json.RawMessage(strconv.Itoa(id))
+		entry.request, _ = json.Marshal(request)
+		entry.conn = r.route(method)
+		return entry
+	}
+
+	args, err := splitArgs(line)
+	if err != nil {
+		entry.reply.Error = &btcjson.RPCError{
+			Code:    btcjson.ErrRPCParse.Code,
+			Message: err.Error(),
+		}
+		return entry
+	}
+	params := make([]interface{}, 0, len(args[1:]))
+	for _, arg := range args[1:] {
+		param, err := readFileArg(arg)
+		if err != nil {
+			entry.reply.Error = &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParams.Code,
+				Message: err.Error(),
+			}
+			return entry
+		}
+		params = append(params, param)
+	}
+	entry.conn, entry.request, err = r.marshal(args[0], params, id)
+	if err != nil {
+		rpcErr, ok := err.(*btcjson.RPCError)
+		if !ok {
+			rpcErr = &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParams.Code,
+				Message: err.Error(),
+			}
+		}
+		entry.reply.Error = rpcErr
+	}
+	return entry
+}
+
+// flush sends the requests of the pending entries, in a single JSON-RPC batch
+// per server unless batches are disabled, and writes their replies.  Only the
+// failures to reach a server are returned, as the failed commands are
+// replied.
+func (b *batch) flush() error {
+	if len(b.entries) == 0 {
+		return nil
+	}
+
+	if b.cfg.BatchSize > 1 {
+		for _, conn := range b.router.connections() {
+			var requests []*batchEntry
+			for _, entry := range b.entries {
+				if entry.request != nil && entry.conn == conn {
+					requests = append(requests, entry)
+				}
+			}
+			if err := b.sendBatch(conn, requests); err != nil {
+				return err
+			}
+		}
+	} else {
+		for _, entry := range b.entries {
+			if entry.request == nil {
+				continue
+			}
+			if err := b.send(entry); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, entry := range b.entries {
+		if entry.reply.Error != nil {
+			b.failed = true
+		}
+		marshalled, err := json.Marshal(&entry.reply)
+		if err != nil {
+			return err
+		}
+		b.output.Write(marshalled)
+		b.output.WriteByte('\n')
+	}
+	b.entries = b.entries[:0]
+	return b.output.Flush()
+}
+
+// send sends the request of the passed entry on its own.
+func (b *batch) send(entry *batchEntry) error {
+	conn := entry.conn
+	respBytes, err := postJSON(conn.httpClient, entry.request, conn.cfg, true)
+	if rpcErr, ok := err.(*btcjson.RPCError); ok {
+		entry.reply.Error = rpcErr
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var resp btcjson.Response
+	if err := json.Unmarshal(respBytes, &resp); err != nil {
+		return err
+	}
+	return b.setReply(entry, &resp)
+}
+
+// sendBatch sends the requests of the passed entries in a single JSON-RPC
+// batch over the passed connection.
+func (b *batch) sendBatch(conn *connection, entries []*batchEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	requests := make([]json.RawMessage, 0, len(entries))
+	for _, entry := range entries {
+		requests = append(requests, entry.request)
+	}
+	marshalledJSON, err := json.Marshal(requests)
+	if err != nil {
+		return err
+	}
+	respBytes, err := postJSON(conn.httpClient, marshalledJSON, conn.cfg,
+		true)
+	if err != nil {
+		return err
+	}
+	var resps []btcjson.Response
+	if err := json.Unmarshal(respBytes, &resps); err != nil {
+		return err
+	}
+
+	// The responses are matched to the requests by id.
+	byID := make(map[int]*btcjson.Response, len(resps))
+	for i := range resps {
+		if resps[i].ID == nil {
+			continue
+		}
+		if id, ok := (*resps[i].ID).(float64); ok {
+			byID[int(id)] = &resps[i]
+		}
+	}
+	for _, entry := range entries {
+		resp, ok := byID[entry.id]
+		if !ok {
+			entry.reply.Error = &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInternal.Code,
+				Message: "No response in the batch",
+			}
+			continue
+		}
+		if err := b.setReply(entry, resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setReply sets the reply of the passed entry to the passed response, whose
+// result is restricted to the fields selected by the --fields option.
+func (b *batch) setReply(entry *batchEntry, resp *btcjson.Response) error {
+	entry.reply.Error = resp.Error
+	if resp.Error != nil || resp.Result == nil {
+		return nil
+	}
+	result, err := filterResult(resp.Result, b.cfg)
+	if err != nil {
+		return err
+	}
+	entry.reply.Result = result
+	return nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBatchEntry(t *testing.T) {
+
+	r := require.New(t)
+
+	router := newTestRouter([]string{"getblockcount"}, []string{"custom"})
+	path := filepath.Join(t.TempDir(), "hash")
+	r.NoError(os.WriteFile(path, []byte("abc\n"), 0600))
+
+	tests := []struct {
+		line    string
+		conn    *connection
+		request string
+		code    btcjson.RPCErrorCode
+	}{{
+		line:    `{"jsonrpc":"1.0","method":"getbalance","params":[],"id":"x"}`,
+		conn:    router.wallet,
+		request: `{"jsonrpc":"1.0","method":"getbalance","params":[],"id":7}`,
+	}, {
+		line:    `{"method":"custom","params":[1]}`,
+		conn:    router.wallet,
+		request: `{"method":"custom","params":[1],"id":7}`,
+	}, {
+		line: `{"method":`,
+		code: btcjson.ErrRPCParse.Code,
+	}, {
+		line: `{"params":[]}`,
+		code: btcjson.ErrRPCInvalidRequest.Code,
+	}, {
+		line:    "getblock @" + path + " '1'",
+		conn:    router.chain,
+		request: `{"jsonrpc":"1.0","method":"getblock","params":["abc",1],"id":7}`,
+	}, {
+		line:    `custom "a b" 2 @lbry`,
+		conn:    router.wallet,
+		request: `{"jsonrpc":"1.0","method":"custom","params":["a b",2,"@lbry"],"id":7}`,
+	}, {
+		line: `getblock 'abc`,
+		code: btcjson.ErrRPCParse.Code,
+	}, {
+		line: "getblock @" + filepath.Dir(path),
+		code: btcjson.ErrRPCInvalidParams.Code,
+	}, {
+		line: "unknown",
+		code: btcjson.ErrRPCMethodNotFound.Code,
+	}, {
+		line: "getblockcount 1",
+		code: btcjson.ErrRPCInvalidParams.Code,
+	}}
+	for _, test := range tests {
+		entry := newBatchEntry(router, 7, test.line)
+		r.Equal(7, entry.id, test.line)
+		r.Equal(7, entry.reply.ID, test.line)
+		if test.code != 0 {
+			r.NotNil(entry.reply.Error, test.line)
+			r.Equal(test.code, entry.reply.Error.Code, test.line)
+			continue
+		}
+		r.Nil(entry.reply.Error, test.line)
+		r.Equal(test.conn, entry.conn, test.line)
+		r.JSONEq(test.request, string(entry.request), test.line)
+	}
+}
+
+// newTestBatchServer returns an RPC server replying the number of params of
+// each request, or an error to the requests without params, along with the
+// number of HTTP requests it received.
+func newTestBatchServer() (*httptest.Server, *int) {
+	var posts int
+	reply := func(request *btcjson.Request) *btcjson.Response {
+		if len(request.Params) == 0 {
+			return &btcjson.Response{
+				Error: btcjson.NewRPCError(btcjson.ErrRPCMisc,
+					"no params"),
+				ID: &request.ID,
+			}
+		}
+		result, _ := json.Marshal(map[string]int{
+			"params": len(request.Params),
+			"other":  0,
+		})
+		return &btcjson.Response{Result: result, ID: &request.ID}
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		posts++
+		body, err := io.ReadAll(req.Body)
+		if err != nil || len(body) == 0 {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+		if body[0] != '[' {
+			var request btcjson.Request
+			if err := json.Unmarshal(body, &request); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(reply(&request))
+			return
+		}
+		var requests []btcjson.Request
+		if err := json.Unmarshal(body, &requests); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resps := make([]*btcjson.Response, 0, len(requests))
+		for i := range requests {
+			resps = append(resps, reply(&requests[i]))
+		}
+		json.NewEncoder(w).Encode(resps)
+	}))
+	return server, &posts
+}
+
+func TestBatchFlush(t *testing.T) {
+
+	r := require.New(t)
+
+	server, posts := newTestBatchServer()
+	defer server.Close()
+
+	lines := []string{
+		"custom 1 2",
+		"custom",
+		"custom 'abc",
+		`{"method":"custom","params":[1]}`,
+	}
+	replies := []string{
+		`{"result":{"params":2},"error":null,"id":1}`,
+		`{"result":null,"error":{"code":-1,"message":"no params"},"id":2}`,
+		`{"result":null,"error":{"code":-32700,"message":"unterminated ' quote"},"id":3}`,
+		`{"result":{"params":1},"error":null,"id":4}`,
+	}
+
+	// The commands are sent one by one, or in a single batch, and their
+	// replies are in their order either way.
+	for _, batchSize := range []int{1, 10} {
+		cfg := &config{
+			RPCServer: strings.TrimPrefix(server.URL, "http://"),
+			NoTLS:     true,
+			BatchSize: batchSize,
+		}
+		cfg.fieldPaths, _ = parseFieldPaths("params")
+		router := newTestRouter([]string{"custom"}, nil)
+		router.chain.cfg = cfg
+		router.chain.httpClient = server.Client()
+
+		var output bytes.Buffer
+		b := &batch{
+			cfg:    cfg,
+			router: router,
+			output: bufio.NewWriter(&output),
+		}
+		for i, line := range lines {
+			b.entries = append(b.entries, newBatchEntry(router, i+1, line))
+		}
+		*posts = 0
+		r.NoError(b.flush())
+		r.True(b.failed)
+		r.Empty(b.entries)
+		if batchSize == 1 {
+			r.Equal(3, *posts)
+		} else {
+			r.Equal(1, *posts)
+		}
+
+		outLines := strings.Split(strings.TrimSuffix(output.String(), "\n"),
+			"\n")
+		r.Len(outLines, len(replies))
+		for i, reply := range replies {
+			r.JSONEq(reply, outLines[i], "batch size %d", batchSize)
+		}
+	}
+}
@@ -0,0 +1,665 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/claimtrie/merkletrie"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+const (
+	// claimCommand is the command grouping the claim subcommands.
+	claimCommand = "claim"
+
+	// claimURLScheme is the optional scheme of the LBRY URLs.
+	claimURLScheme = "lbry://"
+
+	// claimScanBatchSize is the number of blocks, or of names, queried per
+	// JSON-RPC batch request by the list-by-channel subcommand.
+	claimScanBatchSize = 100
+
+	// signedValuePrefix is the first byte of the value of a claim signed
+	// by a channel, which is followed by the claim id of the channel in
+	// reverse byte order, and by the signature.
+	signedValuePrefix = 0x01
+
+	// signedValueMinLen is the length of the header of a signed value.
+	signedValueMinLen = 1 + 20 + 64
+)
+
+// errValuesUnavailable is returned when the values of the claims, which hold
+// their signing channel, aren't available from the RPC server.
+var errValuesUnavailable = errors.New("the values of the claims are " +
+	"required, which the RPC server provides with its transaction index " +
+	"(--txindex)")
+
+// claimSubcommand is a subcommand of the claim command, which composes the
+// claim RPCs into a human-readable result.
+type claimSubcommand struct {
+	name        string
+	usage       string
+	description string
+	minArgs     int
+	maxArgs     int
+	run         func(c *claimClient, args []string) error
+}
+
+// claimSubcommands are the subcommands of the claim command, in the order of
+// its usage.
+var claimSubcommands = []*claimSubcommand{
+	{
+		name:        "resolve",
+		usage:       "<url>",
+		description: "Resolve a name or LBRY URL to a claim",
+		minArgs:     1,
+		maxArgs:     1,
+		run:         (*claimClient).resolve,
+	},
+	{
+		name:        "list-by-channel",
+		usage:       "<channel> [startheight [endheight]]",
+		description: "List the claims signed by a channel",
+		minArgs:     1,
+		maxArgs:     3,
+		run:         (*claimClient).listByChannel,
+	},
+	{
+		name:        "proof",
+		usage:       "<name>",
+		description: "Verify the claimtrie proof of a name at the tip",
+		minArgs:     1,
+		maxArgs:     1,
+		run:         (*claimClient).proof,
+	},
+}
+
+// claimUsage returns the usage of the claim subcommands.
+func claimUsage() string {
+	var usage strings.Builder
+	w := tabwriter.NewWriter(&usage, 0, 0, 2, ' ', 0)
+	for _, sub := range claimSubcommands {
+		fmt.Fprintf(w, "  %s %s %s\t%s\n", claimCommand, sub.name,
+			sub.usage, sub.description)
+	}
+	w.Flush()
+	return usage.String()
+}
+
+// runClaim runs the claim subcommand of the passed arguments.
+func runClaim(cfg *config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("No claim subcommand specified -- choose "+
+			"one of:\n%s", claimUsage())
+	}
+
+	var sub *claimSubcommand
+	for _, s := range claimSubcommands {
+		if s.name == args[0] {
+			sub = s
+			break
+		}
+	}
+	if sub == nil {
+		return fmt.Errorf("Unrecognized claim subcommand %q -- choose "+
+			"one of:\n%s", args[0], claimUsage())
+	}
+	if len(args)-1 < sub.minArgs || len(args)-1 > sub.maxArgs {
+		return fmt.Errorf("Usage:\n  %s %s %s", claimCommand,
+			sub.name, sub.usage)
+	}
+
+	httpClient, err := newHTTPClient(cfg)
+	if err != nil {
+		return err
+	}
+	c := &claimClient{cfg: cfg, httpClient: httpClient}
+	return sub.run(c, args[1:])
+}
+
+// claimClient issues the claim RPCs of the claim subcommands over a single
+// connection to the RPC server.
+type claimClient struct {
+	cfg        *config
+	httpClient *http.Client
+}
+
+// call issues the passed command, and unmarshals its result into result.
+func (c *claimClient) call(cmd interface{}, result interface{}) error {
+	marshalledJSON, err := btcjson.MarshalCmd(btcjson.RpcVersion1, 1, cmd)
+	if err != nil {
+		return err
+	}
+	reply, err := postRequest(c.httpClient, marshalledJSON, c.cfg, true)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(reply, result)
+}
+
+// callBatch issues the passed commands in a single JSON-RPC batch request,
+// and unmarshals the results of those which succeed into the results of the
+// same index.  The errors of the commands are returned by index, along with
+// the error of the request.
+func (c *claimClient) callBatch(cmds []interface{}, results []interface{}) ([]error, error) {
+	requests := make([]json.RawMessage, 0, len(cmds))
+	for i, cmd := range cmds {
+		request, err := btcjson.MarshalCmd(btcjson.RpcVersion2, i, cmd)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, request)
+	}
+	marshalledJSON, err := json.Marshal(requests)
+	if err != nil {
+		return nil, err
+	}
+	respBytes, err := postJSON(c.httpClient, marshalledJSON, c.cfg, true)
+	if err != nil {
+		return nil, err
+	}
+	var resps []btcjson.Response
+	if err := json.Unmarshal(respBytes, &resps); err != nil {
+		return nil, err
+	}
+
+	errs := make([]error, len(cmds))
+	for i := range errs {
+		errs[i] = errors.New("No response in the batch")
+	}
+	for _, resp := range resps {
+		if resp.ID == nil {
+			continue
+		}
+		id, ok := (*resp.ID).(float64)
+		if !ok || id < 0 || int(id) >= len(cmds) {
+			continue
+		}
+		i := int(id)
+		switch {
+		case resp.Error != nil:
+			errs[i] = resp.Error
+		default:
+			errs[i] = json.Unmarshal(resp.Result, results[i])
+		}
+	}
+	return errs, nil
+}
+
+// fetchClaims returns the claims of a name at the tip, with their values
+// unless the server doesn't provide them, in which case they are fetched
+// without values when they aren't required.
+func (c *claimClient) fetchClaims(name string, requireValues bool) (*btcjson.GetClaimsForNameResult, error) {
+	var result btcjson.GetClaimsForNameResult
+	cmd := btcjson.NewGetClaimsForNameCmd(name, nil, btcjson.Bool(true))
+	err := c.call(cmd, &result)
+	if isNoTxInfo(err) {
+		if requireValues {
+			return nil, errValuesUnavailable
+		}
+		cmd.IncludeValues = btcjson.Bool(false)
+		err = c.call(cmd, &result)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// isNoTxInfo returns whether err is the error of the RPC server which can't
+// look up a transaction.
+func isNoTxInfo(err error) bool {
+	var rpcErr *btcjson.RPCError
+	return errors.As(err, &rpcErr) && rpcErr.Code == btcjson.ErrRPCNoTxInfo
+}
+
+// claimURLPart is a name of a LBRY URL, along with the modifier selecting one
+// of its claims: a prefix of its claim id, its sequence, starting at 1 in the
+// order the claims were made, or its bid position, starting at 1 for the
+// highest bid.  No modifier selects the controlling claim.
+type claimURLPart struct {
+	name     string
+	claimID  string
+	sequence int
+	position int
+}
+
+// claimURL is a parsed LBRY URL of a claim, which is a stream or a channel
+// optionally in a channel, such as lbry://@channel#1/stream.
+type claimURL struct {
+	channel *claimURLPart
+	claim   claimURLPart
+}
+
+// parseClaimURL parses a LBRY URL, or a bare name.
+func parseClaimURL(url string) (*claimURL, error) {
+	path := strings.TrimPrefix(url, claimURLScheme)
+	parts := strings.Split(path, "/")
+	if len(parts) > 2 {
+		return nil, fmt.Errorf("invalid URL %q: too many path "+
+			"segments", url)
+	}
+
+	var u claimURL
+	if len(parts) == 2 {
+		channel, err := parseClaimURLPart(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL %q: %v", url, err)
+		}
+		if !strings.HasPrefix(channel.name, "@") {
+			return nil, fmt.Errorf("invalid URL %q: %q isn't a "+
+				"channel name", url, channel.name)
+		}
+		u.channel = channel
+	}
+	claim, err := parseClaimURLPart(parts[len(parts)-1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %v", url, err)
+	}
+	u.claim = *claim
+	return &u, nil
+}
+
+// parseClaimURLPart parses a name of a LBRY URL with its optional modifier:
+// #claimid, :sequence or $position.
+func parseClaimURLPart(part string) (*claimURLPart, error) {
+	end := strings.IndexAny(part, "#:$")
+	if end == -1 {
+		end = len(part)
+	}
+	p := &claimURLPart{name: part[:end]}
+	if p.name == "" {
+		return nil, errors.New("empty name")
+	}
+	if end == len(part) {
+		return p, nil
+	}
+
+	modifier, value := part[end], part[end+1:]
+	switch modifier {
+	case '#':
+		notHex := func(r rune) bool {
+			return !strings.ContainsRune("0123456789abcdefABCDEF", r)
+		}
+		if value == "" || len(value) > 2*20 ||
+			strings.IndexFunc(value, notHex) != -1 {
+
+			return nil, fmt.Errorf("invalid claim id %q", value)
+		}
+		p.claimID = strings.ToLower(value)
+
+	default:
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid %c modifier %q", modifier,
+				value)
+		}
+		if modifier == ':' {
+			p.sequence = n
+		} else {
+			p.position = n
+		}
+	}
+	return p, nil
+}
+
+// selectClaim returns the claim of the passed claims, which are in bid order,
+// selected by the modifier of the passed URL part among those accepted by
+// filter, or nil when there is none.
+func selectClaim(claims []btcjson.ClaimResult, part *claimURLPart,
+	filter func(*btcjson.ClaimResult) bool) *btcjson.ClaimResult {
+
+	position := 0
+	for i := range claims {
+		claim := &claims[i]
+		if !filter(claim) {
+			continue
+		}
+		position++
+		switch {
+		case part.claimID != "":
+			if strings.HasPrefix(claim.ClaimID, part.claimID) {
+				return claim
+			}
+		case part.sequence != 0:
+			if int(claim.Sequence) == part.sequence-1 {
+				return claim
+			}
+		case part.position != 0:
+			if position == part.position {
+				return claim
+			}
+		default:
+			return claim
+		}
+	}
+	return nil
+}
+
+// resolvePart returns the claims of the name of the passed URL part, and its
+// claim selected by the modifier among those accepted by filter.
+func (c *claimClient) resolvePart(part *claimURLPart, requireValues bool,
+	filter func(*btcjson.ClaimResult) bool) (*btcjson.GetClaimsForNameResult,
+	*btcjson.ClaimResult, error) {
+
+	result, err := c.fetchClaims(part.name, requireValues)
+	if err != nil {
+		return nil, nil, err
+	}
+	claim := selectClaim(result.Claims, part, filter)
+	if claim == nil {
+		return nil, nil, fmt.Errorf("No claim of %q matches", part.name)
+	}
+	return result, claim, nil
+}
+
+// signingChannel returns the claim id of the channel which signed a claim,
+// from its hex-encoded value, or "" when it isn't signed.
+func signingChannel(value string) string {
+	v, err := hex.DecodeString(value)
+	if err != nil || len(v) < signedValueMinLen || v[0] != signedValuePrefix {
+		return ""
+	}
+	id := make([]byte, 20)
+	for i := range id {
+		id[i] = v[20-i]
+	}
+	return hex.EncodeToString(id)
+}
+
+// supportsTotal returns the sum of the amounts of the supports of a claim.
+func supportsTotal(claim *btcjson.ClaimResult) int64 {
+	var total int64
+	for _, support := range claim.Supports {
+		total += support.Amount
+	}
+	return total
+}
+
+// resolve displays the claim of a LBRY URL.
+func (c *claimClient) resolve(args []string) error {
+	u, err := parseClaimURL(args[0])
+	if err != nil {
+		return err
+	}
+
+	var channel *btcjson.ClaimResult
+	if u.channel != nil {
+		_, channel, err = c.resolvePart(u.channel, false,
+			func(*btcjson.ClaimResult) bool { return true })
+		if err != nil {
+			return err
+		}
+	}
+	signedBy := func(claim *btcjson.ClaimResult) bool {
+		return channel == nil ||
+			signingChannel(claim.Value) == channel.ClaimID
+	}
+	result, claim, err := c.resolvePart(&u.claim, channel != nil, signedBy)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	row := func(key, format string, args ...interface{}) {
+		fmt.Fprintf(w, "%s:\t%s\n", key, fmt.Sprintf(format, args...))
+	}
+	row("URL", "%s", args[0])
+	row("Name", "%s", result.NormalizedName)
+	row("Claim ID", "%s", claim.ClaimID)
+	row("Outpoint", "%s:%d", claim.TXID, claim.N)
+	if claim.Address != "" {
+		row("Address", "%s", claim.Address)
+	}
+	row("Height", "%d (active at %d)", claim.Height, claim.ValidAtHeight)
+	row("Amount", "%s", btcutil.Amount(claim.Amount))
+	row("Supports", "%s (%d)", btcutil.Amount(supportsTotal(claim)),
+		len(claim.Supports))
+	row("Effective amount", "%s", btcutil.Amount(claim.EffectiveAmount))
+	row("Bid position", "%d of %d", claim.Bid+1, len(result.Claims))
+	row("Sequence", "%d", claim.Sequence+1)
+	if claim.Bid == 0 && claim.ValidAtHeight <= result.Height {
+		row("Controlling", "yes, since height %d",
+			result.LastTakeoverHeight)
+	} else {
+		row("Controlling", "no")
+	}
+	switch {
+	case claim.Value == "" && claim.Address == "":
+		row("Signing channel", "unknown (%v)", errValuesUnavailable)
+	case signingChannel(claim.Value) != "":
+		row("Signing channel", "%s", signingChannel(claim.Value))
+	default:
+		row("Signing channel", "none")
+	}
+	if claim.Value != "" {
+		row("Value", "%d bytes", len(claim.Value)/2)
+	}
+	row("Resolved at", "block %s (height %d)", result.Hash, result.Height)
+	return w.Flush()
+}
+
+// listByChannel displays the claims signed by a channel, which are found
+// among the names changed in the blocks scanned, from the passed start
+// height, or 1, to the passed end height, or the tip.
+func (c *claimClient) listByChannel(args []string) error {
+	u, err := parseClaimURLPart(strings.TrimPrefix(args[0], claimURLScheme))
+	if err != nil {
+		return fmt.Errorf("invalid channel %q: %v", args[0], err)
+	}
+	if !strings.HasPrefix(u.name, "@") {
+		return fmt.Errorf("%q isn't a channel name", u.name)
+	}
+
+	heights := make([]int32, 2)
+	for i, arg := range args[1:] {
+		height, err := strconv.ParseInt(arg, 10, 32)
+		if err != nil || height < 0 {
+			return fmt.Errorf("invalid height %q", arg)
+		}
+		heights[i] = int32(height)
+	}
+	start, end := heights[0], heights[1]
+	if start == 0 {
+		start = 1
+	}
+	if end == 0 {
+		var count int64
+		if err := c.call(btcjson.NewGetBlockCountCmd(), &count); err != nil {
+			return err
+		}
+		end = int32(count)
+	}
+
+	_, channel, err := c.resolvePart(u, true,
+		func(*btcjson.ClaimResult) bool { return true })
+	if err != nil {
+		return err
+	}
+
+	// The names changed in the blocks scanned are those which may have
+	// claims signed by the channel.
+	names := make(map[string]struct{})
+	for height := start; height <= end; height += claimScanBatchSize {
+		var cmds, results []interface{}
+		for h := height; h < height+claimScanBatchSize && h <= end; h++ {
+			hashOrHeight := strconv.Itoa(int(h))
+			cmds = append(cmds,
+				btcjson.NewGetChangesInBlockCmd(&hashOrHeight))
+			results = append(results,
+				&btcjson.GetChangesInBlockResult{})
+		}
+		errs, err := c.callBatch(cmds, results)
+		if err != nil {
+			return err
+		}
+		for i, err := range errs {
+			if err != nil {
+				return err
+			}
+			changes := results[i].(*btcjson.GetChangesInBlockResult)
+			for _, name := range changes.Names {
+				names[name] = struct{}{}
+			}
+		}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	type signedClaim struct {
+		name        string
+		claim       *btcjson.ClaimResult
+		controlling bool
+	}
+	var signed []signedClaim
+	for i := 0; i < len(sorted); i += claimScanBatchSize {
+		batch := sorted[i:]
+		if len(batch) > claimScanBatchSize {
+			batch = batch[:claimScanBatchSize]
+		}
+		var cmds, results []interface{}
+		for _, name := range batch {
+			cmds = append(cmds, btcjson.NewGetClaimsForNameCmd(name,
+				nil, btcjson.Bool(true)))
+			results = append(results,
+				&btcjson.GetClaimsForNameResult{})
+		}
+		errs, err := c.callBatch(cmds, results)
+		if err != nil {
+			return err
+		}
+		for j, err := range errs {
+			// The names whose claims were all spent since don't
+			// exist anymore.
+			var rpcErr *btcjson.RPCError
+			switch {
+			case isNoTxInfo(err):
+				return errValuesUnavailable
+			case errors.As(err, &rpcErr) &&
+				rpcErr.Code == btcjson.ErrRPCMisc:
+				continue
+			case err != nil:
+				return err
+			}
+			result := results[j].(*btcjson.GetClaimsForNameResult)
+			for k := range result.Claims {
+				claim := &result.Claims[k]
+				if signingChannel(claim.Value) != channel.ClaimID {
+					continue
+				}
+				signed = append(signed, signedClaim{
+					name:  result.NormalizedName,
+					claim: claim,
+					controlling: claim.Bid == 0 &&
+						claim.ValidAtHeight <= result.Height,
+				})
+			}
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if len(signed) > 0 {
+		fmt.Fprintln(w, "NAME\tCLAIM ID\tHEIGHT\tAMOUNT\tEFFECTIVE "+
+			"AMOUNT\tBID\tCONTROLLING")
+	}
+	for _, s := range signed {
+		controlling := "no"
+		if s.controlling {
+			controlling = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%d\t%s\n", s.name,
+			s.claim.ClaimID, s.claim.Height,
+			btcutil.Amount(s.claim.Amount),
+			btcutil.Amount(s.claim.EffectiveAmount), s.claim.Bid+1,
+			controlling)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("%d claims signed by %s#%s, among %d names changed in "+
+		"blocks %d to %d\n", len(signed), u.name, channel.ClaimID,
+		len(sorted), start, end)
+	return nil
+}
+
+// proof displays the claimtrie proof of a name at the tip, and verifies that
+// it yields the claimtrie hash of the tip.
+func (c *claimClient) proof(args []string) error {
+	var proof btcjson.GetNameProofResult
+	if err := c.call(btcjson.NewGetNameProofCmd(args[0]), &proof); err != nil {
+		return err
+	}
+	var block btcjson.GetBlockVerboseResult
+	cmd := btcjson.NewGetBlockCmd(proof.Hash, btcjson.Int(1))
+	if err := c.call(cmd, &block); err != nil {
+		return err
+	}
+	var claims btcjson.GetClaimsForNameResult
+	claimsCmd := btcjson.NewGetClaimsForNameCmd(args[0], &proof.Hash, nil)
+	if err := c.call(claimsCmd, &claims); err != nil {
+		return err
+	}
+
+	claimsHash, err := chainhash.NewHashFromStr(proof.ClaimsHash)
+	if err != nil {
+		return fmt.Errorf("invalid claims hash: %v", err)
+	}
+	pairs := make([]merkletrie.ProofPair, 0, len(proof.Pairs))
+	for _, p := range proof.Pairs {
+		hash, err := chainhash.NewHashFromStr(p.Hash)
+		if err != nil {
+			return fmt.Errorf("invalid proof hash: %v", err)
+		}
+		pairs = append(pairs, merkletrie.ProofPair{Odd: p.Odd, Hash: hash})
+	}
+	root := merkletrie.VerifyProof(claimsHash, pairs)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	row := func(key, format string, args ...interface{}) {
+		fmt.Fprintf(w, "%s:\t%s\n", key, fmt.Sprintf(format, args...))
+	}
+	row("Name", "%s", proof.NormalizedName)
+	row("Block", "%s (height %d)", proof.Hash, proof.Height)
+	for _, claim := range claims.Claims {
+		if claim.Bid == 0 && claim.ValidAtHeight <= claims.Height {
+			row("Controlling claim", "%s (%s)", claim.ClaimID,
+				btcutil.Amount(claim.EffectiveAmount))
+		}
+	}
+	row("Claims", "%d", len(claims.Claims))
+	row("Claims hash", "%s", proof.ClaimsHash)
+	row("Proof", "%d steps", len(pairs))
+	for i, p := range proof.Pairs {
+		side := "right"
+		if p.Odd {
+			side = "left"
+		}
+		row(fmt.Sprintf("  %d", i+1), "%-5s %s", side, p.Hash)
+	}
+	row("Computed root", "%s", root)
+	row("Block claimtrie", "%s", block.ClaimTrie)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if root.String() != block.ClaimTrie || root.String() != proof.ClaimTrie {
+		return fmt.Errorf("The proof of %q does NOT match the claimtrie "+
+			"hash of block %d", proof.NormalizedName, proof.Height)
+	}
+	fmt.Printf("The proof of %q matches the claimtrie hash of block %d\n",
+		proof.NormalizedName, proof.Height)
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseClaimURL(t *testing.T) {
+
+	r := require.New(t)
+
+	tests := []struct {
+		url     string
+		channel *claimURLPart
+		claim   claimURLPart
+	}{
+		{url: "lbry", claim: claimURLPart{name: "lbry"}},
+		{url: "lbry://lbry#AB12", claim: claimURLPart{name: "lbry",
+			claimID: "ab12"}},
+		{url: "lbry:2", claim: claimURLPart{name: "lbry", sequence: 2}},
+		{url: "lbry$3", claim: claimURLPart{name: "lbry", position: 3}},
+		{url: "lbry://@channel#1/stream:1",
+			channel: &claimURLPart{name: "@channel", claimID: "1"},
+			claim:   claimURLPart{name: "stream", sequence: 1}},
+	}
+	for _, test := range tests {
+		u, err := parseClaimURL(test.url)
+		r.NoError(err, test.url)
+		r.Equal(test.channel, u.channel, test.url)
+		r.Equal(test.claim, u.claim, test.url)
+	}
+
+	for _, url := range []string{"", "lbry://", "#ab", "lbry#", "lbry#xyz",
+		"lbry#" + strings.Repeat("a", 41), "lbry:0", "lbry$x",
+		"channel/stream", "@a/b/c", "@a/"} {
+
+		_, err := parseClaimURL(url)
+		r.Error(err, url)
+	}
+}
+
+func TestSelectClaim(t *testing.T) {
+
+	r := require.New(t)
+
+	// The claims are in bid order.
+	claims := []btcjson.ClaimResult{
+		{ClaimID: "aa01", Sequence: 1},
+		{ClaimID: "bb02", Sequence: 2},
+		{ClaimID: "aa03", Sequence: 0},
+	}
+	all := func(*btcjson.ClaimResult) bool { return true }
+	notFirst := func(claim *btcjson.ClaimResult) bool {
+		return claim.ClaimID != "aa01"
+	}
+
+	selected := func(part claimURLPart,
+		filter func(*btcjson.ClaimResult) bool) string {
+
+		claim := selectClaim(claims, &part, filter)
+		if claim == nil {
+			return ""
+		}
+		return claim.ClaimID
+	}
+	r.Equal("aa01", selected(claimURLPart{}, all))
+	r.Equal("bb02", selected(claimURLPart{}, notFirst))
+	r.Equal("aa01", selected(claimURLPart{claimID: "aa"}, all))
+	r.Equal("aa03", selected(claimURLPart{claimID: "aa"}, notFirst))
+	r.Equal("aa03", selected(claimURLPart{sequence: 1}, all))
+	r.Equal("bb02", selected(claimURLPart{sequence: 3}, all))
+	r.Equal("bb02", selected(claimURLPart{position: 2}, all))
+	r.Equal("aa03", selected(claimURLPart{position: 2}, notFirst))
+	r.Equal("", selected(claimURLPart{position: 3}, notFirst))
+	r.Equal("", selected(claimURLPart{claimID: "cc"}, all))
+}
+
+func TestSigningChannel(t *testing.T) {
+
+	r := require.New(t)
+
+	// The claim id of the channel is in reverse byte order.
+	channelID := "00112233445566778899aabbccddeeff00112233"
+	id, err := hex.DecodeString(channelID)
+	r.NoError(err)
+	value := []byte{signedValuePrefix}
+	for i := len(id) - 1; i >= 0; i-- {
+		value = append(value, id[i])
+	}
+	value = append(value, make([]byte, 64)...)
+	r.Equal(channelID, signingChannel(hex.EncodeToString(value)))
+
+	value[0] = 0x00
+	r.Empty(signingChannel(hex.EncodeToString(value)))
+	r.Empty(signingChannel(hex.EncodeToString(value[:signedValueMinLen-1])))
+	r.Empty(signingChannel("not hex"))
+}
@@ -12,6 +12,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	flags "github.com/jessevdk/go-flags"
 	"github.com/lbryio/lbcd/btcjson"
@@ -94,25 +95,45 @@ func listCommands() {
 //
 // See loadConfig for details on the configuration load process.
 type config struct {
-	ConfigFile     string `short:"C" long:"configfile" description:"Path to configuration file"`
-	ListCommands   bool   `short:"l" long:"listcommands" description:"List all of the supported commands and exit"`
-	NoTLS          bool   `long:"notls" description:"Disable TLS"`
-	TLSSkipVerify  bool   `long:"skipverify" description:"Do not verify tls certificates (not recommended!)"`
-	Proxy          string `long:"proxy" description:"Connect via SOCKS5 proxy (eg. 127.0.0.1:9050)"`
-	ProxyPass      string `long:"proxypass" default-mask:"-" description:"Password for proxy server"`
-	ProxyUser      string `long:"proxyuser" description:"Username for proxy server"`
-	RPCCert        string `short:"c" long:"rpccert" description:"RPC server certificate chain for validation"`
-	RPCPassword    string `short:"P" long:"rpcpass" default-mask:"-" description:"RPC password"`
-	RPCServer      string `short:"s" long:"rpcserver" description:"RPC server to connect to"`
-	RPCUser        string `short:"u" long:"rpcuser" description:"RPC username"`
-	TestNet3       bool   `long:"testnet" description:"Connect to testnet (default RPC server: localhost:19245)"`
-	RegressionTest bool   `long:"regtest" description:"Connect to the regression test network (default RPC server: localhost:29245)"`
-	SimNet         bool   `long:"simnet" description:"Connect to the simulation test network (default RPC server: localhost:39245)"`
-	SigNet         bool   `long:"signet" description:"Connect to signet (default RPC server: localhost:49245)"`
-	Wallet         bool   `long:"wallet" description:"Connect to wallet RPC server instead (default: localhost:9244, testnet: localhost:19244, regtest: localhost:29244)"`
-	ShowVersion    bool   `short:"V" long:"version" description:"Display version information and exit"`
-	Timed          bool   `short:"t" long:"timed" description:"Display RPC response time"`
-	Quiet          bool   `short:"q" long:"quiet" description:"Do not output results to stdout"`
+	ConfigFile     string        `short:"C" long:"configfile" description:"Path to configuration file"`
+	ListCommands   bool          `short:"l" long:"listcommands" description:"List all of the supported commands and exit"`
+	NoTLS          bool          `long:"notls" description:"Disable TLS"`
+	TLSSkipVerify  bool          `long:"skipverify" description:"Do not verify tls certificates (not recommended!)"`
+	Proxy          string        `long:"proxy" description:"Connect via SOCKS5 proxy (eg. 127.0.0.1:9050)"`
+	ProxyPass      string        `long:"proxypass" default-mask:"-" description:"Password for proxy server"`
+	ProxyUser      string        `long:"proxyuser" description:"Username for proxy server"`
+	RPCCert        string        `short:"c" long:"rpccert" description:"RPC server certificate chain for validation"`
+	RPCPassword    string        `short:"P" long:"rpcpass" default-mask:"-" description:"RPC password"`
+	RPCServer      string        `short:"s" long:"rpcserver" description:"RPC server to connect to, or its unix domain socket in the format unix:<path>"`
+	RPCUser        string        `short:"u" long:"rpcuser" description:"RPC username"`
+	TestNet3       bool          `long:"testnet" description:"Connect to testnet (default RPC server: localhost:19245)"`
+	RegressionTest bool          `long:"regtest" description:"Connect to the regression test network (default RPC server: localhost:29245)"`
+	SimNet         bool          `long:"simnet" description:"Connect to the simulation test network (default RPC server: localhost:39245)"`
+	SigNet         bool          `long:"signet" description:"Connect to signet (default RPC server: localhost:49245)"`
+	Wallet         bool          `long:"wallet" description:"Connect to wallet RPC server instead (default: localhost:9244, testnet: localhost:19244, regtest: localhost:29244)"`
+	WalletServer   string        `long:"walletrpcserver" description:"Wallet RPC server to send the wallet commands to, the other commands going to the RPC server"`
+	WalletCert     string        `long:"walletrpccert" description:"Wallet RPC server certificate chain for validation"`
+	ShowVersion    bool          `short:"V" long:"version" description:"Display version information and exit"`
+	Timed          bool          `short:"t" long:"timed" description:"Display RPC response time"`
+	Quiet          bool          `short:"q" long:"quiet" description:"Do not output results to stdout"`
+	BatchSize      int           `long:"batchsize" description:"Number of commands of the batch command sent per JSON-RPC batch request (default: 1, one request per command)"`
+	Format         string        `long:"format" description:"Format of the results: json (indented), table or raw (the values, tab-separated per array element)"`
+	Fields         string        `long:"fields" description:"Comma-separated fields of the results to output, as simple JSONPath expressions such as claims[].claimid"`
+	Watch          time.Duration `long:"watch" optional:"yes" optional-value:"2s" description:"Run the command repeatedly at the interval, clearing the screen before each result (default interval: 2s)"`
+	WatchDiff      bool          `long:"watchdiff" description:"With --watch, display the changed lines of the results rather than clearing the screen"`
+	Profile        string        `long:"profile" description:"Connection profile of the config file to use, from its [profile <name>] section"`
+
+	// fieldPaths are the parsed paths of the Fields option, or nil when it
+	// isn't set.
+	fieldPaths [][]fieldSegment
+
+	// params are the parameters of the selected network, which the offline
+	// transaction subcommands encode and decode addresses for.
+	params *chaincfg.Params
+
+	// walletCfg is the config of the connection to the wallet RPC server of
+	// the WalletServer option, or nil when it isn't set.
+	walletCfg *config
 }
 
 // normalizeAddress returns addr with the passed default port appended if
@@ -191,6 +212,8 @@ func loadConfig() (*config, []string, error) {
 		ConfigFile: defaultConfigFile,
 		RPCServer:  defaultRPCServer,
 		RPCCert:    defaultRPCCertFile,
+		WalletCert: defaultWalletCertFile,
+		Format:     formatJSON,
 	}
 
 	// Pre-parse the command line options to see if an alternative config
@@ -207,7 +230,9 @@ func loadConfig() (*config, []string, error) {
 			fmt.Fprintln(os.Stderr, "The special parameter `-` "+
 				"indicates that a parameter should be read "+
 				"from the\nnext unread line from standard "+
-				"input.")
+				"input, and a parameter of the form "+
+				"`@file`\nthat it should be read from the "+
+				"existing file.")
 			return nil, nil, err
 		}
 	}
@@ -245,7 +270,7 @@ func loadConfig() (*config, []string, error) {
 
 	// Load additional config from file.
 	parser := flags.NewParser(&cfg, flags.Default)
-	err = flags.NewIniParser(parser).ParseFile(preCfg.ConfigFile)
+	profiles, err := readConfigFile(parser, preCfg.ConfigFile)
 	if err != nil {
 		if _, ok := err.(*os.PathError); !ok {
 			fmt.Fprintf(os.Stderr, "Error parsing config file: %v\n",
@@ -255,6 +280,21 @@ func loadConfig() (*config, []string, error) {
 		}
 	}
 
+	// Apply the connection profile selected on the command line, or else
+	// by the config file, over the options of the config file.
+	profile := cfg.Profile
+	if preCfg.Profile != "" {
+		profile = preCfg.Profile
+	}
+	if profile != "" {
+		err := applyProfile(&cfg, profiles, profile, preCfg.ConfigFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading profile: %v\n", err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+
 	// Parse command line options again to ensure they take precedence.
 	remainingArgs, err := parser.Parse()
 	if err != nil {
@@ -293,6 +333,37 @@ func loadConfig() (*config, []string, error) {
 		fmt.Fprintln(os.Stderr, err)
 		return nil, nil, err
 	}
+	cfg.params = network
+
+	// Validate the format and the fields of the results.
+	switch cfg.Format {
+	case formatJSON, formatTable, formatRaw:
+	default:
+		str := "%s: Invalid format '%s' -- choose json, table or raw"
+		err := fmt.Errorf(str, "loadConfig", cfg.Format)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.Fields != "" {
+		cfg.fieldPaths, err = parseFieldPaths(cfg.Fields)
+		if err != nil {
+			err := fmt.Errorf("%s: %v", "loadConfig", err)
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+	}
+
+	// Validate the watch interval.  The changes of the results are watched
+	// at the default interval when none is specified.
+	if cfg.Watch < 0 {
+		str := "%s: The watch interval may not be negative -- parsed [%v]"
+		err := fmt.Errorf(str, "loadConfig", cfg.Watch)
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.WatchDiff && cfg.Watch == 0 {
+		cfg.Watch = defaultWatchInterval
+	}
 
 	// Override the RPC certificate if the --wallet flag was specified and
 	// the user did not specify one.
@@ -304,10 +375,41 @@ func loadConfig() (*config, []string, error) {
 	cfg.RPCCert = cleanAndExpandPath(cfg.RPCCert)
 
 	// Add default port to RPC server based on --testnet and --wallet flags
-	// if needed.
-	cfg.RPCServer, err = normalizeAddress(cfg.RPCServer, network, cfg.Wallet)
-	if err != nil {
-		return nil, nil, err
+	// if needed.  The path of a unix domain socket is expanded instead.
+	if strings.HasPrefix(cfg.RPCServer, unixSocketPrefix) {
+		path := strings.TrimPrefix(cfg.RPCServer, unixSocketPrefix)
+		cfg.RPCServer = unixSocketPrefix + cleanAndExpandPath(path)
+	} else {
+		cfg.RPCServer, err = normalizeAddress(cfg.RPCServer, network, cfg.Wallet)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// The wallet commands are sent to the wallet RPC server, with the same
+	// credentials, when it is set.  The --wallet flag sends all of them
+	// there instead.
+	if cfg.WalletServer != "" {
+		if cfg.Wallet {
+			err := fmt.Errorf("%s: The --wallet and --walletrpcserver "+
+				"options may not be used together", "loadConfig")
+			fmt.Fprintln(os.Stderr, err)
+			return nil, nil, err
+		}
+		walletCfg := cfg
+		walletCfg.Wallet = true
+		walletCfg.RPCCert = cleanAndExpandPath(cfg.WalletCert)
+		if strings.HasPrefix(cfg.WalletServer, unixSocketPrefix) {
+			path := strings.TrimPrefix(cfg.WalletServer, unixSocketPrefix)
+			walletCfg.RPCServer = unixSocketPrefix + cleanAndExpandPath(path)
+		} else {
+			walletCfg.RPCServer, err = normalizeAddress(cfg.WalletServer,
+				network, true)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		cfg.walletCfg = &walletCfg
 	}
 
 	return &cfg, remainingArgs, nil
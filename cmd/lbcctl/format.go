@@ -0,0 +1,454 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// The formats of the results selected by the --format option.
+const (
+	formatJSON  = "json"
+	formatTable = "table"
+	formatRaw   = "raw"
+)
+
+// allElements is the index of a field segment selecting all the elements of
+// an array.
+const allElements = -1
+
+// fieldSegment is a segment of the path of a field: the key of an object, or
+// the index of an element of an array.
+type fieldSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parseFieldPaths parses the comma-separated field paths of the --fields
+// option.  A path is a simplified JSONPath, with an optional leading $, made of
+// the keys of the objects separated by dots, and of the indices of the array
+// elements within brackets, such as claims[0].claimid; [] or [*] select all
+// the elements.  The keys apply to all the elements of the arrays they are
+// matched against, so that addr selects the address of every peer of
+// getpeerinfo.
+func parseFieldPaths(fields string) ([][]fieldSegment, error) {
+	var paths [][]fieldSegment
+	for _, field := range strings.Split(fields, ",") {
+		path, err := parseFieldPath(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("invalid field %q: %v", field, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// parseFieldPath parses a field path of the --fields option.
+func parseFieldPath(path string) ([]fieldSegment, error) {
+	p := strings.TrimPrefix(path, "$")
+	var segments []fieldSegment
+	for len(p) > 0 {
+		if p[0] == '[' {
+			end := strings.IndexByte(p, ']')
+			if end == -1 {
+				return nil, errors.New("unterminated [")
+			}
+			index := allElements
+			if inner := p[1:end]; inner != "" && inner != "*" {
+				var err error
+				index, err = strconv.Atoi(inner)
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("invalid index %q", inner)
+				}
+			}
+			segments = append(segments, fieldSegment{
+				index:   index,
+				isIndex: true,
+			})
+			p = p[end+1:]
+			continue
+		}
+
+		p = strings.TrimPrefix(p, ".")
+		end := strings.IndexAny(p, ".[")
+		if end == -1 {
+			end = len(p)
+		}
+		if end == 0 {
+			return nil, errors.New("empty key")
+		}
+		segments = append(segments, fieldSegment{key: p[:end]})
+		p = p[end:]
+	}
+	if len(segments) == 0 {
+		return nil, errors.New("empty path")
+	}
+	return segments, nil
+}
+
+// jsonObject is a decoded JSON object which keeps the order of its keys, so
+// that the results are displayed in the order of the server.
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// set sets the value of a key, which is appended to the keys when it is new.
+func (o *jsonObject) set(key string, value interface{}) {
+	if o.values == nil {
+		o.values = make(map[string]interface{})
+	}
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// decodeJSON decodes a JSON value into a *jsonObject, a []interface{}, a
+// string, a json.Number, a bool or nil.
+func decodeJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return decodeJSONValue(dec)
+}
+
+// decodeJSONValue decodes the next JSON value of the passed decoder.
+func decodeJSONValue(dec *json.Decoder) (interface{}, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		object := &jsonObject{}
+		for dec.More() {
+			token, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := token.(string)
+			value, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			object.set(key, value)
+		}
+		_, err := dec.Token()
+		return object, err
+
+	case json.Delim('['):
+		array := []interface{}{}
+		for dec.More() {
+			value, err := decodeJSONValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err := dec.Token()
+		return array, err
+	}
+	return token, nil
+}
+
+// encodeJSON appends the compact JSON encoding of a value decoded by
+// decodeJSON to buf.  Unlike json.Marshal, it doesn't escape the HTML
+// characters of the strings.
+func encodeJSON(buf *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case *jsonObject:
+		buf.WriteByte('{')
+		for i, key := range v.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			encodeJSON(buf, key)
+			buf.WriteByte(':')
+			encodeJSON(buf, v.values[key])
+		}
+		buf.WriteByte('}')
+
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, elem := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			encodeJSON(buf, elem)
+		}
+		buf.WriteByte(']')
+
+	case string:
+		enc := json.NewEncoder(buf)
+		enc.SetEscapeHTML(false)
+		enc.Encode(v)
+		buf.Truncate(buf.Len() - 1) // Remove the newline of Encode.
+
+	case json.Number:
+		buf.WriteString(v.String())
+
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+
+	default:
+		buf.WriteString("null")
+	}
+}
+
+// selectFields returns the parts of the passed value at the passed paths,
+// with the structure of the value, and whether any path matched.
+func selectFields(value interface{}, paths [][]fieldSegment) (interface{}, bool) {
+	for _, path := range paths {
+		if len(path) == 0 {
+			return value, true
+		}
+	}
+
+	switch v := value.(type) {
+	case *jsonObject:
+		// The paths are grouped by key, in the order of the paths.
+		var keys []string
+		keyPaths := make(map[string][][]fieldSegment)
+		for _, path := range paths {
+			segment := path[0]
+			if segment.isIndex {
+				continue
+			}
+			if _, ok := v.values[segment.key]; !ok {
+				continue
+			}
+			if _, ok := keyPaths[segment.key]; !ok {
+				keys = append(keys, segment.key)
+			}
+			keyPaths[segment.key] = append(keyPaths[segment.key], path[1:])
+		}
+
+		selected := &jsonObject{}
+		for _, key := range keys {
+			child, ok := selectFields(v.values[key], keyPaths[key])
+			if ok {
+				selected.set(key, child)
+			}
+		}
+		return selected, len(selected.keys) > 0
+
+	case []interface{}:
+		selected := []interface{}{}
+		for i, elem := range v {
+			var elemPaths [][]fieldSegment
+			for _, path := range paths {
+				segment := path[0]
+				switch {
+				case !segment.isIndex:
+					elemPaths = append(elemPaths, path)
+				case segment.index == allElements || segment.index == i:
+					elemPaths = append(elemPaths, path[1:])
+				}
+			}
+			if len(elemPaths) == 0 {
+				continue
+			}
+			if child, ok := selectFields(elem, elemPaths); ok {
+				selected = append(selected, child)
+			}
+		}
+		return selected, len(selected) > 0
+	}
+	return nil, false
+}
+
+// filterResult returns the marshalled fields of the passed marshalled result
+// selected by the --fields option, or null when none of them is found.
+func filterResult(result []byte, cfg *config) ([]byte, error) {
+	if cfg.fieldPaths == nil {
+		return result, nil
+	}
+	value, err := decodeJSON(result)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := selectFields(value, cfg.fieldPaths)
+	if !ok {
+		value = nil
+	}
+	var buf bytes.Buffer
+	encodeJSON(&buf, value)
+	return buf.Bytes(), nil
+}
+
+// cellText returns the text of a value in a table or in the raw format: the
+// text of the scalars, with the strings unquoted, and the compact JSON encoding
+// of the objects and arrays.
+func cellText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	}
+	var buf bytes.Buffer
+	encodeJSON(&buf, value)
+	return buf.String()
+}
+
+// leaves returns the texts of the scalars of a value, in order.
+func leaves(value interface{}) []string {
+	switch v := value.(type) {
+	case *jsonObject:
+		var texts []string
+		for _, key := range v.keys {
+			texts = append(texts, leaves(v.values[key])...)
+		}
+		return texts
+
+	case []interface{}:
+		var texts []string
+		for _, elem := range v {
+			texts = append(texts, leaves(elem)...)
+		}
+		return texts
+	}
+	return []string{cellText(value)}
+}
+
+// rawLines returns the lines of a value in the raw format: the scalars are on
+// their own line, except those of the objects and arrays which are elements of
+// an array, which are on the line of their element separated by tabs.
+func rawLines(value interface{}) []string {
+	switch v := value.(type) {
+	case *jsonObject:
+		var lines []string
+		for _, key := range v.keys {
+			lines = append(lines, rawLines(v.values[key])...)
+		}
+		return lines
+
+	case []interface{}:
+		lines := make([]string, 0, len(v))
+		for _, elem := range v {
+			lines = append(lines, strings.Join(leaves(elem), "\t"))
+		}
+		return lines
+	}
+	return []string{cellText(value)}
+}
+
+// writeTable writes a value as a table.  The objects with a single key whose
+// value is an object or an array are replaced by that value, so that the table
+// of getclaimsforname --fields=claims is that of its claims.  An array of
+// objects has a row per element and a column per key, an object a row per key,
+// and an array of scalars a row per element.
+func writeTable(output io.Writer, value interface{}) error {
+	for {
+		object, ok := value.(*jsonObject)
+		if !ok || len(object.keys) != 1 {
+			break
+		}
+		child := object.values[object.keys[0]]
+		switch child.(type) {
+		case *jsonObject, []interface{}:
+			value = child
+			continue
+		}
+		break
+	}
+
+	w := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	writeRow := func(cells []string) {
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		// The columns are the keys of all the objects, in the order
+		// they are first found.
+		var columns []string
+		seen := make(map[string]struct{})
+		for _, elem := range v {
+			object, ok := elem.(*jsonObject)
+			if !ok {
+				columns = nil
+				break
+			}
+			for _, key := range object.keys {
+				if _, ok := seen[key]; !ok {
+					seen[key] = struct{}{}
+					columns = append(columns, key)
+				}
+			}
+		}
+		if columns == nil {
+			for _, elem := range v {
+				writeRow([]string{cellText(elem)})
+			}
+			break
+		}
+
+		header := make([]string, len(columns))
+		for i, column := range columns {
+			header[i] = strings.ToUpper(column)
+		}
+		writeRow(header)
+		for _, elem := range v {
+			object := elem.(*jsonObject)
+			cells := make([]string, len(columns))
+			for i, column := range columns {
+				if value, ok := object.values[column]; ok {
+					cells[i] = cellText(value)
+				}
+			}
+			writeRow(cells)
+		}
+
+	case *jsonObject:
+		for _, key := range v.keys {
+			writeRow([]string{key, cellText(v.values[key])})
+		}
+
+	case nil:
+
+	default:
+		writeRow([]string{cellText(v)})
+	}
+	return w.Flush()
+}
+
+// formatResult writes the passed marshalled result in the format selected by
+// the --format option, restricted to the fields selected by the --fields
+// option.
+func formatResult(output io.Writer, result []byte, cfg *config) error {
+	result, err := filterResult(result, cfg)
+	if err != nil {
+		return fmt.Errorf("Failed to select fields: %v", err)
+	}
+
+	switch cfg.Format {
+	case formatRaw:
+		value, err := decodeJSON(result)
+		if err != nil {
+			return fmt.Errorf("Failed to format result: %v", err)
+		}
+		if value == nil {
+			return nil
+		}
+		for _, line := range rawLines(value) {
+			fmt.Fprintln(output, line)
+		}
+		return nil
+
+	case formatTable:
+		value, err := decodeJSON(result)
+		if err != nil {
+			return fmt.Errorf("Failed to format result: %v", err)
+		}
+		return writeTable(output, value)
+	}
+	return printResult(output, result)
+}
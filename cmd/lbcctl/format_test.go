@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testClaimsResult is a result of getclaimsforname.
+const testClaimsResult = `{"name":"lbry","claims":[` +
+	`{"claimid":"aa","amount":10,"supports":[{"amount":1},{"amount":2}]},` +
+	`{"claimid":"bb","amount":5,"supports":[]}],"lastblock":100}`
+
+func TestParseFieldPaths(t *testing.T) {
+
+	r := require.New(t)
+
+	paths, err := parseFieldPaths("name, $.claims[0].claimid,claims[].amount,claims[*]")
+	r.NoError(err)
+	r.Equal([][]fieldSegment{
+		{{key: "name"}},
+		{{key: "claims"}, {index: 0, isIndex: true}, {key: "claimid"}},
+		{{key: "claims"}, {index: allElements, isIndex: true}, {key: "amount"}},
+		{{key: "claims"}, {index: allElements, isIndex: true}},
+	}, paths)
+
+	for _, fields := range []string{"", "$", "claims[", "claims[x]",
+		"claims[-1]", "claims..amount", "name,"} {
+
+		_, err := parseFieldPaths(fields)
+		r.Error(err, fields)
+	}
+}
+
+func TestFilterResult(t *testing.T) {
+
+	r := require.New(t)
+
+	tests := []struct {
+		fields string
+		result string
+	}{
+		{fields: "name", result: `{"name":"lbry"}`},
+		{fields: "lastblock,name", result: `{"name":"lbry","lastblock":100}`},
+		{fields: "claims[1].claimid", result: `{"claims":[{"claimid":"bb"}]}`},
+		{fields: "claims.claimid", result: `{"claims":[{"claimid":"aa"},{"claimid":"bb"}]}`},
+		{fields: "claims[].supports[].amount",
+			result: `{"claims":[{"supports":[{"amount":1},{"amount":2}]}]}`},
+		{fields: "claims[2]", result: `null`},
+		{fields: "unknown", result: `null`},
+	}
+	for _, test := range tests {
+		paths, err := parseFieldPaths(test.fields)
+		r.NoError(err, test.fields)
+		result, err := filterResult([]byte(testClaimsResult),
+			&config{fieldPaths: paths})
+		r.NoError(err, test.fields)
+		r.JSONEq(test.result, string(result), test.fields)
+	}
+
+	// The results are kept as is without fields.
+	result, err := filterResult([]byte(testClaimsResult), &config{})
+	r.NoError(err)
+	r.Equal(testClaimsResult, string(result))
+}
+
+func TestFormatResult(t *testing.T) {
+
+	r := require.New(t)
+
+	tests := []struct {
+		format string
+		fields string
+		result string
+		output string
+	}{{
+		format: formatTable,
+		fields: "claims.claimid,claims.amount",
+		result: testClaimsResult,
+		output: "CLAIMID  AMOUNT\n" +
+			"aa       10\n" +
+			"bb       5\n",
+	}, {
+		format: formatTable,
+		fields: "name,lastblock",
+		result: testClaimsResult,
+		output: "name       lbry\n" +
+			"lastblock  100\n",
+	}, {
+		// The columns are the keys of all the elements.
+		format: formatTable,
+		result: `[{"a":1},{"b":"x","a":{"c":true}}]`,
+		output: "A           B\n" +
+			"1           \n" +
+			`{"c":true}  x` + "\n",
+	}, {
+		format: formatTable,
+		result: `["a",1,null]`,
+		output: "a\n1\nnull\n",
+	}, {
+		format: formatTable,
+		result: `"abc"`,
+		output: "abc\n",
+	}, {
+		format: formatRaw,
+		fields: "claims.claimid,claims.amount",
+		result: testClaimsResult,
+		output: "aa\t10\nbb\t5\n",
+	}, {
+		format: formatRaw,
+		fields: "name,lastblock",
+		result: testClaimsResult,
+		output: "lbry\n100\n",
+	}, {
+		format: formatRaw,
+		fields: "unknown",
+		result: testClaimsResult,
+		output: "",
+	}, {
+		format: formatJSON,
+		fields: "lastblock",
+		result: testClaimsResult,
+		output: "{\n  \"lastblock\": 100\n}\n",
+	}, {
+		format: formatJSON,
+		result: `"abc"`,
+		output: "abc\n",
+	}}
+	for _, test := range tests {
+		cfg := &config{Format: test.format}
+		if test.fields != "" {
+			var err error
+			cfg.fieldPaths, err = parseFieldPaths(test.fields)
+			r.NoError(err)
+		}
+		var output bytes.Buffer
+		err := formatResult(&output, []byte(test.result), cfg)
+		r.NoError(err, test.format+" "+test.fields)
+		r.Equal(test.output, output.String(), test.format+" "+test.fields)
+	}
+}
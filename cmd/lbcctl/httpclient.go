@@ -9,14 +9,32 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"strings"
 
 	"github.com/btcsuite/go-socks/socks"
 	"github.com/lbryio/lbcd/btcjson"
 )
 
+// unixSocketPrefix is the prefix of an RPC server which is the path of a unix
+// domain socket.
+const unixSocketPrefix = "unix:"
+
 // newHTTPClient returns a new HTTP client that is configured according to the
 // proxy and TLS settings in the associated connection configuration.
 func newHTTPClient(cfg *config) (*http.Client, error) {
+	// Connections to a unix domain socket bypass the proxy and TLS.
+	if strings.HasPrefix(cfg.RPCServer, unixSocketPrefix) {
+		path := strings.TrimPrefix(cfg.RPCServer, unixSocketPrefix)
+		client := http.Client{
+			Transport: &http.Transport{
+				Dial: func(_, _ string) (net.Conn, error) {
+					return net.Dial("unix", path)
+				},
+			},
+		}
+		return &client, nil
+	}
+
 	// Configure proxy if needed.
 	var dial func(network, addr string) (net.Conn, error)
 	if cfg.Proxy != "" {
@@ -66,29 +84,66 @@ func newHTTPClient(cfg *config) (*http.Client, error) {
 // unmarshal the response as a JSON-RPC response and returns either the result
 // field or the error field depending on whether or not there is an error.
 func sendPostRequest(marshalledJSON []byte, cfg *config) ([]byte, error) {
+	// Create the new HTTP client that is configured according to the user-
+	// specified options and submit the request.
+	httpClient, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return postRequest(httpClient, marshalledJSON, cfg, false)
+}
+
+// postRequest sends the marshalled JSON-RPC command with the passed HTTP
+// client like sendPostRequest.  The connection is kept open for the following
+// requests of the client when keepAlive is set.
+func postRequest(httpClient *http.Client, marshalledJSON []byte, cfg *config,
+	keepAlive bool) ([]byte, error) {
+
+	respBytes, err := postJSON(httpClient, marshalledJSON, cfg, keepAlive)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal the response.
+	var resp btcjson.Response
+	if err := json.Unmarshal(respBytes, &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	return resp.Result, nil
+}
+
+// postJSON sends the marshalled JSON-RPC request, which may be a batch of
+// requests, with the passed HTTP client like postRequest, and returns the
+// marshalled response of a successful HTTP response.
+func postJSON(httpClient *http.Client, marshalledJSON []byte, cfg *config,
+	keepAlive bool) ([]byte, error) {
+
 	// Generate a request to the configured RPC server.
 	protocol := "http"
-	if !cfg.NoTLS {
+	host := cfg.RPCServer
+	switch {
+	case strings.HasPrefix(host, unixSocketPrefix):
+		// The socket path isn't a valid host.
+		host = "localhost"
+	case !cfg.NoTLS:
 		protocol = "https"
 	}
-	url := protocol + "://" + cfg.RPCServer
+	url := protocol + "://" + host
 	bodyReader := bytes.NewReader(marshalledJSON)
 	httpRequest, err := http.NewRequest("POST", url, bodyReader)
 	if err != nil {
 		return nil, err
 	}
-	httpRequest.Close = true
+	httpRequest.Close = !keepAlive
 	httpRequest.Header.Set("Content-Type", "application/json")
 
 	// Configure basic access authorization.
 	httpRequest.SetBasicAuth(cfg.RPCUser, cfg.RPCPassword)
 
-	// Create the new HTTP client that is configured according to the user-
-	// specified options and submit the request.
-	httpClient, err := newHTTPClient(cfg)
-	if err != nil {
-		return nil, err
-	}
 	httpResponse, err := httpClient.Do(httpRequest)
 	if err != nil {
 		return nil, err
@@ -112,17 +167,16 @@ func sendPostRequest(marshalledJSON []byte, cfg *config) ([]byte, error) {
 			return nil, fmt.Errorf("%d %s", httpResponse.StatusCode,
 				http.StatusText(httpResponse.StatusCode))
 		}
-		return nil, fmt.Errorf("%s", respBytes)
-	}
 
-	// Unmarshal the response.
-	var resp btcjson.Response
-	if err := json.Unmarshal(respBytes, &resp); err != nil {
-		return nil, err
-	}
+		// Servers such as the wallet reply some errors with a JSON-RPC
+		// response, whose error is returned as is.
+		var resp btcjson.Response
+		if err := json.Unmarshal(respBytes, &resp); err == nil &&
+			resp.Error != nil {
 
-	if resp.Error != nil {
-		return nil, resp.Error
+			return nil, resp.Error
+		}
+		return nil, fmt.Errorf("%s", bytes.TrimSpace(respBytes))
 	}
-	return resp.Result, nil
+	return respBytes, nil
 }
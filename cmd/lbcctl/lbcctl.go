@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -41,140 +42,339 @@ func usage(errorMessage string) {
 	appName = strings.TrimSuffix(appName, filepath.Ext(appName))
 	fmt.Fprintln(os.Stderr, errorMessage)
 	fmt.Fprintln(os.Stderr, "Usage:")
-	fmt.Fprintf(os.Stderr, "  %s [OPTIONS] <command> <args...>\n\n",
-		appName)
+	fmt.Fprintf(os.Stderr, "  %s [OPTIONS] <command> <args...>\n", appName)
+	fmt.Fprintf(os.Stderr, "  %s [OPTIONS] %s\n", appName, shellCommand)
+	fmt.Fprintf(os.Stderr, "  %s [OPTIONS] %s [file]\n", appName,
+		batchCommand)
+	fmt.Fprintf(os.Stderr, "  %s [OPTIONS] %s <subcommand> <args...>\n",
+		appName, claimCommand)
+	fmt.Fprintf(os.Stderr, "  %s [OPTIONS] %s <subcommand> <args...>\n\n",
+		appName, txCommand)
 	fmt.Fprintln(os.Stderr, showHelpMessage)
 	fmt.Fprintln(os.Stderr, listCmdMessage)
 }
 
-func main() {
-	cfg, args, err := loadConfig()
-	if err != nil {
-		os.Exit(1)
-	}
-	if len(args) < 1 {
-		usage("No command specified")
-		os.Exit(1)
-	}
-
+// marshalCommand returns the marshalled JSON-RPC request with the passed id of
+// the passed method with the passed params.  The error of an invalid command
+// is a btcjson.RPCError whose code tells whether its method is unknown or its
+// params are invalid.
+func marshalCommand(method string, params []interface{}, id interface{}) ([]byte, error) {
 	// Ensure the specified method identifies a valid registered command and
 	// is one of the usable types.
-	method := args[0]
 	usageFlags, err := btcjson.MethodUsageFlags(method)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Unrecognized command '%s'\n", method)
-		fmt.Fprintln(os.Stderr, listCmdMessage)
-		os.Exit(1)
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMethodNotFound.Code,
+			Message: fmt.Sprintf("Unrecognized command '%s'", method),
+		}
 	}
 	if usageFlags&unusableFlags != 0 {
-		fmt.Fprintf(os.Stderr, "The '%s' command can only be used via "+
-			"websockets\n", method)
-		fmt.Fprintln(os.Stderr, listCmdMessage)
-		os.Exit(1)
-	}
-
-	// Convert remaining command line args to a slice of interface values
-	// to be passed along as parameters to new command creation function.
-	//
-	// Since some commands, such as submitblock, can involve data which is
-	// too large for the Operating System to allow as a normal command line
-	// parameter, support using '-' as an argument to allow the argument
-	// to be read from a stdin pipe.
-	bio := bufio.NewReader(os.Stdin)
-	params := make([]interface{}, 0, len(args[1:]))
-	for _, arg := range args[1:] {
-		if arg == "-" {
-			param, err := bio.ReadString('\n')
-			if err != nil && err != io.EOF {
-				fmt.Fprintf(os.Stderr, "Failed to read data "+
-					"from stdin: %v\n", err)
-				os.Exit(1)
-			}
-			if err == io.EOF && len(param) == 0 {
-				fmt.Fprintln(os.Stderr, "Not enough lines "+
-					"provided on stdin")
-				os.Exit(1)
-			}
-			param = strings.TrimRight(param, "\r\n")
-			params = append(params, param)
-			continue
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCMethodNotFound.Code,
+			Message: fmt.Sprintf("The '%s' command can only be "+
+				"used via websockets", method),
 		}
-
-		params = append(params, arg)
 	}
 
 	// Attempt to create the appropriate command using the arguments
-	// provided by the user.
-	cmd, err := btcjson.NewCmd(method, params...)
+	// provided by the user.  The named arguments are passed by the name
+	// of their parameter rather than by position.
+	positional, named, err := splitNamedArgs(method, params)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParams.Code,
+			Message: fmt.Sprintf("%s command: %v", method, err),
+		}
+	}
+	var cmd interface{}
+	if named == nil {
+		cmd, err = btcjson.NewCmd(method, positional...)
+	} else {
+		cmd, err = btcjson.NewCmdNamed(method, positional, named)
+	}
 	if err != nil {
 		// Show the error along with its error code when it's a
 		// btcjson.Error as it reallistcally will always be since the
 		// NewCmd function is only supposed to return errors of that
 		// type.
+		message := fmt.Sprintf("%s command: %v", method, err)
 		if jerr, ok := err.(btcjson.Error); ok {
-			fmt.Fprintf(os.Stderr, "%s command: %v (code: %s)\n",
-				method, err, jerr.ErrorCode)
-			commandUsage(method)
-			os.Exit(1)
+			message += fmt.Sprintf(" (code: %s)", jerr.ErrorCode)
+		}
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParams.Code,
+			Message: message,
 		}
-
-		// The error is not a btcjson.Error and this really should not
-		// happen.  Nevertheless, fallback to just showing the error
-		// if it should happen due to a bug in the package.
-		fmt.Fprintf(os.Stderr, "%s command: %v\n", method, err)
-		commandUsage(method)
-		os.Exit(1)
 	}
 
 	// Marshal the command into a JSON-RPC byte slice in preparation for
 	// sending it to the RPC server.
-	marshalledJSON, err := btcjson.MarshalCmd(btcjson.RpcVersion1, 1, cmd)
+	return btcjson.MarshalCmd(btcjson.RpcVersion1, id, cmd)
+}
+
+// splitNamedArgs splits the passed args of a method into its positional args
+// and its named args, which are of the form name=value where name is the name
+// of one of the parameters of the method, matched case-insensitively.  The
+// other args, even those containing =, are positional, and must all precede
+// the named args.
+func splitNamedArgs(method string, args []interface{}) ([]interface{}, map[string]interface{}, error) {
+	paramNames, err := btcjson.MethodParamNames(method)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		return nil, nil, err
 	}
 
-	started := time.Now()
+	var positional []interface{}
+	var named map[string]interface{}
+	for _, arg := range args {
+		str, _ := arg.(string)
+		var name, value string
+		var isNamed bool
+		if i := strings.IndexByte(str, '='); i > 0 {
+			name, value = str[:i], str[i+1:]
+			for _, paramName := range paramNames {
+				if strings.EqualFold(name, paramName) {
+					isNamed = true
+					break
+				}
+			}
+		}
 
-	// Send the JSON-RPC request to the server using the user-specified
-	// connection configuration.
-	result, err := sendPostRequest(marshalledJSON, cfg)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
-	}
+		switch {
+		case isNamed:
+			if named == nil {
+				named = make(map[string]interface{})
+			}
+			if _, ok := named[strings.ToLower(name)]; ok {
+				return nil, nil, fmt.Errorf("parameter '%s' is "+
+					"supplied more than once", name)
+			}
+			named[strings.ToLower(name)] = value
 
-	if cfg.Timed {
-		elapsed := time.Since(started)
-		defer fmt.Fprintf(os.Stderr, "%s\n", elapsed)
+		case named != nil:
+			return nil, nil, fmt.Errorf("positional parameter '%v' "+
+				"follows named parameters", arg)
+
+		default:
+			positional = append(positional, arg)
+		}
 	}
+	return positional, named, nil
+}
 
-	var output io.Writer = os.Stdout
-	if cfg.Quiet {
-		output = io.Discard
+// newCommand returns the connection to the server of the passed method along
+// with the marshalled JSON-RPC request of the method with the passed params.
+// It displays why the command is invalid, followed by listHint or by the usage
+// of the method, and returns false otherwise.
+func newCommand(r *router, method string, params []interface{}, listHint string) (*connection, []byte, bool) {
+	conn, marshalledJSON, err := r.marshal(method, params, 1)
+	if rpcErr, ok := err.(*btcjson.RPCError); ok {
+		fmt.Fprintln(os.Stderr, rpcErr.Message)
+		if rpcErr.Code == btcjson.ErrRPCMethodNotFound.Code {
+			fmt.Fprintln(os.Stderr, listHint)
+		} else {
+			commandUsage(method)
+		}
+		return nil, nil, false
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, false
 	}
+	return conn, marshalledJSON, true
+}
 
-	// Choose how to display the result based on its type.
+// printResult displays the result of a command to the passed writer, choosing
+// how based on its type: objects and arrays are indented, and strings are
+// unquoted.
+func printResult(output io.Writer, result []byte) error {
 	strResult := string(result)
 	if strings.HasPrefix(strResult, "{") || strings.HasPrefix(strResult, "[") {
 		var dst bytes.Buffer
 		if err := json.Indent(&dst, result, "", "  "); err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to format result: %v",
-				err)
-			os.Exit(1)
+			return fmt.Errorf("Failed to format result: %v", err)
 		}
 		fmt.Fprintln(output, dst.String())
 
 	} else if strings.HasPrefix(strResult, `"`) {
 		var str string
 		if err := json.Unmarshal(result, &str); err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to unmarshal result: %v",
-				err)
-			os.Exit(1)
+			return fmt.Errorf("Failed to unmarshal result: %v", err)
 		}
 		fmt.Fprintln(output, str)
 
 	} else if strResult != "null" {
 		fmt.Fprintln(output, strResult)
 	}
+	return nil
+}
+
+// argFilePrefix prefixes the arguments naming a file whose contents are the
+// argument, such as @tx.hex.
+const argFilePrefix = "@"
+
+// readFileArg returns the argument, or the contents of the file it names when
+// it's prefixed with argFilePrefix, without their surrounding whitespace.  The
+// file has to exist: the other arguments are kept as is, since the names of the
+// channels, such as @lbry, have the same prefix.
+func readFileArg(arg string) (string, error) {
+	path := strings.TrimPrefix(arg, argFilePrefix)
+	if path == arg || path == "" {
+		return arg, nil
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return arg, nil
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("Failed to read argument from file: %v",
+			err)
+	}
+	return strings.TrimSpace(string(contents)), nil
+}
+
+// readArgs returns the passed args with each '-' replaced by the next line read
+// from stdin, and each name of a file prefixed with argFilePrefix by its
+// contents.
+//
+// Since some commands, such as submitblock, can involve data which is too
+// large for the Operating System to allow as a normal command line parameter,
+// this allows the argument to be read from a stdin pipe or a file.
+func readArgs(args []string) ([]string, error) {
+	bio := bufio.NewReader(os.Stdin)
+	result := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg != "-" {
+			arg, err := readFileArg(arg)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, arg)
+			continue
+		}
+
+		param, err := bio.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("Failed to read data from "+
+				"stdin: %v", err)
+		}
+		if err == io.EOF && len(param) == 0 {
+			return nil, errors.New("Not enough lines provided on " +
+				"stdin")
+		}
+		result = append(result, strings.TrimRight(param, "\r\n"))
+	}
+	return result, nil
+}
+
+func main() {
+	cfg, args, err := loadConfig()
+	if err != nil {
+		os.Exit(1)
+	}
+	if len(args) < 1 {
+		usage("No command specified")
+		os.Exit(1)
+	}
+
+	// Start the interactive shell when requested instead of running a
+	// single command.
+	method := args[0]
+	if method == shellCommand && len(args) == 1 {
+		if err := runShell(cfg); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Run the commands of a file or stdin when requested.
+	if method == batchCommand && len(args) <= 2 {
+		var path string
+		if len(args) == 2 {
+			path = args[1]
+		}
+		ok, err := runBatch(cfg, path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Run the claim subcommands, which compose the claim RPCs.
+	if method == claimCommand {
+		if err := runClaim(cfg, args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Run the tx subcommands, which process transactions offline.
+	if method == txCommand {
+		if err := runTx(cfg, args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Convert remaining command line args to a slice of interface values
+	// to be passed along as parameters to new command creation function.
+	cmdArgs, err := readArgs(args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	params := make([]interface{}, 0, len(cmdArgs))
+	for _, arg := range cmdArgs {
+		params = append(params, arg)
+	}
+
+	r, err := newRouter(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	conn, marshalledJSON, ok := newCommand(r, method, params, listCmdMessage)
+	if !ok {
+		os.Exit(1)
+	}
+
+	// Run the command repeatedly until interrupted when watching it.
+	if cfg.Watch > 0 {
+		if err := runWatch(conn, method, marshalledJSON); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	started := time.Now()
+
+	// Send the JSON-RPC request to the server of the method using the
+	// user-specified connection configuration.
+	result, err := conn.post(marshalledJSON, false)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if cfg.Timed {
+		elapsed := time.Since(started)
+		defer fmt.Fprintf(os.Stderr, "%s\n", elapsed)
+	}
+
+	var output io.Writer = os.Stdout
+	if cfg.Quiet {
+		output = io.Discard
+	}
+
+	if err := formatResult(output, result, cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadFileArg(t *testing.T) {
+
+	r := require.New(t)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tx.hex")
+	r.NoError(os.WriteFile(path, []byte("  0100abcd\n\n"), 0600))
+
+	arg, err := readFileArg("@" + path)
+	r.NoError(err)
+	r.Equal("0100abcd", arg)
+
+	// The arguments naming no file, such as the channel names, and those
+	// without the file prefix are kept as is.
+	for _, kept := range []string{"@lbry", "@", path, "lbry"} {
+		arg, err := readFileArg(kept)
+		r.NoError(err)
+		r.Equal(kept, arg)
+	}
+
+	// A file which exists but can't be read fails.
+	_, err = readFileArg("@" + dir)
+	r.Error(err)
+}
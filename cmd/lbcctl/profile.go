@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	flags "github.com/jessevdk/go-flags"
+)
+
+// profileSectionPrefix prefixes the name of the sections of the configuration
+// file holding connection profiles, such as [profile testnet].
+const profileSectionPrefix = "profile "
+
+// readConfigFile parses the options of the configuration file at path, except
+// those of its connection profiles, into the options of the parser.  The
+// profiles are returned by name, each as the contents of the file with only
+// the lines of its own section, so that the line numbers of the errors remain
+// those of the file.
+func readConfigFile(parser *flags.Parser, path string) (map[string]string, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each line is owned by the profile of its section, which is empty
+	// outside of the profile sections.  The section headers of the
+	// profiles are dropped.
+	lines := strings.Split(string(contents), "\n")
+	owners := make([]string, len(lines))
+	profiles := make(map[string]string)
+	var profile string
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			section := strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			profile = ""
+			if strings.HasPrefix(section, profileSectionPrefix) {
+				profile = strings.TrimSpace(section[len(profileSectionPrefix):])
+				if _, ok := profiles[profile]; ok || profile == "" {
+					return nil, &flags.IniError{
+						Message: fmt.Sprintf("invalid or "+
+							"duplicate profile %q", profile),
+						File:       path,
+						LineNumber: uint(i + 1),
+					}
+				}
+				profiles[profile] = ""
+				lines[i] = ""
+			}
+		}
+		owners[i] = profile
+	}
+
+	linesOf := func(owner string) string {
+		kept := make([]string, len(lines))
+		for i, line := range lines {
+			if owners[i] == owner {
+				kept[i] = line
+			}
+		}
+		return strings.Join(kept, "\n")
+	}
+	for name := range profiles {
+		profiles[name] = linesOf(name)
+	}
+
+	err = flags.NewIniParser(parser).Parse(strings.NewReader(linesOf("")))
+	if e, ok := err.(*flags.IniError); ok {
+		e.File = path
+	}
+	return profiles, err
+}
+
+// applyProfile parses the options of the named connection profile into cfg.
+// The network of a profile selecting one replaces that of the options of the
+// configuration file.
+func applyProfile(cfg *config, profiles map[string]string, name, path string) error {
+	contents, ok := profiles[name]
+	if !ok {
+		if len(profiles) == 0 {
+			return fmt.Errorf("Unknown profile %q -- %s has no [%s<name>] "+
+				"sections", name, path, profileSectionPrefix)
+		}
+		names := make([]string, 0, len(profiles))
+		for n := range profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("Unknown profile %q -- choose one of: %s", name,
+			strings.Join(names, ", "))
+	}
+
+	parse := func(dst *config) error {
+		parser := flags.NewParser(dst, flags.None)
+		err := flags.NewIniParser(parser).Parse(strings.NewReader(contents))
+		if e, ok := err.(*flags.IniError); ok {
+			e.File = path
+		}
+		return err
+	}
+
+	var profileCfg config
+	if err := parse(&profileCfg); err != nil {
+		return err
+	}
+	if profileCfg.TestNet3 || profileCfg.SimNet ||
+		profileCfg.RegressionTest || profileCfg.SigNet {
+
+		cfg.TestNet3 = false
+		cfg.SimNet = false
+		cfg.RegressionTest = false
+		cfg.SigNet = false
+	}
+	return parse(cfg)
+}
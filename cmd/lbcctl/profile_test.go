@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	flags "github.com/jessevdk/go-flags"
+	"github.com/stretchr/testify/require"
+)
+
+// testConfigFile is a configuration file with connection profiles.
+const testConfigFile = `rpcuser=user
+rpcpass=pass
+testnet=1
+profile=local
+
+[profile local]
+rpcserver=localhost:1234
+
+[profile regtest]
+rpcserver=regtest:2345
+regtest=1
+rpcuser=regtestuser
+`
+
+// writeTestConfigFile writes the configuration file to a temporary directory
+// and returns its path.
+func writeTestConfigFile(t *testing.T, contents string) string {
+	path := filepath.Join(t.TempDir(), "lbcctl.conf")
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0600))
+	return path
+}
+
+func TestApplyProfile(t *testing.T) {
+
+	r := require.New(t)
+
+	path := writeTestConfigFile(t, testConfigFile)
+	var cfg config
+	profiles, err := readConfigFile(flags.NewParser(&cfg, flags.None), path)
+	r.NoError(err)
+	r.Len(profiles, 2)
+
+	// The options of the profiles aren't those of the file.
+	r.Equal("user", cfg.RPCUser)
+	r.Equal("pass", cfg.RPCPassword)
+	r.Empty(cfg.RPCServer)
+	r.True(cfg.TestNet3)
+	r.Equal("local", cfg.Profile)
+
+	local := cfg
+	r.NoError(applyProfile(&local, profiles, "local", path))
+	r.Equal("localhost:1234", local.RPCServer)
+	r.Equal("user", local.RPCUser)
+	r.True(local.TestNet3)
+
+	// The network of the profile replaces that of the file.
+	regtest := cfg
+	r.NoError(applyProfile(&regtest, profiles, "regtest", path))
+	r.Equal("regtest:2345", regtest.RPCServer)
+	r.Equal("regtestuser", regtest.RPCUser)
+	r.Equal("pass", regtest.RPCPassword)
+	r.True(regtest.RegressionTest)
+	r.False(regtest.TestNet3)
+
+	err = applyProfile(&cfg, profiles, "mainnet", path)
+	r.EqualError(err, `Unknown profile "mainnet" -- choose one of: local, regtest`)
+	err = applyProfile(&cfg, nil, "mainnet", path)
+	r.EqualError(err, `Unknown profile "mainnet" -- `+path+
+		` has no [profile <name>] sections`)
+}
+
+func TestReadConfigFileErrors(t *testing.T) {
+
+	r := require.New(t)
+
+	path := writeTestConfigFile(t, "[profile a]\nrpcuser=a\n"+
+		"[profile a]\nrpcuser=b\n")
+	var cfg config
+	_, err := readConfigFile(flags.NewParser(&cfg, flags.None), path)
+	iniErr, ok := err.(*flags.IniError)
+	r.True(ok)
+	r.Equal(path, iniErr.File)
+	r.EqualValues(3, iniErr.LineNumber)
+
+	// The line numbers of the errors of a profile are those of the file.
+	path = writeTestConfigFile(t, "rpcuser=a\n[profile a]\nunknown=1\n")
+	profiles, err := readConfigFile(flags.NewParser(&cfg, flags.None), path)
+	r.NoError(err)
+	err = applyProfile(&cfg, profiles, "a", path)
+	iniErr, ok = err.(*flags.IniError)
+	r.True(ok)
+	r.Equal(path, iniErr.File)
+	r.EqualValues(3, iniErr.LineNumber)
+}
+
+func TestLoadConfigProfile(t *testing.T) {
+
+	r := require.New(t)
+
+	path := writeTestConfigFile(t, testConfigFile)
+	args := os.Args
+	defer func() {
+		os.Args = args
+	}()
+	load := func(args ...string) *config {
+		os.Args = append([]string{"lbcctl", "-C", path}, args...)
+		cfg, _, err := loadConfig()
+		r.NoError(err)
+		return cfg
+	}
+
+	// The profile of the config file is used unless the command line
+	// selects another one, whose options are overridden by those of the
+	// command line.
+	cfg := load()
+	r.Equal("localhost:1234", cfg.RPCServer)
+	r.Equal("user", cfg.RPCUser)
+	r.True(cfg.TestNet3)
+
+	cfg = load("--profile=regtest")
+	r.Equal("regtest:2345", cfg.RPCServer)
+	r.Equal("regtestuser", cfg.RPCUser)
+	r.True(cfg.RegressionTest)
+	r.False(cfg.TestNet3)
+
+	cfg = load("--profile=regtest", "-u", "cmdline", "-s", "cmdline:3456")
+	r.Equal("cmdline:3456", cfg.RPCServer)
+	r.Equal("cmdline", cfg.RPCUser)
+	r.Equal("pass", cfg.RPCPassword)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/lbryio/lbcd/btcjson"
+)
+
+// connection is a connection to an RPC server, whose HTTP client is kept for
+// the following requests.
+type connection struct {
+	cfg        *config
+	httpClient *http.Client
+
+	// methods are the methods listed by the help command of the server, or
+	// nil until they are first needed.
+	methods map[string]bool
+}
+
+// newConnection returns a connection to the RPC server of the passed config.
+func newConnection(cfg *config) (*connection, error) {
+	httpClient, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &connection{cfg: cfg, httpClient: httpClient}, nil
+}
+
+// post sends the marshalled JSON-RPC command to the server like postRequest.
+func (c *connection) post(marshalledJSON []byte, keepAlive bool) ([]byte, error) {
+	return postRequest(c.httpClient, marshalledJSON, c.cfg, keepAlive)
+}
+
+// usage returns the one-line usages of the methods of the server, one per
+// line.
+func (c *connection) usage() (string, error) {
+	cmd := btcjson.NewHelpCmd(nil)
+	marshalledJSON, err := btcjson.MarshalCmd(btcjson.RpcVersion1, 1, cmd)
+	if err != nil {
+		return "", err
+	}
+	result, err := c.post(marshalledJSON, true)
+	if err != nil {
+		return "", err
+	}
+	var usage string
+	err = json.Unmarshal(result, &usage)
+	return usage, err
+}
+
+// listedMethods returns the methods listed by the help command of the server,
+// which are fetched once.  A server whose help can't be fetched lists none.
+func (c *connection) listedMethods() map[string]bool {
+	if c.methods == nil {
+		c.methods = make(map[string]bool)
+		usage, err := c.usage()
+		if err == nil {
+			for _, line := range strings.Split(usage, "\n") {
+				if fields := strings.Fields(line); len(fields) > 0 {
+					c.methods[fields[0]] = true
+				}
+			}
+		}
+	}
+	return c.methods
+}
+
+// router routes each command to the server of its method: the wallet RPC
+// server of the --walletrpcserver option for the wallet commands when it is
+// set, and the RPC server otherwise.
+type router struct {
+	chain  *connection
+	wallet *connection
+}
+
+// newRouter returns a router over new connections to the servers of the
+// passed config.
+func newRouter(cfg *config) (*router, error) {
+	chain, err := newConnection(cfg)
+	if err != nil {
+		return nil, err
+	}
+	r := &router{chain: chain}
+	if cfg.walletCfg != nil {
+		r.wallet, err = newConnection(cfg.walletCfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// connections returns the connections of the router, the one to the RPC server
+// first.
+func (r *router) connections() []*connection {
+	if r.wallet == nil {
+		return []*connection{r.chain}
+	}
+	return []*connection{r.chain, r.wallet}
+}
+
+// route returns the connection to the server of the passed method.  The
+// methods known to this utility are routed by their usage flags, and the others
+// to the first server whose help lists them.
+func (r *router) route(method string) *connection {
+	if r.wallet == nil {
+		return r.chain
+	}
+	if flags, err := btcjson.MethodUsageFlags(method); err == nil {
+		if flags&btcjson.UFWalletOnly != 0 {
+			return r.wallet
+		}
+		return r.chain
+	}
+	if !r.chain.listedMethods()[method] && r.wallet.listedMethods()[method] {
+		return r.wallet
+	}
+	return r.chain
+}
+
+// marshal returns the connection to the server of the passed method along with
+// the marshalled request of the command like marshalCommand.  The methods this
+// utility doesn't know, but which the help of their server lists, are passed
+// through as is.
+func (r *router) marshal(method string, params []interface{}, id interface{}) (*connection, []byte, error) {
+	conn := r.route(method)
+	marshalledJSON, err := marshalCommand(method, params, id)
+	if _, unknown := btcjson.MethodUsageFlags(method); unknown != nil &&
+		conn.listedMethods()[method] {
+
+		marshalledJSON, err = marshalPassthrough(method, params, id)
+	}
+	return conn, marshalledJSON, err
+}
+
+// marshalPassthrough returns the marshalled JSON-RPC request with the passed id
+// of a method unknown to this utility.  Each param is passed as the JSON value
+// it is when it is valid JSON, such as a number, and as a string otherwise.
+func marshalPassthrough(method string, params []interface{}, id interface{}) ([]byte, error) {
+	rawParams := make([]interface{}, 0, len(params))
+	for _, param := range params {
+		str, ok := param.(string)
+		if ok && json.Valid([]byte(str)) {
+			rawParams = append(rawParams, json.RawMessage(str))
+			continue
+		}
+		rawParams = append(rawParams, param)
+	}
+	request, err := btcjson.NewRequest(btcjson.RpcVersion1, id, method,
+		rawParams)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(request)
+}
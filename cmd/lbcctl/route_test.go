@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRouter returns a router over connections whose help lists the passed
+// methods, which are never connected.
+func newTestRouter(chainMethods, walletMethods []string) *router {
+	newConn := func(methods []string) *connection {
+		conn := &connection{cfg: &config{}, methods: make(map[string]bool)}
+		for _, method := range methods {
+			conn.methods[method] = true
+		}
+		return conn
+	}
+	r := &router{chain: newConn(chainMethods)}
+	if walletMethods != nil {
+		r.wallet = newConn(walletMethods)
+	}
+	return r
+}
+
+func TestRouterRoute(t *testing.T) {
+
+	r := require.New(t)
+
+	// All the methods go to the RPC server without a wallet RPC server.
+	router := newTestRouter([]string{"getblockcount"}, nil)
+	r.Equal(router.chain, router.route("getbalance"))
+	r.Equal(router.chain, router.route("walletpassphrase2"))
+	r.Len(router.connections(), 1)
+
+	router = newTestRouter([]string{"getblockcount", "both"},
+		[]string{"getbalance", "walletpassphrase2", "both"})
+	r.Equal(router.wallet, router.route("getbalance"))
+	r.Equal(router.chain, router.route("getblockcount"))
+	r.Equal(router.wallet, router.route("walletpassphrase2"))
+	r.Equal(router.chain, router.route("both"))
+	r.Equal(router.chain, router.route("unknown"))
+	r.Equal([]*connection{router.chain, router.wallet}, router.connections())
+}
+
+func TestRouterMarshal(t *testing.T) {
+
+	r := require.New(t)
+
+	router := newTestRouter([]string{"getblockcount"}, []string{"custom"})
+
+	conn, request, err := router.marshal("getblock", []interface{}{"abc",
+		"0"}, 1)
+	r.NoError(err)
+	r.Equal(router.chain, conn)
+	r.JSONEq(`{"jsonrpc":"1.0","method":"getblock","params":["abc",0],"id":1}`,
+		string(request))
+
+	// The methods unknown to lbcctl, but listed by their server, are passed
+	// through with their params as the JSON values they are.
+	conn, request, err = router.marshal("custom", []interface{}{"1", "abc",
+		`{"a":[true]}`, "null"}, 2)
+	r.NoError(err)
+	r.Equal(router.wallet, conn)
+	r.JSONEq(`{"jsonrpc":"1.0","method":"custom",`+
+		`"params":[1,"abc",{"a":[true]},null],"id":2}`, string(request))
+
+	_, _, err = router.marshal("unknown", nil, 3)
+	rpcErr, ok := err.(*btcjson.RPCError)
+	r.True(ok)
+	r.Equal(btcjson.ErrRPCMethodNotFound.Code, rpcErr.Code)
+
+	_, _, err = router.marshal("getblock", nil, 4)
+	rpcErr, ok = err.(*btcjson.RPCError)
+	r.True(ok)
+	r.Equal(btcjson.ErrRPCInvalidParams.Code, rpcErr.Code)
+}
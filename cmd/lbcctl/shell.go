@@ -0,0 +1,347 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/lbryio/lbcd/btcjson"
+	"golang.org/x/term"
+)
+
+const (
+	// shellCommand is the command starting the interactive shell.
+	shellCommand = "shell"
+
+	// shellPrompt is the prompt of the lines typed in the shell.
+	shellPrompt = "lbcctl> "
+
+	// shellListMessage tells how to list the commands in the shell.
+	shellListMessage = "Type help to list the available commands"
+
+	// shellWidth is the width of the terminal assumed when it isn't known.
+	shellWidth = 80
+)
+
+// shellExitCommands are the commands leaving the shell.
+var shellExitCommands = []string{"exit", "quit"}
+
+// shell runs the commands typed by the user, one per line, over a single
+// connection to each RPC server kept alive between them.
+type shell struct {
+	cfg    *config
+	router *router
+
+	// methods are the sorted methods completed by the tab key.
+	methods []string
+
+	// term edits the lines typed in a terminal, and keeps their history,
+	// or is nil when the commands are piped to the shell.
+	term  *term.Terminal
+	width int
+}
+
+// runShell runs the interactive shell until the user leaves it with exit,
+// quit or an end of file.
+func runShell(cfg *config) error {
+	r, err := newRouter(cfg)
+	if err != nil {
+		return err
+	}
+	s := &shell{cfg: cfg, router: r, width: shellWidth}
+	s.methods = s.fetchMethods()
+
+	// The commands piped to the shell are run without prompt.
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		bio := bufio.NewReader(os.Stdin)
+		for {
+			line, err := bio.ReadString('\n')
+			if err != nil && err != io.EOF {
+				return err
+			}
+			if len(line) > 0 && !s.run(line) {
+				return nil
+			}
+			if err == io.EOF {
+				return nil
+			}
+		}
+	}
+
+	s.term = term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, shellPrompt)
+	s.term.AutoCompleteCallback = s.complete
+	for {
+		line, err := s.readLine(fd)
+		if err == io.EOF {
+			fmt.Println()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !s.run(line) {
+			return nil
+		}
+	}
+}
+
+// readLine reads a line typed in the terminal, which is in raw mode while the
+// line is edited so that the output of the commands is displayed as usual.
+func (s *shell) readLine(fd int) (string, error) {
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(fd, state)
+
+	if width, height, err := term.GetSize(fd); err == nil && width > 0 {
+		s.width = width
+		s.term.SetSize(width, height)
+	}
+	return s.term.ReadLine()
+}
+
+// run runs the command of the passed line, and returns false when it leaves
+// the shell.  The errors are displayed rather than returned, so that the user
+// may fix the command.
+func (s *shell) run(line string) bool {
+	args, err := splitArgs(line)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return true
+	}
+	if len(args) == 0 {
+		return true
+	}
+	for _, exit := range shellExitCommands {
+		if args[0] == exit {
+			return false
+		}
+	}
+
+	params := make([]interface{}, 0, len(args[1:]))
+	for _, arg := range args[1:] {
+		param, err := readFileArg(arg)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return true
+		}
+		params = append(params, param)
+	}
+	conn, marshalledJSON, ok := newCommand(s.router, args[0], params,
+		shellListMessage)
+	if !ok {
+		return true
+	}
+
+	started := time.Now()
+	result, err := conn.post(marshalledJSON, true)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return true
+	}
+
+	if s.cfg.Timed {
+		elapsed := time.Since(started)
+		defer fmt.Fprintf(os.Stderr, "%s\n", elapsed)
+	}
+
+	var output io.Writer = os.Stdout
+	if s.cfg.Quiet {
+		output = io.Discard
+	}
+	if err := formatResult(output, result, s.cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	return true
+}
+
+// fetchMethods returns the sorted methods to complete: those listed by the
+// help command of the RPC servers which are usable from this utility or
+// unknown to it, or all the usable methods it knows when the servers don't
+// list them, along with the commands leaving the shell.
+func (s *shell) fetchMethods() []string {
+	usable := func(method string) bool {
+		flags, err := btcjson.MethodUsageFlags(method)
+		return err == nil && flags&unusableFlags == 0
+	}
+
+	var methods []string
+	listed := make(map[string]bool)
+	for _, conn := range s.router.connections() {
+		for method := range conn.listedMethods() {
+			flags, err := btcjson.MethodUsageFlags(method)
+			if listed[method] || err == nil && flags&unusableFlags != 0 {
+				continue
+			}
+			listed[method] = true
+			methods = append(methods, method)
+		}
+	}
+	if len(methods) == 0 {
+		for _, method := range btcjson.RegisteredCmdMethods() {
+			if usable(method) {
+				methods = append(methods, method)
+			}
+		}
+	}
+
+	methods = append(methods, shellExitCommands...)
+	sort.Strings(methods)
+	return methods
+}
+
+// complete is the autocomplete callback of the terminal.  The tab key
+// completes the method of the line, the method whose help is requested, or the
+// name of a named argument of the method.  The candidates matching the word
+// are listed when it can't be completed further.
+func (s *shell) complete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+
+	prefix := line[:pos]
+	start := strings.LastIndexFunc(prefix, unicode.IsSpace) + 1
+	word := prefix[start:]
+	candidates, suffix := s.methods, " "
+	switch previous := strings.Fields(prefix[:start]); {
+	case len(previous) == 0:
+	case len(previous) == 1 && previous[0] == "help":
+	case !strings.Contains(word, "="):
+		names, err := btcjson.MethodParamNames(previous[0])
+		if err != nil {
+			return "", 0, false
+		}
+		candidates, suffix = names, "="
+	default:
+		return "", 0, false
+	}
+
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, word) {
+			matches = append(matches, candidate)
+		}
+	}
+
+	var completion string
+	switch len(matches) {
+	case 0:
+		return "", 0, false
+	case 1:
+		completion = matches[0] + suffix
+	default:
+		completion = commonPrefix(matches)
+		if completion == word {
+			s.listMatches(matches)
+		}
+	}
+	newLine := prefix[:start] + completion + line[pos:]
+	return newLine, start + len(completion), true
+}
+
+// listMatches displays the passed candidates in columns above the line being
+// edited.
+func (s *shell) listMatches(candidates []string) {
+	columnWidth := 0
+	for _, candidate := range candidates {
+		if len(candidate) > columnWidth {
+			columnWidth = len(candidate)
+		}
+	}
+	columnWidth += 2
+	columns := s.width / columnWidth
+	if columns < 1 {
+		columns = 1
+	}
+
+	var list strings.Builder
+	for i, candidate := range candidates {
+		if i%columns == columns-1 || i == len(candidates)-1 {
+			list.WriteString(candidate + "\n")
+			continue
+		}
+		list.WriteString(candidate +
+			strings.Repeat(" ", columnWidth-len(candidate)))
+	}
+	s.term.Write([]byte(list.String()))
+}
+
+// commonPrefix returns the longest prefix of all the passed strings.
+func commonPrefix(strs []string) string {
+	prefix := strs[0]
+	for _, str := range strs[1:] {
+		for !strings.HasPrefix(str, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// splitArgs splits a command line into its arguments, which are separated by
+// spaces unless they are quoted or escaped with a backslash, as in a POSIX
+// shell: nothing is escaped within single quotes, and only double quotes and
+// backslashes are within double quotes.  This allows typing JSON arguments
+// such as '{"key":"value"}'.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	var inArg, escaped bool
+	var quote rune
+	for _, r := range line {
+		switch {
+		case escaped:
+			if quote == '"' && r != '"' && r != '\\' {
+				arg.WriteRune('\\')
+			}
+			arg.WriteRune(r)
+			escaped = false
+
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+
+		case quote != 0 && r == quote:
+			quote = 0
+
+		case quote != 0:
+			arg.WriteRune(r)
+
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	switch {
+	case quote != 0:
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	case escaped:
+		return nil, errors.New("unterminated backslash escape")
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
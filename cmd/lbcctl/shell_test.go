@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitArgs(t *testing.T) {
+
+	r := require.New(t)
+
+	tests := []struct {
+		line string
+		args []string
+		err  string
+	}{
+		{line: "", args: nil},
+		{line: "  getblockcount  ", args: []string{"getblockcount"}},
+		{line: "getblock abc 0", args: []string{"getblock", "abc", "0"}},
+		{line: `claimname 'a b' "c d"`, args: []string{"claimname", "a b", "c d"}},
+		{line: `decode '{"key":"value"}'`, args: []string{"decode", `{"key":"value"}`}},
+		{line: `a\ b`, args: []string{"a b"}},
+		{line: `'a\b'`, args: []string{`a\b`}},
+		{line: `"a\"b\\c\d"`, args: []string{`a"b\c\d`}},
+		{line: `a'b'"c"`, args: []string{"abc"}},
+		{line: `'' ""`, args: []string{"", ""}},
+		{line: `'abc`, err: "unterminated ' quote"},
+		{line: `"abc`, err: `unterminated " quote`},
+		{line: `abc\`, err: "unterminated backslash escape"},
+	}
+	for _, test := range tests {
+		args, err := splitArgs(test.line)
+		if test.err != "" {
+			r.EqualError(err, test.err, test.line)
+			continue
+		}
+		r.NoError(err, test.line)
+		r.Equal(test.args, args, test.line)
+	}
+}
+
+func TestCommonPrefix(t *testing.T) {
+
+	r := require.New(t)
+
+	r.Equal("getblock", commonPrefix([]string{"getblock"}))
+	r.Equal("getblock", commonPrefix([]string{"getblock", "getblockcount",
+		"getblockhash"}))
+	r.Equal("get", commonPrefix([]string{"getblock", "getclaimsforname"}))
+	r.Equal("", commonPrefix([]string{"getblock", "stop"}))
+}
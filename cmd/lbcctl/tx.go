@@ -0,0 +1,633 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/claimtrie/change"
+	"github.com/lbryio/lbcd/psbt"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/txscript/claimscript"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+const (
+	// txCommand is the command grouping the offline transaction
+	// subcommands.
+	txCommand = "tx"
+
+	// psbtMagic is the magic prefix of a serialized PSBT.
+	psbtMagic = "psbt\xff"
+
+	// witnessScaleFactor is the weight of the non-witness data of a
+	// transaction relative to its witness data.
+	witnessScaleFactor = 4
+)
+
+// txSubcommand is a subcommand of the tx command, which creates or processes
+// transactions without connecting to the RPC server.
+type txSubcommand struct {
+	name        string
+	usage       string
+	description string
+	minArgs     int
+	maxArgs     int // -1 when unlimited
+	run         func(params *chaincfg.Params, args []string) (interface{}, error)
+}
+
+// txSubcommands are the subcommands of the tx command, in the order of its
+// usage.
+var txSubcommands = []*txSubcommand{
+	{
+		name:        "create",
+		usage:       "<inputs> <outputs> [locktime]",
+		description: "Create an unsigned transaction",
+		minArgs:     2,
+		maxArgs:     3,
+		run:         txCreate,
+	},
+	{
+		name:        "createpsbt",
+		usage:       "<inputs> <outputs> [locktime]",
+		description: "Create a PSBT, with the UTXOs and scripts of the inputs",
+		minArgs:     2,
+		maxArgs:     3,
+		run:         txCreatePsbt,
+	},
+	{
+		name:        "decode",
+		usage:       "<tx|psbt>",
+		description: "Decode a transaction or a PSBT",
+		minArgs:     1,
+		maxArgs:     1,
+		run:         txDecode,
+	},
+	{
+		name:        "sign",
+		usage:       "<tx|psbt> <wif[,wif...]> [prevtxs [sighashtype]]",
+		description: "Sign a transaction or a PSBT with private keys",
+		minArgs:     2,
+		maxArgs:     4,
+		run:         txSign,
+	},
+	{
+		name:        "combine",
+		usage:       "<tx|psbt> <tx|psbt>...",
+		description: "Combine the signatures of copies of a transaction or PSBT",
+		minArgs:     2,
+		maxArgs:     -1,
+		run:         txCombine,
+	},
+}
+
+// txUsage returns the usage of the tx subcommands.
+func txUsage() string {
+	var usage strings.Builder
+	w := tabwriter.NewWriter(&usage, 0, 0, 2, ' ', 0)
+	for _, sub := range txSubcommands {
+		fmt.Fprintf(w, "  %s %s %s\t%s\n", txCommand, sub.name,
+			sub.usage, sub.description)
+	}
+	w.Flush()
+	return usage.String()
+}
+
+// runTx runs the tx subcommand of the passed arguments, and displays its
+// result as that of a command.
+func runTx(cfg *config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("No tx subcommand specified -- choose one "+
+			"of:\n%s", txUsage())
+	}
+
+	var sub *txSubcommand
+	for _, s := range txSubcommands {
+		if s.name == args[0] {
+			sub = s
+			break
+		}
+	}
+	if sub == nil {
+		return fmt.Errorf("Unrecognized tx subcommand %q -- choose one "+
+			"of:\n%s", args[0], txUsage())
+	}
+	if len(args)-1 < sub.minArgs ||
+		(sub.maxArgs >= 0 && len(args)-1 > sub.maxArgs) {
+
+		return fmt.Errorf("Usage:\n  %s %s %s", txCommand, sub.name,
+			sub.usage)
+	}
+
+	subArgs, err := readArgs(args[1:])
+	if err != nil {
+		return err
+	}
+	result, err := sub.run(cfg.params, subArgs)
+	if err != nil {
+		return err
+	}
+	marshalled, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	var output io.Writer = os.Stdout
+	if cfg.Quiet {
+		output = io.Discard
+	}
+	return formatResult(output, marshalled, cfg)
+}
+
+// txInput is an input of the create and createpsbt subcommands, or a
+// previous output of the sign subcommand.  The UTXO of an input is either the
+// hex encoded transaction holding it, or its public key script and amount.
+type txInput struct {
+	Txid          string   `json:"txid"`
+	Vout          uint32   `json:"vout"`
+	Sequence      *uint32  `json:"sequence"`
+	PrevTx        string   `json:"prevtx"`
+	ScriptPubKey  string   `json:"scriptPubKey"`
+	Amount        *float64 `json:"amount"`
+	RedeemScript  string   `json:"redeemScript"`
+	WitnessScript string   `json:"witnessScript"`
+}
+
+// outPoint returns the previous outpoint of the input.
+func (in *txInput) outPoint() (*wire.OutPoint, error) {
+	hash, err := chainhash.NewHashFromStr(in.Txid)
+	if err != nil {
+		return nil, fmt.Errorf("invalid txid %q: %v", in.Txid, err)
+	}
+	return wire.NewOutPoint(hash, in.Vout), nil
+}
+
+// utxo returns the output spent by the input, or nil if neither the previous
+// transaction nor the public key script of the input are passed.  The amount
+// of the output is 0 when only its public key script is passed.
+func (in *txInput) utxo() (*wire.TxOut, error) {
+	if in.PrevTx != "" {
+		prevTx, err := decodeRawTx(in.PrevTx)
+		if err != nil {
+			return nil, fmt.Errorf("invalid prevtx: %v", err)
+		}
+		if prevTx.TxHash().String() != in.Txid {
+			return nil, fmt.Errorf("prevtx is not the transaction %s",
+				in.Txid)
+		}
+		if in.Vout >= uint32(len(prevTx.TxOut)) {
+			return nil, fmt.Errorf("prevtx has no output %d", in.Vout)
+		}
+		return prevTx.TxOut[in.Vout], nil
+	}
+	if in.ScriptPubKey == "" {
+		return nil, nil
+	}
+
+	pkScript, err := hex.DecodeString(in.ScriptPubKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scriptPubKey: %v", err)
+	}
+	var amount btcutil.Amount
+	if in.Amount != nil {
+		amount, err = btcutil.NewAmount(*in.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("invalid amount: %v", err)
+		}
+	}
+	return wire.NewTxOut(int64(amount), pkScript), nil
+}
+
+// scripts returns the decoded redeem and witness scripts of the input.
+func (in *txInput) scripts() ([]byte, []byte, error) {
+	redeemScript, err := hex.DecodeString(in.RedeemScript)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid redeemScript: %v", err)
+	}
+	witnessScript, err := hex.DecodeString(in.WitnessScript)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid witnessScript: %v", err)
+	}
+	return redeemScript, witnessScript, nil
+}
+
+// parseTxInputs parses a JSON array of inputs.
+func parseTxInputs(arg string) ([]txInput, error) {
+	var inputs []txInput
+	if err := json.Unmarshal([]byte(arg), &inputs); err != nil {
+		return nil, fmt.Errorf("invalid inputs, which have to be a "+
+			"JSON array of objects: %v", err)
+	}
+	return inputs, nil
+}
+
+// txClaimOutput is a claim, update or support output of the create and
+// createpsbt subcommands.  The value is hex encoded.
+type txClaimOutput struct {
+	Name    string  `json:"name"`
+	ClaimID string  `json:"claimid"`
+	Value   string  `json:"value"`
+	Address string  `json:"address"`
+	Amount  float64 `json:"amount"`
+}
+
+// txOut returns the output of the passed kind: claim, update or support.
+func (c *txClaimOutput) txOut(params *chaincfg.Params, kind string) (*wire.TxOut, error) {
+	pkScript, err := addressScript(params, c.Address)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := outputAmount(c.Amount)
+	if err != nil {
+		return nil, err
+	}
+	value, err := hex.DecodeString(c.Value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value: %v", err)
+	}
+
+	var claimID change.ClaimID
+	if kind != "claim" {
+		claimID, err = claimscript.ParseClaimID(c.ClaimID)
+		if err != nil {
+			return nil, err
+		}
+	} else if c.ClaimID != "" {
+		return nil, errors.New("the claim id of a new claim derives " +
+			"from its outpoint")
+	}
+
+	var script []byte
+	switch {
+	case kind == "claim":
+		script, err = claimscript.ClaimName(c.Name, value, pkScript)
+	case kind == "update":
+		script, err = claimscript.UpdateClaim(c.Name, claimID, value,
+			pkScript)
+	case len(value) > 0:
+		script, err = claimscript.SupportClaimWithValue(c.Name, claimID,
+			value, pkScript)
+	default:
+		script, err = claimscript.SupportClaim(c.Name, claimID, pkScript)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return wire.NewTxOut(amount, script), nil
+}
+
+// parseTxOutputs parses the outputs, which are a JSON object, or an array of
+// JSON objects, whose fields are the outputs in order.  A field is either an
+// address with its amount, data with its hex encoded OP_RETURN payload, or a
+// claim, update or support with its txClaimOutput.
+func parseTxOutputs(params *chaincfg.Params, arg string) ([]*wire.TxOut, error) {
+	objects := []json.RawMessage{json.RawMessage(arg)}
+	if strings.HasPrefix(strings.TrimSpace(arg), "[") {
+		if err := json.Unmarshal([]byte(arg), &objects); err != nil {
+			return nil, fmt.Errorf("invalid outputs: %v", err)
+		}
+	}
+
+	var txOuts []*wire.TxOut
+	for _, object := range objects {
+		dec := json.NewDecoder(bytes.NewReader(object))
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+			return nil, errors.New("invalid outputs, which have to " +
+				"be a JSON object or an array of objects")
+		}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, fmt.Errorf("invalid outputs: %v", err)
+			}
+			key, _ := tok.(string)
+			var value json.RawMessage
+			if err := dec.Decode(&value); err != nil {
+				return nil, fmt.Errorf("invalid outputs: %v", err)
+			}
+
+			txOut, err := newTxOutput(params, key, value)
+			if err != nil {
+				return nil, fmt.Errorf("output %d (%s): %v",
+					len(txOuts), key, err)
+			}
+			txOuts = append(txOuts, txOut)
+		}
+	}
+	if len(txOuts) == 0 {
+		return nil, errors.New("no outputs specified")
+	}
+	return txOuts, nil
+}
+
+// newTxOutput returns the output of a field of the outputs.
+func newTxOutput(params *chaincfg.Params, key string, value json.RawMessage) (*wire.TxOut, error) {
+	switch key {
+	case "data":
+		var data string
+		if err := json.Unmarshal(value, &data); err != nil {
+			return nil, errors.New("the data has to be a hex string")
+		}
+		payload, err := hex.DecodeString(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid data: %v", err)
+		}
+		script, err := txscript.NullDataScript(payload)
+		if err != nil {
+			return nil, err
+		}
+		return wire.NewTxOut(0, script), nil
+
+	case "claim", "update", "support":
+		var c txClaimOutput
+		if err := json.Unmarshal(value, &c); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", key, err)
+		}
+		return c.txOut(params, key)
+	}
+
+	var amount float64
+	if err := json.Unmarshal(value, &amount); err != nil {
+		return nil, errors.New("the amount has to be a number")
+	}
+	pkScript, err := addressScript(params, key)
+	if err != nil {
+		return nil, err
+	}
+	satoshi, err := outputAmount(amount)
+	if err != nil {
+		return nil, err
+	}
+	return wire.NewTxOut(satoshi, pkScript), nil
+}
+
+// addressScript returns the public key script paying to the address.
+func addressScript(params *chaincfg.Params, address string) ([]byte, error) {
+	addr, err := btcutil.DecodeAddress(address, params)
+	if err != nil || !addr.IsForNet(params) {
+		return nil, fmt.Errorf("invalid address %q for the %s network",
+			address, params.Name)
+	}
+	return txscript.PayToAddrScript(addr)
+}
+
+// outputAmount returns the amount of an output in dewies.
+func outputAmount(amount float64) (int64, error) {
+	satoshi, err := btcutil.NewAmount(amount)
+	if err != nil || satoshi <= 0 || satoshi > btcutil.MaxSatoshi {
+		return 0, fmt.Errorf("invalid amount %v", amount)
+	}
+	return int64(satoshi), nil
+}
+
+// newUnsignedTx returns the unsigned transaction of the arguments of the
+// create and createpsbt subcommands, along with its inputs.
+func newUnsignedTx(params *chaincfg.Params, args []string) (*wire.MsgTx, []txInput, error) {
+	inputs, err := parseTxInputs(args[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	txOuts, err := parseTxOutputs(params, args[1])
+	if err != nil {
+		return nil, nil, err
+	}
+	var lockTime uint32
+	if len(args) > 2 {
+		n, err := strconv.ParseUint(args[2], 10, 32)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid locktime %q",
+				args[2])
+		}
+		lockTime = uint32(n)
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.LockTime = lockTime
+	for i := range inputs {
+		op, err := inputs[i].outPoint()
+		if err != nil {
+			return nil, nil, fmt.Errorf("input %d: %v", i, err)
+		}
+		txIn := wire.NewTxIn(op, nil, nil)
+		switch {
+		case inputs[i].Sequence != nil:
+			txIn.Sequence = *inputs[i].Sequence
+		case lockTime != 0:
+			txIn.Sequence = wire.MaxTxInSequenceNum - 1
+		}
+		tx.AddTxIn(txIn)
+	}
+	for _, txOut := range txOuts {
+		tx.AddTxOut(txOut)
+	}
+	return tx, inputs, nil
+}
+
+// txCreate runs the create subcommand.
+func txCreate(params *chaincfg.Params, args []string) (interface{}, error) {
+	tx, _, err := newUnsignedTx(params, args)
+	if err != nil {
+		return nil, err
+	}
+	return txToHex(tx)
+}
+
+// txCreatePsbt runs the createpsbt subcommand.  The claim information of the
+// inputs spending claims is added to the PSBT.
+func txCreatePsbt(params *chaincfg.Params, args []string) (interface{}, error) {
+	tx, inputs, err := newUnsignedTx(params, args)
+	if err != nil {
+		return nil, err
+	}
+	p, err := psbt.NewFromUnsignedTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	u, err := psbt.NewUpdater(p)
+	if err != nil {
+		return nil, err
+	}
+	for i := range inputs {
+		if err := addPsbtInput(u, i, &inputs[i]); err != nil {
+			return nil, fmt.Errorf("input %d: %v", i, err)
+		}
+	}
+	return psbt.B64Encode(p)
+}
+
+// addPsbtInput adds the UTXO and the scripts of the passed input to the input
+// at inIndex of the PSBT of the updater.  A previous transaction is added as a
+// non-witness UTXO, which legacy inputs require, and a public key script and
+// amount as a witness UTXO.
+func addPsbtInput(u *psbt.Updater, inIndex int, in *txInput) error {
+	utxo, err := in.utxo()
+	if err != nil {
+		return err
+	}
+	switch {
+	case in.PrevTx != "":
+		prevTx, _ := decodeRawTx(in.PrevTx)
+		err = u.AddInNonWitnessUtxo(prevTx, inIndex)
+	case utxo != nil:
+		err = u.AddInWitnessUtxo(utxo, inIndex)
+	}
+	if err != nil {
+		return err
+	}
+
+	redeemScript, witnessScript, err := in.scripts()
+	if err != nil {
+		return err
+	}
+	if len(redeemScript) > 0 {
+		if err := u.AddInRedeemScript(redeemScript, inIndex); err != nil {
+			return err
+		}
+	}
+	if len(witnessScript) > 0 {
+		if err := u.AddInWitnessScript(witnessScript, inIndex); err != nil {
+			return err
+		}
+	}
+
+	if utxo != nil && claimscript.IsClaimScript(utxo.PkScript) {
+		return psbt.AddInClaim(u, inIndex)
+	}
+	return nil
+}
+
+// decodeRawTx decodes a hex encoded transaction.
+func decodeRawTx(s string) (*wire.MsgTx, error) {
+	tx, p, err := decodeTxOrPsbt(s)
+	if err != nil {
+		return nil, err
+	}
+	if p != nil {
+		return nil, errors.New("a transaction is expected, not a PSBT")
+	}
+	return tx, nil
+}
+
+// decodeTxOrPsbt decodes a hex encoded transaction, or a PSBT, base64 or hex
+// encoded.  Either the transaction or the PSBT is returned.
+func decodeTxOrPsbt(s string) (*wire.MsgTx, *psbt.Packet, error) {
+	s = strings.TrimSpace(s)
+	serialized, err := hex.DecodeString(s)
+	if err != nil {
+		p, err := psbt.NewFromRawBytes(strings.NewReader(s), true)
+		if err != nil {
+			return nil, nil, errors.New("neither a hex encoded " +
+				"transaction nor a base64 encoded PSBT")
+		}
+		return nil, p, nil
+	}
+
+	if bytes.HasPrefix(serialized, []byte(psbtMagic)) {
+		p, err := psbt.NewFromRawBytes(bytes.NewReader(serialized), false)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid PSBT: %v", err)
+		}
+		return nil, p, nil
+	}
+
+	// A transaction without inputs, such as one created to be funded
+	// later, reads as having a witness marker, and so is deserialized again
+	// without witness.
+	var tx wire.MsgTx
+	err = tx.Deserialize(bytes.NewReader(serialized))
+	if err != nil {
+		tx = wire.MsgTx{}
+		if tx.DeserializeNoWitness(bytes.NewReader(serialized)) != nil {
+			return nil, nil, fmt.Errorf("invalid transaction: %v", err)
+		}
+	}
+	return &tx, nil, nil
+}
+
+// txToHex returns the hex encoding of the serialized transaction.
+func txToHex(tx *wire.MsgTx) (string, error) {
+	var buf bytes.Buffer
+	buf.Grow(tx.SerializeSize())
+	if err := tx.Serialize(&buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf.Bytes()), nil
+}
+
+// txCombine runs the combine subcommand.  PSBTs are combined by psbt.Combine,
+// whose inputs are finalized when they can be, with the result of the sign
+// subcommand; each input of combined transactions takes the first signature
+// script and witness found among their copies.
+func txCombine(params *chaincfg.Params, args []string) (interface{}, error) {
+	var txs []*wire.MsgTx
+	var packets []*psbt.Packet
+	for i, arg := range args {
+		tx, p, err := decodeTxOrPsbt(arg)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %v", i+1, err)
+		}
+		if p != nil {
+			packets = append(packets, p)
+		} else {
+			txs = append(txs, tx)
+		}
+	}
+
+	switch {
+	case len(packets) > 0 && len(txs) > 0:
+		return nil, errors.New("PSBTs can't be combined with " +
+			"transactions")
+
+	case len(packets) > 0:
+		combined, err := psbt.Combine(packets...)
+		if err != nil {
+			return nil, err
+		}
+
+		// The inputs still lacking signatures are left to be signed
+		// by other signers.
+		for i := range combined.Inputs {
+			_, _ = psbt.MaybeFinalize(combined, i)
+		}
+		return finalizePsbtResult(combined)
+	}
+
+	combined := txs[0].Copy()
+	unsignedHash := unsignedTxHash(combined)
+	for _, tx := range txs[1:] {
+		if unsignedTxHash(tx) != unsignedHash {
+			return nil, errors.New("Cannot combine different " +
+				"transactions")
+		}
+		for i, txIn := range tx.TxIn {
+			dst := combined.TxIn[i]
+			if len(dst.SignatureScript) == 0 {
+				dst.SignatureScript = txIn.SignatureScript
+			}
+			if len(dst.Witness) == 0 {
+				dst.Witness = txIn.Witness
+			}
+		}
+	}
+	return txToHex(combined)
+}
+
+// unsignedTxHash returns the hash of the transaction without its signature
+// scripts.
+func unsignedTxHash(tx *wire.MsgTx) chainhash.Hash {
+	unsigned := tx.Copy()
+	for _, txIn := range unsigned.TxIn {
+		txIn.SignatureScript = nil
+	}
+	return unsigned.TxHash()
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/lbryio/lbcd/btcec"
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/claimtrie/change"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/txscript/claimscript"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+	"github.com/stretchr/testify/require"
+)
+
+// testTxKeys are the private keys the test transactions are signed with.
+type testTxKeys struct {
+	wifs    []*btcutil.WIF
+	pubKeys [][]byte
+}
+
+// newTestTxKeys returns n private keys of the network.
+func newTestTxKeys(r *require.Assertions, params *chaincfg.Params, n int) *testTxKeys {
+	keys := &testTxKeys{}
+	for i := 1; i <= n; i++ {
+		key, _ := btcec.PrivKeyFromBytes(btcec.S256(),
+			bytes.Repeat([]byte{byte(i)}, 32))
+		wif, err := btcutil.NewWIF(key, params, true)
+		r.NoError(err)
+		keys.wifs = append(keys.wifs, wif)
+		keys.pubKeys = append(keys.pubKeys, wif.SerializePubKey())
+	}
+	return keys
+}
+
+// wif returns the comma-separated keys at the indexes in wallet import format.
+func (k *testTxKeys) wif(indexes ...int) string {
+	var s string
+	for i, index := range indexes {
+		if i > 0 {
+			s += ","
+		}
+		s += k.wifs[index].String()
+	}
+	return s
+}
+
+// testScript returns the script of the builder.
+func testScript(r *require.Assertions, builder *txscript.ScriptBuilder) []byte {
+	script, err := builder.Script()
+	r.NoError(err)
+	return script
+}
+
+// testPrevOut is an output of the funding transaction of the tests, along
+// with the scripts it commits to.
+type testPrevOut struct {
+	name          string
+	pkScript      []byte
+	redeemScript  []byte
+	witnessScript []byte
+	keys          []int
+}
+
+// testPrevOuts returns the outputs the test transactions spend: the legacy,
+// claim, support, witness and nested witness outputs of the first key, and a
+// 2-of-2 multisig witness script of the first two keys.
+func testPrevOuts(r *require.Assertions, keys *testTxKeys) []testPrevOut {
+	p2pkh := testScript(r, txscript.NewScriptBuilder().AddOp(txscript.OP_DUP).
+		AddOp(txscript.OP_HASH160).AddData(btcutil.Hash160(keys.pubKeys[0])).
+		AddOp(txscript.OP_EQUALVERIFY).AddOp(txscript.OP_CHECKSIG))
+	p2wpkh := testScript(r, txscript.NewScriptBuilder().AddOp(txscript.OP_0).
+		AddData(btcutil.Hash160(keys.pubKeys[0])))
+	p2shP2wpkh := testScript(r, txscript.NewScriptBuilder().
+		AddOp(txscript.OP_HASH160).AddData(btcutil.Hash160(p2wpkh)).
+		AddOp(txscript.OP_EQUAL))
+	multiSig := testScript(r, txscript.NewScriptBuilder().AddOp(txscript.OP_2).
+		AddData(keys.pubKeys[0]).AddData(keys.pubKeys[1]).
+		AddOp(txscript.OP_2).AddOp(txscript.OP_CHECKMULTISIG))
+	multiSigHash := sha256.Sum256(multiSig)
+	p2wsh := testScript(r, txscript.NewScriptBuilder().AddOp(txscript.OP_0).
+		AddData(multiSigHash[:]))
+	claim, err := claimscript.ClaimName("tester", []byte("value"), p2pkh)
+	r.NoError(err)
+	support, err := claimscript.SupportClaim("tester", change.ClaimID{1},
+		p2pkh)
+	r.NoError(err)
+
+	return []testPrevOut{
+		{name: "p2pkh", pkScript: p2pkh, keys: []int{0}},
+		{name: "claim", pkScript: claim, keys: []int{0}},
+		{name: "support", pkScript: support, keys: []int{0}},
+		{name: "p2wpkh", pkScript: p2wpkh, keys: []int{0}},
+		{name: "p2sh-p2wpkh", pkScript: p2shP2wpkh, redeemScript: p2wpkh,
+			keys: []int{0}},
+		{name: "p2wsh multisig", pkScript: p2wsh, witnessScript: multiSig,
+			keys: []int{0, 1}},
+	}
+}
+
+// testFundingTx returns the transaction paying 1 LBC to each of the outputs.
+func testFundingTx(prevOuts []testPrevOut) *wire.MsgTx {
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+	for _, prevOut := range prevOuts {
+		tx.AddTxOut(wire.NewTxOut(1e8, prevOut.pkScript))
+	}
+	return tx
+}
+
+// testTxInputs returns the JSON inputs of the tx subcommands spending the
+// outputs of the funding transaction at the indexes.  The UTXOs are passed as
+// the funding transaction for the legacy outputs, and as their public key
+// script and amount for the witness ones.
+func testTxInputs(r *require.Assertions, fundingTx *wire.MsgTx,
+	prevOuts []testPrevOut, indexes ...int) string {
+
+	fundingHex, err := txToHex(fundingTx)
+	r.NoError(err)
+	amount := 1.0
+
+	inputs := make([]txInput, 0, len(indexes))
+	for _, i := range indexes {
+		in := txInput{
+			Txid:          fundingTx.TxHash().String(),
+			Vout:          uint32(i),
+			RedeemScript:  hex.EncodeToString(prevOuts[i].redeemScript),
+			WitnessScript: hex.EncodeToString(prevOuts[i].witnessScript),
+		}
+		pkScript := prevOuts[i].pkScript
+		if txscript.IsWitnessProgram(pkScript) ||
+			len(prevOuts[i].redeemScript) > 0 {
+
+			in.ScriptPubKey = hex.EncodeToString(pkScript)
+			in.Amount = &amount
+		} else {
+			in.PrevTx = fundingHex
+		}
+		inputs = append(inputs, in)
+	}
+	marshalled, err := json.Marshal(inputs)
+	r.NoError(err)
+	return string(marshalled)
+}
+
+// verifyTestTx checks the scripts of all the inputs of the transaction spending
+// the outputs of the funding transaction.
+func verifyTestTx(r *require.Assertions, txHex string, fundingTx *wire.MsgTx) {
+	tx, err := decodeRawTx(txHex)
+	r.NoError(err)
+	sigHashes := txscript.NewTxSigHashes(tx)
+	for i, txIn := range tx.TxIn {
+		r.Equal(fundingTx.TxHash(), txIn.PreviousOutPoint.Hash)
+		prevOut := fundingTx.TxOut[txIn.PreviousOutPoint.Index]
+		vm, err := txscript.NewEngine(prevOut.PkScript, tx, i,
+			txscript.StandardVerifyFlags, nil, sigHashes, prevOut.Value)
+		r.NoError(err)
+		r.NoError(vm.Execute(), "input %d", i)
+	}
+}
+
+func TestTxSign(t *testing.T) {
+
+	r := require.New(t)
+	params := &chaincfg.RegressionNetParams
+
+	keys := newTestTxKeys(r, params, 2)
+	prevOuts := testPrevOuts(r, keys)
+	fundingTx := testFundingTx(prevOuts)
+	addr, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(keys.pubKeys[1]),
+		params)
+	r.NoError(err)
+	outputs := `{"` + addr.EncodeAddress() + `": 0.5}`
+
+	for i, prevOut := range prevOuts {
+		inputs := testTxInputs(r, fundingTx, prevOuts, i)
+		wifs := keys.wif(prevOut.keys...)
+
+		// The transaction is signed with the previous outputs passed
+		// as prevtxs.
+		txHex, err := txCreate(params, []string{inputs, outputs})
+		r.NoError(err, prevOut.name)
+		result, err := txSign(params, []string{txHex.(string), wifs, inputs})
+		r.NoError(err, prevOut.name)
+		signed := result.(*btcjson.SignRawTransactionResult)
+		r.True(signed.Complete, "%s: %v", prevOut.name, signed.Errors)
+		verifyTestTx(r, signed.Hex, fundingTx)
+
+		// The PSBT holds the UTXOs, and is finalized once signed.
+		b64, err := txCreatePsbt(params, []string{inputs, outputs})
+		r.NoError(err, prevOut.name)
+		result, err = txSign(params, []string{b64.(string), wifs})
+		r.NoError(err, prevOut.name)
+		finalized := result.(*btcjson.FinalizePsbtResult)
+		r.True(finalized.Complete, prevOut.name)
+		verifyTestTx(r, finalized.Hex, fundingTx)
+
+		// The keys of other scripts don't sign the input.
+		result, err = txSign(params, []string{txHex.(string),
+			keys.wif(1), inputs})
+		r.NoError(err, prevOut.name)
+		r.False(result.(*btcjson.SignRawTransactionResult).Complete,
+			prevOut.name)
+	}
+}
+
+func TestTxSignErrors(t *testing.T) {
+
+	r := require.New(t)
+	params := &chaincfg.RegressionNetParams
+
+	keys := newTestTxKeys(r, params, 2)
+	prevOuts := testPrevOuts(r, keys)
+	fundingTx := testFundingTx(prevOuts)
+	inputs := testTxInputs(r, fundingTx, prevOuts, 0, 3)
+	txHex, err := txCreate(params, []string{inputs, `{"data": "00"}`})
+	r.NoError(err)
+
+	tests := []struct {
+		name string
+		args []string
+		err  bool
+	}{
+		{"invalid key", []string{txHex.(string), "key"}, true},
+		{"wrong network", []string{txHex.(string),
+			keys.wifs[0].String()[1:] + "x"}, true},
+		{"invalid sighash", []string{txHex.(string), keys.wif(0), inputs,
+			"EVERYTHING"}, true},
+		{"invalid prevtxs", []string{txHex.(string), keys.wif(0), "{}"},
+			true},
+		{"missing prevtxs", []string{txHex.(string), keys.wif(0)}, false},
+	}
+	for _, test := range tests {
+		result, err := txSign(params, test.args)
+		if test.err {
+			r.Error(err, test.name)
+			continue
+		}
+		r.NoError(err, test.name)
+		signed := result.(*btcjson.SignRawTransactionResult)
+		r.False(signed.Complete, test.name)
+		r.Len(signed.Errors, 2, test.name)
+	}
+
+	// The witness inputs can't be signed without their amount.
+	var in []txInput
+	r.NoError(json.Unmarshal([]byte(inputs), &in))
+	in[1].Amount = nil
+	noAmount, err := json.Marshal(in)
+	r.NoError(err)
+	result, err := txSign(params, []string{txHex.(string), keys.wif(0),
+		string(noAmount)})
+	r.NoError(err)
+	signed := result.(*btcjson.SignRawTransactionResult)
+	r.Len(signed.Errors, 1)
+	r.EqualValues(3, signed.Errors[0].Vout)
+}
+
+func TestTxCombine(t *testing.T) {
+
+	r := require.New(t)
+	params := &chaincfg.RegressionNetParams
+
+	keys := newTestTxKeys(r, params, 2)
+	prevOuts := testPrevOuts(r, keys)
+	fundingTx := testFundingTx(prevOuts)
+	outputs := `{"data": "00"}`
+
+	// The transactions signed by different signers for different inputs
+	// are combined.
+	inputs := testTxInputs(r, fundingTx, prevOuts, 1, 3)
+	var in []txInput
+	r.NoError(json.Unmarshal([]byte(inputs), &in))
+	txHex, err := txCreate(params, []string{inputs, outputs})
+	r.NoError(err)
+	var copies []string
+	for i := range in {
+		prevTx, err := json.Marshal(in[i : i+1])
+		r.NoError(err)
+		result, err := txSign(params, []string{txHex.(string), keys.wif(0),
+			string(prevTx)})
+		r.NoError(err)
+		signed := result.(*btcjson.SignRawTransactionResult)
+		r.False(signed.Complete)
+		copies = append(copies, signed.Hex)
+	}
+	combined, err := txCombine(params, copies)
+	r.NoError(err)
+	verifyTestTx(r, combined.(string), fundingTx)
+
+	otherHex, err := txCreate(params, []string{inputs, `{"data": "01"}`})
+	r.NoError(err)
+	_, err = txCombine(params, []string{copies[0], otherHex.(string)})
+	r.Error(err)
+
+	// The PSBTs signed by each key of the multisig witness script are
+	// combined and finalized.
+	inputs = testTxInputs(r, fundingTx, prevOuts, 5)
+	b64, err := txCreatePsbt(params, []string{inputs, outputs})
+	r.NoError(err)
+	var packets []string
+	for i := range keys.wifs {
+		result, err := txSign(params, []string{b64.(string), keys.wif(i)})
+		r.NoError(err)
+		finalized := result.(*btcjson.FinalizePsbtResult)
+		r.False(finalized.Complete)
+		r.Empty(finalized.Hex)
+		packets = append(packets, finalized.Psbt)
+	}
+	result, err := txCombine(params, packets)
+	r.NoError(err)
+	finalized := result.(*btcjson.FinalizePsbtResult)
+	r.True(finalized.Complete)
+	verifyTestTx(r, finalized.Hex, fundingTx)
+
+	_, err = txCombine(params, []string{packets[0], txHex.(string)})
+	r.Error(err)
+}
+
+func TestTxDecode(t *testing.T) {
+
+	r := require.New(t)
+	params := &chaincfg.RegressionNetParams
+
+	keys := newTestTxKeys(r, params, 2)
+	prevOuts := testPrevOuts(r, keys)
+	fundingTx := testFundingTx(prevOuts)
+	addr, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(keys.pubKeys[0]),
+		params)
+	r.NoError(err)
+	outputs := `[{"support": {"name": "tester", "claimid": "` +
+		change.ClaimID{1}.String() + `", "address": "` +
+		addr.EncodeAddress() + `", "amount": 0.1}}, {"data": "00"}]`
+	inputs := testTxInputs(r, fundingTx, prevOuts, 1)
+
+	// The claim spent by the PSBT and its support output are decoded.
+	b64, err := txCreatePsbt(params, []string{inputs, outputs})
+	r.NoError(err)
+	result, err := txDecode(params, []string{b64.(string)})
+	r.NoError(err)
+	decoded := result.(*btcjson.DecodePsbtResult)
+	r.Len(decoded.Inputs, 1)
+	r.NotNil(decoded.Inputs[0].Claim)
+	r.Equal("tester", decoded.Inputs[0].Claim.Name)
+	r.Len(decoded.Outputs, 2)
+	r.NotNil(decoded.Outputs[0].Claim)
+	r.Equal(change.ClaimID{1}.String(), decoded.Outputs[0].Claim.ClaimID)
+	r.Nil(decoded.Outputs[1].Claim)
+
+	txHex, err := txCreate(params, []string{inputs, outputs})
+	r.NoError(err)
+	result, err = txDecode(params, []string{txHex.(string)})
+	r.NoError(err)
+	decodedTx := result.(*txDecodeResult)
+	r.Len(decodedTx.Vin, 1)
+	r.Len(decodedTx.Vout, 2)
+	r.NotNil(decodedTx.Vout[0].Claim)
+	r.Equal("support", decodedTx.Vout[0].Claim.Type)
+	r.Equal(change.ClaimID{1}.String(), decodedTx.Vout[0].Claim.ClaimID)
+	r.Nil(decodedTx.Vout[1].Claim)
+
+	_, err = txDecode(params, []string{"invalid"})
+	r.Error(err)
+}
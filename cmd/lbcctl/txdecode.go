@@ -0,0 +1,373 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/claimtrie/change"
+	"github.com/lbryio/lbcd/psbt"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/txscript/claimscript"
+	"github.com/lbryio/lbcd/txscript/txsign"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+// txDecodeResult is the result of the decode subcommand for a transaction,
+// which is that of the decoderawtransaction command, with the claims of the
+// outputs.
+type txDecodeResult struct {
+	Txid     string        `json:"txid"`
+	Hash     string        `json:"hash"`
+	Version  int32         `json:"version"`
+	Size     int           `json:"size"`
+	Vsize    int           `json:"vsize"`
+	Weight   int           `json:"weight"`
+	Locktime uint32        `json:"locktime"`
+	Vin      []btcjson.Vin `json:"vin"`
+	Vout     []txVout      `json:"vout"`
+}
+
+// txVout is an output of the result of the decode subcommand.
+type txVout struct {
+	btcjson.Vout
+	Claim *txVoutClaim `json:"claim,omitempty"`
+}
+
+// txVoutClaim is the claim, update or support created by an output.  The
+// value is hex encoded.
+type txVoutClaim struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	ClaimID string `json:"claimid"`
+	Value   string `json:"value,omitempty"`
+}
+
+// txDecode runs the decode subcommand.
+func txDecode(params *chaincfg.Params, args []string) (interface{}, error) {
+	tx, p, err := decodeTxOrPsbt(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if p != nil {
+		return decodePsbtResult(params, p)
+	}
+
+	weight := tx.SerializeSizeStripped()*(witnessScaleFactor-1) +
+		tx.SerializeSize()
+	txHash := tx.TxHash()
+	result := &txDecodeResult{
+		Txid:     txHash.String(),
+		Hash:     tx.WitnessHash().String(),
+		Version:  tx.Version,
+		Size:     tx.SerializeSize(),
+		Vsize:    (weight + witnessScaleFactor - 1) / witnessScaleFactor,
+		Weight:   weight,
+		Locktime: tx.LockTime,
+		Vin:      txVinList(tx),
+	}
+	for i, vout := range txVoutList(params, tx) {
+		v := txVout{Vout: vout}
+		if cs, err := claimscript.Parse(tx.TxOut[i].PkScript); err == nil {
+			id := cs.ClaimIDFor(wire.OutPoint{Hash: txHash, Index: uint32(i)})
+			v.Claim = &txVoutClaim{
+				Type:    cs.Type.String(),
+				Name:    string(cs.Name),
+				ClaimID: id.String(),
+				Value:   hex.EncodeToString(cs.Value),
+			}
+		}
+		result.Vout = append(result.Vout, v)
+	}
+	return result, nil
+}
+
+// isCoinBase returns whether the transaction is a coinbase, whose single input
+// spends no previous output.
+func isCoinBase(tx *wire.MsgTx) bool {
+	if len(tx.TxIn) != 1 {
+		return false
+	}
+	prevOut := tx.TxIn[0].PreviousOutPoint
+	return prevOut.Index == wire.MaxPrevOutIndex &&
+		prevOut.Hash == chainhash.Hash{}
+}
+
+// witnessToHex returns the hex encoded items of a witness.
+func witnessToHex(witness wire.TxWitness) []string {
+	result := make([]string, 0, len(witness))
+	for _, item := range witness {
+		result = append(result, hex.EncodeToString(item))
+	}
+	return result
+}
+
+// txVinList returns the JSON objects of the inputs of the transaction, as the
+// RPC server does.
+func txVinList(tx *wire.MsgTx) []btcjson.Vin {
+	vinList := make([]btcjson.Vin, len(tx.TxIn))
+	if isCoinBase(tx) {
+		txIn := tx.TxIn[0]
+		vinList[0].Coinbase = hex.EncodeToString(txIn.SignatureScript)
+		vinList[0].Sequence = txIn.Sequence
+		vinList[0].Witness = witnessToHex(txIn.Witness)
+		return vinList
+	}
+
+	for i, txIn := range tx.TxIn {
+		// The disassembled string will contain [error] inline if the
+		// script doesn't fully parse, so ignore the error here.
+		disbuf, _ := txscript.DisasmString(txIn.SignatureScript)
+
+		vinEntry := &vinList[i]
+		vinEntry.Txid = txIn.PreviousOutPoint.Hash.String()
+		vinEntry.Vout = txIn.PreviousOutPoint.Index
+		vinEntry.Sequence = txIn.Sequence
+		vinEntry.ScriptSig = &btcjson.ScriptSig{
+			Asm: disbuf,
+			Hex: hex.EncodeToString(txIn.SignatureScript),
+		}
+		if tx.HasWitness() {
+			vinEntry.Witness = witnessToHex(txIn.Witness)
+		}
+	}
+	return vinList
+}
+
+// txVoutList returns the JSON objects of the outputs of the transaction, as
+// the RPC server does.
+func txVoutList(params *chaincfg.Params, tx *wire.MsgTx) []btcjson.Vout {
+	voutList := make([]btcjson.Vout, 0, len(tx.TxOut))
+	for i, txOut := range tx.TxOut {
+		vout := btcjson.Vout{
+			N:            uint32(i),
+			Value:        btcutil.Amount(txOut.Value).ToBTC(),
+			ScriptPubKey: scriptPubKeyResult(params, txOut.PkScript),
+		}
+		voutList = append(voutList, vout)
+	}
+	return voutList
+}
+
+// scriptPubKeyResult returns the JSON object of a public key script, whose
+// type is that of the script following the claim prefix, if any.
+func scriptPubKeyResult(params *chaincfg.Params, pkScript []byte) btcjson.ScriptPubKeyResult {
+	// The disassembled string will contain [error] inline if the script
+	// doesn't fully parse, so ignore the error here.
+	disbuf, _ := txscript.DisasmString(pkScript)
+
+	// An error means the script couldn't parse and there is no additional
+	// information about it anyways.
+	script := txscript.StripClaimScriptPrefix(pkScript)
+	class, addrs, reqSigs, _ := txscript.ExtractPkScriptAddrs(script, params)
+
+	result := btcjson.ScriptPubKeyResult{
+		Asm:       disbuf,
+		Hex:       hex.EncodeToString(pkScript),
+		ReqSigs:   int32(reqSigs),
+		Addresses: make([]string, len(addrs)),
+	}
+	for i, addr := range addrs {
+		result.Addresses[i] = addr.EncodeAddress()
+	}
+	if len(script) < len(pkScript) {
+		result.IsClaim = pkScript[0] == txscript.OP_CLAIMNAME ||
+			pkScript[0] == txscript.OP_UPDATECLAIM
+		result.IsSupport = pkScript[0] == txscript.OP_SUPPORTCLAIM
+		result.SubType = class.String()
+		result.Type = txscript.ScriptClass.String(0)
+	} else {
+		result.Type = class.String()
+	}
+	return result
+}
+
+// txRawDecodeResult returns the JSON object of a transaction of a PSBT.
+func txRawDecodeResult(params *chaincfg.Params, tx *wire.MsgTx) *btcjson.TxRawDecodeResult {
+	return &btcjson.TxRawDecodeResult{
+		Txid:     tx.TxHash().String(),
+		Version:  tx.Version,
+		Locktime: tx.LockTime,
+		Vin:      txVinList(tx),
+		Vout:     txVoutList(params, tx),
+	}
+}
+
+// decodePsbtResult returns the result of the decode subcommand for a PSBT,
+// which is that of the decodepsbt command.
+func decodePsbtResult(params *chaincfg.Params, p *psbt.Packet) (*btcjson.DecodePsbtResult, error) {
+	result := &btcjson.DecodePsbtResult{
+		Tx:      *txRawDecodeResult(params, p.UnsignedTx),
+		Unknown: make(map[string]string),
+		Inputs:  make([]btcjson.DecodePsbtInput, len(p.Inputs)),
+		Outputs: make([]btcjson.DecodePsbtOutput, len(p.Outputs)),
+	}
+	for _, u := range p.Unknowns {
+		result.Unknown[hex.EncodeToString(u.Key)] = hex.EncodeToString(u.Value)
+	}
+
+	for i := range p.Inputs {
+		pInput := &p.Inputs[i]
+		in := &result.Inputs[i]
+
+		if pInput.NonWitnessUtxo != nil {
+			in.NonWitnessUtxo = txRawDecodeResult(params,
+				pInput.NonWitnessUtxo)
+		}
+		if pInput.WitnessUtxo != nil {
+			in.WitnessUtxo = &btcjson.PsbtUtxo{
+				Amount: btcutil.Amount(pInput.WitnessUtxo.Value).ToBTC(),
+				ScriptPubKey: scriptPubKeyResult(params,
+					pInput.WitnessUtxo.PkScript),
+			}
+		}
+		if len(pInput.PartialSigs) > 0 {
+			in.PartialSignatures = make(map[string]string)
+			for _, ps := range pInput.PartialSigs {
+				in.PartialSignatures[hex.EncodeToString(ps.PubKey)] =
+					hex.EncodeToString(ps.Signature)
+			}
+		}
+		if pInput.SighashType != 0 {
+			in.Sighash = txsign.SigHashTypeName(pInput.SighashType)
+		}
+		in.RedeemScript = psbtScriptResult(pInput.RedeemScript)
+		in.WitnessScript = psbtScriptResult(pInput.WitnessScript)
+		in.Bip32Derivs = psbtBip32DerivsResult(pInput.Bip32Derivation)
+		if pInput.FinalScriptSig != nil {
+			disbuf, _ := txscript.DisasmString(pInput.FinalScriptSig)
+			in.FinalScriptSig = &btcjson.ScriptSig{
+				Asm: disbuf,
+				Hex: hex.EncodeToString(pInput.FinalScriptSig),
+			}
+		}
+		if pInput.FinalScriptWitness != nil {
+			witness, err := readPsbtWitness(pInput.FinalScriptWitness)
+			if err != nil {
+				return nil, fmt.Errorf("invalid final witness of "+
+					"input %d: %v", i, err)
+			}
+			in.FinalScriptWitness = witnessToHex(witness)
+		}
+		in.Claim = psbtClaimResult(psbt.InputClaim(pInput))
+		in.Unknown = psbtUnknownResult(pInput.Unknowns)
+	}
+
+	for i := range p.Outputs {
+		pOutput := &p.Outputs[i]
+		out := &result.Outputs[i]
+
+		out.RedeemScript = psbtScriptResult(pOutput.RedeemScript)
+		out.WitnessScript = psbtScriptResult(pOutput.WitnessScript)
+		out.Bip32Derivs = psbtBip32DerivsResult(pOutput.Bip32Derivation)
+		out.Claim = psbtClaimResult(psbt.OutputClaim(p, i))
+	}
+
+	if fee, err := psbt.SumUtxoInputValues(p); err == nil {
+		for _, txOut := range p.UnsignedTx.TxOut {
+			fee -= txOut.Value
+		}
+		feeBTC := btcutil.Amount(fee).ToBTC()
+		result.Fee = &feeBTC
+	}
+	return result, nil
+}
+
+// psbtScriptResult returns the JSON object of a script of a PSBT, or nil if
+// there is none.
+func psbtScriptResult(script []byte) *btcjson.PsbtScript {
+	if script == nil {
+		return nil
+	}
+	disbuf, _ := txscript.DisasmString(script)
+	return &btcjson.PsbtScript{
+		Asm:  disbuf,
+		Hex:  hex.EncodeToString(script),
+		Type: txscript.GetScriptClass(script).String(),
+	}
+}
+
+// psbtBip32DerivsResult returns the JSON objects of BIP 32 derivation paths.
+func psbtBip32DerivsResult(derivs []*psbt.Bip32Derivation) []btcjson.PsbtBip32Deriv {
+	if len(derivs) == 0 {
+		return nil
+	}
+
+	result := make([]btcjson.PsbtBip32Deriv, 0, len(derivs))
+	for _, d := range derivs {
+		path := "m"
+		for _, index := range d.Bip32Path {
+			if index >= 0x80000000 {
+				path += fmt.Sprintf("/%d'", index-0x80000000)
+			} else {
+				path += fmt.Sprintf("/%d", index)
+			}
+		}
+		result = append(result, btcjson.PsbtBip32Deriv{
+			PubKey:            hex.EncodeToString(d.PubKey),
+			MasterFingerprint: fmt.Sprintf("%08x", d.MasterKeyFingerprint),
+			Path:              path,
+		})
+	}
+	return result
+}
+
+// psbtClaimResult returns the JSON object of the claim information of an
+// input or output, or nil if there is none.
+func psbtClaimResult(claim *psbt.Claim) *btcjson.PsbtClaim {
+	if claim == nil {
+		return nil
+	}
+
+	result := &btcjson.PsbtClaim{Name: string(claim.Name)}
+	if len(claim.ClaimID) == change.ClaimIDSize {
+		var id change.ClaimID
+		copy(id[:], claim.ClaimID)
+		result.ClaimID = id.String()
+	}
+	return result
+}
+
+// psbtUnknownResult returns the hex encoded unknown fields of an input or
+// output, excluding the claim fields.
+func psbtUnknownResult(unknowns []*psbt.Unknown) map[string]string {
+	var result map[string]string
+	for _, u := range unknowns {
+		prefix, _, _, err := psbt.ParseProprietaryKey(u.Key)
+		if err == nil && string(prefix) == psbt.ClaimPrefix {
+			continue
+		}
+		if result == nil {
+			result = make(map[string]string)
+		}
+		result[hex.EncodeToString(u.Key)] = hex.EncodeToString(u.Value)
+	}
+	return result
+}
+
+// readPsbtWitness parses a serialized final witness of a PSBT input.
+func readPsbtWitness(serialized []byte) (wire.TxWitness, error) {
+	r := bytes.NewReader(serialized)
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, err
+	}
+	if count > uint64(len(serialized)) {
+		return nil, fmt.Errorf("witness item count %d exceeds its size",
+			count)
+	}
+
+	witness := make(wire.TxWitness, count)
+	for i := range witness {
+		witness[i], err = wire.ReadVarBytes(r, 0, txscript.MaxScriptSize,
+			"witness")
+		if err != nil {
+			return nil, err
+		}
+	}
+	return witness, nil
+}
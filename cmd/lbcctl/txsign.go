@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/psbt"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/txscript/txsign"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+// txSign runs the sign subcommand.  The previous outputs spent by the inputs
+// of a transaction have to be passed as prevtxs, with their public key script,
+// and their amount for the witness inputs; those of a PSBT only when it lacks
+// their UTXOs.
+func txSign(params *chaincfg.Params, args []string) (interface{}, error) {
+	keys, err := parseWIFs(params, args[1])
+	if err != nil {
+		return nil, err
+	}
+	var prevOuts []txInput
+	if len(args) > 2 && args[2] != "" {
+		prevOuts, err = parseTxInputs(args[2])
+		if err != nil {
+			return nil, err
+		}
+	}
+	hashType := txscript.SigHashAll
+	if len(args) > 3 {
+		var ok bool
+		hashType, ok = txsign.ParseSigHashType(args[3])
+		if !ok {
+			return nil, fmt.Errorf("invalid sighash type %q", args[3])
+		}
+	}
+
+	tx, p, err := decodeTxOrPsbt(args[0])
+	if err != nil {
+		return nil, err
+	}
+	prevOutsByOutPoint := make(map[wire.OutPoint]*txInput, len(prevOuts))
+	for i := range prevOuts {
+		op, err := prevOuts[i].outPoint()
+		if err != nil {
+			return nil, fmt.Errorf("prevtx %d: %v", i, err)
+		}
+		prevOutsByOutPoint[*op] = &prevOuts[i]
+	}
+
+	if p != nil {
+		return signPsbt(params, p, keys, hashType, prevOutsByOutPoint)
+	}
+	return signTx(params, tx, keys, hashType, prevOutsByOutPoint)
+}
+
+// parseWIFs parses the comma-separated private keys in the wallet import
+// format of the network.
+func parseWIFs(params *chaincfg.Params, arg string) ([]*btcutil.WIF, error) {
+	var keys []*btcutil.WIF
+	for i, s := range strings.Split(arg, ",") {
+		wif, err := btcutil.DecodeWIF(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key %d: %v", i+1, err)
+		}
+		if !wif.IsForNet(params) {
+			return nil, fmt.Errorf("private key %d is not for the %s "+
+				"network", i+1, params.Name)
+		}
+		keys = append(keys, wif)
+	}
+	return keys, nil
+}
+
+// signTx signs the inputs of the transaction spending the passed previous
+// outputs with the keys, and verifies them.  The result is that of the
+// signrawtransaction command.
+func signTx(params *chaincfg.Params, tx *wire.MsgTx, keys []*btcutil.WIF,
+	hashType txscript.SigHashType,
+	prevOuts map[wire.OutPoint]*txInput) (*btcjson.SignRawTransactionResult, error) {
+
+	var signErrors []btcjson.SignRawTransactionError
+	sigHashes := txscript.NewTxSigHashes(tx)
+	for i, txIn := range tx.TxIn {
+		err := errors.New("unknown previous output, which has to be " +
+			"passed in prevtxs")
+		if prevOut, ok := prevOuts[txIn.PreviousOutPoint]; ok {
+			err = signTxInput(params, tx, sigHashes, i, prevOut, keys,
+				hashType)
+		}
+		if err != nil {
+			signErrors = append(signErrors, btcjson.SignRawTransactionError{
+				TxID:      txIn.PreviousOutPoint.Hash.String(),
+				Vout:      txIn.PreviousOutPoint.Index,
+				ScriptSig: hex.EncodeToString(txIn.SignatureScript),
+				Sequence:  txIn.Sequence,
+				Error:     err.Error(),
+			})
+		}
+	}
+
+	txHex, err := txToHex(tx)
+	if err != nil {
+		return nil, err
+	}
+	return &btcjson.SignRawTransactionResult{
+		Hex:      txHex,
+		Complete: len(signErrors) == 0,
+		Errors:   signErrors,
+	}, nil
+}
+
+// signTxInput signs the input at idx of the transaction spending prevOut with
+// the keys, and verifies the resulting scripts.
+func signTxInput(params *chaincfg.Params, tx *wire.MsgTx,
+	sigHashes *txscript.TxSigHashes, idx int, prevOut *txInput,
+	keys []*btcutil.WIF, hashType txscript.SigHashType) error {
+
+	utxo, err := prevOut.utxo()
+	if err != nil {
+		return err
+	}
+	if utxo == nil {
+		return errors.New("the scriptPubKey of the previous output is " +
+			"missing")
+	}
+	redeemScript, witnessScript, err := prevOut.scripts()
+	if err != nil {
+		return err
+	}
+
+	return txsign.SignTxInput(params, tx, sigHashes, idx, &txsign.PrevOut{
+		TxOut:         utxo,
+		HasAmount:     prevOut.PrevTx != "" || prevOut.Amount != nil,
+		RedeemScript:  redeemScript,
+		WitnessScript: witnessScript,
+	}, keys, hashType)
+}
+
+// signPsbt adds the signatures of the passed keys to the inputs of the PSBT
+// whose UTXO is known, and finalizes the inputs which can be.  The passed
+// previous outputs provide the UTXOs missing from the PSBT.  The result is
+// that of the finalizepsbt command, with the PSBT.
+func signPsbt(params *chaincfg.Params, p *psbt.Packet, keys []*btcutil.WIF,
+	hashType txscript.SigHashType,
+	prevOuts map[wire.OutPoint]*txInput) (*btcjson.FinalizePsbtResult, error) {
+
+	u, err := psbt.NewUpdater(p)
+	if err != nil {
+		return nil, err
+	}
+	for i, txIn := range p.UnsignedTx.TxIn {
+		prevOut, ok := prevOuts[txIn.PreviousOutPoint]
+		if !ok || psbt.InputUtxo(p, i) != nil {
+			continue
+		}
+		if err := addPsbtInput(u, i, prevOut); err != nil {
+			return nil, fmt.Errorf("input %d: %v", i, err)
+		}
+	}
+
+	sigHashes := txscript.NewTxSigHashes(p.UnsignedTx)
+	for i := range p.Inputs {
+		err := signPsbtInput(params, u, sigHashes, i, keys, hashType)
+		if err != nil {
+			return nil, fmt.Errorf("input %d: %v", i, err)
+		}
+
+		// The inputs lacking signatures are left to be signed by
+		// other signers.
+		_, _ = psbt.MaybeFinalize(p, i)
+	}
+
+	return finalizePsbtResult(p)
+}
+
+// finalizePsbtResult returns the PSBT as the result of the finalizepsbt
+// command, with the extracted transaction when it's complete.
+func finalizePsbtResult(p *psbt.Packet) (*btcjson.FinalizePsbtResult, error) {
+	b64, err := psbt.B64Encode(p)
+	if err != nil {
+		return nil, err
+	}
+	result := &btcjson.FinalizePsbtResult{Psbt: b64, Complete: p.IsComplete()}
+	if result.Complete {
+		tx, err := psbt.Extract(p)
+		if err != nil {
+			return nil, err
+		}
+		result.Hex, err = txToHex(tx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// signPsbtInput adds the signatures of the passed keys to the input at inIndex
+// of the PSBT of the updater, unless it's finalized or its UTXO is unknown.
+// The keys of the script signed are those of the public key script following
+// the claim prefix of a claim, of the redeem script of a pay-to-script-hash,
+// or of the witness script of a pay-to-witness-script-hash.  The inner
+// pay-to-script-hash and witness scripts of claims take no signature.
+func signPsbtInput(params *chaincfg.Params, u *psbt.Updater,
+	sigHashes *txscript.TxSigHashes, inIndex int, keys []*btcutil.WIF,
+	hashType txscript.SigHashType) error {
+
+	p := u.Upsbt
+	pInput := &p.Inputs[inIndex]
+	utxo := psbt.InputUtxo(p, inIndex)
+	if utxo == nil || pInput.FinalScriptSig != nil ||
+		pInput.FinalScriptWitness != nil {
+
+		return nil
+	}
+	if pInput.SighashType != 0 {
+		hashType = pInput.SighashType
+	}
+
+	// script holds the keys signing, and subScript is the script the
+	// signatures commit to.
+	pkScript := utxo.PkScript
+	script, subScript := pkScript, pkScript
+	var witness bool
+	if inner := txscript.StripClaimScriptPrefix(pkScript); len(inner) < len(pkScript) {
+		switch txscript.GetScriptClass(inner) {
+		case txscript.ScriptHashTy, txscript.WitnessV0PubKeyHashTy,
+			txscript.WitnessV0ScriptHashTy:
+
+			return nil
+		}
+		script = inner
+	} else {
+		if txscript.IsPayToScriptHash(script) {
+			script, subScript = pInput.RedeemScript, pInput.RedeemScript
+		}
+		switch {
+		case script == nil:
+			return nil
+
+		case txscript.IsPayToWitnessPubKeyHash(script):
+			witness = true
+
+		case txscript.IsPayToWitnessScriptHash(script):
+			if pInput.WitnessScript == nil {
+				return nil
+			}
+			script, subScript = pInput.WitnessScript, pInput.WitnessScript
+			witness = true
+		}
+	}
+
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(script, params)
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		wif, pubKey := txsign.FindKey(keys, addr)
+		if wif == nil || hasPartialSig(pInput, pubKey) {
+			continue
+		}
+
+		var sig []byte
+		if witness {
+			sig, err = txscript.RawTxInWitnessSignature(p.UnsignedTx,
+				sigHashes, inIndex, utxo.Value, subScript, hashType,
+				wif.PrivKey)
+		} else {
+			sig, err = txscript.RawTxInSignature(p.UnsignedTx, inIndex,
+				subScript, hashType, wif.PrivKey)
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := psbt.Sign(u, inIndex, sig, pubKey, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasPartialSig returns whether the input has a partial signature of the
+// public key.
+func hasPartialSig(pInput *psbt.PInput, pubKey []byte) bool {
+	for _, ps := range pInput.PartialSigs {
+		if bytes.Equal(ps.PubKey, pubKey) {
+			return true
+		}
+	}
+	return false
+}
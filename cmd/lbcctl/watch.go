@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const (
+	// defaultWatchInterval is the interval of the --watchdiff option when
+	// the --watch option isn't specified, which is also the optional value
+	// of the latter.
+	defaultWatchInterval = 2 * time.Second
+
+	// clearScreen moves the cursor of the terminal to its top left corner
+	// and clears it.
+	clearScreen = "\x1b[H\x1b[2J"
+
+	// maxDiffCells bounds the size of the table of the longest common
+	// subsequence computed by diffLines, beyond which the differing lines
+	// are all displayed as changed.
+	maxDiffCells = 1 << 22
+)
+
+// runWatch runs the passed marshalled command repeatedly at the interval of
+// the --watch option, over the passed connection to its server, until it is
+// interrupted.  The screen of a terminal is cleared before each result is
+// displayed, unless the --watchdiff option displays the changes of the lines
+// of the result instead.  The errors of the command are displayed as its
+// result, so that the watch goes on.
+func runWatch(conn *connection, method string, marshalledJSON []byte) error {
+	cfg := conn.cfg
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	clear := !cfg.WatchDiff && term.IsTerminal(int(os.Stdout.Fd()))
+	var previous []string
+	for first := true; ; first = false {
+		started := time.Now()
+		var output bytes.Buffer
+		result, err := conn.post(marshalledJSON, true)
+		if err == nil {
+			err = formatResult(&output, result, cfg)
+		}
+		if err != nil {
+			output.Reset()
+			fmt.Fprintln(&output, err)
+		}
+		lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+
+		header := fmt.Sprintf("Every %s: %s  %s", cfg.Watch, method,
+			started.Format("2006-01-02 15:04:05"))
+		if cfg.Timed {
+			header += fmt.Sprintf("  (%s)", time.Since(started))
+		}
+
+		out := os.Stdout
+		switch {
+		case clear:
+			fmt.Fprint(out, clearScreen)
+			fmt.Fprintf(out, "%s\n\n", header)
+			writeLines(out, lines)
+
+		case cfg.WatchDiff && !first:
+			changes := diffLines(previous, lines)
+			if len(changes) > 0 {
+				fmt.Fprintf(out, "%s\n", header)
+				writeLines(out, changes)
+			}
+
+		default:
+			fmt.Fprintf(out, "%s\n", header)
+			writeLines(out, lines)
+		}
+		previous = lines
+
+		select {
+		case <-interrupt:
+			return nil
+		case <-time.After(time.Until(started.Add(cfg.Watch))):
+		}
+	}
+}
+
+// writeLines writes the passed lines.
+func writeLines(w io.Writer, lines []string) {
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+}
+
+// diffLines returns the changes from the old lines to the new lines: the
+// removed lines prefixed with -, and the added lines prefixed with +, in their
+// order.  The lines kept are those of their longest common subsequence.
+func diffLines(old, new []string) []string {
+	// The common prefix and suffix, which are most of the lines of a
+	// result changing slowly, are trimmed first.
+	for len(old) > 0 && len(new) > 0 && old[0] == new[0] {
+		old, new = old[1:], new[1:]
+	}
+	for len(old) > 0 && len(new) > 0 &&
+		old[len(old)-1] == new[len(new)-1] {
+
+		old, new = old[:len(old)-1], new[:len(new)-1]
+	}
+
+	var changes []string
+	if (len(old)+1)*(len(new)+1) > maxDiffCells {
+		for _, line := range old {
+			changes = append(changes, "-"+line)
+		}
+		for _, line := range new {
+			changes = append(changes, "+"+line)
+		}
+		return changes
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of
+	// old[i:] and new[j:].
+	lcs := make([][]int, len(old)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			switch {
+			case old[i] == new[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(old) || j < len(new) {
+		switch {
+		case i < len(old) && j < len(new) && old[i] == new[j]:
+			i++
+			j++
+		case j == len(new) || (i < len(old) && lcs[i+1][j] >= lcs[i][j+1]):
+			changes = append(changes, "-"+old[i])
+			i++
+		default:
+			changes = append(changes, "+"+new[j])
+			j++
+		}
+	}
+	return changes
+}
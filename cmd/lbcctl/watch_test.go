@@ -0,0 +1,22 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffLines(t *testing.T) {
+
+	r := require.New(t)
+
+	r.Nil(diffLines([]string{"a", "b"}, []string{"a", "b"}))
+	r.Equal([]string{"+c"}, diffLines([]string{"a", "b"},
+		[]string{"a", "b", "c"}))
+	r.Equal([]string{"-a"}, diffLines([]string{"a", "b"}, []string{"b"}))
+	r.Equal([]string{"-b", "+x", "-d", "+y"},
+		diffLines([]string{"a", "b", "c", "d", "e"},
+			[]string{"a", "x", "c", "y", "e"}))
+	r.Equal([]string{"-a", "+a"}, diffLines([]string{"a", "b"},
+		[]string{"b", "a"}))
+}
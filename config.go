@@ -14,6 +14,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -29,8 +30,9 @@ import (
 	"github.com/lbryio/lbcd/chaincfg/chainhash"
 	"github.com/lbryio/lbcd/connmgr"
 	"github.com/lbryio/lbcd/database"
-	_ "github.com/lbryio/lbcd/database/ffldb"
+	"github.com/lbryio/lbcd/database/ffldb"
 	"github.com/lbryio/lbcd/mempool"
+	"github.com/lbryio/lbcd/mining"
 	"github.com/lbryio/lbcd/peer"
 	"github.com/lbryio/lbcd/version"
 	"github.com/lbryio/lbcd/wire"
@@ -44,13 +46,20 @@ const (
 	defaultLogDirname            = "logs"
 	defaultLogFilename           = "lbcd.log"
 	defaultMaxPeers              = 125
+	defaultBlockRelayOnlyPeers   = 2
 	defaultBanDuration           = time.Hour * 24
 	defaultBanThreshold          = 100
 	defaultConnectTimeout        = time.Second * 30
 	defaultMaxRPCClients         = 10
+	defaultMaxElectrumClients    = 100
+	defaultDNSSeederPort         = "53"
 	defaultMaxRPCWebsockets      = 25
 	defaultMaxRPCConcurrentReqs  = 20
+	defaultRPCWSQueueSize        = 10000
+	defaultRPCWSQueueBytes       = 64 * 1024 * 1024
+	defaultRPCWSQueuePolicy      = wsQueueDisconnect
 	defaultDbType                = "ffldb"
+	defaultDbCheck               = "quick"
 	defaultFreeTxRelayLimit      = 15.0
 	defaultTrickleInterval       = peer.DefaultTrickleInterval
 	defaultBlockMinSize          = 0
@@ -65,6 +74,13 @@ const (
 	defaultMaxOrphanTransactions = 100
 	defaultMaxOrphanTxSize       = 100000
 	defaultSigCacheMaxSize       = 100000
+	defaultBlockCacheSize        = 16
+	defaultReadyMaxLag           = 2
+	defaultSlowBlock             = 5 * time.Second
+	defaultSlowRPC               = 10 * time.Second
+	defaultRPCDrainTimeout       = 10 * time.Second
+	defaultMinFreeSpace          = 1024
+	defaultWarnFreeSpace         = 10240
 	sampleConfigFilename         = "sample-lbcd.conf"
 	defaultTxIndex               = true
 	defaultAddrIndex             = false
@@ -107,37 +123,71 @@ type config struct {
 	AgentBlacklist       []string      `long:"agentblacklist" description:"A comma separated list of user-agent substrings which will cause lbcd to reject any peers whose user-agent contains any of the blacklisted substrings."`
 	AgentWhitelist       []string      `long:"agentwhitelist" description:"A comma separated list of user-agent substrings which will cause lbcd to require all peers' user-agents to contain one of the whitelisted substrings. The blacklist is applied before the whitelist, and an empty whitelist will allow all agents that do not fail the blacklist."`
 	BanDuration          time.Duration `long:"banduration" description:"How long to ban misbehaving peers.  Valid time units are {s, m, h}.  Minimum 1 second"`
+	BatchSigVerify       bool          `long:"batchsigverify" description:"Defer signature checks while validating the scripts of a block and verify them together using a pool of workers"`
 	BanThreshold         uint32        `long:"banthreshold" description:"Maximum allowed ban score before disconnecting and banning misbehaving peers."`
+	BlockCacheSize       uint          `long:"blockcachesize" description:"The number of recently served blocks to keep in memory for the RPC and peer requests -- 0 disables it"`
 	BlockMaxSize         uint32        `long:"blockmaxsize" description:"Maximum block size in bytes to be used when creating a block"`
 	BlockMinSize         uint32        `long:"blockminsize" description:"Mininum block size in bytes to be used when creating a block"`
 	BlockMaxWeight       uint32        `long:"blockmaxweight" description:"Maximum block weight to be used when creating a block"`
 	BlockMinWeight       uint32        `long:"blockminweight" description:"Mininum block weight to be used when creating a block"`
 	BlockPrioritySize    uint32        `long:"blockprioritysize" description:"Size in bytes for high-priority/low-fee transactions when creating a block"`
+	BlocksDir            string        `long:"blocksdir" description:"Directory to store the block files apart from the rest of the data, which are moved there from datadir"`
+	BlockRelayOnlyPeers  int           `long:"blockrelayonlypeers" description:"Number of block-relay-only outbound peers to maintain in addition to the full-relay ones, which exchange neither transactions nor addresses with them"`
 	BlocksOnly           bool          `long:"blocksonly" description:"Do not accept transactions from remote peers."`
+	ChainParams          []string      `long:"chainparam" description:"Override a parameter of the regtest or simnet chain, given as <name>=<value> such as segwitheight=500 -- Can be specified multiple times"`
+	ClaimSnapshot        bool          `long:"claimsnapshot" description:"Bootstrap the claimtrie from a snapshot of the peers, checked against the block headers, instead of processing the claims of all the blocks"`
 	ConfigFile           string        `short:"C" long:"configfile" description:"Path to configuration file"`
 	ConnectPeers         []string      `long:"connect" description:"Connect only to the specified peers at startup"`
 	CPUProfile           string        `long:"cpuprofile" description:"Write CPU profile to the specified file"`
 	MemProfile           string        `long:"memprofile" description:"Write memory profile to the specified file"`
 	DataDir              string        `short:"b" long:"datadir" description:"Directory to store data"`
-	DbType               string        `long:"dbtype" description:"Database backend to use for the Block Chain"`
+	DbCheck              string        `long:"dbcheck" description:"How thoroughly to check the consistency of the block files with the block database on start up: none, quick or full -- A full check reads every block"`
+	DbType               string        `long:"dbtype" description:"Database backend to use for the Block Chain -- ffpebble stores its metadata in pebble rather than leveldb"`
 	DebugLevel           string        `short:"d" long:"debuglevel" description:"Logging level for all subsystems {trace, debug, info, warn, error, critical} -- You may also specify <subsystem>=<level>,<subsystem2>=<level>,... to set the log level for individual subsystems -- Use show to list available subsystems"`
+	DNSSeeder            string        `long:"dnsseeder" description:"Crawl the network for good peers and answer the DNS queries for this domain and its x<hex services> subdomains with their addresses"`
+	DNSSeederListeners   []string      `long:"dnsseederlisten" description:"Add an interface/port to answer the DNS queries of the seeder on over UDP (default port: 53)"`
+	DNSSeederNameserver  string        `long:"dnsseedernameserver" description:"The host name of the nameserver of the seeder domain, returned in its NS and SOA records"`
 	DropAddrIndex        bool          `long:"dropaddrindex" description:"Deletes the address-based transaction index from the database on start up and then exits."`
 	DropCfIndex          bool          `long:"dropcfindex" description:"Deletes the index used for committed filtering (CF) support from the database on start up and then exits."`
+	DropScriptHashIndex  bool          `long:"dropscripthashindex" description:"Deletes the script hash index used by the Electrum server from the database on start up and then exits."`
 	DropTxIndex          bool          `long:"droptxindex" description:"Deletes the hash-based transaction index from the database on start up and then exits."`
+	DustRelayFee         float64       `long:"dustrelayfee" description:"The fee rate in LBC/kB used to determine whether an output is dust -- Defaults to minrelaytxfee when not set"`
+	ElectrumListeners    []string      `long:"electrumlisten" description:"Add an interface/port to serve the Electrum protocol of the LBRY wallets on, which requires the RPC server and the address index -- The Electrum server is disabled unless at least one is specified"`
+	ElectrumMaxClients   int           `long:"electrummaxclients" description:"Max number of Electrum protocol clients"`
+	EventExport          string        `long:"eventexport" description:"Export the block, claim and transaction events to a Kafka topic, given as kafka://[<user>:<pass>@]<host:port>[,<host:port>...]/<topic>, or to the NATS JetStream subjects <subject>.<type>, given as nats://[<user>:<pass>@]<host:port>/<subject>, whose user may be a token without password -- The credentials require eventexporttls"`
+	EventExportCAFile    string        `long:"eventexportcafile" description:"File of the certificate authorities verifying the TLS certificates of the event export brokers -- Defaults to those of the system"`
+	EventExportFormat    string        `long:"eventexportformat" description:"Encoding of the exported events {json, protobuf}"`
+	EventExportSASL      string        `long:"eventexportsasl" description:"SASL mechanism authenticating to the Kafka brokers of the event export with the credentials of eventexport {plain, scram-sha-256, scram-sha-512}"`
+	EventExportTLS       bool          `long:"eventexporttls" description:"Connect to the Kafka brokers or to the NATS server of the event export over TLS"`
 	ExternalIPs          []string      `long:"externalip" description:"Add an ip to the list of local addresses we claim to listen on to peers"`
 	Generate             bool          `long:"generate" description:"Generate (mine) bitcoins using the CPU"`
+	GenesisBlock         string        `long:"genesisblock" description:"Use the hex-encoded serialized block as the genesis block of a private network based on the regtest or simnet chain"`
+	GenesisMessage       string        `long:"genesismessage" description:"Generate the genesis block of a private network based on the regtest or simnet chain with the message in its coinbase"`
+	GenesisTime          int64         `long:"genesistime" description:"Generate the genesis block of a private network based on the regtest or simnet chain with the unix time -- Defaults to the time of the genesis block of the chain"`
+	GRPCListeners        []string      `long:"grpclisten" description:"Add an interface/port to listen for gRPC connections, which share the users and TLS settings of the RPC server -- The gRPC server is disabled unless at least one is specified"`
 	FreeTxRelayLimit     float64       `long:"limitfreerelay" description:"Limit relay of transactions with no transaction fee to the given amount in thousands of bytes per minute"`
 	Listeners            []string      `long:"listen" description:"Add an interface/port to listen for connections (default all interfaces port: 9246, testnet: 19246, regtest: 29246)"`
+	LoadBlocks           []string      `long:"loadblock" description:"Import the blocks of a block archive written by exportblocks, or of a bootstrap.dat file, at startup -- Can be specified multiple times"`
 	LogDir               string        `long:"logdir" description:"Directory to log output."`
+	LogFormat            string        `long:"logformat" description:"Format of the log records: text, or one JSON object per line with the time, level, subsystem, message and fields of the record {text, json}"`
 	MaxOrphanTxs         int           `long:"maxorphantx" description:"Max number of orphan transactions to keep in memory"`
 	MaxPeers             int           `long:"maxpeers" description:"Max number of inbound and outbound peers"`
+	MaxStdMultiSigKeys   int           `long:"maxstdmultisigkeys" description:"Max number of public keys in a standard bare multi-signature output script"`
+	MaxStdNullData       int           `long:"maxstdnulldataoutputs" description:"Max number of data carrier (nulldata) outputs in a standard transaction"`
+	MaxStdP2SHSigOps     int           `long:"maxstdp2shsigops" description:"Max number of signature operations in a standard pay-to-script-hash redemption"`
+	MaxStdSigScriptSize  int           `long:"maxstdsigscriptsize" description:"Max size in bytes of a standard transaction input signature script"`
+	MaxStdTxWeight       int64         `long:"maxstdtxweight" description:"Max weight of a standard transaction"`
+	MinFreeSpace         uint64        `long:"minfreespace" description:"Stop accepting blocks while the free space of the volume of the block files, the chain state or the claimtrie falls below this many MiB -- 0 disables it"`
 	MiningAddrs          []string      `long:"miningaddr" description:"Add the specified payment address to the list of addresses to use for generated blocks -- At least one address is required if the generate option is set"`
 	MinRelayTxFee        float64       `long:"minrelaytxfee" description:"The minimum transaction fee in LBC/kB to be considered a non-zero fee."`
+	MmapBlockFiles       bool          `long:"mmapblockfiles" description:"Read the block files from memory mappings of them, and hint the OS to read them ahead of the sequential scans of the chain, such as those building the indexes"`
 	DisableBanning       bool          `long:"nobanning" description:"Disable banning of misbehaving peers"`
 	NoCFilters           bool          `long:"nocfilters" description:"Disable committed filtering (CF) support"`
 	DisableCheckpoints   bool          `long:"nocheckpoints" description:"Disable built-in checkpoints.  Don't do this unless you know what you're doing."`
 	DisableDNSSeed       bool          `long:"nodnsseed" description:"Disable DNS seeding for peers"`
+	NoDbRepair           bool          `long:"nodbrepair" description:"Fail to start when the block files are inconsistent with the block database, instead of truncating them to their last consistent point and downloading the dropped blocks again"`
 	DisableListen        bool          `long:"nolisten" description:"Disable listening for incoming connections -- NOTE: Listening is automatically disabled if the --connect or --proxy options are used without also specifying listen interfaces via --listen"`
+	NoOneHopRelay        bool          `long:"noonehoprelay" description:"Broadcast the transactions submitted locally to all peers at once, instead of relaying them through a single outbound peer first"`
 	NoOnion              bool          `long:"noonion" description:"Disable connecting to tor hidden services"`
 	NoPeerBloomFilters   bool          `long:"nopeerbloomfilters" description:"Disable bloom filtering support"`
 	NoRelayPriority      bool          `long:"norelaypriority" description:"Do not require free or low-fee transactions to have high priority for relaying"`
@@ -148,44 +198,79 @@ type config struct {
 	OnionProxy           string        `long:"onion" description:"Connect to tor hidden services via SOCKS5 proxy (eg. 127.0.0.1:9050)"`
 	OnionProxyPass       string        `long:"onionpass" default-mask:"-" description:"Password for onion proxy server"`
 	OnionProxyUser       string        `long:"onionuser" description:"Username for onion proxy server"`
+	OutboundNetworks     []string      `long:"outboundnetwork" description:"Maintain a number of the full-relay outbound peers on a network, given as <network>=<count> with network ipv4, ipv6 or onion, so that network is not crowded out by the others -- Can be specified multiple times"`
+	OTLPEndpoint         string        `long:"otlpendpoint" description:"Export traces of the processing of blocks and RPC requests to the OTLP/HTTP traces endpoint of an OpenTelemetry collector, such as http://localhost:4318/v1/traces -- Tracing is disabled unless specified"`
+	P2PWSListeners       []string      `long:"p2pwslisten" description:"Add an interface/port to accept peer-to-peer connections over WebSocket on, for the in-browser light clients -- The WebSocket listener is disabled unless at least one is specified"`
+	P2PWSOrigins         []string      `long:"p2pwsorigin" description:"Add an origin, such as https://example.com, of the web pages allowed to connect over WebSocket, or * to allow any -- Browsers are refused unless their origin is allowed"`
+	P2PWSTLS             bool          `long:"p2pwstls" description:"Serve the WebSocket peer-to-peer listeners over TLS with the certificate and key of the RPC server"`
+	PersistSigCache      bool          `long:"persistsigcache" description:"Save the signature verification cache to the data directory on shutdown and restore it on startup"`
+	PolicyPlugin         string        `long:"policyplugin" description:"Consult the gRPC policy service of a sidecar at the address, given as <host:port> or unix:<path>, before accepting transactions to the mempool and including them in block templates"`
+	PolicyPluginFailOpen bool          `long:"policypluginfailopen" description:"Accept the transactions when the policy plugin is unavailable instead of rejecting them"`
+	PolicyPluginTimeout  time.Duration `long:"policyplugintimeout" description:"Max time to wait for the verdicts of the policy plugin.  Valid time units are {ms, s, m, h}"`
 	Profile              string        `long:"profile" description:"Enable HTTP profiling on given port -- NOTE port must be between 1024 and 65536"`
 	Proxy                string        `long:"proxy" description:"Connect via SOCKS5 proxy (eg. 127.0.0.1:9050)"`
 	ProxyPass            string        `long:"proxypass" default-mask:"-" description:"Password for proxy server"`
 	ProxyUser            string        `long:"proxyuser" description:"Username for proxy server"`
+	ReadyMaxLag          uint32        `long:"readymaxlag" description:"Max number of blocks the best chain may be behind the peers for /readyz to report the node as ready"`
 	RegressionTest       bool          `long:"regtest" description:"Use the regression test network"`
 	RejectNonStd         bool          `long:"rejectnonstd" description:"Reject non-standard transactions regardless of the default settings for the active network."`
 	RejectReplacement    bool          `long:"rejectreplacement" description:"Reject transactions that attempt to replace existing transactions within the mempool through the Replace-By-Fee (RBF) signaling policy."`
 	RelayNonStd          bool          `long:"relaynonstd" description:"Relay non-standard transactions regardless of the default settings for the active network."`
+	REST                 bool          `long:"rest" description:"Accept public REST requests under /rest/ on the RPC listeners -- NOTE: The REST interface is not authenticated"`
+	RPCAuth              []string      `long:"rpcauth" description:"Add an RPC user with a hashed password, in the format <user>:<salt>$<hmac-sha256 of the password keyed by salt>, hex-encoded -- Can be specified multiple times"`
 	RPCCert              string        `long:"rpccert" description:"File containing the certificate file"`
+	RPCDrainTimeout      time.Duration `long:"rpcdraintimeout" description:"Max time to wait on shutdown for the RPC and gRPC requests being processed to finish -- 0 stops the servers without waiting.  Valid time units are {ms, s, m, h}"`
 	RPCKey               string        `long:"rpckey" description:"File containing the certificate key"`
 	RPCLimitPass         string        `long:"rpclimitpass" default-mask:"-" description:"Password for limited RPC connections"`
 	RPCLimitUser         string        `long:"rpclimituser" description:"Username for limited RPC connections"`
-	RPCListeners         []string      `long:"rpclisten" description:"Add an interface/port to listen for RPC connections (default port: 9245, testnet: 19245, regtest: 29245)"`
+	RPCListeners         []string      `long:"rpclisten" description:"Add an interface/port to listen for RPC connections, or a unix domain socket in the format unix:<path>, which never uses TLS (default port: 9245, testnet: 19245, regtest: 29245)"`
 	RPCMaxClients        int           `long:"rpcmaxclients" description:"Max number of RPC clients for standard connections"`
 	RPCMaxConcurrentReqs int           `long:"rpcmaxconcurrentreqs" description:"Max number of concurrent RPC requests that may be processed concurrently"`
-	RPCMaxWebsockets     int           `long:"rpcmaxwebsockets" description:"Max number of RPC websocket connections"`
+	RPCMaxWebsockets     int           `long:"rpcmaxwebsockets" description:"Max number of RPC websocket and event stream connections"`
 	RPCQuirks            bool          `long:"rpcquirks" description:"Mirror some JSON-RPC quirks of Bitcoin Core -- NOTE: Discouraged unless interoperability issues need to be worked around"`
 	RPCPass              string        `short:"P" long:"rpcpass" default-mask:"-" description:"Password for RPC connections"`
 	RPCUser              string        `short:"u" long:"rpcuser" description:"Username for RPC connections"`
+	RPCWSQueueBytes      int           `long:"rpcwsqueuebytes" description:"Max size in bytes of the notifications queued to a websocket client"`
+	RPCWSQueuePolicy     string        `long:"rpcwsqueuepolicy" description:"What to do when the notification queue of a websocket client is full: disconnect the client, or drop the oldest or newest notification {disconnect, dropoldest, dropnewest}"`
+	RPCWSQueueSize       int           `long:"rpcwsqueuesize" description:"Max number of notifications queued to a websocket client"`
+	RPCWhitelist         []string      `long:"rpcwhitelist" description:"Restrict an rpcauth user to the listed commands, in the format <user>:<command>,<command>,... -- Can be specified multiple times"`
+	ServeClaimSnapshots  bool          `long:"serveclaimsnapshots" description:"Write a snapshot of the claimtrie every 10000 blocks and serve it to the peers"`
 	SigCacheMaxSize      uint          `long:"sigcachemaxsize" description:"The maximum number of entries in the signature verification cache"`
 	SimNet               bool          `long:"simnet" description:"Use the simulation test network"`
+	SlowBlock            time.Duration `long:"slowblock" description:"Log the blocks taking longer than this to connect to the main chain, with a breakdown of the time spent -- 0 disables the logging.  Valid time units are {ms, s, m, h}"`
+	SlowRPC              time.Duration `long:"slowrpc" description:"Log the RPC and gRPC requests taking longer than this to process -- 0 disables the logging.  Valid time units are {ms, s, m, h}"`
 	SigNet               bool          `long:"signet" description:"Use the signet test network"`
 	SigNetChallenge      string        `long:"signetchallenge" description:"Connect to a custom signet network defined by this challenge instead of using the global default signet test network -- Can be specified multiple times"`
 	SigNetSeedNode       []string      `long:"signetseednode" description:"Specify a seed node for the signet network instead of using the global default signet network seed nodes"`
 	TestNet3             bool          `long:"testnet" description:"Use the test network"`
+	TorControl           string        `long:"torcontrol" description:"Create an onion service for the peer-to-peer listener through the Tor control port (eg. 127.0.0.1:9051) -- Its key is kept in the data directory"`
+	TorControlPass       string        `long:"torcontrolpass" default-mask:"-" description:"Password for the Tor control port -- The authentication cookie of Tor is used when not set"`
 	TorIsolation         bool          `long:"torisolation" description:"Enable Tor stream isolation by randomizing user credentials for each connection."`
+	TorOnionRPC          bool          `long:"toronionrpc" description:"Also serve the RPC server on the onion service created through the Tor control port"`
 	TrickleInterval      time.Duration `long:"trickleinterval" description:"Minimum time between attempts to send new inventory to a connected peer"`
 	TxIndex              bool          `long:"txindex" description:"Maintain a full hash-based transaction index which makes all transactions available via the getrawtransaction RPC"`
 	UserAgentComments    []string      `long:"uacomment" description:"Comment to add to the user agent -- See BIP 14 for more information."`
 	Upnp                 bool          `long:"upnp" description:"Use UPnP to map our listening port outside of NAT"`
 	ShowVersion          bool          `short:"V" long:"version" description:"Display version information and exit"`
+	VersionBits          []string      `long:"versionbits" description:"Set whether the block templates signal for a rule change deployment, given as <deployment>=<signal|nosignal|default> such as dummy=signal -- Can be specified multiple times"`
+	WarnFreeSpace        uint64        `long:"warnfreespace" description:"Warn while the free space of the volume of the block files, the chain state or the claimtrie falls below this many MiB -- 0 disables it"`
+	Webhooks             []string      `long:"webhook" description:"Add a URL to POST JSON notifications of new blocks, reorganizations, transactions of the webhookaddr addresses, claim takeovers and changes of the free disk space to"`
+	WebhookAddrs         []string      `long:"webhookaddr" description:"Add an address whose transactions are notified to the webhooks"`
+	WebhookSecret        string        `long:"webhooksecret" default-mask:"-" description:"Secret to sign the webhook payloads with, sent as the HMAC-SHA256 of the body in the X-Lbcd-Signature header"`
 	Whitelists           []string      `long:"whitelist" description:"Add an IP network or IP that will not be banned. (eg. 192.168.1.0/24 or ::1)"`
 	lookup               func(string) ([]net.IP, error)
 	oniondial            func(string, string, time.Duration) (net.Conn, error)
+	outboundNetworks     map[connmgr.Network]uint32
+	dbCheck              ffldb.CheckLevel
 	dial                 func(string, string, time.Duration) (net.Conn, error)
 	addCheckpoints       []chaincfg.Checkpoint
+	dustRelayFee         btcutil.Amount
+	eventExport          *eventExportTarget
 	miningAddrs          []btcutil.Address
 	minRelayTxFee        btcutil.Amount
+	rpcAuthUsers         []*rpcAuthUser
+	signaling            *mining.VersionBitsSignaling
+	webhookAddrs         []btcutil.Address
 	whitelists           []*net.IPNet
 }
 
@@ -328,6 +413,16 @@ func normalizeAddress(addr, defaultPort string) string {
 	return addr
 }
 
+// unixListenerPrefix is the prefix of the RPC listen addresses which are paths
+// of unix domain sockets rather than TCP interfaces.
+const unixListenerPrefix = "unix:"
+
+// isUnixListener returns whether the passed listen address is the path of a
+// unix domain socket.
+func isUnixListener(addr string) bool {
+	return strings.HasPrefix(addr, unixListenerPrefix)
+}
+
 // normalizeAddresses returns a new slice with all the passed peer addresses
 // normalized with the given default port, and all duplicates removed.
 func normalizeAddresses(addrs []string, defaultPort string) []string {
@@ -338,6 +433,27 @@ func normalizeAddresses(addrs []string, defaultPort string) []string {
 	return removeDuplicateAddresses(addrs)
 }
 
+// isValidDomainName returns whether the passed name, without trailing dot, is
+// a valid domain name made of letters, digits and hyphens.
+func isValidDomainName(name string) bool {
+	if len(name) == 0 || len(name) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(name, ".") {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' ||
+			label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' ||
+				c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // newCheckpointFromStr parses checkpoints in the '<height>:<hash>' format.
 func newCheckpointFromStr(checkpoint string) (chaincfg.Checkpoint, error) {
 	parts := strings.Split(checkpoint, ":")
@@ -405,44 +521,118 @@ func newConfigParser(cfg *config, so *serviceOptions, options flags.Options) *fl
 	return parser
 }
 
-// loadConfig initializes and parses the config using a config file and command
-// line options.
+// parseVersionBitsSignal parses the <deployment>=<signal> value of the
+// versionbits option into the deployment ID and its signal.
+func parseVersionBitsSignal(versionBits string) (uint32, mining.Signal, error) {
+	name, signalStr, ok := strings.Cut(versionBits, "=")
+	if !ok {
+		return 0, 0, fmt.Errorf("version bits signal %q is not of the "+
+			"form <deployment>=<signal>", versionBits)
+	}
+	id, err := mining.DeploymentByName(strings.TrimSpace(name))
+	if err != nil {
+		return 0, 0, err
+	}
+	signal, err := mining.ParseSignal(strings.TrimSpace(signalStr))
+	if err != nil {
+		return 0, 0, err
+	}
+	return id, signal, nil
+}
+
+// parseOutboundNetworks parses the <network>=<count> values of the
+// outboundnetwork option into the target number of outbound peers of each
+// network.
+func parseOutboundNetworks(values []string) (map[connmgr.Network]uint32, error) {
+	targets := make(map[connmgr.Network]uint32, len(values))
+	for _, value := range values {
+		name, countStr, ok := strings.Cut(value, "=")
+		if !ok {
+			return nil, fmt.Errorf("outbound network %q is not of the "+
+				"form <network>=<count>", value)
+		}
+		network, err := connmgr.ParseNetwork(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := targets[network]; ok {
+			return nil, fmt.Errorf("outbound network %v specified "+
+				"more than once", network)
+		}
+		count, err := strconv.ParseUint(strings.TrimSpace(countStr), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid count of outbound "+
+				"network %v: %v", network, err)
+		}
+		targets[network] = uint32(count)
+	}
+	return targets, nil
+}
+
+// loadConfig initializes and parses the config using a config file, the
+// environment and command line options.
 //
 // The configuration proceeds as follows:
 //  1. Start with a default config with sane settings
-//  2. Pre-parse the command line to check for an alternative config file
+//  2. Pre-parse the environment and the command line to check for an
+//     alternative config file
 //  3. Load configuration file overwriting defaults with any specified options
-//  4. Parse CLI options and overwrite/add any specified options
+//  4. Parse the LBCD_ environment variables and overwrite/add any specified
+//     options
+//  5. Parse CLI options and overwrite/add any specified options
 //
 // The above results in lbcd functioning properly without any config settings
-// while still allowing the user to override settings with config files and
-// command line options.  Command line options always take precedence.
+// while still allowing the user to override settings with config files, the
+// environment and command line options.  Command line options always take
+// precedence.
 func loadConfig() (*config, []string, error) {
 	// Default config.
 	cfg := config{
 		ConfigFile:           defaultConfigFile,
 		DebugLevel:           defaultLogLevel,
 		MaxPeers:             defaultMaxPeers,
+		BlockRelayOnlyPeers:  defaultBlockRelayOnlyPeers,
 		BanDuration:          defaultBanDuration,
 		BanThreshold:         defaultBanThreshold,
 		RPCMaxClients:        defaultMaxRPCClients,
+		ElectrumMaxClients:   defaultMaxElectrumClients,
+		EventExportFormat:    exportFormatJSON,
+		EventExportSASL:      defaultEventExportSASL,
+		PolicyPluginTimeout:  defaultPolicyPluginTimeout,
 		RPCMaxWebsockets:     defaultMaxRPCWebsockets,
 		RPCMaxConcurrentReqs: defaultMaxRPCConcurrentReqs,
+		RPCDrainTimeout:      defaultRPCDrainTimeout,
+		MinFreeSpace:         defaultMinFreeSpace,
+		WarnFreeSpace:        defaultWarnFreeSpace,
+		RPCWSQueueSize:       defaultRPCWSQueueSize,
+		RPCWSQueueBytes:      defaultRPCWSQueueBytes,
+		RPCWSQueuePolicy:     defaultRPCWSQueuePolicy,
 		DataDir:              defaultDataDir,
 		LogDir:               defaultLogDir,
+		LogFormat:            logFormatText,
+		DbCheck:              defaultDbCheck,
 		DbType:               defaultDbType,
 		RPCKey:               defaultRPCKeyFile,
 		RPCCert:              defaultRPCCertFile,
 		MinRelayTxFee:        mempool.DefaultMinRelayTxFee.ToBTC(),
 		FreeTxRelayLimit:     defaultFreeTxRelayLimit,
 		TrickleInterval:      defaultTrickleInterval,
+		BlockCacheSize:       defaultBlockCacheSize,
 		BlockMinSize:         defaultBlockMinSize,
 		BlockMaxSize:         defaultBlockMaxSize,
 		BlockMinWeight:       defaultBlockMinWeight,
 		BlockMaxWeight:       defaultBlockMaxWeight,
 		BlockPrioritySize:    mempool.DefaultBlockPrioritySize,
 		MaxOrphanTxs:         defaultMaxOrphanTransactions,
+		MaxStdMultiSigKeys:   mining.DefaultMaxStandardMultiSigKeys,
+		MaxStdNullData:       mining.DefaultMaxStandardNullDataOutputs,
+		MaxStdP2SHSigOps:     mining.DefaultMaxStandardP2SHSigOps,
+		MaxStdSigScriptSize:  mining.DefaultMaxStandardSigScriptSize,
+		MaxStdTxWeight:       mining.DefaultMaxStandardTxWeight,
 		SigCacheMaxSize:      defaultSigCacheMaxSize,
+		ReadyMaxLag:          defaultReadyMaxLag,
+		SlowBlock:            defaultSlowBlock,
+		SlowRPC:              defaultSlowRPC,
 		Generate:             defaultGenerate,
 		TxIndex:              defaultTxIndex,
 		AddrIndex:            defaultAddrIndex,
@@ -452,12 +642,13 @@ func loadConfig() (*config, []string, error) {
 	// Service options which are only added on Windows.
 	serviceOpts := serviceOptions{}
 
-	// Pre-parse the command line options to see if an alternative config
-	// file or the version flag was specified.  Any errors aside from the
-	// help message error can be ignored here since they will be caught by
-	// the final parse below.
+	// Pre-parse the environment variables and the command line options to
+	// see if an alternative config file or the version flag was specified.
+	// Any errors aside from the help message error can be ignored here
+	// since they will be caught by the final parse below.
 	preCfg := cfg
 	preParser := newConfigParser(&preCfg, &serviceOpts, flags.HelpFlag)
+	_ = parseEnvConfig(preParser, os.LookupEnv)
 	_, err := preParser.Parse()
 	if err != nil {
 		if e, ok := err.(*flags.Error); ok && e.Type == flags.ErrHelp {
@@ -500,7 +691,7 @@ func loadConfig() (*config, []string, error) {
 			}
 		}
 
-		err := flags.NewIniParser(parser).ParseFile(preCfg.ConfigFile)
+		err := parseConfigFile(parser, &cfg, &preCfg, preCfg.ConfigFile)
 		if err != nil {
 			if _, ok := err.(*os.PathError); !ok {
 				fmt.Fprintf(os.Stderr, "Error parsing config "+
@@ -517,6 +708,14 @@ func loadConfig() (*config, []string, error) {
 		cfg.AddPeers = nil
 	}
 
+	// Parse the environment variables, which take precedence over the
+	// config file.
+	if err := parseEnvConfig(parser, os.LookupEnv); err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing environment: %v\n", err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Parse command line options again to ensure they take precedence.
 	remainingArgs, err := parser.Parse()
 	if err != nil {
@@ -613,6 +812,42 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Override the parameters of the test chains, or make private networks
+	// of them.  The chain parameters are changed in place, since the chains
+	// are identified by their parameters, while the claimtrie parameters
+	// are copied into them.
+	if len(cfg.ChainParams) > 0 || cfg.GenesisBlock != "" ||
+		cfg.GenesisMessage != "" || cfg.GenesisTime != 0 {
+
+		if !(cfg.RegressionTest || cfg.SimNet) {
+			str := "%s: The chainparam and genesis options are only " +
+				"supported on the regtest and simnet networks"
+			err := fmt.Errorf(str, funcName)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		base := *activeNetParams.Params
+		claims := base.ClaimTrieParams()
+		activeNetParams.ClaimTrie = &claims
+		err := applyChainParams(activeNetParams.Params, &claims,
+			cfg.ChainParams)
+		if err == nil {
+			var genesis *wire.MsgBlock
+			genesis, err = configuredGenesisBlock(activeNetParams.Params,
+				cfg.GenesisBlock, cfg.GenesisTime, cfg.GenesisMessage)
+			if err == nil {
+				err = customizeNetwork(activeNetParams, &base, genesis)
+			}
+		}
+		if err != nil {
+			err := fmt.Errorf("%s: %v", funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+
 	// If mainnet is active, then we won't allow the stall handler to be
 	// disabled.
 	if activeNetParams.Params.Net == wire.MainNet && cfg.DisableStallHandler {
@@ -652,6 +887,25 @@ func loadConfig() (*config, []string, error) {
 	cfg.DataDir = cleanAndExpandPath(cfg.DataDir)
 	cfg.DataDir = filepath.Join(cfg.DataDir, netName(activeNetParams))
 
+	// Namespace the block files directory per network in the same fashion
+	// as the data directory.
+	if cfg.BlocksDir != "" {
+		cfg.BlocksDir = cleanAndExpandPath(cfg.BlocksDir)
+		cfg.BlocksDir = filepath.Join(cfg.BlocksDir, netName(activeNetParams))
+	}
+
+	// Ensure the block archives to import exist.
+	for i, path := range cfg.LoadBlocks {
+		cfg.LoadBlocks[i] = cleanAndExpandPath(path)
+		if !fileExists(cfg.LoadBlocks[i]) {
+			str := "%s: The block archive %v to import does not exist"
+			err := fmt.Errorf(str, funcName, path)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+
 	// Append the network type to the log directory so it is "namespaced"
 	// per network in the same fashion as the data directory.
 	cfg.LogDir = cleanAndExpandPath(cfg.LogDir)
@@ -663,6 +917,19 @@ func loadConfig() (*config, []string, error) {
 		os.Exit(0)
 	}
 
+	// Validate the format of the log records.
+	switch cfg.LogFormat {
+	case logFormatText, logFormatJSON:
+		jsonLogs = cfg.LogFormat == logFormatJSON
+	default:
+		str := "%s: The logformat option must be one of text or " +
+			"json -- parsed [%s]"
+		err := fmt.Errorf(str, funcName, cfg.LogFormat)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Initialize log rotation.  After log rotation has been initialized, the
 	// logger variables may be used.
 	initLogRotator(filepath.Join(cfg.LogDir, defaultLogFilename))
@@ -685,6 +952,16 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Validate the consistency check level of the block database.
+	cfg.dbCheck, err = ffldb.ParseCheckLevel(cfg.DbCheck)
+	if err != nil {
+		str := "%s: The dbcheck option must be none, quick or full: %v"
+		err := fmt.Errorf(str, funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Validate profile port number
 	if cfg.Profile != "" {
 		profilePort, err := strconv.Atoi(cfg.Profile)
@@ -788,12 +1065,121 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
-	// The RPC server is disabled if no username or password is provided.
-	if (cfg.RPCUser == "" || cfg.RPCPass == "") &&
-		(cfg.RPCLimitUser == "" || cfg.RPCLimitPass == "") {
+	// Parse the RPC users and their command whitelists.
+	cfg.rpcAuthUsers, err = rpcAuthUsers(&cfg)
+	if err != nil {
+		str := "%s: %v"
+		err := fmt.Errorf(str, funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// The RPC server is disabled if no users are provided.
+	if len(cfg.rpcAuthUsers) == 0 {
 		cfg.DisableRPC = true
 	}
 
+	// The gRPC server requires the users and TLS settings of the RPC
+	// server, and an explicit port as there is no default one.
+	if len(cfg.GRPCListeners) > 0 {
+		if cfg.DisableRPC {
+			str := "%s: the gRPC server requires the RPC server, " +
+				"which is disabled or has no users"
+			err := fmt.Errorf(str, funcName)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		for _, addr := range cfg.GRPCListeners {
+			if _, _, err := net.SplitHostPort(addr); err != nil {
+				str := "%s: gRPC listen interface '%s' is " +
+					"invalid: %v"
+				err := fmt.Errorf(str, funcName, addr, err)
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, usageMessage)
+				return nil, nil, err
+			}
+		}
+		cfg.GRPCListeners = removeDuplicateAddresses(cfg.GRPCListeners)
+	}
+
+	// The Electrum server uses the command handlers of the RPC server and
+	// serves the histories of the address index, and needs an explicit port
+	// as there is no default one.
+	if len(cfg.ElectrumListeners) > 0 {
+		var str string
+		switch {
+		case cfg.DisableRPC:
+			str = "%s: the Electrum server requires the RPC server, " +
+				"which is disabled or has no users"
+		case !cfg.AddrIndex:
+			str = "%s: the Electrum server requires the address " +
+				"index (--addrindex)"
+		case cfg.ElectrumMaxClients < 1:
+			str = "%s: the max number of Electrum clients must be " +
+				"at least 1"
+		}
+		if str != "" {
+			err := fmt.Errorf(str, funcName)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		for _, addr := range cfg.ElectrumListeners {
+			if _, _, err := net.SplitHostPort(addr); err != nil {
+				str := "%s: Electrum listen interface '%s' is " +
+					"invalid: %v"
+				err := fmt.Errorf(str, funcName, addr, err)
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, usageMessage)
+				return nil, nil, err
+			}
+		}
+		cfg.ElectrumListeners = removeDuplicateAddresses(cfg.ElectrumListeners)
+	}
+
+	// The WebSocket peer-to-peer listeners need an explicit port as there is
+	// no default one, and the origins allowed must be of the form
+	// scheme://host[:port], as sent by the browsers.
+	if len(cfg.P2PWSListeners) > 0 {
+		for _, addr := range cfg.P2PWSListeners {
+			if _, _, err := net.SplitHostPort(addr); err != nil {
+				str := "%s: WebSocket listen interface '%s' is " +
+					"invalid: %v"
+				err := fmt.Errorf(str, funcName, addr, err)
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, usageMessage)
+				return nil, nil, err
+			}
+		}
+		for _, origin := range cfg.P2PWSOrigins {
+			if origin == "*" {
+				continue
+			}
+			u, err := url.Parse(origin)
+			if err != nil || u.Scheme == "" || u.Host == "" ||
+				strings.TrimSuffix(u.Path, "/") != "" ||
+				u.RawQuery != "" || u.Fragment != "" {
+
+				str := "%s: WebSocket origin '%s' is invalid, " +
+					"it must be of the form scheme://host[:port]"
+				err := fmt.Errorf(str, funcName, origin)
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, usageMessage)
+				return nil, nil, err
+			}
+		}
+		cfg.P2PWSListeners = removeDuplicateAddresses(cfg.P2PWSListeners)
+	} else if len(cfg.P2PWSOrigins) > 0 || cfg.P2PWSTLS {
+		str := "%s: the --p2pwsorigin and --p2pwstls options require " +
+			"the --p2pwslisten option"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	if cfg.DisableRPC {
 		btcdLog.Infof("RPC service is disabled")
 	}
@@ -820,6 +1206,46 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	if cfg.RPCDrainTimeout < 0 {
+		str := "%s: The rpcdraintimeout option may not be less than 0 " +
+			"-- parsed [%v]"
+		err := fmt.Errorf(str, funcName, cfg.RPCDrainTimeout)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	if cfg.WarnFreeSpace != 0 && cfg.WarnFreeSpace < cfg.MinFreeSpace {
+		str := "%s: The warnfreespace option may not be less than " +
+			"minfreespace -- parsed [%d] and [%d]"
+		err := fmt.Errorf(str, funcName, cfg.WarnFreeSpace,
+			cfg.MinFreeSpace)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Validate the websocket notification queues.
+	if cfg.RPCWSQueueSize <= 0 || cfg.RPCWSQueueBytes <= 0 {
+		str := "%s: The rpcwsqueuesize and rpcwsqueuebytes options " +
+			"must be positive -- parsed [%d] and [%d]"
+		err := fmt.Errorf(str, funcName, cfg.RPCWSQueueSize,
+			cfg.RPCWSQueueBytes)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+	switch cfg.RPCWSQueuePolicy {
+	case wsQueueDisconnect, wsQueueDropOldest, wsQueueDropNewest:
+	default:
+		str := "%s: The rpcwsqueuepolicy option must be one of " +
+			"disconnect, dropoldest or dropnewest -- parsed [%s]"
+		err := fmt.Errorf(str, funcName, cfg.RPCWSQueuePolicy)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Validate the the minrelaytxfee.
 	cfg.minRelayTxFee, err = btcutil.NewAmount(cfg.MinRelayTxFee)
 	if err != nil {
@@ -830,6 +1256,38 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Validate the dustrelayfee.
+	cfg.dustRelayFee, err = btcutil.NewAmount(cfg.DustRelayFee)
+	if err != nil || cfg.dustRelayFee < 0 {
+		str := "%s: invalid dustrelayfee: %v"
+		err := fmt.Errorf(str, funcName, cfg.DustRelayFee)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Ensure the standardness policy limits are sane.
+	if cfg.MaxStdTxWeight < 1 || cfg.MaxStdTxWeight > blockchain.MaxBlockWeight {
+		str := "%s: The maxstdtxweight option must be in between 1 " +
+			"and %d -- parsed [%d]"
+		err := fmt.Errorf(str, funcName, blockchain.MaxBlockWeight,
+			cfg.MaxStdTxWeight)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+	if cfg.MaxStdSigScriptSize < 0 || cfg.MaxStdP2SHSigOps < 0 ||
+		cfg.MaxStdMultiSigKeys < 0 || cfg.MaxStdNullData < 0 {
+
+		str := "%s: The maxstdsigscriptsize, maxstdp2shsigops, " +
+			"maxstdmultisigkeys and maxstdnulldataoutputs options " +
+			"may not be less than 0"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Limit the max block size to a sane value.
 	if cfg.BlockMaxSize < blockMaxSizeMin || cfg.BlockMaxSize >
 		blockMaxSizeMax {
@@ -856,6 +1314,15 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	if cfg.BlockRelayOnlyPeers < 0 {
+		str := "%s: The blockrelayonlypeers option may not be less " +
+			"than 0 -- parsed [%d]"
+		err := fmt.Errorf(str, funcName, cfg.BlockRelayOnlyPeers)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Limit the max orphan count to a sane vlue.
 	if cfg.MaxOrphanTxs < 0 {
 		str := "%s: The maxorphantx option may not be less than 0 " +
@@ -932,6 +1399,48 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// The DNS seeder answers the queries for a valid domain, by default on
+	// all interfaces.
+	if cfg.DNSSeeder == "" {
+		if len(cfg.DNSSeederListeners) > 0 || cfg.DNSSeederNameserver != "" {
+			str := "%s: the --dnsseederlisten and --dnsseedernameserver " +
+				"options require the --dnsseeder option"
+			err := fmt.Errorf(str, funcName)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	} else {
+		cfg.DNSSeeder = strings.ToLower(strings.TrimSuffix(cfg.DNSSeeder, "."))
+		cfg.DNSSeederNameserver = strings.ToLower(
+			strings.TrimSuffix(cfg.DNSSeederNameserver, "."))
+		for _, host := range []string{cfg.DNSSeeder, cfg.DNSSeederNameserver} {
+			if host != "" && !isValidDomainName(host) {
+				str := "%s: '%s' is not a valid domain name"
+				err := fmt.Errorf(str, funcName, host)
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, usageMessage)
+				return nil, nil, err
+			}
+		}
+		if len(cfg.DNSSeederListeners) == 0 {
+			cfg.DNSSeederListeners = []string{""}
+		}
+		cfg.DNSSeederListeners = normalizeAddresses(cfg.DNSSeederListeners,
+			defaultDNSSeederPort)
+	}
+
+	// --electrumlisten and --dropscripthashindex do not mix.
+	if len(cfg.ElectrumListeners) > 0 && cfg.DropScriptHashIndex {
+		err := fmt.Errorf("%s: the --electrumlisten and "+
+			"--dropscripthashindex options may not be activated at "+
+			"the same time because the Electrum server relies on the "+
+			"script hash index", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Check mining addresses are valid and saved parsed versions.
 	cfg.miningAddrs = make([]btcutil.Address, 0, len(cfg.MiningAddrs))
 	for _, strAddr := range cfg.MiningAddrs {
@@ -964,20 +1473,144 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Check the signals of the rule change deployments against the
+	// deployments of the network.
+	cfg.signaling = mining.NewVersionBitsSignaling(activeNetParams.Params)
+	for _, versionBits := range cfg.VersionBits {
+		id, signal, err := parseVersionBitsSignal(versionBits)
+		if err == nil {
+			err = cfg.signaling.SetSignal(id, signal)
+		}
+		if err != nil {
+			err := fmt.Errorf("%s: %v", funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+
+	// Check the event export target and format.
+	if cfg.EventExport != "" {
+		cfg.eventExport, err = parseEventExportTarget(cfg.EventExport)
+		if err != nil {
+			str := "%s: invalid event export target '%s': %v"
+			err := fmt.Errorf(str, funcName, cfg.EventExport, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		if cfg.EventExportCAFile != "" {
+			cfg.EventExportCAFile = cleanAndExpandPath(cfg.EventExportCAFile)
+		}
+		err = cfg.eventExport.setSecurity(cfg.EventExportTLS,
+			cfg.EventExportCAFile, cfg.EventExportSASL)
+		if err != nil {
+			str := "%s: invalid event export security: %v"
+			err := fmt.Errorf(str, funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+	if cfg.EventExportFormat != exportFormatJSON &&
+		cfg.EventExportFormat != exportFormatProtobuf {
+
+		str := "%s: the event export format must be json or protobuf, " +
+			"not '%s'"
+		err := fmt.Errorf(str, funcName, cfg.EventExportFormat)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Check the policy plugin waits for its verdicts.
+	if cfg.PolicyPluginTimeout <= 0 {
+		str := "%s: the policyplugintimeout option must be positive"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Check the webhooks are absolute http or https URLs.
+	for _, hook := range cfg.Webhooks {
+		u, err := url.Parse(hook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") ||
+			u.Host == "" {
+
+			str := "%s: webhook '%s' is not a valid http or https URL"
+			err := fmt.Errorf(str, funcName, hook)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+
+	// Check webhook addresses are valid and saved parsed versions.
+	if len(cfg.WebhookAddrs) > 0 && len(cfg.Webhooks) == 0 {
+		str := "%s: webhook addresses are specified, but there are no " +
+			"webhooks to notify"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+	cfg.webhookAddrs = make([]btcutil.Address, 0, len(cfg.WebhookAddrs))
+	for _, strAddr := range cfg.WebhookAddrs {
+		addr, err := btcutil.DecodeAddress(strAddr, activeNetParams.Params)
+		if err != nil {
+			str := "%s: webhook address '%s' failed to decode: %v"
+			err := fmt.Errorf(str, funcName, strAddr, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		if !addr.IsForNet(activeNetParams.Params) {
+			str := "%s: webhook address '%s' is on the wrong network"
+			err := fmt.Errorf(str, funcName, strAddr)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		cfg.webhookAddrs = append(cfg.webhookAddrs, addr)
+	}
+
 	// Add default port to all listener addresses if needed and remove
 	// duplicate addresses.
 	cfg.Listeners = normalizeAddresses(cfg.Listeners,
 		activeNetParams.DefaultPort)
 
 	// Add default port to all rpc listener addresses if needed and remove
-	// duplicate addresses.
-	cfg.RPCListeners = normalizeAddresses(cfg.RPCListeners,
-		activeNetParams.rpcPort)
+	// duplicate addresses.  The paths of unix domain sockets are expanded
+	// instead.
+	var rpcTCPListeners, rpcUnixListeners []string
+	for _, addr := range cfg.RPCListeners {
+		if !isUnixListener(addr) {
+			rpcTCPListeners = append(rpcTCPListeners, addr)
+			continue
+		}
+		path := strings.TrimPrefix(addr, unixListenerPrefix)
+		if path == "" {
+			str := "%s: RPC listen interface '%s' is missing the " +
+				"path of the unix domain socket"
+			err := fmt.Errorf(str, funcName, addr)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		rpcUnixListeners = append(rpcUnixListeners,
+			unixListenerPrefix+cleanAndExpandPath(path))
+	}
+	cfg.RPCListeners = append(normalizeAddresses(rpcTCPListeners,
+		activeNetParams.rpcPort), removeDuplicateAddresses(rpcUnixListeners)...)
 
 	// Only allow TLS to be disabled if the RPC is bound to localhost
 	// addresses.
 	if !cfg.DisableRPC && cfg.DisableTLS {
 		for _, addr := range cfg.RPCListeners {
+			if isUnixListener(addr) {
+				continue
+			}
 			_, _, err := net.SplitHostPort(addr)
 			if err != nil {
 				str := "%s: RPC listen interface '%s' is " +
@@ -997,6 +1630,42 @@ func loadConfig() (*config, []string, error) {
 	cfg.ConnectPeers = normalizeAddresses(cfg.ConnectPeers,
 		activeNetParams.DefaultPort)
 
+	// The onion service created through the Tor control port forwards to
+	// the peer-to-peer listener, and optionally to the RPC server.
+	if cfg.TorControl != "" {
+		if _, _, err := net.SplitHostPort(cfg.TorControl); err != nil {
+			str := "%s: Tor control port address '%s' is invalid: %v"
+			err := fmt.Errorf(str, funcName, cfg.TorControl, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		_, p2pOK := torOnionTarget(cfg.Listeners)
+		_, rpcOK := torOnionTarget(cfg.RPCListeners)
+		var str string
+		switch {
+		case cfg.DisableListen || !p2pOK:
+			str = "%s: the --torcontrol option requires a TCP " +
+				"peer-to-peer listener, and listening is disabled"
+		case cfg.TorOnionRPC && (cfg.DisableRPC || !rpcOK):
+			str = "%s: the --toronionrpc option requires a TCP RPC " +
+				"listener, and the RPC server is disabled or has none"
+		}
+		if str != "" {
+			err := fmt.Errorf(str, funcName)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	} else if cfg.TorOnionRPC || cfg.TorControlPass != "" {
+		str := "%s: the --toronionrpc and --torcontrolpass options " +
+			"require the --torcontrol option"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// --noonion and --onion do not mix.
 	if cfg.NoOnion && cfg.OnionProxy != "" {
 		err := fmt.Errorf("%s: the --noonion and --onion options may "+
@@ -1130,6 +1799,41 @@ func loadConfig() (*config, []string, error) {
 		}
 	}
 
+	// The outbound peers maintained on the networks have to be reachable
+	// and fit within the max number of peers.  lbcd learns and dials no
+	// I2P addresses.
+	if len(cfg.OutboundNetworks) > 0 {
+		cfg.outboundNetworks, err = parseOutboundNetworks(cfg.OutboundNetworks)
+		if err == nil {
+			var sum uint32
+			for network, count := range cfg.outboundNetworks {
+				switch {
+				case network == connmgr.NetworkI2P:
+					err = errors.New("i2p outbound peers are " +
+						"not supported")
+				case network == connmgr.NetworkTor && count > 0 &&
+					(cfg.NoOnion || (cfg.OnionProxy == "" &&
+						cfg.Proxy == "")):
+					err = errors.New("onion outbound peers " +
+						"require the --onion or --proxy " +
+						"option without --noonion")
+				}
+				sum += count
+			}
+			if err == nil && sum > uint32(cfg.MaxPeers) {
+				err = fmt.Errorf("%d outbound network peers exceed "+
+					"maxpeers", sum)
+			}
+		}
+		if err != nil {
+			str := "%s: The outboundnetwork option is invalid: %v"
+			err := fmt.Errorf(str, funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+
 	// Warn about missing config file only after all other configuration is
 	// done.  This prevents the warning on help messages and invalid
 	// options.  Note this should go directly before the return.
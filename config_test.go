@@ -22,7 +22,7 @@ var (
 // match for the test to work.
 type configCmdLineOnly struct {
 	ConfigFile          string   `short:"C" long:"configfile" description:"Path to configuration file"`
-	DbType              string   `long:"dbtype" description:"Database backend to use for the Block Chain"`
+	DbType              string   `long:"dbtype" description:"Database backend to use for the Block Chain -- ffpebble stores its metadata in pebble rather than leveldb"`
 	DropCfIndex         bool     `long:"dropcfindex" description:"Deletes the index used for committed filtering (CF) support from the database on start up and then exits."`
 	DropTxIndex         bool     `long:"droptxindex" description:"Deletes the hash-based transaction index from the database on start up and then exits."`
 	DisableCheckpoints  bool     `long:"nocheckpoints" description:"Disable built-in checkpoints.  Don't do this unless you know what you're doing."`
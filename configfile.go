@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	flags "github.com/jessevdk/go-flags"
+)
+
+const (
+	// includeConfKey is the key of the lines of the config files including
+	// another config file.
+	includeConfKey = "includeconf"
+
+	// maxIncludeDepth is the max depth of the config files including each
+	// other.
+	maxIncludeDepth = 8
+
+	// envConfigPrefix is the prefix of the environment variables setting the
+	// options, followed by their long name in upper case.
+	envConfigPrefix = "LBCD_"
+
+	// appOptionsSection is the name of the section of the application
+	// options, which are also those outside of any section.
+	appOptionsSection = "Application Options"
+)
+
+// configNetworkSections are the names of the sections of the config files
+// whose options only apply to a network, which are those of bitcoind along with
+// simnet.
+var configNetworkSections = map[string]struct{}{
+	"main":    {},
+	"test":    {},
+	"regtest": {},
+	"simnet":  {},
+	"signet":  {},
+}
+
+// configLine is the origin of a line of the options of the config files.
+type configLine struct {
+	path string
+	num  int
+}
+
+// configSection is the text of the options of a section of the config files,
+// along with the origin of each line.
+type configSection struct {
+	name    string
+	lines   []string
+	origins []configLine
+}
+
+// configFiles holds the options read from a config file and the files it
+// includes, split between the sections applying to all networks and those
+// applying to a single network.
+type configFiles struct {
+	sections []*configSection
+	networks map[string]*configSection
+	reading  map[string]struct{}
+}
+
+// readConfigFiles reads the options of a config file and of the files it
+// includes.  The included files are read as if their options were written in
+// place of the includeconf line, and their sections end with them.  Relative
+// paths are relative to the directory of the including file.
+func readConfigFiles(path string) (*configFiles, error) {
+	c := &configFiles{
+		networks: make(map[string]*configSection),
+		reading:  make(map[string]struct{}),
+	}
+	if err := c.read(path, "", 0); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// section returns the section of the options applying to all networks with the
+// passed name, or to the passed network.
+func (c *configFiles) section(name, network string) *configSection {
+	if network != "" {
+		s, ok := c.networks[network]
+		if !ok {
+			s = &configSection{}
+			c.networks[network] = s
+		}
+		return s
+	}
+	for _, s := range c.sections {
+		if s.name == name {
+			return s
+		}
+	}
+	// The options outside of any section must come first.
+	s := &configSection{name: name}
+	if name == "" {
+		c.sections = append([]*configSection{s}, c.sections...)
+		return s
+	}
+	c.sections = append(c.sections, s)
+	return s
+}
+
+// read reads the options of a config file, starting in the section of the
+// passed network when it is included from a network section.
+func (c *configFiles) read(path, network string, depth int) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if _, ok := c.reading[absPath]; ok {
+		return fmt.Errorf("config file %s includes itself", path)
+	}
+	c.reading[absPath] = struct{}{}
+	defer delete(c.reading, absPath)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	name := ""
+	scanner := bufio.NewScanner(f)
+	for num := 1; scanner.Scan(); num++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed[0] == ';' || trimmed[0] == '#' {
+			continue
+		}
+
+		if len(trimmed) > 2 && trimmed[0] == '[' &&
+			trimmed[len(trimmed)-1] == ']' {
+
+			name = strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			network = ""
+			if _, ok := configNetworkSections[name]; ok {
+				network, name = name, ""
+			}
+
+			// The application options are kept in the order they
+			// are read whether they are in their section or not,
+			// as the sections are parsed in no particular order.
+			if strings.EqualFold(name, appOptionsSection) {
+				name = ""
+			}
+			continue
+		}
+
+		keyval := strings.SplitN(trimmed, "=", 2)
+		if len(keyval) == 2 && strings.EqualFold(
+			strings.TrimSpace(keyval[0]), includeConfKey) {
+
+			if depth == maxIncludeDepth {
+				return fmt.Errorf("%s:%d: too many nested included "+
+					"config files", path, num)
+			}
+			include := strings.TrimSpace(keyval[1])
+			if unquoted, err := strconv.Unquote(include); err == nil {
+				include = unquoted
+			}
+			include = cleanAndExpandPath(include)
+			if !filepath.IsAbs(include) {
+				include = filepath.Join(filepath.Dir(path), include)
+			}
+			err := c.read(include, network, depth+1)
+			if err != nil {
+				return fmt.Errorf("%s:%d: failed to include config "+
+					"file: %v", path, num, err)
+			}
+			continue
+		}
+
+		s := c.section(name, network)
+		s.lines = append(s.lines, line)
+		s.origins = append(s.origins, configLine{path: path, num: num})
+	}
+	return scanner.Err()
+}
+
+// parseConfigSections parses the options of the passed sections, reporting the
+// errors at the lines of the config files they come from.
+func parseConfigSections(parser *flags.Parser, sections []*configSection) error {
+	var text strings.Builder
+	var origins []configLine
+	for _, s := range sections {
+		if len(s.lines) == 0 {
+			continue
+		}
+		if s.name != "" {
+			fmt.Fprintf(&text, "[%s]\n", s.name)
+			origins = append(origins, s.origins[0])
+		}
+		for _, line := range s.lines {
+			text.WriteString(line)
+			text.WriteByte('\n')
+		}
+		origins = append(origins, s.origins...)
+	}
+
+	err := flags.NewIniParser(parser).Parse(strings.NewReader(text.String()))
+	if e, ok := err.(*flags.IniError); ok && e.LineNumber > 0 &&
+		int(e.LineNumber) <= len(origins) {
+
+		origin := origins[e.LineNumber-1]
+		e.File, e.LineNumber = origin.path, uint(origin.num)
+	}
+	return err
+}
+
+// configNetworkSection returns the name of the section of the config files of
+// the network selected by the first of the passed configs selecting one.
+func configNetworkSection(cfgs ...*config) string {
+	for _, cfg := range cfgs {
+		switch {
+		case cfg.TestNet3:
+			return "test"
+		case cfg.RegressionTest:
+			return "regtest"
+		case cfg.SimNet:
+			return "simnet"
+		case cfg.SigNet:
+			return "signet"
+		}
+	}
+	return "main"
+}
+
+// parseConfigFile parses the options of a config file and of the files it
+// includes into cfg.  The options of the section of the network selected by
+// preCfg, parsed from the command line, or else by the other options, are
+// parsed last so they override the options applying to all networks.
+func parseConfigFile(parser *flags.Parser, cfg, preCfg *config, path string) error {
+	files, err := readConfigFiles(path)
+	if err != nil {
+		return err
+	}
+	if err := parseConfigSections(parser, files.sections); err != nil {
+		return err
+	}
+
+	network := files.networks[configNetworkSection(preCfg, cfg)]
+	if network == nil {
+		return nil
+	}
+	return parseConfigSections(parser, []*configSection{network})
+}
+
+// envConfigKey returns the environment variable setting an option.
+func envConfigKey(option *flags.Option) string {
+	return envConfigPrefix + strings.ToUpper(option.LongName)
+}
+
+// parseEnvConfig parses the options set by the environment variables.  The
+// values of the list options are separated by commas.
+func parseEnvConfig(parser *flags.Parser, lookupEnv func(string) (string, bool)) error {
+	var text strings.Builder
+	var keys []string
+	groups := append([]*flags.Group{parser.Group}, parser.Groups()...)
+	for _, group := range groups {
+		for _, option := range group.Options() {
+			if option.LongName == "" {
+				continue
+			}
+			key := envConfigKey(option)
+			value, ok := lookupEnv(key)
+			if !ok {
+				continue
+			}
+			values := []string{value}
+			if option.Field().Type.Kind() == reflect.Slice {
+				values = strings.Split(value, ",")
+			}
+			for _, value := range values {
+				fmt.Fprintf(&text, "%s=%s\n", option.LongName,
+					strconv.Quote(strings.TrimSpace(value)))
+				keys = append(keys, key)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	err := flags.NewIniParser(parser).Parse(strings.NewReader(text.String()))
+	if e, ok := err.(*flags.IniError); ok && e.LineNumber > 0 &&
+		int(e.LineNumber) <= len(keys) {
+
+		return fmt.Errorf("environment variable %s: %s",
+			keys[e.LineNumber-1], e.Message)
+	}
+	return err
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	flags "github.com/jessevdk/go-flags"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfigFile(t *testing.T) {
+
+	r := require.New(t)
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		r.NoError(os.MkdirAll(filepath.Dir(path), 0700))
+		r.NoError(os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+	parse := func(path string, preCfg config) (*config, error) {
+		cfg := config{MaxPeers: defaultMaxPeers}
+		parser := newConfigParser(&cfg, &serviceOptions{}, flags.Default)
+		return &cfg, parseConfigFile(parser, &cfg, &preCfg, path)
+	}
+
+	write("shared/base.conf", `
+rpcuser=base
+maxpeers=10
+addpeer=10.0.0.1
+`)
+	write("shared/regtest.conf", "maxpeers=30\n")
+	path := write("lbcd.conf", `
+[Application Options]
+includeconf=shared/base.conf
+rpcpass=pass
+addpeer=10.0.0.2
+
+[main]
+maxpeers=20
+
+[regtest]
+includeconf=shared/regtest.conf
+addpeer=10.0.0.3
+`)
+
+	// The options of the main network section override the others.
+	cfg, err := parse(path, config{})
+	r.NoError(err)
+	r.Equal("base", cfg.RPCUser)
+	r.Equal("pass", cfg.RPCPass)
+	r.Equal(20, cfg.MaxPeers)
+	r.Equal([]string{"10.0.0.1", "10.0.0.2"}, cfg.AddPeers)
+
+	// The network is selected by the command line, and the included files
+	// inherit the network section they are included from.
+	cfg, err = parse(path, config{RegressionTest: true})
+	r.NoError(err)
+	r.Equal(30, cfg.MaxPeers)
+	r.Equal([]string{"10.0.0.3"}, cfg.AddPeers)
+
+	// The network is selected by the options outside of the sections.
+	cfg, err = parse(write("test.conf", "regtest=1\n[regtest]\nmaxpeers=40\n"),
+		config{})
+	r.NoError(err)
+	r.Equal(40, cfg.MaxPeers)
+	_, err = parse(write("test.conf", "[simnet]\nmaxpeers=40\n"),
+		config{SimNet: true})
+	r.NoError(err)
+
+	// The errors are reported at the lines they come from.
+	write("shared/bad.conf", "\nunknownoption=1\n")
+	_, err = parse(write("bad.conf", "rpcuser=a\nincludeconf=shared/bad.conf\n"),
+		config{})
+	r.Error(err)
+	r.Contains(err.Error(), filepath.Join("shared", "bad.conf")+":2: unknown option")
+
+	// The included files must exist and can't include themselves.
+	_, err = parse(write("missing.conf", "includeconf=nofile.conf\n"), config{})
+	r.Error(err)
+	_, err = parse(write("loop.conf", "includeconf=loop.conf\n"), config{})
+	r.ErrorContains(err, "includes itself")
+
+	// A missing config file is reported as is.
+	_, err = parse(filepath.Join(dir, "nofile.conf"), config{})
+	r.True(os.IsNotExist(err))
+}
+
+func TestParseEnvConfig(t *testing.T) {
+
+	r := require.New(t)
+
+	parse := func(cfg *config, env map[string]string) error {
+		parser := newConfigParser(cfg, &serviceOptions{}, flags.Default)
+		return parseEnvConfig(parser, func(key string) (string, bool) {
+			value, ok := env[key]
+			return value, ok
+		})
+	}
+
+	cfg := config{RPCUser: "file", MaxPeers: defaultMaxPeers,
+		AddPeers: []string{"10.0.0.1"}}
+	err := parse(&cfg, map[string]string{
+		"LBCD_RPCUSER": "env",
+		"LBCD_RPCPASS": `"quoted" pass`,
+		"LBCD_TXINDEX": "1",
+		"LBCD_ADDPEER": "10.0.0.2, 10.0.0.3",
+		"LBCD_UNKNOWN": "1",
+		"RPCUSER":      "other",
+	})
+	r.NoError(err)
+	r.Equal("env", cfg.RPCUser)
+	r.Equal(`"quoted" pass`, cfg.RPCPass)
+	r.True(cfg.TxIndex)
+	r.Equal(defaultMaxPeers, cfg.MaxPeers)
+	r.Equal([]string{"10.0.0.2", "10.0.0.3"}, cfg.AddPeers)
+
+	err = parse(&cfg, map[string]string{"LBCD_TXINDEX": "0"})
+	r.NoError(err)
+	r.False(cfg.TxIndex)
+
+	err = parse(&config{}, map[string]string{"LBCD_MAXPEERS": "many"})
+	r.ErrorContains(err, "environment variable LBCD_MAXPEERS")
+}
+
+func TestConfigPrecedence(t *testing.T) {
+
+	r := require.New(t)
+
+	dir := t.TempDir()
+	r.NoError(os.WriteFile(filepath.Join(dir, "inc.conf"),
+		[]byte("rpclimituser=inc\n"), 0600))
+	path := filepath.Join(dir, "lbcd.conf")
+	r.NoError(os.WriteFile(path, []byte(`
+rpcuser=top
+rpclimituser=top
+txindex=1
+addpeer=10.0.0.1
+
+[Application Options]
+rpclimituser=app
+includeconf=inc.conf
+rpcpass=app
+maxpeers=10
+addpeer=10.0.0.2
+`), 0600))
+
+	// The options are applied in the order of loadConfig: the config file,
+	// then the environment variables and then the command line.
+	load := func(env map[string]string, args ...string) *config {
+		cfg := config{MaxPeers: defaultMaxPeers}
+		parser := newConfigParser(&cfg, &serviceOptions{}, flags.Default)
+		r.NoError(parseConfigFile(parser, &cfg, &config{}, path))
+		r.NoError(parseEnvConfig(parser, func(key string) (string, bool) {
+			value, ok := env[key]
+			return value, ok
+		}))
+		_, err := parser.ParseArgs(args)
+		r.NoError(err)
+		return &cfg
+	}
+
+	// The options in the application options section are parsed with the
+	// options outside of any section, including those of the included files,
+	// in the order they are written.
+	cfg := load(nil)
+	r.Equal("top", cfg.RPCUser)
+	r.Equal("inc", cfg.RPCLimitUser)
+	r.Equal("app", cfg.RPCPass)
+	r.True(cfg.TxIndex)
+	r.Equal(10, cfg.MaxPeers)
+	r.Equal([]string{"10.0.0.1", "10.0.0.2"}, cfg.AddPeers)
+
+	// The environment variables override the options of the config file,
+	// whether they are in the application options section or not.
+	env := map[string]string{
+		"LBCD_RPCUSER":  "env",
+		"LBCD_RPCPASS":  "env",
+		"LBCD_MAXPEERS": "20",
+		"LBCD_ADDPEER":  "10.0.0.3",
+	}
+	cfg = load(env)
+	r.Equal("env", cfg.RPCUser)
+	r.Equal("inc", cfg.RPCLimitUser)
+	r.Equal("env", cfg.RPCPass)
+	r.True(cfg.TxIndex)
+	r.Equal(20, cfg.MaxPeers)
+	r.Equal([]string{"10.0.0.3"}, cfg.AddPeers)
+
+	// The command line overrides both.
+	cfg = load(env, "--rpcuser=flag", "--rpclimituser=flag", "--maxpeers=30",
+		"--addpeer=10.0.0.4")
+	r.Equal("flag", cfg.RPCUser)
+	r.Equal("flag", cfg.RPCLimitUser)
+	r.Equal("env", cfg.RPCPass)
+	r.Equal(30, cfg.MaxPeers)
+	r.Equal([]string{"10.0.0.4"}, cfg.AddPeers)
+}
@@ -22,6 +22,11 @@ var (
 	//ErrDialNil is used to indicate that Dial cannot be nil in the configuration.
 	ErrDialNil = errors.New("Config: Dial cannot be nil")
 
+	// ErrGetNetworkAddressNil is used to indicate that GetNetworkAddress
+	// cannot be nil in the configuration when TargetNetworkOutbound is set.
+	ErrGetNetworkAddressNil = errors.New("Config: GetNetworkAddress cannot " +
+		"be nil with TargetNetworkOutbound")
+
 	// maxRetryDuration is the max duration of time retrying of a persistent
 	// connection is allowed to grow to.  This is necessary since the retry
 	// logic uses a backoff mechanism which increases the interval base times
@@ -53,13 +58,21 @@ const (
 )
 
 // ConnReq is the connection request to a network address. If permanent, the
-// connection will be retried on disconnection.
+// connection will be retried on disconnection.  If block-relay-only, the
+// connection is one of those maintained in addition to the TargetOutbound
+// ones, which the caller is expected to use for relaying blocks only.
+//
+// Network is the network the address of a new connection request was asked
+// for by the connection manager to meet the TargetNetworkOutbound ones, or
+// NetworkUnknown when it was asked for any address.
 type ConnReq struct {
 	// The following variables must only be used atomically.
 	id uint64
 
-	Addr      net.Addr
-	Permanent bool
+	Addr           net.Addr
+	Permanent      bool
+	BlockRelayOnly bool
+	Network        Network
 
 	conn       net.Conn
 	state      ConnState
@@ -124,6 +137,21 @@ type Config struct {
 	// maintain. Defaults to 8.
 	TargetOutbound uint32
 
+	// TargetNetworkOutbound is the number of the TargetOutbound
+	// connections to maintain to each network, so the connections to the
+	// networks with fewer known addresses, such as the anonymity ones,
+	// aren't crowded out by the others.  The addresses of these
+	// connections are asked for with GetNetworkAddress, while the rest of
+	// the TargetOutbound ones are made to any network.  TargetOutbound is
+	// raised to the sum of these targets when lower.
+	TargetNetworkOutbound map[Network]uint32
+
+	// TargetBlockRelayOnly is the number of block-relay-only outbound
+	// network connections to maintain in addition to the TargetOutbound
+	// ones.  Their connection requests have BlockRelayOnly set.  Defaults
+	// to 0.
+	TargetBlockRelayOnly uint32
+
 	// RetryDuration is the duration to wait before retrying connection
 	// requests. Defaults to 5s.
 	RetryDuration time.Duration
@@ -140,6 +168,11 @@ type Config struct {
 	// to.  If nil, no new connections will be made automatically.
 	GetNewAddress func() (net.Addr, error)
 
+	// GetNetworkAddress is a way to get an address on a network to make a
+	// network connection to.  It cannot be nil if TargetNetworkOutbound is
+	// set.
+	GetNetworkAddress func(Network) (net.Addr, error)
+
 	// Dial connects to the address on the named network. It cannot be nil.
 	Dial func(net.Addr) (net.Conn, error)
 }
@@ -211,10 +244,10 @@ func (cm *ConnManager) handleFailedConn(c *ConnReq) {
 				"-- retrying connection in: %v", maxFailedAttempts,
 				cm.cfg.RetryDuration)
 			time.AfterFunc(cm.cfg.RetryDuration, func() {
-				cm.NewConnReq()
+				cm.newConnReq(c.BlockRelayOnly)
 			})
 		} else {
-			go cm.NewConnReq()
+			go cm.newConnReq(c.BlockRelayOnly)
 		}
 	}
 }
@@ -234,6 +267,11 @@ func (cm *ConnManager) connHandler() {
 
 		// conns represents the set of all actively connected peers.
 		conns = make(map[uint64]*ConnReq, cm.cfg.TargetOutbound)
+
+		// networks holds the networks of the conn requests made to
+		// meet the target of a network that have yet to succeed or
+		// fail.
+		networks = make(map[uint64]Network)
 	)
 
 out:
@@ -244,12 +282,26 @@ out:
 
 			case registerPending:
 				connReq := msg.c
+
+				// The new connection requests are made to the
+				// networks short of their target first.
+				if connReq.Addr == nil && !connReq.Permanent &&
+					!connReq.BlockRelayOnly {
+
+					connReq.Network = cm.neededNetwork(networks,
+						conns)
+					if connReq.Network != NetworkUnknown {
+						networks[connReq.id] = connReq.Network
+					}
+				}
+
 				connReq.updateState(ConnPending)
 				pending[msg.c.id] = connReq
 				close(msg.done)
 
 			case handleConnected:
 				connReq := msg.c
+				delete(networks, connReq.id)
 
 				if _, ok := pending[connReq.id]; !ok {
 					if msg.conn != nil {
@@ -274,6 +326,7 @@ out:
 				}
 
 			case handleDisconnected:
+				delete(networks, msg.id)
 				connReq, ok := conns[msg.id]
 				if !ok {
 					connReq, ok = pending[msg.id]
@@ -322,8 +375,8 @@ out:
 				// re added to the pending map, so that
 				// subsequent processing of connections and
 				// failures do not ignore the request.
-				if uint32(len(conns)) < cm.cfg.TargetOutbound ||
-					connReq.Permanent {
+				if connReq.Permanent ||
+					cm.needsConn(conns, connReq.BlockRelayOnly) {
 
 					connReq.updateState(ConnPending)
 					log.Debugf("Reconnecting to %v",
@@ -334,6 +387,7 @@ out:
 
 			case handleFailed:
 				connReq := msg.c
+				delete(networks, connReq.id)
 
 				if _, ok := pending[connReq.id]; !ok {
 					log.Debugf("Ignoring connection for "+
@@ -356,9 +410,68 @@ out:
 	log.Trace("Connection handler done")
 }
 
+// needsConn returns whether there are fewer connections of the class than
+// its target.
+func (cm *ConnManager) needsConn(conns map[uint64]*ConnReq, blockRelayOnly bool) bool {
+	target := cm.cfg.TargetOutbound
+	if blockRelayOnly {
+		target = cm.cfg.TargetBlockRelayOnly
+	}
+
+	var count uint32
+	for _, connReq := range conns {
+		if connReq.BlockRelayOnly == blockRelayOnly {
+			count++
+		}
+	}
+	return count < target
+}
+
+// neededNetwork returns a network with fewer full-relay connections than its
+// target, or NetworkUnknown when there is none.  The established connections
+// count towards the network of their address, and the pending requests made
+// to meet the target of a network towards that network, since their address
+// may still be being set.
+func (cm *ConnManager) neededNetwork(networks map[uint64]Network,
+	conns map[uint64]*ConnReq) Network {
+
+	if len(cm.cfg.TargetNetworkOutbound) == 0 {
+		return NetworkUnknown
+	}
+
+	var counts [numNetworks]uint32
+	for _, connReq := range conns {
+		if !connReq.BlockRelayOnly {
+			counts[AddrNetwork(connReq.Addr)]++
+		}
+	}
+	for _, network := range networks {
+		counts[network]++
+	}
+
+	for network := NetworkIPv4; network < numNetworks; network++ {
+		if counts[network] < cm.cfg.TargetNetworkOutbound[network] {
+			return network
+		}
+	}
+	return NetworkUnknown
+}
+
 // NewConnReq creates a new connection request and connects to the
 // corresponding address.
 func (cm *ConnManager) NewConnReq() {
+	cm.newConnReq(false)
+}
+
+// NewBlockRelayOnlyConnReq creates a new block-relay-only connection request
+// and connects to the corresponding address.
+func (cm *ConnManager) NewBlockRelayOnlyConnReq() {
+	cm.newConnReq(true)
+}
+
+// newConnReq creates a new connection request of the class and connects to
+// the corresponding address.
+func (cm *ConnManager) newConnReq(blockRelayOnly bool) {
 	if atomic.LoadInt32(&cm.stop) != 0 {
 		return
 	}
@@ -366,7 +479,7 @@ func (cm *ConnManager) NewConnReq() {
 		return
 	}
 
-	c := &ConnReq{}
+	c := &ConnReq{BlockRelayOnly: blockRelayOnly}
 	atomic.StoreUint64(&c.id, atomic.AddUint64(&cm.connReqCount, 1))
 
 	// Submit a request of a pending connection attempt to the connection
@@ -388,7 +501,13 @@ func (cm *ConnManager) NewConnReq() {
 		return
 	}
 
-	addr, err := cm.cfg.GetNewAddress()
+	var addr net.Addr
+	var err error
+	if c.Network != NetworkUnknown {
+		addr, err = cm.cfg.GetNetworkAddress(c.Network)
+	} else {
+		addr, err = cm.cfg.GetNewAddress()
+	}
 	if err != nil {
 		select {
 		case cm.requests <- handleFailed{c, err}:
@@ -529,6 +648,9 @@ func (cm *ConnManager) Start() {
 	for i := atomic.LoadUint64(&cm.connReqCount); i < uint64(cm.cfg.TargetOutbound); i++ {
 		go cm.NewConnReq()
 	}
+	for i := uint32(0); i < cm.cfg.TargetBlockRelayOnly; i++ {
+		go cm.newConnReq(true)
+	}
 }
 
 // Wait blocks until the connection manager halts gracefully.
@@ -573,5 +695,23 @@ func New(cfg *Config) (*ConnManager, error) {
 		requests: make(chan interface{}),
 		quit:     make(chan struct{}),
 	}
+	if len(cfg.TargetNetworkOutbound) > 0 {
+		if cfg.GetNetworkAddress == nil {
+			return nil, ErrGetNetworkAddressNil
+		}
+		var sum uint32
+		cm.cfg.TargetNetworkOutbound = make(map[Network]uint32)
+		for network, target := range cfg.TargetNetworkOutbound {
+			if network == NetworkUnknown || network >= numNetworks {
+				return nil, fmt.Errorf("Config: invalid network "+
+					"%v in TargetNetworkOutbound", network)
+			}
+			cm.cfg.TargetNetworkOutbound[network] = target
+			sum += target
+		}
+		if cm.cfg.TargetOutbound < sum {
+			cm.cfg.TargetOutbound = sum
+		}
+	}
 	return &cm, nil
 }
@@ -75,6 +75,14 @@ func TestNewConfig(t *testing.T) {
 	if err == nil {
 		t.Fatalf("New expected error: 'Dial can't be nil', got nil")
 	}
+	_, err = New(&Config{
+		Dial:                  mockDialer,
+		TargetNetworkOutbound: map[Network]uint32{NetworkTor: 1},
+	})
+	if err != ErrGetNetworkAddressNil {
+		t.Fatalf("New expected error: %v, got %v",
+			ErrGetNetworkAddressNil, err)
+	}
 	_, err = New(&Config{
 		Dial: mockDialer,
 	})
@@ -216,6 +224,147 @@ func TestTargetOutbound(t *testing.T) {
 	cmgr.Stop()
 }
 
+// TestTargetBlockRelayOnly tests that the block-relay-only connections are
+// maintained in addition to the target outbound ones.
+//
+// We wait for the connections of both classes, disconnect a block-relay-only
+// one and wait for its replacement of the same class.
+func TestTargetBlockRelayOnly(t *testing.T) {
+	targetOutbound := uint32(3)
+	targetBlockRelayOnly := uint32(2)
+	connected := make(chan *ConnReq)
+	cmgr, err := New(&Config{
+		TargetOutbound:       targetOutbound,
+		TargetBlockRelayOnly: targetBlockRelayOnly,
+		Dial:                 mockDialer,
+		GetNewAddress: func() (net.Addr, error) {
+			return &net.TCPAddr{
+				IP:   net.ParseIP("127.0.0.1"),
+				Port: 18555,
+			}, nil
+		},
+		OnConnection: func(c *ConnReq, conn net.Conn) {
+			connected <- c
+		},
+	})
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	cmgr.Start()
+
+	var blockRelayOnly []*ConnReq
+	for i := uint32(0); i < targetOutbound+targetBlockRelayOnly; i++ {
+		c := <-connected
+		if c.BlockRelayOnly {
+			blockRelayOnly = append(blockRelayOnly, c)
+		}
+	}
+	if uint32(len(blockRelayOnly)) != targetBlockRelayOnly {
+		t.Fatalf("block-relay-only connections: got %d, want %d",
+			len(blockRelayOnly), targetBlockRelayOnly)
+	}
+
+	select {
+	case c := <-connected:
+		t.Fatalf("target outbound: got unexpected connection - %v", c.Addr)
+	case <-time.After(time.Millisecond):
+		break
+	}
+
+	cmgr.Disconnect(blockRelayOnly[0].ID())
+	select {
+	case c := <-connected:
+		if !c.BlockRelayOnly {
+			t.Fatalf("replacement connection %v is not "+
+				"block-relay-only", c)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("block-relay-only connection was not replaced")
+	}
+
+	cmgr.Stop()
+}
+
+// TestTargetNetworkOutbound tests that the target outbound connections to
+// each network are maintained.
+//
+// We wait for the target outbound connections, check their networks,
+// disconnect an onion one and wait for its replacement on the same network.
+func TestTargetNetworkOutbound(t *testing.T) {
+	targetOutbound := uint32(4)
+	targets := map[Network]uint32{NetworkTor: 2, NetworkIPv6: 1}
+	connected := make(chan *ConnReq)
+	cmgr, err := New(&Config{
+		TargetOutbound:        targetOutbound,
+		TargetNetworkOutbound: targets,
+		Dial:                  mockDialer,
+		GetNewAddress: func() (net.Addr, error) {
+			return &net.TCPAddr{
+				IP:   net.ParseIP("127.0.0.1"),
+				Port: 18555,
+			}, nil
+		},
+		GetNetworkAddress: func(network Network) (net.Addr, error) {
+			switch network {
+			case NetworkTor:
+				return &mockAddr{"onion", "aaaaaaaaaaaaaaaa.onion:9246"}, nil
+			case NetworkIPv6:
+				return &net.TCPAddr{IP: net.ParseIP("::1"), Port: 18555}, nil
+			}
+			return nil, fmt.Errorf("no %v address", network)
+		},
+		OnConnection: func(c *ConnReq, conn net.Conn) {
+			connected <- c
+		},
+	})
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	cmgr.Start()
+
+	counts := make(map[Network]uint32)
+	var onion *ConnReq
+	for i := uint32(0); i < targetOutbound; i++ {
+		c := <-connected
+		network := AddrNetwork(c.Addr)
+		if c.Network != NetworkUnknown && c.Network != network {
+			t.Fatalf("connection %v requested on network %v", c,
+				c.Network)
+		}
+		if network == NetworkTor {
+			onion = c
+		}
+		counts[network]++
+	}
+	want := map[Network]uint32{NetworkTor: 2, NetworkIPv6: 1, NetworkIPv4: 1}
+	for network, count := range want {
+		if counts[network] != count {
+			t.Fatalf("%v connections: got %d, want %d", network,
+				counts[network], count)
+		}
+	}
+
+	select {
+	case c := <-connected:
+		t.Fatalf("target outbound: got unexpected connection - %v", c.Addr)
+	case <-time.After(time.Millisecond):
+		break
+	}
+
+	cmgr.Disconnect(onion.ID())
+	select {
+	case c := <-connected:
+		if c.Network != NetworkTor {
+			t.Fatalf("replacement connection %v is not requested "+
+				"on network onion", c)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("onion connection was not replaced")
+	}
+
+	cmgr.Stop()
+}
+
 // TestRetryPermanent tests that permanent connection requests are retried.
 //
 // We make a permanent connection request using Connect, disconnect it using
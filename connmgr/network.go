@@ -0,0 +1,85 @@
+package connmgr
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Network is a class of networks reached by the outbound connections, for
+// which the connection manager maintains a number of connections given by
+// Config.TargetNetworkOutbound.
+type Network uint8
+
+// The networks are IPv4, IPv6, Tor onion services and I2P.  NetworkUnknown is
+// the network of the addresses of none of them, such as unresolved host names.
+const (
+	NetworkUnknown Network = iota
+	NetworkIPv4
+	NetworkIPv6
+	NetworkTor
+	NetworkI2P
+
+	numNetworks
+)
+
+// networkNames are the names of the networks, by network.
+var networkNames = [numNetworks]string{
+	NetworkUnknown: "unknown",
+	NetworkIPv4:    "ipv4",
+	NetworkIPv6:    "ipv6",
+	NetworkTor:     "onion",
+	NetworkI2P:     "i2p",
+}
+
+// onionCatNet is the IPv6 range in which Tor onion services are encoded.
+var onionCatNet = net.IPNet{
+	IP:   net.ParseIP("fd87:d87e:eb43::"),
+	Mask: net.CIDRMask(48, 128),
+}
+
+// String returns the name of the network: ipv4, ipv6, onion, i2p or unknown.
+func (n Network) String() string {
+	if n >= numNetworks {
+		return fmt.Sprintf("Unknown Network (%d)", uint8(n))
+	}
+	return networkNames[n]
+}
+
+// ParseNetwork returns the network of a name returned by String, other than
+// unknown.
+func ParseNetwork(name string) (Network, error) {
+	for n := NetworkIPv4; n < numNetworks; n++ {
+		if strings.EqualFold(name, networkNames[n]) {
+			return n, nil
+		}
+	}
+	return NetworkUnknown, fmt.Errorf("unknown network %q", name)
+}
+
+// AddrNetwork returns the network of an address, given as an IP address or a
+// .onion or .i2p host name, with or without a port.
+func AddrNetwork(addr net.Addr) Network {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		host = addr.String()
+	}
+
+	switch host = strings.ToLower(host); {
+	case strings.HasSuffix(host, ".onion"):
+		return NetworkTor
+	case strings.HasSuffix(host, ".i2p"):
+		return NetworkI2P
+	}
+
+	ip := net.ParseIP(host)
+	switch {
+	case ip == nil:
+		return NetworkUnknown
+	case ip.To4() != nil:
+		return NetworkIPv4
+	case onionCatNet.Contains(ip):
+		return NetworkTor
+	}
+	return NetworkIPv6
+}
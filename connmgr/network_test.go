@@ -0,0 +1,41 @@
+package connmgr
+
+import (
+	"net"
+	"testing"
+)
+
+// TestAddrNetwork tests the networks of the addresses.
+func TestAddrNetwork(t *testing.T) {
+	tests := []struct {
+		addr net.Addr
+		want Network
+	}{
+		{&net.TCPAddr{IP: net.ParseIP("1.2.3.4"), Port: 9246}, NetworkIPv4},
+		{&net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 9246}, NetworkIPv6},
+		{&net.TCPAddr{IP: net.ParseIP("fd87:d87e:eb43::1"), Port: 9246}, NetworkTor},
+		{&mockAddr{"onion", "aaaaaaaaaaaaaaaa.onion:9246"}, NetworkTor},
+		{&mockAddr{"tcp", "AAAA.b32.I2P:0"}, NetworkI2P},
+		{&mockAddr{"tcp", "seed.example.com:9246"}, NetworkUnknown},
+	}
+
+	for _, test := range tests {
+		if got := AddrNetwork(test.addr); got != test.want {
+			t.Errorf("AddrNetwork(%v): got %v, want %v", test.addr,
+				got, test.want)
+			continue
+		}
+		if test.want == NetworkUnknown {
+			continue
+		}
+		network, err := ParseNetwork(test.want.String())
+		if err != nil || network != test.want {
+			t.Errorf("ParseNetwork(%q): got %v, %v", test.want, network,
+				err)
+		}
+	}
+
+	if _, err := ParseNetwork("unknown"); err == nil {
+		t.Errorf("ParseNetwork(\"unknown\"): expected error")
+	}
+}
@@ -0,0 +1,160 @@
+package ffldb
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/lbryio/lbcd/database"
+	"github.com/lbryio/lbcd/database/internal/treap"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// backupBatchSize is the max size in bytes of the batches of metadata written
+// to a backup.
+const backupBatchSize = 4 * 1024 * 1024
+
+// copyBlockFile copies the first size bytes of a block file, syncing the copy.
+func copyBlockFile(from, to string, size int64) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(to, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.CopyN(dst, src, size); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Sync(); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// backupMetadata writes the metadata of the passed transaction to a new
+// metadata database of the same backend at path.
+func (db *db) backupMetadata(tx *transaction, path string) error {
+	mdb, err := db.backend.open(path, true)
+	if err != nil {
+		return err
+	}
+	defer mdb.Close()
+
+	iter := tx.snapshot.NewIterator(&util.Range{})
+	defer iter.Release()
+	batch := treap.NewMutable()
+	noDeletes := treap.NewMutable()
+	for ok := iter.First(); ok; ok = iter.Next() {
+		batch.Put(copySlice(iter.Key()), copySlice(iter.Value()))
+		if batch.Size() < backupBatchSize {
+			continue
+		}
+		if err := mdb.Write(batch, noDeletes); err != nil {
+			return err
+		}
+		batch.Reset()
+	}
+	if err := iter.Error(); err != nil {
+		return convertErr("failed to read metadata", err)
+	}
+
+	if err := mdb.Write(batch, noDeletes); err != nil {
+		return err
+	}
+	if err := mdb.Close(); err != nil {
+		return convertErr("failed to close metadata backup", err)
+	}
+	return nil
+}
+
+// Backup writes a copy of the database to dir, which must not exist, while the
+// database keeps being used.  The copy is consistent with a snapshot of the
+// database taken when the backup starts: its metadata is copied from the
+// snapshot, along with the block files up to the write cursor of the snapshot,
+// linking the full block files when they are on the same file system.  The copy
+// holds the metadata and the block files in the same directory, and is opened
+// as is.
+func (db *db) Backup(dir string) error {
+	if _, err := os.Stat(dir); err == nil {
+		str := fmt.Sprintf("backup directory %s already exists", dir)
+		return makeDbErr(database.ErrDriverSpecific, str, nil)
+	}
+
+	// The backup is written to a temporary directory first, so that it is
+	// either complete or missing.
+	tmpDir := dir + ".tmp"
+	if err := os.RemoveAll(tmpDir); err != nil {
+		str := fmt.Sprintf("failed to remove %s", tmpDir)
+		return makeDbErr(database.ErrDriverSpecific, str, err)
+	}
+	if err := os.MkdirAll(tmpDir, 0700); err != nil {
+		str := fmt.Sprintf("failed to create %s", tmpDir)
+		return makeDbErr(database.ErrDriverSpecific, str, err)
+	}
+
+	err := db.View(func(dbTx database.Tx) error {
+		tx := dbTx.(*transaction)
+		writeRow := tx.snapshot.Get(bucketizedKey(metadataBucketID,
+			writeLocKeyName))
+		curFileNum, curOffset, err := deserializeWriteRow(writeRow)
+		if err != nil {
+			return err
+		}
+
+		err = db.backupMetadata(tx, filepath.Join(tmpDir, metadataDbName))
+		if err != nil {
+			return err
+		}
+
+		// The block files before the one of the write cursor are full,
+		// and the data following the cursor isn't part of the
+		// snapshot.
+		for fileNum := uint32(0); fileNum <= curFileNum; fileNum++ {
+			from := blockFilePath(db.store.basePath, fileNum)
+			to := blockFilePath(tmpDir, fileNum)
+			size := int64(curOffset)
+			if fileNum < curFileNum {
+				// The full block files don't change, so they
+				// are linked when on the same file system.
+				if os.Link(from, to) == nil {
+					continue
+				}
+
+				fi, err := os.Stat(from)
+				if err != nil {
+					str := fmt.Sprintf("failed to stat block "+
+						"file %d", fileNum)
+					return makeDbErr(database.ErrDriverSpecific,
+						str, err)
+				}
+				size = fi.Size()
+			}
+			if size == 0 {
+				continue
+			}
+			err := copyBlockFile(from, to, size)
+			if err != nil {
+				str := fmt.Sprintf("failed to copy block file %d",
+					fileNum)
+				return makeDbErr(database.ErrDriverSpecific, str, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return err
+	}
+
+	if err := os.Rename(tmpDir, dir); err != nil {
+		str := fmt.Sprintf("failed to rename %s", tmpDir)
+		return makeDbErr(database.ErrDriverSpecific, str, err)
+	}
+	return nil
+}
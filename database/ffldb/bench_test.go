@@ -5,11 +5,13 @@
 package ffldb
 
 import (
+	"encoding/binary"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
 	"github.com/lbryio/lbcd/database"
 	btcutil "github.com/lbryio/lbcutil"
 )
@@ -95,3 +97,93 @@ func BenchmarkBlock(b *testing.B) {
 	// Don't benchmark teardown.
 	b.StopTimer()
 }
+
+// BenchmarkIBD benchmarks how long it takes each metadata backend to store the
+// test blocks along with a churn of metadata like that of the UTXO set during
+// the initial block download: the outputs created by a block are put and those
+// spent are deleted.  The database cache is kept small, so that it is flushed
+// to the metadata database many times.
+func BenchmarkIBD(b *testing.B) {
+	blocks, err := loadBlocks(b, blockDataFile, blockDataNet)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, dbType := range []string{dbType, pebbleDbType} {
+		dbType := dbType
+		b.Run(dbType, func(b *testing.B) {
+			benchmarkIBD(b, dbType, blocks)
+		})
+	}
+}
+
+// ibdOutputsPerBlock and ibdSpentPerBlock are the numbers of the metadata keys
+// put and deleted for each block by the IBD benchmark.
+const (
+	ibdOutputsPerBlock = 2000
+	ibdSpentPerBlock   = 1800
+)
+
+// benchmarkIBD runs the IBD benchmark against new databases of the passed type.
+func benchmarkIBD(b *testing.B, dbType string, blocks []*btcutil.Block) {
+	bucketName := []byte("ibdutxos")
+	value := make([]byte, 40)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		dbPath := filepath.Join(os.TempDir(), dbType+"-benchibd")
+		_ = os.RemoveAll(dbPath)
+		idb, err := database.Create(dbType, dbPath, blockDataNet)
+		if err != nil {
+			b.Fatal(err)
+		}
+		idb.(*db).cache.maxSize = 1024 * 1024
+		b.StartTimer()
+
+		var created, spent uint32
+		for _, block := range blocks {
+			err := idb.Update(func(tx database.Tx) error {
+				if err := tx.StoreBlock(block); err != nil {
+					return err
+				}
+				bucket, err := tx.Metadata().CreateBucketIfNotExists(
+					bucketName)
+				if err != nil {
+					return err
+				}
+
+				// The keys are hashed like the outpoints of the
+				// UTXO set, so the writes are spread over the
+				// key space.
+				for j := 0; j < ibdOutputsPerBlock; j++ {
+					key := chainhash.HashB(binary.BigEndian.
+						AppendUint32(nil, created))
+					if err := bucket.Put(key, value); err != nil {
+						return err
+					}
+					created++
+				}
+				for j := 0; j < ibdSpentPerBlock; j++ {
+					key := chainhash.HashB(binary.BigEndian.
+						AppendUint32(nil, spent))
+					if err := bucket.Delete(key); err != nil {
+						return err
+					}
+					spent++
+				}
+				return nil
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		// Closing flushes the last changes to the metadata database.
+		if err := idb.Close(); err != nil {
+			b.Fatal(err)
+		}
+		b.StopTimer()
+		_ = os.RemoveAll(dbPath)
+		b.StartTimer()
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 
 	"github.com/lbryio/lbcd/chaincfg/chainhash"
 	"github.com/lbryio/lbcd/database"
@@ -104,11 +105,17 @@ type writeCursor struct {
 // blockStore houses information used to handle reading and writing blocks (and
 // part of blocks) into flat files with support for multiple concurrent readers.
 type blockStore struct {
+	// bytesRead and bytesWritten count the bytes read from and written to
+	// the flat files since the store was opened.  They are updated
+	// atomically, and are first in the struct to be 64-bit aligned.
+	bytesRead    uint64
+	bytesWritten uint64
+
 	// network is the specific network to use in the flat files for each
 	// block.
 	network wire.BitcoinNet
 
-	// basePath is the base path used for the flat block files and metadata.
+	// basePath is the base path used for the flat block files.
 	basePath string
 
 	// maxBlockFileSize is the maximum size for each file used to store
@@ -116,6 +123,10 @@ type blockStore struct {
 	// override the value.
 	maxBlockFileSize uint32
 
+	// mmapFiles is whether the read-only block files are read from memory
+	// mappings of them.
+	mmapFiles bool
+
 	// The following fields are related to the flat files which hold the
 	// actual blocks.   The number of open files is limited by maxOpenFiles.
 	//
@@ -258,6 +269,15 @@ func (s *blockStore) openFile(fileNum uint32) (*lockableFile, error) {
 			err)
 	}
 	blockFile := &lockableFile{file: file}
+	if s.mmapFiles {
+		mapped, err := newMappedFile(file)
+		if err != nil {
+			log.Warnf("Failed to map block file %d, reading it "+
+				"without mapping: %v", fileNum, err)
+		} else {
+			blockFile.file = mapped
+		}
+	}
 
 	// Close the least recently used file if the file exceeds the max
 	// allowed open files.  This is not done until after the file open in
@@ -379,6 +399,7 @@ func (s *blockStore) writeData(data []byte, fieldName string) error {
 	wc := s.writeCursor
 	n, err := wc.curFile.file.WriteAt(data, int64(wc.curOffset))
 	wc.curOffset += uint32(n)
+	atomic.AddUint64(&s.bytesWritten, uint64(n))
 	if err != nil {
 		str := fmt.Sprintf("failed to write %s to file %d at "+
 			"offset %d: %v", fieldName, wc.curFileNum,
@@ -517,6 +538,7 @@ func (s *blockStore) readBlock(hash *chainhash.Hash, loc blockLocation) ([]byte,
 	serializedData := make([]byte, loc.blockLen)
 	n, err := blockFile.file.ReadAt(serializedData, int64(loc.fileOffset))
 	blockFile.RUnlock()
+	atomic.AddUint64(&s.bytesRead, uint64(n))
 	if err != nil {
 		str := fmt.Sprintf("failed to read block %s from file %d, "+
 			"offset %d: %v", hash, loc.blockFileNum, loc.fileOffset,
@@ -575,8 +597,9 @@ func (s *blockStore) readBlockRegion(loc blockLocation, offset, numBytes uint32)
 	// for block length.  Thus, add 8 bytes to adjust.
 	readOffset := loc.fileOffset + 8 + offset
 	serializedData := make([]byte, numBytes)
-	_, err = blockFile.file.ReadAt(serializedData, int64(readOffset))
+	n, err := blockFile.file.ReadAt(serializedData, int64(readOffset))
 	blockFile.RUnlock()
+	atomic.AddUint64(&s.bytesRead, uint64(n))
 	if err != nil {
 		str := fmt.Sprintf("failed to read region from block file %d, "+
 			"offset %d, len %d: %v", loc.blockFileNum, readOffset,
@@ -712,6 +735,79 @@ func (s *blockStore) handleRollback(oldBlockFileNum, oldBlockOffset uint32) {
 	}
 }
 
+// moveBlockFile moves a block file to another directory, copying it when it
+// can't be renamed there, such as when the directory is on another volume.
+// The copy is synced before it replaces the destination, so it is complete
+// whenever the destination exists.
+func moveBlockFile(from, to string) error {
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	tmp := to + ".tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Sync(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, to); err != nil {
+		return err
+	}
+	src.Close()
+	return os.Remove(from)
+}
+
+// migrateBlockFiles moves the flat block files found in fromPath to toPath,
+// which happens once the block files are stored apart from the metadata.  A
+// file found in both paths was moved already unless their sizes differ, so the
+// migration resumes where it was interrupted.
+func migrateBlockFiles(fromPath, toPath string) error {
+	paths, err := filepath.Glob(filepath.Join(fromPath, "*.fdb"))
+	if err != nil || len(paths) == 0 {
+		return err
+	}
+
+	log.Infof("Moving %d block files from %s to %s", len(paths), fromPath,
+		toPath)
+	for _, from := range paths {
+		to := filepath.Join(toPath, filepath.Base(from))
+		fromInfo, err := os.Stat(from)
+		if err != nil {
+			return err
+		}
+		toInfo, err := os.Stat(to)
+		switch {
+		case err == nil && toInfo.Size() == fromInfo.Size():
+			err = os.Remove(from)
+		case err == nil:
+			err = fmt.Errorf("block file %s differs from %s", from, to)
+		case os.IsNotExist(err):
+			err = moveBlockFile(from, to)
+		}
+		if err != nil {
+			str := fmt.Sprintf("failed to move block file %s to %s",
+				from, toPath)
+			return makeDbErr(database.ErrDriverSpecific, str, err)
+		}
+	}
+	return nil
+}
+
 // scanBlockFiles searches the database directory for all flat block files to
 // find the end of the most recent file.  This position is considered the
 // current write cursor which is also stored in the metadata.  Thus, it is used
@@ -0,0 +1,112 @@
+package ffldb
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sync"
+)
+
+// readAheadSize is the size of the region of a mapped block file the OS is
+// hinted to read ahead of a sequential scan of the file.
+const readAheadSize = 8 * 1024 * 1024 // 8 MiB
+
+// mappedFile is a read-only block file whose reads are copied from a memory
+// mapping of the file instead of issuing a pread syscall each.  When the reads
+// move forward through the file, as when a scan reads each block in turn, the
+// OS is hinted to read the file ahead of them.
+//
+// The reads beyond the mapping, such as those of data appended to the file
+// after it was mapped, fall back to the file.
+type mappedFile struct {
+	*os.File
+	data []byte
+
+	// hintMtx protects the tracking of the sequential reads: the end of the
+	// last read, and the end of the region hinted to be read ahead.
+	hintMtx  sync.Mutex
+	lastEnd  int64
+	hintEnd  int64
+	pageSize int64
+}
+
+// Enforce mappedFile implements the filer interface.
+var _ filer = (*mappedFile)(nil)
+
+// newMappedFile maps the read-only block file into memory.
+func newMappedFile(file *os.File) (*mappedFile, error) {
+	data, err := mapFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return &mappedFile{
+		File:     file,
+		data:     data,
+		pageSize: int64(os.Getpagesize()),
+	}, nil
+}
+
+// ReadAt reads len(b) bytes from the file starting at byte offset off.
+//
+// This is part of the filer interface.
+func (f *mappedFile) ReadAt(b []byte, off int64) (int, error) {
+	if off < 0 || off+int64(len(b)) > int64(len(f.data)) {
+		return f.File.ReadAt(b, off)
+	}
+	f.adviseReadAhead(off, int64(len(b)))
+	return f.copyAt(b, off)
+}
+
+// copyAt copies the mapping at byte offset off into b.  Accessing the mapping
+// faults when the file was truncated below it behind the back of the database,
+// which is turned into an error rather than crashing the process.
+func (f *mappedFile) copyAt(b []byte, off int64) (n int, err error) {
+	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
+	defer func() {
+		if r := recover(); r != nil {
+			n, err = 0, fmt.Errorf("fault reading the mapping of %s "+
+				"at offset %d: %v", f.Name(), off, r)
+		}
+	}()
+	return copy(b, f.data[off:]), nil
+}
+
+// adviseReadAhead hints the OS to read the region following a read ahead of
+// time when the read moves forward through the file from the last one, and
+// reaches the second half of the region hinted before.
+func (f *mappedFile) adviseReadAhead(off, n int64) {
+	f.hintMtx.Lock()
+	sequential := off >= f.lastEnd && off-f.lastEnd < readAheadSize
+	f.lastEnd = off + n
+	if !sequential || f.lastEnd+readAheadSize/2 <= f.hintEnd {
+		f.hintMtx.Unlock()
+		return
+	}
+	start := f.lastEnd
+	if f.hintEnd > start {
+		start = f.hintEnd
+	}
+	end := start + readAheadSize
+	if end > int64(len(f.data)) {
+		end = int64(len(f.data))
+	}
+	f.hintEnd = end
+	f.hintMtx.Unlock()
+
+	// The hinted region must start at a page boundary.
+	start -= start % f.pageSize
+	if start < end {
+		adviseWillNeed(f.data[start:end])
+	}
+}
+
+// Close unmaps and closes the file.
+//
+// This is part of the filer interface.
+func (f *mappedFile) Close() error {
+	if err := unmapFile(f.data); err != nil {
+		_ = f.File.Close()
+		return err
+	}
+	return f.File.Close()
+}
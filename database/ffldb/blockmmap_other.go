@@ -0,0 +1,29 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package ffldb
+
+import (
+	"errors"
+	"os"
+)
+
+// mmapSupported is whether the block files can be memory mapped on this
+// platform.
+const mmapSupported = false
+
+// mapFile returns an error since memory mapping the block files is not
+// supported on this platform.
+func mapFile(file *os.File) ([]byte, error) {
+	return nil, errors.New("memory mapping files is not supported")
+}
+
+// unmapFile does nothing since memory mapping the block files is not supported
+// on this platform.
+func unmapFile(data []byte) error {
+	return nil
+}
+
+// adviseWillNeed does nothing since memory mapping the block files is not
+// supported on this platform.
+func adviseWillNeed(data []byte) {}
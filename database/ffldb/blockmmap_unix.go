@@ -0,0 +1,45 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package ffldb
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// mmapSupported is whether the block files can be memory mapped on this
+// platform.
+const mmapSupported = true
+
+// mapFile maps the whole file into memory for reads.
+func mapFile(file *os.File) ([]byte, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size == 0 {
+		return nil, nil
+	}
+	if int64(int(size)) != size {
+		return nil, fmt.Errorf("file %s is too large to map", file.Name())
+	}
+	return unix.Mmap(int(file.Fd()), 0, int(size), unix.PROT_READ,
+		unix.MAP_SHARED)
+}
+
+// unmapFile unmaps a mapping returned by mapFile.
+func unmapFile(data []byte) error {
+	if data == nil {
+		return nil
+	}
+	return unix.Munmap(data)
+}
+
+// adviseWillNeed hints the OS the region of a mapping will be read soon.
+func adviseWillNeed(data []byte) {
+	_ = unix.Madvise(data, unix.MADV_WILLNEED)
+}
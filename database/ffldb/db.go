@@ -7,13 +7,16 @@ package ffldb
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
 	"sort"
 	"sync"
+	"sync/atomic"
 
+	"github.com/cockroachdb/pebble"
 	"github.com/lbryio/lbcd/chaincfg/chainhash"
 	"github.com/lbryio/lbcd/database"
 	"github.com/lbryio/lbcd/database/internal/treap"
@@ -22,9 +25,7 @@ import (
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/comparer"
 	ldberrors "github.com/syndtr/goleveldb/leveldb/errors"
-	"github.com/syndtr/goleveldb/leveldb/filter"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
-	"github.com/syndtr/goleveldb/leveldb/opt"
 	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
@@ -145,13 +146,13 @@ func convertErr(desc string, ldbErr error) database.Error {
 		code = database.ErrCorruption
 
 	// Database open/create errors.
-	case ldbErr == leveldb.ErrClosed:
+	case ldbErr == leveldb.ErrClosed, errors.Is(ldbErr, pebble.ErrClosed):
 		code = database.ErrDbNotOpen
 
 	// Transaction errors.
 	case ldbErr == leveldb.ErrSnapshotReleased:
 		code = database.ErrTxClosed
-	case ldbErr == leveldb.ErrIterReleased:
+	case ldbErr == leveldb.ErrIterReleased, ldbErr == errIterReleased:
 		code = database.ErrTxClosed
 	}
 
@@ -1731,7 +1732,14 @@ type db struct {
 	closeLock sync.RWMutex // Make database close block while txns active.
 	closed    bool         // Is the database closed?
 	store     *blockStore  // Handles read/writing blocks to flat files.
-	cache     *dbCache     // Cache layer which wraps underlying leveldb DB.
+	cache     *dbCache     // Cache layer which wraps underlying metadata DB.
+
+	// backend is the database engine of the metadata.
+	backend *metadataBackend
+
+	// lastRepair is the result of the check which repaired the database
+	// when it was opened, if any.
+	lastRepair *CheckResult
 }
 
 // Enforce db implements the database.DB interface.
@@ -1742,7 +1750,112 @@ var _ database.DB = (*db)(nil)
 //
 // This function is part of the database.DB interface implementation.
 func (db *db) Type() string {
-	return dbType
+	return db.backend.dbType
+}
+
+// CacheSize returns the current size of the database cache and the maximum size
+// it grows to before it is flushed.
+func (db *db) CacheSize() (uint64, uint64) {
+	return db.cache.Size(), db.cache.maxSize
+}
+
+// Stats are the I/O statistics of the database since it was opened.
+type Stats struct {
+	// The following fields are the statistics of the database holding
+	// the metadata, whose engine is leveldb or pebble.  Compactions
+	// excludes the flushes of the memtable, which are counted by Flushes.
+	MetadataEngine     string
+	MetadataSize       int64
+	MetadataReadBytes  uint64
+	MetadataWriteBytes uint64
+	Compactions        uint64
+	Flushes            uint64
+	OpenTables         int
+
+	// CacheHits and CacheMisses count the metadata keys fetched from the
+	// cache of the changes yet to be flushed and from the database.
+	CacheHits   uint64
+	CacheMisses uint64
+
+	// The following fields are the statistics of the flat files holding
+	// the blocks.
+	BlockFilesSize  int64
+	BlockReadBytes  uint64
+	BlockWriteBytes uint64
+	OpenBlockFiles  int
+}
+
+// Stats returns the I/O statistics of the database.
+func (db *db) Stats() (*Stats, error) {
+	stats := &Stats{
+		MetadataEngine:  db.backend.engine,
+		CacheHits:       atomic.LoadUint64(&db.cache.hits),
+		CacheMisses:     atomic.LoadUint64(&db.cache.misses),
+		BlockReadBytes:  atomic.LoadUint64(&db.store.bytesRead),
+		BlockWriteBytes: atomic.LoadUint64(&db.store.bytesWritten),
+	}
+	if err := db.cache.mdb.FillStats(stats); err != nil {
+		return nil, err
+	}
+
+	db.store.obfMutex.RLock()
+	stats.OpenBlockFiles = len(db.store.openBlockFiles)
+	db.store.obfMutex.RUnlock()
+
+	// The block file being written isn't among the files opened for reads.
+	wc := db.store.writeCursor
+	wc.RLock()
+	curFileNum := wc.curFileNum
+	wc.curFile.RLock()
+	if wc.curFile.file != nil {
+		stats.OpenBlockFiles++
+	}
+	wc.curFile.RUnlock()
+	wc.RUnlock()
+	for fileNum := uint32(0); fileNum <= curFileNum; fileNum++ {
+		fi, err := os.Stat(blockFilePath(db.store.basePath, fileNum))
+		if err != nil {
+			continue
+		}
+		stats.BlockFilesSize += fi.Size()
+	}
+
+	return stats, nil
+}
+
+// BucketsSize returns the approximate size on disk of the passed top-level
+// buckets of the metadata, including the buckets nested in them.  The buckets
+// which don't exist are skipped, and the changes which have yet to be flushed
+// from the cache aren't accounted.
+func (db *db) BucketsSize(names [][]byte) (int64, error) {
+	var ranges []util.Range
+	var addBucket func(b *bucket) error
+	addBucket = func(b *bucket) error {
+		// The keys of a bucket are all prefixed with its ID.
+		limit := binary.BigEndian.Uint32(b.id[:]) + 1
+		ranges = append(ranges, util.Range{
+			Start: append([]byte(nil), b.id[:]...),
+			Limit: binary.BigEndian.AppendUint32(nil, limit),
+		})
+		return b.ForEachBucket(func(k []byte) error {
+			return addBucket(b.Bucket(k).(*bucket))
+		})
+	}
+	err := db.View(func(dbTx database.Tx) error {
+		for _, name := range names {
+			if b := dbTx.Metadata().Bucket(name); b != nil {
+				if err := addBucket(b.(*bucket)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return db.cache.mdb.SizeOf(ranges)
 }
 
 // begin is the implementation function for the Begin database method.  See its
@@ -1919,7 +2032,7 @@ func (db *db) Close() error {
 	// cache and clear all state without the individual locks.
 
 	// Close the database cache which will flush any existing entries to
-	// disk and close the underlying leveldb database.  Any error is saved
+	// disk and close the underlying metadata database.  Any error is saved
 	// and returned at the end after the remaining cleanup since the
 	// database will be marked closed even if this fails given there is no
 	// good way for the caller to recover from a failure here anyways.
@@ -1953,10 +2066,10 @@ func fileExists(name string) bool {
 
 // initDB creates the initial buckets and values used by the package.  This is
 // mainly in a separate function for testing purposes.
-func initDB(ldb *leveldb.DB) error {
+func initDB(mdb metadataStore) error {
 	// The starting block file write cursor location is file num 0, offset
 	// 0.
-	batch := new(leveldb.Batch)
+	batch := treap.NewMutable()
 	batch.Put(bucketizedKey(metadataBucketID, writeLocKeyName),
 		serializeWriteRow(0, 0))
 
@@ -1971,18 +2084,14 @@ func initDB(ldb *leveldb.DB) error {
 	batch.Put(curBucketIDKeyName, blockIdxBucketID[:])
 
 	// Write everything as a single batch.
-	if err := ldb.Write(batch, nil); err != nil {
-		str := fmt.Sprintf("failed to initialize metadata database: %v",
-			err)
-		return convertErr(str, err)
-	}
-
-	return nil
+	return mdb.Write(batch, treap.NewMutable())
 }
 
-// openDB opens the database at the provided path.  database.ErrDbDoesNotExist
-// is returned if the database doesn't exist and the create flag is not set.
-func openDB(dbPath string, network wire.BitcoinNet, create bool) (database.DB, error) {
+// openDB opens the database at the provided path, whose block files are stored
+// at blocksPath and metadata in the provided backend, with the provided
+// options.  database.ErrDbDoesNotExist is returned if the database doesn't
+// exist and the create flag is not set.
+func openDB(backend *metadataBackend, dbPath, blocksPath string, network wire.BitcoinNet, dbOpts Options, create bool) (database.DB, error) {
 	// Error if the database doesn't exist and the create flag is not set.
 	metadataDbPath := filepath.Join(dbPath, metadataDbName)
 	dbExists := fileExists(metadataDbPath)
@@ -1993,35 +2102,54 @@ func openDB(dbPath string, network wire.BitcoinNet, create bool) (database.DB, e
 
 	// Ensure the full path to the database exists.
 	if !dbExists {
-		// The error can be ignored here since the call to
-		// leveldb.OpenFile will fail if the directory couldn't be
-		// created.
+		// The error can be ignored here since opening the metadata
+		// database will fail if the directory couldn't be created.
 		_ = os.MkdirAll(dbPath, 0700)
 	}
 
+	// Move the block files stored with the metadata when they are now
+	// stored apart.
+	if blocksPath != dbPath {
+		if err := os.MkdirAll(blocksPath, 0700); err != nil {
+			str := fmt.Sprintf("failed to create block files "+
+				"directory %s", blocksPath)
+			return nil, makeDbErr(database.ErrDriverSpecific, str, err)
+		}
+		if err := migrateBlockFiles(dbPath, blocksPath); err != nil {
+			return nil, err
+		}
+	}
+
 	// Open the metadata database (will create it if needed).
-	opts := opt.Options{
-		ErrorIfExist:           create,
-		Strict:                 opt.DefaultStrict,
-		Compression:            opt.NoCompression,
-		Filter:                 filter.NewBloomFilter(10),
-		OpenFilesCacheCapacity: 2000,
-	}
-	ldb, err := leveldb.OpenFile(metadataDbPath, &opts)
+	mdb, err := backend.open(metadataDbPath, create)
 	if err != nil {
-		return nil, convertErr(err.Error(), err)
+		return nil, err
 	}
 
 	// Create the block store which includes scanning the existing flat
 	// block files to find what the current write cursor position is
 	// according to the data that is actually on disk.  Also create the
-	// database cache which wraps the underlying leveldb database to provide
+	// database cache which wraps the underlying metadata database to provide
 	// write caching.
-	store := newBlockStore(dbPath, network)
-	cache := newDbCache(ldb, store, defaultCacheSize, defaultFlushSecs)
-	pdb := &db{store: store, cache: cache}
+	store := newBlockStore(blocksPath, network)
+	if dbOpts.MmapBlockFiles {
+		if mmapSupported {
+			store.mmapFiles = true
+		} else {
+			log.Warnf("Memory mapping the block files is not " +
+				"supported on this platform")
+		}
+	}
+	cache := newDbCache(mdb, store, defaultCacheSize, defaultFlushSecs)
+	pdb := &db{store: store, cache: cache, backend: backend}
 
 	// Perform any reconciliation needed between the block and metadata as
-	// well as database initialization, if needed.
-	return reconcileDB(pdb, create)
+	// well as database initialization, if needed.  The database is closed
+	// when it fails so it can be opened again, such as to repair it.
+	rdb, err := reconcileDB(pdb, create, dbOpts)
+	if err != nil {
+		_ = pdb.Close()
+		return nil, err
+	}
+	return rdb, nil
 }
@@ -6,12 +6,11 @@ package ffldb
 
 import (
 	"bytes"
-	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/lbryio/lbcd/database/internal/treap"
-	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/util"
 )
@@ -285,7 +284,8 @@ func (iter *dbCacheIterator) Error() error {
 // dbCacheSnapshot defines a snapshot of the database cache and underlying
 // database at a particular point in time.
 type dbCacheSnapshot struct {
-	dbSnapshot    *leveldb.Snapshot
+	cache         *dbCache
+	dbSnapshot    metadataSnapshot
 	pendingKeys   *treap.Immutable
 	pendingRemove *treap.Immutable
 }
@@ -301,8 +301,7 @@ func (snap *dbCacheSnapshot) Has(key []byte) bool {
 	}
 
 	// Consult the database.
-	hasKey, _ := snap.dbSnapshot.Has(key, nil)
-	return hasKey
+	return snap.dbSnapshot.Has(key)
 }
 
 // Get returns the value for the passed key.  The function will return nil when
@@ -310,18 +309,17 @@ func (snap *dbCacheSnapshot) Has(key []byte) bool {
 func (snap *dbCacheSnapshot) Get(key []byte) []byte {
 	// Check the cached entries first.
 	if snap.pendingRemove.Has(key) {
+		atomic.AddUint64(&snap.cache.hits, 1)
 		return nil
 	}
 	if value := snap.pendingKeys.Get(key); value != nil {
+		atomic.AddUint64(&snap.cache.hits, 1)
 		return value
 	}
 
 	// Consult the database.
-	value, err := snap.dbSnapshot.Get(key, nil)
-	if err != nil {
-		return nil
-	}
-	return value
+	atomic.AddUint64(&snap.cache.misses, 1)
+	return snap.dbSnapshot.Get(key)
 }
 
 // Release releases the snapshot.
@@ -340,7 +338,7 @@ func (snap *dbCacheSnapshot) Release() {
 // can be nil if the functionality is not desired.
 func (snap *dbCacheSnapshot) NewIterator(slice *util.Range) *dbCacheIterator {
 	return &dbCacheIterator{
-		dbIter:        snap.dbSnapshot.NewIterator(slice, nil),
+		dbIter:        snap.dbSnapshot.NewIterator(slice),
 		cacheIter:     newLdbCacheIter(snap, slice),
 		cacheSnapshot: snap,
 	}
@@ -354,8 +352,14 @@ func (snap *dbCacheSnapshot) NewIterator(slice *util.Range) *dbCacheIterator {
 // can commit transactions at will without incurring large performance hits due
 // to frequent disk syncs.
 type dbCache struct {
-	// ldb is the underlying leveldb DB for metadata.
-	ldb *leveldb.DB
+	// hits and misses count the keys fetched from the cache and from the
+	// underlying database.  They are updated atomically, and are first in
+	// the struct to be 64-bit aligned.
+	hits   uint64
+	misses uint64
+
+	// mdb is the underlying database for metadata.
+	mdb metadataStore
 
 	// store is used to sync blocks to flat files.
 	store *blockStore
@@ -391,15 +395,23 @@ type dbCache struct {
 	cachedRemove *treap.Immutable
 }
 
+// Size returns the total size of the keys and values waiting in the cache to be
+// flushed to the underlying database.
+func (c *dbCache) Size() uint64 {
+	c.cacheLock.RLock()
+	size := c.cachedKeys.Size() + c.cachedRemove.Size()
+	c.cacheLock.RUnlock()
+	return size
+}
+
 // Snapshot returns a snapshot of the database cache and underlying database at
 // a particular point in time.
 //
 // The snapshot must be released after use by calling Release.
 func (c *dbCache) Snapshot() (*dbCacheSnapshot, error) {
-	dbSnapshot, err := c.ldb.GetSnapshot()
+	dbSnapshot, err := c.mdb.Snapshot()
 	if err != nil {
-		str := "failed to open transaction"
-		return nil, convertErr(str, err)
+		return nil, err
 	}
 
 	// Since the cached keys to be added and removed use an immutable treap,
@@ -407,6 +419,7 @@ func (c *dbCache) Snapshot() (*dbCacheSnapshot, error) {
 	// which is used to atomically swap the root.
 	c.cacheLock.RLock()
 	cacheSnapshot := &dbCacheSnapshot{
+		cache:         c,
 		dbSnapshot:    dbSnapshot,
 		pendingKeys:   c.cachedKeys,
 		pendingRemove: c.cachedRemove,
@@ -415,30 +428,6 @@ func (c *dbCache) Snapshot() (*dbCacheSnapshot, error) {
 	return cacheSnapshot, nil
 }
 
-// updateDB invokes the passed function in the context of a managed leveldb
-// transaction.  Any errors returned from the user-supplied function will cause
-// the transaction to be rolled back and are returned from this function.
-// Otherwise, the transaction is committed when the user-supplied function
-// returns a nil error.
-func (c *dbCache) updateDB(fn func(ldbTx *leveldb.Transaction) error) error {
-	// Start a leveldb transaction.
-	ldbTx, err := c.ldb.OpenTransaction()
-	if err != nil {
-		return convertErr("failed to open ldb transaction", err)
-	}
-
-	if err := fn(ldbTx); err != nil {
-		ldbTx.Discard()
-		return err
-	}
-
-	// Commit the leveldb transaction and convert any errors as needed.
-	if err := ldbTx.Commit(); err != nil {
-		return convertErr("failed to commit leveldb transaction", err)
-	}
-	return nil
-}
-
 // TreapForEacher is an interface which allows iteration of a treap in ascending
 // order using a user-supplied callback for each key/value pair.  It mainly
 // exists so both mutable and immutable treaps can be atomically committed to
@@ -450,34 +439,8 @@ type TreapForEacher interface {
 // commitTreaps atomically commits all of the passed pending add/update/remove
 // updates to the underlying database.
 func (c *dbCache) commitTreaps(pendingKeys, pendingRemove TreapForEacher) error {
-	// Perform all leveldb updates using an atomic transaction.
-	return c.updateDB(func(ldbTx *leveldb.Transaction) error {
-		var innerErr error
-		pendingKeys.ForEach(func(k, v []byte) bool {
-			if dbErr := ldbTx.Put(k, v, nil); dbErr != nil {
-				str := fmt.Sprintf("failed to put key %q to "+
-					"ldb transaction", k)
-				innerErr = convertErr(str, dbErr)
-				return false
-			}
-			return true
-		})
-		if innerErr != nil {
-			return innerErr
-		}
-
-		pendingRemove.ForEach(func(k, v []byte) bool {
-			if dbErr := ldbTx.Delete(k, nil); dbErr != nil {
-				str := fmt.Sprintf("failed to delete "+
-					"key %q from ldb transaction",
-					k)
-				innerErr = convertErr(str, dbErr)
-				return false
-			}
-			return true
-		})
-		return innerErr
-	})
+	// Perform all updates using an atomic write.
+	return c.mdb.Write(pendingKeys, pendingRemove)
 }
 
 // flush flushes the database cache to persistent storage.  This involes syncing
@@ -511,7 +474,7 @@ func (c *dbCache) flush() error {
 		return nil
 	}
 
-	// Perform all leveldb updates using an atomic transaction.
+	// Perform all updates using an atomic write.
 	if err := c.commitTreaps(cachedKeys, cachedRemove); err != nil {
 		return err
 	}
@@ -568,7 +531,7 @@ func (c *dbCache) commitTx(tx *transaction) error {
 			return err
 		}
 
-		// Perform all leveldb updates using an atomic transaction.
+		// Perform all updates using an atomic write.
 		err := c.commitTreaps(tx.pendingKeys, tx.pendingRemove)
 		if err != nil {
 			return err
@@ -617,7 +580,7 @@ func (c *dbCache) commitTx(tx *transaction) error {
 }
 
 // Close cleanly shuts down the database cache by syncing all data and closing
-// the underlying leveldb database.
+// the underlying metadata database.
 //
 // This function MUST be called with the database write lock held.
 func (c *dbCache) Close() error {
@@ -626,13 +589,13 @@ func (c *dbCache) Close() error {
 		// Even if there is an error while flushing, attempt to close
 		// the underlying database.  The error is ignored since it would
 		// mask the flush error.
-		_ = c.ldb.Close()
+		_ = c.mdb.Close()
 		return err
 	}
 
-	// Close the underlying leveldb database.
-	if err := c.ldb.Close(); err != nil {
-		str := "failed to close underlying leveldb database"
+	// Close the underlying metadata database.
+	if err := c.mdb.Close(); err != nil {
+		str := "failed to close underlying metadata database"
 		return convertErr(str, err)
 	}
 
@@ -640,12 +603,12 @@ func (c *dbCache) Close() error {
 }
 
 // newDbCache returns a new database cache instance backed by the provided
-// leveldb instance.  The cache will be flushed to leveldb when the max size
+// metadata database.  The cache will be flushed to it when the max size
 // exceeds the provided value or it has been longer than the provided interval
 // since the last flush.
-func newDbCache(ldb *leveldb.DB, store *blockStore, maxSize uint64, flushIntervalSecs uint32) *dbCache {
+func newDbCache(mdb metadataStore, store *blockStore, maxSize uint64, flushIntervalSecs uint32) *dbCache {
 	return &dbCache{
-		ldb:           ldb,
+		mdb:           mdb,
 		store:         store,
 		maxSize:       maxSize,
 		flushInterval: time.Second * time.Duration(flushIntervalSecs),
@@ -25,5 +25,35 @@ database path as a string and the block network:
 	if err != nil {
 		// Handle error
 	}
+
+The flat block files are stored in the database path, unless the path of
+another directory is passed as a third parameter.  The block files found in the
+database path are then moved to that directory when it's opened:
+
+	db, err := database.Open("ffldb", "path/to/database", wire.MainNet,
+		"path/to/blocks")
+	if err != nil {
+		// Handle error
+	}
+
+The Options of the database can be passed as a fourth parameter, such as to
+read the block files from memory mappings of them, which speeds up the
+sequential scans of the chain:
+
+	db, err := database.Open("ffldb", "path/to/database", wire.MainNet,
+		"path/to/database", ffldb.Options{MmapBlockFiles: true})
+	if err != nil {
+		// Handle error
+	}
+
+The package also provides the database type of "ffpebble", which takes the same
+parameters and stores the metadata in pebble instead of leveldb.  Pebble stalls
+the writes far less while it compacts, such as during the initial block
+download.  The formats of the metadata of the two types are incompatible.
+
+	db, err := database.Open("ffpebble", "path/to/database", wire.MainNet)
+	if err != nil {
+		// Handle error
+	}
 */
 package ffldb
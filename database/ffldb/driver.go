@@ -15,52 +15,110 @@ import (
 var log = btclog.Disabled
 
 const (
+	// dbType is the type of the driver of the databases with their
+	// metadata in leveldb.
 	dbType = "ffldb"
+
+	// pebbleDbType is the type of the driver of the databases with their
+	// metadata in pebble.
+	pebbleDbType = "ffpebble"
 )
 
-// parseArgs parses the arguments from the database Open/Create methods.
-func parseArgs(funcName string, args ...interface{}) (string, wire.BitcoinNet, error) {
-	if len(args) != 2 {
-		return "", 0, fmt.Errorf("invalid arguments to %s.%s -- "+
-			"expected database path and block network", dbType,
-			funcName)
+// Options are the optional settings of the database, which are passed to the
+// database Open/Create methods after the optional path of the block files.
+type Options struct {
+	// MmapBlockFiles reads the block files which are no longer appended to
+	// from memory mappings of them rather than with a syscall per read,
+	// and hints the OS to read them ahead of the sequential scans of the
+	// chain, such as those building the indexes.  It is ignored on the
+	// platforms which don't support memory mapping files.
+	MmapBlockFiles bool
+
+	// Check is how thoroughly the consistency of the block files with the
+	// metadata is checked when the database is opened.  Defaults to
+	// CheckNone.
+	Check CheckLevel
+
+	// Repair truncates the block files and the metadata to their last
+	// consistent point when the database is found inconsistent as it is
+	// opened, dropping the blocks stored after it, instead of failing with
+	// ErrCorruption.  LastRepair reports the dropped blocks, which the
+	// higher layers should fetch again.
+	Repair bool
+}
+
+// parseArgs parses the arguments from the database Open/Create methods of the
+// driver type.  The optional path of the block files defaults to the database
+// path.
+func parseArgs(dbType, funcName string, args ...interface{}) (string, wire.BitcoinNet, string, Options, error) {
+	if len(args) < 2 || len(args) > 4 {
+		return "", 0, "", Options{}, fmt.Errorf("invalid arguments to "+
+			"%s.%s -- expected database path, block network, "+
+			"optional block files path and optional options",
+			dbType, funcName)
 	}
 
 	dbPath, ok := args[0].(string)
 	if !ok {
-		return "", 0, fmt.Errorf("first argument to %s.%s is invalid -- "+
+		return "", 0, "", Options{}, fmt.Errorf("first argument to %s.%s is invalid -- "+
 			"expected database path string", dbType, funcName)
 	}
 
 	network, ok := args[1].(wire.BitcoinNet)
 	if !ok {
-		return "", 0, fmt.Errorf("second argument to %s.%s is invalid -- "+
+		return "", 0, "", Options{}, fmt.Errorf("second argument to %s.%s is invalid -- "+
 			"expected block network", dbType, funcName)
 	}
 
-	return dbPath, network, nil
-}
+	blocksPath := dbPath
+	if len(args) >= 3 {
+		blocksPath, ok = args[2].(string)
+		if !ok {
+			return "", 0, "", Options{}, fmt.Errorf("third argument to %s.%s is "+
+				"invalid -- expected block files path string",
+				dbType, funcName)
+		}
+	}
 
-// openDBDriver is the callback provided during driver registration that opens
-// an existing database for use.
-func openDBDriver(args ...interface{}) (database.DB, error) {
-	dbPath, network, err := parseArgs("Open", args...)
-	if err != nil {
-		return nil, err
+	var opts Options
+	if len(args) == 4 {
+		opts, ok = args[3].(Options)
+		if !ok {
+			return "", 0, "", Options{}, fmt.Errorf("fourth argument to %s.%s is "+
+				"invalid -- expected ffldb.Options", dbType, funcName)
+		}
 	}
 
-	return openDB(dbPath, network, false)
+	return dbPath, network, blocksPath, opts, nil
 }
 
-// createDBDriver is the callback provided during driver registration that
-// creates, initializes, and opens a database for use.
-func createDBDriver(args ...interface{}) (database.DB, error) {
-	dbPath, network, err := parseArgs("Create", args...)
-	if err != nil {
-		return nil, err
+// openDBDriver returns the callback provided during the registration of the
+// driver of the backend that opens an existing database for use.
+func openDBDriver(backend *metadataBackend) func(args ...interface{}) (database.DB, error) {
+	return func(args ...interface{}) (database.DB, error) {
+		dbPath, network, blocksPath, opts, err := parseArgs(
+			backend.dbType, "Open", args...)
+		if err != nil {
+			return nil, err
+		}
+
+		return openDB(backend, dbPath, blocksPath, network, opts, false)
 	}
+}
 
-	return openDB(dbPath, network, true)
+// createDBDriver returns the callback provided during the registration of the
+// driver of the backend that creates, initializes, and opens a database for
+// use.
+func createDBDriver(backend *metadataBackend) func(args ...interface{}) (database.DB, error) {
+	return func(args ...interface{}) (database.DB, error) {
+		dbPath, network, blocksPath, opts, err := parseArgs(
+			backend.dbType, "Create", args...)
+		if err != nil {
+			return nil, err
+		}
+
+		return openDB(backend, dbPath, blocksPath, network, opts, true)
+	}
 }
 
 // useLogger is the callback provided during driver registration that sets the
@@ -70,15 +128,18 @@ func useLogger(logger btclog.Logger) {
 }
 
 func init() {
-	// Register the driver.
-	driver := database.Driver{
-		DbType:    dbType,
-		Create:    createDBDriver,
-		Open:      openDBDriver,
-		UseLogger: useLogger,
-	}
-	if err := database.RegisterDriver(driver); err != nil {
-		panic(fmt.Sprintf("Failed to regiser database driver '%s': %v",
-			dbType, err))
+	// Register a driver for each metadata backend.  They share the
+	// logger, which is set by either.
+	for _, backend := range []*metadataBackend{ldbBackend, pebbleBackend} {
+		driver := database.Driver{
+			DbType:    backend.dbType,
+			Create:    createDBDriver(backend),
+			Open:      openDBDriver(backend),
+			UseLogger: useLogger,
+		}
+		if err := database.RegisterDriver(driver); err != nil {
+			panic(fmt.Sprintf("Failed to regiser database driver "+
+				"'%s': %v", backend.dbType, err))
+		}
 	}
 }
@@ -20,6 +20,10 @@ import (
 // dbType is the database type name for this driver.
 const dbType = "ffldb"
 
+// pebbleDbType is the database type name for the driver with the metadata in
+// pebble.
+const pebbleDbType = "ffpebble"
+
 // TestCreateOpenFail ensures that errors related to creating and opening a
 // database are handled properly.
 func TestCreateOpenFail(t *testing.T) {
@@ -36,8 +40,9 @@ func TestCreateOpenFail(t *testing.T) {
 	// Ensure that attempting to open a database with the wrong number of
 	// parameters returns the expected error.
 	wantErr := fmt.Errorf("invalid arguments to %s.Open -- expected "+
-		"database path and block network", dbType)
-	_, err = database.Open(dbType, 1, 2, 3)
+		"database path, block network, optional block files path "+
+		"and optional options", dbType)
+	_, err = database.Open(dbType, 1, 2, 3, 4, 5)
 	if err.Error() != wantErr.Error() {
 		t.Errorf("Open: did not receive expected error - got %v, "+
 			"want %v", err, wantErr)
@@ -66,11 +71,34 @@ func TestCreateOpenFail(t *testing.T) {
 		return
 	}
 
+	// Ensure that attempting to open a database with an invalid type for
+	// the third parameter returns the expected error.
+	wantErr = fmt.Errorf("third argument to %s.Open is invalid -- "+
+		"expected block files path string", dbType)
+	_, err = database.Open(dbType, "noexist", blockDataNet, 1)
+	if err.Error() != wantErr.Error() {
+		t.Errorf("Open: did not receive expected error - got %v, "+
+			"want %v", err, wantErr)
+		return
+	}
+
+	// Ensure that attempting to open a database with an invalid type for
+	// the fourth parameter returns the expected error.
+	wantErr = fmt.Errorf("fourth argument to %s.Open is invalid -- "+
+		"expected ffldb.Options", dbType)
+	_, err = database.Open(dbType, "noexist", blockDataNet, "noexist", 1)
+	if err.Error() != wantErr.Error() {
+		t.Errorf("Open: did not receive expected error - got %v, "+
+			"want %v", err, wantErr)
+		return
+	}
+
 	// Ensure that attempting to create a database with the wrong number of
 	// parameters returns the expected error.
 	wantErr = fmt.Errorf("invalid arguments to %s.Create -- expected "+
-		"database path and block network", dbType)
-	_, err = database.Create(dbType, 1, 2, 3)
+		"database path, block network, optional block files path "+
+		"and optional options", dbType)
+	_, err = database.Create(dbType, 1, 2, 3, 4, 5)
 	if err.Error() != wantErr.Error() {
 		t.Errorf("Create: did not receive expected error - got %v, "+
 			"want %v", err, wantErr)
@@ -99,6 +127,28 @@ func TestCreateOpenFail(t *testing.T) {
 		return
 	}
 
+	// Ensure that attempting to create a database with an invalid type for
+	// the third parameter returns the expected error.
+	wantErr = fmt.Errorf("third argument to %s.Create is invalid -- "+
+		"expected block files path string", dbType)
+	_, err = database.Create(dbType, "noexist", blockDataNet, 1)
+	if err.Error() != wantErr.Error() {
+		t.Errorf("Create: did not receive expected error - got %v, "+
+			"want %v", err, wantErr)
+		return
+	}
+
+	// Ensure that attempting to create a database with an invalid type for
+	// the fourth parameter returns the expected error.
+	wantErr = fmt.Errorf("fourth argument to %s.Create is invalid -- "+
+		"expected ffldb.Options", dbType)
+	_, err = database.Create(dbType, "noexist", blockDataNet, "noexist", 1)
+	if err.Error() != wantErr.Error() {
+		t.Errorf("Create: did not receive expected error - got %v, "+
+			"want %v", err, wantErr)
+		return
+	}
+
 	// Ensure operations against a closed database return the expected
 	// error.
 	dbPath := filepath.Join(os.TempDir(), "ffldb-createfail")
@@ -151,8 +201,23 @@ func TestCreateOpenFail(t *testing.T) {
 func TestPersistence(t *testing.T) {
 	t.Parallel()
 
+	runPersistenceTest(t, dbType, "ffldb-persistencetest")
+}
+
+// TestPebblePersistence ensures that values stored are still valid after
+// closing and reopening a database with the metadata in pebble.
+func TestPebblePersistence(t *testing.T) {
+	t.Parallel()
+
+	runPersistenceTest(t, pebbleDbType, "ffpebble-persistencetest")
+}
+
+// runPersistenceTest ensures that values stored in a new database of the passed
+// type created in the temporary directory dbName are still valid after closing
+// and reopening it.
+func runPersistenceTest(t *testing.T, dbType, dbName string) {
 	// Create a new database to run tests against.
-	dbPath := filepath.Join(os.TempDir(), "ffldb-persistencetest")
+	dbPath := filepath.Join(os.TempDir(), dbName)
 	_ = os.RemoveAll(dbPath)
 	db, err := database.Create(dbType, dbPath, blockDataNet)
 	if err != nil {
@@ -257,8 +322,22 @@ func TestPersistence(t *testing.T) {
 func TestInterface(t *testing.T) {
 	t.Parallel()
 
+	runInterfaceTests(t, dbType, "ffldb-interfacetest")
+}
+
+// TestPebbleInterface performs all interfaces tests for the database driver
+// with the metadata in pebble.
+func TestPebbleInterface(t *testing.T) {
+	t.Parallel()
+
+	runInterfaceTests(t, pebbleDbType, "ffpebble-interfacetest")
+}
+
+// runInterfaceTests performs all interfaces tests against a new database of the
+// passed type created in the temporary directory dbName.
+func runInterfaceTests(t *testing.T, dbType, dbName string) {
 	// Create a new database to run tests against.
-	dbPath := filepath.Join(os.TempDir(), "ffldb-interfacetest")
+	dbPath := filepath.Join(os.TempDir(), dbName)
 	_ = os.RemoveAll(dbPath)
 	db, err := database.Create(dbType, dbPath, blockDataNet)
 	if err != nil {
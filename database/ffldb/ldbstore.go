@@ -0,0 +1,157 @@
+package ffldb
+
+import (
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/filter"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// ldbStore is the metadata database stored in leveldb.
+type ldbStore struct {
+	ldb *leveldb.DB
+}
+
+// Enforce ldbStore implements the metadataStore interface.
+var _ metadataStore = (*ldbStore)(nil)
+
+// openLdbStore opens the leveldb metadata database at path, creating it if
+// needed.  It fails if the database exists and the create flag is set.
+func openLdbStore(path string, create bool) (metadataStore, error) {
+	opts := opt.Options{
+		ErrorIfExist:           create,
+		Strict:                 opt.DefaultStrict,
+		Compression:            opt.NoCompression,
+		Filter:                 filter.NewBloomFilter(10),
+		OpenFilesCacheCapacity: 2000,
+	}
+	ldb, err := leveldb.OpenFile(path, &opts)
+	if err != nil {
+		return nil, convertErr(err.Error(), err)
+	}
+	return &ldbStore{ldb: ldb}, nil
+}
+
+// ldbSnapshot is a snapshot of the leveldb metadata database.
+type ldbSnapshot struct {
+	*leveldb.Snapshot
+}
+
+// Has returns whether or not the passed key exists.
+//
+// This is part of the metadataSnapshot interface implementation.
+func (snap ldbSnapshot) Has(key []byte) bool {
+	hasKey, _ := snap.Snapshot.Has(key, nil)
+	return hasKey
+}
+
+// Get returns the value for the passed key, or nil when it does not exist.
+//
+// This is part of the metadataSnapshot interface implementation.
+func (snap ldbSnapshot) Get(key []byte) []byte {
+	value, err := snap.Snapshot.Get(key, nil)
+	if err != nil {
+		return nil
+	}
+	return value
+}
+
+// NewIterator returns a new iterator for the range of keys.
+//
+// This is part of the metadataSnapshot interface implementation.
+func (snap ldbSnapshot) NewIterator(slice *util.Range) iterator.Iterator {
+	return snap.Snapshot.NewIterator(slice, nil)
+}
+
+// Snapshot returns a snapshot of the database.
+//
+// This is part of the metadataStore interface implementation.
+func (s *ldbStore) Snapshot() (metadataSnapshot, error) {
+	snap, err := s.ldb.GetSnapshot()
+	if err != nil {
+		return nil, convertErr("failed to open transaction", err)
+	}
+	return ldbSnapshot{snap}, nil
+}
+
+// Write atomically stores puts and deletes deletes in a leveldb transaction.
+//
+// This is part of the metadataStore interface implementation.
+func (s *ldbStore) Write(puts, deletes TreapForEacher) error {
+	// Start a leveldb transaction.
+	ldbTx, err := s.ldb.OpenTransaction()
+	if err != nil {
+		return convertErr("failed to open ldb transaction", err)
+	}
+
+	var innerErr error
+	puts.ForEach(func(k, v []byte) bool {
+		if dbErr := ldbTx.Put(k, v, nil); dbErr != nil {
+			str := fmt.Sprintf("failed to put key %q to "+
+				"ldb transaction", k)
+			innerErr = convertErr(str, dbErr)
+			return false
+		}
+		return true
+	})
+	if innerErr == nil {
+		deletes.ForEach(func(k, v []byte) bool {
+			if dbErr := ldbTx.Delete(k, nil); dbErr != nil {
+				str := fmt.Sprintf("failed to delete "+
+					"key %q from ldb transaction", k)
+				innerErr = convertErr(str, dbErr)
+				return false
+			}
+			return true
+		})
+	}
+	if innerErr != nil {
+		ldbTx.Discard()
+		return innerErr
+	}
+
+	// Commit the leveldb transaction and convert any errors as needed.
+	if err := ldbTx.Commit(); err != nil {
+		return convertErr("failed to commit leveldb transaction", err)
+	}
+	return nil
+}
+
+// SizeOf returns the approximate size on disk of the ranges of keys.
+//
+// This is part of the metadataStore interface implementation.
+func (s *ldbStore) SizeOf(ranges []util.Range) (int64, error) {
+	sizes, err := s.ldb.SizeOf(ranges)
+	if err != nil {
+		return 0, convertErr("failed to fetch leveldb sizes", err)
+	}
+	return sizes.Sum(), nil
+}
+
+// FillStats sets the metadata fields of the statistics from those of leveldb.
+//
+// This is part of the metadataStore interface implementation.
+func (s *ldbStore) FillStats(stats *Stats) error {
+	var ldbStats leveldb.DBStats
+	if err := s.ldb.Stats(&ldbStats); err != nil {
+		return convertErr("failed to fetch leveldb stats", err)
+	}
+	stats.MetadataSize = ldbStats.LevelSizes.Sum()
+	stats.MetadataReadBytes = ldbStats.IORead
+	stats.MetadataWriteBytes = ldbStats.IOWrite
+	stats.Compactions = uint64(ldbStats.Level0Comp) +
+		uint64(ldbStats.NonLevel0Comp) + uint64(ldbStats.SeekComp)
+	stats.Flushes = uint64(ldbStats.MemComp)
+	stats.OpenTables = ldbStats.OpenedTablesCount
+	return nil
+}
+
+// Close closes the leveldb database.
+//
+// This is part of the metadataStore interface implementation.
+func (s *ldbStore) Close() error {
+	return s.ldb.Close()
+}
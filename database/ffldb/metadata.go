@@ -0,0 +1,80 @@
+package ffldb
+
+import (
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// metadataSnapshot is a read-only snapshot of the metadata database at a
+// particular point in time.  The iterators of the snapshot use the leveldb
+// interface, which the database cache and the cursors are built on.
+type metadataSnapshot interface {
+	// Has returns whether or not the passed key exists.
+	Has(key []byte) bool
+
+	// Get returns the value for the passed key.  The function will return
+	// nil when the key does not exist.
+	Get(key []byte) []byte
+
+	// NewIterator returns a new iterator for the range of keys, which is
+	// only valid until the snapshot is released.
+	NewIterator(slice *util.Range) iterator.Iterator
+
+	// Release releases the snapshot.
+	Release()
+}
+
+// metadataStore is the key/value database holding the metadata of the blocks
+// stored in the flat files, such as the block index and the buckets of the
+// higher layers.  The database cache flushes to it in atomic writes, so it
+// only needs snapshots and batches of puts and deletes.
+type metadataStore interface {
+	// Snapshot returns a snapshot of the database, which must be
+	// released after use.
+	Snapshot() (metadataSnapshot, error)
+
+	// Write atomically and durably stores the keys of puts and deletes
+	// the keys of deletes.
+	Write(puts, deletes TreapForEacher) error
+
+	// SizeOf returns the approximate size on disk of the ranges of keys.
+	SizeOf(ranges []util.Range) (int64, error)
+
+	// FillStats sets the metadata fields of the statistics of the
+	// database.
+	FillStats(stats *Stats) error
+
+	// Close closes the database.
+	Close() error
+}
+
+// metadataBackend is a database engine storing the metadata, each of which is
+// registered as its own database driver type.
+type metadataBackend struct {
+	// dbType is the type of the driver of the ffldb databases with
+	// their metadata in this engine.
+	dbType string
+
+	// engine is the name of the database engine.
+	engine string
+
+	// open opens the metadata database at path, failing if it exists
+	// and the create flag is set.
+	open func(path string, create bool) (metadataStore, error)
+}
+
+// The metadata backends are leveldb, which is the original one of ffldb, and
+// pebble, which stalls writes far less while compacting.  The formats of their
+// databases are incompatible.
+var (
+	ldbBackend = &metadataBackend{
+		dbType: dbType,
+		engine: "leveldb",
+		open:   openLdbStore,
+	}
+	pebbleBackend = &metadataBackend{
+		dbType: pebbleDbType,
+		engine: "pebble",
+		open:   openPebbleStore,
+	}
+)
@@ -0,0 +1,376 @@
+package ffldb
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/bloom"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// pebbleStore is the metadata database stored in pebble.
+//
+// Pebble panics when a closed database is used, so the store fails with
+// pebble.ErrClosed instead, which is converted to database.ErrDbNotOpen.
+type pebbleStore struct {
+	pdb    *pebble.DB
+	closed bool
+}
+
+// Enforce pebbleStore implements the metadataStore interface.
+var _ metadataStore = (*pebbleStore)(nil)
+
+// openPebbleStore opens the pebble metadata database at path, creating it if
+// needed.  It fails if the database exists and the create flag is set.
+func openPebbleStore(path string, create bool) (metadataStore, error) {
+	opts := pebble.Options{
+		ErrorIfExists: create,
+		Cache:         pebble.NewCache(16 << 20),
+		BytesPerSync:  16 << 20,
+		MaxOpenFiles:  2000,
+	}
+	defer opts.Cache.Unref()
+	opts.EnsureDefaults()
+	for i := range opts.Levels {
+		opts.Levels[i].FilterPolicy = bloom.FilterPolicy(10)
+	}
+	pdb, err := pebble.Open(path, &opts)
+	if err != nil {
+		return nil, convertErr(err.Error(), err)
+	}
+	return &pebbleStore{pdb: pdb}, nil
+}
+
+// pebbleSnapshot is a snapshot of the pebble metadata database.
+type pebbleSnapshot struct {
+	*pebble.Snapshot
+}
+
+// Has returns whether or not the passed key exists.
+//
+// This is part of the metadataSnapshot interface implementation.
+func (snap pebbleSnapshot) Has(key []byte) bool {
+	_, closer, err := snap.Snapshot.Get(key)
+	if err != nil {
+		return false
+	}
+	_ = closer.Close()
+	return true
+}
+
+// Get returns the value for the passed key, or nil when it does not exist.  The
+// value is copied since pebble only keeps it valid until its closer is closed.
+//
+// This is part of the metadataSnapshot interface implementation.
+func (snap pebbleSnapshot) Get(key []byte) []byte {
+	value, closer, err := snap.Snapshot.Get(key)
+	if err != nil {
+		return nil
+	}
+	value = append(make([]byte, 0, len(value)), value...)
+	_ = closer.Close()
+	return value
+}
+
+// NewIterator returns a new iterator for the range of keys.
+//
+// This is part of the metadataSnapshot interface implementation.
+func (snap pebbleSnapshot) NewIterator(slice *util.Range) iterator.Iterator {
+	var opts pebble.IterOptions
+	if slice != nil {
+		opts.LowerBound = slice.Start
+		opts.UpperBound = slice.Limit
+	}
+	return &pebbleIter{iter: snap.Snapshot.NewIter(&opts)}
+}
+
+// Release releases the snapshot.
+//
+// This is part of the metadataSnapshot interface implementation.
+func (snap pebbleSnapshot) Release() {
+	_ = snap.Snapshot.Close()
+}
+
+// The positions of a pebbleIter.  Pebble leaves the moves of an unpositioned
+// iterator and of one past the ends of its keys undefined, so the wrapper
+// tracks them.
+const (
+	iterUnpositioned = iota
+	iterPositioned
+	iterBeforeFirst
+	iterAfterLast
+)
+
+// pebbleIter wraps a pebble iterator to provide the semantics of the leveldb
+// iterator.Iterator interface: a Next or Prev on an unpositioned iterator
+// moves it to the first or last key, and one on an iterator past an end of its
+// keys moves it back to the key at that end.
+type pebbleIter struct {
+	iter     *pebble.Iterator
+	pos      int
+	err      error
+	releaser util.Releaser
+	released bool
+}
+
+// Enforce pebbleIter implements the leveldb iterator.Iterator interface.
+var _ iterator.Iterator = (*pebbleIter)(nil)
+
+// setPos records the position of the iterator after a move in the passed
+// direction, and returns whether it points to a key.
+func (iter *pebbleIter) setPos(valid, forwards bool) bool {
+	switch {
+	case valid:
+		iter.pos = iterPositioned
+	case forwards:
+		iter.pos = iterAfterLast
+	default:
+		iter.pos = iterBeforeFirst
+	}
+	return valid
+}
+
+// First positions the iterator at the first key.
+//
+// This is part of the leveldb iterator.Iterator interface implementation.
+func (iter *pebbleIter) First() bool {
+	if iter.released {
+		iter.err = errIterReleased
+		return false
+	}
+	return iter.setPos(iter.iter.First(), true)
+}
+
+// Last positions the iterator at the last key.
+//
+// This is part of the leveldb iterator.Iterator interface implementation.
+func (iter *pebbleIter) Last() bool {
+	if iter.released {
+		iter.err = errIterReleased
+		return false
+	}
+	return iter.setPos(iter.iter.Last(), false)
+}
+
+// Seek positions the iterator at the first key greater than or equal to the
+// passed one.
+//
+// This is part of the leveldb iterator.Iterator interface implementation.
+func (iter *pebbleIter) Seek(key []byte) bool {
+	if iter.released {
+		iter.err = errIterReleased
+		return false
+	}
+	return iter.setPos(iter.iter.SeekGE(key), true)
+}
+
+// Next moves the iterator to the next key.
+//
+// This is part of the leveldb iterator.Iterator interface implementation.
+func (iter *pebbleIter) Next() bool {
+	switch {
+	case iter.released:
+		iter.err = errIterReleased
+		return false
+	case iter.pos == iterAfterLast:
+		return false
+	case iter.pos != iterPositioned:
+		return iter.First()
+	}
+	return iter.setPos(iter.iter.Next(), true)
+}
+
+// Prev moves the iterator to the previous key.
+//
+// This is part of the leveldb iterator.Iterator interface implementation.
+func (iter *pebbleIter) Prev() bool {
+	switch {
+	case iter.released:
+		iter.err = errIterReleased
+		return false
+	case iter.pos == iterBeforeFirst:
+		return false
+	case iter.pos != iterPositioned:
+		return iter.Last()
+	}
+	return iter.setPos(iter.iter.Prev(), false)
+}
+
+// Valid returns whether the iterator points to a key.
+//
+// This is part of the leveldb iterator.Iterator interface implementation.
+func (iter *pebbleIter) Valid() bool {
+	return !iter.released && iter.pos == iterPositioned
+}
+
+// Key returns the current key, which is only valid until the iterator moves.
+//
+// This is part of the leveldb iterator.Iterator interface implementation.
+func (iter *pebbleIter) Key() []byte {
+	if !iter.Valid() {
+		return nil
+	}
+	return iter.iter.Key()
+}
+
+// Value returns the current value, which is only valid until the iterator
+// moves.
+//
+// This is part of the leveldb iterator.Iterator interface implementation.
+func (iter *pebbleIter) Value() []byte {
+	if !iter.Valid() {
+		return nil
+	}
+	return iter.iter.Value()
+}
+
+// Error returns the error of the iterator.
+//
+// This is part of the leveldb iterator.Iterator interface implementation.
+func (iter *pebbleIter) Error() error {
+	if iter.err != nil || iter.released {
+		return iter.err
+	}
+	return iter.iter.Error()
+}
+
+// SetReleaser sets the releaser called when the iterator is released.
+//
+// This is part of the leveldb iterator.Iterator interface implementation.
+func (iter *pebbleIter) SetReleaser(releaser util.Releaser) {
+	if !iter.released {
+		iter.releaser = releaser
+	}
+}
+
+// Release closes the pebble iterator.
+//
+// This is part of the leveldb iterator.Iterator interface implementation.
+func (iter *pebbleIter) Release() {
+	if iter.released {
+		return
+	}
+	iter.err = iter.iter.Close()
+	iter.released = true
+	if iter.releaser != nil {
+		iter.releaser.Release()
+		iter.releaser = nil
+	}
+}
+
+// Snapshot returns a snapshot of the database.
+//
+// This is part of the metadataStore interface implementation.
+func (s *pebbleStore) Snapshot() (metadataSnapshot, error) {
+	if s.closed {
+		return nil, convertErr("failed to open transaction",
+			pebble.ErrClosed)
+	}
+	return pebbleSnapshot{s.pdb.NewSnapshot()}, nil
+}
+
+// Write atomically and durably stores puts and deletes deletes in a pebble
+// batch.
+//
+// This is part of the metadataStore interface implementation.
+func (s *pebbleStore) Write(puts, deletes TreapForEacher) error {
+	if s.closed {
+		return convertErr("failed to write pebble batch",
+			pebble.ErrClosed)
+	}
+
+	batch := s.pdb.NewBatch()
+	defer batch.Close()
+
+	var innerErr error
+	puts.ForEach(func(k, v []byte) bool {
+		if dbErr := batch.Set(k, v, nil); dbErr != nil {
+			str := fmt.Sprintf("failed to put key %q to pebble "+
+				"batch", k)
+			innerErr = convertErr(str, dbErr)
+			return false
+		}
+		return true
+	})
+	if innerErr == nil {
+		deletes.ForEach(func(k, v []byte) bool {
+			if dbErr := batch.Delete(k, nil); dbErr != nil {
+				str := fmt.Sprintf("failed to delete key %q "+
+					"from pebble batch", k)
+				innerErr = convertErr(str, dbErr)
+				return false
+			}
+			return true
+		})
+	}
+	if innerErr != nil {
+		return innerErr
+	}
+
+	if err := batch.Commit(pebble.Sync); err != nil {
+		return convertErr("failed to commit pebble batch", err)
+	}
+	return nil
+}
+
+// SizeOf returns the approximate size on disk of the ranges of keys.
+//
+// This is part of the metadataStore interface implementation.
+func (s *pebbleStore) SizeOf(ranges []util.Range) (int64, error) {
+	if s.closed {
+		return 0, convertErr("failed to fetch pebble sizes",
+			pebble.ErrClosed)
+	}
+
+	var size int64
+	for _, r := range ranges {
+		// The limit of the range is exclusive, unlike the end of the
+		// range of pebble, but the sizes are approximate anyways.
+		rangeSize, err := s.pdb.EstimateDiskUsage(r.Start, r.Limit)
+		if err != nil {
+			return 0, convertErr("failed to fetch pebble sizes", err)
+		}
+		size += int64(rangeSize)
+	}
+	return size, nil
+}
+
+// FillStats sets the metadata fields of the statistics from the metrics of
+// pebble.  The read bytes are only those read by the compactions.
+//
+// This is part of the metadataStore interface implementation.
+func (s *pebbleStore) FillStats(stats *Stats) error {
+	if s.closed {
+		return convertErr("failed to fetch pebble metrics",
+			pebble.ErrClosed)
+	}
+
+	m := s.pdb.Metrics()
+	total := m.Total()
+	stats.MetadataSize = int64(m.DiskSpaceUsage())
+	stats.MetadataReadBytes = total.BytesRead
+	stats.MetadataWriteBytes = m.WAL.BytesWritten + total.BytesFlushed +
+		total.BytesCompacted
+	stats.Compactions = uint64(m.Compact.Count)
+	stats.Flushes = uint64(m.Flush.Count)
+	stats.OpenTables = int(m.TableCache.Count)
+	return nil
+}
+
+// Close closes the pebble database.
+//
+// This is part of the metadataStore interface implementation.
+func (s *pebbleStore) Close() error {
+	if s.closed {
+		return pebble.ErrClosed
+	}
+	s.closed = true
+	return s.pdb.Close()
+}
+
+// errIterReleased is the error of the pebble iterators used after they are
+// released, which is converted to database.ErrTxClosed like its leveldb
+// equivalent.
+var errIterReleased = errors.New("pebble: iterator released")
@@ -47,13 +47,14 @@ func deserializeWriteRow(writeRow []byte) (uint32, uint32, error) {
 	return fileNum, fileOffset, nil
 }
 
-// reconcileDB reconciles the metadata with the flat block files on disk.  It
-// will also initialize the underlying database if the create flag is set.
-func reconcileDB(pdb *db, create bool) (database.DB, error) {
+// reconcileDB reconciles the metadata with the flat block files on disk, and
+// checks their consistency at the check level of the options.  It will also
+// initialize the underlying database if the create flag is set.
+func reconcileDB(pdb *db, create bool, opts Options) (database.DB, error) {
 	// Perform initial internal bucket and value creation during database
 	// creation.
 	if create {
-		if err := initDB(pdb.cache.ldb); err != nil {
+		if err := initDB(pdb.cache.mdb); err != nil {
 			return nil, err
 		}
 	}
@@ -96,21 +97,37 @@ func reconcileDB(pdb *db, create bool) (database.DB, error) {
 
 	// When the write cursor position found by scanning the block files on
 	// disk is BEFORE the position the metadata believes to be true, return
-	// a corruption error.  Since sync is called after each block is written
-	// and before the metadata is updated, this should only happen in the
-	// case of missing, deleted, or truncated block files, which generally
-	// is not an easily recoverable scenario.  In the future, it might be
-	// possible to rescan and rebuild the metadata from the block files,
-	// however, that would need to happen with coordination from a higher
-	// layer since it could invalidate other metadata.
+	// a corruption error unless repairs are allowed.  Since sync is called
+	// after each block is written and before the metadata is updated, this
+	// should only happen in the case of missing, deleted, or truncated
+	// block files.  The repair drops the blocks past the end of the data
+	// from the metadata, which the higher layers then have to fetch again,
+	// so at least a quick check finds them.
+	level := opts.Check
 	if wc.curFileNum < curFileNum || (wc.curFileNum == curFileNum &&
 		wc.curOffset < curOffset) {
 
-		str := fmt.Sprintf("metadata claims file %d, offset %d, but "+
-			"block data is at file %d, offset %d", curFileNum,
-			curOffset, wc.curFileNum, wc.curOffset)
-		log.Warnf("***Database corruption detected***: %v", str)
-		return nil, makeDbErr(database.ErrCorruption, str, nil)
+		if !opts.Repair {
+			str := fmt.Sprintf("metadata claims file %d, offset %d, "+
+				"but block data is at file %d, offset %d",
+				curFileNum, curOffset, wc.curFileNum,
+				wc.curOffset)
+			log.Warnf("***Database corruption detected***: %v", str)
+			return nil, makeDbErr(database.ErrCorruption, str, nil)
+		}
+		if level < CheckQuick {
+			level = CheckQuick
+		}
+	}
+
+	if level != CheckNone && !create {
+		result, err := pdb.checkAndRepair(level, opts.Repair)
+		if err != nil {
+			return nil, err
+		}
+		if result.Repaired {
+			pdb.lastRepair = result
+		}
 	}
 
 	return pdb, nil
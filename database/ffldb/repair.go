@@ -0,0 +1,376 @@
+package ffldb
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/database"
+	"github.com/lbryio/lbcd/wire"
+)
+
+// checkProgressInterval is the interval of the progress logs of a full check.
+const checkProgressInterval = 10 * time.Second
+
+// CheckLevel is how thoroughly a consistency check verifies the block files
+// against the metadata.
+type CheckLevel uint8
+
+const (
+	// CheckNone only reconciles the write cursor of the metadata with the
+	// end of the block files, which is always done when the database is
+	// opened.
+	CheckNone CheckLevel = iota
+
+	// CheckQuick also checks that every block of the block index lies
+	// within its block file, before the write cursor, without reading it.
+	CheckQuick
+
+	// CheckFull also reads every block, checking its checksum, network and
+	// hash.
+	CheckFull
+)
+
+// checkLevelNames are the names of the check levels.
+var checkLevelNames = []string{
+	CheckNone:  "none",
+	CheckQuick: "quick",
+	CheckFull:  "full",
+}
+
+// String returns the name of the check level: none, quick or full.
+func (l CheckLevel) String() string {
+	if int(l) < len(checkLevelNames) {
+		return checkLevelNames[l]
+	}
+	return fmt.Sprintf("Unknown CheckLevel (%d)", uint8(l))
+}
+
+// ParseCheckLevel returns the check level of a name returned by String.
+func ParseCheckLevel(name string) (CheckLevel, error) {
+	for l, levelName := range checkLevelNames {
+		if strings.EqualFold(name, levelName) {
+			return CheckLevel(l), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown check level %q", name)
+}
+
+// BadBlock is a block found inconsistent by a check.
+type BadBlock struct {
+	Hash    chainhash.Hash
+	FileNum uint32
+	Offset  uint32
+	Reason  string
+}
+
+// CheckResult is the result of a consistency check of the database.
+type CheckResult struct {
+	Level CheckLevel
+
+	// Blocks is the number of blocks in the block index.
+	Blocks int
+
+	// BadBlocks are the blocks found inconsistent, in the order of the
+	// block files.
+	BadBlocks []BadBlock
+
+	// WriteFileNum and WriteOffset are the write cursor of the metadata.
+	WriteFileNum uint32
+	WriteOffset  uint32
+
+	// ConsistentFileNum and ConsistentOffset are the last consistent point
+	// of the block files, before which all the blocks are consistent with
+	// the metadata.  It is the write cursor of a consistent database.
+	ConsistentFileNum uint32
+	ConsistentOffset  uint32
+
+	// Dropped are the blocks stored from the last consistent point on,
+	// which are dropped by a repair truncating the database to it.
+	Dropped []chainhash.Hash
+
+	// Repaired reports whether the database was truncated to the last
+	// consistent point.
+	Repaired bool
+}
+
+// Consistent reports whether the block files and the metadata were found
+// consistent.
+func (r *CheckResult) Consistent() bool {
+	return len(r.BadBlocks) == 0 && r.ConsistentFileNum == r.WriteFileNum &&
+		r.ConsistentOffset == r.WriteOffset
+}
+
+// locBefore returns whether the first location in the block files is before
+// the second one.
+func locBefore(fileNum1, offset1, fileNum2, offset2 uint32) bool {
+	return fileNum1 < fileNum2 || (fileNum1 == fileNum2 && offset1 < offset2)
+}
+
+// checkedBlock is a block of the block index along with its location.
+type checkedBlock struct {
+	hash chainhash.Hash
+	loc  blockLocation
+}
+
+// check checks the consistency of the block files with the metadata of the
+// passed transaction.  The blocks are consistent at most up to the end of the
+// data of the block files, which is the write cursor of the block store.
+func (db *db) check(tx *transaction, level CheckLevel) (*CheckResult, error) {
+	writeRow := tx.metaBucket.Get(writeLocKeyName)
+	if writeRow == nil {
+		str := "write cursor does not exist"
+		return nil, makeDbErr(database.ErrCorruption, str, nil)
+	}
+	writeFileNum, writeOffset, err := deserializeWriteRow(writeRow)
+	if err != nil {
+		return nil, err
+	}
+	result := &CheckResult{
+		Level:        level,
+		WriteFileNum: writeFileNum,
+		WriteOffset:  writeOffset,
+	}
+
+	// The blocks are consistent at most up to the end of the data of the
+	// block files, which is before the write cursor of the metadata after
+	// an unclean shutdown losing data.
+	wc := db.store.writeCursor
+	wc.RLock()
+	endFileNum, endOffset := wc.curFileNum, wc.curOffset
+	wc.RUnlock()
+	if locBefore(endFileNum, endOffset, writeFileNum, writeOffset) {
+		result.ConsistentFileNum = endFileNum
+		result.ConsistentOffset = endOffset
+	} else {
+		result.ConsistentFileNum = writeFileNum
+		result.ConsistentOffset = writeOffset
+	}
+
+	// Check the blocks in the order of the files.
+	var blocks []checkedBlock
+	err = tx.blockIdxBucket.ForEach(func(k, v []byte) error {
+		var block checkedBlock
+		copy(block.hash[:], k)
+		block.loc = deserializeBlockLoc(v)
+		blocks = append(blocks, block)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		return locBefore(blocks[i].loc.blockFileNum,
+			blocks[i].loc.fileOffset, blocks[j].loc.blockFileNum,
+			blocks[j].loc.fileOffset)
+	})
+	result.Blocks = len(blocks)
+
+	if level != CheckNone {
+		log.Infof("Checking %d blocks of the database (level %v)",
+			len(blocks), level)
+	}
+	fileSizes := make(map[uint32]int64)
+	lastLog := time.Now()
+	for i := 0; level != CheckNone && i < len(blocks); i++ {
+		block := &blocks[i]
+		reason := db.checkBlock(block, level, fileSizes)
+		if reason != "" {
+			result.BadBlocks = append(result.BadBlocks, BadBlock{
+				Hash:    block.hash,
+				FileNum: block.loc.blockFileNum,
+				Offset:  block.loc.fileOffset,
+				Reason:  reason,
+			})
+		}
+		if level == CheckFull && time.Since(lastLog) >= checkProgressInterval {
+			log.Infof("Checked %d of %d blocks", i+1, len(blocks))
+			lastLog = time.Now()
+		}
+	}
+
+	// The last consistent point is before the first bad block, which is
+	// also the case of the blocks beyond the end of the data.
+	if len(result.BadBlocks) > 0 {
+		first := result.BadBlocks[0]
+		if locBefore(first.FileNum, first.Offset,
+			result.ConsistentFileNum, result.ConsistentOffset) {
+
+			result.ConsistentFileNum = first.FileNum
+			result.ConsistentOffset = first.Offset
+		}
+	}
+	for i := range blocks {
+		loc := &blocks[i].loc
+		if !locBefore(loc.blockFileNum, loc.fileOffset,
+			result.ConsistentFileNum, result.ConsistentOffset) {
+
+			result.Dropped = append(result.Dropped, blocks[i].hash)
+		}
+	}
+
+	return result, nil
+}
+
+// checkBlock returns why a block of the block index is inconsistent at the
+// check level, or an empty string.  The sizes of the block files are cached
+// in the passed map.
+func (db *db) checkBlock(block *checkedBlock, level CheckLevel,
+	fileSizes map[uint32]int64) string {
+
+	loc := block.loc
+	size, ok := fileSizes[loc.blockFileNum]
+	if !ok {
+		fi, err := os.Stat(blockFilePath(db.store.basePath, loc.blockFileNum))
+		if err != nil {
+			size = -1
+		} else {
+			size = fi.Size()
+		}
+		fileSizes[loc.blockFileNum] = size
+	}
+	switch {
+	case size < 0:
+		return fmt.Sprintf("block file %d is missing", loc.blockFileNum)
+	case int64(loc.fileOffset)+int64(loc.blockLen) > size:
+		return fmt.Sprintf("block ends past the end of block file %d "+
+			"(%d bytes)", loc.blockFileNum, size)
+	case loc.blockLen < blockHdrSize+12:
+		return fmt.Sprintf("block length %d is too short", loc.blockLen)
+	}
+	if level != CheckFull {
+		return ""
+	}
+
+	// The checksum and network are checked by reading the block.
+	blockBytes, err := db.store.readBlock(&block.hash, loc)
+	if err != nil {
+		return err.Error()
+	}
+	var header wire.BlockHeader
+	if err := header.Deserialize(bytes.NewReader(blockBytes)); err != nil {
+		return fmt.Sprintf("failed to deserialize block header: %v", err)
+	}
+	if hash := header.BlockHash(); hash != block.hash {
+		return fmt.Sprintf("block data is for block %v", hash)
+	}
+	return ""
+}
+
+// repair truncates the block files and the metadata of the passed writable
+// transaction to the last consistent point of the check result, dropping the
+// blocks stored from it on.  The transaction is committed and the metadata is
+// flushed.  The block files are truncated first, so an interrupted repair
+// finds the metadata past the end of the data again on the next open.
+func (db *db) repair(tx *transaction, result *CheckResult) error {
+	for i := range result.Dropped {
+		err := tx.blockIdxBucket.Delete(result.Dropped[i][:])
+		if err != nil {
+			return err
+		}
+	}
+
+	// Delete the block files following the last consistent point, along
+	// with those left after a missing one, then truncate the block files
+	// to it.  The write cursor saved in the metadata on commit is the one
+	// of the block store.
+	wc := db.store.writeCursor
+	wc.RLock()
+	lastFileNum := wc.curFileNum
+	wc.RUnlock()
+	if result.WriteFileNum > lastFileNum {
+		lastFileNum = result.WriteFileNum
+	}
+	for _, bad := range result.BadBlocks {
+		if bad.FileNum > lastFileNum {
+			lastFileNum = bad.FileNum
+		}
+	}
+	db.store.handleRollback(result.ConsistentFileNum, result.ConsistentOffset)
+	for fileNum := result.ConsistentFileNum + 1; fileNum <= lastFileNum; fileNum++ {
+		err := os.Remove(blockFilePath(db.store.basePath, fileNum))
+		if err != nil && !os.IsNotExist(err) {
+			str := fmt.Sprintf("failed to delete block file %d",
+				fileNum)
+			return makeDbErr(database.ErrDriverSpecific, str, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if err := db.cache.flush(); err != nil {
+		return err
+	}
+	result.Repaired = true
+	return nil
+}
+
+// checkAndRepair checks the consistency of the database being opened at the
+// check level, and repairs it when inconsistent and allowed to, returning an
+// ErrCorruption error otherwise.
+func (db *db) checkAndRepair(level CheckLevel, repair bool) (*CheckResult, error) {
+	tx, err := db.begin(true)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if !tx.closed {
+			tx.close()
+		}
+	}()
+
+	result, err := db.check(tx, level)
+	if err != nil {
+		return nil, err
+	}
+	if result.Consistent() {
+		return result, nil
+	}
+
+	for _, bad := range result.BadBlocks {
+		log.Warnf("Block %v in file %d at offset %d is inconsistent: %s",
+			bad.Hash, bad.FileNum, bad.Offset, bad.Reason)
+	}
+	str := fmt.Sprintf("metadata claims file %d, offset %d, but the "+
+		"block data is consistent up to file %d, offset %d with %d "+
+		"blocks stored after", result.WriteFileNum, result.WriteOffset,
+		result.ConsistentFileNum, result.ConsistentOffset,
+		len(result.Dropped))
+	log.Warnf("***Database corruption detected***: %v", str)
+	if !repair {
+		return nil, makeDbErr(database.ErrCorruption, str, nil)
+	}
+
+	log.Infof("Repairing the database by dropping the %d blocks stored "+
+		"after file %d, offset %d", len(result.Dropped),
+		result.ConsistentFileNum, result.ConsistentOffset)
+	if err := db.repair(tx, result); err != nil {
+		return nil, err
+	}
+	log.Infof("Database repaired")
+	return result, nil
+}
+
+// Check checks the consistency of the block files with the metadata at the
+// check level, without repairing it.  The blocks written while it runs are not
+// checked.  A full check reads every block, which takes a while.
+func (db *db) Check(level CheckLevel) (*CheckResult, error) {
+	tx, err := db.begin(false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.close()
+
+	return db.check(tx, level)
+}
+
+// LastRepair returns the result of the check which repaired the database when
+// it was opened, or nil if it wasn't repaired.
+func (db *db) LastRepair() *CheckResult {
+	return db.lastRepair
+}
@@ -8,6 +8,7 @@
 package ffldb
 
 import (
+	"bytes"
 	"compress/bzip2"
 	"encoding/binary"
 	"fmt"
@@ -17,11 +18,13 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/cockroachdb/pebble"
 	"github.com/lbryio/lbcd/database"
 	"github.com/lbryio/lbcd/wire"
 	btcutil "github.com/lbryio/lbcutil"
 	"github.com/syndtr/goleveldb/leveldb"
 	ldberrors "github.com/syndtr/goleveldb/leveldb/errors"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 var (
@@ -38,7 +41,7 @@ var (
 
 // loadBlocks loads the blocks contained in the testdata directory and returns
 // a slice of them.
-func loadBlocks(t *testing.T, dataFile string, network wire.BitcoinNet) ([]*btcutil.Block, error) {
+func loadBlocks(t testing.TB, dataFile string, network wire.BitcoinNet) ([]*btcutil.Block, error) {
 	// Open the file that contains the blocks for reading.
 	fi, err := os.Open(dataFile)
 	if err != nil {
@@ -148,6 +151,8 @@ func TestConvertErr(t *testing.T) {
 		{leveldb.ErrClosed, database.ErrDbNotOpen},
 		{leveldb.ErrSnapshotReleased, database.ErrTxClosed},
 		{leveldb.ErrIterReleased, database.ErrTxClosed},
+		{pebble.ErrClosed, database.ErrDbNotOpen},
+		{errIterReleased, database.ErrTxClosed},
 	}
 
 	for i, test := range tests {
@@ -179,7 +184,7 @@ func TestCornerCases(t *testing.T) {
 	// directory is needed.
 	testName := "openDB: fail due to file at target location"
 	wantErrCode := database.ErrDriverSpecific
-	idb, err := openDB(dbPath, blockDataNet, true)
+	idb, err := openDB(ldbBackend, dbPath, dbPath, blockDataNet, Options{}, true)
 	if !checkDbError(t, testName, err, wantErrCode) {
 		if err == nil {
 			idb.Close()
@@ -191,7 +196,7 @@ func TestCornerCases(t *testing.T) {
 	// Remove the file and create the database to run tests against.  It
 	// should be successful this time.
 	_ = os.RemoveAll(dbPath)
-	idb, err = openDB(dbPath, blockDataNet, true)
+	idb, err = openDB(ldbBackend, dbPath, dbPath, blockDataNet, Options{}, true)
 	if err != nil {
 		t.Errorf("openDB: unexpected error: %v", err)
 		return
@@ -215,14 +220,14 @@ func TestCornerCases(t *testing.T) {
 	_ = os.RemoveAll(filePath)
 
 	// Close the underlying leveldb database out from under the database.
-	ldb := idb.(*db).cache.ldb
-	ldb.Close()
+	mdb := idb.(*db).cache.mdb
+	mdb.Close()
 
 	// Ensure initilization errors in the underlying database work as
 	// expected.
 	testName = "initDB: reinitialization"
 	wantErrCode = database.ErrDbNotOpen
-	err = initDB(ldb)
+	err = initDB(mdb)
 	if !checkDbError(t, testName, err, wantErrCode) {
 		return
 	}
@@ -704,3 +709,582 @@ func TestFailureScenarios(t *testing.T) {
 	// Test various corruption scenarios.
 	testCorruption(tc)
 }
+
+// TestStats ensures the statistics of the database track the blocks written
+// and read.
+func TestStats(t *testing.T) {
+	// Create a new database to run tests against.
+	dbPath := filepath.Join(os.TempDir(), "ffldb-stats")
+	_ = os.RemoveAll(dbPath)
+	idb, err := database.Create(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Errorf("Failed to create test database (%s) %v", dbType, err)
+		return
+	}
+	defer os.RemoveAll(dbPath)
+	defer idb.Close()
+
+	blocks, err := loadBlocks(t, blockDataFile, blockDataNet)
+	if err != nil {
+		t.Errorf("loadBlocks: Unexpected error: %v", err)
+		return
+	}
+
+	var blockBytes uint64
+	err = idb.Update(func(tx database.Tx) error {
+		for _, block := range blocks {
+			if err := tx.StoreBlock(block); err != nil {
+				return err
+			}
+			blockBytes += uint64(block.MsgBlock().SerializeSize())
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("StoreBlock: Unexpected error: %v", err)
+		return
+	}
+	err = idb.View(func(tx database.Tx) error {
+		_, err := tx.FetchBlock(blocks[0].Hash())
+		return err
+	})
+	if err != nil {
+		t.Errorf("FetchBlock: Unexpected error: %v", err)
+		return
+	}
+
+	stats, err := idb.(*db).Stats()
+	if err != nil {
+		t.Errorf("Stats: Unexpected error: %v", err)
+		return
+	}
+
+	// Each block is written with its network, length and checksum.
+	wantWritten := blockBytes + uint64(len(blocks))*12
+	if stats.BlockWriteBytes != wantWritten {
+		t.Errorf("Stats: unexpected block write bytes - got %d, want %d",
+			stats.BlockWriteBytes, wantWritten)
+	}
+	if stats.BlockFilesSize != int64(wantWritten) {
+		t.Errorf("Stats: unexpected block files size - got %d, want %d",
+			stats.BlockFilesSize, wantWritten)
+	}
+	if stats.BlockReadBytes == 0 {
+		t.Errorf("Stats: no block read bytes")
+	}
+	if stats.OpenBlockFiles != 1 {
+		t.Errorf("Stats: unexpected open block files - got %d, want 1",
+			stats.OpenBlockFiles)
+	}
+	if stats.CacheHits+stats.CacheMisses == 0 {
+		t.Errorf("Stats: no cache lookups")
+	}
+
+	// Flush the block index to the leveldb tables for its size to be
+	// accounted.
+	pdb := idb.(*db)
+	if err := pdb.cache.flush(); err != nil {
+		t.Errorf("flush: Unexpected error: %v", err)
+		return
+	}
+	ldb := pdb.cache.mdb.(*ldbStore).ldb
+	if err := ldb.CompactRange(util.Range{}); err != nil {
+		t.Errorf("CompactRange: Unexpected error: %v", err)
+		return
+	}
+	size, err := pdb.BucketsSize([][]byte{blockIdxBucketName})
+	if err != nil {
+		t.Errorf("BucketsSize: Unexpected error: %v", err)
+		return
+	}
+	if size == 0 {
+		t.Errorf("BucketsSize: no size of the block index")
+	}
+	size, err = pdb.BucketsSize([][]byte{[]byte("missing")})
+	if err != nil {
+		t.Errorf("BucketsSize: Unexpected error: %v", err)
+		return
+	}
+	if size != 0 {
+		t.Errorf("BucketsSize: unexpected size of a missing bucket - "+
+			"got %d, want 0", size)
+	}
+}
+
+// TestMigrateBlockFiles ensures the block files stored with the metadata are
+// moved once they are stored apart.
+func TestMigrateBlockFiles(t *testing.T) {
+	dbPath := filepath.Join(os.TempDir(), "ffldb-migrateblockfiles")
+	blocksPath := filepath.Join(dbPath, "blocks")
+	_ = os.RemoveAll(dbPath)
+	defer os.RemoveAll(dbPath)
+
+	blocks, err := loadBlocks(t, blockDataFile, blockDataNet)
+	if err != nil {
+		t.Errorf("loadBlocks: Unexpected error: %v", err)
+		return
+	}
+
+	// Store blocks in a few files with the metadata.
+	idb, err := openDB(ldbBackend, dbPath, dbPath, blockDataNet, Options{}, true)
+	if err != nil {
+		t.Errorf("openDB: unexpected error: %v", err)
+		return
+	}
+	idb.(*db).store.maxBlockFileSize = 1024
+	err = idb.Update(func(tx database.Tx) error {
+		for _, block := range blocks[:20] {
+			if err := tx.StoreBlock(block); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	idb.Close()
+	if err != nil {
+		t.Errorf("StoreBlock: Unexpected error: %v", err)
+		return
+	}
+	files, _ := filepath.Glob(filepath.Join(dbPath, "*.fdb"))
+	if len(files) < 2 {
+		t.Errorf("unexpected number of block files - got %d, want "+
+			"at least 2", len(files))
+		return
+	}
+
+	// Simulate a migration interrupted after copying the first file.
+	if err := os.MkdirAll(blocksPath, 0700); err != nil {
+		t.Errorf("MkdirAll: unexpected error: %v", err)
+		return
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Errorf("ReadFile: unexpected error: %v", err)
+		return
+	}
+	copied := filepath.Join(blocksPath, filepath.Base(files[0]))
+	if err := os.WriteFile(copied, data, 0600); err != nil {
+		t.Errorf("WriteFile: unexpected error: %v", err)
+		return
+	}
+
+	idb, err = openDB(ldbBackend, dbPath, blocksPath, blockDataNet, Options{}, false)
+	if err != nil {
+		t.Errorf("openDB: unexpected error: %v", err)
+		return
+	}
+	defer idb.Close()
+	if left, _ := filepath.Glob(filepath.Join(dbPath, "*.fdb")); len(left) != 0 {
+		t.Errorf("block files left with the metadata: %v", left)
+	}
+	moved, _ := filepath.Glob(filepath.Join(blocksPath, "*.fdb"))
+	if len(moved) != len(files) {
+		t.Errorf("unexpected number of moved block files - got %d, "+
+			"want %d", len(moved), len(files))
+	}
+	err = idb.View(func(tx database.Tx) error {
+		for _, block := range blocks[:20] {
+			if _, err := tx.FetchBlock(block.Hash()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("FetchBlock: unexpected error: %v", err)
+	}
+}
+
+// TestBackup ensures the backup of a database holds the blocks and metadata
+// stored before it started, and can be opened as a database of the same
+// metadata backend.
+func TestBackup(t *testing.T) {
+	for _, backend := range []*metadataBackend{ldbBackend, pebbleBackend} {
+		testBackup(t, backend)
+	}
+}
+
+// testBackup runs the backup test against a database of the metadata backend.
+func testBackup(t *testing.T, backend *metadataBackend) {
+	dbPath := filepath.Join(os.TempDir(), backend.dbType+"-backup")
+	backupPath := filepath.Join(os.TempDir(), backend.dbType+"-backup-copy")
+	_ = os.RemoveAll(dbPath)
+	_ = os.RemoveAll(backupPath)
+	idb, err := database.Create(backend.dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Errorf("Failed to create test database (%s) %v",
+			backend.dbType, err)
+		return
+	}
+	defer os.RemoveAll(dbPath)
+	defer os.RemoveAll(backupPath)
+	defer idb.Close()
+
+	// Use small block files so the blocks span several of them.
+	idb.(*db).store.maxBlockFileSize = 4096
+
+	blocks, err := loadBlocks(t, blockDataFile, blockDataNet)
+	if err != nil {
+		t.Errorf("loadBlocks: Unexpected error: %v", err)
+		return
+	}
+	key := []byte("backupkey")
+	store := func(blocks []*btcutil.Block, value string) error {
+		return idb.Update(func(tx database.Tx) error {
+			for _, block := range blocks {
+				if err := tx.StoreBlock(block); err != nil {
+					return err
+				}
+			}
+			return tx.Metadata().Put(key, []byte(value))
+		})
+	}
+	if err := store(blocks[:128], "before"); err != nil {
+		t.Errorf("StoreBlock: Unexpected error: %v", err)
+		return
+	}
+
+	if err := idb.(*db).Backup(backupPath); err != nil {
+		t.Errorf("Backup: Unexpected error: %v", err)
+		return
+	}
+	if err := idb.(*db).Backup(backupPath); err == nil {
+		t.Errorf("Backup: expected error for existing directory")
+	}
+	if err := store(blocks[128:], "after"); err != nil {
+		t.Errorf("StoreBlock: Unexpected error: %v", err)
+		return
+	}
+
+	backup, err := openDB(backend, backupPath, backupPath, blockDataNet,
+		Options{}, false)
+	if err != nil {
+		t.Errorf("openDB: Unexpected error: %v", err)
+		return
+	}
+	defer backup.Close()
+	err = backup.View(func(tx database.Tx) error {
+		if value := tx.Metadata().Get(key); string(value) != "before" {
+			return fmt.Errorf("unexpected metadata value %q", value)
+		}
+		for i, block := range blocks {
+			has, err := tx.HasBlock(block.Hash())
+			if err != nil {
+				return err
+			}
+			if has != (i < 128) {
+				return fmt.Errorf("unexpected HasBlock %v for "+
+					"block %d", has, i)
+			}
+			if !has {
+				continue
+			}
+			if _, err := tx.FetchBlock(block.Hash()); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("backup: %v", err)
+	}
+}
+
+// TestMmapBlockFiles ensures the blocks read from memory mapped block files
+// match those stored.
+func TestMmapBlockFiles(t *testing.T) {
+	dbPath := filepath.Join(os.TempDir(), "ffldb-mmapblockfiles")
+	_ = os.RemoveAll(dbPath)
+	defer os.RemoveAll(dbPath)
+
+	blocks, err := loadBlocks(t, blockDataFile, blockDataNet)
+	if err != nil {
+		t.Errorf("loadBlocks: Unexpected error: %v", err)
+		return
+	}
+
+	// Store blocks in a few files, so all but the last one are read-only.
+	opts := Options{MmapBlockFiles: true}
+	idb, err := openDB(ldbBackend, dbPath, dbPath, blockDataNet, opts, true)
+	if err != nil {
+		t.Errorf("openDB: unexpected error: %v", err)
+		return
+	}
+	defer idb.Close()
+	store := idb.(*db).store
+	store.maxBlockFileSize = 1024
+	err = idb.Update(func(tx database.Tx) error {
+		for _, block := range blocks[:20] {
+			if err := tx.StoreBlock(block); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("StoreBlock: Unexpected error: %v", err)
+		return
+	}
+
+	err = idb.View(func(tx database.Tx) error {
+		for _, block := range blocks[:20] {
+			want, err := block.Bytes()
+			if err != nil {
+				return err
+			}
+			got, err := tx.FetchBlock(block.Hash())
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(got, want) {
+				return fmt.Errorf("block %v does not match",
+					block.Hash())
+			}
+			region, err := tx.FetchBlockRegion(&database.BlockRegion{
+				Hash:   block.Hash(),
+				Offset: 4,
+				Len:    32,
+			})
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(region, want[4:36]) {
+				return fmt.Errorf("region of block %v does not "+
+					"match", block.Hash())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("FetchBlock: unexpected error: %v", err)
+		return
+	}
+
+	if !mmapSupported {
+		return
+	}
+	store.obfMutex.RLock()
+	defer store.obfMutex.RUnlock()
+	if len(store.openBlockFiles) == 0 {
+		t.Errorf("no read-only block files were opened")
+	}
+	for fileNum, blockFile := range store.openBlockFiles {
+		if _, ok := blockFile.file.(*mappedFile); !ok {
+			t.Errorf("block file %d is not mapped", fileNum)
+		}
+	}
+}
+
+// TestMappedFileTruncated ensures reading the mapping of a block file truncated
+// below it returns an error instead of crashing.
+func TestMappedFileTruncated(t *testing.T) {
+	if !mmapSupported {
+		t.Skip("memory mapping files is not supported")
+	}
+
+	path := filepath.Join(t.TempDir(), "000000000.fdb")
+	pageSize := os.Getpagesize()
+	if err := os.WriteFile(path, make([]byte, 2*pageSize), 0600); err != nil {
+		t.Fatalf("WriteFile: unexpected error: %v", err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open: unexpected error: %v", err)
+	}
+	mapped, err := newMappedFile(file)
+	if err != nil {
+		t.Fatalf("newMappedFile: unexpected error: %v", err)
+	}
+	defer mapped.Close()
+
+	buf := make([]byte, 16)
+	if _, err := mapped.ReadAt(buf, int64(pageSize)); err != nil {
+		t.Fatalf("ReadAt: unexpected error: %v", err)
+	}
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatalf("Truncate: unexpected error: %v", err)
+	}
+	if _, err := mapped.ReadAt(buf, int64(pageSize)); err == nil {
+		t.Fatalf("ReadAt: read a truncated mapping")
+	}
+}
+
+// TestCheckAndRepair ensures the consistency check finds the blocks lost or
+// corrupted in the block files, and the repair truncates the database to the
+// last consistent point so the dropped blocks can be stored again.
+func TestCheckAndRepair(t *testing.T) {
+	dbPath := filepath.Join(os.TempDir(), "ffldb-checkandrepair")
+	_ = os.RemoveAll(dbPath)
+	defer os.RemoveAll(dbPath)
+
+	blocks, err := loadBlocks(t, blockDataFile, blockDataNet)
+	if err != nil {
+		t.Fatalf("loadBlocks: Unexpected error: %v", err)
+	}
+	blocks = blocks[:20]
+
+	// storeBlocks stores blocks in several files and returns the location
+	// of each of them.
+	storeBlocks := func(idb database.DB, blocks []*btcutil.Block) []blockLocation {
+		idb.(*db).store.maxBlockFileSize = 1024
+		err := idb.Update(func(tx database.Tx) error {
+			for _, block := range blocks {
+				if err := tx.StoreBlock(block); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("StoreBlock: Unexpected error: %v", err)
+		}
+		locs := make([]blockLocation, len(blocks))
+		err = idb.View(func(tx database.Tx) error {
+			for i, block := range blocks {
+				row, err := tx.(*transaction).fetchBlockRow(block.Hash())
+				if err != nil {
+					return err
+				}
+				locs[i] = deserializeBlockLoc(row)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("fetchBlockRow: Unexpected error: %v", err)
+		}
+		return locs
+	}
+
+	// checkStored ensures the blocks before the passed one are stored and
+	// the others are not.
+	checkStored := func(idb database.DB, stored int) {
+		err := idb.View(func(tx database.Tx) error {
+			for i, block := range blocks {
+				has, err := tx.HasBlock(block.Hash())
+				if err != nil {
+					return err
+				}
+				if has != (i < stored) {
+					return fmt.Errorf("HasBlock(%d) = %v", i, has)
+				}
+				if !has {
+					continue
+				}
+				if _, err := tx.FetchBlock(block.Hash()); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("checkStored: unexpected error: %v", err)
+		}
+	}
+
+	idb, err := openDB(ldbBackend, dbPath, dbPath, blockDataNet, Options{}, true)
+	if err != nil {
+		t.Fatalf("openDB: unexpected error: %v", err)
+	}
+	locs := storeBlocks(idb, blocks)
+	result, err := idb.(*db).Check(CheckFull)
+	if err != nil {
+		t.Fatalf("Check: unexpected error: %v", err)
+	}
+	if !result.Consistent() || result.Blocks != len(blocks) {
+		t.Fatalf("Check: unexpected result %+v", result)
+	}
+
+	// Corrupt a byte of block 5, which only a full check finds.
+	loc := locs[5]
+	file, err := os.OpenFile(blockFilePath(dbPath, loc.blockFileNum),
+		os.O_RDWR, 0)
+	if err != nil {
+		t.Fatalf("OpenFile: unexpected error: %v", err)
+	}
+	_, err = file.WriteAt([]byte{0xff}, int64(loc.fileOffset)+20)
+	file.Close()
+	if err != nil {
+		t.Fatalf("WriteAt: unexpected error: %v", err)
+	}
+	result, err = idb.(*db).Check(CheckQuick)
+	if err != nil || !result.Consistent() {
+		t.Fatalf("Check: unexpected result %+v, %v", result, err)
+	}
+	result, err = idb.(*db).Check(CheckFull)
+	if err != nil {
+		t.Fatalf("Check: unexpected error: %v", err)
+	}
+	if len(result.BadBlocks) != 1 || result.BadBlocks[0].Hash != *blocks[5].Hash() ||
+		len(result.Dropped) != len(blocks)-5 {
+
+		t.Fatalf("Check: unexpected result %+v", result)
+	}
+	if err := idb.Close(); err != nil {
+		t.Fatalf("Close: unexpected error: %v", err)
+	}
+
+	// The corruption is only detected on open at the full check level, and
+	// only repaired when allowed to.
+	opts := Options{Check: CheckQuick}
+	idb, err = openDB(ldbBackend, dbPath, dbPath, blockDataNet, opts, false)
+	if err != nil {
+		t.Fatalf("openDB: unexpected error: %v", err)
+	}
+	idb.Close()
+	opts = Options{Check: CheckFull}
+	_, err = openDB(ldbBackend, dbPath, dbPath, blockDataNet, opts, false)
+	if !checkDbError(t, "openDB", err, database.ErrCorruption) {
+		return
+	}
+	opts = Options{Check: CheckFull, Repair: true}
+	idb, err = openDB(ldbBackend, dbPath, dbPath, blockDataNet, opts, false)
+	if err != nil {
+		t.Fatalf("openDB: unexpected error: %v", err)
+	}
+	repair := idb.(*db).LastRepair()
+	if repair == nil || !repair.Repaired || len(repair.Dropped) != len(blocks)-5 {
+		t.Fatalf("LastRepair: unexpected result %+v", repair)
+	}
+	checkStored(idb, 5)
+
+	// Store the dropped blocks again, then delete one of the block files
+	// but the last one, which puts the data behind the metadata.
+	locs = append(locs[:5], storeBlocks(idb, blocks[5:])...)
+	checkStored(idb, len(blocks))
+	if err := idb.Close(); err != nil {
+		t.Fatalf("Close: unexpected error: %v", err)
+	}
+	fileNum := locs[12].blockFileNum
+	if fileNum == locs[len(locs)-1].blockFileNum {
+		t.Fatalf("block 12 is in the last block file")
+	}
+	if err := os.Remove(blockFilePath(dbPath, fileNum)); err != nil {
+		t.Fatalf("Remove: unexpected error: %v", err)
+	}
+	_, err = openDB(ldbBackend, dbPath, dbPath, blockDataNet, Options{}, false)
+	if !checkDbError(t, "openDB", err, database.ErrCorruption) {
+		return
+	}
+	idb, err = openDB(ldbBackend, dbPath, dbPath, blockDataNet,
+		Options{Repair: true}, false)
+	if err != nil {
+		t.Fatalf("openDB: unexpected error: %v", err)
+	}
+	defer idb.Close()
+	first := 0
+	for locs[first].blockFileNum < fileNum {
+		first++
+	}
+	repair = idb.(*db).LastRepair()
+	if repair == nil || len(repair.Dropped) != len(blocks)-first {
+		t.Fatalf("LastRepair: unexpected result %+v", repair)
+	}
+	checkStored(idb, first)
+	for num := fileNum; num <= locs[len(locs)-1].blockFileNum; num++ {
+		if _, err := os.Stat(blockFilePath(dbPath, num)); !os.IsNotExist(err) {
+			t.Fatalf("block file %d was not deleted", num)
+		}
+	}
+	storeBlocks(idb, blocks[first:])
+	checkStored(idb, len(blocks))
+}
@@ -0,0 +1,117 @@
+package descriptor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// inputCharset is the set of the characters a descriptor may consist of,
+// ordered so that the most common ones belong to the first group of 32.
+const inputCharset = "0123456789()[],'/*abcdefgh@:$%{}" +
+	"IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~" +
+	"ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+
+// checksumCharset is the set of the characters of a checksum, which are those
+// of bech32.
+const checksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// checksumLength is the number of characters of a checksum.
+const checksumLength = 8
+
+// polyMod computes the BCH code of the passed symbols into c, whose generator
+// is that of BIP 380.
+func polyMod(c uint64, symbol int) uint64 {
+	c0 := c >> 35
+	c = (c&0x7ffffffff)<<5 ^ uint64(symbol)
+	if c0&1 != 0 {
+		c ^= 0xf5dee51989
+	}
+	if c0&2 != 0 {
+		c ^= 0xa9fdca3312
+	}
+	if c0&4 != 0 {
+		c ^= 0x1bab10e32d
+	}
+	if c0&8 != 0 {
+		c ^= 0x3706b1677a
+	}
+	if c0&16 != 0 {
+		c ^= 0x644d626ffd
+	}
+	return c
+}
+
+// Checksum returns the checksum of the descriptor, which must not have one
+// already.
+func Checksum(desc string) (string, error) {
+	c := uint64(1)
+	class, classCount := 0, 0
+	for i, r := range desc {
+		pos := strings.IndexRune(inputCharset, r)
+		if pos < 0 {
+			return "", fmt.Errorf("invalid character %q at position %d",
+				r, i)
+		}
+
+		// Each character is the symbol of its position in its group,
+		// and the groups of every three characters are a symbol too.
+		c = polyMod(c, pos&31)
+		class = class*3 + pos>>5
+		classCount++
+		if classCount == 3 {
+			c = polyMod(c, class)
+			class, classCount = 0, 0
+		}
+	}
+	if classCount > 0 {
+		c = polyMod(c, class)
+	}
+	for i := 0; i < checksumLength; i++ {
+		c = polyMod(c, 0)
+	}
+	c ^= 1
+
+	var checksum [checksumLength]byte
+	for i := range checksum {
+		checksum[i] = checksumCharset[(c>>(5*(7-i)))&31]
+	}
+	return string(checksum[:]), nil
+}
+
+// AddChecksum returns the descriptor followed by its checksum.
+func AddChecksum(desc string) (string, error) {
+	checksum, err := Checksum(desc)
+	if err != nil {
+		return "", err
+	}
+	return desc + "#" + checksum, nil
+}
+
+// splitChecksum returns the descriptor without its checksum, after verifying
+// the checksum when there is one.  A missing checksum is an error when it is
+// required.
+func splitChecksum(desc string, requireChecksum bool) (string, error) {
+	pos := strings.LastIndexByte(desc, '#')
+	if pos < 0 {
+		if requireChecksum {
+			return "", ErrMissingChecksum
+		}
+		return desc, nil
+	}
+
+	checksum := desc[pos+1:]
+	desc = desc[:pos]
+	if len(checksum) != checksumLength {
+		return "", fmt.Errorf("expected %d character checksum, not %d "+
+			"characters", checksumLength, len(checksum))
+	}
+	expected, err := Checksum(desc)
+	if err != nil {
+		return "", err
+	}
+	if checksum != expected {
+		return "", fmt.Errorf("provided checksum %q does not match "+
+			"computed checksum %q", checksum, expected)
+	}
+	return desc, nil
+}
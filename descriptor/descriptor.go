@@ -0,0 +1,469 @@
+// Package descriptor implements the output script descriptors of BIP 380 to
+// 386, which describe the output scripts of a wallet, and may derive a range of
+// them from extended keys:
+// https://github.com/bitcoin/bips/blob/master/bip-0380.mediawiki
+package descriptor
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lbryio/lbcd/btcec"
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/txscript"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+// ErrMissingChecksum is returned when a descriptor which must have a checksum
+// has none.
+var ErrMissingChecksum = errors.New("missing checksum")
+
+const (
+	// maxPubKeysPerMultiSig is the maximum number of keys of a multisig
+	// script.
+	maxPubKeysPerMultiSig = 20
+
+	// maxBareMultiSigPubKeys is the maximum number of keys of a multisig
+	// script which isn't nested in a script hash.
+	maxBareMultiSigPubKeys = 3
+
+	// maxScriptElementSize is the maximum size of a pay-to-script-hash
+	// script.
+	maxScriptElementSize = 520
+
+	// maxWitnessScriptSize is the maximum standard size of a
+	// pay-to-witness-script-hash script.
+	maxWitnessScriptSize = 3600
+)
+
+// context is the script expression an expression is nested in.
+type context int
+
+const (
+	topContext context = iota
+	shContext
+	wshContext
+)
+
+// expr is a SCRIPT expression of a descriptor.
+type expr struct {
+	// function is the name of the function of the expression, such as
+	// pkh.
+	function string
+
+	// keys are the KEY expressions of the functions of keys, and threshold
+	// is the number of signatures of a multisig script.
+	keys      []*key
+	threshold int
+
+	// sub is the expression nested in sh and wsh.
+	sub *expr
+
+	// addr is the address of an addr expression, and raw the script of a
+	// raw expression.
+	addr btcutil.Address
+	raw  []byte
+}
+
+// Descriptor is an output script descriptor.
+type Descriptor struct {
+	root *expr
+}
+
+// Parse parses an output script descriptor of the network.  The checksum
+// following the descriptor, if any, is verified, and it is required when
+// requireChecksum is set.
+func Parse(desc string, params *chaincfg.Params, requireChecksum bool) (*Descriptor, error) {
+	desc, err := splitChecksum(desc, requireChecksum)
+	if err != nil {
+		return nil, err
+	}
+	root, err := parseExpr(desc, params, topContext)
+	if err != nil {
+		return nil, err
+	}
+	return &Descriptor{root: root}, nil
+}
+
+// splitCall returns the name and the arguments of a function call expression,
+// such as pkh(KEY).
+func splitCall(s string) (string, string, error) {
+	open := strings.IndexByte(s, '(')
+	if open < 0 || !strings.HasSuffix(s, ")") {
+		return "", "", fmt.Errorf("%q is not a script expression", s)
+	}
+	return s[:open], s[open+1 : len(s)-1], nil
+}
+
+// splitArgs splits the arguments of a function call on the commas which
+// aren't nested in parentheses.
+func splitArgs(s string) []string {
+	var args []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(args, s[start:])
+}
+
+// parseExpr parses a SCRIPT expression nested in the passed context.
+func parseExpr(s string, params *chaincfg.Params, ctx context) (*expr, error) {
+	function, args, err := splitCall(s)
+	if err != nil {
+		return nil, err
+	}
+	e := &expr{function: function}
+	witness := ctx == wshContext
+
+	switch function {
+	case "pk", "pkh":
+		k, err := parseKey(args, params, witness)
+		if err != nil {
+			return nil, err
+		}
+		e.keys = []*key{k}
+
+	case "wpkh", "combo":
+		switch {
+		case function == "combo" && ctx != topContext:
+			return nil, errors.New("can only have combo() at top level")
+		case ctx == wshContext:
+			return nil, errors.New("can only have wpkh() at top level " +
+				"or inside sh()")
+		}
+		k, err := parseKey(args, params, function == "wpkh")
+		if err != nil {
+			return nil, err
+		}
+		e.keys = []*key{k}
+
+	case "multi", "sortedmulti":
+		if err := e.parseMultiSig(args, params, ctx); err != nil {
+			return nil, err
+		}
+
+	case "sh", "wsh":
+		switch {
+		case function == "sh" && ctx != topContext:
+			return nil, errors.New("can only have sh() at top level")
+		case function == "wsh" && ctx == wshContext:
+			return nil, errors.New("can only have wsh() at top level " +
+				"or inside sh()")
+		}
+		subCtx := shContext
+		if function == "wsh" {
+			subCtx = wshContext
+		}
+		e.sub, err = parseExpr(args, params, subCtx)
+		if err != nil {
+			return nil, err
+		}
+
+	case "addr":
+		if ctx != topContext {
+			return nil, errors.New("can only have addr() at top level")
+		}
+		// The hex public keys which DecodeAddress accepts aren't
+		// addresses.
+		e.addr, err = btcutil.DecodeAddress(args, params)
+		_, isPubKey := e.addr.(*btcutil.AddressPubKey)
+		if err != nil || isPubKey || !e.addr.IsForNet(params) {
+			return nil, fmt.Errorf("address %q is not valid", args)
+		}
+
+	case "raw":
+		if ctx != topContext {
+			return nil, errors.New("can only have raw() at top level")
+		}
+		e.raw, err = hex.DecodeString(args)
+		if err != nil {
+			return nil, fmt.Errorf("raw script %q is not hex", args)
+		}
+
+	default:
+		return nil, fmt.Errorf("%q is not a valid descriptor function",
+			function)
+	}
+	return e, nil
+}
+
+// parseMultiSig parses the threshold and the keys of a multisig expression.
+func (e *expr) parseMultiSig(args string, params *chaincfg.Params, ctx context) error {
+	argv := splitArgs(args)
+	threshold, err := strconv.ParseUint(argv[0], 10, 32)
+	if err != nil {
+		return fmt.Errorf("multi threshold %q is not valid", argv[0])
+	}
+	e.threshold = int(threshold)
+
+	scriptSize := 3
+	for _, arg := range argv[1:] {
+		k, err := parseKey(arg, params, ctx == wshContext)
+		if err != nil {
+			return err
+		}
+		e.keys = append(e.keys, k)
+		if k.extKey != nil {
+			scriptSize += 1 + btcec.PubKeyBytesLenCompressed
+		} else {
+			scriptSize += 1 + len(k.pubKey)
+		}
+	}
+
+	switch n := len(e.keys); {
+	case n == 0 || n > maxPubKeysPerMultiSig:
+		return fmt.Errorf("cannot have %d keys in multisig; must have "+
+			"between 1 and %d keys, inclusive", n,
+			maxPubKeysPerMultiSig)
+	case e.threshold < 1 || e.threshold > n:
+		return fmt.Errorf("multisig threshold cannot be %d, must be "+
+			"between 1 and %d", e.threshold, n)
+	case ctx == topContext && n > maxBareMultiSigPubKeys:
+		return fmt.Errorf("cannot have %d pubkeys in bare multisig; "+
+			"only at most %d pubkeys", n, maxBareMultiSigPubKeys)
+	case ctx == shContext && scriptSize > maxScriptElementSize:
+		return fmt.Errorf("P2SH script is too large, %d bytes is "+
+			"larger than %d bytes", scriptSize,
+			maxScriptElementSize)
+	case ctx == wshContext && scriptSize > maxWitnessScriptSize:
+		return fmt.Errorf("P2WSH script is too large, %d bytes is "+
+			"larger than %d bytes", scriptSize,
+			maxWitnessScriptSize)
+	}
+	return nil
+}
+
+// allKeys returns the keys of the expression and of the expression it nests.
+func (e *expr) allKeys() []*key {
+	if e.sub != nil {
+		return e.sub.allKeys()
+	}
+	return e.keys
+}
+
+// String returns the canonical form of the expression, with its public keys.
+func (e *expr) String() string {
+	var args []string
+	switch e.function {
+	case "multi", "sortedmulti":
+		args = append(args, fmt.Sprint(e.threshold))
+		fallthrough
+	case "pk", "pkh", "wpkh", "combo":
+		for _, k := range e.keys {
+			args = append(args, k.String())
+		}
+	case "sh", "wsh":
+		args = append(args, e.sub.String())
+	case "addr":
+		args = append(args, e.addr.EncodeAddress())
+	case "raw":
+		args = append(args, hex.EncodeToString(e.raw))
+	}
+	return e.function + "(" + strings.Join(args, ",") + ")"
+}
+
+// pubKeysAt returns the serialized public keys of the expression at the
+// passed index.
+func (e *expr) pubKeysAt(index uint32) ([][]byte, error) {
+	pubKeys := make([][]byte, 0, len(e.keys))
+	for _, k := range e.keys {
+		pubKey, err := k.pubKeyAt(index)
+		if err != nil {
+			return nil, err
+		}
+		pubKeys = append(pubKeys, pubKey)
+	}
+	return pubKeys, nil
+}
+
+// script returns the output script of an expression other than combo at the
+// passed index.
+func (e *expr) script(index uint32) ([]byte, error) {
+	if e.sub != nil {
+		subScript, err := e.sub.script(index)
+		if err != nil {
+			return nil, err
+		}
+		if e.function == "sh" {
+			return payToScriptHash(subScript), nil
+		}
+		return payToWitnessScriptHash(subScript), nil
+	}
+
+	switch e.function {
+	case "addr":
+		return txscript.PayToAddrScript(e.addr)
+	case "raw":
+		return e.raw, nil
+	}
+
+	pubKeys, err := e.pubKeysAt(index)
+	if err != nil {
+		return nil, err
+	}
+	switch e.function {
+	case "pk":
+		return txscript.NewScriptBuilder().AddData(pubKeys[0]).
+			AddOp(txscript.OP_CHECKSIG).Script()
+	case "pkh":
+		return payToPubKeyHash(pubKeys[0]), nil
+	case "wpkh":
+		return payToWitnessPubKeyHash(pubKeys[0]), nil
+	}
+
+	// The keys of a sorted multisig script are sorted by their serialized
+	// public keys.
+	if e.function == "sortedmulti" {
+		sort.Slice(pubKeys, func(i, j int) bool {
+			return bytes.Compare(pubKeys[i], pubKeys[j]) < 0
+		})
+	}
+	builder := txscript.NewScriptBuilder().AddInt64(int64(e.threshold))
+	for _, pubKey := range pubKeys {
+		builder.AddData(pubKey)
+	}
+	return builder.AddInt64(int64(len(pubKeys))).
+		AddOp(txscript.OP_CHECKMULTISIG).Script()
+}
+
+// payToPubKeyHash returns a pay-to-pubkey-hash script of the public key.
+func payToPubKeyHash(pubKey []byte) []byte {
+	script, _ := txscript.NewScriptBuilder().AddOp(txscript.OP_DUP).
+		AddOp(txscript.OP_HASH160).AddData(btcutil.Hash160(pubKey)).
+		AddOp(txscript.OP_EQUALVERIFY).AddOp(txscript.OP_CHECKSIG).
+		Script()
+	return script
+}
+
+// payToWitnessPubKeyHash returns a pay-to-witness-pubkey-hash script of the
+// public key.
+func payToWitnessPubKeyHash(pubKey []byte) []byte {
+	script, _ := txscript.NewScriptBuilder().AddOp(txscript.OP_0).
+		AddData(btcutil.Hash160(pubKey)).Script()
+	return script
+}
+
+// payToScriptHash returns a pay-to-script-hash script of the script.
+func payToScriptHash(script []byte) []byte {
+	script, _ = txscript.NewScriptBuilder().AddOp(txscript.OP_HASH160).
+		AddData(btcutil.Hash160(script)).AddOp(txscript.OP_EQUAL).
+		Script()
+	return script
+}
+
+// payToWitnessScriptHash returns a pay-to-witness-script-hash script of the
+// script.
+func payToWitnessScriptHash(script []byte) []byte {
+	hash := sha256.Sum256(script)
+	script, _ = txscript.NewScriptBuilder().AddOp(txscript.OP_0).
+		AddData(hash[:]).Script()
+	return script
+}
+
+// String returns the canonical form of the descriptor, with its public keys,
+// followed by its checksum.
+func (d *Descriptor) String() string {
+	// The canonical form only consists of valid characters.
+	desc, _ := AddChecksum(d.root.String())
+	return desc
+}
+
+// IsRange returns whether the descriptor derives scripts per index.
+func (d *Descriptor) IsRange() bool {
+	for _, k := range d.root.allKeys() {
+		if k.isRange() {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSolvable returns whether the descriptor has the information to sign its
+// scripts given the private keys, which all of them but addr and raw do.
+func (d *Descriptor) IsSolvable() bool {
+	return d.root.function != "addr" && d.root.function != "raw"
+}
+
+// HasPrivateKeys returns whether the descriptor has a private key.
+func (d *Descriptor) HasPrivateKeys() bool {
+	for _, k := range d.root.allKeys() {
+		if k.isPrivate() {
+			return true
+		}
+	}
+	return false
+}
+
+// Scripts returns the output scripts of the descriptor at the passed index,
+// which is ignored when it isn't ranged.  A combo descriptor has the
+// pay-to-pubkey and pay-to-pubkey-hash scripts of its key, and the witness
+// scripts of a compressed key, and the other descriptors a single script.
+func (d *Descriptor) Scripts(index uint32) ([][]byte, error) {
+	if d.root.function != "combo" {
+		script, err := d.root.script(index)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{script}, nil
+	}
+
+	pubKey, err := d.root.keys[0].pubKeyAt(index)
+	if err != nil {
+		return nil, err
+	}
+	payToPubKey, err := txscript.NewScriptBuilder().AddData(pubKey).
+		AddOp(txscript.OP_CHECKSIG).Script()
+	if err != nil {
+		return nil, err
+	}
+	scripts := [][]byte{payToPubKey, payToPubKeyHash(pubKey)}
+	if len(pubKey) == btcec.PubKeyBytesLenCompressed {
+		witnessScript := payToWitnessPubKeyHash(pubKey)
+		scripts = append(scripts, witnessScript,
+			payToScriptHash(witnessScript))
+	}
+	return scripts, nil
+}
+
+// Addresses returns the addresses of the output scripts of the descriptor at
+// the passed index, which is ignored when it isn't ranged.  The scripts which
+// have no address, such as pay-to-pubkey and bare multisig scripts, are
+// skipped.
+func (d *Descriptor) Addresses(index uint32, params *chaincfg.Params) ([]btcutil.Address, error) {
+	scripts, err := d.Scripts(index)
+	if err != nil {
+		return nil, err
+	}
+	var addrs []btcutil.Address
+	for _, script := range scripts {
+		class, scriptAddrs, _, err := txscript.ExtractPkScriptAddrs(
+			script, params)
+		if err != nil || len(scriptAddrs) != 1 {
+			continue
+		}
+		switch class {
+		case txscript.PubKeyHashTy, txscript.ScriptHashTy,
+			txscript.WitnessV0PubKeyHashTy,
+			txscript.WitnessV0ScriptHashTy:
+
+			addrs = append(addrs, scriptAddrs[0])
+		}
+	}
+	return addrs, nil
+}
@@ -0,0 +1,213 @@
+package descriptor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/lbryio/lbcd/btcec"
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/txscript"
+	btcutil "github.com/lbryio/lbcutil"
+	"github.com/lbryio/lbcutil/hdkeychain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecksum(t *testing.T) {
+
+	r := require.New(t)
+
+	desc, err := AddChecksum("pkh(02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5)")
+	r.NoError(err)
+	r.Equal("pkh(02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5)#8fhd9pwu", desc)
+	checksum, err := Checksum("wpkh(02f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9)")
+	r.NoError(err)
+	r.Equal("8zl0zxma", checksum)
+	_, err = Checksum("pkh(é)")
+	r.Error(err)
+
+	params := &chaincfg.RegressionNetParams
+	_, err = Parse(desc, params, true)
+	r.NoError(err)
+	_, err = Parse(strings.TrimSuffix(desc, "#8fhd9pwu"), params, false)
+	r.NoError(err)
+	_, err = Parse(strings.TrimSuffix(desc, "#8fhd9pwu"), params, true)
+	r.Equal(ErrMissingChecksum, err)
+	_, err = Parse(strings.TrimSuffix(desc, "u")+"q", params, false)
+	r.Error(err)
+	_, err = Parse(strings.TrimSuffix(desc, "u"), params, false)
+	r.Error(err)
+}
+
+func TestParse(t *testing.T) {
+
+	r := require.New(t)
+	params := &chaincfg.RegressionNetParams
+
+	privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), bytes.Repeat([]byte{1}, 32))
+	wif, err := btcutil.NewWIF(privKey, params, true)
+	r.NoError(err)
+	pubKey := hex.EncodeToString(wif.SerializePubKey())
+	master, err := hdkeychain.NewMaster(bytes.Repeat([]byte{2}, 32), params)
+	r.NoError(err)
+	xpub, err := master.Neuter()
+	r.NoError(err)
+
+	// The canonical form has the public keys, the hardened steps marked
+	// with an apostrophe, and the hex in lower case.
+	tests := []struct {
+		desc, canonical         string
+		isRange, hasPrivateKeys bool
+	}{
+		{"pkh(" + wif.String() + ")", "pkh(" + pubKey + ")", false, true},
+		{"wpkh(" + strings.ToUpper(pubKey) + ")", "wpkh(" + pubKey + ")", false, false},
+		{"sh(wpkh([DEADBEEF/84h/1'/0h]" + xpub.String() + "/1/*))",
+			"sh(wpkh([deadbeef/84'/1'/0']" + xpub.String() + "/1/*))", true, false},
+		{"wsh(sortedmulti(1," + master.String() + "/0h/*h," + pubKey + "))",
+			"wsh(sortedmulti(1," + xpub.String() + "/0'/*'," + pubKey + "))", true, true},
+		{"combo(" + pubKey + ")", "combo(" + pubKey + ")", false, false},
+		{"raw(6A00)", "raw(6a00)", false, false},
+	}
+	for _, test := range tests {
+		d, err := Parse(test.desc, params, false)
+		r.NoError(err, test.desc)
+		canonical, err := AddChecksum(test.canonical)
+		r.NoError(err)
+		r.Equal(canonical, d.String())
+		r.Equal(test.isRange, d.IsRange(), test.desc)
+		r.Equal(test.hasPrivateKeys, d.HasPrivateKeys(), test.desc)
+		r.Equal(!strings.HasPrefix(test.desc, "raw"), d.IsSolvable())
+	}
+
+	uncompressed, err := btcutil.NewWIF(privKey, params, false)
+	r.NoError(err)
+	mainNetXpub, err := hdkeychain.NewMaster(bytes.Repeat([]byte{2}, 32),
+		&chaincfg.MainNetParams)
+	r.NoError(err)
+	invalid := []string{
+		"pkh()",
+		"pkh(" + pubKey,
+		"foo(" + pubKey + ")",
+		"pkh(02" + strings.Repeat("00", 32) + ")",
+		"wpkh(" + uncompressed.String() + ")",
+		"wsh(pkh(" + uncompressed.String() + "))",
+		"pkh(" + mainNetXpub.String() + ")",
+		"pkh(" + xpub.String() + "/0h/*)",
+		"pkh(" + xpub.String() + "/*h)",
+		"pkh(" + xpub.String() + "/2147483648)",
+		"pkh([deadbe]" + pubKey + ")",
+		"pkh([deadbeef" + pubKey + ")",
+		"sh(sh(pkh(" + pubKey + ")))",
+		"wsh(wsh(pkh(" + pubKey + ")))",
+		"wsh(wpkh(" + pubKey + "))",
+		"sh(combo(" + pubKey + "))",
+		"multi(0," + pubKey + ")",
+		"multi(2," + pubKey + ")",
+		"multi(1)",
+		"multi(1," + strings.Repeat(pubKey+",", 3) + pubKey + ")",
+		"sh(multi(1," + strings.Repeat(pubKey+",", 15) + pubKey + "))",
+		"addr(" + pubKey + ")",
+		"raw(6a0)",
+	}
+	for _, desc := range invalid {
+		_, err := Parse(desc, params, false)
+		r.Error(err, desc)
+	}
+}
+
+func TestScripts(t *testing.T) {
+
+	r := require.New(t)
+	params := &chaincfg.RegressionNetParams
+
+	var pubKeys []string
+	var serializedPubKeys [][]byte
+	for i := byte(3); i >= 1; i-- {
+		privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), bytes.Repeat([]byte{i}, 32))
+		serializedPubKeys = append(serializedPubKeys, privKey.PubKey().SerializeCompressed())
+		pubKeys = append(pubKeys, hex.EncodeToString(serializedPubKeys[len(serializedPubKeys)-1]))
+	}
+	master, err := hdkeychain.NewMaster(bytes.Repeat([]byte{2}, 32), params)
+	r.NoError(err)
+	child, err := master.Derive(hdkeychain.HardenedKeyStart + 7)
+	r.NoError(err)
+	childPubKey, err := child.ECPubKey()
+	r.NoError(err)
+
+	script := func(builder *txscript.ScriptBuilder) []byte {
+		s, err := builder.Script()
+		r.NoError(err)
+		return s
+	}
+	p2pk := script(txscript.NewScriptBuilder().AddData(serializedPubKeys[0]).
+		AddOp(txscript.OP_CHECKSIG))
+	p2pkh := payToPubKeyHash(serializedPubKeys[0])
+	p2wpkh := payToWitnessPubKeyHash(serializedPubKeys[0])
+	multiSig := script(txscript.NewScriptBuilder().AddOp(txscript.OP_2).
+		AddData(serializedPubKeys[0]).AddData(serializedPubKeys[1]).
+		AddData(serializedPubKeys[2]).AddOp(txscript.OP_3).
+		AddOp(txscript.OP_CHECKMULTISIG))
+	sorted := append([][]byte(nil), serializedPubKeys...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i], sorted[j]) < 0
+	})
+	r.NotEqual(serializedPubKeys, sorted)
+	sortedMultiSig := script(txscript.NewScriptBuilder().AddOp(txscript.OP_2).
+		AddData(sorted[0]).AddData(sorted[1]).AddData(sorted[2]).
+		AddOp(txscript.OP_3).AddOp(txscript.OP_CHECKMULTISIG))
+	keys := strings.Join(pubKeys, ",")
+
+	tests := []struct {
+		desc    string
+		scripts [][]byte
+		addrs   int
+	}{
+		{"pk(" + pubKeys[0] + ")", [][]byte{p2pk}, 0},
+		{"pkh(" + pubKeys[0] + ")", [][]byte{p2pkh}, 1},
+		{"wpkh(" + pubKeys[0] + ")", [][]byte{p2wpkh}, 1},
+		{"sh(wpkh(" + pubKeys[0] + "))", [][]byte{payToScriptHash(p2wpkh)}, 1},
+		{"multi(2," + keys + ")", [][]byte{multiSig}, 0},
+		{"sh(sortedmulti(2," + keys + "))", [][]byte{payToScriptHash(sortedMultiSig)}, 1},
+		{"sh(wsh(multi(2," + keys + ")))",
+			[][]byte{payToScriptHash(payToWitnessScriptHash(multiSig))}, 1},
+		{"combo(" + pubKeys[0] + ")", [][]byte{p2pk, p2pkh, p2wpkh, payToScriptHash(p2wpkh)}, 3},
+		{"addr(" + mustAddress(r, p2pkh, params) + ")", [][]byte{p2pkh}, 1},
+		{"raw(" + hex.EncodeToString(p2wpkh) + ")", [][]byte{p2wpkh}, 1},
+		{"pkh(" + master.String() + "/*')",
+			[][]byte{payToPubKeyHash(childPubKey.SerializeCompressed())}, 1},
+	}
+	for _, test := range tests {
+		d, err := Parse(test.desc, params, false)
+		r.NoError(err, test.desc)
+		scripts, err := d.Scripts(7)
+		r.NoError(err, test.desc)
+		r.Equal(test.scripts, scripts, test.desc)
+		addrs, err := d.Addresses(7, params)
+		r.NoError(err, test.desc)
+		r.Len(addrs, test.addrs, test.desc)
+	}
+
+	// The public descriptor of a ranged key derives the same scripts.
+	d, err := Parse("wpkh("+master.String()+"/0/1/*)", params, false)
+	r.NoError(err)
+	public, err := Parse(d.String(), params, true)
+	r.NoError(err)
+	r.False(public.HasPrivateKeys())
+	scripts, err := d.Scripts(5)
+	r.NoError(err)
+	publicScripts, err := public.Scripts(5)
+	r.NoError(err)
+	r.Equal(scripts, publicScripts)
+	_, err = d.Scripts(hdkeychain.HardenedKeyStart)
+	r.Error(err)
+}
+
+// mustAddress returns the encoded address of the standard script.
+func mustAddress(r *require.Assertions, script []byte, params *chaincfg.Params) string {
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(script, params)
+	r.NoError(err)
+	r.Len(addrs, 1)
+	return addrs[0].EncodeAddress()
+}
@@ -0,0 +1,253 @@
+package descriptor
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lbryio/lbcd/btcec"
+	"github.com/lbryio/lbcd/chaincfg"
+	btcutil "github.com/lbryio/lbcutil"
+	"github.com/lbryio/lbcutil/hdkeychain"
+)
+
+// rangeType is the type of the last derivation step of a ranged extended key.
+type rangeType int
+
+const (
+	// noRange is the range type of the keys which aren't ranged.
+	noRange rangeType = iota
+
+	// unhardenedRange derives the index of the descriptor as is.
+	unhardenedRange
+
+	// hardenedRange derives the index of the descriptor hardened.
+	hardenedRange
+)
+
+// key is a KEY expression of a descriptor: a public key, a private key in
+// wallet import format, or an extended key with its derivation path, which may
+// be preceded by the origin of the key.
+type key struct {
+	// origin is the canonical form of the origin of the key, with its
+	// brackets, or empty.
+	origin string
+
+	// pubKey is the serialized public key of the keys which aren't
+	// extended.
+	pubKey []byte
+
+	// wif is the private key of the keys in wallet import format.
+	wif *btcutil.WIF
+
+	// extKey is the extended key of the extended keys, path its derivation
+	// path, and base the key it derives.
+	extKey *hdkeychain.ExtendedKey
+	path   []uint32
+	base   *hdkeychain.ExtendedKey
+
+	// ranged is the type of the last derivation step of a ranged extended
+	// key.
+	ranged rangeType
+}
+
+// parsePath parses the derivation steps, such as 0, 1' or 2h, of a key
+// origin or of an extended key.
+func parsePath(steps []string) ([]uint32, error) {
+	path := make([]uint32, 0, len(steps))
+	for _, step := range steps {
+		hardened := strings.HasSuffix(step, "'") ||
+			strings.HasSuffix(step, "h")
+		if hardened {
+			step = step[:len(step)-1]
+		}
+		index, err := strconv.ParseUint(step, 10, 32)
+		if err != nil || index >= hdkeychain.HardenedKeyStart {
+			return nil, fmt.Errorf("key path value %q is out of range",
+				step)
+		}
+		if hardened {
+			index += hdkeychain.HardenedKeyStart
+		}
+		path = append(path, uint32(index))
+	}
+	return path, nil
+}
+
+// formatPath returns the canonical form of a derivation path, with each step
+// preceded by a slash.
+func formatPath(path []uint32) string {
+	var b strings.Builder
+	for _, index := range path {
+		if index >= hdkeychain.HardenedKeyStart {
+			fmt.Fprintf(&b, "/%d'", index-hdkeychain.HardenedKeyStart)
+			continue
+		}
+		fmt.Fprintf(&b, "/%d", index)
+	}
+	return b.String()
+}
+
+// parseOrigin parses the key origin of a KEY expression, which is the
+// fingerprint of the root key followed by the derivation path of the key,
+// within brackets, and returns its canonical form along with the rest of the
+// expression.
+func parseOrigin(expr string) (string, string, error) {
+	if !strings.HasPrefix(expr, "[") {
+		return "", expr, nil
+	}
+	end := strings.IndexByte(expr, ']')
+	if end < 0 {
+		return "", "", errors.New("key origin start '[' character " +
+			"without matching ']' character")
+	}
+
+	steps := strings.Split(expr[1:end], "/")
+	fingerprint, err := hex.DecodeString(steps[0])
+	if err != nil || len(fingerprint) != 4 {
+		return "", "", fmt.Errorf("fingerprint %q is not 4 hex bytes",
+			steps[0])
+	}
+	path, err := parsePath(steps[1:])
+	if err != nil {
+		return "", "", err
+	}
+	origin := "[" + hex.EncodeToString(fingerprint) + formatPath(path) +
+		"]"
+	return origin, expr[end+1:], nil
+}
+
+// parseKey parses a KEY expression of the network.  The public keys must be
+// compressed when witness is set.
+func parseKey(expr string, params *chaincfg.Params, witness bool) (*key, error) {
+	origin, expr, err := parseOrigin(expr)
+	if err != nil {
+		return nil, err
+	}
+	k := &key{origin: origin}
+
+	steps := strings.Split(expr, "/")
+	if len(steps) == 1 {
+		if pubKey, err := hex.DecodeString(expr); err == nil {
+			_, err := btcec.ParsePubKey(pubKey, btcec.S256())
+			if err != nil {
+				return nil, fmt.Errorf("pubkey %q is invalid", expr)
+			}
+			k.pubKey = pubKey
+		} else if wif, err := btcutil.DecodeWIF(expr); err == nil {
+			if !wif.IsForNet(params) {
+				return nil, errors.New("private key is not " +
+					"valid for the network")
+			}
+			k.wif = wif
+			k.pubKey = wif.SerializePubKey()
+		}
+		if k.pubKey != nil {
+			if witness && len(k.pubKey) != btcec.PubKeyBytesLenCompressed {
+				return nil, errors.New("uncompressed keys are " +
+					"not allowed in witness scripts")
+			}
+			return k, nil
+		}
+	}
+
+	k.extKey, err = hdkeychain.NewKeyFromString(steps[0])
+	if err != nil {
+		return nil, fmt.Errorf("key %q is not valid", steps[0])
+	}
+	if !k.extKey.IsForNet(params) {
+		return nil, errors.New("extended key is not valid for the " +
+			"network")
+	}
+	if _, err := k.extKey.Neuter(); err != nil {
+		return nil, err
+	}
+	steps = steps[1:]
+	if len(steps) > 0 {
+		switch steps[len(steps)-1] {
+		case "*":
+			k.ranged = unhardenedRange
+		case "*'", "*h":
+			k.ranged = hardenedRange
+		}
+		if k.ranged != noRange {
+			steps = steps[:len(steps)-1]
+		}
+	}
+	k.path, err = parsePath(steps)
+	if err != nil {
+		return nil, err
+	}
+
+	k.base = k.extKey
+	for _, index := range k.path {
+		k.base, err = k.base.Derive(index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if k.ranged == hardenedRange && !k.base.IsPrivate() {
+		return nil, hdkeychain.ErrDeriveHardFromPublic
+	}
+	return k, nil
+}
+
+// isRange returns whether the key derives a public key per index.
+func (k *key) isRange() bool {
+	return k.ranged != noRange
+}
+
+// isPrivate returns whether the key is a private key.
+func (k *key) isPrivate() bool {
+	return k.wif != nil || k.extKey != nil && k.extKey.IsPrivate()
+}
+
+// pubKeyAt returns the serialized public key of the key at the passed index of
+// a ranged descriptor.
+func (k *key) pubKeyAt(index uint32) ([]byte, error) {
+	if k.extKey == nil {
+		return k.pubKey, nil
+	}
+
+	extKey := k.base
+	if k.ranged != noRange {
+		if index >= hdkeychain.HardenedKeyStart {
+			return nil, fmt.Errorf("index %d is out of range", index)
+		}
+		if k.ranged == hardenedRange {
+			index += hdkeychain.HardenedKeyStart
+		}
+		var err error
+		extKey, err = extKey.Derive(index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	pubKey, err := extKey.ECPubKey()
+	if err != nil {
+		return nil, err
+	}
+	return pubKey.SerializeCompressed(), nil
+}
+
+// String returns the canonical form of the KEY expression, with its public
+// key.
+func (k *key) String() string {
+	if k.extKey == nil {
+		return k.origin + hex.EncodeToString(k.pubKey)
+	}
+
+	// The extended private keys were checked to be neutered when they were
+	// parsed.
+	pubKey, _ := k.extKey.Neuter()
+	s := k.origin + pubKey.String() + formatPath(k.path)
+	switch k.ranged {
+	case unhardenedRange:
+		s += "/*"
+	case hardenedRange:
+		s += "/*'"
+	}
+	return s
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lbryio/lbcd/blockchain"
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// diskSpaceCheckInterval is the interval between the checks of the free space
+// of the volumes of the databases.
+const diskSpaceCheckInterval = 10 * time.Second
+
+// Statuses of the free space of a volume, from the best to the worst.
+const (
+	diskSpaceOK   = "ok"
+	diskSpaceLow  = "low"
+	diskSpaceFull = "full"
+)
+
+// diskSpaceRank orders the statuses of the free space of a volume.
+var diskSpaceRank = map[string]int{
+	diskSpaceOK:   0,
+	diskSpaceLow:  1,
+	diskSpaceFull: 2,
+}
+
+// diskVolume is the free space of the volume of a database.
+type diskVolume struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Free   uint64 `json:"free"`
+	Total  uint64 `json:"total"`
+	Status string `json:"status"`
+}
+
+// diskSpaceEvent is the data of the diskspace events posted to the webhooks
+// when the worst status of the volumes changes.
+type diskSpaceEvent struct {
+	Status  string       `json:"status"`
+	Volumes []diskVolume `json:"volumes"`
+}
+
+// diskSpaceMonitorConfig is a descriptor containing the disk space monitor
+// configuration.
+type diskSpaceMonitorConfig struct {
+	// Volumes are the names and the directories of the databases whose
+	// volumes are checked.
+	Volumes []diskVolume
+
+	// WarnSpace and MinSpace are the free space in bytes below which a
+	// volume is low or full.  Zero disables them.
+	WarnSpace uint64
+	MinSpace  uint64
+
+	// Usage returns the free and total space in bytes of the volume of a
+	// path.
+	Usage func(path string) (uint64, uint64, error)
+
+	// Notify is called with the volumes when their worst status changes.
+	Notify func(event *diskSpaceEvent)
+
+	Chain *blockchain.BlockChain
+}
+
+// diskSpaceMonitor checks the free space of the volumes of the databases,
+// warning when it runs low and halting the acceptance of blocks when it runs
+// out, before writing to the databases fails and corrupts them.
+type diskSpaceMonitor struct {
+	started  int32
+	shutdown int32
+	cfg      diskSpaceMonitorConfig
+
+	lock    sync.RWMutex
+	volumes []diskVolume
+	status  string
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+// newDiskSpaceMonitor returns a new instance of the diskSpaceMonitor struct.
+func newDiskSpaceMonitor(config *diskSpaceMonitorConfig) *diskSpaceMonitor {
+	m := diskSpaceMonitor{
+		cfg:    *config,
+		status: diskSpaceOK,
+		quit:   make(chan struct{}),
+	}
+	for _, volume := range config.Volumes {
+		volume.Status = diskSpaceOK
+		m.volumes = append(m.volumes, volume)
+	}
+	return &m
+}
+
+// diskUsage returns the free and total space in bytes of the volume of path.
+func diskUsage(path string) (uint64, uint64, error) {
+	usage, err := disk.Usage(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	return usage.Free, usage.Total, nil
+}
+
+// Start checks the free space of the volumes, and then keeps checking it
+// periodically.
+func (m *diskSpaceMonitor) Start() {
+	if atomic.AddInt32(&m.started, 1) != 1 {
+		return
+	}
+
+	m.check()
+	m.wg.Add(1)
+	go m.checkHandler()
+}
+
+// Stop stops checking the free space of the volumes.
+func (m *diskSpaceMonitor) Stop() {
+	if atomic.AddInt32(&m.shutdown, 1) != 1 {
+		return
+	}
+	close(m.quit)
+	m.wg.Wait()
+}
+
+// checkHandler checks the free space of the volumes periodically.
+//
+// This must be run as a goroutine.
+func (m *diskSpaceMonitor) checkHandler() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(diskSpaceCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.check()
+
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+// volumeStatus returns the status of a volume with the passed free space.
+func (m *diskSpaceMonitor) volumeStatus(free uint64) string {
+	switch {
+	case m.cfg.MinSpace > 0 && free < m.cfg.MinSpace:
+		return diskSpaceFull
+	case m.cfg.WarnSpace > 0 && free < m.cfg.WarnSpace:
+		return diskSpaceLow
+	}
+	return diskSpaceOK
+}
+
+// check reads the free space of the volumes, halting or resuming the
+// acceptance of blocks and notifying when their worst status changes.  The
+// volumes whose free space can't be read keep their previous status.
+func (m *diskSpaceMonitor) check() {
+	volumes := m.Volumes()
+	status := diskSpaceOK
+	var full []string
+	for i, volume := range volumes {
+		free, total, err := m.cfg.Usage(volume.Path)
+		if err != nil {
+			btcdLog.Warnf("Failed to read the free space of %s: %v",
+				volume.Path, err)
+		} else {
+			volume.Free, volume.Total = free, total
+			volume.Status = m.volumeStatus(free)
+		}
+		if diskSpaceRank[volume.Status] > diskSpaceRank[status] {
+			status = volume.Status
+		}
+		if volume.Status == diskSpaceFull {
+			full = append(full, volume.Name)
+		}
+		volumes[i] = volume
+	}
+
+	m.lock.Lock()
+	prevStatus := m.status
+	m.volumes = volumes
+	m.status = status
+	m.lock.Unlock()
+
+	if status == prevStatus {
+		return
+	}
+	for _, volume := range volumes {
+		if volume.Status == diskSpaceOK {
+			continue
+		}
+		btcdLog.Warnf("Free space of the %s volume (%s) is %s: %.1f GB "+
+			"of %.1f GB", volume.Name, volume.Path, volume.Status,
+			toGB(volume.Free), toGB(volume.Total))
+	}
+	switch {
+	case status == diskSpaceFull:
+		btcdLog.Errorf("Halting the acceptance of blocks until more "+
+			"than %.1f GB are free on the %s volumes",
+			toGB(m.cfg.MinSpace), strings.Join(full, ", "))
+		m.cfg.Chain.HaltAcceptance(fmt.Sprintf("low free space on "+
+			"the %s volumes", strings.Join(full, ", ")))
+
+	case prevStatus == diskSpaceFull:
+		btcdLog.Infof("Resuming the acceptance of blocks")
+		m.cfg.Chain.ResumeAcceptance()
+	}
+	if status == diskSpaceOK {
+		btcdLog.Infof("Free space of the volumes is back to normal")
+	}
+
+	if m.cfg.Notify != nil {
+		m.cfg.Notify(&diskSpaceEvent{Status: status, Volumes: volumes})
+	}
+}
+
+// Volumes returns the free space of the volumes as of the last check.
+func (m *diskSpaceMonitor) Volumes() []diskVolume {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return append([]diskVolume(nil), m.volumes...)
+}
+
+// Warning returns a warning about the free space of the volumes, or an empty
+// string when it is enough.
+func (m *diskSpaceMonitor) Warning() string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	var names []string
+	for _, volume := range m.volumes {
+		if volume.Status != diskSpaceOK {
+			names = append(names, volume.Name)
+		}
+	}
+	switch m.status {
+	case diskSpaceFull:
+		return fmt.Sprintf("Warning: Out of disk space on the %s "+
+			"volumes, blocks are not accepted! ", strings.Join(names, ", "))
+	case diskSpaceLow:
+		return fmt.Sprintf("Warning: Disk space is low on the %s "+
+			"volumes! ", strings.Join(names, ", "))
+	}
+	return ""
+}
+
+// diskSpaceVolumes returns the names and the directories of the databases
+// whose volumes are checked: the block files, the chain state and the
+// claimtrie.
+func diskSpaceVolumes() []diskVolume {
+	chainState := blockDbPath(cfg.DbType)
+	blocks := chainState
+	if cfg.BlocksDir != "" {
+		blocks = blockFilesPath(cfg.DbType)
+	}
+	return []diskVolume{
+		{Name: "blocks", Path: blocks},
+		{Name: "chainstate", Path: chainState},
+		{Name: "claim_dbs", Path: filepath.Join(cfg.DataDir, "claim_dbs")},
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btclog"
+	"github.com/lbryio/lbcd/blockchain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiskSpaceMonitor(t *testing.T) {
+
+	r := require.New(t)
+
+	defer func(log btclog.Logger) { btcdLog = log }(btcdLog)
+	btcdLog = btclog.Disabled
+
+	free := map[string]uint64{"/blocks": 100, "/claims": 100}
+	var events []*diskSpaceEvent
+	chain := &blockchain.BlockChain{}
+	m := newDiskSpaceMonitor(&diskSpaceMonitorConfig{
+		Volumes: []diskVolume{
+			{Name: "blocks", Path: "/blocks"},
+			{Name: "claim_dbs", Path: "/claims"},
+		},
+		WarnSpace: 50,
+		MinSpace:  10,
+		Usage: func(path string) (uint64, uint64, error) {
+			if free[path] == 0 {
+				return 0, 0, errors.New("unreadable")
+			}
+			return free[path], 1000, nil
+		},
+		Notify: func(event *diskSpaceEvent) {
+			events = append(events, event)
+		},
+		Chain: chain,
+	})
+
+	// Nothing is notified while the free space is enough.
+	m.check()
+	r.Empty(events)
+	r.Empty(m.Warning())
+	r.Equal(uint64(100), m.Volumes()[0].Free)
+
+	// A volume below the warning threshold is low.
+	free["/claims"] = 40
+	m.check()
+	r.Len(events, 1)
+	r.Equal(diskSpaceLow, events[0].Status)
+	r.Equal(diskSpaceLow, events[0].Volumes[1].Status)
+	r.Contains(m.Warning(), "low on the claim_dbs volumes")
+	r.Empty(chain.AcceptanceHalted())
+
+	// Blocks aren't accepted while a volume is below the minimum, and a
+	// volume whose free space can't be read keeps its status.
+	free["/blocks"] = 5
+	free["/claims"] = 0
+	m.check()
+	r.Len(events, 2)
+	r.Equal(diskSpaceFull, events[1].Status)
+	r.Equal(diskSpaceLow, m.Volumes()[1].Status)
+	r.Contains(chain.AcceptanceHalted(), "blocks volumes")
+	r.Contains(m.Warning(), "blocks are not accepted")
+	m.check()
+	r.Len(events, 2)
+
+	// Blocks are accepted again once the space is freed.
+	free["/blocks"] = 20
+	free["/claims"] = 100
+	m.check()
+	r.Len(events, 3)
+	r.Equal(diskSpaceLow, events[2].Status)
+	r.Empty(chain.AcceptanceHalted())
+	free["/blocks"] = 100
+	m.check()
+	r.Len(events, 4)
+	r.Equal(diskSpaceOK, events[3].Status)
+	r.Empty(m.Warning())
+}
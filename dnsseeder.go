@@ -0,0 +1,638 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lbryio/lbcd/addrmgr"
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/peer"
+	"github.com/lbryio/lbcd/wire"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// dnsSeederCrawlInterval is how often the crawler looks for the nodes
+	// due to be crawled.
+	dnsSeederCrawlInterval = 10 * time.Second
+
+	// dnsSeederMaxCrawls is the max number of nodes crawled at once.
+	dnsSeederMaxCrawls = 32
+
+	// dnsSeederMaxNodes is the max number of nodes tracked by the crawler.
+	dnsSeederMaxNodes = 50000
+
+	// dnsSeederRecrawlInterval is how long the crawler waits before
+	// crawling a good node again.
+	dnsSeederRecrawlInterval = 30 * time.Minute
+
+	// dnsSeederRetryInterval is how long the crawler waits before crawling
+	// a node again after its first failure.  The wait doubles with each
+	// consecutive failure.
+	dnsSeederRetryInterval = 15 * time.Minute
+
+	// dnsSeederMaxFailures is the number of consecutive failures after
+	// which a node is forgotten until the address manager offers it again.
+	dnsSeederMaxFailures = 6
+
+	// dnsSeederConnectTimeout is the time allowed to connect to a node.
+	dnsSeederConnectTimeout = 10 * time.Second
+
+	// dnsSeederHandshakeTimeout is the time allowed to a node to complete
+	// the version handshake once connected.
+	dnsSeederHandshakeTimeout = 20 * time.Second
+
+	// dnsSeederAddrTimeout is how long the crawler waits for a node to
+	// answer its getaddr message.
+	dnsSeederAddrTimeout = 10 * time.Second
+
+	// dnsSeederMaxLag is the number of blocks a node may be behind the best
+	// chain of the seeder to be good, which is about a day of blocks.
+	dnsSeederMaxLag = 576
+
+	// dnsSeederTTL is the time to live in seconds of the answers.
+	dnsSeederTTL = 60
+
+	// dnsSeederMaxAnswers is the max number of addresses in an answer.
+	dnsSeederMaxAnswers = 25
+
+	// dnsMaxUDPSize is the max size of the DNS messages over UDP.
+	dnsMaxUDPSize = 512
+)
+
+// seederNode is a node of the network tracked by the crawler of the DNS
+// seeder.
+type seederNode struct {
+	na              *wire.NetAddress
+	services        wire.ServiceFlag
+	protocolVersion uint32
+	height          int32
+	lastAttempt     time.Time
+	lastSuccess     time.Time
+	failures        int
+	crawling        bool
+}
+
+// isGood returns whether the node can be handed out by the DNS seeder, which is
+// when its last crawl succeeded, it serves the full chain, and it isn't far
+// behind the best height of the seeder.
+func (n *seederNode) isGood(bestHeight int32) bool {
+	return !n.lastSuccess.IsZero() && n.failures == 0 &&
+		n.services&wire.SFNodeNetwork == wire.SFNodeNetwork &&
+		n.protocolVersion >= peer.MinAcceptableProtocolVersion &&
+		n.height >= bestHeight-dnsSeederMaxLag
+}
+
+// due returns whether the node is due to be crawled at the time.
+func (n *seederNode) due(now time.Time) bool {
+	switch {
+	case n.crawling:
+		return false
+	case n.lastAttempt.IsZero():
+		return true
+	case n.failures == 0:
+		return now.Sub(n.lastAttempt) >= dnsSeederRecrawlInterval
+	}
+	return now.Sub(n.lastAttempt) >= dnsSeederRetryInterval<<(n.failures-1)
+}
+
+// dnsSeederConfig is a descriptor containing the DNS seeder configuration.
+type dnsSeederConfig struct {
+	// Domain is the domain the seeder answers the queries of, without its
+	// trailing dot.
+	Domain string
+
+	// Nameserver is the host name returned in the NS and SOA records of
+	// the domain.  The seeder answers neither when it is empty.
+	Nameserver string
+
+	// Listeners are the UDP sockets the queries are answered on.
+	Listeners []net.PacketConn
+
+	// AddrManager provides the addresses to crawl, and learns the ones the
+	// nodes crawled know of.
+	AddrManager *addrmgr.AddrManager
+
+	// ChainParams identifies the network crawled, whose default port is
+	// the one of the nodes handed out.
+	ChainParams *chaincfg.Params
+
+	// BestHeight returns the height of the best chain of the seeder.
+	BestHeight func() int32
+
+	// Dial connects to the nodes.
+	Dial func(network, addr string, timeout time.Duration) (net.Conn, error)
+}
+
+// dnsSeeder crawls the network from the addresses of the address manager and
+// answers the DNS queries for its domain with the addresses of the good nodes,
+// which lets the nodes of the network find each other without a separate
+// crawler.
+//
+// As the answers carry no port, only the nodes listening on the default port
+// of the network are handed out.  The nodes may be filtered by the service
+// bits they must have with the x<hex services>.<domain> subdomains, such as
+// x9.seed.example.com for the nodes with the network and witness services,
+// which is how the DNS seeds with filtering are queried.
+type dnsSeeder struct {
+	started  int32
+	shutdown int32
+	cfg      dnsSeederConfig
+	port     uint16
+	domain   dnsmessage.Name
+
+	mtx      sync.Mutex
+	nodes    map[string]*seederNode
+	crawling int
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+// newDNSSeeder returns a new DNS seeder with the configuration.
+func newDNSSeeder(config *dnsSeederConfig) (*dnsSeeder, error) {
+	domain, err := dnsmessage.NewName(config.Domain + ".")
+	if err != nil {
+		return nil, fmt.Errorf("invalid domain %q: %v", config.Domain, err)
+	}
+	port, err := strconv.ParseUint(config.ChainParams.DefaultPort, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid default port %q: %v",
+			config.ChainParams.DefaultPort, err)
+	}
+	return &dnsSeeder{
+		cfg:    *config,
+		port:   uint16(port),
+		domain: domain,
+		nodes:  make(map[string]*seederNode),
+		quit:   make(chan struct{}),
+	}, nil
+}
+
+// Start begins crawling the network and answering the DNS queries.
+func (s *dnsSeeder) Start() {
+	if atomic.AddInt32(&s.started, 1) != 1 {
+		return
+	}
+
+	seedLog.Infof("DNS seeder answering the queries for %s", s.cfg.Domain)
+
+	for _, conn := range s.cfg.Listeners {
+		seedLog.Infof("DNS seeder listening on %s", conn.LocalAddr())
+		s.wg.Add(1)
+		go s.serve(conn)
+	}
+
+	s.wg.Add(1)
+	go s.crawlHandler()
+}
+
+// Stop stops the crawler and the listeners, and waits for the crawls in
+// progress to end.
+func (s *dnsSeeder) Stop() {
+	if atomic.AddInt32(&s.shutdown, 1) != 1 {
+		return
+	}
+
+	close(s.quit)
+	for _, conn := range s.cfg.Listeners {
+		conn.Close()
+	}
+	s.wg.Wait()
+}
+
+// crawlHandler periodically imports the addresses of the address manager and
+// crawls the nodes due to be crawled.
+//
+// It must be run as a goroutine.
+func (s *dnsSeeder) crawlHandler() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(dnsSeederCrawlInterval)
+	defer ticker.Stop()
+
+	for {
+		s.importAddresses()
+		s.crawlDue(time.Now())
+
+		select {
+		case <-ticker.C:
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// importAddresses tracks the routable addresses on the default port of a
+// sample of the address manager which aren't tracked yet.
+func (s *dnsSeeder) importAddresses() {
+	addrs := s.cfg.AddrManager.AddressCache()
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for _, na := range addrs {
+		if len(s.nodes) >= dnsSeederMaxNodes {
+			return
+		}
+		if na.Port != s.port || !addrmgr.IsRoutable(na) {
+			continue
+		}
+		key := addrmgr.NetAddressKey(na)
+		if _, ok := s.nodes[key]; !ok {
+			s.nodes[key] = &seederNode{na: na}
+		}
+	}
+}
+
+// crawlDue starts crawling the nodes due to be crawled at the time, up to the
+// max number of concurrent crawls.
+func (s *dnsSeeder) crawlDue(now time.Time) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for key, n := range s.nodes {
+		if s.crawling >= dnsSeederMaxCrawls {
+			return
+		}
+		if !n.due(now) {
+			continue
+		}
+		n.crawling = true
+		n.lastAttempt = now
+		s.crawling++
+		s.wg.Add(1)
+		go s.crawl(key, n.na)
+	}
+}
+
+// crawl connects to the node, records the outcome and, on success, the height,
+// services and protocol version of the node.
+//
+// It must be run as a goroutine.
+func (s *dnsSeeder) crawl(key string, na *wire.NetAddress) {
+	defer s.wg.Done()
+
+	s.cfg.AddrManager.Attempt(na)
+	p, err := s.handshake(na)
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.crawling--
+	n := s.nodes[key]
+	n.crawling = false
+	if err != nil {
+		seedLog.Debugf("DNS seeder failed to crawl %s: %v", key, err)
+		n.failures++
+		if n.failures >= dnsSeederMaxFailures {
+			delete(s.nodes, key)
+		}
+		return
+	}
+
+	n.failures = 0
+	n.lastSuccess = n.lastAttempt
+	n.services = p.Services()
+	n.protocolVersion = p.ProtocolVersion()
+	n.height = p.LastBlock()
+	s.cfg.AddrManager.Good(na)
+	s.cfg.AddrManager.SetServices(na, n.services)
+}
+
+// handshake connects to the node, completes the version handshake, and waits
+// for the addresses it knows of, which are added to the address manager.  It
+// returns the disconnected peer.
+func (s *dnsSeeder) handshake(na *wire.NetAddress) (*peer.Peer, error) {
+	addr := addrmgr.NetAddressKey(na)
+	conn, err := s.cfg.Dial("tcp", addr, dnsSeederConnectTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	verAck := make(chan struct{})
+	addrs := make(chan struct{}, 1)
+	config := &peer.Config{
+		Listeners: peer.MessageListeners{
+			OnVerAck: func(*peer.Peer, *wire.MsgVerAck) {
+				close(verAck)
+			},
+			OnAddr: func(p *peer.Peer, msg *wire.MsgAddr) {
+				s.cfg.AddrManager.AddAddresses(msg.AddrList, p.NA())
+
+				// The nodes may first advertise their own
+				// address alone.
+				if len(msg.AddrList) > 1 {
+					select {
+					case addrs <- struct{}{}:
+					default:
+					}
+				}
+			},
+		},
+		HostToNetAddress:  s.cfg.AddrManager.HostToNetAddress,
+		UserAgentName:     userAgentName,
+		UserAgentVersion:  userAgentVersion,
+		UserAgentComments: cfg.UserAgentComments,
+		ChainParams:       s.cfg.ChainParams,
+		DisableRelayTx:    true,
+		ProtocolVersion:   peer.MaxProtocolVersion,
+	}
+	p, err := peer.NewOutboundPeer(config, addr)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	p.AssociateConnection(conn)
+	defer func() {
+		p.Disconnect()
+		p.WaitForDisconnect()
+	}()
+
+	disconnected := make(chan struct{})
+	go func() {
+		p.WaitForDisconnect()
+		close(disconnected)
+	}()
+
+	select {
+	case <-verAck:
+	case <-disconnected:
+		return nil, errors.New("disconnected during the handshake")
+	case <-time.After(dnsSeederHandshakeTimeout):
+		return nil, errors.New("handshake timeout")
+	case <-s.quit:
+		return nil, errors.New("seeder shutting down")
+	}
+
+	p.QueueMessage(wire.NewMsgGetAddr(), nil)
+	select {
+	case <-addrs:
+	case <-disconnected:
+	case <-time.After(dnsSeederAddrTimeout):
+	case <-s.quit:
+	}
+	return p, nil
+}
+
+// goodAddresses returns the IPs of up to max random good nodes with the
+// services, which are either IPv4 or IPv6 addresses.
+func (s *dnsSeeder) goodAddresses(services wire.ServiceFlag, ipv4 bool,
+	max int) []net.IP {
+
+	bestHeight := s.cfg.BestHeight()
+
+	s.mtx.Lock()
+	var ips []net.IP
+	for _, n := range s.nodes {
+		if n.services&services != services || !n.isGood(bestHeight) ||
+			addrmgr.IsIPv4(n.na) != ipv4 {
+			continue
+		}
+		ips = append(ips, n.na.IP)
+	}
+	s.mtx.Unlock()
+
+	rand.Shuffle(len(ips), func(i, j int) {
+		ips[i], ips[j] = ips[j], ips[i]
+	})
+	if len(ips) > max {
+		ips = ips[:max]
+	}
+	return ips
+}
+
+// serve answers the DNS queries received on the socket until it is closed.
+//
+// It must be run as a goroutine.
+func (s *dnsSeeder) serve(conn net.PacketConn) {
+	defer s.wg.Done()
+
+	buf := make([]byte, dnsMaxUDPSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if atomic.LoadInt32(&s.shutdown) != 0 {
+				return
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Temporary() {
+				continue
+			}
+			seedLog.Errorf("DNS seeder stopped listening on %s: %v",
+				conn.LocalAddr(), err)
+			return
+		}
+
+		resp := s.handleQuery(buf[:n])
+		if resp == nil {
+			continue
+		}
+		if _, err := conn.WriteTo(resp, addr); err != nil {
+			seedLog.Debugf("DNS seeder failed to answer %s: %v", addr,
+				err)
+		}
+	}
+}
+
+// parseServiceFilter returns the services of the x<hex services> label of the
+// filtering subdomains.
+func parseServiceFilter(label string) (wire.ServiceFlag, bool) {
+	if len(label) < 2 || label[0] != 'x' {
+		return 0, false
+	}
+	services, err := strconv.ParseUint(label[1:], 16, 64)
+	if err != nil {
+		return 0, false
+	}
+	return wire.ServiceFlag(services), true
+}
+
+// handleQuery returns the response to the DNS query, or nil when it isn't a
+// query the seeder responds to.
+func (s *dnsSeeder) handleQuery(query []byte) []byte {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(query)
+	if err != nil || header.Response {
+		return nil
+	}
+	respHeader := dnsmessage.Header{
+		ID:               header.ID,
+		Response:         true,
+		OpCode:           header.OpCode,
+		Authoritative:    true,
+		RecursionDesired: header.RecursionDesired,
+	}
+
+	question, err := parser.Question()
+	if err != nil {
+		respHeader.RCode = dnsmessage.RCodeFormatError
+		return s.buildResponse(respHeader, nil, nil)
+	}
+	if header.OpCode != 0 {
+		respHeader.RCode = dnsmessage.RCodeNotImplemented
+		return s.buildResponse(respHeader, &question, nil)
+	}
+
+	// Find the services of the nodes the query is for, which are the ones
+	// of the filtering subdomains, or the network service at the apex.
+	name := strings.ToLower(question.Name.String())
+	domain := strings.ToLower(s.domain.String())
+	apex := name == domain
+	services := wire.SFNodeNetwork
+	if !apex {
+		label := strings.TrimSuffix(name, "."+domain)
+		if label == name {
+			respHeader.Authoritative = false
+			respHeader.RCode = dnsmessage.RCodeRefused
+			return s.buildResponse(respHeader, &question, nil)
+		}
+		var ok bool
+		services, ok = parseServiceFilter(label)
+		if !ok {
+			respHeader.RCode = dnsmessage.RCodeNameError
+			return s.buildResponse(respHeader, &question, nil)
+		}
+	}
+
+	var ips []net.IP
+	if question.Class == dnsmessage.ClassINET {
+		switch question.Type {
+		case dnsmessage.TypeA:
+			ips = s.goodAddresses(services, true, dnsSeederMaxAnswers)
+		case dnsmessage.TypeAAAA:
+			ips = s.goodAddresses(services, false, dnsSeederMaxAnswers)
+		}
+	}
+
+	// Drop addresses until the response fits a UDP message, which may not
+	// be the case for the domains with long names.
+	for {
+		resp := s.buildResponse(respHeader, &question, ips)
+		if len(resp) <= dnsMaxUDPSize || len(ips) == 0 {
+			return resp
+		}
+		ips = ips[:len(ips)-1]
+	}
+}
+
+// buildResponse returns the response with the header to the question, if any,
+// answered with the IPs.  The NS and SOA records of the domain are answered to
+// the queries for their types at the apex, and the SOA record is returned in
+// the authority section of the responses without answers, when the seeder has
+// a nameserver.
+func (s *dnsSeeder) buildResponse(header dnsmessage.Header,
+	question *dnsmessage.Question, ips []net.IP) []byte {
+
+	resp, err := s.packResponse(header, question, ips)
+	if err != nil {
+		seedLog.Warnf("DNS seeder failed to build a response: %v", err)
+		return nil
+	}
+	return resp
+}
+
+// packResponse packs the response described by buildResponse.
+func (s *dnsSeeder) packResponse(header dnsmessage.Header,
+	question *dnsmessage.Question, ips []net.IP) ([]byte, error) {
+
+	b := dnsmessage.NewBuilder(make([]byte, 0, dnsMaxUDPSize), header)
+	b.EnableCompression()
+	if question == nil {
+		return b.Finish()
+	}
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(*question); err != nil {
+		return nil, err
+	}
+	if header.RCode != dnsmessage.RCodeSuccess &&
+		header.RCode != dnsmessage.RCodeNameError {
+		return b.Finish()
+	}
+
+	var nameserver dnsmessage.Name
+	if s.cfg.Nameserver != "" {
+		var err error
+		nameserver, err = dnsmessage.NewName(s.cfg.Nameserver + ".")
+		if err != nil {
+			return nil, err
+		}
+	}
+	apex := strings.EqualFold(question.Name.String(), s.domain.String())
+	answers := len(ips) > 0 || (apex && s.cfg.Nameserver != "" &&
+		header.RCode == dnsmessage.RCodeSuccess &&
+		(question.Type == dnsmessage.TypeNS ||
+			question.Type == dnsmessage.TypeSOA))
+
+	if err := b.StartAnswers(); err != nil {
+		return nil, err
+	}
+	rh := dnsmessage.ResourceHeader{
+		Name:  question.Name,
+		Class: dnsmessage.ClassINET,
+		TTL:   dnsSeederTTL,
+	}
+	for _, ip := range ips {
+		var err error
+		if ip4 := ip.To4(); ip4 != nil {
+			var r dnsmessage.AResource
+			copy(r.A[:], ip4)
+			err = b.AResource(rh, r)
+		} else {
+			var r dnsmessage.AAAAResource
+			copy(r.AAAA[:], ip.To16())
+			err = b.AAAAResource(rh, r)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if answers && len(ips) == 0 {
+		var err error
+		if question.Type == dnsmessage.TypeNS {
+			err = b.NSResource(rh, dnsmessage.NSResource{NS: nameserver})
+		} else {
+			err = b.SOAResource(rh, s.soa(nameserver))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !answers && s.cfg.Nameserver != "" {
+		if err := b.StartAuthorities(); err != nil {
+			return nil, err
+		}
+		rh.Name = s.domain
+		if err := b.SOAResource(rh, s.soa(nameserver)); err != nil {
+			return nil, err
+		}
+	}
+	return b.Finish()
+}
+
+// soa returns the SOA record of the domain served by the nameserver.
+func (s *dnsSeeder) soa(nameserver dnsmessage.Name) dnsmessage.SOAResource {
+	mbox, err := dnsmessage.NewName("hostmaster." + s.domain.String())
+	if err != nil {
+		mbox = s.domain
+	}
+	return dnsmessage.SOAResource{
+		NS:      nameserver,
+		MBox:    mbox,
+		Serial:  uint32(time.Now().Unix()),
+		Refresh: 3600,
+		Retry:   600,
+		Expire:  86400,
+		MinTTL:  dnsSeederTTL,
+	}
+}
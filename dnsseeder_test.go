@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/peer"
+	"github.com/lbryio/lbcd/wire"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// testDNSSeeder returns a DNS seeder for seed.example.com tracking nodes with
+// various states.
+func testDNSSeeder(t *testing.T) *dnsSeeder {
+	s, err := newDNSSeeder(&dnsSeederConfig{
+		Domain:      "seed.example.com",
+		Nameserver:  "ns.example.com",
+		ChainParams: &chaincfg.MainNetParams,
+		BestHeight:  func() int32 { return 1000 },
+	})
+	require.NoError(t, err)
+
+	now := time.Now()
+	good := func(ip string, services wire.ServiceFlag, height int32,
+		failures int) {
+
+		na := wire.NewNetAddressIPPort(net.ParseIP(ip), s.port, services)
+		s.nodes[ip] = &seederNode{
+			na:              na,
+			services:        services,
+			protocolVersion: peer.MaxProtocolVersion,
+			height:          height,
+			lastAttempt:     now,
+			lastSuccess:     now,
+			failures:        failures,
+		}
+	}
+	good("1.2.3.4", wire.SFNodeNetwork|wire.SFNodeWitness, 1000, 0)
+	good("5.6.7.8", wire.SFNodeNetwork, 990, 0)
+	good("9.9.9.9", wire.SFNodeNetwork, 100, 0)
+	good("8.8.8.8", wire.SFNodeNetwork, 1000, 1)
+	good("7.7.7.7", wire.SFNodeWitness, 1000, 0)
+	good("2001:db8::1", wire.SFNodeNetwork, 1000, 0)
+	s.nodes["6.6.6.6"] = &seederNode{
+		na: wire.NewNetAddressIPPort(net.ParseIP("6.6.6.6"), s.port,
+			wire.SFNodeNetwork),
+	}
+	return s
+}
+
+// dnsQuery returns the response of the seeder to the query for the name and
+// type.
+func dnsQuery(t *testing.T, s *dnsSeeder, name string,
+	qtype dnsmessage.Type) *dnsmessage.Message {
+
+	query := dnsmessage.Message{
+		Header: dnsmessage.Header{ID: 42, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{
+			Name:  dnsmessage.MustNewName(name),
+			Type:  qtype,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+	packed, err := query.Pack()
+	require.NoError(t, err)
+
+	resp := s.handleQuery(packed)
+	require.NotNil(t, resp)
+	require.LessOrEqual(t, len(resp), dnsMaxUDPSize)
+
+	var msg dnsmessage.Message
+	require.NoError(t, msg.Unpack(resp))
+	require.Equal(t, uint16(42), msg.ID)
+	require.True(t, msg.Response)
+	require.True(t, msg.RecursionDesired)
+	require.Equal(t, query.Questions, msg.Questions)
+	return &msg
+}
+
+// answerIPs returns the sorted IPs of the A and AAAA answers of the message.
+func answerIPs(msg *dnsmessage.Message) []string {
+	var ips []string
+	for _, answer := range msg.Answers {
+		switch r := answer.Body.(type) {
+		case *dnsmessage.AResource:
+			ips = append(ips, net.IP(r.A[:]).String())
+		case *dnsmessage.AAAAResource:
+			ips = append(ips, net.IP(r.AAAA[:]).String())
+		}
+	}
+	sort.Strings(ips)
+	return ips
+}
+
+func TestDNSSeederAnswers(t *testing.T) {
+
+	r := require.New(t)
+	s := testDNSSeeder(t)
+
+	// The apex serves the nodes with the network service.
+	msg := dnsQuery(t, s, "seed.example.com.", dnsmessage.TypeA)
+	r.Equal(dnsmessage.RCodeSuccess, msg.RCode)
+	r.True(msg.Authoritative)
+	r.Equal([]string{"1.2.3.4", "5.6.7.8"}, answerIPs(msg))
+	r.Equal(uint32(dnsSeederTTL), msg.Answers[0].Header.TTL)
+
+	msg = dnsQuery(t, s, "seed.example.com.", dnsmessage.TypeAAAA)
+	r.Equal([]string{"2001:db8::1"}, answerIPs(msg))
+
+	// The subdomains filter the nodes by services, case insensitively.
+	msg = dnsQuery(t, s, "X9.Seed.Example.COM.", dnsmessage.TypeA)
+	r.Equal(dnsmessage.RCodeSuccess, msg.RCode)
+	r.Equal([]string{"1.2.3.4"}, answerIPs(msg))
+
+	msg = dnsQuery(t, s, "x8.seed.example.com.", dnsmessage.TypeA)
+	r.Equal([]string{"1.2.3.4"}, answerIPs(msg))
+
+	msg = dnsQuery(t, s, "x400.seed.example.com.", dnsmessage.TypeA)
+	r.Equal(dnsmessage.RCodeSuccess, msg.RCode)
+	r.Empty(msg.Answers)
+	r.Len(msg.Authorities, 1)
+
+	// The nameserver is served at the apex.
+	msg = dnsQuery(t, s, "seed.example.com.", dnsmessage.TypeNS)
+	r.Len(msg.Answers, 1)
+	r.Equal("ns.example.com.", msg.Answers[0].Body.(*dnsmessage.NSResource).NS.String())
+
+	msg = dnsQuery(t, s, "seed.example.com.", dnsmessage.TypeSOA)
+	r.Len(msg.Answers, 1)
+	soa := msg.Answers[0].Body.(*dnsmessage.SOAResource)
+	r.Equal("ns.example.com.", soa.NS.String())
+	r.Equal("hostmaster.seed.example.com.", soa.MBox.String())
+
+	// The types without answers return the SOA record as authority.
+	msg = dnsQuery(t, s, "seed.example.com.", dnsmessage.TypeMX)
+	r.Equal(dnsmessage.RCodeSuccess, msg.RCode)
+	r.Empty(msg.Answers)
+	r.Len(msg.Authorities, 1)
+	r.Equal("seed.example.com.", msg.Authorities[0].Header.Name.String())
+
+	// Unknown subdomains don't exist, and other domains are refused.
+	msg = dnsQuery(t, s, "www.seed.example.com.", dnsmessage.TypeA)
+	r.Equal(dnsmessage.RCodeNameError, msg.RCode)
+	r.Len(msg.Authorities, 1)
+
+	msg = dnsQuery(t, s, "example.com.", dnsmessage.TypeA)
+	r.Equal(dnsmessage.RCodeRefused, msg.RCode)
+	r.False(msg.Authoritative)
+	r.Empty(msg.Authorities)
+
+	// Responses and malformed messages are ignored.
+	r.Nil(s.handleQuery([]byte{0, 1}))
+	resp := dnsmessage.Message{Header: dnsmessage.Header{Response: true}}
+	packed, err := resp.Pack()
+	r.NoError(err)
+	r.Nil(s.handleQuery(packed))
+}
+
+func TestDNSSeederAnswerSize(t *testing.T) {
+
+	r := require.New(t)
+	s := testDNSSeeder(t)
+
+	for i := 0; i < 100; i++ {
+		ip := net.ParseIP(fmt.Sprintf("2001:db8::1:%x", i))
+		s.nodes[ip.String()] = &seederNode{
+			na:              wire.NewNetAddressIPPort(ip, s.port, wire.SFNodeNetwork),
+			services:        wire.SFNodeNetwork,
+			protocolVersion: peer.MaxProtocolVersion,
+			height:          1000,
+			lastAttempt:     time.Now(),
+			lastSuccess:     time.Now(),
+		}
+	}
+
+	// The answers are dropped until the response fits.
+	msg := dnsQuery(t, s, "seed.example.com.", dnsmessage.TypeAAAA)
+	r.NotEmpty(msg.Answers)
+	r.Less(len(msg.Answers), dnsSeederMaxAnswers)
+}
+
+func TestDNSSeederNodeDue(t *testing.T) {
+
+	r := require.New(t)
+
+	now := time.Now()
+	n := &seederNode{}
+	r.True(n.due(now))
+
+	n.lastAttempt = now.Add(-dnsSeederRecrawlInterval + time.Minute)
+	r.False(n.due(now))
+	n.lastAttempt = now.Add(-dnsSeederRecrawlInterval)
+	r.True(n.due(now))
+
+	// The failed nodes are retried with an exponential backoff.
+	n.failures = 3
+	n.lastAttempt = now.Add(-2 * dnsSeederRetryInterval)
+	r.False(n.due(now))
+	n.lastAttempt = now.Add(-4 * dnsSeederRetryInterval)
+	r.True(n.due(now))
+
+	n.crawling = true
+	r.False(n.due(now))
+}
+
+func TestParseServiceFilter(t *testing.T) {
+
+	r := require.New(t)
+
+	services, ok := parseServiceFilter("x9")
+	r.True(ok)
+	r.Equal(wire.SFNodeNetwork|wire.SFNodeWitness, services)
+
+	services, ok = parseServiceFilter("x409")
+	r.True(ok)
+	r.Equal(wire.ServiceFlag(0x409), services)
+
+	for _, label := range []string{"", "x", "9", "xz", "x-1", "y9"} {
+		_, ok := parseServiceFilter(label)
+		r.False(ok, label)
+	}
+}
@@ -17,6 +17,11 @@ parsed when lbcd starts up.  By default, the configuration file is located at
 on Windows.  The -C (--configfile) flag, as shown below, can be used to override
 this location.
 
+Each option can also be set by an environment variable named after its long
+form in upper case with the LBCD_ prefix, such as LBCD_RPCUSER.  The
+environment variables take precedence over the configuration file, and the
+flags take precedence over both.
+
 Usage:
 
 	lbcd [OPTIONS]
@@ -34,6 +39,9 @@ Application Options:
 	                            24h0m0s)
 	    --banthreshold=         Maximum allowed ban score before disconnecting
 	                            and banning misbehaving peers. (default: 100)
+	    --blockcachesize=       The number of recently served blocks to keep in
+	                            memory for the RPC and peer requests -- 0
+	                            disables it (default: 16)
 	    --blockmaxsize=         Maximum block size in bytes to be used when
 	                            creating a block (default: 750000)
 	    --blockminsize=         Mininum block size in bytes to be used when
@@ -45,13 +53,32 @@ Application Options:
 	    --blockprioritysize=    Size in bytes for high-priority/low-fee
 	                            transactions when creating a block (default:
 	                            50000)
+	    --blockrelayonlypeers=  Number of block-relay-only outbound peers to
+	                            maintain in addition to the full-relay ones,
+	                            which exchange neither transactions nor
+	                            addresses with them (default: 2)
+	    --blocksdir=            Directory to store the block files apart from the
+	                            rest of the data, which are moved there from
+	                            datadir
 	    --blocksonly            Do not accept transactions from remote peers.
+	    --chainparam=           Override a parameter of the regtest or simnet
+	                            chain, given as <name>=<value> such as
+	                            segwitheight=500 -- Can be specified multiple
+	                            times
+	    --claimsnapshot         Bootstrap the claimtrie from a snapshot of the
+	                            peers, checked against the block headers, instead
+	                            of processing the claims of all the blocks
 	-C, --configfile=           Path to configuration file
 	    --connect=              Connect only to the specified peers at startup
 	    --cpuprofile=           Write CPU profile to the specified file
 	-b, --datadir=              Directory to store data
-	    --dbtype=               Database backend to use for the Block Chain
-	                            (default: ffldb)
+	    --dbcheck=              How thoroughly to check the consistency of the
+	                            block files with the block database on start up:
+	                            none, quick or full -- A full check reads every
+	                            block (default: quick)
+	    --dbtype=               Database backend to use for the Block Chain --
+	                            ffpebble stores its metadata in pebble rather
+	                            than leveldb (default: ffldb)
 	-d, --debuglevel=           Logging level for all subsystems {trace, debug,
 	                            info, warn, error, critical} -- You may also
 	                            specify
@@ -59,43 +86,111 @@ Application Options:
 	                            set the log level for individual subsystems --
 	                            Use show to list available subsystems (default:
 	                            info)
+	    --dnsseeder=            Crawl the network for good peers and answer the
+	                            DNS queries for this domain and its x<hex
+	                            services> subdomains with their addresses
+	    --dnsseederlisten=      Add an interface/port to answer the DNS queries
+	                            of the seeder on over UDP (default port: 53)
+	    --dnsseedernameserver=  The host name of the nameserver of the seeder
+	                            domain, returned in its NS and SOA records
 	    --dropaddrindex         Deletes the address-based transaction index from
 	                            the database on start up and then exits.
 	    --dropcfindex           Deletes the index used for committed filtering
 	                            (CF) support from the database on start up and
 	                            then exits.
+	    --dropscripthashindex   Deletes the script hash index of the Electrum
+	                            server from the database on start up and then
+	                            exits.
 	    --droptxindex           Deletes the hash-based transaction index from the
 	                            database on start up and then exits.
+	    --electrumlisten=       Add an interface/port to serve the Electrum
+	                            protocol of the LBRY wallets on, which requires
+	                            the RPC server and the address index -- The
+	                            Electrum server is disabled unless at least one
+	                            is specified
+	    --electrummaxclients=   Max number of Electrum protocol clients (default:
+	                            100)
+	    --eventexport=          Export the block, claim and transaction events
+	                            to a Kafka topic, given as
+	                            kafka://[<user>:<pass>@]<host:port>[,<host:port>...]/<topic>,
+	                            or to the NATS JetStream subjects
+	                            <subject>.<type>, given as
+	                            nats://[<user>:<pass>@]<host:port>/<subject>,
+	                            whose user may be a token without password --
+	                            The credentials require eventexporttls
+	    --eventexportcafile=    File of the certificate authorities verifying
+	                            the TLS certificates of the event export brokers
+	                            -- Defaults to those of the system
+	    --eventexportformat=    Encoding of the exported events {json, protobuf}
+	                            (default: json)
+	    --eventexportsasl=      SASL mechanism authenticating to the Kafka
+	                            brokers of the event export with the credentials
+	                            of eventexport {plain, scram-sha-256,
+	                            scram-sha-512} (default: scram-sha-512)
+	    --eventexporttls        Connect to the Kafka brokers or to the NATS
+	                            server of the event export over TLS
 	    --externalip=           Add an ip to the list of local addresses we claim
 	                            to listen on to peers
 	    --generate              Generate (mine) bitcoins using the CPU
+	    --genesisblock=         Use the hex-encoded serialized block as the
+	                            genesis block of a private network based on the
+	                            regtest or simnet chain
+	    --genesismessage=       Generate the genesis block of a private network
+	                            based on the regtest or simnet chain with the
+	                            message in its coinbase
+	    --genesistime=          Generate the genesis block of a private network
+	                            based on the regtest or simnet chain with the
+	                            unix time -- Defaults to the time of the genesis
+	                            block of the chain
 	    --limitfreerelay=       Limit relay of transactions with no transaction
 	                            fee to the given amount in thousands of bytes per
 	                            minute (default: 15)
 	    --listen=               Add an interface/port to listen for connections
 	                            (default all interfaces port: 9246, testnet:
 	                            19246, regtest: 29246, signet: 39246)
+	    --loadblock=            Import the blocks of a block archive written by
+	                            exportblocks, or of a bootstrap.dat file, at
+	                            startup -- Can be specified multiple times
 	    --logdir=               Directory to log output
+	    --logformat=            Format of the log records: text, or one JSON
+	                            object per line with the time, level,
+	                            subsystem, message and fields of the record
+	                            {text, json} (default: text)
 	    --maxorphantx=          Max number of orphan transactions to keep in
 	                            memory (default: 100)
 	    --maxpeers=             Max number of inbound and outbound peers
 	                            (default: 125)
 	    --memprofile=           Write memory profile to the specified file
+	    --minfreespace=         Stop accepting blocks while the free space of the
+	                            volume of the block files, the chain state or the
+	                            claimtrie falls below this many MiB -- 0 disables
+	                            it (default: 1024)
 	    --miningaddr=           Add the specified payment address to the list of
 	                            addresses to use for generated blocks -- At least
 	                            one address is required if the generate option is
 	                            set
 	    --minrelaytxfee=        The minimum transaction fee in BTC/kB to be
 	                            considered a non-zero fee. (default: 1e-05)
+	    --mmapblockfiles        Read the block files from memory mappings of
+	                            them, and hint the OS to read them ahead of the
+	                            sequential scans of the chain, such as those
+	                            building the indexes
 	    --nobanning             Disable banning of misbehaving peers
 	    --nocfilters            Disable committed filtering (CF) support
 	    --nocheckpoints         Disable built-in checkpoints.  Don't do this
 	                            unless you know what you're doing.
+	    --nodbrepair            Fail to start when the block files are
+	                            inconsistent with the block database, instead of
+	                            truncating them to their last consistent point
+	                            and downloading the dropped blocks again
 	    --nodnsseed             Disable DNS seeding for peers
 	    --nolisten              Disable listening for incoming connections --
 	                            NOTE: Listening is automatically disabled if the
 	                            --connect or --proxy options are used without
 	                            also specifying listen interfaces via --listen
+	    --noonehoprelay         Broadcast the transactions submitted locally to
+	                            all peers at once, instead of relaying them
+	                            through a single outbound peer first
 	    --noonion               Disable connecting to tor hidden services
 	    --nopeerbloomfilters    Disable bloom filtering support
 	    --norelaypriority       Do not require free or low-fee transactions to
@@ -109,17 +204,59 @@ Application Options:
 	                            (eg. 127.0.0.1:9050)
 	    --onionpass=            Password for onion proxy server
 	    --onionuser=            Username for onion proxy server
+	    --otlpendpoint=         Export traces of the processing of blocks and
+	                            RPC requests to the OTLP/HTTP traces endpoint
+	                            of an OpenTelemetry collector, such as
+	                            http://localhost:4318/v1/traces -- Tracing is
+	                            disabled unless specified
+	    --outboundnetwork=      Maintain a number of the full-relay outbound
+	                            peers on a network, given as <network>=<count>
+	                            with network ipv4, ipv6 or onion, so that
+	                            network is not crowded out by the others -- Can
+	                            be specified multiple times
+	    --p2pwslisten=          Add an interface/port to accept peer-to-peer
+	                            connections over WebSocket on, for the
+	                            in-browser light clients -- The WebSocket
+	                            listener is disabled unless at least one is
+	                            specified
+	    --p2pwsorigin=          Add an origin, such as https://example.com, of
+	                            the web pages allowed to connect over
+	                            WebSocket, or * to allow any -- Browsers are
+	                            refused unless their origin is allowed
+	    --p2pwstls              Serve the WebSocket peer-to-peer listeners over
+	                            TLS with the certificate and key of the RPC
+	                            server
+	    --persistsigcache       Save the signature verification cache to the
+	                            data directory on shutdown and restore it on
+	                            startup
+	    --policyplugin=         Consult the gRPC policy service of a sidecar at
+	                            the address, given as <host:port> or
+	                            unix:<path>, before accepting transactions to
+	                            the mempool and including them in block
+	                            templates
+	    --policypluginfailopen  Accept the transactions when the policy plugin
+	                            is unavailable instead of rejecting them
+	    --policyplugintimeout=  Max time to wait for the verdicts of the policy
+	                            plugin.  Valid time units are {ms, s, m, h}
+	                            (default: 1s)
 	    --profile=              Enable HTTP profiling on given port -- NOTE port
 	                            must be between 1024 and 65536
 	    --proxy=                Connect via SOCKS5 proxy (eg. 127.0.0.1:9050)
 	    --proxypass=            Password for proxy server
 	    --proxyuser=            Username for proxy server
+	    --readymaxlag=          Max number of blocks the best chain may be behind
+	                            the peers for /readyz to report the node as ready
+	                            (default: 2)
 	    --regtest               Use the regression test network
 	    --rejectnonstd          Reject non-standard transactions regardless of
 	                            the default settings for the active network.
 	    --relaynonstd           Relay non-standard transactions regardless of the
 	                            default settings for the active network.
 	    --rpccert=              File containing the certificate file
+	    --rpcdraintimeout=      Max time to wait on shutdown for the RPC and gRPC
+	                            requests being processed to finish -- 0 stops
+	                            the servers without waiting.  Valid time units
+	                            are {ms, s, m, h} (default: 10s)
 	    --rpckey=               File containing the certificate key
 	    --rpclimitpass=         Password for limited RPC connections
 	    --rpclimituser=         Username for limited RPC connections
@@ -129,19 +266,37 @@ Application Options:
 	                            connections (default: 10)
 	    --rpcmaxconcurrentreqs= Max number of concurrent RPC requests that may be
 	                            processed concurrently (default: 20)
-	    --rpcmaxwebsockets=     Max number of RPC websocket connections (default:
-	                            25)
+	    --rpcmaxwebsockets=     Max number of RPC websocket and event stream
+	                            connections (default: 25)
 	    --rpcquirks             Mirror some JSON-RPC quirks of Bitcoin Core --
 	                            NOTE: Discouraged unless interoperability issues
 	                            need to be worked around
 	-P, --rpcpass=              Password for RPC connections
 	-u, --rpcuser=              Username for RPC connections
+	    --serveclaimsnapshots   Write a snapshot of the claimtrie every 10000
+	                            blocks and serve it to the peers
 	    --sigcachemaxsize=      The maximum number of entries in the signature
 	                            verification cache (default: 100000)
 	    --simnet                Use the simulation test network
+	    --slowblock=            Log the blocks taking longer than this to connect
+	                            to the main chain, with a breakdown of the time
+	                            spent -- 0 disables the logging.  Valid time
+	                            units are {ms, s, m, h} (default: 5s)
+	    --slowrpc=              Log the RPC and gRPC requests taking longer than
+	                            this to process -- 0 disables the logging.  Valid
+	                            time units are {ms, s, m, h} (default: 10s)
 	    --testnet               Use the test network
+	    --torcontrol=           Create an onion service for the peer-to-peer
+	                            listener through the Tor control port (eg.
+	                            127.0.0.1:9051) -- Its key is kept in the data
+	                            directory
+	    --torcontrolpass=       Password for the Tor control port -- The
+	                            authentication cookie of Tor is used when not
+	                            set
 	    --torisolation          Enable Tor stream isolation by randomizing user
 	                            credentials for each connection.
+	    --toronionrpc           Also serve the RPC server on the onion service
+	                            created through the Tor control port
 	    --trickleinterval=      Minimum time between attempts to send new
 	                            inventory to a connected peer (default: 10s)
 	    --txindex               Maintain a full hash-based transaction index
@@ -151,6 +306,14 @@ Application Options:
 	                            for more information.
 	    --upnp                  Use UPnP to map our listening port outside of NAT
 	-V, --version               Display version information and exit
+	    --versionbits=          Set whether the block templates signal for a rule
+	                            change deployment, given as
+	                            <deployment>=<signal|nosignal|default> such as
+	                            dummy=signal -- Can be specified multiple times
+	    --warnfreespace=        Warn while the free space of the volume of the
+	                            block files, the chain state or the claimtrie
+	                            falls below this many MiB -- 0 disables it
+	                            (default: 10240)
 	    --whitelist=            Add an IP network or IP that will not be banned.
 	                            (eg. 192.168.1.0/24 or ::1)
 
@@ -0,0 +1,1573 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lbryio/lbcd/blockchain"
+	"github.com/lbryio/lbcd/blockchain/indexers"
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/database"
+	"github.com/lbryio/lbcd/mempool"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+const (
+	// electrumProtocolVersion is the version of the Electrum protocol the
+	// server speaks.
+	electrumProtocolVersion = "1.4"
+
+	// electrumIdleTimeout is how long a client may stay silent before it
+	// is disconnected.  The clients ping the server to stay connected.
+	electrumIdleTimeout = 10 * time.Minute
+
+	// electrumWriteTimeout is how long writing a message to a client may
+	// take before the client is disconnected.
+	electrumWriteTimeout = 30 * time.Second
+
+	// electrumMaxMessageSize is the max size in bytes of a line received
+	// from a client, which fits the hex of the largest transactions.
+	electrumMaxMessageSize = 4 * 1024 * 1024
+
+	// electrumMaxSubscriptions is the max number of script hashes and
+	// addresses a client may subscribe to.
+	electrumMaxSubscriptions = 10000
+
+	// electrumMaxHistory is the max number of transactions in the history
+	// of a script hash or address served to the clients.
+	electrumMaxHistory = 10000
+
+	// electrumMaxHeaders is the max number of headers returned by
+	// blockchain.block.headers.
+	electrumMaxHeaders = 2016
+
+	// electrumNotifyInterval is the minimum time between the updates of
+	// the subscriptions, which coalesces the updates of the transactions
+	// accepted to the mempool in the meantime.
+	electrumNotifyInterval = 500 * time.Millisecond
+)
+
+// electrumHandler handles a method of the Electrum protocol with the
+// positional parameters of the request.
+type electrumHandler func(c *electrumClient, params []json.RawMessage) (interface{}, error)
+
+// electrumHandlers maps the methods of the Electrum protocol to their
+// handlers.  It is initialized in init to avoid an initialization loop.
+var electrumHandlers map[string]electrumHandler
+
+func init() {
+	electrumHandlers = map[string]electrumHandler{
+		"blockchain.block.header":           handleElectrumBlockHeader,
+		"blockchain.block.headers":          handleElectrumBlockHeaders,
+		"blockchain.estimatefee":            handleElectrumEstimateFee,
+		"blockchain.headers.subscribe":      handleElectrumHeadersSubscribe,
+		"blockchain.relayfee":               handleElectrumRelayFee,
+		"blockchain.transaction.broadcast":  handleElectrumBroadcast,
+		"blockchain.transaction.get":        handleElectrumGetTransaction,
+		"blockchain.transaction.get_merkle": handleElectrumGetMerkle,
+		"blockchain.claimtrie.resolve":      handleElectrumResolve,
+		"blockchain.address.get_balance":    electrumTargetHandler(false, electrumBalance),
+		"blockchain.address.get_history":    electrumTargetHandler(false, electrumHistory),
+		"blockchain.address.get_mempool":    electrumTargetHandler(false, electrumMempool),
+		"blockchain.address.listunspent":    electrumTargetHandler(false, electrumUnspent),
+		"blockchain.address.subscribe":      electrumSubscribeHandler(false),
+		"blockchain.address.unsubscribe":    electrumUnsubscribeHandler(false),
+		"blockchain.scripthash.get_balance": electrumTargetHandler(true, electrumBalance),
+		"blockchain.scripthash.get_history": electrumTargetHandler(true, electrumHistory),
+		"blockchain.scripthash.get_mempool": electrumTargetHandler(true, electrumMempool),
+		"blockchain.scripthash.listunspent": electrumTargetHandler(true, electrumUnspent),
+		"blockchain.scripthash.subscribe":   electrumSubscribeHandler(true),
+		"blockchain.scripthash.unsubscribe": electrumUnsubscribeHandler(true),
+		"server.banner":                     handleElectrumBanner,
+		"server.donation_address":           handleElectrumDonationAddress,
+		"server.features":                   handleElectrumFeatures,
+		"server.peers.subscribe":            handleElectrumPeers,
+		"server.ping":                       handleElectrumPing,
+		"server.version":                    handleElectrumVersion,
+	}
+}
+
+// electrumServerConfig is a descriptor containing the Electrum server
+// configuration.
+type electrumServerConfig struct {
+	// Listeners defines a slice of listeners for which the Electrum server
+	// will take ownership of and accept connections.
+	Listeners []net.Listener
+
+	// MaxClients is the max number of clients connected at once.
+	MaxClients int
+
+	// RPC is the JSON-RPC server, whose command handlers are shared with
+	// the Electrum server.
+	RPC *rpcServer
+
+	// ScriptHashIndex looks up the scripts of the script hashes the clients
+	// refer to.
+	ScriptHashIndex *indexers.ScriptHashIndex
+}
+
+// electrumServer serves the Electrum protocol used by the LBRY wallets from
+// the address index, the transaction index and the claimtrie, which spares the
+// small operators a separate hub.
+type electrumServer struct {
+	started    int32
+	shutdown   int32
+	numClients int32
+	cfg        electrumServerConfig
+	wg         sync.WaitGroup
+
+	clientsLock sync.Mutex
+	clients     map[*electrumClient]struct{}
+
+	update chan struct{}
+	quit   chan struct{}
+}
+
+// newElectrumServer returns a new instance of the electrumServer struct.
+func newElectrumServer(config *electrumServerConfig) *electrumServer {
+	s := &electrumServer{
+		cfg:     *config,
+		clients: make(map[*electrumClient]struct{}),
+		update:  make(chan struct{}, 1),
+		quit:    make(chan struct{}),
+	}
+	config.RPC.electrumServer = s
+	config.RPC.cfg.Chain.Subscribe(s.handleBlockchainNotification)
+	return s
+}
+
+// Start is used by server.go to start the Electrum listeners.
+func (s *electrumServer) Start() {
+	if atomic.AddInt32(&s.started, 1) != 1 {
+		return
+	}
+
+	for _, listener := range s.cfg.Listeners {
+		s.wg.Add(1)
+		go s.listenHandler(listener)
+	}
+	s.wg.Add(1)
+	go s.notifyHandler()
+}
+
+// Stop is used by server.go to stop the Electrum listeners and disconnect the
+// clients.
+func (s *electrumServer) Stop() {
+	if atomic.AddInt32(&s.shutdown, 1) != 1 {
+		rpcsLog.Infof("Electrum server is already in the process of " +
+			"shutting down")
+		return
+	}
+	rpcsLog.Warnf("Electrum server shutting down")
+	close(s.quit)
+
+	for _, listener := range s.cfg.Listeners {
+		listener.Close()
+	}
+	s.clientsLock.Lock()
+	for c := range s.clients {
+		c.conn.Close()
+	}
+	s.clientsLock.Unlock()
+
+	s.wg.Wait()
+	rpcsLog.Infof("Electrum server shutdown complete")
+}
+
+// listenHandler accepts the connections of the listener until it's closed.
+//
+// This must be run as a goroutine.
+func (s *electrumServer) listenHandler(listener net.Listener) {
+	defer s.wg.Done()
+
+	rpcsLog.Infof("Electrum server listening on %s", listener.Addr())
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			break
+		}
+		if int(atomic.AddInt32(&s.numClients, 1)) > s.cfg.MaxClients {
+			atomic.AddInt32(&s.numClients, -1)
+			rpcsLog.Infof("Max Electrum clients exceeded [%d] - "+
+				"disconnecting client %s", s.cfg.MaxClients,
+				conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
+
+		c := &electrumClient{
+			server:        s,
+			conn:          conn,
+			addr:          conn.RemoteAddr().String(),
+			subscriptions: make(map[string]*electrumSubscription),
+		}
+		// The clients connecting while the server shuts down wouldn't
+		// be disconnected by Stop.
+		s.clientsLock.Lock()
+		select {
+		case <-s.quit:
+			s.clientsLock.Unlock()
+			atomic.AddInt32(&s.numClients, -1)
+			conn.Close()
+			continue
+		default:
+		}
+		s.clients[c] = struct{}{}
+		s.clientsLock.Unlock()
+
+		s.wg.Add(1)
+		go s.clientHandler(c)
+	}
+	rpcsLog.Tracef("Electrum listener done for %s", listener.Addr())
+}
+
+// clientHandler reads and handles the requests of the client, one per line,
+// until it disconnects.
+//
+// This must be run as a goroutine.
+func (s *electrumServer) clientHandler(c *electrumClient) {
+	defer s.wg.Done()
+	defer func() {
+		s.clientsLock.Lock()
+		delete(s.clients, c)
+		s.clientsLock.Unlock()
+		atomic.AddInt32(&s.numClients, -1)
+		c.conn.Close()
+		rpcsLog.Debugf("Electrum client %s disconnected", c.addr)
+	}()
+
+	rpcsLog.Debugf("Electrum client %s connected", c.addr)
+	scanner := bufio.NewScanner(c.conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), electrumMaxMessageSize)
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(electrumIdleTimeout))
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				rpcsLog.Debugf("Electrum client %s: %v", c.addr, err)
+			}
+			return
+		}
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		reply := c.handleMessage(line)
+		if reply == nil {
+			continue
+		}
+		if err := c.write(reply); err != nil {
+			rpcsLog.Debugf("Electrum client %s: %v", c.addr, err)
+			return
+		}
+	}
+}
+
+// signalUpdate schedules an update of the subscriptions of the clients.
+func (s *electrumServer) signalUpdate() {
+	select {
+	case s.update <- struct{}{}:
+	default:
+	}
+}
+
+// handleBlockchainNotification schedules an update of the subscriptions when
+// blocks are connected to, or disconnected from, the main chain.  The clients
+// are notified later on, as the chain is locked at this time.
+func (s *electrumServer) handleBlockchainNotification(notification *blockchain.Notification) {
+	switch notification.Type {
+	case blockchain.NTBlockConnected, blockchain.NTBlockDisconnected:
+		s.signalUpdate()
+	}
+}
+
+// NotifyNewTransactions schedules an update of the subscriptions for the
+// transactions accepted to the mempool.
+func (s *electrumServer) NotifyNewTransactions([]*mempool.TxDesc) {
+	s.signalUpdate()
+}
+
+// notifyHandler notifies the clients of the new tips of the main chain and of
+// the new statuses of their subscriptions as they are scheduled.
+//
+// This must be run as a goroutine.
+func (s *electrumServer) notifyHandler() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.update:
+		case <-s.quit:
+			return
+		}
+
+		s.clientsLock.Lock()
+		clients := make([]*electrumClient, 0, len(s.clients))
+		for c := range s.clients {
+			clients = append(clients, c)
+		}
+		s.clientsLock.Unlock()
+
+		tip, err := s.tipHeader()
+		if err != nil {
+			rpcsLog.Errorf("Electrum server: %v", err)
+			continue
+		}
+		r := newElectrumResolver(s)
+		for _, c := range clients {
+			if err := c.notify(tip, r); err != nil {
+				rpcsLog.Debugf("Electrum client %s: %v", c.addr, err)
+				c.conn.Close()
+			}
+		}
+
+		select {
+		case <-time.After(electrumNotifyInterval):
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// electrumHeader is a header of the main chain as notified to the clients.
+type electrumHeader struct {
+	Hex    string `json:"hex"`
+	Height int32  `json:"height"`
+
+	hash chainhash.Hash
+}
+
+// tipHeader returns the header of the tip of the main chain.
+func (s *electrumServer) tipHeader() (*electrumHeader, error) {
+	best := s.cfg.RPC.cfg.Chain.BestSnapshot()
+	header, err := s.cfg.RPC.cfg.Chain.HeaderByHash(&best.Hash)
+	if err != nil {
+		return nil, err
+	}
+	return &electrumHeader{
+		Hex:    serializeElectrumHeader(&header),
+		Height: best.Height,
+		hash:   best.Hash,
+	}, nil
+}
+
+// serializeElectrumHeader returns the hex of the serialized header.
+func serializeElectrumHeader(header *wire.BlockHeader) string {
+	var buf bytes.Buffer
+	buf.Grow(wire.MaxBlockHeaderPayload)
+	_ = header.Serialize(&buf)
+	return hex.EncodeToString(buf.Bytes())
+}
+
+// electrumTarget is the script hash or address the history is looked up for.
+type electrumTarget struct {
+	addr btcutil.Address
+
+	// scriptHash is the script hash the target was looked up by, or nil
+	// for addresses.
+	scriptHash *chainhash.Hash
+}
+
+// pays returns whether the output script pays to the target.
+func (t *electrumTarget) pays(pkScript []byte, s *electrumServer) bool {
+	if t.scriptHash != nil {
+		return indexers.ScriptHash(pkScript) == *t.scriptHash
+	}
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript,
+		s.cfg.RPC.cfg.ChainParams)
+	return err == nil && len(addrs) == 1 &&
+		addrs[0].EncodeAddress() == t.addr.EncodeAddress()
+}
+
+// electrumResolver looks up the targets of the script hashes and addresses.
+// The scripts of the outputs of the mempool, which aren't in the script hash
+// index yet, are collected once per resolver.
+type electrumResolver struct {
+	s              *electrumServer
+	mempoolScripts map[chainhash.Hash][]byte
+}
+
+// newElectrumResolver returns a resolver of the targets of the server.
+func newElectrumResolver(s *electrumServer) *electrumResolver {
+	return &electrumResolver{s: s}
+}
+
+// target returns the target of the script hash or address, or nil when the
+// script hash isn't known yet.
+func (r *electrumResolver) target(param string, isScriptHash bool) (*electrumTarget, error) {
+	params := r.s.cfg.RPC.cfg.ChainParams
+	if !isScriptHash {
+		addr, err := btcutil.DecodeAddress(param, params)
+		if err != nil || !addr.IsForNet(params) {
+			return nil, electrumParamError("invalid address %q", param)
+		}
+		return &electrumTarget{addr: addr}, nil
+	}
+
+	if len(param) != chainhash.MaxHashStringSize {
+		return nil, electrumParamError("invalid script hash %q", param)
+	}
+	hash, err := chainhash.NewHashFromStr(param)
+	if err != nil {
+		return nil, electrumParamError("invalid script hash %q", param)
+	}
+	pkScript, err := r.s.cfg.ScriptHashIndex.ScriptForHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	if pkScript == nil {
+		if r.mempoolScripts == nil {
+			r.mempoolScripts = make(map[chainhash.Hash][]byte)
+			for _, desc := range r.s.cfg.RPC.cfg.TxMemPool.TxDescs() {
+				for _, txOut := range desc.Tx.MsgTx().TxOut {
+					h := indexers.ScriptHash(txOut.PkScript)
+					r.mempoolScripts[h] = txOut.PkScript
+				}
+			}
+		}
+		pkScript = r.mempoolScripts[*hash]
+	}
+	if pkScript == nil {
+		return nil, nil
+	}
+
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript, params)
+	if err != nil || len(addrs) != 1 {
+		return nil, nil
+	}
+	return &electrumTarget{addr: addrs[0], scriptHash: hash}, nil
+}
+
+// electrumTx is a transaction of the history of a target.
+type electrumTx struct {
+	tx     *btcutil.Tx
+	height int32
+
+	// fee is only set for the transactions of the mempool.
+	fee int64
+}
+
+// electrumTxns is the history of a target, confirmed transactions first.
+type electrumTxns struct {
+	confirmed []electrumTx
+	mempool   []electrumTx
+}
+
+// fetchTxns returns the history of the target in the main chain and the
+// mempool.  Nil targets, whose script hash isn't known yet, have no history.
+func (s *electrumServer) fetchTxns(t *electrumTarget) (*electrumTxns, error) {
+	txns := &electrumTxns{}
+	if t == nil {
+		return txns, nil
+	}
+
+	rpc := s.cfg.RPC
+	var regions []database.BlockRegion
+	var serializedTxns [][]byte
+	err := rpc.cfg.DB.View(func(dbTx database.Tx) error {
+		var err error
+		regions, _, err = rpc.cfg.AddrIndex.TxRegionsForAddress(dbTx, t.addr,
+			0, electrumMaxHistory+1, false)
+		if err != nil {
+			return err
+		}
+		serializedTxns, err = dbTx.FetchBlockRegions(regions)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	mpTxns := rpc.cfg.AddrIndex.UnconfirmedTxnsForAddress(t.addr)
+	if len(regions)+len(mpTxns) > electrumMaxHistory {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCMisc,
+			Message: fmt.Sprintf("the history has more than %d "+
+				"transactions", electrumMaxHistory),
+		}
+	}
+
+	heights := make(map[chainhash.Hash]int32)
+	for i, serializedTx := range serializedTxns {
+		tx, err := btcutil.NewTxFromBytes(serializedTx)
+		if err != nil {
+			return nil, err
+		}
+		height, ok := heights[*regions[i].Hash]
+		if !ok {
+			height, err = rpc.cfg.Chain.BlockHeightByHash(regions[i].Hash)
+			if err != nil {
+				return nil, err
+			}
+			heights[*regions[i].Hash] = height
+		}
+		txns.confirmed = append(txns.confirmed, electrumTx{
+			tx:     tx,
+			height: height,
+		})
+	}
+
+	if len(mpTxns) == 0 {
+		return txns, nil
+	}
+	mp := rpc.cfg.TxMemPool
+	fees := make(map[chainhash.Hash]int64)
+	for _, desc := range mp.TxDescs() {
+		fees[*desc.Tx.Hash()] = desc.Fee
+	}
+	for _, tx := range mpTxns {
+		// The transactions spending the outputs of the mempool have a
+		// height of -1, and the others a height of 0.
+		var height int32
+		for _, txIn := range tx.MsgTx().TxIn {
+			if mp.HaveTransaction(&txIn.PreviousOutPoint.Hash) {
+				height = -1
+				break
+			}
+		}
+		txns.mempool = append(txns.mempool, electrumTx{
+			tx:     tx,
+			height: height,
+			fee:    fees[*tx.Hash()],
+		})
+	}
+	sort.Slice(txns.mempool, func(i, j int) bool {
+		a, b := txns.mempool[i], txns.mempool[j]
+		if a.height != b.height {
+			return a.height > b.height
+		}
+		return a.tx.Hash().String() < b.tx.Hash().String()
+	})
+	return txns, nil
+}
+
+// electrumHistoryEntry is a transaction of the history of a target.
+type electrumHistoryEntry struct {
+	TxHash string `json:"tx_hash"`
+	Height int32  `json:"height"`
+	Fee    *int64 `json:"fee,omitempty"`
+}
+
+// historyEntries returns the entries of the transactions.
+func historyEntries(txns []electrumTx, withFee bool) []electrumHistoryEntry {
+	entries := make([]electrumHistoryEntry, 0, len(txns))
+	for _, tx := range txns {
+		entry := electrumHistoryEntry{
+			TxHash: tx.tx.Hash().String(),
+			Height: tx.height,
+		}
+		if withFee {
+			fee := tx.fee
+			entry.Fee = &fee
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// electrumHistory returns the confirmed and mempool history of the target.
+func electrumHistory(s *electrumServer, t *electrumTarget) (interface{}, error) {
+	txns, err := s.fetchTxns(t)
+	if err != nil {
+		return nil, err
+	}
+	return append(historyEntries(txns.confirmed, false),
+		historyEntries(txns.mempool, true)...), nil
+}
+
+// electrumMempool returns the mempool history of the target.
+func electrumMempool(s *electrumServer, t *electrumTarget) (interface{}, error) {
+	txns, err := s.fetchTxns(t)
+	if err != nil {
+		return nil, err
+	}
+	return historyEntries(txns.mempool, true), nil
+}
+
+// electrumStatus returns the status of the history as defined by the Electrum
+// protocol, which is nil for empty histories.
+func electrumStatus(history []electrumHistoryEntry) *string {
+	if len(history) == 0 {
+		return nil
+	}
+	h := sha256.New()
+	for _, entry := range history {
+		fmt.Fprintf(h, "%s:%d:", entry.TxHash, entry.Height)
+	}
+	status := hex.EncodeToString(h.Sum(nil))
+	return &status
+}
+
+// prevOut returns the output spent by the outpoint from the main chain or the
+// mempool, or nil if it can't be found.
+func (s *electrumServer) prevOut(outpoint wire.OutPoint) *wire.TxOut {
+	rpc := s.cfg.RPC
+	if tx, err := rpc.cfg.TxMemPool.FetchTransaction(&outpoint.Hash); err == nil {
+		if outpoint.Index < uint32(len(tx.MsgTx().TxOut)) {
+			return tx.MsgTx().TxOut[outpoint.Index]
+		}
+		return nil
+	}
+	entry, err := rpc.cfg.Chain.FetchUtxoEntry(outpoint)
+	if err != nil || entry == nil || entry.IsSpent() {
+		return nil
+	}
+	return wire.NewTxOut(entry.Amount(), entry.PkScript())
+}
+
+// electrumUnspentEntry is an unspent output of a target.
+type electrumUnspentEntry struct {
+	TxHash string `json:"tx_hash"`
+	TxPos  uint32 `json:"tx_pos"`
+	Height int32  `json:"height"`
+	Value  int64  `json:"value"`
+}
+
+// unspent returns the outputs paying to the target which are unspent in the
+// main chain, and in the mempool when spentInMempool is false.
+func (s *electrumServer) unspent(t *electrumTarget, txns *electrumTxns,
+	spentInMempool bool) []electrumUnspentEntry {
+
+	rpc := s.cfg.RPC
+	var entries []electrumUnspentEntry
+	for _, tx := range append(txns.confirmed, txns.mempool...) {
+		for i, txOut := range tx.tx.MsgTx().TxOut {
+			if !t.pays(txOut.PkScript, s) {
+				continue
+			}
+			outpoint := wire.OutPoint{Hash: *tx.tx.Hash(), Index: uint32(i)}
+			if tx.height > 0 {
+				entry, err := rpc.cfg.Chain.FetchUtxoEntry(outpoint)
+				if err != nil || entry == nil || entry.IsSpent() {
+					continue
+				}
+			}
+			if !spentInMempool && rpc.cfg.TxMemPool.CheckSpend(outpoint) != nil {
+				continue
+			}
+			height := tx.height
+			if height < 0 {
+				height = 0
+			}
+			entries = append(entries, electrumUnspentEntry{
+				TxHash: outpoint.Hash.String(),
+				TxPos:  outpoint.Index,
+				Height: height,
+				Value:  txOut.Value,
+			})
+		}
+	}
+	return entries
+}
+
+// electrumUnspent returns the unspent outputs of the target, including those
+// of the mempool and excluding those spent by the mempool.
+func electrumUnspent(s *electrumServer, t *electrumTarget) (interface{}, error) {
+	txns, err := s.fetchTxns(t)
+	if err != nil {
+		return nil, err
+	}
+	entries := []electrumUnspentEntry{}
+	if t != nil {
+		entries = append(entries, s.unspent(t, txns, false)...)
+	}
+	return entries, nil
+}
+
+// electrumBalanceResult is the balance of a target in dewies.  The
+// unconfirmed balance is the change of the balance by the mempool.
+type electrumBalanceResult struct {
+	Confirmed   int64 `json:"confirmed"`
+	Unconfirmed int64 `json:"unconfirmed"`
+}
+
+// electrumBalance returns the balance of the target.
+func electrumBalance(s *electrumServer, t *electrumTarget) (interface{}, error) {
+	txns, err := s.fetchTxns(t)
+	if err != nil {
+		return nil, err
+	}
+	var result electrumBalanceResult
+	if t == nil {
+		return result, nil
+	}
+
+	confirmed := &electrumTxns{confirmed: txns.confirmed}
+	for _, entry := range s.unspent(t, confirmed, true) {
+		result.Confirmed += entry.Value
+	}
+	for _, tx := range txns.mempool {
+		for _, txOut := range tx.tx.MsgTx().TxOut {
+			if t.pays(txOut.PkScript, s) {
+				result.Unconfirmed += txOut.Value
+			}
+		}
+		for _, txIn := range tx.tx.MsgTx().TxIn {
+			prevOut := s.prevOut(txIn.PreviousOutPoint)
+			if prevOut != nil && t.pays(prevOut.PkScript, s) {
+				result.Unconfirmed -= prevOut.Value
+			}
+		}
+	}
+	return result, nil
+}
+
+// electrumSubscription is a subscription of a client to the status of a
+// script hash or address.
+type electrumSubscription struct {
+	method       string
+	param        string
+	isScriptHash bool
+
+	// target is nil until the script hash is known.
+	target *electrumTarget
+	status *string
+}
+
+// electrumClient is a client connected to the Electrum server.
+type electrumClient struct {
+	server *electrumServer
+	conn   net.Conn
+	addr   string
+
+	writeLock sync.Mutex
+
+	mtx           sync.Mutex
+	headers       bool
+	tip           chainhash.Hash
+	subscriptions map[string]*electrumSubscription
+}
+
+// electrumRequest is a request, or a notification when it has no ID, of a
+// client.
+type electrumRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// electrumResponse is the response to a request.  Exactly one of the result
+// and the error is set.
+type electrumResponse struct {
+	Jsonrpc string            `json:"jsonrpc"`
+	Result  json.RawMessage   `json:"result,omitempty"`
+	Error   *btcjson.RPCError `json:"error,omitempty"`
+	ID      json.RawMessage   `json:"id"`
+}
+
+// electrumNotification is a notification sent to a client.
+type electrumNotification struct {
+	Jsonrpc string        `json:"jsonrpc"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+// electrumParamError returns the invalid parameters error with the message.
+func electrumParamError(format string, args ...interface{}) *btcjson.RPCError {
+	return &btcjson.RPCError{
+		Code:    btcjson.ErrRPCInvalidParams.Code,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// parseElectrumParams unmarshals the positional parameters into args, of which
+// the first required ones must be given.
+func parseElectrumParams(params []json.RawMessage, required int, args ...interface{}) error {
+	if len(params) < required || len(params) > len(args) {
+		if required == len(args) {
+			return electrumParamError("expected %d parameters, got %d",
+				required, len(params))
+		}
+		return electrumParamError("expected %d to %d parameters, got %d",
+			required, len(args), len(params))
+	}
+	for i, param := range params {
+		if err := json.Unmarshal(param, args[i]); err != nil {
+			return electrumParamError("invalid parameter %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// handleMessage handles a line received from the client, which is either a
+// request or a batch of requests, and returns the reply, or nil when there is
+// nothing to reply.
+func (c *electrumClient) handleMessage(line []byte) []byte {
+	if line[0] != '[' {
+		reply, _ := c.handleRequest(line)
+		return reply
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(line, &batch); err != nil || len(batch) == 0 {
+		reply, _ := json.Marshal(electrumErrorResponse(nil,
+			btcjson.ErrRPCInvalidRequest))
+		return reply
+	}
+	replies := make([]json.RawMessage, 0, len(batch))
+	for _, req := range batch {
+		reply, ok := c.handleRequest(req)
+		if ok {
+			replies = append(replies, reply)
+		}
+	}
+	if len(replies) == 0 {
+		return nil
+	}
+	reply, _ := json.Marshal(replies)
+	return reply
+}
+
+// electrumErrorResponse returns the error response to the request with the ID.
+func electrumErrorResponse(id json.RawMessage, rpcErr *btcjson.RPCError) *electrumResponse {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &electrumResponse{Jsonrpc: "2.0", Error: rpcErr, ID: id}
+}
+
+// handleRequest handles a request and returns its response, and whether there
+// is one, as the notifications are not responded to.
+func (c *electrumClient) handleRequest(msg []byte) ([]byte, bool) {
+	var req electrumRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		reply, _ := json.Marshal(electrumErrorResponse(nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCParse.Code,
+			Message: "Failed to parse request: " + err.Error(),
+		}))
+		return reply, true
+	}
+	isNotification := len(req.ID) == 0 || string(req.ID) == "null"
+
+	var params []json.RawMessage
+	var result interface{}
+	var err error
+	if p := bytes.TrimSpace(req.Params); len(p) > 0 && string(p) != "null" {
+		if jsonErr := json.Unmarshal(p, &params); jsonErr != nil {
+			err = electrumParamError("the parameters must be an array")
+		}
+	}
+	if err == nil {
+		handler, ok := electrumHandlers[req.Method]
+		if !ok {
+			err = &btcjson.RPCError{
+				Code:    btcjson.ErrRPCMethodNotFound.Code,
+				Message: "Method not found: " + req.Method,
+			}
+		} else {
+			done := c.server.cfg.RPC.trackCommand(req.Method, c.addr)
+			result, err = handler(c, params)
+			done(err)
+		}
+	}
+	if isNotification {
+		return nil, false
+	}
+
+	var resp *electrumResponse
+	if err != nil {
+		rpcErr, ok := err.(*btcjson.RPCError)
+		if !ok {
+			rpcErr = &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInternal.Code,
+				Message: err.Error(),
+			}
+		}
+		resp = electrumErrorResponse(req.ID, rpcErr)
+	} else {
+		marshalled, err := json.Marshal(result)
+		if err != nil {
+			resp = electrumErrorResponse(req.ID, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInternal.Code,
+				Message: err.Error(),
+			})
+		} else {
+			resp = &electrumResponse{
+				Jsonrpc: "2.0",
+				Result:  marshalled,
+				ID:      req.ID,
+			}
+		}
+	}
+	reply, _ := json.Marshal(resp)
+	return reply, true
+}
+
+// write writes the message to the client, followed by a newline.
+func (c *electrumClient) write(msg []byte) error {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(electrumWriteTimeout))
+	_, err := c.conn.Write(append(msg, '\n'))
+	return err
+}
+
+// sendNotification sends the notification of the method to the client.
+func (c *electrumClient) sendNotification(method string, params ...interface{}) error {
+	msg, err := json.Marshal(&electrumNotification{
+		Jsonrpc: "2.0",
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+	return c.write(msg)
+}
+
+// notify notifies the client of the tip of the main chain, if it subscribed to
+// the headers and it's new, and of the new statuses of its subscriptions.
+func (c *electrumClient) notify(tip *electrumHeader, r *electrumResolver) error {
+	c.mtx.Lock()
+	notifyTip := c.headers && c.tip != tip.hash
+	c.tip = tip.hash
+	subs := make([]*electrumSubscription, 0, len(c.subscriptions))
+	for _, sub := range c.subscriptions {
+		subs = append(subs, sub)
+	}
+	c.mtx.Unlock()
+
+	if notifyTip {
+		err := c.sendNotification("blockchain.headers.subscribe", tip)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, sub := range subs {
+		target := sub.target
+		if target == nil {
+			var err error
+			target, err = r.target(sub.param, sub.isScriptHash)
+			if err != nil {
+				rpcsLog.Warnf("Electrum server: failed to look up "+
+					"%s: %v", sub.param, err)
+				continue
+			}
+		}
+		status, err := c.server.status(target)
+		if err != nil {
+			rpcsLog.Warnf("Electrum server: failed to update the "+
+				"status of %s: %v", sub.param, err)
+			continue
+		}
+
+		c.mtx.Lock()
+		changed := !equalStatus(sub.status, status)
+		sub.target = target
+		sub.status = status
+		c.mtx.Unlock()
+
+		if changed {
+			err := c.sendNotification(sub.method, sub.param, status)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// status returns the status of the history of the target.
+func (s *electrumServer) status(t *electrumTarget) (*string, error) {
+	history, err := electrumHistory(s, t)
+	if err != nil {
+		return nil, err
+	}
+	return electrumStatus(history.([]electrumHistoryEntry)), nil
+}
+
+// equalStatus returns whether the statuses are the same.
+func equalStatus(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// electrumTargetMethod returns the prefix of the methods of the script hashes
+// or of the addresses.
+func electrumTargetMethod(isScriptHash bool) string {
+	if isScriptHash {
+		return "blockchain.scripthash."
+	}
+	return "blockchain.address."
+}
+
+// electrumTargetHandler returns the handler of a method whose only parameter
+// is a script hash or an address.
+func electrumTargetHandler(isScriptHash bool,
+	handler func(*electrumServer, *electrumTarget) (interface{}, error)) electrumHandler {
+
+	return func(c *electrumClient, params []json.RawMessage) (interface{}, error) {
+		var param string
+		if err := parseElectrumParams(params, 1, &param); err != nil {
+			return nil, err
+		}
+		target, err := newElectrumResolver(c.server).target(param, isScriptHash)
+		if err != nil {
+			return nil, err
+		}
+		return handler(c.server, target)
+	}
+}
+
+// electrumSubscribeHandler returns the handler subscribing to the status of a
+// script hash or an address, which returns its current status.
+func electrumSubscribeHandler(isScriptHash bool) electrumHandler {
+	return func(c *electrumClient, params []json.RawMessage) (interface{}, error) {
+		var param string
+		if err := parseElectrumParams(params, 1, &param); err != nil {
+			return nil, err
+		}
+		target, err := newElectrumResolver(c.server).target(param, isScriptHash)
+		if err != nil {
+			return nil, err
+		}
+		status, err := c.server.status(target)
+		if err != nil {
+			return nil, err
+		}
+
+		method := electrumTargetMethod(isScriptHash) + "subscribe"
+		c.mtx.Lock()
+		defer c.mtx.Unlock()
+		if _, ok := c.subscriptions[method+param]; !ok &&
+			len(c.subscriptions) >= electrumMaxSubscriptions {
+
+			return nil, &btcjson.RPCError{
+				Code: btcjson.ErrRPCMisc,
+				Message: fmt.Sprintf("too many subscriptions (max "+
+					"%d)", electrumMaxSubscriptions),
+			}
+		}
+		c.subscriptions[method+param] = &electrumSubscription{
+			method:       method,
+			param:        param,
+			isScriptHash: isScriptHash,
+			target:       target,
+			status:       status,
+		}
+		return status, nil
+	}
+}
+
+// electrumUnsubscribeHandler returns the handler unsubscribing from the status
+// of a script hash or an address, which returns whether there was such a
+// subscription.
+func electrumUnsubscribeHandler(isScriptHash bool) electrumHandler {
+	return func(c *electrumClient, params []json.RawMessage) (interface{}, error) {
+		var param string
+		if err := parseElectrumParams(params, 1, &param); err != nil {
+			return nil, err
+		}
+
+		key := electrumTargetMethod(isScriptHash) + "subscribe" + param
+		c.mtx.Lock()
+		defer c.mtx.Unlock()
+		_, ok := c.subscriptions[key]
+		delete(c.subscriptions, key)
+		return ok, nil
+	}
+}
+
+// handleElectrumBlockHeader implements the blockchain.block.header method.
+func handleElectrumBlockHeader(c *electrumClient, params []json.RawMessage) (interface{}, error) {
+	var height, cpHeight int32
+	if err := parseElectrumParams(params, 1, &height, &cpHeight); err != nil {
+		return nil, err
+	}
+	if cpHeight != 0 {
+		return nil, electrumParamError("checkpoint proofs are not supported")
+	}
+
+	chain := c.server.cfg.RPC.cfg.Chain
+	hash, err := chain.BlockHashByHeight(height)
+	if err != nil {
+		return nil, electrumParamError("no block at height %d", height)
+	}
+	header, err := chain.HeaderByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	return serializeElectrumHeader(&header), nil
+}
+
+// electrumHeadersResult is the result of blockchain.block.headers.
+type electrumHeadersResult struct {
+	Count int    `json:"count"`
+	Hex   string `json:"hex"`
+	Max   int    `json:"max"`
+}
+
+// handleElectrumBlockHeaders implements the blockchain.block.headers method.
+func handleElectrumBlockHeaders(c *electrumClient, params []json.RawMessage) (interface{}, error) {
+	var start, count, cpHeight int32
+	if err := parseElectrumParams(params, 2, &start, &count, &cpHeight); err != nil {
+		return nil, err
+	}
+	if cpHeight != 0 {
+		return nil, electrumParamError("checkpoint proofs are not supported")
+	}
+	if start < 0 || count < 0 {
+		return nil, electrumParamError("the start height and the count " +
+			"can't be negative")
+	}
+	if count > electrumMaxHeaders {
+		count = electrumMaxHeaders
+	}
+
+	chain := c.server.cfg.RPC.cfg.Chain
+	var hexHeaders strings.Builder
+	result := electrumHeadersResult{Max: electrumMaxHeaders}
+	for height := start; height < start+count; height++ {
+		hash, err := chain.BlockHashByHeight(height)
+		if err != nil {
+			break
+		}
+		header, err := chain.HeaderByHash(hash)
+		if err != nil {
+			return nil, err
+		}
+		hexHeaders.WriteString(serializeElectrumHeader(&header))
+		result.Count++
+	}
+	result.Hex = hexHeaders.String()
+	return result, nil
+}
+
+// handleElectrumHeadersSubscribe implements the blockchain.headers.subscribe
+// method.
+func handleElectrumHeadersSubscribe(c *electrumClient, params []json.RawMessage) (interface{}, error) {
+	if err := parseElectrumParams(params, 0); err != nil {
+		return nil, err
+	}
+	tip, err := c.server.tipHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mtx.Lock()
+	c.headers = true
+	c.tip = tip.hash
+	c.mtx.Unlock()
+	return tip, nil
+}
+
+// handleElectrumEstimateFee implements the blockchain.estimatefee method,
+// which returns -1 when the fee can't be estimated.
+func handleElectrumEstimateFee(c *electrumClient, params []json.RawMessage) (interface{}, error) {
+	var numBlocks int64
+	if err := parseElectrumParams(params, 1, &numBlocks); err != nil {
+		return nil, err
+	}
+	cmd := &btcjson.EstimateFeeCmd{NumBlocks: numBlocks}
+	result, err := handleEstimateFee(c.server.cfg.RPC, cmd, nil)
+	if err != nil {
+		return -1, nil
+	}
+	return result, nil
+}
+
+// handleElectrumRelayFee implements the blockchain.relayfee method.
+func handleElectrumRelayFee(c *electrumClient, params []json.RawMessage) (interface{}, error) {
+	if err := parseElectrumParams(params, 0); err != nil {
+		return nil, err
+	}
+	return cfg.minRelayTxFee.ToBTC(), nil
+}
+
+// handleElectrumBroadcast implements the blockchain.transaction.broadcast
+// method, which submits the transaction the same as sendrawtransaction.
+func handleElectrumBroadcast(c *electrumClient, params []json.RawMessage) (interface{}, error) {
+	var hexTx string
+	if err := parseElectrumParams(params, 1, &hexTx); err != nil {
+		return nil, err
+	}
+	cmd := &btcjson.SendRawTransactionCmd{HexTx: hexTx}
+	return handleSendRawTransaction(c.server.cfg.RPC, cmd, nil)
+}
+
+// handleElectrumGetTransaction implements the blockchain.transaction.get
+// method, which returns the transaction the same as getrawtransaction.
+func handleElectrumGetTransaction(c *electrumClient, params []json.RawMessage) (interface{}, error) {
+	var txHash string
+	var verbose bool
+	if err := parseElectrumParams(params, 1, &txHash, &verbose); err != nil {
+		return nil, err
+	}
+	cmd := &btcjson.GetRawTransactionCmd{Txid: txHash, Verbose: &verbose}
+	return handleGetRawTransaction(c.server.cfg.RPC, cmd, nil)
+}
+
+// electrumMerkleResult is the result of blockchain.transaction.get_merkle.
+type electrumMerkleResult struct {
+	BlockHeight int32    `json:"block_height"`
+	Merkle      []string `json:"merkle"`
+	Pos         int      `json:"pos"`
+}
+
+// electrumMerkleBranch returns the hashes of the merkle branch of the
+// transaction at the position among the transactions, from the leaves up.
+func electrumMerkleBranch(txns []*btcutil.Tx, pos int) []string {
+	store := blockchain.BuildMerkleTreeStore(txns, false)
+	branch := []string{}
+	offset := 0
+	for width := (len(store) + 1) / 2; width > 1; width /= 2 {
+		sibling := store[offset+(pos^1)]
+		if sibling == nil {
+			sibling = store[offset+pos]
+		}
+		branch = append(branch, sibling.String())
+		offset += width
+		pos /= 2
+	}
+	return branch
+}
+
+// handleElectrumGetMerkle implements the blockchain.transaction.get_merkle
+// method.
+func handleElectrumGetMerkle(c *electrumClient, params []json.RawMessage) (interface{}, error) {
+	var txHash string
+	var height int32
+	if err := parseElectrumParams(params, 2, &txHash, &height); err != nil {
+		return nil, err
+	}
+	hash, err := chainhash.NewHashFromStr(txHash)
+	if err != nil {
+		return nil, electrumParamError("invalid transaction hash %q", txHash)
+	}
+
+	block, err := c.server.cfg.RPC.cfg.Chain.BlockByHeight(height)
+	if err != nil {
+		return nil, electrumParamError("no block at height %d", height)
+	}
+	for pos, tx := range block.Transactions() {
+		if *tx.Hash() == *hash {
+			return electrumMerkleResult{
+				BlockHeight: height,
+				Merkle:      electrumMerkleBranch(block.Transactions(), pos),
+				Pos:         pos,
+			}, nil
+		}
+	}
+	return nil, electrumParamError("transaction %v is not in the block at "+
+		"height %d", hash, height)
+}
+
+// claimURL is a claim of a name as referred to by an LBRY URL.
+type claimURL struct {
+	name string
+
+	// modifier is one of '#' for a claim ID prefix, ':' for a sequence and
+	// '$' for a bid order, or 0 for the claim owning the name.  The
+	// sequences and the bid orders of the URLs start at 1.
+	modifier byte
+	claimID  string
+	number   int32
+}
+
+// parseClaimURL parses an LBRY URL such as lbry://name, name#claimid,
+// name:sequence or name$order.  The paths of the claims of channels are not
+// supported.
+func parseClaimURL(url string) (*claimURL, error) {
+	rest := strings.TrimPrefix(url, "lbry://")
+	if strings.Contains(rest, "/") {
+		return nil, errors.New("the paths of channel claims are not supported")
+	}
+
+	c := &claimURL{name: rest}
+	if i := strings.IndexAny(rest, "#:$"); i >= 0 {
+		c.name, c.modifier = rest[:i], rest[i]
+		arg := rest[i+1:]
+		switch c.modifier {
+		case '#':
+			if len(arg) == 0 || len(arg) > 40 ||
+				strings.Trim(arg, "0123456789abcdefABCDEF") != "" {
+
+				return nil, fmt.Errorf("invalid claim ID %q", arg)
+			}
+			c.claimID = strings.ToLower(arg)
+		default:
+			n, err := strconv.ParseInt(arg, 10, 32)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid position %q", arg)
+			}
+			c.number = int32(n)
+		}
+	}
+	if c.name == "" {
+		return nil, errors.New("missing name")
+	}
+	return c, nil
+}
+
+// electrumResolveResult is the claim an URL resolves to, or the error
+// resolving it.
+type electrumResolveResult struct {
+	URL string `json:"url"`
+	*btcjson.GetValueForNameResult
+	Error string `json:"error,omitempty"`
+}
+
+// resolveClaimURL returns the claim the URL resolves to at the tip of the main
+// chain.
+func resolveClaimURL(s *rpcServer, url string) (*btcjson.GetValueForNameResult, error) {
+	u, err := parseClaimURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	includeValues := true
+	var cmd interface{}
+	var handler commandHandler
+	switch u.modifier {
+	case 0:
+		result, err := handleGetValueForName(s, &btcjson.GetValueForNameCmd{
+			Name:          u.name,
+			IncludeValues: &includeValues,
+		}, nil)
+		if err != nil {
+			return nil, err
+		}
+		claim := result.(btcjson.GetValueForNameResult)
+		return &claim, nil
+	case '#':
+		cmd = &btcjson.GetClaimsForNameByIDCmd{
+			Name:            u.name,
+			PartialClaimIDs: []string{u.claimID},
+			IncludeValues:   &includeValues,
+		}
+		handler = handleGetClaimsForNameByID
+	case ':':
+		cmd = &btcjson.GetClaimsForNameBySeqCmd{
+			Name:          u.name,
+			Sequences:     []int32{u.number - 1},
+			IncludeValues: &includeValues,
+		}
+		handler = handleGetClaimsForNameBySeq
+	case '$':
+		cmd = &btcjson.GetClaimsForNameByBidCmd{
+			Name:          u.name,
+			Bids:          []int32{u.number - 1},
+			IncludeValues: &includeValues,
+		}
+		handler = handleGetClaimsForNameByBid
+	}
+
+	result, err := handler(s, cmd, nil)
+	if err != nil {
+		return nil, err
+	}
+	claims := result.(btcjson.GetClaimsForNameResult)
+	if len(claims.Claims) == 0 {
+		return nil, fmt.Errorf("no claim of %s matches %s", u.name, url)
+	}
+	return &btcjson.GetValueForNameResult{
+		Hash:               claims.Hash,
+		Height:             claims.Height,
+		LastTakeoverHeight: claims.LastTakeoverHeight,
+		NormalizedName:     claims.NormalizedName,
+		Claim:              claims.Claims[0],
+	}, nil
+}
+
+// handleElectrumResolve implements the blockchain.claimtrie.resolve method,
+// which resolves each URL parameter to a claim, with its value, in the format
+// of getvalueforname.
+func handleElectrumResolve(c *electrumClient, params []json.RawMessage) (interface{}, error) {
+	if len(params) == 0 {
+		return nil, electrumParamError("expected at least 1 URL")
+	}
+	urls := make([]string, len(params))
+	args := make([]interface{}, len(params))
+	for i := range urls {
+		args[i] = &urls[i]
+	}
+	if err := parseElectrumParams(params, 1, args...); err != nil {
+		return nil, err
+	}
+
+	results := make([]electrumResolveResult, 0, len(urls))
+	for _, url := range urls {
+		claim, err := resolveClaimURL(c.server.cfg.RPC, url)
+		result := electrumResolveResult{URL: url, GetValueForNameResult: claim}
+		if err != nil {
+			if rpcErr, ok := err.(*btcjson.RPCError); ok {
+				result.Error = rpcErr.Message
+			} else {
+				result.Error = err.Error()
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// electrumServerVersion returns the version of the server software reported
+// to the clients.
+func electrumServerVersion() string {
+	return "lbcd " + userAgentVersion
+}
+
+// parseProtocolVersion parses a version of the protocol such as 1.4.
+func parseProtocolVersion(version string) ([]int, error) {
+	var parts []int
+	for _, part := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid protocol version %q", version)
+		}
+		parts = append(parts, n)
+	}
+	return parts, nil
+}
+
+// compareProtocolVersions returns -1, 0 or 1 when the version a is lower than,
+// the same as, or higher than b.
+func compareProtocolVersions(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// negotiateProtocolVersion checks the protocol version requested by a client,
+// which is either a version or the range of the versions it supports, includes
+// the version of the server.
+func negotiateProtocolVersion(requested json.RawMessage) error {
+	var versions []string
+	var version string
+	if len(requested) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(requested, &version); err == nil {
+		versions = []string{version, version}
+	} else if err := json.Unmarshal(requested, &versions); err != nil ||
+		len(versions) != 2 {
+
+		return electrumParamError("invalid protocol version %s",
+			requested)
+	}
+
+	server, _ := parseProtocolVersion(electrumProtocolVersion)
+	min, err := parseProtocolVersion(versions[0])
+	if err != nil {
+		return electrumParamError("%v", err)
+	}
+	max, err := parseProtocolVersion(versions[1])
+	if err != nil {
+		return electrumParamError("%v", err)
+	}
+	if compareProtocolVersions(min, server) > 0 ||
+		compareProtocolVersions(max, server) < 0 {
+
+		return &btcjson.RPCError{
+			Code: btcjson.ErrRPCMisc,
+			Message: fmt.Sprintf("unsupported protocol version %s, "+
+				"the server speaks %s", requested,
+				electrumProtocolVersion),
+		}
+	}
+	return nil
+}
+
+// handleElectrumVersion implements the server.version method.
+func handleElectrumVersion(c *electrumClient, params []json.RawMessage) (interface{}, error) {
+	var clientName string
+	var protocolVersion json.RawMessage
+	err := parseElectrumParams(params, 0, &clientName, &protocolVersion)
+	if err != nil {
+		return nil, err
+	}
+	if err := negotiateProtocolVersion(protocolVersion); err != nil {
+		return nil, err
+	}
+	return []string{electrumServerVersion(), electrumProtocolVersion}, nil
+}
+
+// electrumFeaturesResult is the result of server.features.
+type electrumFeaturesResult struct {
+	GenesisHash   string                 `json:"genesis_hash"`
+	Hosts         map[string]interface{} `json:"hosts"`
+	ProtocolMax   string                 `json:"protocol_max"`
+	ProtocolMin   string                 `json:"protocol_min"`
+	Pruning       *int                   `json:"pruning"`
+	ServerVersion string                 `json:"server_version"`
+	HashFunction  string                 `json:"hash_function"`
+}
+
+// handleElectrumFeatures implements the server.features method.
+func handleElectrumFeatures(c *electrumClient, params []json.RawMessage) (interface{}, error) {
+	if err := parseElectrumParams(params, 0); err != nil {
+		return nil, err
+	}
+	return electrumFeaturesResult{
+		GenesisHash:   c.server.cfg.RPC.cfg.ChainParams.GenesisHash.String(),
+		Hosts:         map[string]interface{}{},
+		ProtocolMax:   electrumProtocolVersion,
+		ProtocolMin:   electrumProtocolVersion,
+		ServerVersion: electrumServerVersion(),
+		HashFunction:  "sha256",
+	}, nil
+}
+
+// handleElectrumBanner implements the server.banner method.
+func handleElectrumBanner(_ *electrumClient, params []json.RawMessage) (interface{}, error) {
+	if err := parseElectrumParams(params, 0); err != nil {
+		return nil, err
+	}
+	return "Welcome to " + electrumServerVersion(), nil
+}
+
+// handleElectrumDonationAddress implements the server.donation_address method.
+func handleElectrumDonationAddress(_ *electrumClient, params []json.RawMessage) (interface{}, error) {
+	if err := parseElectrumParams(params, 0); err != nil {
+		return nil, err
+	}
+	return "", nil
+}
+
+// handleElectrumPeers implements the server.peers.subscribe method.  No peer
+// servers are known.
+func handleElectrumPeers(_ *electrumClient, params []json.RawMessage) (interface{}, error) {
+	if err := parseElectrumParams(params, 0); err != nil {
+		return nil, err
+	}
+	return []interface{}{}, nil
+}
+
+// handleElectrumPing implements the server.ping method.
+func handleElectrumPing(_ *electrumClient, params []json.RawMessage) (interface{}, error) {
+	if err := parseElectrumParams(params, 0); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lbryio/lbcd/blockchain"
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestElectrumStatus(t *testing.T) {
+
+	r := require.New(t)
+
+	r.Nil(electrumStatus(nil))
+
+	// The status is the hex of the sha256 of "<tx hash>:<height>:" of each
+	// transaction of the history.
+	history := []electrumHistoryEntry{
+		{TxHash: "aa", Height: 5},
+		{TxHash: "bb", Height: -1},
+	}
+	status := electrumStatus(history)
+	r.NotNil(status)
+	r.Equal("2e4edd38a29794a6d62e03f1bd18328ebf18f04575d40ea1db500c20a7bc1079", *status)
+
+	r.True(equalStatus(nil, nil))
+	r.False(equalStatus(status, nil))
+	r.True(equalStatus(status, electrumStatus(history)))
+}
+
+func TestElectrumMerkleBranch(t *testing.T) {
+
+	r := require.New(t)
+
+	var txns []*btcutil.Tx
+	for i := 0; i < 5; i++ {
+		tx := wire.NewMsgTx(1)
+		tx.LockTime = uint32(i)
+		txns = append(txns, btcutil.NewTx(tx))
+	}
+	store := blockchain.BuildMerkleTreeStore(txns, false)
+	root := store[len(store)-1]
+
+	// Folding the branch of each transaction gives the merkle root.
+	for pos, tx := range txns {
+		hash := tx.Hash()
+		index := pos
+		for _, s := range electrumMerkleBranch(txns, pos) {
+			sibling, err := chainhash.NewHashFromStr(s)
+			r.NoError(err)
+			if index%2 == 0 {
+				hash = blockchain.HashMerkleBranches(hash, sibling)
+			} else {
+				hash = blockchain.HashMerkleBranches(sibling, hash)
+			}
+			index /= 2
+		}
+		r.Equal(root, hash, "position %d", pos)
+	}
+
+	r.Empty(electrumMerkleBranch(txns[:1], 0))
+}
+
+func TestParseClaimURL(t *testing.T) {
+
+	r := require.New(t)
+
+	tests := []struct {
+		url      string
+		name     string
+		modifier byte
+		claimID  string
+		number   int32
+		valid    bool
+	}{
+		{"lbry://hello", "hello", 0, "", 0, true},
+		{"@channel", "@channel", 0, "", 0, true},
+		{"hello#64B6", "hello", '#', "64b6", 0, true},
+		{"hello:2", "hello", ':', "", 2, true},
+		{"lbry://hello$1", "hello", '$', "", 1, true},
+		{"hello#", "", 0, "", 0, false},
+		{"hello#xyz", "", 0, "", 0, false},
+		{"hello:0", "", 0, "", 0, false},
+		{"hello$-1", "", 0, "", 0, false},
+		{"#abcd", "", 0, "", 0, false},
+		{"@channel/hello", "", 0, "", 0, false},
+	}
+	for _, test := range tests {
+		u, err := parseClaimURL(test.url)
+		if !test.valid {
+			r.Error(err, test.url)
+			continue
+		}
+		r.NoError(err, test.url)
+		r.Equal(&claimURL{
+			name:     test.name,
+			modifier: test.modifier,
+			claimID:  test.claimID,
+			number:   test.number,
+		}, u, test.url)
+	}
+}
+
+func TestNegotiateProtocolVersion(t *testing.T) {
+
+	r := require.New(t)
+
+	r.NoError(negotiateProtocolVersion(nil))
+	r.NoError(negotiateProtocolVersion(json.RawMessage(`"1.4"`)))
+	r.NoError(negotiateProtocolVersion(json.RawMessage(`["1.2", "1.4.2"]`)))
+	r.Error(negotiateProtocolVersion(json.RawMessage(`"1.2"`)))
+	r.Error(negotiateProtocolVersion(json.RawMessage(`["1.5", "2.0"]`)))
+	r.Error(negotiateProtocolVersion(json.RawMessage(`["1.4"]`)))
+	r.Error(negotiateProtocolVersion(json.RawMessage(`"1.x"`)))
+}
+
+func TestElectrumHandleMessage(t *testing.T) {
+
+	r := require.New(t)
+
+	c := &electrumClient{}
+	decode := func(reply []byte) electrumResponse {
+		var resp electrumResponse
+		r.NoError(json.Unmarshal(reply, &resp))
+		return resp
+	}
+
+	resp := decode(c.handleMessage([]byte(`{"id": 7, "method": "nope"}`)))
+	r.Equal(btcjson.ErrRPCMethodNotFound.Code, resp.Error.Code)
+	r.Equal("7", string(resp.ID))
+	r.Nil(resp.Result)
+
+	resp = decode(c.handleMessage([]byte(`{"id": 1, "method": "server.ping", "params": {}}`)))
+	r.Equal(btcjson.ErrRPCInvalidParams.Code, resp.Error.Code)
+
+	resp = decode(c.handleMessage([]byte(`{"id": 1`)))
+	r.Equal(btcjson.ErrRPCParse.Code, resp.Error.Code)
+	r.Equal("null", string(resp.ID))
+
+	// The notifications are not responded to, alone or in batches.
+	r.Nil(c.handleMessage([]byte(`{"method": "nope"}`)))
+	r.Nil(c.handleMessage([]byte(`[{"method": "nope"}]`)))
+
+	var batch []electrumResponse
+	reply := c.handleMessage([]byte(`[{"id": "a", "method": "nope"}, {"method": "nope"}, {"id": "b", "method": "nope"}]`))
+	r.NoError(json.Unmarshal(reply, &batch))
+	r.Len(batch, 2)
+	r.Equal(`"a"`, string(batch[0].ID))
+	r.Equal(`"b"`, string(batch[1].ID))
+
+	resp = decode(c.handleMessage([]byte(`[]`)))
+	r.Equal(btcjson.ErrRPCInvalidRequest.Code, resp.Error.Code)
+}
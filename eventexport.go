@@ -0,0 +1,754 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lbryio/lbcd/blockchain"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/lbcdrpc"
+	"github.com/lbryio/lbcd/mempool"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// eventExportStateFilename is the file of the data directory the
+	// position of the exporter in the chain is saved to, so it resumes
+	// from there after a restart.
+	eventExportStateFilename = "eventexport.json"
+
+	// eventExportBatchSize is the max number of events published at once.
+	eventExportBatchSize = 100
+
+	// eventExportTxQueueSize is the number of accepted transactions queued
+	// to the exporter, after which new ones are dropped until it catches
+	// up.
+	eventExportTxQueueSize = 1000
+
+	// eventExportTimeout is the time allowed to the broker to acknowledge
+	// a batch of events.
+	eventExportTimeout = 30 * time.Second
+
+	// eventExportMaxBackoff is the longest wait between two attempts to
+	// publish the events.
+	eventExportMaxBackoff = time.Minute
+
+	// defaultEventExportSASL is the default SASL mechanism authenticating
+	// to the Kafka brokers.
+	defaultEventExportSASL = "scram-sha-512"
+)
+
+// Types of the events exported.
+const (
+	exportBlockConnected    = "blockconnected"
+	exportBlockDisconnected = "blockdisconnected"
+	exportClaimsChanged     = "claimschanged"
+	exportTxAccepted        = "txaccepted"
+)
+
+// Encodings of the events exported.
+const (
+	exportFormatJSON     = "json"
+	exportFormatProtobuf = "protobuf"
+)
+
+// eventExportTarget is the message queue the events are exported to, parsed
+// from the eventexport option.
+type eventExportTarget struct {
+	// Kind is either kafka or nats.
+	Kind string
+
+	// Brokers are the addresses of the Kafka brokers the metadata are
+	// requested from, or of the NATS server.
+	Brokers []string
+
+	// Topic is the Kafka topic, or the prefix of the NATS subjects.
+	Topic string
+
+	// User and Pass authenticate to the Kafka brokers with SASL, or to the
+	// NATS server, when User isn't empty.  A NATS user without a password
+	// is an authentication token.
+	User string
+	Pass string
+
+	// TLS is the configuration of the TLS connections to the brokers, or
+	// nil when they're plaintext.
+	TLS *tls.Config
+
+	// SASL is the SASL mechanism authenticating to the Kafka brokers, such
+	// as SCRAM-SHA-512.
+	SASL string
+}
+
+// parseEventExportTarget parses an eventexport option, which is a
+// kafka://<host:port>[,<host:port>...]/<topic> or
+// nats://[<user>:<pass>@]<host:port>/<subject> URL.
+func parseEventExportTarget(s string) (*eventExportTarget, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	topic := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || topic == "" || strings.Contains(topic, "/") {
+		return nil, errors.New("the URL must have hosts and a topic")
+	}
+
+	t := &eventExportTarget{Kind: u.Scheme, Topic: topic}
+	switch u.Scheme {
+	case "kafka":
+		if u.User != nil {
+			t.User = u.User.Username()
+			t.Pass, _ = u.User.Password()
+			if t.User == "" || t.Pass == "" {
+				return nil, errors.New("the kafka credentials " +
+					"must have a user and a password")
+			}
+		}
+		t.Brokers = strings.Split(u.Host, ",")
+		for i, broker := range t.Brokers {
+			t.Brokers[i] = normalizeAddress(broker, "9092")
+		}
+
+	case "nats":
+		if strings.Contains(u.Host, ",") {
+			return nil, errors.New("only one nats server is supported")
+		}
+		if strings.ContainsAny(topic, " \t*>") {
+			return nil, fmt.Errorf("invalid nats subject %q", topic)
+		}
+		t.Brokers = []string{normalizeAddress(u.Host, "4222")}
+		if u.User != nil {
+			t.User = u.User.Username()
+			t.Pass, _ = u.User.Password()
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	return t, nil
+}
+
+// setSecurity sets the TLS configuration of the connections to the brokers,
+// verified by the certificate authorities of caFile or else by those of the
+// system, and the SASL mechanism authenticating to Kafka.  The credentials of
+// the target are refused without TLS, since they would be sent in plaintext.
+func (t *eventExportTarget) setSecurity(useTLS bool, caFile, mechanism string) error {
+	if t.Kind == "kafka" {
+		t.SASL = strings.ToUpper(mechanism)
+		switch t.SASL {
+		case kafkaSASLPlain, kafkaSASLScramSHA256, kafkaSASLScramSHA512:
+		default:
+			return fmt.Errorf("unsupported sasl mechanism %q", mechanism)
+		}
+	}
+
+	if !useTLS {
+		if t.User != "" {
+			return errors.New("the credentials of the target require " +
+				"tls (--eventexporttls), since they would be sent " +
+				"in plaintext")
+		}
+		if caFile != "" {
+			return errors.New("the certificate authorities require " +
+				"tls (--eventexporttls)")
+		}
+		return nil
+	}
+
+	t.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return err
+		}
+		t.TLS.RootCAs = x509.NewCertPool()
+		if !t.TLS.RootCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificate in %s", caFile)
+		}
+	}
+	return nil
+}
+
+// dialEventExport connects to the broker at addr, over TLS when tlsConfig
+// isn't nil.
+func dialEventExport(addr string, tlsConfig *tls.Config) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: eventExportTimeout}
+	if tlsConfig == nil {
+		return dialer.Dial("tcp", addr)
+	}
+	return tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+}
+
+// String returns the target without its credentials, which identifies it in
+// the saved state.
+func (t *eventExportTarget) String() string {
+	return t.Kind + "://" + strings.Join(t.Brokers, ",") + "/" + t.Topic
+}
+
+// exportMessage is an encoded event published to the message queue.
+type exportMessage struct {
+	// ID identifies the event, so its redeliveries can be told apart.
+	ID string
+
+	// Kind is the type of the event.
+	Kind string
+
+	// Key is the hash of the block or transaction of the event.
+	Key string
+
+	Value []byte
+}
+
+// eventPublisher publishes the events to a message queue.
+type eventPublisher interface {
+	// Publish publishes the messages in order, and returns once the broker
+	// acknowledged all of them, with the offset of the last one.
+	Publish(msgs []*exportMessage) (int64, error)
+
+	// Close closes the connections to the broker, making any pending
+	// Publish fail.
+	Close()
+}
+
+// exportEventJSON is the JSON encoding of an exported event.
+type exportEventJSON struct {
+	ID   string      `json:"id"`
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// exportEvent is an event to export, with its JSON data and its protobuf
+// message.
+type exportEvent struct {
+	id   string
+	kind string
+	key  string
+	data interface{}
+	msg  proto.Message
+}
+
+// encode returns the message of the event in the format.
+func (e *exportEvent) encode(format string) (*exportMessage, error) {
+	var value []byte
+	var err error
+	if format == exportFormatProtobuf {
+		value, err = proto.Marshal(e.msg)
+	} else {
+		value, err = json.Marshal(&exportEventJSON{
+			ID:   e.id,
+			Type: e.kind,
+			Data: e.data,
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &exportMessage{ID: e.id, Kind: e.kind, Key: e.key, Value: value}, nil
+}
+
+// eventExportState is the position of the exporter in the chain, saved once
+// the events up to it are acknowledged.  Seq counts the block events, so that
+// their IDs differ when a block is connected again after a reorganization.
+type eventExportState struct {
+	Target string `json:"target"`
+	Hash   string `json:"hash"`
+	Height int32  `json:"height"`
+	Seq    uint64 `json:"seq"`
+	Offset int64  `json:"offset"`
+}
+
+// eventExporterConfig is a descriptor containing the event exporter
+// configuration.
+type eventExporterConfig struct {
+	Target *eventExportTarget
+
+	// Format is the encoding of the events, json or protobuf.
+	Format string
+
+	// StateFile is the file the position of the exporter is saved to.
+	StateFile string
+
+	// Dial returns a new publisher to the target.
+	Dial func() (eventPublisher, error)
+
+	Chain *blockchain.BlockChain
+}
+
+// eventExporter publishes the blocks connected to and disconnected from the
+// main chain, the names whose claims they changed, and the transactions
+// accepted to the mempool, to Kafka or NATS JetStream, for the consumers which
+// can't hold a websocket open.
+//
+// The block events are derived from the chain rather than queued, so none is
+// lost while the broker is unavailable or lbcd is stopped: the exporter saves
+// the last block whose events were acknowledged, and resumes from it.  Events
+// are retried until they're acknowledged, so they're delivered at least once,
+// and in order.  The transactions are queued since they aren't kept, and are
+// dropped when lbcd stops or the queue overflows.
+type eventExporter struct {
+	started  int32
+	shutdown int32
+	cfg      eventExporterConfig
+
+	// state is only accessed by the export handler.
+	state eventExportState
+
+	pubMtx    sync.Mutex
+	publisher eventPublisher
+
+	wake chan struct{}
+	txns chan *mempool.TxDesc
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+// newEventExporter returns a new instance of the eventExporter struct.
+func newEventExporter(config *eventExporterConfig) *eventExporter {
+	x := &eventExporter{
+		cfg:  *config,
+		wake: make(chan struct{}, 1),
+		txns: make(chan *mempool.TxDesc, eventExportTxQueueSize),
+		quit: make(chan struct{}),
+	}
+	config.Chain.Subscribe(x.handleBlockchainNotification)
+	return x
+}
+
+// Start loads the saved position of the exporter and begins exporting the
+// events.
+func (x *eventExporter) Start() {
+	if atomic.AddInt32(&x.started, 1) != 1 {
+		return
+	}
+
+	x.loadState()
+	x.wg.Add(1)
+	go x.exportHandler()
+}
+
+// Stop abandons the pending events and waits for the export handler to
+// finish.
+func (x *eventExporter) Stop() {
+	if atomic.AddInt32(&x.shutdown, 1) != 1 {
+		return
+	}
+
+	close(x.quit)
+	x.closePublisher()
+	x.wg.Wait()
+}
+
+// loadState loads the saved position of the exporter.  It starts from the tip
+// of the main chain when there is none, or when it was saved for another
+// target or at a block unknown to the chain.
+func (x *eventExporter) loadState() {
+	target := x.cfg.Target.String()
+	b, err := os.ReadFile(x.cfg.StateFile)
+	if err == nil {
+		var state eventExportState
+		err = json.Unmarshal(b, &state)
+		if err == nil && state.Target == target && x.knownBlock(state.Hash) {
+			exptLog.Infof("Resuming the event export after block %s "+
+				"(%d) at offset %d", state.Hash, state.Height,
+				state.Offset)
+			x.state = state
+			return
+		}
+	}
+	if err != nil && !os.IsNotExist(err) {
+		exptLog.Warnf("Unable to read the event export state: %v", err)
+	}
+
+	best := x.cfg.Chain.BestSnapshot()
+	x.state = eventExportState{
+		Target: target,
+		Hash:   best.Hash.String(),
+		Height: best.Height,
+	}
+	exptLog.Infof("Exporting the events after block %s (%d)", best.Hash,
+		best.Height)
+	if err := x.saveState(); err != nil {
+		exptLog.Errorf("Unable to save the event export state: %v", err)
+	}
+}
+
+// knownBlock returns whether the block with the hash is known to the chain.
+func (x *eventExporter) knownBlock(hash string) bool {
+	h, err := chainhash.NewHashFromStr(hash)
+	if err != nil {
+		return false
+	}
+	_, err = x.cfg.Chain.HeaderByHash(h)
+	return err == nil
+}
+
+// saveState saves the position of the exporter.
+func (x *eventExporter) saveState() error {
+	b, err := json.Marshal(&x.state)
+	if err != nil {
+		return err
+	}
+	tmp := x.cfg.StateFile + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, x.cfg.StateFile)
+}
+
+// handleBlockchainNotification wakes the export handler up when a block is
+// connected to or disconnected from the main chain.
+func (x *eventExporter) handleBlockchainNotification(notification *blockchain.Notification) {
+	switch notification.Type {
+	case blockchain.NTBlockConnected, blockchain.NTBlockDisconnected:
+		select {
+		case x.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// NotifyNewTransactions queues the transactions accepted to the mempool,
+// dropping them when the queue is full.
+func (x *eventExporter) NotifyNewTransactions(txns []*mempool.TxDesc) {
+	for i, txD := range txns {
+		select {
+		case x.txns <- txD:
+		default:
+			exptLog.Warnf("Dropping %d accepted %s, as the event "+
+				"export is falling behind", len(txns)-i,
+				pickNoun(uint64(len(txns)-i), "transaction",
+					"transactions"))
+			return
+		}
+	}
+}
+
+// exportHandler exports the events of the chain after the saved position and
+// of the queued transactions, retrying with an exponential backoff on failure.
+//
+// This must be run as a goroutine.
+func (x *eventExporter) exportHandler() {
+	defer x.wg.Done()
+	defer x.closePublisher()
+
+	var pendingTxns []*exportEvent
+	backoff := time.Second
+	for {
+		err := x.exportBlocks()
+		if err == nil {
+			pendingTxns = x.drainTxns(pendingTxns)
+			err = x.exportTxns(&pendingTxns)
+		}
+		if err != nil {
+			select {
+			case <-x.quit:
+				return
+			default:
+			}
+			x.closePublisher()
+			exptLog.Warnf("Unable to export the events to %s, retrying "+
+				"in %v: %v", x.cfg.Target, backoff, err)
+			select {
+			case <-time.After(backoff):
+			case <-x.quit:
+				return
+			}
+			backoff *= 2
+			if backoff > eventExportMaxBackoff {
+				backoff = eventExportMaxBackoff
+			}
+			continue
+		}
+		backoff = time.Second
+
+		select {
+		case <-x.wake:
+		case txD := <-x.txns:
+			pendingTxns = append(pendingTxns, newTxAcceptedEvent(txD))
+		case <-x.quit:
+			return
+		}
+	}
+}
+
+// drainTxns appends the events of the queued transactions to pending.
+func (x *eventExporter) drainTxns(pending []*exportEvent) []*exportEvent {
+	for {
+		select {
+		case txD := <-x.txns:
+			pending = append(pending, newTxAcceptedEvent(txD))
+		default:
+			return pending
+		}
+	}
+}
+
+// getPublisher returns the publisher to the target, dialing it when needed.
+func (x *eventExporter) getPublisher() (eventPublisher, error) {
+	x.pubMtx.Lock()
+	publisher := x.publisher
+	x.pubMtx.Unlock()
+	if publisher != nil {
+		return publisher, nil
+	}
+
+	publisher, err := x.cfg.Dial()
+	if err != nil {
+		return nil, err
+	}
+
+	x.pubMtx.Lock()
+	defer x.pubMtx.Unlock()
+	select {
+	case <-x.quit:
+		publisher.Close()
+		return nil, errors.New("exporter shutting down")
+	default:
+	}
+	x.publisher = publisher
+	return publisher, nil
+}
+
+// closePublisher closes the publisher to the target, if any.
+func (x *eventExporter) closePublisher() {
+	x.pubMtx.Lock()
+	defer x.pubMtx.Unlock()
+
+	if x.publisher != nil {
+		x.publisher.Close()
+		x.publisher = nil
+	}
+}
+
+// publish encodes and publishes the events, returning the offset of the last
+// one.
+func (x *eventExporter) publish(events []*exportEvent) (int64, error) {
+	msgs := make([]*exportMessage, 0, len(events))
+	for _, event := range events {
+		msg, err := event.encode(x.cfg.Format)
+		if err != nil {
+			return 0, err
+		}
+		msgs = append(msgs, msg)
+	}
+
+	publisher, err := x.getPublisher()
+	if err != nil {
+		return 0, err
+	}
+	return publisher.Publish(msgs)
+}
+
+// exportBlocks publishes the events of the blocks disconnected from and
+// connected to the main chain since the saved position, in batches, saving
+// the position after each.
+func (x *eventExporter) exportBlocks() error {
+	for {
+		events, next, err := x.nextBlockEvents()
+		if err != nil {
+			return err
+		}
+		if next == nil {
+			return nil
+		}
+		if len(events) > 0 {
+			next.Offset, err = x.publish(events)
+			if err != nil {
+				return err
+			}
+		}
+		x.state = *next
+		if err := x.saveState(); err != nil {
+			return err
+		}
+
+		select {
+		case <-x.quit:
+			return nil
+		default:
+		}
+	}
+}
+
+// nextBlockEvents returns the next batch of events of the blocks after the
+// saved position, and the position after them, which is nil when it is the
+// tip of the main chain.  The position moves back to the parent of a block
+// which is no longer in the main chain, with a disconnected event.
+func (x *eventExporter) nextBlockEvents() ([]*exportEvent, *eventExportState, error) {
+	chain := x.cfg.Chain
+	next := x.state
+	var events []*exportEvent
+	for len(events) < eventExportBatchSize {
+		hash, err := chainhash.NewHashFromStr(next.Hash)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !chain.MainChainHasBlock(hash) {
+			header, err := chain.HeaderByHash(hash)
+			if err != nil {
+				return nil, nil, err
+			}
+			next.Seq++
+			events = append(events, newBlockDisconnectedEvent(next.Seq,
+				&header, hash, next.Height))
+			next.Hash = header.PrevBlock.String()
+			next.Height--
+			continue
+		}
+
+		if next.Height >= chain.BestSnapshot().Height {
+			break
+		}
+		block, err := chain.BlockByHeight(next.Height + 1)
+		if err != nil {
+			return nil, nil, err
+		}
+		// The chain was reorganized since the check above.
+		if block.MsgBlock().Header.PrevBlock != *hash {
+			continue
+		}
+
+		next.Seq++
+		events = append(events, newBlockConnectedEvent(next.Seq, block))
+		names, err := chain.GetNamesChangedInBlock(block.Height())
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(names) > 0 {
+			next.Seq++
+			events = append(events, newClaimsChangedEvent(next.Seq,
+				block, names))
+		}
+		next.Hash = block.Hash().String()
+		next.Height = block.Height()
+	}
+	if next.Hash == x.state.Hash {
+		return nil, nil, nil
+	}
+	return events, &next, nil
+}
+
+// exportTxns publishes the events of the pending transactions in batches,
+// removing them once acknowledged.
+func (x *eventExporter) exportTxns(pending *[]*exportEvent) error {
+	for len(*pending) > 0 {
+		n := len(*pending)
+		if n > eventExportBatchSize {
+			n = eventExportBatchSize
+		}
+		if _, err := x.publish((*pending)[:n]); err != nil {
+			return err
+		}
+		*pending = (*pending)[n:]
+	}
+	*pending = nil
+	return nil
+}
+
+// blockEventID returns the ID of the block event with the sequence number.
+func blockEventID(seq uint64, kind string, hash *chainhash.Hash) string {
+	return fmt.Sprintf("%d:%s:%s", seq, kind, hash)
+}
+
+// newBlockConnectedEvent returns the event of a block connected to the main
+// chain.
+func newBlockConnectedEvent(seq uint64, block *btcutil.Block) *exportEvent {
+	header := &block.MsgBlock().Header
+	return &exportEvent{
+		id:   blockEventID(seq, exportBlockConnected, block.Hash()),
+		kind: exportBlockConnected,
+		key:  block.Hash().String(),
+		data: newBlockEventData(block),
+		msg: &lbcdrpc.BlockNotification{
+			Type: lbcdrpc.BlockNotification_CONNECTED,
+			Header: grpcBlockHeader(header, block.Hash(),
+				block.Height(), 0),
+		},
+	}
+}
+
+// newBlockDisconnectedEvent returns the event of a block disconnected from the
+// main chain.
+func newBlockDisconnectedEvent(seq uint64, header *wire.BlockHeader,
+	hash *chainhash.Hash, height int32) *exportEvent {
+
+	return &exportEvent{
+		id:   blockEventID(seq, exportBlockDisconnected, hash),
+		kind: exportBlockDisconnected,
+		key:  hash.String(),
+		data: &blockEventData{
+			Hash:         hash.String(),
+			Height:       height,
+			PreviousHash: header.PrevBlock.String(),
+			Time:         header.Timestamp.Unix(),
+		},
+		msg: &lbcdrpc.BlockNotification{
+			Type:   lbcdrpc.BlockNotification_DISCONNECTED,
+			Header: grpcBlockHeader(header, hash, height, 0),
+		},
+	}
+}
+
+// newClaimsChangedEvent returns the event of the names whose claims changed in
+// a block connected to the main chain.
+func newClaimsChangedEvent(seq uint64, block *btcutil.Block, names []string) *exportEvent {
+	return &exportEvent{
+		id:   blockEventID(seq, exportClaimsChanged, block.Hash()),
+		kind: exportClaimsChanged,
+		key:  block.Hash().String(),
+		data: &sseClaimData{
+			Hash:   block.Hash().String(),
+			Height: block.Height(),
+			Names:  names,
+		},
+		msg: &lbcdrpc.ClaimNotification{
+			Block: &lbcdrpc.BlockStamp{
+				Hash:   block.Hash().String(),
+				Height: block.Height(),
+			},
+			Names: names,
+		},
+	}
+}
+
+// newTxAcceptedEvent returns the event of a transaction accepted to the
+// mempool.
+func newTxAcceptedEvent(txD *mempool.TxDesc) *exportEvent {
+	tx := txD.Tx
+	var amount int64
+	for _, txOut := range tx.MsgTx().TxOut {
+		amount += txOut.Value
+	}
+	var buf bytes.Buffer
+	tx.MsgTx().Serialize(&buf)
+
+	return &exportEvent{
+		id:   exportTxAccepted + ":" + tx.Hash().String(),
+		kind: exportTxAccepted,
+		key:  tx.Hash().String(),
+		data: &sseTxData{
+			Txid:   tx.Hash().String(),
+			Amount: btcutil.Amount(amount).ToBTC(),
+			Fee:    btcutil.Amount(txD.Fee).ToBTC(),
+			VSize:  mempool.GetTxVirtualSize(tx),
+		},
+		msg: &lbcdrpc.Transaction{
+			Hash:  tx.Hash().String(),
+			RawTx: buf.Bytes(),
+		},
+	}
+}
@@ -0,0 +1,515 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btclog"
+	"github.com/lbryio/lbcd/mempool"
+	"github.com/lbryio/lbcd/mining"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEventExportTarget(t *testing.T) {
+
+	r := require.New(t)
+
+	target, err := parseEventExportTarget("kafka://k1,k2:9093/events")
+	r.NoError(err)
+	r.Equal(&eventExportTarget{
+		Kind:    "kafka",
+		Brokers: []string{"k1:9092", "k2:9093"},
+		Topic:   "events",
+	}, target)
+	r.Equal("kafka://k1:9092,k2:9093/events", target.String())
+
+	target, err = parseEventExportTarget("nats://u:p@127.0.0.1/lbcd.main")
+	r.NoError(err)
+	r.Equal(&eventExportTarget{
+		Kind:    "nats",
+		Brokers: []string{"127.0.0.1:4222"},
+		Topic:   "lbcd.main",
+		User:    "u",
+		Pass:    "p",
+	}, target)
+	r.Equal("nats://127.0.0.1:4222/lbcd.main", target.String())
+
+	target, err = parseEventExportTarget("kafka://u:p@k1/events")
+	r.NoError(err)
+	r.Equal("u", target.User)
+	r.Equal("p", target.Pass)
+	r.Equal("kafka://k1:9092/events", target.String())
+
+	for _, s := range []string{
+		"kafka://k1/",
+		"kafka:///events",
+		"kafka://u@k1/events",
+		"kafka://:p@k1/events",
+		"nats://n1,n2/lbcd",
+		"nats://n1/lbcd.*",
+		"amqp://a1/events",
+	} {
+		_, err := parseEventExportTarget(s)
+		r.Error(err, s)
+	}
+}
+
+// newTestExportTLS returns the TLS configuration of a test broker, along with
+// the file of its certificate, which is valid for 127.0.0.1.
+func newTestExportTLS(t *testing.T) (*tls.Config, string) {
+	cert, key, err := btcutil.NewTLSCertPair("lbcd test broker",
+		time.Now().Add(time.Hour), nil)
+	require.NoError(t, err)
+	keyPair, err := tls.X509KeyPair(cert, key)
+	require.NoError(t, err)
+	caFile := filepath.Join(t.TempDir(), "broker.cert")
+	require.NoError(t, os.WriteFile(caFile, cert, 0600))
+	return &tls.Config{Certificates: []tls.Certificate{keyPair}}, caFile
+}
+
+func TestEventExportTargetSecurity(t *testing.T) {
+
+	r := require.New(t)
+
+	_, caFile := newTestExportTLS(t)
+	parse := func(s string) *eventExportTarget {
+		target, err := parseEventExportTarget(s)
+		r.NoError(err)
+		return target
+	}
+
+	// The credentials are refused without TLS.
+	target := parse("kafka://k1/events")
+	r.NoError(target.setSecurity(false, "", "plain"))
+	r.Nil(target.TLS)
+	r.Equal(kafkaSASLPlain, target.SASL)
+	r.Error(parse("kafka://u:p@k1/events").setSecurity(false, "",
+		defaultEventExportSASL))
+	r.Error(parse("nats://token@n1/lbcd").setSecurity(false, "",
+		defaultEventExportSASL))
+	r.Error(parse("nats://n1/lbcd").setSecurity(false, caFile,
+		defaultEventExportSASL))
+
+	target = parse("kafka://u:p@k1/events")
+	r.NoError(target.setSecurity(true, caFile, "scram-sha-256"))
+	r.Equal(kafkaSASLScramSHA256, target.SASL)
+	r.NotNil(target.TLS.RootCAs)
+	target = parse("nats://u:p@n1/lbcd")
+	r.NoError(target.setSecurity(true, "", "unused"))
+	r.NotNil(target.TLS)
+	r.Nil(target.TLS.RootCAs)
+
+	r.Error(parse("kafka://k1/events").setSecurity(true, "", "gssapi"))
+	r.Error(parse("kafka://k1/events").setSecurity(true,
+		filepath.Join(t.TempDir(), "missing.cert"), "plain"))
+	r.Error(parse("kafka://k1/events").setSecurity(true,
+		filepath.Join(t.TempDir()), "plain"))
+}
+
+func TestExportEventEncode(t *testing.T) {
+
+	r := require.New(t)
+
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	msgTx.AddTxOut(wire.NewTxOut(150000000, nil))
+	tx := btcutil.NewTx(msgTx)
+	event := newTxAcceptedEvent(&mempool.TxDesc{
+		TxDesc: mining.TxDesc{Tx: tx, Fee: 10000},
+	})
+
+	msg, err := event.encode(exportFormatJSON)
+	r.NoError(err)
+	r.Equal("txaccepted:"+tx.Hash().String(), msg.ID)
+	r.Equal(exportTxAccepted, msg.Kind)
+	r.Equal(tx.Hash().String(), msg.Key)
+	r.JSONEq(`{"id":"txaccepted:`+tx.Hash().String()+`","type":"txaccepted",`+
+		`"data":{"txid":"`+tx.Hash().String()+`","amount":1.5,"fee":0.0001,"vsize":19}}`,
+		string(msg.Value))
+
+	msg, err = event.encode(exportFormatProtobuf)
+	r.NoError(err)
+	r.NotEmpty(msg.Value)
+	r.NotEqual('{', msg.Value[0])
+}
+
+// testKafkaBroker serves the metadata and produce requests of a kafka
+// publisher, recording the record batches produced.  When it has credentials,
+// the requests are only served once authenticated with them by SASL PLAIN.
+type testKafkaBroker struct {
+	t           *testing.T
+	listener    net.Listener
+	credentials string
+	errorCode   int16
+	batches     chan []byte
+}
+
+func newTestKafkaBroker(t *testing.T, tlsConfig *tls.Config, credentials string) *testKafkaBroker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	b := &testKafkaBroker{
+		t:           t,
+		listener:    listener,
+		credentials: credentials,
+		batches:     make(chan []byte, 10),
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+	return b
+}
+
+func (b *testKafkaBroker) serve(conn net.Conn) {
+	defer conn.Close()
+
+	host, portStr, _ := net.SplitHostPort(b.listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	authenticated := b.credentials == ""
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(conn, size[:]); err != nil {
+			return
+		}
+		req := make([]byte, binary.BigEndian.Uint32(size[:]))
+		if _, err := io.ReadFull(conn, req); err != nil {
+			return
+		}
+		r := &kafkaReader{b: req}
+		apiKey := r.int16()
+		r.int16() // api_version
+		correlationID := r.int32()
+		r.string() // client_id
+
+		w := &kafkaWriter{}
+		w.int32(0)
+		w.int32(correlationID)
+		if !authenticated && apiKey != kafkaAPISaslHandshake &&
+			apiKey != kafkaAPISaslAuthenticate {
+
+			return
+		}
+		switch apiKey {
+		case kafkaAPISaslHandshake:
+			if r.string() == kafkaSASLPlain {
+				w.int16(0)
+			} else {
+				w.int16(33) // unsupported_sasl_mechanism
+			}
+			w.int32(1)
+			w.string(kafkaSASLPlain)
+
+		case kafkaAPISaslAuthenticate:
+			if string(r.bytes()) == b.credentials {
+				authenticated = true
+				w.int16(0)
+				w.int16(-1)
+			} else {
+				w.int16(58) // sasl_authentication_failed
+				w.string("invalid credentials")
+			}
+			w.int32(0)
+
+		case kafkaAPIMetadata:
+			w.int32(0) // throttle_time_ms
+			w.int32(1)
+			w.int32(7)
+			w.string(host)
+			w.int32(int32(port))
+			w.int16(-1) // rack
+			w.int16(-1) // cluster_id
+			w.int32(7)  // controller_id
+			w.int32(1)
+			w.int16(0)
+			w.string("events")
+			w.bool(false)
+			w.int32(1)
+			w.int16(0)
+			w.int32(kafkaPartition)
+			w.int32(7)
+			w.int32(0) // replica_nodes
+			w.int32(0) // isr_nodes
+
+		case kafkaAPIProduce:
+			r.string() // transactional_id
+			require.Equal(b.t, int16(-1), r.int16())
+			r.int32() // timeout_ms
+			r.int32()
+			require.Equal(b.t, "events", r.string())
+			r.int32()
+			require.Equal(b.t, int32(kafkaPartition), r.int32())
+			b.batches <- r.next(int(r.int32()))
+
+			w.int32(1)
+			w.string("events")
+			w.int32(1)
+			w.int32(kafkaPartition)
+			w.int16(b.errorCode)
+			w.int64(41)
+			w.int64(-1)
+			w.int32(0) // throttle_time_ms
+		}
+		resp := w.buf.Bytes()
+		binary.BigEndian.PutUint32(resp, uint32(len(resp)-4))
+		if _, err := conn.Write(resp); err != nil {
+			return
+		}
+	}
+}
+
+func TestKafkaPublisher(t *testing.T) {
+
+	r := require.New(t)
+
+	defer func(log btclog.Logger) { exptLog = log }(exptLog)
+	exptLog = btclog.Disabled
+
+	broker := newTestKafkaBroker(t, nil, "")
+	defer broker.listener.Close()
+
+	// The first broker is down, so the leader is found from the second.
+	p, err := dialKafka(&eventExportTarget{
+		Brokers: []string{"127.0.0.1:1", broker.listener.Addr().String()},
+		Topic:   "events",
+	})
+	r.NoError(err)
+	defer p.Close()
+
+	msgs := []*exportMessage{
+		{ID: "1:blockconnected:aa", Kind: "blockconnected", Key: "aa", Value: []byte("{}")},
+		{ID: "2:claimschanged:aa", Kind: "claimschanged", Key: "aa", Value: []byte("[]")},
+	}
+	offset, err := p.Publish(msgs)
+	r.NoError(err)
+	r.Equal(int64(42), offset)
+
+	// The record batch is checksummed and holds both records.
+	batch := <-broker.batches
+	r.Equal(kafkaRecordBatch(msgs, time.Time{})[:17], batch[:17])
+	br := &kafkaReader{b: batch}
+	r.Equal(int64(0), br.int64())
+	r.Equal(int32(len(batch)-12), br.int32())
+	br.int32()
+	r.Equal([]byte{2}, br.next(1))
+	crc := uint32(br.int32())
+	r.Equal(crc32.Checksum(br.b, kafkaCastagnoli), crc)
+	r.Equal(int16(0), br.int16())
+	r.Equal(int32(1), br.int32())
+	br.next(8 + 8 + 8 + 2 + 4)
+	r.Equal(int32(2), br.int32())
+	r.Contains(string(br.b), "2:claimschanged:aa")
+
+	broker.errorCode = 6
+	_, err = p.Publish(msgs)
+	r.EqualError(err, "kafka error code 6")
+}
+
+func TestKafkaPublisherSASL(t *testing.T) {
+
+	r := require.New(t)
+
+	defer func(log btclog.Logger) { exptLog = log }(exptLog)
+	exptLog = btclog.Disabled
+
+	serverTLS, caFile := newTestExportTLS(t)
+	broker := newTestKafkaBroker(t, serverTLS, "\x00u\x00p")
+	defer broker.listener.Close()
+
+	dial := func(user, pass, mechanism string) (*kafkaPublisher, error) {
+		target := &eventExportTarget{
+			Kind:    "kafka",
+			Brokers: []string{broker.listener.Addr().String()},
+			Topic:   "events",
+			User:    user,
+			Pass:    pass,
+		}
+		r.NoError(target.setSecurity(true, caFile, mechanism))
+		return dialKafka(target)
+	}
+
+	p, err := dial("u", "p", "plain")
+	r.NoError(err)
+	defer p.Close()
+	offset, err := p.Publish([]*exportMessage{{ID: "1", Kind: "blockconnected"}})
+	r.NoError(err)
+	r.Equal(int64(41), offset)
+
+	_, err = dial("u", "wrong", "plain")
+	r.EqualError(err, "kafka sasl authentication failed: invalid "+
+		"credentials (code 58)")
+	_, err = dial("u", "p", "scram-sha-512")
+	r.EqualError(err, "kafka sasl mechanism SCRAM-SHA-512 not enabled, the "+
+		"broker enables PLAIN")
+	_, err = dial("", "", "plain")
+	r.Error(err)
+}
+
+// testNATSServer is a NATS server acknowledging the messages published to the
+// JetStream stream, except those of the unknown subjects.  It requires TLS when
+// it has a TLS configuration.
+func testNATSServer(t *testing.T, conn net.Conn, tlsConfig *tls.Config,
+	checkConnect func(*natsConnect), published chan<- string) {
+
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "INFO {\"server_id\":\"test\",\"headers\":true,"+
+		"\"tls_required\":%v}\r\n", tlsConfig != nil)
+	if tlsConfig != nil {
+		tlsConn := tls.Server(conn, tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			return
+		}
+		conn = tlsConn
+	}
+	rd := bufio.NewReader(conn)
+	seq := 0
+	for {
+		line, err := rd.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "CONNECT":
+			var connect natsConnect
+			require.NoError(t, json.Unmarshal([]byte(fields[1]), &connect))
+			require.True(t, connect.Headers)
+			checkConnect(&connect)
+
+		case "PING":
+			fmt.Fprintf(conn, "PONG\r\n")
+
+		case "HPUB":
+			size, _ := strconv.Atoi(fields[4])
+			payload := make([]byte, size+2)
+			io.ReadFull(rd, payload)
+			published <- fields[1] + " " + string(payload[:size])
+
+			// An unsolicited ping is answered while publishing.
+			fmt.Fprintf(conn, "PING\r\n")
+			if strings.HasSuffix(fields[1], ".unknown") {
+				status := "NATS/1.0 503\r\n\r\n"
+				fmt.Fprintf(conn, "HMSG %s 1 %d %d\r\n%s\r\n", fields[2],
+					len(status), len(status), status)
+				continue
+			}
+			seq++
+			ack := fmt.Sprintf(`{"stream":"LBCD","seq":%d}`, seq)
+			fmt.Fprintf(conn, "MSG %s 1 %d\r\n%s\r\n", fields[2],
+				len(ack), ack)
+		}
+	}
+}
+
+func TestNATSPublisher(t *testing.T) {
+
+	r := require.New(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+	defer listener.Close()
+	published := make(chan string, 10)
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			testNATSServer(t, conn, nil, func(connect *natsConnect) {
+				require.Equal(t, "u", connect.User)
+				require.Equal(t, "p", connect.Pass)
+				require.False(t, connect.TLSRequired)
+			}, published)
+		}
+	}()
+
+	p, err := dialNATS(&eventExportTarget{
+		Kind:    "nats",
+		Brokers: []string{listener.Addr().String()},
+		Topic:   "lbcd",
+		User:    "u",
+		Pass:    "p",
+	})
+	r.NoError(err)
+	defer p.Close()
+
+	offset, err := p.Publish([]*exportMessage{
+		{ID: "1:blockconnected:aa", Kind: "blockconnected", Value: []byte("a")},
+		{ID: "2:claimschanged:aa", Kind: "claimschanged", Value: []byte("b")},
+	})
+	r.NoError(err)
+	r.Equal(int64(2), offset)
+	r.Equal("lbcd.blockconnected NATS/1.0\r\nNats-Msg-Id: 1:blockconnected:aa\r\n"+
+		"Lbcd-Event: blockconnected\r\n\r\na", <-published)
+	r.Equal("lbcd.claimschanged NATS/1.0\r\nNats-Msg-Id: 2:claimschanged:aa\r\n"+
+		"Lbcd-Event: claimschanged\r\n\r\nb", <-published)
+
+	// A subject without stream has no responders.
+	_, err = p.Publish([]*exportMessage{{ID: "3", Kind: "unknown"}})
+	r.EqualError(err, "no jetstream stream captures subject lbcd.unknown")
+}
+
+func TestNATSPublisherTLS(t *testing.T) {
+
+	r := require.New(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+	defer listener.Close()
+	serverTLS, caFile := newTestExportTLS(t)
+	published := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go testNATSServer(t, conn, serverTLS, func(connect *natsConnect) {
+				require.Empty(t, connect.User)
+				require.Equal(t, "token", connect.AuthToken)
+				require.True(t, connect.TLSRequired)
+			}, published)
+		}
+	}()
+
+	// A user without a password is a token, and it requires TLS like the
+	// server.
+	target, err := parseEventExportTarget("nats://token@" +
+		listener.Addr().String() + "/lbcd")
+	r.NoError(err)
+	r.NoError(target.setSecurity(true, caFile, defaultEventExportSASL))
+	p, err := dialNATS(target)
+	r.NoError(err)
+	defer p.Close()
+	offset, err := p.Publish([]*exportMessage{
+		{ID: "1:blockconnected:aa", Kind: "blockconnected", Value: []byte("a")},
+	})
+	r.NoError(err)
+	r.Equal(int64(1), offset)
+	r.Contains(<-published, "lbcd.blockconnected ")
+
+	_, err = dialNATS(&eventExportTarget{
+		Kind:    "nats",
+		Brokers: target.Brokers,
+		Topic:   "lbcd",
+	})
+	r.EqualError(err, "the nats server requires tls (--eventexporttls)")
+}
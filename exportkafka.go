@@ -0,0 +1,477 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// kafkaClientID is the client ID sent in the headers of the requests.
+	kafkaClientID = "lbcd"
+
+	// kafkaMaxResponseSize is the max size of a response read from a
+	// broker.
+	kafkaMaxResponseSize = 16 * 1024 * 1024
+
+	// kafkaPartition is the partition of the topic the events are produced
+	// to, so that they're consumed in order.
+	kafkaPartition = 0
+)
+
+// The keys and versions of the APIs of the Kafka protocol requested.
+const (
+	kafkaAPIProduce         int16 = 0
+	kafkaAPIProduceVersion  int16 = 3
+	kafkaAPIMetadata        int16 = 3
+	kafkaAPIMetadataVersion int16 = 4
+
+	kafkaAPISaslHandshake           int16 = 17
+	kafkaAPISaslHandshakeVersion    int16 = 1
+	kafkaAPISaslAuthenticate        int16 = 36
+	kafkaAPISaslAuthenticateVersion int16 = 0
+)
+
+// The SASL mechanisms authenticating to the brokers.
+const (
+	kafkaSASLPlain       = "PLAIN"
+	kafkaSASLScramSHA256 = "SCRAM-SHA-256"
+	kafkaSASLScramSHA512 = "SCRAM-SHA-512"
+)
+
+// kafkaCastagnoli is the CRC-32C table of the checksums of the record batches.
+var kafkaCastagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// kafkaPublisher is an eventPublisher producing the events to a partition of a
+// Kafka topic, with the acknowledgement of all its in-sync replicas.  The
+// requests are written with the plain Kafka protocol, from a connection to the
+// leader of the partition found in the metadata of the bootstrap brokers.  The
+// connections are over TLS, and authenticated with SASL, when the target
+// configures them.
+type kafkaPublisher struct {
+	topic         string
+	conn          net.Conn
+	correlationID int32
+}
+
+// dialKafka returns a publisher to the leader of the partition of the topic of
+// the target, found in the metadata of the first of its brokers answering.
+func dialKafka(target *eventExportTarget) (*kafkaPublisher, error) {
+	var err error
+	for _, broker := range target.Brokers {
+		var p *kafkaPublisher
+		p, err = dialKafkaLeader(target, broker)
+		if err == nil {
+			return p, nil
+		}
+		exptLog.Debugf("Unable to find the leader of kafka topic %s from "+
+			"%s: %v", target.Topic, broker, err)
+	}
+	return nil, err
+}
+
+// dialKafkaLeader returns a publisher to the leader of the partition of the
+// topic, found in the metadata of the broker.
+func dialKafkaLeader(target *eventExportTarget, broker string) (*kafkaPublisher, error) {
+	p, err := dialKafkaBroker(target, broker)
+	if err != nil {
+		return nil, err
+	}
+	leader, err := p.leader()
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+	if leader == broker {
+		return p, nil
+	}
+	p.Close()
+	return dialKafkaBroker(target, leader)
+}
+
+// dialKafkaBroker returns a publisher to the broker, authenticated when the
+// target has credentials.
+func dialKafkaBroker(target *eventExportTarget, broker string) (*kafkaPublisher, error) {
+	conn, err := dialEventExport(broker, target.TLS)
+	if err != nil {
+		return nil, err
+	}
+	p := &kafkaPublisher{topic: target.Topic, conn: conn}
+	if target.User != "" {
+		if err := p.authenticate(target); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// authenticate authenticates to the broker with the SASL mechanism and the
+// credentials of the target.
+func (p *kafkaPublisher) authenticate(target *eventExportTarget) error {
+	w := &kafkaWriter{}
+	w.string(target.SASL)
+	r, err := p.request(kafkaAPISaslHandshake, kafkaAPISaslHandshakeVersion,
+		w.buf.Bytes())
+	if err != nil {
+		return err
+	}
+	code := r.int16()
+	var mechanisms []string
+	for n := r.int32(); n > 0 && r.err == nil; n-- {
+		mechanisms = append(mechanisms, r.string())
+	}
+	if r.err != nil {
+		return r.err
+	}
+	if code != 0 {
+		return fmt.Errorf("kafka sasl mechanism %s not enabled, the "+
+			"broker enables %s", target.SASL,
+			strings.Join(mechanisms, ", "))
+	}
+
+	mechanism, err := newSASLMechanism(target.SASL, target.User, target.Pass)
+	if err != nil {
+		return err
+	}
+	msg, err := mechanism.next(nil)
+	for msg != nil && err == nil {
+		w := &kafkaWriter{}
+		w.bytes(msg)
+		r, err = p.request(kafkaAPISaslAuthenticate,
+			kafkaAPISaslAuthenticateVersion, w.buf.Bytes())
+		if err != nil {
+			return err
+		}
+		code := r.int16()
+		message := r.string()
+		challenge := r.bytes()
+		if r.err != nil {
+			return r.err
+		}
+		if code != 0 {
+			return fmt.Errorf("kafka sasl authentication failed: %s "+
+				"(code %d)", message, code)
+		}
+		msg, err = mechanism.next(challenge)
+	}
+	return err
+}
+
+// Close closes the connection to the leader.
+func (p *kafkaPublisher) Close() {
+	p.conn.Close()
+}
+
+// request sends a request to the broker and returns the body of its response.
+func (p *kafkaPublisher) request(apiKey, apiVersion int16, body []byte) (*kafkaReader, error) {
+	p.correlationID++
+	w := &kafkaWriter{}
+	w.int32(0) // size, set below
+	w.int16(apiKey)
+	w.int16(apiVersion)
+	w.int32(p.correlationID)
+	w.string(kafkaClientID)
+	w.buf.Write(body)
+	req := w.buf.Bytes()
+	binary.BigEndian.PutUint32(req, uint32(len(req)-4))
+
+	p.conn.SetDeadline(time.Now().Add(eventExportTimeout))
+	if _, err := p.conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	var header [8]byte
+	if _, err := io.ReadFull(p.conn, header[:]); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header[:4])
+	if size < 4 || size > kafkaMaxResponseSize {
+		return nil, fmt.Errorf("invalid kafka response size %d", size)
+	}
+	if id := int32(binary.BigEndian.Uint32(header[4:])); id != p.correlationID {
+		return nil, fmt.Errorf("kafka response %d to request %d", id,
+			p.correlationID)
+	}
+	resp := make([]byte, size-4)
+	if _, err := io.ReadFull(p.conn, resp); err != nil {
+		return nil, err
+	}
+	return &kafkaReader{b: resp}, nil
+}
+
+// leader returns the address of the leader of the partition of the topic, from
+// the metadata of the broker.
+func (p *kafkaPublisher) leader() (string, error) {
+	w := &kafkaWriter{}
+	w.int32(1)
+	w.string(p.topic)
+	w.bool(false) // allow_auto_topic_creation
+	r, err := p.request(kafkaAPIMetadata, kafkaAPIMetadataVersion, w.buf.Bytes())
+	if err != nil {
+		return "", err
+	}
+
+	r.int32() // throttle_time_ms
+	brokers := make(map[int32]string)
+	for n := r.int32(); n > 0 && r.err == nil; n-- {
+		id := r.int32()
+		host := r.string()
+		port := r.int32()
+		r.string() // rack
+		brokers[id] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	r.string() // cluster_id
+	r.int32()  // controller_id
+
+	leader := int32(-1)
+	for n := r.int32(); n > 0 && r.err == nil; n-- {
+		code := r.int16()
+		name := r.string()
+		r.bool() // is_internal
+		for m := r.int32(); m > 0 && r.err == nil; m-- {
+			partCode := r.int16()
+			index := r.int32()
+			id := r.int32()
+			r.int32s() // replica_nodes
+			r.int32s() // isr_nodes
+			if name == p.topic && index == kafkaPartition {
+				if partCode != 0 {
+					code = partCode
+				}
+				leader = id
+			}
+		}
+		if name == p.topic && code != 0 {
+			return "", kafkaError(code)
+		}
+	}
+	if r.err != nil {
+		return "", r.err
+	}
+	addr, ok := brokers[leader]
+	if !ok {
+		return "", fmt.Errorf("no leader for partition %d of topic %s",
+			kafkaPartition, p.topic)
+	}
+	return addr, nil
+}
+
+// Publish produces the messages as a record batch, and returns the offset of
+// the last one.
+func (p *kafkaPublisher) Publish(msgs []*exportMessage) (int64, error) {
+	batch := kafkaRecordBatch(msgs, time.Now())
+
+	w := &kafkaWriter{}
+	w.int16(-1) // transactional_id
+	w.int16(-1) // acks from all the in-sync replicas
+	w.int32(int32(eventExportTimeout / time.Millisecond))
+	w.int32(1)
+	w.string(p.topic)
+	w.int32(1)
+	w.int32(kafkaPartition)
+	w.bytes(batch)
+	r, err := p.request(kafkaAPIProduce, kafkaAPIProduceVersion, w.buf.Bytes())
+	if err != nil {
+		return 0, err
+	}
+
+	offset := int64(-1)
+	for n := r.int32(); n > 0 && r.err == nil; n-- {
+		r.string() // name
+		for m := r.int32(); m > 0 && r.err == nil; m-- {
+			r.int32() // index
+			code := r.int16()
+			baseOffset := r.int64()
+			r.int64() // log_append_time_ms
+			if code != 0 {
+				return 0, kafkaError(code)
+			}
+			offset = baseOffset + int64(len(msgs)) - 1
+		}
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+	if offset < 0 {
+		return 0, errors.New("no kafka produce response for the partition")
+	}
+	return offset, nil
+}
+
+// kafkaRecordBatch returns the record batch (magic 2) of the messages, keyed by
+// their hashes, with their IDs and types in the id and type headers.
+func kafkaRecordBatch(msgs []*exportMessage, now time.Time) []byte {
+	records := &kafkaWriter{}
+	for i, msg := range msgs {
+		rec := &kafkaWriter{}
+		rec.buf.WriteByte(0) // attributes
+		rec.varint(0)        // timestamp delta
+		rec.varint(int64(i)) // offset delta
+		rec.varbytes([]byte(msg.Key))
+		rec.varbytes(msg.Value)
+		rec.varint(2)
+		rec.varbytes([]byte("id"))
+		rec.varbytes([]byte(msg.ID))
+		rec.varbytes([]byte("type"))
+		rec.varbytes([]byte(msg.Kind))
+
+		records.varint(int64(rec.buf.Len()))
+		records.buf.Write(rec.buf.Bytes())
+	}
+
+	timestamp := now.UnixNano() / int64(time.Millisecond)
+	body := &kafkaWriter{}
+	body.int16(0) // attributes
+	body.int32(int32(len(msgs) - 1))
+	body.int64(timestamp)
+	body.int64(timestamp)
+	body.int64(-1) // producer id
+	body.int16(-1) // producer epoch
+	body.int32(-1) // base sequence
+	body.int32(int32(len(msgs)))
+	body.buf.Write(records.buf.Bytes())
+
+	batch := &kafkaWriter{}
+	batch.int64(0) // base offset
+	batch.int32(int32(4 + 1 + 4 + body.buf.Len()))
+	batch.int32(-1) // partition leader epoch
+	batch.buf.WriteByte(2)
+	batch.int32(int32(crc32.Checksum(body.buf.Bytes(), kafkaCastagnoli)))
+	batch.buf.Write(body.buf.Bytes())
+	return batch.buf.Bytes()
+}
+
+// kafkaError returns the error of a Kafka error code.
+func kafkaError(code int16) error {
+	return fmt.Errorf("kafka error code %d", code)
+}
+
+// kafkaWriter encodes the primitive types of the Kafka protocol.
+type kafkaWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *kafkaWriter) bool(v bool) {
+	if v {
+		w.buf.WriteByte(1)
+	} else {
+		w.buf.WriteByte(0)
+	}
+}
+
+func (w *kafkaWriter) int16(v int16) {
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], uint16(v))
+	w.buf.Write(b[:])
+}
+
+func (w *kafkaWriter) int32(v int32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(v))
+	w.buf.Write(b[:])
+}
+
+func (w *kafkaWriter) int64(v int64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(v))
+	w.buf.Write(b[:])
+}
+
+func (w *kafkaWriter) string(s string) {
+	w.int16(int16(len(s)))
+	w.buf.WriteString(s)
+}
+
+func (w *kafkaWriter) bytes(b []byte) {
+	w.int32(int32(len(b)))
+	w.buf.Write(b)
+}
+
+// varint writes a zigzag encoded variable length integer, as in the records.
+func (w *kafkaWriter) varint(v int64) {
+	var b [binary.MaxVarintLen64]byte
+	w.buf.Write(b[:binary.PutVarint(b[:], v)])
+}
+
+func (w *kafkaWriter) varbytes(b []byte) {
+	w.varint(int64(len(b)))
+	w.buf.Write(b)
+}
+
+// kafkaReader decodes the primitive types of the Kafka protocol.  The first
+// error is kept in err, after which zero values are returned.
+type kafkaReader struct {
+	b   []byte
+	err error
+}
+
+func (r *kafkaReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || len(r.b) < n {
+		r.err = errors.New("truncated kafka response")
+		return nil
+	}
+	b := r.b[:n]
+	r.b = r.b[n:]
+	return b
+}
+
+func (r *kafkaReader) bool() bool {
+	b := r.next(1)
+	return b != nil && b[0] != 0
+}
+
+func (r *kafkaReader) int16() int16 {
+	if b := r.next(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (r *kafkaReader) int32() int32 {
+	if b := r.next(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (r *kafkaReader) int64() int64 {
+	if b := r.next(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+// string reads a nullable string, returning an empty one for null.
+func (r *kafkaReader) string() string {
+	n := r.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(r.next(int(n)))
+}
+
+// bytes reads nullable bytes, returning nil for null.
+func (r *kafkaReader) bytes() []byte {
+	n := r.int32()
+	if n < 0 {
+		return nil
+	}
+	return r.next(int(n))
+}
+
+func (r *kafkaReader) int32s() {
+	for n := r.int32(); n > 0 && r.err == nil; n-- {
+		r.int32()
+	}
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// natsMaxLineSize is the max size of a protocol line read from the
+	// server.
+	natsMaxLineSize = 64 * 1024
+
+	// natsMaxPayloadSize is the max size of a message payload read from
+	// the server.
+	natsMaxPayloadSize = 1024 * 1024
+
+	// natsNoResponders is the status of the header of the reply sent by the
+	// server when no JetStream stream captures a subject.
+	natsNoResponders = "503"
+)
+
+// natsConnect is the CONNECT message sent to the server.
+type natsConnect struct {
+	Verbose      bool   `json:"verbose"`
+	Pedantic     bool   `json:"pedantic"`
+	Headers      bool   `json:"headers"`
+	NoResponders bool   `json:"no_responders"`
+	Name         string `json:"name"`
+	Lang         string `json:"lang"`
+	Version      string `json:"version"`
+	Protocol     int    `json:"protocol"`
+	User         string `json:"user,omitempty"`
+	Pass         string `json:"pass,omitempty"`
+	AuthToken    string `json:"auth_token,omitempty"`
+	TLSRequired  bool   `json:"tls_required"`
+}
+
+// natsInfo is the part of the INFO message of the server read.
+type natsInfo struct {
+	TLSRequired bool `json:"tls_required"`
+}
+
+// natsPubAck is the acknowledgement of a message published to a JetStream
+// stream.
+type natsPubAck struct {
+	Stream string `json:"stream"`
+	Seq    int64  `json:"seq"`
+	Error  *struct {
+		Code        int    `json:"code"`
+		Description string `json:"description"`
+	} `json:"error"`
+}
+
+// natsPublisher is an eventPublisher publishing the events to NATS JetStream,
+// to the <subject>.<type> subjects, with the acknowledgement of the stream
+// capturing them.  The events are published with the plain NATS protocol, with
+// their IDs in the Nats-Msg-Id header so that JetStream discards their
+// redeliveries in its duplicate window.  The connection is upgraded to TLS
+// after the INFO message of the server when the target configures it.
+type natsPublisher struct {
+	subject string
+	inbox   string
+	conn    net.Conn
+	r       *bufio.Reader
+	w       *bufio.Writer
+}
+
+// dialNATS returns a publisher to the NATS server.
+func dialNATS(target *eventExportTarget) (*natsPublisher, error) {
+	conn, err := dialEventExport(target.Brokers[0], nil)
+	if err != nil {
+		return nil, err
+	}
+	p := &natsPublisher{
+		subject: target.Topic,
+		inbox:   fmt.Sprintf("_INBOX.lbcd%d", time.Now().UnixNano()),
+	}
+	p.setConn(conn)
+	if err := p.handshake(target); err != nil {
+		p.conn.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// setConn sets the connection to the server.
+func (p *natsPublisher) setConn(conn net.Conn) {
+	p.conn = conn
+	p.r = bufio.NewReaderSize(conn, natsMaxLineSize)
+	p.w = bufio.NewWriter(conn)
+}
+
+// handshake reads the INFO message of the server, upgrades the connection to
+// TLS, authenticates, and subscribes to the inbox the acknowledgements are sent
+// to.
+func (p *natsPublisher) handshake(target *eventExportTarget) error {
+	p.conn.SetDeadline(time.Now().Add(eventExportTimeout))
+	line, err := p.readLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("unexpected nats greeting %q", line)
+	}
+	var info natsInfo
+	if err := json.Unmarshal([]byte(line[len("INFO "):]), &info); err != nil {
+		return fmt.Errorf("invalid nats info: %v", err)
+	}
+
+	if info.TLSRequired && target.TLS == nil {
+		return errors.New("the nats server requires tls " +
+			"(--eventexporttls)")
+	}
+	if target.TLS != nil {
+		tlsConfig := target.TLS.Clone()
+		if tlsConfig.ServerName == "" {
+			host, _, err := net.SplitHostPort(target.Brokers[0])
+			if err != nil {
+				return err
+			}
+			tlsConfig.ServerName = host
+		}
+		tlsConn := tls.Client(p.conn, tlsConfig)
+		if err := tlsConn.Handshake(); err != nil {
+			return err
+		}
+		p.setConn(tlsConn)
+	}
+
+	// A user without a password is an authentication token.
+	connect := &natsConnect{
+		Headers:      true,
+		NoResponders: true,
+		Name:         userAgentName,
+		Lang:         "go",
+		Version:      userAgentVersion,
+		Protocol:     1,
+		User:         target.User,
+		Pass:         target.Pass,
+		TLSRequired:  target.TLS != nil,
+	}
+	if target.Pass == "" {
+		connect.User, connect.AuthToken = "", target.User
+	}
+	marshalled, err := json.Marshal(connect)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(p.w, "CONNECT %s\r\nSUB %s.* 1\r\nPING\r\n", marshalled,
+		p.inbox)
+	if err := p.w.Flush(); err != nil {
+		return err
+	}
+
+	for {
+		line, err := p.readLine()
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("nats server error: %s", line[4:])
+		}
+	}
+}
+
+// readLine reads a protocol line from the server.
+func (p *natsPublisher) readLine() (string, error) {
+	line, isPrefix, err := p.r.ReadLine()
+	if err != nil {
+		return "", err
+	}
+	if isPrefix {
+		return "", errors.New("nats protocol line too long")
+	}
+	return string(line), nil
+}
+
+// Close closes the connection to the server.
+func (p *natsPublisher) Close() {
+	p.conn.Close()
+}
+
+// Publish publishes the messages with the inbox as their reply subject, and
+// waits for the acknowledgements of JetStream.  It returns the stream sequence
+// of the last message.
+func (p *natsPublisher) Publish(msgs []*exportMessage) (int64, error) {
+	p.conn.SetDeadline(time.Now().Add(eventExportTimeout))
+	for i, msg := range msgs {
+		header := "NATS/1.0\r\nNats-Msg-Id: " + msg.ID +
+			"\r\nLbcd-Event: " + msg.Kind + "\r\n\r\n"
+		fmt.Fprintf(p.w, "HPUB %s.%s %s.%d %d %d\r\n%s", p.subject,
+			msg.Kind, p.inbox, i, len(header),
+			len(header)+len(msg.Value), header)
+		p.w.Write(msg.Value)
+		p.w.WriteString("\r\n")
+	}
+	if err := p.w.Flush(); err != nil {
+		return 0, err
+	}
+
+	acked := make([]bool, len(msgs))
+	var offset int64
+	for pending := len(msgs); pending > 0; {
+		line, err := p.readLine()
+		if err != nil {
+			return 0, err
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var header, payload []byte
+		switch fields[0] {
+		case "PING":
+			p.w.WriteString("PONG\r\n")
+			if err := p.w.Flush(); err != nil {
+				return 0, err
+			}
+			continue
+
+		case "-ERR":
+			return 0, fmt.Errorf("nats server error: %s", line[4:])
+
+		case "MSG":
+			// MSG <subject> <sid> [reply] <size>
+			if len(fields) < 4 {
+				return 0, fmt.Errorf("invalid nats message %q", line)
+			}
+			payload, err = p.readPayload(fields[len(fields)-1])
+			if err != nil {
+				return 0, err
+			}
+
+		case "HMSG":
+			// HMSG <subject> <sid> [reply] <header size> <size>
+			if len(fields) < 5 {
+				return 0, fmt.Errorf("invalid nats message %q", line)
+			}
+			payload, err = p.readPayload(fields[len(fields)-1])
+			if err != nil {
+				return 0, err
+			}
+			n, err := strconv.Atoi(fields[len(fields)-2])
+			if err != nil || n > len(payload) {
+				return 0, fmt.Errorf("invalid nats message %q", line)
+			}
+			header, payload = payload[:n], payload[n:]
+
+		default:
+			continue
+		}
+
+		i, err := strconv.Atoi(strings.TrimPrefix(fields[1], p.inbox+"."))
+		if err != nil || i < 0 || i >= len(msgs) || acked[i] {
+			continue
+		}
+		if status := natsStatus(header); status != "" {
+			if status == natsNoResponders {
+				return 0, fmt.Errorf("no jetstream stream captures "+
+					"subject %s.%s", p.subject, msgs[i].Kind)
+			}
+			return 0, fmt.Errorf("nats status %s for subject %s.%s",
+				status, p.subject, msgs[i].Kind)
+		}
+
+		var ack natsPubAck
+		if err := json.Unmarshal(payload, &ack); err != nil {
+			return 0, fmt.Errorf("invalid jetstream ack: %v", err)
+		}
+		if ack.Error != nil {
+			return 0, fmt.Errorf("jetstream error %d: %s", ack.Error.Code,
+				ack.Error.Description)
+		}
+		acked[i] = true
+		pending--
+		if i == len(msgs)-1 {
+			offset = ack.Seq
+		}
+	}
+	return offset, nil
+}
+
+// readPayload reads a message payload of the size, followed by CRLF.
+func (p *natsPublisher) readPayload(size string) ([]byte, error) {
+	n, err := strconv.Atoi(size)
+	if err != nil || n < 0 || n > natsMaxPayloadSize {
+		return nil, fmt.Errorf("invalid nats payload size %q", size)
+	}
+	payload := make([]byte, n+2)
+	if _, err := io.ReadFull(p.r, payload); err != nil {
+		return nil, err
+	}
+	return payload[:n], nil
+}
+
+// natsStatus returns the status of the header of a message, such as 503, or an
+// empty string when it has none.
+func natsStatus(header []byte) string {
+	line := header
+	if i := bytes.IndexByte(header, '\r'); i >= 0 {
+		line = header[:i]
+	}
+	fields := strings.Fields(string(line))
+	if len(fields) < 2 || fields[0] != "NATS/1.0" {
+		return ""
+	}
+	return fields[1]
+}
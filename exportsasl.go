@@ -0,0 +1,164 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// saslMechanism is the client side of a SASL authentication exchange.
+type saslMechanism interface {
+	// next returns the next message to send to the server from its last
+	// challenge, which is nil before the first message, or nil once the
+	// exchange completed.
+	next(challenge []byte) ([]byte, error)
+}
+
+// newSASLMechanism returns the client of the SASL mechanism, PLAIN,
+// SCRAM-SHA-256 or SCRAM-SHA-512, authenticating the user.
+func newSASLMechanism(mechanism, user, pass string) (saslMechanism, error) {
+	switch mechanism {
+	case kafkaSASLPlain:
+		return &saslPlain{user: user, pass: pass}, nil
+	case kafkaSASLScramSHA256:
+		return newSCRAMClient(sha256.New, user, pass)
+	case kafkaSASLScramSHA512:
+		return newSCRAMClient(sha512.New, user, pass)
+	}
+	return nil, fmt.Errorf("unsupported sasl mechanism %q", mechanism)
+}
+
+// saslPlain is the client of the PLAIN mechanism (RFC 4616), which sends the
+// password as is, so it's only used over TLS.
+type saslPlain struct {
+	user string
+	pass string
+	sent bool
+}
+
+// next returns the credentials, and then nil.
+func (m *saslPlain) next(challenge []byte) ([]byte, error) {
+	if m.sent {
+		return nil, nil
+	}
+	m.sent = true
+	return []byte("\x00" + m.user + "\x00" + m.pass), nil
+}
+
+// scramClient is the client of a SCRAM mechanism (RFC 5802), which proves the
+// knowledge of the password without sending it, and verifies the server knows
+// it as well.  The names aren't normalized with SASLprep, so they have to be
+// ASCII.
+type scramClient struct {
+	hash  func() hash.Hash
+	user  string
+	pass  string
+	nonce string
+	step  int
+
+	clientFirstBare string
+	serverSignature []byte
+}
+
+// newSCRAMClient returns the client of the SCRAM mechanism of the hash,
+// authenticating the user with a random nonce.
+func newSCRAMClient(h func() hash.Hash, user, pass string) (*scramClient, error) {
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	return &scramClient{
+		hash:  h,
+		user:  user,
+		pass:  pass,
+		nonce: base64.RawStdEncoding.EncodeToString(nonce[:]),
+	}, nil
+}
+
+// hmac returns the HMAC of the message with the key.
+func (c *scramClient) hmac(key []byte, msg string) []byte {
+	mac := hmac.New(c.hash, key)
+	mac.Write([]byte(msg))
+	return mac.Sum(nil)
+}
+
+// next returns the client-first message, then the client-final message from
+// the server-first message, and finally nil once the signature of the
+// server-final message is verified.
+func (c *scramClient) next(challenge []byte) ([]byte, error) {
+	c.step++
+	switch c.step {
+	case 1:
+		name := strings.NewReplacer("=", "=3D", ",", "=2C").Replace(c.user)
+		c.clientFirstBare = "n=" + name + ",r=" + c.nonce
+		return []byte("n,," + c.clientFirstBare), nil
+
+	case 2:
+		serverFirst := string(challenge)
+		attrs := scramAttributes(serverFirst)
+		nonce := attrs['r']
+		salt, err := base64.StdEncoding.DecodeString(attrs['s'])
+		if err != nil || len(salt) == 0 {
+			return nil, errors.New("invalid scram salt")
+		}
+		iterations, err := strconv.Atoi(attrs['i'])
+		if err != nil || iterations <= 0 {
+			return nil, errors.New("invalid scram iteration count")
+		}
+		if !strings.HasPrefix(nonce, c.nonce) || len(nonce) == len(c.nonce) {
+			return nil, errors.New("invalid scram nonce")
+		}
+
+		salted := pbkdf2.Key([]byte(c.pass), salt, iterations,
+			c.hash().Size(), c.hash)
+		clientKey := c.hmac(salted, "Client Key")
+		h := c.hash()
+		h.Write(clientKey)
+		storedKey := h.Sum(nil)
+
+		clientFinal := "c=biws,r=" + nonce
+		authMessage := c.clientFirstBare + "," + serverFirst + "," +
+			clientFinal
+		proof := c.hmac(storedKey, authMessage)
+		for i := range proof {
+			proof[i] ^= clientKey[i]
+		}
+		c.serverSignature = c.hmac(c.hmac(salted, "Server Key"),
+			authMessage)
+		return []byte(clientFinal + ",p=" +
+			base64.StdEncoding.EncodeToString(proof)), nil
+
+	case 3:
+		attrs := scramAttributes(string(challenge))
+		if e, ok := attrs['e']; ok {
+			return nil, fmt.Errorf("scram authentication failed: %s", e)
+		}
+		signature, err := base64.StdEncoding.DecodeString(attrs['v'])
+		if err != nil || !hmac.Equal(signature, c.serverSignature) {
+			return nil, errors.New("invalid scram server signature")
+		}
+		return nil, nil
+	}
+	return nil, errors.New("unexpected scram challenge")
+}
+
+// scramAttributes returns the values of the attributes of a SCRAM message,
+// such as r=nonce, by name.
+func scramAttributes(msg string) map[byte]string {
+	attrs := make(map[byte]string)
+	for _, attr := range strings.Split(msg, ",") {
+		if len(attr) >= 2 && attr[1] == '=' {
+			attrs[attr[0]] = attr[2:]
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSCRAMClient(t *testing.T) {
+
+	r := require.New(t)
+
+	// The exchange of RFC 7677.
+	c, err := newSCRAMClient(sha256.New, "user", "pencil")
+	r.NoError(err)
+	c.nonce = "rOprNGfwEbeRWgbNEkqO"
+
+	msg, err := c.next(nil)
+	r.NoError(err)
+	r.Equal("n,,n=user,r=rOprNGfwEbeRWgbNEkqO", string(msg))
+	msg, err = c.next([]byte("r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0," +
+		"s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"))
+	r.NoError(err)
+	r.Equal("c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,"+
+		"p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ=", string(msg))
+	msg, err = c.next([]byte("v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4="))
+	r.NoError(err)
+	r.Nil(msg)
+	_, err = c.next(nil)
+	r.Error(err)
+
+	// The server has to prove it knows the password, and to extend the
+	// nonce of the client.
+	c, err = newSCRAMClient(sha256.New, "user", "pencil")
+	r.NoError(err)
+	c.nonce = "rOprNGfwEbeRWgbNEkqO"
+	_, err = c.next(nil)
+	r.NoError(err)
+	_, err = c.next([]byte("r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0," +
+		"s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"))
+	r.NoError(err)
+	_, err = c.next([]byte("v=AAAA"))
+	r.EqualError(err, "invalid scram server signature")
+	_, err = c.next([]byte("e=invalid-proof"))
+	r.Error(err)
+
+	for _, serverFirst := range []string{
+		"r=other,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096",
+		"r=rOprNGfwEbeRWgbNEkqO,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096",
+		"r=rOprNGfwEbeRWgbNEkqOx,s=,i=4096",
+		"r=rOprNGfwEbeRWgbNEkqOx,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=0",
+	} {
+		c.step = 1
+		_, err = c.next([]byte(serverFirst))
+		r.Error(err, serverFirst)
+	}
+}
+
+func TestSASLPlain(t *testing.T) {
+
+	r := require.New(t)
+
+	m, err := newSASLMechanism(kafkaSASLPlain, "u", "p")
+	r.NoError(err)
+	msg, err := m.next(nil)
+	r.NoError(err)
+	r.Equal("\x00u\x00p", string(msg))
+	msg, err = m.next(nil)
+	r.NoError(err)
+	r.Nil(msg)
+
+	_, err = newSASLMechanism("GSSAPI", "u", "p")
+	r.Error(err)
+}
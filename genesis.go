@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/lbryio/lbcd/blockchain"
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/claimtrie/merkletrie"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+// configuredGenesisBlock returns the genesis block of the private network based
+// on the chain which is given by the genesis options, either as the hex-encoded
+// serialized block or as the timestamp and message to generate it with.  It
+// returns nil when none of the options is set.
+func configuredGenesisBlock(chain *chaincfg.Params, hexBlock string,
+	timestamp int64, message string) (*wire.MsgBlock, error) {
+
+	switch {
+	case hexBlock != "" && (timestamp != 0 || message != ""):
+		return nil, fmt.Errorf("the genesisblock option can't be used " +
+			"with the genesistime and genesismessage options")
+
+	case hexBlock != "":
+		serialized, err := hex.DecodeString(hexBlock)
+		if err != nil {
+			return nil, fmt.Errorf("invalid genesis block: %v", err)
+		}
+		var block wire.MsgBlock
+		err = block.Deserialize(bytes.NewReader(serialized))
+		if err != nil {
+			return nil, fmt.Errorf("invalid genesis block: %v", err)
+		}
+		if err := checkGenesisBlock(chain, &block); err != nil {
+			return nil, fmt.Errorf("invalid genesis block: %v", err)
+		}
+		return &block, nil
+
+	case timestamp != 0 || message != "":
+		if timestamp == 0 {
+			timestamp = chain.GenesisBlock.Header.Timestamp.Unix()
+		}
+		return generateGenesisBlock(chain, time.Unix(timestamp, 0), message)
+	}
+	return nil, nil
+}
+
+// generateGenesisBlock returns the genesis block of a private network based on
+// the chain with the timestamp and coinbase message, solved at the proof of
+// work limit of the chain.
+func generateGenesisBlock(chain *chaincfg.Params, timestamp time.Time,
+	message string) (*wire.MsgBlock, error) {
+
+	if len(message) > chaincfg.MaxGenesisMessageLen {
+		return nil, fmt.Errorf("the genesis message can't be longer "+
+			"than %d bytes", chaincfg.MaxGenesisMessageLen)
+	}
+
+	block := chaincfg.CustomGenesisBlock(timestamp, chain.PowLimitBits,
+		message)
+	target := blockchain.CompactToBig(block.Header.Bits)
+	for nonce := uint64(0); nonce <= math.MaxUint32; nonce++ {
+		block.Header.Nonce = uint32(nonce)
+		hash := block.Header.BlockPoWHash()
+		if blockchain.HashToBig(&hash).Cmp(target) <= 0 {
+			return block, checkGenesisBlock(chain, block)
+		}
+	}
+	return nil, fmt.Errorf("no nonce solves the genesis block -- use " +
+		"another genesis time or message")
+}
+
+// checkGenesisBlock returns an error when the block can't be the genesis block
+// of a private network based on the chain.  Besides being a sane block, the
+// genesis block has no parent and commits to the empty claimtrie the chain
+// starts with.
+func checkGenesisBlock(chain *chaincfg.Params, block *wire.MsgBlock) error {
+	if block.Header.PrevBlock != (chainhash.Hash{}) {
+		return fmt.Errorf("the genesis block has a previous block")
+	}
+	if block.Header.ClaimTrie != *merkletrie.EmptyTrieHash {
+		return fmt.Errorf("the claimtrie root of the genesis block "+
+			"must be the one of the empty claimtrie, %v",
+			merkletrie.EmptyTrieHash)
+	}
+	return blockchain.CheckBlockSanity(btcutil.NewBlock(block),
+		chain.PowLimit, blockchain.NewMedianTime())
+}
+
+// customizeNetwork makes the network a private network when the genesis block
+// or the network magic or address prefixes of its chain differ from the ones
+// of the base chain it was overridden from.  A private network with a custom
+// genesis block gets a network magic of its own unless one is given, and is
+// registered along with its claimtrie parameters for its addresses to be
+// decoded.
+func customizeNetwork(p *params, base *chaincfg.Params, genesis *wire.MsgBlock) error {
+	chain := p.Params
+	if genesis != nil {
+		hash := genesis.BlockHash()
+		chain.GenesisBlock = genesis
+		chain.GenesisHash = &hash
+		if chain.Net == base.Net {
+			chain.Net = chaincfg.CustomGenesisNet(&hash)
+		}
+	}
+
+	if chain.Net == base.Net {
+		if chain.PubKeyHashAddrID != base.PubKeyHashAddrID ||
+			chain.ScriptHashAddrID != base.ScriptHashAddrID ||
+			chain.PrivateKeyID != base.PrivateKeyID ||
+			chain.Bech32HRPSegwit != base.Bech32HRPSegwit {
+
+			return fmt.Errorf("the address prefixes can only be " +
+				"overridden along with the genesis block or the " +
+				"network magic")
+		}
+		return nil
+	}
+
+	if chain.ClaimTrie == nil {
+		claims := base.ClaimTrieParams()
+		chain.ClaimTrie = &claims
+	}
+	if err := chaincfg.Register(chain); err != nil {
+		return fmt.Errorf("the private network can't be registered: %v",
+			err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/claimtrie/param"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfiguredGenesisBlock(t *testing.T) {
+
+	r := require.New(t)
+	chain := chaincfg.RegressionNetParams
+
+	none, err := configuredGenesisBlock(&chain, "", 0, "")
+	r.NoError(err)
+	r.Nil(none)
+
+	// The generated genesis blocks are the same for the same options, and
+	// are solved.
+	block, err := configuredGenesisBlock(&chain, "", 1700000000, "private")
+	r.NoError(err)
+	again, err := configuredGenesisBlock(&chain, "", 1700000000, "private")
+	r.NoError(err)
+	r.Equal(block.BlockHash(), again.BlockHash())
+	r.Equal(time.Unix(1700000000, 0), block.Header.Timestamp)
+	r.Equal(chain.PowLimitBits, block.Header.Bits)
+	defaulted, err := configuredGenesisBlock(&chain, "", 0, "private")
+	r.NoError(err)
+	r.Equal(chain.GenesisBlock.Header.Timestamp, defaulted.Header.Timestamp)
+
+	// The supplied genesis blocks are checked.
+	var buf bytes.Buffer
+	r.NoError(block.Serialize(&buf))
+	decoded, err := configuredGenesisBlock(&chain, hex.EncodeToString(buf.Bytes()), 0, "")
+	r.NoError(err)
+	r.Equal(block.BlockHash(), decoded.BlockHash())
+
+	_, err = configuredGenesisBlock(&chain, hex.EncodeToString(buf.Bytes()), 0, "private")
+	r.Error(err)
+	_, err = configuredGenesisBlock(&chain, "00", 0, "")
+	r.Error(err)
+	_, err = configuredGenesisBlock(&chain, "", 0, string(make([]byte, chaincfg.MaxGenesisMessageLen+1)))
+	r.Error(err)
+
+	invalid := *block
+	invalid.Header.ClaimTrie = chainhash.Hash{2}
+	invalid.Header.MerkleRoot = chainhash.Hash{}
+	r.Error(checkGenesisBlock(&chain, &invalid))
+	invalid = *block
+	invalid.Header.PrevBlock = chainhash.Hash{3}
+	r.Error(checkGenesisBlock(&chain, &invalid))
+	r.Error(checkGenesisBlock(&chaincfg.MainNetParams, block))
+}
+
+func TestCustomizeNetwork(t *testing.T) {
+
+	r := require.New(t)
+	base := chaincfg.RegressionNetParams
+
+	// Networks which aren't customized are left alone.
+	chain := base
+	p := &params{Params: &chain}
+	r.NoError(customizeNetwork(p, &base, nil))
+	r.Equal(base.Net, chain.Net)
+	r.Nil(chain.ClaimTrie)
+	chain.Bech32HRPSegwit = "plbc"
+	r.Error(customizeNetwork(p, &base, nil))
+
+	// A custom genesis block makes a private network of its own, whose
+	// addresses are decoded with its prefixes.
+	message := fmt.Sprintf("%s %d", t.Name(), time.Now().UnixNano())
+	genesis, err := generateGenesisBlock(&base, base.GenesisBlock.Header.Timestamp, message)
+	r.NoError(err)
+	r.NoError(customizeNetwork(p, &base, genesis))
+	hash := genesis.BlockHash()
+	r.Equal(&hash, chain.GenesisHash)
+	r.Equal(chaincfg.CustomGenesisNet(&hash), chain.Net)
+	r.Equal(param.Regtest, chain.ClaimTrieParams())
+	claims, ok := param.ForNetwork(chain.Net)
+	r.True(ok)
+	r.Equal(param.Regtest, claims)
+	registered, err := chaincfg.LookupNet(chain.Net)
+	r.NoError(err)
+	r.Same(&chain, registered)
+	addr, err := btcutil.NewAddressWitnessPubKeyHash(make([]byte, 20), &chain)
+	r.NoError(err)
+	decoded, err := btcutil.DecodeAddress(addr.EncodeAddress(), &chain)
+	r.NoError(err)
+	r.True(decoded.IsForNet(&chain))
+
+	// The network magic of a registered network can't be reused.
+	chain = base
+	chain.Net = wire.MainNet
+	r.Error(customizeNetwork(&params{Params: &chain}, &base, nil))
+}
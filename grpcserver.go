@@ -0,0 +1,712 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lbryio/lbcd/blockchain"
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/claimtrie/normalization"
+	"github.com/lbryio/lbcd/database"
+	"github.com/lbryio/lbcd/lbcdrpc"
+	"github.com/lbryio/lbcd/mempool"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// grpcSubscriberBuffer is the number of notifications queued for a
+// subscriber.  A subscriber which falls further behind is disconnected rather
+// than holding up the chain or the mempool.
+const grpcSubscriberBuffer = 1000
+
+// grpcCommands maps the methods of the gRPC service to the JSON-RPC commands
+// which whitelist them.
+var grpcCommands = map[string]string{
+	"GetBestBlock":          "getbestblock",
+	"GetBlock":              "getblock",
+	"GetBlockHeader":        "getblockheader",
+	"GetTransaction":        "getrawtransaction",
+	"SendTransaction":       "sendrawtransaction",
+	"GetMempool":            "getrawmempool",
+	"GetClaimsForName":      "getclaimsforname",
+	"SubscribeBlocks":       "notifyblocks",
+	"SubscribeTransactions": "notifynewtransactions",
+	"SubscribeClaims":       "getchangesinblock",
+}
+
+// grpcSubscription is the kind of notifications a subscriber receives.
+type grpcSubscription int
+
+const (
+	grpcSubscribeBlocks grpcSubscription = iota
+	grpcSubscribeTransactions
+	grpcSubscribeClaims
+)
+
+// grpcBlockNtfn is the notification of a block connected to, or disconnected
+// from, the main chain.
+type grpcBlockNtfn struct {
+	block     *btcutil.Block
+	connected bool
+}
+
+// grpcSubscriber is a stream of a subscription method.  Notifications are
+// queued to ntfns, and dropped is closed when the queue overflows.
+type grpcSubscriber struct {
+	kind    grpcSubscription
+	ntfns   chan interface{}
+	dropped chan struct{}
+}
+
+// grpcServerConfig is a descriptor containing the gRPC server configuration.
+type grpcServerConfig struct {
+	// Listeners defines a slice of listeners for which the gRPC server
+	// will take ownership of and accept connections.
+	Listeners []net.Listener
+
+	// TLS is the TLS configuration of the connections, or nil if TLS is
+	// disabled.
+	TLS *tls.Config
+
+	// RPC is the JSON-RPC server, whose users and command handlers are
+	// shared with the gRPC server.
+	RPC *rpcServer
+}
+
+// grpcServer serves the lbcdrpc.Lbcd gRPC service.
+type grpcServer struct {
+	lbcdrpc.UnimplementedLbcdServer
+
+	started  int32
+	shutdown int32
+	cfg      grpcServerConfig
+	server   *grpc.Server
+	wg       sync.WaitGroup
+
+	subscribersLock sync.Mutex
+	subscribers     map[*grpcSubscriber]struct{}
+
+	quit chan struct{}
+}
+
+// newGRPCServer returns a new instance of the grpcServer struct.
+func newGRPCServer(config *grpcServerConfig) *grpcServer {
+	g := &grpcServer{
+		cfg:         *config,
+		subscribers: make(map[*grpcSubscriber]struct{}),
+		quit:        make(chan struct{}),
+	}
+
+	opts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(g.unaryInterceptor),
+		grpc.StreamInterceptor(g.streamInterceptor),
+	}
+	if config.TLS != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(config.TLS)))
+	}
+	g.server = grpc.NewServer(opts...)
+	lbcdrpc.RegisterLbcdServer(g.server, g)
+
+	config.RPC.grpcServer = g
+	config.RPC.cfg.Chain.Subscribe(g.handleBlockchainNotification)
+
+	return g
+}
+
+// Start is used by server.go to start the gRPC listeners.
+func (g *grpcServer) Start() {
+	if atomic.AddInt32(&g.started, 1) != 1 {
+		return
+	}
+
+	for _, listener := range g.cfg.Listeners {
+		g.wg.Add(1)
+		go func(listener net.Listener) {
+			rpcsLog.Infof("gRPC server listening on %s", listener.Addr())
+			g.server.Serve(listener)
+			rpcsLog.Tracef("gRPC listener done for %s", listener.Addr())
+			g.wg.Done()
+		}(listener)
+	}
+}
+
+// Stop is used by server.go to stop the gRPC listeners and end the
+// subscription streams.
+func (g *grpcServer) Stop() {
+	if atomic.AddInt32(&g.shutdown, 1) != 1 {
+		rpcsLog.Infof("gRPC server is already in the process of shutting down")
+		return
+	}
+	rpcsLog.Warnf("gRPC server shutting down")
+	close(g.quit)
+
+	// Stop accepting new requests, and wait for those being processed to
+	// finish up to the drain timeout.
+	stopped := make(chan struct{})
+	go func() {
+		g.server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(cfg.RPCDrainTimeout):
+		rpcsLog.Warnf("Abandoning the gRPC requests still being processed")
+		g.server.Stop()
+		<-stopped
+	}
+	g.wg.Wait()
+	rpcsLog.Infof("gRPC server shutdown complete")
+}
+
+// authorize checks the HTTP Basic credentials of the "authorization" metadata
+// of the request against the RPC users, and the whitelist of the user against
+// the JSON-RPC command of the method.
+func (g *grpcServer) authorize(ctx context.Context, fullMethod string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	auth := md.Get("authorization")
+	if len(auth) == 0 {
+		return status.Error(codes.Unauthenticated, "auth failure")
+	}
+
+	name, pass, ok := parseBasicAuth(auth[0])
+	var user *rpcAuthUser
+	if ok {
+		user = g.cfg.RPC.checkCredentials(name, pass)
+	}
+	if user == nil {
+		rpcsLog.Warnf("gRPC authentication failure for %s", fullMethod)
+		return status.Error(codes.Unauthenticated, "auth failure")
+	}
+
+	method := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	if !user.allowed(grpcCommands[method]) {
+		return status.Error(codes.PermissionDenied,
+			"user not authorized for this method")
+	}
+	return nil
+}
+
+func (g *grpcServer) unaryInterceptor(ctx context.Context, req interface{},
+	info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+
+	if err := g.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+
+	var client string
+	if p, ok := peer.FromContext(ctx); ok {
+		client = p.Addr.String()
+	}
+	done := g.cfg.RPC.trackCommand(info.FullMethod, client)
+	defer func() { done(err) }()
+
+	return handler(ctx, req)
+}
+
+func (g *grpcServer) streamInterceptor(srv interface{}, ss grpc.ServerStream,
+	info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+
+	if err := g.authorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// grpcError converts an error of the JSON-RPC handlers to a gRPC status
+// error.
+func grpcError(err error) error {
+	if err == ErrClientQuit {
+		return status.Error(codes.Canceled, err.Error())
+	}
+	rpcErr, ok := err.(*btcjson.RPCError)
+	if !ok {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	code := codes.Internal
+	switch rpcErr.Code {
+	case btcjson.ErrRPCBlockNotFound:
+		code = codes.NotFound
+	case btcjson.ErrRPCInvalidParameter, btcjson.ErrRPCDeserialization:
+		code = codes.InvalidArgument
+	case btcjson.ErrRPCTxAlreadyInChain:
+		code = codes.AlreadyExists
+	case btcjson.ErrRPCTxRejected, btcjson.ErrRPCTxError:
+		code = codes.FailedPrecondition
+	case btcjson.ErrRPCClientInInitialDownload:
+		code = codes.Unavailable
+	}
+	return status.Error(code, rpcErr.Message)
+}
+
+// GetBestBlock returns the hash and height of the tip of the main chain.
+func (g *grpcServer) GetBestBlock(context.Context, *lbcdrpc.GetBestBlockRequest) (*lbcdrpc.BlockStamp, error) {
+	best := g.cfg.RPC.cfg.Chain.BestSnapshot()
+	return &lbcdrpc.BlockStamp{
+		Hash:   best.Hash.String(),
+		Height: best.Height,
+	}, nil
+}
+
+// blockHash returns the hash and height of the main chain block selected by
+// the hash or height of a request.
+func (g *grpcServer) blockHash(hash *string, height *int32) (*chainhash.Hash, int32, error) {
+	chain := g.cfg.RPC.cfg.Chain
+	if height != nil {
+		blockHash, err := chain.BlockHashByHeight(*height)
+		if err != nil {
+			return nil, 0, status.Errorf(codes.NotFound,
+				"no block at height %d", *height)
+		}
+		return blockHash, *height, nil
+	}
+
+	if hash == nil {
+		return nil, 0, status.Error(codes.InvalidArgument,
+			"missing block hash or height")
+	}
+	blockHash, err := chainhash.NewHashFromStr(*hash)
+	if err != nil {
+		return nil, 0, status.Errorf(codes.InvalidArgument,
+			"invalid block hash %q: %v", *hash, err)
+	}
+	blockHeight, err := chain.BlockHeightByHash(blockHash)
+	if err != nil {
+		return nil, 0, status.Errorf(codes.NotFound,
+			"block %v is not in the main chain", blockHash)
+	}
+	return blockHash, blockHeight, nil
+}
+
+// getBlockRequestHash returns the hash and height of the block selected by a
+// GetBlockRequest.
+func (g *grpcServer) getBlockRequestHash(req *lbcdrpc.GetBlockRequest) (*chainhash.Hash, int32, error) {
+	switch b := req.Block.(type) {
+	case *lbcdrpc.GetBlockRequest_Hash:
+		return g.blockHash(&b.Hash, nil)
+	case *lbcdrpc.GetBlockRequest_Height:
+		return g.blockHash(nil, &b.Height)
+	}
+	return g.blockHash(nil, nil)
+}
+
+// grpcBlockHeader returns the message of the header of the block with the
+// passed hash and height.
+func grpcBlockHeader(header *wire.BlockHeader, hash *chainhash.Hash,
+	height int32, confirmations int64) *lbcdrpc.BlockHeader {
+
+	return &lbcdrpc.BlockHeader{
+		Hash:          hash.String(),
+		Height:        height,
+		Version:       header.Version,
+		PrevBlock:     header.PrevBlock.String(),
+		MerkleRoot:    header.MerkleRoot.String(),
+		ClaimTrie:     header.ClaimTrie.String(),
+		Timestamp:     header.Timestamp.Unix(),
+		Bits:          header.Bits,
+		Nonce:         header.Nonce,
+		Confirmations: confirmations,
+	}
+}
+
+// GetBlockHeader returns the header of a block of the main chain.
+func (g *grpcServer) GetBlockHeader(_ context.Context, req *lbcdrpc.GetBlockRequest) (*lbcdrpc.BlockHeader, error) {
+	hash, height, err := g.getBlockRequestHash(req)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := g.cfg.RPC.cfg.Chain
+	header, err := chain.HeaderByHash(hash)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "block %v not found", hash)
+	}
+	confirmations := int64(1 + chain.BestSnapshot().Height - height)
+	return grpcBlockHeader(&header, hash, height, confirmations), nil
+}
+
+// GetBlock returns a block of the main chain.
+func (g *grpcServer) GetBlock(_ context.Context, req *lbcdrpc.GetBlockRequest) (*lbcdrpc.Block, error) {
+	hash, height, err := g.getBlockRequestHash(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var blkBytes []byte
+	err = g.cfg.RPC.cfg.DB.View(func(dbTx database.Tx) error {
+		var err error
+		blkBytes, err = dbTx.FetchBlock(hash)
+		return err
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "block %v not found", hash)
+	}
+	blk, err := btcutil.NewBlockFromBytes(blkBytes)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal,
+			"failed to deserialize block %v: %v", hash, err)
+	}
+
+	confirmations := int64(1 + g.cfg.RPC.cfg.Chain.BestSnapshot().Height - height)
+	result := &lbcdrpc.Block{
+		Header: grpcBlockHeader(&blk.MsgBlock().Header, hash, height,
+			confirmations),
+	}
+	for _, tx := range blk.Transactions() {
+		result.TxHashes = append(result.TxHashes, tx.Hash().String())
+	}
+	if req.IncludeRawBlock {
+		result.RawBlock = blkBytes
+	}
+	return result, nil
+}
+
+// GetTransaction returns a transaction of the mempool, or of the main chain
+// when the transaction index is enabled.
+func (g *grpcServer) GetTransaction(_ context.Context, req *lbcdrpc.GetTransactionRequest) (*lbcdrpc.Transaction, error) {
+	txHash, err := chainhash.NewHashFromStr(req.Hash)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"invalid transaction hash %q: %v", req.Hash, err)
+	}
+
+	rpc := g.cfg.RPC
+	if tx, err := rpc.cfg.TxMemPool.FetchTransaction(txHash); err == nil {
+		var buf bytes.Buffer
+		if err := tx.MsgTx().Serialize(&buf); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		return &lbcdrpc.Transaction{
+			Hash:  txHash.String(),
+			RawTx: buf.Bytes(),
+		}, nil
+	}
+
+	if rpc.cfg.TxIndex == nil {
+		return nil, status.Errorf(codes.NotFound, "transaction %v is "+
+			"not in the mempool, and the transaction index is "+
+			"disabled", txHash)
+	}
+	blockRegion, err := rpc.cfg.TxIndex.TxBlockRegion(txHash)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal,
+			"failed to retrieve transaction location: %v", err)
+	}
+	if blockRegion == nil {
+		return nil, status.Errorf(codes.NotFound,
+			"transaction %v not found", txHash)
+	}
+
+	var txBytes []byte
+	err = rpc.cfg.DB.View(func(dbTx database.Tx) error {
+		var err error
+		txBytes, err = dbTx.FetchBlockRegion(blockRegion)
+		return err
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound,
+			"transaction %v not found", txHash)
+	}
+	height, err := rpc.cfg.Chain.BlockHeightByHash(blockRegion.Hash)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal,
+			"failed to retrieve block height: %v", err)
+	}
+
+	return &lbcdrpc.Transaction{
+		Hash:  txHash.String(),
+		RawTx: txBytes,
+		Block: &lbcdrpc.BlockStamp{
+			Hash:   blockRegion.Hash.String(),
+			Height: height,
+		},
+		Confirmations: int64(1 + rpc.cfg.Chain.BestSnapshot().Height - height),
+	}, nil
+}
+
+// SendTransaction submits a transaction to the mempool and relays it, the same
+// as the sendrawtransaction command.
+func (g *grpcServer) SendTransaction(ctx context.Context, req *lbcdrpc.SendTransactionRequest) (*lbcdrpc.SendTransactionResponse, error) {
+	cmd := &btcjson.SendRawTransactionCmd{
+		HexTx: hex.EncodeToString(req.RawTx),
+	}
+	result, err := handleSendRawTransaction(g.cfg.RPC, cmd, ctx.Done())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &lbcdrpc.SendTransactionResponse{Hash: result.(string)}, nil
+}
+
+// GetMempool returns the transactions of the mempool.
+func (g *grpcServer) GetMempool(context.Context, *lbcdrpc.GetMempoolRequest) (*lbcdrpc.GetMempoolResponse, error) {
+	descs := g.cfg.RPC.cfg.TxMemPool.TxDescs()
+	result := &lbcdrpc.GetMempoolResponse{
+		Transactions: make([]*lbcdrpc.MempoolEntry, 0, len(descs)),
+	}
+	for _, desc := range descs {
+		result.Transactions = append(result.Transactions, &lbcdrpc.MempoolEntry{
+			Hash:   desc.Tx.Hash().String(),
+			Fee:    desc.Fee,
+			Size:   int32(desc.Tx.MsgTx().SerializeSize()),
+			Time:   desc.Added.Unix(),
+			Height: desc.Height,
+		})
+	}
+	return result, nil
+}
+
+// GetClaimsForName returns the claims of a name, the same as the
+// getclaimsforname command.
+func (g *grpcServer) GetClaimsForName(ctx context.Context, req *lbcdrpc.GetClaimsForNameRequest) (*lbcdrpc.GetClaimsForNameResponse, error) {
+	cmd := &btcjson.GetClaimsForNameCmd{
+		Name:          req.Name,
+		IncludeValues: &req.IncludeValues,
+	}
+	switch b := req.Block.(type) {
+	case *lbcdrpc.GetClaimsForNameRequest_Hash:
+		cmd.HashOrHeight = &b.Hash
+	case *lbcdrpc.GetClaimsForNameRequest_Height:
+		height := strconv.Itoa(int(b.Height))
+		cmd.HashOrHeight = &height
+	}
+
+	result, err := handleGetClaimsForName(g.cfg.RPC, cmd, ctx.Done())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	claims := result.(btcjson.GetClaimsForNameResult)
+
+	resp := &lbcdrpc.GetClaimsForNameResponse{
+		Block: &lbcdrpc.BlockStamp{
+			Hash:   claims.Hash,
+			Height: claims.Height,
+		},
+		NormalizedName:     claims.NormalizedName,
+		LastTakeoverHeight: claims.LastTakeoverHeight,
+	}
+	for _, c := range claims.Claims {
+		value, _ := hex.DecodeString(c.Value)
+		claim := &lbcdrpc.Claim{
+			ClaimId:         c.ClaimID,
+			TxHash:          c.TXID,
+			N:               c.N,
+			Height:          c.Height,
+			ValidAtHeight:   c.ValidAtHeight,
+			Amount:          c.Amount,
+			EffectiveAmount: c.EffectiveAmount,
+			Sequence:        c.Sequence,
+			Bid:             c.Bid,
+			Address:         c.Address,
+			Value:           value,
+		}
+		for _, s := range c.Supports {
+			value, _ := hex.DecodeString(s.Value)
+			claim.Supports = append(claim.Supports, &lbcdrpc.Support{
+				TxHash:        s.TXID,
+				N:             s.N,
+				Height:        s.Height,
+				ValidAtHeight: s.ValidAtHeight,
+				Amount:        s.Amount,
+				Address:       s.Address,
+				Value:         value,
+			})
+		}
+		resp.Claims = append(resp.Claims, claim)
+	}
+	return resp, nil
+}
+
+// subscribe adds a subscriber for the passed kind of notifications.
+func (g *grpcServer) subscribe(kind grpcSubscription) *grpcSubscriber {
+	sub := &grpcSubscriber{
+		kind:    kind,
+		ntfns:   make(chan interface{}, grpcSubscriberBuffer),
+		dropped: make(chan struct{}),
+	}
+	g.subscribersLock.Lock()
+	g.subscribers[sub] = struct{}{}
+	g.subscribersLock.Unlock()
+	return sub
+}
+
+// unsubscribe removes a subscriber.
+func (g *grpcServer) unsubscribe(sub *grpcSubscriber) {
+	g.subscribersLock.Lock()
+	delete(g.subscribers, sub)
+	g.subscribersLock.Unlock()
+}
+
+// notify queues the notification to the subscribers of the passed kinds,
+// dropping those whose queue is full.
+func (g *grpcServer) notify(ntfn interface{}, kinds ...grpcSubscription) {
+	g.subscribersLock.Lock()
+	defer g.subscribersLock.Unlock()
+
+	for sub := range g.subscribers {
+		for _, kind := range kinds {
+			if sub.kind != kind {
+				continue
+			}
+			select {
+			case sub.ntfns <- ntfn:
+			default:
+				close(sub.dropped)
+				delete(g.subscribers, sub)
+			}
+		}
+	}
+}
+
+// handleBlockchainNotification queues the notifications of blocks connected
+// to and disconnected from the main chain to the subscribers.
+func (g *grpcServer) handleBlockchainNotification(notification *blockchain.Notification) {
+	switch notification.Type {
+	case blockchain.NTBlockConnected:
+		block, ok := notification.Data.(*btcutil.Block)
+		if !ok {
+			break
+		}
+		g.notify(&grpcBlockNtfn{block: block, connected: true},
+			grpcSubscribeBlocks, grpcSubscribeClaims)
+
+	case blockchain.NTBlockDisconnected:
+		block, ok := notification.Data.(*btcutil.Block)
+		if !ok {
+			break
+		}
+		g.notify(&grpcBlockNtfn{block: block}, grpcSubscribeBlocks)
+	}
+}
+
+// NotifyNewTransactions queues the transactions accepted to the mempool to the
+// subscribers.
+func (g *grpcServer) NotifyNewTransactions(txns []*mempool.TxDesc) {
+	for _, txD := range txns {
+		g.notify(txD.Tx, grpcSubscribeTransactions)
+	}
+}
+
+// serveSubscription sends the notifications of the subscriber, converted by
+// send, until the stream ends, the subscriber falls behind or the server shuts
+// down.
+func (g *grpcServer) serveSubscription(ctx context.Context, kind grpcSubscription,
+	send func(ntfn interface{}) error) error {
+
+	sub := g.subscribe(kind)
+	defer g.unsubscribe(sub)
+
+	for {
+		select {
+		case ntfn := <-sub.ntfns:
+			if err := send(ntfn); err != nil {
+				return err
+			}
+
+		case <-sub.dropped:
+			return status.Error(codes.ResourceExhausted,
+				"subscriber fell behind the notifications")
+
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+
+		case <-g.quit:
+			return status.Error(codes.Unavailable, "server shutting down")
+		}
+	}
+}
+
+// SubscribeBlocks streams the blocks connected to and disconnected from the
+// main chain.
+func (g *grpcServer) SubscribeBlocks(_ *lbcdrpc.SubscribeBlocksRequest, stream lbcdrpc.Lbcd_SubscribeBlocksServer) error {
+	return g.serveSubscription(stream.Context(), grpcSubscribeBlocks,
+		func(ntfn interface{}) error {
+			n := ntfn.(*grpcBlockNtfn)
+			typ := lbcdrpc.BlockNotification_DISCONNECTED
+			if n.connected {
+				typ = lbcdrpc.BlockNotification_CONNECTED
+			}
+			return stream.Send(&lbcdrpc.BlockNotification{
+				Type: typ,
+				Header: grpcBlockHeader(&n.block.MsgBlock().Header,
+					n.block.Hash(), n.block.Height(), 0),
+			})
+		})
+}
+
+// SubscribeTransactions streams the transactions accepted to the mempool.
+func (g *grpcServer) SubscribeTransactions(_ *lbcdrpc.SubscribeTransactionsRequest, stream lbcdrpc.Lbcd_SubscribeTransactionsServer) error {
+	return g.serveSubscription(stream.Context(), grpcSubscribeTransactions,
+		func(ntfn interface{}) error {
+			tx := ntfn.(*btcutil.Tx)
+			var buf bytes.Buffer
+			if err := tx.MsgTx().Serialize(&buf); err != nil {
+				return status.Error(codes.Internal, err.Error())
+			}
+			return stream.Send(&lbcdrpc.Transaction{
+				Hash:  tx.Hash().String(),
+				RawTx: buf.Bytes(),
+			})
+		})
+}
+
+// SubscribeClaims streams the names whose claims changed in each block
+// connected to the main chain.  Blocks without changes to the requested names
+// are skipped.
+func (g *grpcServer) SubscribeClaims(req *lbcdrpc.SubscribeClaimsRequest, stream lbcdrpc.Lbcd_SubscribeClaimsServer) error {
+	return g.serveSubscription(stream.Context(), grpcSubscribeClaims,
+		func(ntfn interface{}) error {
+			block := ntfn.(*grpcBlockNtfn).block
+			height := block.Height()
+
+			// The changes are looked up here, rather than when the
+			// block is connected, as the chain is locked at that time.
+			names, err := g.cfg.RPC.cfg.Chain.GetNamesChangedInBlock(height)
+			if err != nil {
+				return status.Errorf(codes.Internal, "failed to "+
+					"retrieve the claim changes of block %v: %v",
+					block.Hash(), err)
+			}
+
+			if len(req.Names) > 0 {
+				wanted := make(map[string]struct{}, len(req.Names))
+				for _, name := range req.Names {
+					normalized := normalization.NormalizeIfNecessary(
+						[]byte(name), height)
+					wanted[string(normalized)] = struct{}{}
+				}
+				filtered := names[:0]
+				for _, name := range names {
+					if _, ok := wanted[name]; ok {
+						filtered = append(filtered, name)
+					}
+				}
+				names = filtered
+			}
+			if len(names) == 0 {
+				return nil
+			}
+
+			return stream.Send(&lbcdrpc.ClaimNotification{
+				Block: &lbcdrpc.BlockStamp{
+					Hash:   block.Hash().String(),
+					Height: height,
+				},
+				Names: names,
+			})
+		})
+}
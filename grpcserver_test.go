@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/btcsuite/btclog"
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCAuthorize(t *testing.T) {
+
+	r := require.New(t)
+
+	// The log rotator isn't initialized by the tests.
+	defer func(log btclog.Logger) { rpcsLog = log }(rpcsLog)
+	rpcsLog = btclog.Disabled
+
+	users, err := rpcAuthUsers(&config{
+		RPCUser:      "admin",
+		RPCPass:      "adminpass",
+		RPCLimitUser: "limited",
+		RPCLimitPass: "limitedpass",
+	})
+	r.NoError(err)
+	g := &grpcServer{cfg: grpcServerConfig{RPC: &rpcServer{authUsers: users}}}
+
+	authorize := func(login, method string) codes.Code {
+		ctx := context.Background()
+		if login != "" {
+			auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
+			ctx = metadata.NewIncomingContext(ctx,
+				metadata.Pairs("authorization", auth))
+		}
+		return status.Code(g.authorize(ctx, "/lbcdrpc.Lbcd/"+method))
+	}
+
+	r.Equal(codes.Unauthenticated, authorize("", "GetBlock"))
+	r.Equal(codes.Unauthenticated, authorize("admin:limitedpass", "GetBlock"))
+	r.Equal(codes.OK, authorize("admin:adminpass", "GetClaimsForName"))
+	r.Equal(codes.OK, authorize("limited:limitedpass", "GetBlock"))
+	r.Equal(codes.OK, authorize("limited:limitedpass", "SubscribeBlocks"))
+	r.Equal(codes.PermissionDenied, authorize("limited:limitedpass", "GetClaimsForName"))
+}
+
+func TestGRPCError(t *testing.T) {
+
+	r := require.New(t)
+
+	err := grpcError(&btcjson.RPCError{Code: btcjson.ErrRPCBlockNotFound, Message: "missing"})
+	r.Equal(codes.NotFound, status.Code(err))
+	r.Equal("missing", status.Convert(err).Message())
+
+	err = grpcError(&btcjson.RPCError{Code: btcjson.ErrRPCTxAlreadyInChain})
+	r.Equal(codes.AlreadyExists, status.Code(err))
+
+	err = grpcError(&btcjson.RPCError{Code: btcjson.ErrRPCInternal.Code})
+	r.Equal(codes.Internal, status.Code(err))
+
+	err = grpcError(ErrClientQuit)
+	r.Equal(codes.Canceled, status.Code(err))
+}
+
+func TestGRPCNotify(t *testing.T) {
+
+	r := require.New(t)
+
+	g := &grpcServer{subscribers: make(map[*grpcSubscriber]struct{})}
+	blocks := g.subscribe(grpcSubscribeBlocks)
+	claims := g.subscribe(grpcSubscribeClaims)
+
+	g.notify(1, grpcSubscribeBlocks, grpcSubscribeClaims)
+	g.notify(2, grpcSubscribeBlocks)
+	r.Len(blocks.ntfns, 2)
+	r.Len(claims.ntfns, 1)
+
+	// A subscriber with a full queue is dropped.
+	for i := 0; i < grpcSubscriberBuffer; i++ {
+		g.notify(i, grpcSubscribeClaims)
+	}
+	_, ok := <-claims.dropped
+	r.False(ok)
+	r.NotContains(g.subscribers, claims)
+	r.Contains(g.subscribers, blocks)
+
+	g.unsubscribe(blocks)
+	r.Empty(g.subscribers)
+}
@@ -14,11 +14,14 @@ import (
 	"runtime"
 	"runtime/debug"
 	"runtime/pprof"
+	"time"
 
 	"github.com/lbryio/lbcd/blockchain/indexers"
 	"github.com/lbryio/lbcd/claimtrie/param"
 	"github.com/lbryio/lbcd/database"
+	"github.com/lbryio/lbcd/database/ffldb"
 	"github.com/lbryio/lbcd/limits"
+	"github.com/lbryio/lbcd/tracing"
 	"github.com/lbryio/lbcd/version"
 
 	"github.com/felixge/fgprof"
@@ -80,6 +83,23 @@ func btcdMain(serverChan chan<- *server) error {
 		}()
 	}
 
+	// Export traces if requested.
+	if cfg.OTLPEndpoint != "" {
+		err := tracing.Enable(&tracing.Config{
+			Endpoint:    cfg.OTLPEndpoint,
+			ServiceName: "lbcd",
+			Attributes: []tracing.Attribute{
+				{Key: "service.version", Value: version.Full()},
+				{Key: "lbcd.network", Value: activeNetParams.Name},
+			},
+		})
+		if err != nil {
+			btcdLog.Errorf("Unable to export traces: %v", err)
+			return err
+		}
+		defer tracing.Disable()
+	}
+
 	// Write cpu profile if requested.
 	if cfg.CPUProfile != "" {
 		f, err := os.Create(cfg.CPUProfile)
@@ -131,7 +151,10 @@ func btcdMain(serverChan chan<- *server) error {
 	defer func() {
 		// Ensure the database is sync'd and closed on shutdown.
 		btcdLog.Infof("Gracefully shutting down the database...")
+		start := time.Now()
 		db.Close()
+		btcdLog.Infof("Database shutdown complete in %v",
+			time.Since(start).Round(time.Millisecond))
 	}()
 
 	// Return now if an interrupt signal was triggered.
@@ -167,8 +190,16 @@ func btcdMain(serverChan chan<- *server) error {
 
 		return nil
 	}
+	if cfg.DropScriptHashIndex {
+		if err := indexers.DropScriptHashIndex(db, interrupt); err != nil {
+			btcdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
 
-	param.SetNetwork(activeNetParams.Params.Net) // prep the claimtrie params
+	param.ActiveParams = activeNetParams.ClaimTrieParams() // prep the claimtrie params
 
 	go logMemoryUsage()
 
@@ -183,12 +214,18 @@ func btcdMain(serverChan chan<- *server) error {
 	}
 	defer func() {
 		btcdLog.Infof("Gracefully shutting down the server...")
+		start := time.Now()
 		server.Stop()
 		server.WaitForShutdown()
-		srvrLog.Infof("Server shutdown complete")
+		srvrLog.Infof("Server shutdown complete in %v",
+			time.Since(start).Round(time.Millisecond))
 		// TODO: tie into the sync manager for shutdown instead
 		if ct := server.chain.ClaimTrie(); ct != nil {
+			btcdLog.Infof("Flushing and closing the claimtrie...")
+			start = time.Now()
 			ct.Close()
+			btcdLog.Infof("Claimtrie shutdown complete in %v",
+				time.Since(start).Round(time.Millisecond))
 		}
 	}()
 	server.Start()
@@ -214,6 +251,12 @@ func blockDbPath(dbType string) string {
 	return dbPath
 }
 
+// blockFilesPath returns the path to the block files of the block database of
+// the passed type when they are stored in blocksdir.
+func blockFilesPath(dbType string) string {
+	return filepath.Join(cfg.BlocksDir, blockDbNamePrefix+"_"+dbType)
+}
+
 // warnMultipleDBs shows a warning if multiple block database types are detected.
 // This is not a situation most users want.  It is handy for development however
 // to support multiple side-by-side databases.
@@ -221,7 +264,7 @@ func warnMultipleDBs() {
 	// This is intentionally not using the known db types which depend
 	// on the database types compiled into the binary since we want to
 	// detect legacy db types as well.
-	dbTypes := []string{"ffldb", "leveldb", "sqlite"}
+	dbTypes := []string{"ffldb", "ffpebble", "leveldb", "sqlite"}
 	duplicateDbPaths := make([]string, 0, len(dbTypes)-1)
 	for _, dbType := range dbTypes {
 		if dbType == cfg.DbType {
@@ -269,8 +312,26 @@ func loadBlockDB() (database.DB, error) {
 
 	// The database name is based on the database type.
 	dbPath := blockDbPath(cfg.DbType)
-	btcdLog.Infof("Loading block database from '%s'", dbPath)
-	db, err := database.Open(cfg.DbType, dbPath, activeNetParams.Net)
+	dbArgs := []interface{}{dbPath, activeNetParams.Net}
+	if cfg.BlocksDir != "" {
+		blocksPath := blockFilesPath(cfg.DbType)
+		btcdLog.Infof("Loading block database from '%s' with the block "+
+			"files in '%s'", dbPath, blocksPath)
+		dbArgs = append(dbArgs, blocksPath)
+	} else {
+		btcdLog.Infof("Loading block database from '%s'", dbPath)
+	}
+	if cfg.DbType == "ffldb" || cfg.DbType == "ffpebble" {
+		if len(dbArgs) == 2 {
+			dbArgs = append(dbArgs, dbPath)
+		}
+		dbArgs = append(dbArgs, ffldb.Options{
+			MmapBlockFiles: cfg.MmapBlockFiles,
+			Check:          cfg.dbCheck,
+			Repair:         !cfg.NoDbRepair,
+		})
+	}
+	db, err := database.Open(cfg.DbType, dbArgs...)
 	if err != nil {
 		// Return the error if it's not because the database doesn't
 		// exist.
@@ -285,7 +346,7 @@ func loadBlockDB() (database.DB, error) {
 		if err != nil {
 			return nil, err
 		}
-		db, err = database.Create(cfg.DbType, dbPath, activeNetParams.Net)
+		db, err = database.Create(cfg.DbType, dbArgs...)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,13 @@
+// Package lbcdrpc provides the gRPC service of lbcd, defined in lbcd.proto,
+// and the policy service lbcd consults, defined in policy.proto.
+//
+// The Lbcd service mirrors the core JSON-RPC commands over typed messages and
+// adds server-streaming subscriptions to blocks, mempool transactions and claim
+// changes.  It is enabled by the --grpclisten option of lbcd.
+//
+// The Policy service is implemented by a sidecar enforcing the local policy of
+// lbcd on the transactions of its mempool and block templates.  It is consulted
+// with the --policyplugin option of lbcd.
+package lbcdrpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative lbcd.proto policy.proto
@@ -0,0 +1,553 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: lbcd.proto
+
+package lbcdrpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// LbcdClient is the client API for Lbcd service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type LbcdClient interface {
+	// GetBestBlock returns the hash and height of the tip of the main chain.
+	// It is whitelisted as getbestblock.
+	GetBestBlock(ctx context.Context, in *GetBestBlockRequest, opts ...grpc.CallOption) (*BlockStamp, error)
+	// GetBlock returns a block of the main chain.  It is whitelisted as
+	// getblock.
+	GetBlock(ctx context.Context, in *GetBlockRequest, opts ...grpc.CallOption) (*Block, error)
+	// GetBlockHeader returns the header of a block of the main chain.  It is
+	// whitelisted as getblockheader.
+	GetBlockHeader(ctx context.Context, in *GetBlockRequest, opts ...grpc.CallOption) (*BlockHeader, error)
+	// GetTransaction returns a transaction of the mempool, or of the main
+	// chain when the transaction index is enabled.  It is whitelisted as
+	// getrawtransaction.
+	GetTransaction(ctx context.Context, in *GetTransactionRequest, opts ...grpc.CallOption) (*Transaction, error)
+	// SendTransaction submits a transaction to the mempool and relays it.  It
+	// is whitelisted as sendrawtransaction.
+	SendTransaction(ctx context.Context, in *SendTransactionRequest, opts ...grpc.CallOption) (*SendTransactionResponse, error)
+	// GetMempool returns the transactions of the mempool.  It is whitelisted
+	// as getrawmempool.
+	GetMempool(ctx context.Context, in *GetMempoolRequest, opts ...grpc.CallOption) (*GetMempoolResponse, error)
+	// GetClaimsForName returns the claims of a name.  It is whitelisted as
+	// getclaimsforname.
+	GetClaimsForName(ctx context.Context, in *GetClaimsForNameRequest, opts ...grpc.CallOption) (*GetClaimsForNameResponse, error)
+	// SubscribeBlocks streams the blocks connected to and disconnected from
+	// the main chain.  It is whitelisted as notifyblocks.
+	SubscribeBlocks(ctx context.Context, in *SubscribeBlocksRequest, opts ...grpc.CallOption) (Lbcd_SubscribeBlocksClient, error)
+	// SubscribeTransactions streams the transactions accepted to the
+	// mempool.  It is whitelisted as notifynewtransactions.
+	SubscribeTransactions(ctx context.Context, in *SubscribeTransactionsRequest, opts ...grpc.CallOption) (Lbcd_SubscribeTransactionsClient, error)
+	// SubscribeClaims streams the names whose claims changed in each block
+	// connected to the main chain.  It is whitelisted as getchangesinblock.
+	SubscribeClaims(ctx context.Context, in *SubscribeClaimsRequest, opts ...grpc.CallOption) (Lbcd_SubscribeClaimsClient, error)
+}
+
+type lbcdClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLbcdClient(cc grpc.ClientConnInterface) LbcdClient {
+	return &lbcdClient{cc}
+}
+
+func (c *lbcdClient) GetBestBlock(ctx context.Context, in *GetBestBlockRequest, opts ...grpc.CallOption) (*BlockStamp, error) {
+	out := new(BlockStamp)
+	err := c.cc.Invoke(ctx, "/lbcdrpc.Lbcd/GetBestBlock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lbcdClient) GetBlock(ctx context.Context, in *GetBlockRequest, opts ...grpc.CallOption) (*Block, error) {
+	out := new(Block)
+	err := c.cc.Invoke(ctx, "/lbcdrpc.Lbcd/GetBlock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lbcdClient) GetBlockHeader(ctx context.Context, in *GetBlockRequest, opts ...grpc.CallOption) (*BlockHeader, error) {
+	out := new(BlockHeader)
+	err := c.cc.Invoke(ctx, "/lbcdrpc.Lbcd/GetBlockHeader", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lbcdClient) GetTransaction(ctx context.Context, in *GetTransactionRequest, opts ...grpc.CallOption) (*Transaction, error) {
+	out := new(Transaction)
+	err := c.cc.Invoke(ctx, "/lbcdrpc.Lbcd/GetTransaction", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lbcdClient) SendTransaction(ctx context.Context, in *SendTransactionRequest, opts ...grpc.CallOption) (*SendTransactionResponse, error) {
+	out := new(SendTransactionResponse)
+	err := c.cc.Invoke(ctx, "/lbcdrpc.Lbcd/SendTransaction", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lbcdClient) GetMempool(ctx context.Context, in *GetMempoolRequest, opts ...grpc.CallOption) (*GetMempoolResponse, error) {
+	out := new(GetMempoolResponse)
+	err := c.cc.Invoke(ctx, "/lbcdrpc.Lbcd/GetMempool", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lbcdClient) GetClaimsForName(ctx context.Context, in *GetClaimsForNameRequest, opts ...grpc.CallOption) (*GetClaimsForNameResponse, error) {
+	out := new(GetClaimsForNameResponse)
+	err := c.cc.Invoke(ctx, "/lbcdrpc.Lbcd/GetClaimsForName", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *lbcdClient) SubscribeBlocks(ctx context.Context, in *SubscribeBlocksRequest, opts ...grpc.CallOption) (Lbcd_SubscribeBlocksClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Lbcd_ServiceDesc.Streams[0], "/lbcdrpc.Lbcd/SubscribeBlocks", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &lbcdSubscribeBlocksClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Lbcd_SubscribeBlocksClient interface {
+	Recv() (*BlockNotification, error)
+	grpc.ClientStream
+}
+
+type lbcdSubscribeBlocksClient struct {
+	grpc.ClientStream
+}
+
+func (x *lbcdSubscribeBlocksClient) Recv() (*BlockNotification, error) {
+	m := new(BlockNotification)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *lbcdClient) SubscribeTransactions(ctx context.Context, in *SubscribeTransactionsRequest, opts ...grpc.CallOption) (Lbcd_SubscribeTransactionsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Lbcd_ServiceDesc.Streams[1], "/lbcdrpc.Lbcd/SubscribeTransactions", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &lbcdSubscribeTransactionsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Lbcd_SubscribeTransactionsClient interface {
+	Recv() (*Transaction, error)
+	grpc.ClientStream
+}
+
+type lbcdSubscribeTransactionsClient struct {
+	grpc.ClientStream
+}
+
+func (x *lbcdSubscribeTransactionsClient) Recv() (*Transaction, error) {
+	m := new(Transaction)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *lbcdClient) SubscribeClaims(ctx context.Context, in *SubscribeClaimsRequest, opts ...grpc.CallOption) (Lbcd_SubscribeClaimsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Lbcd_ServiceDesc.Streams[2], "/lbcdrpc.Lbcd/SubscribeClaims", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &lbcdSubscribeClaimsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Lbcd_SubscribeClaimsClient interface {
+	Recv() (*ClaimNotification, error)
+	grpc.ClientStream
+}
+
+type lbcdSubscribeClaimsClient struct {
+	grpc.ClientStream
+}
+
+func (x *lbcdSubscribeClaimsClient) Recv() (*ClaimNotification, error) {
+	m := new(ClaimNotification)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// LbcdServer is the server API for Lbcd service.
+// All implementations must embed UnimplementedLbcdServer
+// for forward compatibility
+type LbcdServer interface {
+	// GetBestBlock returns the hash and height of the tip of the main chain.
+	// It is whitelisted as getbestblock.
+	GetBestBlock(context.Context, *GetBestBlockRequest) (*BlockStamp, error)
+	// GetBlock returns a block of the main chain.  It is whitelisted as
+	// getblock.
+	GetBlock(context.Context, *GetBlockRequest) (*Block, error)
+	// GetBlockHeader returns the header of a block of the main chain.  It is
+	// whitelisted as getblockheader.
+	GetBlockHeader(context.Context, *GetBlockRequest) (*BlockHeader, error)
+	// GetTransaction returns a transaction of the mempool, or of the main
+	// chain when the transaction index is enabled.  It is whitelisted as
+	// getrawtransaction.
+	GetTransaction(context.Context, *GetTransactionRequest) (*Transaction, error)
+	// SendTransaction submits a transaction to the mempool and relays it.  It
+	// is whitelisted as sendrawtransaction.
+	SendTransaction(context.Context, *SendTransactionRequest) (*SendTransactionResponse, error)
+	// GetMempool returns the transactions of the mempool.  It is whitelisted
+	// as getrawmempool.
+	GetMempool(context.Context, *GetMempoolRequest) (*GetMempoolResponse, error)
+	// GetClaimsForName returns the claims of a name.  It is whitelisted as
+	// getclaimsforname.
+	GetClaimsForName(context.Context, *GetClaimsForNameRequest) (*GetClaimsForNameResponse, error)
+	// SubscribeBlocks streams the blocks connected to and disconnected from
+	// the main chain.  It is whitelisted as notifyblocks.
+	SubscribeBlocks(*SubscribeBlocksRequest, Lbcd_SubscribeBlocksServer) error
+	// SubscribeTransactions streams the transactions accepted to the
+	// mempool.  It is whitelisted as notifynewtransactions.
+	SubscribeTransactions(*SubscribeTransactionsRequest, Lbcd_SubscribeTransactionsServer) error
+	// SubscribeClaims streams the names whose claims changed in each block
+	// connected to the main chain.  It is whitelisted as getchangesinblock.
+	SubscribeClaims(*SubscribeClaimsRequest, Lbcd_SubscribeClaimsServer) error
+	mustEmbedUnimplementedLbcdServer()
+}
+
+// UnimplementedLbcdServer must be embedded to have forward compatible implementations.
+type UnimplementedLbcdServer struct {
+}
+
+func (UnimplementedLbcdServer) GetBestBlock(context.Context, *GetBestBlockRequest) (*BlockStamp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBestBlock not implemented")
+}
+func (UnimplementedLbcdServer) GetBlock(context.Context, *GetBlockRequest) (*Block, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBlock not implemented")
+}
+func (UnimplementedLbcdServer) GetBlockHeader(context.Context, *GetBlockRequest) (*BlockHeader, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBlockHeader not implemented")
+}
+func (UnimplementedLbcdServer) GetTransaction(context.Context, *GetTransactionRequest) (*Transaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransaction not implemented")
+}
+func (UnimplementedLbcdServer) SendTransaction(context.Context, *SendTransactionRequest) (*SendTransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendTransaction not implemented")
+}
+func (UnimplementedLbcdServer) GetMempool(context.Context, *GetMempoolRequest) (*GetMempoolResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMempool not implemented")
+}
+func (UnimplementedLbcdServer) GetClaimsForName(context.Context, *GetClaimsForNameRequest) (*GetClaimsForNameResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetClaimsForName not implemented")
+}
+func (UnimplementedLbcdServer) SubscribeBlocks(*SubscribeBlocksRequest, Lbcd_SubscribeBlocksServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeBlocks not implemented")
+}
+func (UnimplementedLbcdServer) SubscribeTransactions(*SubscribeTransactionsRequest, Lbcd_SubscribeTransactionsServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeTransactions not implemented")
+}
+func (UnimplementedLbcdServer) SubscribeClaims(*SubscribeClaimsRequest, Lbcd_SubscribeClaimsServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeClaims not implemented")
+}
+func (UnimplementedLbcdServer) mustEmbedUnimplementedLbcdServer() {}
+
+// UnsafeLbcdServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LbcdServer will
+// result in compilation errors.
+type UnsafeLbcdServer interface {
+	mustEmbedUnimplementedLbcdServer()
+}
+
+func RegisterLbcdServer(s grpc.ServiceRegistrar, srv LbcdServer) {
+	s.RegisterService(&Lbcd_ServiceDesc, srv)
+}
+
+func _Lbcd_GetBestBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBestBlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LbcdServer).GetBestBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lbcdrpc.Lbcd/GetBestBlock",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LbcdServer).GetBestBlock(ctx, req.(*GetBestBlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lbcd_GetBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LbcdServer).GetBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lbcdrpc.Lbcd/GetBlock",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LbcdServer).GetBlock(ctx, req.(*GetBlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lbcd_GetBlockHeader_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LbcdServer).GetBlockHeader(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lbcdrpc.Lbcd/GetBlockHeader",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LbcdServer).GetBlockHeader(ctx, req.(*GetBlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lbcd_GetTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LbcdServer).GetTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lbcdrpc.Lbcd/GetTransaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LbcdServer).GetTransaction(ctx, req.(*GetTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lbcd_SendTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LbcdServer).SendTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lbcdrpc.Lbcd/SendTransaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LbcdServer).SendTransaction(ctx, req.(*SendTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lbcd_GetMempool_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMempoolRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LbcdServer).GetMempool(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lbcdrpc.Lbcd/GetMempool",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LbcdServer).GetMempool(ctx, req.(*GetMempoolRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lbcd_GetClaimsForName_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetClaimsForNameRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LbcdServer).GetClaimsForName(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/lbcdrpc.Lbcd/GetClaimsForName",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LbcdServer).GetClaimsForName(ctx, req.(*GetClaimsForNameRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Lbcd_SubscribeBlocks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeBlocksRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LbcdServer).SubscribeBlocks(m, &lbcdSubscribeBlocksServer{stream})
+}
+
+type Lbcd_SubscribeBlocksServer interface {
+	Send(*BlockNotification) error
+	grpc.ServerStream
+}
+
+type lbcdSubscribeBlocksServer struct {
+	grpc.ServerStream
+}
+
+func (x *lbcdSubscribeBlocksServer) Send(m *BlockNotification) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Lbcd_SubscribeTransactions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeTransactionsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LbcdServer).SubscribeTransactions(m, &lbcdSubscribeTransactionsServer{stream})
+}
+
+type Lbcd_SubscribeTransactionsServer interface {
+	Send(*Transaction) error
+	grpc.ServerStream
+}
+
+type lbcdSubscribeTransactionsServer struct {
+	grpc.ServerStream
+}
+
+func (x *lbcdSubscribeTransactionsServer) Send(m *Transaction) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Lbcd_SubscribeClaims_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeClaimsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LbcdServer).SubscribeClaims(m, &lbcdSubscribeClaimsServer{stream})
+}
+
+type Lbcd_SubscribeClaimsServer interface {
+	Send(*ClaimNotification) error
+	grpc.ServerStream
+}
+
+type lbcdSubscribeClaimsServer struct {
+	grpc.ServerStream
+}
+
+func (x *lbcdSubscribeClaimsServer) Send(m *ClaimNotification) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Lbcd_ServiceDesc is the grpc.ServiceDesc for Lbcd service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Lbcd_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "lbcdrpc.Lbcd",
+	HandlerType: (*LbcdServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetBestBlock",
+			Handler:    _Lbcd_GetBestBlock_Handler,
+		},
+		{
+			MethodName: "GetBlock",
+			Handler:    _Lbcd_GetBlock_Handler,
+		},
+		{
+			MethodName: "GetBlockHeader",
+			Handler:    _Lbcd_GetBlockHeader_Handler,
+		},
+		{
+			MethodName: "GetTransaction",
+			Handler:    _Lbcd_GetTransaction_Handler,
+		},
+		{
+			MethodName: "SendTransaction",
+			Handler:    _Lbcd_SendTransaction_Handler,
+		},
+		{
+			MethodName: "GetMempool",
+			Handler:    _Lbcd_GetMempool_Handler,
+		},
+		{
+			MethodName: "GetClaimsForName",
+			Handler:    _Lbcd_GetClaimsForName_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeBlocks",
+			Handler:       _Lbcd_SubscribeBlocks_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SubscribeTransactions",
+			Handler:       _Lbcd_SubscribeTransactions_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SubscribeClaims",
+			Handler:       _Lbcd_SubscribeClaims_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "lbcd.proto",
+}
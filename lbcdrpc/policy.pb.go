@@ -0,0 +1,818 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.0
+// 	protoc        (unknown)
+// source: policy.proto
+
+package lbcdrpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CheckTransactionsRequest_Stage int32
+
+const (
+	// The transaction is being accepted to the mempool.
+	CheckTransactionsRequest_MEMPOOL CheckTransactionsRequest_Stage = 0
+	// The transactions are candidates for a block template.
+	CheckTransactionsRequest_BLOCK_TEMPLATE CheckTransactionsRequest_Stage = 1
+)
+
+// Enum value maps for CheckTransactionsRequest_Stage.
+var (
+	CheckTransactionsRequest_Stage_name = map[int32]string{
+		0: "MEMPOOL",
+		1: "BLOCK_TEMPLATE",
+	}
+	CheckTransactionsRequest_Stage_value = map[string]int32{
+		"MEMPOOL":        0,
+		"BLOCK_TEMPLATE": 1,
+	}
+)
+
+func (x CheckTransactionsRequest_Stage) Enum() *CheckTransactionsRequest_Stage {
+	p := new(CheckTransactionsRequest_Stage)
+	*p = x
+	return p
+}
+
+func (x CheckTransactionsRequest_Stage) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CheckTransactionsRequest_Stage) Descriptor() protoreflect.EnumDescriptor {
+	return file_policy_proto_enumTypes[0].Descriptor()
+}
+
+func (CheckTransactionsRequest_Stage) Type() protoreflect.EnumType {
+	return &file_policy_proto_enumTypes[0]
+}
+
+func (x CheckTransactionsRequest_Stage) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CheckTransactionsRequest_Stage.Descriptor instead.
+func (CheckTransactionsRequest_Stage) EnumDescriptor() ([]byte, []int) {
+	return file_policy_proto_rawDescGZIP(), []int{0, 0}
+}
+
+type ClaimScript_Type int32
+
+const (
+	ClaimScript_CLAIM_NAME    ClaimScript_Type = 0
+	ClaimScript_SUPPORT_CLAIM ClaimScript_Type = 1
+	ClaimScript_UPDATE_CLAIM  ClaimScript_Type = 2
+)
+
+// Enum value maps for ClaimScript_Type.
+var (
+	ClaimScript_Type_name = map[int32]string{
+		0: "CLAIM_NAME",
+		1: "SUPPORT_CLAIM",
+		2: "UPDATE_CLAIM",
+	}
+	ClaimScript_Type_value = map[string]int32{
+		"CLAIM_NAME":    0,
+		"SUPPORT_CLAIM": 1,
+		"UPDATE_CLAIM":  2,
+	}
+)
+
+func (x ClaimScript_Type) Enum() *ClaimScript_Type {
+	p := new(ClaimScript_Type)
+	*p = x
+	return p
+}
+
+func (x ClaimScript_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ClaimScript_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_policy_proto_enumTypes[1].Descriptor()
+}
+
+func (ClaimScript_Type) Type() protoreflect.EnumType {
+	return &file_policy_proto_enumTypes[1]
+}
+
+func (x ClaimScript_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ClaimScript_Type.Descriptor instead.
+func (ClaimScript_Type) EnumDescriptor() ([]byte, []int) {
+	return file_policy_proto_rawDescGZIP(), []int{4, 0}
+}
+
+type CheckTransactionsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Stage CheckTransactionsRequest_Stage `protobuf:"varint,1,opt,name=stage,proto3,enum=lbcdrpc.CheckTransactionsRequest_Stage" json:"stage,omitempty"`
+	// The height of the next block.
+	Height       int32                `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	Transactions []*PolicyTransaction `protobuf:"bytes,3,rep,name=transactions,proto3" json:"transactions,omitempty"`
+}
+
+func (x *CheckTransactionsRequest) Reset() {
+	*x = CheckTransactionsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_policy_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CheckTransactionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckTransactionsRequest) ProtoMessage() {}
+
+func (x *CheckTransactionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_policy_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckTransactionsRequest.ProtoReflect.Descriptor instead.
+func (*CheckTransactionsRequest) Descriptor() ([]byte, []int) {
+	return file_policy_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CheckTransactionsRequest) GetStage() CheckTransactionsRequest_Stage {
+	if x != nil {
+		return x.Stage
+	}
+	return CheckTransactionsRequest_MEMPOOL
+}
+
+func (x *CheckTransactionsRequest) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *CheckTransactionsRequest) GetTransactions() []*PolicyTransaction {
+	if x != nil {
+		return x.Transactions
+	}
+	return nil
+}
+
+type PolicyTransaction struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Hash    string          `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	RawTx   []byte          `protobuf:"bytes,2,opt,name=raw_tx,json=rawTx,proto3" json:"raw_tx,omitempty"`
+	Fee     int64           `protobuf:"varint,3,opt,name=fee,proto3" json:"fee,omitempty"`
+	Vsize   int64           `protobuf:"varint,4,opt,name=vsize,proto3" json:"vsize,omitempty"`
+	Inputs  []*PolicyInput  `protobuf:"bytes,5,rep,name=inputs,proto3" json:"inputs,omitempty"`
+	Outputs []*PolicyOutput `protobuf:"bytes,6,rep,name=outputs,proto3" json:"outputs,omitempty"`
+}
+
+func (x *PolicyTransaction) Reset() {
+	*x = PolicyTransaction{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_policy_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PolicyTransaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PolicyTransaction) ProtoMessage() {}
+
+func (x *PolicyTransaction) ProtoReflect() protoreflect.Message {
+	mi := &file_policy_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PolicyTransaction.ProtoReflect.Descriptor instead.
+func (*PolicyTransaction) Descriptor() ([]byte, []int) {
+	return file_policy_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PolicyTransaction) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *PolicyTransaction) GetRawTx() []byte {
+	if x != nil {
+		return x.RawTx
+	}
+	return nil
+}
+
+func (x *PolicyTransaction) GetFee() int64 {
+	if x != nil {
+		return x.Fee
+	}
+	return 0
+}
+
+func (x *PolicyTransaction) GetVsize() int64 {
+	if x != nil {
+		return x.Vsize
+	}
+	return 0
+}
+
+func (x *PolicyTransaction) GetInputs() []*PolicyInput {
+	if x != nil {
+		return x.Inputs
+	}
+	return nil
+}
+
+func (x *PolicyTransaction) GetOutputs() []*PolicyOutput {
+	if x != nil {
+		return x.Outputs
+	}
+	return nil
+}
+
+type PolicyInput struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PrevHash  string `protobuf:"bytes,1,opt,name=prev_hash,json=prevHash,proto3" json:"prev_hash,omitempty"`
+	PrevIndex uint32 `protobuf:"varint,2,opt,name=prev_index,json=prevIndex,proto3" json:"prev_index,omitempty"`
+	// The output spent, unset when it is the output of a transaction of the
+	// mempool in the block template stage.
+	PrevOutput *PolicyOutput `protobuf:"bytes,3,opt,name=prev_output,json=prevOutput,proto3" json:"prev_output,omitempty"`
+}
+
+func (x *PolicyInput) Reset() {
+	*x = PolicyInput{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_policy_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PolicyInput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PolicyInput) ProtoMessage() {}
+
+func (x *PolicyInput) ProtoReflect() protoreflect.Message {
+	mi := &file_policy_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PolicyInput.ProtoReflect.Descriptor instead.
+func (*PolicyInput) Descriptor() ([]byte, []int) {
+	return file_policy_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PolicyInput) GetPrevHash() string {
+	if x != nil {
+		return x.PrevHash
+	}
+	return ""
+}
+
+func (x *PolicyInput) GetPrevIndex() uint32 {
+	if x != nil {
+		return x.PrevIndex
+	}
+	return 0
+}
+
+func (x *PolicyInput) GetPrevOutput() *PolicyOutput {
+	if x != nil {
+		return x.PrevOutput
+	}
+	return nil
+}
+
+type PolicyOutput struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Value    int64  `protobuf:"varint,1,opt,name=value,proto3" json:"value,omitempty"`
+	PkScript []byte `protobuf:"bytes,2,opt,name=pk_script,json=pkScript,proto3" json:"pk_script,omitempty"`
+	Address  string `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+	// The claim operation of the output script, unset for other scripts.
+	Claim *ClaimScript `protobuf:"bytes,4,opt,name=claim,proto3" json:"claim,omitempty"`
+}
+
+func (x *PolicyOutput) Reset() {
+	*x = PolicyOutput{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_policy_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PolicyOutput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PolicyOutput) ProtoMessage() {}
+
+func (x *PolicyOutput) ProtoReflect() protoreflect.Message {
+	mi := &file_policy_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PolicyOutput.ProtoReflect.Descriptor instead.
+func (*PolicyOutput) Descriptor() ([]byte, []int) {
+	return file_policy_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PolicyOutput) GetValue() int64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *PolicyOutput) GetPkScript() []byte {
+	if x != nil {
+		return x.PkScript
+	}
+	return nil
+}
+
+func (x *PolicyOutput) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *PolicyOutput) GetClaim() *ClaimScript {
+	if x != nil {
+		return x.Claim
+	}
+	return nil
+}
+
+type ClaimScript struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type ClaimScript_Type `protobuf:"varint,1,opt,name=type,proto3,enum=lbcdrpc.ClaimScript_Type" json:"type,omitempty"`
+	// The name is not necessarily valid UTF-8.
+	Name []byte `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// The ID of the claim created, updated or supported.
+	ClaimId string `protobuf:"bytes,3,opt,name=claim_id,json=claimId,proto3" json:"claim_id,omitempty"`
+	// The value of the claim, or the data of the support.
+	Value []byte `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (x *ClaimScript) Reset() {
+	*x = ClaimScript{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_policy_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ClaimScript) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClaimScript) ProtoMessage() {}
+
+func (x *ClaimScript) ProtoReflect() protoreflect.Message {
+	mi := &file_policy_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClaimScript.ProtoReflect.Descriptor instead.
+func (*ClaimScript) Descriptor() ([]byte, []int) {
+	return file_policy_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ClaimScript) GetType() ClaimScript_Type {
+	if x != nil {
+		return x.Type
+	}
+	return ClaimScript_CLAIM_NAME
+}
+
+func (x *ClaimScript) GetName() []byte {
+	if x != nil {
+		return x.Name
+	}
+	return nil
+}
+
+func (x *ClaimScript) GetClaimId() string {
+	if x != nil {
+		return x.ClaimId
+	}
+	return ""
+}
+
+func (x *ClaimScript) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+type CheckTransactionsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Verdicts []*PolicyVerdict `protobuf:"bytes,1,rep,name=verdicts,proto3" json:"verdicts,omitempty"`
+}
+
+func (x *CheckTransactionsResponse) Reset() {
+	*x = CheckTransactionsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_policy_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CheckTransactionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckTransactionsResponse) ProtoMessage() {}
+
+func (x *CheckTransactionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_policy_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckTransactionsResponse.ProtoReflect.Descriptor instead.
+func (*CheckTransactionsResponse) Descriptor() ([]byte, []int) {
+	return file_policy_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CheckTransactionsResponse) GetVerdicts() []*PolicyVerdict {
+	if x != nil {
+		return x.Verdicts
+	}
+	return nil
+}
+
+type PolicyVerdict struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Reject bool `protobuf:"varint,1,opt,name=reject,proto3" json:"reject,omitempty"`
+	// The reason of the rejection, logged and returned to the submitter.
+	Reason string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+}
+
+func (x *PolicyVerdict) Reset() {
+	*x = PolicyVerdict{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_policy_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PolicyVerdict) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PolicyVerdict) ProtoMessage() {}
+
+func (x *PolicyVerdict) ProtoReflect() protoreflect.Message {
+	mi := &file_policy_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PolicyVerdict.ProtoReflect.Descriptor instead.
+func (*PolicyVerdict) Descriptor() ([]byte, []int) {
+	return file_policy_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *PolicyVerdict) GetReject() bool {
+	if x != nil {
+		return x.Reject
+	}
+	return false
+}
+
+func (x *PolicyVerdict) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+var File_policy_proto protoreflect.FileDescriptor
+
+var file_policy_proto_rawDesc = []byte{
+	0x0a, 0x0c, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x07,
+	0x6c, 0x62, 0x63, 0x64, 0x72, 0x70, 0x63, 0x22, 0xdb, 0x01, 0x0a, 0x18, 0x43, 0x68, 0x65, 0x63,
+	0x6b, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x3d, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x27, 0x2e, 0x6c, 0x62, 0x63, 0x64, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x68,
+	0x65, 0x63, 0x6b, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x53, 0x74, 0x61, 0x67, 0x65, 0x52, 0x05, 0x73, 0x74,
+	0x61, 0x67, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x06, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x12, 0x3e, 0x0a, 0x0c, 0x74,
+	0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x1a, 0x2e, 0x6c, 0x62, 0x63, 0x64, 0x72, 0x70, 0x63, 0x2e, 0x50, 0x6f, 0x6c, 0x69,
+	0x63, 0x79, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0c, 0x74,
+	0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x28, 0x0a, 0x05, 0x53,
+	0x74, 0x61, 0x67, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x4d, 0x45, 0x4d, 0x50, 0x4f, 0x4f, 0x4c, 0x10,
+	0x00, 0x12, 0x12, 0x0a, 0x0e, 0x42, 0x4c, 0x4f, 0x43, 0x4b, 0x5f, 0x54, 0x45, 0x4d, 0x50, 0x4c,
+	0x41, 0x54, 0x45, 0x10, 0x01, 0x22, 0xc5, 0x01, 0x0a, 0x11, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79,
+	0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x68,
+	0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x68, 0x61, 0x73, 0x68, 0x12,
+	0x15, 0x0a, 0x06, 0x72, 0x61, 0x77, 0x5f, 0x74, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x05, 0x72, 0x61, 0x77, 0x54, 0x78, 0x12, 0x10, 0x0a, 0x03, 0x66, 0x65, 0x65, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x03, 0x66, 0x65, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x73, 0x69, 0x7a,
+	0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x76, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x2c,
+	0x0a, 0x06, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14,
+	0x2e, 0x6c, 0x62, 0x63, 0x64, 0x72, 0x70, 0x63, 0x2e, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x49,
+	0x6e, 0x70, 0x75, 0x74, 0x52, 0x06, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x73, 0x12, 0x2f, 0x0a, 0x07,
+	0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e,
+	0x6c, 0x62, 0x63, 0x64, 0x72, 0x70, 0x63, 0x2e, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x4f, 0x75,
+	0x74, 0x70, 0x75, 0x74, 0x52, 0x07, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x73, 0x22, 0x81, 0x01,
+	0x0a, 0x0b, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x12, 0x1b, 0x0a,
+	0x09, 0x70, 0x72, 0x65, 0x76, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x70, 0x72, 0x65, 0x76, 0x48, 0x61, 0x73, 0x68, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x72,
+	0x65, 0x76, 0x5f, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09,
+	0x70, 0x72, 0x65, 0x76, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x36, 0x0a, 0x0b, 0x70, 0x72, 0x65,
+	0x76, 0x5f, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15,
+	0x2e, 0x6c, 0x62, 0x63, 0x64, 0x72, 0x70, 0x63, 0x2e, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x4f,
+	0x75, 0x74, 0x70, 0x75, 0x74, 0x52, 0x0a, 0x70, 0x72, 0x65, 0x76, 0x4f, 0x75, 0x74, 0x70, 0x75,
+	0x74, 0x22, 0x87, 0x01, 0x0a, 0x0c, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x4f, 0x75, 0x74, 0x70,
+	0x75, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x6b, 0x5f, 0x73,
+	0x63, 0x72, 0x69, 0x70, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x70, 0x6b, 0x53,
+	0x63, 0x72, 0x69, 0x70, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12,
+	0x2a, 0x0a, 0x05, 0x63, 0x6c, 0x61, 0x69, 0x6d, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14,
+	0x2e, 0x6c, 0x62, 0x63, 0x64, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x6c, 0x61, 0x69, 0x6d, 0x53, 0x63,
+	0x72, 0x69, 0x70, 0x74, 0x52, 0x05, 0x63, 0x6c, 0x61, 0x69, 0x6d, 0x22, 0xbe, 0x01, 0x0a, 0x0b,
+	0x43, 0x6c, 0x61, 0x69, 0x6d, 0x53, 0x63, 0x72, 0x69, 0x70, 0x74, 0x12, 0x2d, 0x0a, 0x04, 0x74,
+	0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x19, 0x2e, 0x6c, 0x62, 0x63, 0x64,
+	0x72, 0x70, 0x63, 0x2e, 0x43, 0x6c, 0x61, 0x69, 0x6d, 0x53, 0x63, 0x72, 0x69, 0x70, 0x74, 0x2e,
+	0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x19,
+	0x0a, 0x08, 0x63, 0x6c, 0x61, 0x69, 0x6d, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x63, 0x6c, 0x61, 0x69, 0x6d, 0x49, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22,
+	0x3b, 0x0a, 0x04, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0e, 0x0a, 0x0a, 0x43, 0x4c, 0x41, 0x49, 0x4d,
+	0x5f, 0x4e, 0x41, 0x4d, 0x45, 0x10, 0x00, 0x12, 0x11, 0x0a, 0x0d, 0x53, 0x55, 0x50, 0x50, 0x4f,
+	0x52, 0x54, 0x5f, 0x43, 0x4c, 0x41, 0x49, 0x4d, 0x10, 0x01, 0x12, 0x10, 0x0a, 0x0c, 0x55, 0x50,
+	0x44, 0x41, 0x54, 0x45, 0x5f, 0x43, 0x4c, 0x41, 0x49, 0x4d, 0x10, 0x02, 0x22, 0x4f, 0x0a, 0x19,
+	0x43, 0x68, 0x65, 0x63, 0x6b, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x32, 0x0a, 0x08, 0x76, 0x65, 0x72,
+	0x64, 0x69, 0x63, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x6c, 0x62,
+	0x63, 0x64, 0x72, 0x70, 0x63, 0x2e, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x56, 0x65, 0x72, 0x64,
+	0x69, 0x63, 0x74, 0x52, 0x08, 0x76, 0x65, 0x72, 0x64, 0x69, 0x63, 0x74, 0x73, 0x22, 0x3f, 0x0a,
+	0x0d, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x56, 0x65, 0x72, 0x64, 0x69, 0x63, 0x74, 0x12, 0x16,
+	0x0a, 0x06, 0x72, 0x65, 0x6a, 0x65, 0x63, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06,
+	0x72, 0x65, 0x6a, 0x65, 0x63, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x32, 0x64,
+	0x0a, 0x06, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x5a, 0x0a, 0x11, 0x43, 0x68, 0x65, 0x63,
+	0x6b, 0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x21, 0x2e,
+	0x6c, 0x62, 0x63, 0x64, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x54, 0x72, 0x61,
+	0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x22, 0x2e, 0x6c, 0x62, 0x63, 0x64, 0x72, 0x70, 0x63, 0x2e, 0x43, 0x68, 0x65, 0x63, 0x6b,
+	0x54, 0x72, 0x61, 0x6e, 0x73, 0x61, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x42, 0x20, 0x5a, 0x1e, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63,
+	0x6f, 0x6d, 0x2f, 0x6c, 0x62, 0x72, 0x79, 0x69, 0x6f, 0x2f, 0x6c, 0x62, 0x63, 0x64, 0x2f, 0x6c,
+	0x62, 0x63, 0x64, 0x72, 0x70, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_policy_proto_rawDescOnce sync.Once
+	file_policy_proto_rawDescData = file_policy_proto_rawDesc
+)
+
+func file_policy_proto_rawDescGZIP() []byte {
+	file_policy_proto_rawDescOnce.Do(func() {
+		file_policy_proto_rawDescData = protoimpl.X.CompressGZIP(file_policy_proto_rawDescData)
+	})
+	return file_policy_proto_rawDescData
+}
+
+var file_policy_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_policy_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_policy_proto_goTypes = []interface{}{
+	(CheckTransactionsRequest_Stage)(0), // 0: lbcdrpc.CheckTransactionsRequest.Stage
+	(ClaimScript_Type)(0),               // 1: lbcdrpc.ClaimScript.Type
+	(*CheckTransactionsRequest)(nil),    // 2: lbcdrpc.CheckTransactionsRequest
+	(*PolicyTransaction)(nil),           // 3: lbcdrpc.PolicyTransaction
+	(*PolicyInput)(nil),                 // 4: lbcdrpc.PolicyInput
+	(*PolicyOutput)(nil),                // 5: lbcdrpc.PolicyOutput
+	(*ClaimScript)(nil),                 // 6: lbcdrpc.ClaimScript
+	(*CheckTransactionsResponse)(nil),   // 7: lbcdrpc.CheckTransactionsResponse
+	(*PolicyVerdict)(nil),               // 8: lbcdrpc.PolicyVerdict
+}
+var file_policy_proto_depIdxs = []int32{
+	0, // 0: lbcdrpc.CheckTransactionsRequest.stage:type_name -> lbcdrpc.CheckTransactionsRequest.Stage
+	3, // 1: lbcdrpc.CheckTransactionsRequest.transactions:type_name -> lbcdrpc.PolicyTransaction
+	4, // 2: lbcdrpc.PolicyTransaction.inputs:type_name -> lbcdrpc.PolicyInput
+	5, // 3: lbcdrpc.PolicyTransaction.outputs:type_name -> lbcdrpc.PolicyOutput
+	5, // 4: lbcdrpc.PolicyInput.prev_output:type_name -> lbcdrpc.PolicyOutput
+	6, // 5: lbcdrpc.PolicyOutput.claim:type_name -> lbcdrpc.ClaimScript
+	1, // 6: lbcdrpc.ClaimScript.type:type_name -> lbcdrpc.ClaimScript.Type
+	8, // 7: lbcdrpc.CheckTransactionsResponse.verdicts:type_name -> lbcdrpc.PolicyVerdict
+	2, // 8: lbcdrpc.Policy.CheckTransactions:input_type -> lbcdrpc.CheckTransactionsRequest
+	7, // 9: lbcdrpc.Policy.CheckTransactions:output_type -> lbcdrpc.CheckTransactionsResponse
+	9, // [9:10] is the sub-list for method output_type
+	8, // [8:9] is the sub-list for method input_type
+	8, // [8:8] is the sub-list for extension type_name
+	8, // [8:8] is the sub-list for extension extendee
+	0, // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_policy_proto_init() }
+func file_policy_proto_init() {
+	if File_policy_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_policy_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CheckTransactionsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_policy_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PolicyTransaction); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_policy_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PolicyInput); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_policy_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PolicyOutput); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_policy_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ClaimScript); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_policy_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CheckTransactionsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_policy_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PolicyVerdict); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_policy_proto_rawDesc,
+			NumEnums:      2,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_policy_proto_goTypes,
+		DependencyIndexes: file_policy_proto_depIdxs,
+		EnumInfos:         file_policy_proto_enumTypes,
+		MessageInfos:      file_policy_proto_msgTypes,
+	}.Build()
+	File_policy_proto = out.File
+	file_policy_proto_rawDesc = nil
+	file_policy_proto_goTypes = nil
+	file_policy_proto_depIdxs = nil
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/lbryio/lbcd/mining/cpuminer"
 	"github.com/lbryio/lbcd/netsync"
 	"github.com/lbryio/lbcd/peer"
+	"github.com/lbryio/lbcd/tracing"
 	"github.com/lbryio/lbcd/txscript"
 
 	"github.com/btcsuite/btclog"
@@ -47,10 +48,11 @@ func (logWriter) Write(p []byte) (n int, err error) {
 // log file.  This must be performed early during application startup by calling
 // initLogRotator.
 var (
-	// backendLog is the logging backend used to create all subsystem loggers.
-	// The backend must not be used before the log rotator has been initialized,
-	// or data races and/or nil pointer dereferences will occur.
-	backendLog = btclog.NewBackend(logWriter{})
+	// backendLog is the logging backend used to create all subsystem loggers,
+	// which write JSON records when JSON logs are enabled.  The backend must
+	// not be used before the log rotator has been initialized, or data races
+	// and/or nil pointer dereferences will occur.
+	backendLog = newLogBackend(logWriter{})
 
 	// logRotator is one of the logging outputs.  It should be closed on
 	// application shutdown.
@@ -63,15 +65,20 @@ var (
 	chanLog = backendLog.Logger("CHAN")
 	cmgrLog = backendLog.Logger("CMGR")
 	discLog = backendLog.Logger("DISC")
+	exptLog = backendLog.Logger("EXPT")
 	feesLog = backendLog.Logger("FEES")
+	hookLog = backendLog.Logger("HOOK")
 	indxLog = backendLog.Logger("INDX")
 	lbryLog = backendLog.Logger("LBRY")
 	minrLog = backendLog.Logger("MINR")
 	peerLog = backendLog.Logger("PEER")
+	plcyLog = backendLog.Logger("PLCY")
 	rpcsLog = backendLog.Logger("RPCS")
 	scrpLog = backendLog.Logger("SCRP")
+	seedLog = backendLog.Logger("SEED")
 	srvrLog = backendLog.Logger("SRVR")
 	syncLog = backendLog.Logger("SYNC")
+	trceLog = backendLog.Logger("TRCE")
 	txmpLog = backendLog.Logger("TXMP")
 )
 
@@ -89,6 +96,7 @@ func init() {
 	netsync.UseLogger(syncLog)
 	node.UseLogger(lbryLog)
 	peer.UseLogger(peerLog)
+	tracing.UseLogger(trceLog)
 	txscript.UseLogger(scrpLog)
 }
 
@@ -100,15 +108,20 @@ var subsystemLoggers = map[string]btclog.Logger{
 	"CHAN": chanLog,
 	"CMGR": cmgrLog,
 	"DISC": discLog,
+	"EXPT": exptLog,
+	"HOOK": hookLog,
 	"INDX": indxLog,
 	"LBRY": lbryLog,
 	"MAIN": btcdLog,
 	"MINR": minrLog,
 	"PEER": peerLog,
+	"PLCY": plcyLog,
 	"RPCS": rpcsLog,
 	"SCRP": scrpLog,
+	"SEED": seedLog,
 	"SRVR": srvrLog,
 	"SYNC": syncLog,
+	"TRCE": trceLog,
 	"TXMP": txmpLog,
 }
 
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btclog"
+)
+
+// Formats of the log records.
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// jsonLogs is set when the log records are written as JSON objects, one per
+// line, rather than in the text format of btclog.
+var jsonLogs bool
+
+// logLevelNames are the names of the levels of the JSON records.
+var logLevelNames = map[btclog.Level]string{
+	btclog.LevelTrace:    "trace",
+	btclog.LevelDebug:    "debug",
+	btclog.LevelInfo:     "info",
+	btclog.LevelWarn:     "warn",
+	btclog.LevelError:    "error",
+	btclog.LevelCritical: "critical",
+}
+
+// jsonLogRecord is a log record written as JSON.  Fields holds the fields
+// passed explicitly with the message by logFields.
+type jsonLogRecord struct {
+	Time      string                 `json:"time"`
+	Level     string                 `json:"level"`
+	Subsystem string                 `json:"subsystem"`
+	Message   string                 `json:"message"`
+	Fields    map[string]interface{} `json:"fields,omitempty"`
+}
+
+// logBackend is the logging backend of the subsystem loggers, which write their
+// records in the text format of btclog, or as JSON objects when JSON logs are
+// enabled.
+type logBackend struct {
+	text *btclog.Backend
+
+	mtx sync.Mutex
+	w   io.Writer
+}
+
+// newLogBackend returns a new logging backend writing to w.
+func newLogBackend(w io.Writer) *logBackend {
+	return &logBackend{text: btclog.NewBackend(w), w: w}
+}
+
+// Logger returns a new logger of the subsystem, whose level is info.
+func (b *logBackend) Logger(subsystem string) btclog.Logger {
+	return &subsystemLogger{
+		Logger:    b.text.Logger(subsystem),
+		backend:   b,
+		subsystem: subsystem,
+	}
+}
+
+// subsystemLogger is the logger of a subsystem.  The text records are written
+// by the btclog logger it embeds, which also holds its level, and the JSON
+// records from their level, subsystem and time directly.
+type subsystemLogger struct {
+	btclog.Logger
+	backend   *logBackend
+	subsystem string
+}
+
+// writeJSON writes the JSON record of the level with the message and the
+// fields.
+func (l *subsystemLogger) writeJSON(level btclog.Level, msg string,
+	fields map[string]interface{}) {
+
+	t := time.Now()
+	b, err := json.Marshal(&jsonLogRecord{
+		Time:      t.Format(time.RFC3339Nano),
+		Level:     logLevelNames[level],
+		Subsystem: l.subsystem,
+		Message:   msg,
+		Fields:    fields,
+	})
+	if err != nil {
+		return
+	}
+	b = append(b, '\n')
+
+	l.backend.mtx.Lock()
+	l.backend.w.Write(b)
+	l.backend.mtx.Unlock()
+}
+
+// writef writes the JSON record of the level with the formatted message, if
+// the level is enabled.
+func (l *subsystemLogger) writef(level btclog.Level, format string,
+	params []interface{}) {
+
+	if level >= l.Level() {
+		l.writeJSON(level, fmt.Sprintf(format, params...), nil)
+	}
+}
+
+// write writes the JSON record of the level with the message of the operands,
+// if the level is enabled.  The operands are separated by spaces like those of
+// the non-formatting methods of btclog.
+func (l *subsystemLogger) write(level btclog.Level, v []interface{}) {
+	if level >= l.Level() {
+		msg := strings.TrimSuffix(fmt.Sprintln(v...), "\n")
+		l.writeJSON(level, msg, nil)
+	}
+}
+
+// Tracef writes a record with LevelTrace.
+func (l *subsystemLogger) Tracef(format string, params ...interface{}) {
+	if jsonLogs {
+		l.writef(btclog.LevelTrace, format, params)
+		return
+	}
+	l.Logger.Tracef(format, params...)
+}
+
+// Debugf writes a record with LevelDebug.
+func (l *subsystemLogger) Debugf(format string, params ...interface{}) {
+	if jsonLogs {
+		l.writef(btclog.LevelDebug, format, params)
+		return
+	}
+	l.Logger.Debugf(format, params...)
+}
+
+// Infof writes a record with LevelInfo.
+func (l *subsystemLogger) Infof(format string, params ...interface{}) {
+	if jsonLogs {
+		l.writef(btclog.LevelInfo, format, params)
+		return
+	}
+	l.Logger.Infof(format, params...)
+}
+
+// Warnf writes a record with LevelWarn.
+func (l *subsystemLogger) Warnf(format string, params ...interface{}) {
+	if jsonLogs {
+		l.writef(btclog.LevelWarn, format, params)
+		return
+	}
+	l.Logger.Warnf(format, params...)
+}
+
+// Errorf writes a record with LevelError.
+func (l *subsystemLogger) Errorf(format string, params ...interface{}) {
+	if jsonLogs {
+		l.writef(btclog.LevelError, format, params)
+		return
+	}
+	l.Logger.Errorf(format, params...)
+}
+
+// Criticalf writes a record with LevelCritical.
+func (l *subsystemLogger) Criticalf(format string, params ...interface{}) {
+	if jsonLogs {
+		l.writef(btclog.LevelCritical, format, params)
+		return
+	}
+	l.Logger.Criticalf(format, params...)
+}
+
+// Trace writes a record with LevelTrace.
+func (l *subsystemLogger) Trace(v ...interface{}) {
+	if jsonLogs {
+		l.write(btclog.LevelTrace, v)
+		return
+	}
+	l.Logger.Trace(v...)
+}
+
+// Debug writes a record with LevelDebug.
+func (l *subsystemLogger) Debug(v ...interface{}) {
+	if jsonLogs {
+		l.write(btclog.LevelDebug, v)
+		return
+	}
+	l.Logger.Debug(v...)
+}
+
+// Info writes a record with LevelInfo.
+func (l *subsystemLogger) Info(v ...interface{}) {
+	if jsonLogs {
+		l.write(btclog.LevelInfo, v)
+		return
+	}
+	l.Logger.Info(v...)
+}
+
+// Warn writes a record with LevelWarn.
+func (l *subsystemLogger) Warn(v ...interface{}) {
+	if jsonLogs {
+		l.write(btclog.LevelWarn, v)
+		return
+	}
+	l.Logger.Warn(v...)
+}
+
+// Error writes a record with LevelError.
+func (l *subsystemLogger) Error(v ...interface{}) {
+	if jsonLogs {
+		l.write(btclog.LevelError, v)
+		return
+	}
+	l.Logger.Error(v...)
+}
+
+// Critical writes a record with LevelCritical.
+func (l *subsystemLogger) Critical(v ...interface{}) {
+	if jsonLogs {
+		l.write(btclog.LevelCritical, v)
+		return
+	}
+	l.Logger.Critical(v...)
+}
+
+// logFields writes a record of the level to the logger with the message and
+// the fields, passed as pairs of keys and values.  The fields are those of the
+// JSON records, and follow the message as key=value pairs in the text records.
+// The JSON values are the strings, numbers and booleans as is, and the text of
+// the others.
+func logFields(log btclog.Logger, level btclog.Level, msg string,
+	keyvals ...interface{}) {
+
+	if level < log.Level() {
+		return
+	}
+
+	if l, ok := log.(*subsystemLogger); ok && jsonLogs {
+		fields := make(map[string]interface{}, len(keyvals)/2)
+		for i := 0; i+1 < len(keyvals); i += 2 {
+			value := keyvals[i+1]
+			switch value.(type) {
+			case string, bool, int, int32, int64, uint, uint32, uint64,
+				float64:
+			default:
+				value = fmt.Sprint(value)
+			}
+			fields[fmt.Sprint(keyvals[i])] = value
+		}
+		l.writeJSON(level, msg, fields)
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString(msg)
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		value := fmt.Sprint(keyvals[i+1])
+		if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&text, " %v=%s", keyvals[i], value)
+	}
+
+	switch level {
+	case btclog.LevelTrace:
+		log.Trace(text.String())
+	case btclog.LevelDebug:
+		log.Debug(text.String())
+	case btclog.LevelInfo:
+		log.Info(text.String())
+	case btclog.LevelWarn:
+		log.Warn(text.String())
+	case btclog.LevelError:
+		log.Error(text.String())
+	case btclog.LevelCritical:
+		log.Critical(text.String())
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btclog"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeJSONLogRecords returns the JSON records written to the buffer, along
+// with their times.
+func decodeJSONLogRecords(r *require.Assertions,
+	buf *bytes.Buffer) ([]jsonLogRecord, []time.Time) {
+
+	var records []jsonLogRecord
+	var times []time.Time
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var record jsonLogRecord
+		r.NoError(dec.Decode(&record))
+		t, err := time.Parse(time.RFC3339Nano, record.Time)
+		r.NoError(err)
+		record.Time = ""
+		records = append(records, record)
+		times = append(times, t)
+	}
+	return records, times
+}
+
+func TestLogBackendJSON(t *testing.T) {
+
+	r := require.New(t)
+
+	defer func(enabled bool) { jsonLogs = enabled }(jsonLogs)
+	jsonLogs = true
+
+	var buf bytes.Buffer
+	log := newLogBackend(&buf).Logger("RPCS")
+	log.SetLevel(btclog.LevelInfo)
+
+	start := time.Now().Add(-time.Second)
+	log.Warnf("Replacing tx (fee_rate=%d sat/kb)", 100)
+	log.Debugf("Filtered out")
+	log.Info("Version", 1)
+	log.Trace("Filtered out")
+	logFields(log, btclog.LevelWarn, "Slow command", "method", "getblock",
+		"duration", 1500*time.Millisecond, "height", 5)
+	logFields(log, btclog.LevelDebug, "Filtered out", "a", 1)
+
+	// The messages aren't parsed for fields, only those passed explicitly
+	// are reported.
+	records, times := decodeJSONLogRecords(r, &buf)
+	r.Equal([]jsonLogRecord{{
+		Level:     "warn",
+		Subsystem: "RPCS",
+		Message:   "Replacing tx (fee_rate=100 sat/kb)",
+	}, {
+		Level:     "info",
+		Subsystem: "RPCS",
+		Message:   "Version 1",
+	}, {
+		Level:     "warn",
+		Subsystem: "RPCS",
+		Message:   "Slow command",
+		Fields: map[string]interface{}{
+			"method":   "getblock",
+			"duration": "1.5s",
+			"height":   float64(5),
+		},
+	}}, records)
+	for _, ts := range times {
+		r.True(ts.After(start))
+	}
+}
+
+func TestLogBackendText(t *testing.T) {
+
+	r := require.New(t)
+
+	defer func(enabled bool) { jsonLogs = enabled }(jsonLogs)
+	jsonLogs = false
+
+	var buf bytes.Buffer
+	log := newLogBackend(&buf).Logger("RPCS")
+	log.SetLevel(btclog.LevelInfo)
+
+	log.Infof("Version %d", 1)
+	log.Debug("Filtered out")
+	logFields(log, btclog.LevelWarn, "Slow command", "method", "getblock",
+		"client", "a b", "empty", "", "duration", 1500*time.Millisecond)
+	logFields(log, btclog.LevelDebug, "Filtered out", "a", 1)
+
+	// The text records are those of btclog, with the fields after the
+	// message.
+	lines := bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte("\n")),
+		[]byte("\n"))
+	r.Len(lines, 2)
+	r.Regexp(`^\d{4}-\d\d-\d\d \d\d:\d\d:\d\d\.\d{3} \[INF\] RPCS: Version 1$`,
+		string(lines[0]))
+	r.Regexp(` \[WRN\] RPCS: Slow command method=getblock client="a b" `+
+		`empty="" duration=1.5s$`, string(lines[1]))
+}
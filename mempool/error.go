@@ -5,10 +5,32 @@
 package mempool
 
 import (
+	"errors"
+
 	"github.com/lbryio/lbcd/blockchain"
 	"github.com/lbryio/lbcd/wire"
 )
 
+var (
+	// ErrTxPolicyUnavailable is wrapped by the errors returned when the
+	// local policy of the node can't be consulted on a transaction.
+	ErrTxPolicyUnavailable = errors.New("local policy unavailable")
+
+	// ErrTxPolicyPending is wrapped by the errors returned when a
+	// transaction is processed before the local policy was consulted on
+	// it, which happens when the pool changed in the meantime.
+	ErrTxPolicyPending = errors.New("awaiting the verdict of the local " +
+		"policy")
+)
+
+// IsTxPolicyRetryable returns whether the error is one of a transaction on
+// which the local policy has no verdict yet.  The transaction isn't rejected,
+// and may be processed again later.
+func IsTxPolicyRetryable(err error) bool {
+	return errors.Is(err, ErrTxPolicyUnavailable) ||
+		errors.Is(err, ErrTxPolicyPending)
+}
+
 // RuleError identifies a rule violation.  It is used to indicate that
 // processing of a transaction failed due to one of the many validation
 // rules.  The caller can use type assertions to determine if a failure was
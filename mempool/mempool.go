@@ -6,6 +6,7 @@ package mempool
 
 import (
 	"container/list"
+	"errors"
 	"fmt"
 	"math"
 	"reflect"
@@ -110,6 +111,25 @@ type Config struct {
 	// whenever a transaction is removed from the mempool in order to track fee
 	// estimation.
 	RemoveTxFromFeeEstimation func(txHash *chainhash.Hash)
+
+	// CheckTxPolicy defines an optional function consulting the local
+	// policy of the node on a transaction, with the utxo view of its
+	// inputs, the height of the next block and its fee.  It returns an
+	// error describing why the policy rejects the transaction, or one
+	// wrapping ErrTxPolicyUnavailable when it can't be consulted.
+	//
+	// It may block, so it is never called with the pool locked: it is
+	// called before a transaction is processed, and its verdict is applied
+	// last before the transaction is accepted.  The orphans whose inputs
+	// become available are kept until it is called in the background.
+	CheckTxPolicy func(tx *btcutil.Tx, utxoView *blockchain.UtxoViewpoint,
+		nextBlockHeight int32, fee int64) error
+
+	// PolicyAcceptedTxs defines an optional function called with the
+	// orphans accepted once the local policy allowed them in the
+	// background, along with the orphans depending on them, so that they
+	// are relayed.
+	PolicyAcceptedTxs func(txns []*TxDesc)
 }
 
 // Policy houses the policy (configuration parameters) which is used to
@@ -155,6 +175,10 @@ type Policy struct {
 	// transactions using the Replace-By-Fee (RBF) signaling policy into
 	// the mempool.
 	RejectReplacement bool
+
+	// Standard defines the limits used to determine whether a transaction
+	// is standard.  It is not consulted when AcceptNonStd is true.
+	Standard mining.StandardPolicy
 }
 
 // aggregateInfo tracks aggregated serialized size, memory usage, and fees
@@ -238,6 +262,14 @@ type TxPool struct {
 
 	// unbroadcast is a set of transactions yet to be broadcast.
 	unbroadcast map[chainhash.Hash]bool
+
+	// policyVerdicts are the verdicts of the local policy on the
+	// transactions about to be processed, and policyPending the orphans on
+	// which it is consulted in the background.  They are protected by the
+	// policy lock.
+	policyMtx      sync.Mutex
+	policyVerdicts map[chainhash.Hash]error
+	policyPending  map[chainhash.Hash]struct{}
 }
 
 // Ensure the TxPool type implements the mining.TxSource interface.
@@ -1061,7 +1093,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 	if !mp.cfg.Policy.AcceptNonStd {
 		err = checkTransactionStandard(tx, nextBlockHeight,
 			medianTimePast, mp.cfg.Policy.MinRelayTxFee,
-			mp.cfg.Policy.MaxTxVersion)
+			mp.cfg.Policy.MaxTxVersion, &mp.cfg.Policy.Standard)
 		if err != nil {
 			// Attempt to extract a reject code from the error so
 			// it can be retained.  When not possible, fall back to
@@ -1166,7 +1198,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 	// Don't allow transactions with non-standard inputs if the network
 	// parameters forbid their acceptance.
 	if !mp.cfg.Policy.AcceptNonStd {
-		err := checkInputsStandard(tx, utxoView)
+		err := checkInputsStandard(tx, utxoView, &mp.cfg.Policy.Standard)
 		if err != nil {
 			// Attempt to extract a reject code from the error so
 			// it can be retained.  When not possible, fall back to
@@ -1286,6 +1318,21 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 		return nil, nil, err
 	}
 
+	// Consult the local policy once the transaction is otherwise valid,
+	// since it may be enforced by an external process.
+	if mp.cfg.CheckTxPolicy != nil {
+		found, err := mp.takeTxPolicyVerdict(txHash)
+		if !found {
+			return nil, nil, fmt.Errorf("transaction %v: %w", txHash,
+				ErrTxPolicyPending)
+		}
+		if err != nil {
+			str := fmt.Sprintf("transaction %v rejected by local "+
+				"policy: %v", txHash, err)
+			return nil, nil, txRuleError(wire.RejectNonstandard, str)
+		}
+	}
+
 	// Now that we've deemed the transaction as valid, we can add it to the
 	// mempool. If it ended up replacing any transactions, we'll remove them
 	// first.
@@ -1320,6 +1367,14 @@ func (mp *TxPool) maybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit, rejec
 //
 // This function is safe for concurrent access.
 func (mp *TxPool) MaybeAcceptTransaction(tx *btcutil.Tx, isNew, rateLimit bool) ([]*chainhash.Hash, *TxDesc, error) {
+	// Consult the local policy before locking the pool, since it may wait
+	// on an external process.
+	if mp.cfg.CheckTxPolicy != nil {
+		if err := mp.consultTxPolicy(tx); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// Protect concurrent access.
 	mp.mtx.Lock()
 	hashes, txD, err := mp.maybeAcceptTransaction(tx, isNew, rateLimit, true)
@@ -1366,6 +1421,12 @@ func (mp *TxPool) processOrphans(acceptedTx *btcutil.Tx) []*TxDesc {
 			for _, tx := range orphans {
 				missing, txD, err := mp.maybeAcceptTransaction(
 					tx, true, true, false)
+				if errors.Is(err, ErrTxPolicyPending) {
+					// The orphan is kept until the local
+					// policy is consulted on it.
+					mp.checkOrphanTxPolicy(tx)
+					continue
+				}
 				if err != nil {
 					// The orphan is now invalid, so there
 					// is no way any other orphans which
@@ -1443,6 +1504,14 @@ func (mp *TxPool) ProcessOrphans(acceptedTx *btcutil.Tx) []*TxDesc {
 func (mp *TxPool) ProcessTransaction(tx *btcutil.Tx, allowOrphan, rateLimit bool, tag Tag) ([]*TxDesc, error) {
 	log.Tracef("Processing transaction %v", tx.Hash())
 
+	// Consult the local policy before locking the pool, since it may wait
+	// on an external process.
+	if mp.cfg.CheckTxPolicy != nil {
+		if err := mp.consultTxPolicy(tx); err != nil {
+			return nil, err
+		}
+	}
+
 	// Protect concurrent access.
 	mp.mtx.Lock()
 	defer mp.mtx.Unlock()
@@ -1664,5 +1733,7 @@ func New(cfg *Config) *TxPool {
 		nextExpireScan: time.Now().Add(orphanExpireScanInterval),
 		outpoints:      make(map[wire.OutPoint]*btcutil.Tx),
 		unbroadcast:    make(map[chainhash.Hash]bool),
+		policyVerdicts: make(map[chainhash.Hash]error),
+		policyPending:  make(map[chainhash.Hash]struct{}),
 	}
 }
@@ -6,6 +6,8 @@ package mempool
 
 import (
 	"encoding/hex"
+	"errors"
+	"fmt"
 	"reflect"
 	"strings"
 	"sync"
@@ -16,6 +18,7 @@ import (
 	"github.com/lbryio/lbcd/btcec"
 	"github.com/lbryio/lbcd/chaincfg"
 	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/mining"
 	"github.com/lbryio/lbcd/txscript"
 	"github.com/lbryio/lbcd/wire"
 	btcutil "github.com/lbryio/lbcutil"
@@ -330,6 +333,7 @@ func newPoolHarness(chainParams *chaincfg.Params) (*poolHarness, []spendableOutp
 				MaxSigOpCostPerTx:    blockchain.MaxBlockSigOpsCost / 4,
 				MinRelayTxFee:        1000, // 1 Satoshi per byte
 				MaxTxVersion:         1,
+				Standard:             mining.DefaultStandardPolicy(),
 			},
 			ChainParams:      chainParams,
 			FetchUtxoView:    chain.FetchUtxoView,
@@ -1857,3 +1861,146 @@ func TestRBF(t *testing.T) {
 		}
 	}
 }
+
+// TestCheckTxPolicy ensures the transactions rejected by the local policy are
+// not accepted to the pool, that the policy is consulted with their context
+// without the pool locked, and that the errors are retryable when the policy
+// is unavailable.
+func TestCheckTxPolicy(t *testing.T) {
+	t.Parallel()
+
+	harness, outputs, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	parentTx, err := harness.CreateSignedTx(outputs, 2, 0, false)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	filteredTx, err := harness.CreateSignedTx([]spendableOutput{
+		txOutToSpendableOut(parentTx, 0),
+	}, 1, 0, false)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	unavailableTx, err := harness.CreateSignedTx([]spendableOutput{
+		txOutToSpendableOut(parentTx, 1),
+	}, 1, 0, false)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	chainedTxns := []*btcutil.Tx{parentTx, filteredTx}
+
+	var checked int
+	harness.txPool.cfg.CheckTxPolicy = func(tx *btcutil.Tx,
+		utxoView *blockchain.UtxoViewpoint, nextBlockHeight int32,
+		fee int64) error {
+
+		// The policy is consulted without the pool locked.
+		if harness.txPool.HaveTransaction(tx.Hash()) {
+			t.Fatalf("policy consulted on transaction %v in the pool",
+				tx.Hash())
+		}
+		if tx.Hash().IsEqual(unavailableTx.Hash()) {
+			return fmt.Errorf("%w: test", ErrTxPolicyUnavailable)
+		}
+		if nextBlockHeight != harness.chain.BestHeight()+1 {
+			t.Fatalf("unexpected next block height %d",
+				nextBlockHeight)
+		}
+		prevOut := tx.MsgTx().TxIn[0].PreviousOutPoint
+		if utxoView.LookupEntry(prevOut) == nil {
+			t.Fatalf("missing input %v in utxo view", prevOut)
+		}
+		checked++
+		if tx.Hash().IsEqual(chainedTxns[1].Hash()) {
+			return errors.New("filtered claim")
+		}
+		return nil
+	}
+
+	_, err = harness.txPool.ProcessTransaction(chainedTxns[0], true,
+		false, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: failed to accept tx: %v", err)
+	}
+	_, err = harness.txPool.ProcessTransaction(chainedTxns[1], true,
+		false, 0)
+	code, extracted := extractRejectCode(err)
+	if !extracted || code != wire.RejectNonstandard {
+		t.Fatalf("ProcessTransaction: unexpected error %v", err)
+	}
+	if !strings.Contains(err.Error(), "filtered claim") {
+		t.Fatalf("ProcessTransaction: error %q misses the reason", err)
+	}
+
+	// The transactions are not rejected when the policy is unavailable.
+	_, err = harness.txPool.ProcessTransaction(unavailableTx, true, false, 0)
+	if _, ok := err.(RuleError); ok || !IsTxPolicyRetryable(err) {
+		t.Fatalf("ProcessTransaction: unexpected error %v", err)
+	}
+
+	tc := &testContext{t, harness}
+	testPoolMembership(tc, chainedTxns[0], false, true)
+	testPoolMembership(tc, chainedTxns[1], false, false)
+	testPoolMembership(tc, unavailableTx, false, false)
+	if checked != 2 {
+		t.Fatalf("policy consulted %d times, want 2", checked)
+	}
+}
+
+// TestCheckOrphanTxPolicy ensures the orphans whose inputs become available
+// are accepted to the pool once the local policy is consulted on them in the
+// background.
+func TestCheckOrphanTxPolicy(t *testing.T) {
+	t.Parallel()
+
+	harness, outputs, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	chainedTxns, err := harness.CreateTxChain(outputs[0], 2)
+	if err != nil {
+		t.Fatalf("unable to create transaction chain: %v", err)
+	}
+
+	harness.txPool.cfg.CheckTxPolicy = func(tx *btcutil.Tx,
+		utxoView *blockchain.UtxoViewpoint, nextBlockHeight int32,
+		fee int64) error {
+
+		return nil
+	}
+	accepted := make(chan []*TxDesc, 1)
+	harness.txPool.cfg.PolicyAcceptedTxs = func(txns []*TxDesc) {
+		accepted <- txns
+	}
+
+	_, err = harness.txPool.ProcessTransaction(chainedTxns[1], true,
+		false, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: failed to accept orphan: %v", err)
+	}
+	acceptedTxns, err := harness.txPool.ProcessTransaction(chainedTxns[0],
+		true, false, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: failed to accept tx: %v", err)
+	}
+	if len(acceptedTxns) != 1 {
+		t.Fatalf("ProcessTransaction: accepted %d transactions, want 1",
+			len(acceptedTxns))
+	}
+
+	select {
+	case txns := <-accepted:
+		if len(txns) != 1 || !txns[0].Tx.Hash().IsEqual(chainedTxns[1].Hash()) {
+			t.Fatalf("unexpected transactions accepted by policy: %v",
+				txns)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("orphan not accepted after the policy verdict")
+	}
+
+	tc := &testContext{t, harness}
+	testPoolMembership(tc, chainedTxns[0], false, true)
+	testPoolMembership(tc, chainedTxns[1], false, true)
+}
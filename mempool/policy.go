@@ -10,50 +10,19 @@ import (
 	"time"
 
 	"github.com/lbryio/lbcd/blockchain"
+	"github.com/lbryio/lbcd/mining"
 	"github.com/lbryio/lbcd/txscript"
 	"github.com/lbryio/lbcd/wire"
 	btcutil "github.com/lbryio/lbcutil"
 )
 
 const (
-	// maxStandardP2SHSigOps is the maximum number of signature operations
-	// that are considered standard in a pay-to-script-hash script.
-	maxStandardP2SHSigOps = 15
-
-	// maxStandardTxCost is the max weight permitted by any transaction
-	// according to the current default policy.
-	maxStandardTxWeight = 400000
-
-	// maxStandardSigScriptSize is the maximum size allowed for a
-	// transaction input signature script to be considered standard.  This
-	// value allows for a 15-of-15 CHECKMULTISIG pay-to-script-hash with
-	// compressed keys.
-	//
-	// The form of the overall script is: OP_0 <15 signatures> OP_PUSHDATA2
-	// <2 bytes len> [OP_15 <15 pubkeys> OP_15 OP_CHECKMULTISIG]
-	//
-	// For the p2sh script portion, each of the 15 compressed pubkeys are
-	// 33 bytes (plus one for the OP_DATA_33 opcode), and the thus it totals
-	// to (15*34)+3 = 513 bytes.  Next, each of the 15 signatures is a max
-	// of 73 bytes (plus one for the OP_DATA_73 opcode).  Also, there is one
-	// extra byte for the initial extra OP_0 push and 3 bytes for the
-	// OP_PUSHDATA2 needed to specify the 513 bytes for the script push.
-	// That brings the total to 1+(15*74)+3+513 = 1627.  This value also
-	// adds a few extra bytes to provide a little buffer.
-	// (1 + 15*74 + 3) + (15*34 + 3) + 23 = 1650
-	maxStandardSigScriptSize = 1650
-
 	// DefaultMinRelayTxFee is the minimum fee in satoshi that is required
 	// for a transaction to be treated as free for relay and mining
 	// purposes.  It is also used to help determine if a transaction is
 	// considered dust and as a base for calculating minimum required fees
 	// for larger transactions.  This value is in Satoshi/1000 bytes.
 	DefaultMinRelayTxFee = btcutil.Amount(1000)
-
-	// maxStandardMultiSigKeys is the maximum number of public keys allowed
-	// in a multi-signature transaction output script for it to be
-	// considered standard.
-	maxStandardMultiSigKeys = 3
 )
 
 // calcMinRequiredTxRelayFee returns the minimum transaction fee required for a
@@ -83,14 +52,16 @@ func calcMinRequiredTxRelayFee(serializedSize int64, minRelayTxFee btcutil.Amoun
 // checkInputsStandard performs a series of checks on a transaction's inputs
 // to ensure they are "standard".  A standard transaction input within the
 // context of this function is one whose referenced public key script is of a
-// standard form and, for pay-to-script-hash, does not have more than
-// maxStandardP2SHSigOps signature operations.  However, it should also be noted
-// that standard inputs also are those which have a clean stack after execution
-// and only contain pushed data in their signature scripts.  This function does
-// not perform those checks because the script engine already does this more
-// accurately and concisely via the txscript.ScriptVerifyCleanStack and
-// txscript.ScriptVerifySigPushOnly flags.
-func checkInputsStandard(tx *btcutil.Tx, utxoView *blockchain.UtxoViewpoint) error {
+// standard form and, for pay-to-script-hash, does not have more than the
+// policy's MaxP2SHSigOps signature operations.  However, it should also be
+// noted that standard inputs also are those which have a clean stack after
+// execution and only contain pushed data in their signature scripts.  This
+// function does not perform those checks because the script engine already
+// does this more accurately and concisely via the
+// txscript.ScriptVerifyCleanStack and txscript.ScriptVerifySigPushOnly flags.
+func checkInputsStandard(tx *btcutil.Tx, utxoView *blockchain.UtxoViewpoint,
+	policy *mining.StandardPolicy) error {
+
 	// NOTE: The reference implementation also does a coinbase check here,
 	// but coinbases have already been rejected prior to calling this
 	// function so no need to recheck.
@@ -105,11 +76,11 @@ func checkInputsStandard(tx *btcutil.Tx, utxoView *blockchain.UtxoViewpoint) err
 		case txscript.ScriptHashTy:
 			numSigOps := txscript.GetPreciseSigOpCount(
 				txIn.SignatureScript, originPkScript, true)
-			if numSigOps > maxStandardP2SHSigOps {
+			if numSigOps > policy.MaxP2SHSigOps {
 				str := fmt.Sprintf("transaction input #%d has "+
 					"%d signature operations which is more "+
 					"than the allowed max amount of %d",
-					i, numSigOps, maxStandardP2SHSigOps)
+					i, numSigOps, policy.MaxP2SHSigOps)
 				return txRuleError(wire.RejectNonstandard, str)
 			}
 
@@ -126,9 +97,11 @@ func checkInputsStandard(tx *btcutil.Tx, utxoView *blockchain.UtxoViewpoint) err
 // checkPkScriptStandard performs a series of checks on a transaction output
 // script (public key script) to ensure it is a "standard" public key script.
 // A standard public key script is one that is a recognized form, and for
-// multi-signature scripts, only contains from 1 to maxStandardMultiSigKeys
-// public keys.
-func checkPkScriptStandard(pkScript []byte, scriptClass txscript.ScriptClass) error {
+// multi-signature scripts, only contains from 1 to the policy's
+// MaxMultiSigKeys public keys.
+func checkPkScriptStandard(pkScript []byte, scriptClass txscript.ScriptClass,
+	policy *mining.StandardPolicy) error {
+
 	switch scriptClass {
 	case txscript.MultiSigTy:
 		numPubKeys, numSigs, err := txscript.CalcMultiSigStats(pkScript)
@@ -139,15 +112,15 @@ func checkPkScriptStandard(pkScript []byte, scriptClass txscript.ScriptClass) er
 		}
 
 		// A standard multi-signature public key script must contain
-		// from 1 to maxStandardMultiSigKeys public keys.
+		// from 1 to MaxMultiSigKeys public keys.
 		if numPubKeys < 1 {
 			str := "multi-signature script with no pubkeys"
 			return txRuleError(wire.RejectNonstandard, str)
 		}
-		if numPubKeys > maxStandardMultiSigKeys {
+		if numPubKeys > policy.MaxMultiSigKeys {
 			str := fmt.Sprintf("multi-signature script with %d "+
 				"public keys which is more than the allowed "+
-				"max of %d", numPubKeys, maxStandardMultiSigKeys)
+				"max of %d", numPubKeys, policy.MaxMultiSigKeys)
 			return txRuleError(wire.RejectNonstandard, str)
 		}
 
@@ -287,10 +260,13 @@ func IsDust(txOut *wire.TxOut, minRelayTxFee btcutil.Amount) bool {
 // "sane" transaction such as having a version in the supported range, being
 // finalized, conforming to more stringent size constraints, having scripts
 // of recognized forms, and not containing "dust" outputs (those that are
-// so small it costs more to process them than they are worth).
+// so small it costs more to process them than they are worth).  The limits
+// applied are those of the passed standardness policy.  Dust is determined
+// using the policy's DustRelayFee, falling back to the minimum relay fee when
+// it is not set.
 func checkTransactionStandard(tx *btcutil.Tx, height int32,
 	medianTimePast time.Time, minRelayTxFee btcutil.Amount,
-	maxTxVersion int32) error {
+	maxTxVersion int32, policy *mining.StandardPolicy) error {
 
 	// The transaction must be a currently supported version.
 	msgTx := tx.MsgTx()
@@ -313,22 +289,23 @@ func checkTransactionStandard(tx *btcutil.Tx, height int32,
 	// size of a transaction.  This also helps mitigate CPU exhaustion
 	// attacks.
 	txWeight := blockchain.GetTransactionWeight(tx)
-	if txWeight > maxStandardTxWeight {
+	if txWeight > policy.MaxTxWeight {
 		str := fmt.Sprintf("weight of transaction %v is larger than max "+
-			"allowed weight of %v", txWeight, maxStandardTxWeight)
+			"allowed weight of %v", txWeight, policy.MaxTxWeight)
 		return txRuleError(wire.RejectNonstandard, str)
 	}
 
 	for i, txIn := range msgTx.TxIn {
 		// Each transaction input signature script must not exceed the
-		// maximum size allowed for a standard transaction.  See
-		// the comment on maxStandardSigScriptSize for more details.
+		// maximum size allowed for a standard transaction.  See the
+		// comment on mining.DefaultMaxStandardSigScriptSize for more
+		// details.
 		sigScriptLen := len(txIn.SignatureScript)
-		if sigScriptLen > maxStandardSigScriptSize {
+		if sigScriptLen > policy.MaxSigScriptSize {
 			str := fmt.Sprintf("transaction input %d: signature "+
 				"script size of %d bytes is large than max "+
 				"allowed size of %d bytes", i, sigScriptLen,
-				maxStandardSigScriptSize)
+				policy.MaxSigScriptSize)
 			return txRuleError(wire.RejectNonstandard, str)
 		}
 
@@ -341,13 +318,18 @@ func checkTransactionStandard(tx *btcutil.Tx, height int32,
 		}
 	}
 
+	dustRelayFee := policy.DustRelayFee
+	if dustRelayFee == 0 {
+		dustRelayFee = minRelayTxFee
+	}
+
 	// None of the output public key scripts can be a non-standard script or
 	// be "dust" (except when the script is a null data script).
 	numNullDataOutputs := 0
 	for i, txOut := range msgTx.TxOut {
 		pkScript := txscript.StripClaimScriptPrefix(txOut.PkScript)
 		scriptClass := txscript.GetScriptClass(pkScript)
-		err := checkPkScriptStandard(pkScript, scriptClass)
+		err := checkPkScriptStandard(pkScript, scriptClass, policy)
 		if err != nil {
 			// Attempt to extract a reject code from the error so
 			// it can be retained.  When not possible, fall back to
@@ -365,17 +347,19 @@ func checkTransactionStandard(tx *btcutil.Tx, height int32,
 		// "dust".
 		if scriptClass == txscript.NullDataTy {
 			numNullDataOutputs++
-		} else if IsDust(txOut, minRelayTxFee) {
+		} else if IsDust(txOut, dustRelayFee) {
 			str := fmt.Sprintf("transaction output %d: payment "+
 				"of %d is dust", i, txOut.Value)
 			return txRuleError(wire.RejectDust, str)
 		}
 	}
 
-	// A standard transaction must not have more than one output script that
-	// only carries data.
-	if numNullDataOutputs > 1 {
-		str := "more than one transaction output in a nulldata script"
+	// A standard transaction must not have more than the allowed number
+	// of output scripts that only carry data.
+	if numNullDataOutputs > policy.MaxNullDataOutputs {
+		str := fmt.Sprintf("%d transaction outputs in a nulldata "+
+			"script which is more than the allowed max of %d",
+			numNullDataOutputs, policy.MaxNullDataOutputs)
 		return txRuleError(wire.RejectNonstandard, str)
 	}
 
@@ -12,6 +12,7 @@ import (
 	"github.com/lbryio/lbcd/btcec"
 	"github.com/lbryio/lbcd/chaincfg"
 	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/mining"
 	"github.com/lbryio/lbcd/txscript"
 	"github.com/lbryio/lbcd/wire"
 	btcutil "github.com/lbryio/lbcutil"
@@ -41,13 +42,13 @@ func TestCalcMinRequiredTxRelayFee(t *testing.T) {
 		},
 		{
 			"max standard tx size with default minimum relay fee",
-			maxStandardTxWeight / 4,
+			mining.DefaultMaxStandardTxWeight / 4,
 			DefaultMinRelayTxFee,
 			100000,
 		},
 		{
 			"max standard tx size with max satoshi relay fee",
-			maxStandardTxWeight / 4,
+			mining.DefaultMaxStandardTxWeight / 4,
 			btcutil.MaxSatoshi / 100, // overflow on purpose
 			btcutil.MaxSatoshi,
 		},
@@ -193,7 +194,8 @@ func TestCheckPkScriptStandard(t *testing.T) {
 			continue
 		}
 		scriptClass := txscript.GetScriptClass(script)
-		got := checkPkScriptStandard(script, scriptClass)
+		policy := mining.DefaultStandardPolicy()
+		got := checkPkScriptStandard(script, scriptClass, &policy)
 		if (test.isStandard && got != nil) ||
 			(!test.isStandard && got == nil) {
 
@@ -360,7 +362,7 @@ func TestCheckTransactionStandard(t *testing.T) {
 				TxOut: []*wire.TxOut{{
 					Value: 0,
 					PkScript: bytes.Repeat([]byte{0x00},
-						(maxStandardTxWeight/4)+1),
+						(mining.DefaultMaxStandardTxWeight/4)+1),
 				}},
 				LockTime: 0,
 			},
@@ -375,7 +377,7 @@ func TestCheckTransactionStandard(t *testing.T) {
 				TxIn: []*wire.TxIn{{
 					PreviousOutPoint: dummyPrevOut,
 					SignatureScript: bytes.Repeat([]byte{0x00},
-						maxStandardSigScriptSize+1),
+						mining.DefaultMaxStandardSigScriptSize+1),
 					Sequence: wire.MaxTxInSequenceNum,
 				}},
 				TxOut:    []*wire.TxOut{&dummyTxOut},
@@ -467,10 +469,12 @@ func TestCheckTransactionStandard(t *testing.T) {
 	}
 
 	pastMedianTime := time.Now()
+	policy := mining.DefaultStandardPolicy()
 	for _, test := range tests {
 		// Ensure standardness is as expected.
 		err := checkTransactionStandard(btcutil.NewTx(&test.tx),
-			test.height, pastMedianTime, DefaultMinRelayTxFee, 1)
+			test.height, pastMedianTime, DefaultMinRelayTxFee, 1,
+			&policy)
 		if err == nil && test.isStandard {
 			// Test passes since function returned standard for a
 			// transaction which is intended to be standard.
@@ -510,3 +514,77 @@ func TestCheckTransactionStandard(t *testing.T) {
 		}
 	}
 }
+
+// TestCheckTransactionStandardPolicy ensures the limits of a custom
+// standardness policy are honored by checkTransactionStandard.
+func TestCheckTransactionStandardPolicy(t *testing.T) {
+	prevOutHash, err := chainhash.NewHashFromStr("01")
+	if err != nil {
+		t.Fatalf("NewShaHashFromStr: unexpected error: %v", err)
+	}
+	tx := btcutil.NewTx(&wire.MsgTx{
+		Version: 1,
+		TxIn: []*wire.TxIn{{
+			PreviousOutPoint: wire.OutPoint{Hash: *prevOutHash, Index: 1},
+			SignatureScript:  bytes.Repeat([]byte{0x00}, 65),
+			Sequence:         wire.MaxTxInSequenceNum,
+		}},
+		TxOut: []*wire.TxOut{{
+			Value:    0,
+			PkScript: []byte{txscript.OP_RETURN},
+		}, {
+			Value:    0,
+			PkScript: []byte{txscript.OP_RETURN},
+		}},
+		LockTime: 0,
+	})
+
+	tests := []struct {
+		name       string
+		modify     func(*mining.StandardPolicy)
+		isStandard bool
+	}{
+		{
+			name:       "default policy rejects two nulldata outputs",
+			modify:     func(p *mining.StandardPolicy) {},
+			isStandard: false,
+		},
+		{
+			name: "relaxed policy allows two nulldata outputs",
+			modify: func(p *mining.StandardPolicy) {
+				p.MaxNullDataOutputs = 2
+			},
+			isStandard: true,
+		},
+		{
+			name: "signature script larger than policy limit",
+			modify: func(p *mining.StandardPolicy) {
+				p.MaxNullDataOutputs = 2
+				p.MaxSigScriptSize = 64
+			},
+			isStandard: false,
+		},
+		{
+			name: "weight larger than policy limit",
+			modify: func(p *mining.StandardPolicy) {
+				p.MaxNullDataOutputs = 2
+				p.MaxTxWeight = 100
+			},
+			isStandard: false,
+		},
+	}
+
+	pastMedianTime := time.Now()
+	for _, test := range tests {
+		policy := mining.DefaultStandardPolicy()
+		test.modify(&policy)
+		err := checkTransactionStandard(tx, 300000, pastMedianTime,
+			DefaultMinRelayTxFee, 1, &policy)
+		if test.isStandard && err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		}
+		if !test.isStandard && err == nil {
+			t.Errorf("%s: standard when it should not be", test.name)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package mempool
+
+import (
+	"github.com/lbryio/lbcd/blockchain"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+const (
+	// maxPolicyVerdicts is the max number of verdicts of the local policy
+	// kept for the transactions about to be processed.
+	maxPolicyVerdicts = 1000
+
+	// maxPendingPolicyChecks is the max number of orphans on which the
+	// local policy is consulted in the background at once.
+	maxPendingPolicyChecks = 100
+)
+
+// txInputsFee returns the fee of the transaction from the outputs it spends in
+// the utxo view, or false when some of them are missing.
+func txInputsFee(tx *btcutil.Tx, utxoView *blockchain.UtxoViewpoint) (int64, bool) {
+	var fee int64
+	for _, txIn := range tx.MsgTx().TxIn {
+		entry := utxoView.LookupEntry(txIn.PreviousOutPoint)
+		if entry == nil || entry.IsSpent() {
+			return 0, false
+		}
+		fee += entry.Amount()
+	}
+	for _, txOut := range tx.MsgTx().TxOut {
+		fee -= txOut.Value
+	}
+	return fee, true
+}
+
+// consultTxPolicy obtains the verdict of the local policy on the transaction
+// before it is processed, which takes it with takeTxPolicyVerdict once the pool
+// is locked.  There is no verdict when the transaction is already in the pool
+// or some of the outputs it spends are unknown, since it doesn't reach the
+// policy then.  It returns an error wrapping ErrTxPolicyUnavailable when the
+// policy can't be consulted.
+//
+// This function MUST NOT be called with the mempool lock held, since the policy
+// may wait on an external process.
+func (mp *TxPool) consultTxPolicy(tx *btcutil.Tx) error {
+	txHash := tx.Hash()
+	mp.mtx.RLock()
+	if mp.isTransactionInPool(txHash) {
+		mp.mtx.RUnlock()
+		return nil
+	}
+	utxoView, err := mp.fetchInputUtxos(tx)
+	nextBlockHeight := mp.cfg.BestHeight() + 1
+	mp.mtx.RUnlock()
+	if err != nil {
+		// The error is reported when the transaction is processed.
+		return nil
+	}
+	fee, ok := txInputsFee(tx, utxoView)
+	if !ok {
+		return nil
+	}
+
+	verdict := mp.cfg.CheckTxPolicy(tx, utxoView, nextBlockHeight, fee)
+	if IsTxPolicyRetryable(verdict) {
+		return verdict
+	}
+
+	mp.policyMtx.Lock()
+	if len(mp.policyVerdicts) >= maxPolicyVerdicts {
+		// Evict an arbitrary verdict, of a transaction which was
+		// rejected before reaching the policy.
+		for hash := range mp.policyVerdicts {
+			delete(mp.policyVerdicts, hash)
+			break
+		}
+	}
+	mp.policyVerdicts[*txHash] = verdict
+	mp.policyMtx.Unlock()
+	return nil
+}
+
+// takeTxPolicyVerdict returns and forgets the verdict of the local policy on
+// the transaction obtained by consultTxPolicy, along with whether there is one.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) takeTxPolicyVerdict(txHash *chainhash.Hash) (bool, error) {
+	mp.policyMtx.Lock()
+	defer mp.policyMtx.Unlock()
+
+	verdict, ok := mp.policyVerdicts[*txHash]
+	delete(mp.policyVerdicts, *txHash)
+	return ok, verdict
+}
+
+// checkOrphanTxPolicy consults the local policy in the background on an orphan
+// whose inputs became available, and then accepts it to the pool when the
+// policy allows it, along with the orphans depending on it.  The transactions
+// accepted are passed to the PolicyAcceptedTxs function of the config.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) checkOrphanTxPolicy(tx *btcutil.Tx) {
+	txHash := *tx.Hash()
+	mp.policyMtx.Lock()
+	_, pending := mp.policyPending[txHash]
+	if pending || len(mp.policyPending) >= maxPendingPolicyChecks {
+		mp.policyMtx.Unlock()
+		return
+	}
+	mp.policyPending[txHash] = struct{}{}
+	mp.policyMtx.Unlock()
+
+	go func() {
+		defer func() {
+			mp.policyMtx.Lock()
+			delete(mp.policyPending, txHash)
+			mp.policyMtx.Unlock()
+		}()
+
+		if err := mp.consultTxPolicy(tx); err != nil {
+			log.Debugf("Unable to consult the local policy on orphan "+
+				"%v: %v", txHash, err)
+			return
+		}
+
+		mp.mtx.Lock()
+		var acceptedTxns []*TxDesc
+		if mp.isOrphanInPool(&txHash) {
+			missing, txD, err := mp.maybeAcceptTransaction(tx, true,
+				true, false)
+			switch {
+			case IsTxPolicyRetryable(err):
+				// The pool changed since the policy was
+				// consulted, so the orphan is kept until its
+				// inputs are available again.
+
+			case err != nil:
+				mp.removeOrphan(tx, true)
+
+			case len(missing) == 0:
+				mp.removeOrphan(tx, false)
+				acceptedTxns = append([]*TxDesc{txD},
+					mp.processOrphans(tx)...)
+			}
+		}
+		mp.mtx.Unlock()
+
+		if len(acceptedTxns) > 0 && mp.cfg.PolicyAcceptedTxs != nil {
+			mp.cfg.PolicyAcceptedTxs(acceptedTxns)
+		}
+	}()
+}
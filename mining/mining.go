@@ -504,6 +504,13 @@ func (g *BlkTmplGenerator) NewBlockTemplate(payToAddress btcutil.Address) (*Bloc
 	log.Debugf("Considering %d transactions for inclusion to new block",
 		len(sourceTxns))
 
+	// Consult the local policy on the candidate transactions.  The
+	// dependents of those it leaves out never become ready for inclusion.
+	var policyRejects map[chainhash.Hash]string
+	if g.policy.FilterTemplateTxs != nil {
+		policyRejects = g.policy.FilterTemplateTxs(sourceTxns, nextBlockHeight)
+	}
+
 mempoolLoop:
 	for _, txDesc := range sourceTxns {
 		// A block can't have more than one coinbase or contain
@@ -513,6 +520,11 @@ mempoolLoop:
 			log.Tracef("Skipping coinbase tx %s", tx.Hash())
 			continue
 		}
+		if reason, ok := policyRejects[*tx.Hash()]; ok {
+			log.Tracef("Skipping tx %s rejected by local policy: %s",
+				tx.Hash(), reason)
+			continue
+		}
 		if !blockchain.IsFinalizedTransaction(tx, nextBlockHeight,
 			g.timeSource.AdjustedTime()) {
 
@@ -675,6 +687,17 @@ mempoolLoop:
 			continue
 		}
 
+		// Enforce the size limits of the standardness policy when
+		// configured to do so.
+		if g.policy.EnforceStandard {
+			if err := checkTxStandardSize(tx, &g.policy.Standard); err != nil {
+				log.Tracef("Skipping tx %s because it is not "+
+					"standard: %v", tx.Hash(), err)
+				logSkippedDeps(tx, deps)
+				continue
+			}
+		}
+
 		// Enforce maximum signature operation cost per block.  Also
 		// check for overflow.
 		sigOpCost, err := blockchain.GetSigOpCost(tx, false,
@@ -817,7 +840,7 @@ mempoolLoop:
 
 	// Calculate the next expected block version based on the state of the
 	// rule change deployments.
-	nextBlockVersion, err := g.chain.CalcNextBlockVersion()
+	nextBlockVersion, err := g.NextBlockVersion()
 	if err != nil {
 		return nil, err
 	}
@@ -979,3 +1002,24 @@ func (g *BlkTmplGenerator) BestSnapshot() *blockchain.BestState {
 func (g *BlkTmplGenerator) TxSource() TxSource {
 	return g.txSource
 }
+
+// NextBlockVersion returns the version of the next block template, which is the
+// one expected by the chain based on the state of the rule change deployments
+// with the signaling of the policy applied.
+//
+// This function is safe for concurrent access.
+func (g *BlkTmplGenerator) NextBlockVersion() (int32, error) {
+	version, err := g.chain.CalcNextBlockVersion()
+	if err != nil || g.policy.Signaling == nil {
+		return version, err
+	}
+	return g.policy.Signaling.BlockVersion(version, g.chain.ThresholdState)
+}
+
+// Signaling returns the signaling of the rule change deployments of the policy,
+// which is nil when the templates signal as the chain expects.
+//
+// This function is safe for concurrent access.
+func (g *BlkTmplGenerator) Signaling() *VersionBitsSignaling {
+	return g.policy.Signaling
+}
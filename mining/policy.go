@@ -5,7 +5,10 @@
 package mining
 
 import (
+	"fmt"
+
 	"github.com/lbryio/lbcd/blockchain"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
 	"github.com/lbryio/lbcd/wire"
 	btcutil "github.com/lbryio/lbcutil"
 )
@@ -15,8 +18,87 @@ const (
 	// contextual transaction information provided in a transaction store
 	// when it has not yet been mined into a block.
 	UnminedHeight = 0x7fffffff
+
+	// DefaultMaxStandardP2SHSigOps is the default maximum number of
+	// signature operations that are considered standard in a
+	// pay-to-script-hash script.
+	DefaultMaxStandardP2SHSigOps = 15
+
+	// DefaultMaxStandardTxWeight is the default max weight permitted by any
+	// transaction according to the standardness policy.
+	DefaultMaxStandardTxWeight = 400000
+
+	// DefaultMaxStandardSigScriptSize is the default maximum size allowed
+	// for a transaction input signature script to be considered standard.
+	// This value allows for a 15-of-15 CHECKMULTISIG pay-to-script-hash
+	// with compressed keys.
+	//
+	// The form of the overall script is: OP_0 <15 signatures> OP_PUSHDATA2
+	// <2 bytes len> [OP_15 <15 pubkeys> OP_15 OP_CHECKMULTISIG]
+	//
+	// For the p2sh script portion, each of the 15 compressed pubkeys are
+	// 33 bytes (plus one for the OP_DATA_33 opcode), and the thus it totals
+	// to (15*34)+3 = 513 bytes.  Next, each of the 15 signatures is a max
+	// of 73 bytes (plus one for the OP_DATA_73 opcode).  Also, there is one
+	// extra byte for the initial extra OP_0 push and 3 bytes for the
+	// OP_PUSHDATA2 needed to specify the 513 bytes for the script push.
+	// That brings the total to 1+(15*74)+3+513 = 1627.  This value also
+	// adds a few extra bytes to provide a little buffer.
+	// (1 + 15*74 + 3) + (15*34 + 3) + 23 = 1650
+	DefaultMaxStandardSigScriptSize = 1650
+
+	// DefaultMaxStandardMultiSigKeys is the default maximum number of
+	// public keys allowed in a multi-signature transaction output script
+	// for it to be considered standard.
+	DefaultMaxStandardMultiSigKeys = 3
+
+	// DefaultMaxStandardNullDataOutputs is the default maximum number of
+	// outputs in a standard transaction which only carry data.
+	DefaultMaxStandardNullDataOutputs = 1
 )
 
+// StandardPolicy houses the parameters which define what is considered a
+// "standard" transaction.  It is shared by the memory pool, which uses it to
+// decide which transactions to accept and relay, and the block template
+// generator, which uses it to decide which transactions to mine.
+type StandardPolicy struct {
+	// MaxTxWeight is the maximum weight of a standard transaction.
+	MaxTxWeight int64
+
+	// MaxSigScriptSize is the maximum size in bytes of a standard
+	// transaction input signature script.
+	MaxSigScriptSize int
+
+	// MaxP2SHSigOps is the maximum number of signature operations in a
+	// standard pay-to-script-hash redemption.
+	MaxP2SHSigOps int
+
+	// MaxMultiSigKeys is the maximum number of public keys in a standard
+	// multi-signature output script.
+	MaxMultiSigKeys int
+
+	// MaxNullDataOutputs is the maximum number of outputs which only carry
+	// data that a standard transaction may contain.
+	MaxNullDataOutputs int
+
+	// DustRelayFee is the fee rate in Satoshi/1000 bytes used to determine
+	// whether an output is dust.  When zero, the minimum relay fee is used
+	// instead.
+	DustRelayFee btcutil.Amount
+}
+
+// DefaultStandardPolicy returns the standardness policy used when no
+// overrides have been configured.
+func DefaultStandardPolicy() StandardPolicy {
+	return StandardPolicy{
+		MaxTxWeight:        DefaultMaxStandardTxWeight,
+		MaxSigScriptSize:   DefaultMaxStandardSigScriptSize,
+		MaxP2SHSigOps:      DefaultMaxStandardP2SHSigOps,
+		MaxMultiSigKeys:    DefaultMaxStandardMultiSigKeys,
+		MaxNullDataOutputs: DefaultMaxStandardNullDataOutputs,
+	}
+}
+
 // Policy houses the policy (configuration parameters) which is used to control
 // the generation of block templates.  See the documentation for
 // NewBlockTemplate for more details on each of these parameters are used.
@@ -45,6 +127,49 @@ type Policy struct {
 	// required for a transaction to be treated as free for mining purposes
 	// (block template generation).
 	TxMinFreeFee btcutil.Amount
+
+	// Standard is the standardness policy enforced on transactions
+	// selected for inclusion in a block template.  It is only consulted
+	// when EnforceStandard is true.
+	Standard StandardPolicy
+
+	// EnforceStandard defines whether transactions which exceed the size
+	// limits of the standardness policy are skipped when generating a
+	// block template.
+	EnforceStandard bool
+
+	// Signaling overrides which of the rule change deployments the block
+	// templates signal for in their version when it is not nil.
+	Signaling *VersionBitsSignaling
+
+	// FilterTemplateTxs defines an optional function consulted with the
+	// candidate transactions of a block template and the height of the
+	// block.  It returns the reasons the local policy of the node leaves
+	// transactions out of the template, by transaction hash.  The
+	// transactions depending on those left out are left out as well.
+	FilterTemplateTxs func(txns []*TxDesc, height int32) map[chainhash.Hash]string
+}
+
+// checkTxStandardSize returns an error when the passed transaction exceeds the
+// weight or signature script size limits of the provided standardness policy.
+func checkTxStandardSize(tx *btcutil.Tx, policy *StandardPolicy) error {
+	txWeight := blockchain.GetTransactionWeight(tx)
+	if txWeight > policy.MaxTxWeight {
+		return fmt.Errorf("weight of transaction %v is larger than "+
+			"max allowed weight of %v", txWeight, policy.MaxTxWeight)
+	}
+
+	for i, txIn := range tx.MsgTx().TxIn {
+		sigScriptLen := len(txIn.SignatureScript)
+		if sigScriptLen > policy.MaxSigScriptSize {
+			return fmt.Errorf("transaction input %d: signature "+
+				"script size of %d bytes is larger than max "+
+				"allowed size of %d bytes", i, sigScriptLen,
+				policy.MaxSigScriptSize)
+		}
+	}
+
+	return nil
 }
 
 // minInt is a helper function to return the minimum of two ints.  This avoids
@@ -0,0 +1,228 @@
+package mining
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbcd/blockchain"
+	"github.com/lbryio/lbcd/chaincfg"
+)
+
+const (
+	// vbTopBits defines the bits to set in the version to signal that the
+	// version bits scheme is being used.
+	vbTopBits = 0x20000000
+
+	// vbTopMask is the bitmask to use to determine whether or not the
+	// version bits scheme is in use.
+	vbTopMask = 0xe0000000
+
+	// vbNumBits is the total number of bits available for use with the
+	// version bits scheme.
+	vbNumBits = 29
+)
+
+// deploymentNames are the names of the rule change deployments, indexed by
+// their deployment IDs.
+var deploymentNames = [chaincfg.DefinedDeployments]string{
+	chaincfg.DeploymentTestDummy: "dummy",
+	chaincfg.DeploymentCSV:       "csv",
+	chaincfg.DeploymentSegwit:    "segwit",
+	chaincfg.DeploymentTaproot:   "taproot",
+}
+
+// DeploymentName returns the name of the rule change deployment.
+func DeploymentName(deploymentID uint32) string {
+	if deploymentID >= chaincfg.DefinedDeployments {
+		return fmt.Sprintf("unknown(%d)", deploymentID)
+	}
+	return deploymentNames[deploymentID]
+}
+
+// DeploymentByName returns the ID of the rule change deployment with the name.
+func DeploymentByName(name string) (uint32, error) {
+	for id, deploymentName := range deploymentNames {
+		if strings.EqualFold(name, deploymentName) {
+			return uint32(id), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown deployment %q -- valid deployments "+
+		"are %s", name, strings.Join(deploymentNames[:], ", "))
+}
+
+// VersionSignals returns whether the block version is one of the version bits
+// scheme with the bit set.
+func VersionSignals(version int32, bit uint8) bool {
+	v := uint32(version)
+	return v&vbTopMask == vbTopBits && bit < vbNumBits && v&(1<<bit) != 0
+}
+
+// Signal describes whether the block templates signal for a rule change
+// deployment.
+type Signal int
+
+const (
+	// SignalDefault signals for the deployment while it is started or
+	// locked in, as the chain expects.
+	SignalDefault Signal = iota
+
+	// SignalAlways signals for the deployment while it is started or
+	// locked in, even when the chain doesn't expect it.
+	SignalAlways
+
+	// SignalNever doesn't signal for the deployment.
+	SignalNever
+)
+
+// signalStrings are the strings the signals are referred to by.
+var signalStrings = [...]string{
+	SignalDefault: "default",
+	SignalAlways:  "signal",
+	SignalNever:   "nosignal",
+}
+
+// String returns the Signal as a human-readable string.
+func (s Signal) String() string {
+	if s < 0 || int(s) >= len(signalStrings) {
+		return fmt.Sprintf("Unknown Signal (%d)", int(s))
+	}
+	return signalStrings[s]
+}
+
+// ParseSignal returns the Signal referred to by the string.
+func ParseSignal(str string) (Signal, error) {
+	for s, signalString := range signalStrings {
+		if strings.EqualFold(str, signalString) {
+			return Signal(s), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown signal %q -- valid signals are %s",
+		str, strings.Join(signalStrings[:], ", "))
+}
+
+// VersionBitsSignaling overrides which of the rule change deployments of the
+// chain the block templates signal for in their version, so that the miners
+// can coordinate the activation of a deployment.
+type VersionBitsSignaling struct {
+	params *chaincfg.Params
+
+	mtx     sync.RWMutex
+	signals [chaincfg.DefinedDeployments]Signal
+}
+
+// NewVersionBitsSignaling returns a VersionBitsSignaling of the chain which
+// signals for the deployments as the chain expects until told otherwise.
+func NewVersionBitsSignaling(params *chaincfg.Params) *VersionBitsSignaling {
+	return &VersionBitsSignaling{params: params}
+}
+
+// Signal returns the Signal of the deployment.
+//
+// This function is safe for concurrent access.
+func (s *VersionBitsSignaling) Signal(deploymentID uint32) Signal {
+	if deploymentID >= chaincfg.DefinedDeployments {
+		return SignalDefault
+	}
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	return s.signals[deploymentID]
+}
+
+// SetSignal sets the Signal of the deployment.  Signaling can only be
+// overridden for the deployments which are activated by signaling, using a bit
+// of the version bits scheme of their own, and which haven't expired.
+//
+// This function is safe for concurrent access.
+func (s *VersionBitsSignaling) SetSignal(deploymentID uint32, signal Signal) error {
+	if deploymentID >= chaincfg.DefinedDeployments {
+		return fmt.Errorf("deployment ID %d does not exist",
+			deploymentID)
+	}
+	if signal < SignalDefault || signal > SignalNever {
+		return fmt.Errorf("unknown signal %d", int(signal))
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if signal != SignalDefault {
+		name := deploymentNames[deploymentID]
+		deployment := &s.params.Deployments[deploymentID]
+		if deployment.ForceActiveAt > 0 {
+			return fmt.Errorf("deployment %s is activated at height "+
+				"%d regardless of signaling", name,
+				deployment.ForceActiveAt)
+		}
+		if deployment.BitNumber >= vbNumBits {
+			return fmt.Errorf("deployment %s uses bit %d, which is "+
+				"not a bit of the version bits scheme", name,
+				deployment.BitNumber)
+		}
+		if deployment.ExpireTime <= uint64(time.Now().Unix()) {
+			return fmt.Errorf("deployment %s has expired", name)
+		}
+
+		// The deployments sharing the bit must be signaled alike.
+		for id, other := range s.params.Deployments {
+			if uint32(id) == deploymentID || s.signals[id] == SignalDefault ||
+				other.BitNumber != deployment.BitNumber ||
+				s.signals[id] == signal {
+
+				continue
+			}
+			return fmt.Errorf("deployment %s shares bit %d with "+
+				"deployment %s, which is set to %v", name,
+				deployment.BitNumber, deploymentNames[id],
+				s.signals[id])
+		}
+	}
+
+	s.signals[deploymentID] = signal
+	return nil
+}
+
+// BlockVersion returns the version of the next block after applying the
+// signals to the version expected by the chain, as returned by
+// CalcNextBlockVersion.  The deployments are only signaled for while their
+// threshold state for the next block, as returned by thresholdState, is
+// started or locked in.
+//
+// This function is safe for concurrent access.
+func (s *VersionBitsSignaling) BlockVersion(version int32,
+	thresholdState func(deploymentID uint32) (blockchain.ThresholdState, error)) (int32, error) {
+
+	v := uint32(version)
+	if v&vbTopMask != vbTopBits {
+		return version, nil
+	}
+
+	s.mtx.RLock()
+	signals := s.signals
+	s.mtx.RUnlock()
+
+	for id, signal := range signals {
+		if signal == SignalDefault {
+			continue
+		}
+		state, err := thresholdState(uint32(id))
+		if err != nil {
+			return 0, err
+		}
+		if state != blockchain.ThresholdStarted &&
+			state != blockchain.ThresholdLockedIn {
+
+			continue
+		}
+
+		mask := uint32(1) << s.params.Deployments[id].BitNumber
+		if signal == SignalAlways {
+			v |= mask
+		} else {
+			v &^= mask
+		}
+	}
+	return int32(v), nil
+}
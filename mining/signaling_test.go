@@ -0,0 +1,124 @@
+package mining
+
+import (
+	"math"
+	"testing"
+
+	"github.com/lbryio/lbcd/blockchain"
+	"github.com/lbryio/lbcd/chaincfg"
+)
+
+// TestVersionBitsSignaling ensures the signals of the deployments are checked
+// against the chain parameters and applied to the block versions while the
+// deployments are voted on.
+func TestVersionBitsSignaling(t *testing.T) {
+	t.Parallel()
+
+	params := chaincfg.RegressionNetParams
+	params.Deployments[chaincfg.DeploymentTaproot] = chaincfg.ConsensusDeployment{
+		BitNumber:  28,
+		ExpireTime: math.MaxInt64,
+	}
+	s := NewVersionBitsSignaling(&params)
+
+	// Only the deployments activated by signaling can be signaled for.
+	tests := []struct {
+		name       string
+		deployment uint32
+		signal     Signal
+		valid      bool
+	}{
+		{"dummy signal", chaincfg.DeploymentTestDummy, SignalAlways, true},
+		{"csv forced active", chaincfg.DeploymentCSV, SignalNever, false},
+		{"csv default", chaincfg.DeploymentCSV, SignalDefault, true},
+		{"taproot shared bit", chaincfg.DeploymentTaproot, SignalNever, false},
+		{"taproot same signal", chaincfg.DeploymentTaproot, SignalAlways, true},
+		{"unknown deployment", chaincfg.DefinedDeployments, SignalAlways, false},
+		{"unknown signal", chaincfg.DeploymentTestDummy, Signal(7), false},
+	}
+	for _, test := range tests {
+		err := s.SetSignal(test.deployment, test.signal)
+		if (err == nil) != test.valid {
+			t.Fatalf("%s: SetSignal: unexpected error: %v", test.name, err)
+		}
+	}
+	if signal := s.Signal(chaincfg.DeploymentTestDummy); signal != SignalAlways {
+		t.Fatalf("Signal: got %v, want %v", signal, SignalAlways)
+	}
+
+	mainNet := NewVersionBitsSignaling(&chaincfg.MainNetParams)
+	err := mainNet.SetSignal(chaincfg.DeploymentTestDummy, SignalAlways)
+	if err == nil {
+		t.Fatalf("SetSignal: expected error for expired deployment")
+	}
+
+	// The signals only apply while the deployments are started or locked
+	// in, and to the versions of the version bits scheme.
+	states := map[uint32]blockchain.ThresholdState{
+		chaincfg.DeploymentTestDummy: blockchain.ThresholdStarted,
+		chaincfg.DeploymentTaproot:   blockchain.ThresholdActive,
+	}
+	thresholdState := func(id uint32) (blockchain.ThresholdState, error) {
+		return states[id], nil
+	}
+	version, err := s.BlockVersion(vbTopBits, thresholdState)
+	if err != nil {
+		t.Fatalf("BlockVersion: unexpected error: %v", err)
+	}
+	if want := int32(vbTopBits | 1<<28); version != want {
+		t.Fatalf("BlockVersion: got %#x, want %#x", version, want)
+	}
+
+	if err := s.SetSignal(chaincfg.DeploymentTaproot, SignalDefault); err != nil {
+		t.Fatalf("SetSignal: unexpected error: %v", err)
+	}
+	if err := s.SetSignal(chaincfg.DeploymentTestDummy, SignalNever); err != nil {
+		t.Fatalf("SetSignal: unexpected error: %v", err)
+	}
+	states[chaincfg.DeploymentTestDummy] = blockchain.ThresholdLockedIn
+	version, err = s.BlockVersion(vbTopBits|1<<28, thresholdState)
+	if err != nil {
+		t.Fatalf("BlockVersion: unexpected error: %v", err)
+	}
+	if version != vbTopBits {
+		t.Fatalf("BlockVersion: got %#x, want %#x", version, vbTopBits)
+	}
+	if VersionSignals(version, 28) || !VersionSignals(vbTopBits|1<<28, 28) ||
+		VersionSignals(int32(uint32(1)<<28), 28) {
+
+		t.Fatalf("VersionSignals: unexpected signaling")
+	}
+	version, err = s.BlockVersion(4, thresholdState)
+	if err != nil {
+		t.Fatalf("BlockVersion: unexpected error: %v", err)
+	}
+	if version != 4 {
+		t.Fatalf("BlockVersion: got %#x, want 4", version)
+	}
+}
+
+// TestParseSignal ensures the signals and deployments are looked up by name.
+func TestParseSignal(t *testing.T) {
+	t.Parallel()
+
+	for _, signal := range []Signal{SignalDefault, SignalAlways, SignalNever} {
+		parsed, err := ParseSignal(signal.String())
+		if err != nil || parsed != signal {
+			t.Fatalf("ParseSignal(%q): got %v, %v", signal, parsed, err)
+		}
+	}
+	if _, err := ParseSignal("yes"); err == nil {
+		t.Fatalf("ParseSignal: expected error for unknown signal")
+	}
+
+	id, err := DeploymentByName("SegWit")
+	if err != nil || id != chaincfg.DeploymentSegwit {
+		t.Fatalf("DeploymentByName: got %d, %v", id, err)
+	}
+	if name := DeploymentName(id); name != "segwit" {
+		t.Fatalf("DeploymentName: got %q, want segwit", name)
+	}
+	if _, err := DeploymentByName("bip9"); err == nil {
+		t.Fatalf("DeploymentByName: expected error for unknown deployment")
+	}
+}
@@ -6,6 +6,7 @@ package netsync
 
 import (
 	"container/list"
+	"errors"
 	"math/rand"
 	"net"
 	"sync"
@@ -19,6 +20,7 @@ import (
 	"github.com/lbryio/lbcd/fees"
 	"github.com/lbryio/lbcd/mempool"
 	peerpkg "github.com/lbryio/lbcd/peer"
+	"github.com/lbryio/lbcd/tracing"
 	"github.com/lbryio/lbcd/wire"
 	btcutil "github.com/lbryio/lbcutil"
 )
@@ -48,6 +50,15 @@ const (
 	// stallSampleInterval the interval at which we will check to see if our
 	// sync has stalled.
 	stallSampleInterval = 30 * time.Second
+
+	// maxGetDataBlocks is the maximum number of blocks requested from the
+	// sync peer by a single getdata message.
+	maxGetDataBlocks = wire.MaxInvPerMsg / 99
+
+	// missingBlockTimeout is the time after which a block missing from
+	// the database is requested again from another peer when the one it
+	// was requested from hasn't sent it.
+	missingBlockTimeout = 2 * time.Minute
 )
 
 // zeroHash is the zero value hash (all zeros).  It is defined as a convenience.
@@ -155,6 +166,16 @@ type peerSyncState struct {
 	requestedBlocks map[chainhash.Hash]struct{}
 }
 
+// missingBlockRequest is a request of a block of the main chain whose data is
+// missing from the database.  The peer which last failed to send the block,
+// either by replying it has not found it, by timing out or by sending an
+// invalid block, is not asked for it again while other peers can be.
+type missingBlockRequest struct {
+	peer       *peerpkg.Peer
+	time       time.Time
+	failedPeer *peerpkg.Peer
+}
+
 // limitAdd is a helper function for maps that require a maximum limit by
 // evicting a random value if adding the new value would cause it to
 // overflow the maximum allowed.
@@ -195,6 +216,8 @@ type SyncManager struct {
 	rejectedTxns     map[chainhash.Hash]struct{}
 	requestedTxns    map[chainhash.Hash]struct{}
 	requestedBlocks  map[chainhash.Hash]struct{}
+	blockRequests    map[chainhash.Hash]time.Time
+	missingBlocks    map[chainhash.Hash]*missingBlockRequest
 	syncPeer         *peerpkg.Peer
 	peerStates       map[*peerpkg.Peer]*peerSyncState
 	lastProgressTime time.Time
@@ -209,6 +232,37 @@ type SyncManager struct {
 	feeEstimator *fees.Estimator
 }
 
+// traceBlockRequest records the time a block was requested at, which the trace
+// of its processing starts from.
+func (sm *SyncManager) traceBlockRequest(hash *chainhash.Hash) {
+	if !tracing.Enabled() {
+		return
+	}
+	// The requests of blocks which are never received are forgotten past
+	// the max number of requested blocks.
+	if len(sm.blockRequests) >= maxRequestedBlocks {
+		sm.blockRequests = make(map[chainhash.Hash]time.Time)
+	}
+	sm.blockRequests[*hash] = time.Now()
+}
+
+// traceBlockReceived starts the trace of the processing of a block received
+// from the peer, with a span of its download when it was requested.
+func (sm *SyncManager) traceBlockReceived(hash *chainhash.Hash, peer *peerpkg.Peer) *tracing.Span {
+	requested, ok := sm.blockRequests[*hash]
+	delete(sm.blockRequests, *hash)
+	if !ok {
+		requested = time.Now()
+	}
+
+	span := tracing.StartBlockSpan(hash, "block", requested)
+	span.SetAttribute("peer", peer.Addr())
+	if ok {
+		span.ChildAt("download", requested).End()
+	}
+	return span
+}
+
 // resetHeaderState sets the headers-first mode state to values appropriate for
 // syncing from a new peer.
 func (sm *SyncManager) resetHeaderState(newestHash *chainhash.Hash, newestHeight int32) {
@@ -376,6 +430,8 @@ func (sm *SyncManager) startSync() {
 		// syncPeer to avoid instantly detecting it as stalled in the
 		// event the progress time hasn't been updated recently.
 		sm.lastProgressTime = time.Now()
+
+		sm.fetchMissingBlocks()
 	} else {
 		log.Warnf("No sync peer candidates available")
 	}
@@ -452,11 +508,20 @@ func (sm *SyncManager) handleStallSample() {
 		return
 	}
 
+	sm.expireMissingBlockRequests()
+
 	// If we don't have an active sync peer, exit early.
 	if sm.syncPeer == nil {
 		return
 	}
 
+	// The sync peer isn't stalling while the acceptance of blocks is
+	// halted, so the stall timeout only starts once it resumes.
+	if sm.chain.AcceptanceHalted() != "" {
+		sm.lastProgressTime = time.Now()
+		return
+	}
+
 	// If the stall timeout has not elapsed, exit early.
 	if time.Since(sm.lastProgressTime) <= maxStallDuration {
 		return
@@ -519,6 +584,10 @@ func (sm *SyncManager) handleDonePeerMsg(peer *peerpkg.Peer) {
 		// peer before signaling to the sync manager.
 		sm.updateSyncPeer(false)
 	}
+
+	// Request the blocks missing from the database which were requested
+	// from the peer from the other ones.
+	sm.fetchMissingBlocks()
 }
 
 // clearRequestedState wipes all expected transactions and blocks from the sync
@@ -537,6 +606,9 @@ func (sm *SyncManager) clearRequestedState(state *peerSyncState) {
 	// and request them now to speed things up a little.
 	for blockHash := range state.requestedBlocks {
 		delete(sm.requestedBlocks, blockHash)
+		if request, exists := sm.missingBlocks[blockHash]; exists {
+			request.peer = nil
+		}
 	}
 }
 
@@ -603,6 +675,14 @@ func (sm *SyncManager) handleTxMsg(tmsg *txMsg) {
 	delete(state.requestedTxns, *txHash)
 	delete(sm.requestedTxns, *txHash)
 
+	// The transactions on which the local policy has no verdict yet aren't
+	// rejected, so they are requested again when announced.
+	if mempool.IsTxPolicyRetryable(err) {
+		log.Debugf("Deferred transaction %v from %s: %v", txHash, peer,
+			err)
+		return
+	}
+
 	if err != nil {
 		// Do not request this transaction again until a new block
 		// has been processed.
@@ -706,9 +786,45 @@ func (sm *SyncManager) handleBlockMsg(bmsg *blockMsg) {
 	delete(state.requestedBlocks, *blockHash)
 	delete(sm.requestedBlocks, *blockHash)
 
+	// Restore the data of the block when it was missing from the database,
+	// and fetch the next missing ones.
+	restored, err := sm.chain.RestoreBlock(bmsg.block)
+	if err != nil {
+		log.Warnf("Failed to restore block %v from %s: %v", blockHash,
+			peer, err)
+		if _, ok := err.(blockchain.RuleError); ok {
+			code, reason := mempool.ErrToRejectErr(err)
+			peer.PushRejectMsg(wire.CmdBlock, code, reason,
+				blockHash, false)
+		}
+		sm.failMissingBlockRequest(*blockHash, peer)
+		sm.fetchMissingBlocks()
+		return
+	}
+	if restored {
+		delete(sm.missingBlocks, *blockHash)
+		sm.fetchMissingBlocks()
+		return
+	}
+
+	// Trace the download of the block since it was requested, and its
+	// processing.
+	span := sm.traceBlockReceived(blockHash, peer)
+	defer span.End()
+	processSpan := span.Child("process")
+
 	// Process the block to include validation, best chain selection, orphan
 	// handling, etc.
 	_, isOrphan, err := sm.chain.ProcessBlock(bmsg.block, behaviorFlags)
+	processSpan.SetAttribute("orphan", isOrphan)
+	processSpan.SetError(err)
+	processSpan.End()
+	if errors.Is(err, blockchain.ErrAcceptanceHalted) {
+		// The peer isn't told the block was rejected, as the sync
+		// restarts from the stall handler once the acceptance resumes.
+		log.Debugf("Ignoring block %v from %s: %v", blockHash, peer, err)
+		return
+	}
 	if err != nil {
 		// When the error is a rule error, it means the block was simply
 		// rejected as opposed to something actually going wrong, so log
@@ -891,6 +1007,7 @@ func (sm *SyncManager) fetchHeaderBlocks() {
 
 			sm.requestedBlocks[*node.hash] = struct{}{}
 			syncPeerState.requestedBlocks[*node.hash] = struct{}{}
+			sm.traceBlockRequest(node.hash)
 
 			// If we're fetching from a witness enabled peer
 			// post-fork, then ensure that we receive all the
@@ -903,7 +1020,7 @@ func (sm *SyncManager) fetchHeaderBlocks() {
 			numRequested++
 		}
 		sm.startHeader = e.Next()
-		if numRequested >= wire.MaxInvPerMsg/99 {
+		if numRequested >= maxGetDataBlocks {
 			break
 		}
 	}
@@ -912,6 +1029,131 @@ func (sm *SyncManager) fetchHeaderBlocks() {
 	}
 }
 
+// fetchMissingBlocks requests the blocks of the main chain whose data is
+// missing from the database, such as after a repair of its block files, while
+// less than minInFlightBlocks of them are in flight.  They are requested from
+// the sync peer, which has them since it is at least at the height of the best
+// chain, unless it failed to send them, in which case another peer at that
+// height is asked for them.
+func (sm *SyncManager) fetchMissingBlocks() {
+	var inFlight int
+	for _, request := range sm.missingBlocks {
+		if request.peer != nil {
+			inFlight++
+		}
+	}
+	if inFlight >= minInFlightBlocks {
+		return
+	}
+
+	best := sm.chain.BestSnapshot()
+	getData := make(map[*peerpkg.Peer]*wire.MsgGetData)
+	for _, hash := range sm.chain.MissingBlocks(maxRequestedBlocks) {
+		hash := hash
+		request := sm.missingBlocks[hash]
+		if request == nil {
+			request = &missingBlockRequest{}
+			sm.missingBlocks[hash] = request
+		}
+		if request.peer != nil {
+			continue
+		}
+		peer := sm.missingBlockPeer(request.failedPeer, best.Height)
+		if peer == nil {
+			break
+		}
+
+		request.peer = peer
+		request.time = time.Now()
+		sm.requestedBlocks[hash] = struct{}{}
+		sm.peerStates[peer].requestedBlocks[hash] = struct{}{}
+		sm.traceBlockRequest(&hash)
+
+		gdmsg := getData[peer]
+		if gdmsg == nil {
+			gdmsg = wire.NewMsgGetData()
+			getData[peer] = gdmsg
+		}
+		gdmsg.AddInvVect(wire.NewInvVect(wire.InvTypeWitnessBlock, &hash))
+		inFlight++
+		if inFlight >= maxGetDataBlocks {
+			break
+		}
+	}
+	for peer, gdmsg := range getData {
+		log.Debugf("Requesting %d blocks missing from the database from "+
+			"%s", len(gdmsg.InvList), peer)
+		peer.QueueMessage(gdmsg, nil)
+	}
+}
+
+// missingBlockPeer returns the peer to request a block missing from the
+// database from, which is the sync peer unless it is the passed peer which
+// failed to send the block, or else another witness enabled sync candidate at
+// least at the passed height of the best chain.  The failed peer is only asked
+// again when there is no other one, and nil is returned when there is none.
+func (sm *SyncManager) missingBlockPeer(failedPeer *peerpkg.Peer,
+	height int32) *peerpkg.Peer {
+
+	if sm.syncPeer != nil && sm.syncPeer != failedPeer &&
+		sm.syncPeer.IsWitnessEnabled() {
+
+		return sm.syncPeer
+	}
+	for peer, state := range sm.peerStates {
+		if peer == failedPeer || !state.syncCandidate ||
+			!peer.IsWitnessEnabled() || peer.LastBlock() < height {
+
+			continue
+		}
+		return peer
+	}
+	if _, exists := sm.peerStates[failedPeer]; exists {
+		return failedPeer
+	}
+	return nil
+}
+
+// failMissingBlockRequest records that the passed peer failed to send the
+// block missing from the database with the passed hash, so that it is
+// requested again, from another peer when possible.
+func (sm *SyncManager) failMissingBlockRequest(hash chainhash.Hash,
+	peer *peerpkg.Peer) {
+
+	request, exists := sm.missingBlocks[hash]
+	if !exists || request.peer != peer {
+		return
+	}
+	request.peer = nil
+	request.failedPeer = peer
+}
+
+// expireMissingBlockRequests requests again the blocks missing from the
+// database which the peers they were requested from haven't sent within
+// missingBlockTimeout.
+func (sm *SyncManager) expireMissingBlockRequests() {
+	var expired bool
+	for hash, request := range sm.missingBlocks {
+		if request.peer == nil ||
+			time.Since(request.time) < missingBlockTimeout {
+
+			continue
+		}
+		log.Debugf("Block %v missing from the database requested from %s "+
+			"timed out", hash, request.peer)
+		if state, exists := sm.peerStates[request.peer]; exists {
+			delete(state.requestedBlocks, hash)
+		}
+		delete(sm.requestedBlocks, hash)
+		delete(sm.blockRequests, hash)
+		sm.failMissingBlockRequest(hash, request.peer)
+		expired = true
+	}
+	if expired {
+		sm.fetchMissingBlocks()
+	}
+}
+
 // handleHeadersMsg handles block header messages from all peers.  Headers are
 // requested when performing a headers-first sync.
 func (sm *SyncManager) handleHeadersMsg(hmsg *headersMsg) {
@@ -1038,6 +1280,8 @@ func (sm *SyncManager) handleNotFoundMsg(nfmsg *notFoundMsg) {
 			if _, exists := state.requestedBlocks[inv.Hash]; exists {
 				delete(state.requestedBlocks, inv.Hash)
 				delete(sm.requestedBlocks, inv.Hash)
+				delete(sm.blockRequests, inv.Hash)
+				sm.failMissingBlockRequest(inv.Hash, peer)
 			}
 
 		case wire.InvTypeWitnessTx:
@@ -1049,6 +1293,10 @@ func (sm *SyncManager) handleNotFoundMsg(nfmsg *notFoundMsg) {
 			}
 		}
 	}
+
+	// Request the blocks missing from the database which the peer has not
+	// found from the other ones.
+	sm.fetchMissingBlocks()
 }
 
 // haveInventory returns whether or not the inventory represented by the passed
@@ -1261,6 +1509,7 @@ func (sm *SyncManager) handleInvMsg(imsg *invMsg) {
 			if _, exists := sm.requestedBlocks[iv.Hash]; !exists {
 				limitAdd(sm.requestedBlocks, iv.Hash, maxRequestedBlocks)
 				limitAdd(state.requestedBlocks, iv.Hash, maxRequestedBlocks)
+				sm.traceBlockRequest(&iv.Hash)
 
 				if peer.IsWitnessEnabled() {
 					iv.Type = wire.InvTypeWitnessBlock
@@ -1621,6 +1870,8 @@ func New(config *Config) (*SyncManager, error) {
 		rejectedTxns:    make(map[chainhash.Hash]struct{}),
 		requestedTxns:   make(map[chainhash.Hash]struct{}),
 		requestedBlocks: make(map[chainhash.Hash]struct{}),
+		blockRequests:   make(map[chainhash.Hash]time.Time),
+		missingBlocks:   make(map[chainhash.Hash]*missingBlockRequest),
 		peerStates:      make(map[*peerpkg.Peer]*peerSyncState),
 		progressLogger:  newBlockProgressLogger("Processed", log),
 		msgChan:         make(chan interface{}, config.MaxPeers*3),
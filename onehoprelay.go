@@ -0,0 +1,235 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/mempool"
+)
+
+const (
+	// oneHopRelayEpoch is how long the transactions submitted locally are
+	// relayed through the same relay peer.
+	oneHopRelayEpoch = 10 * time.Minute
+
+	// oneHopRelayEmbargo is the min time the transactions are embargoed
+	// before the node broadcasts them itself, unless they are seen
+	// announced by other peers first.
+	oneHopRelayEmbargo = 10 * time.Second
+
+	// oneHopRelayEmbargoJitter is the max random time added to the embargo
+	// of each transaction, so the time of the fallback broadcast doesn't
+	// tell when the transaction was submitted.
+	oneHopRelayEmbargoJitter = 30 * time.Second
+)
+
+// errTxEmbargoed is returned when an embargoed transaction is requested by a
+// peer other than its relay peer.
+var errTxEmbargoed = errors.New("transaction is embargoed")
+
+// oneHopRelayConfig is the configuration of the one-hop relay.
+type oneHopRelayConfig struct {
+	// Candidates returns the connected peers which may be chosen as the
+	// relay peer.
+	Candidates func() []*serverPeer
+
+	// Relay announces the transactions to the relay peer only.
+	Relay func(sp *serverPeer, txns []*mempool.TxDesc)
+
+	// Broadcast announces the transactions to all the peers.
+	Broadcast func(txns []*mempool.TxDesc)
+
+	// Epoch is how long the same relay peer is used.
+	Epoch time.Duration
+
+	// Embargo is the min time the transactions are embargoed.
+	Embargo time.Duration
+
+	// EmbargoJitter is the max random time added to the embargo.
+	EmbargoJitter time.Duration
+}
+
+// relayedTxns is a group of transactions submitted together, which are
+// relayed through the same relay peer and broadcast together.
+type relayedTxns struct {
+	txns  []*mempool.TxDesc
+	relay *serverPeer
+	timer *time.Timer
+}
+
+// oneHopRelay relays the transactions submitted locally through a single
+// outbound peer, the relay peer, instead of broadcasting them to all the peers
+// at once.  The spy nodes connected to many peers locate the origin of the
+// transactions from the timing of the announcements, so the node announcing
+// them to all its peers first gives itself away.  Announced to the relay peer
+// only, which broadcasts them, they first spread from it.
+//
+// This is a single hop: the relay peer learns the transactions come from the
+// node, and the spy nodes may still locate the node when it is the relay peer
+// or when they tell which peers it relays through.  The relay peer changes
+// with each epoch rather than with each transaction, so the transactions of
+// the node don't reveal it by spreading from many peers.  Until the
+// transactions are seen announced by other peers, they are embargoed: they are
+// neither announced nor served to the other peers.  The node broadcasts them
+// itself when the embargo runs out or the relay peer disconnects, so they
+// still propagate when the relay peer drops them.
+type oneHopRelay struct {
+	cfg oneHopRelayConfig
+
+	mtx      sync.Mutex
+	relay    *serverPeer
+	epochEnd time.Time
+	relayed  map[chainhash.Hash]*relayedTxns
+}
+
+// newOneHopRelay returns a new one-hop relay with the configuration.
+func newOneHopRelay(cfg *oneHopRelayConfig) *oneHopRelay {
+	return &oneHopRelay{
+		cfg:     *cfg,
+		relayed: make(map[chainhash.Hash]*relayedTxns),
+	}
+}
+
+// relayPeer returns the relay peer of the current epoch, choosing a new one
+// when the epoch ended or the relay peer disconnected.  It returns nil when no
+// peer can be the relay peer.
+//
+// This function MUST be called with the relay lock held.
+func (r *oneHopRelay) relayPeer() *serverPeer {
+	now := time.Now()
+	if r.relay != nil && now.Before(r.epochEnd) {
+		return r.relay
+	}
+
+	r.relay = nil
+	candidates := r.cfg.Candidates()
+	if len(candidates) == 0 {
+		return nil
+	}
+	r.relay = candidates[randomUint16Number(uint16(len(candidates)))]
+	r.epochEnd = now.Add(r.cfg.Epoch)
+	srvrLog.Debugf("Relaying the local transactions through %v", r.relay)
+	return r.relay
+}
+
+// Relay relays the transactions submitted locally through the relay peer, or
+// broadcasts them directly when there is no peer to be the relay peer.
+//
+// This function is safe for concurrent access.
+func (r *oneHopRelay) Relay(txns []*mempool.TxDesc) {
+	if len(txns) == 0 {
+		return
+	}
+
+	r.mtx.Lock()
+	relay := r.relayPeer()
+	if relay == nil {
+		r.mtx.Unlock()
+		r.cfg.Broadcast(txns)
+		return
+	}
+
+	relayed := &relayedTxns{txns: txns, relay: relay}
+	for _, txD := range txns {
+		r.relayed[*txD.Tx.Hash()] = relayed
+	}
+	embargo := r.cfg.Embargo
+	if jitter := r.cfg.EmbargoJitter / time.Millisecond; jitter > 0 {
+		embargo += time.Duration(randomUint16Number(uint16(jitter))) *
+			time.Millisecond
+	}
+	relayed.timer = time.AfterFunc(embargo, func() {
+		r.broadcast(relayed, "embargo expired")
+	})
+	r.mtx.Unlock()
+
+	r.cfg.Relay(relay, txns)
+}
+
+// broadcast ends the embargo of the transactions and broadcasts them, unless
+// they were already.
+//
+// This function is safe for concurrent access.
+func (r *oneHopRelay) broadcast(relayed *relayedTxns, reason string) {
+	r.mtx.Lock()
+	removed := false
+	for _, txD := range relayed.txns {
+		hash := *txD.Tx.Hash()
+		if r.relayed[hash] == relayed {
+			delete(r.relayed, hash)
+			removed = true
+		}
+	}
+	r.mtx.Unlock()
+	if !removed {
+		return
+	}
+
+	relayed.timer.Stop()
+	srvrLog.Debugf("Broadcasting %d embargoed %s (%s)", len(relayed.txns),
+		pickNoun(uint64(len(relayed.txns)), "transaction", "transactions"),
+		reason)
+	r.cfg.Broadcast(relayed.txns)
+}
+
+// Embargoed returns whether the transaction is embargoed and must be hidden
+// from the peer, which is any peer but the relay peer.
+//
+// This function is safe for concurrent access.
+func (r *oneHopRelay) Embargoed(hash *chainhash.Hash, sp *serverPeer) bool {
+	r.mtx.Lock()
+	relayed, ok := r.relayed[*hash]
+	r.mtx.Unlock()
+	return ok && relayed.relay != sp
+}
+
+// Seen is called when the peer announces the transaction.  The transaction
+// spread from the relay peer when announced by another peer, so its embargo
+// ends and it is broadcast like the transactions from the network.
+//
+// This function is safe for concurrent access.
+func (r *oneHopRelay) Seen(hash *chainhash.Hash, sp *serverPeer) {
+	r.mtx.Lock()
+	relayed, ok := r.relayed[*hash]
+	r.mtx.Unlock()
+	if ok && relayed.relay != sp {
+		r.broadcast(relayed, "announced by "+sp.String())
+	}
+}
+
+// PeerDone is called when the peer disconnects.  The transactions relayed
+// through the peer are broadcast, since it may not have.
+//
+// This function is safe for concurrent access.
+func (r *oneHopRelay) PeerDone(sp *serverPeer) {
+	r.mtx.Lock()
+	if r.relay == sp {
+		r.relay = nil
+	}
+	var done []*relayedTxns
+	for _, relayed := range r.relayed {
+		if relayed.relay == sp {
+			done = append(done, relayed)
+		}
+	}
+	r.mtx.Unlock()
+
+	for _, relayed := range done {
+		r.broadcast(relayed, "relay peer disconnected")
+	}
+}
+
+// Stop stops the embargo timers.  The transactions still embargoed are
+// dropped.
+//
+// This function is safe for concurrent access.
+func (r *oneHopRelay) Stop() {
+	r.mtx.Lock()
+	for hash, relayed := range r.relayed {
+		relayed.timer.Stop()
+		delete(r.relayed, hash)
+	}
+	r.mtx.Unlock()
+}
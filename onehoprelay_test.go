@@ -0,0 +1,147 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btclog"
+	"github.com/lbryio/lbcd/mempool"
+	"github.com/lbryio/lbcd/mining"
+	"github.com/lbryio/lbcd/peer"
+	"github.com/lbryio/lbcd/wire"
+	"github.com/lbryio/lbcutil"
+	"github.com/stretchr/testify/require"
+)
+
+// testOneHopRelay records the transactions relayed by a one-hop relay.
+type testOneHopRelay struct {
+	mtx        sync.Mutex
+	candidates []*serverPeer
+	relayed    []*serverPeer
+	broadcast  [][]*mempool.TxDesc
+	broadcastc chan struct{}
+}
+
+// newTestOneHopRelay returns a one-hop relay choosing among the candidates,
+// with the embargo.
+func newTestOneHopRelay(embargo time.Duration, candidates ...*serverPeer) (*oneHopRelay, *testOneHopRelay) {
+	td := &testOneHopRelay{
+		candidates: candidates,
+		broadcastc: make(chan struct{}, 10),
+	}
+	r := newOneHopRelay(&oneHopRelayConfig{
+		Candidates: func() []*serverPeer {
+			td.mtx.Lock()
+			defer td.mtx.Unlock()
+			return td.candidates
+		},
+		Relay: func(sp *serverPeer, txns []*mempool.TxDesc) {
+			td.mtx.Lock()
+			defer td.mtx.Unlock()
+			td.relayed = append(td.relayed, sp)
+		},
+		Broadcast: func(txns []*mempool.TxDesc) {
+			td.mtx.Lock()
+			td.broadcast = append(td.broadcast, txns)
+			td.mtx.Unlock()
+			td.broadcastc <- struct{}{}
+		},
+		Epoch:   time.Hour,
+		Embargo: embargo,
+	})
+	return r, td
+}
+
+// testRelayedTx returns a transaction with the lock time, so each one has its own
+// hash.
+func testRelayedTx(lockTime uint32) *mempool.TxDesc {
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	msgTx.LockTime = lockTime
+	return &mempool.TxDesc{TxDesc: mining.TxDesc{Tx: lbcutil.NewTx(msgTx)}}
+}
+
+// testServerPeer returns a server peer which is only used as an identity.
+func testServerPeer() *serverPeer {
+	return &serverPeer{Peer: peer.NewInboundPeer(&peer.Config{})}
+}
+
+func TestOneHopRelay(t *testing.T) {
+
+	r := require.New(t)
+
+	defer func(log btclog.Logger) { srvrLog = log }(srvrLog)
+	srvrLog = btclog.Disabled
+
+	relay, other := testServerPeer(), testServerPeer()
+	relayer, td := newTestOneHopRelay(time.Hour, relay)
+
+	// The transactions are relayed through the relay peer and hidden from
+	// the other peers.
+	tx1, tx2 := testRelayedTx(1), testRelayedTx(2)
+	relayer.Relay([]*mempool.TxDesc{tx1})
+	relayer.Relay([]*mempool.TxDesc{tx2})
+	r.Equal([]*serverPeer{relay, relay}, td.relayed)
+	r.Empty(td.broadcast)
+	r.True(relayer.Embargoed(tx1.Tx.Hash(), other))
+	r.False(relayer.Embargoed(tx1.Tx.Hash(), relay))
+
+	// The relay announcing the transaction doesn't end the embargo,
+	// while another peer does.
+	relayer.Seen(tx1.Tx.Hash(), relay)
+	r.Empty(td.broadcast)
+	relayer.Seen(tx1.Tx.Hash(), other)
+	r.Equal([][]*mempool.TxDesc{{tx1}}, td.broadcast)
+	r.False(relayer.Embargoed(tx1.Tx.Hash(), other))
+	relayer.Seen(tx1.Tx.Hash(), other)
+	r.Len(td.broadcast, 1)
+
+	// The transactions of a disconnected relay peer are broadcast, and
+	// another relay peer is chosen.
+	relayer.PeerDone(relay)
+	r.Equal([][]*mempool.TxDesc{{tx1}, {tx2}}, td.broadcast)
+	r.False(relayer.Embargoed(tx2.Tx.Hash(), other))
+
+	td.candidates = []*serverPeer{other}
+	tx3 := testRelayedTx(3)
+	relayer.Relay([]*mempool.TxDesc{tx3})
+	r.Equal([]*serverPeer{relay, relay, other}, td.relayed)
+	relayer.Stop()
+	r.False(relayer.Embargoed(tx3.Tx.Hash(), relay))
+	r.Len(td.broadcast, 2)
+
+	// The transactions are broadcast directly without any relay.
+	td.candidates = nil
+	relayer.PeerDone(other)
+	tx4 := testRelayedTx(4)
+	relayer.Relay([]*mempool.TxDesc{tx4})
+	r.Equal([][]*mempool.TxDesc{{tx1}, {tx2}, {tx4}}, td.broadcast)
+	r.False(relayer.Embargoed(tx4.Tx.Hash(), other))
+}
+
+func TestOneHopRelayEmbargo(t *testing.T) {
+
+	r := require.New(t)
+
+	defer func(log btclog.Logger) { srvrLog = log }(srvrLog)
+	srvrLog = btclog.Disabled
+
+	relay, other := testServerPeer(), testServerPeer()
+	relayer, td := newTestOneHopRelay(100*time.Millisecond, relay)
+
+	// The transactions are broadcast together once their embargo expires.
+	txns := []*mempool.TxDesc{testRelayedTx(1), testRelayedTx(2)}
+	relayer.Relay(txns)
+	r.True(relayer.Embargoed(txns[1].Tx.Hash(), other))
+
+	select {
+	case <-td.broadcastc:
+	case <-time.After(5 * time.Second):
+		t.Fatal("embargo timeout")
+	}
+	td.mtx.Lock()
+	r.Equal([][]*mempool.TxDesc{txns}, td.broadcast)
+	td.mtx.Unlock()
+	r.False(relayer.Embargoed(txns[0].Tx.Hash(), other))
+	r.False(relayer.Embargoed(txns[1].Tx.Hash(), other))
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/websocket"
+	"github.com/lbryio/lbcd/wire"
+)
+
+const (
+	// wsPeerHandshakeTimeout is the time allowed for the HTTP request and
+	// the WebSocket handshake of a peer.
+	wsPeerHandshakeTimeout = 10 * time.Second
+
+	// wsPeerCloseTimeout is the time allowed to send the close message when
+	// disconnecting a peer.
+	wsPeerCloseTimeout = time.Second
+)
+
+// errWSListenerClosed is returned by Accept once the WebSocket peer listener
+// is closed.
+var errWSListenerClosed = errors.New("WebSocket peer listener closed")
+
+// wsPeerListener is a net.Listener accepting peer-to-peer connections over
+// WebSocket, so the in-browser light clients can talk to the node.  The
+// connections carry the plain wire protocol in binary messages, and are
+// handled by the connection manager like the inbound TCP peers, which applies
+// the limits, bans and whitelists to the address of the WebSocket client.
+type wsPeerListener struct {
+	listener  net.Listener
+	server    *http.Server
+	origins   map[string]struct{}
+	anyOrigin bool
+	conns     chan net.Conn
+	quit      chan struct{}
+	closeOnce sync.Once
+}
+
+// Ensure wsPeerListener implements the net.Listener interface.
+var _ net.Listener = (*wsPeerListener)(nil)
+
+// newWSPeerListener returns a listener serving WebSocket connections on the
+// passed listener.  Browsers are only allowed from the passed origins, such as
+// https://example.com, or from any origin with "*".  The clients which don't
+// send an origin, which are not browsers, are always allowed.
+func newWSPeerListener(listener net.Listener, origins []string) *wsPeerListener {
+	l := wsPeerListener{
+		listener: listener,
+		origins:  make(map[string]struct{}, len(origins)),
+		conns:    make(chan net.Conn),
+		quit:     make(chan struct{}),
+	}
+	for _, origin := range origins {
+		if origin == "*" {
+			l.anyOrigin = true
+			continue
+		}
+		l.origins[normalizeOrigin(origin)] = struct{}{}
+	}
+	l.server = &http.Server{
+		Handler:     &l,
+		ReadTimeout: wsPeerHandshakeTimeout,
+	}
+	go l.server.Serve(listener)
+	return &l
+}
+
+// normalizeOrigin returns the origin in the lowercase form without the
+// trailing slash used to compare origins.
+func normalizeOrigin(origin string) string {
+	return strings.TrimSuffix(strings.ToLower(origin), "/")
+}
+
+// checkOrigin returns whether the origin of the request is allowed.
+func (l *wsPeerListener) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || l.anyOrigin {
+		return true
+	}
+	_, ok := l.origins[normalizeOrigin(origin)]
+	return ok
+}
+
+// ServeHTTP upgrades the request to a WebSocket connection, which is then
+// returned by Accept.
+func (l *wsPeerListener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{
+		HandshakeTimeout: wsPeerHandshakeTimeout,
+		CheckOrigin:      l.checkOrigin,
+	}
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already responded with the error.
+		peerLog.Debugf("Refused WebSocket peer %s (origin %q): %v",
+			r.RemoteAddr, r.Header.Get("Origin"), err)
+		return
+	}
+
+	// The handshake deadline of the server no longer applies.
+	ws.UnderlyingConn().SetDeadline(time.Time{})
+	ws.SetReadLimit(wire.MessageHeaderSize + wire.MaxMessagePayload)
+
+	select {
+	case l.conns <- newWSPeerConn(ws):
+	case <-l.quit:
+		ws.Close()
+	}
+}
+
+// Accept waits for and returns the next WebSocket connection.  This is part
+// of the net.Listener interface implementation.
+func (l *wsPeerListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.quit:
+		return nil, errWSListenerClosed
+	}
+}
+
+// Close stops the listener.  The accepted connections are not closed.  This is
+// part of the net.Listener interface implementation.
+func (l *wsPeerListener) Close() error {
+	var err error
+	l.closeOnce.Do(func() {
+		close(l.quit)
+		err = l.server.Close()
+	})
+	return err
+}
+
+// Addr returns the address of the listener.  This is part of the net.Listener
+// interface implementation.
+func (l *wsPeerListener) Addr() net.Addr {
+	return l.listener.Addr()
+}
+
+// wsPeerConn is a net.Conn carrying the wire protocol over a WebSocket
+// connection.  Each wire message written is sent in its own binary message,
+// so the browsers don't need to reassemble them, while the binary messages
+// read are joined into a stream, so the clients may split or group their wire
+// messages freely.
+//
+// As for the connections of the peers, reads and writes may happen
+// concurrently with each other, but not with themselves.
+type wsPeerConn struct {
+	ws      *websocket.Conn
+	reader  io.Reader
+	pending []byte
+}
+
+// Ensure wsPeerConn implements the net.Conn interface.
+var _ net.Conn = (*wsPeerConn)(nil)
+
+// newWSPeerConn returns a new connection carrying the wire protocol over the
+// passed WebSocket connection.
+func newWSPeerConn(ws *websocket.Conn) *wsPeerConn {
+	return &wsPeerConn{ws: ws}
+}
+
+// Read reads the stream of the binary messages received.  This is part of the
+// net.Conn interface implementation.
+func (c *wsPeerConn) Read(p []byte) (int, error) {
+	for {
+		if c.reader == nil {
+			messageType, r, err := c.ws.NextReader()
+			if err != nil {
+				return 0, c.readError(err)
+			}
+			if messageType != websocket.BinaryMessage {
+				return 0, errors.New("WebSocket peers must " +
+					"send binary messages")
+			}
+			c.reader = r
+		}
+
+		n, err := c.reader.Read(p)
+		if err == io.EOF {
+			c.reader = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		if err != nil {
+			err = c.readError(err)
+		}
+		return n, err
+	}
+}
+
+// readError returns the error to report for the failed read of the
+// connection.  The connections closed by the clients, with or
+// without a close message, end the stream like the TCP connections closed by
+// the peers, so they are not reported as malformed messages.
+func (c *wsPeerConn) readError(err error) error {
+	if _, ok := err.(net.Error); ok {
+		return err
+	}
+	peerLog.Debugf("WebSocket connection from %s closed: %v",
+		c.RemoteAddr(), err)
+	return io.EOF
+}
+
+// Write buffers the bytes written until they complete a wire message, which
+// is then sent in a binary message.  This is part of the net.Conn interface
+// implementation.
+func (c *wsPeerConn) Write(p []byte) (int, error) {
+	c.pending = append(c.pending, p...)
+	for len(c.pending) >= wire.MessageHeaderSize {
+		// The payload length follows the magic and the command.
+		length := binary.LittleEndian.Uint32(c.pending[16:20])
+		size := wire.MessageHeaderSize + int(length)
+		if len(c.pending) < size {
+			break
+		}
+
+		err := c.ws.WriteMessage(websocket.BinaryMessage, c.pending[:size])
+		if err != nil {
+			return 0, err
+		}
+		c.pending = append(c.pending[:0], c.pending[size:]...)
+	}
+	return len(p), nil
+}
+
+// Close sends the close message and closes the connection.  This is part of
+// the net.Conn interface implementation.
+func (c *wsPeerConn) Close() error {
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	c.ws.WriteControl(websocket.CloseMessage, msg,
+		time.Now().Add(wsPeerCloseTimeout))
+	return c.ws.Close()
+}
+
+// LocalAddr returns the local address of the connection.  This is part of the
+// net.Conn interface implementation.
+func (c *wsPeerConn) LocalAddr() net.Addr {
+	return c.ws.LocalAddr()
+}
+
+// RemoteAddr returns the address of the WebSocket client.  This is part of the
+// net.Conn interface implementation.
+func (c *wsPeerConn) RemoteAddr() net.Addr {
+	return c.ws.RemoteAddr()
+}
+
+// SetDeadline sets the read and write deadlines of the connection.  This is
+// part of the net.Conn interface implementation.
+func (c *wsPeerConn) SetDeadline(t time.Time) error {
+	return c.ws.UnderlyingConn().SetDeadline(t)
+}
+
+// SetReadDeadline sets the read deadline of the connection.  This is part of
+// the net.Conn interface implementation.
+func (c *wsPeerConn) SetReadDeadline(t time.Time) error {
+	return c.ws.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the write deadline of the connection.  This is part
+// of the net.Conn interface implementation.
+func (c *wsPeerConn) SetWriteDeadline(t time.Time) error {
+	return c.ws.SetWriteDeadline(t)
+}
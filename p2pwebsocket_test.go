@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/btcsuite/btclog"
+	"github.com/btcsuite/websocket"
+	"github.com/lbryio/lbcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+// dialWSPeer connects a WebSocket client to the listener from the origin.
+func dialWSPeer(t *testing.T, l net.Listener, origin string) (*websocket.Conn, error) {
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	header := http.Header{}
+	if origin != "" {
+		header.Set("Origin", origin)
+	}
+	u := &url.URL{Scheme: "ws", Host: l.Addr().String(), Path: "/"}
+	ws, _, err := websocket.NewClient(conn, u, header, 1024, 1024)
+	return ws, err
+}
+
+// testWSPeerListener returns a WebSocket peer listener on a local port
+// allowing the origins.
+func testWSPeerListener(t *testing.T, origins ...string) *wsPeerListener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	l := newWSPeerListener(listener, origins)
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func TestWSPeerListenerOrigins(t *testing.T) {
+
+	r := require.New(t)
+
+	defer func(log btclog.Logger) { peerLog = log }(peerLog)
+	peerLog = btclog.Disabled
+
+	l := testWSPeerListener(t, "https://Example.com/", "http://localhost:8080")
+	accepted := make(chan net.Conn, 10)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				close(accepted)
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	for _, origin := range []string{"", "https://example.com",
+		"HTTPS://EXAMPLE.COM", "http://localhost:8080"} {
+
+		_, err := dialWSPeer(t, l, origin)
+		r.NoError(err, origin)
+		conn := <-accepted
+		r.NotNil(conn.RemoteAddr(), origin)
+		r.NoError(conn.Close())
+	}
+
+	for _, origin := range []string{"http://example.com",
+		"https://example.com:8443", "http://localhost", "null"} {
+
+		_, err := dialWSPeer(t, l, origin)
+		r.Equal(websocket.ErrBadHandshake, err, origin)
+	}
+
+	// Any origin is allowed with *.
+	l2 := testWSPeerListener(t, "*")
+	errc := make(chan error, 1)
+	go func() {
+		_, err := dialWSPeer(t, l2, "https://anywhere.example")
+		errc <- err
+	}()
+	conn, err := l2.Accept()
+	r.NoError(err)
+	r.NoError(<-errc)
+	conn.Close()
+
+	// Accept returns once the listener is closed.
+	r.NoError(l.Close())
+	_, ok := <-accepted
+	r.False(ok)
+	_, err = l.Accept()
+	r.Equal(errWSListenerClosed, err)
+}
+
+func TestWSPeerConn(t *testing.T) {
+
+	r := require.New(t)
+
+	l := testWSPeerListener(t)
+	clientc := make(chan *websocket.Conn, 1)
+	errc := make(chan error, 1)
+	go func() {
+		ws, err := dialWSPeer(t, l, "")
+		clientc <- ws
+		errc <- err
+	}()
+	conn, err := l.Accept()
+	r.NoError(err)
+	defer conn.Close()
+	client := <-clientc
+	r.NoError(<-errc)
+
+	// The wire messages written in parts are sent in their own binary
+	// messages.
+	var buf bytes.Buffer
+	_, err = wire.WriteMessageN(&buf, wire.NewMsgPing(42),
+		wire.ProtocolVersion, wire.MainNet)
+	r.NoError(err)
+	_, err = wire.WriteMessageN(&buf, wire.NewMsgVerAck(),
+		wire.ProtocolVersion, wire.MainNet)
+	r.NoError(err)
+	ping := buf.Bytes()[:wire.MessageHeaderSize+8]
+	verack := buf.Bytes()[wire.MessageHeaderSize+8:]
+
+	stream := buf.Bytes()
+	for _, part := range [][]byte{stream[:10], stream[10:36], stream[36:]} {
+		_, err = conn.Write(part)
+		r.NoError(err)
+	}
+
+	for _, want := range [][]byte{ping, verack} {
+		messageType, msg, err := client.ReadMessage()
+		r.NoError(err)
+		r.Equal(websocket.BinaryMessage, messageType)
+		r.Equal(want, msg)
+	}
+
+	// The binary messages received are joined into a stream.
+	r.NoError(client.WriteMessage(websocket.BinaryMessage, ping[:5]))
+	r.NoError(client.WriteMessage(websocket.BinaryMessage, nil))
+	r.NoError(client.WriteMessage(websocket.BinaryMessage, stream[5:]))
+
+	msg, _, err := wire.ReadMessage(conn, wire.ProtocolVersion, wire.MainNet)
+	r.NoError(err)
+	r.Equal(wire.NewMsgPing(42), msg)
+	msg, _, err = wire.ReadMessage(conn, wire.ProtocolVersion, wire.MainNet)
+	r.NoError(err)
+	r.Equal(wire.NewMsgVerAck(), msg)
+
+	// Text messages are refused.
+	r.NoError(client.WriteMessage(websocket.TextMessage, []byte("hi")))
+	_, err = conn.Read(make([]byte, 1))
+	r.Error(err)
+
+	// Closing the connection closes the client.
+	r.NoError(conn.Close())
+	_, _, err = client.ReadMessage()
+	r.Error(err)
+}
@@ -0,0 +1,108 @@
+package peer
+
+import (
+	"sync/atomic"
+
+	"github.com/decred/dcrd/lru"
+	"github.com/lbryio/lbcd/wire"
+)
+
+// knownInventoryShards is the number of shards the known inventory of a peer
+// is split into.  The inventory is looked up by the goroutine relaying it to
+// all of the peers, while the peer's own goroutines add to it, so each shard
+// has a lock of its own for them not to serialize on a single one.
+const knownInventoryShards = 8
+
+// knownInventory is the cache of the inventory a peer is known to have.  It
+// is split into shards by the first byte of the inventory hash, each being a
+// least recently used cache of its share of the maxKnownInventory items.
+type knownInventory [knownInventoryShards]lru.Cache
+
+// newKnownInventory returns an empty known inventory cache holding up to
+// about limit items.
+func newKnownInventory(limit uint) *knownInventory {
+	var k knownInventory
+	for i := range k {
+		k[i] = lru.NewCache(limit/knownInventoryShards + 1)
+	}
+	return &k
+}
+
+// shard returns the shard the inventory vector belongs to.
+func (k *knownInventory) shard(invVect *wire.InvVect) *lru.Cache {
+	return &k[int(invVect.Hash[0])%knownInventoryShards]
+}
+
+// Add adds the inventory vector to the cache, evicting the least recently used
+// item of its shard when the shard is full.
+//
+// This function is safe for concurrent access.
+func (k *knownInventory) Add(invVect *wire.InvVect) {
+	k.shard(invVect).Add(*invVect)
+}
+
+// Contains returns whether the inventory vector is in the cache.
+//
+// This function is safe for concurrent access.
+func (k *knownInventory) Contains(invVect *wire.InvVect) bool {
+	return k.shard(invVect).Contains(*invVect)
+}
+
+// invQueueNode is an inventory vector pushed to an invQueue.
+type invQueueNode struct {
+	invVect *wire.InvVect
+	next    *invQueueNode
+}
+
+// invQueue is a lock-free queue of the inventory to relay to a peer.  Any
+// number of goroutines push to it without ever blocking, while the queue
+// handler of the peer pops all of the queued inventory at once whenever it is
+// signalled.
+type invQueue struct {
+	head   atomic.Pointer[invQueueNode]
+	signal chan struct{}
+}
+
+// newInvQueue returns an empty invQueue.
+func newInvQueue() *invQueue {
+	return &invQueue{signal: make(chan struct{}, 1)}
+}
+
+// Push adds the inventory vector to the queue and signals it.
+//
+// This function is safe for concurrent access.
+func (q *invQueue) Push(invVect *wire.InvVect) {
+	node := &invQueueNode{invVect: invVect}
+	for {
+		node.next = q.head.Load()
+		if q.head.CompareAndSwap(node.next, node) {
+			break
+		}
+	}
+
+	select {
+	case q.signal <- struct{}{}:
+	default:
+		// The queue is already signalled.
+	}
+}
+
+// PopAll removes all of the queued inventory and returns it in the order it
+// was pushed.
+//
+// This function is safe for concurrent access.
+func (q *invQueue) PopAll() []*wire.InvVect {
+	var count int
+	head := q.head.Swap(nil)
+	for node := head; node != nil; node = node.next {
+		count++
+	}
+
+	// The nodes are linked from the most recently pushed one.
+	invVects := make([]*wire.InvVect, count)
+	for node := head; node != nil; node = node.next {
+		count--
+		invVects[count] = node.invVect
+	}
+	return invVects
+}
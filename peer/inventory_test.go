@@ -0,0 +1,79 @@
+package peer
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/wire"
+)
+
+// TestKnownInventory ensures the known inventory is keyed by the value of the
+// inventory vectors and stays bounded.
+func TestKnownInventory(t *testing.T) {
+	known := newKnownInventory(16)
+
+	iv := wire.NewInvVect(wire.InvTypeTx, &chainhash.Hash{1})
+	known.Add(iv)
+	if !known.Contains(wire.NewInvVect(wire.InvTypeTx, &chainhash.Hash{1})) {
+		t.Fatal("Contains: a copy of the added inventory is unknown")
+	}
+	if known.Contains(wire.NewInvVect(wire.InvTypeBlock, &chainhash.Hash{1})) {
+		t.Fatal("Contains: inventory of another type is known")
+	}
+
+	// Filling the shard of the inventory evicts it.
+	for i := 0; i < 16; i++ {
+		hash := chainhash.Hash{1, byte(i + 1)}
+		known.Add(wire.NewInvVect(wire.InvTypeTx, &hash))
+	}
+	if known.Contains(iv) {
+		t.Fatal("Contains: the least recently used inventory wasn't evicted")
+	}
+}
+
+// TestInvQueue ensures the inventory pushed to an invQueue by concurrent
+// goroutines is all popped, in the order each goroutine pushed it.
+func TestInvQueue(t *testing.T) {
+	const pushers, perPusher = 4, 1000
+
+	q := newInvQueue()
+	if invVects := q.PopAll(); len(invVects) != 0 {
+		t.Fatalf("PopAll: got %d items from an empty queue", len(invVects))
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < pushers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < perPusher; j++ {
+				hash := chainhash.Hash{byte(i), byte(j), byte(j >> 8)}
+				q.Push(wire.NewInvVect(wire.InvTypeTx, &hash))
+			}
+		}(i)
+	}
+
+	next := make([]int, pushers)
+	var popped int
+	check := func() {
+		for _, iv := range q.PopAll() {
+			i, j := int(iv.Hash[0]), int(iv.Hash[1])|int(iv.Hash[2])<<8
+			if j != next[i] {
+				t.Fatalf("PopAll: got item %d of pusher %d, want %d",
+					j, i, next[i])
+			}
+			next[i]++
+			popped++
+		}
+	}
+	for popped < pushers*perPusher {
+		<-q.signal
+		check()
+	}
+	wg.Wait()
+	check()
+	if popped != pushers*perPusher {
+		t.Fatalf("PopAll: got %d items, want %d", popped, pushers*perPusher)
+	}
+}
@@ -118,6 +118,8 @@ type MessageListeners struct {
 	OnTx func(p *Peer, msg *wire.MsgTx)
 
 	// OnBlock is invoked when a peer receives a block bitcoin message.
+	// The raw bytes of the block are owned by the listener, which may hand
+	// them back with wire.ReleasePayload once no longer referenced.
 	OnBlock func(p *Peer, msg *wire.MsgBlock, buf []byte)
 
 	// OnCFilter is invoked when a peer receives a cfilter bitcoin message.
@@ -164,6 +166,28 @@ type MessageListeners struct {
 	// bitcoin message.
 	OnGetCFCheckpt func(p *Peer, msg *wire.MsgGetCFCheckpt)
 
+	// OnGetClaimProof is invoked when a peer receives a getclmproof
+	// message.
+	OnGetClaimProof func(p *Peer, msg *wire.MsgGetClaimProof)
+
+	// OnClaimProof is invoked when a peer receives a clmproof message.
+	OnClaimProof func(p *Peer, msg *wire.MsgClaimProof)
+
+	// OnGetClaimSnapshot is invoked when a peer receives a getctsnap
+	// message.
+	OnGetClaimSnapshot func(p *Peer, msg *wire.MsgGetClaimSnapshot)
+
+	// OnClaimSnapshot is invoked when a peer receives a ctsnap message.
+	OnClaimSnapshot func(p *Peer, msg *wire.MsgClaimSnapshot)
+
+	// OnGetClaimSnapshotChunk is invoked when a peer receives a getctchunk
+	// message.
+	OnGetClaimSnapshotChunk func(p *Peer, msg *wire.MsgGetClaimSnapshotChunk)
+
+	// OnClaimSnapshotChunk is invoked when a peer receives a ctchunk
+	// message.
+	OnClaimSnapshotChunk func(p *Peer, msg *wire.MsgClaimSnapshotChunk)
+
 	// OnFeeFilter is invoked when a peer receives a feefilter bitcoin message.
 	OnFeeFilter func(p *Peer, msg *wire.MsgFeeFilter)
 
@@ -458,7 +482,7 @@ type Peer struct {
 
 	wireEncoding wire.MessageEncoding
 
-	knownInventory     lru.Cache
+	knownInventory     *knownInventory
 	prevGetBlocksMtx   sync.Mutex
 	prevGetBlocksBegin *chainhash.Hash
 	prevGetBlocksStop  *chainhash.Hash
@@ -478,15 +502,15 @@ type Peer struct {
 	lastPingTime       time.Time // Time we sent last ping.
 	lastPingMicros     int64     // Time for last ping to return.
 
-	stallControl  chan stallControlMsg
-	outputQueue   chan outMsg
-	sendQueue     chan outMsg
-	sendDoneQueue chan struct{}
-	outputInvChan chan *wire.InvVect
-	inQuit        chan struct{}
-	queueQuit     chan struct{}
-	outQuit       chan struct{}
-	quit          chan struct{}
+	stallControl   chan stallControlMsg
+	outputQueue    chan outMsg
+	sendQueue      chan outMsg
+	sendDoneQueue  chan struct{}
+	outputInvQueue *invQueue
+	inQuit         chan struct{}
+	queueQuit      chan struct{}
+	outQuit        chan struct{}
+	quit           chan struct{}
 }
 
 // String returns the peer's address and directionality as a human-readable
@@ -1491,6 +1515,36 @@ out:
 				p.cfg.Listeners.OnGetCFCheckpt(p, msg)
 			}
 
+		case *wire.MsgGetClaimProof:
+			if p.cfg.Listeners.OnGetClaimProof != nil {
+				p.cfg.Listeners.OnGetClaimProof(p, msg)
+			}
+
+		case *wire.MsgClaimProof:
+			if p.cfg.Listeners.OnClaimProof != nil {
+				p.cfg.Listeners.OnClaimProof(p, msg)
+			}
+
+		case *wire.MsgGetClaimSnapshot:
+			if p.cfg.Listeners.OnGetClaimSnapshot != nil {
+				p.cfg.Listeners.OnGetClaimSnapshot(p, msg)
+			}
+
+		case *wire.MsgClaimSnapshot:
+			if p.cfg.Listeners.OnClaimSnapshot != nil {
+				p.cfg.Listeners.OnClaimSnapshot(p, msg)
+			}
+
+		case *wire.MsgGetClaimSnapshotChunk:
+			if p.cfg.Listeners.OnGetClaimSnapshotChunk != nil {
+				p.cfg.Listeners.OnGetClaimSnapshotChunk(p, msg)
+			}
+
+		case *wire.MsgClaimSnapshotChunk:
+			if p.cfg.Listeners.OnClaimSnapshotChunk != nil {
+				p.cfg.Listeners.OnClaimSnapshotChunk(p, msg)
+			}
+
 		case *wire.MsgCFilter:
 			if p.cfg.Listeners.OnCFilter != nil {
 				p.cfg.Listeners.OnCFilter(p, msg)
@@ -1611,9 +1665,13 @@ out:
 			val := pendingMsgs.Remove(next)
 			p.sendQueue <- val.(outMsg)
 
-		case iv := <-p.outputInvChan:
+		case <-p.outputInvQueue.signal:
 			// No handshake?  They'll find out soon enough.
-			if p.VersionKnown() {
+			invVects := p.outputInvQueue.PopAll()
+			if !p.VersionKnown() {
+				continue
+			}
+			for _, iv := range invVects {
 				// If this is a new block, then we'll blast it
 				// out immediately, sipping the inv trickle
 				// queue.
@@ -1688,8 +1746,6 @@ cleanup:
 			if msg.doneChan != nil {
 				msg.doneChan <- struct{}{}
 			}
-		case <-p.outputInvChan:
-			// Just drain channel
 		// sendDoneQueue is buffered so doesn't need draining.
 		default:
 			break cleanup
@@ -1856,14 +1912,12 @@ func (p *Peer) QueueInventory(invVect *wire.InvVect) {
 		return
 	}
 
-	// Avoid risk of deadlock if goroutine already exited.  The goroutine
-	// we will be sending to hangs around until it knows for a fact that
-	// it is marked as disconnected and *then* it drains the channels.
+	// Don't let the queue grow once the queue handler is going away.
 	if !p.Connected() {
 		return
 	}
 
-	p.outputInvChan <- invVect
+	p.outputInvQueue.Push(invVect)
 }
 
 // Connected returns whether or not the peer is currently connected.
@@ -2250,12 +2304,12 @@ func newPeerBase(origCfg *Config, inbound bool) *Peer {
 	p := Peer{
 		inbound:         inbound,
 		wireEncoding:    encoding,
-		knownInventory:  lru.NewCache(maxKnownInventory),
+		knownInventory:  newKnownInventory(maxKnownInventory),
 		stallControl:    make(chan stallControlMsg, 1), // nonblocking sync
 		outputQueue:     make(chan outMsg, outputBufferSize),
 		sendQueue:       make(chan outMsg, 1),   // nonblocking sync
 		sendDoneQueue:   make(chan struct{}, 1), // nonblocking sync
-		outputInvChan:   make(chan *wire.InvVect, outputBufferSize),
+		outputInvQueue:  newInvQueue(),
 		inQuit:          make(chan struct{}),
 		queueQuit:       make(chan struct{}),
 		outQuit:         make(chan struct{}),
@@ -413,6 +413,24 @@ func TestPeerListeners(t *testing.T) {
 			OnGetCFCheckpt: func(p *peer.Peer, msg *wire.MsgGetCFCheckpt) {
 				ok <- msg
 			},
+			OnGetClaimProof: func(p *peer.Peer, msg *wire.MsgGetClaimProof) {
+				ok <- msg
+			},
+			OnClaimProof: func(p *peer.Peer, msg *wire.MsgClaimProof) {
+				ok <- msg
+			},
+			OnGetClaimSnapshot: func(p *peer.Peer, msg *wire.MsgGetClaimSnapshot) {
+				ok <- msg
+			},
+			OnClaimSnapshot: func(p *peer.Peer, msg *wire.MsgClaimSnapshot) {
+				ok <- msg
+			},
+			OnGetClaimSnapshotChunk: func(p *peer.Peer, msg *wire.MsgGetClaimSnapshotChunk) {
+				ok <- msg
+			},
+			OnClaimSnapshotChunk: func(p *peer.Peer, msg *wire.MsgClaimSnapshotChunk) {
+				ok <- msg
+			},
 			OnCFilter: func(p *peer.Peer, msg *wire.MsgCFilter) {
 				ok <- msg
 			},
@@ -557,6 +575,30 @@ func TestPeerListeners(t *testing.T) {
 			"OnGetCFCheckpt",
 			wire.NewMsgGetCFCheckpt(wire.GCSFilterRegular, &chainhash.Hash{}),
 		},
+		{
+			"OnGetClaimProof",
+			wire.NewMsgGetClaimProof("name"),
+		},
+		{
+			"OnClaimProof",
+			wire.NewMsgClaimProof("name", &chainhash.Hash{}),
+		},
+		{
+			"OnGetClaimSnapshot",
+			wire.NewMsgGetClaimSnapshot(),
+		},
+		{
+			"OnClaimSnapshot",
+			wire.NewMsgClaimSnapshot(1, &chainhash.Hash{}, &chainhash.Hash{}),
+		},
+		{
+			"OnGetClaimSnapshotChunk",
+			wire.NewMsgGetClaimSnapshotChunk(&chainhash.Hash{}, 0),
+		},
+		{
+			"OnClaimSnapshotChunk",
+			wire.NewMsgClaimSnapshotChunk(&chainhash.Hash{}, 0, []byte("chunk")),
+		},
 		{
 			"OnCFilter",
 			wire.NewMsgCFilter(wire.GCSFilterRegular, &chainhash.Hash{},
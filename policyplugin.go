@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lbryio/lbcd/blockchain"
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/lbcdrpc"
+	"github.com/lbryio/lbcd/mempool"
+	"github.com/lbryio/lbcd/mining"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/txscript/claimscript"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const (
+	// defaultPolicyPluginTimeout is the default max time to wait for the
+	// verdicts of the policy plugin.
+	defaultPolicyPluginTimeout = time.Second
+
+	// policyPluginBatchSize is the max number of transactions of a block
+	// template sent in a request to the policy plugin.
+	policyPluginBatchSize = 500
+)
+
+// policyPluginConfig is the configuration of a policyPlugin.
+type policyPluginConfig struct {
+	// Addr is the address of the policy service, as <host:port> or
+	// unix:<path>.
+	Addr string
+
+	// Timeout is the max time to wait for the verdicts of a request.
+	Timeout time.Duration
+
+	// FailOpen accepts the transactions when the service is unavailable,
+	// instead of rejecting them.
+	FailOpen bool
+
+	ChainParams *chaincfg.Params
+
+	// FetchUtxoView fetches the outputs of the main chain spent by a
+	// transaction of a block template.
+	FetchUtxoView func(*btcutil.Tx) (*blockchain.UtxoViewpoint, error)
+}
+
+// policyPlugin enforces the custom local policy of the node by consulting the
+// lbcdrpc.Policy service of a sidecar process on the transactions accepted to
+// the mempool and included in the block templates.
+type policyPlugin struct {
+	cfg    policyPluginConfig
+	conn   *grpc.ClientConn
+	client lbcdrpc.PolicyClient
+}
+
+// newPolicyPlugin returns a policy plugin consulting the service at the
+// address of the config.  The connection is established on demand, so the
+// sidecar may start after lbcd.
+func newPolicyPlugin(cfg *policyPluginConfig) (*policyPlugin, error) {
+	conn, err := grpc.Dial(cfg.Addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	return &policyPlugin{
+		cfg:    *cfg,
+		conn:   conn,
+		client: lbcdrpc.NewPolicyClient(conn),
+	}, nil
+}
+
+// Close closes the connection to the service.
+func (p *policyPlugin) Close() error {
+	return p.conn.Close()
+}
+
+// check returns the verdicts of the service on the transactions, in order.
+func (p *policyPlugin) check(stage lbcdrpc.CheckTransactionsRequest_Stage,
+	height int32, txns []*lbcdrpc.PolicyTransaction) ([]*lbcdrpc.PolicyVerdict, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.cfg.Timeout)
+	defer cancel()
+
+	resp, err := p.client.CheckTransactions(ctx, &lbcdrpc.CheckTransactionsRequest{
+		Stage:        stage,
+		Height:       height,
+		Transactions: txns,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Verdicts) != len(txns) {
+		return nil, fmt.Errorf("%d verdicts on %d transactions",
+			len(resp.Verdicts), len(txns))
+	}
+	return resp.Verdicts, nil
+}
+
+// CheckMempoolTx returns an error describing why the policy rejects a
+// transaction being accepted to the mempool, or one wrapping
+// mempool.ErrTxPolicyUnavailable when the service is unavailable and doesn't
+// fail open, so the transaction may be processed again later.  It implements
+// the CheckTxPolicy function of the mempool config.
+func (p *policyPlugin) CheckMempoolTx(tx *btcutil.Tx,
+	utxoView *blockchain.UtxoViewpoint, nextBlockHeight int32, fee int64) error {
+
+	verdicts, err := p.check(lbcdrpc.CheckTransactionsRequest_MEMPOOL,
+		nextBlockHeight, []*lbcdrpc.PolicyTransaction{
+			p.policyTx(tx, fee, utxoView),
+		})
+	if err != nil {
+		if p.cfg.FailOpen {
+			plcyLog.Warnf("Accepting transaction %v without policy "+
+				"verdict: %v", tx.Hash(), err)
+			return nil
+		}
+		return fmt.Errorf("%w: policy plugin: %v",
+			mempool.ErrTxPolicyUnavailable, err)
+	}
+	return verdictError(verdicts[0])
+}
+
+// FilterTemplateTxs returns the reasons the policy leaves transactions out of
+// a block template, by hash.  It implements the FilterTemplateTxs function of
+// the mining policy.
+func (p *policyPlugin) FilterTemplateTxs(txns []*mining.TxDesc,
+	height int32) map[chainhash.Hash]string {
+
+	rejects := make(map[chainhash.Hash]string)
+	for len(txns) > 0 {
+		batch := txns
+		if len(batch) > policyPluginBatchSize {
+			batch = batch[:policyPluginBatchSize]
+		}
+		txns = txns[len(batch):]
+
+		ptxns := make([]*lbcdrpc.PolicyTransaction, 0, len(batch))
+		for _, txDesc := range batch {
+			// The outputs spent from the mempool are left unset.
+			utxoView, err := p.cfg.FetchUtxoView(txDesc.Tx)
+			if err != nil {
+				utxoView = nil
+			}
+			ptxns = append(ptxns, p.policyTx(txDesc.Tx, txDesc.Fee,
+				utxoView))
+		}
+
+		verdicts, err := p.check(lbcdrpc.CheckTransactionsRequest_BLOCK_TEMPLATE,
+			height, ptxns)
+		if err != nil {
+			if p.cfg.FailOpen {
+				plcyLog.Warnf("Including %d transactions in block "+
+					"template without policy verdict: %v",
+					len(batch), err)
+				continue
+			}
+			plcyLog.Warnf("Leaving %d transactions out of block "+
+				"template without policy verdict: %v", len(batch),
+				err)
+			reason := fmt.Sprintf("policy plugin unavailable: %v", err)
+			for _, txDesc := range batch {
+				rejects[*txDesc.Tx.Hash()] = reason
+			}
+			continue
+		}
+		for i, verdict := range verdicts {
+			if err := verdictError(verdict); err != nil {
+				rejects[*batch[i].Tx.Hash()] = err.Error()
+			}
+		}
+	}
+	if len(rejects) > 0 {
+		plcyLog.Debugf("Policy left %d transactions out of block template "+
+			"at height %d", len(rejects), height)
+	}
+	return rejects
+}
+
+// verdictError returns the error of a rejection verdict, or nil.
+func verdictError(verdict *lbcdrpc.PolicyVerdict) error {
+	if !verdict.Reject {
+		return nil
+	}
+	if verdict.Reason == "" {
+		return errors.New("rejected by policy plugin")
+	}
+	return errors.New(verdict.Reason)
+}
+
+// policyTx returns the context of a transaction sent to the service, with the
+// outputs it spends found in the utxo view, which may be nil.
+func (p *policyPlugin) policyTx(tx *btcutil.Tx, fee int64,
+	utxoView *blockchain.UtxoViewpoint) *lbcdrpc.PolicyTransaction {
+
+	msgTx := tx.MsgTx()
+	var buf bytes.Buffer
+	buf.Grow(msgTx.SerializeSize())
+	_ = msgTx.Serialize(&buf)
+
+	ptx := &lbcdrpc.PolicyTransaction{
+		Hash:  tx.Hash().String(),
+		RawTx: buf.Bytes(),
+		Fee:   fee,
+		Vsize: mempool.GetTxVirtualSize(tx),
+	}
+	for _, txIn := range msgTx.TxIn {
+		prevOut := txIn.PreviousOutPoint
+		input := &lbcdrpc.PolicyInput{
+			PrevHash:  prevOut.Hash.String(),
+			PrevIndex: prevOut.Index,
+		}
+		if utxoView != nil {
+			entry := utxoView.LookupEntry(prevOut)
+			if entry != nil && !entry.IsSpent() {
+				input.PrevOutput = p.policyOutput(entry.Amount(),
+					entry.PkScript(), prevOut)
+			}
+		}
+		ptx.Inputs = append(ptx.Inputs, input)
+	}
+	for i, txOut := range msgTx.TxOut {
+		op := wire.OutPoint{Hash: *tx.Hash(), Index: uint32(i)}
+		ptx.Outputs = append(ptx.Outputs, p.policyOutput(txOut.Value,
+			txOut.PkScript, op))
+	}
+	return ptx
+}
+
+// policyOutput returns the context of the output at the outpoint, with its
+// address and claim operation when it has any.
+func (p *policyPlugin) policyOutput(value int64, pkScript []byte,
+	op wire.OutPoint) *lbcdrpc.PolicyOutput {
+
+	output := &lbcdrpc.PolicyOutput{
+		Value:    value,
+		PkScript: pkScript,
+	}
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript,
+		p.cfg.ChainParams)
+	if err == nil && len(addrs) == 1 {
+		output.Address = addrs[0].EncodeAddress()
+	}
+
+	cs, err := claimscript.Parse(pkScript)
+	if err != nil {
+		return output
+	}
+	output.Claim = &lbcdrpc.ClaimScript{
+		Name:    cs.Name,
+		ClaimId: cs.ClaimIDFor(op).String(),
+		Value:   cs.Value,
+	}
+	switch cs.Type {
+	case claimscript.TypeClaimName:
+		output.Claim.Type = lbcdrpc.ClaimScript_CLAIM_NAME
+	case claimscript.TypeSupportClaim:
+		output.Claim.Type = lbcdrpc.ClaimScript_SUPPORT_CLAIM
+	case claimscript.TypeUpdateClaim:
+		output.Claim.Type = lbcdrpc.ClaimScript_UPDATE_CLAIM
+	}
+	return output
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btclog"
+	"github.com/lbryio/lbcd/blockchain"
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/claimtrie/change"
+	"github.com/lbryio/lbcd/lbcdrpc"
+	"github.com/lbryio/lbcd/mempool"
+	"github.com/lbryio/lbcd/mining"
+	"github.com/lbryio/lbcd/txscript/claimscript"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// testPolicyServer rejects the transactions with an output claiming the name
+// "blocked", recording the requests.
+type testPolicyServer struct {
+	lbcdrpc.UnimplementedPolicyServer
+	requests chan *lbcdrpc.CheckTransactionsRequest
+}
+
+func (s *testPolicyServer) CheckTransactions(ctx context.Context,
+	req *lbcdrpc.CheckTransactionsRequest) (*lbcdrpc.CheckTransactionsResponse, error) {
+
+	s.requests <- req
+	resp := &lbcdrpc.CheckTransactionsResponse{}
+	for _, tx := range req.Transactions {
+		verdict := &lbcdrpc.PolicyVerdict{}
+		for _, out := range tx.Outputs {
+			if out.Claim != nil && string(out.Claim.Name) == "blocked" {
+				verdict.Reject = true
+				verdict.Reason = "blocked name"
+			}
+		}
+		resp.Verdicts = append(resp.Verdicts, verdict)
+	}
+	return resp, nil
+}
+
+func TestPolicyPlugin(t *testing.T) {
+
+	r := require.New(t)
+
+	defer func(log btclog.Logger) { plcyLog = log }(plcyLog)
+	plcyLog = btclog.Disabled
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(err)
+	server := grpc.NewServer()
+	policyServer := &testPolicyServer{
+		requests: make(chan *lbcdrpc.CheckTransactionsRequest, 10),
+	}
+	lbcdrpc.RegisterPolicyServer(server, policyServer)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	pkScript := []byte{0x51}
+	prevTx := wire.NewMsgTx(wire.TxVersion)
+	prevTx.AddTxOut(wire.NewTxOut(100000, pkScript))
+	prevTx.AddTxOut(wire.NewTxOut(300000, pkScript))
+	prevOut := wire.OutPoint{Hash: prevTx.TxHash(), Index: 1}
+	view := blockchain.NewUtxoViewpoint()
+	view.AddTxOut(btcutil.NewTx(prevTx), 1, 7)
+
+	newTx := func(name string) *btcutil.Tx {
+		script, err := claimscript.ClaimName(name, []byte("value"), pkScript)
+		r.NoError(err)
+		msgTx := wire.NewMsgTx(wire.TxVersion)
+		msgTx.AddTxIn(wire.NewTxIn(&prevOut, nil, nil))
+		msgTx.AddTxOut(wire.NewTxOut(200000, script))
+		return btcutil.NewTx(msgTx)
+	}
+	allowed, blocked := newTx("allowed"), newTx("blocked")
+
+	p, err := newPolicyPlugin(&policyPluginConfig{
+		Addr:        listener.Addr().String(),
+		Timeout:     5 * time.Second,
+		ChainParams: &chaincfg.RegressionNetParams,
+		// The spent output is only known to the chain for the first
+		// transaction in the block template stage.
+		FetchUtxoView: func(tx *btcutil.Tx) (*blockchain.UtxoViewpoint, error) {
+			if tx == allowed {
+				return view, nil
+			}
+			return blockchain.NewUtxoViewpoint(), nil
+		},
+	})
+	r.NoError(err)
+	defer p.Close()
+
+	r.NoError(p.CheckMempoolTx(allowed, view, 101, 100000))
+	req := <-policyServer.requests
+	r.Equal(lbcdrpc.CheckTransactionsRequest_MEMPOOL, req.Stage)
+	r.Equal(int32(101), req.Height)
+	r.Len(req.Transactions, 1)
+	ptx := req.Transactions[0]
+	r.Equal(allowed.Hash().String(), ptx.Hash)
+	r.Equal(int64(100000), ptx.Fee)
+	r.Equal(prevOut.Hash.String(), ptx.Inputs[0].PrevHash)
+	r.Equal(int64(300000), ptx.Inputs[0].PrevOutput.Value)
+	r.Equal(pkScript, ptx.Inputs[0].PrevOutput.PkScript)
+	claim := ptx.Outputs[0].Claim
+	r.Equal(lbcdrpc.ClaimScript_CLAIM_NAME, claim.Type)
+	r.Equal([]byte("allowed"), claim.Name)
+	r.Equal([]byte("value"), claim.Value)
+	op := wire.OutPoint{Hash: *allowed.Hash(), Index: 0}
+	r.Equal(change.NewClaimID(op).String(), claim.ClaimId)
+
+	r.EqualError(p.CheckMempoolTx(blocked, view, 101, 100000), "blocked name")
+	<-policyServer.requests
+
+	rejects := p.FilterTemplateTxs([]*mining.TxDesc{
+		{Tx: allowed, Fee: 100000},
+		{Tx: blocked, Fee: 100000},
+	}, 102)
+	r.Equal(map[chainhash.Hash]string{*blocked.Hash(): "blocked name"}, rejects)
+	req = <-policyServer.requests
+	r.Equal(lbcdrpc.CheckTransactionsRequest_BLOCK_TEMPLATE, req.Stage)
+	r.Len(req.Transactions, 2)
+	r.NotNil(req.Transactions[0].Inputs[0].PrevOutput)
+	r.Nil(req.Transactions[1].Inputs[0].PrevOutput)
+
+	// The transactions are rejected when the plugin is unavailable, unless
+	// it fails open.
+	server.Stop()
+	p.cfg.Timeout = 100 * time.Millisecond
+	r.ErrorIs(p.CheckMempoolTx(allowed, view, 101, 100000),
+		mempool.ErrTxPolicyUnavailable)
+	r.Len(p.FilterTemplateTxs([]*mining.TxDesc{{Tx: allowed}}, 102), 1)
+
+	p.cfg.FailOpen = true
+	r.NoError(p.CheckMempoolTx(blocked, view, 101, 100000))
+	r.Empty(p.FilterTemplateTxs([]*mining.TxDesc{{Tx: blocked}}, 102))
+}
@@ -0,0 +1,422 @@
+package psbt
+
+import (
+	"bytes"
+
+	"github.com/lbryio/lbcd/btcec"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/txscript/claimscript"
+	"github.com/lbryio/lbcd/wire"
+	"github.com/lbryio/lbcutil"
+	"github.com/lbryio/lbcutil/psbt"
+)
+
+// ClaimPrefix is the identifier prefix of the proprietary fields which hold
+// the claim information of inputs spending claims, supports and updates.
+const ClaimPrefix = "lbry"
+
+const (
+	// ClaimNameSubtype is the subtype of the proprietary field holding
+	// the claim name as it appears in the claim script.
+	ClaimNameSubtype = 0x00
+
+	// ClaimIDSubtype is the subtype of the proprietary field holding the
+	// claim ID, in the byte order used by claim scripts.
+	ClaimIDSubtype = 0x01
+)
+
+// Claim is the claim information of a PSBT input or output.  The claim ID is
+// absent from outputs creating a new claim, as it depends on the final
+// transaction hash.
+type Claim struct {
+	Name    []byte
+	ClaimID []byte
+}
+
+// ProprietaryKey returns the key of a proprietary field:
+// {0xFC}|<compact size prefix length>|<prefix>|<compact size subtype>|<key data>.
+func ProprietaryKey(prefix []byte, subtype uint64, keyData []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(byte(ProprietaryInputType))
+	_ = wire.WriteVarBytes(&buf, 0, prefix)
+	_ = wire.WriteVarInt(&buf, 0, subtype)
+	buf.Write(keyData)
+	return buf.Bytes()
+}
+
+// ParseProprietaryKey splits the key of a proprietary field into its prefix,
+// subtype and key data.
+func ParseProprietaryKey(key []byte) ([]byte, uint64, []byte, error) {
+	if len(key) == 0 || key[0] != byte(ProprietaryInputType) {
+		return nil, 0, nil, ErrInvalidKeydata
+	}
+	r := bytes.NewReader(key[1:])
+	prefix, err := wire.ReadVarBytes(r, 0, MaxPsbtKeyLength, "prefix")
+	if err != nil {
+		return nil, 0, nil, ErrInvalidKeydata
+	}
+	subtype, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, 0, nil, ErrInvalidKeydata
+	}
+	keyData := key[len(key)-r.Len():]
+	return prefix, subtype, keyData, nil
+}
+
+// InputClaim returns the claim information of the input, or nil if it has
+// none.
+func InputClaim(pInput *PInput) *Claim {
+	return claimFromUnknowns(pInput.Unknowns)
+}
+
+// OutputClaim returns the claim information of the claim, support or update
+// created by the output at outIndex, or nil if it isn't a claim script.  Unlike
+// the inputs, it is read from the script of the output, which holds it.
+func OutputClaim(p *Packet, outIndex int) *Claim {
+	cs, err := claimscript.Parse(p.UnsignedTx.TxOut[outIndex].PkScript)
+	if err != nil {
+		return nil
+	}
+
+	claim := &Claim{Name: cs.Name}
+	if cs.Type != claimscript.TypeClaimName {
+		claim.ClaimID = cs.ClaimID[:]
+	}
+	return claim
+}
+
+// AddInClaim adds the claim information of the claim, support or update spent
+// by the input at inIndex, replacing any existing claim fields.  The UTXO of
+// the input has to be known.  If it isn't a claim script, a txscript.Error with
+// the txscript.ErrNotClaimScript code is returned.
+func AddInClaim(u *Updater, inIndex int) error {
+	utxo := InputUtxo(u.Upsbt, inIndex)
+	if utxo == nil {
+		return ErrInvalidPsbtFormat
+	}
+	cs, err := claimscript.Parse(utxo.PkScript)
+	if err != nil {
+		return err
+	}
+
+	op := u.Upsbt.UnsignedTx.TxIn[inIndex].PreviousOutPoint
+	id := cs.ClaimIDFor(op)
+	claim := &Claim{
+		Name:    cs.Name,
+		ClaimID: id[:],
+	}
+	u.Upsbt.Inputs[inIndex].Unknowns = setClaim(
+		u.Upsbt.Inputs[inIndex].Unknowns, claim,
+	)
+	return u.Upsbt.SanityCheck()
+}
+
+// claimFromUnknowns returns the claim information held by the fields, or nil
+// if there is none.
+func claimFromUnknowns(unknowns []*Unknown) *Claim {
+	var claim *Claim
+	for _, u := range unknowns {
+		prefix, subtype, _, err := ParseProprietaryKey(u.Key)
+		if err != nil || string(prefix) != ClaimPrefix {
+			continue
+		}
+		if claim == nil {
+			claim = &Claim{}
+		}
+		switch subtype {
+		case ClaimNameSubtype:
+			claim.Name = u.Value
+		case ClaimIDSubtype:
+			claim.ClaimID = u.Value
+		}
+	}
+	return claim
+}
+
+// setClaim returns the fields with any claim fields replaced by those of the
+// passed claim.
+func setClaim(unknowns []*Unknown, claim *Claim) []*Unknown {
+	kept := unknowns[:0]
+	for _, u := range unknowns {
+		prefix, _, _, err := ParseProprietaryKey(u.Key)
+		if err == nil && string(prefix) == ClaimPrefix {
+			continue
+		}
+		kept = append(kept, u)
+	}
+
+	kept = append(kept, &Unknown{
+		Key:   ProprietaryKey([]byte(ClaimPrefix), ClaimNameSubtype, nil),
+		Value: claim.Name,
+	})
+	if claim.ClaimID != nil {
+		kept = append(kept, &Unknown{
+			Key:   ProprietaryKey([]byte(ClaimPrefix), ClaimIDSubtype, nil),
+			Value: claim.ClaimID,
+		})
+	}
+	return kept
+}
+
+// InputUtxo returns the output spent by the input at inIndex, or nil if the
+// PSBT doesn't include it.
+func InputUtxo(p *Packet, inIndex int) *wire.TxOut {
+	pInput := p.Inputs[inIndex]
+	switch {
+	case pInput.WitnessUtxo != nil:
+		return pInput.WitnessUtxo
+
+	case pInput.NonWitnessUtxo != nil:
+		outIndex := p.UnsignedTx.TxIn[inIndex].PreviousOutPoint.Index
+		if outIndex >= uint32(len(pInput.NonWitnessUtxo.TxOut)) {
+			return nil
+		}
+		return pInput.NonWitnessUtxo.TxOut[outIndex]
+	}
+	return nil
+}
+
+// Sign adds the partial signature of the public key to the input at inIndex,
+// along with the redeem and witness scripts when they are passed, like
+// Updater.Sign.  The inputs spending claim scripts are checked here, since the
+// script engine evaluates them as plain scripts, never as pay-to-script-hash
+// or witness scripts, so only an inner pay-to-script-hash redeem script is
+// checked against them.
+func Sign(u *Updater, inIndex int, sig []byte, pubKey []byte,
+	redeemScript []byte, witnessScript []byte) (SignOutcome, error) {
+
+	pkScript := claimInputScript(u.Upsbt, inIndex)
+	if pkScript == nil {
+		return u.Sign(inIndex, sig, pubKey, redeemScript, witnessScript)
+	}
+
+	pInput := &u.Upsbt.Inputs[inIndex]
+	if isFinalized(pInput) {
+		return SignFinalized, nil
+	}
+	if witnessScript != nil || pInput.WitnessScript != nil {
+		return SignInvalid, ErrInvalidSignatureForInput
+	}
+	if _, err := btcec.ParsePubKey(pubKey, btcec.S256()); err != nil {
+		return SignInvalid, ErrInvalidPsbtFormat
+	}
+	if _, err := btcec.ParseDERSignature(sig, btcec.S256()); err != nil {
+		return SignInvalid, ErrInvalidPsbtFormat
+	}
+	for _, ps := range pInput.PartialSigs {
+		if bytes.Equal(ps.PubKey, pubKey) {
+			return SignInvalid, ErrDuplicateKey
+		}
+	}
+
+	if redeemScript == nil {
+		redeemScript = pInput.RedeemScript
+	}
+	if redeemScript != nil {
+		inner := txscript.StripClaimScriptPrefix(pkScript)
+		scriptHashScript, err := txscript.NewScriptBuilder().
+			AddOp(txscript.OP_HASH160).
+			AddData(lbcutil.Hash160(redeemScript)).
+			AddOp(txscript.OP_EQUAL).
+			Script()
+		if err != nil {
+			return SignInvalid, err
+		}
+		if !bytes.Equal(scriptHashScript, inner) {
+			return SignInvalid, ErrInvalidSignatureForInput
+		}
+		pInput.RedeemScript = redeemScript
+	}
+
+	pInput.PartialSigs = append(pInput.PartialSigs, &PartialSig{
+		PubKey:    pubKey,
+		Signature: sig,
+	})
+	if err := u.Upsbt.SanityCheck(); err != nil {
+		return SignInvalid, err
+	}
+	return SignSuccesful, nil
+}
+
+// MaybeFinalize finalizes the input at inIndex if it can be, like the
+// MaybeFinalize function of lbcutil, and returns whether it was.
+func MaybeFinalize(p *Packet, inIndex int) (bool, error) {
+	pkScript := claimInputScript(p, inIndex)
+	if pkScript == nil {
+		return psbt.MaybeFinalize(p, inIndex)
+	}
+
+	if isFinalized(&p.Inputs[inIndex]) {
+		return true, nil
+	}
+	if !isFinalizableClaimInput(&p.Inputs[inIndex], pkScript) {
+		return false, ErrNotFinalizable
+	}
+	if err := finalizeClaimInput(p, inIndex, pkScript); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// MaybeFinalizeAll finalizes all the inputs of the PSBT, and returns an error
+// if one of them can't be.
+func MaybeFinalizeAll(p *Packet) error {
+	for i := range p.UnsignedTx.TxIn {
+		success, err := MaybeFinalize(p, i)
+		if err != nil || !success {
+			return err
+		}
+	}
+	return nil
+}
+
+// Finalize finalizes the input at inIndex, like the Finalize function of
+// lbcutil.
+func Finalize(p *Packet, inIndex int) error {
+	pkScript := claimInputScript(p, inIndex)
+	if pkScript == nil {
+		return psbt.Finalize(p, inIndex)
+	}
+	return finalizeClaimInput(p, inIndex, pkScript)
+}
+
+// isFinalized returns true if the input has its final scripts.
+func isFinalized(pInput *PInput) bool {
+	return pInput.FinalScriptSig != nil || pInput.FinalScriptWitness != nil
+}
+
+// claimInputScript returns the claim script spent by the input at inIndex, or
+// nil if it doesn't spend one or its UTXO is unknown.
+func claimInputScript(p *Packet, inIndex int) []byte {
+	utxo := InputUtxo(p, inIndex)
+	if utxo == nil || !claimscript.IsClaimScript(utxo.PkScript) {
+		return nil
+	}
+	return utxo.PkScript
+}
+
+// isFinalizableClaimInput returns true if the input spending the claim script
+// pkScript can be finalized.  The script engine evaluates claim scripts as
+// plain scripts, never as pay-to-script-hash or witness scripts, so only the
+// partial signatures (or the redeem script of an inner pay-to-script-hash)
+// are needed.
+func isFinalizableClaimInput(pInput *PInput, pkScript []byte) bool {
+	if pInput.WitnessScript != nil {
+		return false
+	}
+
+	inner := txscript.StripClaimScriptPrefix(pkScript)
+	switch txscript.GetScriptClass(inner) {
+	case txscript.PubKeyHashTy, txscript.PubKeyTy:
+		return len(pInput.PartialSigs) == 1
+	case txscript.MultiSigTy:
+		return len(pInput.PartialSigs) > 0
+	case txscript.ScriptHashTy:
+		return pInput.RedeemScript != nil
+	default:
+		return false
+	}
+}
+
+// finalizeClaimInput creates the final sigScript of the input at inIndex which
+// spends the claim script pkScript, and removes all other fields except for the
+// UTXO fields.
+func finalizeClaimInput(p *Packet, inIndex int, pkScript []byte) error {
+	pInput := p.Inputs[inIndex]
+	if isFinalized(&pInput) {
+		return ErrInputAlreadyFinalized
+	}
+
+	var (
+		pubKeys [][]byte
+		sigs    [][]byte
+	)
+	for _, ps := range pInput.PartialSigs {
+		if !checkSigHashFlags(ps.Signature, &pInput) {
+			return ErrInvalidSigHashFlags
+		}
+		pubKeys = append(pubKeys, ps.PubKey)
+		sigs = append(sigs, ps.Signature)
+	}
+
+	inner := txscript.StripClaimScriptPrefix(pkScript)
+	builder := txscript.NewScriptBuilder()
+	switch txscript.GetScriptClass(inner) {
+	case txscript.PubKeyHashTy:
+		if len(sigs) != 1 {
+			return ErrNotFinalizable
+		}
+		builder.AddData(sigs[0]).AddData(pubKeys[0])
+
+	case txscript.PubKeyTy:
+		if len(sigs) != 1 {
+			return ErrNotFinalizable
+		}
+		builder.AddData(sigs[0])
+
+	case txscript.MultiSigTy:
+		orderedSigs, err := orderMultiSigSignatures(inner, pubKeys, sigs)
+		if err != nil {
+			return err
+		}
+		builder.AddOp(txscript.OP_FALSE)
+		for _, sig := range orderedSigs {
+			builder.AddData(sig)
+		}
+
+	case txscript.ScriptHashTy:
+		// Only the hash of the redeem script is checked, see
+		// txscript.SignTxOutput.
+		if pInput.RedeemScript == nil {
+			return ErrNotFinalizable
+		}
+		builder.AddData(pInput.RedeemScript)
+
+	default:
+		return ErrUnsupportedScriptType
+	}
+
+	sigScript, err := builder.Script()
+	if err != nil {
+		return err
+	}
+
+	newInput := NewPsbtInput(pInput.NonWitnessUtxo, pInput.WitnessUtxo)
+	newInput.FinalScriptSig = sigScript
+	p.Inputs[inIndex] = *newInput
+
+	return nil
+}
+
+// checkSigHashFlags returns true if the sighash type of the signature is the
+// one of the input, which is SigHashAll when the input has none.
+func checkSigHashFlags(sig []byte, pInput *PInput) bool {
+	expectedSighashType := txscript.SigHashAll
+	if pInput.SighashType != 0 {
+		expectedSighashType = pInput.SighashType
+	}
+	return expectedSighashType == txscript.SigHashType(sig[len(sig)-1])
+}
+
+// orderMultiSigSignatures returns the signatures of the public keys in the
+// order of the keys of the multisig script, which the script engine requires.
+func orderMultiSigSignatures(script []byte, pubKeys, sigs [][]byte) ([][]byte, error) {
+	scriptPubKeys, err := txscript.PushedData(script)
+	if err != nil {
+		return nil, err
+	}
+
+	ordered := make([][]byte, 0, len(sigs))
+	for _, scriptPubKey := range scriptPubKeys {
+		for i, pubKey := range pubKeys {
+			if bytes.Equal(scriptPubKey, pubKey) {
+				ordered = append(ordered, sigs[i])
+				break
+			}
+		}
+	}
+	if len(ordered) != len(sigs) {
+		return nil, ErrInvalidSignatureForInput
+	}
+	return ordered, nil
+}
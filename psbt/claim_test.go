@@ -0,0 +1,144 @@
+package psbt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lbryio/lbcd/btcec"
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/claimtrie/change"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/txscript/claimscript"
+	"github.com/lbryio/lbcd/wire"
+	"github.com/lbryio/lbcutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProprietaryKey(t *testing.T) {
+
+	r := require.New(t)
+
+	key := ProprietaryKey([]byte(ClaimPrefix), ClaimIDSubtype, []byte{7})
+	r.Equal([]byte{0xfc, 4, 'l', 'b', 'r', 'y', ClaimIDSubtype, 7}, key)
+
+	prefix, subtype, keyData, err := ParseProprietaryKey(key)
+	r.NoError(err)
+	r.Equal([]byte(ClaimPrefix), prefix)
+	r.Equal(uint64(ClaimIDSubtype), subtype)
+	r.Equal([]byte{7}, keyData)
+
+	_, _, _, err = ParseProprietaryKey([]byte{0x00, 4, 'l'})
+	r.Error(err)
+}
+
+func TestClaimRoundTrip(t *testing.T) {
+
+	r := require.New(t)
+
+	privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), bytes.Repeat([]byte{1}, 32))
+	r.NotNil(privKey)
+	pubKey := privKey.PubKey().SerializeCompressed()
+	addr, err := lbcutil.NewAddressPubKeyHash(lbcutil.Hash160(pubKey), &chaincfg.RegressionNetParams)
+	r.NoError(err)
+	p2pkh, err := txscript.PayToAddrScript(addr)
+	r.NoError(err)
+
+	// The previous transaction creates a claim, which is updated by the
+	// transaction of the PSBT.
+	claimScript, err := claimscript.ClaimName("tester", []byte("value"), p2pkh)
+	r.NoError(err)
+	prevTx := wire.NewMsgTx(1)
+	prevTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+	prevTx.AddTxOut(wire.NewTxOut(100000, claimScript))
+	prevOut := wire.OutPoint{Hash: prevTx.TxHash(), Index: 0}
+	claimID := change.NewClaimID(prevOut)
+
+	updateScript, err := claimscript.UpdateClaim("tester", claimID, []byte("new value"), p2pkh)
+	r.NoError(err)
+	p, err := New([]*wire.OutPoint{&prevOut},
+		[]*wire.TxOut{wire.NewTxOut(90000, updateScript)}, 1, 0, []uint32{wire.MaxTxInSequenceNum})
+	r.NoError(err)
+
+	u, err := NewUpdater(p)
+	r.NoError(err)
+	r.NoError(u.AddInNonWitnessUtxo(prevTx, 0))
+	r.NoError(AddInClaim(u, 0))
+
+	// The claim fields have to survive serialization.
+	var buf bytes.Buffer
+	r.NoError(Serialize(p, &buf))
+	p, err = NewFromRawBytes(&buf, false)
+	r.NoError(err)
+
+	in := InputClaim(&p.Inputs[0])
+	r.NotNil(in)
+	r.Equal([]byte("tester"), in.Name)
+	r.Equal(claimID[:], in.ClaimID)
+	out := OutputClaim(p, 0)
+	r.NotNil(out)
+	r.Equal([]byte("tester"), out.Name)
+	r.Equal(claimID[:], out.ClaimID)
+
+	// Sign, finalize and extract the update, and check it against the
+	// script engine.
+	u, err = NewUpdater(p)
+	r.NoError(err)
+	sig, err := txscript.RawTxInSignature(p.UnsignedTx, 0, claimScript, txscript.SigHashAll, privKey)
+	r.NoError(err)
+	outcome, err := Sign(u, 0, sig, pubKey, nil, nil)
+	r.NoError(err)
+	r.Equal(SignOutcome(SignSuccesful), outcome)
+
+	r.NoError(MaybeFinalizeAll(p))
+	r.True(p.IsComplete())
+	tx, err := Extract(p)
+	r.NoError(err)
+
+	vm, err := txscript.NewEngine(claimScript, tx, 0, txscript.StandardVerifyFlags, nil, nil, 100000)
+	r.NoError(err)
+	r.NoError(vm.Execute())
+}
+
+func TestFinalizeClaimMultiSig(t *testing.T) {
+
+	r := require.New(t)
+
+	var keys []*btcec.PrivateKey
+	var pubKeys [][]byte
+	builder := txscript.NewScriptBuilder().AddOp(txscript.OP_2)
+	for i := byte(1); i <= 3; i++ {
+		key, _ := btcec.PrivKeyFromBytes(btcec.S256(), bytes.Repeat([]byte{i}, 32))
+		keys = append(keys, key)
+		pubKeys = append(pubKeys, key.PubKey().SerializeCompressed())
+		builder.AddData(pubKeys[i-1])
+	}
+	multiSig, err := builder.AddOp(txscript.OP_3).AddOp(txscript.OP_CHECKMULTISIG).Script()
+	r.NoError(err)
+
+	supportScript, err := claimscript.SupportClaim("tester", change.ClaimID{1}, multiSig)
+	r.NoError(err)
+	prevOut := wire.OutPoint{Index: 3}
+	p, err := New([]*wire.OutPoint{&prevOut},
+		[]*wire.TxOut{wire.NewTxOut(1000, multiSig)}, 1, 0, []uint32{wire.MaxTxInSequenceNum})
+	r.NoError(err)
+
+	u, err := NewUpdater(p)
+	r.NoError(err)
+	r.NoError(u.AddInWitnessUtxo(wire.NewTxOut(2000, supportScript), 0))
+
+	// Sign in the reverse order of the keys in the script.
+	for _, i := range []int{2, 0} {
+		sig, err := txscript.RawTxInSignature(p.UnsignedTx, 0, supportScript, txscript.SigHashAll, keys[i])
+		r.NoError(err)
+		_, err = Sign(u, 0, sig, pubKeys[i], nil, nil)
+		r.NoError(err)
+	}
+
+	r.NoError(MaybeFinalizeAll(p))
+	tx, err := Extract(p)
+	r.NoError(err)
+
+	vm, err := txscript.NewEngine(supportScript, tx, 0, txscript.StandardVerifyFlags, nil, nil, 2000)
+	r.NoError(err)
+	r.NoError(vm.Execute())
+}
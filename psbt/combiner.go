@@ -0,0 +1,180 @@
+package psbt
+
+// The Combiner role of BIP 174 merges the fields of PSBTs of the same
+// transaction, such as the partial signatures of a multisig input collected
+// from several signers.
+
+import (
+	"bytes"
+	"errors"
+)
+
+// ErrDifferentTransactions is returned by Combine when the PSBTs don't share
+// the same unsigned transaction.
+var ErrDifferentTransactions = errors.New("Cannot combine PSBTs of " +
+	"different transactions")
+
+// Combine returns a new PSBT holding the union of the fields of the passed
+// PSBTs, which have to share the same unsigned transaction.  The fields of the
+// first PSBT having a value are kept, and the lists of partial signatures,
+// BIP32 derivations and unknowns are merged by key.  The inputs finalized in
+// any of the PSBTs are finalized in the result.  The passed PSBTs are not
+// modified.
+func Combine(packets ...*Packet) (*Packet, error) {
+	if len(packets) == 0 {
+		return nil, ErrInvalidPsbtFormat
+	}
+
+	// The result starts as a copy of the first PSBT, so that neither its
+	// slices nor its transaction are shared.
+	var buf bytes.Buffer
+	if err := Serialize(packets[0], &buf); err != nil {
+		return nil, err
+	}
+	combined, err := NewFromRawBytes(&buf, false)
+	if err != nil {
+		return nil, err
+	}
+
+	txHash := combined.UnsignedTx.TxHash()
+	for _, p := range packets[1:] {
+		if p.UnsignedTx.TxHash() != txHash ||
+			len(p.Inputs) != len(combined.Inputs) ||
+			len(p.Outputs) != len(combined.Outputs) {
+
+			return nil, ErrDifferentTransactions
+		}
+
+		for i := range p.Inputs {
+			combineInput(&combined.Inputs[i], &p.Inputs[i])
+		}
+		for i := range p.Outputs {
+			combineOutput(&combined.Outputs[i], &p.Outputs[i])
+		}
+		combined.Unknowns = combineGlobalUnknowns(combined.Unknowns,
+			p.Unknowns)
+	}
+
+	if err := combined.SanityCheck(); err != nil {
+		return nil, err
+	}
+	return combined, nil
+}
+
+// combineInput adds the fields of src missing from dst.
+func combineInput(dst, src *PInput) {
+	if dst.NonWitnessUtxo == nil {
+		dst.NonWitnessUtxo = src.NonWitnessUtxo
+	}
+	if dst.WitnessUtxo == nil {
+		dst.WitnessUtxo = src.WitnessUtxo
+	}
+	if dst.FinalScriptSig == nil {
+		dst.FinalScriptSig = src.FinalScriptSig
+	}
+	if dst.FinalScriptWitness == nil {
+		dst.FinalScriptWitness = src.FinalScriptWitness
+	}
+	dst.Unknowns = combineUnknowns(dst.Unknowns, src.Unknowns)
+
+	// A finalized input only keeps its UTXO and final scripts, as
+	// Finalize leaves it.
+	if dst.FinalScriptSig != nil || dst.FinalScriptWitness != nil {
+		dst.PartialSigs = nil
+		dst.SighashType = 0
+		dst.RedeemScript = nil
+		dst.WitnessScript = nil
+		dst.Bip32Derivation = nil
+		return
+	}
+
+	for _, sig := range src.PartialSigs {
+		var found bool
+		for _, existing := range dst.PartialSigs {
+			if bytes.Equal(existing.PubKey, sig.PubKey) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst.PartialSigs = append(dst.PartialSigs, sig)
+		}
+	}
+	if dst.SighashType == 0 {
+		dst.SighashType = src.SighashType
+	}
+	if dst.RedeemScript == nil {
+		dst.RedeemScript = src.RedeemScript
+	}
+	if dst.WitnessScript == nil {
+		dst.WitnessScript = src.WitnessScript
+	}
+	dst.Bip32Derivation = combineBip32Derivations(dst.Bip32Derivation,
+		src.Bip32Derivation)
+}
+
+// combineOutput adds the fields of src missing from dst.
+func combineOutput(dst, src *POutput) {
+	if dst.RedeemScript == nil {
+		dst.RedeemScript = src.RedeemScript
+	}
+	if dst.WitnessScript == nil {
+		dst.WitnessScript = src.WitnessScript
+	}
+	dst.Bip32Derivation = combineBip32Derivations(dst.Bip32Derivation,
+		src.Bip32Derivation)
+}
+
+// combineBip32Derivations returns dst with the derivations of src whose public
+// key it lacks appended.
+func combineBip32Derivations(dst, src []*Bip32Derivation) []*Bip32Derivation {
+	for _, derivation := range src {
+		var found bool
+		for _, existing := range dst {
+			if bytes.Equal(existing.PubKey, derivation.PubKey) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst = append(dst, derivation)
+		}
+	}
+	return dst
+}
+
+// combineUnknowns returns dst with the fields of src whose key it lacks
+// appended.
+func combineUnknowns(dst, src []*Unknown) []*Unknown {
+	for _, u := range src {
+		var found bool
+		for _, existing := range dst {
+			if bytes.Equal(existing.Key, u.Key) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst = append(dst, u)
+		}
+	}
+	return dst
+}
+
+// combineGlobalUnknowns returns dst with the global fields of src whose key it
+// lacks appended.
+func combineGlobalUnknowns(dst, src []Unknown) []Unknown {
+	for _, u := range src {
+		var found bool
+		for _, existing := range dst {
+			if bytes.Equal(existing.Key, u.Key) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst = append(dst, u)
+		}
+	}
+	return dst
+}
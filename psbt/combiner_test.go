@@ -0,0 +1,85 @@
+package psbt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lbryio/lbcd/btcec"
+	"github.com/lbryio/lbcd/claimtrie/change"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/txscript/claimscript"
+	"github.com/lbryio/lbcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCombine(t *testing.T) {
+
+	r := require.New(t)
+
+	var keys []*btcec.PrivateKey
+	var pubKeys [][]byte
+	builder := txscript.NewScriptBuilder().AddOp(txscript.OP_2)
+	for i := byte(1); i <= 3; i++ {
+		key, _ := btcec.PrivKeyFromBytes(btcec.S256(), bytes.Repeat([]byte{i}, 32))
+		keys = append(keys, key)
+		pubKeys = append(pubKeys, key.PubKey().SerializeCompressed())
+		builder.AddData(pubKeys[i-1])
+	}
+	multiSig, err := builder.AddOp(txscript.OP_3).AddOp(txscript.OP_CHECKMULTISIG).Script()
+	r.NoError(err)
+
+	supportScript, err := claimscript.SupportClaim("tester", change.ClaimID{1}, multiSig)
+	r.NoError(err)
+	prevOut := wire.OutPoint{Index: 3}
+	unsigned, err := New([]*wire.OutPoint{&prevOut},
+		[]*wire.TxOut{wire.NewTxOut(1000, multiSig)}, 1, 0, []uint32{wire.MaxTxInSequenceNum})
+	r.NoError(err)
+	u, err := NewUpdater(unsigned)
+	r.NoError(err)
+	r.NoError(u.AddInWitnessUtxo(wire.NewTxOut(2000, supportScript), 0))
+
+	// Each signer signs its own copy of the PSBT.
+	sign := func(i int) *Packet {
+		var buf bytes.Buffer
+		r.NoError(Serialize(unsigned, &buf))
+		p, err := NewFromRawBytes(&buf, false)
+		r.NoError(err)
+		u, err := NewUpdater(p)
+		r.NoError(err)
+		sig, err := txscript.RawTxInSignature(p.UnsignedTx, 0, supportScript, txscript.SigHashAll, keys[i])
+		r.NoError(err)
+		_, err = Sign(u, 0, sig, pubKeys[i], nil, nil)
+		r.NoError(err)
+		return p
+	}
+	first, second := sign(0), sign(2)
+
+	combined, err := Combine(first, second, first)
+	r.NoError(err)
+	r.Len(combined.Inputs[0].PartialSigs, 2)
+	r.Len(first.Inputs[0].PartialSigs, 1)
+
+	// The combined PSBT is finalized, and combined again with a copy which
+	// isn't.
+	r.NoError(MaybeFinalizeAll(combined))
+	combined, err = Combine(second, combined)
+	r.NoError(err)
+	r.True(combined.IsComplete())
+	r.Nil(combined.Inputs[0].PartialSigs)
+
+	tx, err := Extract(combined)
+	r.NoError(err)
+	vm, err := txscript.NewEngine(supportScript, tx, 0, txscript.StandardVerifyFlags, nil, nil, 2000)
+	r.NoError(err)
+	r.NoError(vm.Execute())
+
+	// The PSBTs of different transactions can't be combined.
+	other, err := New([]*wire.OutPoint{{Index: 4}},
+		[]*wire.TxOut{wire.NewTxOut(1000, multiSig)}, 1, 0, []uint32{wire.MaxTxInSequenceNum})
+	r.NoError(err)
+	_, err = Combine(first, other)
+	r.Equal(ErrDifferentTransactions, err)
+
+	_, err = Combine()
+	r.Error(err)
+}
@@ -0,0 +1,143 @@
+// Package psbt extends the BIP 174 partially signed transactions of the psbt
+// package of lbcutil with the claim scripts of LBRY.
+//
+// The types of lbcutil are used as they are.  The inputs spending claims,
+// supports and updates are signed by Sign and finalized by MaybeFinalize,
+// MaybeFinalizeAll and Finalize here, since lbcutil only knows them as legacy
+// or witness scripts, and the claim information of the inputs is kept in
+// proprietary fields.  The PSBTs are decoded by NewFromRawBytes, which rejects
+// the duplicate keys of unknown fields as BIP 174 requires, and encoded by
+// Serialize and B64Encode, which keep the unknown global fields.
+package psbt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+
+	"github.com/lbryio/lbcd/wire"
+	"github.com/lbryio/lbcutil/psbt"
+)
+
+// The types of the psbt package of lbcutil.
+type (
+	Packet          = psbt.Packet
+	PInput          = psbt.PInput
+	POutput         = psbt.POutput
+	PartialSig      = psbt.PartialSig
+	Bip32Derivation = psbt.Bip32Derivation
+	Unknown         = psbt.Unknown
+	Updater         = psbt.Updater
+	SignOutcome     = psbt.SignOutcome
+)
+
+const (
+	// MinTxVersion is the lowest transaction version of a PSBT.
+	MinTxVersion = psbt.MinTxVersion
+
+	// MaxPsbtKeyLength is the length of the largest key of a field.
+	MaxPsbtKeyLength = psbt.MaxPsbtKeyLength
+
+	// ProprietaryInputType is the key type of the proprietary fields of
+	// the inputs.
+	ProprietaryInputType = psbt.ProprietaryInputType
+
+	// The outcomes of Sign.
+	SignSuccesful = psbt.SignSuccesful
+	SignFinalized = psbt.SignFinalized
+	SignInvalid   = psbt.SignInvalid
+)
+
+// The errors of the psbt package of lbcutil.
+var (
+	ErrInvalidPsbtFormat        = psbt.ErrInvalidPsbtFormat
+	ErrDuplicateKey             = psbt.ErrDuplicateKey
+	ErrInvalidKeydata           = psbt.ErrInvalidKeydata
+	ErrInvalidSignatureForInput = psbt.ErrInvalidSignatureForInput
+	ErrInputAlreadyFinalized    = psbt.ErrInputAlreadyFinalized
+	ErrNotFinalizable           = psbt.ErrNotFinalizable
+	ErrInvalidSigHashFlags      = psbt.ErrInvalidSigHashFlags
+	ErrUnsupportedScriptType    = psbt.ErrUnsupportedScriptType
+)
+
+// The functions of the psbt package of lbcutil which don't depend on the
+// scripts of the inputs.
+var (
+	New                = psbt.New
+	NewFromUnsignedTx  = psbt.NewFromUnsignedTx
+	NewUpdater         = psbt.NewUpdater
+	NewPsbtInput       = psbt.NewPsbtInput
+	Extract            = psbt.Extract
+	SumUtxoInputValues = psbt.SumUtxoInputValues
+)
+
+// psbtMagic is the magic bytes every PSBT starts with.
+var psbtMagic = []byte{0x70, 0x73, 0x62, 0x74, 0xff}
+
+// NewFromRawBytes decodes a PSBT, which is base64 encoded when b64 is true.
+// Unlike lbcutil, which only rejects the unknown fields duplicating both the
+// key and the value of another one, the unknown fields may not have the same
+// key, as BIP 174 requires of all the fields of a map.
+func NewFromRawBytes(r io.Reader, b64 bool) (*Packet, error) {
+	p, err := psbt.NewFromRawBytes(r, b64)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]struct{}, len(p.Unknowns))
+	for _, u := range p.Unknowns {
+		if _, ok := keys[string(u.Key)]; ok {
+			return nil, ErrDuplicateKey
+		}
+		keys[string(u.Key)] = struct{}{}
+	}
+	for _, pInput := range p.Inputs {
+		keys := make(map[string]struct{}, len(pInput.Unknowns))
+		for _, u := range pInput.Unknowns {
+			if _, ok := keys[string(u.Key)]; ok {
+				return nil, ErrDuplicateKey
+			}
+			keys[string(u.Key)] = struct{}{}
+		}
+	}
+	return p, nil
+}
+
+// Serialize writes the serialization of the PSBT like Packet.Serialize, along
+// with its unknown global fields, which Packet.Serialize leaves out.
+func Serialize(p *Packet, w io.Writer) error {
+	var buf bytes.Buffer
+	if err := p.Serialize(&buf); err != nil {
+		return err
+	}
+	serialized := buf.Bytes()
+
+	// The global section starts with the unsigned transaction, whose key
+	// is a single byte, and the unknown global fields follow it.
+	txSize := p.UnsignedTx.SerializeSize()
+	globalEnd := len(psbtMagic) + 2 +
+		wire.VarIntSerializeSize(uint64(txSize)) + txSize
+	if _, err := w.Write(serialized[:globalEnd]); err != nil {
+		return err
+	}
+	for _, u := range p.Unknowns {
+		if err := wire.WriteVarBytes(w, 0, u.Key); err != nil {
+			return err
+		}
+		if err := wire.WriteVarBytes(w, 0, u.Value); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(serialized[globalEnd:])
+	return err
+}
+
+// B64Encode returns the base64 encoding of the serialization of the PSBT by
+// Serialize.
+func B64Encode(p *Packet) (string, error) {
+	var buf bytes.Buffer
+	if err := Serialize(p, &buf); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
@@ -0,0 +1,52 @@
+package psbt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lbryio/lbcd/wire"
+	"github.com/lbryio/lbcutil/psbt"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobalUnknownSerialization(t *testing.T) {
+
+	r := require.New(t)
+
+	p, err := New([]*wire.OutPoint{{Index: 1}},
+		[]*wire.TxOut{wire.NewTxOut(1000, []byte{0x51})}, 2, 0, []uint32{wire.MaxTxInSequenceNum})
+	r.NoError(err)
+	p.Unknowns = []Unknown{{Key: []byte{0xf0, 0x01}, Value: []byte{0x02}}}
+
+	b64, err := B64Encode(p)
+	r.NoError(err)
+	p2, err := NewFromRawBytes(bytes.NewReader([]byte(b64)), true)
+	r.NoError(err)
+	r.Equal(p.Unknowns, p2.Unknowns)
+	r.Equal(p.UnsignedTx.TxHash(), p2.UnsignedTx.TxHash())
+	r.Len(p2.Inputs, 1)
+	r.Len(p2.Outputs, 1)
+}
+
+func TestDuplicateUnknownKeys(t *testing.T) {
+
+	r := require.New(t)
+
+	p, err := New([]*wire.OutPoint{{Index: 1}},
+		[]*wire.TxOut{wire.NewTxOut(1000, []byte{0x51})}, 2, 0, []uint32{wire.MaxTxInSequenceNum})
+	r.NoError(err)
+	key := ProprietaryKey([]byte(ClaimPrefix), ClaimNameSubtype, nil)
+	p.Inputs[0].Unknowns = []*Unknown{
+		{Key: key, Value: []byte("first")},
+		{Key: key, Value: []byte("second")},
+	}
+
+	// The fields only differing by their value are accepted by lbcutil,
+	// but BIP 174 requires the keys of a map to be unique.
+	var buf bytes.Buffer
+	r.NoError(Serialize(p, &buf))
+	_, err = psbt.NewFromRawBytes(bytes.NewReader(buf.Bytes()), false)
+	r.NoError(err)
+	_, err = NewFromRawBytes(bytes.NewReader(buf.Bytes()), false)
+	r.Equal(ErrDuplicateKey, err)
+}
@@ -19,6 +19,10 @@ func toGB(n uint64) float64 {
 func dirSize(path string) (int64, error) {
 	var size int64
 	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		// Skip the files removed while walking, such as compacted tables.
+		if os.IsNotExist(err) {
+			return nil
+		}
 		if err != nil {
 			return err
 		}
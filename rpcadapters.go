@@ -44,6 +44,15 @@ func (p *rpcPeer) IsTxRelayDisabled() bool {
 	return (*serverPeer)(p).disableRelayTx
 }
 
+// IsBlockRelayOnly returns whether or not the peer is a block-relay-only
+// outbound peer.
+//
+// This function is safe for concurrent access and is part of the rpcserverPeer
+// interface implementation.
+func (p *rpcPeer) IsBlockRelayOnly() bool {
+	return (*serverPeer)(p).blockRelayOnly
+}
+
 // BanScore returns the current integer value that represents how close the peer
 // is to being banned.
 //
@@ -270,9 +279,10 @@ func (cm *rpcConnManager) AddRebroadcastInventory(iv *wire.InvVect, data interfa
 }
 
 // RelayTransactions generates and relays inventory vectors for all of the
-// passed transactions to all connected peers.
+// passed transactions submitted locally, through a single relay peer first
+// when the one-hop relay is enabled.
 func (cm *rpcConnManager) RelayTransactions(txns []*mempool.TxDesc) {
-	cm.server.relayTransactions(txns)
+	cm.server.relayLocalTransactions(txns)
 }
 
 // NodeAddresses returns an array consisting node addresses which can
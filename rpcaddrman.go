@@ -0,0 +1,88 @@
+package main
+
+import (
+	"github.com/lbryio/lbcd/addrmgr"
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/wire"
+)
+
+// handleExportAddrMan implements the exportaddrman command.  The addresses are
+// exported without the secret key of the address manager placing them in
+// their buckets.
+func handleExportAddrMan(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	pf := s.cfg.AddrMgr.Export()
+
+	result := &btcjson.AddrManPeers{
+		Version:   pf.Version,
+		Addresses: make([]btcjson.AddrManAddress, 0, len(pf.Addresses)),
+	}
+	for _, pa := range pf.Addresses {
+		result.Addresses = append(result.Addresses, btcjson.AddrManAddress{
+			Addr:        pa.Addr,
+			Services:    uint64(pa.Services),
+			Src:         pa.Src,
+			SrcServices: uint64(pa.SrcServices),
+			TimeStamp:   pa.TimeStamp,
+			Attempts:    pa.Attempts,
+			LastAttempt: pa.LastAttempt,
+			LastSuccess: pa.LastSuccess,
+			Tried:       pa.Tried,
+			Buckets:     pa.Buckets,
+		})
+	}
+	return result, nil
+}
+
+// handleGetAddrManInfo implements the getaddrmaninfo command.
+func handleGetAddrManInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	counts := s.cfg.AddrMgr.NetworkCounts()
+
+	result := &btcjson.GetAddrManInfoResult{}
+	for network, r := range map[string]*btcjson.AddrManCounts{
+		"ipv4":  &result.IPv4,
+		"ipv6":  &result.IPv6,
+		"onion": &result.Onion,
+	} {
+		c := counts[network]
+		r.New, r.Tried, r.Total = c.New, c.Tried, c.New+c.Tried
+		result.AllNetworks.New += c.New
+		result.AllNetworks.Tried += c.Tried
+		result.AllNetworks.Total += c.New + c.Tried
+	}
+	return result, nil
+}
+
+// handleImportAddrMan implements the importaddrman command.  The unknown
+// routable addresses are added to the new table, and the number of addresses
+// added is returned.
+func handleImportAddrMan(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.ImportAddrManCmd)
+
+	pf := &addrmgr.PeersFile{
+		Version:   c.Peers.Version,
+		Addresses: make([]*addrmgr.PeerAddress, 0, len(c.Peers.Addresses)),
+	}
+	for _, a := range c.Peers.Addresses {
+		pf.Addresses = append(pf.Addresses, &addrmgr.PeerAddress{
+			Addr:        a.Addr,
+			Services:    wire.ServiceFlag(a.Services),
+			Src:         a.Src,
+			SrcServices: wire.ServiceFlag(a.SrcServices),
+			TimeStamp:   a.TimeStamp,
+			Attempts:    a.Attempts,
+			LastAttempt: a.LastAttempt,
+			LastSuccess: a.LastSuccess,
+			Tried:       a.Tried,
+			Buckets:     a.Buckets,
+		})
+	}
+
+	added, err := s.cfg.AddrMgr.Import(pf)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: err.Error(),
+		}
+	}
+	return added, nil
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// rpcAuthSaltSize is the size of the random salt generated for the users
+// specified by --rpcuser and --rpclimituser.
+const rpcAuthSaltSize = 16
+
+// rpcAuthUser is a user of the RPC server.  Only a salted hash of the password
+// is kept, in the format of the rpcauth entries: the HMAC-SHA256 of the
+// password keyed by the salt.
+type rpcAuthUser struct {
+	name string
+	salt string
+	hash []byte
+
+	// whitelist holds the commands the user is allowed to call.  A nil
+	// whitelist allows all commands.
+	whitelist map[string]struct{}
+}
+
+// allowed returns whether the user may call the passed command.
+func (u *rpcAuthUser) allowed(method string) bool {
+	if u.whitelist == nil {
+		return true
+	}
+	_, ok := u.whitelist[method]
+	return ok
+}
+
+// matches returns whether the passed password is the one of the user.
+func (u *rpcAuthUser) matches(pass string) bool {
+	return hmac.Equal(rpcAuthHash(u.salt, pass), u.hash)
+}
+
+// rpcAuthHash returns the hash of the password kept by rpcauth entries.  It
+// matches the one of the rpcauth.py script shipped with Bitcoin Core.
+func rpcAuthHash(salt, pass string) []byte {
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(pass))
+	return mac.Sum(nil)
+}
+
+// newRPCAuthUser returns a user with the passed plain text password, hashed
+// with a random salt.
+func newRPCAuthUser(name, pass string) (*rpcAuthUser, error) {
+	var salt [rpcAuthSaltSize]byte
+	if _, err := rand.Read(salt[:]); err != nil {
+		return nil, err
+	}
+	saltHex := hex.EncodeToString(salt[:])
+	return &rpcAuthUser{
+		name: name,
+		salt: saltHex,
+		hash: rpcAuthHash(saltHex, pass),
+	}, nil
+}
+
+// parseRPCAuth parses an rpcauth entry of the form <user>:<salt>$<hash>, where
+// hash is the hex-encoded HMAC-SHA256 of the password keyed by salt.
+func parseRPCAuth(entry string) (*rpcAuthUser, error) {
+	name, cred, ok := strings.Cut(entry, ":")
+	if !ok || name == "" {
+		return nil, fmt.Errorf("rpcauth entry %q is not of the form "+
+			"<user>:<salt>$<hash>", entry)
+	}
+	salt, hashHex, ok := strings.Cut(cred, "$")
+	if !ok || salt == "" {
+		return nil, fmt.Errorf("rpcauth entry for user %q is not of "+
+			"the form <user>:<salt>$<hash>", name)
+	}
+	hash, err := hex.DecodeString(hashHex)
+	if err != nil || len(hash) != sha256.Size {
+		return nil, fmt.Errorf("rpcauth entry for user %q does not "+
+			"have a hex-encoded %d byte hash", name, sha256.Size)
+	}
+	return &rpcAuthUser{name: name, salt: salt, hash: hash}, nil
+}
+
+// parseRPCWhitelist parses an rpcwhitelist entry of the form
+// <user>:<command>,<command>,... and returns the user and the commands.  An
+// empty list of commands denies all of them.
+func parseRPCWhitelist(entry string) (string, []string, error) {
+	name, list, ok := strings.Cut(entry, ":")
+	if !ok || name == "" {
+		return "", nil, fmt.Errorf("rpcwhitelist entry %q is not of "+
+			"the form <user>:<command>,<command>,...", entry)
+	}
+
+	var methods []string
+	for _, method := range strings.Split(list, ",") {
+		method = strings.TrimSpace(method)
+		if method == "" {
+			continue
+		}
+		_, ok := rpcHandlers[method]
+		if !ok {
+			_, ok = wsHandlers[method]
+		}
+		if !ok {
+			return "", nil, fmt.Errorf("rpcwhitelist entry for "+
+				"user %q contains unknown command %q", name,
+				method)
+		}
+		methods = append(methods, method)
+	}
+	return name, methods, nil
+}
+
+// rpcAuthUsers returns the users of the RPC server specified by the
+// configuration.  The user of --rpcuser may call every command, the one of
+// --rpclimituser only those of rpcLimited, and those of --rpcauth the
+// commands of their --rpcwhitelist entries, or every command when they have
+// none.  Multiple whitelist entries of a user are merged.
+func rpcAuthUsers(cfg *config) ([]*rpcAuthUser, error) {
+	var users []*rpcAuthUser
+	byName := make(map[string]*rpcAuthUser)
+	add := func(user *rpcAuthUser) error {
+		if _, ok := byName[user.name]; ok {
+			return fmt.Errorf("RPC user %q is specified more than "+
+				"once", user.name)
+		}
+		users = append(users, user)
+		byName[user.name] = user
+		return nil
+	}
+
+	if cfg.RPCUser != "" && cfg.RPCPass != "" {
+		user, err := newRPCAuthUser(cfg.RPCUser, cfg.RPCPass)
+		if err != nil {
+			return nil, err
+		}
+		if err := add(user); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.RPCLimitUser != "" && cfg.RPCLimitPass != "" {
+		user, err := newRPCAuthUser(cfg.RPCLimitUser, cfg.RPCLimitPass)
+		if err != nil {
+			return nil, err
+		}
+		user.whitelist = make(map[string]struct{}, len(rpcLimited))
+		for method := range rpcLimited {
+			user.whitelist[method] = struct{}{}
+		}
+		if err := add(user); err != nil {
+			return nil, err
+		}
+	}
+	for _, entry := range cfg.RPCAuth {
+		user, err := parseRPCAuth(entry)
+		if err != nil {
+			return nil, err
+		}
+		if err := add(user); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, entry := range cfg.RPCWhitelist {
+		name, methods, err := parseRPCWhitelist(entry)
+		if err != nil {
+			return nil, err
+		}
+		user, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("rpcwhitelist entry for unknown "+
+				"user %q", name)
+		}
+		if user.name == cfg.RPCUser || user.name == cfg.RPCLimitUser {
+			return nil, fmt.Errorf("rpcwhitelist entry for user "+
+				"%q, which is not specified by rpcauth", name)
+		}
+		if user.whitelist == nil {
+			user.whitelist = make(map[string]struct{}, len(methods))
+		}
+		for _, method := range methods {
+			user.whitelist[method] = struct{}{}
+		}
+	}
+
+	return users, nil
+}
+
+// checkCredentials returns the RPC user with the passed name and password, or
+// nil if there is none.  Every user is checked, so the time taken doesn't
+// depend on which of them, if any, matches.
+func (s *rpcServer) checkCredentials(name, pass string) *rpcAuthUser {
+	var found *rpcAuthUser
+	for _, user := range s.authUsers {
+		nameCmp := subtle.ConstantTimeCompare([]byte(name), []byte(user.name))
+		if user.matches(pass) && nameCmp == 1 {
+			found = user
+		}
+	}
+	return found
+}
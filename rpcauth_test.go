@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRPCAuth(t *testing.T) {
+
+	r := require.New(t)
+
+	hash := hex.EncodeToString(rpcAuthHash("cafe", "secret"))
+	user, err := parseRPCAuth("explorer:cafe$" + hash)
+	r.NoError(err)
+	r.Equal("explorer", user.name)
+	r.True(user.matches("secret"))
+	r.False(user.matches("Secret"))
+
+	for _, entry := range []string{
+		"explorer",
+		":cafe$" + hash,
+		"explorer:cafe",
+		"explorer:$" + hash,
+		"explorer:cafe$" + hash[2:],
+		"explorer:cafe$zz" + hash[2:],
+	} {
+		_, err = parseRPCAuth(entry)
+		r.Error(err, entry)
+	}
+}
+
+func TestRPCAuthUsers(t *testing.T) {
+
+	r := require.New(t)
+
+	hash := hex.EncodeToString(rpcAuthHash("cafe", "secret"))
+	c := &config{
+		RPCUser:      "admin",
+		RPCPass:      "adminpass",
+		RPCLimitUser: "limited",
+		RPCLimitPass: "limitedpass",
+		RPCAuth:      []string{"explorer:cafe$" + hash, "other:cafe$" + hash},
+		RPCWhitelist: []string{"explorer:getblock, getblockhash", "explorer:getinfo"},
+	}
+	users, err := rpcAuthUsers(c)
+	r.NoError(err)
+	r.Len(users, 4)
+
+	s := &rpcServer{authUsers: users}
+	admin := s.checkCredentials("admin", "adminpass")
+	r.NotNil(admin)
+	r.True(admin.allowed("stop"))
+	r.Nil(s.checkCredentials("admin", "limitedpass"))
+
+	limited := s.checkCredentials("limited", "limitedpass")
+	r.NotNil(limited)
+	r.True(limited.allowed("getblock"))
+	r.False(limited.allowed("stop"))
+
+	explorer := s.checkCredentials("explorer", "secret")
+	r.NotNil(explorer)
+	r.True(explorer.allowed("getblock"))
+	r.True(explorer.allowed("getblockhash"))
+	r.True(explorer.allowed("getinfo"))
+	r.False(explorer.allowed("getbestblock"))
+	r.False(explorer.allowed("stop"))
+
+	other := s.checkCredentials("other", "secret")
+	r.NotNil(other)
+	r.True(other.allowed("stop"))
+
+	for _, whitelist := range []string{
+		"unknown:getblock",
+		"explorer:nosuchcommand",
+		"admin:getblock",
+		"explorer",
+	} {
+		c.RPCWhitelist = []string{whitelist}
+		_, err = rpcAuthUsers(c)
+		r.Error(err, whitelist)
+	}
+
+	c.RPCWhitelist = nil
+	c.RPCAuth = append(c.RPCAuth, "admin:cafe$"+hash)
+	_, err = rpcAuthUsers(c)
+	r.Error(err)
+}
+
+func TestParseBasicAuth(t *testing.T) {
+
+	r := require.New(t)
+
+	login := base64.StdEncoding.EncodeToString([]byte("user:pass:word"))
+	name, pass, ok := parseBasicAuth("Basic " + login)
+	r.True(ok)
+	r.Equal("user", name)
+	r.Equal("pass:word", pass)
+
+	_, _, ok = parseBasicAuth("Bearer " + login)
+	r.False(ok)
+	_, _, ok = parseBasicAuth("Basic !!")
+	r.False(ok)
+}
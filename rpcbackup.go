@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/lbryio/lbcd/btcjson"
+)
+
+// backupsDirname is the directory of the data directory the backups with a
+// relative destination are written to.
+const backupsDirname = "backups"
+
+// rpcserverDBBackup is the interface of the block databases which can be
+// backed up while in use.
+type rpcserverDBBackup interface {
+	Backup(dir string) error
+}
+
+// backupDestination returns the directory a backup is written to, creating it
+// when missing.  Relative destinations are relative to the backups directory of
+// the data directory.
+func backupDestination(dest string) (string, error) {
+	if dest == "" {
+		return "", &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "The destination must not be empty",
+		}
+	}
+	dest = cleanAndExpandPath(dest)
+	if !filepath.IsAbs(dest) {
+		dest = filepath.Join(cfg.DataDir, backupsDirname, dest)
+	}
+	if err := os.MkdirAll(dest, 0700); err != nil {
+		context := "Failed to create backup directory"
+		return "", internalRPCError(err.Error(), context)
+	}
+	return dest, nil
+}
+
+// backupTarget returns the path a backup is written to within the destination
+// directory, which must not exist yet.
+func backupTarget(dest, name string) (string, error) {
+	dest, err := backupDestination(dest)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dest, name)
+	if _, err := os.Stat(path); err == nil {
+		return "", &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("%s already exists", path),
+		}
+	}
+	return path, nil
+}
+
+// startBackup prevents backups from running concurrently.  The returned
+// function must be called once the backup is done.
+func (s *rpcServer) startBackup() (func(), error) {
+	if !atomic.CompareAndSwapInt32(&s.backingUp, 0, 1) {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "A backup is already running",
+		}
+	}
+	return func() { atomic.StoreInt32(&s.backingUp, 0) }, nil
+}
+
+// handleBackupChainState implements the backupchainstate command.
+func handleBackupChainState(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.BackupChainStateCmd)
+
+	db, ok := s.cfg.DB.(rpcserverDBBackup)
+	if !ok {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCMisc,
+			Message: fmt.Sprintf("The %s database doesn't support "+
+				"backups", cfg.DbType),
+		}
+	}
+
+	done, err := s.startBackup()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	path, err := backupTarget(c.Destination,
+		filepath.Base(blockDbPath(cfg.DbType)))
+	if err != nil {
+		return nil, err
+	}
+
+	rpcsLog.Infof("Backing up the block database to %s", path)
+	start := time.Now()
+	if err := db.Backup(path); err != nil {
+		context := "Failed to back up the block database"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	size, err := dirSize(path)
+	if err != nil {
+		context := "Failed to read the size of the backup"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	rpcsLog.Infof("Backed up the block database to %s in %v", path,
+		time.Since(start).Round(time.Millisecond))
+
+	return &btcjson.BackupResult{
+		Path: path,
+		Size: size,
+	}, nil
+}
+
+// handleBackupClaimDBs implements the backupclaimdbs command.
+func handleBackupClaimDBs(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.BackupClaimDBsCmd)
+
+	done, err := s.startBackup()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	path, err := backupTarget(c.Destination, "claim_dbs")
+	if err != nil {
+		return nil, err
+	}
+
+	rpcsLog.Infof("Backing up the claimtrie databases to %s", path)
+	start := time.Now()
+	height, err := s.cfg.Chain.BackupClaimTrie(path)
+	if err != nil {
+		os.RemoveAll(path)
+		context := "Failed to back up the claimtrie databases"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	size, err := dirSize(path)
+	if err != nil {
+		context := "Failed to read the size of the backup"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	rpcsLog.Infof("Backed up the claimtrie databases at height %d to %s "+
+		"in %v", height, path, time.Since(start).Round(time.Millisecond))
+
+	return &btcjson.BackupResult{
+		Path:   path,
+		Size:   size,
+		Height: height,
+	}, nil
+}
@@ -0,0 +1,880 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/rpcclient"
+)
+
+const (
+	// chainSnapshotsDirname is the directory of the data directory the
+	// chain snapshots are stored in.
+	chainSnapshotsDirname = "chainsnapshots"
+
+	// chainSnapshotManifestName is the name of the manifest in the
+	// directory of a chain snapshot.  It is written last, so a snapshot is
+	// complete once it has one.
+	chainSnapshotManifestName = "manifest.json"
+
+	// chainSnapshotVersion is the version of the manifests of the chain
+	// snapshots.
+	chainSnapshotVersion = 1
+
+	// chainSnapshotChunkSize is the size of the chunks of the chain
+	// snapshots created by this server.
+	chainSnapshotChunkSize = 4 * 1024 * 1024
+
+	// maxChainSnapshotChunkSize is the largest chunk size of the snapshots
+	// downloaded from other servers, which return each chunk in a single
+	// response.
+	maxChainSnapshotChunkSize = 64 * 1024 * 1024
+
+	// chainSnapshotChunkAttempts is the number of times the download of a
+	// chunk is attempted before the download of the snapshot fails.
+	chainSnapshotChunkAttempts = 3
+
+	// maxReportedBadChunks is the maximum number of bad chunks listed by
+	// the verifychainsnapshot command.
+	maxReportedBadChunks = 100
+)
+
+// The statuses of the chain snapshots listed by listchainsnapshots.
+const (
+	chainSnapshotComplete    = "complete"
+	chainSnapshotDownloading = "downloading"
+	chainSnapshotFailed      = "failed"
+)
+
+// chainSnapshotNameRegexp matches the valid names of the chain snapshots,
+// which are the names of their directories.
+var chainSnapshotNameRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// chainSnapshotDownload is the state of the download of a chain snapshot.
+type chainSnapshotDownload struct {
+	name     string
+	hash     string
+	source   string
+	manifest *btcjson.ChainSnapshotManifest
+
+	// The following fields are protected by the mutex of the chain
+	// snapshots of the server.
+	downloaded int
+	err        error
+}
+
+// loadedChainSnapshot is the manifest of a complete chain snapshot, cached by
+// the server along with the modification time of the manifest so the chunk
+// requests don't parse it again.
+type loadedChainSnapshot struct {
+	modTime  time.Time
+	hash     string
+	manifest *btcjson.ChainSnapshotManifest
+}
+
+// chainSnapshots holds the state of the chain snapshots of the server.
+type chainSnapshots struct {
+	mtx       sync.Mutex
+	loaded    map[string]*loadedChainSnapshot
+	downloads map[string]*chainSnapshotDownload
+}
+
+// newChainSnapshots returns the empty state of the chain snapshots of a server.
+func newChainSnapshots() *chainSnapshots {
+	return &chainSnapshots{
+		loaded:    make(map[string]*loadedChainSnapshot),
+		downloads: make(map[string]*chainSnapshotDownload),
+	}
+}
+
+// chainSnapshotDir returns the directory of the chain snapshot with the passed
+// name, which must be valid.
+func chainSnapshotDir(name string) (string, error) {
+	if !chainSnapshotNameRegexp.MatchString(name) ||
+		strings.HasSuffix(name, ".tmp") {
+
+		return "", &btcjson.RPCError{
+			Code: btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Invalid snapshot name %q: it must "+
+				"only contain letters, digits, '.', '_' and '-', "+
+				"and must not end in .tmp", name),
+		}
+	}
+	return filepath.Join(cfg.DataDir, chainSnapshotsDirname, name), nil
+}
+
+// chainSnapshotHash returns the hash of a manifest, which is the hex-encoded
+// SHA-256 hash of its JSON encoding.
+func chainSnapshotHash(m *btcjson.ChainSnapshotManifest) (string, error) {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(b)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// chunkHash returns the hex-encoded SHA-256 hash of a chunk.
+func chunkHash(chunk []byte) string {
+	hash := sha256.Sum256(chunk)
+	return hex.EncodeToString(hash[:])
+}
+
+// numChunks returns the number of chunks of a file of the passed size.
+func numChunks(size int64, chunkSize int) int {
+	return int((size + int64(chunkSize) - 1) / int64(chunkSize))
+}
+
+// chunkLen returns the length of the chunk of a file of the passed size at the
+// passed offset.
+func chunkLen(size, offset int64, chunkSize int) int {
+	if size-offset < int64(chunkSize) {
+		return int(size - offset)
+	}
+	return chunkSize
+}
+
+// hashChainSnapshotFiles returns the files of the chain snapshot in dir along
+// with the hashes of their chunks, in lexical order.
+func hashChainSnapshotFiles(dir string, chunkSize int) ([]btcjson.ChainSnapshotFile, error) {
+	files := []btcjson.ChainSnapshotFile{}
+	buf := make([]byte, chunkSize)
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == chainSnapshotManifestName {
+			return nil
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		file := btcjson.ChainSnapshotFile{
+			Path:   rel,
+			Size:   info.Size(),
+			Chunks: make([]string, 0, numChunks(info.Size(), chunkSize)),
+		}
+		for offset := int64(0); offset < file.Size; offset += int64(chunkSize) {
+			chunk := buf[:chunkLen(file.Size, offset, chunkSize)]
+			if _, err := io.ReadFull(f, chunk); err != nil {
+				return fmt.Errorf("failed to read %s: %v", rel, err)
+			}
+			file.Chunks = append(file.Chunks, chunkHash(chunk))
+		}
+		files = append(files, file)
+		return nil
+	})
+	return files, err
+}
+
+// validateChainSnapshot returns an error when a manifest downloaded from
+// another server is not a valid manifest of a snapshot of the network, so that
+// its files can be written safely to the directory of the snapshot.
+func validateChainSnapshot(m *btcjson.ChainSnapshotManifest) error {
+	if m.Version != chainSnapshotVersion {
+		return fmt.Errorf("unknown snapshot version %d", m.Version)
+	}
+	if m.Network != activeNetParams.Name {
+		return fmt.Errorf("the snapshot is of the %s network",
+			m.Network)
+	}
+	if m.ChunkSize <= 0 || m.ChunkSize > maxChainSnapshotChunkSize {
+		return fmt.Errorf("invalid chunk size %d", m.ChunkSize)
+	}
+
+	paths := make(map[string]struct{}, len(m.Files))
+	for i := range m.Files {
+		file := &m.Files[i]
+		if file.Path == "" || file.Path == "." || file.Path == ".." ||
+			path.IsAbs(file.Path) || path.Clean(file.Path) != file.Path ||
+			strings.HasPrefix(file.Path, "../") ||
+			strings.Contains(file.Path, "\\") ||
+			file.Path == chainSnapshotManifestName {
+
+			return fmt.Errorf("invalid file path %q", file.Path)
+		}
+		if _, ok := paths[file.Path]; ok {
+			return fmt.Errorf("duplicate file path %q", file.Path)
+		}
+		paths[file.Path] = struct{}{}
+
+		if file.Size < 0 || len(file.Chunks) !=
+			numChunks(file.Size, m.ChunkSize) {
+
+			return fmt.Errorf("file %s of %d bytes has %d chunks",
+				file.Path, file.Size, len(file.Chunks))
+		}
+		for _, hash := range file.Chunks {
+			if b, err := hex.DecodeString(hash); err != nil ||
+				len(b) != sha256.Size {
+
+				return fmt.Errorf("invalid chunk hash %q of %s",
+					hash, file.Path)
+			}
+		}
+	}
+	return nil
+}
+
+// chainSnapshotChunks returns the number of chunks and the total size of the
+// files of a manifest.
+func chainSnapshotChunks(m *btcjson.ChainSnapshotManifest) (int, int64) {
+	var chunks int
+	var size int64
+	for i := range m.Files {
+		chunks += len(m.Files[i].Chunks)
+		size += m.Files[i].Size
+	}
+	return chunks, size
+}
+
+// readChainSnapshotManifest reads the manifest of the chain snapshot in dir.
+func readChainSnapshotManifest(dir string) (*btcjson.ChainSnapshotManifest, error) {
+	b, err := os.ReadFile(filepath.Join(dir, chainSnapshotManifestName))
+	if err != nil {
+		return nil, err
+	}
+	var m btcjson.ChainSnapshotManifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse the manifest of %s: %v",
+			dir, err)
+	}
+	return &m, nil
+}
+
+// writeChainSnapshotManifest writes the manifest of the chain snapshot in dir.
+func writeChainSnapshotManifest(dir string, m *btcjson.ChainSnapshotManifest) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	p := filepath.Join(dir, chainSnapshotManifestName)
+	if err := os.WriteFile(p+".tmp", b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(p+".tmp", p)
+}
+
+// loadChainSnapshot returns the name, manifest and hash of the complete chain
+// snapshot with the passed name.  The manifests are cached until they change.
+func (s *rpcServer) loadChainSnapshot(name string) (string, *loadedChainSnapshot, error) {
+	dir, err := chainSnapshotDir(name)
+	if err != nil {
+		return "", nil, err
+	}
+	fi, err := os.Stat(filepath.Join(dir, chainSnapshotManifestName))
+	if err != nil {
+		return "", nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("No complete snapshot named %q", name),
+		}
+	}
+
+	snapshots := s.chainSnapshots
+	snapshots.mtx.Lock()
+	defer snapshots.mtx.Unlock()
+
+	loaded, ok := snapshots.loaded[name]
+	if ok && loaded.modTime.Equal(fi.ModTime()) {
+		return dir, loaded, nil
+	}
+	m, err := readChainSnapshotManifest(dir)
+	if err != nil {
+		context := "Failed to read snapshot manifest"
+		return "", nil, internalRPCError(err.Error(), context)
+	}
+	hash, err := chainSnapshotHash(m)
+	if err != nil {
+		context := "Failed to hash snapshot manifest"
+		return "", nil, internalRPCError(err.Error(), context)
+	}
+	loaded = &loadedChainSnapshot{
+		modTime:  fi.ModTime(),
+		hash:     hash,
+		manifest: m,
+	}
+	snapshots.loaded[name] = loaded
+	return dir, loaded, nil
+}
+
+// chainSnapshotInfo returns the description of a chain snapshot returned by
+// listchainsnapshots.
+func chainSnapshotInfo(name, hash string, m *btcjson.ChainSnapshotManifest) btcjson.ChainSnapshotInfo {
+	chunks, size := chainSnapshotChunks(m)
+	return btcjson.ChainSnapshotInfo{
+		Name:      name,
+		Hash:      hash,
+		Status:    chainSnapshotComplete,
+		Network:   m.Network,
+		DbType:    m.DbType,
+		Height:    m.Height,
+		BlockHash: m.BlockHash,
+		Created:   m.Created,
+		Size:      size,
+		Chunks:    chunks,
+	}
+}
+
+// handleCreateChainSnapshot implements the createchainsnapshot command.
+func handleCreateChainSnapshot(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.CreateChainSnapshotCmd)
+
+	dir, err := chainSnapshotDir(c.Name)
+	if err != nil {
+		return nil, err
+	}
+	db, ok := s.cfg.DB.(rpcserverDBBackup)
+	if !ok {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCMisc,
+			Message: fmt.Sprintf("The %s database doesn't support "+
+				"backups", cfg.DbType),
+		}
+	}
+
+	done, err := s.startBackup()
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	s.chainSnapshots.mtx.Lock()
+	_, downloading := s.chainSnapshots.downloads[c.Name]
+	s.chainSnapshots.mtx.Unlock()
+	if _, err := os.Stat(dir); err == nil || downloading {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Snapshot %q already exists", c.Name),
+		}
+	}
+
+	// The snapshot is written to a temporary directory first, so that it
+	// is either complete or missing.
+	tmpDir := dir + ".tmp"
+	if err := os.RemoveAll(tmpDir); err != nil {
+		context := "Failed to remove snapshot directory"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	if err := os.MkdirAll(tmpDir, 0700); err != nil {
+		context := "Failed to create snapshot directory"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// The block database is backed up before the claimtrie, so that the
+	// claimtrie is at or above the height of the block database, which
+	// are both at or above the best block recorded in the manifest.
+	rpcsLog.Infof("Creating chain snapshot %s", c.Name)
+	start := time.Now()
+	best := s.cfg.Chain.BestSnapshot()
+	err = db.Backup(filepath.Join(tmpDir, filepath.Base(blockDbPath(cfg.DbType))))
+	if err != nil {
+		context := "Failed to back up the block database"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	claimTrieHeight, err := s.cfg.Chain.BackupClaimTrie(
+		filepath.Join(tmpDir, "claim_dbs"))
+	if err != nil {
+		context := "Failed to back up the claimtrie databases"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	files, err := hashChainSnapshotFiles(tmpDir, chainSnapshotChunkSize)
+	if err != nil {
+		context := "Failed to hash the snapshot files"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	m := &btcjson.ChainSnapshotManifest{
+		Version:         chainSnapshotVersion,
+		Network:         activeNetParams.Name,
+		DbType:          cfg.DbType,
+		Height:          best.Height,
+		BlockHash:       best.Hash.String(),
+		ClaimTrieHeight: claimTrieHeight,
+		Created:         time.Now().Unix(),
+		ChunkSize:       chainSnapshotChunkSize,
+		Files:           files,
+	}
+	hash, err := chainSnapshotHash(m)
+	if err == nil {
+		err = writeChainSnapshotManifest(tmpDir, m)
+	}
+	if err == nil {
+		err = os.Rename(tmpDir, dir)
+	}
+	if err != nil {
+		context := "Failed to write the snapshot manifest"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	chunks, size := chainSnapshotChunks(m)
+	rpcsLog.Infof("Created chain snapshot %s at height %d with %d chunks "+
+		"(%d bytes) in %v, hash %s", c.Name, m.Height, chunks, size,
+		time.Since(start).Round(time.Millisecond), hash)
+
+	return &btcjson.GetChainSnapshotResult{
+		Name:     c.Name,
+		Hash:     hash,
+		Manifest: *m,
+	}, nil
+}
+
+// handleListChainSnapshots implements the listchainsnapshots command.
+func handleListChainSnapshots(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	entries, err := os.ReadDir(filepath.Join(cfg.DataDir, chainSnapshotsDirname))
+	if err != nil && !os.IsNotExist(err) {
+		context := "Failed to read the snapshots directory"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	snapshots := []btcjson.ChainSnapshotInfo{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || strings.HasSuffix(name, ".tmp") {
+			continue
+		}
+		_, loaded, err := s.loadChainSnapshot(name)
+		if err != nil {
+			// Skip the directories which aren't snapshots.
+			continue
+		}
+		snapshots = append(snapshots,
+			chainSnapshotInfo(name, loaded.hash, loaded.manifest))
+	}
+
+	s.chainSnapshots.mtx.Lock()
+	for _, d := range s.chainSnapshots.downloads {
+		info := chainSnapshotInfo(d.name, d.hash, d.manifest)
+		info.Status = chainSnapshotDownloading
+		info.DownloadedChunks = d.downloaded
+		info.Source = d.source
+		if d.err != nil {
+			info.Status = chainSnapshotFailed
+			info.Error = d.err.Error()
+		}
+		snapshots = append(snapshots, info)
+	}
+	s.chainSnapshots.mtx.Unlock()
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Name < snapshots[j].Name
+	})
+	return snapshots, nil
+}
+
+// handleGetChainSnapshot implements the getchainsnapshot command.
+func handleGetChainSnapshot(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetChainSnapshotCmd)
+
+	_, loaded, err := s.loadChainSnapshot(c.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &btcjson.GetChainSnapshotResult{
+		Name:     c.Name,
+		Hash:     loaded.hash,
+		Manifest: *loaded.manifest,
+	}, nil
+}
+
+// chainSnapshotChunk returns the file of a manifest holding the chunk at the
+// passed index, along with the offset and the length of the chunk in the file.
+func chainSnapshotChunk(m *btcjson.ChainSnapshotManifest, index int) (*btcjson.ChainSnapshotFile, int64, int, bool) {
+	if index < 0 {
+		return nil, 0, 0, false
+	}
+	for i := range m.Files {
+		file := &m.Files[i]
+		if index < len(file.Chunks) {
+			offset := int64(index) * int64(m.ChunkSize)
+			return file, offset, chunkLen(file.Size, offset,
+				m.ChunkSize), true
+		}
+		index -= len(file.Chunks)
+	}
+	return nil, 0, 0, false
+}
+
+// readChunk reads the chunk of length n at the passed offset of a file.
+func readChunk(p string, offset int64, n int) ([]byte, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	chunk := make([]byte, n)
+	if _, err := f.ReadAt(chunk, offset); err != nil {
+		return nil, err
+	}
+	return chunk, nil
+}
+
+// handleGetChainSnapshotChunk implements the getchainsnapshotchunk command.
+func handleGetChainSnapshotChunk(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetChainSnapshotChunkCmd)
+
+	dir, loaded, err := s.loadChainSnapshot(c.Name)
+	if err != nil {
+		return nil, err
+	}
+	file, offset, n, ok := chainSnapshotChunk(loaded.manifest, c.Index)
+	if !ok {
+		chunks, _ := chainSnapshotChunks(loaded.manifest)
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Chunk index must be between 0 and "+
+				"%d", chunks-1),
+		}
+	}
+	chunk, err := readChunk(filepath.Join(dir, filepath.FromSlash(file.Path)),
+		offset, n)
+	if err != nil {
+		context := "Failed to read snapshot chunk"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	return &btcjson.GetChainSnapshotChunkResult{
+		Index: c.Index,
+		Hash:  chunkHash(chunk),
+		Data:  base64.StdEncoding.EncodeToString(chunk),
+	}, nil
+}
+
+// handleVerifyChainSnapshot implements the verifychainsnapshot command.
+func handleVerifyChainSnapshot(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.VerifyChainSnapshotCmd)
+
+	dir, loaded, err := s.loadChainSnapshot(c.Name)
+	if err != nil {
+		return nil, err
+	}
+	m := loaded.manifest
+
+	rpcsLog.Infof("Verifying chain snapshot %s", c.Name)
+	result := &btcjson.VerifyChainSnapshotResult{
+		Name: c.Name,
+	}
+	for i := range m.Files {
+		file := &m.Files[i]
+		p := filepath.Join(dir, filepath.FromSlash(file.Path))
+		fi, err := os.Stat(p)
+		if err != nil || fi.Size() != file.Size {
+			result.BadFiles = append(result.BadFiles, file.Path)
+		}
+		for j, hash := range file.Chunks {
+			select {
+			case <-closeChan:
+				return nil, ErrClientQuit
+			case <-s.quit:
+				return nil, ErrClientQuit
+			default:
+			}
+
+			offset := int64(j) * int64(m.ChunkSize)
+			chunk, err := readChunk(p, offset,
+				chunkLen(file.Size, offset, m.ChunkSize))
+			if err != nil || chunkHash(chunk) != hash {
+				if len(result.BadChunks) < maxReportedBadChunks {
+					result.BadChunks = append(result.BadChunks,
+						result.Chunks)
+				}
+				result.NumBadChunks++
+			}
+			result.Chunks++
+		}
+	}
+	result.Valid = result.NumBadChunks == 0 && len(result.BadFiles) == 0
+	rpcsLog.Infof("Verified chain snapshot %s: %d of %d chunks bad, %d "+
+		"files missing or of the wrong size", c.Name, result.NumBadChunks,
+		result.Chunks, len(result.BadFiles))
+
+	return result, nil
+}
+
+// handleDownloadChainSnapshot implements the downloadchainsnapshot command.
+func handleDownloadChainSnapshot(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.DownloadChainSnapshotCmd)
+
+	dir, err := chainSnapshotDir(c.Name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(dir); err == nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Snapshot %q already exists", c.Name),
+		}
+	}
+
+	connCfg := &rpcclient.ConnConfig{
+		Host:         c.Host,
+		User:         c.User,
+		Pass:         c.Pass,
+		HTTPPostMode: true,
+		DisableTLS:   *c.NoTLS,
+	}
+	if c.CertFile != nil && *c.CertFile != "" {
+		certs, err := os.ReadFile(cleanAndExpandPath(*c.CertFile))
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code: btcjson.ErrRPCInvalidParameter,
+				Message: fmt.Sprintf("Failed to read the "+
+					"certificate file: %v", err),
+			}
+		}
+		connCfg.Certificates = certs
+	}
+	client, err := rpcclient.New(connCfg, nil)
+	if err != nil {
+		context := "Failed to create RPC client"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	// The manifest is only trusted when its hash is the one given, which
+	// pins the hashes of all the chunks.
+	result, err := client.GetChainSnapshot(c.Name)
+	if err != nil {
+		client.Shutdown()
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCMisc,
+			Message: fmt.Sprintf("Failed to fetch the snapshot "+
+				"manifest from %s: %v", c.Host, err),
+		}
+	}
+	m := &result.Manifest
+	hash, err := chainSnapshotHash(m)
+	if err == nil && !strings.EqualFold(hash, c.Hash) {
+		err = fmt.Errorf("the manifest has hash %s", hash)
+	}
+	if err == nil {
+		err = validateChainSnapshot(m)
+	}
+	if err != nil {
+		client.Shutdown()
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCMisc,
+			Message: fmt.Sprintf("Invalid snapshot manifest from %s: "+
+				"%v", c.Host, err),
+		}
+	}
+
+	d := &chainSnapshotDownload{
+		name:     c.Name,
+		hash:     hash,
+		source:   c.Host,
+		manifest: m,
+	}
+	s.chainSnapshots.mtx.Lock()
+	if prev, ok := s.chainSnapshots.downloads[c.Name]; ok && prev.err == nil {
+		s.chainSnapshots.mtx.Unlock()
+		client.Shutdown()
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCMisc,
+			Message: fmt.Sprintf("Snapshot %q is already being "+
+				"downloaded", c.Name),
+		}
+	}
+	s.chainSnapshots.downloads[c.Name] = d
+	s.chainSnapshots.mtx.Unlock()
+
+	chunks, size := chainSnapshotChunks(m)
+	rpcsLog.Infof("Downloading chain snapshot %s at height %d with %d "+
+		"chunks (%d bytes) from %s", c.Name, m.Height, chunks, size,
+		c.Host)
+	s.wg.Add(1)
+	go s.downloadChainSnapshot(client, d, dir)
+
+	info := chainSnapshotInfo(d.name, d.hash, d.manifest)
+	info.Status = chainSnapshotDownloading
+	info.Source = d.source
+	return &info, nil
+}
+
+// downloadChainSnapshot downloads the chain snapshot of d to dir with the
+// passed client, which is shut down once done.  The failed downloads are kept
+// in the snapshots of the server along with their error.
+//
+// It must be run as a goroutine.
+func (s *rpcServer) downloadChainSnapshot(client *rpcclient.Client,
+	d *chainSnapshotDownload, dir string) {
+
+	defer s.wg.Done()
+	defer client.Shutdown()
+
+	start := time.Now()
+	err := s.fetchChainSnapshot(client, d, dir)
+
+	s.chainSnapshots.mtx.Lock()
+	if err != nil {
+		d.err = err
+	} else {
+		delete(s.chainSnapshots.downloads, d.name)
+	}
+	s.chainSnapshots.mtx.Unlock()
+
+	if err != nil {
+		rpcsLog.Errorf("Failed to download chain snapshot %s: %v",
+			d.name, err)
+		return
+	}
+	rpcsLog.Infof("Downloaded chain snapshot %s in %v", d.name,
+		time.Since(start).Round(time.Millisecond))
+}
+
+// errChainSnapshotShutdown is the error of the downloads of the chain
+// snapshots interrupted by the shutdown of the server.
+var errChainSnapshotShutdown = errors.New("the server shut down")
+
+// fetchChainSnapshot downloads the files of the chain snapshot of d to a
+// temporary directory, which is renamed to dir once complete.  The chunks of
+// an interrupted download of the same snapshot which are already in the
+// temporary directory aren't downloaded again.
+func (s *rpcServer) fetchChainSnapshot(client *rpcclient.Client,
+	d *chainSnapshotDownload, dir string) error {
+
+	m := d.manifest
+	tmpDir := dir + ".tmp"
+	index := 0
+	paths := make(map[string]struct{}, len(m.Files))
+	for i := range m.Files {
+		file := &m.Files[i]
+		p := filepath.Join(tmpDir, filepath.FromSlash(file.Path))
+		paths[p] = struct{}{}
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(p, os.O_CREATE|os.O_RDWR, 0600)
+		if err != nil {
+			return err
+		}
+		err = s.fetchChainSnapshotFile(client, d, f, file, index)
+		if err == nil {
+			err = f.Truncate(file.Size)
+		}
+		if err == nil {
+			err = f.Sync()
+		}
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		index += len(file.Chunks)
+	}
+
+	// Remove the files left in the temporary directory which aren't part
+	// of the snapshot, such as those of another snapshot of the same name.
+	err := filepath.Walk(tmpDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		if _, ok := paths[p]; !ok {
+			return os.Remove(p)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := writeChainSnapshotManifest(tmpDir, m); err != nil {
+		return err
+	}
+	return os.Rename(tmpDir, dir)
+}
+
+// fetchChainSnapshotFile downloads the chunks of a file of the chain snapshot
+// of d to f, starting at the passed chunk index of the snapshot and skipping
+// the chunks f already holds.
+func (s *rpcServer) fetchChainSnapshotFile(client *rpcclient.Client,
+	d *chainSnapshotDownload, f *os.File, file *btcjson.ChainSnapshotFile,
+	index int) error {
+
+	m := d.manifest
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	existing := fi.Size()
+	buf := make([]byte, m.ChunkSize)
+	for i, hash := range file.Chunks {
+		select {
+		case <-s.quit:
+			return errChainSnapshotShutdown
+		default:
+		}
+
+		offset := int64(i) * int64(m.ChunkSize)
+		chunk := buf[:chunkLen(file.Size, offset, m.ChunkSize)]
+		have := false
+		if offset+int64(len(chunk)) <= existing {
+			_, err := f.ReadAt(chunk, offset)
+			have = err == nil && chunkHash(chunk) == hash
+		}
+		if !have {
+			chunk, err = fetchChainSnapshotChunk(client, d.name,
+				index+i, hash)
+			if err != nil {
+				return err
+			}
+			if _, err := f.WriteAt(chunk, offset); err != nil {
+				return err
+			}
+		}
+
+		s.chainSnapshots.mtx.Lock()
+		d.downloaded++
+		s.chainSnapshots.mtx.Unlock()
+	}
+	return nil
+}
+
+// fetchChainSnapshotChunk downloads the chunk of the snapshot at the passed
+// index, which must have the passed hash.
+func fetchChainSnapshotChunk(client *rpcclient.Client, name string, index int,
+	hash string) ([]byte, error) {
+
+	var err error
+	for attempt := 1; attempt <= chainSnapshotChunkAttempts; attempt++ {
+		var result *btcjson.GetChainSnapshotChunkResult
+		result, err = client.GetChainSnapshotChunk(name, index)
+		if err == nil {
+			var chunk []byte
+			chunk, err = base64.StdEncoding.DecodeString(result.Data)
+			if err == nil && chunkHash(chunk) != hash {
+				err = fmt.Errorf("chunk %d has hash %s instead "+
+					"of %s", index, chunkHash(chunk), hash)
+			}
+			if err == nil {
+				return chunk, nil
+			}
+		}
+		rpcsLog.Debugf("Attempt %d to download chunk %d of chain "+
+			"snapshot %s failed: %v", attempt, index, name, err)
+	}
+	return nil, fmt.Errorf("failed to download chunk %d: %v", index, err)
+}
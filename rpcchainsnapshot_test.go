@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btclog"
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainSnapshots(t *testing.T) {
+
+	r := require.New(t)
+
+	defer func(log btclog.Logger) { rpcsLog = log }(rpcsLog)
+	rpcsLog = btclog.Disabled
+	prevCfg := cfg
+	defer func() { cfg = prevCfg }()
+	cfg = &config{DataDir: t.TempDir()}
+
+	// Lay out a snapshot by hand, with chunks of 10 bytes.
+	dir, err := chainSnapshotDir("clone")
+	r.NoError(err)
+	contents := map[string][]byte{
+		"blocks_ffldb/000000000.fdb": bytes.Repeat([]byte{1}, 25),
+		"blocks_ffldb/metadata/LOG":  []byte("log"),
+		"claim_dbs/empty":            nil,
+	}
+	for name, data := range contents {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		r.NoError(os.MkdirAll(filepath.Dir(p), 0700))
+		r.NoError(os.WriteFile(p, data, 0600))
+	}
+	files, err := hashChainSnapshotFiles(dir, 10)
+	r.NoError(err)
+	r.Len(files, 3)
+	r.Equal("blocks_ffldb/000000000.fdb", files[0].Path)
+	r.Len(files[0].Chunks, 3)
+	r.Len(files[1].Chunks, 1)
+	r.Empty(files[2].Chunks)
+	m := &btcjson.ChainSnapshotManifest{
+		Version:   chainSnapshotVersion,
+		Network:   activeNetParams.Name,
+		DbType:    "ffldb",
+		Height:    10,
+		ChunkSize: 10,
+		Files:     files,
+	}
+	r.NoError(validateChainSnapshot(m))
+	r.NoError(writeChainSnapshotManifest(dir, m))
+
+	s := &rpcServer{chainSnapshots: newChainSnapshots()}
+	result, err := handleListChainSnapshots(s, &btcjson.ListChainSnapshotsCmd{}, nil)
+	r.NoError(err)
+	infos := result.([]btcjson.ChainSnapshotInfo)
+	r.Len(infos, 1)
+	r.Equal(chainSnapshotComplete, infos[0].Status)
+	r.Equal(4, infos[0].Chunks)
+	r.EqualValues(28, infos[0].Size)
+
+	result, err = handleGetChainSnapshot(s, btcjson.NewGetChainSnapshotCmd("clone"), nil)
+	r.NoError(err)
+	snapshot := result.(*btcjson.GetChainSnapshotResult)
+	hash, err := chainSnapshotHash(&snapshot.Manifest)
+	r.NoError(err)
+	r.Equal(infos[0].Hash, hash)
+	_, err = handleGetChainSnapshot(s, btcjson.NewGetChainSnapshotCmd("../clone"), nil)
+	r.Error(err)
+	_, err = handleGetChainSnapshot(s, btcjson.NewGetChainSnapshotCmd("missing"), nil)
+	r.Error(err)
+
+	// The chunks are numbered across the files.
+	result, err = handleGetChainSnapshotChunk(s, btcjson.NewGetChainSnapshotChunkCmd("clone", 2), nil)
+	r.NoError(err)
+	chunk := result.(*btcjson.GetChainSnapshotChunkResult)
+	data, err := base64.StdEncoding.DecodeString(chunk.Data)
+	r.NoError(err)
+	r.Equal(bytes.Repeat([]byte{1}, 5), data)
+	r.Equal(files[0].Chunks[2], chunk.Hash)
+	result, err = handleGetChainSnapshotChunk(s, btcjson.NewGetChainSnapshotChunkCmd("clone", 3), nil)
+	r.NoError(err)
+	data, err = base64.StdEncoding.DecodeString(result.(*btcjson.GetChainSnapshotChunkResult).Data)
+	r.NoError(err)
+	r.Equal([]byte("log"), data)
+	_, err = handleGetChainSnapshotChunk(s, btcjson.NewGetChainSnapshotChunkCmd("clone", 4), nil)
+	r.Error(err)
+
+	verify := func() *btcjson.VerifyChainSnapshotResult {
+		result, err := handleVerifyChainSnapshot(s, btcjson.NewVerifyChainSnapshotCmd("clone"), nil)
+		r.NoError(err)
+		return result.(*btcjson.VerifyChainSnapshotResult)
+	}
+	r.True(verify().Valid)
+	p := filepath.Join(dir, "blocks_ffldb", "000000000.fdb")
+	corrupted := bytes.Repeat([]byte{1}, 26)
+	corrupted[12] = 2
+	r.NoError(os.WriteFile(p, corrupted, 0600))
+	v := verify()
+	r.False(v.Valid)
+	r.Equal([]int{1}, v.BadChunks)
+	r.Equal([]string{"blocks_ffldb/000000000.fdb"}, v.BadFiles)
+
+	// The manifests downloaded from other nodes must not write outside of
+	// the snapshot directory or disagree with their files.
+	for _, path := range []string{"", ".", "..", "../x", "/x", "a/../b",
+		"a//b", `a\b`, chainSnapshotManifestName} {
+
+		bad := *m
+		bad.Files = []btcjson.ChainSnapshotFile{{Path: path}}
+		r.Error(validateChainSnapshot(&bad), path)
+	}
+	bad := *m
+	bad.Files = append([]btcjson.ChainSnapshotFile{}, m.Files...)
+	bad.Files[0].Size = 31
+	r.Error(validateChainSnapshot(&bad))
+	bad = *m
+	bad.Network = "othernet"
+	r.Error(validateChainSnapshot(&bad))
+	bad = *m
+	bad.ChunkSize = 0
+	r.Error(validateChainSnapshot(&bad))
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/database/ffldb"
+)
+
+// maxCheckDBBadBlocks is the maximum number of inconsistent blocks listed by
+// the checkdb command.
+const maxCheckDBBadBlocks = 100
+
+// rpcserverDBCheck is the interface of the block databases which can check the
+// consistency of their block files, such as the ffldb database.
+type rpcserverDBCheck interface {
+	Check(level ffldb.CheckLevel) (*ffldb.CheckResult, error)
+	LastRepair() *ffldb.CheckResult
+}
+
+// handleCheckDB implements the checkdb command.
+func handleCheckDB(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.CheckDBCmd)
+
+	db, ok := s.cfg.DB.(rpcserverDBCheck)
+	if !ok {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCMisc,
+			Message: fmt.Sprintf("The %s database doesn't support "+
+				"consistency checks", cfg.DbType),
+		}
+	}
+	level, err := ffldb.ParseCheckLevel(*c.Level)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "The level must be none, quick or full",
+		}
+	}
+
+	start := time.Now()
+	check, err := db.Check(level)
+	if err != nil {
+		context := "Failed to check the block database"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	rpcsLog.Infof("Checked the block database (level %v) in %v", level,
+		time.Since(start).Round(time.Millisecond))
+
+	result := &btcjson.CheckDBResult{
+		Level:            level.String(),
+		Consistent:       check.Consistent(),
+		Blocks:           check.Blocks,
+		WriteFile:        check.WriteFileNum,
+		WriteOffset:      check.WriteOffset,
+		ConsistentFile:   check.ConsistentFileNum,
+		ConsistentOffset: check.ConsistentOffset,
+		BadBlocks:        len(check.BadBlocks),
+		DroppedBlocks:    len(check.Dropped),
+		MissingBlocks:    len(s.cfg.Chain.MissingBlocks(math.MaxInt32)),
+	}
+	for i, bad := range check.BadBlocks {
+		if i == maxCheckDBBadBlocks {
+			break
+		}
+		result.Bad = append(result.Bad, btcjson.CheckDBBadBlock{
+			Hash:   bad.Hash.String(),
+			File:   bad.FileNum,
+			Offset: bad.Offset,
+			Reason: bad.Reason,
+		})
+	}
+	if repair := db.LastRepair(); repair != nil {
+		result.LastRepair = &btcjson.CheckDBRepair{
+			ConsistentFile:   repair.ConsistentFileNum,
+			ConsistentOffset: repair.ConsistentOffset,
+			DroppedBlocks:    len(repair.Dropped),
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/hex"
+
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/claimtrie/node"
+	"github.com/lbryio/lbcd/claimtrie/normalization"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/txscript/claimscript"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+// wsClaimClient is a websocket client registered for claim notifications.
+// names are the names the notifications are restricted to, or nil for all the
+// names.
+type wsClaimClient struct {
+	wsc   *wsClient
+	names []string
+}
+
+// claimNtfn is a marshalled claim notification of a block, along with the
+// normalized name it is about.
+type claimNtfn struct {
+	name       string
+	marshalled []byte
+}
+
+// RegisterClaimUpdates requests claim notifications to the passed websocket
+// client, restricted to the passed names unless they are nil.
+func (m *wsNotificationManager) RegisterClaimUpdates(wsc *wsClient, names []string) {
+	m.queueNotification <- &notificationRegisterClaims{wsc: wsc, names: names}
+}
+
+// UnregisterClaimUpdates removes claim notifications for the passed websocket
+// client.
+func (m *wsNotificationManager) UnregisterClaimUpdates(wsc *wsClient) {
+	m.queueNotification <- (*notificationUnregisterClaims)(wsc)
+}
+
+// notifyClaims notifies the clients of the claims created and updated, and of
+// the names taken over, by a block connected to the main chain.
+func (m *wsNotificationManager) notifyClaims(clients map[chan struct{}]*wsClaimClient,
+	block *btcutil.Block) {
+
+	ntfns := m.claimNtfns(block)
+	if len(ntfns) == 0 {
+		return
+	}
+
+	height := block.Height()
+	for _, c := range clients {
+		var wanted map[string]struct{}
+		if c.names != nil {
+			wanted = make(map[string]struct{}, len(c.names))
+			for _, name := range c.names {
+				n := normalization.NormalizeIfNecessary([]byte(name), height)
+				wanted[string(n)] = struct{}{}
+			}
+		}
+		for _, ntfn := range ntfns {
+			if _, ok := wanted[ntfn.name]; wanted != nil && !ok {
+				continue
+			}
+			c.wsc.QueueNotification(ntfn.marshalled)
+		}
+	}
+}
+
+// claimNtfns returns the claim notifications of a block: those of the claims
+// it created and updated, in the order of its outputs, followed by those of the
+// names it took over.  The claims are checked against the claimtrie, so that
+// the invalid updates, which it ignores, aren't notified.
+func (m *wsNotificationManager) claimNtfns(block *btcutil.Block) []claimNtfn {
+	chain := m.server.cfg.Chain
+	height := block.Height()
+	hash := block.Hash().String()
+
+	// The nodes are looked up once per name.
+	nodes := make(map[string]*node.Node)
+	lookup := func(name string) *node.Node {
+		if n, ok := nodes[name]; ok {
+			return n
+		}
+		_, n, err := chain.GetClaimsForName(height, name)
+		if err != nil {
+			n = nil
+		}
+		nodes[name] = n
+		return n
+	}
+
+	var ntfns []claimNtfn
+	add := func(name string, ntfn interface{}) {
+		marshalled, err := btcjson.MarshalCmd(btcjson.RpcVersion1, nil, ntfn)
+		if err != nil {
+			rpcsLog.Errorf("Failed to marshal claim notification: %v",
+				err)
+			return
+		}
+		ntfns = append(ntfns, claimNtfn{name: name, marshalled: marshalled})
+	}
+
+	for _, tx := range block.Transactions() {
+		for i, txOut := range tx.MsgTx().TxOut {
+			cs, err := claimscript.Parse(txOut.PkScript)
+			if err != nil || cs.Type == claimscript.TypeSupportClaim {
+				continue
+			}
+			op := wire.OutPoint{Hash: *tx.Hash(), Index: uint32(i)}
+			claimID := cs.ClaimIDFor(op)
+			name := string(normalization.NormalizeIfNecessary(cs.Name, height))
+			n := lookup(name)
+			if n == nil {
+				continue
+			}
+			var found bool
+			for _, c := range n.Claims {
+				if c.ClaimID == claimID && c.OutPoint == op {
+					found = true
+					break
+				}
+			}
+			if !found {
+				continue
+			}
+
+			claim := btcjson.ClaimNtfnData{
+				Name:           string(cs.Name),
+				NormalizedName: name,
+				ClaimID:        claimID.String(),
+				TXID:           op.Hash.String(),
+				N:              op.Index,
+				Amount:         txOut.Value,
+				Value:          hex.EncodeToString(cs.Value),
+				Hash:           hash,
+				Height:         height,
+			}
+			_, addrs, _, _ := txscript.ExtractPkScriptAddrs(cs.PkScript,
+				m.server.cfg.ChainParams)
+			if len(addrs) > 0 {
+				claim.Address = addrs[0].EncodeAddress()
+			}
+			if cs.Type == claimscript.TypeClaimName {
+				add(name, btcjson.NewClaimAddedNtfn(claim))
+			} else {
+				add(name, btcjson.NewClaimUpdatedNtfn(claim))
+			}
+		}
+	}
+
+	names, err := chain.GetNamesChangedInBlock(height)
+	if err != nil {
+		rpcsLog.Errorf("Failed to retrieve the claim changes of block "+
+			"%v: %v", block.Hash(), err)
+		return ntfns
+	}
+	for _, name := range names {
+		n := lookup(name)
+		if n == nil || n.BestClaim == nil || n.TakenOverAt != height {
+			continue
+		}
+		for i, c := range n.Claims {
+			if c.ClaimID != n.BestClaim.ClaimID {
+				continue
+			}
+			result, err := toClaimResult(m.server, int32(i), n, nil)
+			if err != nil {
+				break
+			}
+			takeover := btcjson.NameTakeover{
+				NormalizedName: name,
+				Hash:           hash,
+				Height:         height,
+				Claim:          result,
+			}
+			_, prev, err := chain.GetClaimsForName(height-1, name)
+			if err == nil && prev.HasActiveBestClaim() {
+				takeover.PreviousClaimID = prev.BestClaim.ClaimID.String()
+			}
+			add(name, btcjson.NewNameTakeoverNtfn(takeover))
+			break
+		}
+	}
+	return ntfns
+}
@@ -3,16 +3,21 @@ package main
 import (
 	"bytes"
 	"encoding/hex"
+	"fmt"
 	"strconv"
 	"strings"
 
+	"github.com/lbryio/lbcd/blockchain"
 	"github.com/lbryio/lbcd/btcjson"
 	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/claimtrie/change"
 	"github.com/lbryio/lbcd/claimtrie/node"
 	"github.com/lbryio/lbcd/claimtrie/normalization"
 	"github.com/lbryio/lbcd/database"
 	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/txscript/claimscript"
 	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
 )
 
 var claimtrieHandlers = map[string]commandHandler{
@@ -21,7 +26,10 @@ var claimtrieHandlers = map[string]commandHandler{
 	"getclaimsfornamebyid":  handleGetClaimsForNameByID,
 	"getclaimsfornamebybid": handleGetClaimsForNameByBid,
 	"getclaimsfornamebyseq": handleGetClaimsForNameBySeq,
+	"getnameproof":          handleGetNameProof,
+	"getvalueforname":       handleGetValueForName,
 	"normalize":             handleGetNormalized,
+	"simulateclaimsupport":  handleSimulateClaimSupport,
 }
 
 func handleGetChangesInBlock(s *rpcServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
@@ -91,7 +99,7 @@ func parseHashOrHeight(s *rpcServer, hashOrHeight *string) (string, int32, error
 	return hs.String(), h, nil
 }
 
-func handleGetClaimsForName(s *rpcServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+func handleGetClaimsForName(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 
 	c := cmd.(*btcjson.GetClaimsForNameCmd)
 	hash, height, err := parseHashOrHeight(s, c.HashOrHeight)
@@ -109,6 +117,9 @@ func handleGetClaimsForName(s *rpcServer, cmd interface{}, _ <-chan struct{}) (i
 
 	var results []btcjson.ClaimResult
 	for i := range n.Claims {
+		if err := clientQuit(closeChan); err != nil {
+			return nil, err
+		}
 		cr, err := toClaimResult(s, int32(i), n, c.IncludeValues)
 		if err != nil {
 			return nil, err
@@ -125,7 +136,7 @@ func handleGetClaimsForName(s *rpcServer, cmd interface{}, _ <-chan struct{}) (i
 	}, nil
 }
 
-func handleGetClaimsForNameByID(s *rpcServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+func handleGetClaimsForNameByID(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 
 	c := cmd.(*btcjson.GetClaimsForNameByIDCmd)
 	hash, height, err := parseHashOrHeight(s, c.HashOrHeight)
@@ -143,6 +154,9 @@ func handleGetClaimsForNameByID(s *rpcServer, cmd interface{}, _ <-chan struct{}
 
 	var results []btcjson.ClaimResult
 	for i := 0; i < len(n.Claims); i++ {
+		if err := clientQuit(closeChan); err != nil {
+			return nil, err
+		}
 		for _, id := range c.PartialClaimIDs {
 			if strings.HasPrefix(n.Claims[i].ClaimID.String(), id) {
 				cr, err := toClaimResult(s, int32(i), n, c.IncludeValues)
@@ -241,6 +255,148 @@ func handleGetClaimsForNameBySeq(s *rpcServer, cmd interface{}, _ <-chan struct{
 	}, nil
 }
 
+func handleGetValueForName(s *rpcServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+
+	c := cmd.(*btcjson.GetValueForNameCmd)
+	hash, height, err := parseHashOrHeight(s, c.HashOrHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	name, n, err := s.cfg.Chain.GetClaimsForName(height, c.Name)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "Message: " + err.Error(),
+		}
+	}
+
+	for i := range n.Claims {
+		if n.Claims[i] != n.BestClaim {
+			continue
+		}
+		cr, err := toClaimResult(s, int32(i), n, c.IncludeValues)
+		if err != nil {
+			return nil, err
+		}
+		return btcjson.GetValueForNameResult{
+			Hash:               hash,
+			Height:             height,
+			LastTakeoverHeight: n.TakenOverAt,
+			NormalizedName:     name,
+			Claim:              cr,
+		}, nil
+	}
+
+	return nil, &btcjson.RPCError{
+		Code:    btcjson.ErrRPCMisc,
+		Message: "No claim owns the name " + name + " at height " + strconv.Itoa(int(height)),
+	}
+}
+
+func handleGetNameProof(s *rpcServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+
+	c := cmd.(*btcjson.GetNameProofCmd)
+	if !s.cfg.Chain.IsCurrent() {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCClientInInitialDownload,
+			Message: "Unable to query the chain tip during initial download",
+		}
+	}
+
+	proof, err := s.cfg.Chain.GetClaimTrieProof(c.Name)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "Message: " + err.Error(),
+		}
+	}
+	return toNameProofResult(proof), nil
+}
+
+func handleSimulateClaimSupport(s *rpcServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+
+	c := cmd.(*btcjson.SimulateClaimSupportCmd)
+	if !s.cfg.Chain.IsCurrent() {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCClientInInitialDownload,
+			Message: "Unable to query the chain tip during initial download",
+		}
+	}
+
+	claimID, err := change.NewIDFromString(c.ClaimID)
+	if err == nil && len(c.ClaimID) != 2*len(claimID) {
+		err = fmt.Errorf("expected %d hex characters", 2*len(claimID))
+	}
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Unable to parse the claim ID " + c.ClaimID + ": " + err.Error(),
+		}
+	}
+	if c.Amount <= 0 || c.Amount > btcutil.MaxSatoshi {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "The amount must be a positive number of dewies",
+		}
+	}
+
+	name, sim, err := s.cfg.Chain.SimulateClaimSupport(c.Name, claimID, c.Amount)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "Message: " + err.Error(),
+		}
+	}
+	hash, err := s.cfg.Chain.BlockHashByHeight(sim.Height - 1)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCBlockNotFound,
+			Message: "Unable to locate the tip: " + err.Error(),
+		}
+	}
+
+	result := btcjson.SimulateClaimSupportResult{
+		Hash:            hash.String(),
+		Height:          sim.Height - 1,
+		NormalizedName:  name,
+		ClaimID:         claimID.String(),
+		Amount:          c.Amount,
+		IsBest:          sim.WasBest,
+		SupportHeight:   sim.Height,
+		ValidAtHeight:   sim.ActiveAt,
+		EffectiveAmount: sim.EffectiveAmount,
+		BestAmount:      sim.BestAmount,
+		MinAmount:       sim.MinAmount,
+	}
+	if sim.Best != nil {
+		result.BestClaimID = sim.Best.ClaimID.String()
+		result.WouldBeBest = sim.Best.ClaimID == claimID
+		if result.WouldBeBest {
+			result.TakeoverHeight = sim.TakenOverAt
+		}
+	}
+	return result, nil
+}
+
+func toNameProofResult(proof *blockchain.ClaimTrieProof) btcjson.GetNameProofResult {
+	result := btcjson.GetNameProofResult{
+		NormalizedName: proof.Name,
+		Hash:           proof.BlockHash.String(),
+		Height:         proof.Height,
+		ClaimTrie:      proof.ClaimTrieHash.String(),
+		ClaimsHash:     proof.ClaimsHash.String(),
+		Pairs:          make([]btcjson.ProofPairResult, 0, len(proof.Pairs)),
+	}
+	for _, p := range proof.Pairs {
+		result.Pairs = append(result.Pairs, btcjson.ProofPairResult{
+			Odd:  p.Odd,
+			Hash: p.Hash.String(),
+		})
+	}
+	return result
+}
+
 func toClaimResult(s *rpcServer, i int32, n *node.Node, includeValues *bool) (btcjson.ClaimResult, error) {
 	claim := n.Claims[i]
 	address, value, err := lookupValue(s, claim.OutPoint, includeValues)
@@ -333,16 +489,141 @@ func lookupValue(s *rpcServer, outpoint wire.OutPoint, includeValues *bool) (str
 	}
 
 	txo := msgTx.TxOut[outpoint.Index]
-	cs, err := txscript.ExtractClaimScript(txo.PkScript)
+	cs, err := claimscript.Parse(txo.PkScript)
 	if err != nil {
 		context := "Failed to decode the claim script"
 		return "", "", internalRPCError(err.Error(), context)
 	}
 
-	_, addresses, _, _ := txscript.ExtractPkScriptAddrs(txo.PkScript[cs.Size:], s.cfg.ChainParams)
+	_, addresses, _, _ := txscript.ExtractPkScriptAddrs(cs.PkScript, s.cfg.ChainParams)
 	return addresses[0].EncodeAddress(), hex.EncodeToString(cs.Value), nil
 }
 
+// addressClaimsBatchSize is the number of transactions of the address index
+// which addressClaims loads at a time.
+const addressClaimsBatchSize = 100
+
+// addressClaims returns the claims and the supports of the unspent outputs
+// paying to the address, which are found through the address index, as they
+// are in the claimtrie at the tip of the main chain.  The HasActiveClaims of
+// the result is whether any of the claims is active.
+func addressClaims(s *rpcServer, addr btcutil.Address, closeChan <-chan struct{}) ([]btcjson.AddressClaimResult, bool, error) {
+	addrIndex := s.cfg.AddrIndex
+	if addrIndex == nil {
+		return nil, false, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "Address index must be enabled (--addrindex)",
+		}
+	}
+
+	height := s.cfg.Chain.BestSnapshot().Height
+	encoded := addr.EncodeAddress()
+	results := []btcjson.AddressClaimResult{}
+	hasActiveClaims := false
+	for skip := uint32(0); ; skip += addressClaimsBatchSize {
+		var serializedTxns [][]byte
+		err := s.cfg.DB.View(func(dbTx database.Tx) error {
+			regions, _, err := addrIndex.TxRegionsForAddress(dbTx, addr,
+				skip, addressClaimsBatchSize, false)
+			if err != nil {
+				return err
+			}
+			serializedTxns, err = dbTx.FetchBlockRegions(regions)
+			return err
+		})
+		if err != nil {
+			context := "Failed to load address index entries"
+			return nil, false, internalRPCError(err.Error(), context)
+		}
+
+		for _, serializedTx := range serializedTxns {
+			var msgTx wire.MsgTx
+			err := msgTx.Deserialize(bytes.NewReader(serializedTx))
+			if err != nil {
+				context := "Failed to deserialize transaction"
+				return nil, false, internalRPCError(err.Error(), context)
+			}
+			txHash := msgTx.TxHash()
+			for i, txOut := range msgTx.TxOut {
+				// The transactions of the address index also
+				// include those spending from the address.
+				cs, err := txscript.ExtractClaimScript(txOut.PkScript)
+				if err != nil || !paysToAddress(s, txOut.PkScript, encoded) {
+					continue
+				}
+				outpoint := *wire.NewOutPoint(&txHash, uint32(i))
+				entry, err := s.cfg.Chain.FetchUtxoEntry(outpoint)
+				if err != nil {
+					context := "Failed to retrieve utxo entry"
+					return nil, false, internalRPCError(err.Error(), context)
+				}
+				if entry == nil || entry.IsSpent() {
+					continue
+				}
+
+				result, ok := addressClaimResult(s, height, string(cs.Name), outpoint)
+				if !ok {
+					continue
+				}
+				results = append(results, result)
+				if result.IsActive && !result.IsSupport {
+					hasActiveClaims = true
+				}
+			}
+		}
+
+		if err := clientQuit(closeChan); err != nil {
+			return nil, false, err
+		}
+		if len(serializedTxns) < addressClaimsBatchSize {
+			return results, hasActiveClaims, nil
+		}
+	}
+}
+
+// paysToAddress returns whether the output script, with its claim prefix, pays
+// to the encoded address, or to a key of the address among those of a
+// multisig script.
+func paysToAddress(s *rpcServer, pkScript []byte, encoded string) bool {
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript, s.cfg.ChainParams)
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		if addr.EncodeAddress() == encoded {
+			return true
+		}
+	}
+	return false
+}
+
+// addressClaimResult returns the claim or the support of the outpoint in the
+// node of the name at the height, which has none of the claim scripts the
+// claimtrie rejected.
+func addressClaimResult(s *rpcServer, height int32, name string, outpoint wire.OutPoint) (btcjson.AddressClaimResult, bool) {
+	_, n, err := s.cfg.Chain.GetClaimsForName(height, name)
+	if err != nil {
+		return btcjson.AddressClaimResult{}, false
+	}
+	for i, claims := range []node.ClaimList{n.Claims, n.Supports} {
+		for _, c := range claims {
+			if c.OutPoint != outpoint {
+				continue
+			}
+			return btcjson.AddressClaimResult{
+				Name:      name,
+				ClaimID:   c.ClaimID.String(),
+				TXID:      outpoint.Hash.String(),
+				N:         outpoint.Index,
+				Amount:    c.Amount,
+				IsSupport: i == 1,
+				IsActive:  c.Status == node.Activated,
+			}, true
+		}
+	}
+	return btcjson.AddressClaimResult{}, false
+}
+
 func handleGetNormalized(_ *rpcServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
 	c := cmd.(*btcjson.GetNormalizedCmd)
 	r := btcjson.GetNormalizedResult{
@@ -350,3 +631,59 @@ func handleGetNormalized(_ *rpcServer, cmd interface{}, _ <-chan struct{}) (inte
 	}
 	return r, nil
 }
+
+// claimScript returns the public key script prefixed by a claim, an update or
+// a support, by claim type, of the name with the hex-encoded value.  An empty
+// claim type is that of a claim.
+func claimScript(claimType, name, claimIDStr, valueStr string, pkScript []byte) ([]byte, error) {
+	value, err := hex.DecodeString(valueStr)
+	if err != nil {
+		return nil, rpcDecodeHexError(valueStr)
+	}
+
+	// An update or a support refers to its claim, which a new claim has
+	// none of.
+	var claimID change.ClaimID
+	switch claimType {
+	case "", "claim":
+		if claimIDStr != "" {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: "A new claim has no claim ID",
+			}
+		}
+	case "update", "support":
+		claimID, err = claimscript.ParseClaimID(claimIDStr)
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: err.Error(),
+			}
+		}
+	default:
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Unknown claim type '%s'", claimType),
+		}
+	}
+
+	var script []byte
+	switch {
+	case claimType == "update":
+		script, err = claimscript.UpdateClaim(name, claimID, value, pkScript)
+	case claimType == "support" && len(value) == 0:
+		script, err = claimscript.SupportClaim(name, claimID, pkScript)
+	case claimType == "support":
+		script, err = claimscript.SupportClaimWithValue(name, claimID,
+			value, pkScript)
+	default:
+		script, err = claimscript.ClaimName(name, value, pkScript)
+	}
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: err.Error(),
+		}
+	}
+	return script, nil
+}
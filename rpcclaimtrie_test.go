@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/txscript/claimscript"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimScript(t *testing.T) {
+
+	r := require.New(t)
+	pkScript := []byte{0x51, 0x21}
+	pkScript = append(pkScript, make([]byte, 33)...)
+	pkScript = append(pkScript, 0x51, 0xae)
+	claimID := "4e2a3f92fee27474f3a4f3b1cb04a5d17773dd01"
+
+	tests := []struct {
+		claim    btcjson.CreateMultisigClaim
+		typ      claimscript.Type
+		value    []byte
+		hasClaim bool
+	}{
+		{btcjson.CreateMultisigClaim{Name: "@shared", Value: "cafe"},
+			claimscript.TypeClaimName, []byte{0xca, 0xfe}, false},
+		{btcjson.CreateMultisigClaim{Type: "update", Name: "@shared", ClaimID: claimID, Value: "beef"},
+			claimscript.TypeUpdateClaim, []byte{0xbe, 0xef}, true},
+		{btcjson.CreateMultisigClaim{Type: "support", Name: "@shared", ClaimID: claimID},
+			claimscript.TypeSupportClaim, nil, true},
+	}
+	for _, test := range tests {
+		prefixed, err := claimScript(test.claim.Type, test.claim.Name,
+			test.claim.ClaimID, test.claim.Value, pkScript)
+		r.NoError(err, test.claim.Type)
+		script, err := claimscript.Parse(prefixed)
+		r.NoError(err)
+		r.Equal(test.typ, script.Type)
+		r.Equal([]byte("@shared"), script.Name)
+		r.Equal(test.value, script.Value)
+		r.Equal(pkScript, script.PkScript)
+		if test.hasClaim {
+			r.Equal(claimID, script.ClaimID.String())
+		}
+	}
+
+	invalid := []btcjson.CreateMultisigClaim{
+		{Name: "@shared", Value: "zz"},
+		{Name: "@shared", ClaimID: claimID},
+		{Type: "update", Name: "@shared", ClaimID: "12"},
+		{Type: "abandon", Name: "@shared"},
+	}
+	for _, claim := range invalid {
+		_, err := claimScript(claim.Type, claim.Name, claim.ClaimID,
+			claim.Value, pkScript)
+		r.Error(err, claim)
+	}
+}
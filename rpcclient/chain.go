@@ -450,7 +450,7 @@ func (r FutureGetDifficultyResult) Receive() (float64, error) {
 //
 // See GetDifficulty for the blocking version and more details.
 func (c *Client) GetDifficultyAsync() FutureGetDifficultyResult {
-	cmd := btcjson.NewGetDifficultyCmd()
+	cmd := btcjson.NewGetDifficultyCmd(nil)
 	return c.SendCmd(cmd)
 }
 
@@ -460,6 +460,26 @@ func (c *Client) GetDifficulty() (float64, error) {
 	return c.GetDifficultyAsync().Receive()
 }
 
+// GetDifficultyAtAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetDifficultyAt for the blocking version and more details.
+func (c *Client) GetDifficultyAtAsync(hashOrHeight interface{}) FutureGetDifficultyResult {
+	if hash, ok := hashOrHeight.(*chainhash.Hash); ok {
+		hashOrHeight = hash.String()
+	}
+
+	cmd := btcjson.NewGetDifficultyCmd(&btcjson.HashOrHeight{Value: hashOrHeight})
+	return c.SendCmd(cmd)
+}
+
+// GetDifficultyAt returns the proof-of-work difficulty of the block with the
+// given height or hash as a multiple of the minimum difficulty.
+func (c *Client) GetDifficultyAt(hashOrHeight interface{}) (float64, error) {
+	return c.GetDifficultyAtAsync(hashOrHeight).Receive()
+}
+
 // FutureGetBlockChainInfoResult is a promise to deliver the result of a
 // GetBlockChainInfoAsync RPC invocation (or an applicable error).
 type FutureGetBlockChainInfoResult struct {
@@ -1020,6 +1040,43 @@ func (c *Client) VerifyChainBlocks(checkLevel, numBlocks int32) (bool, error) {
 	return c.VerifyChainBlocksAsync(checkLevel, numBlocks).Receive()
 }
 
+// FutureGetVerifyChainInfoResult is a future promise to deliver the result of a
+// GetVerifyChainInfoAsync RPC invocation (or an applicable error).
+type FutureGetVerifyChainInfoResult chan *Response
+
+// Receive waits for the Response promised by the future and returns the
+// progress of the running chain verification, or the result of the last one.
+func (r FutureGetVerifyChainInfoResult) Receive() (*btcjson.GetVerifyChainInfoResult, error) {
+	res, err := ReceiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var info btcjson.GetVerifyChainInfoResult
+	err = json.Unmarshal(res, &info)
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// GetVerifyChainInfoAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetVerifyChainInfo for the blocking version and more details.
+func (c *Client) GetVerifyChainInfoAsync() FutureGetVerifyChainInfoResult {
+	cmd := btcjson.NewGetVerifyChainInfoCmd()
+	return c.SendCmd(cmd)
+}
+
+// GetVerifyChainInfo returns the progress of the chain verification started by
+// one of the VerifyChain functions, which keeps running on the server when the
+// client gives up waiting for it, or the result of the last one.
+func (c *Client) GetVerifyChainInfo() (*btcjson.GetVerifyChainInfoResult, error) {
+	return c.GetVerifyChainInfoAsync().Receive()
+}
+
 // FutureGetTxOutResult is a future promise to deliver the result of a
 // GetTxOutAsync RPC invocation (or an applicable error).
 type FutureGetTxOutResult chan *Response
@@ -1425,3 +1482,76 @@ func (c *Client) GetDescriptorInfoAsync(descriptor string) FutureGetDescriptorIn
 func (c *Client) GetDescriptorInfo(descriptor string) (*btcjson.GetDescriptorInfoResult, error) {
 	return c.GetDescriptorInfoAsync(descriptor).Receive()
 }
+
+// FutureGetChainSnapshotResult is a future promise to deliver the result of a
+// GetChainSnapshotAsync RPC invocation (or an applicable error).
+type FutureGetChainSnapshotResult chan *Response
+
+// Receive waits for the Response promised by the future and returns the
+// manifest of the chain snapshot along with its hash.
+func (r FutureGetChainSnapshotResult) Receive() (*btcjson.GetChainSnapshotResult, error) {
+	res, err := ReceiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot btcjson.GetChainSnapshotResult
+	err = json.Unmarshal(res, &snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// GetChainSnapshotAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetChainSnapshot for the blocking version and more details.
+func (c *Client) GetChainSnapshotAsync(name string) FutureGetChainSnapshotResult {
+	cmd := btcjson.NewGetChainSnapshotCmd(name)
+	return c.SendCmd(cmd)
+}
+
+// GetChainSnapshot returns the manifest of the chain snapshot with the passed
+// name along with its hash.
+func (c *Client) GetChainSnapshot(name string) (*btcjson.GetChainSnapshotResult, error) {
+	return c.GetChainSnapshotAsync(name).Receive()
+}
+
+// FutureGetChainSnapshotChunkResult is a future promise to deliver the result
+// of a GetChainSnapshotChunkAsync RPC invocation (or an applicable error).
+type FutureGetChainSnapshotChunkResult chan *Response
+
+// Receive waits for the Response promised by the future and returns the chunk
+// of the chain snapshot.
+func (r FutureGetChainSnapshotChunkResult) Receive() (*btcjson.GetChainSnapshotChunkResult, error) {
+	res, err := ReceiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var chunk btcjson.GetChainSnapshotChunkResult
+	err = json.Unmarshal(res, &chunk)
+	if err != nil {
+		return nil, err
+	}
+	return &chunk, nil
+}
+
+// GetChainSnapshotChunkAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See GetChainSnapshotChunk for the blocking version and more details.
+func (c *Client) GetChainSnapshotChunkAsync(name string, index int) FutureGetChainSnapshotChunkResult {
+	cmd := btcjson.NewGetChainSnapshotChunkCmd(name, index)
+	return c.SendCmd(cmd)
+}
+
+// GetChainSnapshotChunk returns the chunk at the passed index of the chain
+// snapshot with the passed name.  The hash of the chunk must be checked against
+// the manifest of the snapshot.
+func (c *Client) GetChainSnapshotChunk(name string, index int) (*btcjson.GetChainSnapshotChunkResult, error) {
+	return c.GetChainSnapshotChunkAsync(name, index).Receive()
+}
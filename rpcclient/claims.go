@@ -0,0 +1,306 @@
+package rpcclient
+
+import (
+	"encoding/json"
+
+	"github.com/lbryio/lbcd/btcjson"
+)
+
+// FutureGetChangesInBlockResult is a future promise to deliver the result of a
+// GetChangesInBlockAsync RPC invocation (or an applicable error).
+type FutureGetChangesInBlockResult chan *Response
+
+// Receive waits for the Response promised by the future and returns the names
+// changed by the block.
+func (r FutureGetChangesInBlockResult) Receive() (*btcjson.GetChangesInBlockResult, error) {
+	res, err := ReceiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes btcjson.GetChangesInBlockResult
+	err = json.Unmarshal(res, &changes)
+	if err != nil {
+		return nil, err
+	}
+	return &changes, nil
+}
+
+// GetChangesInBlockAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetChangesInBlock for the blocking version and more details.
+func (c *Client) GetChangesInBlockAsync(hashOrHeight *string) FutureGetChangesInBlockResult {
+	cmd := btcjson.NewGetChangesInBlockCmd(hashOrHeight)
+	return c.SendCmd(cmd)
+}
+
+// GetChangesInBlock returns the names whose claims were changed by the block
+// with the passed hash or height, or by the tip when it is nil.
+func (c *Client) GetChangesInBlock(hashOrHeight *string) (*btcjson.GetChangesInBlockResult, error) {
+	return c.GetChangesInBlockAsync(hashOrHeight).Receive()
+}
+
+// FutureGetClaimsForNameResult is a future promise to deliver the result of a
+// GetClaimsForNameAsync, GetClaimsForNameByIDAsync, GetClaimsForNameByBidAsync
+// or GetClaimsForNameBySeqAsync RPC invocation (or an applicable error).
+type FutureGetClaimsForNameResult chan *Response
+
+// Receive waits for the Response promised by the future and returns the claims
+// of the name.
+func (r FutureGetClaimsForNameResult) Receive() (*btcjson.GetClaimsForNameResult, error) {
+	res, err := ReceiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims btcjson.GetClaimsForNameResult
+	err = json.Unmarshal(res, &claims)
+	if err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+// GetClaimsForNameAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetClaimsForName for the blocking version and more details.
+func (c *Client) GetClaimsForNameAsync(name string, hashOrHeight *string,
+	includeValues *bool) FutureGetClaimsForNameResult {
+
+	cmd := btcjson.NewGetClaimsForNameCmd(name, hashOrHeight, includeValues)
+	return c.SendCmd(cmd)
+}
+
+// GetClaimsForName returns the claims of a name as of the block with the
+// passed hash or height, or of the tip when it is nil.  The values and the
+// addresses of the claims are returned when includeValues is set, which
+// requires the transaction index of the server.
+func (c *Client) GetClaimsForName(name string, hashOrHeight *string,
+	includeValues *bool) (*btcjson.GetClaimsForNameResult, error) {
+
+	return c.GetClaimsForNameAsync(name, hashOrHeight, includeValues).Receive()
+}
+
+// GetClaimsForNameByIDAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See GetClaimsForNameByID for the blocking version and more details.
+func (c *Client) GetClaimsForNameByIDAsync(name string, partialClaimIDs []string,
+	hashOrHeight *string, includeValues *bool) FutureGetClaimsForNameResult {
+
+	cmd := btcjson.NewGetClaimsForNameByIDCmd(name, partialClaimIDs,
+		hashOrHeight, includeValues)
+	return c.SendCmd(cmd)
+}
+
+// GetClaimsForNameByID returns the claims of a name whose claim ID starts with
+// one of the passed partial claim IDs.  See GetClaimsForName for the other
+// parameters.
+func (c *Client) GetClaimsForNameByID(name string, partialClaimIDs []string,
+	hashOrHeight *string, includeValues *bool) (*btcjson.GetClaimsForNameResult, error) {
+
+	return c.GetClaimsForNameByIDAsync(name, partialClaimIDs, hashOrHeight,
+		includeValues).Receive()
+}
+
+// GetClaimsForNameByBidAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See GetClaimsForNameByBid for the blocking version and more details.
+func (c *Client) GetClaimsForNameByBidAsync(name string, bids []int32,
+	hashOrHeight *string, includeValues *bool) FutureGetClaimsForNameResult {
+
+	cmd := btcjson.NewGetClaimsForNameByBidCmd(name, bids, hashOrHeight,
+		includeValues)
+	return c.SendCmd(cmd)
+}
+
+// GetClaimsForNameByBid returns the claims of a name with the passed bids, the
+// claim owning the name having the bid 0.  See GetClaimsForName for the other
+// parameters.
+func (c *Client) GetClaimsForNameByBid(name string, bids []int32,
+	hashOrHeight *string, includeValues *bool) (*btcjson.GetClaimsForNameResult, error) {
+
+	return c.GetClaimsForNameByBidAsync(name, bids, hashOrHeight,
+		includeValues).Receive()
+}
+
+// GetClaimsForNameBySeqAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See GetClaimsForNameBySeq for the blocking version and more details.
+func (c *Client) GetClaimsForNameBySeqAsync(name string, sequences []int32,
+	hashOrHeight *string, includeValues *bool) FutureGetClaimsForNameResult {
+
+	cmd := btcjson.NewGetClaimsForNameBySeqCmd(name, sequences, hashOrHeight,
+		includeValues)
+	return c.SendCmd(cmd)
+}
+
+// GetClaimsForNameBySeq returns the claims of a name with the passed
+// sequences, which order the claims of the name by creation.  See
+// GetClaimsForName for the other parameters.
+func (c *Client) GetClaimsForNameBySeq(name string, sequences []int32,
+	hashOrHeight *string, includeValues *bool) (*btcjson.GetClaimsForNameResult, error) {
+
+	return c.GetClaimsForNameBySeqAsync(name, sequences, hashOrHeight,
+		includeValues).Receive()
+}
+
+// FutureGetValueForNameResult is a future promise to deliver the result of a
+// GetValueForNameAsync RPC invocation (or an applicable error).
+type FutureGetValueForNameResult chan *Response
+
+// Receive waits for the Response promised by the future and returns the claim
+// owning the name.
+func (r FutureGetValueForNameResult) Receive() (*btcjson.GetValueForNameResult, error) {
+	res, err := ReceiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var value btcjson.GetValueForNameResult
+	err = json.Unmarshal(res, &value)
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// GetValueForNameAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetValueForName for the blocking version and more details.
+func (c *Client) GetValueForNameAsync(name string, hashOrHeight *string,
+	includeValues *bool) FutureGetValueForNameResult {
+
+	cmd := btcjson.NewGetValueForNameCmd(name, hashOrHeight, includeValues)
+	return c.SendCmd(cmd)
+}
+
+// GetValueForName returns the claim owning a name as of the block with the
+// passed hash or height, or of the tip when it is nil.  Its value and address
+// are returned unless includeValues is false, and require the transaction index
+// of the server.
+func (c *Client) GetValueForName(name string, hashOrHeight *string,
+	includeValues *bool) (*btcjson.GetValueForNameResult, error) {
+
+	return c.GetValueForNameAsync(name, hashOrHeight, includeValues).Receive()
+}
+
+// FutureGetNameProofResult is a future promise to deliver the result of a
+// GetNameProofAsync RPC invocation (or an applicable error).
+type FutureGetNameProofResult chan *Response
+
+// Receive waits for the Response promised by the future and returns the proof
+// of the name.
+func (r FutureGetNameProofResult) Receive() (*btcjson.GetNameProofResult, error) {
+	res, err := ReceiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var proof btcjson.GetNameProofResult
+	err = json.Unmarshal(res, &proof)
+	if err != nil {
+		return nil, err
+	}
+	return &proof, nil
+}
+
+// GetNameProofAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetNameProof for the blocking version and more details.
+func (c *Client) GetNameProofAsync(name string) FutureGetNameProofResult {
+	cmd := btcjson.NewGetNameProofCmd(name)
+	return c.SendCmd(cmd)
+}
+
+// GetNameProof returns the merkle proof of the claims of a name against the
+// claimtrie hash of the tip.
+func (c *Client) GetNameProof(name string) (*btcjson.GetNameProofResult, error) {
+	return c.GetNameProofAsync(name).Receive()
+}
+
+// FutureNormalizeResult is a future promise to deliver the result of a
+// NormalizeAsync RPC invocation (or an applicable error).
+type FutureNormalizeResult chan *Response
+
+// Receive waits for the Response promised by the future and returns the
+// normalized name.
+func (r FutureNormalizeResult) Receive() (string, error) {
+	res, err := ReceiveFuture(r)
+	if err != nil {
+		return "", err
+	}
+
+	var normalized btcjson.GetNormalizedResult
+	err = json.Unmarshal(res, &normalized)
+	if err != nil {
+		return "", err
+	}
+	return normalized.NormalizedName, nil
+}
+
+// NormalizeAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See Normalize for the blocking version and more details.
+func (c *Client) NormalizeAsync(name string) FutureNormalizeResult {
+	cmd := btcjson.NewGetNormalizedCmd(name)
+	return c.SendCmd(cmd)
+}
+
+// Normalize returns the name as normalized by the claimtrie once names are
+// normalized.
+func (c *Client) Normalize(name string) (string, error) {
+	return c.NormalizeAsync(name).Receive()
+}
+
+// FutureSimulateClaimSupportResult is a future promise to deliver the result of
+// a SimulateClaimSupportAsync RPC invocation (or an applicable error).
+type FutureSimulateClaimSupportResult chan *Response
+
+// Receive waits for the Response promised by the future and returns the
+// outcome of the simulated support.
+func (r FutureSimulateClaimSupportResult) Receive() (*btcjson.SimulateClaimSupportResult, error) {
+	res, err := ReceiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var sim btcjson.SimulateClaimSupportResult
+	err = json.Unmarshal(res, &sim)
+	if err != nil {
+		return nil, err
+	}
+	return &sim, nil
+}
+
+// SimulateClaimSupportAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See SimulateClaimSupport for the blocking version and more details.
+func (c *Client) SimulateClaimSupportAsync(name, claimID string, amount int64) FutureSimulateClaimSupportResult {
+	cmd := btcjson.NewSimulateClaimSupportCmd(name, claimID, amount)
+	return c.SendCmd(cmd)
+}
+
+// SimulateClaimSupport returns whether and when a support of amount dewies for
+// the claim of a name included in the next block would make it the best claim
+// of the name, along with the smallest support which would.
+func (c *Client) SimulateClaimSupport(name, claimID string, amount int64) (*btcjson.SimulateClaimSupportResult, error) {
+	return c.SimulateClaimSupportAsync(name, claimID, amount).Receive()
+}
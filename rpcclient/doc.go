@@ -57,6 +57,33 @@ the Receive method on the returned instance will either return the result
 immediately if it has already arrived, or block until it has.  This is useful
 since it provides the caller with greater control over concurrency.
 
+# Batch Requests
+
+A client created with NewBatch queues the commands issued through the
+asynchronous API instead of sending them.  Send then sends the queued commands
+as a single JSON-RPC 2.0 batch request, and the responses are delivered to
+their futures.  This cuts the round trips when fetching many blocks or
+transactions at once.  Batches are supported in HTTP POST mode, and over
+websockets with lbcd, where they share the connection with the other requests.
+
+# Contexts
+
+The requests of the client returned by WithContext are made with the passed
+context, so the calls of both APIs are cancelled when it is, or when its
+deadline is exceeded.  Their HTTP POST requests are aborted, and their futures
+return the error of the context instead of waiting for the reply.
+
+# Failover
+
+The client fails over to the servers of the FailoverHosts option, in order,
+when the server of Host is unavailable: the HTTP POST requests are sent to the
+next server when one fails or replies 503, and the websocket connection is
+reestablished to the next server.  The servers are checked periodically in HTTP
+POST mode so the requests go back to the preferred ones once available again,
+and the Endpoints function returns their health.  The LoadBalance option
+spreads the read-only queries, such as getblock, round robin across the
+available servers in HTTP POST mode.
+
 # Notifications
 
 The first important part of notifications is to realize that they will only
@@ -93,6 +120,13 @@ notifications are automatically re-registered and any in-flight commands are
 re-issued.  This means from the caller's perspective, the request simply takes
 longer to complete.
 
+The notifications of the blocks connected or disconnected while the connection
+was lost are missed, unless the ReplayBlocks flag is set in the connection
+config.  The block notifications are then re-registered from the last block
+notified, and the missed ones are delivered first.  OnBlockReplayFailed is
+invoked when lbcd refuses to replay them, typically because too many blocks
+were missed.
+
 The caller may invoke the Shutdown method on the client to force the client
 to cease reconnect attempts and return ErrClientShutdown for all outstanding
 commands.
@@ -100,6 +134,23 @@ commands.
 The automatic reconnection can be disabled by setting the DisableAutoReconnect
 flag to true in the connection config when creating the client.
 
+# Retry Policies
+
+The RetryPolicy of the connection config sets how the HTTP POST requests
+failing with a transient error are retried: the max number of attempts, the
+backoff between them, which may grow exponentially and be shortened by a random
+jitter, and the classification of the errors retried, which defaults to
+IsTransientError.  The ReconnectPolicy likewise sets how the websocket
+connection is reestablished, and the client is shut down when it gives up.
+
+# Iterators
+
+AddressTransactions, Blocks and ClaimChanges return iterators over the
+transactions of an address, the blocks of a range of heights and the claim
+changes of those blocks.  They fetch the results page by page as they are
+consumed, so that the callers range over them with Next until it returns false,
+and then check Err.
+
 Minor RPC Server Differences and Chain/Wallet Separation
 
 Some of the commands are extensions specific to a particular RPC server.  For
@@ -0,0 +1,290 @@
+package rpcclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/websocket"
+)
+
+const (
+	// defaultHealthCheckInterval is the interval between the health checks
+	// of the servers of a client with failover hosts, unless overridden by
+	// the HealthCheckInterval option.
+	defaultHealthCheckInterval = 30 * time.Second
+
+	// healthCheckTimeout is the time the health check of a server waits
+	// for its response.
+	healthCheckTimeout = 10 * time.Second
+)
+
+// idempotentMethods are the methods of the read-only queries, whose requests
+// are spread across the servers when the LoadBalance option is set.
+var idempotentMethods = map[string]struct{}{
+	"decoderawtransaction":  {},
+	"decodescript":          {},
+	"estimatefee":           {},
+	"estimatesmartfee":      {},
+	"getbestblock":          {},
+	"getbestblockhash":      {},
+	"getblock":              {},
+	"getblockchaininfo":     {},
+	"getblockcount":         {},
+	"getblockfilter":        {},
+	"getblockhash":          {},
+	"getblockheader":        {},
+	"getblockstats":         {},
+	"getcfilter":            {},
+	"getcfilterheader":      {},
+	"getchaintips":          {},
+	"getchangesinblock":     {},
+	"getclaimsforname":      {},
+	"getclaimsfornamebybid": {},
+	"getclaimsfornamebyid":  {},
+	"getclaimsfornamebyseq": {},
+	"getdifficulty":         {},
+	"getheaders":            {},
+	"getmempoolentry":       {},
+	"getmempoolinfo":        {},
+	"getnameproof":          {},
+	"getrawmempool":         {},
+	"getrawtransaction":     {},
+	"gettxout":              {},
+	"getvalueforname":       {},
+	"normalize":             {},
+	"searchrawtransactions": {},
+	"validateaddress":       {},
+}
+
+// isIdempotent returns whether the requests of the passed method are read-only
+// queries.
+func isIdempotent(method string) bool {
+	_, ok := idempotentMethods[method]
+	return ok
+}
+
+// EndpointStatus is the health of a server of a client, as of its last request
+// or health check.
+type EndpointStatus struct {
+	// Host is the address of the server.
+	Host string
+
+	// Healthy is set unless the last request or health check of the
+	// server failed.
+	Healthy bool
+
+	// LastError is the error of the last failed request or health check
+	// of the server.
+	LastError error
+}
+
+// endpointPool tracks the health of the servers of a client with failover
+// hosts, and orders them for its requests.
+type endpointPool struct {
+	mtx       sync.Mutex
+	endpoints []EndpointStatus
+	next      int
+}
+
+// newEndpointPool returns the pool of the servers of the passed configuration:
+// its host and then its failover hosts, all healthy until proven otherwise.
+func newEndpointPool(config *ConnConfig) *endpointPool {
+	hosts := append([]string{config.Host}, config.FailoverHosts...)
+	endpoints := make([]EndpointStatus, 0, len(hosts))
+	for _, host := range hosts {
+		endpoints = append(endpoints, EndpointStatus{
+			Host:    host,
+			Healthy: true,
+		})
+	}
+	return &endpointPool{endpoints: endpoints}
+}
+
+// order returns the hosts to try for a request, in order: the healthy ones and
+// then the unhealthy ones, by priority.  The healthy ones are rotated round
+// robin instead when balance is set.
+func (p *endpointPool) order(balance bool) []string {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	var healthy, unhealthy []string
+	for _, endpoint := range p.endpoints {
+		if endpoint.Healthy {
+			healthy = append(healthy, endpoint.Host)
+		} else {
+			unhealthy = append(unhealthy, endpoint.Host)
+		}
+	}
+	if balance && len(healthy) > 1 {
+		start := p.next % len(healthy)
+		p.next++
+		healthy = append(healthy[start:], healthy[:start]...)
+	}
+	return append(healthy, unhealthy...)
+}
+
+// report records the result of a request or health check of the server of the
+// passed host.
+func (p *endpointPool) report(host string, err error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	for i := range p.endpoints {
+		endpoint := &p.endpoints[i]
+		if endpoint.Host != host {
+			continue
+		}
+		if err != nil {
+			if endpoint.Healthy {
+				log.Warnf("RPC server %s is unavailable: %v",
+					host, err)
+			}
+			endpoint.Healthy = false
+			endpoint.LastError = err
+		} else if !endpoint.Healthy {
+			log.Infof("RPC server %s is available again", host)
+			endpoint.Healthy = true
+		}
+		return
+	}
+}
+
+// statuses returns the health of the servers, by priority.
+func (p *endpointPool) statuses() []EndpointStatus {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	return append([]EndpointStatus(nil), p.endpoints...)
+}
+
+// checkFailoverConfig returns an error when the failover hosts of the passed
+// configuration can't be used.
+func checkFailoverConfig(config *ConnConfig) error {
+	if len(config.FailoverHosts) == 0 {
+		return nil
+	}
+	for _, host := range append([]string{config.Host}, config.FailoverHosts...) {
+		if strings.HasPrefix(host, UnixSocketPrefix) {
+			return fmt.Errorf("unix domain socket %s can't be used "+
+				"with failover hosts", host)
+		}
+	}
+	return nil
+}
+
+// hosts returns the hosts to try for the passed request, in order.
+func (c *Client) hosts(jReq *jsonRequest) []string {
+	if c.endpoints == nil {
+		return []string{c.config.httpHost()}
+	}
+	return c.endpoints.order(c.config.LoadBalance && jReq.idempotent)
+}
+
+// reportHost records the result of a request to the server of the passed host.
+func (c *Client) reportHost(host string, err error) {
+	if c.endpoints != nil {
+		c.endpoints.report(host, err)
+	}
+}
+
+// Endpoints returns the health of the servers of a client with failover hosts,
+// by priority, or nil without failover hosts.
+func (c *Client) Endpoints() []EndpointStatus {
+	if c.endpoints == nil {
+		return nil
+	}
+	return c.endpoints.statuses()
+}
+
+// dialEndpoints opens a websocket connection to the first server of the passed
+// configuration that accepts it, trying the healthy ones first when it has
+// failover hosts.  The host connected to is returned along with the
+// connection.
+func dialEndpoints(config *ConnConfig, endpoints *endpointPool) (*websocket.Conn,
+	string, error) {
+
+	if endpoints == nil {
+		wsConn, err := dial(config, config.httpHost())
+		return wsConn, config.Host, err
+	}
+
+	var err error
+	for _, host := range endpoints.order(false) {
+		var wsConn *websocket.Conn
+		wsConn, err = dial(config, host)
+		endpoints.report(host, err)
+		if err == nil {
+			return wsConn, host, nil
+		}
+	}
+	return nil, "", err
+}
+
+// checkEndpoint checks the health of the server of the passed host, which must
+// reply to a getblockcount request.
+func (c *Client) checkEndpoint(host string) error {
+	ctx, cancel := context.WithTimeout(context.Background(),
+		healthCheckTimeout)
+	defer cancel()
+
+	jReq := &jsonRequest{
+		marshalledJSON: []byte(`{"jsonrpc":"1.0","method":"getblockcount","params":[],"id":0}`),
+		ctx:            ctx,
+	}
+	httpReq, err := c.newPostRequest(jReq, host)
+	if err != nil {
+		return err
+	}
+
+	httpResponse, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResponse.Body.Close()
+	respBytes, err := ioutil.ReadAll(httpResponse.Body)
+	if err != nil {
+		return err
+	}
+
+	var resp rawResponse
+	if httpResponse.StatusCode != http.StatusOK ||
+		json.Unmarshal(respBytes, &resp) != nil || resp.Error != nil {
+
+		return fmt.Errorf("status code: %d, response: %q",
+			httpResponse.StatusCode, string(respBytes))
+	}
+	return nil
+}
+
+// healthCheckHandler periodically checks the health of the servers of a client
+// with failover hosts in HTTP POST mode, so the requests go back to the
+// preferred servers once they are available again.  It must be run as a
+// goroutine.
+func (c *Client) healthCheckHandler() {
+	defer c.wg.Done()
+
+	interval := c.config.HealthCheckInterval
+	if interval <= 0 {
+		interval = defaultHealthCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for _, endpoint := range c.endpoints.statuses() {
+				err := c.checkEndpoint(endpoint.Host)
+				c.endpoints.report(endpoint.Host, err)
+			}
+
+		case <-c.shutdown:
+			return
+		}
+	}
+}
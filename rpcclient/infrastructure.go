@@ -7,6 +7,7 @@ package rpcclient
 import (
 	"bytes"
 	"container/list"
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/base64"
@@ -100,6 +101,14 @@ type jsonRequest struct {
 	cmd            interface{}
 	marshalledJSON []byte
 	responseChan   chan *Response
+
+	// idempotent is set for the read-only queries, which may be load
+	// balanced across the servers.
+	idempotent bool
+
+	// ctx is the context of the request, which aborts the HTTP POST
+	// requests when done.  It is never nil.
+	ctx context.Context
 }
 
 // BackendVersion represents the version of the backend the client is currently
@@ -130,7 +139,21 @@ const (
 // result of the invocation at some future time.  Invoking the Receive method on
 // the returned future will block until the result is available if it's not
 // already.
+//
+// The requests are made with the context of the client, set by WithContext,
+// which cancels them when done.
 type Client struct {
+	*clientCore
+
+	// ctx is the context the requests of the client are made with, or nil
+	// when they are never cancelled.
+	ctx context.Context
+}
+
+// clientCore is the connection to the RPC server and the state of its
+// requests, which are shared by a Client and those derived from it by
+// WithContext.
+type clientCore struct {
 	id uint64 // atomic, so must stay 64-bit aligned
 
 	// config holds the connection configuration assoiated with this client.
@@ -159,7 +182,13 @@ type Client struct {
 	// disconnected indicated whether or not the server is disconnected.
 	disconnected bool
 
-	// whether or not to batch requests, false unless changed by Batch()
+	// endpoints tracks the health of the servers when there are failover
+	// hosts, and is nil otherwise.
+	endpoints *endpointPool
+
+	// batch is set for the clients created by NewBatch, which queue the
+	// requests to batchList until Send is called.  batchList is protected
+	// by the request lock.
 	batch     bool
 	batchList *list.List
 
@@ -220,16 +249,47 @@ func (c *Client) addRequest(jReq *jsonRequest) error {
 	default:
 	}
 
-	if !c.batch {
-		element := c.requestList.PushBack(jReq)
-		c.requestMap[jReq.id] = element
-	} else {
-		element := c.batchList.PushBack(jReq)
-		c.requestMap[jReq.id] = element
+	element := c.requestList.PushBack(jReq)
+	c.requestMap[jReq.id] = element
+	return nil
+}
+
+// addBatchRequest queues the passed jsonRequest to the batch sent by the next
+// call to Send.
+//
+// If the client has already begun shutting down, ErrClientShutdown is returned
+// and the request is not added.
+//
+// This function is safe for concurrent access.
+func (c *Client) addBatchRequest(jReq *jsonRequest) error {
+	c.requestLock.Lock()
+	defer c.requestLock.Unlock()
+
+	select {
+	case <-c.shutdown:
+		return ErrClientShutdown
+	default:
 	}
+
+	c.batchList.PushBack(jReq)
 	return nil
 }
 
+// takeBatch returns the requests queued to the batch, and starts a new batch.
+//
+// This function is safe for concurrent access.
+func (c *Client) takeBatch() []*jsonRequest {
+	c.requestLock.Lock()
+	defer c.requestLock.Unlock()
+
+	batch := make([]*jsonRequest, 0, c.batchList.Len())
+	for e := c.batchList.Front(); e != nil; e = e.Next() {
+		batch = append(batch, e.Value.(*jsonRequest))
+	}
+	c.batchList.Init()
+	return batch
+}
+
 // removeRequest returns and removes the jsonRequest which contains the response
 // channel and original method associated with the passed id or nil if there is
 // no association.
@@ -282,6 +342,10 @@ func (c *Client) trackRegisteredNtfns(cmd interface{}) {
 
 		}
 
+	case *btcjson.NotifyWorkCmd:
+		c.ntfnState.notifyWork = true
+		c.ntfnState.notifyWorkDeltas = bcmd.Deltas != nil && *bcmd.Deltas
+
 	case *btcjson.NotifySpentCmd:
 		for _, op := range bcmd.OutPoints {
 			c.ntfnState.notifySpent[op] = struct{}{}
@@ -291,6 +355,64 @@ func (c *Client) trackRegisteredNtfns(cmd interface{}) {
 		for _, addr := range bcmd.Addresses {
 			c.ntfnState.notifyReceived[addr] = struct{}{}
 		}
+
+	case *btcjson.LoadTxFilterCmd:
+		if bcmd.Reload {
+			c.ntfnState.txFilterAddresses = make(map[string]struct{})
+			c.ntfnState.txFilterOutPoints = make(map[btcjson.OutPoint]struct{})
+			c.ntfnState.txFilterClaimNames = make(map[string]struct{})
+			c.ntfnState.txFilterClaimIDs = make(map[string]struct{})
+		}
+		for _, addr := range bcmd.Addresses {
+			c.ntfnState.txFilterAddresses[addr] = struct{}{}
+		}
+		for _, op := range bcmd.OutPoints {
+			c.ntfnState.txFilterOutPoints[op] = struct{}{}
+		}
+		if bcmd.ClaimNames != nil {
+			for _, name := range *bcmd.ClaimNames {
+				c.ntfnState.txFilterClaimNames[name] = struct{}{}
+			}
+		}
+		if bcmd.ClaimIDs != nil {
+			for _, id := range *bcmd.ClaimIDs {
+				c.ntfnState.txFilterClaimIDs[id] = struct{}{}
+			}
+		}
+
+	// The notifications stopped aren't reregistered on reconnect.
+	case *btcjson.StopNotifyBlocksCmd:
+		c.ntfnState.notifyBlocks = false
+
+	case *btcjson.StopNotifyNewTransactionsCmd:
+		c.ntfnState.notifyNewTx = false
+		c.ntfnState.notifyNewTxVerbose = false
+
+	case *btcjson.NotifyClaimsCmd:
+		c.ntfnState.notifyClaims = true
+		c.ntfnState.notifyClaimNames = nil
+		if bcmd.Names != nil {
+			c.ntfnState.notifyClaimNames = append([]string(nil),
+				*bcmd.Names...)
+		}
+
+	case *btcjson.StopNotifyClaimsCmd:
+		c.ntfnState.notifyClaims = false
+		c.ntfnState.notifyClaimNames = nil
+
+	case *btcjson.StopNotifyWorkCmd:
+		c.ntfnState.notifyWork = false
+		c.ntfnState.notifyWorkDeltas = false
+
+	case *btcjson.StopNotifySpentCmd:
+		for _, op := range bcmd.OutPoints {
+			delete(c.ntfnState.notifySpent, op)
+		}
+
+	case *btcjson.StopNotifyReceivedCmd:
+		for _, addr := range bcmd.Addresses {
+			delete(c.ntfnState.notifyReceived, addr)
+		}
 	}
 }
 
@@ -322,7 +444,7 @@ func (r FutureGetBulkResult) Receive() (BulkResult, error) {
 // IndividualBulkResult represents one result
 // from a bulk json rpc api
 type IndividualBulkResult struct {
-	Result interface{}       `json:"result"`
+	Result json.RawMessage   `json:"result"`
 	Error  *btcjson.RPCError `json:"error"`
 	Id     uint64            `json:"id"`
 }
@@ -373,6 +495,20 @@ func (r rawResponse) result() (result []byte, err error) {
 
 // handleMessage is the main handler for incoming notifications and responses.
 func (c *Client) handleMessage(msg []byte) {
+	// The responses to a batch request are sent back as an array, and
+	// delivered one by one.
+	if bytes.HasPrefix(bytes.TrimSpace(msg), []byte("[")) {
+		var responses []json.RawMessage
+		if err := json.Unmarshal(msg, &responses); err != nil {
+			log.Warnf("Remote server sent invalid message: %v", err)
+			return
+		}
+		for _, response := range responses {
+			c.handleMessage(response)
+		}
+		return
+	}
+
 	// Attempt to unmarshal the message as either a notification or
 	// response.
 	var in inMessage
@@ -571,10 +707,74 @@ func (c *Client) reregisterNtfns() error {
 	stateCopy := c.ntfnState.Copy()
 	c.ntfnStateLock.Unlock()
 
-	// Reregister notifyblocks if needed.
+	// Reload the transaction filter if needed.  This is done before
+	// reregistering notifyblocks so the filtered block notifications
+	// replayed match it.
+	if len(stateCopy.txFilterAddresses) > 0 ||
+		len(stateCopy.txFilterOutPoints) > 0 ||
+		len(stateCopy.txFilterClaimNames) > 0 ||
+		len(stateCopy.txFilterClaimIDs) > 0 {
+
+		addresses := make([]string, 0, len(stateCopy.txFilterAddresses))
+		for addr := range stateCopy.txFilterAddresses {
+			addresses = append(addresses, addr)
+		}
+		outpoints := make([]btcjson.OutPoint, 0,
+			len(stateCopy.txFilterOutPoints))
+		for op := range stateCopy.txFilterOutPoints {
+			outpoints = append(outpoints, op)
+		}
+		var claimNames, claimIDs *[]string
+		if len(stateCopy.txFilterClaimNames) > 0 ||
+			len(stateCopy.txFilterClaimIDs) > 0 {
+
+			names := make([]string, 0, len(stateCopy.txFilterClaimNames))
+			for name := range stateCopy.txFilterClaimNames {
+				names = append(names, name)
+			}
+			ids := make([]string, 0, len(stateCopy.txFilterClaimIDs))
+			for id := range stateCopy.txFilterClaimIDs {
+				ids = append(ids, id)
+			}
+			claimNames, claimIDs = &names, &ids
+		}
+		log.Debugf("Reloading [loadtxfilter] with %d addresses, %d "+
+			"outpoints and %d claims", len(addresses), len(outpoints),
+			len(stateCopy.txFilterClaimNames)+
+				len(stateCopy.txFilterClaimIDs))
+		cmd := btcjson.NewLoadTxFilterCmd(true, addresses, outpoints,
+			claimNames, claimIDs)
+		if _, err := c.sendCmdAndWait(cmd); err != nil {
+			return err
+		}
+	}
+
+	// Reregister notifyblocks if needed, replaying the notifications of
+	// the blocks missed since the last one notified when requested.
 	if stateCopy.notifyBlocks {
-		log.Debugf("Reregistering [notifyblocks]")
-		if err := c.NotifyBlocks(); err != nil {
+		var err error
+		if c.config.ReplayBlocks && stateCopy.lastBlock != "" {
+			log.Debugf("Reregistering [notifyblocks] from %s",
+				stateCopy.lastBlock)
+			err = c.NotifyBlocksFrom(stateCopy.lastBlock)
+
+			// The notifications resume from the tip when the
+			// server refuses to replay them.
+			if _, ok := err.(*btcjson.RPCError); ok {
+				log.Warnf("Unable to replay the block "+
+					"notifications since %s: %v",
+					stateCopy.lastBlock, err)
+				if c.ntfnHandlers.OnBlockReplayFailed != nil {
+					go c.ntfnHandlers.OnBlockReplayFailed(
+						stateCopy.lastBlock, err)
+				}
+				err = c.NotifyBlocks()
+			}
+		} else {
+			log.Debugf("Reregistering [notifyblocks]")
+			err = c.NotifyBlocks()
+		}
+		if err != nil {
 			return err
 		}
 	}
@@ -589,6 +789,24 @@ func (c *Client) reregisterNtfns() error {
 		}
 	}
 
+	// Reregister notifywork if needed.
+	if stateCopy.notifyWork {
+		log.Debugf("Reregistering [notifywork] (deltas=%v)",
+			stateCopy.notifyWorkDeltas)
+		if err := c.NotifyWork(stateCopy.notifyWorkDeltas); err != nil {
+			return err
+		}
+	}
+
+	// Reregister notifyclaims if needed.
+	if stateCopy.notifyClaims {
+		log.Debugf("Reregistering [notifyclaims] (names=%v)",
+			stateCopy.notifyClaimNames)
+		if err := c.NotifyClaims(stateCopy.notifyClaimNames); err != nil {
+			return err
+		}
+	}
+
 	// Reregister the combination of all previously registered notifyspent
 	// outpoints in one command if needed.
 	nslen := len(stateCopy.notifySpent)
@@ -683,6 +901,8 @@ func (c *Client) resendRequests() {
 //
 // This function must be run as a goroutine.
 func (c *Client) wsReconnectHandler() {
+	policy := c.config.ReconnectPolicy.withDefaults(&defaultReconnectPolicy)
+
 out:
 	for {
 		select {
@@ -702,28 +922,39 @@ out:
 			default:
 			}
 
-			wsConn, err := dial(c.config)
+			wsConn, host, err := dialEndpoints(c.config, c.endpoints)
 			if err != nil {
 				c.retryCount++
 				log.Infof("Failed to connect to %s: %v",
 					c.config.Host, err)
 
+				// The client is shut down once the policy
+				// gives up on the connection.
+				attempts := int(c.retryCount)
+				if !policy.canRetry(attempts) || !policy.Retryable(err) {
+					log.Errorf("Giving up reconnecting to %s "+
+						"after %d attempts", c.config.Host,
+						attempts)
+					c.Shutdown()
+					break out
+				}
+
 				// Scale the retry interval by the number of
 				// retries so there is a backoff up to a max
-				// of 1 minute.
-				scaledInterval := connectionRetryInterval.Nanoseconds() * c.retryCount
-				scaledDuration := time.Duration(scaledInterval)
-				if scaledDuration > time.Minute {
-					scaledDuration = time.Minute
-				}
+				// of 1 minute by default.
+				scaledDuration := policy.backoff(attempts)
 				log.Infof("Retrying connection to %s in "+
 					"%s", c.config.Host, scaledDuration)
-				time.Sleep(scaledDuration)
+				select {
+				case <-time.After(scaledDuration):
+				case <-c.shutdown:
+					break out
+				}
 				continue reconnect
 			}
 
 			log.Infof("Reestablished connection to RPC server %s",
-				c.config.Host)
+				host)
 
 			// Reset the version in case the backend was
 			// disconnected due to an upgrade.
@@ -733,10 +964,10 @@ out:
 
 			// Reset the connection state and signal the reconnect
 			// has happened.
-			c.wsConn = wsConn
 			c.retryCount = 0
 
 			c.mtx.Lock()
+			c.wsConn = wsConn
 			c.disconnect = make(chan struct{})
 			c.disconnected = false
 			c.mtx.Unlock()
@@ -758,60 +989,114 @@ out:
 	log.Tracef("RPC client reconnect handler done for %s", c.config.Host)
 }
 
-// handleSendPostMessage handles performing the passed HTTP request, reading the
-// result, unmarshalling it, and delivering the unmarshalled result to the
-// provided response channel.
-func (c *Client) handleSendPostMessage(jReq *jsonRequest) {
+// newPostRequest returns the HTTP POST request of the passed json request to
+// the server of the passed host.
+func (c *Client) newPostRequest(jReq *jsonRequest, host string) (*http.Request, error) {
 	protocol := "http"
-	if !c.config.DisableTLS {
+	if c.config.useTLS() {
 		protocol = "https"
 	}
-	url := protocol + "://" + c.config.Host
+	url := protocol + "://" + host
+
+	bodyReader := bytes.NewReader(jReq.marshalledJSON)
+	httpReq, err := http.NewRequestWithContext(jReq.ctx, "POST", url,
+		bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Close = true
+	httpReq.Header.Set("Content-Type", "application/json")
+	for key, value := range c.config.ExtraHeaders {
+		httpReq.Header.Set(key, value)
+	}
+
+	// Configure basic access authorization.
+	user, pass, err := c.config.getAuth()
+	if err != nil {
+		return nil, err
+	}
+	httpReq.SetBasicAuth(user, pass)
+	return httpReq, nil
+}
+
+// handleSendPostMessage handles performing the passed HTTP request, reading the
+// result, unmarshalling it, and delivering the unmarshalled result to the
+// provided response channel.  With failover hosts, the request goes to the
+// next server when one is unavailable.
+func (c *Client) handleSendPostMessage(jReq *jsonRequest) {
+	policy := c.config.RetryPolicy.withDefaults(&defaultRetryPolicy)
+	hosts := c.hosts(jReq)
 
 	var err error
-	var backoff time.Duration
 	var httpResponse *http.Response
-	tries := 10
-	for i := 0; tries == 0 || i < tries; i++ {
-		bodyReader := bytes.NewReader(jReq.marshalledJSON)
-		var httpReq *http.Request
-		httpReq, err = http.NewRequest("POST", url, bodyReader)
-		if err != nil {
-			jReq.responseChan <- &Response{result: nil, err: err}
+	for attempt := 1; ; attempt++ {
+		// The request isn't sent, or retried, once its context is
+		// done.
+		if err := jReq.ctx.Err(); err != nil {
+			jReq.responseChan <- &Response{err: err}
 			return
 		}
-		httpReq.Close = true
-		httpReq.Header.Set("Content-Type", "application/json")
-		for key, value := range c.config.ExtraHeaders {
-			httpReq.Header.Set(key, value)
-		}
 
-		// Configure basic access authorization.
-		var user, pass string
-		user, pass, err = c.config.getAuth()
-		if err != nil {
-			jReq.responseChan <- &Response{result: nil, err: err}
-			return
+		for _, host := range hosts {
+			var httpReq *http.Request
+			httpReq, err = c.newPostRequest(jReq, host)
+			if err != nil {
+				jReq.responseChan <- &Response{result: nil, err: err}
+				return
+			}
+
+			httpResponse, err = c.httpClient.Do(httpReq)
+
+			// A server which is too busy or shutting down is
+			// failed over.
+			if err == nil && len(hosts) > 1 &&
+				httpResponse.StatusCode == http.StatusServiceUnavailable {
+
+				httpResponse.Body.Close()
+				err = &HTTPStatusError{
+					StatusCode: httpResponse.StatusCode,
+					Status:     httpResponse.Status,
+				}
+			}
+			if jReq.ctx.Err() == nil {
+				c.reportHost(host, err)
+			}
+			if err == nil {
+				break
+			}
 		}
-		httpReq.SetBasicAuth(user, pass)
 
-		httpResponse, err = c.httpClient.Do(httpReq)
-		if err != nil {
-			backoff = requestRetryInterval * time.Duration(i+1)
-			if backoff > time.Minute {
-				backoff = time.Minute
+		// The responses with an error status are retried when the
+		// policy classifies them as transient, and until the last
+		// attempt, which is replied as is.
+		if err == nil && httpResponse.StatusCode != http.StatusOK &&
+			policy.canRetry(attempt) {
+
+			statusErr := &HTTPStatusError{
+				StatusCode: httpResponse.StatusCode,
+				Status:     httpResponse.Status,
+			}
+			if policy.Retryable(statusErr) {
+				httpResponse.Body.Close()
+				err = statusErr
 			}
-			log.Debugf("Failed command [%s] with id %d attempt %d. Retrying in %v... \n", jReq.method, jReq.id, i, backoff)
-			time.Sleep(backoff)
-			continue
 		}
-		defer httpResponse.Body.Close()
-		break
-	}
-	if err != nil {
-		jReq.responseChan <- &Response{err: err}
-		return
+		if err == nil {
+			break
+		}
+
+		if !policy.canRetry(attempt) || !policy.Retryable(err) {
+			jReq.responseChan <- &Response{err: err}
+			return
+		}
+		backoff := policy.backoff(attempt)
+		log.Debugf("Failed command [%s] with id %d attempt %d. Retrying in %v... \n", jReq.method, jReq.id, attempt, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-jReq.ctx.Done():
+		}
 	}
+	defer httpResponse.Body.Close()
 
 	// Read the raw bytes from the response.
 	respBytes, err := ioutil.ReadAll(httpResponse.Body)
@@ -930,16 +1215,16 @@ func (c *Client) sendRequest(jReq *jsonRequest) {
 	// the client running in HTTP POST mode or not.  When running in HTTP
 	// POST mode, the command is issued via an HTTP client.  Otherwise,
 	// the command is issued via the asynchronous websocket channels.
-	if c.config.HTTPPostMode {
-		if c.batch {
-			if err := c.addRequest(jReq); err != nil {
-				log.Warn(err)
-			}
-		} else {
-			c.sendPostRequest(jReq)
+	if c.batch {
+		if err := c.addBatchRequest(jReq); err != nil {
+			jReq.responseChan <- &Response{err: err}
 		}
 		return
 	}
+	if c.config.HTTPPostMode {
+		c.sendPostRequest(jReq)
+		return
+	}
 
 	// Check whether the websocket connection has never been established,
 	// in which case the handler goroutines are not running.
@@ -962,11 +1247,69 @@ func (c *Client) sendRequest(jReq *jsonRequest) {
 	c.sendMessage(jReq.marshalledJSON)
 }
 
+// WithContext returns a client sharing the connection of c, whose requests are
+// made with the passed context.  When the context is cancelled or its deadline
+// is exceeded, the HTTP POST requests in progress are aborted, the websocket
+// requests stop waiting for their replies, and the futures of the requests
+// return the error of the context.  This makes a context-accepting variant of
+// every request method:
+//
+//	block, err := client.WithContext(ctx).GetBlock(hash)
+//
+// Shutting down either client shuts down the connection they share.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	if ctx == nil {
+		panic("nil context")
+	}
+	return &Client{clientCore: c.clientCore, ctx: ctx}
+}
+
+// Context returns the context the requests of the client are made with.  It is
+// the background context unless the client was returned by WithContext.
+func (c *Client) Context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// watchContext returns a response channel delivering the response of the
+// request, or the error of its context once done, in which case the request is
+// forgotten.
+func (c *Client) watchContext(jReq *jsonRequest) chan *Response {
+	if jReq.ctx.Done() == nil {
+		return jReq.responseChan
+	}
+
+	responseChan := make(chan *Response, 1)
+	go func() {
+		select {
+		case resp := <-jReq.responseChan:
+			responseChan <- resp
+
+		case <-jReq.ctx.Done():
+			// The reply may still come, and is buffered by the
+			// response channel of the request.
+			c.removeRequest(jReq.id)
+			log.Tracef("Cancelled command [%s] with id %d: %v",
+				jReq.method, jReq.id, jReq.ctx.Err())
+			responseChan <- &Response{err: jReq.ctx.Err()}
+		}
+	}()
+	return responseChan
+}
+
 // SendCmd sends the passed command to the associated server and returns a
 // response channel on which the reply will be delivered at some point in the
 // future.  It handles both websocket and HTTP POST mode depending on the
-// configuration of the client.
+// configuration of the client.  The request is made with the context of the
+// client.
 func (c *Client) SendCmd(cmd interface{}) chan *Response {
+	ctx := c.Context()
+	if err := ctx.Err(); err != nil {
+		return newFutureError(err)
+	}
+
 	rpcVersion := btcjson.RpcVersion1
 	if c.batch {
 		rpcVersion = btcjson.RpcVersion2
@@ -992,11 +1335,13 @@ func (c *Client) SendCmd(cmd interface{}) chan *Response {
 		cmd:            cmd,
 		marshalledJSON: marshalledJSON,
 		responseChan:   responseChan,
+		idempotent:     isIdempotent(method),
+		ctx:            ctx,
 	}
 
 	c.sendRequest(jReq)
 
-	return responseChan
+	return c.watchContext(jReq)
 }
 
 // sendCmdAndWait sends the passed command to the associated server, waits
@@ -1111,15 +1456,19 @@ func (c *Client) Shutdown() {
 		return
 	}
 
-	// Send the ErrClientShutdown error to any pending requests.
-	for e := c.requestList.Front(); e != nil; e = e.Next() {
-		req := e.Value.(*jsonRequest)
-		req.responseChan <- &Response{
-			result: nil,
-			err:    ErrClientShutdown,
+	// Send the ErrClientShutdown error to any pending requests, including
+	// those queued to a batch that wasn't sent.
+	for _, requests := range []*list.List{c.requestList, c.batchList} {
+		for e := requests.Front(); e != nil; e = e.Next() {
+			req := e.Value.(*jsonRequest)
+			req.responseChan <- &Response{
+				result: nil,
+				err:    ErrClientShutdown,
+			}
 		}
 	}
 	c.removeAllRequests()
+	c.batchList.Init()
 
 	// Disconnect the client if needed.
 	c.doDisconnect()
@@ -1134,6 +1483,10 @@ func (c *Client) start() {
 	if c.config.HTTPPostMode {
 		c.wg.Add(1)
 		go c.sendPostHandler()
+		if c.endpoints != nil {
+			c.wg.Add(1)
+			go c.healthCheckHandler()
+		}
 	} else {
 		c.wg.Add(3)
 		go func() {
@@ -1159,9 +1512,41 @@ func (c *Client) WaitForShutdown() {
 // This
 type ConnConfig struct {
 	// Host is the IP address and port of the RPC server you want to connect
-	// to.
+	// to.  It may instead be the path of a unix domain socket prefixed by
+	// UnixSocketPrefix, in which case TLS is never used.
 	Host string
 
+	// FailoverHosts are the IP addresses and ports of the RPC servers the
+	// client fails over to, in order, when the server of Host is
+	// unavailable.  The requests go back to the preferred servers once
+	// they are available again, which the client checks periodically in
+	// HTTP POST mode, and on reconnect otherwise.  Unix domain sockets
+	// can't be used along with them.
+	FailoverHosts []string
+
+	// LoadBalance spreads the read-only queries round robin across the
+	// available servers of Host and FailoverHosts in HTTP POST mode.
+	LoadBalance bool
+
+	// HealthCheckInterval is the interval between the checks of the
+	// servers of Host and FailoverHosts in HTTP POST mode.  It defaults to
+	// 30 seconds.
+	HealthCheckInterval time.Duration
+
+	// RetryPolicy is the retry policy of the HTTP POST requests failing
+	// with a transient error.  By default, the requests failing to get a
+	// response are attempted 10 times, and the wait between two attempts
+	// increases by half a second up to 1 minute.
+	RetryPolicy *RetryPolicy
+
+	// ReconnectPolicy is the retry policy of the websocket connection when
+	// it is reestablished, and of the connection attempts of Connect, whose
+	// tries take the place of MaxAttempts.  By default, the connection is
+	// retried until the client is shut down, and the wait between two
+	// attempts increases by 5 seconds up to 1 minute.  The client is shut
+	// down once the policy gives up reconnecting.
+	ReconnectPolicy *RetryPolicy
+
 	// Endpoint is the websocket endpoint on the RPC server.  This is
 	// typically "ws".
 	Endpoint string
@@ -1220,6 +1605,16 @@ type ConnConfig struct {
 	// try to reconnect to the server when it has been disconnected.
 	DisableAutoReconnect bool
 
+	// ReplayBlocks specifies that the block notifications missed while the
+	// websocket client is disconnected are replayed when it reconnects,
+	// by reregistering notifyblocks from the last block notified.  The
+	// notifications of the blocks connected since, and of those
+	// disconnected by a reorganization, are delivered before the live
+	// ones.
+	//
+	// NOTE: This is a lbcd extension.
+	ReplayBlocks bool
+
 	// DisableConnectOnNew specifies that a websocket client connection
 	// should not be tried when creating the client with New.  Instead, the
 	// client is created and returned unconnected, and Connect must be
@@ -1280,9 +1675,54 @@ func (config *ConnConfig) retrieveCookie() (username, passphrase string, err err
 	return config.cookieLastUser, config.cookieLastPass, config.cookieLastErr
 }
 
+// UnixSocketPrefix is the prefix of a ConnConfig Host which is the path of a
+// unix domain socket.
+const UnixSocketPrefix = "unix:"
+
+// unixSocketPath returns the path of the unix domain socket of the RPC server,
+// and whether Host specifies one.
+func (config *ConnConfig) unixSocketPath() (string, bool) {
+	if !strings.HasPrefix(config.Host, UnixSocketPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(config.Host, UnixSocketPrefix), true
+}
+
+// dialUnixSocket connects to the unix domain socket of the RPC server,
+// regardless of the passed network and address.
+func (config *ConnConfig) dialUnixSocket(_, _ string) (net.Conn, error) {
+	path, _ := config.unixSocketPath()
+	return net.Dial("unix", path)
+}
+
+// useTLS returns whether the connection to the RPC server uses TLS.
+func (config *ConnConfig) useTLS() bool {
+	_, unix := config.unixSocketPath()
+	return !config.DisableTLS && !unix
+}
+
+// httpHost returns the host of the URLs of the RPC server.  The requests over
+// a unix domain socket use localhost, as the socket path isn't a valid host.
+func (config *ConnConfig) httpHost() string {
+	if _, ok := config.unixSocketPath(); ok {
+		return "localhost"
+	}
+	return config.Host
+}
+
 // newHTTPClient returns a new http client that is configured according to the
 // proxy and TLS settings in the associated connection configuration.
 func newHTTPClient(config *ConnConfig) (*http.Client, error) {
+	// Connections to a unix domain socket bypass the proxy and TLS.
+	if _, ok := config.unixSocketPath(); ok {
+		client := http.Client{
+			Transport: &http.Transport{
+				Dial: config.dialUnixSocket,
+			},
+		}
+		return &client, nil
+	}
+
 	// Set proxy function if there is a proxy configured.
 	var proxyFunc func(*http.Request) (*url.URL, error)
 	if config.Proxy != "" {
@@ -1318,11 +1758,11 @@ func newHTTPClient(config *ConnConfig) (*http.Client, error) {
 
 // dial opens a websocket connection using the passed connection configuration
 // details.
-func dial(config *ConnConfig) (*websocket.Conn, error) {
+func dial(config *ConnConfig, host string) (*websocket.Conn, error) {
 	// Setup TLS if not disabled.
 	var tlsConfig *tls.Config
 	var scheme = "ws"
-	if !config.DisableTLS {
+	if config.useTLS() {
 		tlsConfig = &tls.Config{
 			MinVersion:         tls.VersionTLS12,
 			InsecureSkipVerify: config.SkipVerify,
@@ -1349,6 +1789,11 @@ func dial(config *ConnConfig) (*websocket.Conn, error) {
 		dialer.NetDial = proxy.Dial
 	}
 
+	// Connections to a unix domain socket bypass the proxy.
+	if _, ok := config.unixSocketPath(); ok {
+		dialer.NetDial = config.dialUnixSocket
+	}
+
 	// The RPC server requires basic authorization, so create a custom
 	// request header with the Authorization header set.
 	user, pass, err := config.getAuth()
@@ -1364,7 +1809,7 @@ func dial(config *ConnConfig) (*websocket.Conn, error) {
 	}
 
 	// Dial the connection.
-	url := fmt.Sprintf("%s://%s/%s", scheme, config.Host, config.Endpoint)
+	url := fmt.Sprintf("%s://%s/%s", scheme, host, config.Endpoint)
 	wsConn, resp, err := dialer.Dial(url, requestHeader)
 	if err != nil {
 		if err != websocket.ErrBadHandshake || resp == nil {
@@ -1397,6 +1842,14 @@ func dial(config *ConnConfig) (*websocket.Conn, error) {
 // interested in receiving notifications and will be ignored if the
 // configuration is set to run in HTTP POST mode.
 func New(config *ConnConfig, ntfnHandlers *NotificationHandlers) (*Client, error) {
+	if err := checkFailoverConfig(config); err != nil {
+		return nil, err
+	}
+	var endpoints *endpointPool
+	if len(config.FailoverHosts) > 0 {
+		endpoints = newEndpointPool(config)
+	}
+
 	// Either open a websocket connection or create an HTTP client depending
 	// on the HTTP POST mode.  Also, set the notification handlers to nil
 	// when running in HTTP POST mode.
@@ -1404,6 +1857,7 @@ func New(config *ConnConfig, ntfnHandlers *NotificationHandlers) (*Client, error
 	var httpClient *http.Client
 	connEstablished := make(chan struct{})
 	var start bool
+	host := config.Host
 	if config.HTTPPostMode {
 		ntfnHandlers = nil
 		start = true
@@ -1416,7 +1870,7 @@ func New(config *ConnConfig, ntfnHandlers *NotificationHandlers) (*Client, error
 	} else {
 		if !config.DisableConnectOnNew {
 			var err error
-			wsConn, err = dial(config)
+			wsConn, host, err = dialEndpoints(config, endpoints)
 			if err != nil {
 				return nil, err
 			}
@@ -1424,7 +1878,7 @@ func New(config *ConnConfig, ntfnHandlers *NotificationHandlers) (*Client, error
 		}
 	}
 
-	client := &Client{
+	client := &Client{clientCore: &clientCore{
 		config:          config,
 		wsConn:          wsConn,
 		httpClient:      httpClient,
@@ -1439,7 +1893,8 @@ func New(config *ConnConfig, ntfnHandlers *NotificationHandlers) (*Client, error
 		connEstablished: connEstablished,
 		disconnect:      make(chan struct{}),
 		shutdown:        make(chan struct{}),
-	}
+		endpoints:       endpoints,
+	}}
 
 	// Default network is mainnet, no parameters are necessary but if mainnet
 	// is specified it will be the param
@@ -1459,8 +1914,7 @@ func New(config *ConnConfig, ntfnHandlers *NotificationHandlers) (*Client, error
 	}
 
 	if start {
-		log.Infof("Established connection to RPC server %s",
-			config.Host)
+		log.Infof("Established connection to RPC server %s", host)
 		close(connEstablished)
 		client.start()
 		if !client.config.HTTPPostMode && !client.config.DisableAutoReconnect {
@@ -1472,21 +1926,21 @@ func New(config *ConnConfig, ntfnHandlers *NotificationHandlers) (*Client, error
 	return client, nil
 }
 
-// Batch is a factory that creates a client able to interact with the server using
-// JSON-RPC 2.0. The client is capable of accepting an arbitrary number of requests
-// and having the server process the all at the same time. It's compatible with both
-// btcd and bitcoind
+// NewBatch creates a client able to interact with the server using JSON-RPC
+// 2.0 batch requests.  The requests of the client are queued instead of being
+// sent, and only the Async functions must be used: their futures are delivered
+// the responses once Send sends the queued requests as a single batch request,
+// cutting the round trips to the server.  It's compatible with both lbcd and
+// bitcoind in HTTP POST mode, and with lbcd over websockets, where the batch
+// is multiplexed with the other traffic of the connection.
 func NewBatch(config *ConnConfig) (*Client, error) {
-	if !config.HTTPPostMode {
-		return nil, errors.New("http post mode is required to use batch client")
-	}
-	// notification parameter is nil since notifications are not supported in POST mode.
+	// notification parameter is nil since notifications are delivered to
+	// the non-batch clients.
 	client, err := New(config, nil)
 	if err != nil {
 		return nil, err
 	}
-	client.batch = true //copy the client with changed batch setting
-	client.start()
+	client.batch = true
 	return client, nil
 }
 
@@ -1513,26 +1967,27 @@ func (c *Client) Connect(tries int) error {
 	}
 
 	// Begin connection attempts.  Increase the backoff after each failed
-	// attempt, up to a maximum of one minute.
+	// attempt, up to a maximum of one minute by default.
+	policy := c.config.ReconnectPolicy.withDefaults(&defaultReconnectPolicy)
 	var err error
-	var backoff time.Duration
 	for i := 0; tries == 0 || i < tries; i++ {
 		var wsConn *websocket.Conn
-		wsConn, err = dial(c.config)
+		var host string
+		wsConn, host, err = dialEndpoints(c.config, c.endpoints)
 		if err != nil {
-			backoff = connectionRetryInterval * time.Duration(i+1)
-			if backoff > time.Minute {
-				backoff = time.Minute
+			if !policy.Retryable(err) {
+				return err
+			}
+			if i+1 != tries {
+				time.Sleep(policy.backoff(i + 1))
 			}
-			time.Sleep(backoff)
 			continue
 		}
 
 		// Connection was established.  Set the websocket connection
 		// member of the client and start the goroutines necessary
 		// to run the client.
-		log.Infof("Established connection to RPC server %s",
-			c.config.Host)
+		log.Infof("Established connection to RPC server %s", host)
 		c.wsConn = wsConn
 		close(c.connEstablished)
 		c.start()
@@ -1628,68 +2083,116 @@ func (c *Client) BackendVersion() (BackendVersion, error) {
 	return *c.backendVersion, nil
 }
 
-func (c *Client) sendAsync() FutureGetBulkResult {
-	// convert the array of marshalled json requests to a single request we can send
-	responseChan := make(chan *Response, 1)
+// marshalBatch returns the batch request of the passed requests.
+func marshalBatch(batch []*jsonRequest) []byte {
 	marshalledRequest := []byte("[")
-	for iter := c.batchList.Front(); iter != nil; iter = iter.Next() {
-		request := iter.Value.(*jsonRequest)
+	for i, request := range batch {
+		if i > 0 {
+			marshalledRequest = append(marshalledRequest, ',')
+		}
 		marshalledRequest = append(marshalledRequest, request.marshalledJSON...)
-		marshalledRequest = append(marshalledRequest, []byte(",")...)
-	}
-	if len(marshalledRequest) > 0 {
-		// removes the trailing comma to process the request individually
-		marshalledRequest = marshalledRequest[:len(marshalledRequest)-1]
 	}
-	marshalledRequest = append(marshalledRequest, []byte("]")...)
+	return append(marshalledRequest, ']')
+}
+
+// sendAsync sends the passed requests as a batch request in HTTP POST mode,
+// and returns a future delivering the responses.
+func (c *Client) sendAsync(batch []*jsonRequest) FutureGetBulkResult {
+	responseChan := make(chan *Response, 1)
 	request := jsonRequest{
 		id:             c.NextID(),
-		method:         "",
+		method:         "batch",
 		cmd:            nil,
-		marshalledJSON: marshalledRequest,
+		marshalledJSON: marshalBatch(batch),
 		responseChan:   responseChan,
+		idempotent:     true,
+		ctx:            c.Context(),
+	}
+	for _, jReq := range batch {
+		request.idempotent = request.idempotent && jReq.idempotent
 	}
 	c.sendPostRequest(&request)
 	return responseChan
 }
 
-// Marshall's bulk requests and sends to the server
-// creates a response channel to receive the response
+// sendWsBatch sends the passed requests as a batch request over the websocket
+// connection.  The requests are tracked like the other requests so their
+// responses are routed to their futures as they come, and they are resent on
+// reconnect.  The error sending the batch is delivered to the futures of the
+// requests.
+func (c *Client) sendWsBatch(batch []*jsonRequest) error {
+	select {
+	case <-c.connEstablished:
+	default:
+		for _, jReq := range batch {
+			jReq.responseChan <- &Response{err: ErrClientNotConnected}
+		}
+		return ErrClientNotConnected
+	}
+
+	for i, jReq := range batch {
+		err := c.addRequest(jReq)
+		if err == nil {
+			continue
+		}
+
+		// The client is shutting down.  The requests added already may
+		// have been answered by the shutdown.
+		for _, added := range batch[:i] {
+			if c.removeRequest(added.id) != nil {
+				added.responseChan <- &Response{err: err}
+			}
+		}
+		for _, jReq := range batch[i:] {
+			jReq.responseChan <- &Response{err: err}
+		}
+		return err
+	}
+	log.Tracef("Sending batch of %d commands", len(batch))
+	c.sendMessage(marshalBatch(batch))
+	return nil
+}
+
+// Send sends the requests queued by a client created with NewBatch as a single
+// batch request, and starts a new batch.  In HTTP POST mode, it waits for the
+// responses and delivers them to the futures of the requests before returning.
+// Over websockets, it returns once the batch is sent, and the futures are
+// delivered the responses as they come.  An error sending the batch is
+// returned, and delivered to the futures of all its requests.
 func (c *Client) Send() error {
-	// if batchlist is empty, there's nothing to send
-	if c.batchList.Len() == 0 {
+	batch := c.takeBatch()
+	if len(batch) == 0 {
 		return nil
 	}
 
-	// clear batchlist in case of an error
-	defer func() {
-		c.batchList = list.New()
-	}()
-
-	result, err := c.sendAsync().Receive()
+	if !c.config.HTTPPostMode {
+		return c.sendWsBatch(batch)
+	}
 
+	result, err := c.sendAsync(batch).Receive()
 	if err != nil {
+		for _, request := range batch {
+			request.responseChan <- &Response{err: err}
+		}
 		return err
 	}
 
-	for iter := c.batchList.Front(); iter != nil; iter = iter.Next() {
-		var requestError error
-		request := iter.Value.(*jsonRequest)
-		individualResult := result[request.id]
-		fullResult, err := json.Marshal(individualResult.Result)
-		if err != nil {
-			return err
+	for _, request := range batch {
+		individualResult, ok := result[request.id]
+		if !ok {
+			request.responseChan <- &Response{
+				err: fmt.Errorf("no response to command [%s] "+
+					"with id %d in batch", request.method,
+					request.id),
+			}
+			continue
 		}
 
 		if individualResult.Error != nil {
-			requestError = individualResult.Error
-		}
-
-		result := Response{
-			result: fullResult,
-			err:    requestError,
+			request.responseChan <- &Response{err: individualResult.Error}
+			continue
 		}
-		request.responseChan <- &result
+		request.responseChan <- &Response{result: individualResult.Result}
 	}
 	return nil
 }
@@ -0,0 +1,640 @@
+package rpcclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/websocket"
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/wire"
+)
+
+// TestUnixSocket ensures that HTTP POST requests are sent to the unix domain
+// socket of a Host prefixed by UnixSocketPrefix, without TLS.
+func TestUnixSocket(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "rpc.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("unable to listen on %s: %v", path, err)
+	}
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, pass, ok := r.BasicAuth()
+			if !ok || user != "user" || pass != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"result":42,"error":null,"id":1}`))
+		}),
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	client, err := New(&ConnConfig{
+		Host:         UnixSocketPrefix + path,
+		User:         "user",
+		Pass:         "pass",
+		HTTPPostMode: true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	defer client.Shutdown()
+
+	count, err := client.GetBlockCount()
+	if err != nil {
+		t.Fatalf("unable to get block count: %v", err)
+	}
+	if count != 42 {
+		t.Fatalf("unexpected block count: got %d, want 42", count)
+	}
+}
+
+// batchResponse returns the response of a fake server to the passed batch
+// request: the block count is 42, the hash of a block is an error, and the
+// other commands are not answered.  The responses are in the reverse order of
+// the requests.
+func batchResponse(t *testing.T, body []byte) []byte {
+	var requests []btcjson.Request
+	if err := json.Unmarshal(body, &requests); err != nil {
+		t.Errorf("invalid batch request %q: %v", body, err)
+		return nil
+	}
+	var responses []string
+	for _, request := range requests {
+		var response string
+		switch request.Method {
+		case "getblockcount":
+			response = fmt.Sprintf(`{"jsonrpc":"2.0","result":42,`+
+				`"error":null,"id":%v}`, request.ID)
+		case "getblockhash":
+			response = fmt.Sprintf(`{"jsonrpc":"2.0","result":null,`+
+				`"error":{"code":-8,"message":"out of range"},`+
+				`"id":%v}`, request.ID)
+		default:
+			continue
+		}
+		responses = append([]string{response}, responses...)
+	}
+	return []byte("[" + strings.Join(responses, ",") + "]")
+}
+
+// checkBatchFutures sends the batch of a client created with NewBatch, and
+// ensures the futures of its commands are delivered their responses.  The
+// future of the unanswered command is returned.
+func checkBatchFutures(t *testing.T, client *Client) FutureGetDifficultyResult {
+	blockCount := client.GetBlockCountAsync()
+	blockHash := client.GetBlockHashAsync(100)
+	difficulty := client.GetDifficultyAsync()
+	if err := client.Send(); err != nil {
+		t.Fatalf("unable to send batch: %v", err)
+	}
+
+	count, err := blockCount.Receive()
+	if err != nil {
+		t.Fatalf("unable to get block count: %v", err)
+	}
+	if count != 42 {
+		t.Fatalf("unexpected block count: got %d, want 42", count)
+	}
+	_, err = blockHash.Receive()
+	if rpcErr, ok := err.(*btcjson.RPCError); !ok || rpcErr.Code != -8 {
+		t.Fatalf("unexpected block hash error: %v", err)
+	}
+
+	// Nothing is sent for an empty batch.
+	if err := client.Send(); err != nil {
+		t.Fatalf("unable to send empty batch: %v", err)
+	}
+	return difficulty
+}
+
+// TestBatchHTTP ensures that the commands of a batch client in HTTP POST mode
+// are sent in a single request, and their futures delivered the responses.
+func TestBatchHTTP(t *testing.T) {
+	t.Parallel()
+
+	var posts, unavailable int32
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&posts, 1)
+			if atomic.LoadInt32(&unavailable) != 0 {
+				http.Error(w, "unavailable",
+					http.StatusServiceUnavailable)
+				return
+			}
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Errorf("unable to read request: %v", err)
+			}
+			w.Write(batchResponse(t, body))
+		}))
+	defer server.Close()
+
+	client, err := NewBatch(&ConnConfig{
+		Host:         strings.TrimPrefix(server.URL, "http://"),
+		User:         "user",
+		Pass:         "pass",
+		HTTPPostMode: true,
+		DisableTLS:   true,
+	})
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	defer client.Shutdown()
+
+	difficulty := checkBatchFutures(t, client)
+	if _, err = difficulty.Receive(); err == nil {
+		t.Fatal("expected an error for the unanswered command")
+	}
+	if n := atomic.LoadInt32(&posts); n != 1 {
+		t.Fatalf("unexpected number of requests: got %d, want 1", n)
+	}
+
+	// The futures of the batch are delivered the error sending it.
+	atomic.StoreInt32(&unavailable, 1)
+	future := client.GetBlockCountAsync()
+	if err := client.Send(); err == nil {
+		t.Fatal("expected an error from an unavailable server")
+	}
+	if _, err := future.Receive(); err == nil {
+		t.Fatal("expected an error for the unsent command")
+	}
+}
+
+// TestBatchWebsocket ensures that the commands of a batch client are sent in a
+// single message over websockets, and their futures delivered the responses.
+func TestBatchWebsocket(t *testing.T) {
+	t.Parallel()
+
+	upgrader := websocket.Upgrader{}
+	messages := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				t.Errorf("unable to upgrade connection: %v", err)
+				return
+			}
+			defer conn.Close()
+			for {
+				_, msg, err := conn.ReadMessage()
+				if err != nil {
+					return
+				}
+				messages <- msg
+				err = conn.WriteMessage(websocket.TextMessage,
+					batchResponse(t, msg))
+				if err != nil {
+					return
+				}
+			}
+		}))
+	defer server.Close()
+
+	client, err := NewBatch(&ConnConfig{
+		Host:                 strings.TrimPrefix(server.URL, "http://"),
+		Endpoint:             "ws",
+		User:                 "user",
+		Pass:                 "pass",
+		DisableTLS:           true,
+		DisableAutoReconnect: true,
+	})
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	defer client.Shutdown()
+
+	difficulty := checkBatchFutures(t, client)
+	if len(messages) != 1 {
+		t.Fatalf("unexpected number of messages: got %d, want 1",
+			len(messages))
+	}
+
+	// The futures of the unanswered commands and of a batch queued at
+	// shutdown are delivered an error.
+	future := client.GetBlockCountAsync()
+	client.Shutdown()
+	if _, err := difficulty.Receive(); err != ErrClientShutdown {
+		t.Fatalf("unexpected error: got %v, want %v", err,
+			ErrClientShutdown)
+	}
+	if _, err := future.Receive(); err != ErrClientShutdown {
+		t.Fatalf("unexpected error: got %v, want %v", err,
+			ErrClientShutdown)
+	}
+}
+
+// TestContextHTTP ensures that the HTTP POST requests made with a context are
+// aborted when it is done.
+func TestContextHTTP(t *testing.T) {
+	t.Parallel()
+
+	aborted := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			// The server notices the client going away once the
+			// body is read.
+			io.ReadAll(r.Body)
+			<-r.Context().Done()
+			close(aborted)
+		}))
+	defer server.Close()
+
+	client, err := New(&ConnConfig{
+		Host:         strings.TrimPrefix(server.URL, "http://"),
+		User:         "user",
+		Pass:         "pass",
+		HTTPPostMode: true,
+		DisableTLS:   true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	defer client.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(),
+		50*time.Millisecond)
+	defer cancel()
+	_, err = client.WithContext(ctx).GetBlockCount()
+	if err != context.DeadlineExceeded {
+		t.Fatalf("unexpected error: got %v, want %v", err,
+			context.DeadlineExceeded)
+	}
+	select {
+	case <-aborted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("HTTP request not aborted")
+	}
+
+	// Nothing is sent with a context done already.
+	_, err = client.WithContext(ctx).GetDifficulty()
+	if err != context.DeadlineExceeded {
+		t.Fatalf("unexpected error: got %v, want %v", err,
+			context.DeadlineExceeded)
+	}
+}
+
+// TestContextWebsocket ensures that the websocket requests made with a context
+// stop waiting for their replies when it is done, without affecting the other
+// requests.
+func TestContextWebsocket(t *testing.T) {
+	t.Parallel()
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				t.Errorf("unable to upgrade connection: %v", err)
+				return
+			}
+			defer conn.Close()
+			for {
+				_, msg, err := conn.ReadMessage()
+				if err != nil {
+					return
+				}
+				var request btcjson.Request
+				if err := json.Unmarshal(msg, &request); err != nil {
+					t.Errorf("invalid request %q: %v", msg, err)
+					return
+				}
+
+				// The block count is never replied to.
+				if request.Method == "getblockcount" {
+					continue
+				}
+				reply := fmt.Sprintf(`{"result":1.5,"error":null,`+
+					`"id":%v}`, request.ID)
+				err = conn.WriteMessage(websocket.TextMessage,
+					[]byte(reply))
+				if err != nil {
+					return
+				}
+			}
+		}))
+	defer server.Close()
+
+	client, err := New(&ConnConfig{
+		Host:                 strings.TrimPrefix(server.URL, "http://"),
+		Endpoint:             "ws",
+		User:                 "user",
+		Pass:                 "pass",
+		DisableTLS:           true,
+		DisableAutoReconnect: true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	defer client.Shutdown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	blockCount := client.WithContext(ctx).GetBlockCountAsync()
+	cancel()
+	if _, err := blockCount.Receive(); err != context.Canceled {
+		t.Fatalf("unexpected error: got %v, want %v", err,
+			context.Canceled)
+	}
+
+	difficulty, err := client.GetDifficulty()
+	if err != nil {
+		t.Fatalf("unable to get difficulty: %v", err)
+	}
+	if difficulty != 1.5 {
+		t.Fatalf("unexpected difficulty: got %v, want 1.5", difficulty)
+	}
+	client.requestLock.Lock()
+	pending := client.requestList.Len()
+	client.requestLock.Unlock()
+	if pending != 0 {
+		t.Fatalf("unexpected pending requests: got %d, want 0", pending)
+	}
+}
+
+// TestReplayBlocks ensures that the notifications are reregistered on
+// reconnect, with the block notifications missed replayed when ReplayBlocks is
+// set.
+func TestReplayBlocks(t *testing.T) {
+	t.Parallel()
+
+	hashes := []string{
+		"6e3fcf1299d4ec5d79c3a4c91d624a4acf9e2e173d95a1a0504f677669687556",
+		"ec5c37c43878b8146eedde0239ada3752d77b59420e90324ef7e75f49688c4e1",
+	}
+
+	// The server notifies a block and drops the first two connections
+	// after the registration for block notifications, and refuses to
+	// replay them on the third one.
+	var conns int32
+	requests := make(chan string, 20)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				t.Errorf("unable to upgrade connection: %v", err)
+				return
+			}
+			defer conn.Close()
+			n := atomic.AddInt32(&conns, 1)
+			for {
+				_, msg, err := conn.ReadMessage()
+				if err != nil {
+					return
+				}
+				var request btcjson.Request
+				if err := json.Unmarshal(msg, &request); err != nil {
+					t.Errorf("invalid request %q: %v", msg, err)
+					return
+				}
+				params, _ := json.Marshal(request.Params)
+				requests <- fmt.Sprintf("%d %s %s", n,
+					request.Method, params)
+
+				reply := fmt.Sprintf(`{"result":null,"error":null,`+
+					`"id":%v}`, request.ID)
+				replay := len(request.Params) > 0
+				if request.Method == "notifyblocks" && replay && n == 3 {
+					reply = fmt.Sprintf(`{"result":null,"error":`+
+						`{"code":-1,"message":"too many"},`+
+						`"id":%v}`, request.ID)
+				}
+				err = conn.WriteMessage(websocket.TextMessage,
+					[]byte(reply))
+				if err != nil {
+					return
+				}
+				if request.Method != "notifyblocks" || n > 2 {
+					continue
+				}
+				ntfn := fmt.Sprintf(`{"jsonrpc":"1.0","method":`+
+					`"blockconnected","params":["%s",%d,0],`+
+					`"id":null}`, hashes[n-1], n)
+				conn.WriteMessage(websocket.TextMessage, []byte(ntfn))
+				return
+			}
+		}))
+	defer server.Close()
+
+	blocks := make(chan string, 10)
+	replayFailures := make(chan string, 10)
+	client, err := New(&ConnConfig{
+		Host:         strings.TrimPrefix(server.URL, "http://"),
+		Endpoint:     "ws",
+		User:         "user",
+		Pass:         "pass",
+		DisableTLS:   true,
+		ReplayBlocks: true,
+	}, &NotificationHandlers{
+		OnBlockConnected: func(hash *chainhash.Hash, height int32,
+			t time.Time) {
+
+			blocks <- hash.String()
+		},
+		OnBlockReplayFailed: func(lastBlock string, err error) {
+			replayFailures <- lastBlock
+		},
+	})
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	defer client.Shutdown()
+
+	outPoint := wire.OutPoint{Index: 1}
+	if err := client.LoadTxFilter(false, nil, []wire.OutPoint{outPoint}); err != nil {
+		t.Fatalf("unable to load tx filter: %v", err)
+	}
+	if err := client.LoadClaimTxFilter(false, []string{"hello"}, nil); err != nil {
+		t.Fatalf("unable to load claim tx filter: %v", err)
+	}
+	if err := client.NotifyBlocks(); err != nil {
+		t.Fatalf("unable to register for block notifications: %v", err)
+	}
+
+	receive := func(c chan string) string {
+		select {
+		case s := <-c:
+			return s
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout")
+			return ""
+		}
+	}
+	for _, hash := range hashes {
+		if got := receive(blocks); got != hash {
+			t.Fatalf("unexpected block: got %s, want %s", got, hash)
+		}
+	}
+	if got := receive(replayFailures); got != hashes[1] {
+		t.Fatalf("unexpected failed replay: got %s, want %s", got,
+			hashes[1])
+	}
+
+	filter := `[{"hash":"` + outPoint.Hash.String() + `","index":1}]`
+	want := []string{
+		`1 loadtxfilter [false,[],` + filter + `]`,
+		`1 loadtxfilter [false,[],[],["hello"],[]]`,
+		`1 notifyblocks []`,
+		`2 loadtxfilter [true,[],` + filter + `,["hello"],[]]`,
+		`2 notifyblocks ["` + hashes[0] + `"]`,
+		`3 loadtxfilter [true,[],` + filter + `,["hello"],[]]`,
+		`3 notifyblocks ["` + hashes[1] + `"]`,
+		`3 notifyblocks []`,
+	}
+	for _, request := range want {
+		if got := receive(requests); got != request {
+			t.Fatalf("unexpected request: got %s, want %s", got,
+				request)
+		}
+	}
+}
+
+// newCountServer returns a test server replying to getblockcount with count,
+// and with 503 while its unavailable flag is set.
+func newCountServer(count int64, unavailable *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			var req btcjson.Request
+			json.NewDecoder(r.Body).Decode(&req)
+			if atomic.LoadInt32(unavailable) != 0 {
+				http.Error(w, "unavailable",
+					http.StatusServiceUnavailable)
+				return
+			}
+			fmt.Fprintf(w, `{"result":%d,"error":null,"id":%v}`,
+				count, req.ID)
+		}))
+}
+
+// TestFailoverHTTP ensures that the HTTP POST requests fail over to the next
+// server when one is unavailable, and go back to it once the health check
+// finds it available again.
+func TestFailoverHTTP(t *testing.T) {
+	t.Parallel()
+
+	var unavailable, closedFlag, backupFlag int32 = 1, 0, 0
+	preferred := newCountServer(1, &unavailable)
+	defer preferred.Close()
+	closed := newCountServer(2, &closedFlag)
+	closed.Close()
+	backup := newCountServer(3, &backupFlag)
+	defer backup.Close()
+
+	host := func(s *httptest.Server) string {
+		return strings.TrimPrefix(s.URL, "http://")
+	}
+	client, err := New(&ConnConfig{
+		Host:                host(preferred),
+		FailoverHosts:       []string{host(closed), host(backup)},
+		HealthCheckInterval: 20 * time.Millisecond,
+		User:                "user",
+		Pass:                "pass",
+		HTTPPostMode:        true,
+		DisableTLS:          true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	defer client.Shutdown()
+
+	count, err := client.GetBlockCount()
+	if err != nil {
+		t.Fatalf("unable to get block count: %v", err)
+	}
+	if count != 3 {
+		t.Fatalf("unexpected block count: got %d, want 3", count)
+	}
+	endpoints := client.Endpoints()
+	if len(endpoints) != 3 {
+		t.Fatalf("unexpected endpoints: got %d, want 3", len(endpoints))
+	}
+	for i, want := range []bool{false, false, true} {
+		if endpoints[i].Healthy != want {
+			t.Fatalf("unexpected health of endpoint %s: got %v, "+
+				"want %v", endpoints[i].Host, endpoints[i].Healthy,
+				want)
+		}
+		if !want && endpoints[i].LastError == nil {
+			t.Fatalf("no error for endpoint %s", endpoints[i].Host)
+		}
+	}
+
+	// The preferred server is used again once it is found available.
+	atomic.StoreInt32(&unavailable, 0)
+	deadline := time.Now().Add(5 * time.Second)
+	for !client.Endpoints()[0].Healthy {
+		if time.Now().After(deadline) {
+			t.Fatal("preferred endpoint not healthy again")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	count, err = client.GetBlockCount()
+	if err != nil {
+		t.Fatalf("unable to get block count: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("unexpected block count: got %d, want 1", count)
+	}
+}
+
+// TestLoadBalanceHTTP ensures that the read-only queries are spread round
+// robin across the servers with the LoadBalance option.
+func TestLoadBalanceHTTP(t *testing.T) {
+	t.Parallel()
+
+	var available int32
+	first := newCountServer(1, &available)
+	defer first.Close()
+	second := newCountServer(2, &available)
+	defer second.Close()
+
+	client, err := New(&ConnConfig{
+		Host:          strings.TrimPrefix(first.URL, "http://"),
+		FailoverHosts: []string{strings.TrimPrefix(second.URL, "http://")},
+		LoadBalance:   true,
+		User:          "user",
+		Pass:          "pass",
+		HTTPPostMode:  true,
+		DisableTLS:    true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	defer client.Shutdown()
+
+	counts := make(map[int64]int)
+	for i := 0; i < 4; i++ {
+		count, err := client.GetBlockCount()
+		if err != nil {
+			t.Fatalf("unable to get block count: %v", err)
+		}
+		counts[count]++
+	}
+	if counts[1] != 2 || counts[2] != 2 {
+		t.Fatalf("requests not balanced: %v", counts)
+	}
+
+	// Unix domain sockets can't be failed over.
+	_, err = New(&ConnConfig{
+		Host:          UnixSocketPrefix + "/tmp/lbcd.sock",
+		FailoverHosts: []string{"127.0.0.1:9245"},
+		HTTPPostMode:  true,
+	}, nil)
+	if err == nil {
+		t.Fatal("unix domain socket accepted with failover hosts")
+	}
+}
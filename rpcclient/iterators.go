@@ -0,0 +1,356 @@
+package rpcclient
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+// defaultPageSize is the number of results fetched per page by the iterators
+// when their page size isn't set.
+const defaultPageSize = 100
+
+// The iterators below fetch the results of the RPCs returning them in pages,
+// or one block at a time, page by page as they are consumed, so that the
+// callers range over them without handling the cursors:
+//
+//	it := client.AddressTransactions(addr, nil)
+//	for it.Next() {
+//		tx := it.Tx()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+//
+// The requests of an iterator are made with the context of its client, so
+// that an iterator of a client returned by WithContext stops with the error of
+// the context once it is done.  An iterator is not safe for concurrent use.
+
+// AddressTxOptions are the options of AddressTransactions.  The zero values of
+// its fields select their defaults.
+type AddressTxOptions struct {
+	// PageSize is the number of transactions fetched per request.  It
+	// defaults to 100.
+	PageSize int
+
+	// Skip is the number of transactions skipped before the first one.
+	Skip int
+
+	// IncludePrevOut includes the previous outputs spent by the inputs of
+	// the transactions.
+	IncludePrevOut bool
+
+	// Reverse returns the most recent transactions first.
+	Reverse bool
+
+	// FilterAddrs restricts the previous outputs included to those paying
+	// these addresses.
+	FilterAddrs []string
+}
+
+// AddressTxIterator iterates over the transactions involving an address, which
+// it fetches with searchrawtransactions.
+type AddressTxIterator struct {
+	client  *Client
+	address btcutil.Address
+	opts    AddressTxOptions
+
+	page []*btcjson.SearchRawTransactionsResult
+	tx   *btcjson.SearchRawTransactionsResult
+	done bool
+	err  error
+}
+
+// AddressTransactions returns an iterator over the transactions involving the
+// passed address, in the order of the blocks they are mined in, followed by
+// those of the mempool, or in the reverse order.  A nil opts selects the
+// default options.
+//
+// NOTE: Chain servers do not typically provide this capability unless they
+// have specifically enabled their address index.
+func (c *Client) AddressTransactions(address btcutil.Address,
+	opts *AddressTxOptions) *AddressTxIterator {
+
+	it := &AddressTxIterator{client: c, address: address}
+	if opts != nil {
+		it.opts = *opts
+	}
+	if it.opts.PageSize <= 0 {
+		it.opts.PageSize = defaultPageSize
+	}
+	return it
+}
+
+// Next advances the iterator to the next transaction, fetching the next page
+// of them when needed.  It returns false once there are no more transactions,
+// or a request failed, which Err reports.
+func (it *AddressTxIterator) Next() bool {
+	if len(it.page) == 0 && !it.done && it.err == nil {
+		it.fetch()
+	}
+	if len(it.page) == 0 {
+		it.tx = nil
+		return false
+	}
+	it.tx, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// fetch fetches the next page of transactions.
+func (it *AddressTxIterator) fetch() {
+	opts := &it.opts
+	page, err := it.client.SearchRawTransactionsVerbose(it.address,
+		opts.Skip, opts.PageSize, opts.IncludePrevOut, opts.Reverse,
+		opts.FilterAddrs)
+
+	// The server replies with an error rather than an empty page past the
+	// last transaction.
+	var rpcErr *btcjson.RPCError
+	if errors.As(err, &rpcErr) && rpcErr.Code == btcjson.ErrRPCNoTxInfo {
+		page, err = nil, nil
+	}
+	if err != nil {
+		it.err = err
+		return
+	}
+
+	opts.Skip += len(page)
+	it.page = page
+	it.done = len(page) < opts.PageSize
+}
+
+// Tx returns the current transaction, which is valid until the next call to
+// Next.
+func (it *AddressTxIterator) Tx() *btcjson.SearchRawTransactionsResult {
+	return it.tx
+}
+
+// Err returns the error which stopped the iterator, if any.
+func (it *AddressTxIterator) Err() error {
+	return it.err
+}
+
+// heightRange is a range of block heights consumed one page at a time.
+type heightRange struct {
+	client   *Client
+	next     int64
+	end      int64
+	pageSize int
+	started  bool
+}
+
+// newHeightRange returns the range of the passed heights, inclusive.  A
+// negative end height is the height of the tip when the first page is
+// fetched.
+func newHeightRange(c *Client, start, end int64, pageSize int) heightRange {
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	return heightRange{client: c, next: start, end: end, pageSize: pageSize}
+}
+
+// nextPage returns the number of heights of the next page, which starts at
+// the next height, and consumes them.
+func (r *heightRange) nextPage() (int64, int, error) {
+	if !r.started {
+		r.started = true
+		if r.end < 0 {
+			count, err := r.client.GetBlockCount()
+			if err != nil {
+				return 0, 0, err
+			}
+			r.end = count
+		}
+	}
+
+	from := r.next
+	n := int64(r.pageSize)
+	if remaining := r.end - from + 1; remaining < n {
+		n = remaining
+	}
+	if n <= 0 {
+		return from, 0, nil
+	}
+	r.next += n
+	return from, int(n), nil
+}
+
+// BlockIterator iterates over the blocks of a range of heights of the main
+// chain, which it fetches with getblockhash and getblock.
+type BlockIterator struct {
+	heights heightRange
+
+	page   []*wire.MsgBlock
+	hashes []*chainhash.Hash
+	height int64
+	block  *wire.MsgBlock
+	hash   *chainhash.Hash
+	err    error
+}
+
+// Blocks returns an iterator over the blocks of the main chain from the
+// passed start height to the passed end height, inclusive, or to the tip when
+// the end height is negative.  The requests of a page of pageSize blocks, or
+// 100 when it isn't positive, are sent at once.
+func (c *Client) Blocks(startHeight, endHeight int64, pageSize int) *BlockIterator {
+	return &BlockIterator{
+		heights: newHeightRange(c, startHeight, endHeight, pageSize),
+		height:  startHeight - 1,
+	}
+}
+
+// Next advances the iterator to the next block, fetching the next page of
+// them when needed.  It returns false once there are no more blocks, or a
+// request failed, which Err reports.
+func (it *BlockIterator) Next() bool {
+	if len(it.page) == 0 && it.err == nil {
+		it.fetch()
+	}
+	if len(it.page) == 0 {
+		it.block, it.hash = nil, nil
+		return false
+	}
+	it.block, it.page = it.page[0], it.page[1:]
+	it.hash, it.hashes = it.hashes[0], it.hashes[1:]
+	it.height++
+	return true
+}
+
+// fetch fetches the next page of blocks.
+func (it *BlockIterator) fetch() {
+	c := it.heights.client
+	from, n, err := it.heights.nextPage()
+	if err != nil || n == 0 {
+		it.err = err
+		return
+	}
+
+	hashFutures := make([]FutureGetBlockHashResult, n)
+	for i := range hashFutures {
+		hashFutures[i] = c.GetBlockHashAsync(from + int64(i))
+	}
+	hashes := make([]*chainhash.Hash, n)
+	for i, future := range hashFutures {
+		hashes[i], err = future.Receive()
+		if err != nil {
+			it.err = err
+			return
+		}
+	}
+
+	blockFutures := make([]FutureGetBlockResult, n)
+	for i, hash := range hashes {
+		blockFutures[i] = c.GetBlockAsync(hash)
+	}
+	blocks := make([]*wire.MsgBlock, n)
+	for i, future := range blockFutures {
+		blocks[i], err = future.Receive()
+		if err != nil {
+			it.err = err
+			return
+		}
+	}
+
+	it.page, it.hashes = blocks, hashes
+}
+
+// Block returns the current block, which is valid until the next call to
+// Next.
+func (it *BlockIterator) Block() *wire.MsgBlock {
+	return it.block
+}
+
+// Hash returns the hash of the current block.
+func (it *BlockIterator) Hash() *chainhash.Hash {
+	return it.hash
+}
+
+// Height returns the height of the current block.
+func (it *BlockIterator) Height() int64 {
+	return it.height
+}
+
+// Err returns the error which stopped the iterator, if any.
+func (it *BlockIterator) Err() error {
+	return it.err
+}
+
+// ClaimChangesIterator iterates over the names whose claims were changed by
+// the blocks of a range of heights of the main chain, which it fetches with
+// getchangesinblock.
+type ClaimChangesIterator struct {
+	heights heightRange
+
+	page    []*btcjson.GetChangesInBlockResult
+	changes *btcjson.GetChangesInBlockResult
+	err     error
+}
+
+// ClaimChanges returns an iterator over the names whose claims were changed by
+// the blocks of the main chain from the passed start height to the passed end
+// height, inclusive, or to the tip when the end height is negative.  The
+// requests of a page of pageSize blocks, or 100 when it isn't positive, are
+// sent at once.
+func (c *Client) ClaimChanges(startHeight, endHeight int64,
+	pageSize int) *ClaimChangesIterator {
+
+	return &ClaimChangesIterator{
+		heights: newHeightRange(c, startHeight, endHeight, pageSize),
+	}
+}
+
+// Next advances the iterator to the changes of the next block, fetching the
+// next page of them when needed.  It returns false once there are no more
+// blocks, or a request failed, which Err reports.
+func (it *ClaimChangesIterator) Next() bool {
+	if len(it.page) == 0 && it.err == nil {
+		it.fetch()
+	}
+	if len(it.page) == 0 {
+		it.changes = nil
+		return false
+	}
+	it.changes, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// fetch fetches the changes of the next page of blocks.
+func (it *ClaimChangesIterator) fetch() {
+	c := it.heights.client
+	from, n, err := it.heights.nextPage()
+	if err != nil || n == 0 {
+		it.err = err
+		return
+	}
+
+	futures := make([]FutureGetChangesInBlockResult, n)
+	for i := range futures {
+		height := strconv.FormatInt(from+int64(i), 10)
+		futures[i] = c.GetChangesInBlockAsync(&height)
+	}
+	page := make([]*btcjson.GetChangesInBlockResult, n)
+	for i, future := range futures {
+		page[i], err = future.Receive()
+		if err != nil {
+			it.err = err
+			return
+		}
+	}
+	it.page = page
+}
+
+// Changes returns the names changed by the current block, along with its hash
+// and height, which are valid until the next call to Next.
+func (it *ClaimChangesIterator) Changes() *btcjson.GetChangesInBlockResult {
+	return it.changes
+}
+
+// Err returns the error which stopped the iterator, if any.
+func (it *ClaimChangesIterator) Err() error {
+	return it.err
+}
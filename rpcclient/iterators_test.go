@@ -0,0 +1,273 @@
+package rpcclient
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+// testChainTip and testAddressTxs are the height of the tip and the number of
+// transactions of the address of the chain of newChainServer.
+const (
+	testChainTip   = 11
+	testAddressTxs = 7
+)
+
+// testBlock returns the block of the chain of newChainServer at the passed
+// height, which is told from the others by its nonce.
+func testBlock(height int64) *wire.MsgBlock {
+	return &wire.MsgBlock{Header: wire.BlockHeader{Nonce: uint32(height)}}
+}
+
+// newChainServer returns a test server replying to the requests of the
+// iterators, and counting them in requests.
+func newChainServer(t *testing.T, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(requests, 1)
+			var req btcjson.Request
+			json.NewDecoder(r.Body).Decode(&req)
+			param := func(i int, v interface{}) {
+				if err := json.Unmarshal(req.Params[i], v); err != nil {
+					t.Errorf("%s: invalid param %d: %v", req.Method,
+						i, err)
+				}
+			}
+
+			rpcError := func(code btcjson.RPCErrorCode, message string) {
+				fmt.Fprintf(w, `{"result":null,"error":{"code":%d,`+
+					`"message":%q},"id":%v}`, code, message, req.ID)
+			}
+
+			var result interface{}
+			switch req.Method {
+			case "getblockcount":
+				result = testChainTip
+
+			case "getblockhash":
+				var height int64
+				param(0, &height)
+				if height > testChainTip {
+					rpcError(btcjson.ErrRPCOutOfRange,
+						"Block number out of range")
+					return
+				}
+				result = testBlock(height).BlockHash().String()
+
+			case "getblock":
+				var hash string
+				param(0, &hash)
+				for height := int64(0); height <= testChainTip; height++ {
+					block := testBlock(height)
+					if block.BlockHash().String() != hash {
+						continue
+					}
+					var buf bytes.Buffer
+					block.Serialize(&buf)
+					result = hex.EncodeToString(buf.Bytes())
+				}
+
+			case "getchangesinblock":
+				var height string
+				param(0, &height)
+				h, _ := strconv.Atoi(height)
+				result = btcjson.GetChangesInBlockResult{
+					Height: int32(h),
+					Names:  []string{"name" + height},
+				}
+
+			case "searchrawtransactions":
+				var skip, count int
+				param(2, &skip)
+				param(3, &count)
+				var txs []btcjson.SearchRawTransactionsResult
+				for i := skip; i < skip+count && i < testAddressTxs; i++ {
+					txs = append(txs, btcjson.SearchRawTransactionsResult{
+						Txid: strconv.Itoa(i),
+					})
+				}
+				if len(txs) == 0 {
+					rpcError(btcjson.ErrRPCNoTxInfo, "No information "+
+						"available about address")
+					return
+				}
+				result = txs
+			}
+
+			marshalled, _ := json.Marshal(result)
+			fmt.Fprintf(w, `{"result":%s,"error":null,"id":%v}`,
+				marshalled, req.ID)
+		}))
+}
+
+// newIteratorClient returns a client of the passed test server.
+func newIteratorClient(t *testing.T, server *httptest.Server) *Client {
+	client, err := New(&ConnConfig{
+		Host:         strings.TrimPrefix(server.URL, "http://"),
+		User:         "user",
+		Pass:         "pass",
+		HTTPPostMode: true,
+		DisableTLS:   true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	return client
+}
+
+// TestAddressTransactions ensures that the address transaction iterator fetches
+// all the transactions page by page.
+func TestAddressTransactions(t *testing.T) {
+	t.Parallel()
+
+	var requests int32
+	server := newChainServer(t, &requests)
+	defer server.Close()
+	client := newIteratorClient(t, server)
+	defer client.Shutdown()
+
+	addr, err := btcutil.DecodeAddress("moeTzoswjcisuguP9hpEfQwabcGmSoW862",
+		&chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("unable to decode address: %v", err)
+	}
+
+	tests := []struct {
+		opts     *AddressTxOptions
+		first    int
+		requests int32
+	}{
+		{nil, 0, 1},
+		{&AddressTxOptions{PageSize: 3}, 0, 3},
+		{&AddressTxOptions{PageSize: 7}, 0, 2},
+		{&AddressTxOptions{PageSize: 2, Skip: 4}, 4, 2},
+	}
+	for i, test := range tests {
+		atomic.StoreInt32(&requests, 0)
+		want := test.first
+		it := client.AddressTransactions(addr, test.opts)
+		for it.Next() {
+			if it.Tx().Txid != strconv.Itoa(want) {
+				t.Fatalf("#%d: unexpected tx: got %s, want %d", i,
+					it.Tx().Txid, want)
+			}
+			want++
+		}
+		if err := it.Err(); err != nil {
+			t.Fatalf("#%d: unexpected error: %v", i, err)
+		}
+		if want != testAddressTxs {
+			t.Fatalf("#%d: unexpected txs: got %d, want %d", i,
+				want-test.first, testAddressTxs-test.first)
+		}
+		if n := atomic.LoadInt32(&requests); n != test.requests {
+			t.Fatalf("#%d: unexpected requests: got %d, want %d", i,
+				n, test.requests)
+		}
+	}
+}
+
+// TestBlocks ensures that the block iterator fetches the blocks of the range
+// of heights in order.
+func TestBlocks(t *testing.T) {
+	t.Parallel()
+
+	var requests int32
+	server := newChainServer(t, &requests)
+	defer server.Close()
+	client := newIteratorClient(t, server)
+	defer client.Shutdown()
+
+	tests := []struct {
+		start, end int64
+		pageSize   int
+		want       int64
+	}{
+		{0, 4, 0, 4},
+		{3, 9, 2, 9},
+		{5, -1, 4, testChainTip},
+		{5, 4, 4, 4},
+	}
+	for i, test := range tests {
+		height := test.start
+		it := client.Blocks(test.start, test.end, test.pageSize)
+		for it.Next() {
+			block := testBlock(height)
+			if it.Height() != height ||
+				it.Block().Header.Nonce != block.Header.Nonce {
+
+				t.Fatalf("#%d: unexpected block at height %d: "+
+					"got nonce %d", i, it.Height(),
+					it.Block().Header.Nonce)
+			}
+			if hash := block.BlockHash(); !it.Hash().IsEqual(&hash) {
+				t.Fatalf("#%d: unexpected hash at height %d: %v", i,
+					height, it.Hash())
+			}
+			height++
+		}
+		if err := it.Err(); err != nil {
+			t.Fatalf("#%d: unexpected error: %v", i, err)
+		}
+		if height-1 != test.want {
+			t.Fatalf("#%d: unexpected last height: got %d, want %d",
+				i, height-1, test.want)
+		}
+	}
+
+	// The iterator stops with the error of a request.
+	it := client.Blocks(testChainTip, testChainTip+1, 0)
+	if it.Next() {
+		t.Fatalf("unexpected block past the tip: %v", it.Hash())
+	}
+	var rpcErr *btcjson.RPCError
+	if !errors.As(it.Err(), &rpcErr) || rpcErr.Code != btcjson.ErrRPCOutOfRange {
+		t.Fatalf("unexpected error past the tip: %v", it.Err())
+	}
+}
+
+// TestClaimChanges ensures that the claim changes iterator fetches the changes
+// of each block of the range of heights.
+func TestClaimChanges(t *testing.T) {
+	t.Parallel()
+
+	var requests int32
+	server := newChainServer(t, &requests)
+	defer server.Close()
+	client := newIteratorClient(t, server)
+	defer client.Shutdown()
+
+	height := int32(2)
+	it := client.ClaimChanges(int64(height), -1, 3)
+	for it.Next() {
+		changes := it.Changes()
+		want := fmt.Sprintf("name%d", height)
+		if changes.Height != height || len(changes.Names) != 1 ||
+			changes.Names[0] != want {
+
+			t.Fatalf("unexpected changes: got %+v, want %s at %d",
+				changes, want, height)
+		}
+		height++
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if height != testChainTip+1 {
+		t.Fatalf("unexpected last height: got %d, want %d", height-1,
+			testChainTip)
+	}
+}
@@ -331,6 +331,28 @@ func (c *Client) GetNetworkHashPS3(blocks, height int) (int64, error) {
 	return c.GetNetworkHashPS3Async(blocks, height).Receive()
 }
 
+// GetNetworkHashPSByHashAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See GetNetworkHashPSByHash for the blocking version and more details.
+func (c *Client) GetNetworkHashPSByHashAsync(blocks int, blockHash *chainhash.Hash) FutureGetNetworkHashPS {
+	cmd := btcjson.NewGetNetworkHashPSCmd(&blocks, nil)
+	hash := blockHash.String()
+	cmd.Hash = &hash
+	return c.SendCmd(cmd)
+}
+
+// GetNetworkHashPSByHash returns the estimated network hashes per second for
+// the specified previous number of blocks working backwards from the block
+// with the given hash.  The blocks parameter can also be -1 in which case the
+// number of blocks since the last difficulty change will be used.
+//
+// See GetNetworkHashPS3 to end the estimate with a block height instead.
+func (c *Client) GetNetworkHashPSByHash(blocks int, blockHash *chainhash.Hash) (int64, error) {
+	return c.GetNetworkHashPSByHashAsync(blocks, blockHash).Receive()
+}
+
 // FutureGetWork is a future promise to deliver the result of a
 // GetWorkAsync RPC invocation (or an applicable error).
 type FutureGetWork chan *Response
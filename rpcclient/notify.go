@@ -11,6 +11,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/lbryio/lbcd/btcjson"
@@ -35,8 +36,25 @@ type notificationState struct {
 	notifyBlocks       bool
 	notifyNewTx        bool
 	notifyNewTxVerbose bool
+	notifyWork         bool
+	notifyWorkDeltas   bool
 	notifyReceived     map[string]struct{}
+	notifyClaims       bool
+	notifyClaimNames   []string
 	notifySpent        map[btcjson.OutPoint]struct{}
+
+	// txFilterAddresses, txFilterOutPoints, txFilterClaimNames and
+	// txFilterClaimIDs are the contents of the transaction filter loaded by
+	// loadtxfilter.
+	txFilterAddresses  map[string]struct{}
+	txFilterOutPoints  map[btcjson.OutPoint]struct{}
+	txFilterClaimNames map[string]struct{}
+	txFilterClaimIDs   map[string]struct{}
+
+	// lastBlock is the hash, or the height, of the last block of the main
+	// chain notified, from which the block notifications are replayed on
+	// reconnect when the ReplayBlocks option is set.
+	lastBlock string
 }
 
 // Copy returns a deep copy of the receiver.
@@ -45,6 +63,10 @@ func (s *notificationState) Copy() *notificationState {
 	stateCopy.notifyBlocks = s.notifyBlocks
 	stateCopy.notifyNewTx = s.notifyNewTx
 	stateCopy.notifyNewTxVerbose = s.notifyNewTxVerbose
+	stateCopy.notifyWork = s.notifyWork
+	stateCopy.notifyWorkDeltas = s.notifyWorkDeltas
+	stateCopy.notifyClaims = s.notifyClaims
+	stateCopy.notifyClaimNames = append([]string(nil), s.notifyClaimNames...)
 	stateCopy.notifyReceived = make(map[string]struct{})
 	for addr := range s.notifyReceived {
 		stateCopy.notifyReceived[addr] = struct{}{}
@@ -53,6 +75,23 @@ func (s *notificationState) Copy() *notificationState {
 	for op := range s.notifySpent {
 		stateCopy.notifySpent[op] = struct{}{}
 	}
+	stateCopy.txFilterAddresses = make(map[string]struct{})
+	for addr := range s.txFilterAddresses {
+		stateCopy.txFilterAddresses[addr] = struct{}{}
+	}
+	stateCopy.txFilterOutPoints = make(map[btcjson.OutPoint]struct{})
+	for op := range s.txFilterOutPoints {
+		stateCopy.txFilterOutPoints[op] = struct{}{}
+	}
+	stateCopy.txFilterClaimNames = make(map[string]struct{})
+	for name := range s.txFilterClaimNames {
+		stateCopy.txFilterClaimNames[name] = struct{}{}
+	}
+	stateCopy.txFilterClaimIDs = make(map[string]struct{})
+	for id := range s.txFilterClaimIDs {
+		stateCopy.txFilterClaimIDs[id] = struct{}{}
+	}
+	stateCopy.lastBlock = s.lastBlock
 
 	return &stateCopy
 }
@@ -60,8 +99,12 @@ func (s *notificationState) Copy() *notificationState {
 // newNotificationState returns a new notification state ready to be populated.
 func newNotificationState() *notificationState {
 	return &notificationState{
-		notifyReceived: make(map[string]struct{}),
-		notifySpent:    make(map[btcjson.OutPoint]struct{}),
+		notifyReceived:     make(map[string]struct{}),
+		notifySpent:        make(map[btcjson.OutPoint]struct{}),
+		txFilterAddresses:  make(map[string]struct{}),
+		txFilterOutPoints:  make(map[btcjson.OutPoint]struct{}),
+		txFilterClaimNames: make(map[string]struct{}),
+		txFilterClaimIDs:   make(map[string]struct{}),
 	}
 }
 
@@ -90,6 +133,16 @@ type NotificationHandlers struct {
 	// notification handlers, and is safe for blocking client requests.
 	OnClientConnected func()
 
+	// OnBlockReplayFailed is invoked when the block notifications missed
+	// while disconnected can't be replayed on reconnect with the
+	// ReplayBlocks option, typically because too many blocks were missed.
+	// The notifications resume from the tip of the main chain, and the
+	// missed blocks since lastBlock, the hash or height of the last block
+	// notified, must be fetched by the caller.  This callback is run async
+	// with the rest of the notification handlers, and is safe for blocking
+	// client requests.
+	OnBlockReplayFailed func(lastBlock string, err error)
+
 	// OnBlockConnected is invoked when a block is connected to the longest
 	// (best) chain.  It will only be invoked if a preceding call to
 	// NotifyBlocks has been made to register for the notification and the
@@ -121,6 +174,13 @@ type NotificationHandlers struct {
 	// OnBlockDisconnected: it receives the block's height and header.
 	OnFilteredBlockDisconnected func(height int32, header *wire.BlockHeader)
 
+	// OnChainReorganized is invoked when the longest (best) chain is
+	// reorganized, after the notifications of the blocks disconnected and
+	// connected.  It will only be invoked if a preceding call to
+	// NotifyBlocks has been made to register for the notification and the
+	// function is non-nil.
+	OnChainReorganized func(reorg *btcjson.ChainReorganization)
+
 	// OnRecvTx is invoked when a transaction that receives funds to a
 	// registered address is received into the memory pool and also
 	// connected to the longest (best) chain.  It will only be invoked if a
@@ -179,6 +239,36 @@ type NotificationHandlers struct {
 	// made to register for the notification and the function is non-nil.
 	OnTxAcceptedVerbose func(txDetails *btcjson.TxRawResult)
 
+	// OnWork is invoked when a new block template is available.  It will
+	// only be invoked if a preceding call to NotifyWork has been made to
+	// register for the notification and the function is non-nil.
+	OnWork func(template *btcjson.GetBlockTemplateResult)
+
+	// OnWorkDelta is invoked when a new block template is available, with
+	// its changes since the previous template of the same previous block.
+	// It will only be invoked if a preceding call to NotifyWork with the
+	// deltas flag set to true has been made to register for the
+	// notification and the function is non-nil.
+	OnWorkDelta func(delta *btcjson.WorkDelta)
+
+	// OnClaimAdded is invoked when a claim is created by a block connected
+	// to the main chain.  It will only be invoked if a preceding call to
+	// NotifyClaims has been made to register for the notification and the
+	// function is non-nil.
+	OnClaimAdded func(claim *btcjson.ClaimNtfnData)
+
+	// OnClaimUpdated is invoked when a claim is updated by a block
+	// connected to the main chain.  It will only be invoked if a preceding
+	// call to NotifyClaims has been made to register for the notification
+	// and the function is non-nil.
+	OnClaimUpdated func(claim *btcjson.ClaimNtfnData)
+
+	// OnNameTakeover is invoked when another claim takes over a name in a
+	// block connected to the main chain.  It will only be invoked if a
+	// preceding call to NotifyClaims has been made to register for the
+	// notification and the function is non-nil.
+	OnNameTakeover func(takeover *btcjson.NameTakeover)
+
 	// OnBtcdConnected is invoked when a wallet connects or disconnects from
 	// btcd.
 	//
@@ -216,6 +306,7 @@ func (c *Client) handleNotification(ntfn *rawNotification) {
 	if c.ntfnHandlers == nil {
 		return
 	}
+	c.trackLastBlock(ntfn)
 
 	switch ntfn.Method {
 	// OnBlockConnected
@@ -290,6 +381,24 @@ func (c *Client) handleNotification(ntfn *rawNotification) {
 		c.ntfnHandlers.OnFilteredBlockDisconnected(blockHeight,
 			blockHeader)
 
+	// OnChainReorganized
+	case btcjson.ChainReorganizedNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnChainReorganized == nil {
+			return
+		}
+
+		var reorg btcjson.ChainReorganization
+		err := parseObjectNtfnParams(ntfn.Params, &reorg)
+		if err != nil {
+			log.Warnf("Received invalid chain reorganized "+
+				"notification: %v", err)
+			return
+		}
+
+		c.ntfnHandlers.OnChainReorganized(&reorg)
+
 	// OnRecvTx
 	case btcjson.RecvTxNtfnMethod:
 		// Ignore the notification if the client is not interested in
@@ -409,6 +518,95 @@ func (c *Client) handleNotification(ntfn *rawNotification) {
 
 		c.ntfnHandlers.OnTxAcceptedVerbose(rawTx)
 
+	// OnWork
+	case btcjson.WorkNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnWork == nil {
+			return
+		}
+
+		var template btcjson.GetBlockTemplateResult
+		err := parseObjectNtfnParams(ntfn.Params, &template)
+		if err != nil {
+			log.Warnf("Received invalid work notification: %v", err)
+			return
+		}
+
+		c.ntfnHandlers.OnWork(&template)
+
+	// OnWorkDelta
+	case btcjson.WorkDeltaNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnWorkDelta == nil {
+			return
+		}
+
+		var delta btcjson.WorkDelta
+		err := parseObjectNtfnParams(ntfn.Params, &delta)
+		if err != nil {
+			log.Warnf("Received invalid work delta notification: %v",
+				err)
+			return
+		}
+
+		c.ntfnHandlers.OnWorkDelta(&delta)
+
+	// OnClaimAdded
+	case btcjson.ClaimAddedNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnClaimAdded == nil {
+			return
+		}
+
+		var claim btcjson.ClaimNtfnData
+		err := parseObjectNtfnParams(ntfn.Params, &claim)
+		if err != nil {
+			log.Warnf("Received invalid claim added notification: %v",
+				err)
+			return
+		}
+
+		c.ntfnHandlers.OnClaimAdded(&claim)
+
+	// OnClaimUpdated
+	case btcjson.ClaimUpdatedNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnClaimUpdated == nil {
+			return
+		}
+
+		var claim btcjson.ClaimNtfnData
+		err := parseObjectNtfnParams(ntfn.Params, &claim)
+		if err != nil {
+			log.Warnf("Received invalid claim updated notification: %v",
+				err)
+			return
+		}
+
+		c.ntfnHandlers.OnClaimUpdated(&claim)
+
+	// OnNameTakeover
+	case btcjson.NameTakeoverNtfnMethod:
+		// Ignore the notification if the client is not interested in
+		// it.
+		if c.ntfnHandlers.OnNameTakeover == nil {
+			return
+		}
+
+		var takeover btcjson.NameTakeover
+		err := parseObjectNtfnParams(ntfn.Params, &takeover)
+		if err != nil {
+			log.Warnf("Received invalid name takeover notification: %v",
+				err)
+			return
+		}
+
+		c.ntfnHandlers.OnNameTakeover(&takeover)
+
 	// OnBtcdConnected
 	case btcjson.BtcdConnectedNtfnMethod:
 		// Ignore the notification if the client is not interested in
@@ -483,6 +681,60 @@ func (e wrongNumParams) Error() string {
 	return fmt.Sprintf("wrong number of parameters (%d)", e)
 }
 
+// trackLastBlock records in the notification state the last block of the main
+// chain notified by the passed notification, when the ReplayBlocks option is
+// set.  Only the headers of the filtered block notifications are parsed.
+func (c *Client) trackLastBlock(ntfn *rawNotification) {
+	if !c.config.ReplayBlocks {
+		return
+	}
+
+	var lastBlock string
+	switch ntfn.Method {
+	case btcjson.BlockConnectedNtfnMethod:
+		hash, _, _, err := parseChainNtfnParams(ntfn.Params)
+		if err != nil {
+			return
+		}
+		lastBlock = hash.String()
+
+	case btcjson.BlockDisconnectedNtfnMethod:
+		_, height, _, err := parseChainNtfnParams(ntfn.Params)
+		if err != nil {
+			return
+		}
+		lastBlock = strconv.Itoa(int(height - 1))
+
+	case btcjson.FilteredBlockConnectedNtfnMethod,
+		btcjson.FilteredBlockDisconnectedNtfnMethod:
+
+		if len(ntfn.Params) < 2 {
+			return
+		}
+		headerBytes, err := parseHexParam(ntfn.Params[1])
+		if err != nil {
+			return
+		}
+		var header wire.BlockHeader
+		err = header.Deserialize(bytes.NewReader(headerBytes))
+		if err != nil {
+			return
+		}
+		if ntfn.Method == btcjson.FilteredBlockConnectedNtfnMethod {
+			lastBlock = header.BlockHash().String()
+		} else {
+			lastBlock = header.PrevBlock.String()
+		}
+
+	default:
+		return
+	}
+
+	c.ntfnStateLock.Lock()
+	c.ntfnState.lastBlock = lastBlock
+	c.ntfnStateLock.Unlock()
+}
+
 // parseChainNtfnParams parses out the block hash and height from the parameters
 // of blockconnected and blockdisconnected notifications.
 func parseChainNtfnParams(params []json.RawMessage) (*chainhash.Hash,
@@ -775,6 +1027,15 @@ func parseTxAcceptedVerboseNtfnParams(params []json.RawMessage) (*btcjson.TxRawR
 	return &rawTx, nil
 }
 
+// parseObjectNtfnParams unmarshals the single object parameter of a
+// notification, such as work and workdelta notifications, into v.
+func parseObjectNtfnParams(params []json.RawMessage, v interface{}) error {
+	if len(params) != 1 {
+		return wrongNumParams(len(params))
+	}
+	return json.Unmarshal(params[0], v)
+}
+
 // parseBtcdConnectedNtfnParams parses out the connection status of btcd
 // and btcwallet from the parameters of a btcdconnected notification.
 func parseBtcdConnectedNtfnParams(params []json.RawMessage) (bool, error) {
@@ -884,7 +1145,7 @@ func (c *Client) NotifyBlocksAsync() FutureNotifyBlocksResult {
 		return newNilFutureResult()
 	}
 
-	cmd := btcjson.NewNotifyBlocksCmd()
+	cmd := btcjson.NewNotifyBlocksCmd(nil)
 	return c.SendCmd(cmd)
 }
 
@@ -895,13 +1156,47 @@ func (c *Client) NotifyBlocksAsync() FutureNotifyBlocksResult {
 // result in an error if the client is configured to run in HTTP POST mode.
 //
 // The notifications delivered as a result of this call will be via one of
-// OnBlockConnected or OnBlockDisconnected.
+// OnBlockConnected, OnBlockDisconnected or OnChainReorganized.
 //
 // NOTE: This is a btcd extension and requires a websocket connection.
 func (c *Client) NotifyBlocks() error {
 	return c.NotifyBlocksAsync().Receive()
 }
 
+// NotifyBlocksFromAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See NotifyBlocksFrom for the blocking version and more details.
+//
+// NOTE: This is a lbcd extension and requires a websocket connection.
+func (c *Client) NotifyBlocksFromAsync(rescanFrom string) FutureNotifyBlocksResult {
+	// Not supported in HTTP POST mode.
+	if c.config.HTTPPostMode {
+		return newFutureError(ErrWebsocketsRequired)
+	}
+
+	// Ignore the notification if the client is not interested in
+	// notifications.
+	if c.ntfnHandlers == nil {
+		return newNilFutureResult()
+	}
+
+	cmd := btcjson.NewNotifyBlocksCmd(&rescanFrom)
+	return c.SendCmd(cmd)
+}
+
+// NotifyBlocksFrom registers the client to receive notifications when blocks
+// are connected and disconnected from the main chain, like NotifyBlocks, after
+// first replaying the notifications of the blocks connected since rescanFrom,
+// which is the height or hash of the last block seen by the client.  When that
+// block is no longer in the main chain, its disconnection is notified as well.
+//
+// NOTE: This is a lbcd extension and requires a websocket connection.
+func (c *Client) NotifyBlocksFrom(rescanFrom string) error {
+	return c.NotifyBlocksFromAsync(rescanFrom).Receive()
+}
+
 // FutureNotifySpentResult is a future promise to deliver the result of a
 // NotifySpentAsync RPC invocation (or an applicable error).
 //
@@ -1037,6 +1332,110 @@ func (c *Client) NotifyNewTransactions(verbose bool) error {
 	return c.NotifyNewTransactionsAsync(verbose).Receive()
 }
 
+// FutureNotifyWorkResult is a future promise to deliver the result of a
+// NotifyWorkAsync RPC invocation (or an applicable error).
+type FutureNotifyWorkResult chan *Response
+
+// Receive waits for the Response promised by the future and returns an error
+// if the registration was not successful.
+func (r FutureNotifyWorkResult) Receive() error {
+	_, err := ReceiveFuture(r)
+	return err
+}
+
+// NotifyWorkAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See NotifyWork for the blocking version and more details.
+//
+// NOTE: This is an lbcd extension and requires a websocket connection.
+func (c *Client) NotifyWorkAsync(deltas bool) FutureNotifyWorkResult {
+	// Not supported in HTTP POST mode.
+	if c.config.HTTPPostMode {
+		return newFutureError(ErrWebsocketsRequired)
+	}
+
+	// Ignore the notification if the client is not interested in
+	// notifications.
+	if c.ntfnHandlers == nil {
+		return newNilFutureResult()
+	}
+
+	cmd := btcjson.NewNotifyWorkCmd(&deltas)
+	return c.SendCmd(cmd)
+}
+
+// NotifyWork registers the client to receive notifications every time a new
+// block template is generated, after the best chain or the memory pool changes.
+// The notifications are delivered to the notification handlers associated with
+// the client.  Calling this function has no effect if there are no
+// notification handlers and will result in an error if the client is
+// configured to run in HTTP POST mode.
+//
+// The notifications delivered as a result of this call will be via OnWork,
+// and via OnWorkDelta for the templates following one of the same previous
+// block when deltas is true.
+//
+// NOTE: This is an lbcd extension and requires a websocket connection.
+func (c *Client) NotifyWork(deltas bool) error {
+	return c.NotifyWorkAsync(deltas).Receive()
+}
+
+// FutureNotifyClaimsResult is a future promise to deliver the result of a
+// NotifyClaimsAsync RPC invocation (or an applicable error).
+type FutureNotifyClaimsResult chan *Response
+
+// Receive waits for the Response promised by the future and returns an error
+// if the registration was not successful.
+func (r FutureNotifyClaimsResult) Receive() error {
+	_, err := ReceiveFuture(r)
+	return err
+}
+
+// NotifyClaimsAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See NotifyClaims for the blocking version and more details.
+//
+// NOTE: This is an lbcd extension and requires a websocket connection.
+func (c *Client) NotifyClaimsAsync(names []string) FutureNotifyClaimsResult {
+	// Not supported in HTTP POST mode.
+	if c.config.HTTPPostMode {
+		return newFutureError(ErrWebsocketsRequired)
+	}
+
+	// Ignore the notification if the client is not interested in
+	// notifications.
+	if c.ntfnHandlers == nil {
+		return newNilFutureResult()
+	}
+
+	var namesParam *[]string
+	if len(names) > 0 {
+		namesParam = &names
+	}
+	cmd := btcjson.NewNotifyClaimsCmd(namesParam)
+	return c.SendCmd(cmd)
+}
+
+// NotifyClaims registers the client to receive notifications of the claims
+// created and updated, and of the names taken over, by the blocks connected to
+// the main chain, restricted to the passed names unless there are none.  The
+// notifications are delivered to the notification handlers associated with the
+// client.  Calling this function has no effect if there are no notification
+// handlers and will result in an error if the client is configured to run in
+// HTTP POST mode.  Calling it again replaces the names.
+//
+// The notifications delivered as a result of this call will be via
+// OnClaimAdded, OnClaimUpdated and OnNameTakeover.
+//
+// NOTE: This is an lbcd extension and requires a websocket connection.
+func (c *Client) NotifyClaims(names []string) error {
+	return c.NotifyClaimsAsync(names).Receive()
+}
+
 // FutureNotifyReceivedResult is a future promise to deliver the result of a
 // NotifyReceivedAsync RPC invocation (or an applicable error).
 //
@@ -1342,7 +1741,8 @@ func (c *Client) LoadTxFilterAsync(reload bool, addresses []btcutil.Address,
 		}
 	}
 
-	cmd := btcjson.NewLoadTxFilterCmd(reload, addrStrs, outPointObjects)
+	cmd := btcjson.NewLoadTxFilterCmd(reload, addrStrs, outPointObjects,
+		nil, nil)
 	return c.SendCmd(cmd)
 }
 
@@ -1355,3 +1755,31 @@ func (c *Client) LoadTxFilterAsync(reload bool, addresses []btcutil.Address,
 func (c *Client) LoadTxFilter(reload bool, addresses []btcutil.Address, outPoints []wire.OutPoint) error {
 	return c.LoadTxFilterAsync(reload, addresses, outPoints).Receive()
 }
+
+// LoadClaimTxFilterAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See LoadClaimTxFilter for the blocking version and more details.
+//
+// NOTE: This is an lbcd extension and requires a websocket connection.
+func (c *Client) LoadClaimTxFilterAsync(reload bool, names []string,
+	claimIDs []string) FutureLoadTxFilterResult {
+
+	names = append([]string{}, names...)
+	claimIDs = append([]string{}, claimIDs...)
+	cmd := btcjson.NewLoadTxFilterCmd(reload, []string{},
+		[]btcjson.OutPoint{}, &names, &claimIDs)
+	return c.SendCmd(cmd)
+}
+
+// LoadClaimTxFilter loads, reloads, or adds claim names and claim IDs to a
+// websocket client's transaction filter.  The transactions claiming, updating
+// or supporting the names or the claims are relevant, and so are the
+// transactions spending their claim outputs.  The names are matched in their
+// normalized form.
+//
+// NOTE: This is an lbcd extension and requires a websocket connection.
+func (c *Client) LoadClaimTxFilter(reload bool, names []string, claimIDs []string) error {
+	return c.LoadClaimTxFilterAsync(reload, names, claimIDs).Receive()
+}
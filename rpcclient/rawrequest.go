@@ -31,6 +31,10 @@ func (c *Client) RawRequestAsync(method string, params []json.RawMessage) Future
 	if method == "" {
 		return newFutureError(errors.New("no method"))
 	}
+	ctx := c.Context()
+	if err := ctx.Err(); err != nil {
+		return newFutureError(err)
+	}
 
 	// Marshal parameters as "[]" instead of "null" when no parameters
 	// are passed.
@@ -62,10 +66,12 @@ func (c *Client) RawRequestAsync(method string, params []json.RawMessage) Future
 		cmd:            nil,
 		marshalledJSON: marshalledJSON,
 		responseChan:   responseChan,
+		idempotent:     isIdempotent(method),
+		ctx:            ctx,
 	}
 	c.sendRequest(jReq)
 
-	return responseChan
+	return c.watchContext(jReq)
 }
 
 // RawRequest allows the caller to send a raw or custom request to the server.
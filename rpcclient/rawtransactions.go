@@ -166,6 +166,52 @@ func (c *Client) GetRawTransactionVerbose(txHash *chainhash.Hash) (*btcjson.TxRa
 	return c.GetRawTransactionVerboseAsync(txHash).Receive()
 }
 
+// FutureGetRawTransactionsVerboseResult is a future promise to deliver the
+// result of a GetRawTransactionsVerboseAsync RPC invocation (or an applicable
+// error).
+type FutureGetRawTransactionsVerboseResult chan *Response
+
+// Receive waits for the Response promised by the future and returns
+// information about the transactions, with nil for the unknown transactions.
+func (r FutureGetRawTransactionsVerboseResult) Receive() ([]*btcjson.TxRawResult, error) {
+	res, err := ReceiveFuture(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of getrawtransaction result objects.
+	var rawTxResults []*btcjson.TxRawResult
+	err = json.Unmarshal(res, &rawTxResults)
+	if err != nil {
+		return nil, err
+	}
+
+	return rawTxResults, nil
+}
+
+// GetRawTransactionsVerboseAsync returns an instance of a type that can be
+// used to get the result of the RPC at some future time by invoking the
+// Receive function on the returned instance.
+//
+// See GetRawTransactionsVerbose for the blocking version and more details.
+func (c *Client) GetRawTransactionsVerboseAsync(txHashes []*chainhash.Hash) FutureGetRawTransactionsVerboseResult {
+	hashes := make([]string, 0, len(txHashes))
+	for _, txHash := range txHashes {
+		hashes = append(hashes, txHash.String())
+	}
+
+	cmd := btcjson.NewGetRawTransactionsCmd(hashes, btcjson.Bool(true))
+	return c.SendCmd(cmd)
+}
+
+// GetRawTransactionsVerbose returns information about transactions given
+// their hashes, in the same order, with nil for the unknown transactions.
+//
+// See GetRawTransactionVerbose to look up a single transaction.
+func (c *Client) GetRawTransactionsVerbose(txHashes []*chainhash.Hash) ([]*btcjson.TxRawResult, error) {
+	return c.GetRawTransactionsVerboseAsync(txHashes).Receive()
+}
+
 // FutureDecodeRawTransactionResult is a future promise to deliver the result
 // of a DecodeRawTransactionAsync RPC invocation (or an applicable error).
 type FutureDecodeRawTransactionResult chan *Response
@@ -730,6 +776,89 @@ func (c *Client) SignRawTransactionWithWallet3(tx *wire.MsgTx,
 	return c.SignRawTransactionWithWallet3Async(tx, inputs, hashType).Receive()
 }
 
+// FutureSignRawTransactionWithKeyResult is a future promise to deliver the
+// result of the SignRawTransactionWithKeyAsync RPC invocation (or an
+// applicable error).
+type FutureSignRawTransactionWithKeyResult chan *Response
+
+// Receive waits for the Response promised by the future and returns the
+// signed transaction as well as whether or not all inputs are now signed.
+func (r FutureSignRawTransactionWithKeyResult) Receive() (*wire.MsgTx, bool, error) {
+	res, err := ReceiveFuture(r)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Unmarshal as a signrawtransactionwithkey result.
+	var signRawTxWithKeyResult btcjson.SignRawTransactionWithKeyResult
+	err = json.Unmarshal(res, &signRawTxWithKeyResult)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Decode the serialized transaction hex to raw bytes.
+	serializedTx, err := hex.DecodeString(signRawTxWithKeyResult.Hex)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Deserialize the transaction and return it.
+	var msgTx wire.MsgTx
+	if err := msgTx.Deserialize(bytes.NewReader(serializedTx)); err != nil {
+		return nil, false, err
+	}
+
+	return &msgTx, signRawTxWithKeyResult.Complete, nil
+}
+
+// SignRawTransactionWithKeyAsync returns an instance of a type that can be used
+// to get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See SignRawTransactionWithKey for the blocking version and more details.
+func (c *Client) SignRawTransactionWithKeyAsync(tx *wire.MsgTx,
+	privKeysWIF []string, inputs []btcjson.RawTxWitnessInput,
+	hashType SigHashType) FutureSignRawTransactionWithKeyResult {
+
+	txHex := ""
+	if tx != nil {
+		// Serialize the transaction and convert to hex string.
+		buf := bytes.NewBuffer(make([]byte, 0, tx.SerializeSize()))
+		if err := tx.Serialize(buf); err != nil {
+			return newFutureError(err)
+		}
+		txHex = hex.EncodeToString(buf.Bytes())
+	}
+
+	var prevTxs *[]btcjson.RawTxWitnessInput
+	if inputs != nil {
+		prevTxs = &inputs
+	}
+	var sigHashType *string
+	if hashType != "" {
+		sigHashType = btcjson.String(string(hashType))
+	}
+	cmd := btcjson.NewSignRawTransactionWithKeyCmd(txHex, privKeysWIF,
+		prevTxs, sigHashType)
+	return c.SendCmd(cmd)
+}
+
+// SignRawTransactionWithKey signs inputs for the passed transaction with the
+// passed private keys in wallet import format, using the specified signature
+// hash type, or the default one when it is empty, and returns the signed
+// transaction as well as whether or not all inputs are now signed.
+//
+// The RPC server looks up the outputs spent by the transaction in its memory
+// pool and UTXO set, so the inputs only need to list those it doesn't know,
+// along with the redeem and witness scripts of the script hash outputs and the
+// amounts of the witness outputs.
+func (c *Client) SignRawTransactionWithKey(tx *wire.MsgTx, privKeysWIF []string,
+	inputs []btcjson.RawTxWitnessInput, hashType SigHashType) (*wire.MsgTx, bool, error) {
+
+	return c.SignRawTransactionWithKeyAsync(tx, privKeysWIF, inputs,
+		hashType).Receive()
+}
+
 // FutureSearchRawTransactionsResult is a future promise to deliver the result
 // of the SearchRawTransactionsAsync RPC invocation (or an applicable error).
 type FutureSearchRawTransactionsResult chan *Response
@@ -0,0 +1,153 @@
+package rpcclient
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy describes how the attempts failing with a transient error are
+// retried.  The zero values of its fields select their defaults.
+type RetryPolicy struct {
+	// MaxAttempts is the max number of attempts, including the first one.
+	// A negative value retries until the attempt succeeds, or its context
+	// is done.
+	MaxAttempts int
+
+	// InitialBackoff is the wait before the first retry.
+	InitialBackoff time.Duration
+
+	// Multiplier scales the wait before each following retry.  The wait
+	// instead increases by InitialBackoff before each retry when it is at
+	// most 1.
+	Multiplier float64
+
+	// MaxBackoff is the longest wait before a retry.  It defaults to 1
+	// minute.
+	MaxBackoff time.Duration
+
+	// Jitter shortens each wait by a random fraction of it of at most
+	// Jitter, between 0 and 1, so that the clients failing at the same
+	// time don't retry at the same time.
+	Jitter float64
+
+	// Retryable returns whether an attempt failing with the passed error
+	// is retried.  It defaults to IsTransientError.
+	Retryable func(err error) bool
+}
+
+// HTTPStatusError is the error of an HTTP POST request whose response has a
+// status code other than 200, passed to the Retryable function of the retry
+// policy when the attempt may be retried.
+type HTTPStatusError struct {
+	StatusCode int
+	Status     string
+}
+
+// Error returns the status of the response.
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("status code: %d (%s)", e.StatusCode, e.Status)
+}
+
+// IsTransientError returns whether the passed error of an HTTP POST request or
+// a websocket connection is likely to go away when retried: it is a network
+// error, or the server is overloaded or unavailable.  Authentication and
+// certificate errors are not transient.
+func IsTransientError(err error) bool {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+
+			return true
+		}
+		return false
+	}
+
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var certificateErr x509.CertificateInvalidError
+	var hostnameErr x509.HostnameError
+	switch {
+	case errors.Is(err, ErrInvalidAuth), errors.Is(err, ErrInvalidEndpoint),
+		errors.As(err, &unknownAuthorityErr),
+		errors.As(err, &certificateErr), errors.As(err, &hostnameErr):
+
+		return false
+	}
+	return err != nil
+}
+
+// isTransportError returns whether the passed error is the error of an HTTP
+// POST request which failed to get a response, which are the errors retried by
+// default.
+func isTransportError(err error) bool {
+	var statusErr *HTTPStatusError
+	return err != nil && !errors.As(err, &statusErr)
+}
+
+// defaultRetryPolicy is the retry policy of the HTTP POST requests unless the
+// RetryPolicy option is set.
+var defaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    10,
+	InitialBackoff: requestRetryInterval,
+	Retryable:      isTransportError,
+}
+
+// defaultReconnectPolicy is the retry policy of the websocket connections
+// unless the ReconnectPolicy option is set.
+var defaultReconnectPolicy = RetryPolicy{
+	InitialBackoff: connectionRetryInterval,
+	Retryable:      func(err error) bool { return true },
+}
+
+// withDefaults returns the policy with the defaults of the passed policy for
+// its unset fields.
+func (p *RetryPolicy) withDefaults(defaults *RetryPolicy) *RetryPolicy {
+	if p == nil {
+		return defaults
+	}
+	policy := *p
+	if policy.MaxAttempts == 0 {
+		policy.MaxAttempts = defaults.MaxAttempts
+	}
+	if policy.InitialBackoff <= 0 {
+		policy.InitialBackoff = defaults.InitialBackoff
+	}
+	if policy.Retryable == nil {
+		policy.Retryable = IsTransientError
+	}
+	return &policy
+}
+
+// canRetry returns whether another attempt may follow the passed number of
+// attempts.
+func (p *RetryPolicy) canRetry(attempts int) bool {
+	return p.MaxAttempts <= 0 || attempts < p.MaxAttempts
+}
+
+// backoff returns the wait before the retry following the passed number of
+// failed attempts.
+func (p *RetryPolicy) backoff(attempts int) time.Duration {
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = time.Minute
+	}
+
+	backoff := float64(p.InitialBackoff) * float64(attempts)
+	if p.Multiplier > 1 {
+		backoff = float64(p.InitialBackoff) *
+			math.Pow(p.Multiplier, float64(attempts-1))
+	}
+	if backoff > float64(maxBackoff) {
+		backoff = float64(maxBackoff)
+	}
+	if p.Jitter > 0 {
+		backoff -= backoff * math.Min(p.Jitter, 1) * rand.Float64()
+	}
+	return time.Duration(backoff)
+}
@@ -0,0 +1,143 @@
+package rpcclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestRetryPolicyBackoff ensures that the waits between the attempts follow
+// the retry policy.
+func TestRetryPolicyBackoff(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		want   []time.Duration
+	}{
+		{
+			name:   "linear",
+			policy: RetryPolicy{InitialBackoff: 20 * time.Second},
+			want: []time.Duration{20 * time.Second, 40 * time.Second,
+				time.Minute, time.Minute},
+		},
+		{
+			name: "exponential",
+			policy: RetryPolicy{
+				InitialBackoff: time.Second,
+				Multiplier:     2,
+				MaxBackoff:     5 * time.Second,
+			},
+			want: []time.Duration{time.Second, 2 * time.Second,
+				4 * time.Second, 5 * time.Second},
+		},
+	}
+	for _, test := range tests {
+		for i, want := range test.want {
+			got := test.policy.backoff(i + 1)
+			if got != want {
+				t.Errorf("%s: unexpected backoff %d: got %v, want %v",
+					test.name, i+1, got, want)
+			}
+		}
+	}
+
+	// The jitter shortens the waits by at most its fraction of them.
+	policy := RetryPolicy{InitialBackoff: time.Second, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		got := policy.backoff(1)
+		if got < 500*time.Millisecond || got > time.Second {
+			t.Fatalf("unexpected jittered backoff: %v", got)
+		}
+	}
+}
+
+// TestIsTransientError ensures that the errors are classified as transient or
+// not.
+func TestIsTransientError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("connection refused"), true},
+		{ErrInvalidAuth, false},
+		{fmt.Errorf("dial: %w", ErrInvalidEndpoint), false},
+		{&HTTPStatusError{StatusCode: http.StatusServiceUnavailable}, true},
+		{&HTTPStatusError{StatusCode: http.StatusTooManyRequests}, true},
+		{&HTTPStatusError{StatusCode: http.StatusInternalServerError}, false},
+		{&HTTPStatusError{StatusCode: http.StatusUnauthorized}, false},
+	}
+	for i, test := range tests {
+		if got := IsTransientError(test.err); got != test.want {
+			t.Errorf("#%d (%v): got %v, want %v", i, test.err, got,
+				test.want)
+		}
+	}
+}
+
+// TestRetryPolicyHTTP ensures that the HTTP POST requests are retried
+// according to the retry policy.
+func TestRetryPolicyHTTP(t *testing.T) {
+	t.Parallel()
+
+	var requests, failures int32
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			n := atomic.AddInt32(&requests, 1)
+			if n <= atomic.LoadInt32(&failures) {
+				http.Error(w, "busy", http.StatusServiceUnavailable)
+				return
+			}
+			fmt.Fprint(w, `{"result":5,"error":null,"id":1}`)
+		}))
+	defer server.Close()
+
+	client, err := New(&ConnConfig{
+		Host:         strings.TrimPrefix(server.URL, "http://"),
+		User:         "user",
+		Pass:         "pass",
+		HTTPPostMode: true,
+		DisableTLS:   true,
+		RetryPolicy: &RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	defer client.Shutdown()
+
+	// The transient failures are retried.
+	atomic.StoreInt32(&failures, 2)
+	count, err := client.GetBlockCount()
+	if err != nil {
+		t.Fatalf("unable to get block count: %v", err)
+	}
+	if count != 5 {
+		t.Fatalf("unexpected block count: got %d, want 5", count)
+	}
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Fatalf("unexpected requests: got %d, want 3", n)
+	}
+
+	// The last attempt is replied as is.
+	atomic.StoreInt32(&requests, 0)
+	atomic.StoreInt32(&failures, 5)
+	_, err = client.GetBlockCount()
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Fatalf("unexpected requests: got %d, want 3", n)
+	}
+}
@@ -910,7 +910,7 @@ func (c *Client) CreateMultisigAsync(requiredSigs int, addresses []btcutil.Addre
 		addrs = append(addrs, addr.String())
 	}
 
-	cmd := btcjson.NewCreateMultisigCmd(requiredSigs, addrs)
+	cmd := btcjson.NewCreateMultisigCmd(requiredSigs, addrs, nil, nil)
 	return c.SendCmd(cmd)
 }
 
@@ -1418,7 +1418,7 @@ func (r FutureValidateAddressResult) Receive() (*btcjson.ValidateAddressWalletRe
 // See ValidateAddress for the blocking version and more details.
 func (c *Client) ValidateAddressAsync(address btcutil.Address) FutureValidateAddressResult {
 	addr := address.EncodeAddress()
-	cmd := btcjson.NewValidateAddressCmd(addr)
+	cmd := btcjson.NewValidateAddressCmd(addr, nil)
 	return c.SendCmd(cmd)
 }
 
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/txscript/claimscript"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+// decodeScriptClaim returns the claim prefix of a script decoded by the
+// decodescript command, or nil when the script has none.
+func decodeScriptClaim(script []byte) *btcjson.DecodeScriptClaim {
+	cs, err := claimscript.Parse(script)
+	if err != nil {
+		return nil
+	}
+
+	// The disassembled string will contain [error] inline if the script
+	// doesn't fully parse, so ignore the error here.
+	disbuf, _ := txscript.DisasmString(cs.PkScript)
+	claim := &btcjson.DecodeScriptClaim{
+		Type:  cs.Type.String(),
+		Name:  string(cs.Name),
+		Value: hex.EncodeToString(cs.Value),
+		Asm:   disbuf,
+		Hex:   hex.EncodeToString(cs.PkScript),
+	}
+	if cs.Type != claimscript.TypeClaimName {
+		claim.ClaimID = cs.ClaimID.String()
+	}
+	return claim
+}
+
+// decodeScriptSegwit returns the witness program which pays to a script
+// decoded by the decodescript command, or nil when the script has no witness
+// form: a pubkey script of a compressed key, or a pubkey hash script, is paid
+// to by its witness pubkey hash, and any other script by its witness script
+// hash.  The scripts which are already witness programs or script hashes,
+// those with a claim prefix, the data-only scripts and the scripts with an
+// uncompressed key are not paid to by a witness program.
+func decodeScriptSegwit(script []byte, params *chaincfg.Params) (*btcjson.DecodeScriptSegwit, error) {
+	if claimscript.IsClaimScript(script) {
+		return nil, nil
+	}
+
+	// Ignore the error here since an error means the script couldn't parse
+	// and there is no additional information about it anyways.
+	scriptClass, addrs, _, _ := txscript.ExtractPkScriptAddrs(script, params)
+	for _, addr := range addrs {
+		pubKey, ok := addr.(*btcutil.AddressPubKey)
+		if ok && pubKey.Format() != btcutil.PKFCompressed {
+			return nil, nil
+		}
+	}
+
+	var witnessAddr btcutil.Address
+	var err error
+	switch scriptClass {
+	case txscript.ScriptHashTy, txscript.WitnessV0PubKeyHashTy,
+		txscript.WitnessV0ScriptHashTy, txscript.WitnessUnknownTy,
+		txscript.NullDataTy:
+		return nil, nil
+
+	case txscript.PubKeyTy:
+		pubKeyHash := addrs[0].(*btcutil.AddressPubKey).AddressPubKeyHash()
+		witnessAddr, err = btcutil.NewAddressWitnessPubKeyHash(
+			pubKeyHash.Hash160()[:], params)
+
+	case txscript.PubKeyHashTy:
+		witnessAddr, err = btcutil.NewAddressWitnessPubKeyHash(
+			addrs[0].ScriptAddress(), params)
+
+	default:
+		scriptHash := sha256.Sum256(script)
+		witnessAddr, err = btcutil.NewAddressWitnessScriptHash(
+			scriptHash[:], params)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	witnessProgram, err := txscript.PayToAddrScript(witnessAddr)
+	if err != nil {
+		return nil, err
+	}
+	p2shSegwit, err := btcutil.NewAddressScriptHash(witnessProgram, params)
+	if err != nil {
+		return nil, err
+	}
+
+	disbuf, _ := txscript.DisasmString(witnessProgram)
+	return &btcjson.DecodeScriptSegwit{
+		Asm:        disbuf,
+		Hex:        hex.EncodeToString(witnessProgram),
+		ReqSigs:    1,
+		Type:       txscript.GetScriptClass(witnessProgram).String(),
+		Addresses:  []string{witnessAddr.EncodeAddress()},
+		P2shSegwit: p2shSegwit.EncodeAddress(),
+	}, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/lbryio/lbcd/btcec"
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/txscript/claimscript"
+	btcutil "github.com/lbryio/lbcutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeScript(t *testing.T) {
+
+	r := require.New(t)
+	params := &chaincfg.RegressionNetParams
+	s := &rpcServer{cfg: rpcserverConfig{ChainParams: params}}
+	decode := func(script []byte) btcjson.DecodeScriptResult {
+		cmd := btcjson.NewDecodeScriptCmd(hex.EncodeToString(script))
+		result, err := handleDecodeScript(s, cmd, nil)
+		r.NoError(err)
+		return result.(btcjson.DecodeScriptResult)
+	}
+
+	privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), bytes.Repeat([]byte{1}, 32))
+	pubKey, err := btcutil.NewAddressPubKey(privKey.PubKey().SerializeCompressed(), params)
+	r.NoError(err)
+	pubKeyHash := pubKey.AddressPubKeyHash()
+	p2pkh, err := txscript.PayToAddrScript(pubKeyHash)
+	r.NoError(err)
+
+	// A pubkey hash script is paid to by the witness pubkey hash of its
+	// key.
+	result := decode(p2pkh)
+	r.Equal("pubkeyhash", result.Type)
+	r.Nil(result.Claim)
+	r.NotNil(result.Segwit)
+	r.Equal("witness_v0_keyhash", result.Segwit.Type)
+	witnessAddr, err := btcutil.NewAddressWitnessPubKeyHash(pubKeyHash.Hash160()[:], params)
+	r.NoError(err)
+	r.Equal([]string{witnessAddr.EncodeAddress()}, result.Segwit.Addresses)
+	witnessProgram, err := txscript.PayToAddrScript(witnessAddr)
+	r.NoError(err)
+	r.Equal(hex.EncodeToString(witnessProgram), result.Segwit.Hex)
+	p2shSegwit, err := btcutil.NewAddressScriptHash(witnessProgram, params)
+	r.NoError(err)
+	r.Equal(p2shSegwit.EncodeAddress(), result.Segwit.P2shSegwit)
+
+	// A multisig script is paid to by its witness script hash.
+	multiSig, err := txscript.MultiSigScript([]*btcutil.AddressPubKey{pubKey}, 1)
+	r.NoError(err)
+	result = decode(multiSig)
+	r.Equal("multisig", result.Type)
+	r.Equal("witness_v0_scripthash", result.Segwit.Type)
+	scriptHash := sha256.Sum256(multiSig)
+	r.Equal(hex.EncodeToString(append([]byte{txscript.OP_0, txscript.OP_DATA_32},
+		scriptHash[:]...)), result.Segwit.Hex)
+
+	// The witness programs, the script hashes and the scripts with an
+	// uncompressed key have no witness form.
+	r.Nil(decode(witnessProgram).Segwit)
+	r.Nil(decode(p2shSegwitScript(r, p2shSegwit)).Segwit)
+	uncompressed, err := btcutil.NewAddressPubKey(privKey.PubKey().SerializeUncompressed(), params)
+	r.NoError(err)
+	multiSig, err = txscript.MultiSigScript([]*btcutil.AddressPubKey{uncompressed}, 1)
+	r.NoError(err)
+	r.Nil(decode(multiSig).Segwit)
+
+	// A claim script exposes its claim and the script which follows it.
+	claimID, err := claimscript.ParseClaimID("4e2a3f92fee27474f3a4f3b1cb04a5d17773dd01")
+	r.NoError(err)
+	update, err := claimscript.UpdateClaim("@chan", claimID, []byte{0xca, 0xfe}, p2pkh)
+	r.NoError(err)
+	result = decode(update)
+	r.Equal("pubkeyhash", result.Type)
+	r.Equal([]string{pubKeyHash.EncodeAddress()}, result.Addresses)
+	r.Nil(result.Segwit)
+	r.Equal(&btcjson.DecodeScriptClaim{
+		Type:    "update",
+		Name:    "@chan",
+		ClaimID: "4e2a3f92fee27474f3a4f3b1cb04a5d17773dd01",
+		Value:   "cafe",
+		Asm:     decode(p2pkh).Asm,
+		Hex:     hex.EncodeToString(p2pkh),
+	}, result.Claim)
+
+	claim, err := claimscript.ClaimName("@chan", nil, p2pkh)
+	r.NoError(err)
+	result = decode(claim)
+	r.Equal("claim", result.Claim.Type)
+	r.Empty(result.Claim.ClaimID)
+	r.Empty(result.Claim.Value)
+}
+
+// p2shSegwitScript returns the script paying to the script hash address.
+func p2shSegwitScript(r *require.Assertions, addr btcutil.Address) []byte {
+	script, err := txscript.PayToAddrScript(addr)
+	r.NoError(err)
+	return script
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/descriptor"
+	"github.com/lbryio/lbcutil/hdkeychain"
+)
+
+// maxDeriveAddressesRange is the maximum number of indexes of the range of
+// deriveaddresses.
+const maxDeriveAddressesRange = 1000000
+
+// parseDescriptor parses an output script descriptor of the network of the
+// server into an RPC error.
+func parseDescriptor(s *rpcServer, desc string, requireChecksum bool) (*descriptor.Descriptor, error) {
+	d, err := descriptor.Parse(desc, s.cfg.ChainParams, requireChecksum)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: err.Error(),
+		}
+	}
+	return d, nil
+}
+
+// handleGetDescriptorInfo implements the getdescriptorinfo command.
+func handleGetDescriptorInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetDescriptorInfoCmd)
+
+	d, err := parseDescriptor(s, c.Descriptor, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// The checksum is that of the descriptor as it was passed, without the
+	// checksum it may already have.
+	desc := c.Descriptor
+	if i := len(desc) - 9; i >= 0 && desc[i] == '#' {
+		desc = desc[:i]
+	}
+	checksum, err := descriptor.Checksum(desc)
+	if err != nil {
+		return nil, internalRPCError(err.Error(), "")
+	}
+
+	return &btcjson.GetDescriptorInfoResult{
+		Descriptor:     d.String(),
+		Checksum:       checksum,
+		IsRange:        d.IsRange(),
+		IsSolvable:     d.IsSolvable(),
+		HasPrivateKeys: d.HasPrivateKeys(),
+	}, nil
+}
+
+// handleDeriveAddresses implements the deriveaddresses command.  The addresses
+// of a ranged descriptor are those of the indexes of the range, from its begin
+// to its end inclusive, and a range which is a single number ends there and
+// begins at 0.
+func handleDeriveAddresses(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.DeriveAddressesCmd)
+
+	d, err := parseDescriptor(s, c.Descriptor, true)
+	if err != nil {
+		return nil, err
+	}
+
+	begin, end := 0, 0
+	switch {
+	case d.IsRange() && c.Range == nil:
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Range must be specified for a ranged descriptor",
+		}
+	case !d.IsRange() && c.Range != nil:
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Range should not be specified for an un-ranged descriptor",
+		}
+	case c.Range != nil:
+		switch v := c.Range.Value.(type) {
+		case int:
+			end = v
+		case []int:
+			begin, end = v[0], v[1]
+		}
+	}
+	switch {
+	case begin < 0 || end < begin:
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Range should be greater or equal than 0, with its begin no greater than its end",
+		}
+	case end >= hdkeychain.HardenedKeyStart:
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "End of range is too high",
+		}
+	case end-begin >= maxDeriveAddressesRange:
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Range is too large",
+		}
+	}
+
+	var result btcjson.DeriveAddressesResult
+	for index := begin; index <= end; index++ {
+		addrs, err := d.Addresses(uint32(index), s.cfg.ChainParams)
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidAddressOrKey,
+				Message: err.Error(),
+			}
+		}
+		if len(addrs) == 0 {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidAddressOrKey,
+				Message: "Descriptor does not have a corresponding address",
+			}
+		}
+		for _, addr := range addrs {
+			result = append(result, addr.EncodeAddress())
+		}
+
+		if err := clientQuit(closeChan); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/database"
+)
+
+const (
+	// exportsDirname is the directory of the data directory the block
+	// archives are written to.
+	exportsDirname = "exports"
+
+	// exportBatchBlocks is the number of blocks read from the database in
+	// each of its transactions during an export.
+	exportBatchBlocks = 100
+)
+
+// handleExportBlocks implements the exportblocks command.
+func handleExportBlocks(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.ExportBlocksCmd)
+
+	best := s.cfg.Chain.BestSnapshot()
+	start := *c.StartHeight
+	end := best.Height
+	if c.EndHeight != nil {
+		end = *c.EndHeight
+	}
+	if start < 0 || start > end || end > best.Height {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Heights must be between 0 and %d, "+
+				"with the start height at most the end height",
+				best.Height),
+		}
+	}
+
+	if !atomic.CompareAndSwapInt32(&s.exportingBlocks, 0, 1) {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "A block export is already running",
+		}
+	}
+	defer atomic.StoreInt32(&s.exportingBlocks, 0)
+
+	dir := filepath.Join(cfg.DataDir, exportsDirname)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		context := "Failed to create exports directory"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("blocks-%d-%d.dat", start, end))
+
+	// The archive is written to a temporary file first, so that it is
+	// either complete or missing.
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		context := "Failed to create block archive"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	defer os.Remove(tmpPath)
+	defer f.Close()
+
+	rpcsLog.Infof("Exporting blocks %d to %d to %s", start, end, path)
+	w := bufio.NewWriter(f)
+	var size int64
+	var prevHash *chainhash.Hash
+	for height := start; height <= end; height += exportBatchBlocks {
+		select {
+		case <-closeChan:
+			return nil, ErrClientQuit
+		case <-s.quit:
+			return nil, ErrClientQuit
+		default:
+		}
+
+		batchEnd := height + exportBatchBlocks - 1
+		if batchEnd > end {
+			batchEnd = end
+		}
+		err := s.cfg.DB.View(func(dbTx database.Tx) error {
+			for h := height; h <= batchEnd; h++ {
+				hash, err := s.cfg.Chain.BlockHashByHeight(h)
+				if err != nil {
+					return err
+				}
+				block, err := dbTx.FetchBlock(hash)
+				if err != nil {
+					return err
+				}
+
+				// The blocks must link to each other, which they
+				// don't once the main chain reorganized.  The
+				// previous block hash follows the block version.
+				if prevHash != nil && !bytes.Equal(
+					block[4:4+chainhash.HashSize], prevHash[:]) {
+
+					return fmt.Errorf("the main chain "+
+						"reorganized at height %d", h)
+				}
+				prevHash = hash
+
+				err = writeArchiveBlock(w, s.cfg.ChainParams.Net,
+					block)
+				if err != nil {
+					return err
+				}
+				size += int64(len(block)) + 8
+			}
+			return nil
+		})
+		if err != nil {
+			context := "Failed to export blocks"
+			return nil, internalRPCError(err.Error(), context)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		context := "Failed to write block archive"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	if err := f.Sync(); err != nil {
+		context := "Failed to write block archive"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	if err := f.Close(); err != nil {
+		context := "Failed to write block archive"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		context := "Failed to write block archive"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	rpcsLog.Infof("Exported %d blocks to %s", end-start+1, path)
+
+	return &btcjson.ExportBlocksResult{
+		Path:        path,
+		StartHeight: start,
+		EndHeight:   end,
+		Blocks:      int(end - start + 1),
+		Size:        size,
+	}, nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/lbryio/lbcd/blockchain/indexers"
+)
+
+const (
+	// healthzPath is the path of the liveness endpoint.
+	healthzPath = "/healthz"
+
+	// readyzPath is the path of the readiness endpoint.
+	readyzPath = "/readyz"
+)
+
+// readyCheck is a named check of the readiness of the node.
+type readyCheck struct {
+	name  string
+	check func(s *rpcServer) error
+}
+
+// readyChecks are the checks which must all pass for the node to be ready to
+// serve requests.
+var readyChecks = []readyCheck{
+	{"sync", checkReadySync},
+	{"indexes", checkReadyIndexes},
+	{"claimtrie", checkReadyClaimTrie},
+	{"diskspace", checkReadyDiskSpace},
+}
+
+// checkPeersLag returns an error when the best chain is more than maxLag blocks
+// behind the best chain advertised by the peers.
+func checkPeersLag(height int32, peerHeights []int32, maxLag uint32) error {
+	if len(peerHeights) == 0 {
+		return fmt.Errorf("no connected peers")
+	}
+	best := peerHeights[0]
+	for _, h := range peerHeights[1:] {
+		if h > best {
+			best = h
+		}
+	}
+	if int64(best)-int64(height) > int64(maxLag) {
+		return fmt.Errorf("best height %d is %d blocks behind the peers",
+			height, best-height)
+	}
+	return nil
+}
+
+// checkReadySync checks the best chain is within readymaxlag blocks of the
+// peers.
+func checkReadySync(s *rpcServer) error {
+	var peerHeights []int32
+	for _, p := range s.cfg.ConnMgr.ConnectedPeers() {
+		peerHeights = append(peerHeights, p.ToPeer().LastBlock())
+	}
+	best := s.cfg.Chain.BestSnapshot()
+	return checkPeersLag(best.Height, peerHeights, cfg.ReadyMaxLag)
+}
+
+// checkReadyIndexes checks the optional indexes are caught up with the best
+// chain.
+func checkReadyIndexes(s *rpcServer) error {
+	var indexes []indexers.Indexer
+	if s.cfg.TxIndex != nil {
+		indexes = append(indexes, s.cfg.TxIndex)
+	}
+	if s.cfg.AddrIndex != nil {
+		indexes = append(indexes, s.cfg.AddrIndex)
+	}
+	if s.cfg.CfIndex != nil {
+		indexes = append(indexes, s.cfg.CfIndex)
+	}
+
+	best := s.cfg.Chain.BestSnapshot()
+	for _, index := range indexes {
+		hash, height, err := indexers.IndexTip(s.cfg.DB, index)
+		if err != nil {
+			return err
+		}
+		if *hash != best.Hash {
+			return fmt.Errorf("%s is at height %d, best height is %d",
+				index.Name(), height, best.Height)
+		}
+	}
+	return nil
+}
+
+// checkReadyClaimTrie checks the claimtrie matches the best block.
+func checkReadyClaimTrie(s *rpcServer) error {
+	return s.cfg.Chain.CheckClaimTrie()
+}
+
+// checkReadyDiskSpace checks blocks are accepted, which they aren't once the
+// free disk space runs out.
+func checkReadyDiskSpace(s *rpcServer) error {
+	if reason := s.cfg.Chain.AcceptanceHalted(); reason != "" {
+		return fmt.Errorf("blocks are not accepted: %s", reason)
+	}
+	return nil
+}
+
+// handleHealthz responds to liveness probes, which only tell the process is
+// serving requests.
+func (s *rpcServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, "ok")
+}
+
+// handleReadyz responds to readiness probes with the result of each of the
+// ready checks, and a 503 status unless all of them pass.
+func (s *rpcServer) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	var body strings.Builder
+	ready := true
+	for _, c := range readyChecks {
+		if err := c.check(s); err != nil {
+			ready = false
+			fmt.Fprintf(&body, "[-]%s failed: %v\n", c.name, err)
+			continue
+		}
+		fmt.Fprintf(&body, "[+]%s ok\n", c.name)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if !ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		body.WriteString("readyz check failed\n")
+	} else {
+		body.WriteString("readyz check passed\n")
+	}
+	w.Write([]byte(body.String()))
+}
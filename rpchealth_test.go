@@ -0,0 +1,18 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckPeersLag(t *testing.T) {
+
+	r := require.New(t)
+
+	r.Error(checkPeersLag(100, nil, 2))
+	r.NoError(checkPeersLag(100, []int32{90, 102}, 2))
+	r.NoError(checkPeersLag(100, []int32{50}, 0))
+	r.EqualError(checkPeersLag(100, []int32{101, 103}, 2),
+		"best height 100 is 3 blocks behind the peers")
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+
+	"github.com/lbryio/lbcd/btcec"
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/txscript/claimscript"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+// Text used to signify that a signed message follows and to prevent
+// inadvertently signing a transaction.  The messages are signed with the
+// header of lbrycrd, and verified with either it or the legacy header which
+// lbcd signed them with before.
+const (
+	messageSignatureHeader       = "LBRYcrd Signed Message:\n"
+	legacyMessageSignatureHeader = "Bitcoin Signed Message:\n"
+)
+
+// messageHash returns the hash of the message which is signed with the header.
+func messageHash(header, message string) []byte {
+	var buf bytes.Buffer
+	wire.WriteVarString(&buf, 0, header)
+	wire.WriteVarString(&buf, 0, message)
+	return chainhash.DoubleHashB(buf.Bytes())
+}
+
+// handleSignMessageWithPrivKey implements the signmessagewithprivkey command.
+func handleSignMessageWithPrivKey(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.SignMessageWithPrivKeyCmd)
+
+	wif, err := decodePrivKey(s.cfg.ChainParams, c.PrivKey)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := btcec.SignCompact(btcec.S256(), wif.PrivKey,
+		messageHash(messageSignatureHeader, c.Message), wif.CompressPubKey)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "Sign failed",
+		}
+	}
+
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// handleVerifyMessage implements the verifymessage command.
+func handleVerifyMessage(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.VerifyMessageCmd)
+
+	// Decode the provided address, or the address a claim script pays to.
+	params := s.cfg.ChainParams
+	addr, err := btcutil.DecodeAddress(c.Address, params)
+	if err != nil {
+		script, hexErr := hex.DecodeString(c.Address)
+		if hexErr != nil || !claimscript.IsClaimScript(script) {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidAddressOrKey,
+				Message: "Invalid address or key: " + err.Error(),
+			}
+		}
+		_, addrs, _, _ := txscript.ExtractPkScriptAddrs(script, params)
+		if len(addrs) != 1 {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCType,
+				Message: "Claim script does not pay to a single address",
+			}
+		}
+		addr = addrs[0]
+	}
+
+	// Only P2PKH addresses are valid for signing.
+	if _, ok := addr.(*btcutil.AddressPubKeyHash); !ok {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCType,
+			Message: "Address is not a pay-to-pubkey-hash address",
+		}
+	}
+
+	// Decode base64 signature.
+	sig, err := base64.StdEncoding.DecodeString(c.Signature)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCParse.Code,
+			Message: "Malformed base64 encoding: " + err.Error(),
+		}
+	}
+
+	for _, header := range []string{messageSignatureHeader, legacyMessageSignatureHeader} {
+		// Validate the signature - this just shows that it was valid
+		// at all.  we will compare it with the key next.
+		pk, wasCompressed, err := btcec.RecoverCompact(btcec.S256(), sig,
+			messageHash(header, c.Message))
+		if err != nil {
+			// Mirror Bitcoin Core behavior, which treats error in
+			// RecoverCompact as invalid signature.
+			continue
+		}
+
+		// Reconstruct the pubkey hash.
+		var serializedPK []byte
+		if wasCompressed {
+			serializedPK = pk.SerializeCompressed()
+		} else {
+			serializedPK = pk.SerializeUncompressed()
+		}
+		address, err := btcutil.NewAddressPubKey(serializedPK, params)
+		if err != nil {
+			// Again mirror Bitcoin Core behavior, which treats
+			// error in public key reconstruction as invalid
+			// signature.
+			continue
+		}
+
+		// Return true if addresses match.
+		if address.EncodeAddress() == addr.EncodeAddress() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
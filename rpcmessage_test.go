@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/lbryio/lbcd/btcec"
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/txscript/claimscript"
+	btcutil "github.com/lbryio/lbcutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerifyMessage(t *testing.T) {
+
+	r := require.New(t)
+	params := &chaincfg.RegressionNetParams
+	s := &rpcServer{cfg: rpcserverConfig{ChainParams: params}}
+
+	privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), bytes.Repeat([]byte{1}, 32))
+	wif, err := btcutil.NewWIF(privKey, params, true)
+	r.NoError(err)
+	addr, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(wif.SerializePubKey()), params)
+	r.NoError(err)
+
+	result, err := handleSignMessageWithPrivKey(s,
+		btcjson.NewSignMessageWithPrivKey(wif.String(), "hello"), nil)
+	r.NoError(err)
+	sig := result.(string)
+	verify := func(address, sig, message string) bool {
+		result, err := handleVerifyMessage(s,
+			btcjson.NewVerifyMessageCmd(address, sig, message), nil)
+		r.NoError(err)
+		return result.(bool)
+	}
+	r.True(verify(addr.EncodeAddress(), sig, "hello"))
+	r.False(verify(addr.EncodeAddress(), sig, "hello!"))
+
+	// The address of a claim is the one its script pays to.
+	pkScript, err := txscript.PayToAddrScript(addr)
+	r.NoError(err)
+	claim, err := claimscript.ClaimName("@chan", []byte{0xca, 0xfe}, pkScript)
+	r.NoError(err)
+	r.True(verify(hex.EncodeToString(claim), sig, "hello"))
+	_, err = handleVerifyMessage(s,
+		btcjson.NewVerifyMessageCmd(hex.EncodeToString(pkScript), sig, "hello"), nil)
+	r.Error(err)
+
+	// The messages signed with the legacy header still verify.
+	legacy, err := btcec.SignCompact(btcec.S256(), privKey,
+		messageHash(legacyMessageSignatureHeader, "hello"), true)
+	r.NoError(err)
+	r.NotEqual(sig, base64.StdEncoding.EncodeToString(legacy))
+	r.True(verify(addr.EncodeAddress(), base64.StdEncoding.EncodeToString(legacy), "hello"))
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/lbryio/lbcd/btcec"
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/descriptor"
+	"github.com/lbryio/lbcd/txscript"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+// handleCreateMultisig implements the createmultisig command.  The address of
+// the multisig script is a script hash, a witness script hash nested in a
+// script hash, or a witness script hash, by address type.
+//
+// A claim prefix is rather applied to the multisig script itself, whose keys
+// are then limited to those of a standard bare multisig script: the claim
+// scripts are evaluated as they are, without the rules of the script hashes
+// and the witness programs they wrap, which would let anyone knowing the
+// multisig script spend the claim.
+func handleCreateMultisig(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.CreateMultisigCmd)
+	params := s.cfg.ChainParams
+
+	addressType := *c.AddressType
+	witness := addressType != "legacy"
+	switch {
+	case c.NRequired < 1:
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "a multisignature address must require at least one key to redeem",
+		}
+	case len(c.Keys) < c.NRequired:
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("not enough keys supplied (got %d "+
+				"keys, but need at least %d to redeem)",
+				len(c.Keys), c.NRequired),
+		}
+	case len(c.Keys) > txscript.MaxPubKeysPerMultiSig:
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Number of keys involved in the "+
+				"multisignature address creation > %d",
+				txscript.MaxPubKeysPerMultiSig),
+		}
+	}
+
+	pubKeys := make([]*btcutil.AddressPubKey, 0, len(c.Keys))
+	hexKeys := make([]string, 0, len(c.Keys))
+	for _, key := range c.Keys {
+		serializedKey, err := hex.DecodeString(key)
+		if err != nil {
+			return nil, rpcDecodeHexError(key)
+		}
+		pubKey, err := btcutil.NewAddressPubKey(serializedKey, params)
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidAddressOrKey,
+				Message: "Invalid public key: " + key,
+			}
+		}
+		if witness && len(serializedKey) != btcec.PubKeyBytesLenCompressed {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidAddressOrKey,
+				Message: "Compressed key required for witness address types: " + key,
+			}
+		}
+		pubKeys = append(pubKeys, pubKey)
+		hexKeys = append(hexKeys, hex.EncodeToString(serializedKey))
+	}
+	redeemScript, err := txscript.MultiSigScript(pubKeys, c.NRequired)
+	if err != nil {
+		return nil, internalRPCError(err.Error(), "Failed to create the multisig script")
+	}
+	if len(redeemScript) > txscript.MaxScriptElementSize {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("redeemScript exceeds size limit: %d > %d",
+				len(redeemScript), txscript.MaxScriptElementSize),
+		}
+	}
+
+	desc := fmt.Sprintf("multi(%d,%s)", c.NRequired, strings.Join(hexKeys, ","))
+	var addr btcutil.Address
+	witnessScriptHash := sha256.Sum256(redeemScript)
+	switch addressType {
+	case "legacy":
+		addr, err = btcutil.NewAddressScriptHash(redeemScript, params)
+		desc = "sh(" + desc + ")"
+	case "p2sh-segwit":
+		var witnessProgram []byte
+		witnessProgram, err = txscript.NewScriptBuilder().AddOp(txscript.OP_0).
+			AddData(witnessScriptHash[:]).Script()
+		if err == nil {
+			addr, err = btcutil.NewAddressScriptHash(witnessProgram, params)
+		}
+		desc = "sh(wsh(" + desc + "))"
+	case "bech32":
+		addr, err = btcutil.NewAddressWitnessScriptHash(witnessScriptHash[:], params)
+		desc = "wsh(" + desc + ")"
+	default:
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Unknown address type '%s'", addressType),
+		}
+	}
+	if err != nil {
+		return nil, internalRPCError(err.Error(), "Failed to create the address")
+	}
+
+	result := &btcjson.CreateMultiSigResult{
+		Address:      addr.EncodeAddress(),
+		RedeemScript: hex.EncodeToString(redeemScript),
+	}
+	if c.Claim != nil {
+		if len(pubKeys) > cfg.MaxStdMultiSigKeys {
+			return nil, &btcjson.RPCError{
+				Code: btcjson.ErrRPCInvalidParameter,
+				Message: fmt.Sprintf("A claim of a multisig script "+
+					"with more than %d keys is nonstandard",
+					cfg.MaxStdMultiSigKeys),
+			}
+		}
+		pkScript, err := claimScript(c.Claim.Type, c.Claim.Name,
+			c.Claim.ClaimID, c.Claim.Value, redeemScript)
+		if err != nil {
+			return nil, err
+		}
+		result.ScriptPubKey = hex.EncodeToString(pkScript)
+		return result, nil
+	}
+
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return nil, internalRPCError(err.Error(), "Failed to create the script")
+	}
+	result.ScriptPubKey = hex.EncodeToString(pkScript)
+	result.Descriptor, err = descriptor.AddChecksum(desc)
+	if err != nil {
+		return nil, internalRPCError(err.Error(), "Failed to create the descriptor")
+	}
+	return result, nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"sync/atomic"
+	"time"
+
+	"github.com/lbryio/lbcd/btcjson"
+)
+
+const (
+	// maxProfileSeconds is the longest profile capture allowed.
+	maxProfileSeconds = 600
+
+	// profilesDirname is the directory of the data directory the captured
+	// profiles are written to.
+	profilesDirname = "profiles"
+)
+
+// captureProfiles are the profiles the captureprofile command supports.  The
+// cpu, block and mutex profiles are recorded during the capture, while the
+// others are snapshots taken at its end.
+var captureProfiles = map[string]struct{}{
+	"cpu":          {},
+	"heap":         {},
+	"allocs":       {},
+	"goroutine":    {},
+	"threadcreate": {},
+	"block":        {},
+	"mutex":        {},
+}
+
+// handleCaptureProfile implements the captureprofile command.
+func handleCaptureProfile(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.CaptureProfileCmd)
+
+	if len(c.Profiles) == 0 {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "No profiles to capture",
+		}
+	}
+	profiles := make(map[string]struct{}, len(c.Profiles))
+	for _, name := range c.Profiles {
+		if _, ok := captureProfiles[name]; !ok {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: fmt.Sprintf("Unknown profile %q", name),
+			}
+		}
+		profiles[name] = struct{}{}
+	}
+	seconds := *c.Seconds
+	if seconds < 0 || seconds > maxProfileSeconds {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Seconds must be between 0 and %d",
+				maxProfileSeconds),
+		}
+	}
+
+	if !atomic.CompareAndSwapInt32(&s.capturingProfile, 0, 1) {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "A profile capture is already running",
+		}
+	}
+	defer atomic.StoreInt32(&s.capturingProfile, 0)
+
+	var cpu bytes.Buffer
+	if _, ok := profiles["cpu"]; ok {
+		if err := pprof.StartCPUProfile(&cpu); err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCMisc,
+				Message: "Failed to start CPU profile: " + err.Error(),
+			}
+		}
+		defer pprof.StopCPUProfile()
+	}
+	if _, ok := profiles["block"]; ok {
+		runtime.SetBlockProfileRate(1)
+		defer runtime.SetBlockProfileRate(0)
+	}
+	if _, ok := profiles["mutex"]; ok {
+		defer runtime.SetMutexProfileFraction(
+			runtime.SetMutexProfileFraction(1))
+	}
+
+	rpcsLog.Infof("Capturing %d profiles for %d seconds", len(profiles),
+		seconds)
+	timer := time.NewTimer(time.Duration(seconds) * time.Second)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-closeChan:
+		return nil, ErrClientQuit
+	case <-s.quit:
+		return nil, ErrClientQuit
+	}
+
+	captured := make(map[string][]byte, len(profiles))
+	for name := range profiles {
+		if name == "cpu" {
+			pprof.StopCPUProfile()
+			captured[name] = cpu.Bytes()
+			continue
+		}
+		var buf bytes.Buffer
+		if err := pprof.Lookup(name).WriteTo(&buf, 0); err != nil {
+			context := "Failed to write " + name + " profile"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		captured[name] = buf.Bytes()
+	}
+
+	result := &btcjson.CaptureProfileResult{Seconds: seconds}
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	for _, name := range c.Profiles {
+		data, ok := captured[name]
+		if !ok {
+			continue
+		}
+		delete(captured, name)
+
+		profile := btcjson.CapturedProfile{Name: name, Size: len(data)}
+		if *c.Base64 {
+			profile.Data = base64.StdEncoding.EncodeToString(data)
+			result.Profiles = append(result.Profiles, profile)
+			continue
+		}
+		dir := filepath.Join(cfg.DataDir, profilesDirname)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			context := "Failed to create profiles directory"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		profile.Path = filepath.Join(dir, name+"-"+stamp+".pprof")
+		if err := os.WriteFile(profile.Path, data, 0600); err != nil {
+			context := "Failed to write " + name + " profile"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		result.Profiles = append(result.Profiles, profile)
+	}
+
+	return result, nil
+}
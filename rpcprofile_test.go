@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/base64"
+	"os"
+	"testing"
+
+	"github.com/btcsuite/btclog"
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptureProfile(t *testing.T) {
+
+	r := require.New(t)
+
+	defer func(log btclog.Logger) { rpcsLog = log }(rpcsLog)
+	rpcsLog = btclog.Disabled
+
+	s := &rpcServer{}
+	capture := func(profiles []string, seconds int, encode bool) (*btcjson.CaptureProfileResult, error) {
+		cmd := btcjson.NewCaptureProfileCmd(profiles, &seconds, &encode)
+		result, err := handleCaptureProfile(s, cmd, nil)
+		if err != nil {
+			return nil, err
+		}
+		return result.(*btcjson.CaptureProfileResult), nil
+	}
+
+	_, err := capture(nil, 0, true)
+	r.Error(err)
+	_, err = capture([]string{"heap", "unknown"}, 0, true)
+	r.Error(err)
+	_, err = capture([]string{"heap"}, maxProfileSeconds+1, true)
+	r.Error(err)
+
+	s.capturingProfile = 1
+	_, err = capture([]string{"heap"}, 0, true)
+	r.Error(err)
+	s.capturingProfile = 0
+
+	result, err := capture([]string{"goroutine", "heap", "goroutine"}, 0, true)
+	r.NoError(err)
+	r.Len(result.Profiles, 2)
+	r.Equal("goroutine", result.Profiles[0].Name)
+	r.Equal("heap", result.Profiles[1].Name)
+	data, err := base64.StdEncoding.DecodeString(result.Profiles[0].Data)
+	r.NoError(err)
+	r.Len(data, result.Profiles[0].Size)
+	r.Empty(result.Profiles[0].Path)
+
+	// The profiles are written to the data directory unless encoded.
+	prevCfg := cfg
+	defer func() { cfg = prevCfg }()
+	cfg = &config{DataDir: t.TempDir()}
+	result, err = capture([]string{"cpu", "mutex"}, 1, false)
+	r.NoError(err)
+	r.Len(result.Profiles, 2)
+	for _, profile := range result.Profiles {
+		r.Empty(profile.Data)
+		fi, err := os.Stat(profile.Path)
+		r.NoError(err)
+		r.EqualValues(profile.Size, fi.Size())
+	}
+}
@@ -0,0 +1,748 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/claimtrie/change"
+	"github.com/lbryio/lbcd/database"
+	"github.com/lbryio/lbcd/mempool"
+	"github.com/lbryio/lbcd/psbt"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/txscript/claimscript"
+	"github.com/lbryio/lbcd/txscript/txsign"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+var psbtHandlers = map[string]commandHandler{
+	"analyzepsbt":    handleAnalyzePsbt,
+	"converttopsbt":  handleConvertToPsbt,
+	"createpsbt":     handleCreatePsbt,
+	"decodepsbt":     handleDecodePsbt,
+	"finalizepsbt":   handleFinalizePsbt,
+	"joinpsbts":      handleJoinPsbts,
+	"utxoupdatepsbt": handleUtxoUpdatePsbt,
+}
+
+// The roles of BIP 174 in the order they process a PSBT.  analyzepsbt reports
+// the role of the next step for each input, and for the PSBT overall.
+const (
+	psbtRoleCreator = iota
+	psbtRoleUpdater
+	psbtRoleSigner
+	psbtRoleFinalizer
+	psbtRoleExtractor
+)
+
+var psbtRoles = []string{"creator", "updater", "signer", "finalizer", "extractor"}
+
+func handleDecodePsbt(s *rpcServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.DecodePsbtCmd)
+	p, err := decodePsbt(c.Psbt)
+	if err != nil {
+		return nil, err
+	}
+
+	params := s.cfg.ChainParams
+	tx := p.UnsignedTx
+	result := btcjson.DecodePsbtResult{
+		Tx: btcjson.TxRawDecodeResult{
+			Txid:     tx.TxHash().String(),
+			Version:  tx.Version,
+			Locktime: tx.LockTime,
+			Vin:      createVinList(tx),
+			Vout:     createVoutList(tx, params, nil),
+		},
+		Unknown: make(map[string]string),
+		Inputs:  make([]btcjson.DecodePsbtInput, len(p.Inputs)),
+		Outputs: make([]btcjson.DecodePsbtOutput, len(p.Outputs)),
+	}
+	for _, u := range p.Unknowns {
+		result.Unknown[hex.EncodeToString(u.Key)] = hex.EncodeToString(u.Value)
+	}
+
+	for i := range p.Inputs {
+		pInput := &p.Inputs[i]
+		in := &result.Inputs[i]
+
+		if pInput.NonWitnessUtxo != nil {
+			prevTx := pInput.NonWitnessUtxo
+			in.NonWitnessUtxo = &btcjson.TxRawDecodeResult{
+				Txid:     prevTx.TxHash().String(),
+				Version:  prevTx.Version,
+				Locktime: prevTx.LockTime,
+				Vin:      createVinList(prevTx),
+				Vout:     createVoutList(prevTx, params, nil),
+			}
+		}
+		if pInput.WitnessUtxo != nil {
+			vout := createVoutList(&wire.MsgTx{
+				TxOut: []*wire.TxOut{pInput.WitnessUtxo},
+			}, params, nil)[0]
+			in.WitnessUtxo = &btcjson.PsbtUtxo{
+				Amount:       vout.Value,
+				ScriptPubKey: vout.ScriptPubKey,
+			}
+		}
+		if len(pInput.PartialSigs) > 0 {
+			in.PartialSignatures = make(map[string]string)
+			for _, ps := range pInput.PartialSigs {
+				in.PartialSignatures[hex.EncodeToString(ps.PubKey)] =
+					hex.EncodeToString(ps.Signature)
+			}
+		}
+		if pInput.SighashType != 0 {
+			in.Sighash = txsign.SigHashTypeName(pInput.SighashType)
+		}
+		in.RedeemScript = createPsbtScript(pInput.RedeemScript)
+		in.WitnessScript = createPsbtScript(pInput.WitnessScript)
+		in.Bip32Derivs = createPsbtBip32Derivs(pInput.Bip32Derivation)
+		if pInput.FinalScriptSig != nil {
+			disbuf, _ := txscript.DisasmString(pInput.FinalScriptSig)
+			in.FinalScriptSig = &btcjson.ScriptSig{
+				Asm: disbuf,
+				Hex: hex.EncodeToString(pInput.FinalScriptSig),
+			}
+		}
+		if pInput.FinalScriptWitness != nil {
+			witness, err := readPsbtWitness(pInput.FinalScriptWitness)
+			if err != nil {
+				return nil, &btcjson.RPCError{
+					Code:    btcjson.ErrRPCDeserialization,
+					Message: "PSBT decode failed: " + err.Error(),
+				}
+			}
+			in.FinalScriptWitness = witnessToHex(witness)
+		}
+		in.Claim = createPsbtClaim(psbt.InputClaim(pInput))
+		in.Unknown = createPsbtUnknown(pInput.Unknowns)
+	}
+
+	for i := range p.Outputs {
+		pOutput := &p.Outputs[i]
+		out := &result.Outputs[i]
+
+		out.RedeemScript = createPsbtScript(pOutput.RedeemScript)
+		out.WitnessScript = createPsbtScript(pOutput.WitnessScript)
+		out.Bip32Derivs = createPsbtBip32Derivs(pOutput.Bip32Derivation)
+		out.Claim = createPsbtClaim(psbt.OutputClaim(p, i))
+	}
+
+	if fee, err := psbtFee(p); err == nil {
+		feeBTC := btcutil.Amount(fee).ToBTC()
+		result.Fee = &feeBTC
+	}
+
+	return result, nil
+}
+
+func handleAnalyzePsbt(s *rpcServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.AnalyzePsbtCmd)
+	p, err := decodePsbt(c.Psbt)
+	if err != nil {
+		return nil, err
+	}
+
+	// The overall next role is the earliest one of any input.
+	next := psbtRoleExtractor
+	result := btcjson.AnalyzePsbtResult{
+		Inputs: make([]btcjson.AnalyzePsbtInput, len(p.Inputs)),
+	}
+	for i := range p.Inputs {
+		in := &result.Inputs[i]
+		role := analyzePsbtInput(s.cfg.ChainParams, p, i, in)
+		in.Next = psbtRoles[role]
+		if role < next {
+			next = role
+		}
+	}
+	if len(p.Inputs) == 0 {
+		next = psbtRoleCreator
+	}
+	result.Next = psbtRoles[next]
+
+	fee, err := psbtFee(p)
+	if err != nil {
+		return result, nil
+	}
+	if fee < 0 {
+		result.Error = "PSBT is not valid. Output amount larger than " +
+			"input amount"
+		result.Next = psbtRoles[psbtRoleCreator]
+		return result, nil
+	}
+	feeBTC := btcutil.Amount(fee).ToBTC()
+	result.Fee = &feeBTC
+
+	// Estimate the size once every input can be finalized, working on a
+	// copy since the partial signatures are dropped by the finalizer.
+	if next < psbtRoleFinalizer {
+		return result, nil
+	}
+	final, err := copyPsbt(p)
+	if err != nil {
+		return nil, internalRPCError(err.Error(), "Failed to copy PSBT")
+	}
+	if err := psbt.MaybeFinalizeAll(final); err != nil {
+		return result, nil
+	}
+	tx, err := psbt.Extract(final)
+	if err != nil {
+		return result, nil
+	}
+	vsize := mempool.GetTxVirtualSize(btcutil.NewTx(tx))
+	feeRate := btcutil.Amount(fee * 1000 / vsize).ToBTC()
+	result.EstimatedVSize = &vsize
+	result.EstimatedFeeRate = &feeRate
+
+	return result, nil
+}
+
+func handleUtxoUpdatePsbt(s *rpcServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.UtxoUpdatePsbtCmd)
+	p, err := decodePsbt(c.Psbt)
+	if err != nil {
+		return nil, err
+	}
+	u, err := psbt.NewUpdater(p)
+	if err != nil {
+		return nil, internalRPCError(err.Error(), "Failed to update PSBT")
+	}
+
+	for i, txIn := range p.UnsignedTx.TxIn {
+		if psbt.InputUtxo(p, i) == nil {
+			err := updatePsbtInputUtxo(s, u, i, &txIn.PreviousOutPoint)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		utxo := psbt.InputUtxo(p, i)
+		if utxo == nil || !claimscript.IsClaimScript(utxo.PkScript) {
+			continue
+		}
+		if err := psbt.AddInClaim(u, i); err != nil {
+			context := "Failed to add claim to PSBT input"
+			return nil, internalRPCError(err.Error(), context)
+		}
+	}
+
+	b64, err := psbt.B64Encode(p)
+	if err != nil {
+		return nil, internalRPCError(err.Error(), "Failed to encode PSBT")
+	}
+	return b64, nil
+}
+
+func handleFinalizePsbt(s *rpcServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.FinalizePsbtCmd)
+	p, err := decodePsbt(c.Psbt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Finalize as many inputs as possible; the rest is reported through
+	// the completeness of the result.
+	for i := range p.Inputs {
+		_, _ = psbt.MaybeFinalize(p, i)
+	}
+
+	result := btcjson.FinalizePsbtResult{
+		Complete: p.IsComplete(),
+	}
+	if result.Complete && (c.Extract == nil || *c.Extract) {
+		tx, err := psbt.Extract(p)
+		if err != nil {
+			context := "Failed to extract transaction"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		result.Hex, err = messageToHex(tx)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	result.Psbt, err = psbt.B64Encode(p)
+	if err != nil {
+		return nil, internalRPCError(err.Error(), "Failed to encode PSBT")
+	}
+	return result, nil
+}
+
+func handleCreatePsbt(s *rpcServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.CreatePsbtCmd)
+	mtx, err := createRawTransaction(s, c.Inputs, c.Outputs, c.LockTime)
+	if err != nil {
+		return nil, err
+	}
+	p, err := psbt.NewFromUnsignedTx(mtx)
+	if err != nil {
+		return nil, internalRPCError(err.Error(), "Failed to create PSBT")
+	}
+
+	b64, err := psbt.B64Encode(p)
+	if err != nil {
+		return nil, internalRPCError(err.Error(), "Failed to encode PSBT")
+	}
+	return b64, nil
+}
+
+func handleConvertToPsbt(s *rpcServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.ConvertToPsbtCmd)
+	serializedTx, err := hex.DecodeString(c.HexTx)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.HexTx)
+	}
+
+	// The transaction is decoded as a witness one unless it is told not to
+	// be, which only matters to transactions without inputs.
+	var mtx wire.MsgTx
+	if c.IsWitness != nil && !*c.IsWitness {
+		err = mtx.DeserializeNoWitness(bytes.NewReader(serializedTx))
+	} else {
+		err = mtx.Deserialize(bytes.NewReader(serializedTx))
+	}
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCDeserialization,
+			Message: "TX decode failed: " + err.Error(),
+		}
+	}
+
+	for _, txIn := range mtx.TxIn {
+		if len(txIn.SignatureScript) == 0 && len(txIn.Witness) == 0 {
+			continue
+		}
+		if !*c.PermitSigData {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCDeserialization,
+				Message: "Inputs must not have scriptSigs and scriptWitnesses",
+			}
+		}
+		txIn.SignatureScript = nil
+		txIn.Witness = nil
+	}
+	p, err := psbt.NewFromUnsignedTx(&mtx)
+	if err != nil {
+		return nil, internalRPCError(err.Error(), "Failed to create PSBT")
+	}
+
+	b64, err := psbt.B64Encode(p)
+	if err != nil {
+		return nil, internalRPCError(err.Error(), "Failed to encode PSBT")
+	}
+	return b64, nil
+}
+
+func handleJoinPsbts(s *rpcServer, cmd interface{}, _ <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.JoinPsbtsCmd)
+	if len(c.Psbts) < 2 {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "At least two PSBTs are required to join PSBTs.",
+		}
+	}
+
+	// The joined transaction has the highest version and the lowest
+	// locktime of the PSBTs.
+	packets := make([]*psbt.Packet, 0, len(c.Psbts))
+	version := int32(psbt.MinTxVersion)
+	lockTime := uint32(wire.MaxTxInSequenceNum)
+	for _, b64 := range c.Psbts {
+		p, err := decodePsbt(b64)
+		if err != nil {
+			return nil, err
+		}
+		if p.UnsignedTx.Version > version {
+			version = p.UnsignedTx.Version
+		}
+		if p.UnsignedTx.LockTime < lockTime {
+			lockTime = p.UnsignedTx.LockTime
+		}
+		packets = append(packets, p)
+	}
+
+	joined, err := joinPsbts(packets, version, lockTime)
+	if err != nil {
+		return nil, err
+	}
+
+	// The inputs and outputs are shuffled so that those of the PSBTs can't
+	// be told apart by their order.
+	tx := joined.UnsignedTx
+	rand.Shuffle(len(tx.TxIn), func(i, j int) {
+		tx.TxIn[i], tx.TxIn[j] = tx.TxIn[j], tx.TxIn[i]
+		joined.Inputs[i], joined.Inputs[j] = joined.Inputs[j], joined.Inputs[i]
+	})
+	rand.Shuffle(len(tx.TxOut), func(i, j int) {
+		tx.TxOut[i], tx.TxOut[j] = tx.TxOut[j], tx.TxOut[i]
+		joined.Outputs[i], joined.Outputs[j] = joined.Outputs[j], joined.Outputs[i]
+	})
+
+	b64, err := psbt.B64Encode(joined)
+	if err != nil {
+		return nil, internalRPCError(err.Error(), "Failed to encode PSBT")
+	}
+	return b64, nil
+}
+
+// joinPsbts returns the PSBT of the transaction of the version and locktime
+// which has the inputs and the outputs of the PSBTs, in order, along with
+// their unknown global fields.  The PSBTs may not spend the same outputs.
+func joinPsbts(packets []*psbt.Packet, version int32, lockTime uint32) (*psbt.Packet, error) {
+	tx := wire.NewMsgTx(version)
+	tx.LockTime = lockTime
+	joined := &psbt.Packet{UnsignedTx: tx}
+	spent := make(map[wire.OutPoint]struct{})
+	unknowns := make(map[string]struct{})
+	for _, p := range packets {
+		for i, txIn := range p.UnsignedTx.TxIn {
+			if _, ok := spent[txIn.PreviousOutPoint]; ok {
+				return nil, &btcjson.RPCError{
+					Code: btcjson.ErrRPCInvalidParameter,
+					Message: fmt.Sprintf("Input %v exists in "+
+						"multiple PSBTs", txIn.PreviousOutPoint),
+				}
+			}
+			spent[txIn.PreviousOutPoint] = struct{}{}
+			tx.AddTxIn(txIn)
+			joined.Inputs = append(joined.Inputs, p.Inputs[i])
+		}
+		for i, txOut := range p.UnsignedTx.TxOut {
+			tx.AddTxOut(txOut)
+			joined.Outputs = append(joined.Outputs, p.Outputs[i])
+		}
+		for _, u := range p.Unknowns {
+			if _, ok := unknowns[string(u.Key)]; ok {
+				continue
+			}
+			unknowns[string(u.Key)] = struct{}{}
+			joined.Unknowns = append(joined.Unknowns, u)
+		}
+	}
+
+	if err := joined.SanityCheck(); err != nil {
+		return nil, internalRPCError(err.Error(), "Failed to join PSBTs")
+	}
+	return joined, nil
+}
+
+// decodePsbt decodes a base64 encoded PSBT.
+func decodePsbt(b64 string) (*psbt.Packet, error) {
+	p, err := psbt.NewFromRawBytes(strings.NewReader(b64), true)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCDeserialization,
+			Message: "PSBT decode failed: " + err.Error(),
+		}
+	}
+	return p, nil
+}
+
+// copyPsbt returns a deep copy of the PSBT.
+func copyPsbt(p *psbt.Packet) (*psbt.Packet, error) {
+	var buf bytes.Buffer
+	if err := psbt.Serialize(p, &buf); err != nil {
+		return nil, err
+	}
+	return psbt.NewFromRawBytes(&buf, false)
+}
+
+// psbtFee returns the fee paid by the PSBT, which requires the UTXOs of all of
+// its inputs.
+func psbtFee(p *psbt.Packet) (int64, error) {
+	fee, err := psbt.SumUtxoInputValues(p)
+	if err != nil {
+		return 0, err
+	}
+	for _, txOut := range p.UnsignedTx.TxOut {
+		fee -= txOut.Value
+	}
+	return fee, nil
+}
+
+// analyzePsbtInput fills in the analysis of the input at inIndex, and returns
+// the index in psbtRoles of the next role to process it.
+func analyzePsbtInput(params *chaincfg.Params, p *psbt.Packet, inIndex int,
+	in *btcjson.AnalyzePsbtInput) int {
+
+	pInput := &p.Inputs[inIndex]
+	utxo := psbt.InputUtxo(p, inIndex)
+	in.HasUtxo = utxo != nil
+	if utxo == nil {
+		return psbtRoleUpdater
+	}
+
+	in.IsFinal = pInput.FinalScriptSig != nil || pInput.FinalScriptWitness != nil
+	if in.IsFinal {
+		return psbtRoleExtractor
+	}
+
+	final, err := copyPsbt(p)
+	if err == nil {
+		if ok, _ := psbt.MaybeFinalize(final, inIndex); ok {
+			return psbtRoleFinalizer
+		}
+	}
+
+	in.Missing = psbtMissing(params, pInput, utxo.PkScript)
+	if in.Missing != nil &&
+		(in.Missing.RedeemScript != "" || in.Missing.WitnessScript != "") {
+		return psbtRoleUpdater
+	}
+	return psbtRoleSigner
+}
+
+// psbtMissing returns what the input spending pkScript lacks to be finalized.
+// Scripts are identified by their hash as found in the spent script, and keys
+// by the hash160 of the public key.  Nil is returned if nothing is known to be
+// missing.
+func psbtMissing(params *chaincfg.Params, pInput *psbt.PInput,
+	pkScript []byte) *btcjson.AnalyzePsbtMissing {
+
+	var missing btcjson.AnalyzePsbtMissing
+
+	// Claim scripts are never evaluated as pay-to-script-hash or witness
+	// scripts.  See txscript.SignTxOutput.
+	script := txscript.StripClaimScriptPrefix(pkScript)
+	isClaim := len(script) != len(pkScript)
+
+	class := txscript.GetScriptClass(script)
+	if class == txscript.ScriptHashTy {
+		if pInput.RedeemScript == nil {
+			missing.RedeemScript = hex.EncodeToString(script[2:22])
+			return &missing
+		}
+		if isClaim {
+			return nil
+		}
+		script = pInput.RedeemScript
+		class = txscript.GetScriptClass(script)
+	}
+	if class == txscript.WitnessV0ScriptHashTy && !isClaim {
+		if pInput.WitnessScript == nil {
+			missing.WitnessScript = hex.EncodeToString(script[2:34])
+			return &missing
+		}
+		script = pInput.WitnessScript
+	}
+
+	_, addrs, reqSigs, err := txscript.ExtractPkScriptAddrs(script, params)
+	if err != nil {
+		return nil
+	}
+
+	signed := make(map[string]struct{})
+	for _, ps := range pInput.PartialSigs {
+		signed[string(btcutil.Hash160(ps.PubKey))] = struct{}{}
+	}
+
+	var unsigned []string
+	for _, addr := range addrs {
+		var keyID []byte
+		switch addr := addr.(type) {
+		case *btcutil.AddressPubKey:
+			keyID = btcutil.Hash160(addr.ScriptAddress())
+		case *btcutil.AddressPubKeyHash, *btcutil.AddressWitnessPubKeyHash:
+			keyID = addr.ScriptAddress()
+		default:
+			continue
+		}
+		if _, ok := signed[string(keyID)]; ok {
+			reqSigs--
+			continue
+		}
+		unsigned = append(unsigned, hex.EncodeToString(keyID))
+	}
+	if reqSigs <= 0 || len(unsigned) == 0 {
+		return nil
+	}
+	missing.Signatures = unsigned
+	return &missing
+}
+
+// updatePsbtInputUtxo adds the output spent by the input at inIndex to the
+// PSBT, if it's found in the memory pool or the UTXO set.  Non-witness inputs
+// need the whole previous transaction, which is only available from the
+// memory pool or the transaction index.  As claim scripts are never evaluated
+// as witness programs, their signatures don't depend on the input amount, and
+// the output alone is added when the previous transaction isn't available.
+func updatePsbtInputUtxo(s *rpcServer, u *psbt.Updater, inIndex int,
+	op *wire.OutPoint) error {
+
+	var txOut *wire.TxOut
+	prevTx := fetchPsbtPrevTx(s, &op.Hash, true)
+	if prevTx != nil && op.Index < uint32(len(prevTx.TxOut)) {
+		txOut = prevTx.TxOut[op.Index]
+	} else {
+		entry, err := s.cfg.Chain.FetchUtxoEntry(*op)
+		if err != nil {
+			context := "Failed to fetch UTXO"
+			return internalRPCError(err.Error(), context)
+		}
+		if entry == nil || entry.IsSpent() {
+			return nil
+		}
+		txOut = wire.NewTxOut(entry.Amount(), entry.PkScript())
+		prevTx = fetchPsbtPrevTx(s, &op.Hash, false)
+	}
+
+	var err error
+	switch {
+	case txscript.IsWitnessProgram(txOut.PkScript):
+		err = u.AddInWitnessUtxo(txOut, inIndex)
+	case prevTx != nil:
+		err = u.AddInNonWitnessUtxo(prevTx, inIndex)
+	case claimscript.IsClaimScript(txOut.PkScript):
+		err = u.AddInWitnessUtxo(txOut, inIndex)
+	}
+	if err != nil {
+		context := "Failed to add UTXO to PSBT input"
+		return internalRPCError(err.Error(), context)
+	}
+	return nil
+}
+
+// fetchPsbtPrevTx returns the transaction with the given hash from the memory
+// pool, or from the transaction index if it is enabled and fromMempool is
+// false.
+// Nil is returned if the transaction isn't found.
+func fetchPsbtPrevTx(s *rpcServer, txHash *chainhash.Hash, fromMempool bool) *wire.MsgTx {
+	if fromMempool {
+		tx, err := s.cfg.TxMemPool.FetchTransaction(txHash)
+		if err != nil {
+			return nil
+		}
+		return tx.MsgTx()
+	}
+
+	if s.cfg.TxIndex == nil {
+		return nil
+	}
+	blockRegion, err := s.cfg.TxIndex.TxBlockRegion(txHash)
+	if err != nil || blockRegion == nil {
+		return nil
+	}
+
+	var txBytes []byte
+	err = s.cfg.DB.View(func(dbTx database.Tx) error {
+		var err error
+		txBytes, err = dbTx.FetchBlockRegion(blockRegion)
+		return err
+	})
+	if err != nil {
+		return nil
+	}
+
+	var msgTx wire.MsgTx
+	if err := msgTx.Deserialize(bytes.NewReader(txBytes)); err != nil {
+		return nil
+	}
+	return &msgTx
+}
+
+// createPsbtScript returns the JSON object of a redeem or witness script, or
+// nil if there is none.
+func createPsbtScript(script []byte) *btcjson.PsbtScript {
+	if script == nil {
+		return nil
+	}
+
+	// The disassembled string will contain [error] inline if the script
+	// doesn't fully parse, so ignore the error here.
+	disbuf, _ := txscript.DisasmString(script)
+	return &btcjson.PsbtScript{
+		Asm:  disbuf,
+		Hex:  hex.EncodeToString(script),
+		Type: txscript.GetScriptClass(script).String(),
+	}
+}
+
+// createPsbtBip32Derivs returns the JSON objects of BIP 32 derivation paths.
+func createPsbtBip32Derivs(derivs []*psbt.Bip32Derivation) []btcjson.PsbtBip32Deriv {
+	if len(derivs) == 0 {
+		return nil
+	}
+
+	result := make([]btcjson.PsbtBip32Deriv, 0, len(derivs))
+	for _, d := range derivs {
+		path := "m"
+		for _, index := range d.Bip32Path {
+			if index >= 0x80000000 {
+				path += fmt.Sprintf("/%d'", index-0x80000000)
+			} else {
+				path += fmt.Sprintf("/%d", index)
+			}
+		}
+		result = append(result, btcjson.PsbtBip32Deriv{
+			PubKey:            hex.EncodeToString(d.PubKey),
+			MasterFingerprint: fmt.Sprintf("%08x", d.MasterKeyFingerprint),
+			Path:              path,
+		})
+	}
+	return result
+}
+
+// createPsbtClaim returns the JSON object of the claim information of an input
+// or output, or nil if there is none.
+func createPsbtClaim(claim *psbt.Claim) *btcjson.PsbtClaim {
+	if claim == nil {
+		return nil
+	}
+
+	result := &btcjson.PsbtClaim{Name: string(claim.Name)}
+	if len(claim.ClaimID) == change.ClaimIDSize {
+		var id change.ClaimID
+		copy(id[:], claim.ClaimID)
+		result.ClaimID = id.String()
+	}
+	return result
+}
+
+// createPsbtUnknown returns the hex encoded unknown fields of an input or
+// output, excluding the claim fields.
+func createPsbtUnknown(unknowns []*psbt.Unknown) map[string]string {
+	var result map[string]string
+	for _, u := range unknowns {
+		prefix, _, _, err := psbt.ParseProprietaryKey(u.Key)
+		if err == nil && string(prefix) == psbt.ClaimPrefix {
+			continue
+		}
+		if result == nil {
+			result = make(map[string]string)
+		}
+		result[hex.EncodeToString(u.Key)] = hex.EncodeToString(u.Value)
+	}
+	return result
+}
+
+// readPsbtWitness parses a serialized final witness of a PSBT input.
+func readPsbtWitness(serialized []byte) (wire.TxWitness, error) {
+	r := bytes.NewReader(serialized)
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, err
+	}
+	if count > uint64(len(serialized)) {
+		return nil, fmt.Errorf("witness item count %d exceeds its size",
+			count)
+	}
+
+	witness := make(wire.TxWitness, count)
+	for i := range witness {
+		witness[i], err = wire.ReadVarBytes(r, 0, txscript.MaxScriptSize,
+			"witness")
+		if err != nil {
+			return nil, err
+		}
+	}
+	return witness, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/psbt"
+	"github.com/lbryio/lbcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAndJoinPsbts(t *testing.T) {
+
+	r := require.New(t)
+	s := &rpcServer{cfg: rpcserverConfig{ChainParams: &chaincfg.RegressionNetParams}}
+	create := func(txid string, lockTime int64) *psbt.Packet {
+		cmd := btcjson.NewCreatePsbtCmd(
+			[]btcjson.TransactionInput{{Txid: txid, Vout: 1}},
+			map[string]interface{}{"mvSvTtvD9H9fkgi8MGDyLALgRaR2LhnWFM": 0.5},
+			&lockTime)
+		b64, err := handleCreatePsbt(s, cmd, nil)
+		r.NoError(err)
+		p, err := psbt.NewFromRawBytes(bytes.NewReader([]byte(b64.(string))), true)
+		r.NoError(err)
+		return p
+	}
+	txid1 := chainhash.HashH([]byte{1}).String()
+	txid2 := chainhash.HashH([]byte{2}).String()
+	p1 := create(txid1, 10)
+	p2 := create(txid2, 0)
+	r.Len(p1.UnsignedTx.TxIn, 1)
+	r.Equal(uint32(10), p1.UnsignedTx.LockTime)
+	r.Equal(uint32(wire.MaxTxInSequenceNum-1), p1.UnsignedTx.TxIn[0].Sequence)
+
+	// The joined PSBT has the inputs and outputs of both, the highest
+	// version and the lowest locktime.
+	p2.UnsignedTx.Version = 2
+	p2.Unknowns = []psbt.Unknown{{Key: []byte{0xf0}, Value: []byte{1}}}
+	b64, err := psbt.B64Encode(p2)
+	r.NoError(err)
+	p1b64, err := psbt.B64Encode(p1)
+	r.NoError(err)
+	result, err := handleJoinPsbts(s, btcjson.NewJoinPsbtsCmd([]string{p1b64, b64}), nil)
+	r.NoError(err)
+	joined, err := decodePsbt(result.(string))
+	r.NoError(err)
+	r.Len(joined.UnsignedTx.TxIn, 2)
+	r.Len(joined.Inputs, 2)
+	r.Len(joined.UnsignedTx.TxOut, 2)
+	r.Equal(int32(2), joined.UnsignedTx.Version)
+	r.Equal(uint32(0), joined.UnsignedTx.LockTime)
+	r.Len(joined.Unknowns, 1)
+
+	_, err = handleJoinPsbts(s, btcjson.NewJoinPsbtsCmd([]string{p1b64}), nil)
+	r.Error(err)
+	_, err = handleJoinPsbts(s, btcjson.NewJoinPsbtsCmd([]string{p1b64, p1b64}), nil)
+	r.Error(err)
+}
+
+func TestConvertToPsbt(t *testing.T) {
+
+	r := require.New(t)
+	s := &rpcServer{}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), []byte{0x51}, nil))
+	tx.AddTxOut(wire.NewTxOut(1000, []byte{0x51}))
+	hexTx, err := messageToHex(tx)
+	r.NoError(err)
+
+	// The signature scripts are only dropped when permitted.
+	_, err = handleConvertToPsbt(s, btcjson.NewConvertToPsbtCmd(hexTx, btcjson.Bool(false), nil), nil)
+	r.Error(err)
+	result, err := handleConvertToPsbt(s, btcjson.NewConvertToPsbtCmd(hexTx, btcjson.Bool(true), nil), nil)
+	r.NoError(err)
+	p, err := decodePsbt(result.(string))
+	r.NoError(err)
+	r.Empty(p.UnsignedTx.TxIn[0].SignatureScript)
+	r.Equal(tx.TxOut, p.UnsignedTx.TxOut)
+
+	_, err = handleConvertToPsbt(s, btcjson.NewConvertToPsbtCmd("zz", btcjson.Bool(true), nil), nil)
+	r.Error(err)
+}
@@ -0,0 +1,496 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/mining"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+const (
+	// restPrefix is the path under which the REST interface is served.
+	restPrefix = "/rest/"
+
+	// restMaxHeaders is the maximum number of headers returned by a
+	// single headers request.
+	restMaxHeaders = 2000
+
+	// restMaxOutpoints is the maximum number of outpoints queried by a
+	// single getutxos request.
+	restMaxOutpoints = 15
+)
+
+// restFormat is the encoding of a REST response, selected by the extension of
+// the request path.
+type restFormat int
+
+const (
+	restBinary restFormat = iota
+	restHex
+	restJSON
+)
+
+var restFormats = map[string]restFormat{
+	"bin":  restBinary,
+	"hex":  restHex,
+	"json": restJSON,
+}
+
+// restError is an error carrying the HTTP status to respond with.
+type restError struct {
+	status  int
+	message string
+}
+
+func (e *restError) Error() string {
+	return e.message
+}
+
+func restErrorf(status int, format string, args ...interface{}) *restError {
+	return &restError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+// restHandler handles a REST request.  The path is the remainder of the
+// request path after the endpoint, without the format extension.
+type restHandler func(s *rpcServer, path string, format restFormat) (interface{}, error)
+
+// restHandlers maps each endpoint to its handler and the formats it supports.
+var restHandlers = map[string]struct {
+	handler restHandler
+	formats []restFormat
+}{
+	"tx":             {restTx, []restFormat{restBinary, restHex, restJSON}},
+	"block":          {restBlock, []restFormat{restBinary, restHex, restJSON}},
+	"headers":        {restHeaders, []restFormat{restBinary, restHex, restJSON}},
+	"chaininfo":      {restChainInfo, []restFormat{restJSON}},
+	"mempool":        {restMempool, []restFormat{restJSON}},
+	"getutxos":       {restGetUtxos, []restFormat{restBinary, restHex, restJSON}},
+	"claim":          {restClaim, []restFormat{restJSON}},
+	"claimtrieproof": {restClaimTrieProof, []restFormat{restJSON}},
+}
+
+// splitRESTPath splits a request path such as /rest/block/<hash>.json into
+// its endpoint, the remainder of the path and the format.
+func splitRESTPath(path string) (string, string, restFormat, error) {
+	path = strings.TrimPrefix(path, restPrefix)
+
+	i := strings.LastIndexByte(path, '.')
+	if i < 0 {
+		return "", "", 0, restErrorf(http.StatusBadRequest,
+			"output format not found (available: bin, hex, json)")
+	}
+	format, ok := restFormats[path[i+1:]]
+	if !ok {
+		return "", "", 0, restErrorf(http.StatusBadRequest,
+			"unknown output format %q (available: bin, hex, json)", path[i+1:])
+	}
+	path = path[:i]
+
+	endpoint, rest := path, ""
+	if j := strings.IndexByte(path, '/'); j >= 0 {
+		endpoint, rest = path[:j], path[j+1:]
+	}
+	return endpoint, rest, format, nil
+}
+
+// restRead serves a REST request.
+func (s *rpcServer) restRead(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "405 Method not allowed.", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var result interface{}
+	endpoint, path, format, err := splitRESTPath(r.URL.EscapedPath())
+	if err == nil {
+		h, ok := restHandlers[endpoint]
+		if !ok {
+			err = restErrorf(http.StatusNotFound, "unknown endpoint %q", endpoint)
+		} else if !restSupports(h.formats, format) {
+			err = restErrorf(http.StatusNotFound, "output format not supported by %s", endpoint)
+		} else {
+			result, err = h.handler(s, path, format)
+		}
+	}
+	if err != nil {
+		status, message := restStatus(err)
+		http.Error(w, message, status)
+		return
+	}
+
+	switch format {
+	case restBinary:
+		w.Header().Set("Content-Type", "application/octet-stream")
+	case restHex:
+		w.Header().Set("Content-Type", "text/plain")
+	case restJSON:
+		w.Header().Set("Content-Type", "application/json")
+	}
+	encoding := acceptedEncoding(r.Header.Get("Accept-Encoding"))
+	if encoding != "" {
+		w.Header().Set("Content-Encoding", encoding)
+	}
+	out := compressWriter(w, encoding)
+	switch format {
+	case restBinary:
+		_, err = out.Write(result.([]byte))
+	case restHex:
+		err = writeString(out, hex.EncodeToString(result.([]byte))+"\n")
+	case restJSON:
+		err = btcjson.EncodeStream(out, result)
+		if err == nil {
+			err = writeString(out, "\n")
+		}
+	}
+	if err == nil {
+		err = out.Close()
+	}
+	if err != nil {
+		rpcsLog.Errorf("Failed to write REST reply: %v", err)
+	}
+}
+
+func restSupports(formats []restFormat, format restFormat) bool {
+	for _, f := range formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// restStatus returns the HTTP status and message to respond with for an error
+// returned by a REST or RPC handler.
+func restStatus(err error) (int, string) {
+	switch e := err.(type) {
+	case *restError:
+		return e.status, e.message
+	case *btcjson.RPCError:
+		switch e.Code {
+		case btcjson.ErrRPCBlockNotFound:
+			return http.StatusNotFound, e.Message
+		case btcjson.ErrRPCDecodeHexString, btcjson.ErrRPCInvalidParameter:
+			return http.StatusBadRequest, e.Message
+		case btcjson.ErrRPCClientInInitialDownload:
+			return http.StatusServiceUnavailable, e.Message
+		}
+		return http.StatusInternalServerError, e.Message
+	}
+	return http.StatusInternalServerError, err.Error()
+}
+
+// restBytes converts the hex encoded result of a non-verbose RPC handler to
+// the raw bytes expected by the binary and hex formats.
+func restBytes(result interface{}) (interface{}, error) {
+	b, err := hex.DecodeString(result.(string))
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// restTx handles /rest/tx/<txid>.
+func restTx(s *rpcServer, path string, format restFormat) (interface{}, error) {
+	verbose := format == restJSON
+	result, err := handleGetRawTransaction(s, &btcjson.GetRawTransactionCmd{
+		Txid:    path,
+		Verbose: &verbose,
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if verbose {
+		return result, nil
+	}
+	return restBytes(result)
+}
+
+// restBlock handles /rest/block/<hash> and /rest/block/notxdetails/<hash>.
+func restBlock(s *rpcServer, path string, format restFormat) (interface{}, error) {
+	verbosity := 2
+	if hash := strings.TrimPrefix(path, "notxdetails/"); hash != path {
+		path, verbosity = hash, 1
+	}
+	if format != restJSON {
+		verbosity = 0
+	}
+
+	result, err := handleGetBlock(s, &btcjson.GetBlockCmd{
+		Hash:      path,
+		Verbosity: &verbosity,
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if verbosity > 0 {
+		return result, nil
+	}
+	return restBytes(result)
+}
+
+// restHeaders handles /rest/headers/<count>/<hash>, which returns up to count
+// headers of the main chain starting at the block hash.
+func restHeaders(s *rpcServer, path string, format restFormat) (interface{}, error) {
+	parts := strings.Split(path, "/")
+	if len(parts) != 2 {
+		return nil, restErrorf(http.StatusBadRequest,
+			"invalid URI format, expected /rest/headers/<count>/<hash>")
+	}
+	count, err := strconv.Atoi(parts[0])
+	if err != nil || count < 1 || count > restMaxHeaders {
+		return nil, restErrorf(http.StatusBadRequest,
+			"header count is out of range [1, %d]: %s", restMaxHeaders, parts[0])
+	}
+	hash, err := chainhash.NewHashFromStr(parts[1])
+	if err != nil {
+		return nil, rpcDecodeHexError(parts[1])
+	}
+
+	// Collect the hashes of the headers up to the tip of the main chain.
+	height, err := s.cfg.Chain.BlockHeightByHash(hash)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCBlockNotFound,
+			Message: "Block not found",
+		}
+	}
+	hashes := []chainhash.Hash{*hash}
+	for i := int32(1); i < int32(count); i++ {
+		next, err := s.cfg.Chain.BlockHashByHeight(height + i)
+		if err != nil {
+			break
+		}
+		hashes = append(hashes, *next)
+	}
+
+	if format == restJSON {
+		verbose := true
+		results := make([]interface{}, 0, len(hashes))
+		for i := range hashes {
+			result, err := handleGetBlockHeader(s, &btcjson.GetBlockHeaderCmd{
+				Hash:    hashes[i].String(),
+				Verbose: &verbose,
+			}, nil)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, result)
+		}
+		return results, nil
+	}
+
+	var buf bytes.Buffer
+	for i := range hashes {
+		header, err := s.cfg.Chain.HeaderByHash(&hashes[i])
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCBlockNotFound,
+				Message: "Block not found",
+			}
+		}
+		if err := header.Serialize(&buf); err != nil {
+			context := "Failed to serialize block header"
+			return nil, internalRPCError(err.Error(), context)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// restChainInfo handles /rest/chaininfo.
+func restChainInfo(s *rpcServer, path string, format restFormat) (interface{}, error) {
+	if path != "" {
+		return nil, restErrorf(http.StatusNotFound, "unknown endpoint chaininfo/%s", path)
+	}
+	return handleGetBlockChainInfo(s, &btcjson.GetBlockChainInfoCmd{}, nil)
+}
+
+// restMempool handles /rest/mempool/info and /rest/mempool/contents.
+func restMempool(s *rpcServer, path string, format restFormat) (interface{}, error) {
+	switch path {
+	case "info":
+		return handleGetMempoolInfo(s, &btcjson.GetMempoolInfoCmd{}, nil)
+	case "contents":
+		return s.cfg.TxMemPool.RawMempoolVerbose(), nil
+	}
+	return nil, restErrorf(http.StatusNotFound, "unknown endpoint mempool/%s", path)
+}
+
+// restUtxo is the JSON form of an unspent output returned by getutxos.
+type restUtxo struct {
+	Height       int32                      `json:"height"`
+	Value        float64                    `json:"value"`
+	ScriptPubKey btcjson.ScriptPubKeyResult `json:"scriptPubKey"`
+}
+
+// restGetUtxosResult is the JSON form of a getutxos response.
+type restGetUtxosResult struct {
+	ChainHeight  int32      `json:"chainHeight"`
+	ChainTipHash string     `json:"chaintipHash"`
+	Bitmap       string     `json:"bitmap"`
+	Utxos        []restUtxo `json:"utxos"`
+}
+
+// restGetUtxos handles /rest/getutxos[/checkmempool]/<txid>-<n>/..., which
+// reports which of the outpoints are unspent, and returns those outputs.
+func restGetUtxos(s *rpcServer, path string, format restFormat) (interface{}, error) {
+	parts := strings.Split(path, "/")
+	checkMempool := parts[0] == "checkmempool"
+	if checkMempool {
+		parts = parts[1:]
+	}
+	if len(parts) == 0 || parts[0] == "" {
+		return nil, restErrorf(http.StatusBadRequest, "no outpoints given")
+	}
+	if len(parts) > restMaxOutpoints {
+		return nil, restErrorf(http.StatusBadRequest,
+			"too many outpoints (maximum is %d)", restMaxOutpoints)
+	}
+
+	outpoints := make([]wire.OutPoint, 0, len(parts))
+	for _, part := range parts {
+		i := strings.IndexByte(part, '-')
+		if i < 0 {
+			return nil, restErrorf(http.StatusBadRequest, "invalid outpoint: %s", part)
+		}
+		hash, err := chainhash.NewHashFromStr(part[:i])
+		if err != nil {
+			return nil, restErrorf(http.StatusBadRequest, "invalid outpoint: %s", part)
+		}
+		n, err := strconv.ParseUint(part[i+1:], 10, 32)
+		if err != nil {
+			return nil, restErrorf(http.StatusBadRequest, "invalid outpoint: %s", part)
+		}
+		outpoints = append(outpoints, wire.OutPoint{Hash: *hash, Index: uint32(n)})
+	}
+
+	// Look up each outpoint in the utxo set of the main chain, and, if
+	// requested, apply the outputs created and spent by the mempool.
+	mp := s.cfg.TxMemPool
+	best := s.cfg.Chain.BestSnapshot()
+	bitmap := make([]byte, (len(outpoints)+7)/8)
+	heights := make([]int32, 0, len(outpoints))
+	outs := make([]*wire.TxOut, 0, len(outpoints))
+	for i, op := range outpoints {
+		var out *wire.TxOut
+		var height int32
+		if checkMempool && mp.CheckSpend(op) != nil {
+			continue
+		}
+		if entry, err := s.cfg.Chain.FetchUtxoEntry(op); err == nil &&
+			entry != nil && !entry.IsSpent() {
+
+			out = wire.NewTxOut(entry.Amount(), entry.PkScript())
+			height = entry.BlockHeight()
+		} else if checkMempool {
+			tx, err := mp.FetchTransaction(&op.Hash)
+			if err == nil && op.Index < uint32(len(tx.MsgTx().TxOut)) {
+				out = tx.MsgTx().TxOut[op.Index]
+				height = mining.UnminedHeight
+			}
+		}
+		if out == nil {
+			continue
+		}
+		bitmap[i/8] |= 1 << (i % 8)
+		heights = append(heights, height)
+		outs = append(outs, out)
+	}
+
+	if format == restJSON {
+		result := restGetUtxosResult{
+			ChainHeight:  best.Height,
+			ChainTipHash: best.Hash.String(),
+			Utxos:        make([]restUtxo, 0, len(outs)),
+		}
+		for i := range outpoints {
+			if bitmap[i/8]&(1<<(i%8)) != 0 {
+				result.Bitmap += "1"
+			} else {
+				result.Bitmap += "0"
+			}
+		}
+		for i, out := range outs {
+			vout := createVoutList(&wire.MsgTx{TxOut: []*wire.TxOut{out}},
+				s.cfg.ChainParams, nil)[0]
+			result.Utxos = append(result.Utxos, restUtxo{
+				Height:       heights[i],
+				Value:        btcutil.Amount(out.Value).ToBTC(),
+				ScriptPubKey: vout.ScriptPubKey,
+			})
+		}
+		return result, nil
+	}
+
+	// The binary form follows the reference implementation: the chain
+	// height and tip, the bitmap and the outputs, each preceded by a
+	// dummy transaction version and its height.
+	var buf bytes.Buffer
+	var scratch [4]byte
+	binary.LittleEndian.PutUint32(scratch[:], uint32(best.Height))
+	buf.Write(scratch[:])
+	buf.Write(best.Hash[:])
+	if err := wire.WriteVarBytes(&buf, 0, bitmap); err != nil {
+		return nil, internalRPCError(err.Error(), "Failed to serialize bitmap")
+	}
+	if err := wire.WriteVarInt(&buf, 0, uint64(len(outs))); err != nil {
+		return nil, internalRPCError(err.Error(), "Failed to serialize outputs")
+	}
+	for i, out := range outs {
+		binary.LittleEndian.PutUint32(scratch[:], 0)
+		buf.Write(scratch[:])
+		binary.LittleEndian.PutUint32(scratch[:], uint32(heights[i]))
+		buf.Write(scratch[:])
+		if err := wire.WriteTxOut(&buf, 0, 0, out); err != nil {
+			return nil, internalRPCError(err.Error(), "Failed to serialize output")
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// restClaim handles /rest/claim/<name>, which returns the claims of a name at
+// the tip of the main chain.
+func restClaim(s *rpcServer, path string, format restFormat) (interface{}, error) {
+	name, err := url.PathUnescape(path)
+	if err != nil || name == "" {
+		return nil, restErrorf(http.StatusBadRequest, "invalid name: %s", path)
+	}
+	result, err := handleGetClaimsForName(s, &btcjson.GetClaimsForNameCmd{Name: name}, nil)
+	if e, ok := err.(*btcjson.RPCError); ok && e.Code == btcjson.ErrRPCMisc {
+		return nil, restErrorf(http.StatusNotFound, "%s", e.Message)
+	}
+	return result, err
+}
+
+// restClaimTrieProof handles /rest/claimtrieproof/<name>, which returns the
+// merkle proof of the claims hash of a name against the claimtrie hash of the
+// best block.  Applying the pairs in order to the claims hash yields the
+// claimtrie hash: an odd pair is the left branch, and the others the right.
+func restClaimTrieProof(s *rpcServer, path string, format restFormat) (interface{}, error) {
+	name, err := url.PathUnescape(path)
+	if err != nil || name == "" {
+		return nil, restErrorf(http.StatusBadRequest, "invalid name: %s", path)
+	}
+	if !s.cfg.Chain.IsCurrent() {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCClientInInitialDownload,
+			Message: "Unable to query the chain tip during initial download",
+		}
+	}
+
+	proof, err := s.cfg.Chain.GetClaimTrieProof(name)
+	if err != nil {
+		return nil, restErrorf(http.StatusNotFound, "%v", err)
+	}
+
+	return toNameProofResult(proof), nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitRESTPath(t *testing.T) {
+
+	r := require.New(t)
+
+	endpoint, path, format, err := splitRESTPath("/rest/block/notxdetails/abcd.json")
+	r.NoError(err)
+	r.Equal("block", endpoint)
+	r.Equal("notxdetails/abcd", path)
+	r.Equal(restJSON, format)
+
+	endpoint, path, format, err = splitRESTPath("/rest/chaininfo.bin")
+	r.NoError(err)
+	r.Equal("chaininfo", endpoint)
+	r.Equal("", path)
+	r.Equal(restBinary, format)
+
+	// Names may contain dots, the format is the last extension.
+	endpoint, path, _, err = splitRESTPath("/rest/claim/a.b.json")
+	r.NoError(err)
+	r.Equal("claim", endpoint)
+	r.Equal("a.b", path)
+
+	_, _, _, err = splitRESTPath("/rest/tx/abcd")
+	r.Error(err)
+	_, _, _, err = splitRESTPath("/rest/tx/abcd.xml")
+	r.Error(err)
+}
+
+func TestRESTStatus(t *testing.T) {
+
+	r := require.New(t)
+
+	status, _ := restStatus(restErrorf(http.StatusBadRequest, "bad"))
+	r.Equal(http.StatusBadRequest, status)
+
+	status, msg := restStatus(&btcjson.RPCError{Code: btcjson.ErrRPCBlockNotFound, Message: "missing"})
+	r.Equal(http.StatusNotFound, status)
+	r.Equal("missing", msg)
+
+	status, _ = restStatus(rpcDecodeHexError("zz"))
+	r.Equal(http.StatusBadRequest, status)
+
+	status, _ = restStatus(&btcjson.RPCError{Code: btcjson.ErrRPCClientInInitialDownload})
+	r.Equal(http.StatusServiceUnavailable, status)
+}
+
+func TestAcceptedEncoding(t *testing.T) {
+
+	r := require.New(t)
+
+	r.Equal("", acceptedEncoding(""))
+	r.Equal("", acceptedEncoding("identity, br"))
+	r.Equal("gzip", acceptedEncoding("deflate, gzip"))
+	r.Equal("gzip", acceptedEncoding("GZIP;q=0.5"))
+	r.Equal("deflate", acceptedEncoding("gzip;q=0, deflate"))
+	r.Equal("", acceptedEncoding("gzip; q=0"))
+}
@@ -7,8 +7,8 @@ package main
 
 import (
 	"bytes"
-	"crypto/sha256"
-	"crypto/subtle"
+	"compress/gzip"
+	"compress/zlib"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
@@ -21,6 +21,8 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
@@ -32,20 +34,22 @@ import (
 	"github.com/lbryio/lbcd/addrmgr"
 	"github.com/lbryio/lbcd/blockchain"
 	"github.com/lbryio/lbcd/blockchain/indexers"
-	"github.com/lbryio/lbcd/btcec"
 	"github.com/lbryio/lbcd/btcjson"
 	"github.com/lbryio/lbcd/chaincfg"
 	"github.com/lbryio/lbcd/chaincfg/chainhash"
 	"github.com/lbryio/lbcd/database"
+	"github.com/lbryio/lbcd/database/ffldb"
 	"github.com/lbryio/lbcd/fees"
 	"github.com/lbryio/lbcd/mempool"
 	"github.com/lbryio/lbcd/mining"
 	"github.com/lbryio/lbcd/mining/cpuminer"
 	"github.com/lbryio/lbcd/peer"
+	"github.com/lbryio/lbcd/tracing"
 	"github.com/lbryio/lbcd/txscript"
 	"github.com/lbryio/lbcd/version"
 	"github.com/lbryio/lbcd/wire"
 	btcutil "github.com/lbryio/lbcutil"
+	"github.com/shirou/gopsutil/v3/process"
 )
 
 // API version constants
@@ -79,6 +83,10 @@ const (
 
 	// maxProtocolVersion is the max protocol version the server supports.
 	maxProtocolVersion = 70002
+
+	// maxGetRawTransactionsTxids is the max number of transactions a
+	// getrawtransactions request may look up.
+	maxGetRawTransactionsTxids = 10000
 )
 
 var (
@@ -125,6 +133,13 @@ var (
 		Code:    btcjson.ErrRPCNoWallet,
 		Message: "This implementation does not implement wallet commands",
 	}
+
+	// ErrRPCShuttingDown is an error returned to RPC clients when the
+	// command is received while the server is shutting down.
+	ErrRPCShuttingDown = &btcjson.RPCError{
+		Code:    btcjson.ErrRPCMisc,
+		Message: "Server is shutting down",
+	}
 )
 
 type commandHandler func(*rpcServer, interface{}, <-chan struct{}) (interface{}, error)
@@ -134,65 +149,93 @@ type commandHandler func(*rpcServer, interface{}, <-chan struct{}) (interface{},
 // a dependency loop.
 var rpcHandlers map[string]commandHandler
 var rpcHandlersBeforeInit = map[string]commandHandler{
-	"addnode":                handleAddNode,
-	"clearbanned":            handleClearBanned,
-	"createrawtransaction":   handleCreateRawTransaction,
-	"debuglevel":             handleDebugLevel,
-	"decoderawtransaction":   handleDecodeRawTransaction,
-	"decodescript":           handleDecodeScript,
-	"estimatefee":            handleEstimateFee,
-	"estimatesmartfee":       handleEstimateSmartFee,
-	"generate":               handleGenerate,
-	"generatetoaddress":      handleGenerateToAddress,
-	"getaddednodeinfo":       handleGetAddedNodeInfo,
-	"getbestblock":           handleGetBestBlock,
-	"getbestblockhash":       handleGetBestBlockHash,
-	"getblock":               handleGetBlock,
-	"getblockchaininfo":      handleGetBlockChainInfo,
-	"getblockcount":          handleGetBlockCount,
-	"getblockhash":           handleGetBlockHash,
-	"getblockheader":         handleGetBlockHeader,
-	"getblockstats":          handleGetBlockStats,
-	"getblocktemplate":       handleGetBlockTemplate,
-	"getcfilter":             handleGetCFilter,
-	"getcfilterheader":       handleGetCFilterHeader,
-	"getchaintips":           handleGetChainTips,
-	"getconnectioncount":     handleGetConnectionCount,
-	"getcurrentnet":          handleGetCurrentNet,
-	"getdifficulty":          handleGetDifficulty,
-	"getgenerate":            handleGetGenerate,
-	"gethashespersec":        handleGetHashesPerSec,
-	"getheaders":             handleGetHeaders,
-	"getinfo":                handleGetInfo,
-	"getmempoolentry":        handleGetMempoolEntry,
-	"getmempoolinfo":         handleGetMempoolInfo,
-	"getmininginfo":          handleGetMiningInfo,
-	"getnettotals":           handleGetNetTotals,
-	"getnetworkhashps":       handleGetNetworkHashPS,
-	"getnetworkinfo":         handleGetNetworkInfo,
-	"getnodeaddresses":       handleGetNodeAddresses,
-	"getpeerinfo":            handleGetPeerInfo,
-	"getrawmempool":          handleGetRawMempool,
-	"getrawtransaction":      handleGetRawTransaction,
-	"gettxout":               handleGetTxOut,
-	"help":                   handleHelp,
-	"invalidateblock":        handleInvalidateBlock,
-	"listbanned":             handleListBanned,
-	"node":                   handleNode,
-	"ping":                   handlePing,
-	"reconsiderblock":        handleReconsiderBlock,
-	"searchrawtransactions":  handleSearchRawTransactions,
-	"sendrawtransaction":     handleSendRawTransaction,
-	"setban":                 handleSetBan,
-	"setgenerate":            handleSetGenerate,
-	"signmessagewithprivkey": handleSignMessageWithPrivKey,
-	"stop":                   handleStop,
-	"submitblock":            handleSubmitBlock,
-	"uptime":                 handleUptime,
-	"validateaddress":        handleValidateAddress,
-	"verifychain":            handleVerifyChain,
-	"verifymessage":          handleVerifyMessage,
-	"version":                handleVersion,
+	"addnode":                   handleAddNode,
+	"backupchainstate":          handleBackupChainState,
+	"backupclaimdbs":            handleBackupClaimDBs,
+	"captureprofile":            handleCaptureProfile,
+	"checkdb":                   handleCheckDB,
+	"clearbanned":               handleClearBanned,
+	"createchainsnapshot":       handleCreateChainSnapshot,
+	"createmultisig":            handleCreateMultisig,
+	"createrawtransaction":      handleCreateRawTransaction,
+	"debuglevel":                handleDebugLevel,
+	"decoderawtransaction":      handleDecodeRawTransaction,
+	"decodescript":              handleDecodeScript,
+	"deriveaddresses":           handleDeriveAddresses,
+	"downloadchainsnapshot":     handleDownloadChainSnapshot,
+	"estimatefee":               handleEstimateFee,
+	"estimatesmartfee":          handleEstimateSmartFee,
+	"exportblocks":              handleExportBlocks,
+	"generate":                  handleGenerate,
+	"generatetoaddress":         handleGenerateToAddress,
+	"getaddednodeinfo":          handleGetAddedNodeInfo,
+	"getbestblock":              handleGetBestBlock,
+	"getbestblockhash":          handleGetBestBlockHash,
+	"getblock":                  handleGetBlock,
+	"getblockchaininfo":         handleGetBlockChainInfo,
+	"getblockcount":             handleGetBlockCount,
+	"getblockhash":              handleGetBlockHash,
+	"getblockheader":            handleGetBlockHeader,
+	"getblockstats":             handleGetBlockStats,
+	"getblocktemplate":          handleGetBlockTemplate,
+	"getcfilter":                handleGetCFilter,
+	"getcfilterheader":          handleGetCFilterHeader,
+	"getchainsnapshot":          handleGetChainSnapshot,
+	"getchainsnapshotchunk":     handleGetChainSnapshotChunk,
+	"getchaintips":              handleGetChainTips,
+	"getconnectioncount":        handleGetConnectionCount,
+	"getcurrentnet":             handleGetCurrentNet,
+	"getdbinfo":                 handleGetDBInfo,
+	"getdescriptorinfo":         handleGetDescriptorInfo,
+	"getdifficulty":             handleGetDifficulty,
+	"getgenerate":               handleGetGenerate,
+	"gethashespersec":           handleGetHashesPerSec,
+	"getheaders":                handleGetHeaders,
+	"getinfo":                   handleGetInfo,
+	"getlatencystats":           handleGetLatencyStats,
+	"getmempoolentry":           handleGetMempoolEntry,
+	"getmempoolinfo":            handleGetMempoolInfo,
+	"getmemoryinfo":             handleGetMemoryInfo,
+	"getmininginfo":             handleGetMiningInfo,
+	"getnettotals":              handleGetNetTotals,
+	"getnetworkhashps":          handleGetNetworkHashPS,
+	"getnetworkinfo":            handleGetNetworkInfo,
+	"getnodeaddresses":          handleGetNodeAddresses,
+	"exportaddrman":             handleExportAddrMan,
+	"getaddrmaninfo":            handleGetAddrManInfo,
+	"importaddrman":             handleImportAddrMan,
+	"getpeerinfo":               handleGetPeerInfo,
+	"getrawmempool":             handleGetRawMempool,
+	"getrawtransaction":         handleGetRawTransaction,
+	"getrawtransactions":        handleGetRawTransactions,
+	"getrpcinfo":                handleGetRPCInfo,
+	"getsysteminfo":             handleGetSystemInfo,
+	"gettxout":                  handleGetTxOut,
+	"getverifychaininfo":        handleGetVerifyChainInfo,
+	"getversionbits":            handleGetVersionBits,
+	"help":                      handleHelp,
+	"invalidateblock":           handleInvalidateBlock,
+	"listbanned":                handleListBanned,
+	"listchainsnapshots":        handleListChainSnapshots,
+	"node":                      handleNode,
+	"ping":                      handlePing,
+	"reconsiderblock":           handleReconsiderBlock,
+	"searchrawtransactions":     handleSearchRawTransactions,
+	"sendrawtransaction":        handleSendRawTransaction,
+	"setban":                    handleSetBan,
+	"setgenerate":               handleSetGenerate,
+	"setsigcachesize":           handleSetSigCacheSize,
+	"setversionbits":            handleSetVersionBits,
+	"signmessagewithprivkey":    handleSignMessageWithPrivKey,
+	"signrawtransactionwithkey": handleSignRawTransactionWithKey,
+	"stop":                      handleStop,
+	"submitblock":               handleSubmitBlock,
+	"uptime":                    handleUptime,
+	"validateaddress":           handleValidateAddress,
+	"verifychain":               handleVerifyChain,
+	"verifychainsnapshot":       handleVerifyChainSnapshot,
+	"verifymessage":             handleVerifyMessage,
+	"version":                   handleVersion,
 }
 
 // list of commands that we recognize, but for which btcd has no support because
@@ -202,7 +245,6 @@ var rpcAskWallet = map[string]struct{}{
 	"addmultisigaddress":     {},
 	"backupwallet":           {},
 	"createencryptedwallet":  {},
-	"createmultisig":         {},
 	"dumpprivkey":            {},
 	"dumpwallet":             {},
 	"encryptwallet":          {},
@@ -265,34 +307,46 @@ var rpcLimited = map[string]struct{}{
 	"help": {},
 
 	// HTTP/S-only commands
-	"createrawtransaction":  {},
-	"decoderawtransaction":  {},
-	"decodescript":          {},
-	"estimatefee":           {},
-	"getbestblock":          {},
-	"getbestblockhash":      {},
-	"getblock":              {},
-	"getblockcount":         {},
-	"getblockhash":          {},
-	"getblockheader":        {},
-	"getcfilter":            {},
-	"getcfilterheader":      {},
-	"getcurrentnet":         {},
-	"getdifficulty":         {},
-	"getheaders":            {},
-	"getinfo":               {},
-	"getnettotals":          {},
-	"getnetworkhashps":      {},
-	"getrawmempool":         {},
-	"getrawtransaction":     {},
-	"gettxout":              {},
-	"searchrawtransactions": {},
-	"sendrawtransaction":    {},
-	"submitblock":           {},
-	"uptime":                {},
-	"validateaddress":       {},
-	"verifymessage":         {},
-	"version":               {},
+	"analyzepsbt":               {},
+	"converttopsbt":             {},
+	"createmultisig":            {},
+	"createpsbt":                {},
+	"createrawtransaction":      {},
+	"decodepsbt":                {},
+	"decoderawtransaction":      {},
+	"decodescript":              {},
+	"deriveaddresses":           {},
+	"estimatefee":               {},
+	"finalizepsbt":              {},
+	"getbestblock":              {},
+	"getbestblockhash":          {},
+	"getblock":                  {},
+	"getblockcount":             {},
+	"getblockhash":              {},
+	"getblockheader":            {},
+	"getcfilter":                {},
+	"getcfilterheader":          {},
+	"getcurrentnet":             {},
+	"getdescriptorinfo":         {},
+	"getdifficulty":             {},
+	"getheaders":                {},
+	"getinfo":                   {},
+	"getnettotals":              {},
+	"getnetworkhashps":          {},
+	"getrawmempool":             {},
+	"getrawtransaction":         {},
+	"getrawtransactions":        {},
+	"gettxout":                  {},
+	"joinpsbts":                 {},
+	"searchrawtransactions":     {},
+	"sendrawtransaction":        {},
+	"signrawtransactionwithkey": {},
+	"submitblock":               {},
+	"uptime":                    {},
+	"utxoupdatepsbt":            {},
+	"validateaddress":           {},
+	"verifymessage":             {},
+	"version":                   {},
 }
 
 // builderScript is a convenience function which is used for hard-coded scripts
@@ -550,9 +604,31 @@ func messageToHex(msg wire.Message) (string, error) {
 func handleCreateRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*btcjson.CreateRawTransactionCmd)
 
+	mtx, err := createRawTransaction(s, c.Inputs, c.Outputs, c.LockTime)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the serialized and hex-encoded transaction.  Note that this
+	// is intentionally not directly returning because the first return
+	// value is a string and it would result in returning an empty string to
+	// the client instead of nothing (nil) in the case of an error.
+	mtxHex, err := messageToHex(mtx)
+	if err != nil {
+		return nil, err
+	}
+	return mtxHex, nil
+}
+
+// createRawTransaction returns the unsigned transaction spending the inputs
+// and paying to the outputs of the createrawtransaction and createpsbt
+// commands.
+func createRawTransaction(s *rpcServer, inputs []btcjson.TransactionInput,
+	outputs map[string]interface{}, lockTime *int64) (*wire.MsgTx, error) {
+
 	// Validate the locktime, if given.
-	if c.LockTime != nil &&
-		(*c.LockTime < 0 || *c.LockTime > int64(wire.MaxTxInSequenceNum)) {
+	if lockTime != nil &&
+		(*lockTime < 0 || *lockTime > int64(wire.MaxTxInSequenceNum)) {
 		return nil, &btcjson.RPCError{
 			Code:    btcjson.ErrRPCInvalidParameter,
 			Message: "Locktime out of range",
@@ -562,7 +638,7 @@ func handleCreateRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan
 	// Add all transaction inputs to a new transaction after performing
 	// some validity checks.
 	mtx := wire.NewMsgTx(wire.TxVersion)
-	for _, input := range c.Inputs {
+	for _, input := range inputs {
 		txHash, err := chainhash.NewHashFromStr(input.Txid)
 		if err != nil {
 			return nil, rpcDecodeHexError(input.Txid)
@@ -570,7 +646,7 @@ func handleCreateRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan
 
 		prevOut := wire.NewOutPoint(txHash, input.Vout)
 		txIn := wire.NewTxIn(prevOut, []byte{}, nil)
-		if c.LockTime != nil && *c.LockTime != 0 {
+		if lockTime != nil && *lockTime != 0 {
 			txIn.Sequence = wire.MaxTxInSequenceNum - 1
 		}
 		mtx.AddTxIn(txIn)
@@ -647,7 +723,66 @@ func handleCreateRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan
 		return wire.NewTxOut(0, data), nil
 	}
 
-	for key, value := range c.Outputs {
+	// Decode the claims, updates or supports of the key, which is either a
+	// single object or an array of them.
+	// Pay their amounts to their addresses, which must be pubkey hash
+	// addresses since the claim scripts of script hashes are not evaluated
+	// as such.
+	// Prefix the scripts with the claims.
+	handleClaimsFn := func(key string, value interface{}) ([]*wire.TxOut,
+		error) {
+
+		switch key {
+		case "claim", "update", "support":
+		default:
+			context := "output key must be an address, \"data\", " +
+				"\"claim\", \"update\" or \"support\""
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: context,
+			}
+		}
+		values, ok := value.([]interface{})
+		if !ok {
+			values = []interface{}{value}
+		}
+
+		txOuts := make([]*wire.TxOut, 0, len(values))
+		for _, value := range values {
+			var claim btcjson.CreateRawTransactionClaim
+			marshalled, err := json.Marshal(value)
+			if err == nil {
+				decoder := json.NewDecoder(bytes.NewReader(marshalled))
+				decoder.DisallowUnknownFields()
+				err = decoder.Decode(&claim)
+			}
+			if err != nil {
+				return nil, &btcjson.RPCError{
+					Code: btcjson.ErrRPCInvalidParameter,
+					Message: fmt.Sprintf("invalid %s output: %v",
+						key, err),
+				}
+			}
+
+			txOut, err := handleAmountFn(claim.Amount, claim.Address)
+			if err != nil {
+				return nil, err
+			}
+			if !txscript.IsPayToPubKeyHash(txOut.PkScript) {
+				return nil, rpcInvalidAddressOrKeyError(claim.Address,
+					"a claim output must pay to a pubkey hash address")
+			}
+			txOut.PkScript, err = claimScript(key, claim.Name,
+				claim.ClaimID, claim.Value, txOut.PkScript)
+			if err != nil {
+				return nil, err
+			}
+			txOuts = append(txOuts, txOut)
+		}
+		return txOuts, nil
+	}
+
+	for key, value := range outputs {
 		var err error
 		var txOut *wire.TxOut
 		switch value := value.(type) {
@@ -655,8 +790,18 @@ func handleCreateRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan
 			txOut, err = handleAmountFn(value, key)
 		case string:
 			txOut, err = handleDataFn(key, value)
+		case map[string]interface{}, []interface{}:
+			txOuts, err := handleClaimsFn(key, value)
+			if err != nil {
+				return nil, err
+			}
+			for _, txOut := range txOuts {
+				mtx.AddTxOut(txOut)
+			}
+			continue
 		default:
-			context := "output value must be a string or float"
+			context := "output value must be a string, a float, " +
+				"an object or an array"
 			return nil, &btcjson.RPCError{
 				Code:    btcjson.ErrRPCType,
 				Message: context,
@@ -669,19 +814,10 @@ func handleCreateRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan
 	}
 
 	// Set the Locktime, if given.
-	if c.LockTime != nil {
-		mtx.LockTime = uint32(*c.LockTime)
+	if lockTime != nil {
+		mtx.LockTime = uint32(*lockTime)
 	}
-
-	// Return the serialized and hex-encoded transaction.  Note that this
-	// is intentionally not directly returning because the first return
-	// value is a string and it would result in returning an empty string to
-	// the client instead of nothing (nil) in the case of an error.
-	mtxHex, err := messageToHex(mtx)
-	if err != nil {
-		return nil, err
-	}
-	return mtxHex, nil
+	return mtx, nil
 }
 
 // handleDebugLevel handles debuglevel commands.
@@ -934,6 +1070,12 @@ func handleDecodeScript(s *rpcServer, cmd interface{}, closeChan <-chan struct{}
 	if scriptClass != txscript.ScriptHashTy {
 		reply.P2sh = p2sh.EncodeAddress()
 	}
+	reply.Claim = decodeScriptClaim(script)
+	reply.Segwit, err = decodeScriptSegwit(script, s.cfg.ChainParams)
+	if err != nil {
+		context := "Failed to convert script to witness program"
+		return nil, internalRPCError(err.Error(), context)
+	}
 	return reply, nil
 }
 
@@ -1241,35 +1383,30 @@ func getDifficultyRatio(bits uint32, params *chaincfg.Params) float64 {
 func handleGetBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*btcjson.GetBlockCmd)
 
-	// Load the raw block bytes from the database.
+	// Load the block from the cache of the recently served blocks, or from
+	// the database.
 	hash, err := chainhash.NewHashFromStr(c.Hash)
 	if err != nil {
 		return nil, rpcDecodeHexError(c.Hash)
 	}
-	var blkBytes []byte
-	err = s.cfg.DB.View(func(dbTx database.Tx) error {
-		var err error
-		blkBytes, err = dbTx.FetchBlock(hash)
-		return err
-	})
+	served, err := s.cfg.BlockCache.fetch(s.cfg.DB, hash)
 	if err != nil {
 		return nil, &btcjson.RPCError{
 			Code:    btcjson.ErrRPCBlockNotFound,
 			Message: "Block not found: " + err.Error(),
 		}
 	}
+	blkBytes, blk := served.bytes, served.block
+
 	// If verbosity is 0, return the serialized block as a hex encoded string.
 	if c.Verbosity != nil && *c.Verbosity == 0 {
 		return hex.EncodeToString(blkBytes), nil
 	}
 
-	// Otherwise, generate the JSON object and return it.
-
-	// Deserialize the block.
-	blk, err := btcutil.NewBlockFromBytes(blkBytes)
-	if err != nil {
-		context := "Failed to deserialize block"
-		return nil, internalRPCError(err.Error(), context)
+	// Otherwise, generate the JSON object and return it, unless it was
+	// generated since the last change of the best chain.
+	if result, ok := served.verboseResult(*c.Verbosity, &s.cfg.Chain.BestSnapshot().Hash); ok {
+		return result, nil
 	}
 
 	params := s.cfg.ChainParams
@@ -1324,12 +1461,15 @@ func handleGetBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (i
 			GetBlockVerboseResultBase: base,
 			Tx:                        txNames,
 		}
-		return blockReply, nil
+		return served.cacheVerboseResult(*c.Verbosity, &best.Hash, blockReply)
 	}
 
 	txns := blk.Transactions()
 	rawTxns := make([]btcjson.TxRawResult, len(txns))
 	for i, tx := range txns {
+		if err := clientQuit(closeChan); err != nil {
+			return nil, err
+		}
 		rawTxn, err := createTxRawResult(params, tx.MsgTx(),
 			tx.Hash().String(), blockHeader, hash.String(),
 			attrs.Height, best.Height)
@@ -1344,7 +1484,7 @@ func handleGetBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (i
 		Tx:                        rawTxns,
 	}
 
-	return blockReply, nil
+	return served.cacheVerboseResult(*c.Verbosity, &best.Hash, blockReply)
 }
 
 // softForkStatus converts a ThresholdState state into a human readable string
@@ -1533,30 +1673,33 @@ func handleGetBlockHeader(s *rpcServer, cmd interface{}, closeChan <-chan struct
 
 	// The verbose flag is set, so generate the JSON object and return it.
 
-	// Get the block height from chain.
-	blockHeight, err := s.cfg.Chain.BlockHeightByHash(hash)
+	// Get the block height, median time and chain work from the chain.
+	attrs, _, err := s.cfg.Chain.BlockAttributesByHash(hash, &blockHeader.PrevBlock)
 	if err != nil {
 		context := "Failed to obtain block height"
 		return nil, internalRPCError(err.Error(), context)
 	}
-	best := s.cfg.Chain.BestSnapshot()
+	if attrs.Confirmations < 0 {
+		context := "Failed to obtain block height"
+		return nil, internalRPCError("block is not in the main chain", context)
+	}
+	txCount, err := s.cfg.Chain.BlockTxCountByHash(hash)
+	if err != nil {
+		context := "Failed to obtain the number of transactions"
+		return nil, internalRPCError(err.Error(), context)
+	}
 
 	// Get next block hash unless there are none.
 	var nextHashString string
-	if blockHeight < best.Height {
-		nextHash, err := s.cfg.Chain.BlockHashByHeight(blockHeight + 1)
-		if err != nil {
-			context := "No next block"
-			return nil, internalRPCError(err.Error(), context)
-		}
-		nextHashString = nextHash.String()
+	if attrs.NextHash != nil {
+		nextHashString = attrs.NextHash.String()
 	}
 
 	params := s.cfg.ChainParams
 	blockHeaderReply := btcjson.GetBlockHeaderVerboseResult{
 		Hash:          c.Hash,
-		Confirmations: int64(1 + best.Height - blockHeight),
-		Height:        blockHeight,
+		Confirmations: int64(attrs.Confirmations),
+		Height:        attrs.Height,
 		Version:       blockHeader.Version,
 		VersionHex:    fmt.Sprintf("%08x", blockHeader.Version),
 		MerkleRoot:    blockHeader.MerkleRoot.String(),
@@ -1564,8 +1707,11 @@ func handleGetBlockHeader(s *rpcServer, cmd interface{}, closeChan <-chan struct
 		PreviousHash:  blockHeader.PrevBlock.String(),
 		Nonce:         uint64(blockHeader.Nonce),
 		Time:          blockHeader.Timestamp.Unix(),
+		MedianTime:    attrs.MedianTime.Unix(),
 		Bits:          strconv.FormatInt(int64(blockHeader.Bits), 16),
 		Difficulty:    getDifficultyRatio(blockHeader.Bits, params),
+		ChainWork:     attrs.ChainWork.Text(16),
+		TxCount:       int(txCount),
 	}
 	return blockHeaderReply, nil
 }
@@ -2406,6 +2552,18 @@ func (state *gbtWorkState) blockTemplateResult(useCoinbaseValue bool, submitOld
 	return &reply, nil
 }
 
+// clientQuit returns ErrClientQuit when closeChan has been closed, which
+// happens once the client that issued the request disconnects.  Long-running
+// handlers check it periodically to abort work nobody is waiting for.
+func clientQuit(closeChan <-chan struct{}) error {
+	select {
+	case <-closeChan:
+		return ErrClientQuit
+	default:
+		return nil
+	}
+}
+
 // handleGetBlockTemplateLongPoll is a helper for handleGetBlockTemplateRequest
 // which deals with handling long polling for block templates.  When a caller
 // sends a request with a long poll ID that was previously returned, a response
@@ -2848,10 +3006,119 @@ func handleGetCurrentNet(s *rpcServer, cmd interface{}, closeChan <-chan struct{
 	return s.cfg.ChainParams.Net, nil
 }
 
+// rpcserverDBStats represents a database which reports the statistics of its
+// I/O, such as the ffldb database.
+type rpcserverDBStats interface {
+	Stats() (*ffldb.Stats, error)
+}
+
+// cacheHitRate returns the ratio of the lookups which hit the cache.
+func cacheHitRate(hits, misses uint64) float64 {
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) / float64(hits+misses)
+}
+
+// handleGetDBInfo implements the getdbinfo command.
+func handleGetDBInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	result := &btcjson.GetDBInfoResult{}
+
+	if db, ok := s.cfg.DB.(rpcserverDBStats); ok {
+		stats, err := db.Stats()
+		if err != nil {
+			context := "Failed to fetch database stats"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		result.Databases = append(result.Databases, btcjson.DBInfo{
+			Name:         "metadata",
+			Engine:       stats.MetadataEngine,
+			Size:         stats.MetadataSize,
+			ReadBytes:    stats.MetadataReadBytes,
+			WriteBytes:   stats.MetadataWriteBytes,
+			Compactions:  stats.Compactions,
+			Flushes:      stats.Flushes,
+			OpenFiles:    int64(stats.OpenTables),
+			CacheHits:    stats.CacheHits,
+			CacheMisses:  stats.CacheMisses,
+			CacheHitRate: cacheHitRate(stats.CacheHits, stats.CacheMisses),
+		}, btcjson.DBInfo{
+			Name:       "blocks",
+			Engine:     "flatfile",
+			Size:       stats.BlockFilesSize,
+			ReadBytes:  stats.BlockReadBytes,
+			WriteBytes: stats.BlockWriteBytes,
+			OpenFiles:  int64(stats.OpenBlockFiles),
+		})
+	}
+
+	// Pebble doesn't count the bytes read by lookups, which are reflected
+	// by the misses of the block cache instead.
+	for _, repo := range s.cfg.Chain.GetClaimTrieRepoMetrics() {
+		m := repo.Metrics
+		total := m.Total()
+		cache := m.BlockCache
+		result.Databases = append(result.Databases, btcjson.DBInfo{
+			Name:      "claimtrie/" + repo.Name,
+			Engine:    "pebble",
+			Size:      int64(m.DiskSpaceUsage()),
+			ReadBytes: total.BytesRead,
+			WriteBytes: m.WAL.BytesWritten + total.BytesFlushed +
+				total.BytesCompacted,
+			Compactions:  uint64(m.Compact.Count),
+			Flushes:      uint64(m.Flush.Count),
+			OpenFiles:    m.TableCache.Count + m.WAL.Files,
+			CacheHits:    uint64(cache.Hits),
+			CacheMisses:  uint64(cache.Misses),
+			CacheHitRate: cacheHitRate(uint64(cache.Hits), uint64(cache.Misses)),
+		})
+	}
+
+	return result, nil
+}
+
 // handleGetDifficulty implements the getdifficulty command.
 func handleGetDifficulty(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
-	best := s.cfg.Chain.BestSnapshot()
-	return getDifficultyRatio(best.Bits, s.cfg.ChainParams), nil
+	c := cmd.(*btcjson.GetDifficultyCmd)
+
+	if c.HashOrHeight == nil {
+		best := s.cfg.Chain.BestSnapshot()
+		return getDifficultyRatio(best.Bits, s.cfg.ChainParams), nil
+	}
+
+	// Return the difficulty as of the block with the passed height or hash,
+	// whose header is in the block index.
+	var hash *chainhash.Hash
+	switch v := c.HashOrHeight.Value.(type) {
+	case int:
+		var err error
+		hash, err = s.cfg.Chain.BlockHashByHeight(int32(v))
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCOutOfRange,
+				Message: "Block number out of range",
+			}
+		}
+	case string:
+		var err error
+		hash, err = chainhash.NewHashFromStr(v)
+		if err != nil {
+			return nil, rpcDecodeHexError(v)
+		}
+	default:
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "The block must be given by height or hash",
+		}
+	}
+	header, err := s.cfg.Chain.HeaderByHash(hash)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCBlockNotFound,
+			Message: "Block not found",
+		}
+	}
+	return getDifficultyRatio(header.Bits, s.cfg.ChainParams), nil
 }
 
 // handleGetGenerate implements the getgenerate command.
@@ -2919,6 +3186,7 @@ func handleGetInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (in
 		Difficulty:      getDifficultyRatio(best.Bits, s.cfg.ChainParams),
 		TestNet:         cfg.TestNet3,
 		RelayFee:        cfg.minRelayTxFee.ToBTC(),
+		Errors:          s.cfg.DiskSpace.Warning(),
 	}
 
 	return ret, nil
@@ -2929,6 +3197,66 @@ func handleGetMempoolInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct
 	return s.cfg.TxMemPool.MempoolInfo(), nil
 }
 
+// rpcserverDBCache represents a database which caches the changes it has yet
+// to write out, such as the ffldb database.
+type rpcserverDBCache interface {
+	CacheSize() (size uint64, maxSize uint64)
+}
+
+// handleGetMemoryInfo implements the getmemoryinfo command.
+func handleGetMemoryInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	result := &btcjson.GetMemoryInfoResult{
+		Runtime: btcjson.MemoryInfoRuntime{
+			Alloc:        stats.Alloc,
+			TotalAlloc:   stats.TotalAlloc,
+			Sys:          stats.Sys,
+			HeapInuse:    stats.HeapInuse,
+			HeapIdle:     stats.HeapIdle,
+			HeapReleased: stats.HeapReleased,
+			HeapObjects:  stats.HeapObjects,
+			StackInuse:   stats.StackInuse,
+			NumGC:        stats.NumGC,
+			LastGC:       time.Unix(0, int64(stats.LastGC)).Unix(),
+			PauseTotal:   int64(stats.PauseTotalNs),
+			Goroutines:   runtime.NumGoroutine(),
+		},
+		Caches: btcjson.MemoryInfoCaches{
+			ClaimTrieNodes: s.cfg.Chain.GetClaimTrieNodeCount(),
+		},
+	}
+
+	// The process stats are left out on the platforms which don't provide
+	// them.
+	if p, err := process.NewProcess(int32(os.Getpid())); err == nil {
+		if m, err := p.MemoryInfo(); err == nil {
+			result.Process.RSS = m.RSS
+			result.Process.VMS = m.VMS
+		}
+		if n, err := p.NumFDs(); err == nil {
+			result.Process.OpenFiles = n
+		}
+	}
+
+	if s.cfg.SigCache != nil {
+		result.Caches.SigCacheEntries, result.Caches.SigCacheMaxEntries =
+			s.cfg.SigCache.Len()
+		result.Caches.SigCacheHits, result.Caches.SigCacheMisses =
+			s.cfg.SigCache.Stats()
+	}
+	if s.cfg.HashCache != nil {
+		result.Caches.HashCacheEntries = s.cfg.HashCache.Len()
+	}
+	if db, ok := s.cfg.DB.(rpcserverDBCache); ok {
+		result.Caches.DBCacheBytes, result.Caches.DBCacheMaxBytes =
+			db.CacheSize()
+	}
+
+	return result, nil
+}
+
 // handleGetMempoolEntry implements the getmempoolentry command.
 func handleGetMempoolEntry(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 
@@ -3037,12 +3365,26 @@ func handleGetNetworkHashPS(s *rpcServer, cmd interface{}, closeChan <-chan stru
 	// When the passed height is too high or zero, just return 0 now
 	// since we can't reasonably calculate the number of network hashes
 	// per second from invalid values.  When it's negative, use the current
-	// best block height.
+	// best block height.  A passed block hash takes precedence over the
+	// height.
 	best := s.cfg.Chain.BestSnapshot()
 	endHeight := int32(-1)
 	if c.Height != nil {
 		endHeight = int32(*c.Height)
 	}
+	if c.Hash != nil {
+		hash, err := chainhash.NewHashFromStr(*c.Hash)
+		if err != nil {
+			return nil, rpcDecodeHexError(*c.Hash)
+		}
+		endHeight, err = s.cfg.Chain.BlockHeightByHash(hash)
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCBlockNotFound,
+				Message: "Block not found in the main chain",
+			}
+		}
+	}
 	if endHeight > best.Height || endHeight == 0 {
 		return int64(0), nil
 	}
@@ -3174,6 +3516,15 @@ func handleGetNetworkInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct
 		}
 	}
 
+	if s.cfg.TorController != nil {
+		if onion, port := s.cfg.TorController.OnionAddress(); onion != "" {
+			localAddrs = append(localAddrs, btcjson.LocalAddressesResult{
+				Address: onion,
+				Port:    port,
+			})
+		}
+	}
+
 	onionProxy := cfg.Proxy
 	if cfg.OnionProxy != "" {
 		onionProxy = cfg.OnionProxy
@@ -3184,6 +3535,7 @@ func handleGetNetworkInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct
 	if unknownRulesWarned {
 		warnings = "Warning: Unknown new rules activated! "
 	}
+	warnings += s.cfg.DiskSpace.Warning()
 
 	var timeOffset int64
 	if !s.cfg.SyncMgr.IsCurrent() {
@@ -3255,6 +3607,7 @@ func handleGetPeerInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 			Version:        statsSnap.Version,
 			SubVer:         statsSnap.UserAgent,
 			Inbound:        statsSnap.Inbound,
+			BlockRelayOnly: p.IsBlockRelayOnly(),
 			StartingHeight: statsSnap.StartingHeight,
 			CurrentHeight:  statsSnap.LastBlock,
 			BanScore:       int32(p.BanScore()),
@@ -3410,6 +3763,234 @@ func handleGetRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan str
 	return *rawTxn, nil
 }
 
+// handleGetRawTransactions implements the getrawtransactions command.
+func handleGetRawTransactions(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetRawTransactionsCmd)
+
+	if len(c.Txids) > maxGetRawTransactionsTxids {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Too many transactions requested: %d "+
+				"(max %d)", len(c.Txids), maxGetRawTransactionsTxids),
+		}
+	}
+
+	// Convert the provided transaction hashes hex to hashes.
+	txHashes := make([]*chainhash.Hash, len(c.Txids))
+	for i, txid := range c.Txids {
+		txHash, err := chainhash.NewHashFromStr(txid)
+		if err != nil {
+			return nil, rpcDecodeHexError(txid)
+		}
+		txHashes[i] = txHash
+	}
+
+	verbose := false
+	if c.Verbose != nil {
+		verbose = *c.Verbose
+	}
+
+	// The transactions of the memory pool are returned from it, and the
+	// others are looked up in the transaction index.  The position of an
+	// unknown transaction in the result is null.
+	results := make([]interface{}, len(txHashes))
+	var indexed []int
+	for i, txHash := range txHashes {
+		tx, err := s.cfg.TxMemPool.FetchTransaction(txHash)
+		if err != nil {
+			indexed = append(indexed, i)
+			continue
+		}
+		if !verbose {
+			mtxHex, err := messageToHex(tx.MsgTx())
+			if err != nil {
+				return nil, err
+			}
+			results[i] = mtxHex
+			continue
+		}
+		rawTxn, err := createTxRawResult(s.cfg.ChainParams, tx.MsgTx(),
+			txHash.String(), nil, "", 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = rawTxn
+	}
+	if len(indexed) == 0 {
+		return results, nil
+	}
+	if s.cfg.TxIndex == nil {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCNoTxInfo,
+			Message: "The transaction index must be " +
+				"enabled to query the blockchain " +
+				"(specify --txindex)",
+		}
+	}
+
+	// Look up the locations of the transactions, and load their raw bytes
+	// from the database, in batches.
+	indexedHashes := make([]*chainhash.Hash, len(indexed))
+	for j, i := range indexed {
+		indexedHashes[j] = txHashes[i]
+	}
+	blockRegions, err := s.cfg.TxIndex.TxBlockRegions(indexedHashes)
+	if err != nil {
+		context := "Failed to retrieve transaction locations"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	found := make([]int, 0, len(indexed))
+	regions := make([]database.BlockRegion, 0, len(indexed))
+	for j, region := range blockRegions {
+		if region != nil {
+			found = append(found, indexed[j])
+			regions = append(regions, *region)
+		}
+	}
+	var txBytes [][]byte
+	err = s.cfg.DB.View(func(dbTx database.Tx) error {
+		var err error
+		txBytes, err = dbTx.FetchBlockRegions(regions)
+		return err
+	})
+	if err != nil {
+		context := "Failed to load transactions"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	// When the verbose flag isn't set, simply return the serialized
+	// transactions as hex-encoded strings.
+	if !verbose {
+		for j, i := range found {
+			results[i] = hex.EncodeToString(txBytes[j])
+		}
+		return results, nil
+	}
+
+	// The heights and headers of the blocks are shared by their
+	// transactions.
+	type txBlock struct {
+		height int32
+		header wire.BlockHeader
+	}
+	blocks := make(map[chainhash.Hash]*txBlock)
+	chainHeight := s.cfg.Chain.BestSnapshot().Height
+	for j, i := range found {
+		blkHash := regions[j].Hash
+		blk, ok := blocks[*blkHash]
+		if !ok {
+			height, err := s.cfg.Chain.BlockHeightByHash(blkHash)
+			if err != nil {
+				context := "Failed to retrieve block height"
+				return nil, internalRPCError(err.Error(), context)
+			}
+			header, err := s.cfg.Chain.HeaderByHash(blkHash)
+			if err != nil {
+				context := "Failed to fetch block header"
+				return nil, internalRPCError(err.Error(), context)
+			}
+			blk = &txBlock{height: height, header: header}
+			blocks[*blkHash] = blk
+		}
+
+		var msgTx wire.MsgTx
+		err := msgTx.Deserialize(bytes.NewReader(txBytes[j]))
+		if err != nil {
+			context := "Failed to deserialize transaction"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		rawTxn, err := createTxRawResult(s.cfg.ChainParams, &msgTx,
+			txHashes[i].String(), &blk.header, blkHash.String(),
+			blk.height, chainHeight)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = rawTxn
+	}
+	return results, nil
+}
+
+// handleGetRPCInfo implements the getrpcinfo command.
+func handleGetRPCInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	return &btcjson.GetRPCInfoResult{
+		ActiveCommands:   s.activeCommands(),
+		WebsocketClients: s.ntfnMgr.ClientQueues(),
+		LogPath:          filepath.Join(cfg.LogDir, defaultLogFilename),
+	}, nil
+}
+
+// rpcserverDBBuckets represents a database which reports the size of its
+// buckets, such as the ffldb database.
+type rpcserverDBBuckets interface {
+	BucketsSize(names [][]byte) (int64, error)
+}
+
+// handleGetSystemInfo implements the getsysteminfo command.
+func handleGetSystemInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	result := &btcjson.GetSystemInfoResult{
+		Version:   version.Full(),
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		BuildTags: append([]string{}, version.BuildTags()...),
+		Network:   s.cfg.ChainParams.Name,
+		DataDir:   cfg.DataDir,
+		BlocksDir: cfg.BlocksDir,
+	}
+
+	// The chain state and the indexes share the database of the metadata,
+	// whose buckets are sized to tell them apart.
+	sizes := &result.DataDirSize
+	if db, ok := s.cfg.DB.(rpcserverDBStats); ok {
+		stats, err := db.Stats()
+		if err != nil {
+			context := "Failed to fetch database stats"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		sizes.Blocks = stats.BlockFilesSize
+		sizes.ChainState = stats.MetadataSize
+	}
+	if db, ok := s.cfg.DB.(rpcserverDBBuckets); ok {
+		indexes, err := db.BucketsSize(indexers.BucketNames())
+		if err != nil {
+			context := "Failed to fetch index sizes"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		sizes.Indexes = indexes
+		sizes.ChainState -= indexes
+		if sizes.ChainState < 0 {
+			sizes.ChainState = 0
+		}
+	}
+
+	var err error
+	sizes.ClaimDBs, err = dirSize(filepath.Join(cfg.DataDir, "claim_dbs"))
+	if err != nil {
+		context := "Failed to size the claimtrie databases"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	sizes.Total, err = dirSize(cfg.DataDir)
+	if err != nil {
+		context := "Failed to size the data directory"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	if cfg.BlocksDir != "" {
+		sizes.Total += sizes.Blocks
+	}
+
+	for _, volume := range s.cfg.DiskSpace.Volumes() {
+		result.DiskSpace = append(result.DiskSpace, btcjson.DiskSpaceResult{
+			Name:   volume.Name,
+			Path:   volume.Path,
+			Free:   volume.Free,
+			Total:  volume.Total,
+			Status: volume.Status,
+		})
+	}
+
+	return result, nil
+}
+
 // handleGetTxOut handles gettxout commands.
 func handleGetTxOut(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*btcjson.GetTxOutCmd)
@@ -4054,6 +4635,12 @@ func handleSearchRawTransactions(s *rpcServer, cmd interface{}, closeChan <-chan
 	best := s.cfg.Chain.BestSnapshot()
 	srtList := make([]btcjson.SearchRawTransactionsResult, len(addressTxns))
 	for i := range addressTxns {
+		// Stop early if the client has gone away, since looking up the
+		// previous outputs of each input can take a long time.
+		if err := clientQuit(closeChan); err != nil {
+			return nil, err
+		}
+
 		// The deserialized transaction is needed, so deserialize the
 		// retrieved transaction if it's in serialized form (which will
 		// be the case when it was lookup up from the database).
@@ -4320,50 +4907,18 @@ func handleSetGenerate(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 	return nil, nil
 }
 
-// Text used to signify that a signed message follows and to prevent
-// inadvertently signing a transaction.
-const messageSignatureHeader = "Bitcoin Signed Message:\n"
+// handleSetSigCacheSize implements the setsigcachesize command.
+func handleSetSigCacheSize(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.SetSigCacheSizeCmd)
 
-// handleSignMessageWithPrivKey implements the signmessagewithprivkey command.
-func handleSignMessageWithPrivKey(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
-	c := cmd.(*btcjson.SignMessageWithPrivKeyCmd)
-
-	wif, err := btcutil.DecodeWIF(c.PrivKey)
-	if err != nil {
-		message := "Invalid private key"
-		switch err {
-		case btcutil.ErrMalformedPrivateKey:
-			message = "Malformed private key"
-		case btcutil.ErrChecksumMismatch:
-			message = "Private key checksum mismatch"
-		}
-		return nil, &btcjson.RPCError{
-			Code:    btcjson.ErrRPCInvalidAddressOrKey,
-			Message: message,
-		}
-	}
-	if !wif.IsForNet(s.cfg.ChainParams) {
+	if s.cfg.SigCache == nil {
 		return nil, &btcjson.RPCError{
-			Code:    btcjson.ErrRPCInvalidAddressOrKey,
-			Message: "Private key for wrong network",
-		}
-	}
-
-	var buf bytes.Buffer
-	wire.WriteVarString(&buf, 0, messageSignatureHeader)
-	wire.WriteVarString(&buf, 0, c.Message)
-	messageHash := chainhash.DoubleHashB(buf.Bytes())
-
-	sig, err := btcec.SignCompact(btcec.S256(), wif.PrivKey,
-		messageHash, wif.CompressPubKey)
-	if err != nil {
-		return nil, &btcjson.RPCError{
-			Code:    btcjson.ErrRPCInvalidAddressOrKey,
-			Message: "Sign failed",
+			Code:    btcjson.ErrRPCMisc,
+			Message: "The signature cache is not available",
 		}
 	}
-
-	return base64.StdEncoding.EncodeToString(sig), nil
+	s.cfg.SigCache.SetMaxEntries(c.MaxEntries)
+	return nil, nil
 }
 
 // handleStop implements the stop command.
@@ -4459,121 +5014,21 @@ func handleValidateAddress(s *rpcServer, cmd interface{}, closeChan <-chan struc
 	result.Address = addr.EncodeAddress()
 	result.IsValid = true
 
-	return result, nil
-}
-
-func verifyChain(s *rpcServer, level, depth int32) error {
-	best := s.cfg.Chain.BestSnapshot()
-	finishHeight := best.Height - depth
-	if finishHeight < 0 {
-		finishHeight = 0
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err == nil {
+		result.ScriptPubKey = hex.EncodeToString(pkScript)
 	}
-	rpcsLog.Infof("Verifying chain for %d blocks at level %d",
-		best.Height-finishHeight, level)
 
-	for height := best.Height; height > finishHeight; height-- {
-		// Level 0 just looks up the block.
-		block, err := s.cfg.Chain.BlockByHeight(height)
+	if *c.IncludeClaims {
+		claims, hasActiveClaims, err := addressClaims(s, addr, closeChan)
 		if err != nil {
-			rpcsLog.Errorf("Verify is unable to fetch block at "+
-				"height %d: %v", height, err)
-			return err
-		}
-
-		// Level 1 does basic chain sanity checks.
-		if level > 0 {
-			err := blockchain.CheckBlockSanity(block,
-				s.cfg.ChainParams.PowLimit, s.cfg.TimeSource)
-			if err != nil {
-				rpcsLog.Errorf("Verify is unable to validate "+
-					"block at hash %v height %d: %v",
-					block.Hash(), height, err)
-				return err
-			}
-		}
-	}
-	rpcsLog.Infof("Chain verify completed successfully")
-
-	return nil
-}
-
-// handleVerifyChain implements the verifychain command.
-func handleVerifyChain(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
-	c := cmd.(*btcjson.VerifyChainCmd)
-
-	var checkLevel, checkDepth int32
-	if c.CheckLevel != nil {
-		checkLevel = *c.CheckLevel
-	}
-	if c.CheckDepth != nil {
-		checkDepth = *c.CheckDepth
-	}
-
-	err := verifyChain(s, checkLevel, checkDepth)
-	return err == nil, nil
-}
-
-// handleVerifyMessage implements the verifymessage command.
-func handleVerifyMessage(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
-	c := cmd.(*btcjson.VerifyMessageCmd)
-
-	// Decode the provided address.
-	params := s.cfg.ChainParams
-	addr, err := btcutil.DecodeAddress(c.Address, params)
-	if err != nil {
-		return nil, &btcjson.RPCError{
-			Code:    btcjson.ErrRPCInvalidAddressOrKey,
-			Message: "Invalid address or key: " + err.Error(),
-		}
-	}
-
-	// Only P2PKH addresses are valid for signing.
-	if _, ok := addr.(*btcutil.AddressPubKeyHash); !ok {
-		return nil, &btcjson.RPCError{
-			Code:    btcjson.ErrRPCType,
-			Message: "Address is not a pay-to-pubkey-hash address",
-		}
-	}
-
-	// Decode base64 signature.
-	sig, err := base64.StdEncoding.DecodeString(c.Signature)
-	if err != nil {
-		return nil, &btcjson.RPCError{
-			Code:    btcjson.ErrRPCParse.Code,
-			Message: "Malformed base64 encoding: " + err.Error(),
+			return nil, err
 		}
+		result.Claims = claims
+		result.HasActiveClaims = btcjson.Bool(hasActiveClaims)
 	}
 
-	// Validate the signature - this just shows that it was valid at all.
-	// we will compare it with the key next.
-	var buf bytes.Buffer
-	wire.WriteVarString(&buf, 0, messageSignatureHeader)
-	wire.WriteVarString(&buf, 0, c.Message)
-	expectedMessageHash := chainhash.DoubleHashB(buf.Bytes())
-	pk, wasCompressed, err := btcec.RecoverCompact(btcec.S256(), sig,
-		expectedMessageHash)
-	if err != nil {
-		// Mirror Bitcoin Core behavior, which treats error in
-		// RecoverCompact as invalid signature.
-		return false, nil
-	}
-
-	// Reconstruct the pubkey hash.
-	var serializedPK []byte
-	if wasCompressed {
-		serializedPK = pk.SerializeCompressed()
-	} else {
-		serializedPK = pk.SerializeUncompressed()
-	}
-	address, err := btcutil.NewAddressPubKey(serializedPK, params)
-	if err != nil {
-		// Again mirror Bitcoin Core behavior, which treats error in public key
-		// reconstruction as invalid signature.
-		return false, nil
-	}
-
-	// Return boolean if addresses match.
-	return address.EncodeAddress() == c.Address, nil
+	return result, nil
 }
 
 // handleVersion implements the version command.
@@ -4595,9 +5050,16 @@ func handleVersion(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (in
 type rpcServer struct {
 	started                int32
 	shutdown               int32
+	capturingProfile       int32
+	exportingBlocks        int32
+	backingUp              int32
+	chainSnapshots         *chainSnapshots
+	verifying              *chainVerification
+	verifyingLock          sync.Mutex
 	cfg                    rpcserverConfig
-	authsha                [sha256.Size]byte
-	limitauthsha           [sha256.Size]byte
+	authUsers              []*rpcAuthUser
+	grpcServer             *grpcServer
+	electrumServer         *electrumServer
 	ntfnMgr                *wsNotificationManager
 	numClients             int32
 	statusLines            map[int]string
@@ -4607,6 +5069,13 @@ type rpcServer struct {
 	helpCacher             *helpCacher
 	requestProcessShutdown chan struct{}
 	feeEstimator           *fees.Estimator
+	activeCmds             map[*rpcActiveCmd]struct{}
+	activeCmdsLock         sync.Mutex
+	methodStats            map[string]*rpcMethodStats
+	methodStatsLock        sync.Mutex
+	sseSubscribers         map[*sseSubscriber]struct{}
+	sseLock                sync.Mutex
+	workUpdates            chan struct{}
 	quit                   chan int
 }
 
@@ -4665,6 +5134,66 @@ func (s *rpcServer) writeHTTPResponseHeaders(req *http.Request, headers http.Hea
 	return err
 }
 
+// acceptedEncoding returns the content encoding to use for a response given
+// the Accept-Encoding header of the request, preferring gzip over deflate.  It
+// returns an empty string when the response should not be compressed.
+func acceptedEncoding(header string) string {
+	var gzipOK, deflateOK bool
+	for _, coding := range strings.Split(header, ",") {
+		name, params := coding, ""
+		if i := strings.IndexByte(coding, ';'); i >= 0 {
+			name, params = coding[:i], coding[i+1:]
+		}
+		params = strings.TrimSpace(params)
+		if strings.HasPrefix(params, "q=") {
+			q, err := strconv.ParseFloat(params[2:], 64)
+			if err != nil || q == 0 {
+				continue
+			}
+		}
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "gzip":
+			gzipOK = true
+		case "deflate":
+			deflateOK = true
+		}
+	}
+	switch {
+	case gzipOK:
+		return "gzip"
+	case deflateOK:
+		return "deflate"
+	}
+	return ""
+}
+
+// nopWriteCloser adds a no-op Close to an uncompressed response writer.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// compressWriter wraps w with a compressor for the passed content encoding, as
+// returned by acceptedEncoding.  Close must be called to flush the compressed
+// stream.
+func compressWriter(w io.Writer, encoding string) io.WriteCloser {
+	switch encoding {
+	case "gzip":
+		gz, _ := gzip.NewWriterLevel(w, gzip.BestSpeed)
+		return gz
+	case "deflate":
+		zw, _ := zlib.NewWriterLevel(w, zlib.BestSpeed)
+		return zw
+	}
+	return nopWriteCloser{w}
+}
+
+func writeString(w io.Writer, s string) error {
+	_, err := io.WriteString(w, s)
+	return err
+}
+
 // Stop is used by server.go to stop the rpc listener.
 func (s *rpcServer) Stop() error {
 	if atomic.AddInt32(&s.shutdown, 1) != 1 {
@@ -4679,6 +5208,7 @@ func (s *rpcServer) Stop() error {
 			return err
 		}
 	}
+	s.drainCommands(cfg.RPCDrainTimeout)
 	s.ntfnMgr.Shutdown()
 	s.ntfnMgr.WaitForShutdown()
 	close(s.quit)
@@ -4687,6 +5217,12 @@ func (s *rpcServer) Stop() error {
 	return nil
 }
 
+// shuttingDown returns whether the RPC server is shutting down, in which case
+// it refuses the new requests while those being processed finish.
+func (s *rpcServer) shuttingDown() bool {
+	return atomic.LoadInt32(&s.shutdown) != 0
+}
+
 // RequestedProcessShutdown returns a channel that is sent to when an authorized
 // RPC client requests the process to shutdown.  If the request can not be read
 // immediately, it is dropped.
@@ -4694,8 +5230,8 @@ func (s *rpcServer) RequestedProcessShutdown() <-chan struct{} {
 	return s.requestProcessShutdown
 }
 
-// NotifyNewTransactions notifies both websocket and getblocktemplate long
-// poll clients of the passed transactions.  This function should be called
+// NotifyNewTransactions notifies websocket, event stream, getblocktemplate long
+// poll and gRPC subscription clients of the passed transactions.  This function should be called
 // whenever new transactions are added to the mempool.
 func (s *rpcServer) NotifyNewTransactions(txns []*mempool.TxDesc) {
 	for _, txD := range txns {
@@ -4705,6 +5241,22 @@ func (s *rpcServer) NotifyNewTransactions(txns []*mempool.TxDesc) {
 		// Potentially notify any getblocktemplate long poll clients
 		// about stale block templates due to the new transaction.
 		s.gbtWorkState.NotifyMempoolTx(s.cfg.TxMemPool.LastUpdated())
+
+		// Notify event stream clients about mempool transactions.
+		s.sseNotify(txD, sseStreamTx)
+	}
+
+	// Notify websocket clients of the new work.
+	s.notifyWorkUpdate()
+
+	// Notify gRPC subscribers about mempool transactions.
+	if s.grpcServer != nil {
+		s.grpcServer.NotifyNewTransactions(txns)
+	}
+
+	// Notify Electrum clients about mempool transactions.
+	if s.electrumServer != nil {
+		s.electrumServer.NotifyNewTransactions(txns)
 	}
 }
 
@@ -4742,47 +5294,143 @@ func (s *rpcServer) decrementClients() {
 	atomic.AddInt32(&s.numClients, -1)
 }
 
+// rpcActiveCmd describes a command being processed by the RPC server.
+type rpcActiveCmd struct {
+	method string
+	client string
+	start  time.Time
+}
+
+// trackCommand records method as being processed on behalf of client, and
+// traces it, until the returned function is called with the error of the
+// command.
+func (s *rpcServer) trackCommand(method, client string) func(error) {
+	cmd := &rpcActiveCmd{method: method, client: client, start: time.Now()}
+	span := tracing.StartSpan("rpc " + method)
+	span.SetAttribute("rpc.method", method)
+	span.SetAttribute("rpc.client", client)
+
+	s.activeCmdsLock.Lock()
+	s.activeCmds[cmd] = struct{}{}
+	s.activeCmdsLock.Unlock()
+
+	return func(err error) {
+		s.activeCmdsLock.Lock()
+		delete(s.activeCmds, cmd)
+		s.activeCmdsLock.Unlock()
+
+		s.recordCommand(cmd, err)
+
+		span.SetError(err)
+		span.End()
+	}
+}
+
+// drainCommands waits up to timeout for the commands being processed to finish,
+// logging those which are still being processed every second.
+func (s *rpcServer) drainCommands(timeout time.Duration) {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	deadline := time.Now().Add(timeout)
+	var lastLog time.Time
+	for {
+		cmds := s.activeCommands()
+		if len(cmds) == 0 {
+			return
+		}
+		methods := make([]string, 0, len(cmds))
+		for _, cmd := range cmds {
+			methods = append(methods, cmd.Method)
+		}
+
+		now := time.Now()
+		if !now.Before(deadline) {
+			rpcsLog.Warnf("Abandoning %d RPC requests still being "+
+				"processed: %s", len(cmds), strings.Join(methods, ", "))
+			return
+		}
+		if now.Sub(lastLog) >= time.Second {
+			rpcsLog.Infof("Waiting up to %v for %d RPC requests to "+
+				"finish: %s", deadline.Sub(now).Round(time.Second),
+				len(cmds), strings.Join(methods, ", "))
+			lastLog = now
+		}
+		<-ticker.C
+	}
+}
+
+// activeCommands returns the commands being processed, oldest first.
+func (s *rpcServer) activeCommands() []btcjson.RPCActiveCommand {
+	s.activeCmdsLock.Lock()
+	cmds := make([]*rpcActiveCmd, 0, len(s.activeCmds))
+	for cmd := range s.activeCmds {
+		cmds = append(cmds, cmd)
+	}
+	s.activeCmdsLock.Unlock()
+
+	sort.Slice(cmds, func(i, j int) bool {
+		return cmds[i].start.Before(cmds[j].start)
+	})
+
+	now := time.Now()
+	result := make([]btcjson.RPCActiveCommand, 0, len(cmds))
+	for _, cmd := range cmds {
+		result = append(result, btcjson.RPCActiveCommand{
+			Method:   cmd.method,
+			Duration: now.Sub(cmd.start).Microseconds(),
+			Client:   cmd.client,
+		})
+	}
+	return result
+}
+
 // checkAuth checks the HTTP Basic authentication supplied by a wallet
 // or RPC client in the HTTP request r.  If the supplied authentication
-// does not match the username and password expected, a non-nil error is
+// does not match the username and password of any user, a non-nil error is
 // returned.
 //
 // This check is time-constant.
 //
-// The first bool return value signifies auth success (true if successful) and
-// the second bool return value specifies whether the user can change the state
-// of the server (true) or whether the user is limited (false). The second is
-// always false if the first is.
-func (s *rpcServer) checkAuth(r *http.Request, require bool) (bool, bool, error) {
+// The returned user is the authenticated one, which determines the commands
+// that may be called.  It is nil when no authentication is supplied and it
+// isn't required.
+func (s *rpcServer) checkAuth(r *http.Request, require bool) (*rpcAuthUser, error) {
 	authhdr := r.Header["Authorization"]
 	if len(authhdr) <= 0 {
 		if require {
 			rpcsLog.Warnf("RPC authentication failure from %s",
 				r.RemoteAddr)
-			return false, false, errors.New("auth failure")
+			return nil, errors.New("auth failure")
 		}
 
-		return false, false, nil
-	}
-
-	authsha := sha256.Sum256([]byte(authhdr[0]))
-
-	// Check for limited auth first as in environments with limited users, those
-	// are probably expected to have a higher volume of calls
-	limitcmp := subtle.ConstantTimeCompare(authsha[:], s.limitauthsha[:])
-	if limitcmp == 1 {
-		return true, false, nil
+		return nil, nil
 	}
 
-	// Check for admin-level auth
-	cmp := subtle.ConstantTimeCompare(authsha[:], s.authsha[:])
-	if cmp == 1 {
-		return true, true, nil
+	name, pass, ok := parseBasicAuth(authhdr[0])
+	if ok {
+		if user := s.checkCredentials(name, pass); user != nil {
+			return user, nil
+		}
 	}
 
-	// Request's auth doesn't match either user
+	// Request's auth doesn't match any user
 	rpcsLog.Warnf("RPC authentication failure from %s", r.RemoteAddr)
-	return false, false, errors.New("auth failure")
+	return nil, errors.New("auth failure")
+}
+
+// parseBasicAuth returns the username and password of an HTTP Basic
+// Authorization header.
+func parseBasicAuth(auth string) (string, string, bool) {
+	const prefix = "Basic "
+	if !strings.HasPrefix(auth, prefix) {
+		return "", "", false
+	}
+	login, err := base64.StdEncoding.DecodeString(auth[len(prefix):])
+	if err != nil {
+		return "", "", false
+	}
+	return strings.Cut(string(login), ":")
 }
 
 // parsedRPCCmd represents a JSON-RPC request object that has been parsed into
@@ -4801,6 +5449,9 @@ type parsedRPCCmd struct {
 // commands which are not recognized or not implemented will return an error
 // suitable for use in replies.
 func (s *rpcServer) standardCmdResult(cmd *parsedRPCCmd, closeChan <-chan struct{}) (interface{}, error) {
+	if s.shuttingDown() {
+		return nil, ErrRPCShuttingDown
+	}
 	handler, ok := rpcHandlers[cmd.method]
 	if ok {
 		goto handled
@@ -4870,18 +5521,43 @@ func createMarshalledReply(rpcVersion btcjson.RPCVersion, id interface{}, result
 	return btcjson.MarshalResponse(rpcVersion, id, result, jsonErr)
 }
 
+// rpcReply is a JSON-RPC response which is encoded as it is written to the
+// client, so that large results are never marshalled in memory as a whole.
+type rpcReply struct {
+	jsonrpc btcjson.RPCVersion
+	id      interface{}
+	result  interface{}
+	err     *btcjson.RPCError
+}
+
+// newRPCReply returns the reply for the passed parameters, or nil if they can't
+// form a valid JSON-RPC response.
+func newRPCReply(rpcVersion btcjson.RPCVersion, id interface{}, result interface{}, jsonErr *btcjson.RPCError) *rpcReply {
+	if rpcVersion == "" {
+		rpcVersion = btcjson.RpcVersion1
+	}
+	if _, err := btcjson.NewResponse(rpcVersion, id, nil, jsonErr); err != nil {
+		rpcsLog.Errorf("Failed to marshal reply: %v", err)
+		return nil
+	}
+	return &rpcReply{jsonrpc: rpcVersion, id: id, result: result, err: jsonErr}
+}
+
+// write encodes the reply to w.
+func (r *rpcReply) write(w io.Writer) error {
+	return btcjson.WriteResponse(w, r.jsonrpc, r.id, r.result, r.err)
+}
+
 // processRequest determines the incoming request type (single or batched),
-// parses it and returns a marshalled response.
-func (s *rpcServer) processRequest(request *btcjson.Request, isAdmin bool, closeChan <-chan struct{}) []byte {
+// parses it and returns the reply, or nil when no reply is to be sent.
+func (s *rpcServer) processRequest(request *btcjson.Request, user *rpcAuthUser, remoteAddr string, closeChan <-chan struct{}) *rpcReply {
 	var result interface{}
 	var err error
 	var jsonErr *btcjson.RPCError
 
-	if !isAdmin {
-		if _, ok := rpcLimited[request.Method]; !ok {
-			jsonErr = internalRPCError("limited user not "+
-				"authorized for this method", "")
-		}
+	if !user.allowed(request.Method) {
+		jsonErr = internalRPCError("user not authorized for this "+
+			"method", "")
 	}
 
 	if jsonErr == nil {
@@ -4890,12 +5566,7 @@ func (s *rpcServer) processRequest(request *btcjson.Request, isAdmin bool, close
 				Code:    btcjson.ErrRPCInvalidRequest.Code,
 				Message: "Invalid request: malformed",
 			}
-			msg, err := createMarshalledReply(request.Jsonrpc, request.ID, result, jsonErr)
-			if err != nil {
-				rpcsLog.Errorf("Failed to marshal reply: %v", err)
-				return nil
-			}
-			return msg
+			return newRPCReply(request.Jsonrpc, request.ID, result, jsonErr)
 		}
 
 		// Valid requests with no ID (notifications) must not have a response
@@ -4910,8 +5581,10 @@ func (s *rpcServer) processRequest(request *btcjson.Request, isAdmin bool, close
 		if parsedCmd.err != nil {
 			jsonErr = parsedCmd.err
 		} else {
+			done := s.trackCommand(request.Method, remoteAddr)
 			result, err = s.standardCmdResult(parsedCmd,
 				closeChan)
+			done(err)
 			if err != nil {
 				if rpcErr, ok := err.(*btcjson.RPCError); ok {
 					jsonErr = rpcErr
@@ -4925,18 +5598,15 @@ func (s *rpcServer) processRequest(request *btcjson.Request, isAdmin bool, close
 		}
 	}
 
-	// Marshal the response.
-	msg, err := createMarshalledReply(request.Jsonrpc, request.ID, result, jsonErr)
-	if err != nil {
-		rpcsLog.Errorf("Failed to marshal reply: %v", err)
-		return nil
-	}
-	return msg
+	return newRPCReply(request.Jsonrpc, request.ID, result, jsonErr)
 }
 
 // jsonRPCRead handles reading and responding to RPC messages.
-func (s *rpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request, isAdmin bool) {
-	if atomic.LoadInt32(&s.shutdown) != 0 {
+func (s *rpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request, user *rpcAuthUser) {
+	if s.shuttingDown() {
+		errCode := http.StatusServiceUnavailable
+		http.Error(w, fmt.Sprintf("%d server is shutting down",
+			errCode), errCode)
 		return
 	}
 
@@ -4986,7 +5656,7 @@ func (s *rpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request, isAdmin
 		}
 	}()
 
-	var results []json.RawMessage
+	var results []*rpcReply
 	var batchSize int
 	var batchedRequest bool
 
@@ -4998,7 +5668,7 @@ func (s *rpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request, isAdmin
 	// Process a single request
 	if !batchedRequest {
 		var req btcjson.Request
-		var resp json.RawMessage
+		var resp *rpcReply
 		err = json.Unmarshal(body, &req)
 		if err != nil {
 			jsonErr := &btcjson.RPCError{
@@ -5006,10 +5676,7 @@ func (s *rpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request, isAdmin
 				Message: fmt.Sprintf("Failed to parse request: %v",
 					err),
 			}
-			resp, err = btcjson.MarshalResponse(btcjson.RpcVersion1, nil, nil, jsonErr)
-			if err != nil {
-				rpcsLog.Errorf("Failed to create reply: %v", err)
-			}
+			resp = newRPCReply(btcjson.RpcVersion1, nil, nil, jsonErr)
 		}
 
 		if err == nil {
@@ -5034,7 +5701,7 @@ func (s *rpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request, isAdmin
 			if req.ID == nil && !(cfg.RPCQuirks && req.Jsonrpc == "") {
 				return
 			}
-			resp = s.processRequest(&req, isAdmin, closeChan)
+			resp = s.processRequest(&req, user, r.RemoteAddr, closeChan)
 		}
 
 		if resp != nil {
@@ -5045,7 +5712,7 @@ func (s *rpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request, isAdmin
 	// Process a batched request
 	if batchedRequest {
 		var batchedRequests []interface{}
-		var resp json.RawMessage
+		var resp *rpcReply
 		err = json.Unmarshal(body, &batchedRequests)
 		if err != nil {
 			jsonErr := &btcjson.RPCError{
@@ -5053,10 +5720,7 @@ func (s *rpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request, isAdmin
 				Message: fmt.Sprintf("Failed to parse request: %v",
 					err),
 			}
-			resp, err = btcjson.MarshalResponse(btcjson.RpcVersion2, nil, nil, jsonErr)
-			if err != nil {
-				rpcsLog.Errorf("Failed to create reply: %v", err)
-			}
+			resp = newRPCReply(btcjson.RpcVersion2, nil, nil, jsonErr)
 
 			if resp != nil {
 				results = append(results, resp)
@@ -5070,10 +5734,7 @@ func (s *rpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request, isAdmin
 					Code:    btcjson.ErrRPCInvalidRequest.Code,
 					Message: "Invalid request: empty batch",
 				}
-				resp, err = btcjson.MarshalResponse(btcjson.RpcVersion2, nil, nil, jsonErr)
-				if err != nil {
-					rpcsLog.Errorf("Failed to marshal reply: %v", err)
-				}
+				resp = newRPCReply(btcjson.RpcVersion2, nil, nil, jsonErr)
 
 				if resp != nil {
 					results = append(results, resp)
@@ -5093,10 +5754,7 @@ func (s *rpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request, isAdmin
 							Message: fmt.Sprintf("Invalid request: %v",
 								err),
 						}
-						resp, err = btcjson.MarshalResponse(btcjson.RpcVersion2, nil, nil, jsonErr)
-						if err != nil {
-							rpcsLog.Errorf("Failed to create reply: %v", err)
-						}
+						resp = newRPCReply(btcjson.RpcVersion2, nil, nil, jsonErr)
 
 						if resp != nil {
 							results = append(results, resp)
@@ -5112,10 +5770,7 @@ func (s *rpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request, isAdmin
 							Message: fmt.Sprintf("Invalid request: %v",
 								err),
 						}
-						resp, err = btcjson.MarshalResponse("", nil, nil, jsonErr)
-						if err != nil {
-							rpcsLog.Errorf("Failed to create reply: %v", err)
-						}
+						resp = newRPCReply("", nil, nil, jsonErr)
 
 						if resp != nil {
 							results = append(results, resp)
@@ -5123,7 +5778,7 @@ func (s *rpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request, isAdmin
 						continue
 					}
 
-					resp = s.processRequest(&req, isAdmin, closeChan)
+					resp = s.processRequest(&req, user, r.RemoteAddr, closeChan)
 					if resp != nil {
 						results = append(results, resp)
 					}
@@ -5132,44 +5787,50 @@ func (s *rpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request, isAdmin
 		}
 	}
 
-	var msg = []byte{}
+	// Write the response, compressed if the client supports it.
+	encoding := acceptedEncoding(r.Header.Get("Accept-Encoding"))
+	if encoding != "" {
+		w.Header().Set("Content-Encoding", encoding)
+	}
+	err = s.writeHTTPResponseHeaders(r, w.Header(), http.StatusOK, buf)
+	if err != nil {
+		rpcsLog.Error(err)
+		return
+	}
+	out := compressWriter(buf, encoding)
+	defer func() {
+		if err := out.Close(); err != nil {
+			rpcsLog.Errorf("Failed to finish compressed reply: %v", err)
+		}
+	}()
+
 	if batchedRequest && batchSize > 0 {
 		if len(results) > 0 {
 			// Form the batched response json
-			var buffer bytes.Buffer
-			buffer.WriteByte('[')
+			err = writeString(out, "[")
 			for idx, reply := range results {
-				if idx == len(results)-1 {
-					buffer.Write(reply)
-					buffer.WriteByte(']')
-					break
+				if err == nil && idx > 0 {
+					err = writeString(out, ",")
+				}
+				if err == nil {
+					err = reply.write(out)
 				}
-				buffer.Write(reply)
-				buffer.WriteByte(',')
 			}
-			msg = buffer.Bytes()
+			if err == nil {
+				err = writeString(out, "]")
+			}
 		}
-	}
-
-	if !batchedRequest || batchSize == 0 {
+	} else if len(results) > 0 {
 		// Respond with the first results entry for single requests
-		if len(results) > 0 {
-			msg = results[0]
-		}
+		err = results[0].write(out)
 	}
-
-	// Write the response.
-	err = s.writeHTTPResponseHeaders(r, w.Header(), http.StatusOK, buf)
 	if err != nil {
-		rpcsLog.Error(err)
-		return
-	}
-	if _, err := buf.Write(msg); err != nil {
 		rpcsLog.Errorf("Failed to write marshalled reply: %v", err)
+		return
 	}
 
 	// Terminate with newline to maintain compatibility with Bitcoin Core.
-	if err := buf.WriteByte('\n'); err != nil {
+	if err := writeString(out, "\n"); err != nil {
 		rpcsLog.Errorf("Failed to append terminating newline to reply: %v", err)
 	}
 }
@@ -5208,19 +5869,56 @@ func (s *rpcServer) Start() {
 		// Keep track of the number of connected clients.
 		s.incrementClients()
 		defer s.decrementClients()
-		_, isAdmin, err := s.checkAuth(r, true)
+		user, err := s.checkAuth(r, true)
 		if err != nil {
 			jsonAuthFail(w)
 			return
 		}
 
 		// Read and respond to the request.
-		s.jsonRPCRead(w, r, isAdmin)
+		s.jsonRPCRead(w, r, user)
 	})
 
+	// REST endpoints, which are public.
+	if cfg.REST {
+		rpcServeMux.HandleFunc(restPrefix, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Connection", "close")
+			r.Close = true
+
+			if s.limitConnections(w, r.RemoteAddr) {
+				return
+			}
+			s.incrementClients()
+			defer s.decrementClients()
+
+			s.restRead(w, r)
+		})
+	}
+
+	// Health endpoints, which are public so that load balancers and
+	// orchestrators can probe the node.
+	for path, handler := range map[string]http.HandlerFunc{
+		healthzPath: s.handleHealthz,
+		readyzPath:  s.handleReadyz,
+	} {
+		handler := handler
+		rpcServeMux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Connection", "close")
+			r.Close = true
+
+			if s.limitConnections(w, r.RemoteAddr) {
+				return
+			}
+			s.incrementClients()
+			defer s.decrementClients()
+
+			handler(w, r)
+		})
+	}
+
 	// Websocket endpoint.
 	rpcServeMux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
-		authenticated, isAdmin, err := s.checkAuth(r, false)
+		user, err := s.checkAuth(r, false)
 		if err != nil {
 			jsonAuthFail(w)
 			return
@@ -5237,7 +5935,17 @@ func (s *rpcServer) Start() {
 			http.Error(w, "400 Bad Request.", http.StatusBadRequest)
 			return
 		}
-		s.WebsocketHandler(ws, r.RemoteAddr, authenticated, isAdmin)
+		s.WebsocketHandler(ws, r.RemoteAddr, user)
+	})
+
+	// Server-Sent Events endpoint.
+	rpcServeMux.HandleFunc(ssePath, func(w http.ResponseWriter, r *http.Request) {
+		user, err := s.checkAuth(r, true)
+		if err != nil {
+			jsonAuthFail(w)
+			return
+		}
+		s.sseHandler(w, r, user)
 	})
 
 	for _, listener := range s.cfg.Listeners {
@@ -5251,6 +5959,9 @@ func (s *rpcServer) Start() {
 	}
 
 	s.ntfnMgr.Start()
+
+	s.wg.Add(1)
+	go s.workHandler()
 }
 
 // genCertPair generates a key/cert pair to the paths provided.
@@ -5289,6 +6000,10 @@ type rpcserverPeer interface {
 	// transaction relay.
 	IsTxRelayDisabled() bool
 
+	// IsBlockRelayOnly returns whether or not the peer is a
+	// block-relay-only outbound peer.
+	IsBlockRelayOnly() bool
+
 	// BanScore returns the current integer value that represents how close
 	// the peer is to being banned.
 	BanScore() uint32
@@ -5367,7 +6082,7 @@ type rpcserverConnManager interface {
 	AddRebroadcastInventory(iv *wire.InvVect, data interface{})
 
 	// RelayTransactions generates and relays inventory vectors for all of
-	// the passed transactions to all connected peers.
+	// the passed transactions submitted locally to the connected peers.
 	RelayTransactions(txns []*mempool.TxDesc)
 
 	// NodeAddresses returns an array consisting node addresses which can
@@ -5436,6 +6151,11 @@ type rpcserverConfig struct {
 	// TxMemPool defines the transaction memory pool to interact with.
 	TxMemPool *mempool.TxPool
 
+	// SigCache and HashCache are the caches shared by the validation of
+	// the transactions and blocks.
+	SigCache  *txscript.SigCache
+	HashCache *txscript.HashCache
+
 	// These fields allow the RPC server to interface with mining.
 	//
 	// Generator produces block templates and the CPUMiner solves them using
@@ -5456,6 +6176,19 @@ type rpcserverConfig struct {
 
 	// Services represents the services supported by this node.
 	Services wire.ServiceFlag
+
+	// DiskSpace checks the free space of the volumes of the databases.
+	DiskSpace *diskSpaceMonitor
+
+	// TorController maintains the onion service of the node, if any.
+	TorController *torController
+
+	// BlockCache keeps the blocks recently served to RPC clients and peers.
+	BlockCache *blockCache
+
+	// SlowRPC is the duration beyond which the commands are logged as
+	// slow, or 0 to disable the logging.
+	SlowRPC time.Duration
 }
 
 // newRPCServer returns a new instance of the rpcServer struct.
@@ -5467,18 +6200,14 @@ func newRPCServer(config *rpcserverConfig) (*rpcServer, error) {
 		helpCacher:             newHelpCacher(),
 		requestProcessShutdown: make(chan struct{}),
 		feeEstimator:           config.FeeEstimator,
+		authUsers:              cfg.rpcAuthUsers,
+		activeCmds:             make(map[*rpcActiveCmd]struct{}),
+		methodStats:            make(map[string]*rpcMethodStats),
+		sseSubscribers:         make(map[*sseSubscriber]struct{}),
+		chainSnapshots:         newChainSnapshots(),
+		workUpdates:            make(chan struct{}, 1),
 		quit:                   make(chan int),
 	}
-	if cfg.RPCUser != "" && cfg.RPCPass != "" {
-		login := cfg.RPCUser + ":" + cfg.RPCPass
-		auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
-		rpc.authsha = sha256.Sum256([]byte(auth))
-	}
-	if cfg.RPCLimitUser != "" && cfg.RPCLimitPass != "" {
-		login := cfg.RPCLimitUser + ":" + cfg.RPCLimitPass
-		auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
-		rpc.limitauthsha = sha256.Sum256([]byte(auth))
-	}
 	rpc.ntfnMgr = newWsNotificationManager(&rpc)
 	rpc.cfg.Chain.Subscribe(rpc.handleBlockchainNotification)
 
@@ -5510,6 +6239,8 @@ func (s *rpcServer) handleBlockchainNotification(notification *blockchain.Notifi
 
 		// Notify registered websocket clients of incoming block.
 		s.ntfnMgr.NotifyBlockConnected(block)
+		s.notifyWorkUpdate()
+		s.sseHandleBlockchainNotification(notification)
 
 	case blockchain.NTBlockDisconnected:
 		block, ok := notification.Data.(*btcutil.Block)
@@ -5520,6 +6251,18 @@ func (s *rpcServer) handleBlockchainNotification(notification *blockchain.Notifi
 
 		// Notify registered websocket clients.
 		s.ntfnMgr.NotifyBlockDisconnected(block)
+		s.sseHandleBlockchainNotification(notification)
+
+	case blockchain.NTChainReorganized:
+		reorg, ok := notification.Data.(*blockchain.Reorganization)
+		if !ok {
+			rpcsLog.Warnf("Chain reorganized notification is not a " +
+				"reorganization.")
+			break
+		}
+
+		// Notify registered websocket clients.
+		s.ntfnMgr.NotifyChainReorganized(reorg)
 	}
 }
 
@@ -5528,5 +6271,8 @@ func init() {
 	for key := range claimtrieHandlers {
 		rpcHandlers[key] = claimtrieHandlers[key]
 	}
+	for key := range psbtHandlers {
+		rpcHandlers[key] = psbtHandlers[key]
+	}
 	rand.Seed(time.Now().UnixNano())
 }
@@ -44,18 +44,183 @@ var helpDescsEnUS = map[string]string{
 	"transactioninput-txid": "The hash of the input transaction",
 	"transactioninput-vout": "The specific output of the input transaction to redeem",
 
+	// CreateMultisigCmd help.
+	"createmultisig--synopsis": "Creates a multisig script requiring some of the signatures of the public keys, along with its address.\n" +
+		"With a claim, the output script is rather the multisig script with the claim prefix, which is limited to the keys of a standard bare multisig script.",
+	"createmultisig-nrequired":   "The number of signatures required",
+	"createmultisig-keys":        "The hex-encoded public keys",
+	"createmultisig-addresstype": "The type of the address: legacy, p2sh-segwit or bech32",
+	"createmultisig-claim":       "The claim, update or support prefixing the multisig script",
+
+	// CreateMultisigClaim help.
+	"createmultisigclaim-type":    "The type of the prefix: claim, the default, update or support",
+	"createmultisigclaim-name":    "The name of the claim",
+	"createmultisigclaim-claimid": "The claim ID of the claim updated or supported",
+	"createmultisigclaim-value":   "The hex-encoded value of the claim, or of the support if any",
+
+	// CreateMultiSigResult help.
+	"createmultisigresult-address":      "The address of the multisig script",
+	"createmultisigresult-redeemScript": "The hex-encoded multisig script",
+	"createmultisigresult-scriptPubKey": "The hex-encoded output script of the address, or with the claim prefix",
+	"createmultisigresult-descriptor":   "The output script descriptor of the address, without a claim",
+
 	// CreateRawTransactionCmd help.
 	"createrawtransaction--synopsis": "Returns a new transaction spending the provided inputs and sending to the provided addresses.\n" +
 		"The transaction inputs are not signed in the created transaction.\n" +
-		"The signrawtransaction RPC command provided by wallet must be used to sign the resulting transaction.",
+		"The signrawtransaction RPC command provided by wallet, or signrawtransactionwithkey, must be used to sign the resulting transaction.",
 	"createrawtransaction-inputs":         "The inputs to the transaction",
 	"createrawtransaction-outputs":        "JSON object with the destination addresses as keys and amounts as values",
-	"createrawtransaction-outputs--key":   "address or \"data\"",
-	"createrawtransaction-outputs--value": "value in BTC as floating point number or hex-encoded data for \"data\"",
+	"createrawtransaction-outputs--key":   "address, \"data\", \"claim\", \"update\" or \"support\"",
+	"createrawtransaction-outputs--value": "value in BTC as floating point number, hex-encoded data for \"data\", or an object, or an array of objects, with the name, the claimid of an update or support, the hex-encoded value, the pubkey hash address and the amount in LBC of each claim output",
 	"createrawtransaction-outputs--desc":  "The destination address as the key and the amount in LBC as the value",
 	"createrawtransaction-locktime":       "Locktime value; a non-zero value will also locktime-activate the inputs",
 	"createrawtransaction--result0":       "Hex-encoded bytes of the serialized transaction",
 
+	// BackupChainStateCmd help.
+	"backupchainstate--synopsis": "Writes a consistent copy of the block database to the destination directory while the server keeps running.\n" +
+		"The copy is laid out as the block database directory of the data directory, next to the claimtrie databases written by backupclaimdbs to the same destination.",
+	"backupchainstate-destination": "The directory to write the backup to, relative to the backups directory of the data directory unless absolute",
+
+	// BackupClaimDBsCmd help.
+	"backupclaimdbs--synopsis": "Writes a consistent copy of the claimtrie databases to the destination directory while the server keeps running.\n" +
+		"The copy is laid out as the claim_dbs directory of the data directory. Run it after backupchainstate so that the claimtrie of the backup is not behind its block database.",
+	"backupclaimdbs-destination": "The directory to write the backup to, relative to the backups directory of the data directory unless absolute",
+
+	// CheckDBCmd help.
+	"checkdb--synopsis": "Checks the consistency of the block files with the block database, without repairing it.\n" +
+		"The inconsistent blocks are dropped and downloaded again when the server is restarted, unless the nodbrepair option is set.",
+	"checkdb-level": "How thoroughly to check: none only compares the write cursor with the end of the block files, quick also checks every block lies within its block file, and full also reads every block, which takes a while",
+
+	// CheckDBBadBlock help.
+	"checkdbbadblock-hash":   "The hash of the block",
+	"checkdbbadblock-file":   "The number of the block file of the block",
+	"checkdbbadblock-offset": "The offset of the block in its block file",
+	"checkdbbadblock-reason": "Why the block is inconsistent",
+
+	// CheckDBRepair help.
+	"checkdbrepair-consistentfile":   "The block file the block database was truncated to",
+	"checkdbrepair-consistentoffset": "The offset the block file was truncated to",
+	"checkdbrepair-droppedblocks":    "The number of blocks dropped by the repair",
+
+	// CheckDBResult help.
+	"checkdbresult-level":            "The level of the check",
+	"checkdbresult-consistent":       "Whether the block files are consistent with the block database",
+	"checkdbresult-blocks":           "The number of blocks in the block database",
+	"checkdbresult-writefile":        "The block file of the write cursor of the block database",
+	"checkdbresult-writeoffset":      "The offset of the write cursor in its block file",
+	"checkdbresult-consistentfile":   "The block file of the last consistent point, up to which the blocks are consistent",
+	"checkdbresult-consistentoffset": "The offset of the last consistent point in its block file",
+	"checkdbresult-badblocks":        "The number of inconsistent blocks",
+	"checkdbresult-bad":              "The first 100 inconsistent blocks, in the order of the block files",
+	"checkdbresult-droppedblocks":    "The number of blocks stored from the last consistent point on, which a repair would drop",
+	"checkdbresult-lastrepair":       "The repair of the block database when the server started, if any",
+	"checkdbresult-missingblocks":    "The number of blocks of the main chain dropped by the repair which are still being downloaded again",
+
+	// BackupResult help.
+	"backupresult-path":   "The directory the backup was written to",
+	"backupresult-size":   "The size of the backup in bytes",
+	"backupresult-height": "The height of the backed up claimtrie (backupclaimdbs only)",
+
+	// CreateChainSnapshotCmd help.
+	"createchainsnapshot--synopsis": "Creates a chain snapshot, which is a consistent copy of the block database and the claimtrie databases split in hashed chunks, in the chainsnapshots directory of the data directory.\n" +
+		"The snapshot is served to the trusted nodes cloning this one with downloadchainsnapshot, which must be given the hash of the snapshot.",
+	"createchainsnapshot-name": "The name of the snapshot, made of letters, digits, '.', '_' and '-'",
+
+	// DownloadChainSnapshotCmd help.
+	"downloadchainsnapshot--synopsis": "Starts downloading a chain snapshot from the RPC server of another node, and returns once its manifest is checked against the hash.\n" +
+		"Every chunk is checked against the manifest, and a download interrupted by a shutdown or a failure resumes from the chunks already downloaded when started again. " +
+		"The progress is reported by listchainsnapshots, and the complete snapshot is restored by copying its directories to the network directory of the data directory while the server is stopped.",
+	"downloadchainsnapshot-host":     "The host:port of the RPC server of the node serving the snapshot",
+	"downloadchainsnapshot-user":     "The RPC username of the node serving the snapshot",
+	"downloadchainsnapshot-pass":     "The RPC password of the node serving the snapshot",
+	"downloadchainsnapshot-name":     "The name of the snapshot",
+	"downloadchainsnapshot-hash":     "The hash of the snapshot, as returned by the node serving it, which pins the contents of the snapshot",
+	"downloadchainsnapshot-certfile": "The certificate of the RPC server of the node serving the snapshot, which is otherwise verified against the system roots",
+	"downloadchainsnapshot-notls":    "Connect to the RPC server without TLS, which only suits a secured network",
+
+	// GetChainSnapshotCmd help.
+	"getchainsnapshot--synopsis": "Returns the manifest of a complete chain snapshot along with its hash.",
+	"getchainsnapshot-name":      "The name of the snapshot",
+
+	// GetChainSnapshotChunkCmd help.
+	"getchainsnapshotchunk--synopsis": "Returns a chunk of a complete chain snapshot. The chunks are numbered across the files of the manifest, in order.",
+	"getchainsnapshotchunk-name":      "The name of the snapshot",
+	"getchainsnapshotchunk-index":     "The index of the chunk",
+
+	// ListChainSnapshotsCmd help.
+	"listchainsnapshots--synopsis": "Lists the complete chain snapshots, which are served to other nodes, along with those being downloaded.",
+
+	// VerifyChainSnapshotCmd help.
+	"verifychainsnapshot--synopsis": "Verifies the files of a complete chain snapshot against the hashes of its manifest.",
+	"verifychainsnapshot-name":      "The name of the snapshot",
+
+	// ChainSnapshotFile help.
+	"chainsnapshotfile-path":   "The path of the file, relative to the snapshot directory",
+	"chainsnapshotfile-size":   "The size of the file in bytes",
+	"chainsnapshotfile-chunks": "The hex-encoded SHA-256 hashes of the chunks of the file",
+
+	// ChainSnapshotManifest help.
+	"chainsnapshotmanifest-version":         "The version of the manifest",
+	"chainsnapshotmanifest-network":         "The network of the snapshot",
+	"chainsnapshotmanifest-dbtype":          "The type of the block database of the snapshot",
+	"chainsnapshotmanifest-height":          "The height of the best block when the snapshot was created, which the snapshot is at or above",
+	"chainsnapshotmanifest-blockhash":       "The hash of the best block when the snapshot was created",
+	"chainsnapshotmanifest-claimtrieheight": "The height of the claimtrie of the snapshot",
+	"chainsnapshotmanifest-created":         "The time the snapshot was created, in seconds since the Unix epoch",
+	"chainsnapshotmanifest-chunksize":       "The size of the chunks in bytes; the last chunk of a file may be shorter",
+	"chainsnapshotmanifest-files":           "The files of the snapshot",
+
+	// ChainSnapshotInfo help.
+	"chainsnapshotinfo-name":             "The name of the snapshot",
+	"chainsnapshotinfo-hash":             "The hash of the snapshot",
+	"chainsnapshotinfo-status":           "The status of the snapshot: complete, downloading or failed",
+	"chainsnapshotinfo-network":          "The network of the snapshot",
+	"chainsnapshotinfo-dbtype":           "The type of the block database of the snapshot",
+	"chainsnapshotinfo-height":           "The height of the best block when the snapshot was created",
+	"chainsnapshotinfo-blockhash":        "The hash of the best block when the snapshot was created",
+	"chainsnapshotinfo-created":          "The time the snapshot was created, in seconds since the Unix epoch",
+	"chainsnapshotinfo-size":             "The size of the snapshot in bytes",
+	"chainsnapshotinfo-chunks":           "The number of chunks of the snapshot",
+	"chainsnapshotinfo-downloadedchunks": "The number of chunks downloaded (downloads only)",
+	"chainsnapshotinfo-source":           "The node the snapshot is downloaded from (downloads only)",
+	"chainsnapshotinfo-error":            "The error of the failed download",
+
+	// GetChainSnapshotResult help.
+	"getchainsnapshotresult-name":     "The name of the snapshot",
+	"getchainsnapshotresult-hash":     "The hash of the snapshot, which is the hex-encoded SHA-256 hash of its manifest encoded in JSON",
+	"getchainsnapshotresult-manifest": "The manifest of the snapshot",
+
+	// GetChainSnapshotChunkResult help.
+	"getchainsnapshotchunkresult-index": "The index of the chunk",
+	"getchainsnapshotchunkresult-hash":  "The hex-encoded SHA-256 hash of the chunk",
+	"getchainsnapshotchunkresult-data":  "The base64-encoded chunk",
+
+	// VerifyChainSnapshotResult help.
+	"verifychainsnapshotresult-name":         "The name of the snapshot",
+	"verifychainsnapshotresult-valid":        "Whether all the chunks and files of the snapshot match its manifest",
+	"verifychainsnapshotresult-chunks":       "The number of chunks of the snapshot",
+	"verifychainsnapshotresult-numbadchunks": "The number of chunks which can't be read or don't match their hash",
+	"verifychainsnapshotresult-badchunks":    "The indexes of the first 100 bad chunks",
+	"verifychainsnapshotresult-badfiles":     "The files which are missing or of the wrong size",
+
+	// CaptureProfileCmd help.
+	"captureprofile--synopsis": "Captures profiles of the server for a number of seconds, and writes them to the profiles directory of the data directory or returns them encoded.\n" +
+		"The cpu, block and mutex profiles are recorded during the capture, while the heap, allocs, goroutine and threadcreate profiles are taken at its end.\n" +
+		"The block and mutex profiles accumulate the events of all the captures since the server started.",
+	"captureprofile-profiles": "The profiles to capture, among cpu, heap, allocs, goroutine, threadcreate, block and mutex",
+	"captureprofile-seconds":  "The number of seconds to capture the profiles for, at most 600",
+	"captureprofile-base64":   "Return the profiles encoded in base64 instead of writing them to files",
+
+	// CaptureProfileResult help.
+	"captureprofileresult-seconds":  "The number of seconds the profiles were captured for",
+	"captureprofileresult-profiles": "The captured profiles",
+
+	// CapturedProfile help.
+	"capturedprofile-name": "The name of the profile",
+	"capturedprofile-size": "The size of the profile in bytes",
+	"capturedprofile-path": "The file the profile was written to",
+	"capturedprofile-data": "The profile encoded in base64",
+
 	// ClearBannedCmd help.
 	"clearbanned--synopsis": "Clear all banned IPs.",
 
@@ -115,11 +280,39 @@ var helpDescsEnUS = map[string]string{
 	"decodescriptresult-type":      "The type of the script (e.g. 'pubkeyhash')",
 	"decodescriptresult-addresses": "The bitcoin addresses associated with this script",
 	"decodescriptresult-p2sh":      "The script hash for use in pay-to-script-hash transactions (only present if the provided redeem script is not already a pay-to-script-hash script)",
+	"decodescriptresult-claim":     "The claim prefix of the script (only present if the script has one)",
+	"decodescriptresult-segwit":    "The witness program paying to the script (only present if the script has a witness form)",
+
+	// DecodeScriptClaim help.
+	"decodescriptclaim-type":    "The type of the claim prefix (claim, update or support)",
+	"decodescriptclaim-name":    "The claim name",
+	"decodescriptclaim-claimid": "The ID of the claim updated or supported",
+	"decodescriptclaim-value":   "The hex-encoded claim value",
+	"decodescriptclaim-asm":     "Disassembly of the script which follows the claim prefix",
+	"decodescriptclaim-hex":     "Hex-encoded bytes of the script which follows the claim prefix",
+
+	// DecodeScriptSegwit help.
+	"decodescriptsegwit-asm":         "Disassembly of the witness program",
+	"decodescriptsegwit-hex":         "Hex-encoded bytes of the witness program",
+	"decodescriptsegwit-reqSigs":     "The number of required signatures",
+	"decodescriptsegwit-type":        "The type of the witness program (e.g. 'witness_v0_keyhash')",
+	"decodescriptsegwit-addresses":   "The bitcoin addresses of the witness program",
+	"decodescriptsegwit-p2sh-segwit": "The script hash of the witness program, for use in pay-to-script-hash transactions",
 
 	// DecodeScriptCmd help.
 	"decodescript--synopsis": "Returns a JSON object with information about the provided hex-encoded script.",
 	"decodescript-hexscript": "Hex-encoded script",
 
+	// DeriveAddressesCmd help.
+	"deriveaddresses--synopsis": "Derives the addresses of an output script descriptor.\n" +
+		"The descriptor must be followed by its checksum, which getdescriptorinfo returns.",
+	"deriveaddresses-descriptor": "The output script descriptor, such as wpkh(tpub.../0/*)#checksum",
+	"deriveaddresses-range":      "The indexes of a ranged descriptor to derive, an end index or a [begin,end] pair, both inclusive",
+	"deriveaddresses--result0":   "The addresses of the descriptor, or of each index of its range",
+
+	// DescriptorRange help.
+	"descriptorrange-value": "An end index, or a [begin,end] pair",
+
 	// EstimateFeeCmd help.
 	"estimatefee--synopsis": "Estimate the fee per kilobyte in satoshis " +
 		"required for a transaction to be mined before a certain number of " +
@@ -138,6 +331,19 @@ var helpDescsEnUS = map[string]string{
 	"estimatesmartfee--result0": "Estimated fee per kilobyte in satoshis necessary for a block to " +
 		"be mined in the next ConfTarget blocks.",
 
+	// ExportBlocksCmd help.
+	"exportblocks--synopsis": "Exports the blocks of the main chain to a block archive in the format of bootstrap.dat, written to the exports directory of the data directory.\n" +
+		"The archive can be imported by another node with the loadblock option or the addblock utility.",
+	"exportblocks-startheight": "The height of the first block to export",
+	"exportblocks-endheight":   "The height of the last block to export (default: the best height)",
+
+	// ExportBlocksResult help.
+	"exportblocksresult-path":        "The file the block archive was written to",
+	"exportblocksresult-startheight": "The height of the first exported block",
+	"exportblocksresult-endheight":   "The height of the last exported block",
+	"exportblocksresult-blocks":      "The number of exported blocks",
+	"exportblocksresult-size":        "The size of the block archive in bytes",
+
 	// GenerateCmd help
 	"generate--synopsis": "Generates a set number of blocks (simnet or regtest only) and returns a JSON\n" +
 		" array of their hashes.",
@@ -335,9 +541,12 @@ var helpDescsEnUS = map[string]string{
 	"getblockheaderverboseresult-versionHex":        "The block version in hexadecimal",
 	"getblockheaderverboseresult-merkleroot":        "Root hash of the merkle tree",
 	"getblockheaderverboseresult-time":              "The block time in seconds since 1 Jan 1970 GMT",
+	"getblockheaderverboseresult-mediantime":        "The median block time in seconds since 1 Jan 1970 GMT",
 	"getblockheaderverboseresult-nonce":             "The block nonce",
 	"getblockheaderverboseresult-bits":              "The bits which represent the block difficulty",
 	"getblockheaderverboseresult-difficulty":        "The proof-of-work difficulty as a multiple of the minimum difficulty",
+	"getblockheaderverboseresult-chainwork":         "Expected number of hashes required to produce the chain up to this block (in hex)",
+	"getblockheaderverboseresult-nTx":               "The number of transactions in the block",
 	"getblockheaderverboseresult-previousblockhash": "The hash of the previous block",
 	"getblockheaderverboseresult-nextblockhash":     "The hash of the next block (only if there is one)",
 	"getblockheaderverboseresult-nameclaimroot":     "The hash of the root of the claim trie",
@@ -440,9 +649,40 @@ var helpDescsEnUS = map[string]string{
 	"getcurrentnet--synopsis": "Get bitcoin network the server is running on.",
 	"getcurrentnet--result0":  "The network identifer",
 
+	// GetDBInfoCmd help.
+	"getdbinfo--synopsis": "Returns the I/O statistics of the databases since they were opened: the leveldb or pebble metadata, the flat block files and each Pebble repository of the claimtrie.",
+
+	// GetDBInfoResult help.
+	"getdbinforesult-databases": "The statistics of each database",
+
+	// DBInfo help.
+	"dbinfo-name":           "The name of the database",
+	"dbinfo-engine":         "The storage engine of the database (leveldb, flatfile or pebble)",
+	"dbinfo-size":           "The size of the database on disk in bytes",
+	"dbinfo-read_bytes":     "The bytes read from disk, only by the compactions for pebble",
+	"dbinfo-write_bytes":    "The bytes written to disk",
+	"dbinfo-compactions":    "The number of compactions",
+	"dbinfo-flushes":        "The number of flushes of the memtable",
+	"dbinfo-open_files":     "The number of open files",
+	"dbinfo-cache_hits":     "The lookups served by the cache, which is the cache of the changes yet to be flushed for the metadata and the block cache for the claimtrie",
+	"dbinfo-cache_misses":   "The lookups which missed the cache",
+	"dbinfo-cache_hit_rate": "The ratio of the lookups which hit the cache",
+
+	// GetDescriptorInfoCmd help.
+	"getdescriptorinfo--synopsis":  "Analyses an output script descriptor.",
+	"getdescriptorinfo-descriptor": "The output script descriptor, whose checksum is optional",
+
+	// GetDescriptorInfoResult help.
+	"getdescriptorinforesult-descriptor":     "The canonical form of the descriptor, with its public keys and its checksum",
+	"getdescriptorinforesult-checksum":       "The checksum of the descriptor as it was passed",
+	"getdescriptorinforesult-isrange":        "Whether the descriptor is ranged",
+	"getdescriptorinforesult-issolvable":     "Whether the descriptor has the information to sign its scripts given the private keys",
+	"getdescriptorinforesult-hasprivatekeys": "Whether the descriptor has a private key",
+
 	// GetDifficultyCmd help.
-	"getdifficulty--synopsis": "Returns the proof-of-work difficulty as a multiple of the minimum difficulty.",
-	"getdifficulty--result0":  "The difficulty",
+	"getdifficulty--synopsis":    "Returns the proof-of-work difficulty as a multiple of the minimum difficulty, as of the best block or the block with the given height or hash.",
+	"getdifficulty-hashorheight": "The hash or height of the block, instead of the best block",
+	"getdifficulty--result0":     "The difficulty",
 
 	// GetGenerateCmd help.
 	"getgenerate--synopsis": "Returns if the server is set to generate coins (mine) or not.",
@@ -503,6 +743,43 @@ var helpDescsEnUS = map[string]string{
 	"getmempoolinforesult-minrelaytxfee":    "Current minimum relay fee for transactions",
 	"getmempoolinforesult-unbroadcastcount": "Current number of transactions that haven't passed initial broadcast yet",
 
+	// GetMemoryInfoCmd help.
+	"getmemoryinfo--synopsis": "Returns the memory usage of the Go runtime, the process and the caches.",
+
+	// GetMemoryInfoResult help.
+	"getmemoryinforesult-runtime": "The memory statistics of the Go runtime",
+	"getmemoryinforesult-process": "The resource usage of the process",
+	"getmemoryinforesult-caches":  "The sizes of the caches",
+
+	// MemoryInfoRuntime help.
+	"memoryinforuntime-alloc":          "The bytes of allocated heap objects",
+	"memoryinforuntime-total_alloc":    "The cumulative bytes allocated for heap objects",
+	"memoryinforuntime-sys":            "The bytes of memory obtained from the OS",
+	"memoryinforuntime-heap_inuse":     "The bytes in in-use heap spans",
+	"memoryinforuntime-heap_idle":      "The bytes in idle heap spans",
+	"memoryinforuntime-heap_released":  "The bytes of idle heap spans returned to the OS",
+	"memoryinforuntime-heap_objects":   "The number of allocated heap objects",
+	"memoryinforuntime-stack_inuse":    "The bytes in stack spans",
+	"memoryinforuntime-num_gc":         "The number of completed garbage collections",
+	"memoryinforuntime-last_gc":        "The time of the last garbage collection in seconds since 1 Jan 1970 GMT",
+	"memoryinforuntime-pause_total_ns": "The cumulative time garbage collections stopped the world in nanoseconds",
+	"memoryinforuntime-goroutines":     "The number of goroutines",
+
+	// MemoryInfoProcess help.
+	"memoryinfoprocess-rss":        "The resident set size of the process in bytes",
+	"memoryinfoprocess-vms":        "The virtual memory size of the process in bytes",
+	"memoryinfoprocess-open_files": "The number of file descriptors opened by the process",
+
+	// MemoryInfoCaches help.
+	"memoryinfocaches-sigcache_entries":     "The number of entries in the signature cache",
+	"memoryinfocaches-sigcache_max_entries": "The maximum number of entries in the signature cache",
+	"memoryinfocaches-sigcache_hits":        "The number of signatures found in the signature cache since the server started",
+	"memoryinfocaches-sigcache_misses":      "The number of signatures not found in the signature cache since the server started",
+	"memoryinfocaches-hashcache_entries":    "The number of transactions in the sighash cache",
+	"memoryinfocaches-dbcache_bytes":        "The size of the database changes waiting in the cache to be flushed, including the unspent transaction outputs",
+	"memoryinfocaches-dbcache_max_bytes":    "The size of the database cache which triggers a flush",
+	"memoryinfocaches-claimtrie_nodes":      "The number of nodes of the claimtrie merkle trie held in memory",
+
 	// GetMiningInfoResult help.
 	"getmininginforesult-blocks":             "Height of the latest best block",
 	"getmininginforesult-currentblocksize":   "Size of the latest best block",
@@ -522,6 +799,44 @@ var helpDescsEnUS = map[string]string{
 	"mempoolfees-ancestor":   "Modified fees (see above) of in-mempool ancestors (including this one) in LBC",
 	"mempoolfees-descendant": "modified fees (see above) of in-mempool descendants (including this one) in LBC",
 
+	// GetLatencyStatsCmd help.
+	"getlatencystats--synopsis": "Returns the latency percentiles of the RPC methods and of the connection of the recent blocks, in microseconds.",
+
+	// GetLatencyStatsResult help.
+	"getlatencystatsresult-rpc":    "The latencies of the RPC methods which were called, sorted by method",
+	"getlatencystatsresult-blocks": "The latencies of the connection of the recent blocks to the main chain",
+
+	// LatencyStats help.
+	"latencystats-samples": "The number of recent latencies the percentiles are computed from",
+	"latencystats-p50":     "The median latency",
+	"latencystats-p90":     "The 90th percentile of the latencies",
+	"latencystats-p99":     "The 99th percentile of the latencies",
+	"latencystats-max":     "The largest latency",
+
+	// RPCMethodLatency help.
+	"rpcmethodlatency-method":  "The name of the command, or the full method name of gRPC calls",
+	"rpcmethodlatency-calls":   "The number of calls since the server started",
+	"rpcmethodlatency-errors":  "The number of calls which failed",
+	"rpcmethodlatency-latency": "The percentiles of the latencies of the recent calls",
+
+	// BlockLatency help.
+	"blocklatency-total":     "The total time spent connecting the blocks",
+	"blocklatency-validate":  "The time spent checking the blocks may be connected, including the scripts",
+	"blocklatency-scripts":   "The time spent validating the scripts of the blocks",
+	"blocklatency-claimtrie": "The time spent updating the claimtrie",
+	"blocklatency-db":        "The time spent writing the blocks to the database, including the indexes",
+	"blocklatency-slowest":   "The breakdown of the slowest recent blocks, slowest first",
+
+	// BlockTimings help.
+	"blocktimings-hash":      "The hash of the block",
+	"blocktimings-height":    "The height of the block",
+	"blocktimings-time":      "The time the block was connected in seconds since 1 Jan 1970 GMT",
+	"blocktimings-total":     "The total time spent connecting the block",
+	"blocktimings-validate":  "The time spent checking the block may be connected, including the scripts",
+	"blocktimings-scripts":   "The time spent validating the scripts of the block",
+	"blocktimings-claimtrie": "The time spent updating the claimtrie",
+	"blocktimings-db":        "The time spent writing the block to the database, including the indexes",
+
 	// GetMempoolEntryCmd help.
 	"getmempoolentry--synopsis": "Returns mempool data for given transaction.",
 	"getmempoolentry-txid":      "The hash of the transaction",
@@ -552,6 +867,7 @@ var helpDescsEnUS = map[string]string{
 	"getnetworkhashps--synopsis": "Returns the estimated network hashes per second for the block heights provided by the parameters.",
 	"getnetworkhashps-blocks":    "The number of blocks, or -1 for blocks since last difficulty change",
 	"getnetworkhashps-height":    "Perform estimate ending with this height or -1 for current best chain block height",
+	"getnetworkhashps-hash":      "Perform estimate ending with the block of this hash instead of the height",
 	"getnetworkhashps--result0":  "Estimated hashes per second",
 
 	// GetNetworkInfo help.
@@ -589,6 +905,45 @@ var helpDescsEnUS = map[string]string{
 	"getnodeaddressesresult-address":  "The address of the node",
 	"getnodeaddressesresult-port":     "The port of the node",
 
+	// AddrManAddress help.
+	"addrmanaddress-addr":        "The address of the node (ip:port, [ip]:port or onion:port)",
+	"addrmanaddress-services":    "The services offered",
+	"addrmanaddress-src":         "The address of the peer the address was learned from",
+	"addrmanaddress-srcservices": "The services offered by the peer the address was learned from",
+	"addrmanaddress-timestamp":   "The time the node was last seen in seconds since 1 Jan 1970 GMT",
+	"addrmanaddress-attempts":    "The number of connection attempts since the last success",
+	"addrmanaddress-lastattempt": "The time of the last connection attempt in seconds since 1 Jan 1970 GMT, 0 if none",
+	"addrmanaddress-lastsuccess": "The time of the last successful connection in seconds since 1 Jan 1970 GMT, 0 if none",
+	"addrmanaddress-tried":       "Whether the address is in the tried table instead of the new table",
+	"addrmanaddress-buckets":     "The buckets of the address in its table, specific to the node",
+
+	// AddrManPeers help.
+	"addrmanpeers-version":   "The version of the format of peers.json",
+	"addrmanpeers-addresses": "The known addresses",
+
+	// ExportAddrManCmd help.
+	"exportaddrman--synopsis": "Returns the addresses known to the address manager, in the format of peers.json without its secret key.",
+
+	// AddrManCounts help.
+	"addrmancounts-new":   "The number of addresses in the new table",
+	"addrmancounts-tried": "The number of addresses in the tried table",
+	"addrmancounts-total": "The number of addresses in both tables",
+
+	// GetAddrManInfoResult help.
+	"getaddrmaninforesult-ipv4":         "The addresses of the IPv4 network",
+	"getaddrmaninforesult-ipv6":         "The addresses of the IPv6 network",
+	"getaddrmaninforesult-onion":        "The addresses of the Tor network",
+	"getaddrmaninforesult-all_networks": "The addresses of all the networks",
+
+	// GetAddrManInfoCmd help.
+	"getaddrmaninfo--synopsis": "Returns the number of addresses in the new and tried tables of the address manager by network.",
+
+	// ImportAddrManCmd help.
+	"importaddrman--synopsis": "Adds the unknown routable addresses of an export of the address manager, such as the result of exportaddrman on another node, to the new table.\n" +
+		"The tables and buckets of the imported addresses are ignored.",
+	"importaddrman-peers":    "The export of the address manager",
+	"importaddrman--result0": "The number of addresses added",
+
 	// GetNodeAddressesCmd help.
 	"getnodeaddresses--synopsis": "Return known addresses which can potentially be used to find new nodes in the network",
 	"getnodeaddresses-count":     "How many addresses to return. Limited to the smaller of 2500 or 23% of all known addresses",
@@ -611,6 +966,7 @@ var helpDescsEnUS = map[string]string{
 	"getpeerinforesult-version":        "The protocol version of the peer",
 	"getpeerinforesult-subver":         "The user agent of the peer",
 	"getpeerinforesult-inbound":        "Whether or not the peer is an inbound connection",
+	"getpeerinforesult-blockrelayonly": "Whether or not the peer is an outbound connection relaying only blocks, which exchanges neither transactions nor addresses",
 	"getpeerinforesult-startingheight": "The latest block height the peer knew about when the connection was established",
 	"getpeerinforesult-currentheight":  "The current height of the peer",
 	"getpeerinforesult-banscore":       "The ban score",
@@ -638,6 +994,57 @@ var helpDescsEnUS = map[string]string{
 	"getrawmempool--condition1": "verbose=true",
 	"getrawmempool--result0":    "Array of transaction hashes",
 
+	// GetRPCInfoCmd help.
+	"getrpcinfo--synopsis": "Returns details of the RPC server, including the commands being processed.",
+
+	// GetRPCInfoResult help.
+	"getrpcinforesult-active_commands":   "The commands being processed, oldest first",
+	"getrpcinforesult-websocket_clients": "The notification queues of the websocket clients",
+	"getrpcinforesult-logpath":           "The path of the log file",
+
+	// RPCWebsocketClient help.
+	"rpcwebsocketclient-client":      "The address of the client",
+	"rpcwebsocketclient-queued":      "The number of notifications waiting to be sent",
+	"rpcwebsocketclient-queuedbytes": "The size of the notifications waiting to be sent in bytes",
+	"rpcwebsocketclient-peakqueued":  "The largest number of notifications that waited to be sent",
+	"rpcwebsocketclient-sent":        "The number of notifications sent",
+	"rpcwebsocketclient-dropped":     "The number of notifications dropped by the queue policy",
+	"rpcwebsocketclient-slow":        "Whether the client is falling behind the notifications",
+
+	// RPCActiveCommand help.
+	"rpcactivecommand-method":   "The name of the command, or the full method name of gRPC calls",
+	"rpcactivecommand-duration": "The time spent on the command so far in microseconds",
+	"rpcactivecommand-client":   "The address of the client that issued the command",
+
+	// GetSystemInfoCmd help.
+	"getsysteminfo--synopsis": "Returns the version and build of the server, its network, and the sizes of its data on disk.",
+
+	// GetSystemInfoResult help.
+	"getsysteminforesult-version":     "The version of the server",
+	"getsysteminforesult-goversion":   "The version of Go the server was built with",
+	"getsysteminforesult-os":          "The operating system the server runs on",
+	"getsysteminforesult-arch":        "The architecture the server runs on",
+	"getsysteminforesult-buildtags":   "The build tags the server was built with",
+	"getsysteminforesult-network":     "The network the server runs on",
+	"getsysteminforesult-datadir":     "The directory of the data of the network",
+	"getsysteminforesult-blocksdir":   "The directory of the block files of the network when stored apart from the data",
+	"getsysteminforesult-datadirsize": "The sizes of the data on disk",
+	"getsysteminforesult-diskspace":   "The free space of the volumes of the block files, the chain state and the claimtrie, as of the last check",
+
+	// DataDirSizes help.
+	"datadirsizes-blocks":     "The size of the block files in bytes",
+	"datadirsizes-chainstate": "The approximate size of the chain state and the block index in bytes",
+	"datadirsizes-claim_dbs":  "The size of the claimtrie databases in bytes",
+	"datadirsizes-indexes":    "The approximate size of the optional indexes in bytes",
+	"datadirsizes-total":      "The size of the whole data directory, and of the block files when stored apart, in bytes",
+
+	// DiskSpaceResult help.
+	"diskspaceresult-name":   "The name of the database: blocks, chainstate or claim_dbs",
+	"diskspaceresult-path":   "The directory of the database",
+	"diskspaceresult-free":   "The free space of its volume in bytes",
+	"diskspaceresult-total":  "The total space of its volume in bytes",
+	"diskspaceresult-status": "ok, low when below warnfreespace, or full when below minfreespace, in which case blocks are not accepted",
+
 	// GetRawTransactionCmd help.
 	"getrawtransaction--synopsis":   "Returns information about a transaction given its hash.",
 	"getrawtransaction-txid":        "The hash of the transaction",
@@ -646,6 +1053,14 @@ var helpDescsEnUS = map[string]string{
 	"getrawtransaction--condition1": "verbose=true",
 	"getrawtransaction--result0":    "Hex-encoded bytes of the serialized transaction",
 
+	// GetRawTransactionsCmd help.
+	"getrawtransactions--synopsis":   "Returns information about transactions given their hashes, in the same order, with null for the unknown transactions.",
+	"getrawtransactions-txids":       "The hashes of the transactions (max 10000)",
+	"getrawtransactions-verbose":     "Specifies the transactions are returned as JSON objects instead of hex-encoded strings",
+	"getrawtransactions--condition0": "verbose=false",
+	"getrawtransactions--condition1": "verbose=true",
+	"getrawtransactions--result0":    "Hex-encoded bytes of the serialized transactions",
+
 	// GetTxOutResult help.
 	"gettxoutresult-bestblock":     "The block hash that contains the transaction output",
 	"gettxoutresult-confirmations": "The number of confirmations",
@@ -660,6 +1075,38 @@ var helpDescsEnUS = map[string]string{
 	"gettxout-vout":           "The index of the output",
 	"gettxout-includemempool": "Include the mempool when true",
 
+	// GetVerifyChainInfoCmd help.
+	"getverifychaininfo--synopsis": "Returns the progress of the running chain verification started by verifychain, or the result of the last one.",
+
+	// GetVerifyChainInfoResult help.
+	"getverifychaininforesult-status":         "The status of the verification (none, running, valid or invalid)",
+	"getverifychaininforesult-checklevel":     "The check level of the verification",
+	"getverifychaininforesult-numblocks":      "The number of blocks to check, or 0 for all of them",
+	"getverifychaininforesult-bestheight":     "The height of the best block when the verification started",
+	"getverifychaininforesult-height":         "The height of the last block checked",
+	"getverifychaininforesult-blocks":         "The number of blocks checked",
+	"getverifychaininforesult-totalblocks":    "The number of blocks to check",
+	"getverifychaininforesult-replayedblocks": "The number of blocks disconnected from the UTXO set in memory, which stops once too many outputs are held",
+	"getverifychaininforesult-claimnames":     "The number of claimtrie names rehashed",
+	"getverifychaininforesult-progress":       "The fraction of the blocks checked",
+	"getverifychaininforesult-starttime":      "The time the verification started in seconds since 1 Jan 1970 GMT",
+	"getverifychaininforesult-elapsed":        "The seconds the verification has been running, or ran for",
+	"getverifychaininforesult-error":          "Why the chain is invalid",
+
+	// GetVersionBitsCmd help.
+	"getversionbits--synopsis": "Returns the version of the next block template along with the state of the rule change deployments and whether the template signals for them.",
+
+	// GetVersionBitsResult help.
+	"getversionbitsresult-version":     "The version of the next block template",
+	"getversionbitsresult-deployments": "The rule change deployments of the chain",
+
+	// VersionBitsDeployment help.
+	"versionbitsdeployment-name":      "The name of the deployment",
+	"versionbitsdeployment-bit":       "The bit of the block version the deployment is signaled with",
+	"versionbitsdeployment-status":    "The threshold state of the deployment for the next block (defined, started, lockedin, active or failed)",
+	"versionbitsdeployment-signal":    "Whether the block templates signal for the deployment (default, signal or nosignal)",
+	"versionbitsdeployment-signaling": "Whether the next block template signals for the deployment",
+
 	// HelpCmd help.
 	"help--synopsis":   "Returns a list of all commands or help for a specified command.",
 	"help-command":     "The command to retrieve help for",
@@ -726,12 +1173,46 @@ var helpDescsEnUS = map[string]string{
 	"setgenerate-generate":     "Use true to enable generation, false to disable it",
 	"setgenerate-genproclimit": "The number of processors (cores) to limit generation to or -1 for default",
 
+	// SetSigCacheSizeCmd help.
+	"setsigcachesize--synopsis":  "Changes the maximum number of entries in the signature cache until the server restarts, evicting random entries when it holds more.",
+	"setsigcachesize-maxentries": "The maximum number of entries in the signature cache -- 0 disables it",
+
+	// SetVersionBitsCmd help.
+	"setversionbits--synopsis": "Sets whether the block templates signal for a rule change deployment until the server restarts, and returns the same as getversionbits.\n" +
+		"Signaling can only be overridden for the deployments activated by signaling which haven't expired, and applies while they are started or locked in.",
+	"setversionbits-deployment": "The name of the deployment, among dummy, csv, segwit and taproot",
+	"setversionbits-signal":     "Signal for the deployment (signal), don't signal for it (nosignal) or signal as the chain expects (default)",
+
 	// SignMessageWithPrivKeyCmd help.
-	"signmessagewithprivkey--synopsis": "Sign a message with the private key of an address",
+	"signmessagewithprivkey--synopsis": "Sign a message, prefixed with the LBRYcrd signed message header, with the private key of an address",
 	"signmessagewithprivkey-privkey":   "The private key to sign the message with",
 	"signmessagewithprivkey-message":   "The message to create a signature of",
 	"signmessagewithprivkey--result0":  "The signature of the message encoded in base 64",
 
+	// SignRawTransactionWithKeyCmd help.
+	"signrawtransactionwithkey--synopsis": "Signs the inputs of a raw transaction with the provided private keys.\n" +
+		"The outputs spent by the inputs are looked up in the memory pool and the UTXO set unless they are provided as prevtxs.\n" +
+		"Claim, support and update outputs are signed as their inner script, and witness inputs need the amount of their output.",
+	"signrawtransactionwithkey-rawtx":          "The hex-encoded transaction",
+	"signrawtransactionwithkey-privkeys":       "The private keys to sign with, in wallet import format",
+	"signrawtransactionwithkey-prevtxs":        "The outputs spent by the transaction which aren't in the memory pool or the UTXO set",
+	"signrawtransactionwithkey-sighashtype":    "The signature hash type: ALL, NONE or SINGLE, optionally followed by |ANYONECANPAY",
+	"rawtxwitnessinput-txid":                   "The hash of the transaction of the output",
+	"rawtxwitnessinput-vout":                   "The index of the output",
+	"rawtxwitnessinput-scriptPubKey":           "The hex-encoded public key script of the output",
+	"rawtxwitnessinput-redeemScript":           "The hex-encoded redeem script of a pay-to-script-hash output",
+	"rawtxwitnessinput-witnessScript":          "The hex-encoded witness script of a pay-to-witness-script-hash output",
+	"rawtxwitnessinput-amount":                 "The value of the output in LBC, required for witness outputs",
+	"signrawtransactionwithkeyresult-hex":      "The hex-encoded transaction with the signatures",
+	"signrawtransactionwithkeyresult-complete": "Whether all inputs are signed",
+	"signrawtransactionwithkeyresult-errors":   "The inputs which couldn't be signed, if any",
+	"signrawtransactionerror-txid":             "The hash of the transaction of the output spent by the input",
+	"signrawtransactionerror-vout":             "The index of the output spent by the input",
+	"signrawtransactionerror-witness":          "The hex-encoded items of the witness of the input",
+	"signrawtransactionerror-scriptSig":        "The hex-encoded signature script of the input",
+	"signrawtransactionerror-sequence":         "The sequence number of the input",
+	"signrawtransactionerror-error":            "Why the input couldn't be signed or verified",
+
 	// StopCmd help.
 	"stop--synopsis": "Shutdown lbcd.",
 	"stop--result0":  "The string 'lbcd stopping.'",
@@ -754,24 +1235,41 @@ var helpDescsEnUS = map[string]string{
 	"validateaddresschainresult-iswitness":       "If the address is a witness address",
 	"validateaddresschainresult-witness_version": "The version number of the witness program",
 	"validateaddresschainresult-witness_program": "The hex value of the witness program",
+	"validateaddresschainresult-scriptPubKey":    "The hex-encoded script paying to the address, which follows the claim prefix of the claims held by the address",
+	"validateaddresschainresult-hasactiveclaims": "Whether the address holds a claim which is active (only when includeclaims is true)",
+	"validateaddresschainresult-claims":          "The claims and supports held by the unspent outputs of the address (only when includeclaims is true)",
+
+	// AddressClaimResult help.
+	"addressclaimresult-name":      "The name of the claim",
+	"addressclaimresult-claimid":   "The claim ID of the claim, or of the claim supported",
+	"addressclaimresult-txid":      "The hash of the transaction of the output",
+	"addressclaimresult-n":         "The index of the output in the transaction",
+	"addressclaimresult-amount":    "The amount of the output in dewies",
+	"addressclaimresult-issupport": "Whether the output is a support",
+	"addressclaimresult-isactive":  "Whether the claim or support is active in the claimtrie",
 
 	// ValidateAddressCmd help.
-	"validateaddress--synopsis": "Verify an address is valid.",
-	"validateaddress-address":   "Bitcoin address to validate",
+	"validateaddress--synopsis":     "Verify an address is valid.",
+	"validateaddress-address":       "Bitcoin address to validate",
+	"validateaddress-includeclaims": "Whether to return the claims and supports held by the address, which requires the address index (--addrindex)",
 
 	// VerifyChainCmd help.
 	"verifychain--synopsis": "Verifies the block chain database.\n" +
 		"The actual checks performed by the checklevel parameter are implementation specific.\n" +
 		"For lbcd this is:\n" +
 		"checklevel=0 - Look up each block and ensure it can be loaded from the database.\n" +
-		"checklevel=1 - Perform basic context-free sanity checks on each block.",
-	"verifychain-checklevel": "How thorough the block verification is",
-	"verifychain-checkdepth": "The number of blocks to check",
+		"checklevel=1 - Perform basic context-free sanity checks on each block.\n" +
+		"checklevel=2 - Check the undo data of each block matches its inputs.\n" +
+		"checklevel=3 - Disconnect each block from the UTXO set in memory with its undo data, checking the outputs it created are unspent and the ones it spent are restored.\n" +
+		"checklevel=4 - Check the claimtrie hash at the height of each block, and recompute the claimtrie hash at the best block from its claims.\n" +
+		"The verification runs in the background until it completes, even when the client disconnects, and its progress is returned by getverifychaininfo.",
+	"verifychain-checklevel": "How thorough the block verification is, clamped to 0 through 4",
+	"verifychain-checkdepth": "The number of blocks to check from the best block, or 0 for all of them",
 	"verifychain--result0":   "Whether or not the chain verified",
 
 	// VerifyMessageCmd help.
-	"verifymessage--synopsis": "Verify a signed message.",
-	"verifymessage-address":   "The bitcoin address to use for the signature",
+	"verifymessage--synopsis": "Verify a message signed with either the LBRYcrd or the legacy Bitcoin signed message header.",
+	"verifymessage-address":   "The pay-to-pubkey-hash address to use for the signature, or the hex-encoded claim script paying to it",
 	"verifymessage-signature": "The base-64 encoded signature provided by the signer",
 	"verifymessage-message":   "The signed message",
 	"verifymessage--result0":  "Whether or not the signature verified",
@@ -783,7 +1281,8 @@ var helpDescsEnUS = map[string]string{
 	"sessionresult-sessionid": "The unique session ID for a client's websocket connection.",
 
 	// NotifyBlocksCmd help.
-	"notifyblocks--synopsis": "Request notifications for whenever a block is connected or disconnected from the main (best) chain.",
+	"notifyblocks--synopsis":  "Request notifications for whenever a block is connected or disconnected from the main (best) chain.",
+	"notifyblocks-rescanfrom": "The height or hash of the last block seen by the client, whose following blocks are notified before resuming live delivery, disconnecting it first if it is no longer in the main chain",
 
 	// StopNotifyBlocksCmd help.
 	"stopnotifyblocks--synopsis": "Cancel registered notifications for whenever a block is connected or disconnected from the main (best) chain.",
@@ -795,6 +1294,22 @@ var helpDescsEnUS = map[string]string{
 	// StopNotifyNewTransactionsCmd help.
 	"stopnotifynewtransactions--synopsis": "Stop sending either a txaccepted or a txacceptedverbose notification when a new transaction is accepted into the mempool.",
 
+	// NotifyWorkCmd help.
+	"notifywork--synopsis": "Send a work notification with the block template whenever a new one is generated, after the best chain changes or the mempool changes and enough time passed.\n" +
+		"The block template is the one of getblocktemplate in coinbasevalue mode, and the current one is sent immediately when available.",
+	"notifywork-deltas": "Send a workdelta notification, only listing the transactions that changed, instead of the full template when the previous block is the same as the one of the previous notification",
+
+	// StopNotifyWorkCmd help.
+	"stopnotifywork--synopsis": "Stop sending work notifications.",
+
+	// NotifyClaimsCmd help.
+	"notifyclaims--synopsis": "Send claimadded and claimupdated notifications of the claims created and updated by the blocks connected to the main chain, and nametakeover notifications of the names they take over.\n" +
+		"Calling it again replaces the names the notifications are restricted to.",
+	"notifyclaims-names": "The names to send notifications about, all of them when omitted or empty",
+
+	// StopNotifyClaimsCmd help.
+	"stopnotifyclaims--synopsis": "Stop sending claimadded, claimupdated and nametakeover notifications.",
+
 	// NotifyReceivedCmd help.
 	"notifyreceived--synopsis": "Send a recvtx notification when a transaction added to mempool or appears in a newly-attached block contains a txout pkScript sending to any of the passed addresses.\n" +
 		"Matching outpoints are automatically registered for redeemingtx notifications.",
@@ -817,10 +1332,12 @@ var helpDescsEnUS = map[string]string{
 	"stopnotifyspent-outpoints": "List of transaction outpoints to stop monitoring.",
 
 	// LoadTxFilterCmd help.
-	"loadtxfilter--synopsis": "Load, add to, or reload a websocket client's transaction filter for mempool transactions, new blocks and rescanblocks.",
-	"loadtxfilter-reload":    "Load a new filter instead of adding data to an existing one",
-	"loadtxfilter-addresses": "Array of addresses to add to the transaction filter",
-	"loadtxfilter-outpoints": "Array of outpoints to add to the transaction filter",
+	"loadtxfilter--synopsis":  "Load, add to, or reload a websocket client's transaction filter for mempool transactions, new blocks and rescanblocks.",
+	"loadtxfilter-reload":     "Load a new filter instead of adding data to an existing one",
+	"loadtxfilter-addresses":  "Array of addresses to add to the transaction filter",
+	"loadtxfilter-outpoints":  "Array of outpoints to add to the transaction filter",
+	"loadtxfilter-claimnames": "Array of claim names whose claims, updates and supports are added to the transaction filter, matched in their normalized form",
+	"loadtxfilter-claimids":   "Array of claim IDs whose claim, updates and supports are added to the transaction filter",
 
 	// Rescan help.
 	"rescan--synopsis": "Rescan block chain for transactions to addresses.\n" +
@@ -889,6 +1406,27 @@ var helpDescsEnUS = map[string]string{
 	"getclaimsfornameresult-lasttakeoverheight": "Height of the most recent name takeover",
 	"getclaimsfornameresult-hash":               "Hash of the requested block",
 
+	"getvalueforname--synopsis":                "Look up the claim owning the given name as it stands at a given block",
+	"getvalueforname-name":                     "Requested name for lookup",
+	"getvalueforname-hashorheight":             "Requested block hash or height; default to tip",
+	"getvalueforname-includevalues":            "Return the metadata and address",
+	"getvaluefornameresult-claim":              "The claim owning the name",
+	"getvaluefornameresult-normalizedname":     "Lower-case version of the passed-in name",
+	"getvaluefornameresult-height":             "Height of the requested block",
+	"getvaluefornameresult-lasttakeoverheight": "Height of the most recent name takeover",
+	"getvaluefornameresult-hash":               "Hash of the requested block",
+
+	"getnameproof--synopsis":            "Returns the merkle proof of the claims of the given name against the claimtrie hash of the tip; applying the pairs in order to the claims hash yields the claimtrie hash",
+	"getnameproof-name":                 "Requested name for the proof",
+	"getnameproofresult-normalizedname": "Lower-case version of the passed-in name",
+	"getnameproofresult-blockhash":      "Hash of the tip",
+	"getnameproofresult-height":         "Height of the tip",
+	"getnameproofresult-claimtrie":      "The claimtrie hash of the tip",
+	"getnameproofresult-claimshash":     "The hash of the claims of the name",
+	"getnameproofresult-pairs":          "The branches from the claims hash to the claimtrie hash",
+	"proofpairresult-odd":               "The branch is on the left",
+	"proofpairresult-hash":              "The hash of the branch",
+
 	"getchangesinblock--synopsis":    "Returns a list of names affected by a given block",
 	"getchangesinblockresult-names":  "Names that changed (or were at least checked for change) on the given height",
 	"getchangesinblockresult-height": "Height that was requested",
@@ -927,6 +1465,118 @@ var helpDescsEnUS = map[string]string{
 	"normalize--result0":  "The normalized name",
 	"normalize-name":      "The string to be normalized",
 
+	"simulateclaimsupport--synopsis": "Simulates a support for a claim of a name included in the next block, with no other change to the name, and returns whether and when it would make the claim the best claim of the name, along with the smallest support which would",
+	"simulateclaimsupport-name":      "The name of the claim",
+	"simulateclaimsupport-claimid":   "The ID of the claim to support",
+	"simulateclaimsupport-amount":    "The amount of the support in dewies",
+
+	"simulateclaimsupportresult-hash":            "Hash of the tip",
+	"simulateclaimsupportresult-height":          "Height of the tip",
+	"simulateclaimsupportresult-normalizedname":  "Lower-case version of the passed-in name",
+	"simulateclaimsupportresult-claimid":         "The ID of the supported claim",
+	"simulateclaimsupportresult-amount":          "The amount of the support in dewies",
+	"simulateclaimsupportresult-isbest":          "Whether the claim is the best claim of the name at the tip",
+	"simulateclaimsupportresult-wouldbebest":     "Whether the claim would be the best claim once the support and the claim are active",
+	"simulateclaimsupportresult-supportheight":   "The height of the block the support is included in",
+	"simulateclaimsupportresult-validatheight":   "The height the support would activate at, delayed unless it supports the best claim",
+	"simulateclaimsupportresult-takeoverheight":  "The height the claim would have taken over the name at, when it would be the best claim",
+	"simulateclaimsupportresult-effectiveamount": "The effective amount of the claim in dewies once the support and the claim are active",
+	"simulateclaimsupportresult-bestclaimid":     "The ID of the best claim then",
+	"simulateclaimsupportresult-bestamount":      "The effective amount of the best claim in dewies then",
+	"simulateclaimsupportresult-minamount":       "The smallest support in dewies which would make the claim the best claim, or 0 if none would",
+
+	// PSBT help.
+	"psbtscript-asm":  "Disassembly of the script",
+	"psbtscript-hex":  "Hex-encoded bytes of the script",
+	"psbtscript-type": "The type of the script (e.g. 'pubkeyhash')",
+
+	"psbtutxo-amount":       "The value of the output in LBC",
+	"psbtutxo-scriptPubKey": "The public key script of the output",
+
+	"psbtbip32deriv-pubkey":             "The hex-encoded public key",
+	"psbtbip32deriv-master_fingerprint": "The fingerprint of the master key",
+	"psbtbip32deriv-path":               "The derivation path of the public key",
+
+	"psbtclaim-name":    "The claim name",
+	"psbtclaim-claimid": "The claim ID (omitted for new claims)",
+
+	"decodepsbtinput-non_witness_utxo":          "The transaction of the spent output, for non-witness inputs",
+	"decodepsbtinput-witness_utxo":              "The spent output",
+	"decodepsbtinput-partial_signatures":        "The signatures keyed by their hex-encoded public key",
+	"decodepsbtinput-partial_signatures--key":   "public key",
+	"decodepsbtinput-partial_signatures--value": "signature",
+	"decodepsbtinput-partial_signatures--desc":  "The signatures keyed by their hex-encoded public key",
+	"decodepsbtinput-sighash":                   "The signature hash type to use",
+	"decodepsbtinput-redeem_script":             "The redeem script",
+	"decodepsbtinput-witness_script":            "The witness script",
+	"decodepsbtinput-bip32_derivs":              "The BIP 32 derivation paths of the public keys",
+	"decodepsbtinput-final_scriptSig":           "The final signature script",
+	"decodepsbtinput-final_scriptwitness":       "The hex-encoded items of the final witness",
+	"decodepsbtinput-claim":                     "The claim, support or update spent by the input",
+	"decodepsbtinput-unknown":                   "The unknown fields, hex-encoded",
+	"decodepsbtinput-unknown--key":              "key",
+	"decodepsbtinput-unknown--value":            "value",
+	"decodepsbtinput-unknown--desc":             "The unknown fields, hex-encoded",
+	"decodepsbtoutput-redeem_script":            "The redeem script",
+	"decodepsbtoutput-witness_script":           "The witness script",
+	"decodepsbtoutput-bip32_derivs":             "The BIP 32 derivation paths of the public keys",
+	"decodepsbtoutput-claim":                    "The claim, support or update created by the output",
+	"decodepsbtresult-tx":                       "The unsigned transaction",
+	"decodepsbtresult-unknown":                  "The unknown global fields, hex-encoded",
+	"decodepsbtresult-unknown--key":             "key",
+	"decodepsbtresult-unknown--value":           "value",
+	"decodepsbtresult-unknown--desc":            "The unknown global fields, hex-encoded",
+	"decodepsbtresult-inputs":                   "The inputs of the PSBT",
+	"decodepsbtresult-outputs":                  "The outputs of the PSBT",
+	"decodepsbtresult-fee":                      "The transaction fee in LBC if the UTXOs of all inputs are known",
+	"decodepsbt--synopsis":                      "Returns a JSON object representing the provided base64-encoded partially signed transaction.",
+	"decodepsbt-psbt":                           "Base64-encoded PSBT",
+
+	"analyzepsbtmissing-pubkeys":          "The hash160 of the public keys that are missing",
+	"analyzepsbtmissing-signatures":       "The hash160 of the public keys whose signatures are missing",
+	"analyzepsbtmissing-redeemscript":     "The hash160 of the missing redeem script",
+	"analyzepsbtmissing-witnessscript":    "The sha256 of the missing witness script",
+	"analyzepsbtinput-has_utxo":           "Whether the spent output is known",
+	"analyzepsbtinput-is_final":           "Whether the input is finalized",
+	"analyzepsbtinput-missing":            "What the input is missing",
+	"analyzepsbtinput-next":               "The role of the next step for the input",
+	"analyzepsbtresult-inputs":            "The analysis of each input",
+	"analyzepsbtresult-estimated_vsize":   "The estimated virtual size of the final transaction",
+	"analyzepsbtresult-estimated_feerate": "The estimated fee rate of the final transaction in LBC/kB",
+	"analyzepsbtresult-fee":               "The transaction fee in LBC if the UTXOs of all inputs are known",
+	"analyzepsbtresult-next":              "The role of the next step for the PSBT",
+	"analyzepsbtresult-error":             "The reason the PSBT is invalid, if any",
+	"analyzepsbt--synopsis":               "Analyzes the provided base64-encoded PSBT and returns what is needed to complete it.",
+	"analyzepsbt-psbt":                    "Base64-encoded PSBT",
+
+	"utxoupdatepsbt--synopsis": "Adds the spent outputs found in the UTXO set or memory pool, and the claim information of the inputs, to the provided base64-encoded PSBT.",
+	"utxoupdatepsbt--result0":  "The base64-encoded updated PSBT",
+	"utxoupdatepsbt-psbt":      "Base64-encoded PSBT",
+
+	"finalizepsbtresult-psbt":     "The base64-encoded partially finalized PSBT, if not extracted",
+	"finalizepsbtresult-hex":      "The hex-encoded transaction, if extracted",
+	"finalizepsbtresult-complete": "Whether all inputs are finalized",
+	"finalizepsbt--synopsis":      "Finalizes the inputs of the provided base64-encoded PSBT and extracts the transaction if complete.",
+	"finalizepsbt-psbt":           "Base64-encoded PSBT",
+	"finalizepsbt-extract":        "Extract the transaction if the PSBT is complete",
+
+	"createpsbt--synopsis":        "Returns a new base64-encoded PSBT of the transaction which createrawtransaction would return for the same arguments.",
+	"createpsbt--result0":         "The base64-encoded PSBT",
+	"createpsbt-inputs":           "The inputs to the transaction",
+	"createpsbt-outputs":          "JSON object with the destination addresses as keys and amounts as values",
+	"createpsbt-outputs--key":     "address, \"data\", \"claim\", \"update\" or \"support\"",
+	"createpsbt-outputs--value":   "value in BTC as floating point number, hex-encoded data for \"data\", or the claim outputs as for createrawtransaction",
+	"createpsbt-outputs--desc":    "The destination address as the key and the amount in LBC as the value",
+	"createpsbt-locktime":         "Locktime value; a non-zero value will also locktime-activate the inputs",
+	"converttopsbt--synopsis":     "Converts the provided hex-encoded transaction into a base64-encoded PSBT of its unsigned transaction.",
+	"converttopsbt--result0":      "The base64-encoded PSBT",
+	"converttopsbt-hextx":         "Hex-encoded transaction",
+	"converttopsbt-permitsigdata": "Drop the signature scripts and witnesses of the inputs, rather than failing when there are some",
+	"converttopsbt-iswitness":     "Whether the transaction is serialized with witnesses; by default it is decoded as such when possible",
+	"joinpsbts--synopsis":         "Joins the inputs and the outputs of the provided base64-encoded PSBTs, in a shuffled order, into a single PSBT with the highest version and the lowest locktime of theirs.",
+	"joinpsbts--result0":          "The base64-encoded joined PSBT",
+	"joinpsbts-psbts":             "Base64-encoded PSBTs",
+
 	"getblockverboseresult-getblockverboseresultbase": "",
 	"prevout-issupport": "Previous output created a support",
 	"prevout-isclaim":   "Previous output created or updated a claim",
@@ -936,65 +1586,93 @@ var helpDescsEnUS = map[string]string{
 // This information is used to generate the help.  Each result type must be a
 // pointer to the type (or nil to indicate no return value).
 var rpcResultTypes = map[string][]interface{}{
-	"addnode":                nil,
-	"clearbanned":            nil,
-	"createrawtransaction":   {(*string)(nil)},
-	"debuglevel":             {(*string)(nil), (*string)(nil)},
-	"decoderawtransaction":   {(*btcjson.TxRawDecodeResult)(nil)},
-	"decodescript":           {(*btcjson.DecodeScriptResult)(nil)},
-	"estimatefee":            {(*float64)(nil)},
-	"estimatesmartfee":       {(*float64)(nil)},
-	"generate":               {(*[]string)(nil)},
-	"generatetoaddress":      {(*[]string)(nil)},
-	"getaddednodeinfo":       {(*[]string)(nil), (*[]btcjson.GetAddedNodeInfoResult)(nil)},
-	"getbestblock":           {(*btcjson.GetBestBlockResult)(nil)},
-	"getbestblockhash":       {(*string)(nil)},
-	"getblock":               {(*string)(nil), (*btcjson.GetBlockVerboseResult)(nil)},
-	"getblockchaininfo":      {(*btcjson.GetBlockChainInfoResult)(nil)},
-	"getblockcount":          {(*int64)(nil)},
-	"getblockhash":           {(*string)(nil)},
-	"getblockheader":         {(*string)(nil), (*btcjson.GetBlockHeaderVerboseResult)(nil)},
-	"getblockstats":          {(*btcjson.GetBlockStatsResult)(nil)},
-	"getblocktemplate":       {(*btcjson.GetBlockTemplateResult)(nil), (*string)(nil), nil},
-	"getcfilter":             {(*string)(nil)},
-	"getcfilterheader":       {(*string)(nil)},
-	"getchaintips":           {(*[]btcjson.GetChainTipsResult)(nil)},
-	"getconnectioncount":     {(*int32)(nil)},
-	"getcurrentnet":          {(*uint32)(nil)},
-	"getdifficulty":          {(*float64)(nil)},
-	"getgenerate":            {(*bool)(nil)},
-	"gethashespersec":        {(*float64)(nil)},
-	"getheaders":             {(*[]string)(nil)},
-	"getinfo":                {(*btcjson.InfoChainResult)(nil)},
-	"getmempoolentry":        {(*btcjson.GetMempoolEntryResult)(nil)},
-	"getmempoolinfo":         {(*btcjson.GetMempoolInfoResult)(nil)},
-	"getmininginfo":          {(*btcjson.GetMiningInfoResult)(nil)},
-	"getnettotals":           {(*btcjson.GetNetTotalsResult)(nil)},
-	"getnetworkhashps":       {(*int64)(nil)},
-	"getnetworkinfo":         {(*map[string]btcjson.GetNetworkInfoResult)(nil)},
-	"getnodeaddresses":       {(*[]btcjson.GetNodeAddressesResult)(nil)},
-	"getpeerinfo":            {(*[]btcjson.GetPeerInfoResult)(nil)},
-	"getrawmempool":          {(*[]string)(nil), (*btcjson.GetRawMempoolVerboseResult)(nil)},
-	"getrawtransaction":      {(*string)(nil), (*btcjson.TxRawResult)(nil)},
-	"gettxout":               {(*btcjson.GetTxOutResult)(nil)},
-	"help":                   {(*string)(nil), (*string)(nil)},
-	"invalidateblock":        nil,
-	"listbanned":             {(*[]btcjson.ListBannedResult)(nil)},
-	"node":                   nil,
-	"ping":                   nil,
-	"reconsiderblock":        nil,
-	"searchrawtransactions":  {(*string)(nil), (*[]btcjson.SearchRawTransactionsResult)(nil)},
-	"sendrawtransaction":     {(*string)(nil)},
-	"setban":                 nil,
-	"setgenerate":            nil,
-	"signmessagewithprivkey": {(*string)(nil)},
-	"stop":                   {(*string)(nil)},
-	"submitblock":            {nil, (*string)(nil)},
-	"uptime":                 {(*int64)(nil)},
-	"validateaddress":        {(*btcjson.ValidateAddressChainResult)(nil)},
-	"verifychain":            {(*bool)(nil)},
-	"verifymessage":          {(*bool)(nil)},
-	"version":                {(*map[string]btcjson.VersionResult)(nil)},
+	"addnode":                   nil,
+	"backupchainstate":          {(*btcjson.BackupResult)(nil)},
+	"checkdb":                   {(*btcjson.CheckDBResult)(nil)},
+	"backupclaimdbs":            {(*btcjson.BackupResult)(nil)},
+	"captureprofile":            {(*btcjson.CaptureProfileResult)(nil)},
+	"clearbanned":               nil,
+	"createchainsnapshot":       {(*btcjson.GetChainSnapshotResult)(nil)},
+	"createmultisig":            {(*btcjson.CreateMultiSigResult)(nil)},
+	"createrawtransaction":      {(*string)(nil)},
+	"debuglevel":                {(*string)(nil), (*string)(nil)},
+	"decoderawtransaction":      {(*btcjson.TxRawDecodeResult)(nil)},
+	"decodescript":              {(*btcjson.DecodeScriptResult)(nil)},
+	"deriveaddresses":           {(*btcjson.DeriveAddressesResult)(nil)},
+	"downloadchainsnapshot":     {(*btcjson.ChainSnapshotInfo)(nil)},
+	"estimatefee":               {(*float64)(nil)},
+	"estimatesmartfee":          {(*float64)(nil)},
+	"exportblocks":              {(*btcjson.ExportBlocksResult)(nil)},
+	"generate":                  {(*[]string)(nil)},
+	"generatetoaddress":         {(*[]string)(nil)},
+	"getaddednodeinfo":          {(*[]string)(nil), (*[]btcjson.GetAddedNodeInfoResult)(nil)},
+	"getbestblock":              {(*btcjson.GetBestBlockResult)(nil)},
+	"getbestblockhash":          {(*string)(nil)},
+	"getblock":                  {(*string)(nil), (*btcjson.GetBlockVerboseResult)(nil)},
+	"getblockchaininfo":         {(*btcjson.GetBlockChainInfoResult)(nil)},
+	"getblockcount":             {(*int64)(nil)},
+	"getblockhash":              {(*string)(nil)},
+	"getblockheader":            {(*string)(nil), (*btcjson.GetBlockHeaderVerboseResult)(nil)},
+	"getblockstats":             {(*btcjson.GetBlockStatsResult)(nil)},
+	"getblocktemplate":          {(*btcjson.GetBlockTemplateResult)(nil), (*string)(nil), nil},
+	"getcfilter":                {(*string)(nil)},
+	"getcfilterheader":          {(*string)(nil)},
+	"getchainsnapshot":          {(*btcjson.GetChainSnapshotResult)(nil)},
+	"getchainsnapshotchunk":     {(*btcjson.GetChainSnapshotChunkResult)(nil)},
+	"getchaintips":              {(*[]btcjson.GetChainTipsResult)(nil)},
+	"getconnectioncount":        {(*int32)(nil)},
+	"getcurrentnet":             {(*uint32)(nil)},
+	"getdbinfo":                 {(*btcjson.GetDBInfoResult)(nil)},
+	"getdescriptorinfo":         {(*btcjson.GetDescriptorInfoResult)(nil)},
+	"getdifficulty":             {(*float64)(nil)},
+	"getgenerate":               {(*bool)(nil)},
+	"gethashespersec":           {(*float64)(nil)},
+	"getheaders":                {(*[]string)(nil)},
+	"getinfo":                   {(*btcjson.InfoChainResult)(nil)},
+	"getlatencystats":           {(*btcjson.GetLatencyStatsResult)(nil)},
+	"getmempoolentry":           {(*btcjson.GetMempoolEntryResult)(nil)},
+	"getmempoolinfo":            {(*btcjson.GetMempoolInfoResult)(nil)},
+	"getmemoryinfo":             {(*btcjson.GetMemoryInfoResult)(nil)},
+	"getmininginfo":             {(*btcjson.GetMiningInfoResult)(nil)},
+	"getnettotals":              {(*btcjson.GetNetTotalsResult)(nil)},
+	"getnetworkhashps":          {(*int64)(nil)},
+	"getnetworkinfo":            {(*map[string]btcjson.GetNetworkInfoResult)(nil)},
+	"getnodeaddresses":          {(*[]btcjson.GetNodeAddressesResult)(nil)},
+	"exportaddrman":             {(*btcjson.AddrManPeers)(nil)},
+	"getaddrmaninfo":            {(*btcjson.GetAddrManInfoResult)(nil)},
+	"importaddrman":             {(*int)(nil)},
+	"getpeerinfo":               {(*[]btcjson.GetPeerInfoResult)(nil)},
+	"getrawmempool":             {(*[]string)(nil), (*btcjson.GetRawMempoolVerboseResult)(nil)},
+	"getrawtransaction":         {(*string)(nil), (*btcjson.TxRawResult)(nil)},
+	"getrawtransactions":        {(*[]string)(nil), (*[]btcjson.TxRawResult)(nil)},
+	"getrpcinfo":                {(*btcjson.GetRPCInfoResult)(nil)},
+	"getsysteminfo":             {(*btcjson.GetSystemInfoResult)(nil)},
+	"gettxout":                  {(*btcjson.GetTxOutResult)(nil)},
+	"getverifychaininfo":        {(*btcjson.GetVerifyChainInfoResult)(nil)},
+	"getversionbits":            {(*btcjson.GetVersionBitsResult)(nil)},
+	"help":                      {(*string)(nil), (*string)(nil)},
+	"invalidateblock":           nil,
+	"listbanned":                {(*[]btcjson.ListBannedResult)(nil)},
+	"listchainsnapshots":        {(*[]btcjson.ChainSnapshotInfo)(nil)},
+	"node":                      nil,
+	"ping":                      nil,
+	"reconsiderblock":           nil,
+	"searchrawtransactions":     {(*string)(nil), (*[]btcjson.SearchRawTransactionsResult)(nil)},
+	"sendrawtransaction":        {(*string)(nil)},
+	"setban":                    nil,
+	"setgenerate":               nil,
+	"setsigcachesize":           nil,
+	"setversionbits":            {(*btcjson.GetVersionBitsResult)(nil)},
+	"signmessagewithprivkey":    {(*string)(nil)},
+	"signrawtransactionwithkey": {(*btcjson.SignRawTransactionWithKeyResult)(nil)},
+	"stop":                      {(*string)(nil)},
+	"submitblock":               {nil, (*string)(nil)},
+	"uptime":                    {(*int64)(nil)},
+	"validateaddress":           {(*btcjson.ValidateAddressChainResult)(nil)},
+	"verifychain":               {(*bool)(nil)},
+	"verifychainsnapshot":       {(*btcjson.VerifyChainSnapshotResult)(nil)},
+	"verifymessage":             {(*bool)(nil)},
+	"version":                   {(*map[string]btcjson.VersionResult)(nil)},
 
 	// Websocket commands.
 	"loadtxfilter":              nil,
@@ -1007,6 +1685,10 @@ var rpcResultTypes = map[string][]interface{}{
 	"stopnotifyreceived":        nil,
 	"notifyspent":               nil,
 	"stopnotifyspent":           nil,
+	"notifywork":                nil,
+	"stopnotifywork":            nil,
+	"notifyclaims":              nil,
+	"stopnotifyclaims":          nil,
 	"rescan":                    nil,
 	"rescanblocks":              {(*[]btcjson.RescannedBlock)(nil)},
 
@@ -1016,7 +1698,19 @@ var rpcResultTypes = map[string][]interface{}{
 	"getclaimsfornamebybid": {(*btcjson.GetClaimsForNameResult)(nil)},
 	"getclaimsfornamebyseq": {(*btcjson.GetClaimsForNameResult)(nil)},
 	"normalize":             {(*string)(nil)},
+	"simulateclaimsupport":  {(*btcjson.SimulateClaimSupportResult)(nil)},
+	"getvalueforname":       {(*btcjson.GetValueForNameResult)(nil)},
+	"getnameproof":          {(*btcjson.GetNameProofResult)(nil)},
 	"getchangesinblock":     {(*btcjson.GetChangesInBlockResult)(nil)},
+
+	// PSBT
+	"analyzepsbt":    {(*btcjson.AnalyzePsbtResult)(nil)},
+	"converttopsbt":  {(*string)(nil)},
+	"createpsbt":     {(*string)(nil)},
+	"decodepsbt":     {(*btcjson.DecodePsbtResult)(nil)},
+	"finalizepsbt":   {(*btcjson.FinalizePsbtResult)(nil)},
+	"joinpsbts":      {(*string)(nil)},
+	"utxoupdatepsbt": {(*string)(nil)},
 }
 
 // helpCacher provides a concurrent safe type that provides help and usage for
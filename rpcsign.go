@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/txscript/txsign"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+// handleSignRawTransactionWithKey implements the signrawtransactionwithkey
+// command.  The outputs spent by the transaction are those passed as prevtxs,
+// or are looked up in the memory pool and the UTXO set.
+func handleSignRawTransactionWithKey(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.SignRawTransactionWithKeyCmd)
+
+	// Deserialize the transaction.
+	hexStr := c.RawTx
+	if len(hexStr)%2 != 0 {
+		hexStr = "0" + hexStr
+	}
+	serializedTx, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return nil, rpcDecodeHexError(hexStr)
+	}
+	var mtx wire.MsgTx
+	err = mtx.Deserialize(bytes.NewReader(serializedTx))
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCDeserialization,
+			Message: "TX decode failed: " + err.Error(),
+		}
+	}
+
+	keys := make([]*btcutil.WIF, 0, len(c.PrivKeys))
+	for _, privKey := range c.PrivKeys {
+		wif, err := decodePrivKey(s.cfg.ChainParams, privKey)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, wif)
+	}
+
+	hashType, ok := txsign.ParseSigHashType(*c.SigHashType)
+	if !ok {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Invalid sighash param",
+		}
+	}
+
+	prevOuts := make(map[wire.OutPoint]*txsign.PrevOut)
+	if c.PrevTxs != nil {
+		for i := range *c.PrevTxs {
+			op, prevOut, err := parseSignPrevOut(&(*c.PrevTxs)[i])
+			if err != nil {
+				return nil, err
+			}
+			prevOuts[*op] = prevOut
+		}
+	}
+
+	var signErrors []btcjson.SignRawTransactionError
+	sigHashes := txscript.NewTxSigHashes(&mtx)
+	for i, txIn := range mtx.TxIn {
+		prevOut, ok := prevOuts[txIn.PreviousOutPoint]
+		if !ok {
+			prevOut, err = fetchSignPrevOut(s, &txIn.PreviousOutPoint)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if prevOut == nil {
+			err = errors.New("Input not found or already spent")
+		} else {
+			err = txsign.SignTxInput(s.cfg.ChainParams, &mtx, sigHashes,
+				i, prevOut, keys, hashType)
+		}
+		if err != nil {
+			var witness []string
+			for _, item := range txIn.Witness {
+				witness = append(witness, hex.EncodeToString(item))
+			}
+			signErrors = append(signErrors, btcjson.SignRawTransactionError{
+				TxID:      txIn.PreviousOutPoint.Hash.String(),
+				Vout:      txIn.PreviousOutPoint.Index,
+				Witness:   witness,
+				ScriptSig: hex.EncodeToString(txIn.SignatureScript),
+				Sequence:  txIn.Sequence,
+				Error:     err.Error(),
+			})
+		}
+	}
+
+	txHex, err := messageToHex(&mtx)
+	if err != nil {
+		return nil, err
+	}
+	return &btcjson.SignRawTransactionWithKeyResult{
+		Hex:      txHex,
+		Complete: len(signErrors) == 0,
+		Errors:   signErrors,
+	}, nil
+}
+
+// decodePrivKey decodes a private key in the wallet import format of the
+// network.
+func decodePrivKey(params *chaincfg.Params, privKey string) (*btcutil.WIF, error) {
+	wif, err := btcutil.DecodeWIF(privKey)
+	if err != nil {
+		message := "Invalid private key"
+		switch err {
+		case btcutil.ErrMalformedPrivateKey:
+			message = "Malformed private key"
+		case btcutil.ErrChecksumMismatch:
+			message = "Private key checksum mismatch"
+		}
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: message,
+		}
+	}
+	if !wif.IsForNet(params) {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "Private key for wrong network",
+		}
+	}
+	return wif, nil
+}
+
+// parseSignPrevOut parses a previous output passed to signrawtransactionwithkey.
+func parseSignPrevOut(input *btcjson.RawTxWitnessInput) (*wire.OutPoint, *txsign.PrevOut, error) {
+	txHash, err := chainhash.NewHashFromStr(input.Txid)
+	if err != nil {
+		return nil, nil, rpcDecodeHexError(input.Txid)
+	}
+
+	decodeScript := func(hexStr string) ([]byte, error) {
+		script, err := hex.DecodeString(hexStr)
+		if err != nil {
+			return nil, rpcDecodeHexError(hexStr)
+		}
+		return script, nil
+	}
+	pkScript, err := decodeScript(input.ScriptPubKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	prevOut := &txsign.PrevOut{TxOut: wire.NewTxOut(0, pkScript)}
+	if input.RedeemScript != nil {
+		prevOut.RedeemScript, err = decodeScript(*input.RedeemScript)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if input.WitnessScript != nil {
+		prevOut.WitnessScript, err = decodeScript(*input.WitnessScript)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if input.Amount != nil {
+		amount, err := btcutil.NewAmount(*input.Amount)
+		if err != nil || amount < 0 {
+			return nil, nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCType,
+				Message: fmt.Sprintf("Invalid amount %v", *input.Amount),
+			}
+		}
+		prevOut.TxOut.Value = int64(amount)
+		prevOut.HasAmount = true
+	}
+	return wire.NewOutPoint(txHash, input.Vout), prevOut, nil
+}
+
+// fetchSignPrevOut returns the output op spends from the memory pool or the
+// UTXO set, or nil if it isn't found.
+func fetchSignPrevOut(s *rpcServer, op *wire.OutPoint) (*txsign.PrevOut, error) {
+	if tx, err := s.cfg.TxMemPool.FetchTransaction(&op.Hash); err == nil {
+		txOuts := tx.MsgTx().TxOut
+		if op.Index >= uint32(len(txOuts)) {
+			return nil, nil
+		}
+		return &txsign.PrevOut{TxOut: txOuts[op.Index], HasAmount: true}, nil
+	}
+
+	entry, err := s.cfg.Chain.FetchUtxoEntry(*op)
+	if err != nil {
+		context := "Failed to fetch UTXO"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	if entry == nil || entry.IsSpent() {
+		return nil, nil
+	}
+	txOut := wire.NewTxOut(entry.Amount(), entry.PkScript())
+	return &txsign.PrevOut{TxOut: txOut, HasAmount: true}, nil
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lbryio/lbcd/blockchain"
+	"github.com/lbryio/lbcd/claimtrie/normalization"
+	"github.com/lbryio/lbcd/mempool"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+const (
+	// ssePath is the path of the Server-Sent Events endpoint.
+	ssePath = "/events"
+
+	// sseSubscriberBuffer is the number of notifications queued for an
+	// event stream, after which the stream is closed as too slow.
+	sseSubscriberBuffer = 1000
+
+	// sseKeepAliveInterval is the time between two comments sent to idle
+	// event streams, so that proxies don't close them.
+	sseKeepAliveInterval = 30 * time.Second
+)
+
+// sseStream is a kind of notifications an event stream may carry.
+type sseStream uint8
+
+const (
+	sseStreamBlock sseStream = 1 << iota
+	sseStreamTx
+	sseStreamClaim
+)
+
+// sseStreams maps the names of the streams to the command a limited user
+// must be allowed to call to receive it.
+var sseStreams = map[string]struct {
+	stream  sseStream
+	command string
+}{
+	"block": {sseStreamBlock, "notifyblocks"},
+	"tx":    {sseStreamTx, "notifynewtransactions"},
+	"claim": {sseStreamClaim, "getchangesinblock"},
+}
+
+// sseTxData is the data of txaccepted events.
+type sseTxData struct {
+	Txid   string  `json:"txid"`
+	Amount float64 `json:"amount"`
+	Fee    float64 `json:"fee"`
+	VSize  int64   `json:"vsize"`
+}
+
+// sseClaimData is the data of claimschanged events.
+type sseClaimData struct {
+	Hash   string   `json:"hash"`
+	Height int32    `json:"height"`
+	Names  []string `json:"names"`
+}
+
+// sseBlockNtfn is the notification of a block connected to, or disconnected
+// from, the main chain.
+type sseBlockNtfn struct {
+	block     *btcutil.Block
+	connected bool
+}
+
+// sseSubscriber is an event stream.  Notifications are queued to ntfns, and
+// dropped is closed when the queue overflows.
+type sseSubscriber struct {
+	streams sseStream
+	ntfns   chan interface{}
+	dropped chan struct{}
+}
+
+// parseSSEStreams returns the streams requested by the comma separated list of
+// names, all of them if it is empty, and checks that the user may receive
+// them.
+func parseSSEStreams(list string, user *rpcAuthUser) (sseStream, error) {
+	if list == "" {
+		list = "block,tx,claim"
+	}
+	var streams sseStream
+	for _, name := range strings.Split(list, ",") {
+		s, ok := sseStreams[strings.TrimSpace(name)]
+		if !ok {
+			return 0, fmt.Errorf("unknown stream %q", name)
+		}
+		if !user.allowed(s.command) {
+			return 0, fmt.Errorf("stream %q is not allowed", name)
+		}
+		streams |= s.stream
+	}
+	return streams, nil
+}
+
+// sseSubscribe adds an event stream for the passed streams.
+func (s *rpcServer) sseSubscribe(streams sseStream) *sseSubscriber {
+	sub := &sseSubscriber{
+		streams: streams,
+		ntfns:   make(chan interface{}, sseSubscriberBuffer),
+		dropped: make(chan struct{}),
+	}
+	s.sseLock.Lock()
+	s.sseSubscribers[sub] = struct{}{}
+	s.sseLock.Unlock()
+	return sub
+}
+
+// sseUnsubscribe removes an event stream.
+func (s *rpcServer) sseUnsubscribe(sub *sseSubscriber) {
+	s.sseLock.Lock()
+	delete(s.sseSubscribers, sub)
+	s.sseLock.Unlock()
+}
+
+// sseNumClients returns the number of event streams being served.
+func (s *rpcServer) sseNumClients() int {
+	s.sseLock.Lock()
+	defer s.sseLock.Unlock()
+	return len(s.sseSubscribers)
+}
+
+// sseNotify queues the notification to the event streams carrying any of the
+// passed streams, dropping those whose queue is full.
+func (s *rpcServer) sseNotify(ntfn interface{}, streams sseStream) {
+	s.sseLock.Lock()
+	defer s.sseLock.Unlock()
+
+	for sub := range s.sseSubscribers {
+		if sub.streams&streams == 0 {
+			continue
+		}
+		select {
+		case sub.ntfns <- ntfn:
+		default:
+			close(sub.dropped)
+			delete(s.sseSubscribers, sub)
+		}
+	}
+}
+
+// sseHandleBlockchainNotification queues the notifications of blocks connected
+// to and disconnected from the main chain to the event streams.
+func (s *rpcServer) sseHandleBlockchainNotification(notification *blockchain.Notification) {
+	block, ok := notification.Data.(*btcutil.Block)
+	if !ok {
+		return
+	}
+	switch notification.Type {
+	case blockchain.NTBlockConnected:
+		s.sseNotify(&sseBlockNtfn{block: block, connected: true},
+			sseStreamBlock|sseStreamClaim)
+
+	case blockchain.NTBlockDisconnected:
+		s.sseNotify(&sseBlockNtfn{block: block}, sseStreamBlock)
+	}
+}
+
+// writeSSEEvent writes an event with the JSON encoding of data.
+func writeSSEEvent(w io.Writer, event string, data interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+	return err
+}
+
+// sseWriteNotification writes the events of a notification to the stream.
+// wanted holds the normalized names the claim events are restricted to when
+// it isn't empty.
+func (s *rpcServer) sseWriteNotification(w io.Writer, sub *sseSubscriber,
+	ntfn interface{}, wanted []string) error {
+
+	switch ntfn := ntfn.(type) {
+	case *sseBlockNtfn:
+		if !ntfn.connected {
+			return writeSSEEvent(w, "blockdisconnected",
+				newBlockEventData(ntfn.block))
+		}
+		if sub.streams&sseStreamBlock != 0 {
+			err := writeSSEEvent(w, "blockconnected",
+				newBlockEventData(ntfn.block))
+			if err != nil {
+				return err
+			}
+		}
+		if sub.streams&sseStreamClaim == 0 {
+			return nil
+		}
+
+		// The changes are looked up here, rather than when the block is
+		// connected, as the chain is locked at that time.
+		height := ntfn.block.Height()
+		names, err := s.cfg.Chain.GetNamesChangedInBlock(height)
+		if err != nil {
+			rpcsLog.Errorf("Failed to retrieve the claim changes of "+
+				"block %v: %v", ntfn.block.Hash(), err)
+			return nil
+		}
+		if len(wanted) > 0 {
+			normalized := make(map[string]struct{}, len(wanted))
+			for _, name := range wanted {
+				n := normalization.NormalizeIfNecessary([]byte(name), height)
+				normalized[string(n)] = struct{}{}
+			}
+			filtered := names[:0]
+			for _, name := range names {
+				if _, ok := normalized[name]; ok {
+					filtered = append(filtered, name)
+				}
+			}
+			names = filtered
+		}
+		if len(names) == 0 {
+			return nil
+		}
+		return writeSSEEvent(w, "claimschanged", &sseClaimData{
+			Hash:   ntfn.block.Hash().String(),
+			Height: height,
+			Names:  names,
+		})
+
+	case *mempool.TxDesc:
+		var amount int64
+		for _, txOut := range ntfn.Tx.MsgTx().TxOut {
+			amount += txOut.Value
+		}
+		return writeSSEEvent(w, "txaccepted", &sseTxData{
+			Txid:   ntfn.Tx.Hash().String(),
+			Amount: btcutil.Amount(amount).ToBTC(),
+			Fee:    btcutil.Amount(ntfn.Fee).ToBTC(),
+			VSize:  mempool.GetTxVirtualSize(ntfn.Tx),
+		})
+	}
+	return nil
+}
+
+// sseHandler serves an event stream of the notifications selected by the
+// streams query parameter until the client disconnects, falls behind or the
+// server shuts down.
+func (s *rpcServer) sseHandler(w http.ResponseWriter, r *http.Request, user *rpcAuthUser) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "405 Method Not Allowed.", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "500 Streaming Unsupported.", http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	streams, err := parseSSEStreams(query.Get("streams"), user)
+	if err != nil {
+		http.Error(w, "403 Forbidden: "+err.Error(), http.StatusForbidden)
+		return
+	}
+	var names []string
+	if list := query.Get("names"); list != "" {
+		names = strings.Split(list, ",")
+	}
+
+	if s.ntfnMgr.NumClients()+s.sseNumClients()+1 > cfg.RPCMaxWebsockets {
+		rpcsLog.Infof("Max websocket clients exceeded [%d] - "+
+			"disconnecting event stream client %s",
+			cfg.RPCMaxWebsockets, r.RemoteAddr)
+		http.Error(w, "503 Too busy.  Try again later.",
+			http.StatusServiceUnavailable)
+		return
+	}
+
+	sub := s.sseSubscribe(streams)
+	defer s.sseUnsubscribe(sub)
+
+	rpcsLog.Infof("New event stream client %s", r.RemoteAddr)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case ntfn := <-sub.ntfns:
+			err = s.sseWriteNotification(w, sub, ntfn, names)
+
+		case <-keepAlive.C:
+			_, err = io.WriteString(w, ": keepalive\n\n")
+
+		case <-sub.dropped:
+			rpcsLog.Infof("Event stream client %s fell behind the "+
+				"notifications", r.RemoteAddr)
+			return
+
+		case <-r.Context().Done():
+			rpcsLog.Infof("Event stream client %s disconnected",
+				r.RemoteAddr)
+			return
+
+		case <-s.quit:
+			return
+		}
+		if err != nil {
+			rpcsLog.Debugf("Failed to write to event stream client %s: %v",
+				r.RemoteAddr, err)
+			return
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lbryio/lbcd/mempool"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSSEStreams(t *testing.T) {
+
+	r := require.New(t)
+
+	admin := &rpcAuthUser{}
+	limited := &rpcAuthUser{whitelist: map[string]struct{}{"notifyblocks": {}}}
+
+	streams, err := parseSSEStreams("", admin)
+	r.NoError(err)
+	r.Equal(sseStreamBlock|sseStreamTx|sseStreamClaim, streams)
+
+	streams, err = parseSSEStreams("block, claim", admin)
+	r.NoError(err)
+	r.Equal(sseStreamBlock|sseStreamClaim, streams)
+
+	_, err = parseSSEStreams("blocks", admin)
+	r.Error(err)
+
+	streams, err = parseSSEStreams("block", limited)
+	r.NoError(err)
+	r.Equal(sseStreamBlock, streams)
+
+	_, err = parseSSEStreams("", limited)
+	r.Error(err)
+}
+
+func TestSSENotify(t *testing.T) {
+
+	r := require.New(t)
+
+	s := &rpcServer{sseSubscribers: make(map[*sseSubscriber]struct{})}
+	blocks := s.sseSubscribe(sseStreamBlock)
+	txs := s.sseSubscribe(sseStreamTx | sseStreamClaim)
+
+	tx := &mempool.TxDesc{}
+	tx.Tx = btcutil.NewTx(wire.NewMsgTx(1))
+	tx.Fee = 1000
+	s.sseNotify(tx, sseStreamTx)
+	s.sseNotify(&sseBlockNtfn{}, sseStreamBlock)
+	r.Len(blocks.ntfns, 1)
+	r.Len(txs.ntfns, 1)
+
+	var buf bytes.Buffer
+	r.NoError(s.sseWriteNotification(&buf, txs, <-txs.ntfns, nil))
+	r.Equal("event: txaccepted\ndata: {\"txid\":\""+tx.Tx.Hash().String()+
+		"\",\"amount\":0,\"fee\":0.00001,\"vsize\":10}\n\n", buf.String())
+
+	// A subscriber with a full queue is dropped.
+	for i := 0; i < sseSubscriberBuffer; i++ {
+		s.sseNotify(i, sseStreamBlock)
+	}
+	_, ok := <-blocks.dropped
+	r.False(ok)
+	r.Equal(1, s.sseNumClients())
+
+	s.sseUnsubscribe(txs)
+	r.Zero(s.sseNumClients())
+}
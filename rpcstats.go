@@ -0,0 +1,172 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	"github.com/btcsuite/btclog"
+	"github.com/lbryio/lbcd/blockchain"
+	"github.com/lbryio/lbcd/btcjson"
+)
+
+const (
+	// latencySamples is the number of most recent latencies of each RPC
+	// method the percentiles are computed from.
+	latencySamples = 1024
+
+	// slowestBlocks is the number of the slowest recent blocks returned by
+	// getlatencystats.
+	slowestBlocks = 10
+)
+
+// latencyWindow keeps the most recent latencies of an operation.
+type latencyWindow struct {
+	samples []time.Duration
+	next    int
+}
+
+// add adds a latency, overwriting the oldest one once the window is full.
+func (w *latencyWindow) add(d time.Duration) {
+	if len(w.samples) < latencySamples {
+		w.samples = append(w.samples, d)
+		return
+	}
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % latencySamples
+}
+
+// latencyStats returns the percentiles of the passed latencies, which it sorts.
+func latencyStats(samples []time.Duration) btcjson.LatencyStats {
+	if len(samples) == 0 {
+		return btcjson.LatencyStats{}
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i] < samples[j]
+	})
+
+	// The percentiles are the nearest-rank ones.
+	percentile := func(p int) int64 {
+		rank := (p*len(samples) + 99) / 100
+		return samples[rank-1].Microseconds()
+	}
+	return btcjson.LatencyStats{
+		Samples: len(samples),
+		P50:     percentile(50),
+		P90:     percentile(90),
+		P99:     percentile(99),
+		Max:     samples[len(samples)-1].Microseconds(),
+	}
+}
+
+// rpcMethodStats are the statistics of the calls of an RPC method.
+type rpcMethodStats struct {
+	calls     uint64
+	errors    uint64
+	latencies latencyWindow
+}
+
+// recordCommand records the latency of a command, and logs it when it took
+// longer than the slowrpc threshold.
+func (s *rpcServer) recordCommand(cmd *rpcActiveCmd, err error) {
+	d := time.Since(cmd.start)
+
+	s.methodStatsLock.Lock()
+	stats, ok := s.methodStats[cmd.method]
+	if !ok {
+		stats = &rpcMethodStats{}
+		s.methodStats[cmd.method] = stats
+	}
+	stats.calls++
+	if err != nil {
+		stats.errors++
+	}
+	stats.latencies.add(d)
+	s.methodStatsLock.Unlock()
+
+	if s.cfg.SlowRPC <= 0 || d <= s.cfg.SlowRPC {
+		return
+	}
+	if err != nil {
+		logFields(rpcsLog, btclog.LevelWarn, "Slow command failed",
+			"method", cmd.method, "client", cmd.client, "duration", d,
+			"error", err)
+		return
+	}
+	logFields(rpcsLog, btclog.LevelWarn, "Slow command", "method",
+		cmd.method, "client", cmd.client, "duration", d)
+}
+
+// methodLatencies returns the statistics of the RPC methods which were called,
+// sorted by method.
+func (s *rpcServer) methodLatencies() []btcjson.RPCMethodLatency {
+	s.methodStatsLock.Lock()
+	result := make([]btcjson.RPCMethodLatency, 0, len(s.methodStats))
+	samples := make([][]time.Duration, 0, len(s.methodStats))
+	for method, stats := range s.methodStats {
+		result = append(result, btcjson.RPCMethodLatency{
+			Method: method,
+			Calls:  stats.calls,
+			Errors: stats.errors,
+		})
+		samples = append(samples, append([]time.Duration(nil),
+			stats.latencies.samples...))
+	}
+	s.methodStatsLock.Unlock()
+
+	for i := range result {
+		result[i].Latency = latencyStats(samples[i])
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Method < result[j].Method
+	})
+	return result
+}
+
+// blockLatencies returns the statistics of the connection of the passed blocks.
+func blockLatencies(timings []blockchain.BlockTimings) btcjson.BlockLatency {
+	var total, validate, scripts, claimTrie, db []time.Duration
+	for _, t := range timings {
+		total = append(total, t.Total)
+		validate = append(validate, t.Validate)
+		scripts = append(scripts, t.Scripts)
+		claimTrie = append(claimTrie, t.ClaimTrie)
+		db = append(db, t.DB)
+	}
+
+	sort.SliceStable(timings, func(i, j int) bool {
+		return timings[i].Total > timings[j].Total
+	})
+	if len(timings) > slowestBlocks {
+		timings = timings[:slowestBlocks]
+	}
+	slowest := make([]btcjson.BlockTimings, 0, len(timings))
+	for _, t := range timings {
+		slowest = append(slowest, btcjson.BlockTimings{
+			Hash:      t.Hash.String(),
+			Height:    t.Height,
+			Time:      t.Connected.Unix(),
+			Total:     t.Total.Microseconds(),
+			Validate:  t.Validate.Microseconds(),
+			Scripts:   t.Scripts.Microseconds(),
+			ClaimTrie: t.ClaimTrie.Microseconds(),
+			DB:        t.DB.Microseconds(),
+		})
+	}
+
+	return btcjson.BlockLatency{
+		Total:     latencyStats(total),
+		Validate:  latencyStats(validate),
+		Scripts:   latencyStats(scripts),
+		ClaimTrie: latencyStats(claimTrie),
+		DB:        latencyStats(db),
+		Slowest:   slowest,
+	}
+}
+
+// handleGetLatencyStats implements the getlatencystats command.
+func handleGetLatencyStats(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	return &btcjson.GetLatencyStatsResult{
+		RPC:    s.methodLatencies(),
+		Blocks: blockLatencies(s.cfg.Chain.RecentBlockTimings()),
+	}, nil
+}
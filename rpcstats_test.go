@@ -0,0 +1,97 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btclog"
+	"github.com/lbryio/lbcd/blockchain"
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyStats(t *testing.T) {
+
+	r := require.New(t)
+
+	r.Equal(btcjson.LatencyStats{}, latencyStats(nil))
+
+	var w latencyWindow
+	for i := 1; i <= latencySamples+100; i++ {
+		w.add(time.Duration(i) * time.Microsecond)
+	}
+	r.Len(w.samples, latencySamples)
+
+	// The first 100 latencies were overwritten.
+	stats := latencyStats(w.samples)
+	r.Equal(latencySamples, stats.Samples)
+	r.EqualValues(100+latencySamples/2, stats.P50)
+	r.EqualValues(100+(90*latencySamples+99)/100, stats.P90)
+	r.EqualValues(100+(99*latencySamples+99)/100, stats.P99)
+	r.EqualValues(100+latencySamples, stats.Max)
+
+	stats = latencyStats([]time.Duration{3 * time.Millisecond})
+	r.Equal(btcjson.LatencyStats{Samples: 1, P50: 3000, P90: 3000,
+		P99: 3000, Max: 3000}, stats)
+}
+
+func TestBlockLatencies(t *testing.T) {
+
+	r := require.New(t)
+
+	var timings []blockchain.BlockTimings
+	for i := 1; i <= slowestBlocks+5; i++ {
+		timings = append(timings, blockchain.BlockTimings{
+			Height: int32(i),
+			Total:  time.Duration(i%7) * time.Millisecond,
+			DB:     time.Millisecond,
+		})
+	}
+
+	latency := blockLatencies(timings)
+	r.Equal(slowestBlocks+5, latency.Total.Samples)
+	r.EqualValues(6000, latency.Total.Max)
+	r.EqualValues(1000, latency.DB.P50)
+	r.Len(latency.Slowest, slowestBlocks)
+	r.EqualValues(6, latency.Slowest[0].Height)
+	r.EqualValues(13, latency.Slowest[1].Height)
+	for i := 1; i < len(latency.Slowest); i++ {
+		r.LessOrEqual(latency.Slowest[i].Total, latency.Slowest[i-1].Total)
+	}
+}
+
+func TestDrainCommands(t *testing.T) {
+
+	r := require.New(t)
+
+	defer func(log btclog.Logger) { rpcsLog = log }(rpcsLog)
+	rpcsLog = btclog.Disabled
+
+	s := &rpcServer{
+		activeCmds:  make(map[*rpcActiveCmd]struct{}),
+		methodStats: make(map[string]*rpcMethodStats),
+	}
+
+	// Draining returns once the commands being processed finish.
+	done := s.trackCommand("getinfo", "client")
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		time.Sleep(200 * time.Millisecond)
+		done(nil)
+	}()
+	start := time.Now()
+	s.drainCommands(time.Minute)
+	r.Less(time.Since(start), 10*time.Second)
+	<-finished
+	r.Empty(s.activeCommands())
+
+	// The commands still being processed are abandoned after the timeout.
+	done = s.trackCommand("getinfo", "client")
+	start = time.Now()
+	s.drainCommands(300 * time.Millisecond)
+	r.GreaterOrEqual(time.Since(start), 300*time.Millisecond)
+	r.Len(s.activeCommands(), 1)
+	done(nil)
+	r.Empty(s.activeCommands())
+}
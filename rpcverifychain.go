@@ -0,0 +1,195 @@
+package main
+
+import (
+	"time"
+
+	"github.com/lbryio/lbcd/blockchain"
+	"github.com/lbryio/lbcd/btcjson"
+)
+
+// The statuses of the verifications of the chain reported by
+// getverifychaininfo.
+const (
+	verifyChainNone    = "none"
+	verifyChainRunning = "running"
+	verifyChainValid   = "valid"
+	verifyChainInvalid = "invalid"
+)
+
+// chainVerification is the state of a verification of the chain started by
+// the verifychain command.
+type chainVerification struct {
+	level      int32
+	depth      int32
+	bestHeight int32
+	start      time.Time
+	done       chan struct{}
+
+	// The following fields are protected by the verification lock of the
+	// server.
+	progress blockchain.VerifyProgress
+	finished time.Time
+	err      error
+}
+
+// info returns the state of the verification as returned by the
+// getverifychaininfo command.
+//
+// This function MUST be called with the verification lock of the server held.
+func (v *chainVerification) info(now time.Time) *btcjson.GetVerifyChainInfoResult {
+	result := &btcjson.GetVerifyChainInfoResult{
+		Status:         verifyChainRunning,
+		CheckLevel:     v.level,
+		NumBlocks:      v.depth,
+		BestHeight:     v.bestHeight,
+		Height:         v.progress.Height,
+		Blocks:         v.progress.Blocks,
+		TotalBlocks:    v.progress.TotalBlocks,
+		ReplayedBlocks: v.progress.ReplayedBlocks,
+		ClaimNames:     v.progress.ClaimNames,
+		StartTime:      v.start.Unix(),
+	}
+	if v.progress.TotalBlocks > 0 {
+		result.Progress = float64(v.progress.Blocks) /
+			float64(v.progress.TotalBlocks)
+	}
+	if !v.finished.IsZero() {
+		now = v.finished
+		result.Status = verifyChainValid
+		if v.err != nil {
+			result.Status = verifyChainInvalid
+			result.Error = v.err.Error()
+		}
+	}
+	result.Elapsed = now.Sub(v.start).Seconds()
+	return result
+}
+
+// startVerifyChain starts the verification of the depth blocks at the end of
+// the main chain at the passed check level, unless a verification is already
+// running.
+func (s *rpcServer) startVerifyChain(level, depth int32) (*chainVerification, error) {
+	s.verifyingLock.Lock()
+	defer s.verifyingLock.Unlock()
+
+	if s.verifying != nil && s.verifying.finished.IsZero() {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCMisc,
+			Message: "A chain verification is already running, see " +
+				"getverifychaininfo for its progress",
+		}
+	}
+
+	v := &chainVerification{
+		level:      level,
+		depth:      depth,
+		bestHeight: s.cfg.Chain.BestSnapshot().Height,
+		start:      time.Now(),
+		done:       make(chan struct{}),
+	}
+	s.verifying = v
+	rpcsLog.Infof("Starting the verification of the chain at level %d "+
+		"from height %d", level, v.bestHeight)
+
+	s.wg.Add(1)
+	go s.verifyChain(v)
+
+	return v, nil
+}
+
+// verifyChain runs the verification of the chain of v until it completes or
+// the server shuts down.
+//
+// It must be run as a goroutine.
+func (s *rpcServer) verifyChain(v *chainVerification) {
+	defer s.wg.Done()
+
+	interrupt := make(chan struct{})
+	go func() {
+		select {
+		case <-s.quit:
+			close(interrupt)
+		case <-v.done:
+		}
+	}()
+
+	err := s.cfg.Chain.VerifyChain(v.level, v.depth, interrupt,
+		func(p *blockchain.VerifyProgress) {
+			s.verifyingLock.Lock()
+			v.progress = *p
+			s.verifyingLock.Unlock()
+		})
+
+	s.verifyingLock.Lock()
+	v.err = err
+	v.finished = time.Now()
+	s.verifyingLock.Unlock()
+	close(v.done)
+
+	elapsed := v.finished.Sub(v.start).Round(time.Millisecond)
+	if err != nil {
+		rpcsLog.Errorf("Chain verification failed after %v: %v", elapsed,
+			err)
+		return
+	}
+	rpcsLog.Infof("Chain verification completed successfully in %v",
+		elapsed)
+}
+
+// handleVerifyChain implements the verifychain command.
+//
+// The verification keeps running in the background when the client
+// disconnects, and its progress is returned by getverifychaininfo.
+func handleVerifyChain(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.VerifyChainCmd)
+
+	var checkLevel, checkDepth int32
+	if c.CheckLevel != nil {
+		checkLevel = *c.CheckLevel
+	}
+	if c.CheckDepth != nil {
+		checkDepth = *c.CheckDepth
+	}
+
+	// The levels above the highest supported level are clamped to it, and
+	// zero blocks verifies the whole chain.
+	if checkLevel < blockchain.VerifyLoad {
+		checkLevel = blockchain.VerifyLoad
+	}
+	if checkLevel > blockchain.MaxVerifyLevel {
+		checkLevel = blockchain.MaxVerifyLevel
+	}
+	if checkDepth < 0 {
+		checkDepth = 0
+	}
+
+	v, err := s.startVerifyChain(checkLevel, checkDepth)
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case <-v.done:
+	case <-closeChan:
+		return nil, ErrClientQuit
+	case <-s.quit:
+		return nil, ErrClientQuit
+	}
+
+	s.verifyingLock.Lock()
+	err = v.err
+	s.verifyingLock.Unlock()
+	return err == nil, nil
+}
+
+// handleGetVerifyChainInfo implements the getverifychaininfo command.
+func handleGetVerifyChainInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	s.verifyingLock.Lock()
+	defer s.verifyingLock.Unlock()
+
+	if s.verifying == nil {
+		return &btcjson.GetVerifyChainInfoResult{
+			Status: verifyChainNone,
+		}, nil
+	}
+	return s.verifying.info(time.Now()), nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbcd/blockchain"
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyChainInfo(t *testing.T) {
+
+	r := require.New(t)
+
+	s := &rpcServer{}
+	result, err := handleGetVerifyChainInfo(s, &btcjson.GetVerifyChainInfoCmd{}, nil)
+	r.NoError(err)
+	r.Equal(verifyChainNone, result.(*btcjson.GetVerifyChainInfoResult).Status)
+
+	// A running verification reports its progress and blocks the start of
+	// another one.
+	start := time.Unix(1700000000, 0)
+	v := &chainVerification{
+		level:      blockchain.MaxVerifyLevel,
+		bestHeight: 100,
+		start:      start,
+		progress: blockchain.VerifyProgress{
+			Height:         76,
+			Blocks:         25,
+			TotalBlocks:    100,
+			ReplayedBlocks: 25,
+		},
+	}
+	s.verifying = v
+	info := v.info(start.Add(2 * time.Second))
+	r.Equal(verifyChainRunning, info.Status)
+	r.Equal(blockchain.MaxVerifyLevel, info.CheckLevel)
+	r.EqualValues(76, info.Height)
+	r.Equal(0.25, info.Progress)
+	r.Equal(2.0, info.Elapsed)
+	r.Empty(info.Error)
+	_, err = s.startVerifyChain(blockchain.VerifyLoad, 1)
+	r.Error(err)
+
+	v.finished = start.Add(3 * time.Second)
+	info = v.info(start.Add(time.Minute))
+	r.Equal(verifyChainValid, info.Status)
+	r.Equal(3.0, info.Elapsed)
+
+	v.err = errors.New("bad undo data")
+	info = v.info(start.Add(time.Minute))
+	r.Equal(verifyChainInvalid, info.Status)
+	r.Equal("bad undo data", info.Error)
+}
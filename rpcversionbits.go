@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/lbryio/lbcd/blockchain"
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/lbryio/lbcd/mining"
+)
+
+// versionBitsResult returns the version of the next block template, along with
+// the state and signaling of each of the rule change deployments of the chain.
+func versionBitsResult(s *rpcServer) (*btcjson.GetVersionBitsResult, error) {
+	version, err := s.cfg.Generator.NextBlockVersion()
+	if err != nil {
+		context := "Failed to calculate the next block version"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	signaling := s.cfg.Generator.Signaling()
+	result := &btcjson.GetVersionBitsResult{Version: version}
+	for id, deployment := range s.cfg.ChainParams.Deployments {
+		state, err := s.cfg.Chain.ThresholdState(uint32(id))
+		if err != nil {
+			context := "Failed to obtain deployment status"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		status, err := softForkStatus(state)
+		if err != nil {
+			return nil, internalRPCError(err.Error(), "")
+		}
+
+		signal := mining.SignalDefault
+		if signaling != nil {
+			signal = signaling.Signal(uint32(id))
+		}
+		voting := state == blockchain.ThresholdStarted ||
+			state == blockchain.ThresholdLockedIn
+		result.Deployments = append(result.Deployments, btcjson.VersionBitsDeployment{
+			Name:      mining.DeploymentName(uint32(id)),
+			Bit:       deployment.BitNumber,
+			Status:    strings.ToLower(status),
+			Signal:    signal.String(),
+			Signaling: voting && mining.VersionSignals(version, deployment.BitNumber),
+		})
+	}
+	return result, nil
+}
+
+// handleGetVersionBits implements the getversionbits command.
+func handleGetVersionBits(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	return versionBitsResult(s)
+}
+
+// handleSetVersionBits implements the setversionbits command.
+func handleSetVersionBits(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.SetVersionBitsCmd)
+
+	signaling := s.cfg.Generator.Signaling()
+	if signaling == nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "The signaling of the block templates is not available",
+		}
+	}
+	id, err := mining.DeploymentByName(c.Deployment)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: err.Error(),
+		}
+	}
+	signal, err := mining.ParseSignal(c.Signal)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: err.Error(),
+		}
+	}
+	if err := signaling.SetSignal(id, signal); err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: err.Error(),
+		}
+	}
+
+	// Make the next getblocktemplate request generate a template with the
+	// new version.
+	state := s.gbtWorkState
+	state.Lock()
+	state.prevHash = nil
+	state.Unlock()
+
+	rpcsLog.Infof("Set the signal of deployment %s to %v",
+		mining.DeploymentName(id), signal)
+	return versionBitsResult(s)
+}
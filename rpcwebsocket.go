@@ -7,16 +7,14 @@ package main
 
 import (
 	"bytes"
-	"container/list"
-	"crypto/sha256"
-	"crypto/subtle"
-	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"math"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
@@ -25,8 +23,11 @@ import (
 	"github.com/lbryio/lbcd/btcjson"
 	"github.com/lbryio/lbcd/chaincfg"
 	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/claimtrie/change"
+	"github.com/lbryio/lbcd/claimtrie/normalization"
 	"github.com/lbryio/lbcd/database"
 	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/txscript/claimscript"
 	"github.com/lbryio/lbcd/wire"
 	btcutil "github.com/lbryio/lbcutil"
 	"golang.org/x/crypto/ripemd160"
@@ -39,6 +40,11 @@ const (
 	// handler since notifications have their own queuing mechanism
 	// independent of the send channel buffer.
 	websocketSendBufferSize = 50
+
+	// maxNotificationReplay is the maximum number of blocks whose
+	// notifications are replayed to a client registering for block updates
+	// from a previous block, as the other clients wait meanwhile.
+	maxNotificationReplay = 2000
 )
 
 type semaphore chan struct{}
@@ -66,14 +72,18 @@ var wsHandlersBeforeInit = map[string]wsCommandHandler{
 	"loadtxfilter":              handleLoadTxFilter,
 	"help":                      handleWebsocketHelp,
 	"notifyblocks":              handleNotifyBlocks,
+	"notifyclaims":              handleNotifyClaims,
 	"notifynewtransactions":     handleNotifyNewTransactions,
 	"notifyreceived":            handleNotifyReceived,
 	"notifyspent":               handleNotifySpent,
+	"notifywork":                handleNotifyWork,
 	"session":                   handleSession,
 	"stopnotifyblocks":          handleStopNotifyBlocks,
+	"stopnotifyclaims":          handleStopNotifyClaims,
 	"stopnotifynewtransactions": handleStopNotifyNewTransactions,
 	"stopnotifyspent":           handleStopNotifySpent,
 	"stopnotifyreceived":        handleStopNotifyReceived,
+	"stopnotifywork":            handleStopNotifyWork,
 	"rescan":                    handleRescan,
 	"rescanblocks":              handleRescanBlocks,
 }
@@ -84,7 +94,7 @@ var wsHandlersBeforeInit = map[string]wsCommandHandler{
 // server handler which runs each new connection in a new goroutine thereby
 // satisfying the requirement.
 func (s *rpcServer) WebsocketHandler(conn *websocket.Conn, remoteAddr string,
-	authenticated bool, isAdmin bool) {
+	user *rpcAuthUser) {
 
 	// Clear the read deadline that was set before the websocket hijacked
 	// the connection.
@@ -92,7 +102,7 @@ func (s *rpcServer) WebsocketHandler(conn *websocket.Conn, remoteAddr string,
 
 	// Limit max number of websocket clients.
 	rpcsLog.Infof("New websocket client %s", remoteAddr)
-	if s.ntfnMgr.NumClients()+1 > cfg.RPCMaxWebsockets {
+	if s.ntfnMgr.NumClients()+s.sseNumClients()+1 > cfg.RPCMaxWebsockets {
 		rpcsLog.Infof("Max websocket clients exceeded [%d] - "+
 			"disconnecting client %s", cfg.RPCMaxWebsockets,
 			remoteAddr)
@@ -103,7 +113,7 @@ func (s *rpcServer) WebsocketHandler(conn *websocket.Conn, remoteAddr string,
 	// Create a new websocket client to handle the new websocket connection
 	// and wait for it to shutdown.  Once it has shutdown (and hence
 	// disconnected), remove it and any notifications it registered for.
-	client, err := newWebsocketClient(s, conn, remoteAddr, authenticated, isAdmin)
+	client, err := newWebsocketClient(s, conn, remoteAddr, user)
 	if err != nil {
 		rpcsLog.Errorf("Failed to serve client %s: %v", remoteAddr, err)
 		conn.Close()
@@ -139,6 +149,10 @@ type wsNotificationManager struct {
 	// Access channel for current number of connected clients.
 	numClients chan int
 
+	// numWorkClients is the number of clients registered for work
+	// notifications.  It must be accessed atomically.
+	numWorkClients int32
+
 	// Shutdown handling
 	wg   sync.WaitGroup
 	quit chan struct{}
@@ -226,6 +240,15 @@ func (m *wsNotificationManager) NotifyBlockDisconnected(block *btcutil.Block) {
 	}
 }
 
+// NotifyChainReorganized passes a reorganization of the best chain to the
+// notification manager for block notification processing.
+func (m *wsNotificationManager) NotifyChainReorganized(reorg *blockchain.Reorganization) {
+	select {
+	case m.queueNotification <- (*notificationChainReorganized)(reorg):
+	case <-m.quit:
+	}
+}
+
 // NotifyMempoolTx passes a transaction accepted by mempool to the
 // notification manager for transaction notification processing.  If
 // isNew is true, the tx is is a new transaction, rather than one
@@ -246,8 +269,9 @@ func (m *wsNotificationManager) NotifyMempoolTx(tx *btcutil.Tx, isNew bool) {
 	}
 }
 
-// wsClientFilter tracks relevant addresses for each websocket client for
-// the `rescanblocks` extension. It is modified by the `loadtxfilter` command.
+// wsClientFilter tracks relevant addresses, outpoints and claims for each
+// websocket client for the `rescanblocks` extension. It is modified by the
+// `loadtxfilter` command.
 //
 // NOTE: This extension was ported from github.com/decred/dcrd
 type wsClientFilter struct {
@@ -266,13 +290,20 @@ type wsClientFilter struct {
 
 	// Outpoints of unspent outputs.
 	unspent map[wire.OutPoint]struct{}
+
+	// Normalized names and IDs of the claims whose claim, update and
+	// support outputs are relevant.
+	claimNames map[string]struct{}
+	claimIDs   map[change.ClaimID]struct{}
 }
 
 // newWSClientFilter creates a new, empty wsClientFilter struct to be used
 // for a websocket client.
 //
 // NOTE: This extension was ported from github.com/decred/dcrd
-func newWSClientFilter(addresses []string, unspentOutPoints []wire.OutPoint, params *chaincfg.Params) *wsClientFilter {
+func newWSClientFilter(addresses []string, unspentOutPoints []wire.OutPoint,
+	claimNames []string, claimIDs []change.ClaimID, params *chaincfg.Params) *wsClientFilter {
+
 	filter := &wsClientFilter{
 		pubKeyHashes:        map[[ripemd160.Size]byte]struct{}{},
 		scriptHashes:        map[[ripemd160.Size]byte]struct{}{},
@@ -280,6 +311,8 @@ func newWSClientFilter(addresses []string, unspentOutPoints []wire.OutPoint, par
 		uncompressedPubKeys: map[[65]byte]struct{}{},
 		otherAddresses:      map[string]struct{}{},
 		unspent:             make(map[wire.OutPoint]struct{}, len(unspentOutPoints)),
+		claimNames:          make(map[string]struct{}, len(claimNames)),
+		claimIDs:            make(map[change.ClaimID]struct{}, len(claimIDs)),
 	}
 
 	for _, s := range addresses {
@@ -288,6 +321,12 @@ func newWSClientFilter(addresses []string, unspentOutPoints []wire.OutPoint, par
 	for i := range unspentOutPoints {
 		filter.addUnspentOutPoint(&unspentOutPoints[i])
 	}
+	for _, name := range claimNames {
+		filter.addClaimName(name)
+	}
+	for _, id := range claimIDs {
+		filter.addClaimID(id)
+	}
 
 	return filter
 }
@@ -444,9 +483,46 @@ func (f *wsClientFilter) removeUnspentOutPoint(op *wire.OutPoint) {
 	delete(f.unspent, *op)
 }
 
+// addClaimName adds a claim name to the wsClientFilter.  The name is matched
+// in its normalized form, so the names differing only by case match too.
+func (f *wsClientFilter) addClaimName(name string) {
+	f.claimNames[string(normalization.Normalize([]byte(name)))] = struct{}{}
+}
+
+// addClaimID adds a claim ID to the wsClientFilter.
+func (f *wsClientFilter) addClaimID(id change.ClaimID) {
+	f.claimIDs[id] = struct{}{}
+}
+
+// existsClaim returns true if the claim script of the output at the passed
+// outpoint, which may be nil, claims, updates or supports a name or a claim
+// added to the wsClientFilter.
+func (f *wsClientFilter) existsClaim(cs *claimscript.Script, op *wire.OutPoint) bool {
+	if cs == nil || len(f.claimNames)+len(f.claimIDs) == 0 {
+		return false
+	}
+	if _, ok := f.claimNames[string(cs.NormalizedName())]; ok {
+		return true
+	}
+	_, ok := f.claimIDs[cs.ClaimIDFor(*op)]
+	return ok
+}
+
+// parseClaimScript returns the claim script prefixed to an output script, or
+// nil if it has none.
+func parseClaimScript(pkScript []byte) *claimscript.Script {
+	cs, err := claimscript.Parse(pkScript)
+	if err != nil {
+		return nil
+	}
+	return cs
+}
+
 // Notification types
 type notificationBlockConnected btcutil.Block
 type notificationBlockDisconnected btcutil.Block
+type notificationChainReorganized blockchain.Reorganization
+type notificationWork btcjson.GetBlockTemplateResult
 type notificationTxAcceptedByMempool struct {
 	isNew bool
 	tx    *btcutil.Tx
@@ -456,7 +532,25 @@ type notificationTxAcceptedByMempool struct {
 type notificationRegisterClient wsClient
 type notificationUnregisterClient wsClient
 type notificationRegisterBlocks wsClient
+type notificationRegisterBlocksFrom struct {
+	wsc  *wsClient
+	from string
+	done chan error
+}
 type notificationUnregisterBlocks wsClient
+type notificationRegisterWork struct {
+	wsc    *wsClient
+	deltas bool
+}
+type notificationUnregisterWork wsClient
+type notificationRegisterClaims struct {
+	wsc   *wsClient
+	names []string
+}
+type notificationUnregisterClaims wsClient
+type notificationClientQueues struct {
+	done chan []btcjson.RPCWebsocketClient
+}
 type notificationRegisterNewMempoolTxs wsClient
 type notificationUnregisterNewMempoolTxs wsClient
 type notificationRegisterSpent struct {
@@ -491,9 +585,20 @@ func (m *wsNotificationManager) notificationHandler() {
 	// since it is quite a bit more efficient than using the entire struct.
 	blockNotifications := make(map[chan struct{}]*wsClient)
 	txNotifications := make(map[chan struct{}]*wsClient)
+	workNotifications := make(map[chan struct{}]*wsWorkClient)
+	claimNotifications := make(map[chan struct{}]*wsClaimClient)
 	watchedOutPoints := make(map[wire.OutPoint]map[chan struct{}]*wsClient)
 	watchedAddrs := make(map[string]map[chan struct{}]*wsClient)
 
+	// The tip of the main chain as notified so far, which notifications are
+	// replayed up to.
+	best := m.server.cfg.Chain.BestSnapshot()
+	tipHash, tipHeight := best.Hash, best.Height
+
+	// The last block template notified, which is sent to the clients
+	// registering for work notifications while it is current.
+	var lastWork *btcjson.GetBlockTemplateResult
+
 out:
 	for {
 		select {
@@ -505,6 +610,7 @@ out:
 			switch n := n.(type) {
 			case *notificationBlockConnected:
 				block := (*btcutil.Block)(n)
+				tipHash, tipHeight = *block.Hash(), block.Height()
 
 				// Skip iterating through all txs if no
 				// tx notification requests exist.
@@ -522,8 +628,14 @@ out:
 						block)
 				}
 
+				if len(claimNotifications) != 0 {
+					m.notifyClaims(claimNotifications, block)
+				}
+
 			case *notificationBlockDisconnected:
 				block := (*btcutil.Block)(n)
+				tipHash = block.MsgBlock().Header.PrevBlock
+				tipHeight = block.Height() - 1
 
 				if len(blockNotifications) != 0 {
 					m.notifyBlockDisconnected(blockNotifications,
@@ -532,6 +644,16 @@ out:
 						block)
 				}
 
+			case *notificationChainReorganized:
+				if len(blockNotifications) != 0 {
+					m.notifyChainReorganized(blockNotifications,
+						(*blockchain.Reorganization)(n))
+				}
+
+			case *notificationWork:
+				lastWork = (*btcjson.GetBlockTemplateResult)(n)
+				m.notifyWork(workNotifications, lastWork)
+
 			case *notificationTxAcceptedByMempool:
 				if n.isNew && len(txNotifications) != 0 {
 					m.notifyForNewTx(txNotifications, n.tx)
@@ -543,20 +665,64 @@ out:
 				wsc := (*wsClient)(n)
 				blockNotifications[wsc.quit] = wsc
 
+			case *notificationRegisterBlocksFrom:
+				err := m.replayBlocks(n.wsc, n.from, &tipHash, tipHeight)
+				if err == nil {
+					blockNotifications[n.wsc.quit] = n.wsc
+				}
+				n.done <- err
+
 			case *notificationUnregisterBlocks:
 				wsc := (*wsClient)(n)
 				delete(blockNotifications, wsc.quit)
 
+			case *notificationRegisterWork:
+				c := &wsWorkClient{wsc: n.wsc, deltas: n.deltas}
+				workNotifications[n.wsc.quit] = c
+				m.setNumWorkClients(len(workNotifications))
+				if lastWork != nil &&
+					lastWork.PreviousHash == tipHash.String() {
+
+					m.notifyWork(map[chan struct{}]*wsWorkClient{
+						n.wsc.quit: c}, lastWork)
+				}
+				m.server.notifyWorkUpdate()
+
+			case *notificationUnregisterWork:
+				wsc := (*wsClient)(n)
+				delete(workNotifications, wsc.quit)
+				m.setNumWorkClients(len(workNotifications))
+
+			case *notificationRegisterClaims:
+				claimNotifications[n.wsc.quit] = &wsClaimClient{
+					wsc:   n.wsc,
+					names: n.names,
+				}
+
+			case *notificationUnregisterClaims:
+				wsc := (*wsClient)(n)
+				delete(claimNotifications, wsc.quit)
+
 			case *notificationRegisterClient:
 				wsc := (*wsClient)(n)
 				clients[wsc.quit] = wsc
 
+			case *notificationClientQueues:
+				queues := make([]btcjson.RPCWebsocketClient, 0, len(clients))
+				for _, wsc := range clients {
+					queues = append(queues, wsc.ntfnQueue.stats(wsc.addr))
+				}
+				n.done <- queues
+
 			case *notificationUnregisterClient:
 				wsc := (*wsClient)(n)
 				// Remove any requests made by the client as well as
 				// the client itself.
 				delete(blockNotifications, wsc.quit)
 				delete(txNotifications, wsc.quit)
+				delete(workNotifications, wsc.quit)
+				m.setNumWorkClients(len(workNotifications))
+				delete(claimNotifications, wsc.quit)
 				for k := range wsc.spentRequests {
 					op := k
 					m.removeSpentRequest(watchedOutPoints, wsc, &op)
@@ -613,12 +779,137 @@ func (m *wsNotificationManager) NumClients() (n int) {
 	return
 }
 
+// ClientQueues returns the statistics of the notification queues of the
+// clients, sorted by address.
+func (m *wsNotificationManager) ClientQueues() []btcjson.RPCWebsocketClient {
+	done := make(chan []btcjson.RPCWebsocketClient, 1)
+	select {
+	case m.queueNotification <- &notificationClientQueues{done: done}:
+	case <-m.quit:
+		return nil
+	}
+
+	var queues []btcjson.RPCWebsocketClient
+	select {
+	case queues = <-done:
+	case <-m.quit:
+		return nil
+	}
+	sort.Slice(queues, func(i, j int) bool {
+		return queues[i].Client < queues[j].Client
+	})
+	return queues
+}
+
 // RegisterBlockUpdates requests block update notifications to the passed
 // websocket client.
 func (m *wsNotificationManager) RegisterBlockUpdates(wsc *wsClient) {
 	m.queueNotification <- (*notificationRegisterBlocks)(wsc)
 }
 
+// RegisterBlockUpdatesFrom requests block update notifications to the passed
+// websocket client, after replaying those of the blocks connected since the
+// block from, which is the height or hash of the last block seen by the client.
+func (m *wsNotificationManager) RegisterBlockUpdatesFrom(wsc *wsClient, from string) error {
+	done := make(chan error, 1)
+	m.queueNotification <- &notificationRegisterBlocksFrom{
+		wsc:  wsc,
+		from: from,
+		done: done,
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-wsc.quit:
+		return ErrClientQuit
+	}
+}
+
+// replayBlocks queues to the passed websocket client the block update
+// notifications since the block from, up to the notified tip of the main chain.
+// When from is a block which is no longer in the main chain, the disconnection
+// of the blocks back to the main chain is notified first.
+func (m *wsNotificationManager) replayBlocks(wsc *wsClient, from string,
+	tipHash *chainhash.Hash, tipHeight int32) error {
+
+	errReplayLimit := &btcjson.RPCError{
+		Code: btcjson.ErrRPCMisc,
+		Message: fmt.Sprintf("Cannot replay the notifications of more "+
+			"than %d blocks, use rescanblocks instead",
+			maxNotificationReplay),
+	}
+
+	chain := m.server.cfg.Chain
+	if !chain.MainChainHasBlock(tipHash) {
+		return &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "The main chain is being reorganized, retry later",
+		}
+	}
+
+	var height int32
+	var stale []wire.BlockHeader
+	if len(from) == chainhash.MaxHashStringSize {
+		hash, err := chainhash.NewHashFromStr(from)
+		if err != nil {
+			return rpcDecodeHexError(from)
+		}
+		for !chain.MainChainHasBlock(hash) {
+			header, err := chain.HeaderByHash(hash)
+			if err != nil {
+				return &btcjson.RPCError{
+					Code:    btcjson.ErrRPCBlockNotFound,
+					Message: "Block not found: " + from,
+				}
+			}
+			if len(stale) == maxNotificationReplay {
+				return errReplayLimit
+			}
+			stale = append(stale, header)
+			hash = &header.PrevBlock
+		}
+		height, _ = chain.BlockHeightByHash(hash)
+	} else {
+		h, err := strconv.ParseInt(from, 10, 32)
+		if err != nil || h < -1 {
+			return &btcjson.RPCError{
+				Code: btcjson.ErrRPCInvalidParameter,
+				Message: "rescanfrom must be a block hash or " +
+					"height: " + from,
+			}
+		}
+		height = int32(h)
+	}
+
+	count := len(stale)
+	if height < tipHeight {
+		count += int(tipHeight - height)
+	}
+	if count > maxNotificationReplay {
+		return errReplayLimit
+	}
+
+	clients := map[chan struct{}]*wsClient{wsc.quit: wsc}
+	for i := range stale {
+		block := btcutil.NewBlock(&wire.MsgBlock{Header: stale[i]})
+		block.SetHeight(height + int32(len(stale)-i))
+		m.notifyBlockDisconnected(clients, block)
+		m.notifyFilteredBlockDisconnected(clients, block)
+	}
+	for h := height + 1; h <= tipHeight; h++ {
+		block, err := chain.BlockByHeight(h)
+		if err != nil {
+			return &btcjson.RPCError{
+				Code:    btcjson.ErrRPCBlockNotFound,
+				Message: "Failed to fetch block: " + err.Error(),
+			}
+		}
+		m.notifyBlockConnected(clients, block)
+		m.notifyFilteredBlockConnected(clients, block)
+	}
+	return nil
+}
+
 // UnregisterBlockUpdates removes block update notifications for the passed
 // websocket client.
 func (m *wsNotificationManager) UnregisterBlockUpdates(wsc *wsClient) {
@@ -627,7 +918,8 @@ func (m *wsNotificationManager) UnregisterBlockUpdates(wsc *wsClient) {
 
 // subscribedClients returns the set of all websocket client quit channels that
 // are registered to receive notifications regarding tx, either due to tx
-// spending a watched output or outputting to a watched address.  Matching
+// spending a watched output, outputting to a watched address or claiming,
+// updating or supporting a watched claim name or claim ID.  Matching
 // client's filters are updated based on this transaction's outputs and output
 // addresses that may be relevant for a client.
 func (m *wsNotificationManager) subscribedClients(tx *btcutil.Tx,
@@ -655,13 +947,21 @@ func (m *wsNotificationManager) subscribedClients(tx *btcutil.Tx,
 	}
 
 	for i, output := range msgTx.TxOut {
+		// Clients are not able to subscribe to nonstandard or
+		// non-address outputs, unless they are claim outputs.
 		_, addrs, _, err := txscript.ExtractPkScriptAddrs(
 			output.PkScript, m.server.cfg.ChainParams)
 		if err != nil {
-			// Clients are not able to subscribe to
-			// nonstandard or non-address outputs.
+			addrs = nil
+		}
+		cs := parseClaimScript(output.PkScript)
+		if len(addrs) == 0 && cs == nil {
 			continue
 		}
+		op := wire.OutPoint{
+			Hash:  *tx.Hash(),
+			Index: uint32(i),
+		}
 		for quitChan, wsc := range clients {
 			wsc.Lock()
 			filter := wsc.filterData
@@ -670,16 +970,16 @@ func (m *wsNotificationManager) subscribedClients(tx *btcutil.Tx,
 				continue
 			}
 			filter.mu.Lock()
+			relevant := filter.existsClaim(cs, &op)
 			for _, a := range addrs {
 				if filter.existsAddress(a) {
-					subscribed[quitChan] = struct{}{}
-					op := wire.OutPoint{
-						Hash:  *tx.Hash(),
-						Index: uint32(i),
-					}
-					filter.addUnspentOutPoint(&op)
+					relevant = true
 				}
 			}
+			if relevant {
+				subscribed[quitChan] = struct{}{}
+				filter.addUnspentOutPoint(&op)
+			}
 			filter.mu.Unlock()
 		}
 	}
@@ -730,6 +1030,38 @@ func (*wsNotificationManager) notifyBlockDisconnected(clients map[chan struct{}]
 	}
 }
 
+// notifyChainReorganized notifies websocket clients that have registered for
+// block updates when the main chain is reorganized, after the notifications of
+// the blocks disconnected and connected.
+func (*wsNotificationManager) notifyChainReorganized(clients map[chan struct{}]*wsClient,
+	reorg *blockchain.Reorganization) {
+
+	ntfn := btcjson.NewChainReorganizedNtfn(btcjson.ChainReorganization{
+		ForkHash:     reorg.ForkHash.String(),
+		ForkHeight:   reorg.ForkHeight,
+		Depth:        int32(len(reorg.Disconnected)),
+		Disconnected: make([]string, 0, len(reorg.Disconnected)),
+		Connected:    make([]string, 0, len(reorg.Connected)),
+	})
+	for i := range reorg.Disconnected {
+		ntfn.Reorganization.Disconnected = append(
+			ntfn.Reorganization.Disconnected, reorg.Disconnected[i].String())
+	}
+	for i := range reorg.Connected {
+		ntfn.Reorganization.Connected = append(
+			ntfn.Reorganization.Connected, reorg.Connected[i].String())
+	}
+	marshalledJSON, err := btcjson.MarshalCmd(btcjson.RpcVersion1, nil, ntfn)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal chain reorganized "+
+			"notification: %v", err)
+		return
+	}
+	for _, wsc := range clients {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
 // notifyFilteredBlockConnected notifies websocket clients that have registered for
 // block updates when a block is connected to the main chain.
 func (m *wsNotificationManager) notifyFilteredBlockConnected(clients map[chan struct{}]*wsClient,
@@ -1241,8 +1573,9 @@ type wsResponse struct {
 // requests and another for async notifications.  Responses to client requests
 // use SendMessage which employs a buffered channel thereby limiting the number
 // of outstanding requests that can be made.  Notifications are sent via
-// QueueNotification which implements a queue via notificationQueueHandler to
-// ensure sending notifications from other subsystems can't block.  Ultimately,
+// QueueNotification which implements a bounded queue via
+// notificationQueueHandler to ensure sending notifications from other
+// subsystems can't block, while slow clients can't exhaust the memory.  Ultimately,
 // all messages are sent via the outHandler.
 type wsClient struct {
 	sync.Mutex
@@ -1264,9 +1597,10 @@ type wsClient struct {
 	// and therefore is allowed to communicated over the websocket.
 	authenticated bool
 
-	// isAdmin specifies whether a client may change the state of the server;
-	// false means its access is only to the limited set of RPC calls.
-	isAdmin bool
+	// user is the RPC user the client authenticated as, which determines
+	// the commands it may call.  It is nil until the client is
+	// authenticated.
+	user *rpcAuthUser
 
 	// sessionID is a random ID generated for each client when connected.
 	// These IDs may be queried by a client using the session RPC.  A change
@@ -1295,6 +1629,7 @@ type wsClient struct {
 	// Networking infrastructure.
 	serviceRequestSem semaphore
 	ntfnChan          chan []byte
+	ntfnQueue         *wsNotificationQueue
 	sendChan          chan wsResponse
 	quit              chan struct{}
 	wg                sync.WaitGroup
@@ -1410,17 +1745,14 @@ out:
 				break out
 			case !c.authenticated:
 				// Check credentials.
-				login := authCmd.Username + ":" + authCmd.Passphrase
-				auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
-				authSha := sha256.Sum256([]byte(auth))
-				cmp := subtle.ConstantTimeCompare(authSha[:], c.server.authsha[:])
-				limitcmp := subtle.ConstantTimeCompare(authSha[:], c.server.limitauthsha[:])
-				if cmp != 1 && limitcmp != 1 {
+				user := c.server.checkCredentials(authCmd.Username,
+					authCmd.Passphrase)
+				if user == nil {
 					rpcsLog.Warnf("Auth failure.")
 					break out
 				}
 				c.authenticated = true
-				c.isAdmin = cmp == 1
+				c.user = user
 
 				// Marshal and send response.
 				reply, err = createMarshalledReply(cmd.jsonrpc, cmd.id, nil, nil)
@@ -1433,24 +1765,22 @@ out:
 				continue
 			}
 
-			// Check if the client is using limited RPC credentials and
-			// error when not authorized to call the supplied RPC.
-			if !c.isAdmin {
-				if _, ok := rpcLimited[req.Method]; !ok {
-					jsonErr := &btcjson.RPCError{
-						Code:    btcjson.ErrRPCInvalidParams.Code,
-						Message: "limited user not authorized for this method",
-					}
-					// Marshal and send response.
-					reply, err = createMarshalledReply("", req.ID, nil, jsonErr)
-					if err != nil {
-						rpcsLog.Errorf("Failed to marshal parse failure "+
-							"reply: %v", err)
-						continue
-					}
-					c.SendMessage(reply, nil)
+			// Check if the client is allowed to call the supplied
+			// RPC by the whitelist of its user.
+			if !c.user.allowed(req.Method) {
+				jsonErr := &btcjson.RPCError{
+					Code:    btcjson.ErrRPCInvalidParams.Code,
+					Message: "user not authorized for this method",
+				}
+				// Marshal and send response.
+				reply, err = createMarshalledReply("", req.ID, nil, jsonErr)
+				if err != nil {
+					rpcsLog.Errorf("Failed to marshal parse failure "+
+						"reply: %v", err)
 					continue
 				}
+				c.SendMessage(reply, nil)
+				continue
 			}
 
 			// Asynchronously handle the request.  A semaphore is used to
@@ -1647,18 +1977,15 @@ out:
 							break out
 						case !c.authenticated:
 							// Check credentials.
-							login := authCmd.Username + ":" + authCmd.Passphrase
-							auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
-							authSha := sha256.Sum256([]byte(auth))
-							cmp := subtle.ConstantTimeCompare(authSha[:], c.server.authsha[:])
-							limitcmp := subtle.ConstantTimeCompare(authSha[:], c.server.limitauthsha[:])
-							if cmp != 1 && limitcmp != 1 {
+							user := c.server.checkCredentials(authCmd.Username,
+								authCmd.Passphrase)
+							if user == nil {
 								rpcsLog.Warnf("Auth failure.")
 								break out
 							}
 
 							c.authenticated = true
-							c.isAdmin = cmp == 1
+							c.user = user
 
 							// Marshal and send response.
 							reply, err = createMarshalledReply(cmd.jsonrpc, cmd.id, nil, nil)
@@ -1674,38 +2001,40 @@ out:
 							continue
 						}
 
-						// Check if the client is using limited RPC credentials and
-						// error when not authorized to call the supplied RPC.
-						if !c.isAdmin {
-							if _, ok := rpcLimited[req.Method]; !ok {
-								jsonErr := &btcjson.RPCError{
-									Code:    btcjson.ErrRPCInvalidParams.Code,
-									Message: "limited user not authorized for this method",
-								}
-								// Marshal and send response.
-								reply, err = createMarshalledReply(req.Jsonrpc, req.ID, nil, jsonErr)
-								if err != nil {
-									rpcsLog.Errorf("Failed to marshal parse failure "+
-										"reply: %v", err)
-									continue
-								}
-
-								if reply != nil {
-									results = append(results, reply)
-								}
+						// Check if the client is allowed to call the supplied
+						// RPC by the whitelist of its user.
+						if !c.user.allowed(req.Method) {
+							jsonErr := &btcjson.RPCError{
+								Code:    btcjson.ErrRPCInvalidParams.Code,
+								Message: "user not authorized for this method",
+							}
+							// Marshal and send response.
+							reply, err = createMarshalledReply(req.Jsonrpc, req.ID, nil, jsonErr)
+							if err != nil {
+								rpcsLog.Errorf("Failed to marshal parse failure "+
+									"reply: %v", err)
 								continue
 							}
+
+							if reply != nil {
+								results = append(results, reply)
+							}
+							continue
 						}
 
 						// Lookup the websocket extension for the command, if it doesn't
 						// exist fallback to handling the command as a standard command.
 						var resp interface{}
+						done := c.server.trackCommand(cmd.method, c.addr)
 						wsHandler, ok := wsHandlers[cmd.method]
-						if ok {
+						if c.server.shuttingDown() {
+							err = ErrRPCShuttingDown
+						} else if ok {
 							resp, err = wsHandler(c, cmd.cmd)
 						} else {
-							resp, err = c.server.standardCmdResult(cmd, nil)
+							resp, err = c.server.standardCmdResult(cmd, c.quit)
 						}
+						done(err)
 
 						// Marshal request output.
 						reply, err := createMarshalledReply(cmd.jsonrpc, cmd.id, resp, err)
@@ -1771,12 +2100,16 @@ func (c *wsClient) serviceRequest(r *parsedRPCCmd) {
 
 	// Lookup the websocket extension for the command and if it doesn't
 	// exist fallback to handling the command as a standard command.
+	done := c.server.trackCommand(r.method, c.addr)
 	wsHandler, ok := wsHandlers[r.method]
-	if ok {
+	if c.server.shuttingDown() {
+		err = ErrRPCShuttingDown
+	} else if ok {
 		result, err = wsHandler(c, r.cmd)
 	} else {
-		result, err = c.server.standardCmdResult(r, nil)
+		result, err = c.server.standardCmdResult(r, c.quit)
 	}
+	done(err)
 	reply, err := createMarshalledReply(r.jsonrpc, r.id, result, err)
 	if err != nil {
 		rpcsLog.Errorf("Failed to marshal reply for <%s> "+
@@ -1795,16 +2128,15 @@ func (c *wsClient) serviceRequest(r *parsedRPCCmd) {
 func (c *wsClient) notificationQueueHandler() {
 	ntfnSentChan := make(chan bool, 1) // nonblocking sync
 
-	// pendingNtfns is used as a queue for notifications that are ready to
-	// be sent once there are no outstanding notifications currently being
-	// sent.  The waiting flag is used over simply checking for items in the
-	// pending list to ensure cleanup knows what has and hasn't been sent
-	// to the outHandler.  Currently no special cleanup is needed, however
-	// if something like a done channel is added to notifications in the
+	// Notifications are queued to the bounded queue of the client when
+	// there are outstanding notifications currently being sent.  The
+	// waiting flag is used over simply checking for items in the queue to
+	// ensure cleanup knows what has and hasn't been sent to the
+	// outHandler.  Currently no special cleanup is needed, however if
+	// something like a done channel is added to notifications in the
 	// future, not knowing what has and hasn't been sent to the outHandler
 	// (and thus who should respond to the done channel) would be
 	// problematic without using this approach.
-	pendingNtfns := list.New()
 	waiting := false
 out:
 	for {
@@ -1813,12 +2145,14 @@ out:
 		// be sent across the network socket.  It will either send the
 		// message immediately if a send is not already in progress, or
 		// queue the message to be sent once the other pending messages
-		// are sent.
+		// are sent.  Clients too slow to keep up with the bounded
+		// queue are handled according to the queue policy.
 		case msg := <-c.ntfnChan:
 			if !waiting {
+				c.ntfnQueue.markSent()
 				c.SendMessage(msg, ntfnSentChan)
-			} else {
-				pendingNtfns.PushBack(msg)
+			} else if !c.ntfnQueue.push(c.addr, msg) {
+				c.Disconnect()
 			}
 			waiting = true
 
@@ -1827,15 +2161,15 @@ out:
 		case <-ntfnSentChan:
 			// No longer waiting if there are no more messages in
 			// the pending messages queue.
-			next := pendingNtfns.Front()
-			if next == nil {
+			msg, ok := c.ntfnQueue.pop()
+			if !ok {
 				waiting = false
 				continue
 			}
 
 			// Notify the outHandler about the next item to
 			// asynchronously send.
-			msg := pendingNtfns.Remove(next).([]byte)
+			c.ntfnQueue.markSent()
 			c.SendMessage(msg, ntfnSentChan)
 
 		case <-c.quit:
@@ -1991,24 +2325,27 @@ func (c *wsClient) WaitForShutdown() {
 // incoming and outgoing messages in separate goroutines complete with queuing
 // and asynchrous handling for long-running operations.
 func newWebsocketClient(server *rpcServer, conn *websocket.Conn,
-	remoteAddr string, authenticated bool, isAdmin bool) (*wsClient, error) {
+	remoteAddr string, user *rpcAuthUser) (*wsClient, error) {
 
 	sessionID, err := wire.RandomUint64()
 	if err != nil {
 		return nil, err
 	}
 
+	ntfnQueue := newWSNotificationQueue(cfg.RPCWSQueueSize,
+		cfg.RPCWSQueueBytes, cfg.RPCWSQueuePolicy)
 	client := &wsClient{
 		conn:              conn,
 		addr:              remoteAddr,
-		authenticated:     authenticated,
-		isAdmin:           isAdmin,
+		authenticated:     user != nil,
+		user:              user,
 		sessionID:         sessionID,
 		server:            server,
 		addrRequests:      make(map[string]struct{}),
 		spentRequests:     make(map[wire.OutPoint]struct{}),
 		serviceRequestSem: makeSemaphore(cfg.RPCMaxConcurrentReqs),
 		ntfnChan:          make(chan []byte, 1), // nonblocking sync
+		ntfnQueue:         ntfnQueue,
 		sendChan:          make(chan wsResponse, websocketSendBufferSize),
 		quit:              make(chan struct{}),
 	}
@@ -2084,12 +2421,31 @@ func handleLoadTxFilter(wsc *wsClient, icmd interface{}) (interface{}, error) {
 		}
 	}
 
+	var claimNames []string
+	if cmd.ClaimNames != nil {
+		claimNames = *cmd.ClaimNames
+	}
+	var claimIDs []change.ClaimID
+	if cmd.ClaimIDs != nil {
+		claimIDs = make([]change.ClaimID, len(*cmd.ClaimIDs))
+		for i, s := range *cmd.ClaimIDs {
+			id, err := claimscript.ParseClaimID(s)
+			if err != nil {
+				return nil, &btcjson.RPCError{
+					Code:    btcjson.ErrRPCInvalidParameter,
+					Message: err.Error(),
+				}
+			}
+			claimIDs[i] = id
+		}
+	}
+
 	params := wsc.server.cfg.ChainParams
 
 	wsc.Lock()
 	if cmd.Reload || wsc.filterData == nil {
 		wsc.filterData = newWSClientFilter(cmd.Addresses, outPoints,
-			params)
+			claimNames, claimIDs, params)
 		wsc.Unlock()
 	} else {
 		wsc.Unlock()
@@ -2101,6 +2457,12 @@ func handleLoadTxFilter(wsc *wsClient, icmd interface{}) (interface{}, error) {
 		for i := range outPoints {
 			wsc.filterData.addUnspentOutPoint(&outPoints[i])
 		}
+		for _, name := range claimNames {
+			wsc.filterData.addClaimName(name)
+		}
+		for _, id := range claimIDs {
+			wsc.filterData.addClaimID(id)
+		}
 		wsc.filterData.mu.Unlock()
 	}
 
@@ -2110,6 +2472,16 @@ func handleLoadTxFilter(wsc *wsClient, icmd interface{}) (interface{}, error) {
 // handleNotifyBlocks implements the notifyblocks command extension for
 // websocket connections.
 func handleNotifyBlocks(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	cmd, ok := icmd.(*btcjson.NotifyBlocksCmd)
+	if !ok {
+		return nil, btcjson.ErrRPCInternal
+	}
+
+	if cmd.RescanFrom != nil {
+		err := wsc.server.ntfnMgr.RegisterBlockUpdatesFrom(wsc,
+			*cmd.RescanFrom)
+		return nil, err
+	}
 	wsc.server.ntfnMgr.RegisterBlockUpdates(wsc)
 	return nil, nil
 }
@@ -2164,6 +2536,49 @@ func handleStopNotifyNewTransactions(wsc *wsClient, icmd interface{}) (interface
 	return nil, nil
 }
 
+// handleNotifyWork implements the notifywork command extension for websocket
+// connections.
+func handleNotifyWork(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	cmd, ok := icmd.(*btcjson.NotifyWorkCmd)
+	if !ok {
+		return nil, btcjson.ErrRPCInternal
+	}
+
+	wsc.server.ntfnMgr.RegisterWorkUpdates(wsc, cmd.Deltas != nil && *cmd.Deltas)
+	return nil, nil
+}
+
+// handleStopNotifyWork implements the stopnotifywork command extension for
+// websocket connections.
+func handleStopNotifyWork(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	wsc.server.ntfnMgr.UnregisterWorkUpdates(wsc)
+	return nil, nil
+}
+
+// handleNotifyClaims implements the notifyclaims command extension for
+// websocket connections.
+func handleNotifyClaims(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	cmd, ok := icmd.(*btcjson.NotifyClaimsCmd)
+	if !ok {
+		return nil, btcjson.ErrRPCInternal
+	}
+
+	// No names notifies all of them.
+	var names []string
+	if cmd.Names != nil && len(*cmd.Names) > 0 {
+		names = *cmd.Names
+	}
+	wsc.server.ntfnMgr.RegisterClaimUpdates(wsc, names)
+	return nil, nil
+}
+
+// handleStopNotifyClaims implements the stopnotifyclaims command extension for
+// websocket connections.
+func handleStopNotifyClaims(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	wsc.server.ntfnMgr.UnregisterClaimUpdates(wsc)
+	return nil, nil
+}
+
 // handleNotifyReceived implements the notifyreceived command extension for
 // websocket connections.
 func handleNotifyReceived(wsc *wsClient, icmd interface{}) (interface{}, error) {
@@ -2458,28 +2873,32 @@ func rescanBlockFilter(filter *wsClientFilter, block *btcutil.Block, params *cha
 
 		// Scan outputs.
 		for i, output := range msgTx.TxOut {
+			op := wire.OutPoint{
+				Hash:  *tx.Hash(),
+				Index: uint32(i),
+			}
+			relevant := filter.existsClaim(
+				parseClaimScript(output.PkScript), &op)
 			_, addrs, _, err := txscript.ExtractPkScriptAddrs(
 				output.PkScript, params)
-			if err != nil {
+			if err == nil {
+				for _, a := range addrs {
+					if filter.existsAddress(a) {
+						relevant = true
+					}
+				}
+			}
+			if !relevant {
 				continue
 			}
-			for _, a := range addrs {
-				if !filter.existsAddress(a) {
-					continue
-				}
 
-				op := wire.OutPoint{
-					Hash:  *tx.Hash(),
-					Index: uint32(i),
-				}
-				filter.addUnspentOutPoint(&op)
+			filter.addUnspentOutPoint(&op)
 
-				if !added {
-					transactions = append(
-						transactions,
-						txHexString(msgTx))
-					added = true
-				}
+			if !added {
+				transactions = append(
+					transactions,
+					txHexString(msgTx))
+				added = true
 			}
 		}
 	}
@@ -2527,6 +2946,13 @@ func handleRescanBlocks(wsc *wsClient, icmd interface{}) (interface{}, error) {
 	params := wsc.server.cfg.ChainParams
 	var lastBlockHash *chainhash.Hash
 	for i := range blockHashes {
+		// Stop rescanning if the client has disconnected.
+		select {
+		case <-wsc.quit:
+			return nil, ErrClientQuit
+		default:
+		}
+
 		block, err := bc.BlockByHash(blockHashes[i])
 		if err != nil {
 			return nil, &btcjson.RPCError{
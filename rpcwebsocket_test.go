@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/claimtrie/change"
+	"github.com/lbryio/lbcd/txscript/claimscript"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRescanBlockFilterClaims(t *testing.T) {
+
+	r := require.New(t)
+
+	pkScript := []byte{0x51}
+	newTx := func(prevOut *wire.OutPoint, script []byte) *wire.MsgTx {
+		tx := wire.NewMsgTx(wire.TxVersion)
+		tx.AddTxIn(wire.NewTxIn(prevOut, nil, nil))
+		tx.AddTxOut(wire.NewTxOut(1000, script))
+		return tx
+	}
+
+	// The claim of a watched name, its spend, and the supports of a watched
+	// claim and of another one.
+	script, err := claimscript.ClaimName("Hello", []byte("value"), pkScript)
+	r.NoError(err)
+	claim := newTx(&wire.OutPoint{Index: 1}, script)
+	claimOut := wire.OutPoint{Hash: claim.TxHash(), Index: 0}
+	spend := newTx(&claimOut, pkScript)
+
+	watched := change.NewClaimID(wire.OutPoint{Index: 2})
+	script, err = claimscript.SupportClaim("other", watched, pkScript)
+	r.NoError(err)
+	support := newTx(&wire.OutPoint{Index: 3}, script)
+	script, err = claimscript.SupportClaim("other",
+		change.NewClaimID(wire.OutPoint{Index: 4}), pkScript)
+	r.NoError(err)
+	otherSupport := newTx(&wire.OutPoint{Index: 5}, script)
+
+	block := btcutil.NewBlock(&wire.MsgBlock{
+		Transactions: []*wire.MsgTx{claim, spend, support, otherSupport},
+	})
+
+	filter := newWSClientFilter(nil, nil, []string{"hello"},
+		[]change.ClaimID{watched}, &chaincfg.RegressionNetParams)
+	txns := rescanBlockFilter(filter, block, &chaincfg.RegressionNetParams)
+	r.Equal([]string{txHexString(claim), txHexString(spend),
+		txHexString(support)}, txns)
+	r.True(filter.existsUnspentOutPoint(&claimOut))
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/lbryio/lbcd/btcjson"
+)
+
+// wsWorkClient is a websocket client registered for work notifications.  last
+// is the template it was last notified of, which deltas are computed against.
+type wsWorkClient struct {
+	wsc    *wsClient
+	deltas bool
+	last   *btcjson.GetBlockTemplateResult
+}
+
+// notifyWorkUpdate signals the work handler that the best chain or the mempool
+// changed.  It never blocks, as pending signals are merged.
+func (s *rpcServer) notifyWorkUpdate() {
+	select {
+	case s.workUpdates <- struct{}{}:
+	default:
+	}
+}
+
+// workHandler generates the block templates notified to the websocket clients
+// registered for work notifications.  New templates are generated when the
+// best chain changes, or when the mempool changes and it has been at least
+// gbtRegenerateSeconds since the last template was generated, like the
+// templates of getblocktemplate which they are shared with.
+//
+// This must be run as a goroutine.
+func (s *rpcServer) workHandler() {
+	defer s.wg.Done()
+
+	// notified is the generation time of the last template notified.
+	var notified time.Time
+	var regenerate <-chan time.Time
+	for {
+		select {
+		case <-s.workUpdates:
+		case <-regenerate:
+		case <-s.quit:
+			return
+		}
+		regenerate = nil
+
+		if s.ntfnMgr.NumWorkClients() == 0 {
+			continue
+		}
+		template, retry, err := s.currentWork(&notified)
+		if err != nil {
+			rpcsLog.Errorf("Failed to generate work: %v", err)
+			continue
+		}
+		if template != nil {
+			s.ntfnMgr.NotifyWork(template)
+		}
+		if retry > 0 {
+			regenerate = time.After(retry)
+		}
+	}
+}
+
+// currentWork returns the current block template when it was generated after
+// the one last notified, and the time after which a template including the
+// latest mempool changes may be generated.  No work is returned before the
+// chain is synced.
+func (s *rpcServer) currentWork(notified *time.Time) (*btcjson.GetBlockTemplateResult, time.Duration, error) {
+	if !(cfg.RegressionTest || cfg.SimNet) &&
+		s.cfg.ConnMgr.ConnectedCount() == 0 {
+
+		return nil, 0, nil
+	}
+	if s.cfg.Chain.BestSnapshot().Height != 0 && !s.cfg.SyncMgr.IsCurrent() {
+		return nil, 0, nil
+	}
+
+	state := s.gbtWorkState
+	state.Lock()
+	defer state.Unlock()
+
+	if err := state.updateBlockTemplate(s, true); err != nil {
+		return nil, 0, err
+	}
+
+	var retry time.Duration
+	lastTxUpdate := s.cfg.Generator.TxSource().LastUpdated()
+	if lastTxUpdate.After(state.lastTxUpdate) {
+		retry = time.Until(state.lastGenerated.Add(time.Second *
+			gbtRegenerateSeconds))
+	}
+	if state.lastGenerated.Equal(*notified) {
+		return nil, retry, nil
+	}
+
+	template, err := state.blockTemplateResult(true, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	*notified = state.lastGenerated
+	return template, retry, nil
+}
+
+// NumWorkClients returns the number of clients registered for work
+// notifications.
+func (m *wsNotificationManager) NumWorkClients() int {
+	return int(atomic.LoadInt32(&m.numWorkClients))
+}
+
+// setNumWorkClients updates the number of clients registered for work
+// notifications.
+func (m *wsNotificationManager) setNumWorkClients(n int) {
+	atomic.StoreInt32(&m.numWorkClients, int32(n))
+}
+
+// NotifyWork passes a new block template to the notification manager for work
+// notification processing.
+func (m *wsNotificationManager) NotifyWork(template *btcjson.GetBlockTemplateResult) {
+	select {
+	case m.queueNotification <- (*notificationWork)(template):
+	case <-m.quit:
+	}
+}
+
+// RegisterWorkUpdates requests work notifications to the passed websocket
+// client, with deltas after the first template of each previous block when
+// deltas is set.
+func (m *wsNotificationManager) RegisterWorkUpdates(wsc *wsClient, deltas bool) {
+	m.queueNotification <- &notificationRegisterWork{wsc: wsc, deltas: deltas}
+}
+
+// UnregisterWorkUpdates removes work notifications for the passed websocket
+// client.
+func (m *wsNotificationManager) UnregisterWorkUpdates(wsc *wsClient) {
+	m.queueNotification <- (*notificationUnregisterWork)(wsc)
+}
+
+// notifyWork notifies the clients of a new block template, as a delta for
+// those that asked for them and were notified of a template of the same
+// previous block.
+func (m *wsNotificationManager) notifyWork(clients map[chan struct{}]*wsWorkClient,
+	template *btcjson.GetBlockTemplateResult) {
+
+	var marshalled []byte
+	deltas := make(map[*btcjson.GetBlockTemplateResult][]byte)
+	for _, c := range clients {
+		msg := m.marshalWork(c, template, &marshalled, deltas)
+		if msg != nil {
+			c.wsc.QueueNotification(msg)
+			c.last = template
+		}
+	}
+}
+
+// marshalWork returns the notification of the template to the client.  The
+// full notification and the deltas from each previous template are marshalled
+// once, and cached in marshalled and deltas.
+func (m *wsNotificationManager) marshalWork(c *wsWorkClient,
+	template *btcjson.GetBlockTemplateResult, marshalled *[]byte,
+	deltas map[*btcjson.GetBlockTemplateResult][]byte) []byte {
+
+	if c.deltas && c.last != nil && c.last.PreviousHash == template.PreviousHash {
+		if msg, ok := deltas[c.last]; ok {
+			return msg
+		}
+		ntfn := btcjson.NewWorkDeltaNtfn(*workDelta(c.last, template))
+		msg, err := btcjson.MarshalCmd(btcjson.RpcVersion1, nil, ntfn)
+		if err != nil {
+			rpcsLog.Errorf("Failed to marshal work delta notification: "+
+				"%v", err)
+			return nil
+		}
+		deltas[c.last] = msg
+		return msg
+	}
+
+	if *marshalled == nil {
+		ntfn := btcjson.NewWorkNtfn(*template)
+		msg, err := btcjson.MarshalCmd(btcjson.RpcVersion1, nil, ntfn)
+		if err != nil {
+			rpcsLog.Errorf("Failed to marshal work notification: %v",
+				err)
+			return nil
+		}
+		*marshalled = msg
+	}
+	return *marshalled
+}
+
+// workDelta returns the changes of a template since a previous template of the
+// same previous block.
+func workDelta(prev, cur *btcjson.GetBlockTemplateResult) *btcjson.WorkDelta {
+	prevTxs := make(map[string]struct{}, len(prev.Transactions))
+	for _, tx := range prev.Transactions {
+		prevTxs[tx.TxID] = struct{}{}
+	}
+
+	delta := &btcjson.WorkDelta{
+		PreviousHash:             cur.PreviousHash,
+		LongPollID:               cur.LongPollID,
+		CurTime:                  cur.CurTime,
+		MaxTime:                  cur.MaxTime,
+		CoinbaseValue:            cur.CoinbaseValue,
+		ClaimTrieHash:            cur.ClaimTrieHash,
+		DefaultWitnessCommitment: cur.DefaultWitnessCommitment,
+		TxIDs:                    make([]string, 0, len(cur.Transactions)),
+		Added:                    []btcjson.GetBlockTemplateResultTx{},
+		Removed:                  []string{},
+	}
+	for _, tx := range cur.Transactions {
+		delta.TxIDs = append(delta.TxIDs, tx.TxID)
+		if _, ok := prevTxs[tx.TxID]; ok {
+			delete(prevTxs, tx.TxID)
+			continue
+		}
+		delta.Added = append(delta.Added, tx)
+	}
+	for _, tx := range prev.Transactions {
+		if _, ok := prevTxs[tx.TxID]; ok {
+			delta.Removed = append(delta.Removed, tx.TxID)
+		}
+	}
+	return delta
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/lbryio/lbcd/btcjson"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkDelta(t *testing.T) {
+
+	r := require.New(t)
+
+	template := func(prev string, txids ...string) *btcjson.GetBlockTemplateResult {
+		tmpl := &btcjson.GetBlockTemplateResult{PreviousHash: prev}
+		for _, txid := range txids {
+			tmpl.Transactions = append(tmpl.Transactions,
+				btcjson.GetBlockTemplateResultTx{TxID: txid})
+		}
+		return tmpl
+	}
+
+	prev := template("p", "a", "b", "c")
+	cur := template("p", "c", "d", "a")
+	cur.LongPollID = "id"
+	delta := workDelta(prev, cur)
+	r.Equal("id", delta.LongPollID)
+	r.Equal([]string{"c", "d", "a"}, delta.TxIDs)
+	r.Equal([]btcjson.GetBlockTemplateResultTx{{TxID: "d"}}, delta.Added)
+	r.Equal([]string{"b"}, delta.Removed)
+
+	m := &wsNotificationManager{}
+	full := &wsWorkClient{}
+	deltas := &wsWorkClient{deltas: true}
+	var marshalled []byte
+	cache := make(map[*btcjson.GetBlockTemplateResult][]byte)
+
+	// The first template of a previous block is always sent in full.
+	r.Contains(string(m.marshalWork(deltas, prev, &marshalled, cache)),
+		`"method":"work"`)
+	deltas.last, full.last = prev, prev
+
+	marshalled = nil
+	r.Contains(string(m.marshalWork(full, cur, &marshalled, cache)),
+		`"method":"work"`)
+	msg := m.marshalWork(deltas, cur, &marshalled, cache)
+	r.Contains(string(msg), `"method":"workdelta"`)
+	r.Contains(string(msg), `"removed":["b"]`)
+	r.Len(cache, 1)
+
+	marshalled = nil
+	next := template("n", "e")
+	r.Contains(string(m.marshalWork(deltas, next, &marshalled, cache)),
+		`"method":"work"`)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/lbryio/lbcd/btcjson"
+)
+
+// Policies applied to the notifications of a websocket client whose queue is
+// full.
+const (
+	// wsQueueDisconnect disconnects the client, which is the only policy
+	// that never leaves a client with a gap in its notifications.
+	wsQueueDisconnect = "disconnect"
+
+	// wsQueueDropOldest drops the oldest queued notification.
+	wsQueueDropOldest = "dropoldest"
+
+	// wsQueueDropNewest drops the notification being queued.
+	wsQueueDropNewest = "dropnewest"
+)
+
+// wsNotificationQueue is the bounded queue of the notifications waiting to be
+// sent to a websocket client.  It is filled and drained by the notification
+// queue handler of the client, and its statistics are read by getrpcinfo.
+type wsNotificationQueue struct {
+	maxLen   int
+	maxBytes int
+	policy   string
+
+	mtx     sync.Mutex
+	ntfns   list.List
+	bytes   int
+	peak    int
+	sent    uint64
+	dropped uint64
+
+	// slow is set once the queue is half full, and cleared once it is
+	// drained.
+	slow bool
+}
+
+// newWSNotificationQueue returns a queue holding up to maxLen notifications
+// and maxBytes bytes, and applying the passed policy once full.
+func newWSNotificationQueue(maxLen, maxBytes int, policy string) *wsNotificationQueue {
+	return &wsNotificationQueue{
+		maxLen:   maxLen,
+		maxBytes: maxBytes,
+		policy:   policy,
+	}
+}
+
+// push queues a notification.  It returns false when the queue is full and the
+// client must be disconnected.
+func (q *wsNotificationQueue) push(addr string, msg []byte) bool {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	for q.ntfns.Len() > 0 && (q.ntfns.Len()+1 > q.maxLen ||
+		q.bytes+len(msg) > q.maxBytes) {
+
+		switch q.policy {
+		case wsQueueDropOldest:
+			old := q.ntfns.Remove(q.ntfns.Front()).([]byte)
+			q.bytes -= len(old)
+			q.dropped++
+
+		case wsQueueDropNewest:
+			q.dropped++
+			return true
+
+		default:
+			rpcsLog.Warnf("Websocket client %s is too slow with %d "+
+				"notifications (%d bytes) queued - disconnecting",
+				addr, q.ntfns.Len(), q.bytes)
+			return false
+		}
+	}
+
+	q.ntfns.PushBack(msg)
+	q.bytes += len(msg)
+	if q.ntfns.Len() > q.peak {
+		q.peak = q.ntfns.Len()
+	}
+	if !q.slow && (q.ntfns.Len() > q.maxLen/2 || q.bytes > q.maxBytes/2) {
+		q.slow = true
+		rpcsLog.Warnf("Websocket client %s is falling behind with %d "+
+			"notifications (%d bytes) queued", addr, q.ntfns.Len(),
+			q.bytes)
+	}
+	return true
+}
+
+// pop returns the oldest queued notification, or false if the queue is empty.
+func (q *wsNotificationQueue) pop() ([]byte, bool) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	next := q.ntfns.Front()
+	if next == nil {
+		q.slow = false
+		return nil, false
+	}
+	msg := q.ntfns.Remove(next).([]byte)
+	q.bytes -= len(msg)
+	return msg, true
+}
+
+// markSent counts a notification handed to the output handler.
+func (q *wsNotificationQueue) markSent() {
+	q.mtx.Lock()
+	q.sent++
+	q.mtx.Unlock()
+}
+
+// stats returns the statistics of the queue for getrpcinfo.
+func (q *wsNotificationQueue) stats(addr string) btcjson.RPCWebsocketClient {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	return btcjson.RPCWebsocketClient{
+		Client:      addr,
+		Queued:      q.ntfns.Len(),
+		QueuedBytes: q.bytes,
+		PeakQueued:  q.peak,
+		Sent:        q.sent,
+		Dropped:     q.dropped,
+		Slow:        q.slow,
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btclog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWSNotificationQueue(t *testing.T) {
+
+	r := require.New(t)
+
+	// The log rotator isn't initialized by the tests.
+	defer func(log btclog.Logger) { rpcsLog = log }(rpcsLog)
+	rpcsLog = btclog.Disabled
+
+	fill := func(q *wsNotificationQueue) bool {
+		for _, msg := range []string{"a", "b", "c", "d"} {
+			if !q.push("client", []byte(msg)) {
+				return false
+			}
+		}
+		return true
+	}
+	drain := func(q *wsNotificationQueue) string {
+		var s string
+		for msg, ok := q.pop(); ok; msg, ok = q.pop() {
+			s += string(msg)
+		}
+		return s
+	}
+
+	q := newWSNotificationQueue(3, 100, wsQueueDisconnect)
+	r.False(fill(q))
+	r.True(q.stats("client").Slow)
+
+	q = newWSNotificationQueue(3, 100, wsQueueDropOldest)
+	r.True(fill(q))
+	stats := q.stats("client")
+	r.Equal(3, stats.Queued)
+	r.Equal(3, stats.QueuedBytes)
+	r.Equal(uint64(1), stats.Dropped)
+	r.Equal("bcd", drain(q))
+	r.False(q.stats("client").Slow)
+
+	q = newWSNotificationQueue(3, 100, wsQueueDropNewest)
+	r.True(fill(q))
+	r.Equal("abc", drain(q))
+
+	// The size in bytes is bounded as well, but a notification larger than
+	// the limit is still sent to an idle client.
+	q = newWSNotificationQueue(100, 2, wsQueueDropOldest)
+	r.True(fill(q))
+	r.Equal("cd", drain(q))
+	r.True(q.push("client", []byte("large")))
+	r.Equal("large", drain(q))
+	r.Equal(2, q.stats("client").PeakQueued)
+}
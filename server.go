@@ -6,7 +6,6 @@
 package main
 
 import (
-	"bytes"
 	"crypto/rand"
 	"crypto/tls"
 	"encoding/binary"
@@ -14,7 +13,9 @@ import (
 	"fmt"
 	"math"
 	"net"
+	"os"
 	"path"
+	"path/filepath"
 	"runtime"
 	"sort"
 	"strconv"
@@ -50,7 +51,7 @@ const (
 	// defaultServices describes the default services that are supported by
 	// the server.
 	defaultServices = wire.SFNodeNetwork | wire.SFNodeBloom |
-		wire.SFNodeWitness | wire.SFNodeCF
+		wire.SFNodeWitness | wire.SFNodeCF | wire.SFNodeClaimProofs
 
 	// defaultRequiredServices describes the default services that are
 	// required to be supported by outbound peers.
@@ -63,6 +64,12 @@ const (
 	// retries when connecting to persistent peers.  It is adjusted by the
 	// number of retries such that there is a retry backoff.
 	connectionRetryInterval = time.Second * 5
+
+	// maxClaimProofScore is the score of the getclmproof requests of a peer
+	// above which they are ignored.  Each request adds one to the score,
+	// which decays to half each minute, so a peer may send bursts of this
+	// many requests and about three per second in the long run.
+	maxClaimProofScore = 250
 )
 
 var (
@@ -220,7 +227,18 @@ type server struct {
 	connManager          *connmgr.ConnManager
 	sigCache             *txscript.SigCache
 	hashCache            *txscript.HashCache
+	blockCache           *blockCache
 	rpcServer            *rpcServer
+	grpcServer           *grpcServer
+	electrumServer       *electrumServer
+	dnsSeeder            *dnsSeeder
+	torController        *torController
+	oneHopRelay          *oneHopRelay
+	webhooks             *webhookNotifier
+	eventExporter        *eventExporter
+	policyPlugin         *policyPlugin
+	diskSpace            *diskSpaceMonitor
+	claimSnapshots       *claimSnapshotManager
 	syncManager          *netsync.SyncManager
 	chain                *blockchain.BlockChain
 	txMemPool            *mempool.TxPool
@@ -244,9 +262,10 @@ type server struct {
 	// if the associated index is not enabled.  These fields are set during
 	// initial creation of the server and never changed afterwards, so they
 	// do not need to be protected for concurrent access.
-	txIndex   *indexers.TxIndex
-	addrIndex *indexers.AddrIndex
-	cfIndex   *indexers.CfIndex
+	txIndex         *indexers.TxIndex
+	addrIndex       *indexers.AddrIndex
+	scriptHashIndex *indexers.ScriptHashIndex
+	cfIndex         *indexers.CfIndex
 
 	// The fee estimator keeps track of how long transactions are left in
 	// the mempool before they are mined into blocks.
@@ -277,6 +296,7 @@ type serverPeer struct {
 	connReq        *connmgr.ConnReq
 	server         *server
 	persistent     bool
+	blockRelayOnly bool
 	continueHash   *chainhash.Hash
 	relayMtx       sync.Mutex
 	disableRelayTx bool
@@ -286,6 +306,8 @@ type serverPeer struct {
 	addressesMtx   sync.RWMutex
 	knownAddresses map[string]struct{}
 	banScore       connmgr.DynamicBanScore
+	proofScore     connmgr.DynamicBanScore
+	snapshotScore  connmgr.DynamicBanScore
 	quit           chan struct{}
 	// The following chans are used to sync blockmanager and server.
 	txProcessed    chan struct{}
@@ -482,8 +504,8 @@ func (sp *serverPeer) OnVersion(_ *peer.Peer, msg *wire.MsgVersion) *wire.MsgRej
 	sp.server.timeSource.AddTimeSample(sp.Addr(), msg.Timestamp)
 
 	// Choose whether or not to relay transactions before a filter command
-	// is received.
-	sp.setDisableRelayTx(msg.DisableRelayTx)
+	// is received.  They are never relayed to the block-relay-only peers.
+	sp.setDisableRelayTx(msg.DisableRelayTx || sp.blockRelayOnly)
 
 	return nil
 }
@@ -492,6 +514,12 @@ func (sp *serverPeer) OnVersion(_ *peer.Peer, msg *wire.MsgVersion) *wire.MsgRej
 // to kick start communication with them.
 func (sp *serverPeer) OnVerAck(_ *peer.Peer, _ *wire.MsgVerAck) {
 	sp.server.AddPeer(sp)
+
+	// Request the claimtrie snapshot of the peer while the claimtrie is
+	// deferred.
+	if sp.server.claimSnapshots != nil {
+		sp.server.claimSnapshots.PeerConnected(sp)
+	}
 }
 
 // OnMemPool is invoked when a peer receives a mempool bitcoin message.
@@ -499,6 +527,13 @@ func (sp *serverPeer) OnVerAck(_ *peer.Peer, _ *wire.MsgVerAck) {
 // pool up to the maximum inventory allowed per message.  When the peer has a
 // bloom filter loaded, the contents are filtered accordingly.
 func (sp *serverPeer) OnMemPool(_ *peer.Peer, msg *wire.MsgMemPool) {
+	// The transactions are not relayed to the block-relay-only peers.
+	if sp.blockRelayOnly {
+		peerLog.Debugf("Ignoring mempool request from block-relay-only "+
+			"peer %v", sp)
+		return
+	}
+
 	// Only allow mempool requests if the server has bloom filtering
 	// enabled.
 	if sp.server.services&wire.SFNodeBloom != wire.SFNodeBloom {
@@ -526,6 +561,13 @@ func (sp *serverPeer) OnMemPool(_ *peer.Peer, msg *wire.MsgMemPool) {
 	invMsg := wire.NewMsgInvSizeHint(uint(len(txDescs)))
 
 	for _, txDesc := range txDescs {
+		// The embargoed transactions are hidden.
+		if sp.server.oneHopRelay != nil &&
+			sp.server.oneHopRelay.Embargoed(txDesc.Tx.Hash(), sp) {
+
+			continue
+		}
+
 		// Either add all transactions when there is no bloom filter,
 		// or only the transactions that match the filter when there is
 		// one.
@@ -549,9 +591,9 @@ func (sp *serverPeer) OnMemPool(_ *peer.Peer, msg *wire.MsgMemPool) {
 // handler this does not serialize all transactions through a single thread
 // transactions don't rely on the previous one in a linear fashion like blocks.
 func (sp *serverPeer) OnTx(_ *peer.Peer, msg *wire.MsgTx) {
-	if cfg.BlocksOnly {
-		peerLog.Tracef("Ignoring tx %v from %v - blocksonly enabled",
-			msg.TxHash(), sp)
+	if cfg.BlocksOnly || sp.blockRelayOnly {
+		peerLog.Tracef("Ignoring tx %v from %v - transaction relay "+
+			"disabled", msg.TxHash(), sp)
 		return
 	}
 
@@ -595,6 +637,13 @@ func (sp *serverPeer) OnBlock(_ *peer.Peer, msg *wire.MsgBlock, buf []byte) {
 	// the bitcoin block has been fully processed.
 	sp.server.syncManager.QueueBlock(block, sp.Peer, sp.blockProcessed)
 	<-sp.blockProcessed
+
+	// The raw bytes of the block are only referenced while it is processed,
+	// for storing it, unless it is kept as an orphan to be processed later,
+	// so they are reused for the next block read otherwise.
+	if !sp.server.chain.IsKnownOrphan(block.Hash()) {
+		wire.ReleasePayload(buf)
+	}
 }
 
 // OnInv is invoked when a peer receives an inv bitcoin message and is
@@ -602,7 +651,16 @@ func (sp *serverPeer) OnBlock(_ *peer.Peer, msg *wire.MsgBlock, buf []byte) {
 // accordingly.  We pass the message down to blockmanager which will call
 // QueueMessage with any appropriate responses.
 func (sp *serverPeer) OnInv(_ *peer.Peer, msg *wire.MsgInv) {
-	if !cfg.BlocksOnly {
+	if !cfg.BlocksOnly && !sp.blockRelayOnly {
+		// The local transactions announced by the network spread from
+		// the relay peer.
+		if sp.server.oneHopRelay != nil {
+			for _, invVect := range msg.InvList {
+				if invVect.Type == wire.InvTypeTx {
+					sp.server.oneHopRelay.Seen(&invVect.Hash, sp)
+				}
+			}
+		}
 		if len(msg.InvList) > 0 {
 			sp.server.syncManager.QueueInv(msg, sp.Peer)
 		}
@@ -613,7 +671,7 @@ func (sp *serverPeer) OnInv(_ *peer.Peer, msg *wire.MsgInv) {
 	for _, invVect := range msg.InvList {
 		if invVect.Type == wire.InvTypeTx {
 			peerLog.Tracef("Ignoring tx %v in inv from %v -- "+
-				"blocksonly enabled", invVect.Hash, sp)
+				"transaction relay disabled", invVect.Hash, sp)
 			if sp.ProtocolVersion() >= wire.BIP0037Version {
 				peerLog.Infof("Peer %v is announcing "+
 					"transactions -- disconnecting", sp)
@@ -968,6 +1026,111 @@ func (sp *serverPeer) OnGetCFHeaders(_ *peer.Peer, msg *wire.MsgGetCFHeaders) {
 	sp.QueueMessage(headersMsg, nil)
 }
 
+// OnGetClaimProof is invoked when a peer receives a getclmproof message.  It
+// responds with the proof of the best claim of the name at the best block, or
+// with a proof without pairs when it's unavailable, such as for the names
+// without claims.
+func (sp *serverPeer) OnGetClaimProof(_ *peer.Peer, msg *wire.MsgGetClaimProof) {
+	// Ignore getclmproof requests if not in sync.
+	if !sp.server.syncManager.IsCurrent() {
+		return
+	}
+
+	// Ignore the requests beyond the rate allowed to each peer, since
+	// proving a name holds the chain lock.
+	if sp.proofScore.Increase(0, 1) > maxClaimProofScore {
+		peerLog.Debugf("Ignoring getclmproof request from %v -- too "+
+			"many requests", sp)
+		return
+	}
+
+	proof, err := sp.server.chain.GetClaimTrieProof(msg.Name)
+	if err != nil {
+		peerLog.Debugf("Unable to prove name %q for %v: %v", msg.Name,
+			sp, err)
+		best := sp.server.chain.BestSnapshot()
+		sp.QueueMessage(wire.NewMsgClaimProof(msg.Name, &best.Hash), nil)
+		return
+	}
+
+	proofMsg := wire.NewMsgClaimProof(proof.Name, &proof.BlockHash)
+	proofMsg.ClaimsHash = *proof.ClaimsHash
+	for _, pair := range proof.Pairs {
+		err := proofMsg.AddPair(pair.Odd, pair.Hash)
+		if err != nil {
+			peerLog.Warnf("Unable to send the proof of name %q to "+
+				"%v: %v", msg.Name, sp, err)
+			return
+		}
+	}
+	proofMsg.BestClaim = proof.BestClaim
+	proofMsg.TakeoverHeight = proof.TakeoverHeight
+	for _, pair := range proof.ClaimPairs {
+		err := proofMsg.AddClaimPair(pair.Odd, pair.Hash)
+		if err != nil {
+			peerLog.Warnf("Unable to send the proof of name %q to "+
+				"%v: %v", msg.Name, sp, err)
+			return
+		}
+	}
+	sp.QueueMessage(proofMsg, nil)
+}
+
+// OnGetClaimSnapshot is invoked when a peer receives a getctsnap message.  It
+// responds with the manifest of the claimtrie snapshot served, or with a
+// manifest without chunks when there is none.
+func (sp *serverPeer) OnGetClaimSnapshot(_ *peer.Peer, _ *wire.MsgGetClaimSnapshot) {
+	m := sp.server.claimSnapshots
+	if m == nil || !m.cfg.Serve {
+		return
+	}
+
+	if sp.snapshotScore.Increase(0, 1) > maxClaimSnapshotScore {
+		peerLog.Debugf("Ignoring getctsnap request from %v -- too "+
+			"many requests", sp)
+		return
+	}
+	sp.QueueMessage(m.Served(), nil)
+}
+
+// OnClaimSnapshot is invoked when a peer receives a ctsnap message.  The
+// snapshot advertised may be downloaded while the claimtrie is deferred.
+func (sp *serverPeer) OnClaimSnapshot(_ *peer.Peer, msg *wire.MsgClaimSnapshot) {
+	if sp.server.claimSnapshots != nil {
+		sp.server.claimSnapshots.Offered(sp, msg)
+	}
+}
+
+// OnGetClaimSnapshotChunk is invoked when a peer receives a getctchunk
+// message.  It responds with the chunk of the claimtrie snapshot served, or
+// with a chunk without data when it's unavailable.
+func (sp *serverPeer) OnGetClaimSnapshotChunk(_ *peer.Peer, msg *wire.MsgGetClaimSnapshotChunk) {
+	m := sp.server.claimSnapshots
+	if m == nil || !m.cfg.Serve {
+		return
+	}
+
+	// Ignore the requests beyond the rate allowed to each peer, since
+	// each chunk is read from disk.
+	if sp.snapshotScore.Increase(0, 1) > maxClaimSnapshotScore {
+		peerLog.Debugf("Ignoring getctchunk request from %v -- too "+
+			"many requests", sp)
+		return
+	}
+	chunk := m.Chunk(&msg.BlockHash, msg.Index)
+	sp.QueueMessage(wire.NewMsgClaimSnapshotChunk(&msg.BlockHash,
+		msg.Index, chunk), nil)
+}
+
+// OnClaimSnapshotChunk is invoked when a peer receives a ctchunk message.  The
+// chunk is appended to the deferred claimtrie when it was requested from the
+// peer.
+func (sp *serverPeer) OnClaimSnapshotChunk(_ *peer.Peer, msg *wire.MsgClaimSnapshotChunk) {
+	if sp.server.claimSnapshots != nil {
+		sp.server.claimSnapshots.Received(sp, msg)
+	}
+}
+
 // OnGetCFCheckpt is invoked when a peer receives a getcfcheckpt bitcoin message.
 func (sp *serverPeer) OnGetCFCheckpt(_ *peer.Peer, msg *wire.MsgGetCFCheckpt) {
 	// Ignore getcfcheckpt requests if not in sync.
@@ -1224,7 +1387,11 @@ func (sp *serverPeer) OnFilterLoad(_ *peer.Peer, msg *wire.MsgFilterLoad) {
 		return
 	}
 
-	sp.setDisableRelayTx(false)
+	// Loading a filter enables the relay of the matching transactions,
+	// except to the block-relay-only peers.
+	if !sp.blockRelayOnly {
+		sp.setDisableRelayTx(false)
+	}
 
 	sp.filter.Reload(msg)
 }
@@ -1281,6 +1448,15 @@ func (sp *serverPeer) OnAddr(_ *peer.Peer, msg *wire.MsgAddr) {
 		return
 	}
 
+	// Ignore the addresses from the block-relay-only peers, which
+	// don't take part in the gossip of addresses so they can't be
+	// identified from the addresses relayed.
+	if sp.blockRelayOnly {
+		peerLog.Debugf("Ignoring addresses from block-relay-only peer "+
+			"%v", sp)
+		return
+	}
+
 	// A message that has no addresses is invalid.
 	if len(msg.AddrList) == 0 {
 		peerLog.Errorf("Command [%s] from %s does not contain any addresses",
@@ -1422,6 +1598,43 @@ func (s *server) relayTransactions(txns []*mempool.TxDesc) {
 	}
 }
 
+// relayLocalTransactions relays the passed transactions submitted locally.
+// They are relayed through a single relay peer first when the one-hop relay
+// is enabled, or to all connected peers otherwise.
+func (s *server) relayLocalTransactions(txns []*mempool.TxDesc) {
+	if s.oneHopRelay != nil {
+		s.oneHopRelay.Relay(txns)
+		return
+	}
+	s.relayTransactions(txns)
+}
+
+// relayPeerCandidates returns the connected peers which may be the relay peer
+// of the local transactions: the outbound peers relaying transactions.  The
+// inbound peers are left out since they may be spy nodes connecting to many
+// nodes.
+func (s *server) relayPeerCandidates() []*serverPeer {
+	replyChan := make(chan []*serverPeer)
+	s.query <- getPeersMsg{reply: replyChan}
+
+	var candidates []*serverPeer
+	for _, sp := range <-replyChan {
+		if sp.Inbound() || sp.blockRelayOnly || sp.relayTxDisabled() {
+			continue
+		}
+		candidates = append(candidates, sp)
+	}
+	return candidates
+}
+
+// relayTransactionsToPeer announces the passed embargoed transactions to the
+// relay peer only.
+func (s *server) relayTransactionsToPeer(sp *serverPeer, txns []*mempool.TxDesc) {
+	for _, txD := range txns {
+		sp.QueueInventory(wire.NewInvVect(wire.InvTypeTx, txD.Tx.Hash()))
+	}
+}
+
 // AnnounceNewTransactions generates and relays inventory vectors and notifies
 // both websocket and getblocktemplate long poll clients of the passed
 // transactions.  This function should be called whenever new transactions
@@ -1436,6 +1649,14 @@ func (s *server) AnnounceNewTransactions(txns []*mempool.TxDesc) {
 	if s.rpcServer != nil {
 		s.rpcServer.NotifyNewTransactions(txns)
 	}
+
+	if s.webhooks != nil {
+		s.webhooks.NotifyNewTransactions(txns)
+	}
+
+	if s.eventExporter != nil {
+		s.eventExporter.NotifyNewTransactions(txns)
+	}
 }
 
 // Transaction has one confirmation on the main chain. Now we can mark it as no
@@ -1457,8 +1678,13 @@ func (s *server) pushTxMsg(sp *serverPeer, hash *chainhash.Hash, doneChan chan<-
 
 	// Attempt to fetch the requested transaction from the pool.  A
 	// call could be made to check for existence first, but simply trying
-	// to fetch a missing transaction results in the same behavior.
+	// to fetch a missing transaction results in the same behavior.  The
+	// embargoed transactions are only served to their relay peer, so they
+	// are not found by the other peers.
 	tx, err := s.txMemPool.FetchTransaction(hash)
+	if err == nil && s.oneHopRelay != nil && s.oneHopRelay.Embargoed(hash, sp) {
+		err = errTxEmbargoed
+	}
 	if err != nil {
 		peerLog.Tracef("Unable to fetch tx %v from transaction "+
 			"pool: %v", hash, err)
@@ -1484,13 +1710,9 @@ func (s *server) pushTxMsg(sp *serverPeer, hash *chainhash.Hash, doneChan chan<-
 func (s *server) pushBlockMsg(sp *serverPeer, hash *chainhash.Hash, doneChan chan<- struct{},
 	waitChan <-chan struct{}, encoding wire.MessageEncoding) error {
 
-	// Fetch the raw block bytes from the database.
-	var blockBytes []byte
-	err := sp.server.db.View(func(dbTx database.Tx) error {
-		var err error
-		blockBytes, err = dbTx.FetchBlock(hash)
-		return err
-	})
+	// Fetch the block from the cache of the recently served blocks, or
+	// from the database.
+	served, err := sp.server.blockCache.fetch(sp.server.db, hash)
 	if err != nil {
 		peerLog.Tracef("Unable to fetch requested block hash %v: %v",
 			hash, err)
@@ -1500,19 +1722,7 @@ func (s *server) pushBlockMsg(sp *serverPeer, hash *chainhash.Hash, doneChan cha
 		}
 		return err
 	}
-
-	// Deserialize the block.
-	var msgBlock wire.MsgBlock
-	err = msgBlock.Deserialize(bytes.NewReader(blockBytes))
-	if err != nil {
-		peerLog.Tracef("Unable to deserialize requested block hash "+
-			"%v: %v", hash, err)
-
-		if doneChan != nil {
-			doneChan <- struct{}{}
-		}
-		return err
-	}
+	msgBlock := served.block.MsgBlock()
 
 	// Once we have fetched data wait for any previous operation to finish.
 	if waitChan != nil {
@@ -1527,7 +1737,7 @@ func (s *server) pushBlockMsg(sp *serverPeer, hash *chainhash.Hash, doneChan cha
 	if !sendInv {
 		dc = doneChan
 	}
-	sp.QueueMessageWithEncoding(&msgBlock, dc, encoding)
+	sp.QueueMessageWithEncoding(msgBlock, dc, encoding)
 
 	// When the peer requests the final block that was advertised in
 	// response to a getblocks message which requested more blocks than
@@ -1711,12 +1921,15 @@ func (s *server) handleAddPeerMsg(state *peerState, sp *serverPeer) bool {
 	// remote peer for outbound connections. This is skipped when running on
 	// the simulation test network since it is only intended to connect to
 	// specified peers and actively avoids advertising and connecting to
-	// discovered peers.
+	// discovered peers.  The addresses are not exchanged with the
+	// block-relay-only peers.
 	if !cfg.SimNet && !sp.Inbound() {
 		// Advertise the local address when the server accepts incoming
 		// connections and it believes itself to be close to the best
 		// known tip.
-		if !cfg.DisableListen && s.syncManager.IsCurrent() {
+		if !cfg.DisableListen && !sp.blockRelayOnly &&
+			s.syncManager.IsCurrent() {
+
 			// Get address that best matches.
 			lna := s.addrManager.GetBestLocalAddress(sp.NA())
 			if addrmgr.IsRoutable(lna) {
@@ -1730,7 +1943,9 @@ func (s *server) handleAddPeerMsg(state *peerState, sp *serverPeer) bool {
 		// more and the peer has a protocol version new enough to
 		// include a timestamp with addresses.
 		hasTimestamp := sp.ProtocolVersion() >= wire.NetAddressTimeVersion
-		if s.addrManager.NeedMoreAddresses() && hasTimestamp {
+		if s.addrManager.NeedMoreAddresses() && hasTimestamp &&
+			!sp.blockRelayOnly {
+
 			sp.QueueMessage(wire.NewMsgGetAddr(), nil)
 		}
 
@@ -1761,7 +1976,7 @@ func (s *server) handleDonePeerMsg(state *peerState, sp *serverPeer) {
 			s.connManager.Disconnect(sp.connReq.ID())
 		} else {
 			s.connManager.Remove(sp.connReq.ID())
-			go s.connManager.NewConnReq()
+			go s.replaceConnReq(sp.connReq)
 		}
 	}
 
@@ -2093,28 +2308,33 @@ func disconnectPeer(peerList map[int32]*serverPeer, compareFunc func(*serverPeer
 func newPeerConfig(sp *serverPeer) *peer.Config {
 	return &peer.Config{
 		Listeners: peer.MessageListeners{
-			OnVersion:      sp.OnVersion,
-			OnVerAck:       sp.OnVerAck,
-			OnMemPool:      sp.OnMemPool,
-			OnTx:           sp.OnTx,
-			OnBlock:        sp.OnBlock,
-			OnInv:          sp.OnInv,
-			OnHeaders:      sp.OnHeaders,
-			OnGetData:      sp.OnGetData,
-			OnGetBlocks:    sp.OnGetBlocks,
-			OnGetHeaders:   sp.OnGetHeaders,
-			OnGetCFilters:  sp.OnGetCFilters,
-			OnGetCFHeaders: sp.OnGetCFHeaders,
-			OnGetCFCheckpt: sp.OnGetCFCheckpt,
-			OnFeeFilter:    sp.OnFeeFilter,
-			OnFilterAdd:    sp.OnFilterAdd,
-			OnFilterClear:  sp.OnFilterClear,
-			OnFilterLoad:   sp.OnFilterLoad,
-			OnGetAddr:      sp.OnGetAddr,
-			OnAddr:         sp.OnAddr,
-			OnRead:         sp.OnRead,
-			OnWrite:        sp.OnWrite,
-			OnNotFound:     sp.OnNotFound,
+			OnVersion:               sp.OnVersion,
+			OnVerAck:                sp.OnVerAck,
+			OnMemPool:               sp.OnMemPool,
+			OnTx:                    sp.OnTx,
+			OnBlock:                 sp.OnBlock,
+			OnInv:                   sp.OnInv,
+			OnHeaders:               sp.OnHeaders,
+			OnGetData:               sp.OnGetData,
+			OnGetBlocks:             sp.OnGetBlocks,
+			OnGetHeaders:            sp.OnGetHeaders,
+			OnGetCFilters:           sp.OnGetCFilters,
+			OnGetCFHeaders:          sp.OnGetCFHeaders,
+			OnGetCFCheckpt:          sp.OnGetCFCheckpt,
+			OnGetClaimProof:         sp.OnGetClaimProof,
+			OnGetClaimSnapshot:      sp.OnGetClaimSnapshot,
+			OnClaimSnapshot:         sp.OnClaimSnapshot,
+			OnGetClaimSnapshotChunk: sp.OnGetClaimSnapshotChunk,
+			OnClaimSnapshotChunk:    sp.OnClaimSnapshotChunk,
+			OnFeeFilter:             sp.OnFeeFilter,
+			OnFilterAdd:             sp.OnFilterAdd,
+			OnFilterClear:           sp.OnFilterClear,
+			OnFilterLoad:            sp.OnFilterLoad,
+			OnGetAddr:               sp.OnGetAddr,
+			OnAddr:                  sp.OnAddr,
+			OnRead:                  sp.OnRead,
+			OnWrite:                 sp.OnWrite,
+			OnNotFound:              sp.OnNotFound,
 
 			// Note: The reference client currently bans peers that send alerts
 			// not signed with its key.  We could verify against their key, but
@@ -2130,7 +2350,7 @@ func newPeerConfig(sp *serverPeer) *peer.Config {
 		UserAgentComments:   cfg.UserAgentComments,
 		ChainParams:         sp.server.chainParams,
 		Services:            sp.server.services,
-		DisableRelayTx:      cfg.BlocksOnly,
+		DisableRelayTx:      cfg.BlocksOnly || sp.blockRelayOnly,
 		ProtocolVersion:     peer.MaxProtocolVersion,
 		TrickleInterval:     cfg.TrickleInterval,
 		DisableStallHandler: cfg.DisableStallHandler,
@@ -2156,6 +2376,7 @@ func (s *server) inboundPeerConnected(conn net.Conn) {
 // manager of the attempt.
 func (s *server) outboundPeerConnected(c *connmgr.ConnReq, conn net.Conn) {
 	sp := newServerPeer(s, c.Permanent)
+	sp.blockRelayOnly = c.BlockRelayOnly
 	p, err := peer.NewOutboundPeer(newPeerConfig(sp), c.Addr.String())
 	if err != nil {
 		srvrLog.Debugf("Cannot create outbound peer %s: %v", c.Addr, err)
@@ -2163,7 +2384,7 @@ func (s *server) outboundPeerConnected(c *connmgr.ConnReq, conn net.Conn) {
 			s.connManager.Disconnect(c.ID())
 		} else {
 			s.connManager.Remove(c.ID())
-			go s.connManager.NewConnReq()
+			go s.replaceConnReq(c)
 		}
 		return
 	}
@@ -2174,12 +2395,34 @@ func (s *server) outboundPeerConnected(c *connmgr.ConnReq, conn net.Conn) {
 	go s.peerDoneHandler(sp)
 }
 
+// replaceConnReq makes a new connection request of the class of the passed
+// request of a removed outbound peer, so the number of block-relay-only
+// connections is maintained as well.
+func (s *server) replaceConnReq(c *connmgr.ConnReq) {
+	if c.BlockRelayOnly {
+		s.connManager.NewBlockRelayOnlyConnReq()
+		return
+	}
+	s.connManager.NewConnReq()
+}
+
 // peerDoneHandler handles peer disconnects by notifiying the server that it's
 // done along with other performing other desirable cleanup.
 func (s *server) peerDoneHandler(sp *serverPeer) {
 	sp.WaitForDisconnect()
 	s.donePeers <- sp
 
+	// Broadcast the embargoed local transactions the peer was relaying.
+	if s.oneHopRelay != nil {
+		s.oneHopRelay.PeerDone(sp)
+	}
+
+	// Request the claimtrie snapshot chunks the peer was delivering from
+	// the other peers.
+	if s.claimSnapshots != nil {
+		s.claimSnapshots.PeerDone(sp)
+	}
+
 	// Only tell sync manager we are gone if we ever told it we existed.
 	if sp.VerAckReceived() {
 		s.syncManager.DonePeer(sp.Peer)
@@ -2264,6 +2507,7 @@ out:
 
 		case <-s.quit:
 			// Disconnect all peers on server shutdown.
+			srvrLog.Infof("Disconnecting %d peers", state.Count())
 			state.forAllPeers(func(sp *serverPeer) {
 				srvrLog.Tracef("Shutdown peer %s", sp)
 				sp.Disconnect()
@@ -2433,11 +2677,33 @@ func (s *server) Start() {
 
 	srvrLog.Trace("Starting server")
 
+	// Start the webhooks before any block can be connected, as their
+	// chain notifications are queued by a running handler.
+	if s.webhooks != nil {
+		s.webhooks.Start()
+	}
+
+	// The event export resumes from the saved block, so it may start at
+	// any time.
+	if s.eventExporter != nil {
+		s.eventExporter.Start()
+	}
+
+	// Check the free disk space before any block can be connected, so they
+	// aren't accepted when it already ran out.
+	s.diskSpace.Start()
+
 	// Start the peer handler which in turn starts the address and block
 	// managers.
 	s.wg.Add(1)
 	go s.peerHandler()
 
+	// Import the block archives passed with the loadblock option.
+	if len(cfg.LoadBlocks) > 0 {
+		s.wg.Add(1)
+		go s.loadBlockArchives(cfg.LoadBlocks)
+	}
+
 	if s.nat != nil {
 		s.wg.Add(1)
 		go s.upnpUpdateThread()
@@ -2453,6 +2719,26 @@ func (s *server) Start() {
 		s.rpcServer.Start()
 	}
 
+	if s.grpcServer != nil {
+		s.grpcServer.Start()
+	}
+
+	if s.electrumServer != nil {
+		s.electrumServer.Start()
+	}
+
+	if s.dnsSeeder != nil {
+		s.dnsSeeder.Start()
+	}
+
+	if s.torController != nil {
+		s.torController.Start()
+	}
+
+	if s.claimSnapshots != nil {
+		s.claimSnapshots.Start()
+	}
+
 	// Start the CPU miner if generation is enabled.
 	if cfg.Generate {
 		s.cpuMiner.Start()
@@ -2473,6 +2759,53 @@ func (s *server) Stop() error {
 	// Stop the CPU miner if needed
 	s.cpuMiner.Stop()
 
+	// Shutdown the gRPC server if it's enabled.
+	if s.grpcServer != nil {
+		s.grpcServer.Stop()
+	}
+
+	// Shutdown the Electrum server if it's enabled.
+	if s.electrumServer != nil {
+		s.electrumServer.Stop()
+	}
+
+	// Remove the onion service if it's enabled.
+	if s.torController != nil {
+		s.torController.Stop()
+	}
+
+	// Stop the DNS seeder if it's enabled.
+	if s.dnsSeeder != nil {
+		s.dnsSeeder.Stop()
+	}
+
+	// Stop the embargo timers of the local transactions.
+	if s.oneHopRelay != nil {
+		s.oneHopRelay.Stop()
+	}
+
+	s.diskSpace.Stop()
+
+	// Stop writing and loading the claimtrie snapshots if they're enabled.
+	if s.claimSnapshots != nil {
+		s.claimSnapshots.Stop()
+	}
+
+	// Stop the webhooks if they're enabled.
+	if s.webhooks != nil {
+		s.webhooks.Stop()
+	}
+
+	// Stop the event export if it's enabled.
+	if s.eventExporter != nil {
+		s.eventExporter.Stop()
+	}
+
+	// Close the connection to the policy plugin if it's enabled.
+	if s.policyPlugin != nil {
+		s.policyPlugin.Close()
+	}
+
 	// Shutdown the RPC server if it's not disabled.
 	if !cfg.DisableRPC {
 		s.rpcServer.Stop()
@@ -2480,6 +2813,13 @@ func (s *server) Stop() error {
 
 	s.feeEstimator.Close()
 
+	if cfg.PersistSigCache {
+		err := saveSigCache(s.sigCache, cfg.DataDir)
+		if err != nil {
+			srvrLog.Errorf("Unable to save the signature cache: %v", err)
+		}
+	}
+
 	// Signal the remaining goroutines to quit.
 	close(s.quit)
 	return nil
@@ -2630,36 +2970,124 @@ out:
 
 // setupRPCListeners returns a slice of listeners that are configured for use
 // with the RPC server depending on the configuration settings for listen
-// addresses and TLS.
+// addresses and TLS.  Unix domain socket listeners never use TLS.
 func setupRPCListeners() ([]net.Listener, error) {
+	var tcpAddrs, unixPaths []string
+	for _, addr := range cfg.RPCListeners {
+		if isUnixListener(addr) {
+			unixPaths = append(unixPaths,
+				strings.TrimPrefix(addr, unixListenerPrefix))
+			continue
+		}
+		tcpAddrs = append(tcpAddrs, addr)
+	}
+
 	// Setup TLS if not disabled.
 	listenFunc := net.Listen
-	if !cfg.DisableTLS {
-		// Generate the TLS cert and key file if both don't already
-		// exist.
-		if !fileExists(cfg.RPCKey) && !fileExists(cfg.RPCCert) {
-			err := genCertPair(cfg.RPCCert, cfg.RPCKey)
-			if err != nil {
-				return nil, err
-			}
+	if !cfg.DisableTLS && len(tcpAddrs) > 0 {
+		tlsConfig, err := rpcTLSConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		// Change the standard net.Listen function to the tls one.
+		listenFunc = func(net string, laddr string) (net.Listener, error) {
+			return tls.Listen(net, laddr, tlsConfig)
+		}
+	}
+
+	netAddrs, err := parseListeners(tcpAddrs)
+	if err != nil {
+		return nil, err
+	}
+
+	listeners := make([]net.Listener, 0, len(netAddrs)+len(unixPaths))
+	for _, addr := range netAddrs {
+		listener, err := listenFunc(addr.Network(), addr.String())
+		if err != nil {
+			rpcsLog.Warnf("Can't listen on %s: %v", addr, err)
+			continue
+		}
+		listeners = append(listeners, listener)
+	}
+	for _, path := range unixPaths {
+		listener, err := listenUnixSocket(path)
+		if err != nil {
+			rpcsLog.Warnf("Can't listen on %s: %v", path, err)
+			continue
 		}
-		keypair, err := tls.LoadX509KeyPair(cfg.RPCCert, cfg.RPCKey)
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
+
+// rpcTLSConfig returns the TLS configuration of the RPC and gRPC servers, and
+// of the WebSocket peer-to-peer listeners, generating the certificate and key files if both don't already exist.
+func rpcTLSConfig() (*tls.Config, error) {
+	if !fileExists(cfg.RPCKey) && !fileExists(cfg.RPCCert) {
+		err := genCertPair(cfg.RPCCert, cfg.RPCKey)
 		if err != nil {
 			return nil, err
 		}
+	}
+	keypair, err := tls.LoadX509KeyPair(cfg.RPCCert, cfg.RPCKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{keypair},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
 
-		tlsConfig := tls.Config{
-			Certificates: []tls.Certificate{keypair},
-			MinVersion:   tls.VersionTLS12,
+// setupGRPCListeners returns the listeners of the gRPC server for the
+// configured listen addresses, and its TLS configuration, which is nil when
+// TLS is disabled.
+func setupGRPCListeners() ([]net.Listener, *tls.Config, error) {
+	var tlsConfig *tls.Config
+	if !cfg.DisableTLS {
+		var err error
+		tlsConfig, err = rpcTLSConfig()
+		if err != nil {
+			return nil, nil, err
 		}
+	}
 
-		// Change the standard net.Listen function to the tls one.
+	netAddrs, err := parseListeners(cfg.GRPCListeners)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	listeners := make([]net.Listener, 0, len(netAddrs))
+	for _, addr := range netAddrs {
+		listener, err := net.Listen(addr.Network(), addr.String())
+		if err != nil {
+			rpcsLog.Warnf("Can't listen on %s: %v", addr, err)
+			continue
+		}
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, tlsConfig, nil
+}
+
+// setupP2PWSListeners returns the WebSocket peer-to-peer listeners for the
+// configured listen addresses.
+func setupP2PWSListeners() ([]net.Listener, error) {
+	listenFunc := net.Listen
+	if cfg.P2PWSTLS {
+		tlsConfig, err := rpcTLSConfig()
+		if err != nil {
+			return nil, err
+		}
 		listenFunc = func(net string, laddr string) (net.Listener, error) {
-			return tls.Listen(net, laddr, &tlsConfig)
+			return tls.Listen(net, laddr, tlsConfig)
 		}
 	}
 
-	netAddrs, err := parseListeners(cfg.RPCListeners)
+	netAddrs, err := parseListeners(cfg.P2PWSListeners)
 	if err != nil {
 		return nil, err
 	}
@@ -2667,6 +3095,28 @@ func setupRPCListeners() ([]net.Listener, error) {
 	listeners := make([]net.Listener, 0, len(netAddrs))
 	for _, addr := range netAddrs {
 		listener, err := listenFunc(addr.Network(), addr.String())
+		if err != nil {
+			srvrLog.Warnf("Can't listen on %s: %v", addr, err)
+			continue
+		}
+		listeners = append(listeners, newWSPeerListener(listener,
+			cfg.P2PWSOrigins))
+	}
+
+	return listeners, nil
+}
+
+// setupElectrumListeners returns the listeners of the Electrum server for the
+// configured listen addresses.
+func setupElectrumListeners() ([]net.Listener, error) {
+	netAddrs, err := parseListeners(cfg.ElectrumListeners)
+	if err != nil {
+		return nil, err
+	}
+
+	listeners := make([]net.Listener, 0, len(netAddrs))
+	for _, addr := range netAddrs {
+		listener, err := net.Listen(addr.Network(), addr.String())
 		if err != nil {
 			rpcsLog.Warnf("Can't listen on %s: %v", addr, err)
 			continue
@@ -2677,6 +3127,119 @@ func setupRPCListeners() ([]net.Listener, error) {
 	return listeners, nil
 }
 
+// newServerTorController returns the controller of the onion service of the
+// peer-to-peer listener, which also serves the RPC server when requested.
+func newServerTorController(chainParams *chaincfg.Params) (*torController, error) {
+	var ports []torOnionPort
+	add := func(virtual string, listeners []string) error {
+		port, err := strconv.ParseUint(virtual, 10, 16)
+		if err != nil {
+			return err
+		}
+		target, _ := torOnionTarget(listeners)
+		ports = append(ports, torOnionPort{
+			Virtual: uint16(port),
+			Target:  target,
+		})
+		return nil
+	}
+	if err := add(chainParams.DefaultPort, cfg.Listeners); err != nil {
+		return nil, err
+	}
+	if cfg.TorOnionRPC {
+		if err := add(activeNetParams.rpcPort, cfg.RPCListeners); err != nil {
+			return nil, err
+		}
+	}
+
+	return newTorController(&torControllerConfig{
+		ControlAddr: cfg.TorControl,
+		Password:    cfg.TorControlPass,
+		KeyFile:     filepath.Join(cfg.DataDir, torOnionKeyFilename),
+		Ports:       ports,
+		Dial:        net.DialTimeout,
+	}), nil
+}
+
+// setupDNSSeederListeners returns the UDP sockets the DNS seeder answers the
+// queries on.
+func setupDNSSeederListeners() ([]net.PacketConn, error) {
+	netAddrs, err := parseListeners(cfg.DNSSeederListeners)
+	if err != nil {
+		return nil, err
+	}
+
+	listeners := make([]net.PacketConn, 0, len(netAddrs))
+	for _, addr := range netAddrs {
+		network := "udp4"
+		if addr.Network() == "tcp6" {
+			network = "udp6"
+		}
+		listener, err := net.ListenPacket(network, addr.String())
+		if err != nil {
+			seedLog.Warnf("Can't listen on %s: %v", addr, err)
+			continue
+		}
+		listeners = append(listeners, listener)
+	}
+
+	return listeners, nil
+}
+
+// unixSocketListener is the listener of a unix domain socket, which removes
+// the socket on close.
+type unixSocketListener struct {
+	net.Listener
+	path string
+}
+
+// Close stops listening and removes the socket.
+func (l *unixSocketListener) Close() error {
+	err := l.Listener.Close()
+	os.Remove(l.path)
+	return err
+}
+
+// listenUnixSocket returns a listener on the unix domain socket at path.  A
+// socket left behind by a previous run is removed first, and the new one is
+// only made accessible to the owner and group of the process, so the
+// filesystem permissions control which local users may connect.  The socket is
+// created in a private directory, and only moved to path once its permissions
+// are restricted, so no other user can connect to it in between.
+func listenUnixSocket(path string) (net.Listener, error) {
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	// The names are kept short, since the paths of the sockets are limited
+	// to about a hundred bytes.
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".rpc")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	tmpPath := filepath.Join(dir, "s")
+
+	listener, err := net.Listen("unix", tmpPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(tmpPath, 0660); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return &unixSocketListener{Listener: listener, path: path}, nil
+}
+
 // newServer returns a new btcd server configured to listen on addr for the
 // bitcoin network type specified by chainParams.  Use start to begin accepting
 // connections from peers.
@@ -2693,6 +3256,9 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 	if cfg.NoCFilters {
 		services &^= wire.SFNodeCF
 	}
+	if cfg.ServeClaimSnapshots {
+		services |= wire.SFNodeClaimSnapshots
+	}
 
 	amgr := addrmgr.New(cfg.DataDir, btcdLookup)
 
@@ -2709,6 +3275,17 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 		}
 	}
 
+	if len(cfg.P2PWSListeners) > 0 {
+		wsListeners, err := setupP2PWSListeners()
+		if err != nil {
+			return nil, err
+		}
+		if len(wsListeners) == 0 {
+			return nil, errors.New("no valid WebSocket listen address")
+		}
+		listeners = append(listeners, wsListeners...)
+	}
+
 	if len(agentBlacklist) > 0 {
 		srvrLog.Infof("User-agent blacklist %s", agentBlacklist)
 	}
@@ -2734,10 +3311,21 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 		services:             services,
 		sigCache:             txscript.NewSigCache(cfg.SigCacheMaxSize),
 		hashCache:            txscript.NewHashCache(cfg.SigCacheMaxSize),
+		blockCache:           newBlockCache(cfg.BlockCacheSize),
 		cfCheckptCaches:      make(map[wire.FilterType][]cfHeaderKV),
 		agentBlacklist:       agentBlacklist,
 		agentWhitelist:       agentWhitelist,
 	}
+	if cfg.PersistSigCache {
+		// Failing to restore the signature cache only makes validating
+		// the first blocks slower.
+		err := loadSigCache(s.sigCache, cfg.DataDir)
+		if err != nil {
+			srvrLog.Warnf("Unable to restore the signature cache: %v", err)
+		}
+		n, _ := s.sigCache.Len()
+		srvrLog.Infof("Restored %d signature cache entries", n)
+	}
 
 	// Create the transaction and address indexes if needed.
 	//
@@ -2765,6 +3353,12 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 		s.addrIndex = indexers.NewAddrIndex(db, chainParams)
 		indexes = append(indexes, s.addrIndex)
 	}
+	if len(cfg.ElectrumListeners) > 0 {
+		indxLog.Info("Script hash index enabled because it is " +
+			"required by the Electrum server")
+		s.scriptHashIndex = indexers.NewScriptHashIndex(db, chainParams)
+		indexes = append(indexes, s.scriptHashIndex)
+	}
 	if !cfg.NoCFilters {
 		indxLog.Info("Committed filter index is enabled")
 		s.cfIndex = indexers.NewCfIndex(db, chainParams)
@@ -2796,15 +3390,18 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 
 	// Create a new block chain instance with the appropriate configuration.
 	s.chain, err = blockchain.New(&blockchain.Config{
-		DB:           s.db,
-		Interrupt:    interrupt,
-		ChainParams:  s.chainParams,
-		Checkpoints:  checkpoints,
-		TimeSource:   s.timeSource,
-		SigCache:     s.sigCache,
-		IndexManager: indexManager,
-		HashCache:    s.hashCache,
-		ClaimTrie:    ct,
+		DB:                 s.db,
+		Interrupt:          interrupt,
+		ChainParams:        s.chainParams,
+		Checkpoints:        checkpoints,
+		TimeSource:         s.timeSource,
+		SigCache:           s.sigCache,
+		IndexManager:       indexManager,
+		HashCache:          s.hashCache,
+		BatchSigVerify:     cfg.BatchSigVerify,
+		SlowBlockThreshold: cfg.SlowBlock,
+		ClaimTrie:          ct,
+		DeferClaimTrie:     cfg.ClaimSnapshot,
 	})
 	if err != nil {
 		return nil, err
@@ -2830,6 +3427,14 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 	}
 	s.feeEstimator = fe
 
+	stdPolicy := mining.StandardPolicy{
+		MaxTxWeight:        cfg.MaxStdTxWeight,
+		MaxSigScriptSize:   cfg.MaxStdSigScriptSize,
+		MaxP2SHSigOps:      cfg.MaxStdP2SHSigOps,
+		MaxMultiSigKeys:    cfg.MaxStdMultiSigKeys,
+		MaxNullDataOutputs: cfg.MaxStdNullData,
+		DustRelayFee:       cfg.dustRelayFee,
+	}
 	txC := mempool.Config{
 		Policy: mempool.Policy{
 			DisableRelayPriority: cfg.NoRelayPriority,
@@ -2841,6 +3446,7 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 			MinRelayTxFee:        cfg.minRelayTxFee,
 			MaxTxVersion:         2,
 			RejectReplacement:    cfg.RejectReplacement,
+			Standard:             stdPolicy,
 		},
 		ChainParams:    chainParams,
 		FetchUtxoView:  s.chain.FetchUtxoView,
@@ -2856,6 +3462,20 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 		AddTxToFeeEstimation:      s.feeEstimator.AddMemPoolTransaction,
 		RemoveTxFromFeeEstimation: s.feeEstimator.RemoveMemPoolTransaction,
 	}
+	if cfg.PolicyPlugin != "" {
+		s.policyPlugin, err = newPolicyPlugin(&policyPluginConfig{
+			Addr:          cfg.PolicyPlugin,
+			Timeout:       cfg.PolicyPluginTimeout,
+			FailOpen:      cfg.PolicyPluginFailOpen,
+			ChainParams:   chainParams,
+			FetchUtxoView: s.chain.FetchUtxoView,
+		})
+		if err != nil {
+			return nil, err
+		}
+		txC.CheckTxPolicy = s.policyPlugin.CheckMempoolTx
+		txC.PolicyAcceptedTxs = s.AnnounceNewTransactions
+	}
 	s.txMemPool = mempool.New(&txC)
 
 	s.syncManager, err = netsync.New(&netsync.Config{
@@ -2883,6 +3503,12 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 		BlockMaxSize:      cfg.BlockMaxSize,
 		BlockPrioritySize: cfg.BlockPrioritySize,
 		TxMinFreeFee:      cfg.minRelayTxFee,
+		Standard:          stdPolicy,
+		EnforceStandard:   !cfg.RelayNonStd,
+		Signaling:         cfg.signaling,
+	}
+	if s.policyPlugin != nil {
+		policy.FilterTemplateTxs = s.policyPlugin.FilterTemplateTxs
 	}
 	blockTemplateGenerator := mining.NewBlkTmplGenerator(&policy,
 		s.chainParams, s.txMemPool, s.chain, s.timeSource,
@@ -2903,61 +3529,52 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 	// discovered peers in order to prevent it from becoming a public test
 	// network.
 	var newAddressFunc func() (net.Addr, error)
+	var networkAddressFunc func(connmgr.Network) (net.Addr, error)
+	var targetNetworkOutbound map[connmgr.Network]uint32
 	if !cfg.SimNet && len(cfg.ConnectPeers) == 0 {
 		newAddressFunc = func() (net.Addr, error) {
-			for tries := 0; tries < 100; tries++ {
-				addr := s.addrManager.GetAddress()
-				if addr == nil {
-					break
-				}
-
-				// Address will not be invalid, local or unroutable
-				// because addrmanager rejects those on addition.
-				// Just check that we don't already have an address
-				// in the same group so that we are not connecting
-				// to the same network segment at the expense of
-				// others.
-				key := addrmgr.GroupKey(addr.NetAddress())
-				if s.OutboundGroupCount(key) != 0 {
-					continue
-				}
-
-				// only allow recent nodes (10mins) after we failed 30
-				// times
-				if tries < 30 && time.Since(addr.LastAttempt()) < 10*time.Minute {
-					continue
-				}
-
-				// allow nondefault ports after 50 failed tries.
-				if tries < 50 && fmt.Sprintf("%d", addr.NetAddress().Port) !=
-					activeNetParams.DefaultPort {
-					continue
-				}
-
-				// Mark an attempt for the valid address.
-				s.addrManager.Attempt(addr.NetAddress())
-
-				addrString := addrmgr.NetAddressKey(addr.NetAddress())
-				return addrStringToNetAddr(addrString)
-			}
-
-			return nil, errors.New("no valid connect address")
+			return s.newOutboundAddress(s.addrManager.GetAddress)
+		}
+		networkAddressFunc = func(network connmgr.Network) (net.Addr, error) {
+			return s.newOutboundAddress(func() *addrmgr.KnownAddress {
+				return s.addrManager.GetNetworkAddress(network.String())
+			})
 		}
+		targetNetworkOutbound = cfg.outboundNetworks
 	}
 
-	// Create a connection manager.
+	// Create a connection manager.  The outbound peers maintained on the
+	// networks are among the full-relay ones, which are raised to their
+	// number when fewer.
 	targetOutbound := defaultTargetOutbound
+	var networkOutbound int
+	for _, count := range targetNetworkOutbound {
+		networkOutbound += int(count)
+	}
+	if networkOutbound > targetOutbound {
+		targetOutbound = networkOutbound
+	}
 	if cfg.MaxPeers < targetOutbound {
 		targetOutbound = cfg.MaxPeers
 	}
+
+	// The block-relay-only peers are maintained in addition to the
+	// full-relay ones, within the max number of peers.
+	targetBlockRelayOnly := cfg.BlockRelayOnlyPeers
+	if cfg.MaxPeers-targetOutbound < targetBlockRelayOnly {
+		targetBlockRelayOnly = cfg.MaxPeers - targetOutbound
+	}
 	cmgr, err := connmgr.New(&connmgr.Config{
-		Listeners:      listeners,
-		OnAccept:       s.inboundPeerConnected,
-		RetryDuration:  connectionRetryInterval,
-		TargetOutbound: uint32(targetOutbound),
-		Dial:           btcdDial,
-		OnConnection:   s.outboundPeerConnected,
-		GetNewAddress:  newAddressFunc,
+		Listeners:             listeners,
+		OnAccept:              s.inboundPeerConnected,
+		RetryDuration:         connectionRetryInterval,
+		TargetOutbound:        uint32(targetOutbound),
+		TargetBlockRelayOnly:  uint32(targetBlockRelayOnly),
+		TargetNetworkOutbound: targetNetworkOutbound,
+		Dial:                  btcdDial,
+		OnConnection:          s.outboundPeerConnected,
+		GetNewAddress:         newAddressFunc,
+		GetNetworkAddress:     networkAddressFunc,
 	})
 	if err != nil {
 		return nil, err
@@ -2981,6 +3598,96 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 		})
 	}
 
+	if len(cfg.Webhooks) > 0 {
+		s.webhooks = newWebhookNotifier(&webhookNotifierConfig{
+			URLs:            cfg.Webhooks,
+			Secret:          []byte(cfg.WebhookSecret),
+			Addrs:           cfg.webhookAddrs,
+			Chain:           s.chain,
+			ChainParams:     chainParams,
+			HaveTransaction: s.txMemPool.HaveTransaction,
+		})
+	}
+
+	if cfg.eventExport != nil {
+		target := cfg.eventExport
+		s.eventExporter = newEventExporter(&eventExporterConfig{
+			Target:    target,
+			Format:    cfg.EventExportFormat,
+			StateFile: filepath.Join(cfg.DataDir, eventExportStateFilename),
+			Dial: func() (eventPublisher, error) {
+				if target.Kind == "kafka" {
+					return dialKafka(target)
+				}
+				return dialNATS(target)
+			},
+			Chain: s.chain,
+		})
+	}
+
+	if cfg.DNSSeeder != "" {
+		seederListeners, err := setupDNSSeederListeners()
+		if err != nil {
+			return nil, err
+		}
+		if len(seederListeners) == 0 {
+			return nil, errors.New("DNS seeder: No valid listen address")
+		}
+
+		s.dnsSeeder, err = newDNSSeeder(&dnsSeederConfig{
+			Domain:      cfg.DNSSeeder,
+			Nameserver:  cfg.DNSSeederNameserver,
+			Listeners:   seederListeners,
+			AddrManager: s.addrManager,
+			ChainParams: chainParams,
+			BestHeight: func() int32 {
+				return s.chain.BestSnapshot().Height
+			},
+			Dial: cfg.dial,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.TorControl != "" {
+		s.torController, err = newServerTorController(chainParams)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !cfg.NoOneHopRelay {
+		s.oneHopRelay = newOneHopRelay(&oneHopRelayConfig{
+			Candidates:    s.relayPeerCandidates,
+			Relay:         s.relayTransactionsToPeer,
+			Broadcast:     s.relayTransactions,
+			Epoch:         oneHopRelayEpoch,
+			Embargo:       oneHopRelayEmbargo,
+			EmbargoJitter: oneHopRelayEmbargoJitter,
+		})
+	}
+
+	diskSpaceCfg := diskSpaceMonitorConfig{
+		Volumes:   diskSpaceVolumes(),
+		WarnSpace: cfg.WarnFreeSpace * 1024 * 1024,
+		MinSpace:  cfg.MinFreeSpace * 1024 * 1024,
+		Usage:     diskUsage,
+		Chain:     s.chain,
+	}
+	if s.webhooks != nil {
+		diskSpaceCfg.Notify = s.webhooks.NotifyDiskSpace
+	}
+	s.diskSpace = newDiskSpaceMonitor(&diskSpaceCfg)
+
+	if cfg.ServeClaimSnapshots || cfg.ClaimSnapshot {
+		s.claimSnapshots = newClaimSnapshotManager(&claimSnapshotConfig{
+			Chain: s.chain,
+			Dir:   filepath.Join(cfg.DataDir, claimSnapshotsDirname),
+			Serve: cfg.ServeClaimSnapshots,
+		})
+	}
+
 	if !cfg.DisableRPC {
 		// Setup listeners for the configured RPC listen addresses and
 		// TLS settings.
@@ -2993,23 +3700,29 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 		}
 
 		s.rpcServer, err = newRPCServer(&rpcserverConfig{
-			Listeners:    rpcListeners,
-			StartupTime:  startupTime.Unix(),
-			ConnMgr:      &rpcConnManager{&s},
-			AddrMgr:      amgr,
-			SyncMgr:      &rpcSyncMgr{&s, s.syncManager},
-			TimeSource:   s.timeSource,
-			Chain:        s.chain,
-			ChainParams:  chainParams,
-			DB:           db,
-			TxMemPool:    s.txMemPool,
-			Generator:    blockTemplateGenerator,
-			CPUMiner:     s.cpuMiner,
-			TxIndex:      s.txIndex,
-			AddrIndex:    s.addrIndex,
-			CfIndex:      s.cfIndex,
-			FeeEstimator: s.feeEstimator,
-			Services:     s.services,
+			Listeners:     rpcListeners,
+			StartupTime:   startupTime.Unix(),
+			ConnMgr:       &rpcConnManager{&s},
+			AddrMgr:       amgr,
+			SyncMgr:       &rpcSyncMgr{&s, s.syncManager},
+			TimeSource:    s.timeSource,
+			Chain:         s.chain,
+			ChainParams:   chainParams,
+			DB:            db,
+			TxMemPool:     s.txMemPool,
+			SigCache:      s.sigCache,
+			HashCache:     s.hashCache,
+			Generator:     blockTemplateGenerator,
+			CPUMiner:      s.cpuMiner,
+			TxIndex:       s.txIndex,
+			AddrIndex:     s.addrIndex,
+			CfIndex:       s.cfIndex,
+			FeeEstimator:  s.feeEstimator,
+			Services:      s.services,
+			DiskSpace:     s.diskSpace,
+			BlockCache:    s.blockCache,
+			TorController: s.torController,
+			SlowRPC:       cfg.SlowRPC,
 		})
 		if err != nil {
 			return nil, err
@@ -3020,6 +3733,39 @@ func newServer(listenAddrs, agentBlacklist, agentWhitelist []string,
 			<-s.rpcServer.RequestedProcessShutdown()
 			shutdownRequestChannel <- struct{}{}
 		}()
+
+		if len(cfg.GRPCListeners) > 0 {
+			grpcListeners, tlsConfig, err := setupGRPCListeners()
+			if err != nil {
+				return nil, err
+			}
+			if len(grpcListeners) == 0 {
+				return nil, errors.New("gRPC: No valid listen address")
+			}
+
+			s.grpcServer = newGRPCServer(&grpcServerConfig{
+				Listeners: grpcListeners,
+				TLS:       tlsConfig,
+				RPC:       s.rpcServer,
+			})
+		}
+
+		if len(cfg.ElectrumListeners) > 0 {
+			electrumListeners, err := setupElectrumListeners()
+			if err != nil {
+				return nil, err
+			}
+			if len(electrumListeners) == 0 {
+				return nil, errors.New("Electrum: No valid listen address")
+			}
+
+			s.electrumServer = newElectrumServer(&electrumServerConfig{
+				Listeners:       electrumListeners,
+				MaxClients:      cfg.ElectrumMaxClients,
+				RPC:             s.rpcServer,
+				ScriptHashIndex: s.scriptHashIndex,
+			})
+		}
 	}
 
 	return &s, nil
@@ -3108,6 +3854,48 @@ func initListeners(amgr *addrmgr.AddrManager, listenAddrs []string, services wir
 	return listeners, nat, nil
 }
 
+// newOutboundAddress returns an address to make a new outbound connection to,
+// chosen among those returned by the passed function of the address manager.
+func (s *server) newOutboundAddress(getAddress func() *addrmgr.KnownAddress) (net.Addr, error) {
+	for tries := 0; tries < 100; tries++ {
+		addr := getAddress()
+		if addr == nil {
+			break
+		}
+
+		// Address will not be invalid, local or unroutable
+		// because addrmanager rejects those on addition.
+		// Just check that we don't already have an address
+		// in the same group so that we are not connecting
+		// to the same network segment at the expense of
+		// others.
+		key := addrmgr.GroupKey(addr.NetAddress())
+		if s.OutboundGroupCount(key) != 0 {
+			continue
+		}
+
+		// only allow recent nodes (10mins) after we failed 30
+		// times
+		if tries < 30 && time.Since(addr.LastAttempt()) < 10*time.Minute {
+			continue
+		}
+
+		// allow nondefault ports after 50 failed tries.
+		if tries < 50 && fmt.Sprintf("%d", addr.NetAddress().Port) !=
+			activeNetParams.DefaultPort {
+			continue
+		}
+
+		// Mark an attempt for the valid address.
+		s.addrManager.Attempt(addr.NetAddress())
+
+		addrString := addrmgr.NetAddressKey(addr.NetAddress())
+		return addrStringToNetAddr(addrString)
+	}
+
+	return nil, errors.New("no valid connect address")
+}
+
 // addrStringToNetAddr takes an address in the form of 'host:port' and returns
 // a net.Addr which maps to the original address with any host names resolved
 // to IP addresses.  It also handles tor addresses properly by returning a
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenUnixSocket(t *testing.T) {
+
+	r := require.New(t)
+
+	if runtime.GOOS == "windows" {
+		t.Skip("the permissions of the sockets aren't enforced on windows")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rpc.sock")
+
+	// A socket left behind is replaced, but not another file.
+	stale, err := net.Listen("unix", path)
+	r.NoError(err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	r.NoError(stale.Close())
+
+	listener, err := listenUnixSocket(path)
+	r.NoError(err)
+	fi, err := os.Lstat(path)
+	r.NoError(err)
+	r.NotZero(fi.Mode() & os.ModeSocket)
+	r.Equal(os.FileMode(0660), fi.Mode().Perm())
+
+	// The private directory the socket was created in is removed.
+	entries, err := os.ReadDir(dir)
+	r.NoError(err)
+	r.Len(entries, 1)
+
+	conn, err := net.Dial("unix", path)
+	r.NoError(err)
+	r.NoError(conn.Close())
+
+	r.NoError(listener.Close())
+	_, err = os.Lstat(path)
+	r.True(os.IsNotExist(err))
+
+	r.NoError(os.WriteFile(path, nil, 0600))
+	_, err = listenUnixSocket(path)
+	r.Error(err)
+}
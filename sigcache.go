@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+
+	"github.com/lbryio/lbcd/txscript"
+)
+
+// sigCacheFileName is the name of the file of the data directory the signature
+// cache is saved to when it is persisted.
+const sigCacheFileName = "sigcache.dat"
+
+// loadSigCache restores the signature cache saved to the data directory by
+// saveSigCache.  A missing file is not an error, and leaves the cache as it is.
+func loadSigCache(sigCache *txscript.SigCache, dataDir string) error {
+	f, err := os.Open(filepath.Join(dataDir, sigCacheFileName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return sigCache.Deserialize(bufio.NewReader(f))
+}
+
+// saveSigCache saves the signature cache to the data directory.  It is written
+// to a temporary file first, so a failure leaves the previous file in place.
+func saveSigCache(sigCache *txscript.SigCache, dataDir string) error {
+	path := filepath.Join(dataDir, sigCacheFileName)
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	err = sigCache.Serialize(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lbryio/lbcd/btcec"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveAndLoadSigCache(t *testing.T) {
+
+	r := require.New(t)
+	dataDir := t.TempDir()
+
+	// A missing file leaves the cache empty.
+	restored := txscript.NewSigCache(10)
+	r.NoError(loadSigCache(restored, dataDir))
+	n, _ := restored.Len()
+	r.Zero(n)
+
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	r.NoError(err)
+	sigHash := chainhash.HashH([]byte("message"))
+	sig, err := privKey.Sign(sigHash[:])
+	r.NoError(err)
+
+	sigCache := txscript.NewSigCache(10)
+	sigCache.Add(sigHash, sig, privKey.PubKey())
+	r.NoError(saveSigCache(sigCache, dataDir))
+	_, err = os.Stat(filepath.Join(dataDir, sigCacheFileName+".tmp"))
+	r.True(os.IsNotExist(err))
+
+	r.NoError(loadSigCache(restored, dataDir))
+	r.True(restored.Exists(sigHash, sig, privKey.PubKey()))
+}
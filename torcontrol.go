@@ -0,0 +1,499 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// torOnionKeyFilename is the name of the file of the data directory
+	// keeping the private key of the onion service, so its address stays
+	// the same across restarts.
+	torOnionKeyFilename = "onion_v3_private_key"
+
+	// torControlTimeout is the time allowed to connect to the control port
+	// and to complete each command.
+	torControlTimeout = 30 * time.Second
+
+	// torMinRetryInterval and torMaxRetryInterval bound the wait before
+	// connecting to the control port again after a failure.  The wait
+	// doubles with each consecutive failure.
+	torMinRetryInterval = 5 * time.Second
+	torMaxRetryInterval = 5 * time.Minute
+
+	// The keys of the HMACs of the safe cookie authentication.
+	torServerHashKey = "Tor safe cookie authentication server-to-controller hash"
+	torClientHashKey = "Tor safe cookie authentication controller-to-server hash"
+)
+
+// torOnionPort maps a virtual port of the onion service to the local target of
+// its connections.
+type torOnionPort struct {
+	Virtual uint16
+	Target  string
+}
+
+// torControllerConfig is a descriptor containing the Tor controller
+// configuration.
+type torControllerConfig struct {
+	// ControlAddr is the host:port of the control port of Tor.
+	ControlAddr string
+
+	// Password authenticates to the control port.  The authentication
+	// cookie of Tor is used when it is empty.
+	Password string
+
+	// KeyFile is the path of the file keeping the private key of the
+	// onion service.
+	KeyFile string
+
+	// Ports are the ports of the onion service, the first of which is the
+	// one of the peer-to-peer listener.
+	Ports []torOnionPort
+
+	// Dial connects to the control port.
+	Dial func(network, addr string, timeout time.Duration) (net.Conn, error)
+}
+
+// torController creates a v3 onion service for the listeners of lbcd through
+// the control port of Tor, and keeps it up as long as lbcd runs, which spares
+// the configuration of the service in the torrc file.
+//
+// The onion service lasts as long as the control connection, so the controller
+// reconnects and creates it again whenever the connection is lost, such as
+// when Tor restarts.  Its private key is kept in the data directory, so its
+// address doesn't change.
+type torController struct {
+	started  int32
+	shutdown int32
+	cfg      torControllerConfig
+
+	mtx   sync.Mutex
+	conn  net.Conn
+	onion string
+
+	wg   sync.WaitGroup
+	quit chan struct{}
+}
+
+// newTorController returns a new Tor controller with the configuration.
+func newTorController(config *torControllerConfig) *torController {
+	return &torController{
+		cfg:  *config,
+		quit: make(chan struct{}),
+	}
+}
+
+// Start begins maintaining the onion service.
+func (t *torController) Start() {
+	if atomic.AddInt32(&t.started, 1) != 1 {
+		return
+	}
+
+	t.wg.Add(1)
+	go t.controlHandler()
+}
+
+// Stop closes the control connection, which removes the onion service.
+func (t *torController) Stop() {
+	if atomic.AddInt32(&t.shutdown, 1) != 1 {
+		return
+	}
+
+	close(t.quit)
+	t.mtx.Lock()
+	if t.conn != nil {
+		t.conn.Close()
+	}
+	t.mtx.Unlock()
+	t.wg.Wait()
+}
+
+// OnionAddress returns the host name of the onion service, or an empty string
+// while it isn't up, and its peer-to-peer port, which is its first port.
+//
+// This function is safe for concurrent access.
+func (t *torController) OnionAddress() (string, uint16) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	return t.onion, t.cfg.Ports[0].Virtual
+}
+
+// controlHandler creates the onion service, waits for the loss of the control
+// connection, and starts over after a wait.
+//
+// It must be run as a goroutine.
+func (t *torController) controlHandler() {
+	defer t.wg.Done()
+
+	retry := torMinRetryInterval
+	for {
+		created, err := t.serve()
+		if atomic.LoadInt32(&t.shutdown) != 0 {
+			return
+		}
+		if created {
+			retry = torMinRetryInterval
+		}
+		srvrLog.Warnf("Tor control port %s: %v -- retrying in %v",
+			t.cfg.ControlAddr, err, retry)
+
+		select {
+		case <-time.After(retry):
+		case <-t.quit:
+			return
+		}
+		retry *= 2
+		if retry > torMaxRetryInterval {
+			retry = torMaxRetryInterval
+		}
+	}
+}
+
+// serve connects to the control port, creates the onion service, and blocks
+// until the control connection is lost.  It returns whether the service was
+// created, and why it isn't up anymore.
+func (t *torController) serve() (bool, error) {
+	conn, err := t.cfg.Dial("tcp", t.cfg.ControlAddr, torControlTimeout)
+	if err != nil {
+		return false, err
+	}
+	t.mtx.Lock()
+	if atomic.LoadInt32(&t.shutdown) != 0 {
+		t.mtx.Unlock()
+		conn.Close()
+		return false, nil
+	}
+	t.conn = conn
+	t.mtx.Unlock()
+
+	defer func() {
+		conn.Close()
+		t.mtx.Lock()
+		t.conn = nil
+		t.onion = ""
+		t.mtx.Unlock()
+	}()
+
+	c := newTorControlConn(conn)
+	if err := c.authenticate(t.cfg.Password); err != nil {
+		return false, err
+	}
+	onion, err := c.addOnion(t.cfg.KeyFile, t.cfg.Ports)
+	if err != nil {
+		return false, err
+	}
+
+	t.mtx.Lock()
+	t.onion = onion
+	t.mtx.Unlock()
+	for _, port := range t.cfg.Ports {
+		srvrLog.Infof("Tor onion service %s forwarding to %s", net.JoinHostPort(
+			onion, strconv.Itoa(int(port.Virtual))), port.Target)
+	}
+
+	// Nothing is expected from Tor as no events are subscribed to, so any
+	// read ends with the control connection.
+	conn.SetDeadline(time.Time{})
+	for {
+		if _, err := c.readReply(); err != nil {
+			return true, fmt.Errorf("control connection lost: %v", err)
+		}
+	}
+}
+
+// torControlConn is a connection to the control port of Tor.
+type torControlConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// newTorControlConn returns a control connection over the net connection.
+func newTorControlConn(conn net.Conn) *torControlConn {
+	return &torControlConn{conn: conn, reader: bufio.NewReader(conn)}
+}
+
+// readReply reads a reply, returning the text of its lines, without their
+// status codes, when its status is 250.  The data of the multi-line values
+// follows their line.
+func (c *torControlConn) readReply() ([]string, error) {
+	var lines []string
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(line) < 4 {
+			return nil, fmt.Errorf("malformed reply line %q", line)
+		}
+		status, sep, text := line[:3], line[3], line[4:]
+		lines = append(lines, text)
+
+		switch sep {
+		case '+':
+			// The data lines end with a line with a single dot.
+			for {
+				data, err := c.readLine()
+				if err != nil {
+					return nil, err
+				}
+				if data == "." {
+					break
+				}
+				lines = append(lines, strings.TrimPrefix(data, "."))
+			}
+		case '-':
+		case ' ':
+			if status != "250" {
+				return nil, fmt.Errorf("%s %s", status, text)
+			}
+			return lines, nil
+		default:
+			return nil, fmt.Errorf("malformed reply line %q", line)
+		}
+	}
+}
+
+// readLine reads a line without its CRLF.
+func (c *torControlConn) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// command sends the command and returns its reply.
+func (c *torControlConn) command(cmd string) ([]string, error) {
+	c.conn.SetDeadline(time.Now().Add(torControlTimeout))
+	if _, err := c.conn.Write([]byte(cmd + "\r\n")); err != nil {
+		return nil, err
+	}
+	return c.readReply()
+}
+
+// authenticate authenticates with the password when it isn't empty, and with
+// the authentication cookie of Tor otherwise, preferring the safe cookie
+// authentication.
+func (c *torControlConn) authenticate(password string) error {
+	lines, err := c.command("PROTOCOLINFO 1")
+	if err != nil {
+		return fmt.Errorf("PROTOCOLINFO: %v", err)
+	}
+	methods := make(map[string]bool)
+	var cookieFile string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "AUTH ") {
+			continue
+		}
+		args := parseTorReplyArgs(strings.TrimPrefix(line, "AUTH "))
+		for _, method := range strings.Split(args["METHODS"], ",") {
+			methods[method] = true
+		}
+		cookieFile = args["COOKIEFILE"]
+	}
+
+	var auth string
+	switch {
+	case methods["NULL"]:
+		auth = "AUTHENTICATE"
+
+	case password != "":
+		if !methods["HASHEDPASSWORD"] {
+			return errors.New("the control port doesn't accept passwords")
+		}
+		auth = "AUTHENTICATE " + quoteTorString(password)
+
+	case methods["SAFECOOKIE"] && cookieFile != "":
+		cookie, err := ioutil.ReadFile(cookieFile)
+		if err != nil {
+			return fmt.Errorf("unable to read the authentication "+
+				"cookie: %v", err)
+		}
+		clientNonce := make([]byte, 32)
+		if _, err := rand.Read(clientNonce); err != nil {
+			return err
+		}
+		lines, err := c.command("AUTHCHALLENGE SAFECOOKIE " +
+			hex.EncodeToString(clientNonce))
+		if err != nil {
+			return fmt.Errorf("AUTHCHALLENGE: %v", err)
+		}
+		args := parseTorReplyArgs(strings.TrimPrefix(lines[0],
+			"AUTHCHALLENGE "))
+		serverHash, err1 := hex.DecodeString(args["SERVERHASH"])
+		serverNonce, err2 := hex.DecodeString(args["SERVERNONCE"])
+		if err1 != nil || err2 != nil || len(serverNonce) == 0 {
+			return fmt.Errorf("malformed AUTHCHALLENGE reply %q", lines[0])
+		}
+		want := torCookieHash(torServerHashKey, cookie, clientNonce,
+			serverNonce)
+		if !hmac.Equal(serverHash, want) {
+			return errors.New("the control port doesn't know the " +
+				"authentication cookie")
+		}
+		auth = "AUTHENTICATE " + hex.EncodeToString(torCookieHash(
+			torClientHashKey, cookie, clientNonce, serverNonce))
+
+	case methods["COOKIE"] && cookieFile != "":
+		cookie, err := ioutil.ReadFile(cookieFile)
+		if err != nil {
+			return fmt.Errorf("unable to read the authentication "+
+				"cookie: %v", err)
+		}
+		auth = "AUTHENTICATE " + hex.EncodeToString(cookie)
+
+	default:
+		return errors.New("no supported authentication method -- " +
+			"specify the password of the control port")
+	}
+
+	if _, err := c.command(auth); err != nil {
+		return fmt.Errorf("AUTHENTICATE: %v", err)
+	}
+	return nil
+}
+
+// addOnion creates the onion service with the ports and the private key of the
+// key file, or with a new key saved to the key file when it doesn't exist, and
+// returns the host name of the service.
+func (c *torControlConn) addOnion(keyFile string, ports []torOnionPort) (string, error) {
+	key, err := ioutil.ReadFile(keyFile)
+	switch {
+	case os.IsNotExist(err):
+		key = []byte("NEW:ED25519-V3")
+	case err != nil:
+		return "", err
+	default:
+		key = bytes.TrimSpace(key)
+	}
+
+	cmd := "ADD_ONION " + string(key)
+	for _, port := range ports {
+		cmd += fmt.Sprintf(" Port=%d,%s", port.Virtual, port.Target)
+	}
+	lines, err := c.command(cmd)
+	if err != nil {
+		return "", fmt.Errorf("ADD_ONION: %v", err)
+	}
+
+	var serviceID, privateKey string
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "ServiceID="):
+			serviceID = strings.TrimPrefix(line, "ServiceID=")
+		case strings.HasPrefix(line, "PrivateKey="):
+			privateKey = strings.TrimPrefix(line, "PrivateKey=")
+		}
+	}
+	if serviceID == "" {
+		return "", errors.New("ADD_ONION: no service ID in the reply")
+	}
+
+	if privateKey != "" {
+		err := os.MkdirAll(filepath.Dir(keyFile), 0700)
+		if err == nil {
+			err = ioutil.WriteFile(keyFile, []byte(privateKey+"\n"), 0600)
+		}
+		if err != nil {
+			return "", fmt.Errorf("unable to save the onion service "+
+				"key: %v", err)
+		}
+		srvrLog.Infof("Saved the key of the new onion service to %s",
+			keyFile)
+	}
+	return serviceID + ".onion", nil
+}
+
+// torCookieHash returns the HMAC of the safe cookie authentication with the
+// key.
+func torCookieHash(key string, cookie, clientNonce, serverNonce []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(cookie)
+	mac.Write(clientNonce)
+	mac.Write(serverNonce)
+	return mac.Sum(nil)
+}
+
+// quoteTorString returns the quoted string of the control protocol.
+func quoteTorString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// parseTorReplyArgs parses the space separated KEY=VALUE arguments of a reply
+// line, whose values may be quoted strings.
+func parseTorReplyArgs(line string) map[string]string {
+	args := make(map[string]string)
+	for line != "" {
+		line = strings.TrimLeft(line, " ")
+		eq := strings.IndexByte(line, '=')
+		if eq < 0 {
+			break
+		}
+		key := line[:eq]
+		line = line[eq+1:]
+
+		var value strings.Builder
+		if strings.HasPrefix(line, `"`) {
+			i := 1
+			for ; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' && i+1 < len(line) {
+					i++
+				}
+				value.WriteByte(line[i])
+			}
+			if i < len(line) {
+				i++
+			}
+			line = line[i:]
+		} else {
+			end := strings.IndexByte(line, ' ')
+			if end < 0 {
+				end = len(line)
+			}
+			value.WriteString(line[:end])
+			line = line[end:]
+		}
+		args[key] = value.String()
+	}
+	return args
+}
+
+// torOnionTarget returns the local address the connections to the onion
+// service are forwarded to for the listeners, which is the first TCP listener,
+// on the loopback interface when it listens on all of them.
+func torOnionTarget(listeners []string) (string, bool) {
+	for _, addr := range listeners {
+		if isUnixListener(addr) {
+			continue
+		}
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			continue
+		}
+		switch {
+		case host == "" || host == "0.0.0.0":
+			host = "127.0.0.1"
+		case host == "::":
+			host = "::1"
+		}
+		return net.JoinHostPort(host, port), true
+	}
+	return "", false
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btclog"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTorControl serves the control protocol on a pipe with the handler, which
+// returns the reply to each command.  It returns the connection of the
+// controller and the commands received.
+func fakeTorControl(t *testing.T, handler func(cmd string) string) (*torControlConn, *[]string) {
+	client, server := net.Pipe()
+	t.Cleanup(func() { client.Close() })
+
+	var cmds []string
+	go func() {
+		defer server.Close()
+		reader := bufio.NewReader(server)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.TrimRight(line, "\r\n")
+			cmds = append(cmds, cmd)
+			if _, err := server.Write([]byte(handler(cmd))); err != nil {
+				return
+			}
+		}
+	}()
+	return newTorControlConn(client), &cmds
+}
+
+func TestTorControlSafeCookie(t *testing.T) {
+
+	r := require.New(t)
+
+	cookie := []byte("0123456789abcdef0123456789abcdef")
+	cookieFile := filepath.Join(t.TempDir(), "control_auth_cookie")
+	r.NoError(ioutil.WriteFile(cookieFile, cookie, 0600))
+	serverNonce := []byte("server nonce")
+
+	var clientNonce []byte
+	c, cmds := fakeTorControl(t, func(cmd string) string {
+		switch {
+		case cmd == "PROTOCOLINFO 1":
+			return "250-PROTOCOLINFO 1\r\n" +
+				"250-AUTH METHODS=COOKIE,SAFECOOKIE COOKIEFILE=\"" +
+				cookieFile + "\"\r\n" +
+				"250-VERSION Tor=\"0.4.7.10\"\r\n250 OK\r\n"
+		case strings.HasPrefix(cmd, "AUTHCHALLENGE SAFECOOKIE "):
+			clientNonce, _ = hex.DecodeString(strings.TrimPrefix(cmd,
+				"AUTHCHALLENGE SAFECOOKIE "))
+			hash := torCookieHash(torServerHashKey, cookie, clientNonce,
+				serverNonce)
+			return fmt.Sprintf("250 AUTHCHALLENGE SERVERHASH=%x "+
+				"SERVERNONCE=%x\r\n", hash, serverNonce)
+		case cmd == "AUTHENTICATE "+hex.EncodeToString(torCookieHash(
+			torClientHashKey, cookie, clientNonce, serverNonce)):
+			return "250 OK\r\n"
+		}
+		return "515 Authentication failed\r\n"
+	})
+
+	r.NoError(c.authenticate(""))
+	r.Len(*cmds, 3)
+	r.Len(clientNonce, 32)
+}
+
+func TestTorControlPassword(t *testing.T) {
+
+	r := require.New(t)
+
+	c, cmds := fakeTorControl(t, func(cmd string) string {
+		switch cmd {
+		case "PROTOCOLINFO 1":
+			return "250-PROTOCOLINFO 1\r\n" +
+				"250-AUTH METHODS=HASHEDPASSWORD\r\n250 OK\r\n"
+		case `AUTHENTICATE "pa\"ss\\"`:
+			return "250 OK\r\n"
+		}
+		return "515 Authentication failed\r\n"
+	})
+	r.NoError(c.authenticate(`pa"ss\`))
+	r.Len(*cmds, 2)
+
+	err := c.authenticate("wrong")
+	r.EqualError(err, "AUTHENTICATE: 515 Authentication failed")
+
+	// The cookie can't be used without its method.
+	err = c.authenticate("")
+	r.Error(err)
+}
+
+func TestTorControlAddOnion(t *testing.T) {
+
+	r := require.New(t)
+
+	defer func(log btclog.Logger) { srvrLog = log }(srvrLog)
+	srvrLog = btclog.Disabled
+
+	keyFile := filepath.Join(t.TempDir(), torOnionKeyFilename)
+	ports := []torOnionPort{
+		{Virtual: 9246, Target: "127.0.0.1:9246"},
+		{Virtual: 9245, Target: "[::1]:19245"},
+	}
+
+	c, cmds := fakeTorControl(t, func(cmd string) string {
+		switch {
+		case strings.HasPrefix(cmd, "ADD_ONION NEW:ED25519-V3 "):
+			return "250-ServiceID=abcdef\r\n" +
+				"250-PrivateKey=ED25519-V3:c2VjcmV0\r\n250 OK\r\n"
+		case strings.HasPrefix(cmd, "ADD_ONION ED25519-V3:c2VjcmV0 "):
+			return "250-ServiceID=abcdef\r\n250 OK\r\n"
+		}
+		return "512 Invalid argument\r\n"
+	})
+
+	// The key of the new service is saved, and reused afterwards.
+	onion, err := c.addOnion(keyFile, ports)
+	r.NoError(err)
+	r.Equal("abcdef.onion", onion)
+	key, err := ioutil.ReadFile(keyFile)
+	r.NoError(err)
+	r.Equal("ED25519-V3:c2VjcmV0\n", string(key))
+
+	onion, err = c.addOnion(keyFile, ports)
+	r.NoError(err)
+	r.Equal("abcdef.onion", onion)
+
+	r.Equal([]string{
+		"ADD_ONION NEW:ED25519-V3 Port=9246,127.0.0.1:9246 Port=9245,[::1]:19245",
+		"ADD_ONION ED25519-V3:c2VjcmV0 Port=9246,127.0.0.1:9246 Port=9245,[::1]:19245",
+	}, *cmds)
+
+	r.NoError(ioutil.WriteFile(keyFile, []byte("bad"), 0600))
+	_, err = c.addOnion(keyFile, ports)
+	r.EqualError(err, "ADD_ONION: 512 Invalid argument")
+}
+
+func TestTorControlReply(t *testing.T) {
+
+	r := require.New(t)
+
+	c, _ := fakeTorControl(t, func(cmd string) string {
+		return "250+info/names=\r\nconfig/names -- List of names\r\n" +
+			"..dotted\r\n.\r\n250 OK\r\n"
+	})
+	lines, err := c.command("GETINFO info/names")
+	r.NoError(err)
+	r.Equal([]string{"info/names=", "config/names -- List of names",
+		".dotted", "OK"}, lines)
+}
+
+func TestParseTorReplyArgs(t *testing.T) {
+
+	r := require.New(t)
+
+	args := parseTorReplyArgs(`METHODS=COOKIE,SAFECOOKIE COOKIEFILE="/var/run/tor/a \"b\"\\c"`)
+	r.Equal(map[string]string{
+		"METHODS":    "COOKIE,SAFECOOKIE",
+		"COOKIEFILE": `/var/run/tor/a "b"\c`,
+	}, args)
+
+	r.Empty(parseTorReplyArgs(""))
+	r.Equal(map[string]string{"A": ""}, parseTorReplyArgs(`A="`))
+}
+
+func TestTorOnionTarget(t *testing.T) {
+
+	r := require.New(t)
+
+	tests := []struct {
+		listeners []string
+		target    string
+		ok        bool
+	}{
+		{[]string{":9246"}, "127.0.0.1:9246", true},
+		{[]string{"0.0.0.0:9246"}, "127.0.0.1:9246", true},
+		{[]string{"[::]:9246"}, "[::1]:9246", true},
+		{[]string{"10.0.0.1:19246", ":9246"}, "10.0.0.1:19246", true},
+		{[]string{"unix:/tmp/rpc.sock", "127.0.0.1:9245"}, "127.0.0.1:9245", true},
+		{[]string{"unix:/tmp/rpc.sock"}, "", false},
+		{nil, "", false},
+	}
+	for _, test := range tests {
+		target, ok := torOnionTarget(test.listeners)
+		r.Equal(test.ok, ok, test.listeners)
+		r.Equal(test.target, target, test.listeners)
+	}
+}
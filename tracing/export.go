@@ -0,0 +1,289 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// spanBuffer is the number of ended spans waiting to be exported, past
+	// which spans are dropped.
+	spanBuffer = 4096
+
+	// exportBatchSize is the max number of spans exported at once.
+	exportBatchSize = 512
+
+	// exportInterval is the max time spans wait to be exported.
+	exportInterval = 5 * time.Second
+
+	// exportTimeout is the time allowed to the collector to accept spans.
+	exportTimeout = 10 * time.Second
+)
+
+// Config is the configuration of the export of the traces.
+type Config struct {
+	// Endpoint is the URL of the OTLP/HTTP traces endpoint of the
+	// collector, such as http://localhost:4318/v1/traces.
+	Endpoint string
+
+	// ServiceName is the name of the traced service.
+	ServiceName string
+
+	// Attributes are additional attributes of the traced service.
+	Attributes []Attribute
+}
+
+// exporter posts the ended spans in batches to the collector.
+type exporter struct {
+	cfg     Config
+	client  *http.Client
+	spans   chan *Span
+	dropped uint64 // atomic
+	quit    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// Enable starts exporting the spans according to the passed configuration.
+func Enable(cfg *Config) error {
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme of OTLP endpoint %q",
+			cfg.Endpoint)
+	}
+
+	exp := &exporter{
+		cfg:    *cfg,
+		client: &http.Client{Timeout: exportTimeout},
+		spans:  make(chan *Span, spanBuffer),
+		quit:   make(chan struct{}),
+	}
+	if !current.CompareAndSwap(nil, exp) {
+		return fmt.Errorf("tracing is already enabled")
+	}
+	exp.wg.Add(1)
+	go exp.exportHandler()
+	log.Infof("Exporting traces to %s", cfg.Endpoint)
+	return nil
+}
+
+// Disable stops exporting the spans, once those already ended are exported.
+func Disable() {
+	exp := current.Swap(nil)
+	if exp == nil {
+		return
+	}
+	close(exp.quit)
+	exp.wg.Wait()
+}
+
+// queue queues an ended span for export, unless too many are waiting already.
+func (e *exporter) queue(s *Span) {
+	select {
+	case e.spans <- s:
+	default:
+		atomic.AddUint64(&e.dropped, 1)
+	}
+}
+
+// exportHandler exports the queued spans once enough of them are waiting, or
+// they waited long enough.
+//
+// This must be run as a goroutine.
+func (e *exporter) exportHandler() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, exportBatchSize)
+	for {
+		select {
+		case s := <-e.spans:
+			batch = append(batch, s)
+			if len(batch) < exportBatchSize {
+				continue
+			}
+
+		case <-ticker.C:
+
+		case <-e.quit:
+			// Export the spans ended before tracing was disabled.
+			for len(e.spans) > 0 {
+				batch = append(batch, <-e.spans)
+				if len(batch) == exportBatchSize {
+					e.export(batch)
+					batch = batch[:0]
+				}
+			}
+			e.export(batch)
+			return
+		}
+
+		e.export(batch)
+		batch = batch[:0]
+	}
+}
+
+// export posts a batch of spans to the collector.
+func (e *exporter) export(batch []*Span) {
+	if dropped := atomic.SwapUint64(&e.dropped, 0); dropped != 0 {
+		log.Warnf("Dropped %d spans waiting to be exported", dropped)
+	}
+	if len(batch) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(e.marshalTraces(batch))
+	if err != nil {
+		log.Errorf("Failed to marshal spans: %v", err)
+		return
+	}
+	resp, err := e.client.Post(e.cfg.Endpoint, "application/json",
+		bytes.NewReader(body))
+	if err != nil {
+		log.Warnf("Failed to export %d spans: %v", len(batch), err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		log.Warnf("Failed to export %d spans: %s", len(batch),
+			resp.Status)
+		return
+	}
+	log.Tracef("Exported %d spans", len(batch))
+}
+
+// The types below model the JSON encoding of the OTLP trace export requests.
+type (
+	otlpTraces struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		Name              string         `json:"name"`
+		Kind              int            `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Status            otlpStatus     `json:"status"`
+	}
+
+	otlpKeyValue struct {
+		Key   string       `json:"key"`
+		Value otlpAnyValue `json:"value"`
+	}
+
+	otlpAnyValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"`
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+	}
+
+	otlpStatus struct {
+		Message string `json:"message,omitempty"`
+		Code    int    `json:"code,omitempty"`
+	}
+)
+
+// OTLP span kinds and status codes.
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusCodeError  = 2
+)
+
+// marshalTraces returns the export request of a batch of spans.
+func (e *exporter) marshalTraces(batch []*Span) *otlpTraces {
+	spans := make([]otlpSpan, 0, len(batch))
+	for _, s := range batch {
+		span := otlpSpan{
+			TraceID:           hex.EncodeToString(s.traceID[:]),
+			SpanID:            hex.EncodeToString(s.id[:]),
+			Name:              s.name,
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Attributes:        marshalAttributes(s.attrs),
+		}
+		if s.parentID != (SpanID{}) {
+			span.ParentSpanID = hex.EncodeToString(s.parentID[:])
+		}
+		if s.err != nil {
+			span.Status = otlpStatus{
+				Message: s.err.Error(),
+				Code:    otlpStatusCodeError,
+			}
+		}
+		spans = append(spans, span)
+	}
+
+	attrs := append([]Attribute{{"service.name", e.cfg.ServiceName}},
+		e.cfg.Attributes...)
+	return &otlpTraces{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{Attributes: marshalAttributes(attrs)},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: "github.com/lbryio/lbcd/tracing"},
+				Spans: spans,
+			}},
+		}},
+	}
+}
+
+func marshalAttributes(attrs []Attribute) []otlpKeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	kvs := make([]otlpKeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		var v otlpAnyValue
+		switch value := attr.Value.(type) {
+		case string:
+			v.StringValue = &value
+		case bool:
+			v.BoolValue = &value
+		case float64:
+			v.DoubleValue = &value
+		case int, int32, int64, uint32:
+			i := fmt.Sprint(value)
+			v.IntValue = &i
+		default:
+			str := fmt.Sprint(value)
+			v.StringValue = &str
+		}
+		kvs = append(kvs, otlpKeyValue{Key: attr.Key, Value: v})
+	}
+	return kvs
+}
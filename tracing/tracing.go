@@ -0,0 +1,168 @@
+// Package tracing records traces of the processing of blocks and RPC requests,
+// and exports them to an OpenTelemetry collector over OTLP/HTTP.
+//
+// All of the spans of the processing of a block belong to a trace derived from
+// the block hash, so that the packages involved can record them without
+// passing spans around.  While tracing is disabled, the spans are nil and all
+// of their methods do nothing.
+package tracing
+
+import (
+	"crypto/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+)
+
+// TraceID identifies a trace, which is made of the spans of an operation.
+type TraceID [16]byte
+
+// SpanID identifies a span of a trace.
+type SpanID [8]byte
+
+// Attribute is a key/value pair describing a span.  Values are strings,
+// integers, floats or booleans; other values are exported formatted as
+// strings.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Span is a timed operation of a trace.
+type Span struct {
+	exp      *exporter
+	traceID  TraceID
+	id       SpanID
+	parentID SpanID
+	name     string
+	start    time.Time
+	end      time.Time
+	attrs    []Attribute
+	err      error
+}
+
+// current is the exporter of the spans while tracing is enabled.
+var current atomic.Pointer[exporter]
+
+// Enabled returns whether tracing is enabled.
+func Enabled() bool {
+	return current.Load() != nil
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	rand.Read(id[:])
+	return id
+}
+
+// StartSpan starts the root span of a new trace.
+func StartSpan(name string) *Span {
+	exp := current.Load()
+	if exp == nil {
+		return nil
+	}
+	var traceID TraceID
+	rand.Read(traceID[:])
+	return &Span{
+		exp:     exp,
+		traceID: traceID,
+		id:      newSpanID(),
+		name:    name,
+		start:   time.Now(),
+	}
+}
+
+// blockIDs returns the ids of the trace of the processing of a block and of its
+// root span.  The hash is stored least significant byte first, so its leading
+// bytes are never all zeros.
+func blockIDs(hash *chainhash.Hash) (TraceID, SpanID) {
+	var traceID TraceID
+	var id SpanID
+	copy(traceID[:], hash[:16])
+	copy(id[:], hash[16:24])
+	return traceID, id
+}
+
+// StartBlockSpan starts the root span of the processing of a block at the
+// passed time, such as when the block was requested.
+func StartBlockSpan(hash *chainhash.Hash, name string, start time.Time) *Span {
+	exp := current.Load()
+	if exp == nil {
+		return nil
+	}
+	traceID, id := blockIDs(hash)
+	return &Span{
+		exp:     exp,
+		traceID: traceID,
+		id:      id,
+		name:    name,
+		start:   start,
+		attrs:   []Attribute{{"block.hash", hash.String()}},
+	}
+}
+
+// StartBlockChild starts a span of the processing of a block, as a child of
+// its root span.
+func StartBlockChild(hash *chainhash.Hash, name string) *Span {
+	exp := current.Load()
+	if exp == nil {
+		return nil
+	}
+	traceID, parentID := blockIDs(hash)
+	return &Span{
+		exp:      exp,
+		traceID:  traceID,
+		id:       newSpanID(),
+		parentID: parentID,
+		name:     name,
+		start:    time.Now(),
+	}
+}
+
+// Child starts a child span of s.
+func (s *Span) Child(name string) *Span {
+	return s.ChildAt(name, time.Now())
+}
+
+// ChildAt starts a child span of s at the passed time, for operations which
+// were timed before being traced.
+func (s *Span) ChildAt(name string, start time.Time) *Span {
+	if s == nil {
+		return nil
+	}
+	return &Span{
+		exp:      s.exp,
+		traceID:  s.traceID,
+		id:       newSpanID(),
+		parentID: s.id,
+		name:     name,
+		start:    start,
+	}
+}
+
+// SetAttribute adds the key/value pair to the attributes of the span.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.attrs = append(s.attrs, Attribute{key, value})
+}
+
+// SetError marks the span as failed with the passed error, unless it is nil.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.err = err
+}
+
+// End ends the span and queues it for export.  The span must not be used
+// afterwards.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.end = time.Now()
+	s.exp.queue(s)
+}
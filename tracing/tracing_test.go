@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisabled(t *testing.T) {
+
+	r := require.New(t)
+
+	r.False(Enabled())
+	span := StartSpan("rpc")
+	r.Nil(span)
+	child := span.Child("child")
+	child.SetAttribute("key", "value")
+	child.SetError(errors.New("failed"))
+	child.End()
+	span.End()
+	r.Nil(StartBlockChild(&chainhash.Hash{}, "validate"))
+}
+
+func TestExport(t *testing.T) {
+
+	r := require.New(t)
+
+	var mtx sync.Mutex
+	var traces []otlpTraces
+	collector := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			var batch otlpTraces
+			if err := json.NewDecoder(req.Body).Decode(&batch); err == nil {
+				mtx.Lock()
+				traces = append(traces, batch)
+				mtx.Unlock()
+			}
+		}))
+	defer collector.Close()
+
+	r.Error(Enable(&Config{Endpoint: "localhost:4318"}))
+	r.NoError(Enable(&Config{Endpoint: collector.URL, ServiceName: "lbcd"}))
+	r.Error(Enable(&Config{Endpoint: collector.URL}))
+	r.True(Enabled())
+
+	hash, err := chainhash.NewHashFromStr(
+		"00000000000000001f2a6e846e3d0fb7a4c2b9c6d4c1e0f8b2a31c4d5e6f7081")
+	r.NoError(err)
+	block := StartBlockSpan(hash, "block", time.Now().Add(-time.Second))
+	block.ChildAt("download", time.Now().Add(-time.Second)).End()
+	validate := StartBlockChild(hash, "validate")
+	validate.SetAttribute("height", int32(1000))
+	validate.SetError(errors.New("bad block"))
+	validate.End()
+	block.End()
+
+	// The spans are exported once tracing is disabled.
+	Disable()
+	r.False(Enabled())
+	r.Len(traces, 1)
+	r.Equal("service.name", traces[0].ResourceSpans[0].Resource.Attributes[0].Key)
+	spans := traces[0].ResourceSpans[0].ScopeSpans[0].Spans
+	r.Len(spans, 3)
+
+	traceID := hex.EncodeToString(hash[:16])
+	root := spans[2]
+	r.Equal("block", root.Name)
+	r.Equal(hex.EncodeToString(hash[16:24]), root.SpanID)
+	r.Empty(root.ParentSpanID)
+	r.Equal(hash.String(), *root.Attributes[0].Value.StringValue)
+	for _, span := range spans {
+		r.Equal(traceID, span.TraceID)
+	}
+	r.Equal("download", spans[0].Name)
+	r.Equal(root.SpanID, spans[0].ParentSpanID)
+	r.Equal("validate", spans[1].Name)
+	r.Equal(root.SpanID, spans[1].ParentSpanID)
+	r.Equal("1000", *spans[1].Attributes[0].Value.IntValue)
+	r.Equal(otlpStatus{Message: "bad block", Code: otlpStatusCodeError},
+		spans[1].Status)
+}
@@ -4,13 +4,15 @@ import (
 	"bytes"
 	"fmt"
 	"unicode/utf8"
+
+	"github.com/lbryio/lbcd/claimtrie/param"
 )
 
 const (
 	// MaxClaimScriptSize is the max claim script size in bytes, not including the script pubkey part of the script.
 	MaxClaimScriptSize = 8192
 
-	// MaxClaimNameSize is the max claim name size in bytes, for all claim trie transactions.
+	// MaxClaimNameSize is the default max claim name size in bytes, for all claim trie transactions.
 	MaxClaimNameSize = 255
 
 	ClaimIDLength = 160 / 8
@@ -72,6 +74,15 @@ type ClaimScript struct {
 	Size    int
 }
 
+// ClaimNameSizeLimit returns the max claim name size in bytes of the active
+// claimtrie parameters, which defaults to MaxClaimNameSize.
+func ClaimNameSizeLimit() int {
+	if n := param.ActiveParams.MaxNameLength; n > 0 {
+		return n
+	}
+	return MaxClaimNameSize
+}
+
 // ExtractClaimScript exctracts the claim script from the script if it has one.
 // The returned ClaimScript is invalidated if the given script is modified.
 func ExtractClaimScript(script []byte) (*ClaimScript, error) {
@@ -84,12 +95,13 @@ func ExtractClaimScript(script []byte) (*ClaimScript, error) {
 	}
 
 	cs.Opcode = tokenizer.Opcode()
+	maxNameSize := ClaimNameSizeLimit()
 
 	switch tokenizer.Opcode() {
 	case OP_CLAIMNAME:
 		// OP_CLAIMNAME <Name> <Value> OP_2DROP OP_DROP <P2PKH>
-		if !tokenizer.Next() || len(tokenizer.Data()) > MaxClaimNameSize {
-			str := fmt.Sprintf("name size %d exceeds limit %d", len(tokenizer.data), MaxClaimNameSize)
+		if !tokenizer.Next() || len(tokenizer.Data()) > maxNameSize {
+			str := fmt.Sprintf("name size %d exceeds limit %d", len(tokenizer.data), maxNameSize)
 			return nil, claimScriptError(ErrInvalidClaimNameScript, str)
 		}
 		cs.Name = tokenizer.data
@@ -115,8 +127,8 @@ func ExtractClaimScript(script []byte) (*ClaimScript, error) {
 	case OP_SUPPORTCLAIM:
 		// OP_SUPPORTCLAIM <Name> <ClaimID>         OP_2DROP OP_DROP <P2PKH>
 		// OP_SUPPORTCLAIM <Name> <ClaimID> <Value> OP_2DROP OP_2DROP <P2PKH>
-		if !tokenizer.Next() || len(tokenizer.Data()) > MaxClaimNameSize {
-			str := fmt.Sprintf("name size %d exceeds limit %d", len(tokenizer.data), MaxClaimNameSize)
+		if !tokenizer.Next() || len(tokenizer.Data()) > maxNameSize {
+			str := fmt.Sprintf("name size %d exceeds limit %d", len(tokenizer.data), maxNameSize)
 			return nil, claimScriptError(ErrInvalidClaimSupportScript, str)
 		}
 		cs.Name = tokenizer.data
@@ -160,8 +172,8 @@ func ExtractClaimScript(script []byte) (*ClaimScript, error) {
 
 	case OP_UPDATECLAIM:
 		// OP_UPDATECLAIM <Name> <ClaimID> <Value> OP_2DROP OP_2DROP <P2PKH>
-		if !tokenizer.Next() || len(tokenizer.Data()) > MaxClaimNameSize {
-			str := fmt.Sprintf("name size %d exceeds limit %d", len(tokenizer.data), MaxClaimNameSize)
+		if !tokenizer.Next() || len(tokenizer.Data()) > maxNameSize {
+			str := fmt.Sprintf("name size %d exceeds limit %d", len(tokenizer.data), maxNameSize)
 			return nil, claimScriptError(ErrInvalidClaimUpdateScript, str)
 		}
 		cs.Name = tokenizer.data
@@ -0,0 +1,207 @@
+// Package claimscript provides typed construction and parsing of the claim
+// scripts (claim name, support and update) which prefix the public key
+// scripts of claimtrie transaction outputs.
+package claimscript
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/lbryio/lbcd/claimtrie/change"
+	"github.com/lbryio/lbcd/claimtrie/normalization"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/wire"
+)
+
+// Type identifies the kind of a claim script.
+type Type byte
+
+const (
+	// TypeClaimName identifies an OP_CLAIMNAME script.
+	TypeClaimName Type = txscript.OP_CLAIMNAME
+
+	// TypeSupportClaim identifies an OP_SUPPORTCLAIM script.
+	TypeSupportClaim Type = txscript.OP_SUPPORTCLAIM
+
+	// TypeUpdateClaim identifies an OP_UPDATECLAIM script.
+	TypeUpdateClaim Type = txscript.OP_UPDATECLAIM
+)
+
+// String returns the name of the claim script type as used by the RPC server.
+func (t Type) String() string {
+	switch t {
+	case TypeClaimName:
+		return "claim"
+	case TypeSupportClaim:
+		return "support"
+	case TypeUpdateClaim:
+		return "update"
+	default:
+		return fmt.Sprintf("unknown (%d)", byte(t))
+	}
+}
+
+// illegalChars are the characters a claim name may not contain since the
+// claim name soft fork.
+const illegalChars = "=&#:$%?/;\\\b\n\t\r\x00"
+
+// Script is a parsed claim script.
+type Script struct {
+	Type Type
+
+	// Name is the claim name as it appears in the script.
+	Name []byte
+
+	// ClaimID is the claim referenced by a support or update.  It is the
+	// zero value for claim name scripts, whose claim ID derives from the
+	// outpoint; see ClaimIDFor.
+	ClaimID change.ClaimID
+
+	// Value is the claim value.  The value of a support is usually empty.
+	Value []byte
+
+	// PkScript is the public key script which follows the claim prefix.
+	PkScript []byte
+}
+
+// NormalizedName returns the normalized form of the claim name.
+func (s *Script) NormalizedName() []byte {
+	return normalization.Normalize(s.Name)
+}
+
+// ClaimIDFor returns the ID of the claim affected by the script of the output
+// at the given outpoint.
+func (s *Script) ClaimIDFor(op wire.OutPoint) change.ClaimID {
+	if s.Type == TypeClaimName {
+		return change.NewClaimID(op)
+	}
+	return s.ClaimID
+}
+
+// IsClaimScript returns whether the script is prefixed with a claim script.
+func IsClaimScript(script []byte) bool {
+	_, err := txscript.ExtractClaimScript(script)
+	return err == nil
+}
+
+// Parse parses the claim script prefixed to the passed public key script.  An
+// error with the txscript.ErrNotClaimScript code is returned when the script
+// has no claim prefix.  The returned Script references the passed script, so
+// it is invalidated if the script is modified.
+func Parse(script []byte) (*Script, error) {
+	cs, err := txscript.ExtractClaimScript(script)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Script{
+		Type:     Type(cs.Opcode),
+		Name:     cs.Name,
+		Value:    cs.Value,
+		PkScript: script[cs.Size:],
+	}
+	copy(s.ClaimID[:], cs.ClaimID)
+	return s, nil
+}
+
+// ParseClaimID parses a claim ID from its hex encoding, as used by the RPC
+// server and displayed by explorers, which has the reverse byte order of the
+// claim ID in claim scripts.
+func ParseClaimID(s string) (change.ClaimID, error) {
+	if len(s) != hex.EncodedLen(change.ClaimIDSize) {
+		return change.ClaimID{}, fmt.Errorf("claim ID %q is not %d hex "+
+			"characters", s, hex.EncodedLen(change.ClaimIDSize))
+	}
+	return change.NewIDFromString(s)
+}
+
+// ValidateName returns an error if the claim name is not allowed in a new
+// claim script: it has to fit the size limit, be valid UTF-8 and not contain
+// any of the characters prohibited since the claim name soft fork.
+func ValidateName(name string) error {
+	if maxNameSize := txscript.ClaimNameSizeLimit(); len(name) > maxNameSize {
+		return fmt.Errorf("name size %d exceeds limit %d", len(name),
+			maxNameSize)
+	}
+	if !utf8.ValidString(name) {
+		return fmt.Errorf("name is not valid UTF-8")
+	}
+	if bytes.ContainsAny([]byte(name), illegalChars) {
+		return fmt.Errorf("name has illegal chars; it should not "+
+			"contain any of these: %q", illegalChars)
+	}
+	return nil
+}
+
+// ClaimName returns the public key script of a new claim for the name with the
+// given value, paying to pkScript.
+func ClaimName(name string, value []byte, pkScript []byte) ([]byte, error) {
+	b := txscript.NewScriptBuilder().AddOp(txscript.OP_CLAIMNAME).
+		AddData([]byte(name)).AddData(value).
+		AddOp(txscript.OP_2DROP).AddOp(txscript.OP_DROP)
+	return finish(b, name, pkScript)
+}
+
+// SupportClaim returns the public key script of a support for the claim,
+// paying to pkScript.
+func SupportClaim(name string, claimID change.ClaimID, pkScript []byte) ([]byte, error) {
+	b := txscript.NewScriptBuilder().AddOp(txscript.OP_SUPPORTCLAIM).
+		AddData([]byte(name)).AddData(claimID[:]).
+		AddOp(txscript.OP_2DROP).AddOp(txscript.OP_DROP)
+	return finish(b, name, pkScript)
+}
+
+// SupportClaimWithValue returns the public key script of a support for the
+// claim which also carries a value, paying to pkScript.  The value must not be
+// empty.
+func SupportClaimWithValue(name string, claimID change.ClaimID, value []byte,
+	pkScript []byte) ([]byte, error) {
+
+	if len(value) == 0 {
+		return nil, fmt.Errorf("support value is empty")
+	}
+	b := txscript.NewScriptBuilder().AddOp(txscript.OP_SUPPORTCLAIM).
+		AddData([]byte(name)).AddData(claimID[:]).AddData(value).
+		AddOp(txscript.OP_2DROP).AddOp(txscript.OP_2DROP)
+	return finish(b, name, pkScript)
+}
+
+// UpdateClaim returns the public key script of an update of the claim to the
+// given value, paying to pkScript.
+func UpdateClaim(name string, claimID change.ClaimID, value []byte,
+	pkScript []byte) ([]byte, error) {
+
+	b := txscript.NewScriptBuilder().AddOp(txscript.OP_UPDATECLAIM).
+		AddData([]byte(name)).AddData(claimID[:]).AddData(value).
+		AddOp(txscript.OP_2DROP).AddOp(txscript.OP_2DROP)
+	return finish(b, name, pkScript)
+}
+
+// finish validates the name and claim prefix built by b, and appends the
+// pkScript to it.
+func finish(b *txscript.ScriptBuilder, name string, pkScript []byte) ([]byte, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+	if len(pkScript) == 0 {
+		return nil, fmt.Errorf("missing public key script")
+	}
+	if IsClaimScript(pkScript) {
+		return nil, fmt.Errorf("public key script already has a claim prefix")
+	}
+
+	prefix, err := b.Script()
+	if err != nil {
+		return nil, err
+	}
+	if len(prefix) > txscript.MaxClaimScriptSize {
+		return nil, fmt.Errorf("script size %d exceeds limit %d",
+			len(prefix), txscript.MaxClaimScriptSize)
+	}
+
+	script := make([]byte, 0, len(prefix)+len(pkScript))
+	script = append(script, prefix...)
+	return append(script, pkScript...), nil
+}
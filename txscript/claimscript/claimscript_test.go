@@ -0,0 +1,127 @@
+package claimscript
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lbryio/lbcd/claimtrie/change"
+	"github.com/lbryio/lbcd/claimtrie/param"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/wire"
+	"github.com/stretchr/testify/require"
+)
+
+var p2pkh = []byte{
+	txscript.OP_DUP, txscript.OP_HASH160, txscript.OP_DATA_20,
+	1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20,
+	txscript.OP_EQUALVERIFY, txscript.OP_CHECKSIG,
+}
+
+func TestClaimNameRoundTrip(t *testing.T) {
+
+	r := require.New(t)
+
+	script, err := ClaimName("tester", []byte("value"), p2pkh)
+	r.NoError(err)
+	r.True(IsClaimScript(script))
+
+	cs, err := Parse(script)
+	r.NoError(err)
+	r.Equal(TypeClaimName, cs.Type)
+	r.Equal([]byte("tester"), cs.Name)
+	r.Equal([]byte("value"), cs.Value)
+	r.Equal(p2pkh, cs.PkScript)
+	r.Equal(p2pkh, txscript.StripClaimScriptPrefix(script))
+
+	op := wire.OutPoint{Index: 2}
+	r.Equal(change.NewClaimID(op), cs.ClaimIDFor(op))
+}
+
+func TestSupportAndUpdateRoundTrip(t *testing.T) {
+
+	r := require.New(t)
+
+	id := change.NewClaimID(wire.OutPoint{Index: 1})
+
+	script, err := SupportClaim("tester", id, p2pkh)
+	r.NoError(err)
+	cs, err := Parse(script)
+	r.NoError(err)
+	r.Equal(TypeSupportClaim, cs.Type)
+	r.Equal(id, cs.ClaimIDFor(wire.OutPoint{}))
+	r.Empty(cs.Value)
+	r.Equal(p2pkh, cs.PkScript)
+
+	script, err = SupportClaimWithValue("tester", id, []byte("value"), p2pkh)
+	r.NoError(err)
+	cs, err = Parse(script)
+	r.NoError(err)
+	r.Equal(TypeSupportClaim, cs.Type)
+	r.Equal([]byte("value"), cs.Value)
+
+	_, err = SupportClaimWithValue("tester", id, nil, p2pkh)
+	r.Error(err)
+
+	script, err = UpdateClaim("tester", id, []byte("value"), p2pkh)
+	r.NoError(err)
+	cs, err = Parse(script)
+	r.NoError(err)
+	r.Equal(TypeUpdateClaim, cs.Type)
+	r.Equal(id, cs.ClaimID)
+	r.Equal([]byte("value"), cs.Value)
+	r.Equal(p2pkh, cs.PkScript)
+}
+
+func TestBuilderValidation(t *testing.T) {
+
+	r := require.New(t)
+
+	_, err := ClaimName("a/b", nil, p2pkh)
+	r.Error(err)
+
+	_, err = ClaimName("\xff", nil, p2pkh)
+	r.Error(err)
+
+	_, err = ClaimName(strings.Repeat("a", txscript.MaxClaimNameSize+1), nil, p2pkh)
+	r.Error(err)
+
+	// The max name size follows the claimtrie parameters.
+	defer func(n int) { param.ActiveParams.MaxNameLength = n }(param.ActiveParams.MaxNameLength)
+	param.ActiveParams.MaxNameLength = 4
+	_, err = ClaimName("tester", nil, p2pkh)
+	r.Error(err)
+	short, err := ClaimName("test", nil, p2pkh)
+	r.NoError(err)
+	param.ActiveParams.MaxNameLength = 3
+	_, err = Parse(short)
+	r.Error(err)
+	param.ActiveParams.MaxNameLength = 0
+
+	_, err = ClaimName("tester", make([]byte, txscript.MaxClaimScriptSize), p2pkh)
+	r.Error(err)
+
+	_, err = ClaimName("tester", nil, nil)
+	r.Error(err)
+
+	script, err := ClaimName("tester", nil, p2pkh)
+	r.NoError(err)
+	_, err = ClaimName("tester", nil, script)
+	r.Error(err)
+}
+
+func TestParse(t *testing.T) {
+
+	r := require.New(t)
+
+	_, err := Parse(p2pkh)
+	r.True(txscript.IsErrorCode(err, txscript.ErrNotClaimScript))
+	r.False(IsClaimScript(p2pkh))
+
+	id := change.NewClaimID(wire.OutPoint{Index: 1})
+	parsed, err := ParseClaimID(id.String())
+	r.NoError(err)
+	r.Equal(id, parsed)
+
+	_, err = ParseClaimID("abcd")
+	r.Error(err)
+}
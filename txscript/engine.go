@@ -152,6 +152,7 @@ type Engine struct {
 	bip16     bool
 	sigCache  *SigCache
 	hashCache *TxSigHashes
+	sigBatch  *SigBatch
 
 	// The following fields handle keeping track of the current execution state
 	// of the engine.
@@ -1113,6 +1114,35 @@ func (vm *Engine) SetAltStack(data [][]byte) {
 	setStack(&vm.astack, data)
 }
 
+// SetSigBatch configures the engine to defer signature checks whose failure
+// would necessarily cause script execution to fail to the passed batch.  When
+// a batch is set, a successful execution only indicates the scripts are valid
+// once the batch has also been successfully verified.  See SigBatch for more
+// details.
+func (vm *Engine) SetSigBatch(batch *SigBatch) {
+	vm.sigBatch = batch
+}
+
+// isFinalOpcode returns whether the opcode which was just parsed from the
+// current script is the last one the engine will execute, in which case its
+// result directly determines the outcome of the execution.
+func (vm *Engine) isFinalOpcode() bool {
+	if !vm.tokenizer.Done() || vm.scriptIdx != len(vm.scripts)-1 {
+		return false
+	}
+
+	// Pay-to-script-hash and witness program executions append additional
+	// scripts once the public key script (and, for nested witness
+	// programs, the redeem script) has been executed.
+	switch {
+	case vm.scriptIdx == 1 && (vm.bip16 || vm.witnessProgram != nil):
+		return false
+	case vm.scriptIdx == 2 && vm.witnessProgram != nil && vm.bip16:
+		return false
+	}
+	return true
+}
+
 // NewEngine returns a new script engine for the provided public key script,
 // transaction, and input index.  The flags modify the behavior of the script
 // engine according to the description provided by each flag.
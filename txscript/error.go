@@ -343,6 +343,10 @@ const (
 	// serialized in a compressed format.
 	ErrWitnessPubKeyType
 
+	// ErrSigBatchVerify is returned when a signature check which was
+	// deferred to a SigBatch fails verification.
+	ErrSigBatchVerify
+
 	// numErrorCodes is the maximum error code number used in tests.  This
 	// entry MUST be the last entry in the enum.
 	numErrorCodes
@@ -415,6 +419,7 @@ var errorCodeStrings = map[ErrorCode]string{
 	ErrMinimalIf:                          "ErrMinimalIf",
 	ErrWitnessPubKeyType:                  "ErrWitnessPubKeyType",
 	ErrDiscourageUpgradableWitnessProgram: "ErrDiscourageUpgradableWitnessProgram",
+	ErrSigBatchVerify:                     "ErrSigBatchVerify",
 }
 
 // String returns the ErrorCode as a human-readable name.
@@ -82,6 +82,7 @@ func TestErrorCodeStringer(t *testing.T) {
 		{ErrMinimalIf, "ErrMinimalIf"},
 		{ErrWitnessPubKeyType, "ErrWitnessPubKeyType"},
 		{ErrDiscourageUpgradableWitnessProgram, "ErrDiscourageUpgradableWitnessProgram"},
+		{ErrSigBatchVerify, "ErrSigBatchVerify"},
 		{0xffff, "Unknown ErrorCode (65535)"},
 	}
 
@@ -80,6 +80,16 @@ func (h *HashCache) GetSigHashes(txid *chainhash.Hash) (*TxSigHashes, bool) {
 	return item, found
 }
 
+// Len returns the number of transactions with partial sighashes within the
+// HashCache.
+func (h *HashCache) Len() int {
+	h.RLock()
+	n := len(h.sigHashes)
+	h.RUnlock()
+
+	return n
+}
+
 // PurgeSigHashes removes all partial sighashes from the HashCache belonging to
 // the passed transaction.
 func (h *HashCache) PurgeSigHashes(txid *chainhash.Hash) {
@@ -1952,6 +1952,15 @@ func opcodeCheckSig(op *opcode, data []byte, vm *Engine) error {
 		return nil
 	}
 
+	// Defer the signature check to the batch, if any, when a failed check
+	// would necessarily cause the script to fail.  The check is treated as
+	// successful here and the script is only valid once the batch verifies.
+	if vm.canDeferSigCheck(op, sigBytes) {
+		vm.sigBatch.add(hash, signature, pubKey, &vm.tx, vm.txIdx)
+		vm.dstack.PushBool(true)
+		return nil
+	}
+
 	var valid bool
 	if vm.sigCache != nil {
 		var sigHash chainhash.Hash
@@ -1975,6 +1984,28 @@ func opcodeCheckSig(op *opcode, data []byte, vm *Engine) error {
 	return nil
 }
 
+// canDeferSigCheck returns whether the signature check performed by the passed
+// signature checking opcode may be deferred to the signature batch of the
+// engine.  That is only the case when a batch is set and the failure of the
+// check would necessarily result in the script failing, either because the
+// opcode fails on an invalid signature itself, the result is the final one of
+// the execution, or a failed check with a non-empty signature is an error due
+// to ScriptVerifyNullFail.
+func (vm *Engine) canDeferSigCheck(op *opcode, sigBytes []byte) bool {
+	if vm.sigBatch == nil {
+		return false
+	}
+
+	switch {
+	case op.value == OP_CHECKSIGVERIFY:
+		return true
+	case vm.hasFlag(ScriptVerifyNullFail) && len(sigBytes) > 0:
+		return true
+	default:
+		return vm.isFinalOpcode()
+	}
+}
+
 // opcodeCheckSigVerify is a combination of opcodeCheckSig and opcodeVerify.
 // The opcodeCheckSig function is invoked followed by opcodeVerify.  See the
 // documentation for each of those opcodes for more details.
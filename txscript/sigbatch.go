@@ -0,0 +1,154 @@
+package txscript
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/lbryio/lbcd/btcec"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/wire"
+)
+
+// sigBatchEntry houses a signature check which has been deferred to a
+// SigBatch along with enough information to identify the input which
+// produced it.
+type sigBatchEntry struct {
+	sigHash chainhash.Hash
+	sig     *btcec.Signature
+	pubKey  *btcec.PublicKey
+	tx      *wire.MsgTx
+	txIdx   int
+}
+
+// SigBatch collects signature checks from any number of script engines so
+// they can be verified together by a pool of workers once all of the scripts
+// have been executed.
+//
+// A script engine which has been given a batch via SetSigBatch only defers a
+// signature check when a failed check would necessarily cause the script to
+// fail.  This is the case for OP_CHECKSIGVERIFY, for an OP_CHECKSIG which is
+// the final opcode executed, and for any OP_CHECKSIG with a non-empty
+// signature when ScriptVerifyNullFail is set.  The engine optimistically
+// treats such checks as successful, so a script which executes successfully
+// with a batch is only valid once Verify also succeeds.
+//
+// Signatures which verify are added to the signature cache, if any, the batch
+// was created with.
+type SigBatch struct {
+	mtx      sync.Mutex
+	entries  []sigBatchEntry
+	sigCache *SigCache
+}
+
+// NewSigBatch returns a new empty signature batch.  The signature cache is
+// optional and may be nil.
+func NewSigBatch(sigCache *SigCache) *SigBatch {
+	return &SigBatch{sigCache: sigCache}
+}
+
+// add queues the signature check for the passed signature hash, signature and
+// public key which was produced by input txIdx of the provided transaction.
+//
+// This function is safe for concurrent access.
+func (b *SigBatch) add(hash []byte, sig *btcec.Signature,
+	pubKey *btcec.PublicKey, tx *wire.MsgTx, txIdx int) {
+
+	entry := sigBatchEntry{
+		sig:    sig,
+		pubKey: pubKey,
+		tx:     tx,
+		txIdx:  txIdx,
+	}
+	copy(entry.sigHash[:], hash)
+
+	b.mtx.Lock()
+	b.entries = append(b.entries, entry)
+	b.mtx.Unlock()
+}
+
+// Len returns the number of signature checks queued in the batch.
+//
+// This function is safe for concurrent access.
+func (b *SigBatch) Len() int {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return len(b.entries)
+}
+
+// Verify verifies all of the signature checks queued in the batch using up to
+// the provided number of workers.  A number of workers less than one results
+// in a worker per processor core.  The batch is emptied regardless of the
+// result.
+//
+// An error with the ErrSigBatchVerify code which identifies one of the inputs
+// that produced an invalid signature is returned when any check fails.
+//
+// This function is safe for concurrent access.
+func (b *SigBatch) Verify(workers int) error {
+	b.mtx.Lock()
+	entries := b.entries
+	b.entries = nil
+	b.mtx.Unlock()
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(entries) {
+		workers = len(entries)
+	}
+
+	// Each worker verifies an interleaved subset of the entries and stops
+	// early once any worker has found an invalid signature.
+	var (
+		wg      sync.WaitGroup
+		failMtx sync.Mutex
+		failed  *sigBatchEntry
+		quit    = make(chan struct{})
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(start int) {
+			defer wg.Done()
+			for i := start; i < len(entries); i += workers {
+				select {
+				case <-quit:
+					return
+				default:
+				}
+
+				entry := &entries[i]
+				if b.sigCache != nil && b.sigCache.Exists(
+					entry.sigHash, entry.sig, entry.pubKey) {
+
+					continue
+				}
+				if !entry.sig.Verify(entry.sigHash[:], entry.pubKey) {
+					failMtx.Lock()
+					if failed == nil {
+						failed = entry
+						close(quit)
+					}
+					failMtx.Unlock()
+					return
+				}
+				if b.sigCache != nil {
+					b.sigCache.Add(entry.sigHash, entry.sig,
+						entry.pubKey)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if failed != nil {
+		str := fmt.Sprintf("invalid signature for input %s:%d",
+			failed.tx.TxHash(), failed.txIdx)
+		return scriptError(ErrSigBatchVerify, str)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package txscript
+
+import (
+	"testing"
+
+	"github.com/lbryio/lbcd/btcec"
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+// TestSigBatch ensures signature checks deferred to a SigBatch are verified
+// once the batch is verified and that invalid signatures are detected.
+func TestSigBatch(t *testing.T) {
+	t.Parallel()
+
+	key, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("failed to make privKey: %v", err)
+	}
+	otherKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("failed to make privKey: %v", err)
+	}
+	pk := (*btcec.PublicKey)(&key.PublicKey).SerializeCompressed()
+	addr, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(pk),
+		&chaincfg.TestNet3Params)
+	if err != nil {
+		t.Fatalf("failed to make address: %v", err)
+	}
+	pkScript, err := PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("failed to make pkscript: %v", err)
+	}
+
+	tx := &wire.MsgTx{
+		Version: 1,
+		TxIn: []*wire.TxIn{{
+			PreviousOutPoint: wire.OutPoint{
+				Hash:  chainhash.Hash{},
+				Index: 0,
+			},
+			Sequence: 4294967295,
+		}},
+		TxOut:    []*wire.TxOut{{Value: 1}},
+		LockTime: 0,
+	}
+
+	tests := []struct {
+		name    string
+		signKey *btcec.PrivateKey
+		valid   bool
+	}{
+		{"valid signature", key, true},
+		{"signature by the wrong key", otherKey, false},
+	}
+
+	for _, test := range tests {
+		sig, err := RawTxInSignature(tx, 0, pkScript, SigHashAll,
+			test.signKey)
+		if err != nil {
+			t.Fatalf("%s: failed to sign: %v", test.name, err)
+		}
+		sigScript, err := NewScriptBuilder().AddData(sig).AddData(pk).
+			Script()
+		if err != nil {
+			t.Fatalf("%s: failed to build sigscript: %v", test.name,
+				err)
+		}
+		tx.TxIn[0].SignatureScript = sigScript
+
+		batch := NewSigBatch(nil)
+		vm, err := NewEngine(pkScript, tx, 0, ScriptBip16|
+			ScriptVerifyDERSignatures, nil, nil, 1)
+		if err != nil {
+			t.Fatalf("%s: failed to make script engine: %v",
+				test.name, err)
+		}
+		vm.SetSigBatch(batch)
+
+		// The final signature check is deferred, so execution succeeds
+		// regardless of the validity of the signature.
+		if err := vm.Execute(); err != nil {
+			t.Errorf("%s: unexpected execution error: %v", test.name,
+				err)
+			continue
+		}
+		if batch.Len() != 1 {
+			t.Errorf("%s: unexpected number of deferred checks - "+
+				"got %d, want 1", test.name, batch.Len())
+			continue
+		}
+
+		err = batch.Verify(0)
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected batch error: %v", test.name, err)
+		}
+		if !test.valid && !IsErrorCode(err, ErrSigBatchVerify) {
+			t.Errorf("%s: unexpected batch error - got %v, want %v",
+				test.name, err, ErrSigBatchVerify)
+		}
+		if batch.Len() != 0 {
+			t.Errorf("%s: batch not emptied by Verify", test.name)
+		}
+	}
+}
@@ -5,10 +5,28 @@
 package txscript
 
 import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
 	"sync"
+	"sync/atomic"
 
 	"github.com/lbryio/lbcd/btcec"
 	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/wire"
+)
+
+const (
+	// sigCacheSerializeVersion is the version of the serialized signature
+	// cache.
+	sigCacheSerializeVersion = 1
+
+	// serializedSigCacheEntrySize is the size of a serialized entry: the
+	// sigHash, the compressed public key, and the R and S values of the
+	// signature.
+	serializedSigCacheEntrySize = chainhash.HashSize +
+		btcec.PubKeyBytesLenCompressed + 64
 )
 
 // sigCacheEntry represents an entry in the SigCache. Entries within the
@@ -33,6 +51,11 @@ type sigCacheEntry struct {
 // optimization which speeds up the validation of transactions within a block,
 // if they've already been seen and verified within the mempool.
 type SigCache struct {
+	// The following variables must only be used atomically.  They are
+	// placed first to be 64-bit aligned.
+	hits   uint64
+	misses uint64
+
 	sync.RWMutex
 	validSigs  map[chainhash.Hash]sigCacheEntry
 	maxEntries uint
@@ -60,7 +83,12 @@ func (s *SigCache) Exists(sigHash chainhash.Hash, sig *btcec.Signature, pubKey *
 	entry, ok := s.validSigs[sigHash]
 	s.RUnlock()
 
-	return ok && entry.pubKey.IsEqual(pubKey) && entry.sig.IsEqual(sig)
+	if ok && entry.pubKey.IsEqual(pubKey) && entry.sig.IsEqual(sig) {
+		atomic.AddUint64(&s.hits, 1)
+		return true
+	}
+	atomic.AddUint64(&s.misses, 1)
+	return false
 }
 
 // Add adds an entry for a signature over 'sigHash' under public key 'pubKey'
@@ -81,19 +109,137 @@ func (s *SigCache) Add(sigHash chainhash.Hash, sig *btcec.Signature, pubKey *btc
 	// If adding this new entry will put us over the max number of allowed
 	// entries, then evict an entry.
 	if uint(len(s.validSigs)+1) > s.maxEntries {
-		// Remove a random entry from the map. Relying on the random
-		// starting point of Go's map iteration. It's worth noting that
-		// the random iteration starting point is not 100% guaranteed
-		// by the spec, however most Go compilers support it.
-		// Ultimately, the iteration order isn't important here because
-		// in order to manipulate which items are evicted, an adversary
-		// would need to be able to execute preimage attacks on the
-		// hashing function in order to start eviction at a specific
-		// entry.
-		for sigEntry := range s.validSigs {
-			delete(s.validSigs, sigEntry)
+		s.evict(uint(len(s.validSigs)+1) - s.maxEntries)
+	}
+	s.validSigs[sigHash] = sigCacheEntry{sig, pubKey}
+}
+
+// evict removes n random entries from the signature cache.
+//
+// This function MUST be called with the lock held (for writes).
+func (s *SigCache) evict(n uint) {
+	// Remove random entries from the map. Relying on the random starting
+	// point of Go's map iteration. It's worth noting that the random
+	// iteration starting point is not 100% guaranteed by the spec, however
+	// most Go compilers support it. Ultimately, the iteration order isn't
+	// important here because in order to manipulate which items are
+	// evicted, an adversary would need to be able to execute preimage
+	// attacks on the hashing function in order to start eviction at a
+	// specific entry.
+	for sigEntry := range s.validSigs {
+		if n == 0 {
 			break
 		}
+		delete(s.validSigs, sigEntry)
+		n--
 	}
-	s.validSigs[sigHash] = sigCacheEntry{sig, pubKey}
+}
+
+// SetMaxEntries changes the maximum number of entries allowed to exist in the
+// signature cache.  Random entries are evicted when the cache holds more
+// entries than the new max.
+//
+// NOTE: This function is safe for concurrent access. Writers will block
+// simultaneous readers until function execution has concluded.
+func (s *SigCache) SetMaxEntries(maxEntries uint) {
+	s.Lock()
+	defer s.Unlock()
+
+	if uint(len(s.validSigs)) > maxEntries {
+		s.evict(uint(len(s.validSigs)) - maxEntries)
+	}
+	s.maxEntries = maxEntries
+}
+
+// Stats returns the number of lookups of the signature cache which found the
+// signature, and the number of those which didn't, since it was created.
+//
+// NOTE: This function is safe for concurrent access.
+func (s *SigCache) Stats() (uint64, uint64) {
+	return atomic.LoadUint64(&s.hits), atomic.LoadUint64(&s.misses)
+}
+
+// Serialize writes the entries of the signature cache to w, so they can be
+// restored with Deserialize.
+//
+// NOTE: This function is safe for concurrent access. Readers won't be blocked
+// unless there exists a writer, adding an entry to the SigCache.
+func (s *SigCache) Serialize(w io.Writer) error {
+	s.RLock()
+	defer s.RUnlock()
+
+	var buf [serializedSigCacheEntrySize]byte
+	binary.LittleEndian.PutUint32(buf[:4], sigCacheSerializeVersion)
+	if _, err := w.Write(buf[:4]); err != nil {
+		return err
+	}
+	err := wire.WriteVarInt(w, 0, uint64(len(s.validSigs)))
+	if err != nil {
+		return err
+	}
+	for sigHash, entry := range s.validSigs {
+		offset := copy(buf[:], sigHash[:])
+		offset += copy(buf[offset:], entry.pubKey.SerializeCompressed())
+		entry.sig.R.FillBytes(buf[offset : offset+32])
+		entry.sig.S.FillBytes(buf[offset+32:])
+		if _, err := w.Write(buf[:]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Deserialize adds the entries written by Serialize from r to the signature
+// cache.  The signatures are not verified again, so r must come from a trusted
+// source, such as a file written by this process before.  Random entries are
+// evicted once the cache is full, as when adding them.
+//
+// NOTE: This function is safe for concurrent access. Writers will block
+// simultaneous readers until function execution has concluded.
+func (s *SigCache) Deserialize(r io.Reader) error {
+	var buf [serializedSigCacheEntrySize]byte
+	if _, err := io.ReadFull(r, buf[:4]); err != nil {
+		return err
+	}
+	version := binary.LittleEndian.Uint32(buf[:4])
+	if version != sigCacheSerializeVersion {
+		return fmt.Errorf("unknown signature cache version %d", version)
+	}
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < count; i++ {
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return err
+		}
+		var sigHash chainhash.Hash
+		offset := copy(sigHash[:], buf[:])
+		pubKey, err := btcec.ParsePubKey(
+			buf[offset:offset+btcec.PubKeyBytesLenCompressed],
+			btcec.S256())
+		if err != nil {
+			return err
+		}
+		offset += btcec.PubKeyBytesLenCompressed
+		sig := &btcec.Signature{
+			R: new(big.Int).SetBytes(buf[offset : offset+32]),
+			S: new(big.Int).SetBytes(buf[offset+32:]),
+		}
+		s.Add(sigHash, sig, pubKey)
+	}
+	return nil
+}
+
+// Len returns the number of entries in the signature cache and the maximum
+// number of entries it may hold.
+//
+// NOTE: This function is safe for concurrent access. Readers won't be blocked
+// unless there exists a writer, adding an entry to the SigCache.
+func (s *SigCache) Len() (int, uint) {
+	s.RLock()
+	n := len(s.validSigs)
+	s.RUnlock()
+
+	return n, s.maxEntries
 }
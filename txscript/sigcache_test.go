@@ -5,6 +5,7 @@
 package txscript
 
 import (
+	"bytes"
 	"crypto/rand"
 	"testing"
 
@@ -96,9 +97,9 @@ func TestSigCacheAddEvictEntry(t *testing.T) {
 	sigCache.Add(*msgNew, sigNew, keyNew)
 
 	// The sigcache should still have sigCache entries.
-	if uint(len(sigCache.validSigs)) != sigCacheSize {
-		t.Fatalf("sigcache should now have %v entries, instead it has %v",
-			sigCacheSize, len(sigCache.validSigs))
+	if n, max := sigCache.Len(); uint(n) != sigCacheSize || max != sigCacheSize {
+		t.Fatalf("sigcache should now have %v of %v entries, instead it "+
+			"has %v of %v", sigCacheSize, sigCacheSize, n, max)
 	}
 
 	// The entry added above should be found within the sigcache.
@@ -138,3 +139,81 @@ func TestSigCacheAddMaxEntriesZeroOrNegative(t *testing.T) {
 			"been added", len(sigCache.validSigs))
 	}
 }
+
+// TestSigCacheSetMaxEntries tests that shrinking the signature cache evicts
+// the entries above the new max, and counts the lookups.
+func TestSigCacheSetMaxEntries(t *testing.T) {
+	sigCache := NewSigCache(10)
+	for i := 0; i < 10; i++ {
+		msg, sig, key, err := genRandomSig()
+		if err != nil {
+			t.Fatalf("unable to generate random signature test data")
+		}
+		sigCache.Add(*msg, sig, key)
+	}
+
+	sigCache.SetMaxEntries(4)
+	if n, max := sigCache.Len(); n != 4 || max != 4 {
+		t.Fatalf("sigcache has %d of %d entries, want 4 of 4", n, max)
+	}
+
+	// The lookups are counted as hits or misses.
+	msg, sig, key, err := genRandomSig()
+	if err != nil {
+		t.Fatalf("unable to generate random signature test data")
+	}
+	sigCache.Exists(*msg, sig, key)
+	sigCache.Add(*msg, sig, key)
+	sigCache.Exists(*msg, sig, key)
+	if hits, misses := sigCache.Stats(); hits != 1 || misses != 1 {
+		t.Fatalf("sigcache has %d hits and %d misses, want 1 and 1",
+			hits, misses)
+	}
+	if n, _ := sigCache.Len(); n != 4 {
+		t.Fatalf("sigcache has %d entries, want 4", n)
+	}
+}
+
+// TestSigCacheSerialize tests that the entries of a serialized signature cache
+// are restored by deserializing it.
+func TestSigCacheSerialize(t *testing.T) {
+	sigCache := NewSigCache(10)
+	for i := 0; i < 5; i++ {
+		msg, sig, key, err := genRandomSig()
+		if err != nil {
+			t.Fatalf("unable to generate random signature test data")
+		}
+		sigCache.Add(*msg, sig, key)
+	}
+
+	var buf bytes.Buffer
+	if err := sigCache.Serialize(&buf); err != nil {
+		t.Fatalf("unable to serialize sigcache: %v", err)
+	}
+	serialized := buf.Bytes()
+
+	restored := NewSigCache(10)
+	if err := restored.Deserialize(bytes.NewReader(serialized)); err != nil {
+		t.Fatalf("unable to deserialize sigcache: %v", err)
+	}
+	for msg, entry := range sigCache.validSigs {
+		if !restored.Exists(msg, entry.sig, entry.pubKey) {
+			t.Fatalf("entry for %v not restored", msg)
+		}
+	}
+
+	// A smaller cache only keeps as many entries as it can, and a
+	// truncated cache is an error.
+	restored = NewSigCache(2)
+	if err := restored.Deserialize(bytes.NewReader(serialized)); err != nil {
+		t.Fatalf("unable to deserialize sigcache: %v", err)
+	}
+	if n, _ := restored.Len(); n != 2 {
+		t.Fatalf("sigcache has %d entries, want 2", n)
+	}
+	err := NewSigCache(10).Deserialize(bytes.NewReader(
+		serialized[:len(serialized)-1]))
+	if err == nil {
+		t.Fatalf("deserialized a truncated sigcache")
+	}
+}
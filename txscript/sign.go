@@ -432,6 +432,48 @@ func (sc ScriptClosure) GetScript(address btcutil.Address) ([]byte, error) {
 	return sc(address)
 }
 
+// signClaimTxOutput signs output idx of the given tx to resolve the claim
+// script given in pkScript, where inner is the script which follows the claim
+// prefix.
+//
+// The script engine executes a claim script as a whole, so signatures for the
+// inner script commit to the full public key script.  The engine does not
+// apply the pay-to-script-hash or witness program evaluation rules to claim
+// scripts, however, so those inner types are resolved by pushing the redeem
+// script and by an empty signature script, respectively.
+func signClaimTxOutput(chainParams *chaincfg.Params, tx *wire.MsgTx, idx int,
+	pkScript, inner []byte, hashType SigHashType, kdb KeyDB, sdb ScriptDB,
+	previousScript []byte) ([]byte, error) {
+
+	class, addresses, _, err := ExtractPkScriptAddrs(inner, chainParams)
+	if err != nil {
+		return nil, err
+	}
+
+	switch class {
+	case ScriptHashTy:
+		script, err := sdb.GetScript(addresses[0])
+		if err != nil {
+			return nil, err
+		}
+		return NewScriptBuilder().AddData(script).Script()
+
+	case WitnessV0PubKeyHashTy, WitnessV0ScriptHashTy:
+		return nil, nil
+	}
+
+	sigScript, class, addresses, nrequired, err := sign(chainParams, tx,
+		idx, pkScript, hashType, kdb, sdb)
+	if err != nil {
+		return nil, err
+	}
+
+	// Merge scripts with any previous data, if any.
+	mergedScript := mergeScripts(chainParams, tx, idx, pkScript, class,
+		addresses, nrequired, sigScript, previousScript)
+	return mergedScript, nil
+}
+
 // SignTxOutput signs output idx of the given tx to resolve the script given in
 // pkScript with a signature type of hashType. Any keys required will be
 // looked up by calling getKey() with the string of the given address.
@@ -440,6 +482,9 @@ func (sc ScriptClosure) GetScript(address btcutil.Address) ([]byte, error) {
 // will be merged in a type-dependent manner with the newly generated.
 // signature script.
 //
+// Claim scripts are signed according to the script following the claim
+// prefix.  See signClaimTxOutput for how each inner script type is resolved.
+//
 // NOTE: This function is only valid for version 0 scripts.  Since the function
 // does not accept a script version, the results are undefined for other script
 // versions.
@@ -447,6 +492,11 @@ func SignTxOutput(chainParams *chaincfg.Params, tx *wire.MsgTx, idx int,
 	pkScript []byte, hashType SigHashType, kdb KeyDB, sdb ScriptDB,
 	previousScript []byte) ([]byte, error) {
 
+	if inner := StripClaimScriptPrefix(pkScript); len(inner) != len(pkScript) {
+		return signClaimTxOutput(chainParams, tx, idx, pkScript, inner,
+			hashType, kdb, sdb, previousScript)
+	}
+
 	sigScript, class, addresses, nrequired, err := sign(chainParams, tx,
 		idx, pkScript, hashType, kdb, sdb)
 	if err != nil {
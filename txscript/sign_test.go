@@ -1714,3 +1714,132 @@ nexttest:
 		}
 	}
 }
+
+// TestSignClaimTxOutput ensures SignTxOutput produces valid signature scripts
+// for claim scripts wrapping each of the supported inner script types.
+func TestSignClaimTxOutput(t *testing.T) {
+	t.Parallel()
+
+	tx := &wire.MsgTx{
+		Version: 1,
+		TxIn: []*wire.TxIn{{
+			PreviousOutPoint: wire.OutPoint{
+				Hash:  chainhash.Hash{},
+				Index: 0,
+			},
+			Sequence: 4294967295,
+		}},
+		TxOut: []*wire.TxOut{{
+			Value: 1,
+		}},
+		LockTime: 0,
+	}
+
+	key1, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("failed to make privKey: %v", err)
+	}
+	key2, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("failed to make privKey: %v", err)
+	}
+	pk1 := (*btcec.PublicKey)(&key1.PublicKey).SerializeCompressed()
+	pk2 := (*btcec.PublicKey)(&key2.PublicKey).SerializeCompressed()
+
+	params := &chaincfg.TestNet3Params
+	pkhAddr, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(pk1), params)
+	if err != nil {
+		t.Fatalf("failed to make address: %v", err)
+	}
+	pkAddr1, err := btcutil.NewAddressPubKey(pk1, params)
+	if err != nil {
+		t.Fatalf("failed to make address: %v", err)
+	}
+	pkAddr2, err := btcutil.NewAddressPubKey(pk2, params)
+	if err != nil {
+		t.Fatalf("failed to make address: %v", err)
+	}
+	wpkhAddr, err := btcutil.NewAddressWitnessPubKeyHash(
+		btcutil.Hash160(pk1), params)
+	if err != nil {
+		t.Fatalf("failed to make address: %v", err)
+	}
+
+	pkhScript, err := PayToAddrScript(pkhAddr)
+	if err != nil {
+		t.Fatalf("failed to make pkscript: %v", err)
+	}
+	pkScript, err := PayToAddrScript(pkAddr1)
+	if err != nil {
+		t.Fatalf("failed to make pkscript: %v", err)
+	}
+	multiSigScript, err := MultiSigScript([]*btcutil.AddressPubKey{pkAddr1,
+		pkAddr2}, 2)
+	if err != nil {
+		t.Fatalf("failed to make pkscript: %v", err)
+	}
+	shAddr, err := btcutil.NewAddressScriptHash(pkhScript, params)
+	if err != nil {
+		t.Fatalf("failed to make address: %v", err)
+	}
+	shScript, err := PayToAddrScript(shAddr)
+	if err != nil {
+		t.Fatalf("failed to make pkscript: %v", err)
+	}
+	wpkhScript, err := PayToAddrScript(wpkhAddr)
+	if err != nil {
+		t.Fatalf("failed to make pkscript: %v", err)
+	}
+
+	kdb := mkGetKey(map[string]addressToKey{
+		pkhAddr.EncodeAddress(): {key1, true},
+		pkAddr1.EncodeAddress(): {key1, true},
+		pkAddr2.EncodeAddress(): {key2, true},
+	})
+	sdb := mkGetScript(map[string][]byte{
+		shAddr.EncodeAddress(): pkhScript,
+	})
+
+	// The claim builders terminate the prefix with OP_TRUE as a placeholder
+	// for the pubkey script, so strip it before appending the real one.
+	prefix, err := ClaimNameScript("name", "value")
+	if err != nil {
+		t.Fatalf("failed to make claim script: %v", err)
+	}
+	prefix = prefix[:len(prefix)-1]
+
+	tests := []struct {
+		name  string
+		inner []byte
+		flags ScriptFlags
+	}{
+		{"pay-to-pubkey-hash", pkhScript, StandardVerifyFlags},
+		{"pay-to-pubkey", pkScript, StandardVerifyFlags},
+		{"multisig", multiSigScript, StandardVerifyFlags},
+		{"pay-to-script-hash", shScript, StandardVerifyFlags},
+		{"pay-to-witness-pubkey-hash", wpkhScript,
+			ScriptBip16 | ScriptVerifyWitness},
+	}
+
+	for _, test := range tests {
+		claimScript := append(append([]byte{}, prefix...), test.inner...)
+		sigScript, err := SignTxOutput(params, tx, 0, claimScript,
+			SigHashAll, kdb, sdb, nil)
+		if err != nil {
+			t.Errorf("%s: failed to sign output: %v", test.name, err)
+			continue
+		}
+
+		tx.TxIn[0].SignatureScript = sigScript
+		vm, err := NewEngine(claimScript, tx, 0, test.flags, nil, nil, 1)
+		if err != nil {
+			t.Errorf("%s: failed to make script engine: %v",
+				test.name, err)
+			continue
+		}
+		if err := vm.Execute(); err != nil {
+			t.Errorf("%s: invalid script signature: %v", test.name,
+				err)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+// Package txsign signs the inputs of raw transactions with private keys, for
+// the signrawtransactionwithkey command of lbcd and the sign subcommand of
+// lbcctl.
+package txsign
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lbryio/lbcd/btcec"
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+// sigHashTypes are the sighash types by the names of the signrawtransaction
+// commands.
+var sigHashTypes = map[string]txscript.SigHashType{
+	"ALL":                 txscript.SigHashAll,
+	"NONE":                txscript.SigHashNone,
+	"SINGLE":              txscript.SigHashSingle,
+	"ALL|ANYONECANPAY":    txscript.SigHashAll | txscript.SigHashAnyOneCanPay,
+	"NONE|ANYONECANPAY":   txscript.SigHashNone | txscript.SigHashAnyOneCanPay,
+	"SINGLE|ANYONECANPAY": txscript.SigHashSingle | txscript.SigHashAnyOneCanPay,
+}
+
+// ParseSigHashType returns the sighash type of the name, such as
+// ALL|ANYONECANPAY, in any case, and whether there is one.
+func ParseSigHashType(name string) (txscript.SigHashType, bool) {
+	hashType, ok := sigHashTypes[strings.ToUpper(name)]
+	return hashType, ok
+}
+
+// SigHashTypeName returns the name of the sighash type, such as
+// ALL|ANYONECANPAY, or its hex value if it has none.
+func SigHashTypeName(hashType txscript.SigHashType) string {
+	for name, t := range sigHashTypes {
+		if t == hashType {
+			return name
+		}
+	}
+	return fmt.Sprintf("%#x", uint32(hashType))
+}
+
+// PrevOut is an output spent by a transaction to sign, along with the scripts
+// it commits to.  The amount of an output is only needed by the witness
+// inputs, so it may be unknown for the others.
+type PrevOut struct {
+	TxOut         *wire.TxOut
+	HasAmount     bool
+	RedeemScript  []byte
+	WitnessScript []byte
+}
+
+// FindKey returns the private key of the address among the passed keys, along
+// with its serialized public key as the script of the address holds it, or nil
+// if there is none.
+func FindKey(keys []*btcutil.WIF, addr btcutil.Address) (*btcutil.WIF, []byte) {
+	for _, wif := range keys {
+		switch a := addr.(type) {
+		case *btcutil.AddressPubKey:
+			if wif.PrivKey.PubKey().IsEqual(a.PubKey()) {
+				return wif, a.ScriptAddress()
+			}
+
+		case *btcutil.AddressPubKeyHash, *btcutil.AddressWitnessPubKeyHash:
+			pubKey := wif.SerializePubKey()
+			if bytes.Equal(btcutil.Hash160(pubKey), a.ScriptAddress()) {
+				return wif, pubKey
+			}
+		}
+	}
+	return nil, nil
+}
+
+// SignTxInput signs the input at idx of the transaction spending prevOut with
+// the passed keys, and verifies the resulting scripts.  The
+// pay-to-witness-pubkey-hash and pay-to-witness-script-hash outputs, as is or
+// nested in a pay-to-script-hash, are signed with a witness; the others,
+// including the claim scripts, by txscript.SignTxOutput, which merges the new
+// signatures with those of the input.  The pay-to-script-hash outputs nesting
+// the pay-to-witness-pubkey-hash of one of the keys are signed without their
+// redeem script.
+func SignTxInput(params *chaincfg.Params, tx *wire.MsgTx,
+	sigHashes *txscript.TxSigHashes, idx int, prevOut *PrevOut,
+	keys []*btcutil.WIF, hashType txscript.SigHashType) error {
+
+	txIn := tx.TxIn[idx]
+	pkScript := prevOut.TxOut.PkScript
+	witnessProgram := pkScript
+	if txscript.IsPayToScriptHash(pkScript) {
+		witnessProgram = prevOut.RedeemScript
+		if len(witnessProgram) == 0 {
+			witnessProgram = nestedWitnessProgram(pkScript, keys)
+		}
+	}
+
+	isWitness := txscript.IsPayToWitnessPubKeyHash(witnessProgram) ||
+		txscript.IsPayToWitnessScriptHash(witnessProgram)
+	if isWitness && !prevOut.HasAmount {
+		return errors.New("missing amount of the witness input")
+	}
+
+	var err error
+	switch {
+	case txscript.IsPayToWitnessPubKeyHash(witnessProgram):
+		var addrs []btcutil.Address
+		_, addrs, _, err = txscript.ExtractPkScriptAddrs(witnessProgram,
+			params)
+		if err != nil {
+			return err
+		}
+		wif, _ := FindKey(keys, addrs[0])
+		if wif == nil {
+			return errors.New("no private key for the input")
+		}
+		txIn.Witness, err = txscript.WitnessSignature(tx, sigHashes, idx,
+			prevOut.TxOut.Value, witnessProgram, hashType, wif.PrivKey,
+			wif.CompressPubKey)
+
+	case txscript.IsPayToWitnessScriptHash(witnessProgram):
+		txIn.Witness, err = signWitnessScript(params, tx, sigHashes, idx,
+			prevOut, witnessProgram, keys, hashType)
+
+	default:
+		getKey := txscript.KeyClosure(func(addr btcutil.Address) (*btcec.PrivateKey, bool, error) {
+			wif, _ := FindKey(keys, addr)
+			if wif == nil {
+				return nil, false, errors.New("no private key for " +
+					"the input")
+			}
+			return wif.PrivKey, wif.CompressPubKey, nil
+		})
+		getScript := txscript.ScriptClosure(func(btcutil.Address) ([]byte, error) {
+			if len(prevOut.RedeemScript) == 0 {
+				return nil, errors.New("missing redeemScript of " +
+					"the input")
+			}
+			return prevOut.RedeemScript, nil
+		})
+		txIn.SignatureScript, err = txscript.SignTxOutput(params, tx, idx,
+			pkScript, hashType, getKey, getScript, txIn.SignatureScript)
+	}
+	if err != nil {
+		return err
+	}
+
+	// The witness program of a nested witness output is pushed by the
+	// signature script.
+	if isWitness {
+		txIn.SignatureScript = nil
+		if txscript.IsPayToScriptHash(pkScript) {
+			txIn.SignatureScript, err = txscript.NewScriptBuilder().
+				AddData(witnessProgram).Script()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	vm, err := txscript.NewEngine(pkScript, tx, idx,
+		txscript.StandardVerifyFlags, nil, sigHashes, prevOut.TxOut.Value)
+	if err != nil {
+		return err
+	}
+	return vm.Execute()
+}
+
+// nestedWitnessProgram returns the pay-to-witness-pubkey-hash script of one of
+// the passed keys which the pay-to-script-hash pkScript is the hash of, so
+// that the outputs nesting it can be signed without redeemScript, or nil if
+// there is none.
+func nestedWitnessProgram(pkScript []byte, keys []*btcutil.WIF) []byte {
+	for _, wif := range keys {
+		script, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).
+			AddData(btcutil.Hash160(wif.SerializePubKey())).Script()
+		if err != nil {
+			continue
+		}
+		if bytes.Equal(pkScript[2:22], btcutil.Hash160(script)) {
+			return script
+		}
+	}
+	return nil
+}
+
+// signWitnessScript returns the witness of the input at idx spending the
+// pay-to-witness-script-hash witnessProgram, whose witness script has to be
+// passed with prevOut.  Pay-to-pubkey, pay-to-pubkey-hash and multisig witness
+// scripts are signed, and the signatures of a multisig witness already in the
+// input are kept.
+func signWitnessScript(params *chaincfg.Params, tx *wire.MsgTx,
+	sigHashes *txscript.TxSigHashes, idx int, prevOut *PrevOut,
+	witnessProgram []byte, keys []*btcutil.WIF,
+	hashType txscript.SigHashType) (wire.TxWitness, error) {
+
+	script := prevOut.WitnessScript
+	if len(script) == 0 {
+		return nil, errors.New("missing witnessScript of the input")
+	}
+	scriptHash := sha256.Sum256(script)
+	if !bytes.Equal(witnessProgram[2:], scriptHash[:]) {
+		return nil, errors.New("the witnessScript doesn't match the " +
+			"witness program of the input")
+	}
+	amount := prevOut.TxOut.Value
+
+	class, addrs, nRequired, err := txscript.ExtractPkScriptAddrs(script,
+		params)
+	if err != nil {
+		return nil, err
+	}
+	sign := func(wif *btcutil.WIF) ([]byte, error) {
+		return txscript.RawTxInWitnessSignature(tx, sigHashes, idx, amount,
+			script, hashType, wif.PrivKey)
+	}
+
+	switch class {
+	case txscript.PubKeyTy, txscript.PubKeyHashTy:
+		wif, pubKey := FindKey(keys, addrs[0])
+		if wif == nil {
+			return nil, errors.New("no private key for the input")
+		}
+		sig, err := sign(wif)
+		if err != nil {
+			return nil, err
+		}
+		if class == txscript.PubKeyTy {
+			return wire.TxWitness{sig, script}, nil
+		}
+		return wire.TxWitness{sig, pubKey, script}, nil
+
+	case txscript.MultiSigTy:
+		// The signatures of the input are matched to the public keys
+		// they are valid for, which they have to follow the order of.
+		sigs := make([][]byte, len(addrs))
+		witness := tx.TxIn[idx].Witness
+		if len(witness) > 2 && bytes.Equal(witness[len(witness)-1], script) {
+			for _, sig := range witness[1 : len(witness)-1] {
+				if j := multiSigIndex(tx, sigHashes, idx, amount,
+					script, addrs, sig); j >= 0 {
+
+					sigs[j] = sig
+				}
+			}
+		}
+		for j, addr := range addrs {
+			if sigs[j] != nil {
+				continue
+			}
+			if wif, _ := FindKey(keys, addr); wif != nil {
+				sigs[j], err = sign(wif)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		witness = wire.TxWitness{nil}
+		for _, sig := range sigs {
+			if sig != nil && len(witness) <= nRequired {
+				witness = append(witness, sig)
+			}
+		}
+		return append(witness, script), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported witnessScript type %s", class)
+	}
+}
+
+// multiSigIndex returns the index among the public keys of a multisig witness
+// script of the one the signature is valid for, or -1 if there is none.
+func multiSigIndex(tx *wire.MsgTx, sigHashes *txscript.TxSigHashes, idx int,
+	amount int64, script []byte, addrs []btcutil.Address, sig []byte) int {
+
+	if len(sig) == 0 {
+		return -1
+	}
+	hashType := txscript.SigHashType(sig[len(sig)-1])
+	parsed, err := btcec.ParseDERSignature(sig[:len(sig)-1], btcec.S256())
+	if err != nil {
+		return -1
+	}
+	hash, err := txscript.CalcWitnessSigHash(script, sigHashes, hashType, tx,
+		idx, amount)
+	if err != nil {
+		return -1
+	}
+	for j, addr := range addrs {
+		pkAddr, ok := addr.(*btcutil.AddressPubKey)
+		if ok && parsed.Verify(hash, pkAddr.PubKey()) {
+			return j
+		}
+	}
+	return -1
+}
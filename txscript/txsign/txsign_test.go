@@ -0,0 +1,108 @@
+package txsign
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/lbryio/lbcd/btcec"
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/claimtrie/change"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/txscript/claimscript"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignTxInput(t *testing.T) {
+
+	r := require.New(t)
+	params := &chaincfg.RegressionNetParams
+
+	var keys []*btcutil.WIF
+	var pubKeys [][]byte
+	for i := byte(1); i <= 3; i++ {
+		key, _ := btcec.PrivKeyFromBytes(btcec.S256(), bytes.Repeat([]byte{i}, 32))
+		wif, err := btcutil.NewWIF(key, params, true)
+		r.NoError(err)
+		keys = append(keys, wif)
+		pubKeys = append(pubKeys, wif.SerializePubKey())
+	}
+	script := func(builder *txscript.ScriptBuilder) []byte {
+		s, err := builder.Script()
+		r.NoError(err)
+		return s
+	}
+	p2pkh := script(txscript.NewScriptBuilder().AddOp(txscript.OP_DUP).
+		AddOp(txscript.OP_HASH160).AddData(btcutil.Hash160(pubKeys[0])).
+		AddOp(txscript.OP_EQUALVERIFY).AddOp(txscript.OP_CHECKSIG))
+	p2wpkh := script(txscript.NewScriptBuilder().AddOp(txscript.OP_0).
+		AddData(btcutil.Hash160(pubKeys[0])))
+	p2shP2wpkh := script(txscript.NewScriptBuilder().AddOp(txscript.OP_HASH160).
+		AddData(btcutil.Hash160(p2wpkh)).AddOp(txscript.OP_EQUAL))
+	multiSig := script(txscript.NewScriptBuilder().AddOp(txscript.OP_2).
+		AddData(pubKeys[0]).AddData(pubKeys[1]).AddData(pubKeys[2]).
+		AddOp(txscript.OP_3).AddOp(txscript.OP_CHECKMULTISIG))
+	multiSigHash := sha256.Sum256(multiSig)
+	p2wsh := script(txscript.NewScriptBuilder().AddOp(txscript.OP_0).
+		AddData(multiSigHash[:]))
+	support, err := claimscript.SupportClaim("tester", change.ClaimID{1}, p2pkh)
+	r.NoError(err)
+
+	tx := wire.NewMsgTx(1)
+	for i := uint32(0); i < 5; i++ {
+		tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: i}, nil, nil))
+	}
+	tx.AddTxOut(wire.NewTxOut(1000, p2pkh))
+	sigHashes := txscript.NewTxSigHashes(tx)
+	sign := func(idx int, prevOut *PrevOut, keys ...*btcutil.WIF) error {
+		return SignTxInput(params, tx, sigHashes, idx, prevOut, keys,
+			txscript.SigHashAll)
+	}
+
+	// The claims are signed as their inner script, and the nested witness
+	// programs are found from the keys.
+	r.NoError(sign(0, &PrevOut{TxOut: wire.NewTxOut(2000, p2pkh)}, keys...))
+	r.NoError(sign(1, &PrevOut{TxOut: wire.NewTxOut(2000, support)}, keys[0]))
+	r.NoError(sign(2, &PrevOut{TxOut: wire.NewTxOut(2000, p2wpkh), HasAmount: true}, keys[0]))
+	r.NoError(sign(3, &PrevOut{TxOut: wire.NewTxOut(2000, p2shP2wpkh), HasAmount: true}, keys[0]))
+	r.Len(tx.TxIn[3].Witness, 2)
+	r.Error(sign(2, &PrevOut{TxOut: wire.NewTxOut(2000, p2wpkh)}, keys[0]))
+	r.Error(sign(0, &PrevOut{TxOut: wire.NewTxOut(2000, p2pkh)}, keys[1]))
+
+	// The signatures of a multisig witness script are merged, in the order
+	// of the public keys.
+	prevOut := &PrevOut{TxOut: wire.NewTxOut(2000, p2wsh), HasAmount: true}
+	r.Error(sign(4, prevOut, keys[2]))
+	prevOut.WitnessScript = multiSig
+	r.Error(sign(4, prevOut, keys[2]))
+	r.Len(tx.TxIn[4].Witness, 3)
+	r.NoError(sign(4, prevOut, keys[0]))
+	r.Len(tx.TxIn[4].Witness, 4)
+	r.Nil(tx.TxIn[4].SignatureScript)
+
+	var addrs []btcutil.Address
+	for _, pubKey := range pubKeys {
+		addr, err := btcutil.NewAddressPubKey(pubKey, params)
+		r.NoError(err)
+		addrs = append(addrs, addr)
+	}
+	witness := tx.TxIn[4].Witness
+	r.Equal(0, multiSigIndex(tx, sigHashes, 4, 2000, multiSig, addrs, witness[1]))
+	r.Equal(2, multiSigIndex(tx, sigHashes, 4, 2000, multiSig, addrs, witness[2]))
+	r.Equal(-1, multiSigIndex(tx, sigHashes, 3, 2000, multiSig, addrs, witness[2]))
+}
+
+func TestSigHashTypeNames(t *testing.T) {
+
+	r := require.New(t)
+
+	hashType, ok := ParseSigHashType("single|anyonecanpay")
+	r.True(ok)
+	r.Equal(txscript.SigHashSingle|txscript.SigHashAnyOneCanPay, hashType)
+	r.Equal("SINGLE|ANYONECANPAY", SigHashTypeName(hashType))
+	_, ok = ParseSigHashType("ANYONECANPAY")
+	r.False(ok)
+	r.Equal("0x40", SigHashTypeName(0x40))
+}
@@ -64,6 +64,11 @@ func Numeric() int32 {
 	return int32(numeric)
 }
 
+// BuildTags returns the build tags the binary was built with.
+func BuildTags() []string {
+	return parsed.tags
+}
+
 func init() {
 
 	version, prerelease, err := parseTag(appTag)
@@ -88,6 +93,7 @@ func init() {
 
 	var commit string
 	var modified bool
+	var tags []string
 	for _, s := range info.Settings {
 		if s.Key == "vcs.revision" {
 			commit = s.Value
@@ -95,6 +101,9 @@ func init() {
 		if s.Key == "vcs.modified" && s.Value == "true" {
 			modified = true
 		}
+		if s.Key == "-tags" && s.Value != "" {
+			tags = strings.Split(s.Value, ",")
+		}
 	}
 
 	parsed = parsedVersion{
@@ -109,6 +118,8 @@ func init() {
 
 		commit:   commit,
 		modified: modified,
+
+		tags: tags,
 	}
 }
 
@@ -129,6 +140,9 @@ type parsedVersion struct {
 	// Build Metadata
 	commit   string
 	modified bool
+
+	// Build Tags
+	tags []string
 }
 
 func (v parsedVersion) buildmeta() string {
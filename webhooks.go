@@ -0,0 +1,503 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lbryio/lbcd/blockchain"
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/mempool"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+)
+
+const (
+	// webhookQueueSize is the number of events queued to a webhook, after
+	// which new events to it are dropped until it catches up.
+	webhookQueueSize = 1000
+
+	// webhookMaxAttempts is the number of times the delivery of an event is
+	// attempted before it is dropped.
+	webhookMaxAttempts = 6
+
+	// webhookMaxBackoff is the longest wait between two delivery attempts.
+	webhookMaxBackoff = time.Minute
+
+	// webhookTimeout is the time allowed to a webhook to respond.
+	webhookTimeout = 10 * time.Second
+)
+
+// Types of the events posted to the webhooks.
+const (
+	webhookBlock     = "block"
+	webhookReorg     = "reorg"
+	webhookAddressTx = "addresstx"
+	webhookTakeover  = "takeover"
+	webhookDiskSpace = "diskspace"
+)
+
+// webhookEvent is the JSON payload posted to the webhooks.
+type webhookEvent struct {
+	Type string      `json:"type"`
+	Time int64       `json:"time"`
+	Data interface{} `json:"data"`
+}
+
+// blockEventData is the data of the events of block updates, posted to the
+// webhooks and sent to the event streams.
+type blockEventData struct {
+	Hash         string `json:"hash"`
+	Height       int32  `json:"height"`
+	PreviousHash string `json:"previousblockhash"`
+	Time         int64  `json:"time"`
+}
+
+// webhookTxData is the data of addresstx events.  The block is omitted for
+// transactions accepted to the mempool.
+type webhookTxData struct {
+	Txid      string   `json:"txid"`
+	Addresses []string `json:"addresses"`
+	BlockHash string   `json:"blockhash,omitempty"`
+	Height    int32    `json:"height,omitempty"`
+}
+
+// webhookTakeoverData is the data of takeover events.
+type webhookTakeoverData struct {
+	Name      string `json:"name"`
+	ClaimID   string `json:"claimid"`
+	BlockHash string `json:"blockhash"`
+	Height    int32  `json:"height"`
+}
+
+// webhookPayload is a marshalled event queued to a webhook.
+type webhookPayload struct {
+	kind string
+	body []byte
+}
+
+// webhook is a URL the events are posted to.
+type webhook struct {
+	url   string
+	queue chan *webhookPayload
+}
+
+// webhookNotifierConfig is a descriptor containing the webhook notifier
+// configuration.
+type webhookNotifierConfig struct {
+	// URLs are the webhooks the events are posted to.
+	URLs []string
+
+	// Secret signs the payloads with HMAC-SHA256 when it isn't empty.
+	Secret []byte
+
+	// Addrs are the addresses whose transactions are notified.
+	Addrs []btcutil.Address
+
+	Chain       *blockchain.BlockChain
+	ChainParams *chaincfg.Params
+
+	// HaveTransaction returns whether a transaction is in the mempool.
+	HaveTransaction func(*chainhash.Hash) bool
+}
+
+// webhookOutput is an output paying to a watched address, which is confirmed
+// once its transaction is in the main chain.
+type webhookOutput struct {
+	addr      string
+	confirmed bool
+}
+
+// webhookNotifier posts JSON notifications of the chain and the mempool to
+// HTTP endpoints, for services which can't hold a websocket open.
+type webhookNotifier struct {
+	started  int32
+	shutdown int32
+	cfg      webhookNotifierConfig
+	client   *http.Client
+	hooks    []*webhook
+
+	// watched holds the encoded watched addresses, and unspent the outputs
+	// paying to them, to notify their spends.  The unconfirmed outputs are
+	// pruned once their transaction left the mempool without being mined.
+	// Both are only accessed by the dispatch handler.
+	watched map[string]struct{}
+	unspent map[wire.OutPoint]*webhookOutput
+
+	queueNotification chan interface{}
+	notifications     chan interface{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	quit   chan struct{}
+}
+
+// newWebhookNotifier returns a new instance of the webhookNotifier struct.
+func newWebhookNotifier(config *webhookNotifierConfig) *webhookNotifier {
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &webhookNotifier{
+		cfg:               *config,
+		client:            &http.Client{Timeout: webhookTimeout},
+		watched:           make(map[string]struct{}, len(config.Addrs)),
+		unspent:           make(map[wire.OutPoint]*webhookOutput),
+		queueNotification: make(chan interface{}),
+		notifications:     make(chan interface{}),
+		ctx:               ctx,
+		cancel:            cancel,
+		quit:              make(chan struct{}),
+	}
+	for _, url := range config.URLs {
+		n.hooks = append(n.hooks, &webhook{
+			url:   url,
+			queue: make(chan *webhookPayload, webhookQueueSize),
+		})
+	}
+	for _, addr := range config.Addrs {
+		n.watched[addr.EncodeAddress()] = struct{}{}
+	}
+
+	config.Chain.Subscribe(n.handleBlockchainNotification)
+
+	return n
+}
+
+// Start begins delivering the notifications to the webhooks.
+func (n *webhookNotifier) Start() {
+	if atomic.AddInt32(&n.started, 1) != 1 {
+		return
+	}
+
+	n.wg.Add(2 + len(n.hooks))
+	go func() {
+		queueHandler(n.queueNotification, n.notifications, n.quit)
+		n.wg.Done()
+	}()
+	go n.dispatchHandler()
+	for _, hook := range n.hooks {
+		go n.deliveryHandler(hook)
+	}
+}
+
+// Stop abandons the pending notifications and waits for the handlers to
+// finish.
+func (n *webhookNotifier) Stop() {
+	if atomic.AddInt32(&n.shutdown, 1) != 1 {
+		hookLog.Infof("Webhooks are already in the process of shutting down")
+		return
+	}
+	close(n.quit)
+	n.cancel()
+	n.wg.Wait()
+}
+
+// handleBlockchainNotification queues the notifications of blocks connected
+// to and disconnected from the main chain.  They are handled later by the
+// dispatch handler, as the chain is locked at this time.
+func (n *webhookNotifier) handleBlockchainNotification(notification *blockchain.Notification) {
+	switch notification.Type {
+	case blockchain.NTBlockConnected, blockchain.NTBlockDisconnected:
+		select {
+		case n.queueNotification <- notification:
+		case <-n.quit:
+		}
+	}
+}
+
+// NotifyNewTransactions queues the transactions accepted to the mempool.
+func (n *webhookNotifier) NotifyNewTransactions(txns []*mempool.TxDesc) {
+	if len(n.watched) == 0 {
+		return
+	}
+	select {
+	case n.queueNotification <- txns:
+	case <-n.quit:
+	}
+}
+
+// NotifyDiskSpace posts the free space of the volumes of the databases when
+// their worst status changes.  Unlike the chain events, it is posted while the
+// chain is syncing.
+func (n *webhookNotifier) NotifyDiskSpace(event *diskSpaceEvent) {
+	n.post(webhookDiskSpace, event)
+}
+
+// dispatchHandler turns the queued notifications into events for the
+// webhooks.
+//
+// This must be run as a goroutine.
+func (n *webhookNotifier) dispatchHandler() {
+	defer n.wg.Done()
+
+	for {
+		select {
+		case ntfn, ok := <-n.notifications:
+			if !ok {
+				return
+			}
+			switch ntfn := ntfn.(type) {
+			case *blockchain.Notification:
+				block, ok := ntfn.Data.(*btcutil.Block)
+				if !ok {
+					break
+				}
+				// Don't flood the webhooks while syncing.
+				if !n.cfg.Chain.IsCurrent() {
+					break
+				}
+				if ntfn.Type == blockchain.NTBlockConnected {
+					n.blockConnected(block)
+				} else {
+					n.blockDisconnected(block)
+				}
+
+			case []*mempool.TxDesc:
+				for _, txD := range ntfn {
+					n.notifyTx(txD.Tx, nil)
+				}
+			}
+
+		case <-n.quit:
+			return
+		}
+	}
+}
+
+func newBlockEventData(block *btcutil.Block) *blockEventData {
+	header := &block.MsgBlock().Header
+	return &blockEventData{
+		Hash:         block.Hash().String(),
+		Height:       block.Height(),
+		PreviousHash: header.PrevBlock.String(),
+		Time:         header.Timestamp.Unix(),
+	}
+}
+
+// blockConnected posts the events of a block connected to the main chain.
+func (n *webhookNotifier) blockConnected(block *btcutil.Block) {
+	n.post(webhookBlock, newBlockEventData(block))
+
+	if len(n.watched) > 0 {
+		for _, tx := range block.Transactions() {
+			n.notifyTx(tx, block)
+		}
+		// The spent outputs are no longer needed once confirmed.
+		for _, tx := range block.Transactions() {
+			for _, txIn := range tx.MsgTx().TxIn {
+				delete(n.unspent, txIn.PreviousOutPoint)
+			}
+		}
+		n.pruneUnspent()
+	}
+
+	height := block.Height()
+	names, err := n.cfg.Chain.GetNamesChangedInBlock(height)
+	if err != nil {
+		hookLog.Errorf("Failed to retrieve the claim changes of block "+
+			"%v: %v", block.Hash(), err)
+		return
+	}
+	for _, name := range names {
+		normalized, node, err := n.cfg.Chain.GetClaimsForName(height, name)
+		if err != nil || node.BestClaim == nil || node.TakenOverAt != height {
+			continue
+		}
+		n.post(webhookTakeover, &webhookTakeoverData{
+			Name:      normalized,
+			ClaimID:   node.BestClaim.ClaimID.String(),
+			BlockHash: block.Hash().String(),
+			Height:    height,
+		})
+	}
+}
+
+// blockDisconnected posts the reorg event of a block disconnected from the main
+// chain.  The outputs of its transactions are unconfirmed again, and pruned
+// with the next block unless they returned to the mempool.
+func (n *webhookNotifier) blockDisconnected(block *btcutil.Block) {
+	n.post(webhookReorg, newBlockEventData(block))
+
+	for _, tx := range block.Transactions() {
+		for i := range tx.MsgTx().TxOut {
+			op := wire.OutPoint{Hash: *tx.Hash(), Index: uint32(i)}
+			if output, ok := n.unspent[op]; ok {
+				output.confirmed = false
+			}
+		}
+	}
+}
+
+// pruneUnspent removes the unconfirmed outputs whose transaction is no longer
+// in the mempool, as it was evicted, expired or replaced, so the outputs of
+// the transactions which are never mined don't accumulate.
+func (n *webhookNotifier) pruneUnspent() {
+	if n.cfg.HaveTransaction == nil {
+		return
+	}
+	for op, output := range n.unspent {
+		if !output.confirmed && !n.cfg.HaveTransaction(&op.Hash) {
+			delete(n.unspent, op)
+		}
+	}
+}
+
+// notifyTx posts an addresstx event if the transaction pays to, or spends an
+// output paying to, a watched address.  Spends are only recognized for outputs
+// seen since startup.  The block is nil for transactions in the mempool.
+func (n *webhookNotifier) notifyTx(tx *btcutil.Tx, block *btcutil.Block) {
+	matched := make(map[string]struct{})
+	for _, txIn := range tx.MsgTx().TxIn {
+		if output, ok := n.unspent[txIn.PreviousOutPoint]; ok {
+			matched[output.addr] = struct{}{}
+		}
+	}
+	for i, txOut := range tx.MsgTx().TxOut {
+		_, addrs, _, _ := txscript.ExtractPkScriptAddrs(txOut.PkScript,
+			n.cfg.ChainParams)
+		for _, addr := range addrs {
+			encoded := addr.EncodeAddress()
+			if _, ok := n.watched[encoded]; !ok {
+				continue
+			}
+			matched[encoded] = struct{}{}
+			op := wire.OutPoint{Hash: *tx.Hash(), Index: uint32(i)}
+			n.unspent[op] = &webhookOutput{
+				addr:      encoded,
+				confirmed: block != nil,
+			}
+		}
+	}
+	if len(matched) == 0 {
+		return
+	}
+
+	data := &webhookTxData{Txid: tx.Hash().String()}
+	for addr := range matched {
+		data.Addresses = append(data.Addresses, addr)
+	}
+	sort.Strings(data.Addresses)
+	if block != nil {
+		data.BlockHash = block.Hash().String()
+		data.Height = block.Height()
+	}
+	n.post(webhookAddressTx, data)
+}
+
+// post queues an event to the webhooks, dropping it for those whose queue is
+// full.
+func (n *webhookNotifier) post(kind string, data interface{}) {
+	body, err := json.Marshal(&webhookEvent{
+		Type: kind,
+		Time: time.Now().Unix(),
+		Data: data,
+	})
+	if err != nil {
+		hookLog.Errorf("Failed to marshal %s webhook event: %v", kind, err)
+		return
+	}
+
+	payload := &webhookPayload{kind: kind, body: body}
+	for _, hook := range n.hooks {
+		select {
+		case hook.queue <- payload:
+		default:
+			hookLog.Warnf("Dropping %s event to webhook %s, which is "+
+				"falling behind", kind, hook.url)
+		}
+	}
+}
+
+// deliveryHandler posts the queued events to a webhook in order.
+//
+// This must be run as a goroutine.
+func (n *webhookNotifier) deliveryHandler(hook *webhook) {
+	defer n.wg.Done()
+
+	for {
+		select {
+		case payload := <-hook.queue:
+			n.deliver(hook.url, payload, time.Second)
+
+		case <-n.quit:
+			return
+		}
+	}
+}
+
+// deliver posts a payload to url, retrying with an exponential backoff from
+// the passed initial wait on failure.
+func (n *webhookNotifier) deliver(url string, payload *webhookPayload, backoff time.Duration) {
+	for attempt := 1; ; attempt++ {
+		err := n.send(url, payload)
+		if err == nil {
+			return
+		}
+		if attempt == webhookMaxAttempts {
+			hookLog.Warnf("Dropping %s event to webhook %s after %d "+
+				"attempts: %v", payload.kind, url, attempt, err)
+			return
+		}
+		hookLog.Debugf("Failed to post %s event to webhook %s, retrying "+
+			"in %v: %v", payload.kind, url, backoff, err)
+
+		select {
+		case <-time.After(backoff):
+		case <-n.quit:
+			return
+		}
+		backoff *= 2
+		if backoff > webhookMaxBackoff {
+			backoff = webhookMaxBackoff
+		}
+	}
+}
+
+// send makes a single attempt to post a payload to url.  Any 2xx status is a
+// successful delivery.
+func (n *webhookNotifier) send(url string, payload *webhookPayload) error {
+	req, err := http.NewRequestWithContext(n.ctx, http.MethodPost, url,
+		bytes.NewReader(payload.body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgentName+"/"+userAgentVersion)
+	req.Header.Set("X-Lbcd-Event", payload.kind)
+	if len(n.cfg.Secret) > 0 {
+		req.Header.Set("X-Lbcd-Signature",
+			"sha256="+webhookSignature(n.cfg.Secret, payload.body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 1<<16))
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// webhookSignature returns the hex-encoded HMAC-SHA256 of body keyed by
+// secret.
+func webhookSignature(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btclog"
+	"github.com/lbryio/lbcd/chaincfg"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+	"github.com/lbryio/lbcd/txscript"
+	"github.com/lbryio/lbcd/wire"
+	btcutil "github.com/lbryio/lbcutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookDeliver(t *testing.T) {
+
+	r := require.New(t)
+
+	// The log rotator isn't initialized by the tests.
+	defer func(log btclog.Logger) { hookLog = log }(hookLog)
+	hookLog = btclog.Disabled
+
+	var bodies [][]byte
+	var signatures, events []string
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		bodies = append(bodies, body)
+		signatures = append(signatures, req.Header.Get("X-Lbcd-Signature"))
+		events = append(events, req.Header.Get("X-Lbcd-Event"))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer hook.Close()
+
+	n := &webhookNotifier{
+		cfg:    webhookNotifierConfig{Secret: []byte("secret")},
+		client: hook.Client(),
+		ctx:    context.Background(),
+		quit:   make(chan struct{}),
+	}
+	payload := &webhookPayload{kind: "block", body: []byte(`{"type":"block"}`)}
+	n.deliver(hook.URL, payload, time.Millisecond)
+
+	// The first attempt is retried.
+	r.Len(bodies, 2)
+	r.Equal(payload.body, bodies[1])
+	r.Equal("sha256=43c9092a02f55ebd93076d9a72cade4f23f6aae45aa03c597bd36c69f1d3c95f", signatures[1])
+	r.Equal(signatures[0], signatures[1])
+	r.Equal([]string{"block", "block"}, events)
+}
+
+func TestWebhookNotifyTx(t *testing.T) {
+
+	r := require.New(t)
+
+	params := &chaincfg.RegressionNetParams
+	addr, err := btcutil.DecodeAddress("mvSvTtvD9H9fkgi8MGDyLALgRaR2LhnWFM", params)
+	r.NoError(err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	r.NoError(err)
+
+	hook := &webhook{queue: make(chan *webhookPayload, 2)}
+	n := &webhookNotifier{
+		cfg:     webhookNotifierConfig{ChainParams: params},
+		hooks:   []*webhook{hook},
+		watched: map[string]struct{}{addr.EncodeAddress(): {}},
+		unspent: make(map[wire.OutPoint]*webhookOutput),
+	}
+
+	pay := wire.NewMsgTx(1)
+	pay.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, nil, nil))
+	pay.AddTxOut(wire.NewTxOut(1, []byte{txscript.OP_TRUE}))
+	pay.AddTxOut(wire.NewTxOut(2, pkScript))
+	payTx := btcutil.NewTx(pay)
+	n.notifyTx(payTx, nil)
+
+	spend := wire.NewMsgTx(1)
+	spend.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: *payTx.Hash(), Index: 1}, nil, nil))
+	spend.AddTxOut(wire.NewTxOut(1, []byte{txscript.OP_TRUE}))
+	n.notifyTx(btcutil.NewTx(spend), nil)
+
+	// Unrelated transactions aren't notified.
+	n.notifyTx(btcutil.NewTx(wire.NewMsgTx(1)), nil)
+	r.Len(hook.queue, 2)
+
+	for _, txid := range []string{payTx.Hash().String(), spend.TxHash().String()} {
+		var event struct {
+			Type string
+			Data webhookTxData
+		}
+		r.NoError(json.Unmarshal((<-hook.queue).body, &event))
+		r.Equal(webhookAddressTx, event.Type)
+		r.Equal(txid, event.Data.Txid)
+		r.Equal([]string{addr.EncodeAddress()}, event.Data.Addresses)
+		r.Empty(event.Data.BlockHash)
+	}
+}
+
+func TestWebhookPruneUnspent(t *testing.T) {
+
+	r := require.New(t)
+
+	params := &chaincfg.RegressionNetParams
+	addr, err := btcutil.DecodeAddress("mvSvTtvD9H9fkgi8MGDyLALgRaR2LhnWFM", params)
+	r.NoError(err)
+	pkScript, err := txscript.PayToAddrScript(addr)
+	r.NoError(err)
+
+	pool := make(map[chainhash.Hash]bool)
+	n := &webhookNotifier{
+		cfg: webhookNotifierConfig{
+			ChainParams: params,
+			HaveTransaction: func(hash *chainhash.Hash) bool {
+				return pool[*hash]
+			},
+		},
+		hooks:   []*webhook{{queue: make(chan *webhookPayload, 10)}},
+		watched: map[string]struct{}{addr.EncodeAddress(): {}},
+		unspent: make(map[wire.OutPoint]*webhookOutput),
+	}
+
+	newPayTx := func(lockTime uint32) *btcutil.Tx {
+		tx := wire.NewMsgTx(1)
+		tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, nil, nil))
+		tx.AddTxOut(wire.NewTxOut(1, pkScript))
+		tx.LockTime = lockTime
+		return btcutil.NewTx(tx)
+	}
+	pending, evicted, mined := newPayTx(1), newPayTx(2), newPayTx(3)
+	pool[*pending.Hash()] = true
+	n.notifyTx(pending, nil)
+	n.notifyTx(evicted, nil)
+	n.notifyTx(mined, nil)
+
+	block := btcutil.NewBlock(wire.NewMsgBlock(&wire.BlockHeader{}))
+	block.MsgBlock().AddTransaction(mined.MsgTx())
+	n.notifyTx(mined, block)
+
+	// Only the outputs of the transactions which left the mempool without
+	// being mined are pruned.
+	n.pruneUnspent()
+	r.Len(n.unspent, 2)
+	r.Contains(n.unspent, wire.OutPoint{Hash: *pending.Hash()})
+	r.Contains(n.unspent, wire.OutPoint{Hash: *mined.Hash()})
+
+	// The outputs of a disconnected block are pruned unless they return to
+	// the mempool.
+	n.blockDisconnected(block)
+	n.pruneUnspent()
+	r.Len(n.unspent, 1)
+	r.Contains(n.unspent, wire.OutPoint{Hash: *pending.Hash()})
+}
@@ -186,9 +186,10 @@ func BenchmarkReadOutPoint(b *testing.B) {
 	}
 	r := bytes.NewReader(buf)
 	var op OutPoint
+	scratch := binarySerializer.Borrow()
 	for i := 0; i < b.N; i++ {
 		r.Seek(0, 0)
-		readOutPoint(r, 0, 0, &op)
+		readOutPointBuf(r, 0, 0, &op, scratch)
 	}
 }
 
@@ -199,8 +200,9 @@ func BenchmarkWriteOutPoint(b *testing.B) {
 		Hash:  chainhash.Hash{},
 		Index: 0,
 	}
+	scratch := binarySerializer.Borrow()
 	for i := 0; i < b.N; i++ {
-		writeOutPoint(ioutil.Discard, 0, 0, op)
+		writeOutPointBuf(ioutil.Discard, 0, 0, op, scratch)
 	}
 }
 
@@ -224,10 +226,12 @@ func BenchmarkReadTxOut(b *testing.B) {
 	}
 	r := bytes.NewReader(buf)
 	var txOut TxOut
+	var arena scriptArena
+	scratch := binarySerializer.Borrow()
 	for i := 0; i < b.N; i++ {
 		r.Seek(0, 0)
-		readTxOut(r, 0, 0, &txOut)
-		scriptPool.Return(txOut.PkScript)
+		readTxOutBuf(r, 0, 0, &txOut, scratch, &arena)
+		arena.release()
 	}
 }
 
@@ -255,10 +259,12 @@ func BenchmarkReadTxIn(b *testing.B) {
 	}
 	r := bytes.NewReader(buf)
 	var txIn TxIn
+	var arena scriptArena
+	scratch := binarySerializer.Borrow()
 	for i := 0; i < b.N; i++ {
 		r.Seek(0, 0)
-		readTxIn(r, 0, 0, &txIn)
-		scriptPool.Return(txIn.SignatureScript)
+		readTxInBuf(r, 0, 0, &txIn, scratch, &arena)
+		arena.release()
 	}
 }
 
@@ -266,8 +272,9 @@ func BenchmarkReadTxIn(b *testing.B) {
 // a transaction input.
 func BenchmarkWriteTxIn(b *testing.B) {
 	txIn := blockOne.Transactions[0].TxIn[0]
+	scratch := binarySerializer.Borrow()
 	for i := 0; i < b.N; i++ {
-		writeTxIn(ioutil.Discard, 0, 0, txIn)
+		writeTxInBuf(ioutil.Discard, 0, 0, txIn, scratch)
 	}
 }
 
@@ -304,6 +311,7 @@ func BenchmarkDeserializeTxSmall(b *testing.B) {
 
 	r := bytes.NewReader(buf)
 	var tx MsgTx
+	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
 		r.Seek(0, 0)
 		tx.Deserialize(r)
@@ -327,6 +335,7 @@ func BenchmarkDeserializeTxLarge(b *testing.B) {
 
 	r := bytes.NewReader(buf)
 	var tx MsgTx
+	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
 		r.Seek(0, 0)
 		tx.Deserialize(r)
@@ -337,12 +346,86 @@ func BenchmarkDeserializeTxLarge(b *testing.B) {
 // a transaction.
 func BenchmarkSerializeTx(b *testing.B) {
 	tx := blockOne.Transactions[0]
+	b.ReportAllocs()
 	for i := 0; i < b.N; i++ {
 		tx.Serialize(ioutil.Discard)
 
 	}
 }
 
+// manyTxBlock returns a block holding 1000 copies of a multi input and output
+// witness transaction.
+func manyTxBlock() *MsgBlock {
+	block := NewMsgBlock(&blockOne.Header)
+	for i := 0; i < 1000; i++ {
+		block.AddTransaction(multiWitnessTx)
+	}
+	return block
+}
+
+// BenchmarkDeserializeBlock performs a benchmark on how long it takes to
+// deserialize a block with many transactions.
+func BenchmarkDeserializeBlock(b *testing.B) {
+	var buf bytes.Buffer
+	if err := manyTxBlock().Serialize(&buf); err != nil {
+		b.Fatalf("Serialize: unexpected error: %v", err)
+	}
+
+	r := bytes.NewReader(buf.Bytes())
+	var block MsgBlock
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.Seek(0, 0)
+		block.Deserialize(r)
+	}
+}
+
+// BenchmarkSerializeBlock performs a benchmark on how long it takes to
+// serialize a block with many transactions.
+func BenchmarkSerializeBlock(b *testing.B) {
+	block := manyTxBlock()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		block.Serialize(ioutil.Discard)
+	}
+}
+
+// BenchmarkReadBlockMessage performs a benchmark on how long it takes to read
+// block messages with many transactions whose payloads are released once the
+// blocks are decoded.
+func BenchmarkReadBlockMessage(b *testing.B) {
+	var buf bytes.Buffer
+	_, err := WriteMessageWithEncodingN(&buf, manyTxBlock(),
+		ProtocolVersion, MainNet, WitnessEncoding)
+	if err != nil {
+		b.Fatalf("WriteMessage: unexpected error: %v", err)
+	}
+
+	r := bytes.NewReader(buf.Bytes())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.Seek(0, 0)
+		_, _, payload, _ := ReadMessageWithEncodingN(r, ProtocolVersion,
+			MainNet, WitnessEncoding)
+		ReleasePayload(payload)
+	}
+}
+
+// BenchmarkWriteBlockMessage performs a benchmark on how long it takes to
+// write block messages with many transactions.
+func BenchmarkWriteBlockMessage(b *testing.B) {
+	block := manyTxBlock()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		WriteMessageWithEncodingN(ioutil.Discard, block,
+			ProtocolVersion, MainNet, WitnessEncoding)
+	}
+}
+
 // BenchmarkReadBlockHeader performs a benchmark on how long it takes to
 // deserialize a block header.
 func BenchmarkReadBlockHeader(b *testing.B) {
@@ -18,6 +18,10 @@ import (
 const (
 	// MaxVarIntPayload is the maximum payload size for a variable length integer.
 	MaxVarIntPayload = 9
+
+	// binaryFreeListMaxItems is the number of buffers to keep in the free
+	// list to use for binary serialization and deserialization.
+	binaryFreeListMaxItems = 1024
 )
 
 var (
@@ -40,62 +44,78 @@ var (
 // For convenience, functions are provided for each of the primitive unsigned
 // integers that automatically obtain a buffer from the free list, perform the
 // necessary binary conversion, read from or write to the given io.Reader or
-// io.Writer, and return the buffer to the free list.
+// io.Writer, and return the buffer to the free list.  Callers encoding or
+// decoding many values in a row, such as the transactions, should instead
+// borrow a single buffer and use it for all of them.
 type binaryFreeList chan []byte
 
+// Borrow returns a byte slice from the free list with a length of 8.  A new
+// buffer is allocated if there are not any available on the free list.
+func (l binaryFreeList) Borrow() []byte {
+	var buf []byte
+	select {
+	case buf = <-l:
+	default:
+		buf = make([]byte, 8)
+	}
+	return buf[:8]
+}
+
+// Return puts the provided byte slice back on the free list.  The buffer MUST
+// have been obtained via the Borrow function and therefore have a cap of 8.
+func (l binaryFreeList) Return(buf []byte) {
+	select {
+	case l <- buf:
+	default:
+		// Let it go to the garbage collector.
+	}
+}
+
 // Uint8 reads a single byte from the provided reader using a buffer from the
 // free list and returns it as a uint8.
 func (l binaryFreeList) Uint8(r io.Reader) (uint8, error) {
-	buf := make([]byte, 1) // should be allocated on the stack
-	if _, err := io.ReadFull(r, buf); err != nil {
-		return 0, err
-	}
-	rv := buf[0]
-	return rv, nil
+	buf := l.Borrow()
+	rv, err := readUint8(r, buf)
+	l.Return(buf)
+	return rv, err
 }
 
 // Uint16 reads two bytes from the provided reader using a buffer from the
 // free list, converts it to a number using the provided byte order, and returns
 // the resulting uint16.
 func (l binaryFreeList) Uint16(r io.Reader, byteOrder binary.ByteOrder) (uint16, error) {
-	buf := make([]byte, 2) // should be allocated on the stack
-	if _, err := io.ReadFull(r, buf); err != nil {
-		return 0, err
-	}
-	rv := byteOrder.Uint16(buf)
-	return rv, nil
+	buf := l.Borrow()
+	rv, err := readUint16(r, buf, byteOrder)
+	l.Return(buf)
+	return rv, err
 }
 
 // Uint32 reads four bytes from the provided reader using a buffer from the
 // free list, converts it to a number using the provided byte order, and returns
 // the resulting uint32.
 func (l binaryFreeList) Uint32(r io.Reader, byteOrder binary.ByteOrder) (uint32, error) {
-	buf := make([]byte, 4) // should be allocated on the stack
-	if _, err := io.ReadFull(r, buf); err != nil {
-		return 0, err
-	}
-	rv := byteOrder.Uint32(buf)
-	return rv, nil
+	buf := l.Borrow()
+	rv, err := readUint32(r, buf, byteOrder)
+	l.Return(buf)
+	return rv, err
 }
 
 // Uint64 reads eight bytes from the provided reader using a buffer from the
 // free list, converts it to a number using the provided byte order, and returns
 // the resulting uint64.
 func (l binaryFreeList) Uint64(r io.Reader, byteOrder binary.ByteOrder) (uint64, error) {
-	buf := make([]byte, 8) // should be allocated on the stack
-	if _, err := io.ReadFull(r, buf); err != nil {
-		return 0, err
-	}
-	rv := byteOrder.Uint64(buf)
-	return rv, nil
+	buf := l.Borrow()
+	rv, err := readUint64(r, buf, byteOrder)
+	l.Return(buf)
+	return rv, err
 }
 
 // PutUint8 copies the provided uint8 into a buffer from the free list and
 // writes the resulting byte to the given writer.
 func (l binaryFreeList) PutUint8(w io.Writer, val uint8) error {
-	buf := make([]byte, 1) // should be allocated on the stack
-	buf[0] = val
-	_, err := w.Write(buf)
+	buf := l.Borrow()
+	err := writeUint8(w, buf, val)
+	l.Return(buf)
 	return err
 }
 
@@ -103,9 +123,9 @@ func (l binaryFreeList) PutUint8(w io.Writer, val uint8) error {
 // buffer from the free list and writes the resulting two bytes to the given
 // writer.
 func (l binaryFreeList) PutUint16(w io.Writer, byteOrder binary.ByteOrder, val uint16) error {
-	buf := make([]byte, 2) // should be allocated on the stack
-	byteOrder.PutUint16(buf, val)
-	_, err := w.Write(buf)
+	buf := l.Borrow()
+	err := writeUint16(w, buf, byteOrder, val)
+	l.Return(buf)
 	return err
 }
 
@@ -113,9 +133,9 @@ func (l binaryFreeList) PutUint16(w io.Writer, byteOrder binary.ByteOrder, val u
 // buffer from the free list and writes the resulting four bytes to the given
 // writer.
 func (l binaryFreeList) PutUint32(w io.Writer, byteOrder binary.ByteOrder, val uint32) error {
-	buf := make([]byte, 4) // should be allocated on the stack
-	byteOrder.PutUint32(buf, val)
-	_, err := w.Write(buf)
+	buf := l.Borrow()
+	err := writeUint32(w, buf, byteOrder, val)
+	l.Return(buf)
 	return err
 }
 
@@ -123,15 +143,83 @@ func (l binaryFreeList) PutUint32(w io.Writer, byteOrder binary.ByteOrder, val u
 // buffer from the free list and writes the resulting eight bytes to the given
 // writer.
 func (l binaryFreeList) PutUint64(w io.Writer, byteOrder binary.ByteOrder, val uint64) error {
-	buf := make([]byte, 8) // should be allocated on the stack
+	buf := l.Borrow()
+	err := writeUint64(w, buf, byteOrder, val)
+	l.Return(buf)
+	return err
+}
+
+// readUint8 reads a single byte from the provided reader into buf and returns
+// it as a uint8.  The buffer must be at least 1 byte long.
+func readUint8(r io.Reader, buf []byte) (uint8, error) {
+	if _, err := io.ReadFull(r, buf[:1]); err != nil {
+		return 0, err
+	}
+	return buf[0], nil
+}
+
+// readUint16 reads two bytes from the provided reader into buf and returns the
+// resulting uint16.  The buffer must be at least 2 bytes long.
+func readUint16(r io.Reader, buf []byte, byteOrder binary.ByteOrder) (uint16, error) {
+	if _, err := io.ReadFull(r, buf[:2]); err != nil {
+		return 0, err
+	}
+	return byteOrder.Uint16(buf), nil
+}
+
+// readUint32 reads four bytes from the provided reader into buf and returns
+// the resulting uint32.  The buffer must be at least 4 bytes long.
+func readUint32(r io.Reader, buf []byte, byteOrder binary.ByteOrder) (uint32, error) {
+	if _, err := io.ReadFull(r, buf[:4]); err != nil {
+		return 0, err
+	}
+	return byteOrder.Uint32(buf), nil
+}
+
+// readUint64 reads eight bytes from the provided reader into buf and returns
+// the resulting uint64.  The buffer must be at least 8 bytes long.
+func readUint64(r io.Reader, buf []byte, byteOrder binary.ByteOrder) (uint64, error) {
+	if _, err := io.ReadFull(r, buf[:8]); err != nil {
+		return 0, err
+	}
+	return byteOrder.Uint64(buf), nil
+}
+
+// writeUint8 writes the provided uint8 to w using buf, which must be at least
+// 1 byte long.
+func writeUint8(w io.Writer, buf []byte, val uint8) error {
+	buf[0] = val
+	_, err := w.Write(buf[:1])
+	return err
+}
+
+// writeUint16 serializes the provided uint16 into buf, which must be at least
+// 2 bytes long, and writes it to w.
+func writeUint16(w io.Writer, buf []byte, byteOrder binary.ByteOrder, val uint16) error {
+	byteOrder.PutUint16(buf, val)
+	_, err := w.Write(buf[:2])
+	return err
+}
+
+// writeUint32 serializes the provided uint32 into buf, which must be at least
+// 4 bytes long, and writes it to w.
+func writeUint32(w io.Writer, buf []byte, byteOrder binary.ByteOrder, val uint32) error {
+	byteOrder.PutUint32(buf, val)
+	_, err := w.Write(buf[:4])
+	return err
+}
+
+// writeUint64 serializes the provided uint64 into buf, which must be at least
+// 8 bytes long, and writes it to w.
+func writeUint64(w io.Writer, buf []byte, byteOrder binary.ByteOrder, val uint64) error {
 	byteOrder.PutUint64(buf, val)
-	_, err := w.Write(buf)
+	_, err := w.Write(buf[:8])
 	return err
 }
 
 // binarySerializer provides a free list of buffers to use for serializing and
 // deserializing primitive integer values to and from io.Readers and io.Writers.
-var binarySerializer binaryFreeList = make(chan []byte)
+var binarySerializer binaryFreeList = make(chan []byte, binaryFreeListMaxItems)
 
 // errNonCanonicalVarInt is the common format string used for non-canonically
 // encoded variable length integer errors.
@@ -436,7 +524,16 @@ func writeElements(w io.Writer, elements ...interface{}) error {
 
 // ReadVarInt reads a variable length integer from r and returns it as a uint64.
 func ReadVarInt(r io.Reader, pver uint32) (uint64, error) {
-	discriminant, err := binarySerializer.Uint8(r)
+	buf := binarySerializer.Borrow()
+	rv, err := readVarIntBuf(r, pver, buf)
+	binarySerializer.Return(buf)
+	return rv, err
+}
+
+// readVarIntBuf reads a variable length integer from r using buf, which must
+// be at least 8 bytes long, and returns it as a uint64.
+func readVarIntBuf(r io.Reader, pver uint32, buf []byte) (uint64, error) {
+	discriminant, err := readUint8(r, buf)
 	if err != nil {
 		return 0, err
 	}
@@ -444,7 +541,7 @@ func ReadVarInt(r io.Reader, pver uint32) (uint64, error) {
 	var rv uint64
 	switch discriminant {
 	case 0xff:
-		sv, err := binarySerializer.Uint64(r, littleEndian)
+		sv, err := readUint64(r, buf, littleEndian)
 		if err != nil {
 			return 0, err
 		}
@@ -459,7 +556,7 @@ func ReadVarInt(r io.Reader, pver uint32) (uint64, error) {
 		}
 
 	case 0xfe:
-		sv, err := binarySerializer.Uint32(r, littleEndian)
+		sv, err := readUint32(r, buf, littleEndian)
 		if err != nil {
 			return 0, err
 		}
@@ -474,7 +571,7 @@ func ReadVarInt(r io.Reader, pver uint32) (uint64, error) {
 		}
 
 	case 0xfd:
-		sv, err := binarySerializer.Uint16(r, littleEndian)
+		sv, err := readUint16(r, buf, littleEndian)
 		if err != nil {
 			return 0, err
 		}
@@ -498,31 +595,40 @@ func ReadVarInt(r io.Reader, pver uint32) (uint64, error) {
 // WriteVarInt serializes val to w using a variable number of bytes depending
 // on its value.
 func WriteVarInt(w io.Writer, pver uint32, val uint64) error {
+	buf := binarySerializer.Borrow()
+	err := writeVarIntBuf(w, pver, val, buf)
+	binarySerializer.Return(buf)
+	return err
+}
+
+// writeVarIntBuf serializes val to w using buf, which must be at least 8 bytes
+// long.
+func writeVarIntBuf(w io.Writer, pver uint32, val uint64, buf []byte) error {
 	if val < 0xfd {
-		return binarySerializer.PutUint8(w, uint8(val))
+		return writeUint8(w, buf, uint8(val))
 	}
 
 	if val <= math.MaxUint16 {
-		err := binarySerializer.PutUint8(w, 0xfd)
+		err := writeUint8(w, buf, 0xfd)
 		if err != nil {
 			return err
 		}
-		return binarySerializer.PutUint16(w, littleEndian, uint16(val))
+		return writeUint16(w, buf, littleEndian, uint16(val))
 	}
 
 	if val <= math.MaxUint32 {
-		err := binarySerializer.PutUint8(w, 0xfe)
+		err := writeUint8(w, buf, 0xfe)
 		if err != nil {
 			return err
 		}
-		return binarySerializer.PutUint32(w, littleEndian, uint32(val))
+		return writeUint32(w, buf, littleEndian, uint32(val))
 	}
 
-	err := binarySerializer.PutUint8(w, 0xff)
+	err := writeUint8(w, buf, 0xff)
 	if err != nil {
 		return err
 	}
-	return binarySerializer.PutUint64(w, littleEndian, val)
+	return writeUint64(w, buf, littleEndian, val)
 }
 
 // VarIntSerializeSize returns the number of bytes it would take to serialize
@@ -624,8 +730,17 @@ func ReadVarBytes(r io.Reader, pver uint32, maxAllowed uint32,
 // WriteVarBytes serializes a variable length byte array to w as a varInt
 // containing the number of bytes, followed by the bytes themselves.
 func WriteVarBytes(w io.Writer, pver uint32, bytes []byte) error {
+	buf := binarySerializer.Borrow()
+	err := writeVarBytesBuf(w, pver, bytes, buf)
+	binarySerializer.Return(buf)
+	return err
+}
+
+// writeVarBytesBuf serializes a variable length byte array to w using buf,
+// which must be at least 8 bytes long, for the varInt.
+func writeVarBytesBuf(w io.Writer, pver uint32, bytes, buf []byte) error {
 	slen := uint64(len(bytes))
-	err := WriteVarInt(w, pver, slen)
+	err := writeVarIntBuf(w, pver, slen, buf)
 	if err != nil {
 		return err
 	}
@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"sync"
 	"unicode/utf8"
 
 	"github.com/lbryio/lbcd/chaincfg/chainhash"
@@ -26,38 +27,83 @@ const CommandSize = 12
 // individual limits imposed by messages themselves.
 const MaxMessagePayload = (1024 * 1024 * 32) // 32MB
 
+// maxPooledEncodeBuffer is the capacity up to which the buffers messages are
+// encoded into are kept in encodeBufferPool for reuse.
+const maxPooledEncodeBuffer = MaxBlockPayload
+
+// encodeBufferPool holds the buffers the messages are encoded into before they
+// are written along with their header, so that peers serving one block after
+// the other, such as to a syncing peer, don't allocate one for each of them.
+var encodeBufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// blockPayloadPool holds the buffers the payloads of block messages are read
+// into, as released by ReleasePayload once the blocks are processed.
+var blockPayloadPool sync.Pool
+
+// borrowBlockPayload returns a buffer of the passed size to read the payload of
+// a block message into, from blockPayloadPool when it holds a large enough one.
+func borrowBlockPayload(size uint32) []byte {
+	if payload, ok := blockPayloadPool.Get().(*[]byte); ok &&
+		uint32(cap(*payload)) >= size {
+
+		return (*payload)[:size]
+	}
+	return make([]byte, size)
+}
+
+// ReleasePayload hands the raw payload of a block message, as returned by
+// ReadMessageWithEncodingN and the functions wrapping it, back to be reused for
+// the payload of a later block message.
+//
+// The payload must not be referenced once it is released, including through a
+// btcutil.Block created from it, since it is overwritten by the next block read
+// into it.  Releasing a payload is optional, and those never released are
+// garbage collected as usual.
+func ReleasePayload(payload []byte) {
+	payload = payload[:0]
+	blockPayloadPool.Put(&payload)
+}
+
 // Commands used in bitcoin message headers which describe the type of message.
 const (
-	CmdVersion      = "version"
-	CmdVerAck       = "verack"
-	CmdGetAddr      = "getaddr"
-	CmdAddr         = "addr"
-	CmdGetBlocks    = "getblocks"
-	CmdInv          = "inv"
-	CmdGetData      = "getdata"
-	CmdNotFound     = "notfound"
-	CmdBlock        = "block"
-	CmdTx           = "tx"
-	CmdGetHeaders   = "getheaders"
-	CmdHeaders      = "headers"
-	CmdPing         = "ping"
-	CmdPong         = "pong"
-	CmdAlert        = "alert"
-	CmdMemPool      = "mempool"
-	CmdFilterAdd    = "filteradd"
-	CmdFilterClear  = "filterclear"
-	CmdFilterLoad   = "filterload"
-	CmdMerkleBlock  = "merkleblock"
-	CmdReject       = "reject"
-	CmdSendHeaders  = "sendheaders"
-	CmdFeeFilter    = "feefilter"
-	CmdGetCFilters  = "getcfilters"
-	CmdGetCFHeaders = "getcfheaders"
-	CmdGetCFCheckpt = "getcfcheckpt"
-	CmdCFilter      = "cfilter"
-	CmdCFHeaders    = "cfheaders"
-	CmdCFCheckpt    = "cfcheckpt"
-	CmdSendAddrV2   = "sendaddrv2"
+	CmdVersion               = "version"
+	CmdVerAck                = "verack"
+	CmdGetAddr               = "getaddr"
+	CmdAddr                  = "addr"
+	CmdGetBlocks             = "getblocks"
+	CmdInv                   = "inv"
+	CmdGetData               = "getdata"
+	CmdNotFound              = "notfound"
+	CmdBlock                 = "block"
+	CmdTx                    = "tx"
+	CmdGetHeaders            = "getheaders"
+	CmdHeaders               = "headers"
+	CmdPing                  = "ping"
+	CmdPong                  = "pong"
+	CmdAlert                 = "alert"
+	CmdMemPool               = "mempool"
+	CmdFilterAdd             = "filteradd"
+	CmdFilterClear           = "filterclear"
+	CmdFilterLoad            = "filterload"
+	CmdMerkleBlock           = "merkleblock"
+	CmdReject                = "reject"
+	CmdSendHeaders           = "sendheaders"
+	CmdFeeFilter             = "feefilter"
+	CmdGetCFilters           = "getcfilters"
+	CmdGetCFHeaders          = "getcfheaders"
+	CmdGetCFCheckpt          = "getcfcheckpt"
+	CmdCFilter               = "cfilter"
+	CmdCFHeaders             = "cfheaders"
+	CmdCFCheckpt             = "cfcheckpt"
+	CmdSendAddrV2            = "sendaddrv2"
+	CmdGetClaimProof         = "getclmproof"
+	CmdClaimProof            = "clmproof"
+	CmdGetClaimSnapshot      = "getctsnap"
+	CmdClaimSnapshot         = "ctsnap"
+	CmdGetClaimSnapshotChunk = "getctchunk"
+	CmdClaimSnapshotChunk    = "ctchunk"
 )
 
 // MessageEncoding represents the wire message encoding format to be used.
@@ -184,6 +230,24 @@ func makeEmptyMessage(command string) (Message, error) {
 	case CmdCFCheckpt:
 		msg = &MsgCFCheckpt{}
 
+	case CmdGetClaimProof:
+		msg = &MsgGetClaimProof{}
+
+	case CmdClaimProof:
+		msg = &MsgClaimProof{}
+
+	case CmdGetClaimSnapshot:
+		msg = &MsgGetClaimSnapshot{}
+
+	case CmdClaimSnapshot:
+		msg = &MsgClaimSnapshot{}
+
+	case CmdGetClaimSnapshotChunk:
+		msg = &MsgGetClaimSnapshotChunk{}
+
+	case CmdClaimSnapshotChunk:
+		msg = &MsgClaimSnapshotChunk{}
+
 	default:
 		return nil, fmt.Errorf("unhandled command [%s]", command)
 	}
@@ -279,9 +343,16 @@ func WriteMessageWithEncodingN(w io.Writer, msg Message, pver uint32,
 	}
 	copy(command[:], []byte(cmd))
 
-	// Encode the message payload.
-	var bw bytes.Buffer
-	err := msg.BtcEncode(&bw, pver, encoding)
+	// Encode the message payload.  The buffer is reused for later messages
+	// once the payload is written.
+	bw := encodeBufferPool.Get().(*bytes.Buffer)
+	defer func() {
+		if bw.Cap() <= maxPooledEncodeBuffer {
+			bw.Reset()
+			encodeBufferPool.Put(bw)
+		}
+	}()
+	err := msg.BtcEncode(bw, pver, encoding)
 	if err != nil {
 		return totalBytes, err
 	}
@@ -340,7 +411,8 @@ func WriteMessageWithEncodingN(w io.Writer, msg Message, pver uint32,
 // number of bytes read in addition to the parsed Message and raw bytes which
 // comprise the message.  This function is the same as ReadMessageN except it
 // allows the caller to specify which message encoding is to to consult when
-// decoding wire messages.
+// decoding wire messages.  The raw bytes of block messages may be handed back
+// with ReleasePayload once they are no longer referenced.
 func ReadMessageWithEncodingN(r io.Reader, pver uint32, btcnet BitcoinNet,
 	enc MessageEncoding) (int, Message, []byte, error) {
 
@@ -395,17 +467,26 @@ func ReadMessageWithEncodingN(r io.Reader, pver uint32, btcnet BitcoinNet,
 		return totalBytes, nil, nil, messageError("ReadMessage", str)
 	}
 
-	// Read payload.
-	payload := make([]byte, hdr.length)
+	// Read payload.  The payloads of blocks are read into the buffers
+	// released by the callers that processed the previous ones, which are
+	// also released here on failure since they aren't returned.
+	var payload []byte
+	if command == CmdBlock {
+		payload = borrowBlockPayload(hdr.length)
+	} else {
+		payload = make([]byte, hdr.length)
+	}
 	n, err = io.ReadFull(r, payload)
 	totalBytes += n
 	if err != nil {
+		releaseBlockPayload(command, payload)
 		return totalBytes, nil, nil, err
 	}
 
 	// Test checksum.
 	checksum := chainhash.DoubleHashB(payload)[0:4]
 	if !bytes.Equal(checksum, hdr.checksum[:]) {
+		releaseBlockPayload(command, payload)
 		str := fmt.Sprintf("payload checksum failed - header "+
 			"indicates %v, but actual checksum is %v.",
 			hdr.checksum, checksum)
@@ -417,12 +498,21 @@ func ReadMessageWithEncodingN(r io.Reader, pver uint32, btcnet BitcoinNet,
 	pr := bytes.NewBuffer(payload)
 	err = msg.BtcDecode(pr, pver, enc)
 	if err != nil {
+		releaseBlockPayload(command, payload)
 		return totalBytes, nil, nil, err
 	}
 
 	return totalBytes, msg, payload, nil
 }
 
+// releaseBlockPayload releases the payload read for a message of the command
+// when it is a block.
+func releaseBlockPayload(command string, payload []byte) {
+	if command == CmdBlock {
+		ReleasePayload(payload)
+	}
+}
+
 // ReadMessageN reads, validates, and parses the next bitcoin Message from r for
 // the provided protocol version and bitcoin network.  It returns the number of
 // bytes read in addition to the parsed Message and raw bytes which comprise the
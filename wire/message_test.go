@@ -76,6 +76,16 @@ func TestMessage(t *testing.T) {
 		[]byte("payload"))
 	msgCFHeaders := NewMsgCFHeaders()
 	msgCFCheckpt := NewMsgCFCheckpt(GCSFilterRegular, &chainhash.Hash{}, 0)
+	msgGetClaimProof := NewMsgGetClaimProof("name")
+	msgClaimProof := NewMsgClaimProof("name", &chainhash.Hash{})
+	msgClaimProof.AddPair(true, &chainhash.Hash{})
+	msgClaimProof.AddClaimPair(false, &chainhash.Hash{})
+	msgGetClaimSnapshot := NewMsgGetClaimSnapshot()
+	msgClaimSnapshot := NewMsgClaimSnapshot(1, &chainhash.Hash{}, &chainhash.Hash{})
+	msgClaimSnapshot.AddChunkHash(&chainhash.Hash{})
+	msgGetClaimSnapshotChunk := NewMsgGetClaimSnapshotChunk(&chainhash.Hash{}, 0)
+	msgClaimSnapshotChunk := NewMsgClaimSnapshotChunk(&chainhash.Hash{}, 0,
+		[]byte("chunk"))
 
 	tests := []struct {
 		in     Message    // Value to encode
@@ -111,6 +121,12 @@ func TestMessage(t *testing.T) {
 		{msgCFilter, msgCFilter, pver, MainNet, 65},
 		{msgCFHeaders, msgCFHeaders, pver, MainNet, 90},
 		{msgCFCheckpt, msgCFCheckpt, pver, MainNet, 58},
+		{msgGetClaimProof, msgGetClaimProof, pver, MainNet, 29},
+		{msgClaimProof, msgClaimProof, pver, MainNet, 201},
+		{msgGetClaimSnapshot, msgGetClaimSnapshot, pver, MainNet, 24},
+		{msgClaimSnapshot, msgClaimSnapshot, pver, MainNet, 125},
+		{msgGetClaimSnapshotChunk, msgGetClaimSnapshotChunk, pver, MainNet, 60},
+		{msgClaimSnapshotChunk, msgClaimSnapshotChunk, pver, MainNet, 66},
 	}
 
 	t.Logf("Running %d tests", len(tests))
@@ -457,3 +473,44 @@ func TestWriteMessageWireErrors(t *testing.T) {
 		}
 	}
 }
+
+// TestReadMessageReleasePayload ensures the blocks read into released payloads
+// don't reference them, so that they are unaffected by the later blocks read
+// into the same buffers.
+func TestReadMessageReleasePayload(t *testing.T) {
+	pver := ProtocolVersion
+	btcnet := MainNet
+
+	blocks := []*MsgBlock{&blockOne, manyTxBlock(), &blockOne}
+	var buf bytes.Buffer
+	for _, block := range blocks {
+		_, err := WriteMessageWithEncodingN(&buf, block, pver, btcnet,
+			WitnessEncoding)
+		if err != nil {
+			t.Fatalf("WriteMessage: unexpected error: %v", err)
+		}
+	}
+
+	var read []Message
+	for i := range blocks {
+		_, msg, payload, err := ReadMessageWithEncodingN(&buf, pver,
+			btcnet, WitnessEncoding)
+		if err != nil {
+			t.Fatalf("ReadMessage #%d: unexpected error: %v", i, err)
+		}
+		read = append(read, msg)
+		ReleasePayload(payload)
+	}
+	for i, msg := range read {
+		var got, want bytes.Buffer
+		if err := msg.(*MsgBlock).Serialize(&got); err != nil {
+			t.Fatalf("Serialize #%d: unexpected error: %v", i, err)
+		}
+		if err := blocks[i].Serialize(&want); err != nil {
+			t.Fatalf("Serialize #%d: unexpected error: %v", i, err)
+		}
+		if !bytes.Equal(got.Bytes(), want.Bytes()) {
+			t.Errorf("ReadMessage #%d: mismatched block", i)
+		}
+	}
+}
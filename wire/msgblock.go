@@ -81,14 +81,17 @@ func (msg *MsgBlock) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) er
 		return messageError("MsgBlock.BtcDecode", str)
 	}
 
+	// The transactions are allocated in a single block to reduce the
+	// number of allocations the garbage collector needs to track.
+	txs := make([]MsgTx, txCount)
 	msg.Transactions = make([]*MsgTx, 0, txCount)
 	for i := uint64(0); i < txCount; i++ {
-		tx := MsgTx{}
+		tx := &txs[i]
 		err := tx.BtcDecode(r, pver, enc)
 		if err != nil {
 			return err
 		}
-		msg.Transactions = append(msg.Transactions, &tx)
+		msg.Transactions = append(msg.Transactions, tx)
 	}
 
 	return nil
@@ -153,16 +156,17 @@ func (msg *MsgBlock) DeserializeTxLoc(r *bytes.Buffer) ([]TxLoc, error) {
 
 	// Deserialize each transaction while keeping track of its location
 	// within the byte stream.
+	txs := make([]MsgTx, txCount)
 	msg.Transactions = make([]*MsgTx, 0, txCount)
 	txLocs := make([]TxLoc, txCount)
 	for i := uint64(0); i < txCount; i++ {
 		txLocs[i].TxStart = fullLen - r.Len()
-		tx := MsgTx{}
+		tx := &txs[i]
 		err := tx.Deserialize(r)
 		if err != nil {
 			return nil, err
 		}
-		msg.Transactions = append(msg.Transactions, &tx)
+		msg.Transactions = append(msg.Transactions, tx)
 		txLocs[i].TxLen = (fullLen - r.Len()) - txLocs[i].TxStart
 	}
 
@@ -0,0 +1,221 @@
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+)
+
+// MaxClaimProofPairs is the maximum number of pairs in a clmproof message.
+// Each node of the path of a name adds at most the sibling claims hash and
+// the branch of its 256 possible children.
+const MaxClaimProofPairs = 9*MaxClaimProofNameSize + 1
+
+// MaxClaimProofClaimPairs is the maximum number of claim pairs in a clmproof
+// message, which is the length of the merkle branch of 2^32 claims.
+const MaxClaimProofClaimPairs = 32
+
+// ClaimProofPair is one step of the merkle proofs of a clmproof message.
+// Hash is the sibling of the hash being proven, and Odd is set when the proven
+// hash is the right branch.
+type ClaimProofPair struct {
+	Odd  bool
+	Hash chainhash.Hash
+}
+
+// MsgClaimProof implements the Message interface and represents a clmproof
+// message.  It is used to deliver the proof of the claim a name resolves to in
+// response to a getclmproof message (MsgGetClaimProof).
+//
+// The name resolves to BestClaim, the outpoint of the claim controlling it
+// since TakeoverHeight.  The hash of the claim is the double SHA-256 of the
+// double SHA-256 hashes of the transaction hash of BestClaim, of its output
+// index as a decimal string, and of TakeoverHeight as a big-endian uint64.
+// Folding the claim pairs over the hash of the claim gives the claims hash of
+// the name, which merkles the active claims ordered by bid, so claim pairs all
+// without Odd prove the claim comes first.  Folding the pairs over the claims
+// hash then gives the claimtrie hash of the header of BlockHash.
+//
+// The proof is unavailable when it has no pairs, such as for names without
+// claims, in which case ClaimsHash and BestClaim are zero.
+type MsgClaimProof struct {
+	Name           string
+	BlockHash      chainhash.Hash
+	ClaimsHash     chainhash.Hash
+	Pairs          []ClaimProofPair
+	BestClaim      OutPoint
+	TakeoverHeight int32
+	ClaimPairs     []ClaimProofPair
+}
+
+// AddPair adds a new pair to the proof of the claims hash.
+func (msg *MsgClaimProof) AddPair(odd bool, hash *chainhash.Hash) error {
+	if len(msg.Pairs)+1 > MaxClaimProofPairs {
+		str := fmt.Sprintf("too many proof pairs in message [max %v]",
+			MaxClaimProofPairs)
+		return messageError("MsgClaimProof.AddPair", str)
+	}
+
+	msg.Pairs = append(msg.Pairs, ClaimProofPair{Odd: odd, Hash: *hash})
+	return nil
+}
+
+// AddClaimPair adds a new pair to the proof of the best claim.
+func (msg *MsgClaimProof) AddClaimPair(odd bool, hash *chainhash.Hash) error {
+	if len(msg.ClaimPairs)+1 > MaxClaimProofClaimPairs {
+		str := fmt.Sprintf("too many claim proof pairs in message "+
+			"[max %v]", MaxClaimProofClaimPairs)
+		return messageError("MsgClaimProof.AddClaimPair", str)
+	}
+
+	msg.ClaimPairs = append(msg.ClaimPairs,
+		ClaimProofPair{Odd: odd, Hash: *hash})
+	return nil
+}
+
+// readClaimProofPairs reads a list of at most max proof pairs from r.
+func readClaimProofPairs(r io.Reader, pver uint32, max uint64,
+	fieldName string) ([]ClaimProofPair, error) {
+
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return nil, err
+	}
+
+	// Limit to max proof pairs per message.
+	if count > max {
+		str := fmt.Sprintf("too many %s for message [count %v, max %v]",
+			fieldName, count, max)
+		return nil, messageError("MsgClaimProof.BtcDecode", str)
+	}
+
+	pairs := make([]ClaimProofPair, count)
+	for i := range pairs {
+		err := readElements(r, &pairs[i].Odd, &pairs[i].Hash)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pairs, nil
+}
+
+// writeClaimProofPairs writes a list of proof pairs to w.
+func writeClaimProofPairs(w io.Writer, pver uint32, pairs []ClaimProofPair) error {
+	err := WriteVarInt(w, pver, uint64(len(pairs)))
+	if err != nil {
+		return err
+	}
+
+	for _, pair := range pairs {
+		err := writeElements(w, pair.Odd, &pair.Hash)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgClaimProof) BtcDecode(r io.Reader, pver uint32, _ MessageEncoding) error {
+	name, err := ReadVarBytes(r, pver, MaxClaimProofNameSize, "name")
+	if err != nil {
+		return err
+	}
+	msg.Name = string(name)
+
+	err = readElements(r, &msg.BlockHash, &msg.ClaimsHash)
+	if err != nil {
+		return err
+	}
+
+	msg.Pairs, err = readClaimProofPairs(r, pver, MaxClaimProofPairs,
+		"proof pairs")
+	if err != nil {
+		return err
+	}
+
+	err = readElements(r, &msg.BestClaim.Hash, &msg.BestClaim.Index,
+		&msg.TakeoverHeight)
+	if err != nil {
+		return err
+	}
+
+	msg.ClaimPairs, err = readClaimProofPairs(r, pver,
+		MaxClaimProofClaimPairs, "claim proof pairs")
+	return err
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgClaimProof) BtcEncode(w io.Writer, pver uint32, _ MessageEncoding) error {
+	if len(msg.Name) > MaxClaimProofNameSize {
+		str := fmt.Sprintf("name too long [len %v, max %v]",
+			len(msg.Name), MaxClaimProofNameSize)
+		return messageError("MsgClaimProof.BtcEncode", str)
+	}
+
+	// Limit to max proof pairs per message.
+	if count := len(msg.Pairs); count > MaxClaimProofPairs {
+		str := fmt.Sprintf("too many proof pairs for message "+
+			"[count %v, max %v]", count, MaxClaimProofPairs)
+		return messageError("MsgClaimProof.BtcEncode", str)
+	}
+	if count := len(msg.ClaimPairs); count > MaxClaimProofClaimPairs {
+		str := fmt.Sprintf("too many claim proof pairs for message "+
+			"[count %v, max %v]", count, MaxClaimProofClaimPairs)
+		return messageError("MsgClaimProof.BtcEncode", str)
+	}
+
+	err := WriteVarString(w, pver, msg.Name)
+	if err != nil {
+		return err
+	}
+
+	err = writeElements(w, &msg.BlockHash, &msg.ClaimsHash)
+	if err != nil {
+		return err
+	}
+
+	err = writeClaimProofPairs(w, pver, msg.Pairs)
+	if err != nil {
+		return err
+	}
+
+	err = writeElements(w, &msg.BestClaim.Hash, msg.BestClaim.Index,
+		msg.TakeoverHeight)
+	if err != nil {
+		return err
+	}
+
+	return writeClaimProofPairs(w, pver, msg.ClaimPairs)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgClaimProof) Command() string {
+	return CmdClaimProof
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgClaimProof) MaxPayloadLength(pver uint32) uint32 {
+	// Num name bytes (varInt) + name + block hash + claims hash +
+	// num pairs (varInt) + (odd byte + hash) * max pairs + best claim
+	// outpoint + takeover height + num claim pairs (varInt) +
+	// (odd byte + hash) * max claim pairs.
+	return MaxVarIntPayload + MaxClaimProofNameSize + 2*chainhash.HashSize +
+		MaxVarIntPayload + (1+chainhash.HashSize)*MaxClaimProofPairs +
+		chainhash.HashSize + 4 + 4 + MaxVarIntPayload +
+		(1+chainhash.HashSize)*MaxClaimProofClaimPairs
+}
+
+// NewMsgClaimProof returns a new clmproof message that conforms to the
+// Message interface.  See MsgClaimProof for details.
+func NewMsgClaimProof(name string, blockHash *chainhash.Hash) *MsgClaimProof {
+	return &MsgClaimProof{
+		Name:      name,
+		BlockHash: *blockHash,
+	}
+}
@@ -0,0 +1,172 @@
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+)
+
+// TestClaimProofWire tests the MsgGetClaimProof and MsgClaimProof wire encode and
+// decode.
+func TestClaimProofWire(t *testing.T) {
+	t.Parallel()
+
+	blockHash := chainhash.Hash{0x01}
+	claimsHash := chainhash.Hash{0x02}
+	pairHash := chainhash.Hash{0x03}
+	claimHash := chainhash.Hash{0x04}
+	claimPairHash := chainhash.Hash{0x05}
+
+	proof := NewMsgClaimProof("name", &blockHash)
+	proof.ClaimsHash = claimsHash
+	if err := proof.AddPair(true, &pairHash); err != nil {
+		t.Fatalf("AddPair: unexpected error %v", err)
+	}
+	proof.BestClaim = OutPoint{Hash: claimHash, Index: 2}
+	proof.TakeoverHeight = 0x010203
+	if err := proof.AddClaimPair(false, &claimPairHash); err != nil {
+		t.Fatalf("AddClaimPair: unexpected error %v", err)
+	}
+
+	wantProof := []byte{0x04, 'n', 'a', 'm', 'e'}
+	wantProof = append(wantProof, blockHash[:]...)
+	wantProof = append(wantProof, claimsHash[:]...)
+	wantProof = append(wantProof, 0x01, 0x01)
+	wantProof = append(wantProof, pairHash[:]...)
+	wantProof = append(wantProof, claimHash[:]...)
+	wantProof = append(wantProof, 0x02, 0x00, 0x00, 0x00)
+	wantProof = append(wantProof, 0x03, 0x02, 0x01, 0x00)
+	wantProof = append(wantProof, 0x01, 0x00)
+	wantProof = append(wantProof, claimPairHash[:]...)
+
+	tests := []struct {
+		in  Message // Message to encode
+		out Message // Empty message to decode into
+		buf []byte  // Wire encoding
+	}{
+		{
+			NewMsgGetClaimProof("name"),
+			&MsgGetClaimProof{},
+			[]byte{0x04, 'n', 'a', 'm', 'e'},
+		},
+		{proof, &MsgClaimProof{}, wantProof},
+	}
+
+	for i, test := range tests {
+		var buf bytes.Buffer
+		err := test.in.BtcEncode(&buf, ProtocolVersion, BaseEncoding)
+		if err != nil {
+			t.Errorf("BtcEncode #%d error %v", i, err)
+			continue
+		}
+		if !bytes.Equal(buf.Bytes(), test.buf) {
+			t.Errorf("BtcEncode #%d\n got: %s want: %s", i,
+				spew.Sdump(buf.Bytes()), spew.Sdump(test.buf))
+			continue
+		}
+
+		err = test.out.BtcDecode(bytes.NewReader(test.buf),
+			ProtocolVersion, BaseEncoding)
+		if err != nil {
+			t.Errorf("BtcDecode #%d error %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(test.out, test.in) {
+			t.Errorf("BtcDecode #%d\n got: %s want: %s", i,
+				spew.Sdump(test.out), spew.Sdump(test.in))
+		}
+	}
+}
+
+// TestClaimProofLimits tests the MsgGetClaimProof and MsgClaimProof enforce the
+// limits of the names and pairs.
+func TestClaimProofLimits(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	longName := strings.Repeat("a", MaxClaimProofNameSize+1)
+	err := NewMsgGetClaimProof(longName).BtcEncode(&buf, ProtocolVersion,
+		BaseEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcEncode of long name: got %v, want MessageError", err)
+	}
+
+	// Decoding a long name fails before reading it.
+	buf.Reset()
+	WriteVarString(&buf, ProtocolVersion, longName)
+	err = (&MsgGetClaimProof{}).BtcDecode(&buf, ProtocolVersion, BaseEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcDecode of long name: got %v, want MessageError", err)
+	}
+
+	msg := NewMsgClaimProof("name", &chainhash.Hash{})
+	for i := 0; i < MaxClaimProofPairs; i++ {
+		if err := msg.AddPair(false, &chainhash.Hash{}); err != nil {
+			t.Fatalf("AddPair #%d error %v", i, err)
+		}
+	}
+	if err := msg.AddPair(false, &chainhash.Hash{}); err == nil {
+		t.Errorf("AddPair: expected error adding too many pairs")
+	}
+	for i := 0; i < MaxClaimProofClaimPairs; i++ {
+		if err := msg.AddClaimPair(false, &chainhash.Hash{}); err != nil {
+			t.Fatalf("AddClaimPair #%d error %v", i, err)
+		}
+	}
+	if err := msg.AddClaimPair(false, &chainhash.Hash{}); err == nil {
+		t.Errorf("AddClaimPair: expected error adding too many pairs")
+	}
+
+	// The maximum message encodes within its max payload length.
+	buf.Reset()
+	msg.Name = strings.Repeat("a", MaxClaimProofNameSize)
+	err = msg.BtcEncode(&buf, ProtocolVersion, BaseEncoding)
+	if err != nil {
+		t.Fatalf("BtcEncode error %v", err)
+	}
+	if uint32(buf.Len()) > msg.MaxPayloadLength(ProtocolVersion) {
+		t.Errorf("BtcEncode: got %d bytes, max payload %d", buf.Len(),
+			msg.MaxPayloadLength(ProtocolVersion))
+	}
+
+	msg.Pairs = append(msg.Pairs, ClaimProofPair{})
+	err = msg.BtcEncode(&buf, ProtocolVersion, BaseEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcEncode of too many pairs: got %v, want MessageError",
+			err)
+	}
+
+	msg.Pairs = msg.Pairs[:MaxClaimProofPairs]
+	msg.ClaimPairs = append(msg.ClaimPairs, ClaimProofPair{})
+	err = msg.BtcEncode(&buf, ProtocolVersion, BaseEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcEncode of too many claim pairs: got %v, want "+
+			"MessageError", err)
+	}
+
+	buf.Reset()
+	WriteVarString(&buf, ProtocolVersion, "name")
+	buf.Write(make([]byte, 2*chainhash.HashSize))
+	WriteVarInt(&buf, ProtocolVersion, MaxClaimProofPairs+1)
+	err = (&MsgClaimProof{}).BtcDecode(&buf, ProtocolVersion, BaseEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcDecode of too many pairs: got %v, want MessageError",
+			err)
+	}
+
+	buf.Reset()
+	WriteVarString(&buf, ProtocolVersion, "name")
+	buf.Write(make([]byte, 2*chainhash.HashSize))
+	WriteVarInt(&buf, ProtocolVersion, 0)
+	buf.Write(make([]byte, chainhash.HashSize+8))
+	WriteVarInt(&buf, ProtocolVersion, MaxClaimProofClaimPairs+1)
+	err = (&MsgClaimProof{}).BtcDecode(&buf, ProtocolVersion, BaseEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcDecode of too many claim pairs: got %v, want "+
+			"MessageError", err)
+	}
+}
@@ -0,0 +1,128 @@
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+)
+
+// MaxClaimSnapshotChunks is the maximum number of chunks of a claimtrie
+// snapshot, which is enough for 256 GiB of chunks of 1 MiB.
+const MaxClaimSnapshotChunks = 1 << 18
+
+// MsgClaimSnapshot implements the Message interface and represents a ctsnap
+// message.  It is used to advertise the claimtrie snapshot of a peer in
+// response to a getctsnap message (MsgGetClaimSnapshot).
+//
+// The snapshot holds the claimtrie at the block BlockHash of height Height,
+// whose header commits to it with the claimtrie hash ClaimTrie.  The snapshot
+// is cut in chunks, which are requested in order with getctchunk messages
+// (MsgGetClaimSnapshotChunk) and checked against ChunkHashes, the double
+// SHA-256 hashes of the chunks.  The loaded snapshot is then checked against
+// the claimtrie hash of the header of the block, so the peer isn't trusted.
+//
+// The peer has no snapshot when the message has no chunks.
+type MsgClaimSnapshot struct {
+	Height      int32
+	BlockHash   chainhash.Hash
+	ClaimTrie   chainhash.Hash
+	ChunkHashes []chainhash.Hash
+}
+
+// AddChunkHash adds the hash of the next chunk of the snapshot.
+func (msg *MsgClaimSnapshot) AddChunkHash(hash *chainhash.Hash) error {
+	if len(msg.ChunkHashes)+1 > MaxClaimSnapshotChunks {
+		str := fmt.Sprintf("too many chunks in message [max %v]",
+			MaxClaimSnapshotChunks)
+		return messageError("MsgClaimSnapshot.AddChunkHash", str)
+	}
+
+	msg.ChunkHashes = append(msg.ChunkHashes, *hash)
+	return nil
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgClaimSnapshot) BtcDecode(r io.Reader, pver uint32, _ MessageEncoding) error {
+	err := readElements(r, &msg.Height, &msg.BlockHash, &msg.ClaimTrie)
+	if err != nil {
+		return err
+	}
+
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+
+	// Limit to max chunks per message.
+	if count > MaxClaimSnapshotChunks {
+		str := fmt.Sprintf("too many chunks for message "+
+			"[count %v, max %v]", count, MaxClaimSnapshotChunks)
+		return messageError("MsgClaimSnapshot.BtcDecode", str)
+	}
+
+	msg.ChunkHashes = make([]chainhash.Hash, count)
+	for i := range msg.ChunkHashes {
+		err := readElement(r, &msg.ChunkHashes[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgClaimSnapshot) BtcEncode(w io.Writer, pver uint32, _ MessageEncoding) error {
+	// Limit to max chunks per message.
+	count := len(msg.ChunkHashes)
+	if count > MaxClaimSnapshotChunks {
+		str := fmt.Sprintf("too many chunks for message "+
+			"[count %v, max %v]", count, MaxClaimSnapshotChunks)
+		return messageError("MsgClaimSnapshot.BtcEncode", str)
+	}
+
+	err := writeElements(w, msg.Height, &msg.BlockHash, &msg.ClaimTrie)
+	if err != nil {
+		return err
+	}
+
+	err = WriteVarInt(w, pver, uint64(count))
+	if err != nil {
+		return err
+	}
+
+	for i := range msg.ChunkHashes {
+		err := writeElement(w, &msg.ChunkHashes[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgClaimSnapshot) Command() string {
+	return CmdClaimSnapshot
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgClaimSnapshot) MaxPayloadLength(pver uint32) uint32 {
+	// Height + block hash + claimtrie hash + num chunks (varInt) +
+	// chunk hashes.
+	return 4 + 2*chainhash.HashSize + MaxVarIntPayload +
+		MaxClaimSnapshotChunks*chainhash.HashSize
+}
+
+// NewMsgClaimSnapshot returns a new ctsnap message that conforms to the
+// Message interface.  See MsgClaimSnapshot for details.
+func NewMsgClaimSnapshot(height int32, blockHash, claimTrie *chainhash.Hash) *MsgClaimSnapshot {
+	return &MsgClaimSnapshot{
+		Height:    height,
+		BlockHash: *blockHash,
+		ClaimTrie: *claimTrie,
+	}
+}
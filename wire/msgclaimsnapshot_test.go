@@ -0,0 +1,139 @@
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+)
+
+// TestClaimSnapshotWire tests the wire encode and decode of the messages
+// transferring claimtrie snapshots.
+func TestClaimSnapshotWire(t *testing.T) {
+	t.Parallel()
+
+	blockHash := chainhash.Hash{0x01}
+	claimTrie := chainhash.Hash{0x02}
+	chunkHash := chainhash.Hash{0x03}
+
+	snapshot := NewMsgClaimSnapshot(0x010203, &blockHash, &claimTrie)
+	if err := snapshot.AddChunkHash(&chunkHash); err != nil {
+		t.Fatalf("AddChunkHash: unexpected error %v", err)
+	}
+
+	wantSnapshot := []byte{0x03, 0x02, 0x01, 0x00}
+	wantSnapshot = append(wantSnapshot, blockHash[:]...)
+	wantSnapshot = append(wantSnapshot, claimTrie[:]...)
+	wantSnapshot = append(wantSnapshot, 0x01)
+	wantSnapshot = append(wantSnapshot, chunkHash[:]...)
+
+	wantGetChunk := append([]byte{}, blockHash[:]...)
+	wantGetChunk = append(wantGetChunk, 0x02, 0x00, 0x00, 0x00)
+
+	wantChunk := append([]byte{}, wantGetChunk...)
+	wantChunk = append(wantChunk, 0x05, 'c', 'h', 'u', 'n', 'k')
+
+	tests := []struct {
+		in  Message // Message to encode
+		out Message // Empty message to decode into
+		buf []byte  // Wire encoding
+	}{
+		{NewMsgGetClaimSnapshot(), &MsgGetClaimSnapshot{}, []byte{}},
+		{snapshot, &MsgClaimSnapshot{}, wantSnapshot},
+		{
+			NewMsgGetClaimSnapshotChunk(&blockHash, 2),
+			&MsgGetClaimSnapshotChunk{},
+			wantGetChunk,
+		},
+		{
+			NewMsgClaimSnapshotChunk(&blockHash, 2, []byte("chunk")),
+			&MsgClaimSnapshotChunk{},
+			wantChunk,
+		},
+	}
+
+	for i, test := range tests {
+		var buf bytes.Buffer
+		err := test.in.BtcEncode(&buf, ProtocolVersion, BaseEncoding)
+		if err != nil {
+			t.Errorf("BtcEncode #%d error %v", i, err)
+			continue
+		}
+		if !bytes.Equal(buf.Bytes(), test.buf) {
+			t.Errorf("BtcEncode #%d\n got: %s want: %s", i,
+				spew.Sdump(buf.Bytes()), spew.Sdump(test.buf))
+			continue
+		}
+
+		err = test.out.BtcDecode(bytes.NewReader(test.buf),
+			ProtocolVersion, BaseEncoding)
+		if err != nil {
+			t.Errorf("BtcDecode #%d error %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(test.out, test.in) {
+			t.Errorf("BtcDecode #%d\n got: %s want: %s", i,
+				spew.Sdump(test.out), spew.Sdump(test.in))
+		}
+	}
+}
+
+// TestClaimSnapshotLimits tests the MsgClaimSnapshot and MsgClaimSnapshotChunk
+// enforce the limits of the chunks.
+func TestClaimSnapshotLimits(t *testing.T) {
+	t.Parallel()
+
+	msg := NewMsgClaimSnapshot(1, &chainhash.Hash{}, &chainhash.Hash{})
+	msg.ChunkHashes = make([]chainhash.Hash, MaxClaimSnapshotChunks)
+	if err := msg.AddChunkHash(&chainhash.Hash{}); err == nil {
+		t.Errorf("AddChunkHash: expected error adding too many chunks")
+	}
+
+	// The maximum message encodes within its max payload length.
+	var buf bytes.Buffer
+	err := msg.BtcEncode(&buf, ProtocolVersion, BaseEncoding)
+	if err != nil {
+		t.Fatalf("BtcEncode error %v", err)
+	}
+	if uint32(buf.Len()) > msg.MaxPayloadLength(ProtocolVersion) {
+		t.Errorf("BtcEncode: got %d bytes, max payload %d", buf.Len(),
+			msg.MaxPayloadLength(ProtocolVersion))
+	}
+
+	msg.ChunkHashes = append(msg.ChunkHashes, chainhash.Hash{})
+	err = msg.BtcEncode(&buf, ProtocolVersion, BaseEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcEncode of too many chunks: got %v, want MessageError",
+			err)
+	}
+
+	buf.Reset()
+	buf.Write(make([]byte, 4+2*chainhash.HashSize))
+	WriteVarInt(&buf, ProtocolVersion, MaxClaimSnapshotChunks+1)
+	err = (&MsgClaimSnapshot{}).BtcDecode(&buf, ProtocolVersion, BaseEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcDecode of too many chunks: got %v, want MessageError",
+			err)
+	}
+
+	chunk := NewMsgClaimSnapshotChunk(&chainhash.Hash{}, 0,
+		make([]byte, MaxClaimSnapshotChunkSize+1))
+	err = chunk.BtcEncode(&buf, ProtocolVersion, BaseEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcEncode of large chunk: got %v, want MessageError",
+			err)
+	}
+
+	// Decoding a large chunk fails before reading it.
+	buf.Reset()
+	buf.Write(make([]byte, chainhash.HashSize+4))
+	WriteVarInt(&buf, ProtocolVersion, MaxClaimSnapshotChunkSize+1)
+	err = (&MsgClaimSnapshotChunk{}).BtcDecode(&buf, ProtocolVersion,
+		BaseEncoding)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcDecode of large chunk: got %v, want MessageError",
+			err)
+	}
+}
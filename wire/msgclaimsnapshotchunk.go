@@ -0,0 +1,80 @@
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+)
+
+// MaxClaimSnapshotChunkSize is the maximum size of the data of a chunk of a
+// claimtrie snapshot.  Chunks are cut at about 1 MiB, but may exceed it by the
+// changes of a claim.
+const MaxClaimSnapshotChunkSize = 4 * 1024 * 1024
+
+// MsgClaimSnapshotChunk implements the Message interface and represents a
+// ctchunk message.  It is used to deliver the chunk Index of the claimtrie
+// snapshot at the block BlockHash in response to a getctchunk message
+// (MsgGetClaimSnapshotChunk).
+//
+// The chunk is unavailable when the message has no data, such as when the
+// peer no longer has the snapshot.
+type MsgClaimSnapshotChunk struct {
+	BlockHash chainhash.Hash
+	Index     uint32
+	Data      []byte
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgClaimSnapshotChunk) BtcDecode(r io.Reader, pver uint32, _ MessageEncoding) error {
+	err := readElements(r, &msg.BlockHash, &msg.Index)
+	if err != nil {
+		return err
+	}
+
+	msg.Data, err = ReadVarBytes(r, pver, MaxClaimSnapshotChunkSize,
+		"chunk data")
+	return err
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgClaimSnapshotChunk) BtcEncode(w io.Writer, pver uint32, _ MessageEncoding) error {
+	if len(msg.Data) > MaxClaimSnapshotChunkSize {
+		str := fmt.Sprintf("chunk data too large [len %v, max %v]",
+			len(msg.Data), MaxClaimSnapshotChunkSize)
+		return messageError("MsgClaimSnapshotChunk.BtcEncode", str)
+	}
+
+	err := writeElements(w, &msg.BlockHash, msg.Index)
+	if err != nil {
+		return err
+	}
+
+	return WriteVarBytes(w, pver, msg.Data)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgClaimSnapshotChunk) Command() string {
+	return CmdClaimSnapshotChunk
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgClaimSnapshotChunk) MaxPayloadLength(pver uint32) uint32 {
+	// Block hash + index + num data bytes (varInt) + data.
+	return chainhash.HashSize + 4 + MaxVarIntPayload +
+		MaxClaimSnapshotChunkSize
+}
+
+// NewMsgClaimSnapshotChunk returns a new ctchunk message that conforms to the
+// Message interface.  See MsgClaimSnapshotChunk for details.
+func NewMsgClaimSnapshotChunk(blockHash *chainhash.Hash, index uint32, data []byte) *MsgClaimSnapshotChunk {
+	return &MsgClaimSnapshotChunk{
+		BlockHash: *blockHash,
+		Index:     index,
+		Data:      data,
+	}
+}
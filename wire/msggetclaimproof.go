@@ -0,0 +1,62 @@
+package wire
+
+import (
+	"fmt"
+	"io"
+)
+
+// MaxClaimProofNameSize is the maximum byte size of a name in the
+// getclmproof and clmproof messages.  It leaves room for the normalization
+// of names of the maximum claim name size.
+const MaxClaimProofNameSize = 1024
+
+// MsgGetClaimProof implements the Message interface and represents a
+// getclmproof message.  It is used to request the proof of the claim a name
+// resolves to against the claimtrie hash of the best block of the peer, which
+// responds with a clmproof message (MsgClaimProof).
+//
+// This message is only supported by the peers advertising SFNodeClaimProofs.
+type MsgGetClaimProof struct {
+	Name string
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgGetClaimProof) BtcDecode(r io.Reader, pver uint32, _ MessageEncoding) error {
+	name, err := ReadVarBytes(r, pver, MaxClaimProofNameSize, "name")
+	if err != nil {
+		return err
+	}
+	msg.Name = string(name)
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgGetClaimProof) BtcEncode(w io.Writer, pver uint32, _ MessageEncoding) error {
+	if len(msg.Name) > MaxClaimProofNameSize {
+		str := fmt.Sprintf("name too long [len %v, max %v]",
+			len(msg.Name), MaxClaimProofNameSize)
+		return messageError("MsgGetClaimProof.BtcEncode", str)
+	}
+	return WriteVarString(w, pver, msg.Name)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgGetClaimProof) Command() string {
+	return CmdGetClaimProof
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgGetClaimProof) MaxPayloadLength(pver uint32) uint32 {
+	// Num name bytes (varInt) + name.
+	return MaxVarIntPayload + MaxClaimProofNameSize
+}
+
+// NewMsgGetClaimProof returns a new getclmproof message that conforms to the
+// Message interface using the passed parameters.
+func NewMsgGetClaimProof(name string) *MsgGetClaimProof {
+	return &MsgGetClaimProof{Name: name}
+}
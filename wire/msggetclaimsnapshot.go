@@ -0,0 +1,44 @@
+package wire
+
+import (
+	"io"
+)
+
+// MsgGetClaimSnapshot implements the Message interface and represents a
+// getctsnap message.  It is used to request the manifest of the latest
+// claimtrie snapshot of a peer, which responds with a ctsnap message
+// (MsgClaimSnapshot).
+//
+// This message has no payload and is only supported by the peers advertising
+// SFNodeClaimSnapshots.
+type MsgGetClaimSnapshot struct{}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgGetClaimSnapshot) BtcDecode(r io.Reader, pver uint32, _ MessageEncoding) error {
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgGetClaimSnapshot) BtcEncode(w io.Writer, pver uint32, _ MessageEncoding) error {
+	return nil
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgGetClaimSnapshot) Command() string {
+	return CmdGetClaimSnapshot
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgGetClaimSnapshot) MaxPayloadLength(pver uint32) uint32 {
+	return 0
+}
+
+// NewMsgGetClaimSnapshot returns a new getctsnap message that conforms to the
+// Message interface.  See MsgGetClaimSnapshot for details.
+func NewMsgGetClaimSnapshot() *MsgGetClaimSnapshot {
+	return &MsgGetClaimSnapshot{}
+}
@@ -0,0 +1,54 @@
+package wire
+
+import (
+	"io"
+
+	"github.com/lbryio/lbcd/chaincfg/chainhash"
+)
+
+// MsgGetClaimSnapshotChunk implements the Message interface and represents a
+// getctchunk message.  It is used to request the chunk Index of the claimtrie
+// snapshot at the block BlockHash, which the peer advertised with a ctsnap
+// message (MsgClaimSnapshot).  The peer responds with a ctchunk message
+// (MsgClaimSnapshotChunk).
+//
+// This message is only supported by the peers advertising
+// SFNodeClaimSnapshots.
+type MsgGetClaimSnapshotChunk struct {
+	BlockHash chainhash.Hash
+	Index     uint32
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgGetClaimSnapshotChunk) BtcDecode(r io.Reader, pver uint32, _ MessageEncoding) error {
+	return readElements(r, &msg.BlockHash, &msg.Index)
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgGetClaimSnapshotChunk) BtcEncode(w io.Writer, pver uint32, _ MessageEncoding) error {
+	return writeElements(w, &msg.BlockHash, msg.Index)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgGetClaimSnapshotChunk) Command() string {
+	return CmdGetClaimSnapshotChunk
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgGetClaimSnapshotChunk) MaxPayloadLength(pver uint32) uint32 {
+	// Block hash + index.
+	return chainhash.HashSize + 4
+}
+
+// NewMsgGetClaimSnapshotChunk returns a new getctchunk message that conforms
+// to the Message interface using the passed parameters.
+func NewMsgGetClaimSnapshotChunk(blockHash *chainhash.Hash, index uint32) *MsgGetClaimSnapshotChunk {
+	return &MsgGetClaimSnapshotChunk{
+		BlockHash: *blockHash,
+		Index:     index,
+	}
+}
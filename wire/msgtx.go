@@ -80,19 +80,20 @@ const (
 	minTxPayload = 10
 
 	// freeListMaxScriptSize is the size of each buffer in the free list
-	// that	is used for deserializing scripts from the wire before they are
-	// concatenated into a single contiguous buffers.  This value was chosen
-	// because it is slightly more than twice the size of the vast majority
-	// of all "standard" scripts.  Larger scripts are still deserialized
+	// that is used for deserializing scripts from the wire before they are
+	// concatenated into a single contiguous buffer.  Each buffer is shared
+	// by as many scripts of a transaction as fit in it.  This value was
+	// chosen because it holds all of the scripts of the vast majority of
+	// "standard" transactions.  Larger scripts are still deserialized
 	// properly as the free list will simply be bypassed for them.
-	freeListMaxScriptSize = 512
+	freeListMaxScriptSize = 4096
 
 	// freeListMaxItems is the number of buffers to keep in the free list
-	// to use for script deserialization.  This value allows up to 100
-	// scripts per transaction being simultaneously deserialized by 125
-	// peers.  Thus, the peak usage of the free list is 12,500 * 512 =
-	// 6,400,000 bytes.
-	freeListMaxItems = 12500
+	// to use for script deserialization.  This value allows up to 10
+	// buffers per transaction being simultaneously deserialized by 125
+	// peers.  Thus, the peak usage of the free list is 1,250 * 4,096 =
+	// 5,120,000 bytes.
+	freeListMaxItems = 1250
 
 	// maxWitnessItemsPerInput is the maximum number of witness items to
 	// be read for the witness data for a single TxIn. This number is
@@ -154,25 +155,17 @@ const (
 // function and should return it via the Return function when done using it.
 type scriptFreeList chan []byte
 
-// Borrow returns a byte slice from the free list with a length according the
-// provided size.  A new buffer is allocated if there are any items available.
-//
-// When the size is larger than the max size allowed for items on the free list
-// a new buffer of the appropriate size is allocated and returned.  It is safe
-// to attempt to return said buffer via the Return function as it will be
-// ignored and allowed to go the garbage collector.
-func (c scriptFreeList) Borrow(size uint64) []byte {
-	if size > freeListMaxScriptSize {
-		return make([]byte, size)
-	}
-
+// Borrow returns an empty byte slice from the free list with a cap according
+// to the freeListMaxScriptSize constant.  A new buffer is allocated if there
+// are not any items available.
+func (c scriptFreeList) Borrow() []byte {
 	var buf []byte
 	select {
 	case buf = <-c:
 	default:
 		buf = make([]byte, freeListMaxScriptSize)
 	}
-	return buf[:size]
+	return buf[:0]
 }
 
 // Return puts the provided byte slice back on the free list when it has a cap
@@ -196,6 +189,47 @@ func (c scriptFreeList) Return(buf []byte) {
 	}
 }
 
+// scriptArena hands out the space for the scripts read while deserializing a
+// transaction.  The space is carved out of buffers borrowed from the script
+// free list, so the scripts don't each need a buffer of their own.
+type scriptArena struct {
+	buf   []byte
+	full  [][]byte
+	total uint64
+}
+
+// alloc returns a byte slice with the provided size from the arena.  A script
+// larger than the buffers of the free list is allocated separately.
+func (a *scriptArena) alloc(size uint64) []byte {
+	a.total += size
+	if size > freeListMaxScriptSize {
+		return make([]byte, size)
+	}
+
+	if a.buf == nil {
+		a.buf = scriptPool.Borrow()
+	} else if uint64(cap(a.buf)-len(a.buf)) < size {
+		a.full = append(a.full, a.buf)
+		a.buf = scriptPool.Borrow()
+	}
+	start := uint64(len(a.buf))
+	end := start + size
+	a.buf = a.buf[:end]
+	return a.buf[start:end:end]
+}
+
+// release returns the buffers of the arena to the free list.  The scripts
+// handed out by the arena MUST NOT be used afterwards.
+func (a *scriptArena) release() {
+	for _, buf := range a.full {
+		scriptPool.Return(buf)
+	}
+	if a.buf != nil {
+		scriptPool.Return(a.buf)
+	}
+	*a = scriptArena{}
+}
+
 // Create the concurrent safe free list to use for script deserialization.  As
 // previously described, this free list is maintained to significantly reduce
 // the number of allocations.
@@ -435,13 +469,16 @@ func (msg *MsgTx) Copy() *MsgTx {
 // See Deserialize for decoding transactions stored to disk, such as in a
 // database, as opposed to decoding transactions from the wire.
 func (msg *MsgTx) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
-	version, err := binarySerializer.Uint32(r, littleEndian)
+	buf := binarySerializer.Borrow()
+	defer binarySerializer.Return(buf)
+
+	version, err := readUint32(r, buf, littleEndian)
 	if err != nil {
 		return err
 	}
 	msg.Version = int32(version)
 
-	count, err := ReadVarInt(r, pver)
+	count, err := readVarIntBuf(r, pver, buf)
 	if err != nil {
 		return err
 	}
@@ -465,7 +502,7 @@ func (msg *MsgTx) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error
 
 		// With the Segregated Witness specific fields decoded, we can
 		// now read in the actual txin count.
-		count, err = ReadVarInt(r, pver)
+		count, err = readVarIntBuf(r, pver, buf)
 		if err != nil {
 			return err
 		}
@@ -481,55 +518,26 @@ func (msg *MsgTx) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error
 		return messageError("MsgTx.BtcDecode", str)
 	}
 
-	// returnScriptBuffers is a closure that returns any script buffers that
-	// were borrowed from the pool when there are any deserialization
-	// errors.  This is only valid to call before the final step which
-	// replaces the scripts with the location in a contiguous buffer and
-	// returns them.
-	returnScriptBuffers := func() {
-		for _, txIn := range msg.TxIn {
-			if txIn == nil {
-				continue
-			}
-
-			if txIn.SignatureScript != nil {
-				scriptPool.Return(txIn.SignatureScript)
-			}
-
-			for _, witnessElem := range txIn.Witness {
-				if witnessElem != nil {
-					scriptPool.Return(witnessElem)
-				}
-			}
-		}
-		for _, txOut := range msg.TxOut {
-			if txOut == nil || txOut.PkScript == nil {
-				continue
-			}
-			scriptPool.Return(txOut.PkScript)
-		}
-	}
+	// The scripts are read into space borrowed from the free list until
+	// the final step, which copies them to a buffer owned by the
+	// transaction.
+	var arena scriptArena
+	defer arena.release()
 
 	// Deserialize the inputs.
-	var totalScriptSize uint64
 	txIns := make([]TxIn, count)
 	msg.TxIn = make([]*TxIn, count)
 	for i := uint64(0); i < count; i++ {
-		// The pointer is set now in case a script buffer is borrowed
-		// and needs to be returned to the pool on error.
 		ti := &txIns[i]
 		msg.TxIn[i] = ti
-		err = readTxIn(r, pver, msg.Version, ti)
+		err = readTxInBuf(r, pver, msg.Version, ti, buf, &arena)
 		if err != nil {
-			returnScriptBuffers()
 			return err
 		}
-		totalScriptSize += uint64(len(ti.SignatureScript))
 	}
 
-	count, err = ReadVarInt(r, pver)
+	count, err = readVarIntBuf(r, pver, buf)
 	if err != nil {
-		returnScriptBuffers()
 		return err
 	}
 
@@ -537,7 +545,6 @@ func (msg *MsgTx) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error
 	// message.  It would be possible to cause memory exhaustion and panics
 	// without a sane upper bound on this count.
 	if count > uint64(maxTxOutPerMessage) {
-		returnScriptBuffers()
 		str := fmt.Sprintf("too many output transactions to fit into "+
 			"max message size [count %d, max %d]", count,
 			maxTxOutPerMessage)
@@ -548,16 +555,12 @@ func (msg *MsgTx) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error
 	txOuts := make([]TxOut, count)
 	msg.TxOut = make([]*TxOut, count)
 	for i := uint64(0); i < count; i++ {
-		// The pointer is set now in case a script buffer is borrowed
-		// and needs to be returned to the pool on error.
 		to := &txOuts[i]
 		msg.TxOut[i] = to
-		err = readTxOut(r, pver, msg.Version, to)
+		err = readTxOutBuf(r, pver, msg.Version, to, buf, &arena)
 		if err != nil {
-			returnScriptBuffers()
 			return err
 		}
-		totalScriptSize += uint64(len(to.PkScript))
 	}
 
 	// If the transaction's flag byte isn't 0x00 at this point, then one or
@@ -567,16 +570,14 @@ func (msg *MsgTx) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error
 			// For each input, the witness is encoded as a stack
 			// with one or more items. Therefore, we first read a
 			// varint which encodes the number of stack items.
-			witCount, err := ReadVarInt(r, pver)
+			witCount, err := readVarIntBuf(r, pver, buf)
 			if err != nil {
-				returnScriptBuffers()
 				return err
 			}
 
 			// Prevent a possible memory exhaustion attack by
 			// limiting the witCount value to a sane upper bound.
 			if witCount > maxWitnessItemsPerInput {
-				returnScriptBuffers()
 				str := fmt.Sprintf("too many witness items to fit "+
 					"into max message size [count %d, max %d]",
 					witCount, maxWitnessItemsPerInput)
@@ -588,89 +589,44 @@ func (msg *MsgTx) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error
 			// item itself.
 			txin.Witness = make([][]byte, witCount)
 			for j := uint64(0); j < witCount; j++ {
-				txin.Witness[j], err = readScript(r, pver,
-					maxWitnessItemSize, "script witness item")
+				txin.Witness[j], err = readScriptBuf(r, pver, buf,
+					&arena, maxWitnessItemSize, "script witness item")
 				if err != nil {
-					returnScriptBuffers()
 					return err
 				}
-				totalScriptSize += uint64(len(txin.Witness[j]))
 			}
 		}
 	}
 
-	msg.LockTime, err = binarySerializer.Uint32(r, littleEndian)
+	msg.LockTime, err = readUint32(r, buf, littleEndian)
 	if err != nil {
-		returnScriptBuffers()
 		return err
 	}
 
 	// Create a single allocation to house all of the scripts and set each
-	// input signature script and output public key script to the
-	// appropriate subslice of the overall contiguous buffer.  Then, return
-	// each individual script buffer back to the pool so they can be reused
-	// for future deserializations.  This is done because it significantly
-	// reduces the number of allocations the garbage collector needs to
-	// track, which in turn improves performance and drastically reduces the
-	// amount of runtime overhead that would otherwise be needed to keep
-	// track of millions of small allocations.
-	//
-	// NOTE: It is no longer valid to call the returnScriptBuffers closure
-	// after these blocks of code run because it is already done and the
-	// scripts in the transaction inputs and outputs no longer point to the
-	// buffers.
+	// input signature script, witness item and output public key script to
+	// the appropriate subslice of the overall contiguous buffer.  This is
+	// done because it significantly reduces the number of allocations the
+	// garbage collector needs to track, which in turn improves performance
+	// and drastically reduces the amount of runtime overhead that would
+	// otherwise be needed to keep track of millions of small allocations.
 	var offset uint64
-	scripts := make([]byte, totalScriptSize)
-	for i := 0; i < len(msg.TxIn); i++ {
-		// Copy the signature script into the contiguous buffer at the
-		// appropriate offset.
-		signatureScript := msg.TxIn[i].SignatureScript
-		copy(scripts[offset:], signatureScript)
-
-		// Reset the signature script of the transaction input to the
-		// slice of the contiguous buffer where the script lives.
-		scriptSize := uint64(len(signatureScript))
-		end := offset + scriptSize
-		msg.TxIn[i].SignatureScript = scripts[offset:end:end]
-		offset += scriptSize
-
-		// Return the temporary script buffer to the pool.
-		scriptPool.Return(signatureScript)
-
-		for j := 0; j < len(msg.TxIn[i].Witness); j++ {
-			// Copy each item within the witness stack for this
-			// input into the contiguous buffer at the appropriate
-			// offset.
-			witnessElem := msg.TxIn[i].Witness[j]
-			copy(scripts[offset:], witnessElem)
-
-			// Reset the witness item within the stack to the slice
-			// of the contiguous buffer where the witness lives.
-			witnessElemSize := uint64(len(witnessElem))
-			end := offset + witnessElemSize
-			msg.TxIn[i].Witness[j] = scripts[offset:end:end]
-			offset += witnessElemSize
-
-			// Return the temporary buffer used for the witness stack
-			// item to the pool.
-			scriptPool.Return(witnessElem)
+	scripts := make([]byte, arena.total)
+	relocate := func(script []byte) []byte {
+		copy(scripts[offset:], script)
+		end := offset + uint64(len(script))
+		script = scripts[offset:end:end]
+		offset = end
+		return script
+	}
+	for _, txIn := range msg.TxIn {
+		txIn.SignatureScript = relocate(txIn.SignatureScript)
+		for j, witnessElem := range txIn.Witness {
+			txIn.Witness[j] = relocate(witnessElem)
 		}
 	}
-	for i := 0; i < len(msg.TxOut); i++ {
-		// Copy the public key script into the contiguous buffer at the
-		// appropriate offset.
-		pkScript := msg.TxOut[i].PkScript
-		copy(scripts[offset:], pkScript)
-
-		// Reset the public key script of the transaction output to the
-		// slice of the contiguous buffer where the script lives.
-		scriptSize := uint64(len(pkScript))
-		end := offset + scriptSize
-		msg.TxOut[i].PkScript = scripts[offset:end:end]
-		offset += scriptSize
-
-		// Return the temporary script buffer to the pool.
-		scriptPool.Return(pkScript)
+	for _, txOut := range msg.TxOut {
+		txOut.PkScript = relocate(txOut.PkScript)
 	}
 
 	return nil
@@ -706,7 +662,10 @@ func (msg *MsgTx) DeserializeNoWitness(r io.Reader) error {
 // See Serialize for encoding transactions to be stored to disk, such as in a
 // database, as opposed to encoding transactions for the wire.
 func (msg *MsgTx) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
-	err := binarySerializer.PutUint32(w, littleEndian, uint32(msg.Version))
+	buf := binarySerializer.Borrow()
+	defer binarySerializer.Return(buf)
+
+	err := writeUint32(w, buf, littleEndian, uint32(msg.Version))
 	if err != nil {
 		return err
 	}
@@ -721,32 +680,34 @@ func (msg *MsgTx) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error
 		// bytes specific to the witness encoding. This byte sequence is known
 		// as a flag. The first byte is a marker byte (TxFlagMarker) and the
 		// second one is the flag value to indicate presence of witness data.
-		if _, err := w.Write([]byte{TxFlagMarker, WitnessFlag}); err != nil {
+		buf[0] = TxFlagMarker
+		buf[1] = WitnessFlag
+		if _, err := w.Write(buf[:2]); err != nil {
 			return err
 		}
 	}
 
 	count := uint64(len(msg.TxIn))
-	err = WriteVarInt(w, pver, count)
+	err = writeVarIntBuf(w, pver, count, buf)
 	if err != nil {
 		return err
 	}
 
 	for _, ti := range msg.TxIn {
-		err = writeTxIn(w, pver, msg.Version, ti)
+		err = writeTxInBuf(w, pver, msg.Version, ti, buf)
 		if err != nil {
 			return err
 		}
 	}
 
 	count = uint64(len(msg.TxOut))
-	err = WriteVarInt(w, pver, count)
+	err = writeVarIntBuf(w, pver, count, buf)
 	if err != nil {
 		return err
 	}
 
 	for _, to := range msg.TxOut {
-		err = WriteTxOut(w, pver, msg.Version, to)
+		err = writeTxOutBuf(w, pver, msg.Version, to, buf)
 		if err != nil {
 			return err
 		}
@@ -757,14 +718,14 @@ func (msg *MsgTx) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error
 	// within the transaction.
 	if doWitness {
 		for _, ti := range msg.TxIn {
-			err = writeTxWitness(w, pver, msg.Version, ti.Witness)
+			err = writeTxWitnessBuf(w, pver, msg.Version, ti.Witness, buf)
 			if err != nil {
 				return err
 			}
 		}
 	}
 
-	return binarySerializer.PutUint32(w, littleEndian, msg.LockTime)
+	return writeUint32(w, buf, littleEndian, msg.LockTime)
 }
 
 // HasWitness returns false if none of the inputs within the transaction
@@ -921,37 +882,46 @@ func NewMsgTx(version int32) *MsgTx {
 	}
 }
 
-// readOutPoint reads the next sequence of bytes from r as an OutPoint.
-func readOutPoint(r io.Reader, pver uint32, version int32, op *OutPoint) error {
+// readOutPointBuf reads the next sequence of bytes from r as an OutPoint,
+// using buf as the scratch space for the index.  The hash is read directly
+// into the OutPoint.
+func readOutPointBuf(r io.Reader, pver uint32, version int32, op *OutPoint,
+	buf []byte) error {
+
 	_, err := io.ReadFull(r, op.Hash[:])
 	if err != nil {
 		return err
 	}
 
-	op.Index, err = binarySerializer.Uint32(r, littleEndian)
+	op.Index, err = readUint32(r, buf, littleEndian)
 	return err
 }
 
-// writeOutPoint encodes op to the bitcoin protocol encoding for an OutPoint
-// to w.
-func writeOutPoint(w io.Writer, pver uint32, version int32, op *OutPoint) error {
+// writeOutPointBuf encodes op to the bitcoin protocol encoding for an OutPoint
+// to w, using buf as the scratch space for the index.
+func writeOutPointBuf(w io.Writer, pver uint32, version int32, op *OutPoint,
+	buf []byte) error {
+
 	_, err := w.Write(op.Hash[:])
 	if err != nil {
 		return err
 	}
 
-	return binarySerializer.PutUint32(w, littleEndian, op.Index)
+	return writeUint32(w, buf, littleEndian, op.Index)
 }
 
-// readScript reads a variable length byte array that represents a transaction
-// script.  It is encoded as a varInt containing the length of the array
-// followed by the bytes themselves.  An error is returned if the length is
-// greater than the passed maxAllowed parameter which helps protect against
-// memory exhaustion attacks and forced panics through malformed messages.  The
-// fieldName parameter is only used for the error message so it provides more
-// context in the error.
-func readScript(r io.Reader, pver uint32, maxAllowed uint32, fieldName string) ([]byte, error) {
-	count, err := ReadVarInt(r, pver)
+// readScriptBuf reads a variable length byte array that represents a
+// transaction script into space from the provided arena, using buf as the
+// scratch space for the length.  It is encoded as a varInt containing the
+// length of the array followed by the bytes themselves.  An error is returned
+// if the length is greater than the passed maxAllowed parameter which helps
+// protect against memory exhaustion attacks and forced panics through
+// malformed messages.  The fieldName parameter is only used for the error
+// message so it provides more context in the error.
+func readScriptBuf(r io.Reader, pver uint32, buf []byte, arena *scriptArena,
+	maxAllowed uint32, fieldName string) ([]byte, error) {
+
+	count, err := readVarIntBuf(r, pver, buf)
 	if err != nil {
 		return nil, err
 	}
@@ -965,58 +935,67 @@ func readScript(r io.Reader, pver uint32, maxAllowed uint32, fieldName string) (
 		return nil, messageError("readScript", str)
 	}
 
-	b := scriptPool.Borrow(count)
+	b := arena.alloc(count)
 	_, err = io.ReadFull(r, b)
 	if err != nil {
-		scriptPool.Return(b)
 		return nil, err
 	}
 	return b, nil
 }
 
-// readTxIn reads the next sequence of bytes from r as a transaction input
-// (TxIn).
-func readTxIn(r io.Reader, pver uint32, version int32, ti *TxIn) error {
-	err := readOutPoint(r, pver, version, &ti.PreviousOutPoint)
+// readTxInBuf reads the next sequence of bytes from r as a transaction input
+// (TxIn), using buf as the scratch space and reading the signature script into
+// space from the provided arena.
+func readTxInBuf(r io.Reader, pver uint32, version int32, ti *TxIn,
+	buf []byte, arena *scriptArena) error {
+
+	err := readOutPointBuf(r, pver, version, &ti.PreviousOutPoint, buf)
 	if err != nil {
 		return err
 	}
 
-	ti.SignatureScript, err = readScript(r, pver, MaxMessagePayload,
-		"transaction input signature script")
+	ti.SignatureScript, err = readScriptBuf(r, pver, buf, arena,
+		MaxMessagePayload, "transaction input signature script")
 	if err != nil {
 		return err
 	}
 
-	return readElement(r, &ti.Sequence)
+	ti.Sequence, err = readUint32(r, buf, littleEndian)
+	return err
 }
 
-// writeTxIn encodes ti to the bitcoin protocol encoding for a transaction
-// input (TxIn) to w.
-func writeTxIn(w io.Writer, pver uint32, version int32, ti *TxIn) error {
-	err := writeOutPoint(w, pver, version, &ti.PreviousOutPoint)
+// writeTxInBuf encodes ti to the bitcoin protocol encoding for a transaction
+// input (TxIn) to w, using buf as the scratch space.
+func writeTxInBuf(w io.Writer, pver uint32, version int32, ti *TxIn,
+	buf []byte) error {
+
+	err := writeOutPointBuf(w, pver, version, &ti.PreviousOutPoint, buf)
 	if err != nil {
 		return err
 	}
 
-	err = WriteVarBytes(w, pver, ti.SignatureScript)
+	err = writeVarBytesBuf(w, pver, ti.SignatureScript, buf)
 	if err != nil {
 		return err
 	}
 
-	return binarySerializer.PutUint32(w, littleEndian, ti.Sequence)
+	return writeUint32(w, buf, littleEndian, ti.Sequence)
 }
 
-// readTxOut reads the next sequence of bytes from r as a transaction output
-// (TxOut).
-func readTxOut(r io.Reader, pver uint32, version int32, to *TxOut) error {
-	err := readElement(r, &to.Value)
+// readTxOutBuf reads the next sequence of bytes from r as a transaction output
+// (TxOut), using buf as the scratch space and reading the public key script
+// into space from the provided arena.
+func readTxOutBuf(r io.Reader, pver uint32, version int32, to *TxOut,
+	buf []byte, arena *scriptArena) error {
+
+	value, err := readUint64(r, buf, littleEndian)
 	if err != nil {
 		return err
 	}
+	to.Value = int64(value)
 
-	to.PkScript, err = readScript(r, pver, MaxMessagePayload,
-		"transaction output public key script")
+	to.PkScript, err = readScriptBuf(r, pver, buf, arena,
+		MaxMessagePayload, "transaction output public key script")
 	return err
 }
 
@@ -1026,23 +1005,36 @@ func readTxOut(r io.Reader, pver uint32, version int32, to *TxOut) error {
 // NOTE: This function is exported in order to allow txscript to compute the
 // new sighashes for witness transactions (BIP0143).
 func WriteTxOut(w io.Writer, pver uint32, version int32, to *TxOut) error {
-	err := binarySerializer.PutUint64(w, littleEndian, uint64(to.Value))
+	buf := binarySerializer.Borrow()
+	err := writeTxOutBuf(w, pver, version, to, buf)
+	binarySerializer.Return(buf)
+	return err
+}
+
+// writeTxOutBuf encodes to into the bitcoin protocol encoding for a
+// transaction output (TxOut) to w, using buf as the scratch space.
+func writeTxOutBuf(w io.Writer, pver uint32, version int32, to *TxOut,
+	buf []byte) error {
+
+	err := writeUint64(w, buf, littleEndian, uint64(to.Value))
 	if err != nil {
 		return err
 	}
 
-	return WriteVarBytes(w, pver, to.PkScript)
+	return writeVarBytesBuf(w, pver, to.PkScript, buf)
 }
 
-// writeTxWitness encodes the bitcoin protocol encoding for a transaction
-// input's witness into to w.
-func writeTxWitness(w io.Writer, pver uint32, version int32, wit [][]byte) error {
-	err := WriteVarInt(w, pver, uint64(len(wit)))
+// writeTxWitnessBuf encodes the bitcoin protocol encoding for a transaction
+// input's witness into to w, using buf as the scratch space.
+func writeTxWitnessBuf(w io.Writer, pver uint32, version int32, wit [][]byte,
+	buf []byte) error {
+
+	err := writeVarIntBuf(w, pver, uint64(len(wit)), buf)
 	if err != nil {
 		return err
 	}
 	for _, item := range wit {
-		err = WriteVarBytes(w, pver, item)
+		err = writeVarBytesBuf(w, pver, item, buf)
 		if err != nil {
 			return err
 		}
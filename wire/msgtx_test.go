@@ -778,6 +778,49 @@ func TestTxWitnessSize(t *testing.T) {
 	}
 }
 
+// TestTxScriptLayout ensures the scripts of a transaction whose scripts don't
+// fit in a single buffer of the script free list, or in any of them, are
+// deserialized intact into a single contiguous buffer.
+func TestTxScriptLayout(t *testing.T) {
+	tx := NewMsgTx(1)
+	var prevOut OutPoint
+	for i := 0; i < 40; i++ {
+		script := bytes.Repeat([]byte{byte(i)}, 150+i)
+		txIn := NewTxIn(&prevOut, script, [][]byte{{byte(i)}, script})
+		tx.AddTxIn(txIn)
+	}
+	tx.AddTxOut(NewTxOut(1, bytes.Repeat([]byte{0xac}, freeListMaxScriptSize+1)))
+	tx.AddTxOut(NewTxOut(2, []byte{}))
+
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize: unexpected error %v", err)
+	}
+
+	var got MsgTx
+	if err := got.Deserialize(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("Deserialize: unexpected error %v", err)
+	}
+	if !reflect.DeepEqual(&got, tx) {
+		t.Fatalf("Deserialize: mismatched tx - got %v, want %v",
+			spew.Sdump(&got), spew.Sdump(tx))
+	}
+
+	// Every script must be capped to its length so appending to one of
+	// them can't overwrite its neighbour.
+	scripts := [][]byte{got.TxOut[0].PkScript, got.TxOut[1].PkScript}
+	for _, txIn := range got.TxIn {
+		scripts = append(scripts, txIn.SignatureScript)
+		scripts = append(scripts, txIn.Witness...)
+	}
+	for i, script := range scripts {
+		if cap(script) != len(script) {
+			t.Errorf("script #%d has cap %d, want %d", i, cap(script),
+				len(script))
+		}
+	}
+}
+
 // multiTx is a MsgTx with an input and output and used in various tests.
 var multiTx = &MsgTx{
 	Version: 1,
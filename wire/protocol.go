@@ -86,18 +86,30 @@ const (
 	// SFNode2X is a flag used to indicate a peer is running the Segwit2X
 	// software.
 	SFNode2X
+
+	// SFNodeClaimProofs is a flag used to indicate a peer supports the
+	// getclmproof and clmproof commands.  It uses bit 24, the first of
+	// the bits reserved for experimental services.
+	SFNodeClaimProofs ServiceFlag = 1 << 24
+
+	// SFNodeClaimSnapshots is a flag used to indicate a peer serves
+	// claimtrie snapshots with the getctsnap, ctsnap, getctchunk and
+	// ctchunk commands.
+	SFNodeClaimSnapshots ServiceFlag = 1 << 25
 )
 
 // Map of service flags back to their constant names for pretty printing.
 var sfStrings = map[ServiceFlag]string{
-	SFNodeNetwork: "SFNodeNetwork",
-	SFNodeGetUTXO: "SFNodeGetUTXO",
-	SFNodeBloom:   "SFNodeBloom",
-	SFNodeWitness: "SFNodeWitness",
-	SFNodeXthin:   "SFNodeXthin",
-	SFNodeBit5:    "SFNodeBit5",
-	SFNodeCF:      "SFNodeCF",
-	SFNode2X:      "SFNode2X",
+	SFNodeNetwork:        "SFNodeNetwork",
+	SFNodeGetUTXO:        "SFNodeGetUTXO",
+	SFNodeBloom:          "SFNodeBloom",
+	SFNodeWitness:        "SFNodeWitness",
+	SFNodeXthin:          "SFNodeXthin",
+	SFNodeBit5:           "SFNodeBit5",
+	SFNodeCF:             "SFNodeCF",
+	SFNode2X:             "SFNode2X",
+	SFNodeClaimProofs:    "SFNodeClaimProofs",
+	SFNodeClaimSnapshots: "SFNodeClaimSnapshots",
 }
 
 // orderedSFStrings is an ordered list of service flags from highest to
@@ -111,6 +123,8 @@ var orderedSFStrings = []ServiceFlag{
 	SFNodeBit5,
 	SFNodeCF,
 	SFNode2X,
+	SFNodeClaimProofs,
+	SFNodeClaimSnapshots,
 }
 
 // String returns the ServiceFlag in human-readable form.